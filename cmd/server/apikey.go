@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"zbor/internal/storage"
+)
+
+// createAPIKey はAPIキーを生成して表示する（最初の admin キーの作成用）
+func createAPIKey(ctx context.Context, args []string, db *storage.DB) error {
+	fs := flag.NewFlagSet("create-api-key", flag.ExitOnError)
+	scope := fs.String("scope", storage.APIScopeAdmin, "Scope of the key: read, write or admin")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: zbor create-api-key [options] <name>\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	key, k, err := storage.NewAPIKeyRepository(db).Create(ctx, fs.Arg(0), *scope)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created API key %q (id %d, scope %s). It is shown only once:\n", k.Name, k.ID, k.Scope)
+	fmt.Println(key)
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"zbor/internal/backup"
+	"zbor/internal/storage"
+)
+
+// backupDB はデータベースのスナップショット（-sources でソースディレクトリも）をファイルに書き出す
+func backupDB(ctx context.Context, args []string, db *storage.DB, dataDir string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	includeSources := fs.Bool("sources", false, "Include the sources directory (audio files and artifacts)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: zbor backup [options] <file.tar.gz>\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	outPath := fs.Arg(0)
+
+	// 書き込み途中のファイルを残さないよう、一時ファイルに書いてから名前を変える
+	tmp := outPath + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	manifest, err := backup.Create(ctx, db, dataDir, f, backup.Options{IncludeSources: *includeSources})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, outPath); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	fmt.Printf("Backed up to %s\n", outPath)
+	fmt.Printf("  database: %.1f MB (schema version %d)\n", float64(manifest.DatabaseSize)/1024/1024, manifest.SchemaVersion)
+	if manifest.Sources {
+		fmt.Printf("  files: %d (%.1f MB)\n", manifest.Files, float64(manifest.FilesSize)/1024/1024)
+	}
+	return nil
+}
+
+// restoreDB はバックアップからデータベースとソースディレクトリを復元する
+// サーバーを停止してから実行する
+func restoreDB(ctx context.Context, args []string, db *storage.DB, dataDir string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: zbor restore <file.tar.gz>\n\nStop the server before restoring: the database is replaced with the backup.\n")
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	report, err := backup.Restore(ctx, db, dataDir, f)
+	if err != nil {
+		return err
+	}
+
+	m := report.Manifest
+	fmt.Printf("Restored backup created at %s by zbor %s\n", m.CreatedAt.Format("2006-01-02 15:04:05"), m.Version)
+	if m.Sources {
+		fmt.Printf("  files: %d written\n", report.FilesWritten)
+	} else {
+		fmt.Println("  the backup does not include the sources directory; audio files were left as they are")
+	}
+	for _, mig := range report.Migrations {
+		fmt.Printf("  applied migration %04d %s\n", mig.Version, mig.Name)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"zbor/internal/library"
+	"zbor/internal/storage"
+)
+
+// runCommand はサブコマンドを実行し、終了コードを返す
+func runCommand(name string, args []string, db *storage.DB, dataDir string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch name {
+	case "export-library":
+		err = exportLibrary(ctx, args, db, dataDir)
+	case "import-library":
+		err = importLibrary(ctx, args, db, dataDir)
+	case "create-api-key":
+		err = createAPIKey(ctx, args, db)
+	case "create-user":
+		err = createUser(ctx, args, db)
+	case "migrate":
+		err = migrateDB(ctx, args, db)
+	case "backup":
+		err = backupDB(ctx, args, db, dataDir)
+	case "restore":
+		err = restoreDB(ctx, args, db, dataDir)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  zbor                                 Start the server\n")
+		fmt.Fprintf(os.Stderr, "  zbor export-library <file.tar.gz>    Export the whole library\n")
+		fmt.Fprintf(os.Stderr, "  zbor import-library [options] <file> Import an exported library\n")
+		fmt.Fprintf(os.Stderr, "  zbor create-api-key [options] <name> Create an API key\n")
+		fmt.Fprintf(os.Stderr, "  zbor create-user [options] <name>    Create a login user\n")
+		fmt.Fprintf(os.Stderr, "  zbor migrate [-status]               Apply database migrations\n")
+		fmt.Fprintf(os.Stderr, "  zbor backup [-sources] <file.tar.gz> Back up the database (and sources)\n")
+		fmt.Fprintf(os.Stderr, "  zbor restore <file.tar.gz>           Restore a backup (stop the server first)\n")
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+// exportLibrary はライブラリ全体をファイルに書き出す
+func exportLibrary(ctx context.Context, args []string, db *storage.DB, dataDir string) error {
+	fs := flag.NewFlagSet("export-library", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: zbor export-library <file.tar.gz>\n")
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	outPath := fs.Arg(0)
+
+	// 書き込み途中のファイルを残さないよう、一時ファイルに書いてから名前を変える
+	tmp := outPath + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	manifest, err := library.Export(ctx, db, dataDir, f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, outPath); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	var size int64
+	for _, m := range manifest.Media {
+		size += m.Size
+	}
+	fmt.Printf("Exported to %s\n", outPath)
+	printCounts("  ", manifest.Counts)
+	fmt.Printf("  files: %d (%.1f MB)\n", len(manifest.Media), float64(size)/1024/1024)
+	if len(manifest.Missing) > 0 {
+		fmt.Printf("Warning: %d source directories were not found and their files are not included (restore archived sources before exporting):\n", len(manifest.Missing))
+		for _, p := range manifest.Missing {
+			fmt.Printf("  %s\n", p)
+		}
+	}
+	return nil
+}
+
+// importLibrary はエクスポートしたライブラリを取り込む
+func importLibrary(ctx context.Context, args []string, db *storage.DB, dataDir string) error {
+	fs := flag.NewFlagSet("import-library", flag.ExitOnError)
+	onConflict := fs.String("on-conflict", "skip", "What to do with rows whose ID already exists: skip, copy (assign new IDs) or fail")
+	dryRun := fs.Bool("dry-run", false, "Only report conflicts, do not write anything")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: zbor import-library [options] <file.tar.gz>\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	policy, err := library.ParseConflictPolicy(*onConflict)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	report, err := library.Import(ctx, db, f, library.ImportOptions{
+		DataDir:    dataDir,
+		OnConflict: policy,
+		DryRun:     *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	m := report.Manifest
+	fmt.Printf("Archive exported at %s by zbor %s from %s\n", m.ExportedAt.Format("2006-01-02 15:04:05"), m.Version, m.DataDir)
+	if len(report.Conflicts) > 0 {
+		fmt.Printf("%d rows already exist (on-conflict=%s)\n", len(report.Conflicts), policy)
+	}
+	if *dryRun {
+		printCounts("  ", m.Counts)
+		for _, c := range report.Conflicts {
+			fmt.Printf("  conflict: %s\n", c)
+		}
+		fmt.Println("Dry run: nothing was written")
+		return nil
+	}
+
+	fmt.Println("Imported:")
+	printCounts("  ", report.Imported)
+	if len(report.Skipped) > 0 {
+		fmt.Println("Skipped:")
+		printCounts("  ", report.Skipped)
+	}
+	if report.Renamed > 0 {
+		fmt.Printf("Assigned new IDs to %d rows\n", report.Renamed)
+	}
+	fmt.Printf("Files: %d written, %d already existed\n", report.FilesWritten, report.FilesKept)
+	fmt.Println("Transcript search is rebuilt when the server starts; checksums are recorded by the next integrity audit")
+	return nil
+}
+
+// printCounts はテーブルごとの件数を表示
+func printCounts(indent string, counts map[string]int) {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s%s: %d\n", indent, name, counts[name])
+	}
+}
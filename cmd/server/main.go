@@ -4,18 +4,42 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
+	"zbor/internal/alert"
+	"zbor/internal/analyze"
+	"zbor/internal/archive"
 	"zbor/internal/asr"
+	"zbor/internal/autotag"
+	"zbor/internal/backup"
+	"zbor/internal/davfs"
+	"zbor/internal/export"
+	"zbor/internal/feeds"
 	"zbor/internal/handlers"
 	"zbor/internal/ingestion"
+	"zbor/internal/integrity"
+	"zbor/internal/logging"
+	"zbor/internal/metrics"
+	"zbor/internal/notify"
+	"zbor/internal/retention"
+	"zbor/internal/semantic"
 	"zbor/internal/storage"
 	"zbor/internal/storage/sqlc"
+	"zbor/internal/summarize"
+	"zbor/internal/translate"
 	"zbor/internal/version"
+	"zbor/internal/webfetch"
+	"zbor/internal/webhooks"
 	"zbor/internal/worker"
+	"zbor/internal/youtube"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
@@ -26,6 +50,21 @@ func main() {
 	// .envファイルを読み込み（存在しない場合はスキップ）
 	_ = godotenv.Load()
 
+	// 構造化ログ（log/slog）
+	// LOG_LEVEL: debug, info（デフォルト）, warn, error
+	// LOG_FORMAT: text（デフォルト）, json
+	if err := logging.Setup(os.Stderr, os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT")); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	// ジョブの実行中のログ（ffmpeg の警告、ブロックごとのメッセージなど）を job_logs に保存する
+	// ZBOR_JOB_LOG_LEVEL: debug, info（デフォルト）, warn, error
+	jobLogLevel, err := logging.ParseLevel(os.Getenv("ZBOR_JOB_LOG_LEVEL"))
+	if err != nil {
+		log.Fatalf("Invalid ZBOR_JOB_LOG_LEVEL: %v", err)
+	}
+	jobLogs := logging.NewJobLogs()
+	jobLogs.Capture(jobLogLevel)
+
 	// 環境変数からポート番号を取得（デフォルト: 8080）
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -42,13 +81,17 @@ func main() {
 		dbPath = filepath.Join(home, ".zbor", "zbor.db")
 	}
 
-	// データベース初期化
-	db, err := storage.Open(dbPath)
+	// データベース初期化（未適用のマイグレーションを適用する。migrate サブコマンドは自分で適用する）
+	openDB := storage.Open
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		openDB = storage.OpenWithoutMigrations
+	}
+	db, err := openDB(dbPath)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
 	defer db.Close()
-	log.Printf("Database initialized at %s", dbPath)
+	slog.Info("Database initialized", "path", dbPath)
 
 	// データディレクトリ（デフォルト: ~/.zbor/data）
 	dataDir := os.Getenv("ZBOR_DATA_DIR")
@@ -60,6 +103,13 @@ func main() {
 		log.Fatalf("Failed to create data directory: %v", err)
 	}
 
+	// サブコマンド（export-library / import-library / create-api-key / create-user / migrate / backup / restore）はサーバーを起動せずに終了
+	if len(os.Args) > 1 {
+		code := runCommand(os.Args[1], os.Args[2:], db, dataDir)
+		db.Close()
+		os.Exit(code)
+	}
+
 	// ASRモデルパス（デフォルト: ./models/sherpa-onnx-...）
 	modelDir := os.Getenv("ZBOR_MODEL_DIR")
 	if modelDir == "" {
@@ -71,9 +121,9 @@ func main() {
 	if vadModelPath == "" {
 		vadModelPath = "models/silero_vad.onnx"
 	}
-	// VADモデルが存在しない場合は空にして無効化
+	// VADモデルが存在しない場合は空にして無効化（文字起こしはRMSベースの無音検出でブロックに分けるため影響しない）
 	if _, err := os.Stat(vadModelPath); os.IsNotExist(err) {
-		log.Printf("VAD model not found at %s, VAD disabled", vadModelPath)
+		slog.Warn("VAD model not found, VAD disabled (transcription uses silence detection)", "path", vadModelPath)
 		vadModelPath = ""
 	}
 
@@ -83,6 +133,33 @@ func main() {
 	jobRepo := storage.NewJobRepository(db)
 	sourceRepo := storage.NewSourceRepository(db)
 	artifactRepo := storage.NewArtifactRepository(db)
+	holdRepo := storage.NewHoldRepository(db)
+	bookmarkRepo := storage.NewBookmarkRepository(db)
+	replacementRepo := storage.NewReplacementRepository(db)
+	checksumRepo := storage.NewChecksumRepository(db)
+	transcriptRepo := storage.NewTranscriptRepository(db)
+	embeddingRepo := storage.NewEmbeddingRepository(db)
+	userRepo := storage.NewUserRepository(db)
+	experimentRepo := storage.NewExperimentRepository(db)
+	// 音声ファイルの実体（同じ内容のファイルはデータディレクトリの blobs/ の1つをハードリンクで共有）
+	blobRepo := storage.NewBlobRepository(db, filepath.Join(dataDir, "blobs"))
+	// 文字起こしの利用量（ユーザー・APIキー・モデルごと）と月ごとの上限（/api/usage/quotas で設定）
+	usageRepo := storage.NewUsageRepository(db)
+
+	// 記事の一覧・検索のキャッシュ（記事の書き込みで破棄する）
+	// ZBOR_ARTICLE_CACHE_SECONDS: 結果を保持する秒数（デフォルト: 30、0 で無効）。
+	// CLIツールなど他のプロセスによる書き込みはこの秒数が過ぎるまで反映されない
+	// ZBOR_ARTICLE_CACHE_ENTRIES: 保持する結果の数の上限（デフォルト: 256）
+	articleRepo.SetCache(
+		time.Duration(envNonNegativeInt("ZBOR_ARTICLE_CACHE_SECONDS", 30))*time.Second,
+		envNonNegativeInt("ZBOR_ARTICLE_CACHE_ENTRIES", 0),
+	)
+
+	// Webhook（ジョブの完了・失敗、記事の作成、文字起こしの更新を登録したURLに POST する）
+	// イベントはリポジトリの書き込みから配信として保存し、下の Run で送信・再試行する
+	webhookRepo := storage.NewWebhookRepository(db)
+	webhookDispatcher := webhooks.NewDispatcher(webhookRepo)
+	db.SetEventHandler(webhookDispatcher.Publish)
 
 	// ASR設定
 	asrConfig := &asr.Config{
@@ -92,117 +169,1012 @@ func main() {
 		TokensPath:   filepath.Join(modelDir, "tokens.txt"),
 		VADModelPath: vadModelPath,
 		SampleRate:   16000,
-		NumThreads:   4,
 	}
 
+	// ASRの推論スレッド数
+	// ZBOR_ASR_THREADS: 全モデルのスレッド数（デフォルト: 0 = 自動）
+	// 自動の場合はモデルごとに初回使用時にスレッド数をベンチマークし、結果をデータディレクトリの
+	// asr-threads.json にキャッシュする（ZBOR_ASR_BENCHMARK=0 でベンチマークせずCPU数から決める）。
+	// 他の文字起こしが実行中の場合はCPUを分け合うようにスレッド数を減らす
+	threadTuner := asr.NewThreadTuner(filepath.Join(dataDir, "asr-threads.json"), os.Getenv("ZBOR_ASR_BENCHMARK") != "0")
+	threadTuner.SetFixed(envNonNegativeInt("ZBOR_ASR_THREADS", 0))
+
 	// 音声取り込みモジュール
 	audioIngester := ingestion.NewAudioIngester(
 		sourceRepo,
 		artifactRepo,
 		articleRepo,
 		jobRepo,
+		checksumRepo,
+		transcriptRepo,
 		asrConfig,
 		dataDir,
 	)
+	audioIngester.SetThreadTuner(threadTuner)
+	audioIngester.SetBlobRepository(blobRepo)
+	audioIngester.SetUsageRepository(usageRepo)
+
+	// 音声のデコード
+	// ZBOR_AUDIO_DECODER: auto（デフォルト）, native, ffmpeg。auto は WAV/MP3/FLAC/Ogg Vorbis を Go で、
+	// それ以外（m4a, aac, webm, opus など）を ffmpeg でデコードする。native は ffmpeg を使わない。
+	// ffmpeg がなくてもネイティブ形式は文字起こしできる（プレビュー用の Opus プロキシは作られない）。結果は /health に出る
+	if v := os.Getenv("ZBOR_AUDIO_DECODER"); v != "" {
+		if err := asr.SetAudioDecoder(v); err != nil {
+			log.Fatalf("Invalid ZBOR_AUDIO_DECODER: %v", err)
+		}
+	}
+	audioCaps := asr.CheckAudioCapabilities()
+	if !audioCaps.FFmpeg {
+		slog.Warn("ffmpeg not found: only natively supported formats can be transcribed and preview proxies are disabled",
+			"formats", strings.Join(audioCaps.Formats, ", "))
+	} else {
+		slog.Info("Audio decoding", "decoder", audioCaps.Decoder, "formats", strings.Join(audioCaps.Formats, ", "))
+	}
+
+	// ONNX の実行プロバイダー
+	// ZBOR_ASR_PROVIDER: cpu（デフォルト）, cuda, coreml, directml。全モデルの推論に使う（VADはCPUのまま）。
+	// sherpa-onnx のビルドが対応していないプロバイダーは警告を出してCPUで動く
+	provider, err := asr.ParseProvider(os.Getenv("ZBOR_ASR_PROVIDER"))
+	if err != nil {
+		log.Fatalf("Invalid ZBOR_ASR_PROVIDER: %v", err)
+	}
+	audioIngester.SetProvider(provider)
+	if provider != asr.ProviderCPU {
+		slog.Info("ASR execution provider", "provider", provider)
+	}
+
+	// SenseVoice のビームサーチ（sensevoice:beam）
+	// ZBOR_SENSEVOICE_BEAM_PATHS: チャンクごとにデコードする仮説の数（デフォルト: 4）。
+	// sherpa-onnx の SenseVoice は greedy_search のみのため、入力を 10ms ずつずらしてデコードし、
+	// 他の仮説と最も一致するものを選ぶ（処理時間は仮説の数に比例する）
+	audioIngester.SetSenseVoiceBeamPaths(envNonNegativeInt("ZBOR_SENSEVOICE_BEAM_PATHS", 0))
+
+	// 言語モデルによるリスコアリング（reazonspeech:lm、sensevoice:lm）
+	// ZBOR_LM_PATH: 文字単位の n-gram 言語モデル（ARPA形式、KenLM の lmplz などで作成）。未設定なら :lm のモデルは使えない
+	// ZBOR_LM_SCALE: 言語モデルのスコアの重み（デフォルト: 0.5）
+	// ZBOR_LM_PATHS: reazonspeech:lm でブロックごとにデコードする仮説の数（デフォルト: 4。sensevoice:lm は ZBOR_SENSEVOICE_BEAM_PATHS）
+	if path := os.Getenv("ZBOR_LM_PATH"); path != "" {
+		lm, err := asr.LoadNgramLM(path)
+		if err != nil {
+			log.Fatalf("Failed to load language model: %v", err)
+		}
+		scale := envNonNegativeFloat("ZBOR_LM_SCALE", asr.DefaultLMScale)
+		audioIngester.SetRescorer(&asr.Rescorer{LM: lm, Scale: scale}, envNonNegativeInt("ZBOR_LM_PATHS", 0))
+		slog.Info("Language model loaded", "path", path, "order", lm.Order(), "scale", scale)
+	}
+
+	// ITN（数字・日付・時刻の正規化）設定
+	// ZBOR_ITN_MODELS: 適用するモデル（カンマ区切り、デフォルト: 無効。慣用句等を数字にしないよう確認してから有効にする）
+	// ZBOR_ITN_RULES: 追加ルールファイル（<正規表現>\t<置換>）
+	var itnModels []string
+	if v := os.Getenv("ZBOR_ITN_MODELS"); v != "" && v != "none" {
+		for _, m := range strings.Split(v, ",") {
+			itnModels = append(itnModels, strings.TrimSpace(m))
+		}
+	}
+	itn, err := asr.LoadITN(os.Getenv("ZBOR_ITN_RULES"))
+	if err != nil {
+		log.Fatalf("Failed to load ITN rules: %v", err)
+	}
+	audioIngester.SetITN(itn, itnModels)
+
+	// 単語グルーピング（サブワードトークンを単語・文節単位にまとめ、words として保存）
+	// ZBOR_WORD_MODELS: 適用するモデル（カンマ区切り、デフォルト: 無効）
+	var wordModels []string
+	if v := os.Getenv("ZBOR_WORD_MODELS"); v != "" && v != "none" {
+		for _, m := range strings.Split(v, ",") {
+			wordModels = append(wordModels, strings.TrimSpace(m))
+		}
+	}
+	audioIngester.SetWordGrouping(wordModels)
+
+	// 記事のセクション（チャプター）の最大の長さ
+	// ZBOR_ARTICLE_SECTION_MINUTES: これより長いチャプターは等分する（デフォルト: 30、0 で無制限）
+	audioIngester.SetMaxSectionDuration(float64(envNonNegativeInt("ZBOR_ARTICLE_SECTION_MINUTES", 30) * 60))
+
+	// セグメント（字幕のキュー）の分割ルール。新しい文字起こしと、ルールを指定しない resegment ジョブに使う
+	// ZBOR_SEGMENT_MAX_CHARS: 1セグメントの最大文字数（デフォルト: 0 = 無制限）
+	// ZBOR_SEGMENT_MAX_SECONDS: 1セグメントの最大秒数（デフォルト: 0 = 無制限）
+	// ZBOR_SEGMENT_SPLIT_PUNCTUATION=1: 句点（。？！.?!）でセグメントを区切る
+	// ZBOR_SEGMENT_MIN_GAP: この秒数を超える無音でセグメントを区切る（デフォルト: 0.5）
+	segmentation := asr.SegmentationOptions{
+		MaxChars:           envNonNegativeInt("ZBOR_SEGMENT_MAX_CHARS", 0),
+		MaxDuration:        envNonNegativeFloat("ZBOR_SEGMENT_MAX_SECONDS", 0),
+		SplitOnPunctuation: os.Getenv("ZBOR_SEGMENT_SPLIT_PUNCTUATION") == "1",
+		MinGap:             envNonNegativeFloat("ZBOR_SEGMENT_MIN_GAP", 0),
+	}
+	if segmentation.MinGap == asr.DefaultSegmentGap { // デフォルトと同じ（文字起こしのセグメントをそのまま使う）
+		segmentation.MinGap = 0
+	}
+	if err := segmentation.Validate(); err != nil {
+		log.Fatalf("Invalid segmentation settings (ZBOR_SEGMENT_*): %v", err)
+	}
+	audioIngester.SetSegmentation(segmentation)
+	// 置換辞書（ITN の後に文字起こしへ適用）
+	audioIngester.SetReplacementRepository(replacementRepo)
+
+	// YouTube の動画情報・字幕のキャッシュ
+	// ZBOR_YOUTUBE_CACHE_SECONDS: 保持する秒数（デフォルト: 600、0 で無効）。字幕は期限後も条件付きリクエストで再検証する
+	audioIngester.SetYouTubeCacheTTL(time.Duration(envNonNegativeInt("ZBOR_YOUTUBE_CACHE_SECONDS", int(youtube.DefaultCacheTTL/time.Second))) * time.Second)
+
+	// 音声強調（ノイズ除去）。取り込み時に enhance を指定したソースは、文字起こしの前に GTCRN でノイズを除去する
+	// ZBOR_ENHANCE_MODEL: GTCRN のモデル（デフォルト: models/gtcrn_simple.onnx）。無ければ enhance の指定は 503
+	if path := os.Getenv("ZBOR_ENHANCE_MODEL"); path != "" {
+		audioIngester.SetEnhanceModel(path)
+	}
+	if status := audioIngester.EnhancementStatus(); !status.Available {
+		slog.Info("Speech enhancement not available", "reason", status.Reason)
+	}
+
+	// 言語識別。モデルの指定が無いジョブは冒頭の音声から話されている言語を識別し、日本語以外は SenseVoice / Whisper で文字起こしする
+	// ZBOR_LANGID_MODEL: 多言語の Whisper モデルのディレクトリ（デフォルト: models/sherpa-onnx-whisper-tiny）
+	// ZBOR_LANGID_SECONDS: 識別に使う冒頭の秒数（デフォルト: 30、0 で無効）
+	langIDModel := os.Getenv("ZBOR_LANGID_MODEL")
+	if langIDModel == "" {
+		langIDModel = asr.DefaultLangIDModelDir
+	}
+	audioIngester.SetLanguageID(langIDModel, envNonNegativeInt("ZBOR_LANGID_SECONDS", asr.DefaultLangIDSeconds))
+	if status := audioIngester.LanguageIDStatus(); !status.Available {
+		slog.Info("Language identification not available", "reason", status.Reason)
+	}
+
+	// 長いファイルの文字起こしの途中結果（ブロックごと、再開と進行中の表示に使う）
+	// ZBOR_PARTIAL_FLUSH_BLOCKS: まとめて保存するブロック数（デフォルト: 5）
+	audioIngester.SetPartialFlushBlocks(envNonNegativeInt("ZBOR_PARTIAL_FLUSH_BLOCKS", ingestion.DefaultPartialFlushBlocks))
 
 	// AudioHandler（ストリーミング・同期ページ用にリポジトリとASR設定も渡す）
-	audioHandler := handlers.NewAudioHandler(audioIngester, sourceRepo, artifactRepo, articleRepo, jobRepo, asrConfig)
+	audioHandler := handlers.NewAudioHandler(audioIngester, sourceRepo, artifactRepo, articleRepo, jobRepo, bookmarkRepo, asrConfig)
+
+	// 再生用のWAV変換と波形ピークのキャッシュ（データディレクトリの cache/wav）
+	// ZBOR_WAV_CACHE_MB: 合計サイズの上限（デフォルト: 2048、0 で無制限）。超えた分は古く使われたものから削除
+	// ZBOR_WAV_CACHE_DAYS: この日数使われていない変換を削除（デフォルト: 7、0 で無期限）
+	wavCache := asr.NewConversionCache(
+		filepath.Join(dataDir, "cache", "wav"),
+		int64(envNonNegativeInt("ZBOR_WAV_CACHE_MB", 2048))*1024*1024,
+		time.Duration(envNonNegativeInt("ZBOR_WAV_CACHE_DAYS", 7))*24*time.Hour,
+	)
+	audioHandler.SetConversionCache(wavCache)
+	audioIngester.SetConversionCache(wavCache)
+
+	// 再生（/stream）のデフォルト
+	// ZBOR_STREAM_ORIGINAL=1: ブラウザで再生できる形式（MP3, M4A, Ogg, FLAC など）は元のファイルをそのまま返す
+	// （デフォルト: 0 = 16kHzモノラルのWAVに変換して返す）
+	audioHandler.SetStreamOriginal(os.Getenv("ZBOR_STREAM_ORIGINAL") == "1")
+
+	// 文字起こし・記事の伏せ字（?redact=1。電話番号・メールアドレスと以下のキーワードを *** にする。保存したデータは変更しない）
+	// ZBOR_REDACT_KEYWORDS: 伏せるキーワード（カンマ区切り、大文字小文字を区別しない）
+	// ZBOR_REDACT=1: 文字起こし・記事・検索・質問応答・WebDAV の出力を常に伏せ字にする（admin は ?redact=0 で元のテキストを参照できる）
+	var redactKeywords []string
+	if v := os.Getenv("ZBOR_REDACT_KEYWORDS"); v != "" {
+		redactKeywords = strings.Split(v, ",")
+	}
+	redaction := handlers.NewRedaction(asr.NewRedactor(redactKeywords), os.Getenv("ZBOR_REDACT") == "1")
+	audioHandler.SetRedaction(redaction)
+
+	// 再開可能なアップロード（/api/uploads）のチャンク（データディレクトリの uploads）
+	// 完了して取り込まれたものは削除し、staleUploadAge の間チャンクが届かないものは1時間ごとに削除する
+	uploadStore := ingestion.NewUploadStore(filepath.Join(dataDir, "uploads"))
+	audioHandler.SetUploadStore(uploadStore)
+
+	// 音声ファイルのオブジェクトストレージ（ZBOR_BLOB_STORE 設定時のみ）
+	// 取り込んだ音声をコピーし、ファイルの無いノードでも文字起こし・再生できるようにする（複数ノードでの運用）
+	// ZBOR_BLOB_STORE: s3://<バケット>/<プレフィックス>（S3 互換）、または全ノードで共有するディレクトリ
+	// ZBOR_S3_ENDPOINT: AWS 以外のエンドポイント（MinIO, R2, GCS の https://storage.googleapis.com など）
+	// ZBOR_S3_REGION: リージョン（デフォルト: us-east-1）、ZBOR_S3_PATH_STYLE=1: パス形式のURL（MinIO など）
+	// 認証情報は AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY（一時的な認証情報は AWS_SESSION_TOKEN も）
+	var blobStore storage.Blob
+	if spec := os.Getenv("ZBOR_BLOB_STORE"); spec != "" {
+		store, err := newBlobStore(spec)
+		if err != nil {
+			log.Fatalf("Failed to open blob store: %v", err)
+		}
+		blobStore = store
+		audioIngester.SetBlobStore(blobStore)
+		audioHandler.SetBlobStore(blobStore)
+		slog.Info("Blob store enabled", "store", spec)
+	}
 
 	// ワーカー作成・起動
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	w := worker.NewWorker(jobRepo)
+	// ZBOR_JOB_TIMEOUT_MINUTES 分（デフォルト: 0 = 無制限）を超えたジョブは中断して失敗にする
+	w.SetJobTimeout(time.Duration(envNonNegativeInt("ZBOR_JOB_TIMEOUT_MINUTES", 0)) * time.Minute)
 	// 音声文字起こしハンドラーを登録
 	transcribeHandler := func(ctx context.Context, job *sqlc.ProcessingJob) error {
 		return audioIngester.ProcessTranscription(ctx, job, func(progress int, step string) {
 			_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
 		})
 	}
-	// Register handler for all transcription job types
-	w.RegisterHandler(storage.JobTypeTranscribe, transcribeHandler)
-	w.RegisterHandler(storage.JobTypeTranscribeReazonSpeech, transcribeHandler)
-	w.RegisterHandler(storage.JobTypeTranscribeSenseVoice, transcribeHandler)
-	w.RegisterHandler(storage.JobTypeTranscribeSenseVoiceBeam, transcribeHandler)
+	transcribeTypes := []string{
+		storage.JobTypeTranscribe,
+		storage.JobTypeTranscribeReazonSpeech,
+		storage.JobTypeTranscribeReazonSpeechLM,
+		storage.JobTypeTranscribeSenseVoice,
+		storage.JobTypeTranscribeSenseVoiceBeam,
+		storage.JobTypeTranscribeSenseVoiceLM,
+		storage.JobTypeTranscribeWhisper,
+		storage.JobTypeTranscribeWhisperAlign,
+	}
+
+	// 分散モード（ZBOR_REMOTE_WORKERS=1）: 文字起こしはリモートワーカーがAPI経由で処理し、
+	// このサーバーではONNX推論を実行しない。ZBOR_WORKER_TOKEN が必須
+	var remoteHandler *handlers.RemoteWorkerHandler
+	var workerRepo *storage.WorkerRepository
+	workerToken := os.Getenv("ZBOR_WORKER_TOKEN")
+	if os.Getenv("ZBOR_REMOTE_WORKERS") == "1" {
+		if workerToken == "" {
+			log.Fatal("ZBOR_WORKER_TOKEN is required when ZBOR_REMOTE_WORKERS=1")
+		}
+		remote := worker.NewRemote(jobRepo, transcribeTypes)
+		w.SetRemote(remote)
+		workerRepo = storage.NewWorkerRepository(db)
+		remoteHandler = handlers.NewRemoteWorkerHandler(audioIngester, jobRepo, workerRepo, remote)
+		slog.Info("Remote workers enabled: transcription jobs are processed by worker agents")
+	} else {
+		// インストールされているASRモデルを確認する。無いモデルはAPI・画面で利用不可と表示し、
+		// そのモデルのジョブは近いモデル（:lm → 言語モデル無し、whisper:align → whisper、それ以外はデフォルト）で処理する
+		for _, status := range audioIngester.DetectModels() {
+			if !status.Available {
+				slog.Warn("ASR model not available", "model", status.Model, "reason", status.Reason)
+			}
+		}
+		if audioIngester.DefaultModel() == "" {
+			slog.Warn("No ASR model is installed: audio uploads are refused and YouTube videos use captions")
+		} else {
+			slog.Info("Default ASR model", "model", audioIngester.DefaultModel())
+		}
+
+		// Register handler for all transcription job types
+		for _, jobType := range transcribeTypes {
+			w.RegisterHandler(jobType, transcribeHandler)
+		}
+	}
+
+	// 要約（OpenAI互換API）
+	// ZBOR_LLM_URL: APIのベースURL（例: https://api.openai.com/v1、未設定で無効）
+	// ZBOR_LLM_API_KEY: APIキー、ZBOR_LLM_MODEL: モデル名（デフォルト: gpt-4o-mini）
+	summarizer := summarize.NewSummarizer(summarize.NewClientFromEnv(), articleRepo, sourceRepo, artifactRepo, jobRepo)
+	if summarizer.Enabled() {
+		w.RegisterHandler(storage.JobTypeSummarize, func(ctx context.Context, job *sqlc.ProcessingJob) error {
+			return summarizer.ProcessJob(ctx, job, func(progress int, step string) {
+				_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+			})
+		})
+	}
+
+	// 文字起こしの翻訳（セグメント単位。タイムスタンプは文字起こしのまま）
+	// ZBOR_TRANSLATE_BACKEND: llm（ZBOR_LLM_*）または libretranslate（ローカルNMT。未設定時は ZBOR_TRANSLATE_URL があれば libretranslate）
+	// ZBOR_TRANSLATE_URL: LibreTranslate 互換サーバーのベースURL、ZBOR_TRANSLATE_API_KEY: そのAPIキー
+	translateBackend, err := translate.NewBackendFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid translation settings (ZBOR_TRANSLATE_*): %v", err)
+	}
+	translator := translate.NewTranslator(translateBackend, sourceRepo, artifactRepo, jobRepo)
+	if translator.Enabled() {
+		w.RegisterHandler(storage.JobTypeTranslate, func(ctx context.Context, job *sqlc.ProcessingJob) error {
+			return translator.ProcessJob(ctx, job, func(progress int, step string) {
+				_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+			})
+		})
+	}
+
+	// 自動タグ付け（LLMが設定されていればLLM、なければキーワード抽出で提案）
+	// ZBOR_AUTOTAG=1: 新しく作成された記事を自動でタグ付け（未設定時はAPIからの個別実行のみ）
+	// ZBOR_AUTOTAG_METHOD=keyword: LLMが設定されていてもキーワード抽出を使う
+	autotagClient := summarize.NewClientFromEnv()
+	if os.Getenv("ZBOR_AUTOTAG_METHOD") == autotag.MethodKeyword {
+		autotagClient = nil
+	}
+	tagger := autotag.NewTagger(autotagClient, articleRepo, tagRepo, sourceRepo, jobRepo)
+	w.RegisterHandler(storage.JobTypeAutoTag, func(ctx context.Context, job *sqlc.ProcessingJob) error {
+		return tagger.ProcessJob(ctx, job, func(progress int, step string) {
+			_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+		})
+	})
+
+	// 記事の分析（頻出キーワードと人物・組織・地名の抽出。LLMが設定されていればLLM、なければヒューリスティック）
+	// ZBOR_ANALYZE_METHOD=heuristic: LLMが設定されていてもヒューリスティックを使う
+	analyzeClient := summarize.NewClientFromEnv()
+	if os.Getenv("ZBOR_ANALYZE_METHOD") == analyze.MethodHeuristic {
+		analyzeClient = nil
+	}
+	analyzer := analyze.NewAnalyzer(analyzeClient, articleRepo, sourceRepo, artifactRepo, jobRepo)
+	w.RegisterHandler(storage.JobTypeAnalyze, func(ctx context.Context, job *sqlc.ProcessingJob) error {
+		return analyzer.ProcessJob(ctx, job, func(progress int, step string) {
+			_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+		})
+	})
+
+	// セマンティック検索（記事のチャンクの埋め込みベクトル）
+	// ZBOR_EMBED_BACKEND: api（OpenAI互換の /embeddings。Ollama などのローカルサーバーも可）または hash（モデル不要の文字n-gram）
+	// ZBOR_EMBED_URL: 埋め込みAPIのベースURL（設定すると api を使う）、ZBOR_EMBED_API_KEY: そのAPIキー
+	// ZBOR_EMBED_MODEL: モデル名（api、デフォルト text-embedding-3-small）または次元数（hash、デフォルト 512）
+	embedder, err := semantic.NewEmbedderFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid embedding settings (ZBOR_EMBED_*): %v", err)
+	}
+	semanticIndex := semantic.NewIndex(embedder, embeddingRepo, articleRepo, transcriptRepo)
+
+	// 質問応答（RAG。セマンティック検索で取り出したチャンクを根拠に ZBOR_LLM_* のLLMが引用付きで回答）
+	asker := semantic.NewAsker(semanticIndex, summarize.NewClientFromEnv())
+
+	// 記事のエクスポート（front matter 付きの Markdown、SRT・音声を添付した ZIP）
+	exporter := export.NewExporter(articleRepo, sourceRepo, artifactRepo, audioIngester)
+
+	// Webページ取り込み（ブラウザは最初の取得時に起動）
+	webIngester := ingestion.NewWebIngester(sourceRepo, articleRepo, jobRepo, &webfetch.Options{Stealth: true})
+	defer webIngester.Close()
+	w.RegisterHandler(storage.JobTypeFetch, func(ctx context.Context, job *sqlc.ProcessingJob) error {
+		return webIngester.ProcessFetch(ctx, job, func(progress int, step string) {
+			_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+		})
+	})
+	w.RegisterHandler(storage.JobTypeCrawl, func(ctx context.Context, job *sqlc.ProcessingJob) error {
+		return webIngester.ProcessCrawl(ctx, job, func(progress int, step string) {
+			_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+		})
+	})
+
+	// RSS/Atom フィード購読（新しいエントリーは fetch ジョブ、ポッドキャストのエピソードは文字起こしジョブで取り込む）
+	feedService := feeds.NewService(sourceRepo, jobRepo, webIngester, audioIngester)
+	w.RegisterHandler(storage.JobTypeFeedRefresh, func(ctx context.Context, job *sqlc.ProcessingJob) error {
+		return feedService.ProcessRefresh(ctx, job, func(progress int, step string) {
+			_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+		})
+	})
+
+	// セグメントの再生成（保存済みトークンから。ASRは再実行しない）
+	w.RegisterHandler(storage.JobTypeResegment, func(ctx context.Context, job *sqlc.ProcessingJob) error {
+		return audioIngester.ProcessResegment(ctx, job, func(progress int, step string) {
+			_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+		})
+	})
+	// パイプラインのステップ（文字起こし後の字幕の保存、ポッドキャストのショーノートの作成）
+	w.RegisterHandler(storage.JobTypeSubtitles, func(ctx context.Context, job *sqlc.ProcessingJob) error {
+		return audioIngester.ProcessSubtitles(ctx, job, func(progress int, step string) {
+			_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+		})
+	})
+	w.RegisterHandler(storage.JobTypeShowNotes, func(ctx context.Context, job *sqlc.ProcessingJob) error {
+		return audioIngester.ProcessShowNotes(ctx, job, func(progress int, step string) {
+			_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+		})
+	})
+	// 二段階の文字起こしの2回目（信頼度の低い区間を Whisper で再認識）
+	w.RegisterHandler(storage.JobTypeRefine, func(ctx context.Context, job *sqlc.ProcessingJob) error {
+		return audioIngester.ProcessRefine(ctx, job, func(progress int, step string) {
+			_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+		})
+	})
+	// セグメント境界の一括調整（波形ピークから音声の塊の端に揃え、動いた量を boundary_report に保存）
+	w.RegisterHandler(storage.JobTypeAdjustBoundaries, func(ctx context.Context, job *sqlc.ProcessingJob) error {
+		return audioIngester.ProcessAdjustBoundaries(ctx, job, func(progress int, step string) {
+			_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+		})
+	})
+	// 元音声の削除（保持ポリシー）
+	// ZBOR_ORIGINAL_RETENTION_DAYS: 文字起こしの完了から元音声を削除するまでの日数（デフォルト: 0 = 削除しない）。
+	// 1時間ごとに期限を過ぎたソースの cleanup ジョブを作成する。文字起こし・記事・再生用のプレビューは残る
+	storageManager := retention.NewManager(sourceRepo, artifactRepo, jobRepo)
+	storageManager.SetBlobRepository(blobRepo)
+	storageManager.SetChecksumRepository(checksumRepo)
+	storageManager.SetConversionCache(wavCache)
+	storageManager.SetBlobStore(blobStore)
+	storageManager.SetOriginalRetention(time.Duration(envNonNegativeInt("ZBOR_ORIGINAL_RETENTION_DAYS", 0)) * 24 * time.Hour)
+	w.RegisterHandler(storage.JobTypeCleanup, func(ctx context.Context, job *sqlc.ProcessingJob) error {
+		return storageManager.ProcessJob(ctx, job, func(progress int, step string) {
+			_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+		})
+	})
+	// バックアップ（データベースのスナップショットと、指定時はソースディレクトリ）
+	// ZBOR_BACKUP_DIR: 保存先（デフォルト: <データディレクトリ>/backups）
+	// ZBOR_BACKUP_INTERVAL_HOURS: 定期バックアップの間隔（デフォルト: 0 = 無効）。backup ジョブとして作成する
+	// ZBOR_BACKUP_SOURCES=1: 定期バックアップにソースディレクトリ（音声・成果物）を含める
+	// ZBOR_BACKUP_KEEP: 保持するバックアップの数（デフォルト: 7、0 で削除しない）
+	backupDir := os.Getenv("ZBOR_BACKUP_DIR")
+	if backupDir == "" {
+		backupDir = filepath.Join(dataDir, "backups")
+	}
+	backupService, err := backup.NewService(db, jobRepo, dataDir, backupDir)
+	if err != nil {
+		log.Fatalf("Failed to initialize backups: %v", err)
+	}
+	backupInterval := time.Duration(envNonNegativeInt("ZBOR_BACKUP_INTERVAL_HOURS", 0)) * time.Hour
+	backupService.SetSchedule(backupInterval, os.Getenv("ZBOR_BACKUP_SOURCES") == "1")
+	backupService.SetKeep(envNonNegativeInt("ZBOR_BACKUP_KEEP", 7))
+	w.RegisterHandler(storage.JobTypeBackup, func(ctx context.Context, job *sqlc.ProcessingJob) error {
+		return backupService.ProcessJob(ctx, job, func(progress int, step string) {
+			_ = jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+		})
+	})
+	// 前回のプロセスが実行中のまま終了したジョブをキューに戻す（リトライ上限に達したものは失敗にする）
+	if n, err := w.RecoverInterrupted(ctx); err != nil {
+		slog.Error("Failed to recover interrupted jobs", "error", err)
+	} else if n > 0 {
+		slog.Info("Recovered interrupted jobs", "count", n)
+	}
 	w.Start(ctx)
 	defer w.Stop()
 
+	// 購読中のフィードの更新間隔を1分ごとに確認
+	go feedService.Run(ctx, time.Minute)
+
+	// 新しい記事の自動タグ付けジョブを1分ごとに作成
+	if os.Getenv("ZBOR_AUTOTAG") == "1" {
+		go tagger.Run(ctx, time.Minute)
+		slog.Info("Auto-tagging enabled", "method", tagger.Method())
+	}
+
+	// セマンティック検索のチャンクが無い記事（新しい記事・本文が変わった記事）のベクトルを1分ごとに作成
+	if semanticIndex.Enabled() {
+		go semanticIndex.Run(ctx, time.Minute)
+		slog.Info("Semantic search enabled", "model", semanticIndex.Model())
+	}
+
+	// フォルダ同期（処理が完了した記事を1記事1ファイルの Markdown で書き出す。Obsidian の保管庫や静的サイトジェネレーター向け）
+	// ZBOR_SYNC_DIR: 書き出し先のフォルダ（未設定で無効）
+	// ZBOR_SYNC_INTERVAL_MINUTES: 同期の間隔（デフォルト: 5）
+	if dir := os.Getenv("ZBOR_SYNC_DIR"); dir != "" {
+		folderSync := export.NewFolderSync(exporter, articleRepo, dir)
+		interval := time.Duration(max(envNonNegativeInt("ZBOR_SYNC_INTERVAL_MINUTES", 5), 1)) * time.Minute
+		go folderSync.Run(ctx, interval)
+		slog.Info("Folder sync enabled", "dir", folderSync.Dir(), "interval", interval)
+	}
+
+	go storageManager.Run(ctx, time.Hour)
+
+	// 定期バックアップ（最新のバックアップから間隔を過ぎていれば10分以内に backup ジョブを作成）
+	if backupInterval > 0 {
+		go backupService.Run(ctx, 10*time.Minute)
+		slog.Info("Scheduled backups enabled", "dir", backupDir, "interval", backupInterval)
+	}
+
+	// 削除した記事のパージ
+	// 削除から ZBOR_ARTICLE_RETENTION_DAYS 日（デフォルト: 30、0 で無効）経った記事を1時間ごとに物理削除する
+	if retentionDays := envNonNegativeInt("ZBOR_ARTICLE_RETENTION_DAYS", 30); retentionDays > 0 {
+		go purgeArticles(ctx, articleRepo, time.Duration(retentionDays)*24*time.Hour, time.Hour)
+	}
+
+	// 変換済みWAVの掃除
+	// 旧バージョンが音声の隣に作った *_converted.wav を削除し、以降は1時間ごとに変換キャッシュの上限を適用する
+	go func() {
+		if stats, err := asr.RemoveLegacyConversions(dataDir); err != nil {
+			slog.Error("Failed to remove legacy WAV conversions", "error", err)
+		} else if stats.Files > 0 {
+			slog.Info("Removed legacy WAV conversions", "files", stats.Files, "bytes", stats.Bytes)
+		}
+		pruneConversions(ctx, wavCache, time.Hour)
+	}()
+	go pruneUploads(ctx, uploadStore, time.Hour)
+
+	// トランスクリプト検索の導入前に保存した文字起こしをインデックスに登録
+	go func() {
+		n, err := audioIngester.IndexMissingTranscripts(ctx)
+		if err != nil {
+			slog.Error("Failed to index transcripts", "error", err)
+		}
+		if n > 0 {
+			slog.Info("Indexed transcripts for search", "count", n)
+		}
+	}()
+
+	// 参照の無くなった音声の実体（ソースの削除で残ったもの）を削除
+	go func() {
+		n, err := blobRepo.Prune(ctx)
+		if err != nil {
+			slog.Error("Failed to prune blobs", "error", err)
+		}
+		if n > 0 {
+			slog.Info("Pruned unreferenced blobs", "count", n)
+		}
+	}()
+
+	// アーカイブ（ZBOR_ARCHIVE_DIR 設定時のみ）
+	// 最終アクセスから ZBOR_ARCHIVE_AFTER_MONTHS か月（デフォルト: 6）経ったソースの
+	// 音声・成果物をアーカイブ先に移動する。記事は残るので検索可能
+	if archiveDir := os.Getenv("ZBOR_ARCHIVE_DIR"); archiveDir != "" {
+		months := 6
+		if v := os.Getenv("ZBOR_ARCHIVE_AFTER_MONTHS"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				log.Fatalf("Invalid ZBOR_ARCHIVE_AFTER_MONTHS: %s", v)
+			}
+			months = n
+		}
+		store, err := archive.NewDirStore(archiveDir)
+		if err != nil {
+			log.Fatalf("Failed to open archive: %v", err)
+		}
+		archiver := archive.NewArchiver(sourceRepo, artifactRepo, store, time.Duration(months)*30*24*time.Hour)
+		archiver.SetBlobRepository(blobRepo)
+		audioHandler.SetArchiver(archiver)
+		go archiver.Run(ctx, time.Hour)
+		slog.Info("Archive enabled", "dir", archiveDir, "after_months", months)
+	}
+
+	// 整合性監査
+	// ZBOR_INTEGRITY_AUDIT_HOURS 時間ごと（デフォルト: 24、0 で無効）に音声ファイルと成果物の
+	// SHA-256 を検証し、破損・欠損があれば通知する（送信先は ZBOR_NOTIFY_* で設定）
+	notifier := notify.NewFromEnv()
+	auditor := integrity.NewAuditor(sourceRepo, artifactRepo, checksumRepo, notifier)
+	auditHours := 24
+	if v := os.Getenv("ZBOR_INTEGRITY_AUDIT_HOURS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid ZBOR_INTEGRITY_AUDIT_HOURS: %s", v)
+		}
+		auditHours = n
+	}
+	if auditHours > 0 {
+		go auditor.Run(ctx, time.Duration(auditHours)*time.Hour)
+	}
+
+	// アラート（1分ごとに確認し、整合性監査と同じ送信先に通知）
+	// - 同じタイプのジョブが ZBOR_ALERT_FAILURE_WINDOW_MINUTES 分（デフォルト: 30）以内に
+	//   ZBOR_ALERT_FAILURE_COUNT 回（デフォルト: 3）失敗
+	// - 待機中のジョブが ZBOR_ALERT_QUEUE_DEPTH 件（デフォルト: 100）を超えた
+	// - ワーカーから ZBOR_ALERT_HEARTBEAT_MINUTES 分（デフォルト: 30）応答が無い
+	// いずれも 0 で無効
+	rules := alert.DefaultRules()
+	rules.FailureThreshold = envNonNegativeInt("ZBOR_ALERT_FAILURE_COUNT", rules.FailureThreshold)
+	rules.FailureWindow = time.Duration(envNonNegativeInt("ZBOR_ALERT_FAILURE_WINDOW_MINUTES", int(rules.FailureWindow.Minutes()))) * time.Minute
+	rules.QueueThreshold = envNonNegativeInt("ZBOR_ALERT_QUEUE_DEPTH", rules.QueueThreshold)
+	rules.HeartbeatTimeout = time.Duration(envNonNegativeInt("ZBOR_ALERT_HEARTBEAT_MINUTES", int(rules.HeartbeatTimeout.Minutes()))) * time.Minute
+	monitor := alert.NewMonitor(jobRepo, notifier, rules)
+	monitor.SetWorker(w)
+	if workerRepo != nil {
+		monitor.SetRemoteWorkers(workerRepo)
+	}
+	go monitor.Run(ctx, time.Minute)
+	// 集めたジョブのログを0.5秒ごとにまとめて保存
+	go jobLogs.Run(ctx, jobRepo.AppendLogs)
+	// 再試行の時刻になった Webhook の配信を15秒ごとに送信（新しいイベントはすぐに送信）
+	go webhookDispatcher.Run(ctx, 15*time.Second)
+	if channels := notifier.Channels(); len(channels) > 0 {
+		slog.Info("Notifications enabled", "channels", strings.Join(channels, ", "))
+	}
+
 	// ハンドラー作成
-	articleHandler := handlers.NewArticleHandler(articleRepo)
+	articleHandler := handlers.NewArticleHandler(articleRepo, holdRepo, exporter)
 	tagHandler := handlers.NewTagHandler(tagRepo)
 	jobHandler := handlers.NewJobHandler(jobRepo)
+	summarizeHandler := handlers.NewSummarizeHandler(summarizer, articleRepo)
+	translateHandler := handlers.NewTranslateHandler(translator, sourceRepo, artifactRepo)
+	autoTagHandler := handlers.NewAutoTagHandler(tagger, articleRepo, tagRepo)
+	analyzeHandler := handlers.NewAnalyzeHandler(analyzer, articleRepo, artifactRepo)
+	holdHandler := handlers.NewHoldHandler(holdRepo, sourceRepo, articleRepo)
+	webHandler := handlers.NewWebHandler(webIngester, summarizer)
+	integrityHandler := handlers.NewIntegrityHandler(auditor, checksumRepo)
+	feedHandler := handlers.NewFeedHandler(feedService, summarizer)
+	pipelineHandler := handlers.NewPipelineHandler(audioIngester, summarizer)
+	bookmarkHandler := handlers.NewBookmarkHandler(bookmarkRepo, sourceRepo)
+	replacementHandler := handlers.NewReplacementHandler(replacementRepo, sourceRepo, audioIngester)
+	searchHandler := handlers.NewSearchHandler(transcriptRepo, articleRepo, semanticIndex)
+	askHandler := handlers.NewAskHandler(asker)
+	experimentHandler := handlers.NewExperimentHandler(experimentRepo)
+	homeHandler := handlers.NewHomeHandler(blobRepo)
+	storageHandler := handlers.NewStorageHandler(storageManager, sourceRepo)
+	backupHandler := handlers.NewBackupHandler(backupService)
+	webhookHandler := handlers.NewWebhookHandler(webhookRepo, webhookDispatcher)
+	articleHandler.SetRedaction(redaction)
+	jobHandler.SetRedaction(redaction)
+	translateHandler.SetRedaction(redaction)
+	searchHandler.SetRedaction(redaction)
+	askHandler.SetRedaction(redaction)
 
 	// Echoインスタンスの作成
 	e := echo.New()
 
 	// ミドルウェアの設定
-	e.Use(middleware.Logger())
+	// /api/v1/... はルーティングの前に /api/... に書き換える（バージョンの無い /api/... は互換のためのパスで非推奨）
+	e.Pre(handlers.APIVersionRouter())
+	e.Use(handlers.RequestLogger())
 	e.Use(middleware.Recover())
+	e.Use(handlers.MetricsMiddleware())
 
 	// ルートの登録（Web UI）
-	e.GET("/", handlers.Home)
-	e.GET("/about", handlers.About)
-	e.GET("/articles", articleHandler.ListPage)
-	e.GET("/articles/:id", articleHandler.DetailPage)
-	e.GET("/audio/upload", audioHandler.UploadPage)
-	e.GET("/audio/:source_id/sync", audioHandler.TranscriptSyncPage)
-	e.GET("/jobs", jobHandler.ListPage)
+	// ユーザーを作成（create-user か POST /api/users）した場合はログインが必要になり、
+	// 各ユーザーは自分のソース・記事・ジョブだけを参照できる（管理者はすべて）
+	userHandler := handlers.NewUserHandler(userRepo)
+	login := handlers.RequireLogin(userRepo)
+	ownedSource := handlers.OwnedSource(sourceRepo, "source_id")
+	ownedArticle := handlers.OwnedArticle(articleRepo, "id")
+
+	e.GET("/login", userHandler.LoginPage)
+	e.POST("/login", userHandler.Login)
+	e.POST("/logout", userHandler.Logout)
+	e.GET("/", homeHandler.Page, login)
+	e.GET("/about", handlers.About, login)
+	e.GET("/articles", articleHandler.ListPage, login)
+	e.GET("/articles/:id", articleHandler.DetailPage, login)
+	e.GET("/audio/upload", audioHandler.UploadPage, login)
+	e.GET("/audio/:source_id/sync", audioHandler.TranscriptSyncPage, login, ownedSource)
+	e.GET("/jobs", jobHandler.ListPage, login)
+	e.GET("/experiments", experimentHandler.ListPage, login)
+	e.GET("/experiments/compare", experimentHandler.ComparePage, login)
+	// スキーマのバージョンがこのバイナリと一致しない場合（未適用のマイグレーションがある、
+	// 新しいバージョンで更新された）は 503 を返し、ロードバランサーから外せるようにする
 	e.GET("/health", func(c echo.Context) error {
-		return c.JSON(200, map[string]string{
-			"status":  "ok",
-			"version": version.Version,
+		schema, err := db.SchemaStatus(c.Request().Context())
+		if err != nil {
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{"status": "error", "error": err.Error()})
+		}
+		status, code := "ok", http.StatusOK
+		if !schema.Current() {
+			status, code = "schema_mismatch", http.StatusServiceUnavailable
+		}
+		return c.JSON(code, map[string]interface{}{
+			"status":       status,
+			"version":      version.Version,
+			"api_versions": handlers.SupportedAPIVersions,
+			"audio":        audioCaps,
+			"schema":       schema,
 		})
 	})
 
+	// Prometheus のメトリクス（HTTPリクエスト、ジョブキュー、ジョブの処理時間、文字起こしのRTF、ワーカーのエラー）
+	// ZBOR_METRICS_TOKEN: 設定すると Authorization: Bearer <トークン> が必要
+	metrics.NewGaugeFunc("zbor_jobs", "Jobs by status (queued is the queue depth)", func() []metrics.Sample {
+		counts, err := jobRepo.CountByStatus(context.Background())
+		if err != nil {
+			slog.Error("Failed to count jobs for metrics", "error", err)
+			return nil
+		}
+		samples := make([]metrics.Sample, 0, len(counts))
+		for _, row := range counts {
+			if row.Status != nil {
+				samples = append(samples, metrics.Sample{Labels: []string{*row.Status}, Value: float64(row.Count)})
+			}
+		}
+		return samples
+	}, "status")
+	e.GET("/metrics", handlers.Metrics(os.Getenv("ZBOR_METRICS_TOKEN")))
+
 	// API ルートの登録
-	api := e.Group("/api")
+	// ZBOR_API_KEYS（"名前:スコープ:キー" のカンマ区切り）か create-api-key で作成したキーがあれば、
+	// /api 以下はAPIキーが必要（GETは read、それ以外は write、管理系は admin）
+	staticKeys, err := handlers.ParseAPIKeys(os.Getenv("ZBOR_API_KEYS"))
+	if err != nil {
+		log.Fatalf("Invalid ZBOR_API_KEYS: %v", err)
+	}
+	apiKeyRepo := storage.NewAPIKeyRepository(db)
+	if n, err := apiKeyRepo.Count(ctx); err == nil && n == 0 && len(staticKeys) == 0 {
+		slog.Warn("API authentication disabled: no API keys configured (set ZBOR_API_KEYS or run create-api-key)")
+	}
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyRepo)
+	usageHandler := handlers.NewUsageHandler(usageRepo)
+	admin := handlers.RequireScope(storage.APIScopeAdmin)
+
+	apiAuth := handlers.NewAPIAuth(apiKeyRepo, userRepo, staticKeys)
+	api := e.Group("/api", apiAuth.Middleware())
+
+	// API Keys API
+	api.GET("/keys", apiKeyHandler.List, admin)
+	api.POST("/keys", apiKeyHandler.Create, admin)
+	api.DELETE("/keys/:id", apiKeyHandler.Delete, admin)
+
+	// Users API
+	api.GET("/users", userHandler.List, admin)
+	api.POST("/users", userHandler.Create, admin)
+	api.DELETE("/users/:id", userHandler.Delete, admin)
+
+	// Usage API
+	api.GET("/usage", usageHandler.Report)
+	api.GET("/usage/quotas", usageHandler.ListQuotas, admin)
+	api.PUT("/usage/quotas", usageHandler.SetQuota, admin)
+	api.DELETE("/usage/quotas/:scope/:subject", usageHandler.DeleteQuota, admin)
+
+	// Replacement dictionary API（全体のルールはすべてのソースに適用されるため変更は admin）
+	api.GET("/replacements", replacementHandler.ListGlobal)
+	api.POST("/replacements", replacementHandler.CreateGlobal, admin)
+	api.PUT("/replacements/:id", replacementHandler.UpdateGlobal, admin)
+	api.DELETE("/replacements/:id", replacementHandler.DeleteGlobal, admin)
 
 	// Articles API
 	api.GET("/articles", articleHandler.List)
 	api.GET("/articles/search", articleHandler.Search)
+	api.GET("/articles/deleted", articleHandler.ListDeleted)
+	api.GET("/articles/facets", analyzeHandler.Facets)
 	api.POST("/articles", articleHandler.Create)
-	api.GET("/articles/:id", articleHandler.Get)
-	api.PUT("/articles/:id", articleHandler.Update)
-	api.DELETE("/articles/:id", articleHandler.Delete)
-	api.POST("/articles/:id/tags/:tag_id", articleHandler.AddTag)
-	api.DELETE("/articles/:id/tags/:tag_id", articleHandler.RemoveTag)
+	api.GET("/articles/:id", articleHandler.Get, ownedArticle)
+	api.PUT("/articles/:id", articleHandler.Update, ownedArticle)
+	api.DELETE("/articles/:id", articleHandler.Delete, ownedArticle)
+	api.POST("/articles/:id/restore", articleHandler.Restore, ownedArticle)
+	api.GET("/articles/:id/export", articleHandler.Export, ownedArticle)
+	api.GET("/articles/:id/revisions", articleHandler.Revisions, ownedArticle)
+	api.POST("/articles/:id/revisions/:revision_id/restore", articleHandler.RestoreRevision, ownedArticle)
+	api.POST("/articles/:id/tags/:tag_id", articleHandler.AddTag, ownedArticle)
+	api.DELETE("/articles/:id/tags/:tag_id", articleHandler.RemoveTag, ownedArticle)
+	api.POST("/articles/:id/summarize", summarizeHandler.Summarize, ownedArticle)
+	api.GET("/articles/:id/tag-suggestions", autoTagHandler.List, ownedArticle)
+	api.POST("/articles/:id/tag-suggestions", autoTagHandler.CreateJob, ownedArticle)
+	api.POST("/articles/:id/tag-suggestions/:suggestion_id/accept", autoTagHandler.Accept, ownedArticle)
+	api.POST("/articles/:id/tag-suggestions/:suggestion_id/reject", autoTagHandler.Reject, ownedArticle)
+	api.GET("/articles/:id/analysis", analyzeHandler.Get, ownedArticle)
+	api.POST("/articles/:id/analysis", analyzeHandler.CreateJob, ownedArticle)
+	api.GET("/articles/:id/hold", holdHandler.Status(storage.HoldTargetArticle), ownedArticle)
+	api.POST("/articles/:id/hold", holdHandler.Lock(storage.HoldTargetArticle), ownedArticle, admin)
+	api.DELETE("/articles/:id/hold", holdHandler.Unlock(storage.HoldTargetArticle), ownedArticle, admin)
+
+	// Search API
+	api.GET("/search/transcripts", searchHandler.Transcripts)
+	api.GET("/search/semantic", searchHandler.Semantic)
+	api.POST("/ask", askHandler.Ask)
 
 	// Tags API
 	api.GET("/tags", tagHandler.List)
+	api.GET("/tags/suggest", tagHandler.Suggest)
 	api.POST("/tags", tagHandler.Create)
 	api.GET("/tags/:id", tagHandler.Get)
-	api.PUT("/tags/:id", tagHandler.Update)
-	api.DELETE("/tags/:id", tagHandler.Delete)
+	api.PUT("/tags/:id", tagHandler.Update, admin)
+	api.DELETE("/tags/:id", tagHandler.Delete, admin)
+
+	// Legal Hold API
+	api.GET("/holds", holdHandler.List)
+	api.GET("/sources/:id/hold", holdHandler.Status(storage.HoldTargetSource), handlers.OwnedSource(sourceRepo, "id"))
+	api.POST("/sources/:id/hold", holdHandler.Lock(storage.HoldTargetSource), admin)
+	api.DELETE("/sources/:id/hold", holdHandler.Unlock(storage.HoldTargetSource), admin)
+
+	// Integrity API
+	api.GET("/integrity", integrityHandler.Status)
+	api.POST("/integrity/audit", integrityHandler.Audit, admin)
+
+	// Storage API
+	api.GET("/storage/stats", storageHandler.Stats)
+	api.POST("/storage/cleanup", storageHandler.Cleanup, admin)
+	api.GET("/sources/:id/storage", storageHandler.SourceUsage, handlers.OwnedSource(sourceRepo, "id"))
+	api.POST("/sources/:id/cleanup", storageHandler.CleanupSource, admin)
+
+	// Backups API
+	api.GET("/backups", backupHandler.List, admin)
+	api.POST("/backups", backupHandler.Create, admin)
+	api.GET("/backups/:name", backupHandler.Download, admin)
+	api.DELETE("/backups/:name", backupHandler.Delete, admin)
 
 	// Jobs API
 	api.GET("/jobs", jobHandler.List)
 	api.GET("/jobs/stats", jobHandler.Stats)
 	api.GET("/jobs/:id", jobHandler.Get)
+	api.GET("/jobs/:id/partial", jobHandler.Partial)
+	api.GET("/jobs/:id/logs", jobHandler.Logs)
 	api.DELETE("/jobs/:id", jobHandler.Delete)
 
+	// Webhooks API（各ユーザーは自分のデータのイベントを受け取る）
+	api.GET("/webhooks", webhookHandler.List)
+	api.POST("/webhooks", webhookHandler.Create)
+	api.GET("/webhooks/:id", webhookHandler.Get)
+	api.PUT("/webhooks/:id", webhookHandler.Update)
+	api.DELETE("/webhooks/:id", webhookHandler.Delete)
+	api.GET("/webhooks/:id/deliveries", webhookHandler.Deliveries)
+	api.POST("/webhooks/:id/ping", webhookHandler.Ping)
+	api.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+
+	// Experiments API（cmd/transcribe-* の -record で記録した実行）
+	api.GET("/experiments", experimentHandler.List)
+	api.GET("/experiments/:id", experimentHandler.Get)
+	api.DELETE("/experiments/:id", experimentHandler.Delete)
+
 	// Ingest API
 	api.POST("/ingest/audio", audioHandler.Upload)
+	api.POST("/uploads", audioHandler.CreateUpload)
+	api.GET("/uploads/:upload_id", audioHandler.GetUpload)
+	api.PUT("/uploads/:upload_id/chunks/:index", audioHandler.PutUploadChunk)
+	api.DELETE("/uploads/:upload_id", audioHandler.DeleteUpload)
+	api.POST("/ingest/youtube", audioHandler.IngestYouTube)
+	api.POST("/ingest/audio-url", audioHandler.IngestAudioURL)
+	api.POST("/ingest/transcript", audioHandler.IngestTranscript)
+	api.POST("/ingest/url", webHandler.IngestURL)
+	api.POST("/ingest/crawl", webHandler.IngestCrawl)
+
+	// Feeds API
+	api.GET("/feeds", feedHandler.List)
+	api.POST("/feeds", feedHandler.Subscribe)
+	api.POST("/feeds/:id/refresh", feedHandler.Refresh)
+	api.DELETE("/feeds/:id", feedHandler.Unsubscribe)
+	api.GET("/pipelines", pipelineHandler.List)
+	api.POST("/pipelines/podcast-episode", pipelineHandler.PodcastEpisode)
 
 	// Audio API
-	api.GET("/audio/:source_id/stream", audioHandler.Stream)
-	api.GET("/audio/:source_id/transcript", audioHandler.Transcript)
-	api.GET("/audio/:source_id/waveform", audioHandler.Waveform)
-	api.POST("/audio/:source_id/retranscribe", audioHandler.Retranscribe)
-	api.POST("/audio/:source_id/retranscribe-full", audioHandler.RetranscribeFull)
+	api.GET("/models", audioHandler.Models)
+	api.GET("/audio/:source_id/stream", audioHandler.Stream, ownedSource)
+	api.GET("/audio/:source_id/clip", audioHandler.Clip, ownedSource)
+	api.GET("/audio/:source_id/transcript", audioHandler.Transcript, ownedSource)
+	api.GET("/audio/:source_id/transcript/export", audioHandler.ExportTranscript, ownedSource)
+	api.GET("/audio/:source_id/transcripts", audioHandler.TranscriptVersions, ownedSource)
+	api.POST("/audio/:source_id/transcripts/:version_id/promote", audioHandler.PromoteTranscript, ownedSource)
+	api.GET("/audio/:source_id/bookmarks", bookmarkHandler.List, ownedSource)
+	api.POST("/audio/:source_id/bookmarks", bookmarkHandler.Create, ownedSource)
+	api.PUT("/audio/:source_id/bookmarks/:id", bookmarkHandler.Update, ownedSource)
+	api.DELETE("/audio/:source_id/bookmarks/:id", bookmarkHandler.Delete, ownedSource)
+	api.GET("/audio/:source_id/replacements", replacementHandler.List, ownedSource)
+	api.POST("/audio/:source_id/replacements", replacementHandler.Create, ownedSource)
+	api.POST("/audio/:source_id/replacements/apply", replacementHandler.Apply, ownedSource)
+	api.PUT("/audio/:source_id/replacements/:id", replacementHandler.Update, ownedSource)
+	api.DELETE("/audio/:source_id/replacements/:id", replacementHandler.Delete, ownedSource)
+	api.GET("/audio/:source_id/waveform", audioHandler.Waveform, ownedSource)
+	api.POST("/audio/:source_id/chapters", audioHandler.RebuildChapters, ownedSource)
+	api.GET("/audio/:source_id/condensed", audioHandler.Condensed, ownedSource)
+	api.POST("/audio/:source_id/rehydrate", audioHandler.Rehydrate, ownedSource)
+	api.POST("/audio/:source_id/retranscribe", audioHandler.Retranscribe, ownedSource)
+	api.POST("/audio/:source_id/retranscribe-full", audioHandler.RetranscribeFull, ownedSource)
+	api.POST("/audio/:source_id/segments/:idx/adjust-boundary", audioHandler.AdjustBoundary, ownedSource)
+	api.POST("/audio/:source_id/adjust-boundaries", audioHandler.AdjustBoundaries, ownedSource)
+	api.POST("/audio/:source_id/refine", audioHandler.Refine, ownedSource)
+	api.POST("/audio/:source_id/translate", translateHandler.Translate, ownedSource)
+	api.GET("/audio/:source_id/translations", translateHandler.List, ownedSource)
+	api.GET("/audio/:source_id/translations/:lang", translateHandler.Get, ownedSource)
+	api.POST("/transcripts/resegment", audioHandler.ResegmentTranscripts, admin)
+
+	// Remote Worker API（分散モードのみ）
+	if remoteHandler != nil {
+		api.GET("/workers", remoteHandler.ListWorkers)
+
+		workerAPI := api.Group("/worker", handlers.RequireWorkerToken(workerToken))
+		workerAPI.POST("/register", remoteHandler.Register)
+		workerAPI.POST("/heartbeat", remoteHandler.Heartbeat)
+		workerAPI.POST("/jobs/claim", remoteHandler.Claim)
+		workerAPI.GET("/jobs/:id", remoteHandler.Job)
+		workerAPI.GET("/jobs/:id/audio/:file", remoteHandler.Audio)
+		workerAPI.GET("/jobs/:id/chunks", remoteHandler.Chunks)
+		workerAPI.PUT("/jobs/:id/chunks/:index", remoteHandler.UploadChunk)
+		workerAPI.POST("/jobs/:id/progress", remoteHandler.Progress)
+		workerAPI.POST("/jobs/:id/complete", remoteHandler.Complete)
+		workerAPI.POST("/jobs/:id/fail", remoteHandler.Fail)
+	}
+
+	// WebDAV（ZBOR_WEBDAV=1 の場合のみ）: /dav/ 以下でソースごとの文字起こし（txt/srt/vtt/json）を
+	// 読み取り専用で公開する。Basic認証が必要で、ユーザーのユーザー名とパスワードならそのユーザーの文字起こしだけ、
+	// パスワードがAPIキーなら全ての文字起こしを公開する。ユーザーがいない場合は共有のユーザー名
+	// （ZBOR_WEBDAV_USER）とパスワード（ZBOR_WEBDAV_PASSWORD）でもよい
+	if os.Getenv("ZBOR_WEBDAV") == "1" {
+		password := os.Getenv("ZBOR_WEBDAV_PASSWORD")
+		if password == "" {
+			if ok, err := apiAuth.HasCredentials(ctx); err != nil || !ok {
+				log.Fatalf("ZBOR_WEBDAV=1 requires ZBOR_WEBDAV_PASSWORD, an API key or a user: transcripts are not served without authentication")
+			}
+		}
+		davFS := davfs.New(sourceRepo, artifactRepo)
+		if redaction.Enforced() {
+			davFS.SetRedactor(redaction.Redactor())
+		}
+		davHandler := echo.WrapHandler(davFS.Handler("/dav"))
+		davAuth := apiAuth.WebDAVMiddleware(os.Getenv("ZBOR_WEBDAV_USER"), password)
+		e.Match(davfs.Methods, "/dav", davHandler, davAuth)
+		e.Match(davfs.Methods, "/dav/*", davHandler, davAuth)
+		slog.Info("WebDAV enabled: /dav/ (read-only)")
+	}
 
 	// グレースフルシャットダウン
 	go func() {
 		sigCh := make(chan os.Signal, 1)
 		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 		<-sigCh
-		log.Println("Shutting down...")
+		slog.Info("Shutting down...")
 		cancel()
 		e.Close()
 	}()
 
 	// サーバー起動
-	log.Printf("Starting Zbor v%s on port %s", version.Version, port)
+	slog.Info("Starting Zbor", "version", version.Version, "port", port)
 	if err := e.Start(fmt.Sprintf(":%s", port)); err != nil {
-		log.Println("Server stopped")
+		slog.Info("Server stopped")
+	}
+}
+
+// purgeArticles は削除から retention 経った記事を interval ごとに物理削除する
+func purgeArticles(ctx context.Context, articleRepo *storage.ArticleRepository, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := articleRepo.Purge(ctx, time.Now().Add(-retention)); err != nil {
+			slog.Error("Article purge failed", "error", err)
+		} else if n > 0 {
+			slog.Info("Purged deleted articles", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// staleTempWavAge は文字起こし中のプロセス終了で残った一時WAVを削除するまでの時間
+const staleTempWavAge = 24 * time.Hour
+
+// pruneConversions は interval ごとに変換キャッシュの上限を適用し、残った一時WAVを削除する
+func pruneConversions(ctx context.Context, cache *asr.ConversionCache, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if stats, err := cache.Prune(); err != nil {
+			slog.Error("WAV cache prune failed", "error", err)
+		} else if stats.Files > 0 {
+			slog.Info("Pruned WAV cache", "files", stats.Files, "bytes", stats.Bytes)
+		}
+		if stats, err := asr.RemoveStaleTempWavs(staleTempWavAge); err != nil {
+			slog.Error("Temp WAV cleanup failed", "error", err)
+		} else if stats.Files > 0 {
+			slog.Info("Removed stale temp WAVs", "files", stats.Files, "bytes", stats.Bytes)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newBlobStore は ZBOR_BLOB_STORE の値から音声ファイルの保存先を作成（s3:// 以外はディレクトリ）
+func newBlobStore(spec string) (storage.Blob, error) {
+	rest, ok := strings.CutPrefix(spec, "s3://")
+	if !ok {
+		return storage.NewLocalBlob(spec)
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	return storage.NewS3Blob(storage.S3Config{
+		Endpoint:        os.Getenv("ZBOR_S3_ENDPOINT"),
+		Region:          os.Getenv("ZBOR_S3_REGION"),
+		Bucket:          bucket,
+		Prefix:          prefix,
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		PathStyle:       os.Getenv("ZBOR_S3_PATH_STYLE") == "1",
+	})
+}
+
+// staleUploadAge は中断された再開可能なアップロードのチャンクを削除するまでの時間
+const staleUploadAge = 24 * time.Hour
+
+// pruneUploads は interval ごとに staleUploadAge の間チャンクが届かないアップロードを削除する
+func pruneUploads(ctx context.Context, uploads *ingestion.UploadStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := uploads.Prune(staleUploadAge); err != nil {
+			slog.Error("Upload prune failed", "error", err)
+		} else if n > 0 {
+			slog.Info("Removed stale uploads", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// envNonNegativeInt は環境変数を0以上の整数として取得（未設定ならデフォルト、不正な値なら終了）
+func envNonNegativeInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Fatalf("Invalid %s: %s", name, v)
+	}
+	return n
+}
+
+// envNonNegativeFloat は環境変数を0以上の数値として取得（未設定ならデフォルト、不正な値なら終了）
+func envNonNegativeFloat(name string, def float64) float64 {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 {
+		log.Fatalf("Invalid %s: %s", name, v)
 	}
+	return f
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"zbor/internal/storage"
+)
+
+// migrateDB は未適用のマイグレーションを適用する（-status は適用せずに一覧を表示）
+// サーバーも起動時に適用するため、通常は起動前に確認・適用したい場合に使う
+func migrateDB(ctx context.Context, args []string, db *storage.DB) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	statusOnly := fs.Bool("status", false, "List migrations without applying them")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: zbor migrate [options]\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if !*statusOnly {
+		applied, err := db.Migrate(ctx)
+		for _, m := range applied {
+			fmt.Printf("Applied %04d %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	history, err := db.MigrationHistory(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range history {
+		state := "pending"
+		if m.AppliedAt != nil {
+			state = "applied " + m.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%04d %-40s %s\n", m.Version, m.Name, state)
+	}
+	status, err := db.SchemaStatus(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Schema version %d (latest %d, %d pending)\n", status.Version, status.Latest, status.Pending)
+	if status.Version > status.Latest {
+		fmt.Fprintf(os.Stderr, "The database was migrated by a newer version of zbor\n")
+	}
+	return nil
+}
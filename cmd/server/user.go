@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"zbor/internal/storage"
+)
+
+// createUser はログインするユーザーを作成する（最初のユーザーは管理者になり、既存のデータを引き継ぐ）
+// パスワードは環境変数 ZBOR_PASSWORD か標準入力の1行目から読む
+func createUser(ctx context.Context, args []string, db *storage.DB) error {
+	fs := flag.NewFlagSet("create-user", flag.ExitOnError)
+	admin := fs.Bool("admin", false, "Allow the user to see all users' data and manage keys and users")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: zbor create-user [options] <username>\n\n")
+		fmt.Fprintf(os.Stderr, "The password is read from ZBOR_PASSWORD or the first line of stdin.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	password := os.Getenv("ZBOR_PASSWORD")
+	if password == "" {
+		fmt.Fprintf(os.Stderr, "Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	user, err := storage.NewUserRepository(db).Create(ctx, fs.Arg(0), password, *admin)
+	if err != nil {
+		return err
+	}
+	role := "user"
+	if user.IsAdmin == 1 {
+		role = "admin"
+	}
+	fmt.Printf("Created user %q (id %s, %s)\n", user.Username, user.ID, role)
+	return nil
+}
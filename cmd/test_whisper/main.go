@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"zbor/internal/asr"
@@ -29,7 +30,7 @@ func main() {
 	defer recognizer.Close()
 
 	fmt.Printf("Transcribing %s...\n", testAudio)
-	result, err := recognizer.TranscribeFile(testAudio, 30, func(p int, s string) {
+	result, err := recognizer.TranscribeFile(context.Background(), testAudio, 30, func(p int, s string) {
 		fmt.Printf("  [%d%%] %s\n", p, s)
 	})
 	if err != nil {
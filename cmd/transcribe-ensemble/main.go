@@ -0,0 +1,205 @@
+// Experiment: ensemble of ASR models with ROVER-style character voting
+// Transcribes the same audio with two or three models, aligns their outputs
+// on the first model's timestamps and votes character by character
+// (asr.EnsembleVote). Prints the consensus and each segment's disagreement
+//
+// Usage:
+//   go run ./cmd/transcribe-ensemble -i audio.mp3
+//   go run ./cmd/transcribe-ensemble -i audio.mp3 -models reazonspeech,whisper
+//   go run ./cmd/transcribe-ensemble -i audio.mp3 -format json -o ensemble.json
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"zbor/internal/asr"
+	"zbor/internal/experiment"
+)
+
+// Models that can take part in the ensemble
+const (
+	modelReazonSpeech = "reazonspeech"
+	modelSenseVoice   = "sensevoice"
+	modelWhisper      = "whisper"
+)
+
+// whisperChunkSec is the chunk length of full-file Whisper transcription
+const whisperChunkSec = 30
+
+func main() {
+	var (
+		inputFile       = flag.String("i", "", "Input audio file")
+		outputFile      = flag.String("o", "", "Output file (default: stdout)")
+		format          = flag.String("format", "text", "Output format: text, json")
+		models          = flag.String("models", "reazonspeech,sensevoice,whisper", "Comma-separated models (2-3); the first (reazonspeech or sensevoice) provides the timestamps and wins ties")
+		reazonSpeechDir = flag.String("reazonspeech-model", "models/sherpa-onnx-zipformer-ja-reazonspeech-2024-08-01", "ReazonSpeech model directory")
+		senseVoiceDir   = flag.String("sensevoice-model", "models/sherpa-onnx-sense-voice-zh-en-ja-ko-yue-2024-07-17", "SenseVoice model directory")
+		whisperDir      = flag.String("whisper-model", "models/sherpa-onnx-whisper-turbo", "Whisper model directory")
+		language        = flag.String("lang", "ja", "Language of SenseVoice and Whisper")
+		numThreads      = flag.Int("threads", 0, "Number of threads for inference (0: auto)")
+		provider        = flag.String("provider", "cpu", "Execution provider: cpu, cuda, coreml, directml")
+		verbose         = flag.Bool("v", false, "Verbose output")
+	)
+	recordFlags := experiment.RegisterFlags()
+	flag.Parse()
+	ctx := context.Background()
+
+	if *inputFile == "" {
+		fmt.Fprintf(os.Stderr, "Usage: go run ./cmd/transcribe-ensemble -i <file> [-models reazonspeech,sensevoice,whisper]\n")
+		os.Exit(1)
+	}
+	if _, err := os.Stat(*inputFile); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Error: Input file not found: %s\n", *inputFile)
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: Invalid format '%s'. Must be: text or json\n", *format)
+		os.Exit(1)
+	}
+	names := strings.Split(*models, ",")
+	if len(names) < 2 || len(names) > 3 {
+		fmt.Fprintf(os.Stderr, "Error: -models needs 2 or 3 models, got %d\n", len(names))
+		os.Exit(1)
+	}
+	if names[0] == modelWhisper {
+		fmt.Fprintf(os.Stderr, "Error: The first model provides the timestamps and cannot be whisper\n")
+		os.Exit(1)
+	}
+
+	progressCallback := func(progress int, step string) {
+		if *verbose {
+			fmt.Fprintf(os.Stderr, "\r[%3d%%] %s", progress, step)
+		}
+	}
+
+	run := recordFlags.Start("transcribe-ensemble", *inputFile)
+	startTime := time.Now()
+
+	var results []*asr.Result
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if *verbose {
+			fmt.Fprintf(os.Stderr, "Transcribing with %s\n", name)
+		}
+		modelStart := time.Now()
+
+		var result *asr.Result
+		var err error
+		switch name {
+		case modelReazonSpeech:
+			config, cerr := asr.NewConfig(*reazonSpeechDir)
+			if cerr != nil {
+				fmt.Fprintf(os.Stderr, "Error: Failed to load model config: %v\n", cerr)
+				os.Exit(1)
+			}
+			config.NumThreads = *numThreads
+			config.Provider = *provider
+			recognizer, rerr := asr.NewRecognizer(config)
+			if rerr != nil {
+				fmt.Fprintf(os.Stderr, "Error: Failed to create recognizer: %v\n", rerr)
+				os.Exit(1)
+			}
+			result, err = recognizer.TranscribeWithOverlap(ctx, *inputFile, asr.DefaultSilenceConfig(), 1.0, 2.0, progressCallback)
+			recognizer.Close()
+
+		case modelSenseVoice:
+			config := asr.DefaultSenseVoiceConfig(*senseVoiceDir)
+			config.Language = *language
+			config.NumThreads = *numThreads
+			config.Provider = *provider
+			recognizer, rerr := asr.NewSenseVoiceRecognizer(config)
+			if rerr != nil {
+				fmt.Fprintf(os.Stderr, "Error: Failed to create SenseVoice recognizer: %v\n", rerr)
+				os.Exit(1)
+			}
+			result, err = recognizer.TranscribeFile(ctx, *inputFile, 20, progressCallback)
+			recognizer.Close()
+
+		case modelWhisper:
+			config := asr.DefaultWhisperConfig(*whisperDir)
+			config.Language = *language
+			config.NumThreads = *numThreads
+			config.Provider = *provider
+			recognizer, rerr := asr.NewWhisperRecognizer(config)
+			if rerr != nil {
+				fmt.Fprintf(os.Stderr, "Error: Failed to create Whisper recognizer: %v\n", rerr)
+				os.Exit(1)
+			}
+			result, err = recognizer.TranscribeFile(ctx, *inputFile, whisperChunkSec, progressCallback)
+			recognizer.Close()
+
+		default:
+			fmt.Fprintf(os.Stderr, "Error: Unknown model '%s' (reazonspeech, sensevoice, whisper)\n", name)
+			os.Exit(1)
+		}
+
+		if *verbose {
+			fmt.Fprintf(os.Stderr, "\n")
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Transcription with %s failed: %v\n", name, err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "%s: %d chars in %.1fs\n", name, len([]rune(result.Text)), time.Since(modelStart).Seconds())
+		results = append(results, result)
+	}
+
+	ensemble, err := asr.EnsembleVote(results)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Voting failed: %v\n", err)
+		os.Exit(1)
+	}
+	elapsed := time.Since(startTime).Seconds()
+	fmt.Fprintf(os.Stderr, "Consensus: %d chars, %d segments, disagreement %.3f\n",
+		len([]rune(ensemble.Result.Text)), len(ensemble.Segments), ensemble.Disagreement)
+
+	if run != nil {
+		duration, _ := asr.GetAudioDuration(ctx, *inputFile)
+		for _, seg := range ensemble.Result.Segments {
+			run.Chunk(seg.StartTime, seg.EndTime, seg.Text)
+		}
+		if err := run.Finish(ensemble.Result.Text, duration, elapsed); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to record experiment: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var output string
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(ensemble, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to format output: %v\n", err)
+			os.Exit(1)
+		}
+		output = string(data)
+	default:
+		var b strings.Builder
+		for _, seg := range ensemble.Segments {
+			fmt.Fprintf(&b, "[%.2f-%.2f] (%.2f) %s\n", seg.StartTime, seg.EndTime, seg.Disagreement, seg.Text)
+			if *verbose && seg.Disagreement > 0 {
+				for i, hypothesis := range seg.Hypotheses {
+					fmt.Fprintf(&b, "    %-12s %s\n", names[i]+":", hypothesis)
+				}
+			}
+		}
+		fmt.Fprintf(&b, "\n=== Consensus ===\n%s\n", ensemble.Result.Text)
+		output = b.String()
+	}
+
+	if *outputFile != "" {
+		if err := os.WriteFile(*outputFile, []byte(output), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to write output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Print(output)
+}
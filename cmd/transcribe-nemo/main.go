@@ -17,6 +17,8 @@ import (
 	"os/exec"
 	"time"
 
+	"zbor/internal/experiment"
+
 	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
 )
 
@@ -31,6 +33,7 @@ func main() {
 	vadModel := flag.String("vad-model", "", "VAD model path (optional, empty = no VAD)")
 	vadThreshold := flag.Float64("vad-threshold", 0.5, "VAD speech threshold")
 
+	recordFlags := experiment.RegisterFlags()
 	flag.Parse()
 
 	if *inputPath == "" {
@@ -91,6 +94,8 @@ func main() {
 		fmt.Println("VAD enabled")
 	}
 
+	run := recordFlags.Start("transcribe-nemo", *inputPath)
+
 	// Get duration
 	duration, _ := getAudioDuration(*inputPath)
 	fmt.Printf("Audio duration: %.1fs\n\n", duration)
@@ -146,6 +151,7 @@ func main() {
 				endSec := float64(segment.Start+len(segment.Samples)) / float64(sampleRate)
 
 				result := transcribeBytes(recognizer, segment.Samples)
+				run.Chunk(startSec, endSec, result)
 				if result != "" {
 					fmt.Printf("[%.2f-%.2f] %s\n", startSec, endSec, result)
 					allText += result
@@ -162,6 +168,7 @@ func main() {
 			segment := vad.Front()
 			vad.Pop()
 			result := transcribeBytes(recognizer, segment.Samples)
+			run.Chunk(float64(segment.Start)/float64(sampleRate), float64(segment.Start+len(segment.Samples))/float64(sampleRate), result)
 			if result != "" {
 				allText += result
 			}
@@ -189,6 +196,7 @@ func main() {
 			startSec := float64(chunkNum-1) * float64(chunkSec)
 
 			result := transcribeBytes(recognizer, samples)
+			run.Chunk(startSec, startSec+float64(len(samples))/float64(sampleRate), result)
 			if result != "" {
 				fmt.Printf("[%.0f-%.0fs] %s\n", startSec, startSec+float64(chunkSec), result)
 				allText += result
@@ -209,6 +217,10 @@ func main() {
 	fmt.Printf("Real-time factor: %.2fx\n", duration/elapsed)
 	fmt.Printf("Text length: %d chars\n", len(allText))
 	fmt.Printf("\n=== Full Transcript ===\n%s\n", allText)
+
+	if err := run.Finish(allText, duration, elapsed); err != nil {
+		log.Fatalf("Failed to record experiment: %v", err)
+	}
 }
 
 func transcribeBytes(recognizer *sherpa.OfflineRecognizer, samples []float32) string {
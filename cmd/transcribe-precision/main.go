@@ -2,14 +2,26 @@
 // Tests different decoding methods and beam search parameters
 // to improve accuracy on fast speech sections
 //
+// Fast speech mode (-mode fast-speech) estimates the speech rate of each
+// chunk from the base decoder's tokens and, on fast chunks, lets decoder
+// variants (beam search, blank penalty) vote on the transcript instead of
+// slowing the audio down with atempo. Timestamps stay in the original time
+//
+// Sweep mode (-mode sweep) runs a grid of decoding parameters (method x
+// beam x blank penalty x tempo) over a test set of audio files with
+// reference transcripts and prints the parameter sets ranked by CER
+//
 // Usage:
 //   go run ./cmd/transcribe-precision -input audio.mp3
 //   go run ./cmd/transcribe-precision -input audio.mp3 -method modified_beam_search -beam 10
+//   go run ./cmd/transcribe-precision -input audio.mp3 -mode fast-speech -fast-rate 9
+//   go run ./cmd/transcribe-precision -mode sweep -dataset testdata/eval -sweep-blank-penalty 0,1,2 -sweep-tempo 1.0,0.9
 
 package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/binary"
 	"flag"
 	"fmt"
@@ -19,11 +31,21 @@ import (
 	"os/exec"
 	"time"
 
+	"zbor/internal/asr"
+	"zbor/internal/experiment"
+
 	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
 )
 
 const sampleRate = 16000
 
+// Modes of the precision pipeline
+const (
+	modeStandard   = "standard"    // one decoder
+	modeFastSpeech = "fast-speech" // decoder variants vote on fast chunks
+	modeSweep      = "sweep"       // grid of parameter sets over a test set
+)
+
 func main() {
 	inputPath := flag.String("input", "", "Input audio/video file")
 	modelDir := flag.String("model", "models/sherpa-onnx-zipformer-ja-reazonspeech-2024-08-01", "ASR model directory")
@@ -33,17 +55,31 @@ func main() {
 	maxActivePaths := flag.Int("beam", 4, "Max active paths for beam search (higher = more accurate but slower)")
 	blankPenalty := flag.Float64("blank-penalty", 0.0, "Penalty for blank tokens (try 1.0-2.0 for fast speech)")
 	numThreads := flag.Int("threads", 4, "Number of threads")
-	tempo := flag.Float64("tempo", 1.0, "Audio tempo (0.9 = slower for fast speech, timestamps auto-corrected)")
+	provider := flag.String("provider", "cpu", "Execution provider: cpu, cuda, coreml, directml")
+	mode := flag.String("mode", modeStandard, "Pipeline mode: standard, fast-speech or sweep")
+	fastRate := flag.Float64("fast-rate", asr.DefaultFastSpeechRate, "Speech rate (chars/sec) above which a chunk is decoded as fast speech")
 	chunkSec := flag.Int("chunk", 20, "Chunk size in seconds (without VAD)")
 
 	// VAD parameters (optional)
 	vadModel := flag.String("vad-model", "", "VAD model path (optional, empty = no VAD)")
 	vadThreshold := flag.Float64("vad-threshold", 0.5, "VAD speech threshold")
 
+	// Sweep parameters (comma-separated grid values)
+	dataset := flag.String("dataset", "", "Sweep test set: directory of audio files with a reference .txt of the same name (default: -input with -reference)")
+	sweepMethods := flag.String("sweep-method", "greedy_search,modified_beam_search", "Sweep decoding methods")
+	sweepBeams := flag.String("sweep-beam", "4", "Sweep max active paths (modified_beam_search only)")
+	sweepBlanks := flag.String("sweep-blank-penalty", "0", "Sweep blank penalties")
+	sweepTempos := flag.String("sweep-tempo", "1.0", "Sweep tempos (atempo before decoding, 0.5-2.0)")
+	sweepCSV := flag.String("sweep-csv", "", "Write the ranked sweep results to this CSV file")
+
+	recordFlags := experiment.RegisterFlags()
 	flag.Parse()
 
-	if *inputPath == "" {
-		log.Fatal("Usage: go run ./cmd/transcribe-precision -input <file>")
+	if *mode != modeStandard && *mode != modeFastSpeech && *mode != modeSweep {
+		log.Fatalf("Unknown mode: %s (standard, fast-speech or sweep)", *mode)
+	}
+	if *inputPath == "" && (*mode != modeSweep || *dataset == "") {
+		log.Fatal("Usage: go run ./cmd/transcribe-precision -input <file> (or -mode sweep -dataset <dir>)")
 	}
 
 	fmt.Println("=== ASR Precision Test ===")
@@ -51,40 +87,85 @@ func main() {
 	fmt.Printf("MaxActivePaths: %d\n", *maxActivePaths)
 	fmt.Printf("BlankPenalty: %.2f\n", *blankPenalty)
 	fmt.Printf("NumThreads: %d\n", *numThreads)
-	if *tempo != 1.0 {
-		fmt.Printf("Tempo: %.2f (timestamps corrected by %.2fx)\n", *tempo, *tempo)
+	fmt.Printf("Provider: %s\n", *provider)
+	fmt.Printf("Mode: %s\n", *mode)
+	if *mode == modeFastSpeech {
+		fmt.Printf("FastRate: %.1f chars/sec\n", *fastRate)
 	}
 	fmt.Println()
 
-	// If tempo=0.95, audio is slower, timestamps need to be multiplied by 0.95 to get original time
-	tempoFactor := *tempo
-
 	// Create ASR config with precision parameters
-	config := sherpa.OfflineRecognizerConfig{
-		FeatConfig: sherpa.FeatureConfig{
-			SampleRate: sampleRate,
-			FeatureDim: 80,
-		},
-		ModelConfig: sherpa.OfflineModelConfig{
-			Transducer: sherpa.OfflineTransducerModelConfig{
-				Encoder: *modelDir + "/encoder-epoch-99-avg-1.onnx",
-				Decoder: *modelDir + "/decoder-epoch-99-avg-1.onnx",
-				Joiner:  *modelDir + "/joiner-epoch-99-avg-1.onnx",
-			},
-			Tokens:     *modelDir + "/tokens.txt",
-			NumThreads: *numThreads,
-			Debug:      0,
-		},
+	config := &asr.Config{
+		ModelPath:      *modelDir,
+		EncoderPath:    *modelDir + "/encoder-epoch-99-avg-1.onnx",
+		DecoderPath:    *modelDir + "/decoder-epoch-99-avg-1.onnx",
+		JoinerPath:     *modelDir + "/joiner-epoch-99-avg-1.onnx",
+		TokensPath:     *modelDir + "/tokens.txt",
+		NumThreads:     *numThreads,
+		SampleRate:     sampleRate,
 		DecodingMethod: *decodingMethod,
 		MaxActivePaths: *maxActivePaths,
 		BlankPenalty:   float32(*blankPenalty),
+		Provider:       *provider,
+	}
+
+	if *mode == modeSweep {
+		grid, err := parseSweepGrid(*sweepMethods, *sweepBeams, *sweepBlanks, *sweepTempos)
+		if err != nil {
+			log.Fatal(err)
+		}
+		items, err := sweepItems(*dataset, *inputPath, recordFlags.Reference)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := runSweep(context.Background(), config, grid, items, *chunkSec, *sweepCSV); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	recognizer := sherpa.NewOfflineRecognizer(&config)
-	if recognizer == nil {
-		log.Fatal("Failed to create recognizer")
+	recognizer, err := asr.NewRecognizer(config)
+	if err != nil {
+		log.Fatalf("Failed to create recognizer: %v", err)
+	}
+	defer recognizer.Close()
+
+	// Fast speech mode: decoder variants on the same model
+	var fastDecoder *asr.FastSpeechDecoder
+	if *mode == modeFastSpeech {
+		fastDecoder, err = asr.NewFastSpeechDecoder(recognizer, asr.DefaultFastSpeechVariants)
+		if err != nil {
+			log.Fatalf("Failed to create fast speech decoders: %v", err)
+		}
+		defer fastDecoder.Close()
+		fastDecoder.Threshold = *fastRate
+	}
+	var fastChunks, votedChunks int
+
+	// transcribe decodes one chunk or VAD segment starting at startSec
+	transcribe := func(samples []float32, startSec float64) string {
+		if fastDecoder == nil {
+			result, err := recognizer.TranscribeBytes(samples, sampleRate)
+			if err != nil {
+				log.Printf("Chunk at %.1fs failed: %v", startSec, err)
+				return ""
+			}
+			return result.Text
+		}
+		result, decision, err := fastDecoder.Decode(samples, sampleRate)
+		if err != nil {
+			log.Printf("Chunk at %.1fs failed: %v", startSec, err)
+			return ""
+		}
+		if decision.Fast {
+			fastChunks++
+			if decision.Chosen != "base" {
+				votedChunks++
+			}
+			fmt.Printf("  (fast speech %.1f chars/sec at %.1fs: %s)\n", decision.Rate, startSec, decision.Chosen)
+		}
+		return result.Text
 	}
-	defer sherpa.DeleteOfflineRecognizer(recognizer)
 
 	fmt.Println("Recognizer initialized")
 
@@ -113,34 +194,22 @@ func main() {
 		fmt.Println("VAD enabled")
 	}
 
+	run := recordFlags.Start("transcribe-precision", *inputPath)
+
 	// Get duration
 	duration, _ := getAudioDuration(*inputPath)
 	fmt.Printf("Audio duration: %.1fs\n\n", duration)
 
 	// Start ffmpeg
-	var cmd *exec.Cmd
-	if *tempo != 1.0 {
-		cmd = exec.Command("ffmpeg",
-			"-i", *inputPath,
-			"-af", fmt.Sprintf("atempo=%.2f", *tempo),
-			"-f", "s16le",
-			"-acodec", "pcm_s16le",
-			"-ar", fmt.Sprintf("%d", sampleRate),
-			"-ac", "1",
-			"-loglevel", "error",
-			"pipe:1",
-		)
-	} else {
-		cmd = exec.Command("ffmpeg",
-			"-i", *inputPath,
-			"-f", "s16le",
-			"-acodec", "pcm_s16le",
-			"-ar", fmt.Sprintf("%d", sampleRate),
-			"-ac", "1",
-			"-loglevel", "error",
-			"pipe:1",
-		)
-	}
+	cmd := exec.Command("ffmpeg",
+		"-i", *inputPath,
+		"-f", "s16le",
+		"-acodec", "pcm_s16le",
+		"-ar", fmt.Sprintf("%d", sampleRate),
+		"-ac", "1",
+		"-loglevel", "error",
+		"pipe:1",
+	)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -181,7 +250,8 @@ func main() {
 				startSec := float64(segment.Start) / float64(sampleRate)
 				endSec := float64(segment.Start+len(segment.Samples)) / float64(sampleRate)
 
-				result := transcribeBytes(recognizer, segment.Samples)
+				result := transcribe(segment.Samples, startSec)
+				run.Chunk(startSec, endSec, result)
 				if result != "" {
 					fmt.Printf("[%.2f-%.2f] %s\n", startSec, endSec, result)
 					allText += result
@@ -197,7 +267,10 @@ func main() {
 		for !vad.IsEmpty() {
 			segment := vad.Front()
 			vad.Pop()
-			result := transcribeBytes(recognizer, segment.Samples)
+			startSec := float64(segment.Start) / float64(sampleRate)
+			endSec := float64(segment.Start+len(segment.Samples)) / float64(sampleRate)
+			result := transcribe(segment.Samples, startSec)
+			run.Chunk(startSec, endSec, result)
 			if result != "" {
 				allText += result
 			}
@@ -221,15 +294,11 @@ func main() {
 			processedSamples += int64(len(samples))
 			chunkNum++
 
-			// Raw time in slowed audio
-			rawStartSec := float64(chunkNum-1) * float64(*chunkSec)
-			rawEndSec := rawStartSec + float64(*chunkSec)
+			startSec := float64(chunkNum-1) * float64(*chunkSec)
+			endSec := startSec + float64(len(samples))/float64(sampleRate)
 
-			// Corrected time in original audio
-			startSec := rawStartSec * tempoFactor
-			endSec := rawEndSec * tempoFactor
-
-			result := transcribeBytes(recognizer, samples)
+			result := transcribe(samples, startSec)
+			run.Chunk(startSec, endSec, result)
 			if result != "" {
 				fmt.Printf("[%.1f-%.1fs] %s\n", startSec, endSec, result)
 				allText += result
@@ -249,18 +318,14 @@ func main() {
 	fmt.Printf("Processing time: %.1fs\n", elapsed)
 	fmt.Printf("Real-time factor: %.2fx\n", duration/elapsed)
 	fmt.Printf("Text length: %d chars\n", len(allText))
+	if fastDecoder != nil {
+		fmt.Printf("Fast speech: %d chunks (%d replaced by vote)\n", fastChunks, votedChunks)
+	}
 	fmt.Printf("\n=== Full Transcript ===\n%s\n", allText)
-}
-
-func transcribeBytes(recognizer *sherpa.OfflineRecognizer, samples []float32) string {
-	stream := sherpa.NewOfflineStream(recognizer)
-	defer sherpa.DeleteOfflineStream(stream)
 
-	stream.AcceptWaveform(sampleRate, samples)
-	recognizer.Decode(stream)
-
-	result := stream.GetResult()
-	return result.Text
+	if err := run.Finish(allText, duration, elapsed); err != nil {
+		log.Fatalf("Failed to record experiment: %v", err)
+	}
 }
 
 func bytesToFloat32(data []byte) []float32 {
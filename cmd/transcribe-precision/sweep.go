@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"zbor/internal/asr"
+	"zbor/internal/eval"
+)
+
+// sweepGrid is the parameter grid of sweep mode
+type sweepGrid struct {
+	Methods        []string
+	Beams          []int
+	BlankPenalties []float64
+	Tempos         []float64
+}
+
+// sweepPoint is one parameter set of the grid
+type sweepPoint struct {
+	Method       string
+	Beam         int // 0 for greedy_search (not used)
+	BlankPenalty float64
+	Tempo        float64
+}
+
+// sweepResult is the score of one parameter set over the test set
+type sweepResult struct {
+	sweepPoint
+	Chars         eval.Counts
+	CER           float64
+	AudioSec      float64
+	ProcessingSec float64
+	RTF           float64
+	Failed        int
+}
+
+// parseSweepGrid parses the comma-separated grid flags
+func parseSweepGrid(methods, beams, blankPenalties, tempos string) (*sweepGrid, error) {
+	grid := &sweepGrid{}
+	for _, m := range splitList(methods) {
+		if m != "greedy_search" && m != "modified_beam_search" {
+			return nil, fmt.Errorf("unknown method '%s' (greedy_search or modified_beam_search)", m)
+		}
+		grid.Methods = append(grid.Methods, m)
+	}
+	for _, s := range splitList(beams) {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 1 {
+			return nil, fmt.Errorf("invalid beam '%s'", s)
+		}
+		grid.Beams = append(grid.Beams, v)
+	}
+	for _, s := range splitList(blankPenalties) {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid blank penalty '%s'", s)
+		}
+		grid.BlankPenalties = append(grid.BlankPenalties, v)
+	}
+	for _, s := range splitList(tempos) {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v < 0.5 || v > 2 {
+			return nil, fmt.Errorf("invalid tempo '%s' (0.5-2.0, the atempo range)", s)
+		}
+		grid.Tempos = append(grid.Tempos, v)
+	}
+	if len(grid.Methods) == 0 || len(grid.Beams) == 0 || len(grid.BlankPenalties) == 0 || len(grid.Tempos) == 0 {
+		return nil, fmt.Errorf("every grid dimension needs at least one value")
+	}
+	return grid, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// decoders returns the decoder settings of the grid (method x beam x blank
+// penalty). greedy_search does not use the beam, so it appears once per
+// blank penalty
+func (g *sweepGrid) decoders() []sweepPoint {
+	var points []sweepPoint
+	for _, method := range g.Methods {
+		beams := g.Beams
+		if method == "greedy_search" {
+			beams = []int{0}
+		}
+		for _, beam := range beams {
+			for _, blank := range g.BlankPenalties {
+				points = append(points, sweepPoint{Method: method, Beam: beam, BlankPenalty: blank})
+			}
+		}
+	}
+	return points
+}
+
+// runSweep transcribes the test set with every parameter set of the grid and
+// prints the results ranked by CER (then RTF). One recognizer is loaded per
+// decoder setting and reused for every tempo and file, so only one model is
+// in memory at a time
+func runSweep(ctx context.Context, base *asr.Config, grid *sweepGrid, items []eval.Item, chunkSec int, csvPath string) error {
+	durations := make([]float64, len(items))
+	for i, item := range items {
+		durations[i], _ = asr.GetAudioDuration(ctx, item.AudioPath)
+	}
+
+	decoders := grid.decoders()
+	total := len(decoders) * len(grid.Tempos)
+	fmt.Printf("Sweep: %d parameter sets x %d files\n\n", total, len(items))
+
+	var results []sweepResult
+	for _, decoder := range decoders {
+		config := *base
+		config.DecodingMethod = decoder.Method
+		config.MaxActivePaths = max(decoder.Beam, 1)
+		config.BlankPenalty = float32(decoder.BlankPenalty)
+		recognizer, err := asr.NewRecognizer(&config)
+		if err != nil {
+			return fmt.Errorf("failed to create recognizer: %w", err)
+		}
+
+		for _, tempo := range grid.Tempos {
+			point := decoder
+			point.Tempo = tempo
+			result := sweepResult{sweepPoint: point}
+			for i, item := range items {
+				start := time.Now()
+				res, err := recognizer.TranscribeWithTempo(ctx, item.AudioPath, tempo, chunkSec, nil)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "  %s (%s): %v\n", item.Name, point, err)
+					result.Failed++
+					continue
+				}
+				result.ProcessingSec += time.Since(start).Seconds()
+				result.AudioSec += durations[i]
+				result.Chars = result.Chars.Add(eval.CharCounts(item.Reference, res.Text))
+			}
+			result.CER = result.Chars.Rate()
+			if result.AudioSec > 0 {
+				result.RTF = result.ProcessingSec / result.AudioSec
+			}
+			results = append(results, result)
+			fmt.Printf("[%d/%d] %s: CER %.2f%% RTF %.3f\n", len(results), total, point, result.CER*100, result.RTF)
+		}
+		recognizer.Close()
+	}
+
+	// Parameter sets with failed files are ranked last
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if (a.Failed > 0) != (b.Failed > 0) {
+			return a.Failed == 0
+		}
+		if a.CER != b.CER {
+			return a.CER < b.CER
+		}
+		return a.RTF < b.RTF
+	})
+
+	fmt.Printf("\n=== Ranked Results ===\n")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tMETHOD\tBEAM\tBLANK\tTEMPO\tCER\tSUB\tINS\tDEL\tRTF\tFAILED")
+	for i, r := range results {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%.2f\t%.2f\t%.2f%%\t%d\t%d\t%d\t%.3f\t%d\n",
+			i+1, r.Method, beamLabel(r.Beam), r.BlankPenalty, r.Tempo, r.CER*100,
+			r.Chars.Substitutions, r.Chars.Insertions, r.Chars.Deletions, r.RTF, r.Failed)
+	}
+	tw.Flush()
+
+	if csvPath != "" {
+		return writeSweepCSV(csvPath, results)
+	}
+	return nil
+}
+
+func (p sweepPoint) String() string {
+	return fmt.Sprintf("%s beam=%s blank=%.2f tempo=%.2f", p.Method, beamLabel(p.Beam), p.BlankPenalty, p.Tempo)
+}
+
+func beamLabel(beam int) string {
+	if beam == 0 {
+		return "-"
+	}
+	return strconv.Itoa(beam)
+}
+
+// writeSweepCSV writes the ranked results
+func writeSweepCSV(path string, results []sweepResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"rank", "method", "beam", "blank_penalty", "tempo", "ref_chars", "char_sub", "char_ins", "char_del", "cer", "audio_sec", "processing_sec", "rtf", "failed"})
+	for i, r := range results {
+		w.Write([]string{
+			strconv.Itoa(i + 1), r.Method, strconv.Itoa(r.Beam),
+			strconv.FormatFloat(r.BlankPenalty, 'f', -1, 64), strconv.FormatFloat(r.Tempo, 'f', -1, 64),
+			strconv.Itoa(r.Chars.Reference), strconv.Itoa(r.Chars.Substitutions), strconv.Itoa(r.Chars.Insertions), strconv.Itoa(r.Chars.Deletions),
+			strconv.FormatFloat(r.CER, 'f', 4, 64),
+			strconv.FormatFloat(r.AudioSec, 'f', 2, 64), strconv.FormatFloat(r.ProcessingSec, 'f', 2, 64),
+			strconv.FormatFloat(r.RTF, 'f', 4, 64), strconv.Itoa(r.Failed),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// sweepItems returns the sweep test set: the dataset directory, or the input
+// file with the -reference transcript
+func sweepItems(dataset, inputPath, referencePath string) ([]eval.Item, error) {
+	if dataset != "" {
+		return eval.LoadDataset(dataset)
+	}
+	if referencePath == "" {
+		return nil, fmt.Errorf("sweep needs -dataset or -input with -reference")
+	}
+	data, err := os.ReadFile(referencePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reference: %w", err)
+	}
+	return []eval.Item{{
+		Name:          filepath.Base(inputPath),
+		AudioPath:     inputPath,
+		ReferencePath: referencePath,
+		Reference:     strings.TrimSpace(string(data)),
+	}}, nil
+}
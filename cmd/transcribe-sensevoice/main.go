@@ -17,6 +17,9 @@ import (
 	"os/exec"
 	"time"
 
+	"zbor/internal/asr"
+	"zbor/internal/experiment"
+
 	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
 )
 
@@ -28,6 +31,7 @@ func main() {
 	language := flag.String("lang", "ja", "Language: zh, en, ja, ko, yue, auto")
 	useInt8 := flag.Bool("int8", true, "Use int8 quantized model")
 	numThreads := flag.Int("threads", 4, "Number of threads")
+	providerName := flag.String("provider", "cpu", "Execution provider: cpu, cuda, coreml, directml")
 
 	// Chunk size
 	chunkSec := flag.Int("chunk", 20, "Chunk size in seconds (without VAD)")
@@ -36,12 +40,18 @@ func main() {
 	vadModel := flag.String("vad-model", "", "VAD model path (optional, empty = no VAD)")
 	vadThreshold := flag.Float64("vad-threshold", 0.5, "VAD speech threshold")
 
+	recordFlags := experiment.RegisterFlags()
 	flag.Parse()
 
 	if *inputPath == "" {
 		log.Fatal("Usage: go run ./cmd/transcribe-sensevoice -input <file>")
 	}
 
+	provider, err := asr.ParseProvider(*providerName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	modelFile := "model.onnx"
 	if *useInt8 {
 		modelFile = "model.int8.onnx"
@@ -51,6 +61,7 @@ func main() {
 	fmt.Printf("Model: %s/%s\n", *modelDir, modelFile)
 	fmt.Printf("Language: %s\n", *language)
 	fmt.Printf("NumThreads: %d\n", *numThreads)
+	fmt.Printf("Provider: %s\n", provider)
 	fmt.Println()
 
 	// Create ASR config for SenseVoice model
@@ -67,6 +78,7 @@ func main() {
 			},
 			Tokens:     *modelDir + "/tokens.txt",
 			NumThreads: *numThreads,
+			Provider:   provider,
 			Debug:      0,
 		},
 	}
@@ -104,6 +116,8 @@ func main() {
 		fmt.Println("VAD enabled")
 	}
 
+	run := recordFlags.Start("transcribe-sensevoice", *inputPath)
+
 	// Get duration
 	duration, _ := getAudioDuration(*inputPath)
 	fmt.Printf("Audio duration: %.1fs\n\n", duration)
@@ -159,6 +173,7 @@ func main() {
 				endSec := float64(segment.Start+len(segment.Samples)) / float64(sampleRate)
 
 				result := transcribeBytes(recognizer, segment.Samples)
+				run.Chunk(startSec, endSec, result)
 				if result != "" {
 					fmt.Printf("[%.2f-%.2f] %s\n", startSec, endSec, result)
 					allText += result
@@ -175,6 +190,7 @@ func main() {
 			segment := vad.Front()
 			vad.Pop()
 			result := transcribeBytes(recognizer, segment.Samples)
+			run.Chunk(float64(segment.Start)/float64(sampleRate), float64(segment.Start+len(segment.Samples))/float64(sampleRate), result)
 			if result != "" {
 				allText += result
 			}
@@ -201,6 +217,7 @@ func main() {
 			startSec := float64(chunkNum-1) * float64(*chunkSec)
 
 			result := transcribeBytes(recognizer, samples)
+			run.Chunk(startSec, startSec+float64(len(samples))/float64(sampleRate), result)
 			if result != "" {
 				fmt.Printf("[%.0f-%.0fs] %s\n", startSec, startSec+float64(*chunkSec), result)
 				allText += result
@@ -221,6 +238,10 @@ func main() {
 	fmt.Printf("Real-time factor: %.2fx\n", duration/elapsed)
 	fmt.Printf("Text length: %d chars\n", len(allText))
 	fmt.Printf("\n=== Full Transcript ===\n%s\n", allText)
+
+	if err := run.Finish(allText, duration, elapsed); err != nil {
+		log.Fatalf("Failed to record experiment: %v", err)
+	}
 }
 
 func transcribeBytes(recognizer *sherpa.OfflineRecognizer, samples []float32) string {
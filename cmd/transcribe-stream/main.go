@@ -57,7 +57,6 @@ func main() {
 		JoinerPath:  filepath.Join(*modelDir, "joiner-epoch-99-avg-1.onnx"),
 		TokensPath:  filepath.Join(*modelDir, "tokens.txt"),
 		SampleRate:  sampleRate,
-		NumThreads:  4,
 	}
 
 	recognizer, err := asr.NewRecognizer(config)
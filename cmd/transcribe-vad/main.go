@@ -9,18 +9,22 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"zbor/internal/asr"
+	"zbor/internal/experiment"
 )
 
 func main() {
 	var (
 		inputFile      = flag.String("i", "", "Input audio file")
 		outputFile     = flag.String("o", "", "Output file (default: stdout)")
-		format         = flag.String("format", "text", "Output format: text, json, srt")
+		format         = flag.String("format", "text", "Output format: text, json, srt, words")
+		wordsFmt       = flag.String("words-format", "json", "Encoding for -format words: json, csv")
 		modelDir       = flag.String("model", "models/sherpa-onnx-zipformer-ja-reazonspeech-2024-08-01", "Model directory path")
 		vadModelPath   = flag.String("vad", "models/silero_vad.onnx", "VAD model path")
 		vadThreshold   = flag.Float64("vad-threshold", 0.5, "VAD speech threshold (0-1, lower = more sensitive)")
@@ -29,10 +33,13 @@ func main() {
 		maxBlock       = flag.Float64("max-block", 5.0, "Max block duration before splitting (seconds, 0=no split)")
 		overlap        = flag.Float64("overlap", 0.5, "Overlap duration for overlap method (seconds)")
 		tempo          = flag.Float64("tempo", 0.95, "Audio tempo (0.5-1.0, lower = slower for fast speech)")
-		numThreads     = flag.Int("threads", 4, "Number of threads for inference")
+		numThreads     = flag.Int("threads", 0, "Number of threads for inference (0: auto)")
+		provider       = flag.String("provider", "cpu", "Execution provider: cpu, cuda, coreml, directml")
 		method         = flag.String("method", "vad-block", "Method: vad-block, vad-stream, chunk")
 		decodingMethod = flag.String("decoding", "greedy_search", "Decoding method: greedy_search or modified_beam_search")
 		maxActivePaths = flag.Int("max-paths", 4, "Max active paths for modified_beam_search")
+		enhance        = flag.Bool("enhance", false, "Denoise the audio with the speech enhancement model before VAD and ASR")
+		enhanceModel   = flag.String("enhance-model", asr.DefaultEnhanceModelPath, "Speech enhancement (GTCRN) model path for -enhance")
 		verbose        = flag.Bool("v", false, "Verbose output")
 	)
 
@@ -50,9 +57,12 @@ func main() {
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  %s -i audio.wav -method vad-block -tempo 0.9\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -i audio.wav -method chunk -tempo 0.95\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -i noisy.wav -method overlap -enhance\n", os.Args[0])
 	}
 
+	recordFlags := experiment.RegisterFlags()
 	flag.Parse()
+	ctx := context.Background()
 
 	if *inputFile == "" {
 		fmt.Fprintf(os.Stderr, "Error: Input file is required\n\n")
@@ -65,8 +75,12 @@ func main() {
 		os.Exit(1)
 	}
 
-	if *format != "text" && *format != "json" && *format != "srt" {
-		fmt.Fprintf(os.Stderr, "Error: Invalid format '%s'. Must be: text, json, or srt\n", *format)
+	if *format != "text" && *format != "json" && *format != "srt" && *format != "words" {
+		fmt.Fprintf(os.Stderr, "Error: Invalid format '%s'. Must be: text, json, srt, or words\n", *format)
+		os.Exit(1)
+	}
+	if *wordsFmt != "json" && *wordsFmt != "csv" {
+		fmt.Fprintf(os.Stderr, "Error: Invalid words format '%s'. Must be: json or csv\n", *wordsFmt)
 		os.Exit(1)
 	}
 
@@ -83,6 +97,7 @@ func main() {
 		os.Exit(1)
 	}
 	config.NumThreads = *numThreads
+	config.Provider = *provider
 	config.DecodingMethod = *decodingMethod
 	config.MaxActivePaths = *maxActivePaths
 
@@ -101,6 +116,33 @@ func main() {
 		}
 	}
 
+	run := recordFlags.Start("transcribe-vad", *inputFile)
+	startTime := time.Now()
+
+	// Speech enhancement: transcribe a denoised copy of the input
+	audioPath := *inputFile
+	if *enhance {
+		enhanceConfig := asr.DefaultEnhanceConfig(*enhanceModel)
+		enhanceConfig.NumThreads = *numThreads
+		enhanceConfig.Provider = *provider
+		enhancer, err := asr.NewEnhancer(enhanceConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to create enhancer: %v\n", err)
+			os.Exit(1)
+		}
+		var stats *asr.EnhanceStats
+		audioPath, stats, err = enhancer.EnhanceFileTemp(ctx, *inputFile)
+		enhancer.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Speech enhancement failed: %v\n", err)
+			os.Exit(1)
+		}
+		defer os.Remove(audioPath)
+		if *verbose {
+			fmt.Fprintf(os.Stderr, "Enhanced in %.2fs: RMS %.6f -> %.6f\n", stats.WallSeconds, stats.InputRMS, stats.OutputRMS)
+		}
+	}
+
 	var result *asr.Result
 
 	switch *method {
@@ -113,7 +155,7 @@ func main() {
 		if *verbose {
 			fmt.Fprintf(os.Stderr, "Using VAD+block method with tempo=%.2f, vad-threshold=%.2f, min-silence=%.2f, max-block=%.2f\n", *tempo, *vadThreshold, *minSilence, *maxBlock)
 		}
-		result, err = recognizer.TranscribeWithVADBlock(*inputFile, vadConfig, *tempo, progressCallback)
+		result, err = recognizer.TranscribeWithVADBlock(ctx, audioPath, vadConfig, *tempo, progressCallback)
 
 	case "vad-stream":
 		// Existing VAD streaming method (no tempo)
@@ -123,14 +165,14 @@ func main() {
 		if *verbose {
 			fmt.Fprintf(os.Stderr, "Using VAD streaming method (no tempo adjustment), vad-threshold=%.2f, min-silence=%.2f\n", *vadThreshold, *minSilence)
 		}
-		result, err = recognizer.TranscribeWithVAD(*inputFile, vadConfig, progressCallback)
+		result, err = recognizer.TranscribeWithVAD(ctx, audioPath, vadConfig, progressCallback)
 
 	case "chunk":
 		// Existing chunk-based method with tempo
 		if *verbose {
 			fmt.Fprintf(os.Stderr, "Using chunk method with tempo=%.2f\n", *tempo)
 		}
-		result, err = recognizer.TranscribeWithTempo(*inputFile, *tempo, 20, progressCallback)
+		result, err = recognizer.TranscribeWithTempo(ctx, audioPath, *tempo, 20, progressCallback)
 
 	case "silence":
 		// Energy-based silence detection (more sensitive than VAD)
@@ -142,7 +184,7 @@ func main() {
 			fmt.Fprintf(os.Stderr, "Using silence detection method with tempo=%.2f, threshold=%.6f, min-silence=%.2f, max-block=%.2f\n",
 				*tempo, silenceConfig.SilenceThreshold, *minSilence, *maxBlock)
 		}
-		result, err = recognizer.TranscribeWithSilenceDetection(*inputFile, silenceConfig, *tempo, progressCallback)
+		result, err = recognizer.TranscribeWithSilenceDetection(ctx, audioPath, silenceConfig, *tempo, progressCallback)
 
 	case "overlap":
 		// Silence detection with overlapping chunks
@@ -154,7 +196,7 @@ func main() {
 			fmt.Fprintf(os.Stderr, "Using overlap method with tempo=%.2f, threshold=%.6f, max-block=%.2f, overlap=%.2f\n",
 				*tempo, silenceConfig.SilenceThreshold, *maxBlock, *overlap)
 		}
-		result, err = recognizer.TranscribeWithOverlap(*inputFile, silenceConfig, *tempo, *overlap, progressCallback)
+		result, err = recognizer.TranscribeWithOverlap(ctx, audioPath, silenceConfig, *tempo, *overlap, progressCallback)
 
 	default:
 		fmt.Fprintf(os.Stderr, "Error: Unknown method '%s'\n", *method)
@@ -174,6 +216,24 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Tokens: %d, Segments: %d, Duration: %.2fs\n",
 			len(result.Tokens), len(result.Segments), result.TotalDuration)
 	}
+	for _, report := range result.ChunkReports {
+		if report.SkippedSeconds > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %.1fs of audio not transcribed (%d of %d chunks failed)\n",
+				report.SkippedSeconds, report.FailedChunks, report.Chunks)
+		}
+	}
+
+	if run != nil {
+		elapsed := time.Since(startTime).Seconds()
+		duration, _ := asr.GetAudioDuration(ctx, *inputFile)
+		for _, seg := range result.Segments {
+			run.Chunk(seg.StartTime, seg.EndTime, seg.Text)
+		}
+		if err := run.Finish(result.Text, duration, elapsed); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to record experiment: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	// Format output
 	var output string
@@ -186,6 +246,16 @@ func main() {
 		}
 	case "srt":
 		output = result.FormatAsSRT()
+	case "words":
+		if *wordsFmt == "csv" {
+			output, err = result.FormatAsWordsCSV()
+		} else {
+			output, err = result.FormatAsWordsJSON()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to format words: %v\n", err)
+			os.Exit(1)
+		}
 	default:
 		output = result.FormatAsText()
 	}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -13,9 +14,14 @@ func main() {
 	var (
 		inputFile  = flag.String("i", "", "Input audio file (WAV format)")
 		outputFile = flag.String("o", "", "Output file (default: stdout)")
-		format     = flag.String("format", "text", "Output format: text, json, srt")
+		format     = flag.String("format", "text", "Output format: text, json, srt, words")
+		wordsFmt   = flag.String("words-format", "json", "Encoding for -format words: json, csv")
 		modelDir   = flag.String("model", "models/sherpa-onnx-zipformer-ja-reazonspeech-2024-08-01", "Model directory path")
-		numThreads = flag.Int("threads", 2, "Number of threads for inference")
+		numThreads = flag.Int("threads", 0, "Number of threads for inference (0: auto)")
+		provider   = flag.String("provider", "cpu", "Execution provider: cpu, cuda, coreml, directml")
+		useITN     = flag.Bool("itn", false, "Apply inverse text normalization (Japanese numbers to digits)")
+		itnRules   = flag.String("itn-rules", "", "Additional ITN rule file (implies -itn)")
+		groupWords = flag.Bool("group-words", false, "Group subword tokens into words (adds \"words\" to json, used by -format words)")
 		verbose    = flag.Bool("v", false, "Verbose output")
 	)
 
@@ -28,9 +34,12 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s -i audio.wav -o output.txt\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -i audio.wav -format json -o output.json\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -i audio.wav -format srt -o subtitles.srt\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -i audio.wav -format words -words-format csv -o words.csv\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -i audio.wav -format words -group-words\n", os.Args[0])
 	}
 
 	flag.Parse()
+	ctx := context.Background()
 
 	// Validate input
 	if *inputFile == "" {
@@ -46,8 +55,12 @@ func main() {
 	}
 
 	// Validate format
-	if *format != "text" && *format != "json" && *format != "srt" {
-		fmt.Fprintf(os.Stderr, "Error: Invalid format '%s'. Must be: text, json, or srt\n", *format)
+	if *format != "text" && *format != "json" && *format != "srt" && *format != "words" {
+		fmt.Fprintf(os.Stderr, "Error: Invalid format '%s'. Must be: text, json, srt, or words\n", *format)
+		os.Exit(1)
+	}
+	if *wordsFmt != "json" && *wordsFmt != "csv" {
+		fmt.Fprintf(os.Stderr, "Error: Invalid words format '%s'. Must be: json or csv\n", *wordsFmt)
 		os.Exit(1)
 	}
 
@@ -65,6 +78,7 @@ func main() {
 		os.Exit(1)
 	}
 	config.NumThreads = *numThreads
+	config.Provider = *provider
 
 	if *verbose {
 		fmt.Fprintf(os.Stderr, "Creating recognizer...\n")
@@ -83,7 +97,7 @@ func main() {
 	}
 
 	// Transcribe
-	result, err := recognizer.TranscribeFile(*inputFile)
+	result, err := recognizer.TranscribeFile(ctx, *inputFile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Transcription failed: %v\n", err)
 		os.Exit(1)
@@ -93,6 +107,21 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Transcription completed in %.2f seconds\n", result.Duration)
 	}
 
+	// Inverse text normalization
+	if *useITN || *itnRules != "" {
+		itn, err := asr.LoadITN(*itnRules)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to load ITN rules: %v\n", err)
+			os.Exit(1)
+		}
+		itn.Apply(result)
+	}
+
+	// Word grouping (after ITN so that converted numbers stay one word)
+	if *groupWords {
+		result.GroupWords(asr.DefaultWordGroupingConfig())
+	}
+
 	// Format output
 	var output string
 	switch *format {
@@ -104,6 +133,16 @@ func main() {
 		}
 	case "srt":
 		output = result.FormatAsSRT()
+	case "words":
+		if *wordsFmt == "csv" {
+			output, err = result.FormatAsWordsCSV()
+		} else {
+			output, err = result.FormatAsWordsJSON()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to format words: %v\n", err)
+			os.Exit(1)
+		}
 	default: // text
 		output = result.FormatAsText()
 	}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"zbor/internal/webfetch"
@@ -20,7 +21,16 @@ func main() {
 		blockImages = flag.Bool("block-images", false, "Block images")
 		waitTime    = flag.Int("wait", 0, "Wait time in milliseconds")
 		selector    = flag.String("selector", "", "Wait for selector")
-		timeout     = flag.Int("timeout", 60, "Timeout in seconds")
+		extract     = flag.String("extract", "article", "Markdown extraction: article (main content only), full (whole page)")
+		keepImages  = flag.Bool("keep-images", true, "Keep images in Markdown")
+		keepLinks   = flag.Bool("keep-links", true, "Keep links in Markdown")
+		keepTables  = flag.Bool("keep-tables", true, "Keep tables in Markdown")
+		crawl       = flag.Bool("crawl", false, "Crawl links from the URL (same host only)")
+		depth       = flag.Int("depth", 1, "Crawl: link depth to follow")
+		maxPages    = flag.Int("max-pages", webfetch.DefaultCrawlMaxPages, "Crawl: maximum number of pages")
+		pathPrefix  = flag.String("path-prefix", "", "Crawl: only follow links under this path")
+		delay       = flag.Int("delay", 1000, "Crawl: delay between pages in milliseconds")
+		timeout     = flag.Int("timeout", 60, "Timeout in seconds (per page when crawling)")
 		verbose     = flag.Bool("v", false, "Verbose output")
 	)
 
@@ -33,6 +43,8 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s -url https://example.com -format html\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -url https://example.com -o output.md\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -url https://example.com -stealth -block-ads\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -url https://example.com -extract full -keep-images=false\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -url https://example.com/docs/ -crawl -depth 2 -path-prefix /docs/ -o docs\n", os.Args[0])
 	}
 
 	flag.Parse()
@@ -51,6 +63,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *crawl && *format == "html" {
+		fmt.Fprintf(os.Stderr, "Error: -crawl supports markdown or json output only\n")
+		os.Exit(1)
+	}
+
+	// Validate extraction mode
+	mode := webfetch.ExtractMode(*extract)
+	if mode != webfetch.ExtractModeArticle && mode != webfetch.ExtractModeFull {
+		fmt.Fprintf(os.Stderr, "Error: Invalid extract mode '%s'. Must be: article or full\n", *extract)
+		os.Exit(1)
+	}
+
 	if *verbose {
 		fmt.Fprintf(os.Stderr, "Fetching: %s\n", *url)
 	}
@@ -71,11 +95,35 @@ func main() {
 		BlockAds:    *blockAds,
 		BlockImages: *blockImages,
 		Selector:    *selector,
+		Extract: &webfetch.ExtractOptions{
+			Mode:       mode,
+			KeepImages: *keepImages,
+			KeepLinks:  *keepLinks,
+			KeepTables: *keepTables,
+		},
 	}
 	if *waitTime > 0 {
 		fetchOpts.WaitTime = time.Duration(*waitTime) * time.Millisecond
 	}
 
+	if *crawl {
+		crawlOpts := &webfetch.CrawlOptions{
+			MaxDepth:   *depth,
+			MaxPages:   *maxPages,
+			SameDomain: true,
+			PathPrefix: *pathPrefix,
+			Delay:      time.Duration(*delay) * time.Millisecond,
+			Fetch:      fetchOpts,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout**maxPages)*time.Second)
+		defer cancel()
+		if err := runCrawl(ctx, client, *url, crawlOpts, *format, *outputFile, *verbose); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Crawl failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout)*time.Second)
 	defer cancel()
@@ -124,3 +172,42 @@ func main() {
 		fmt.Println(output)
 	}
 }
+
+// runCrawl crawls from startURL and writes each page to stdout,
+// or to one file per page when outputDir is set
+func runCrawl(ctx context.Context, client *webfetch.Client, startURL string, opts *webfetch.CrawlOptions, format, outputDir string, verbose bool) error {
+	if outputDir != "" {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return err
+		}
+	}
+
+	count := 0
+	return client.Crawl(ctx, startURL, opts, func(page *webfetch.CrawlPage) error {
+		if page.Err != nil {
+			fmt.Fprintf(os.Stderr, "Skipped %s: %v\n", page.URL, page.Err)
+			return nil
+		}
+		count++
+		if verbose {
+			fmt.Fprintf(os.Stderr, "[%d] depth %d: %s (%.2fs)\n", count, page.Depth, page.Result.URL, page.Result.Duration.Seconds())
+		}
+
+		output := page.Result.FormatAsText()
+		ext := "md"
+		if format == "json" {
+			var err error
+			if output, err = page.Result.FormatAsJSON(); err != nil {
+				return err
+			}
+			ext = "json"
+		}
+
+		if outputDir == "" {
+			fmt.Printf("<!-- %s -->\n%s\n\n", page.Result.URL, output)
+			return nil
+		}
+		name := filepath.Join(outputDir, fmt.Sprintf("%03d.%s", count, ext))
+		return os.WriteFile(name, []byte(output), 0644)
+	})
+}
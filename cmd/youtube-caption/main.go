@@ -20,6 +20,7 @@ func main() {
 		audioFormat = flag.String("audio-format", "best", "Audio format: mp4, webm, best")
 		audioList   = flag.Bool("audio-list", false, "List available audio formats")
 		verbose     = flag.Bool("v", false, "Verbose output")
+		cacheTTL    = flag.Duration("cache-ttl", youtube.DefaultCacheTTL, "How long video info and captions are cached (0 disables)")
 	)
 
 	flag.Usage = func() {
@@ -46,6 +47,7 @@ func main() {
 
 	// Create client
 	client := youtube.NewClient()
+	client.SetCacheTTL(*cacheTTL)
 
 	if *verbose {
 		fmt.Fprintf(os.Stderr, "Fetching video: %s\n", *url)
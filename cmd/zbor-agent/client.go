@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"zbor/internal/handlers"
+	"zbor/internal/ingestion"
+)
+
+// APIError is a non-2xx response from the server
+type APIError struct {
+	Status  int
+	Message string
+	Missing []int // chunk indexes the server reported missing on complete
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("server returned %d: %s", e.Status, e.Message)
+}
+
+// isStatus reports whether err is an APIError with the given status
+func isStatus(err error, status int) bool {
+	apiErr, ok := err.(*APIError)
+	return ok && apiErr.Status == status
+}
+
+// Client talks to the zbor remote worker API
+type Client struct {
+	baseURL    string
+	token      string
+	workerID   string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the server at baseURL
+func NewClient(baseURL, token, workerID string) *Client {
+	return &Client{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		token:    token,
+		workerID: workerID,
+		// No overall timeout: audio downloads can be large; requests use contexts instead
+		httpClient: &http.Client{},
+	}
+}
+
+// Register registers the agent and returns the jobs it already holds
+func (c *Client) Register(ctx context.Context, req handlers.RegisterRequest) (*handlers.RegisterResponse, error) {
+	var resp handlers.RegisterResponse
+	if _, err := c.do(ctx, http.MethodPost, "/api/v1/worker/register", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Heartbeat refreshes the agent's registration and returns the jobs it still holds
+func (c *Client) Heartbeat(ctx context.Context) ([]string, error) {
+	var resp struct {
+		Held []string `json:"held"`
+	}
+	if _, err := c.do(ctx, http.MethodPost, "/api/v1/worker/heartbeat", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Held, nil
+}
+
+// Claim claims the next compatible job; it returns nil when there is none
+func (c *Client) Claim(ctx context.Context) (*handlers.ClaimResponse, error) {
+	var resp handlers.ClaimResponse
+	status, err := c.do(ctx, http.MethodPost, "/api/v1/worker/jobs/claim", nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNoContent {
+		return nil, nil
+	}
+	return &resp, nil
+}
+
+// Job fetches a job the agent already holds
+func (c *Client) Job(ctx context.Context, jobID string) (*handlers.ClaimResponse, error) {
+	var resp handlers.ClaimResponse
+	if _, err := c.do(ctx, http.MethodGet, "/api/v1/worker/jobs/"+jobID, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Download fetches an audio file of a job to dest
+// A partial download left at dest+".part" is resumed with a Range request
+func (c *Client) Download(ctx context.Context, jobID string, file int, dest string) error {
+	partPath := dest + ".part"
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v1/worker/jobs/%s/audio/%d", jobID, file), nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The previous attempt already received the whole file
+		return os.Rename(partPath, dest)
+	default:
+		return readError(resp)
+	}
+
+	f, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return fmt.Errorf("download interrupted: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(partPath, dest)
+}
+
+// UploadChunk uploads one result chunk
+func (c *Client) UploadChunk(ctx context.Context, jobID string, index int, chunk ingestion.RemoteChunk) error {
+	_, err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/v1/worker/jobs/%s/chunks/%d", jobID, index), chunk, nil)
+	return err
+}
+
+// Progress reports job progress (also extends the job's lease)
+func (c *Client) Progress(ctx context.Context, jobID string, progress int, step string) error {
+	req := handlers.ProgressRequest{Progress: progress, Step: step}
+	_, err := c.do(ctx, http.MethodPost, "/api/v1/worker/jobs/"+jobID+"/progress", req, nil)
+	return err
+}
+
+// Complete asks the server to assemble the uploaded chunks
+func (c *Client) Complete(ctx context.Context, jobID string, chunks int) error {
+	req := handlers.CompleteRequest{Chunks: chunks}
+	_, err := c.do(ctx, http.MethodPost, "/api/v1/worker/jobs/"+jobID+"/complete", req, nil)
+	return err
+}
+
+// Fail reports that the job failed on this agent
+func (c *Client) Fail(ctx context.Context, jobID string, jobErr error) error {
+	req := handlers.FailRequest{Error: jobErr.Error()}
+	_, err := c.do(ctx, http.MethodPost, "/api/v1/worker/jobs/"+jobID+"/fail", req, nil)
+	return err
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set(handlers.WorkerIDHeader, c.workerID)
+	return req, nil
+}
+
+// do sends a JSON request and decodes the JSON response into out (if non-nil)
+func (c *Client) do(ctx context.Context, method, path string, in interface{}, out interface{}) (int, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return 0, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, readError(resp)
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("invalid response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// readError converts an error response into an APIError
+func readError(resp *http.Response) error {
+	var body struct {
+		Error   string `json:"error"`
+		Missing []int  `json:"missing"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err := json.Unmarshal(data, &body); err != nil || body.Error == "" {
+		body.Error = strings.TrimSpace(string(data))
+	}
+	return &APIError{Status: resp.StatusCode, Message: body.Error, Missing: body.Missing}
+}
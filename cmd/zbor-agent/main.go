@@ -0,0 +1,464 @@
+// zbor-agent is a remote transcription worker for a zbor server running in
+// distributed mode (ZBOR_REMOTE_WORKERS=1). It registers with the server,
+// advertises its installed models and capacity, sends heartbeats, claims
+// compatible jobs and uploads the results.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"zbor/internal/asr"
+	"zbor/internal/handlers"
+	"zbor/internal/ingestion"
+	"zbor/internal/logging"
+	"zbor/internal/storage"
+)
+
+// version is reported to the server on registration
+const version = "0.1.0"
+
+// Agent runs claimed jobs on this machine
+type Agent struct {
+	client       *Client
+	register     handlers.RegisterRequest
+	asrConfig    *asr.Config
+	svConfig     *asr.SenseVoiceConfig
+	whConfig     *asr.WhisperConfig
+	threadTuner  *asr.ThreadTuner
+	workDir      string
+	pollInterval time.Duration
+
+	mu      sync.Mutex
+	running map[string]*runningJob // by job ID
+	slots   chan struct{}
+}
+
+// runningJob is a job being processed by this agent
+type runningJob struct {
+	cancel  context.CancelFunc
+	started time.Time
+}
+
+func main() {
+	hostname, _ := os.Hostname()
+
+	var (
+		serverURL    = flag.String("server", envOr("ZBOR_SERVER", "http://localhost:8080"), "zbor server URL (env ZBOR_SERVER)")
+		token        = flag.String("token", os.Getenv("ZBOR_WORKER_TOKEN"), "Worker token (env ZBOR_WORKER_TOKEN)")
+		workerID     = flag.String("id", hostname, "Worker ID (must be unique and stable across restarts)")
+		name         = flag.String("name", hostname, "Display name")
+		capacity     = flag.Int("capacity", 1, "Number of jobs to run at once")
+		modelDir     = flag.String("model", "models/sherpa-onnx-zipformer-ja-reazonspeech-2024-08-01", "ReazonSpeech model directory")
+		senseVoice   = flag.String("sensevoice", "models/sherpa-onnx-sense-voice-zh-en-ja-ko-yue-2024-07-17", "SenseVoice model directory")
+		beamPaths    = flag.Int("sensevoice-beam-paths", 0, "Hypotheses per chunk for sensevoice:beam (0: default 4)")
+		whisper      = flag.String("whisper", ingestion.WhisperModelDir, "Whisper model directory")
+		lmPath       = flag.String("lm", os.Getenv("ZBOR_LM_PATH"), "Character n-gram language model (ARPA) for reazonspeech:lm and sensevoice:lm (env ZBOR_LM_PATH)")
+		lmScale      = flag.Float64("lm-scale", asr.DefaultLMScale, "Weight of the language model score")
+		lmPaths      = flag.Int("lm-paths", 0, "Hypotheses per block for reazonspeech:lm (0: default 4)")
+		vadModel     = flag.String("vad", "models/silero_vad.onnx", "Silero VAD model path")
+		numThreads   = flag.Int("threads", 0, "Number of threads for inference per job (0: auto, benchmarked on first use and shared between concurrent jobs)")
+		benchmark    = flag.Bool("benchmark", true, "Benchmark thread counts on first use of each model (with -threads 0)")
+		providerName = flag.String("provider", envOr("ZBOR_ASR_PROVIDER", "cpu"), "Execution provider: cpu, cuda, coreml, directml (env ZBOR_ASR_PROVIDER)")
+		decoder      = flag.String("decoder", envOr("ZBOR_AUDIO_DECODER", asr.DecoderAuto), "Audio decoder: auto (native WAV/MP3/FLAC/Ogg Vorbis, ffmpeg for the rest), native, ffmpeg (env ZBOR_AUDIO_DECODER)")
+		workDir      = flag.String("work-dir", filepath.Join(os.TempDir(), "zbor-agent"), "Directory for downloaded audio")
+		pollInterval = flag.Duration("poll", 10*time.Second, "How often to ask for work when idle")
+		logLevel     = flag.String("log-level", os.Getenv("LOG_LEVEL"), "Log level: debug, info, warn, error (env LOG_LEVEL)")
+		logFormat    = flag.String("log-format", os.Getenv("LOG_FORMAT"), "Log format: text, json (env LOG_FORMAT)")
+	)
+	flag.Parse()
+
+	if err := logging.Setup(os.Stderr, *logLevel, *logFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *token == "" {
+		fmt.Fprintf(os.Stderr, "Error: -token (or ZBOR_WORKER_TOKEN) is required\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *workerID == "" {
+		fmt.Fprintf(os.Stderr, "Error: -id is required\n")
+		os.Exit(1)
+	}
+	if *capacity < 1 {
+		*capacity = 1
+	}
+	provider, err := asr.ParseProvider(*providerName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := asr.SetAudioDecoder(*decoder); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	var rescorer *asr.Rescorer
+	if *lmPath != "" {
+		lm, err := asr.LoadNgramLM(*lmPath)
+		if err != nil {
+			log.Fatalf("Failed to load language model: %v", err)
+		}
+		rescorer = &asr.Rescorer{LM: lm, Scale: *lmScale}
+	}
+	if err := os.MkdirAll(*workDir, 0755); err != nil {
+		log.Fatalf("Failed to create work directory: %v", err)
+	}
+
+	agent := &Agent{
+		client:       NewClient(*serverURL, *token, *workerID),
+		workDir:      *workDir,
+		pollInterval: *pollInterval,
+		running:      make(map[string]*runningJob),
+		slots:        make(chan struct{}, *capacity),
+		threadTuner:  asr.NewThreadTuner(filepath.Join(*workDir, "asr-threads.json"), *benchmark),
+	}
+
+	// Detect installed models (only models whose files are all present are registered)
+	var models []string
+	vadPath := *vadModel
+	if _, err := os.Stat(vadPath); err != nil {
+		vadPath = ""
+	}
+	asrConfig := &asr.Config{
+		EncoderPath:  filepath.Join(*modelDir, "encoder-epoch-99-avg-1.onnx"),
+		DecoderPath:  filepath.Join(*modelDir, "decoder-epoch-99-avg-1.onnx"),
+		JoinerPath:   filepath.Join(*modelDir, "joiner-epoch-99-avg-1.onnx"),
+		TokensPath:   filepath.Join(*modelDir, "tokens.txt"),
+		VADModelPath: vadPath,
+		SampleRate:   16000,
+		NumThreads:   *numThreads,
+		Provider:     provider,
+		Rescorer:     rescorer,
+		RescorePaths: *lmPaths,
+	}
+	if err := asrConfig.Validate(); err != nil {
+		slog.Warn("ReazonSpeech model not available", "error", err)
+	} else {
+		agent.asrConfig = asrConfig
+		models = append(models, storage.ASRModelReazonSpeech)
+		// Rescoring works on the blocks of the silence-based transcription
+		if rescorer != nil {
+			models = append(models, storage.ASRModelReazonSpeechLM)
+		}
+	}
+	svConfig := asr.DefaultSenseVoiceConfig(*senseVoice)
+	svConfig.NumThreads = *numThreads
+	svConfig.Provider = provider
+	if *beamPaths > 0 {
+		svConfig.MaxActivePaths = *beamPaths
+	}
+	svConfig.Rescorer = rescorer
+	if err := svConfig.Validate(); err != nil {
+		slog.Warn("SenseVoice model not available", "error", err)
+	} else {
+		agent.svConfig = svConfig
+		models = append(models, storage.ASRModelSenseVoice, storage.ASRModelSenseVoiceBeam)
+		if rescorer != nil {
+			models = append(models, storage.ASRModelSenseVoiceLM)
+		}
+	}
+	whConfig := asr.DefaultWhisperConfig(*whisper)
+	whConfig.NumThreads = *numThreads
+	whConfig.Provider = provider
+	if err := whConfig.Validate(); err != nil {
+		slog.Warn("Whisper model not available", "error", err)
+	} else {
+		agent.whConfig = whConfig
+		models = append(models, storage.ASRModelWhisper)
+		// whisper:align takes its timestamps from ReazonSpeech
+		if agent.asrConfig != nil {
+			models = append(models, storage.ASRModelWhisperAlign)
+		}
+	}
+	if len(models) == 0 {
+		log.Fatalf("No models found (checked %s, %s and %s)", *modelDir, *senseVoice, *whisper)
+	}
+
+	agent.register = handlers.RegisterRequest{
+		Name:     *name,
+		Models:   models,
+		Capacity: *capacity,
+		Version:  version,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		slog.Info("Shutting down (unfinished jobs are resumed on the next start)...")
+		cancel()
+	}()
+
+	slog.Info("zbor-agent started", "version", version, "worker", *workerID, "models", models, "capacity", *capacity)
+	agent.Run(ctx)
+}
+
+// Run registers, resumes held jobs and claims new ones until ctx is cancelled
+func (a *Agent) Run(ctx context.Context) {
+	reg := a.registerUntilOK(ctx)
+	if reg == nil {
+		return
+	}
+
+	heartbeat := time.Duration(reg.HeartbeatInterval) * time.Second
+	if heartbeat <= 0 {
+		heartbeat = 30 * time.Second
+	}
+	go a.heartbeatLoop(ctx, heartbeat)
+
+	var wg sync.WaitGroup
+
+	// Jobs held from a previous run keep their claim; finish them first
+	for _, jobID := range reg.Held {
+		claim, err := a.client.Job(ctx, jobID)
+		if err != nil {
+			slog.Error("Failed to resume job", "job_id", jobID, "error", err)
+			continue
+		}
+		a.slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-a.slots }()
+			a.runJob(ctx, claim, true)
+		}()
+	}
+
+	for ctx.Err() == nil {
+		select {
+		case a.slots <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+
+		claim, err := a.client.Claim(ctx)
+		if err != nil || claim == nil {
+			<-a.slots
+			if err != nil && ctx.Err() == nil {
+				slog.Warn("Claim failed", "error", err)
+				if isStatus(err, http.StatusNotFound) {
+					a.registerUntilOK(ctx)
+				}
+			}
+			sleep(ctx, a.pollInterval)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-a.slots }()
+			a.runJob(ctx, claim, false)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// registerUntilOK registers with the server, retrying with backoff
+func (a *Agent) registerUntilOK(ctx context.Context) *handlers.RegisterResponse {
+	backoff := time.Second
+	for {
+		reg, err := a.client.Register(ctx, a.register)
+		if err == nil {
+			slog.Info("Registered with server", "lease_seconds", reg.LeaseTimeout, "held_jobs", len(reg.Held))
+			return reg
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		slog.Warn("Registration failed", "error", err, "retry_in", backoff)
+		sleep(ctx, backoff+time.Duration(rand.Int63n(int64(backoff/2)+1)))
+		if backoff < time.Minute {
+			backoff *= 2
+		}
+	}
+}
+
+// heartbeatLoop keeps the registration and job leases alive
+// Jobs the server no longer lists as held (e.g. requeued after a network
+// partition) are cancelled so two workers don't process the same job
+func (a *Agent) heartbeatLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		sent := time.Now()
+		held, err := a.client.Heartbeat(ctx)
+		if isStatus(err, http.StatusNotFound) {
+			slog.Warn("Server does not know this worker; registering again")
+			if a.registerUntilOK(ctx) == nil {
+				return
+			}
+			continue
+		}
+		if err != nil {
+			slog.Warn("Heartbeat failed", "error", err)
+			continue
+		}
+
+		heldSet := make(map[string]bool, len(held))
+		for _, id := range held {
+			heldSet[id] = true
+		}
+		a.mu.Lock()
+		for id, rj := range a.running {
+			// Jobs claimed after the heartbeat was sent may not be in the list yet
+			if !heldSet[id] && rj.started.Before(sent) {
+				slog.Warn("Job is no longer assigned to this worker; stopping it", "job_id", id)
+				rj.cancel()
+			}
+		}
+		a.mu.Unlock()
+	}
+}
+
+// runJob transcribes each file of a claimed job and uploads one chunk per file
+// (chunk index = file index). Files the server already has are skipped, so a
+// resumed job continues where it stopped
+func (a *Agent) runJob(ctx context.Context, claim *handlers.ClaimResponse, resumed bool) {
+	job, task := claim.Job, claim.Task
+	// Everything logged while running the job carries its ID and model
+	logger := slog.With("job_id", job.ID, "model", task.Model)
+	jobCtx, cancel := context.WithCancel(logging.WithLogger(ctx, logger))
+	defer cancel()
+
+	a.mu.Lock()
+	a.running[job.ID] = &runningJob{cancel: cancel, started: time.Now()}
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		delete(a.running, job.ID)
+		a.mu.Unlock()
+	}()
+
+	if resumed {
+		logger.Info("Resuming job", "files", len(task.Files), "uploaded", len(claim.Received))
+	} else {
+		logger.Info("Claimed job", "files", len(task.Files))
+	}
+
+	err := a.transcribe(jobCtx, claim)
+	if err == nil {
+		err = a.client.Complete(jobCtx, job.ID, len(task.Files))
+	}
+
+	switch {
+	case err == nil:
+		logger.Info("Job completed")
+	case ctx.Err() != nil:
+		// Shutting down: keep the claim so the job resumes after restart
+		logger.Info("Job interrupted")
+	case jobCtx.Err() != nil:
+		// The server reassigned the job; nothing to report
+	case isStatus(err, http.StatusConflict):
+		logger.Warn("Job was taken away from this worker", "error", err)
+	default:
+		logger.Warn("Job failed", "error", err)
+		if failErr := a.client.Fail(ctx, job.ID, err); failErr != nil {
+			logger.Error("Failed to report job failure", "error", failErr)
+		}
+	}
+}
+
+// transcribe downloads, transcribes and uploads the files of a job that are not yet on the server
+func (a *Agent) transcribe(ctx context.Context, claim *handlers.ClaimResponse) error {
+	job, task := claim.Job, claim.Task
+	if len(task.Files) == 0 {
+		return errors.New("job has no files")
+	}
+	received := make(map[int64]bool, len(claim.Received))
+	for _, idx := range claim.Received {
+		received[idx] = true
+	}
+	// Per-job settings given at ingestion
+	opts, err := ingestion.JobTranscriptionOptions(job)
+	if err != nil {
+		return err
+	}
+
+	// Progress 10-99 is spread over the files; reports are throttled
+	var lastReport time.Time
+	report := func(progress int, step string) {
+		if time.Since(lastReport) < 5*time.Second {
+			return
+		}
+		lastReport = time.Now()
+		if err := a.client.Progress(ctx, job.ID, min(max(progress, 0), 99), step); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Warn("Progress report failed", "error", err)
+		}
+	}
+
+	fileCount := len(task.Files)
+	for _, file := range task.Files {
+		if received[int64(file.Index)] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		start := 10 + 89*file.Index/fileCount
+		end := 10 + 89*(file.Index+1)/fileCount
+		report(start, "downloading")
+
+		path := filepath.Join(a.workDir, fmt.Sprintf("%s_%d_%s", job.ID, file.Index, filepath.Base(file.Name)))
+		if err := a.client.Download(ctx, job.ID, file.Index, path); err != nil {
+			return fmt.Errorf("failed to download %s: %w", file.Name, err)
+		}
+
+		// TranscribeFiles reports 30-90 for a single file; map it onto this file's range
+		results, err := ingestion.TranscribeFiles(ctx, a.asrConfig, a.svConfig, a.whConfig, a.threadTuner, job.Type, opts,
+			[]string{path}, []string{file.Speaker}, nil,
+			func(progress int, step string) {
+				report(start+(progress-30)*(end-start)/60, step)
+			})
+		os.Remove(path)
+		if err != nil {
+			return err
+		}
+
+		if err := a.client.UploadChunk(ctx, job.ID, file.Index, ingestion.NewRemoteChunk(file.Index, results[0])); err != nil {
+			return fmt.Errorf("failed to upload result of %s: %w", file.Name, err)
+		}
+		lastReport = time.Time{}
+		report(end, "uploading")
+	}
+	return nil
+}
+
+// sleep waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+
+	"zbor/internal/asr"
+	"zbor/internal/eval"
+	"zbor/internal/ingestion"
+	"zbor/internal/storage"
+)
+
+// evalModels are the models zbor eval can run (storage.ASRModel*)
+var evalModels = []string{
+	storage.ASRModelReazonSpeech,
+	storage.ASRModelReazonSpeechLM,
+	storage.ASRModelSenseVoice,
+	storage.ASRModelSenseVoiceBeam,
+	storage.ASRModelSenseVoiceLM,
+	storage.ASRModelWhisper,
+	storage.ASRModelWhisperAlign,
+}
+
+// runEval transcribes every audio file of a dataset directory that has a
+// reference transcript (talk.mp3 + talk.txt) with the production pipeline of
+// the selected model and reports CER/WER and RTF per file and in total
+func runEval(args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	var (
+		dir            = fs.String("dir", "", "Dataset directory: audio files with a reference .txt of the same name")
+		model          = fs.String("model", storage.ASRModelReazonSpeech, "Model: reazonspeech, reazonspeech:lm, sensevoice, sensevoice:beam, sensevoice:lm, whisper, whisper:align")
+		format         = fs.String("format", "json", "Output format: json, csv")
+		outputFile     = fs.String("o", "", "Output file (default: stdout)")
+		modelDir       = fs.String("reazonspeech-model", "models/sherpa-onnx-zipformer-ja-reazonspeech-2024-08-01", "ReazonSpeech model directory")
+		senseVoiceDir  = fs.String("sensevoice-model", "models/sherpa-onnx-sense-voice-zh-en-ja-ko-yue-2024-07-17", "SenseVoice model directory")
+		whisperDir     = fs.String("whisper-model", ingestion.WhisperModelDir, "Whisper model directory")
+		language       = fs.String("lang", "ja", "Language of SenseVoice and Whisper")
+		decodingMethod = fs.String("method", "greedy_search", "ReazonSpeech decoding method: greedy_search or modified_beam_search")
+		maxActivePaths = fs.Int("beam", 4, "Max active paths for beam search (ReazonSpeech; hypotheses per chunk for sensevoice:beam)")
+		blankPenalty   = fs.Float64("blank-penalty", 0.0, "ReazonSpeech penalty for blank tokens (try 1.0-2.0 for fast speech)")
+		lmPath         = fs.String("lm", os.Getenv("ZBOR_LM_PATH"), "Character n-gram language model (ARPA) for the :lm models (env ZBOR_LM_PATH)")
+		lmScale        = fs.Float64("lm-scale", asr.DefaultLMScale, "Weight of the language model score")
+		numThreads     = fs.Int("threads", 0, "Number of threads for inference (0: auto)")
+		providerName   = fs.String("provider", "cpu", "Execution provider: cpu, cuda, coreml, directml")
+		quiet          = fs.Bool("q", false, "Do not print per-file progress to stderr")
+	)
+	fs.Parse(args)
+
+	if *dir == "" {
+		return fmt.Errorf("-dir is required")
+	}
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("invalid format '%s' (json or csv)", *format)
+	}
+	known := false
+	for _, m := range evalModels {
+		known = known || m == *model
+	}
+	if !known {
+		return fmt.Errorf("unknown model '%s'", *model)
+	}
+	provider, err := asr.ParseProvider(*providerName)
+	if err != nil {
+		return err
+	}
+	items, err := eval.LoadDataset(*dir)
+	if err != nil {
+		return err
+	}
+
+	var rescorer *asr.Rescorer
+	if *lmPath != "" {
+		lm, err := asr.LoadNgramLM(*lmPath)
+		if err != nil {
+			return fmt.Errorf("failed to load language model: %w", err)
+		}
+		rescorer = &asr.Rescorer{LM: lm, Scale: *lmScale}
+	}
+	asrConfig, err := asr.NewConfig(*modelDir)
+	if err != nil && (*model == storage.ASRModelReazonSpeech || *model == storage.ASRModelReazonSpeechLM || *model == storage.ASRModelWhisperAlign) {
+		return fmt.Errorf("failed to load model config: %w", err)
+	}
+	if asrConfig != nil {
+		asrConfig.NumThreads = *numThreads
+		asrConfig.Provider = provider
+		asrConfig.DecodingMethod = *decodingMethod
+		asrConfig.MaxActivePaths = *maxActivePaths
+		asrConfig.BlankPenalty = float32(*blankPenalty)
+		asrConfig.Rescorer = rescorer
+	}
+	svConfig := asr.DefaultSenseVoiceConfig(*senseVoiceDir)
+	svConfig.Language = *language
+	svConfig.NumThreads = *numThreads
+	svConfig.Provider = provider
+	svConfig.MaxActivePaths = *maxActivePaths
+	svConfig.Rescorer = rescorer
+	whConfig := asr.DefaultWhisperConfig(*whisperDir)
+	whConfig.Language = *language
+	whConfig.NumThreads = *numThreads
+	whConfig.Provider = provider
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	jobType := ingestion.TranscriptionJobType(*model)
+	transcribe := func(ctx context.Context, audioPath string) (*asr.Result, error) {
+		results, err := ingestion.TranscribeFiles(ctx, asrConfig, svConfig, whConfig, nil, jobType, nil, []string{audioPath}, []string{""}, nil, func(int, string) {})
+		if err != nil {
+			return nil, err
+		}
+		ingestion.PostProcess(*model, results[0])
+		return results[0], nil
+	}
+	onFile := func(f *eval.FileResult) {
+		if *quiet {
+			return
+		}
+		if f.Error != "" {
+			fmt.Fprintf(os.Stderr, "%s: failed: %s\n", f.File, f.Error)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%s: CER %.2f%% WER %.2f%% RTF %.3f\n", f.File, f.CER*100, f.WER*100, f.RTF)
+	}
+
+	report := eval.Run(ctx, items, transcribe, onFile)
+	report.Model = *model
+	report.Dataset = filepath.Base(filepath.Clean(*dir))
+	report.Params = map[string]string{
+		"lang":          *language,
+		"method":        *decodingMethod,
+		"beam":          strconv.Itoa(*maxActivePaths),
+		"blank-penalty": strconv.FormatFloat(*blankPenalty, 'f', -1, 64),
+		"threads":       strconv.Itoa(*numThreads),
+		"provider":      provider,
+	}
+	if rescorer != nil {
+		report.Params["lm-scale"] = strconv.FormatFloat(*lmScale, 'f', -1, 64)
+	}
+	if !*quiet {
+		t := report.Total
+		fmt.Fprintf(os.Stderr, "Total: %d files (%d failed), CER %.2f%% WER %.2f%% RTF %.3f\n", t.Files, t.Failed, t.CER*100, t.WER*100, t.RTF)
+	}
+
+	var w io.Writer = os.Stdout
+	if *outputFile != "" {
+		f, err := os.Create(*outputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "csv" {
+		return report.WriteCSV(w)
+	}
+	return report.WriteJSON(w)
+}
@@ -0,0 +1,44 @@
+// zbor is the command line tool for offline tasks on this machine.
+//
+// Usage:
+//
+//	zbor eval -dir dataset/ [-model reazonspeech] [-format json|csv]
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// commands are the subcommands of zbor
+var commands = map[string]struct {
+	run   func(args []string) error
+	short string
+}{
+	"eval": {runEval, "Score a model against reference transcripts (CER/WER, RTF)"},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: Unknown command '%s'\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: zbor <command> [options]\n\nCommands:\n")
+	for name, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, cmd.short)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'zbor <command> -h' for the options of a command\n")
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+)
+
+// runArticles lists, searches and shows articles
+func runArticles(ctx context.Context, args []string) error {
+	return runSubcommand(ctx, "articles", []subcommand{
+		{"list", runArticlesList, "List articles, newest first"},
+		{"search", runArticlesSearch, "Full-text search over the articles"},
+		{"show", runArticlesShow, "Print an article"},
+	}, args)
+}
+
+func runArticlesList(ctx context.Context, args []string) error {
+	fs, client := newFlagSet("articles list")
+	var (
+		status     = fs.String("status", "", "Only articles with this status: draft, published")
+		sourceType = fs.String("source-type", "", "Only articles from this kind of source: audio, youtube, web, podcast, article, ...")
+		limit      = fs.Int("limit", 20, "Number of articles")
+		offset     = fs.Int("offset", 0, "Number of articles to skip")
+		asJSON     = fs.Bool("json", false, "Print the response as JSON")
+	)
+	if _, err := parseArgs(fs, args, 0, "articles list [options]"); err != nil {
+		return err
+	}
+
+	page, err := client().Articles(ctx, *status, *sourceType, *limit, *offset)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(page)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCREATED\tTYPE\tSOURCE\tTITLE")
+	for _, article := range page.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", article.ID, article.CreatedAt.Local().Format("2006-01-02 15:04"),
+			deref(article.SourceType), deref(article.SourceID), article.Title)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if page.NextOffset != nil {
+		fmt.Fprintf(os.Stderr, "%d of %d articles (next: -offset %d)\n", len(page.Items), page.Total, *page.NextOffset)
+	}
+	return nil
+}
+
+func runArticlesSearch(ctx context.Context, args []string) error {
+	fs, client := newFlagSet("articles search")
+	var (
+		limit  = fs.Int("limit", 20, "Number of results")
+		asJSON = fs.Bool("json", false, "Print the response as JSON")
+	)
+	words, err := parseArgs(fs, args, -1, "articles search [options] <query>")
+	if err != nil {
+		return err
+	}
+
+	hits, err := client().SearchArticles(ctx, strings.Join(words, " "), *limit)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(hits)
+	}
+	for _, hit := range hits {
+		fmt.Printf("%s  %s\n", hit.ID, plainText(hit.TitleHighlight))
+		if snippet := plainText(hit.Snippet); snippet != "" {
+			fmt.Printf("    %s\n", snippet)
+		}
+	}
+	if len(hits) == 0 {
+		fmt.Fprintln(os.Stderr, "No articles found")
+	}
+	return nil
+}
+
+func runArticlesShow(ctx context.Context, args []string) error {
+	fs, client := newFlagSet("articles show")
+	asJSON := fs.Bool("json", false, "Print the response as JSON")
+	ids, err := parseArgs(fs, args, 1, "articles show [options] <article-id>")
+	if err != nil {
+		return err
+	}
+
+	article, err := client().Article(ctx, ids[0])
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(article)
+	}
+	fmt.Printf("# %s\n\n", article.Title)
+	if article.Summary != nil && *article.Summary != "" {
+		fmt.Printf("%s\n\n", *article.Summary)
+	}
+	fmt.Println(article.Content)
+	return nil
+}
+
+// markTag matches the <mark> tags around the matches of a search
+var markTag = regexp.MustCompile(`</?mark>`)
+
+// plainText converts a highlighted search result (HTML-escaped, matches in
+// <mark>) to text with the matches in brackets
+func plainText(s string) string {
+	s = markTag.ReplaceAllStringFunc(s, func(tag string) string {
+		if tag == "<mark>" {
+			return "["
+		}
+		return "]"
+	})
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
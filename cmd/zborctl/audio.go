@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"zbor/internal/asr"
+)
+
+func runUpload(ctx context.Context, args []string) error {
+	fs, client := newFlagSet("upload")
+	var (
+		title    = fs.String("title", "", "Title of the source (default: the file name)")
+		model    = fs.String("model", "", "Model: reazonspeech, reazonspeech:lm, sensevoice, sensevoice:beam, sensevoice:lm, whisper, whisper:align (default: the server's)")
+		enhance  = fs.Bool("enhance", false, "Denoise the audio before transcription")
+		twoPass  = fs.Bool("two-pass", false, "Transcribe with ReazonSpeech, then refine doubtful segments with Whisper")
+		force    = fs.Bool("force", false, "Transcribe even if the same audio was already uploaded")
+		wait     = fs.Bool("wait", false, "Wait for the transcription and print its progress")
+		interval = fs.Duration("interval", 2*time.Second, "How often to poll the job with -wait")
+	)
+	paths, err := parseArgs(fs, args, -1, "upload [options] <file>... (several files are transcribed as one source, one speaker per file)")
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+	}
+
+	c := client()
+	result, err := c.Upload(ctx, paths, UploadOptions{
+		Title:   *title,
+		Model:   *model,
+		Enhance: *enhance,
+		TwoPass: *twoPass,
+		Force:   *force,
+	})
+	if err != nil {
+		return err
+	}
+	if result.Duplicate {
+		fmt.Fprintf(os.Stderr, "%s\n", result.Message)
+	}
+	fmt.Printf("source_id: %s\njob_id: %s\n", result.SourceID, result.JobID)
+	if result.RefineJobID != "" {
+		fmt.Printf("refine_job_id: %s\n", result.RefineJobID)
+	}
+	if !*wait || result.JobID == "" {
+		return nil
+	}
+	return waitTranscription(ctx, c, result.SourceID, result.JobID, *interval)
+}
+
+func runTranscript(ctx context.Context, args []string) error {
+	fs, client := newFlagSet("transcript")
+	var (
+		format = fs.String("format", "srt", "Format: "+strings.Join(asr.ExportFormats, ", "))
+		output = fs.String("o", "", "Output file (default: stdout)")
+	)
+	ids, err := parseArgs(fs, args, 1, "transcript [options] <source-id>")
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return client().ExportTranscript(ctx, ids[0], *format, os.Stdout)
+	}
+	// Write to a temp file so a failed download doesn't leave a partial transcript
+	partPath := *output + ".part"
+	f, err := os.Create(partPath)
+	if err != nil {
+		return err
+	}
+	if err := client().ExportTranscript(ctx, ids[0], *format, f); err != nil {
+		f.Close()
+		os.Remove(partPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(partPath)
+		return err
+	}
+	return os.Rename(partPath, *output)
+}
+
+func runRetranscribe(ctx context.Context, args []string) error {
+	fs, client := newFlagSet("retranscribe")
+	var (
+		model    = fs.String("model", "", "Model: reazonspeech, reazonspeech:lm, sensevoice, sensevoice:beam, sensevoice:lm, whisper, whisper:align (default: reazonspeech)")
+		wait     = fs.Bool("wait", false, "Wait for the transcription and print its progress")
+		interval = fs.Duration("interval", 2*time.Second, "How often to poll the job with -wait")
+	)
+	ids, err := parseArgs(fs, args, 1, "retranscribe [options] <source-id>")
+	if err != nil {
+		return err
+	}
+
+	c := client()
+	result, err := c.Retranscribe(ctx, ids[0], *model)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("source_id: %s\njob_id: %s\nmodel: %s\n", result.SourceID, result.JobID, result.Model)
+	if !*wait {
+		return nil
+	}
+	return waitTranscription(ctx, c, result.SourceID, result.JobID, *interval)
+}
+
+// waitTranscription follows a transcription job until it finishes and prints
+// where the transcript can be read
+func waitTranscription(ctx context.Context, c *Client, sourceID, jobID string, interval time.Duration) error {
+	if _, err := watchJob(ctx, c, jobID, interval); err != nil {
+		return err
+	}
+	fmt.Printf("transcript: %s/audio/%s/sync\n", c.baseURL, sourceID)
+	return nil
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"zbor/internal/handlers"
+	"zbor/internal/storage/sqlc"
+)
+
+// APIError is a non-2xx response from the server
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("server returned %d: %s", e.Status, e.Message)
+}
+
+// Client talks to the zbor API (/api/v1)
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the server at baseURL
+// apiKey may be empty when the server has no API keys configured
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		// No overall timeout: uploads and transcript downloads can be large; requests use contexts instead
+		httpClient: &http.Client{},
+	}
+}
+
+// UploadOptions are the form fields of an audio upload (see handlers.AudioHandler.Upload)
+type UploadOptions struct {
+	Title   string
+	Model   string
+	Enhance bool
+	TwoPass bool
+	Force   bool
+}
+
+// UploadResult is the response of an audio upload
+type UploadResult struct {
+	SourceID    string `json:"source_id"`
+	JobID       string `json:"job_id"`
+	RefineJobID string `json:"refine_job_id"`
+	Duplicate   bool   `json:"duplicate"`
+	Message     string `json:"message"`
+}
+
+// Upload uploads audio files as one source and returns the transcription job
+// The files are streamed, so large recordings are not read into memory
+func (c *Client) Upload(ctx context.Context, paths []string, opts UploadOptions) (*UploadResult, error) {
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeUploadForm(form, paths, opts))
+	}()
+
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/v1/ingest/audio", pr)
+	if err != nil {
+		pr.Close()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	var result UploadResult
+	if err := c.send(req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// writeUploadForm writes the multipart body of an audio upload
+func writeUploadForm(form *multipart.Writer, paths []string, opts UploadOptions) error {
+	fields := map[string]string{"title": opts.Title, "model": opts.Model}
+	flags := map[string]bool{"enhance": opts.Enhance, "two_pass": opts.TwoPass, "force": opts.Force}
+	for name, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := form.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+	for name, value := range flags {
+		if !value {
+			continue
+		}
+		if err := form.WriteField(name, "1"); err != nil {
+			return err
+		}
+	}
+	for _, path := range paths {
+		if err := writeFormFile(form, "files", path); err != nil {
+			return err
+		}
+	}
+	return form.Close()
+}
+
+func writeFormFile(form *multipart.Writer, field, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	part, err := form.CreateFormFile(field, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, f)
+	return err
+}
+
+// Job fetches a job
+func (c *Client) Job(ctx context.Context, id string) (*sqlc.ProcessingJob, error) {
+	var job sqlc.ProcessingJob
+	if err := c.do(ctx, http.MethodGet, "/api/v1/jobs/"+url.PathEscape(id), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Jobs lists jobs, newest first (status may be empty for all)
+func (c *Client) Jobs(ctx context.Context, status string, limit, offset int) (*handlers.Page[sqlc.ProcessingJob], error) {
+	query := pageQuery(limit, offset)
+	if status != "" {
+		query.Set("status", status)
+	}
+	var page handlers.Page[sqlc.ProcessingJob]
+	if err := c.do(ctx, http.MethodGet, "/api/v1/jobs?"+query.Encode(), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// JobLogs calls fn for each log line of a job after afterID, oldest first
+// With follow the server keeps streaming new lines until the job finishes
+func (c *Client) JobLogs(ctx context.Context, id string, afterID int64, follow bool, fn func(sqlc.JobLog) error) error {
+	logsPath := func(afterID int64) string {
+		query := url.Values{"after": {strconv.FormatInt(afterID, 10)}}
+		if follow {
+			query.Set("follow", "1")
+		}
+		return "/api/v1/jobs/" + url.PathEscape(id) + "/logs?" + query.Encode()
+	}
+
+	if !follow {
+		// One page at a time until an empty page
+		for {
+			var logs []sqlc.JobLog
+			if err := c.do(ctx, http.MethodGet, logsPath(afterID), nil, &logs); err != nil {
+				return err
+			}
+			if len(logs) == 0 {
+				return nil
+			}
+			for _, line := range logs {
+				if err := fn(line); err != nil {
+					return err
+				}
+				afterID = line.ID
+			}
+		}
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, logsPath(afterID), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return readError(resp)
+	}
+	// The response is NDJSON: one log line per JSON value
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var line sqlc.JobLog
+		if err := dec.Decode(&line); err == io.EOF {
+			return nil
+		} else if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("log stream interrupted: %w", err)
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+}
+
+// Articles lists articles, newest first
+func (c *Client) Articles(ctx context.Context, status, sourceType string, limit, offset int) (*handlers.Page[sqlc.Article], error) {
+	query := pageQuery(limit, offset)
+	if status != "" {
+		query.Set("status", status)
+	}
+	if sourceType != "" {
+		query.Set("source_type", sourceType)
+	}
+	var page handlers.Page[sqlc.Article]
+	if err := c.do(ctx, http.MethodGet, "/api/v1/articles?"+query.Encode(), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// SearchHit is an article matching a search, with the matches in <mark>
+type SearchHit struct {
+	sqlc.Article
+	TitleHighlight string `json:"title_highlight"`
+	Snippet        string `json:"snippet"`
+}
+
+// SearchArticles runs a full-text search over the articles
+func (c *Client) SearchArticles(ctx context.Context, q string, limit int) ([]SearchHit, error) {
+	query := url.Values{"q": {q}, "limit": {strconv.Itoa(limit)}}
+	var hits []SearchHit
+	if err := c.do(ctx, http.MethodGet, "/api/v1/articles/search?"+query.Encode(), nil, &hits); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// ArticleDetail is an article with its chapters and tags
+type ArticleDetail struct {
+	sqlc.Article
+	Sections json.RawMessage `json:"sections,omitempty"` // chapters as JSON (models.Section)
+	Tags     []sqlc.Tag      `json:"tags,omitempty"`
+}
+
+// Article fetches an article
+func (c *Client) Article(ctx context.Context, id string) (*ArticleDetail, error) {
+	var article ArticleDetail
+	if err := c.do(ctx, http.MethodGet, "/api/v1/articles/"+url.PathEscape(id), nil, &article); err != nil {
+		return nil, err
+	}
+	return &article, nil
+}
+
+// ExportTranscript writes the transcript of a source in format (srt, vtt, txt, json, ...) to w
+func (c *Client) ExportTranscript(ctx context.Context, sourceID, format string, w io.Writer) error {
+	query := url.Values{"format": {format}}
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/v1/audio/"+url.PathEscape(sourceID)+"/transcript/export?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return readError(resp)
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("download interrupted: %w", err)
+	}
+	return nil
+}
+
+// RetranscribeResult is the response of a full retranscription
+type RetranscribeResult struct {
+	SourceID string `json:"source_id"`
+	JobID    string `json:"job_id"`
+	Model    string `json:"model"`
+}
+
+// Retranscribe transcribes a source again from scratch (the current transcript is kept as a version)
+func (c *Client) Retranscribe(ctx context.Context, sourceID, model string) (*RetranscribeResult, error) {
+	req := handlers.RetranscribeFullRequest{Model: model}
+	var result RetranscribeResult
+	if err := c.do(ctx, http.MethodPost, "/api/v1/audio/"+url.PathEscape(sourceID)+"/retranscribe-full", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// pageQuery returns the pagination parameters (0 leaves the server default)
+func pageQuery(limit, offset int) url.Values {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	return query
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return req, nil
+}
+
+// do sends a JSON request and decodes the JSON response into out (if non-nil)
+func (c *Client) do(ctx context.Context, method, path string, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req, out)
+}
+
+// send sends a request and decodes the JSON response into out (if non-nil)
+func (c *Client) send(req *http.Request, out interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return readError(resp)
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("invalid response: %w", err)
+		}
+	}
+	return nil
+}
+
+// readError converts an error response into an APIError
+func readError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err := json.Unmarshal(data, &body); err != nil || body.Error == "" {
+		body.Error = strings.TrimSpace(string(data))
+	}
+	return &APIError{Status: resp.StatusCode, Message: body.Error}
+}
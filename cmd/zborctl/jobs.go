@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// runJobs lists, shows and watches jobs and prints their logs
+func runJobs(ctx context.Context, args []string) error {
+	return runSubcommand(ctx, "jobs", []subcommand{
+		{"list", runJobsList, "List jobs, newest first"},
+		{"show", runJobsShow, "Show a job"},
+		{"watch", runJobsWatch, "Follow the progress of a job until it finishes"},
+		{"logs", runJobsLogs, "Print the log of a job (-f follows it until the job finishes)"},
+	}, args)
+}
+
+func runJobsList(ctx context.Context, args []string) error {
+	fs, client := newFlagSet("jobs list")
+	var (
+		status = fs.String("status", "", "Only jobs with this status: queued, running, completed, failed")
+		limit  = fs.Int("limit", 20, "Number of jobs")
+		offset = fs.Int("offset", 0, "Number of jobs to skip")
+		asJSON = fs.Bool("json", false, "Print the response as JSON")
+	)
+	if _, err := parseArgs(fs, args, 0, "jobs list [options]"); err != nil {
+		return err
+	}
+
+	page, err := client().Jobs(ctx, *status, *limit, *offset)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(page)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTYPE\tSTATUS\tPROGRESS\tSTEP\tSOURCE\tCREATED")
+	for _, job := range page.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", job.ID, job.Type, deref(job.Status), progressOf(&job),
+			deref(job.CurrentStep), deref(job.SourceID), job.CreatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if page.NextOffset != nil {
+		fmt.Fprintf(os.Stderr, "%d of %d jobs (next: -offset %d)\n", len(page.Items), page.Total, *page.NextOffset)
+	}
+	return nil
+}
+
+func runJobsShow(ctx context.Context, args []string) error {
+	fs, client := newFlagSet("jobs show")
+	asJSON := fs.Bool("json", false, "Print the response as JSON")
+	ids, err := parseArgs(fs, args, 1, "jobs show [options] <job-id>")
+	if err != nil {
+		return err
+	}
+
+	job, err := client().Job(ctx, ids[0])
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(job)
+	}
+	printJob(job)
+	return nil
+}
+
+func runJobsWatch(ctx context.Context, args []string) error {
+	fs, client := newFlagSet("jobs watch")
+	interval := fs.Duration("interval", 2*time.Second, "How often to poll the job")
+	ids, err := parseArgs(fs, args, 1, "jobs watch [options] <job-id>")
+	if err != nil {
+		return err
+	}
+	_, err = watchJob(ctx, client(), ids[0], *interval)
+	return err
+}
+
+func runJobsLogs(ctx context.Context, args []string) error {
+	fs, client := newFlagSet("jobs logs")
+	var (
+		follow = fs.Bool("f", false, "Keep printing new lines until the job finishes")
+		after  = fs.Int64("after", 0, "Only lines after this log ID")
+		asJSON = fs.Bool("json", false, "Print each line as JSON")
+	)
+	ids, err := parseArgs(fs, args, 1, "jobs logs [options] <job-id>")
+	if err != nil {
+		return err
+	}
+
+	return client().JobLogs(ctx, ids[0], *after, *follow, func(line sqlc.JobLog) error {
+		if *asJSON {
+			data, err := json.Marshal(line)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Printf("%s\n", data)
+			return err
+		}
+		_, err := fmt.Printf("%s %-5s %s\n", line.CreatedAt.Local().Format("15:04:05.000"), line.Level, line.Message)
+		return err
+	})
+}
+
+// watchJob prints the progress of a job whenever it changes and returns the
+// job once it has completed; a failed job is returned as an error
+func watchJob(ctx context.Context, client *Client, id string, interval time.Duration) (*sqlc.ProcessingJob, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last string
+	for {
+		job, err := client.Job(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		line := fmt.Sprintf("%s %s %s", deref(job.Status), progressOf(job), deref(job.CurrentStep))
+		if line != last {
+			fmt.Fprintf(os.Stderr, "%s  %s\n", time.Now().Format("15:04:05"), line)
+			last = line
+		}
+
+		switch deref(job.Status) {
+		case storage.JobStatusCompleted:
+			return job, nil
+		case storage.JobStatusFailed:
+			return job, fmt.Errorf("job %s failed: %s", job.ID, deref(job.Error))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// printJob prints the fields of a job
+func printJob(job *sqlc.ProcessingJob) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", job.ID)
+	fmt.Fprintf(w, "Type:\t%s\n", job.Type)
+	fmt.Fprintf(w, "Status:\t%s\n", deref(job.Status))
+	fmt.Fprintf(w, "Progress:\t%s\n", progressOf(job))
+	fmt.Fprintf(w, "Step:\t%s\n", deref(job.CurrentStep))
+	fmt.Fprintf(w, "Source:\t%s\n", deref(job.SourceID))
+	fmt.Fprintf(w, "Created:\t%s\n", job.CreatedAt.Local().Format(time.DateTime))
+	if job.StartedAt != nil {
+		fmt.Fprintf(w, "Started:\t%s\n", job.StartedAt.Local().Format(time.DateTime))
+	}
+	if job.CompletedAt != nil {
+		fmt.Fprintf(w, "Finished:\t%s\n", job.CompletedAt.Local().Format(time.DateTime))
+	}
+	if job.Error != nil {
+		fmt.Fprintf(w, "Error:\t%s\n", *job.Error)
+	}
+	w.Flush()
+}
+
+// progressOf formats the progress of a job as a percentage
+func progressOf(job *sqlc.ProcessingJob) string {
+	if job.Progress == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", *job.Progress)
+}
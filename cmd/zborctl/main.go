@@ -0,0 +1,148 @@
+// zborctl is the command line client for a running zbor server. It wraps the
+// HTTP API so automation scripts don't have to build the requests themselves.
+//
+// Usage:
+//
+//	zborctl upload [-title T] [-model M] [-wait] talk.mp3
+//	zborctl jobs list [-status running]
+//	zborctl jobs watch <job-id>
+//	zborctl articles search <query>
+//	zborctl transcript [-format srt] [-o talk.srt] <source-id>
+//	zborctl retranscribe [-model whisper] [-wait] <source-id>
+//
+// The server and API key are taken from -server / -key or ZBOR_SERVER / ZBOR_API_KEY.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// command is a subcommand of zborctl
+type command struct {
+	name  string
+	run   func(ctx context.Context, args []string) error
+	short string
+}
+
+// commands are the subcommands of zborctl, in the order of the usage
+var commands = []command{
+	{"upload", runUpload, "Upload audio files and queue their transcription"},
+	{"jobs", runJobs, "List, show and watch jobs"},
+	{"articles", runArticles, "List, search and show articles"},
+	{"transcript", runTranscript, "Download the transcript of a source (srt, vtt, txt, json, ...)"},
+	{"retranscribe", runRetranscribe, "Transcribe a source again from scratch"},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	for _, cmd := range commands {
+		if cmd.name != os.Args[1] {
+			continue
+		}
+		if err := cmd.run(ctx, os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Error: Unknown command '%s'\n\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: zborctl <command> [options]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-13s %s\n", cmd.name, cmd.short)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'zborctl <command> -h' for the options of a command\n")
+}
+
+// subcommand is an action of a command with actions (zborctl jobs list)
+type subcommand struct {
+	name  string
+	run   func(ctx context.Context, args []string) error
+	short string
+}
+
+// runSubcommand runs the action named by args[0]
+func runSubcommand(ctx context.Context, command string, actions []subcommand, args []string) error {
+	if len(args) > 0 {
+		for _, action := range actions {
+			if action.name == args[0] {
+				return action.run(ctx, args[1:])
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Error: Unknown action '%s'\n\n", args[0])
+	}
+	fmt.Fprintf(os.Stderr, "Usage: zborctl %s <action> [options]\n\nActions:\n", command)
+	for _, action := range actions {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", action.name, action.short)
+	}
+	os.Exit(2)
+	return nil
+}
+
+// newFlagSet creates the flags of a command, including the server connection
+// The returned function creates the client once the flags are parsed
+func newFlagSet(name string) (*flag.FlagSet, func() *Client) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	server := fs.String("server", envOr("ZBOR_SERVER", "http://localhost:8080"), "zbor server URL (env ZBOR_SERVER)")
+	key := fs.String("key", os.Getenv("ZBOR_API_KEY"), "API key (env ZBOR_API_KEY)")
+	return fs, func() *Client {
+		return NewClient(*server, *key)
+	}
+}
+
+// parseArgs parses the flags and checks the number of positional arguments
+// Flags may follow the positional arguments (zborctl jobs watch <id> -interval 5s)
+func parseArgs(fs *flag.FlagSet, args []string, want int, usage string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if want >= 0 && len(positional) != want || want < 0 && len(positional) == 0 {
+		return nil, fmt.Errorf("usage: zborctl %s", usage)
+	}
+	return positional, nil
+}
+
+// printJSON prints v as indented JSON (the -json output)
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// deref returns the value of p, or "-" when it is nil
+func deref(p *string) string {
+	if p == nil || *p == "" {
+		return "-"
+	}
+	return *p
+}
@@ -0,0 +1,246 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"zbor/internal/notify"
+	"zbor/internal/storage"
+)
+
+// Rules はアラートの発火条件（各しきい値は 0 で無効）
+type Rules struct {
+	FailureThreshold int           // 同じタイプのジョブが FailureWindow 内にこの回数失敗したら通知
+	FailureWindow    time.Duration // 失敗を数える期間
+	QueueThreshold   int           // 待機中のジョブがこの件数を超えたら通知
+	HeartbeatTimeout time.Duration // ワーカーの応答がこの時間無ければ通知
+	RepeatInterval   time.Duration // 継続中のアラートを再通知する間隔（0 で再通知しない）
+}
+
+// DefaultRules はデフォルトのアラート条件を返す
+func DefaultRules() Rules {
+	return Rules{
+		FailureThreshold: 3,
+		FailureWindow:    30 * time.Minute,
+		QueueThreshold:   100,
+		HeartbeatTimeout: 30 * time.Minute,
+		RepeatInterval:   6 * time.Hour,
+	}
+}
+
+// Heartbeater は最後に動作を確認できた時刻を返す（ローカルワーカー）
+type Heartbeater interface {
+	LastHeartbeat() time.Time
+}
+
+// activeAlert は発火中のアラート
+type activeAlert struct {
+	message    notify.Message
+	since      time.Time
+	notifiedAt time.Time
+}
+
+// Monitor はジョブキューとワーカーを定期的に確認し、条件に当てはまれば通知する
+// 同じアラートは解消するまで RepeatInterval ごとにしか通知せず、解消時にも通知する
+type Monitor struct {
+	jobRepo    *storage.JobRepository
+	workerRepo *storage.WorkerRepository
+	worker     Heartbeater
+	notifier   *notify.Notifier
+	rules      Rules
+
+	mu     sync.Mutex
+	active map[string]*activeAlert
+}
+
+// NewMonitor は新しいMonitorを作成
+func NewMonitor(jobRepo *storage.JobRepository, notifier *notify.Notifier, rules Rules) *Monitor {
+	return &Monitor{
+		jobRepo:  jobRepo,
+		notifier: notifier,
+		rules:    rules,
+		active:   make(map[string]*activeAlert),
+	}
+}
+
+// SetWorker はハートビートを監視するローカルワーカーを設定
+func (m *Monitor) SetWorker(worker Heartbeater) {
+	m.worker = worker
+}
+
+// SetRemoteWorkers はハートビートを監視するリモートワーカーの一覧を設定（分散モードのみ）
+func (m *Monitor) SetRemoteWorkers(workerRepo *storage.WorkerRepository) {
+	m.workerRepo = workerRepo
+}
+
+// Run は interval ごとに条件を確認（ctx がキャンセルされるまで）
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := m.Check(ctx); err != nil {
+			slog.Error("Alert check failed", "error", err)
+		}
+	}
+}
+
+// Check は全ての条件を確認し、新たに発火・解消したアラートを通知する
+func (m *Monitor) Check(ctx context.Context) error {
+	now := time.Now()
+	firing := map[string]notify.Message{}
+
+	if err := m.checkFailures(ctx, now, firing); err != nil {
+		return err
+	}
+	if err := m.checkQueue(ctx, firing); err != nil {
+		return err
+	}
+	if err := m.checkHeartbeat(ctx, now, firing); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]string, 0, len(firing))
+	for key := range firing {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		msg := firing[key]
+		a, ok := m.active[key]
+		if !ok {
+			a = &activeAlert{since: now}
+			m.active[key] = a
+		}
+		a.message = msg
+		if ok && (m.rules.RepeatInterval <= 0 || now.Sub(a.notifiedAt) < m.rules.RepeatInterval) {
+			continue
+		}
+		if ok {
+			msg.Details = append(msg.Details, "firing since "+a.since.Format(time.RFC3339))
+		}
+		a.notifiedAt = now
+		m.notify(ctx, msg)
+	}
+
+	for key, a := range m.active {
+		if _, ok := firing[key]; ok {
+			continue
+		}
+		delete(m.active, key)
+		m.notify(ctx, notify.Message{
+			Level: notify.LevelInfo,
+			Title: "Resolved: " + a.message.Title,
+			Text:  fmt.Sprintf("The condition cleared after %s", now.Sub(a.since).Round(time.Second)),
+		})
+	}
+	return nil
+}
+
+// notify は通知を送信（送信失敗はログのみ）
+func (m *Monitor) notify(ctx context.Context, msg notify.Message) {
+	if err := m.notifier.Notify(ctx, msg); err != nil {
+		slog.Error("Failed to send alert", "error", err)
+	}
+}
+
+// checkFailures は同じタイプのジョブの失敗が続いていないか確認
+// リトライ上限に達して failed になったジョブを数える
+func (m *Monitor) checkFailures(ctx context.Context, now time.Time, firing map[string]notify.Message) error {
+	if m.rules.FailureThreshold <= 0 || m.rules.FailureWindow <= 0 {
+		return nil
+	}
+	rows, err := m.jobRepo.CountFailedByTypeSince(ctx, now.Add(-m.rules.FailureWindow))
+	if err != nil {
+		return fmt.Errorf("failed to count failed jobs: %w", err)
+	}
+	for _, row := range rows {
+		if row.Count < int64(m.rules.FailureThreshold) {
+			continue
+		}
+		firing["failures:"+row.Type] = notify.Message{
+			Level: notify.LevelError,
+			Title: "Repeated job failures: " + row.Type,
+			Text:  fmt.Sprintf("%d %s jobs failed in the last %s", row.Count, row.Type, m.rules.FailureWindow),
+		}
+	}
+	return nil
+}
+
+// checkQueue は待機中のジョブが溜まっていないか確認
+func (m *Monitor) checkQueue(ctx context.Context, firing map[string]notify.Message) error {
+	if m.rules.QueueThreshold <= 0 {
+		return nil
+	}
+	queued, err := m.jobRepo.Count(ctx, storage.JobStatusQueued)
+	if err != nil {
+		return fmt.Errorf("failed to count queued jobs: %w", err)
+	}
+	if queued > int64(m.rules.QueueThreshold) {
+		firing["queue"] = notify.Message{
+			Level: notify.LevelWarning,
+			Title: "Job queue backlog",
+			Text:  fmt.Sprintf("%d jobs are queued (threshold %d)", queued, m.rules.QueueThreshold),
+		}
+	}
+	return nil
+}
+
+// checkHeartbeat はワーカーが止まっていないか確認
+// リモートワーカーは、登録済みの全エージェントから応答が無い場合に通知する
+func (m *Monitor) checkHeartbeat(ctx context.Context, now time.Time, firing map[string]notify.Message) error {
+	timeout := m.rules.HeartbeatTimeout
+	if timeout <= 0 {
+		return nil
+	}
+
+	if m.worker != nil {
+		if last := m.worker.LastHeartbeat(); !last.IsZero() && now.Sub(last) > timeout {
+			firing["heartbeat:local"] = notify.Message{
+				Level: notify.LevelError,
+				Title: "Worker stopped responding",
+				Text:  fmt.Sprintf("The job worker has not polled the queue or reported progress since %s", last.Format(time.RFC3339)),
+			}
+		}
+	}
+
+	if m.workerRepo != nil {
+		workers, err := m.workerRepo.List(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list workers: %w", err)
+		}
+		var details []string
+		for _, w := range workers {
+			if now.Sub(w.LastSeenAt) <= timeout {
+				return nil
+			}
+			name := w.ID
+			if w.Name != nil && *w.Name != "" {
+				name = *w.Name + " (" + w.ID + ")"
+			}
+			details = append(details, fmt.Sprintf("%s: last seen %s", name, w.LastSeenAt.Format(time.RFC3339)))
+		}
+		if len(details) > 0 {
+			firing["heartbeat:remote"] = notify.Message{
+				Level:   notify.LevelError,
+				Title:   "Remote workers stopped responding",
+				Text:    fmt.Sprintf("No remote worker agent has sent a heartbeat in the last %s", timeout),
+				Details: details,
+			}
+		}
+	}
+	return nil
+}
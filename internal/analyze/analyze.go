@@ -0,0 +1,341 @@
+// Package analyze は記事（文字起こしの記事を含む）から頻出キーワードと固有表現（人物・組織・地名）を抽出する
+//
+// 抽出結果は記事ごとに構造化したJSONのアーティファクトとして保存し、
+// 記事一覧の絞り込み（言及された人物など）のために article_entities にも記録する。
+package analyze
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/summarize"
+)
+
+// 抽出の方法
+const (
+	MethodHeuristic = "heuristic" // 敬称・法人格・地名の辞書とキーワード抽出
+	MethodLLM       = "llm"       // LLM（OpenAI互換API）
+)
+
+// maxLLMRunes はLLMに渡す本文の最大文字数
+const maxLLMRunes = 8000
+
+const llmPrompt = `あなたは記事から固有表現を抽出するアシスタントです。
+記事に登場する人物・組織・地名と、主題を表すキーワード（最大10個）を、次の形の JSON オブジェクトだけで答えてください。
+{"people": ["..."], "organizations": ["..."], "places": ["..."], "keywords": ["..."]}
+- 記事中の表記をそのまま使い、敬称（さん・氏など）は付けない
+- 該当するものが無ければ空の配列にする`
+
+// ProgressCallback は進捗を報告するコールバック
+type ProgressCallback func(progress int, step string)
+
+// Entity は抽出した語と記事中の出現回数
+type Entity struct {
+	Kind     string `json:"-"`
+	Name     string `json:"name"`
+	Mentions int    `json:"mentions"`
+}
+
+// Analysis は記事の分析結果（アーティファクトのJSON）
+type Analysis struct {
+	ArticleID     string   `json:"article_id"`
+	Method        string   `json:"method"`
+	People        []Entity `json:"people"`
+	Organizations []Entity `json:"organizations"`
+	Places        []Entity `json:"places"`
+	Keywords      []Entity `json:"keywords"`
+}
+
+// newAnalysis は抽出した語を種類ごとにまとめる
+func newAnalysis(articleID, method string, entities []Entity) *Analysis {
+	a := &Analysis{
+		ArticleID:     articleID,
+		Method:        method,
+		People:        []Entity{},
+		Organizations: []Entity{},
+		Places:        []Entity{},
+		Keywords:      []Entity{},
+	}
+	for _, e := range entities {
+		switch e.Kind {
+		case storage.EntityKindPerson:
+			a.People = append(a.People, e)
+		case storage.EntityKindOrganization:
+			a.Organizations = append(a.Organizations, e)
+		case storage.EntityKindPlace:
+			a.Places = append(a.Places, e)
+		case storage.EntityKindKeyword:
+			a.Keywords = append(a.Keywords, e)
+		}
+	}
+	return a
+}
+
+// Analyzer は記事の分析ジョブを処理する
+type Analyzer struct {
+	client       *summarize.Client
+	articleRepo  *storage.ArticleRepository
+	sourceRepo   *storage.SourceRepository
+	artifactRepo *storage.ArtifactRepository
+	jobRepo      *storage.JobRepository
+}
+
+// NewAnalyzer は新しいAnalyzerを作成
+// client が nil の場合はヒューリスティックで抽出する
+func NewAnalyzer(
+	client *summarize.Client,
+	articleRepo *storage.ArticleRepository,
+	sourceRepo *storage.SourceRepository,
+	artifactRepo *storage.ArtifactRepository,
+	jobRepo *storage.JobRepository,
+) *Analyzer {
+	return &Analyzer{
+		client:       client,
+		articleRepo:  articleRepo,
+		sourceRepo:   sourceRepo,
+		artifactRepo: artifactRepo,
+		jobRepo:      jobRepo,
+	}
+}
+
+// Method は抽出の方法を返す
+func (a *Analyzer) Method() string {
+	if a.client != nil {
+		return MethodLLM
+	}
+	return MethodHeuristic
+}
+
+// CreateJob は記事の分析ジョブを作成
+// ジョブはソース単位のため、ソースを持たない記事には記事用のソースを作成して紐付ける
+func (a *Analyzer) CreateJob(ctx context.Context, articleID string, priority int) (string, error) {
+	article, err := a.articleRepo.GetByID(ctx, articleID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get article: %w", err)
+	}
+	if article == nil {
+		return "", fmt.Errorf("article not found: %s", articleID)
+	}
+	if err := a.articleRepo.CheckHold(ctx, article.ID); err != nil {
+		return "", err
+	}
+
+	if article.SourceID == nil {
+		metadata, _ := json.Marshal(map[string]string{"article_id": article.ID})
+		source := &sqlc.Source{
+			Type:     storage.SourceTypeArticle,
+			Metadata: storage.Ptr(string(metadata)),
+			Status:   storage.Ptr(storage.SourceStatusCompleted),
+			OwnerID:  article.OwnerID,
+		}
+		if err := a.sourceRepo.Create(ctx, source); err != nil {
+			return "", fmt.Errorf("failed to create source: %w", err)
+		}
+		article.SourceID = &source.ID
+		if err := a.articleRepo.Update(ctx, article); err != nil {
+			return "", fmt.Errorf("failed to link article to source: %w", err)
+		}
+	}
+
+	job := &sqlc.ProcessingJob{
+		SourceID: article.SourceID,
+		Type:     storage.JobTypeAnalyze,
+		Priority: storage.Ptr(int64(priority)),
+	}
+	if err := a.jobRepo.Create(ctx, job); err != nil {
+		return "", fmt.Errorf("failed to create job: %w", err)
+	}
+	return job.ID, nil
+}
+
+// ProcessJob は分析ジョブを処理する（ワーカーから呼ばれる）
+// ソースに紐づく各記事を分析して保存する。リーガルホールド中の記事は変更しない
+func (a *Analyzer) ProcessJob(ctx context.Context, job *sqlc.ProcessingJob, onProgress ProgressCallback) error {
+	if job.SourceID == nil {
+		return fmt.Errorf("job has no source ID")
+	}
+	reportProgress := func(progress int, step string) {
+		if onProgress != nil {
+			onProgress(progress, step)
+		}
+	}
+
+	articles, err := a.articleRepo.GetBySourceID(ctx, *job.SourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get articles: %w", err)
+	}
+
+	for idx := range articles {
+		article := &articles[idx]
+		reportProgress(10+80*idx/len(articles), "extracting entities")
+
+		if err := a.articleRepo.CheckHold(ctx, article.ID); err != nil {
+			if errors.Is(err, storage.ErrLegalHold) {
+				continue
+			}
+			return err
+		}
+		if _, err := a.Apply(ctx, article); err != nil {
+			return fmt.Errorf("failed to analyze article %s: %w", article.ID, err)
+		}
+	}
+
+	reportProgress(100, "")
+	return nil
+}
+
+// Apply は記事を分析し、アーティファクトと絞り込み用の語を置き換える
+func (a *Analyzer) Apply(ctx context.Context, article *sqlc.Article) (*Analysis, error) {
+	entities, err := a.Extract(ctx, article)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]sqlc.ArticleEntity, len(entities))
+	for i, e := range entities {
+		rows[i] = sqlc.ArticleEntity{Kind: e.Kind, Name: e.Name, Mentions: int64(e.Mentions)}
+	}
+	if err := a.articleRepo.SetEntities(ctx, article.ID, rows); err != nil {
+		return nil, fmt.Errorf("failed to save entities: %w", err)
+	}
+
+	analysis := newAnalysis(article.ID, a.Method(), entities)
+	if article.SourceID != nil {
+		if err := a.saveArtifact(ctx, *article.SourceID, analysis); err != nil {
+			return nil, err
+		}
+	}
+	return analysis, nil
+}
+
+// Extract は記事の固有表現とキーワードを返す（保存はしない）
+func (a *Analyzer) Extract(ctx context.Context, article *sqlc.Article) ([]Entity, error) {
+	if a.client != nil {
+		return a.extractLLM(ctx, article)
+	}
+	return extract(article.Title, article.Content), nil
+}
+
+// llmAnswer はLLMの回答
+type llmAnswer struct {
+	People        []string `json:"people"`
+	Organizations []string `json:"organizations"`
+	Places        []string `json:"places"`
+	Keywords      []string `json:"keywords"`
+}
+
+// extractLLM はLLMに固有表現を抽出させる（出現回数は本文中の表記を数え、見つからなければ1）
+func (a *Analyzer) extractLLM(ctx context.Context, article *sqlc.Article) ([]Entity, error) {
+	content := []rune(strings.TrimSpace(article.Content))
+	if len(content) > maxLLMRunes {
+		content = content[:maxLLMRunes]
+	}
+
+	answer, err := a.client.Complete(ctx, []summarize.Message{
+		{Role: "system", Content: llmPrompt},
+		{Role: "user", Content: "タイトル: " + article.Title + "\n\n" + string(content)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("LLM did not return a JSON object: %q", answer)
+	}
+	var parsed llmAnswer
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid LLM answer %q: %w", answer, err)
+	}
+
+	text := article.Title + "\n" + article.Content
+	var entities []Entity
+	add := func(kind string, names []string) {
+		seen := make(map[string]bool)
+		var list []Entity
+		for _, name := range names {
+			name = strings.TrimSpace(name)
+			key := strings.ToLower(name)
+			if name == "" || seen[key] || len(list) >= maxPerKind {
+				continue
+			}
+			seen[key] = true
+			list = append(list, Entity{Kind: kind, Name: name, Mentions: max(strings.Count(text, name), 1)})
+		}
+		sortEntities(list)
+		entities = append(entities, list...)
+	}
+	add(storage.EntityKindPerson, parsed.People)
+	add(storage.EntityKindOrganization, parsed.Organizations)
+	add(storage.EntityKindPlace, parsed.Places)
+	add(storage.EntityKindKeyword, parsed.Keywords)
+	return entities, nil
+}
+
+// saveArtifact は記事の分析結果のアーティファクトを置き換える
+func (a *Analyzer) saveArtifact(ctx context.Context, sourceID string, analysis *Analysis) error {
+	artifacts, err := a.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	for _, artifact := range artifacts {
+		if artifact.Type != storage.ArtifactTypeEntities || artifactArticleID(artifact) != analysis.ArticleID {
+			continue
+		}
+		if err := a.artifactRepo.Delete(ctx, artifact.ID); err != nil {
+			return fmt.Errorf("failed to delete previous analysis: %w", err)
+		}
+	}
+
+	content, err := json.Marshal(analysis)
+	if err != nil {
+		return fmt.Errorf("failed to marshal analysis: %w", err)
+	}
+	metadata, _ := json.Marshal(map[string]string{"article_id": analysis.ArticleID, "method": analysis.Method})
+	return a.artifactRepo.Create(ctx, &sqlc.ProcessingArtifact{
+		SourceID: &sourceID,
+		Type:     storage.ArtifactTypeEntities,
+		Content:  storage.Ptr(string(content)),
+		Format:   storage.Ptr("json"),
+		Metadata: storage.Ptr(string(metadata)),
+	})
+}
+
+// Load は記事の分析結果を返す（未分析なら nil）
+func Load(ctx context.Context, artifactRepo *storage.ArtifactRepository, article *sqlc.Article) (*Analysis, error) {
+	if article.SourceID == nil {
+		return nil, nil
+	}
+	artifacts, err := artifactRepo.GetBySourceID(ctx, *article.SourceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, artifact := range artifacts {
+		if artifact.Type != storage.ArtifactTypeEntities || artifact.Content == nil || artifactArticleID(artifact) != article.ID {
+			continue
+		}
+		var analysis Analysis
+		if err := json.Unmarshal([]byte(*artifact.Content), &analysis); err != nil {
+			return nil, fmt.Errorf("invalid analysis artifact: %w", err)
+		}
+		return &analysis, nil
+	}
+	return nil, nil
+}
+
+// artifactArticleID はアーティファクトの metadata の記事IDを返す
+func artifactArticleID(artifact sqlc.ProcessingArtifact) string {
+	if artifact.Metadata == nil {
+		return ""
+	}
+	var metadata struct {
+		ArticleID string `json:"article_id"`
+	}
+	_ = json.Unmarshal([]byte(*artifact.Metadata), &metadata)
+	return metadata.ArticleID
+}
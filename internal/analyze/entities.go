@@ -0,0 +1,226 @@
+package analyze
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"zbor/internal/autotag"
+	"zbor/internal/storage"
+)
+
+const (
+	// maxPerKind は種類ごとに保存する語の最大数
+	maxPerKind = 20
+	// maxKeywords は保存するキーワードの最大数
+	maxKeywords = 15
+	// minKeywordScore はキーワードとして保存する最小スコア（タイトルに1回、または本文に2回）
+	minKeywordScore = 2
+)
+
+// 敬称・肩書きの直前の語を人名とみなす（「田中さん」「山田太郎氏」「鈴木教授」）
+var personPattern = regexp.MustCompile(`([\p{Han}\p{Katakana}ー]{2,8})(?:さん|さま|様|氏|君|先生|教授|准教授|博士|社長|会長|部長|課長|議員|首相|大臣|知事|市長|監督|選手|記者)`)
+
+// 英語の敬称に続く語（"Dr. Jane Smith"）
+var personLatinPattern = regexp.MustCompile(`\b(?:Mr|Mrs|Ms|Dr|Prof)\.?\s+([A-Z][a-z]+(?:\s+[A-Z][a-z]+)?)`)
+
+// 人名ではない敬称付きの語（「皆さん」「担当者様」など）の末尾
+var personStopSuffixes = []string{"者", "員", "方", "達", "様", "皆", "客", "屋"}
+
+// 法人格・組織の種類を表す語を含む語を組織名とみなす
+var orgPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?:株式会社|有限会社|合同会社|一般社団法人|公益財団法人)\s?[\p{Han}\p{Katakana}ーA-Za-z0-9]{2,15}`),
+	regexp.MustCompile(`[\p{Han}\p{Katakana}ーA-Za-z0-9]{2,15}\s?(?:株式会社|有限会社|合同会社)`),
+	regexp.MustCompile(`[\p{Han}\p{Katakana}ー]{2,10}(?:大学|銀行|協会|財団|研究所|新聞社|放送局|病院|高校|証券|商事|工業|製作所)`),
+	regexp.MustCompile(`\b[A-Z][A-Za-z0-9&]+(?:\s+[A-Z][A-Za-z0-9&]+)*\s+(?:Inc|Corp|Corporation|Ltd|LLC|University|Foundation)\b`),
+}
+
+// 組織名の前に付きがちな一般語（「国立大学」「大手銀行」は組織名にしない）
+var orgStopPrefixes = []string{"国立", "私立", "公立", "大手", "地方", "市中", "各", "同"}
+
+// 都道府県・主な国名と、「〇〇市」「〇〇区」のような市区町村を地名とみなす
+var prefectures = []string{
+	"北海道", "青森県", "岩手県", "宮城県", "秋田県", "山形県", "福島県", "茨城県", "栃木県", "群馬県",
+	"埼玉県", "千葉県", "東京都", "神奈川県", "新潟県", "富山県", "石川県", "福井県", "山梨県", "長野県",
+	"岐阜県", "静岡県", "愛知県", "三重県", "滋賀県", "京都府", "大阪府", "兵庫県", "奈良県", "和歌山県",
+	"鳥取県", "島根県", "岡山県", "広島県", "山口県", "徳島県", "香川県", "愛媛県", "高知県", "福岡県",
+	"佐賀県", "長崎県", "熊本県", "大分県", "宮崎県", "鹿児島県", "沖縄県",
+	"東京", "大阪", "京都", "名古屋", "横浜", "札幌", "福岡", "神戸", "沖縄",
+}
+
+var countries = []string{
+	"日本", "アメリカ", "米国", "中国", "韓国", "台湾", "イギリス", "英国", "フランス", "ドイツ",
+	"イタリア", "スペイン", "ロシア", "インド", "カナダ", "オーストラリア", "ブラジル", "メキシコ",
+	"タイ", "ベトナム", "インドネシア", "フィリピン", "シンガポール", "ウクライナ",
+}
+
+// 市区町村（「株式市場」「区別」のように後ろの字と熟語になるものは除く）
+var municipalityPattern = regexp.MustCompile(`[\p{Han}\p{Katakana}ー]{2,4}[市区町村]`)
+
+// municipalityStopNext は市区町村の直後に続くと熟語になる字
+const municipalityStopNext = "場別分間域画販況切"
+
+// extract はタイトル・本文から固有表現と頻出キーワードを抽出する
+func extract(title, content string) []Entity {
+	text := title + "\n" + content
+	counts := newCounter()
+
+	for _, m := range personPattern.FindAllStringSubmatch(text, -1) {
+		if !hasAnySuffix(m[1], personStopSuffixes) {
+			counts.add(storage.EntityKindPerson, m[1])
+		}
+	}
+	for _, m := range personLatinPattern.FindAllStringSubmatch(text, -1) {
+		counts.add(storage.EntityKindPerson, m[1])
+	}
+
+	for _, re := range orgPatterns {
+		for _, name := range re.FindAllString(text, -1) {
+			if !hasAnyPrefix(name, orgStopPrefixes) {
+				counts.add(storage.EntityKindOrganization, strings.TrimSpace(name))
+			}
+		}
+	}
+
+	for _, name := range prefectures {
+		counts.addN(storage.EntityKindPlace, name, countWord(text, name))
+	}
+	for _, name := range countries {
+		counts.addN(storage.EntityKindPlace, name, countWord(text, name))
+	}
+	for _, loc := range municipalityPattern.FindAllStringIndex(text, -1) {
+		if next, _ := utf8.DecodeRuneInString(text[loc[1]:]); strings.ContainsRune(municipalityStopNext, next) {
+			continue
+		}
+		counts.add(storage.EntityKindPlace, text[loc[0]:loc[1]])
+	}
+
+	entities := counts.entities(maxPerKind)
+
+	// キーワードは固有表現として抽出した語を除く
+	seen := make(map[string]bool)
+	for _, e := range entities {
+		seen[strings.ToLower(e.Name)] = true
+	}
+	keywords := 0
+	for _, c := range autotag.Keywords(title, content) {
+		if c.Score < minKeywordScore || keywords >= maxKeywords {
+			break
+		}
+		if seen[strings.ToLower(c.Name)] {
+			continue
+		}
+		entities = append(entities, Entity{Kind: storage.EntityKindKeyword, Name: c.Name, Mentions: int(c.Score)})
+		keywords++
+	}
+	return entities
+}
+
+// countWord は text 中の name の出現回数を数える
+// 前後に漢字・カタカナが続く出現（「日本語」「タイトル」）は別の語の一部として数えない
+func countWord(text, name string) int {
+	n := 0
+	for i := 0; ; {
+		j := strings.Index(text[i:], name)
+		if j < 0 {
+			return n
+		}
+		start, end := i+j, i+j+len(name)
+		prev, _ := utf8.DecodeLastRuneInString(text[:start])
+		next, _ := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(prev) && !isWordRune(next) {
+			n++
+		}
+		i = end
+	}
+}
+
+// isWordRune は語の途中とみなす文字（漢字・カタカナ・長音符）かを返す
+func isWordRune(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Katakana, r) || r == 'ー'
+}
+
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// counter は種類ごとの語の出現回数
+type counter struct {
+	counts map[string]map[string]int
+}
+
+func newCounter() *counter {
+	return &counter{counts: make(map[string]map[string]int)}
+}
+
+func (c *counter) add(kind, name string) {
+	c.addN(kind, name, 1)
+}
+
+func (c *counter) addN(kind, name string, n int) {
+	if n <= 0 || name == "" {
+		return
+	}
+	if c.counts[kind] == nil {
+		c.counts[kind] = make(map[string]int)
+	}
+	c.counts[kind][name] += n
+}
+
+// entities は種類ごとに出現回数の多い順（同数は名前順）に最大 limit 個を返す
+// 同じ種類で他の語に含まれる短い語（「東京都」に対する「東京」）は長い語にまとめる
+func (c *counter) entities(limit int) []Entity {
+	var out []Entity
+	for _, kind := range storage.EntityKinds {
+		names := c.counts[kind]
+		var list []Entity
+		for name, n := range names {
+			if containedIn(name, names) {
+				continue
+			}
+			list = append(list, Entity{Kind: kind, Name: name, Mentions: n})
+		}
+		sortEntities(list)
+		if len(list) > limit {
+			list = list[:limit]
+		}
+		out = append(out, list...)
+	}
+	return out
+}
+
+// containedIn は name が names の他の（より長い）語の一部で、その語より多く現れていないかを返す
+func containedIn(name string, names map[string]int) bool {
+	for other, n := range names {
+		if other != name && strings.Contains(other, name) && names[name] <= n {
+			return true
+		}
+	}
+	return false
+}
+
+// sortEntities は出現回数の多い順（同数は名前順）に並べる
+func sortEntities(list []Entity) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Mentions != list[j].Mentions {
+			return list[i].Mentions > list[j].Mentions
+		}
+		return list[i].Name < list[j].Name
+	})
+}
@@ -0,0 +1,399 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// sweepBatch は1回のスイープでアーカイブする最大ソース数
+const sweepBatch = 20
+
+// Manifest はアーカイブしたデータの一覧（ソースのメタデータ "archive" に保存）
+type Manifest struct {
+	ArchivedAt time.Time      `json:"archived_at"`
+	Files      []ArchivedFile `json:"files"`
+	Artifacts  []string       `json:"artifacts"` // コンテンツをアーカイブしたアーティファクトID
+}
+
+// ArchivedFile はアーカイブしたファイル
+type ArchivedFile struct {
+	Path string `json:"path"` // 元のパス（復元先）
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+	Blob bool   `json:"blob,omitempty"` // 重複排除の実体を参照していた（復元時に再登録する）
+}
+
+// Archiver は古いソースの音声・成果物をアーカイブ先に移動し、必要時に復元する
+// 記事（本文・要約）はDBに残すため、アーカイブ後も検索できる
+type Archiver struct {
+	sourceRepo   *storage.SourceRepository
+	artifactRepo *storage.ArtifactRepository
+	blobRepo     *storage.BlobRepository
+	store        Store
+	after        time.Duration
+
+	mu sync.Mutex // アーカイブと復元を直列化
+}
+
+// NewArchiver は新しいArchiverを作成
+// after: 最終アクセス（未アクセスなら作成日時）からアーカイブまでの期間
+func NewArchiver(sourceRepo *storage.SourceRepository, artifactRepo *storage.ArtifactRepository, store Store, after time.Duration) *Archiver {
+	return &Archiver{
+		sourceRepo:   sourceRepo,
+		artifactRepo: artifactRepo,
+		store:        store,
+		after:        after,
+	}
+}
+
+// SetBlobRepository は音声の実体の参照を管理する BlobRepository を設定
+// アーカイブしたファイルの参照を外し、復元時に再登録する
+func (a *Archiver) SetBlobRepository(repo *storage.BlobRepository) {
+	a.blobRepo = repo
+}
+
+// IsArchived はソースがアーカイブ済みかを返す
+func IsArchived(source *sqlc.Source) bool {
+	return source.Status != nil && *source.Status == storage.SourceStatusArchived
+}
+
+// Run は interval ごとにスイープを実行（ctx がキャンセルされるまで）
+func (a *Archiver) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := a.Sweep(ctx); err != nil {
+			slog.Error("Archive sweep failed", "error", err)
+		} else if n > 0 {
+			slog.Info("Archived sources", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep は期限を過ぎたソースをアーカイブし、アーカイブした件数を返す
+func (a *Archiver) Sweep(ctx context.Context) (int, error) {
+	sources, err := a.sourceRepo.ListArchivable(ctx, time.Now().Add(-a.after), sweepBatch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sources: %w", err)
+	}
+
+	archived := 0
+	for _, source := range sources {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := a.Archive(ctx, source.ID); err != nil {
+			slog.Error("Failed to archive source", "source_id", source.ID, "error", err)
+			continue
+		}
+		archived++
+	}
+	return archived, nil
+}
+
+// Archive はソースの音声ファイルと成果物をアーカイブ先に移動する
+// 全データのコピーが完了してからローカルのデータを削除する
+func (a *Archiver) Archive(ctx context.Context, sourceID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	source, err := a.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return fmt.Errorf("source not found: %s", sourceID)
+	}
+	if source.Status == nil || *source.Status != storage.SourceStatusCompleted {
+		return fmt.Errorf("only completed sources can be archived")
+	}
+
+	manifest := &Manifest{ArchivedAt: time.Now()}
+	var stored []string
+	cleanup := func() {
+		for _, key := range stored {
+			_ = a.store.Delete(ctx, key)
+		}
+	}
+
+	// 重複排除の実体を参照しているファイル
+	blobFiles := map[string]bool{}
+	if a.blobRepo != nil {
+		paths, err := a.blobRepo.Files(ctx, sourceID)
+		if err != nil {
+			return fmt.Errorf("failed to get blob files: %w", err)
+		}
+		for _, p := range paths {
+			blobFiles[p] = true
+		}
+	}
+
+	// 音声ファイル（ソースディレクトリ内の全ファイル: 元ファイル、変換済みWAV、プレビュー）
+	if source.FilePath != nil && *source.FilePath != "" {
+		root := *source.FilePath
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && path == root {
+					return fs.SkipDir
+				}
+				return err
+			}
+			if !d.Type().IsRegular() || strings.HasSuffix(path, ".tmp") {
+				return nil
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			key := sourceID + "/files/" + filepath.ToSlash(rel)
+			size, err := a.putFile(ctx, key, path)
+			if err != nil {
+				return fmt.Errorf("failed to archive %s: %w", rel, err)
+			}
+			stored = append(stored, key)
+			manifest.Files = append(manifest.Files, ArchivedFile{Path: path, Key: key, Size: size, Blob: blobFiles[path]})
+			return nil
+		})
+		if err != nil {
+			cleanup()
+			return err
+		}
+	}
+
+	// 成果物のコンテンツ（文字起こしJSONなど）
+	artifacts, err := a.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		cleanup()
+		return fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	for _, artifact := range artifacts {
+		if artifact.Content == nil {
+			continue
+		}
+		key := artifactKey(sourceID, artifact.ID)
+		if err := a.store.Put(ctx, key, strings.NewReader(*artifact.Content)); err != nil {
+			cleanup()
+			return fmt.Errorf("failed to archive artifact %s: %w", artifact.ID, err)
+		}
+		stored = append(stored, key)
+		manifest.Artifacts = append(manifest.Artifacts, artifact.ID)
+	}
+
+	// マニフェストを保存してからローカルのデータを削除
+	if err := a.updateManifest(ctx, source, manifest); err != nil {
+		cleanup()
+		return err
+	}
+	if err := a.sourceRepo.UpdateStatus(ctx, sourceID, storage.SourceStatusArchived); err != nil {
+		return fmt.Errorf("failed to update source status: %w", err)
+	}
+
+	for _, id := range manifest.Artifacts {
+		if err := a.artifactRepo.ClearContent(ctx, id); err != nil {
+			return fmt.Errorf("failed to clear artifact %s: %w", id, err)
+		}
+	}
+	for _, f := range manifest.Files {
+		if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to remove archived file", "path", f.Path, "error", err)
+		}
+	}
+	// 他のソースから参照されていない実体はここで削除される
+	if a.blobRepo != nil {
+		if _, err := a.blobRepo.Release(ctx, sourceID); err != nil {
+			slog.Warn("Failed to release blobs", "source_id", sourceID, "error", err)
+		}
+	}
+
+	slog.Info("Source archived", "source_id", sourceID, "files", len(manifest.Files), "artifacts", len(manifest.Artifacts))
+	return nil
+}
+
+// Rehydrate はアーカイブ済みソースのデータを復元する
+// アーカイブされていないソースでは何もしない
+func (a *Archiver) Rehydrate(ctx context.Context, sourceID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	source, err := a.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return fmt.Errorf("source not found: %s", sourceID)
+	}
+	if !IsArchived(source) {
+		return nil
+	}
+
+	manifest, err := readManifest(source)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range manifest.Files {
+		if err := a.getFile(ctx, f.Key, f.Path); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", filepath.Base(f.Path), err)
+		}
+		if f.Blob && a.blobRepo != nil {
+			if err := a.blobRepo.Store(ctx, sourceID, f.Path); err != nil {
+				slog.Warn("Failed to deduplicate", "path", f.Path, "error", err)
+			}
+		}
+	}
+	for _, id := range manifest.Artifacts {
+		content, err := a.getString(ctx, artifactKey(sourceID, id))
+		if err != nil {
+			return fmt.Errorf("failed to restore artifact %s: %w", id, err)
+		}
+		if err := a.artifactRepo.RestoreContent(ctx, id, content); err != nil {
+			return fmt.Errorf("failed to restore artifact %s: %w", id, err)
+		}
+	}
+
+	// 復元後は最終アクセスから再び期限を数える
+	if err := a.updateManifest(ctx, source, nil); err != nil {
+		return err
+	}
+	if err := a.sourceRepo.UpdateStatus(ctx, sourceID, storage.SourceStatusCompleted); err != nil {
+		return fmt.Errorf("failed to update source status: %w", err)
+	}
+	if err := a.sourceRepo.TouchAccess(ctx, sourceID); err != nil {
+		return fmt.Errorf("failed to update access time: %w", err)
+	}
+
+	// 復元できたのでアーカイブ側のコピーを削除
+	for _, f := range manifest.Files {
+		_ = a.store.Delete(ctx, f.Key)
+	}
+	for _, id := range manifest.Artifacts {
+		_ = a.store.Delete(ctx, artifactKey(sourceID, id))
+	}
+
+	slog.Info("Source rehydrated", "source_id", sourceID, "files", len(manifest.Files), "artifacts", len(manifest.Artifacts))
+	return nil
+}
+
+func artifactKey(sourceID, artifactID string) string {
+	return sourceID + "/artifacts/" + artifactID
+}
+
+// putFile はファイルをアーカイブ先に保存し、サイズを返す
+func (a *Archiver) putFile(ctx context.Context, key, path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if err := a.store.Put(ctx, key, f); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// getFile はアーカイブ先からファイルを復元（一時ファイルに書いてからリネーム）
+func (a *Archiver) getFile(ctx context.Context, key, path string) error {
+	r, err := a.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (a *Archiver) getString(ctx context.Context, key string) (string, error) {
+	r, err := a.store.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// updateManifest はメタデータの "archive" を更新（nil の場合は削除）
+func (a *Archiver) updateManifest(ctx context.Context, source *sqlc.Source, manifest *Manifest) error {
+	metadata := map[string]interface{}{}
+	if source.Metadata != nil && *source.Metadata != "" {
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+			return fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+	if manifest != nil {
+		metadata["archive"] = manifest
+	} else {
+		delete(metadata, "archive")
+	}
+
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if err := a.sourceRepo.UpdateMetadata(ctx, source.ID, string(metadataJSON)); err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+	source.Metadata = storage.Ptr(string(metadataJSON))
+	return nil
+}
+
+// readManifest はメタデータからアーカイブのマニフェストを取得
+func readManifest(source *sqlc.Source) (*Manifest, error) {
+	if source.Metadata == nil {
+		return nil, fmt.Errorf("archive manifest not found")
+	}
+	var metadata struct {
+		Archive *Manifest `json:"archive"`
+	}
+	if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	if metadata.Archive == nil {
+		return nil, fmt.Errorf("archive manifest not found")
+	}
+	return metadata.Archive, nil
+}
@@ -1,5 +1,7 @@
 package asr
 
+import "strings"
+
 // AlignmentDiffItem represents a single character in the alignment diff
 type AlignmentDiffItem struct {
 	Char string `json:"char"` // The character
@@ -192,10 +194,20 @@ func buildAlignedTokens(originalTokens []Token, runeToToken []int, whisperRunes
 		char := string(entry.whisperRune)
 		timestamp := interpolateTimestamp(entry.whisperIdx, anchors)
 
+		// Matched characters keep the confidence of the original token;
+		// inserted characters have no score of their own
+		var confidence float32
+		if entry.op == opMatch && entry.origIdx >= 0 && entry.origIdx < len(runeToToken) {
+			if tokenIdx := runeToToken[entry.origIdx]; tokenIdx < len(originalTokens) {
+				confidence = originalTokens[tokenIdx].Confidence
+			}
+		}
+
 		result = append(result, Token{
-			Text:      char,
-			StartTime: timestamp,
-			Duration:  estimateDuration(entry.whisperIdx, anchors, len(whisperRunes)),
+			Text:       char,
+			StartTime:  timestamp,
+			Duration:   estimateDuration(entry.whisperIdx, anchors, len(whisperRunes)),
+			Confidence: confidence,
 		})
 	}
 
@@ -454,9 +466,10 @@ func AlignTokensForSegmentsWithDiff(originalTokens []Token, whisperText string,
 			}
 
 			finalTokens = append(finalTokens, Token{
-				Text:      token.Text,
-				StartTime: newStartTime,
-				Duration:  float32(duration / float64(max(tokenCount, 1))),
+				Text:       token.Text,
+				StartTime:  newStartTime,
+				Duration:   float32(duration / float64(max(tokenCount, 1))),
+				Confidence: token.Confidence,
 			})
 			tokenIndex++
 		}
@@ -473,3 +486,57 @@ func AlignTokensForSegmentsWithDiff(originalTokens []Token, whisperText string,
 
 	return result
 }
+
+// AlignWhisperResult combines the text of a full-file Whisper result with the
+// timestamps of a timing result (ReazonSpeech/SenseVoice) for the same audio.
+// Alignment runs per window of windowSec seconds (Whisper's chunk length) so
+// that long recordings do not need a single quadratic alignment. Windows with
+// no timing tokens keep Whisper's own timestamps. Segments are rebuilt from
+// the aligned tokens
+func AlignWhisperResult(timing, whisper *Result, windowSec float64) *Result {
+	if len(timing.Tokens) == 0 || len(whisper.Tokens) == 0 || windowSec <= 0 {
+		return whisper
+	}
+
+	var tokens []Token
+	wi, ti := 0, 0
+	for wi < len(whisper.Tokens) {
+		window := int(float64(whisper.Tokens[wi].StartTime) / windowSec)
+		start := float32(float64(window) * windowSec)
+		end := float32(float64(window+1) * windowSec)
+
+		first := wi
+		var text strings.Builder
+		for wi < len(whisper.Tokens) && whisper.Tokens[wi].StartTime < end {
+			text.WriteString(whisper.Tokens[wi].Text)
+			wi++
+		}
+
+		// Timing tokens before the window belong to windows Whisper left empty
+		for ti < len(timing.Tokens) && timing.Tokens[ti].StartTime < start {
+			ti++
+		}
+		timingStart := ti
+		for ti < len(timing.Tokens) && timing.Tokens[ti].StartTime < end {
+			ti++
+		}
+
+		aligned := AlignTokensWithText(timing.Tokens[timingStart:ti], text.String())
+		if len(aligned) == 0 {
+			aligned = whisper.Tokens[first:wi]
+		}
+		tokens = append(tokens, aligned...)
+	}
+
+	var text strings.Builder
+	for _, t := range tokens {
+		text.WriteString(t.Text)
+	}
+	return &Result{
+		Text:          text.String(),
+		Tokens:        tokens,
+		Segments:      tokensToSegments(tokens),
+		TotalDuration: max(timing.TotalDuration, whisper.TotalDuration),
+		ChunkReports:  whisper.ChunkReports,
+	}
+}
@@ -0,0 +1,46 @@
+package asr
+
+import "testing"
+
+// TestAlignWhisperResult tests that Whisper text takes the timing tokens' timestamps
+// window by window and that windows without timing tokens keep Whisper's
+func TestAlignWhisperResult(t *testing.T) {
+	timing := &Result{
+		Tokens: []Token{
+			{Text: "こんにちわ", StartTime: 1.0, Duration: 0.5},
+			{Text: "世界", StartTime: 1.6, Duration: 0.4},
+		},
+		TotalDuration: 40,
+	}
+	whisper := &Result{
+		Text: "こんにちは世界さようなら",
+		Tokens: []Token{
+			{Text: "こんにちは", StartTime: 0, Duration: 15},
+			{Text: "世界", StartTime: 15, Duration: 15},
+			{Text: "さようなら", StartTime: 31, Duration: 5},
+		},
+		TotalDuration: 36,
+	}
+
+	result := AlignWhisperResult(timing, whisper, 30)
+	if result.Text != "こんにちは世界さようなら" {
+		t.Errorf("Text = %q", result.Text)
+	}
+	if result.Tokens[0].StartTime != 1.0 {
+		t.Errorf("first token starts at %v, want 1.0 (timing token)", result.Tokens[0].StartTime)
+	}
+	last := result.Tokens[len(result.Tokens)-1]
+	if last.Text != "さようなら" || last.StartTime != 31 {
+		t.Errorf("last token = %+v, want Whisper's token for the window without timing", last)
+	}
+	if result.TotalDuration != 40 {
+		t.Errorf("TotalDuration = %v, want 40", result.TotalDuration)
+	}
+	if len(result.Segments) == 0 {
+		t.Error("segments were not rebuilt")
+	}
+
+	if got := AlignWhisperResult(&Result{}, whisper, 30); got != whisper {
+		t.Error("result without timing tokens should be returned unchanged")
+	}
+}
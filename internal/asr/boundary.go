@@ -1,5 +1,7 @@
 package asr
 
+import "math"
+
 // BoundaryAdjustmentParams contains parameters for boundary adjustment
 type BoundaryAdjustmentParams struct {
 	Threshold    float64 // Audio detection threshold (0-1), default 0.03
@@ -210,3 +212,83 @@ func AdjustBoundaries(peaks []float64, samplesPerSec float64, segmentStart, segm
 
 	return result
 }
+
+// SegmentBoundaryOptions are the parameters of AdjustSegmentBoundary
+type SegmentBoundaryOptions struct {
+	BoundaryAdjustmentParams
+	PaddingMs int // Padding added before/after the adjusted boundaries (ms), default 200
+}
+
+// DefaultSegmentBoundaryOptions returns default segment boundary adjustment options
+func DefaultSegmentBoundaryOptions() SegmentBoundaryOptions {
+	return SegmentBoundaryOptions{
+		BoundaryAdjustmentParams: DefaultBoundaryParams(),
+		PaddingMs:                200,
+	}
+}
+
+// Clamp replaces values outside the usable ranges (threshold 0-0.1, merge gap
+// up to 500ms, search window up to 2000ms, padding 0-500ms) with the defaults
+func (o *SegmentBoundaryOptions) Clamp() {
+	defaults := DefaultSegmentBoundaryOptions()
+	if o.Threshold <= 0 || o.Threshold > 0.1 {
+		o.Threshold = defaults.Threshold
+	}
+	if o.MergeGapMs <= 0 || o.MergeGapMs > 500 {
+		o.MergeGapMs = defaults.MergeGapMs
+	}
+	if o.SearchWindow <= 0 || o.SearchWindow > 2000 {
+		o.SearchWindow = defaults.SearchWindow
+	}
+	if o.PaddingMs < 0 || o.PaddingMs > 500 {
+		o.PaddingMs = defaults.PaddingMs
+	}
+}
+
+// AdjustSegmentBoundary adjusts the boundaries of segments[idx] to the audio
+// activity around it (see AdjustBoundaries) and pads them by PaddingMs.
+// The adjusted times stay within the audio (duration, 0 for no limit) and do
+// not overlap the previous and next segments. Start/EndExtendedMs include the padding
+func AdjustSegmentBoundary(peaks []float64, samplesPerSec, duration float64, segments []Segment, idx int, opts SegmentBoundaryOptions) BoundaryAdjustmentResult {
+	seg := segments[idx]
+	result := AdjustBoundaries(peaks, samplesPerSec, seg.StartTime, seg.EndTime, opts.BoundaryAdjustmentParams)
+
+	paddingSec := float64(opts.PaddingMs) / 1000.0
+	start := result.AdjustedStart - paddingSec
+	end := result.AdjustedEnd + paddingSec
+
+	minStart := 0.0
+	if idx > 0 {
+		minStart = max(minStart, segments[idx-1].EndTime)
+	}
+	maxEnd := duration
+	if idx+1 < len(segments) && (maxEnd <= 0 || segments[idx+1].StartTime < maxEnd) {
+		maxEnd = segments[idx+1].StartTime
+	}
+	// Boundaries are only moved outward: a neighbor that already overlaps
+	// the segment keeps the original boundary
+	start = max(start, min(minStart, seg.StartTime))
+	if maxEnd > 0 {
+		end = min(end, max(maxEnd, seg.EndTime))
+	}
+
+	result.AdjustedStart = start
+	result.AdjustedEnd = end
+	result.StartExtendedMs = int(math.Round((seg.StartTime - start) * 1000))
+	result.EndExtendedMs = int(math.Round((end - seg.EndTime) * 1000))
+	return result
+}
+
+// AdjustAllBoundaries runs AdjustSegmentBoundary over every segment in order
+// and updates their times in place. Each segment is limited by the already
+// adjusted previous segment, so segments never overlap after the adjustment.
+// The results are returned in segment order
+func AdjustAllBoundaries(peaks []float64, samplesPerSec, duration float64, segments []Segment, opts SegmentBoundaryOptions) []BoundaryAdjustmentResult {
+	results := make([]BoundaryAdjustmentResult, len(segments))
+	for idx := range segments {
+		results[idx] = AdjustSegmentBoundary(peaks, samplesPerSec, duration, segments, idx, opts)
+		segments[idx].StartTime = results[idx].AdjustedStart
+		segments[idx].EndTime = results[idx].AdjustedEnd
+	}
+	return results
+}
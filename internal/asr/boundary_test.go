@@ -0,0 +1,106 @@
+package asr
+
+import (
+	"math"
+	"testing"
+)
+
+// approx reports whether a and b are equal to the millisecond
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 0.001
+}
+
+// TestAdjustSegmentBoundary tests extending a segment to the audio around it,
+// the padding and the limits of the neighboring segments and the audio
+func TestAdjustSegmentBoundary(t *testing.T) {
+	// 10 peaks/sec: audio at 1.0-3.0s and 4.0-6.5s
+	peaks := make([]float64, 80)
+	for i := 10; i < 30; i++ {
+		peaks[i] = 0.5
+	}
+	for i := 40; i < 65; i++ {
+		peaks[i] = 0.5
+	}
+	opts := DefaultSegmentBoundaryOptions()
+	opts.PaddingMs = 100
+
+	// The ASR boundaries cut into the speech on both sides
+	segments := []Segment{{StartTime: 1.5, EndTime: 2.5}}
+	r := AdjustSegmentBoundary(peaks, 10, 8, segments, 0, opts)
+	if !approx(r.AdjustedStart, 0.9) || !approx(r.AdjustedEnd, 3.0) {
+		t.Errorf("adjusted = %.3f-%.3f, want 0.900-3.000", r.AdjustedStart, r.AdjustedEnd)
+	}
+	if r.StartExtendedMs != 600 || r.EndExtendedMs != 500 {
+		t.Errorf("extended = %d/%d ms, want 600/500", r.StartExtendedMs, r.EndExtendedMs)
+	}
+
+	// Neighbors limit the adjustment
+	segments = []Segment{
+		{StartTime: 0, EndTime: 1.2},
+		{StartTime: 1.5, EndTime: 2.5},
+		{StartTime: 2.8, EndTime: 3.5},
+	}
+	r = AdjustSegmentBoundary(peaks, 10, 8, segments, 1, opts)
+	if !approx(r.AdjustedStart, 1.2) || !approx(r.AdjustedEnd, 2.8) {
+		t.Errorf("adjusted = %.3f-%.3f, want 1.200-2.800", r.AdjustedStart, r.AdjustedEnd)
+	}
+
+	// An overlapping neighbor keeps the original boundary; padding stops at the end of the audio
+	segments = []Segment{
+		{StartTime: 4.0, EndTime: 4.6},
+		{StartTime: 4.5, EndTime: 7.9},
+	}
+	r = AdjustSegmentBoundary(peaks, 10, 8, segments, 1, opts)
+	if !approx(r.AdjustedStart, 4.5) || !approx(r.AdjustedEnd, 8.0) {
+		t.Errorf("adjusted = %.3f-%.3f, want 4.500-8.000", r.AdjustedStart, r.AdjustedEnd)
+	}
+}
+
+// TestSegmentBoundaryOptionsClamp tests replacing out-of-range options with the defaults
+func TestSegmentBoundaryOptionsClamp(t *testing.T) {
+	opts := SegmentBoundaryOptions{
+		BoundaryAdjustmentParams: BoundaryAdjustmentParams{Threshold: 0.5, MergeGapMs: 200, SearchWindow: 5000},
+		PaddingMs:                0,
+	}
+	opts.Clamp()
+	want := SegmentBoundaryOptions{
+		BoundaryAdjustmentParams: BoundaryAdjustmentParams{Threshold: 0.03, MergeGapMs: 200, SearchWindow: 1000},
+		PaddingMs:                0,
+	}
+	if opts != want {
+		t.Errorf("clamped = %+v, want %+v", opts, want)
+	}
+}
+
+// TestAdjustAllBoundaries tests that each segment is limited by the adjusted
+// previous segment
+func TestAdjustAllBoundaries(t *testing.T) {
+	// 10 peaks/sec: audio at 1.0-3.0s and 4.0-6.5s
+	peaks := make([]float64, 80)
+	for i := 10; i < 30; i++ {
+		peaks[i] = 0.5
+	}
+	for i := 40; i < 65; i++ {
+		peaks[i] = 0.5
+	}
+	opts := DefaultSegmentBoundaryOptions()
+	opts.PaddingMs = 100
+
+	segments := []Segment{
+		{StartTime: 1.5, EndTime: 2.5},
+		{StartTime: 3.05, EndTime: 3.5},
+	}
+	results := AdjustAllBoundaries(peaks, 10, 8, segments, opts)
+	if len(results) != 2 {
+		t.Fatalf("results = %d, want 2", len(results))
+	}
+	want := [][2]float64{{0.9, 3.0}, {3.0, 3.6}}
+	for i, seg := range segments {
+		if !approx(seg.StartTime, want[i][0]) || !approx(seg.EndTime, want[i][1]) {
+			t.Errorf("segment %d = %.3f-%.3f, want %.3f-%.3f", i, seg.StartTime, seg.EndTime, want[i][0], want[i][1])
+		}
+	}
+	if results[1].StartExtendedMs != 50 || results[1].EndExtendedMs != 100 {
+		t.Errorf("segment 1 extended = %d/%d ms, want 50/100", results[1].StartExtendedMs, results[1].EndExtendedMs)
+	}
+}
@@ -0,0 +1,52 @@
+package asr
+
+import (
+	"strings"
+	"unicode"
+)
+
+// CharacterErrorRate returns the character error rate of hypothesis against
+// reference: the edit distance divided by the reference length. Whitespace
+// and punctuation are ignored, since the models differ in how they output them
+func CharacterErrorRate(reference, hypothesis string) float64 {
+	ref := cerRunes(reference)
+	hyp := cerRunes(hypothesis)
+	if len(ref) == 0 {
+		if len(hyp) == 0 {
+			return 0
+		}
+		return 1
+	}
+	return float64(editDistance(ref, hyp)) / float64(len(ref))
+}
+
+// cerRunes returns the characters of s compared by CharacterErrorRate
+func cerRunes(s string) []rune {
+	return []rune(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) {
+			return -1
+		}
+		return r
+	}, s))
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
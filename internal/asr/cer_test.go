@@ -0,0 +1,50 @@
+package asr
+
+import (
+	"math"
+	"testing"
+)
+
+// TestCharacterErrorRate tests the CER with whitespace and punctuation ignored
+func TestCharacterErrorRate(t *testing.T) {
+	tests := []struct {
+		name       string
+		reference  string
+		hypothesis string
+		want       float64
+	}{
+		{"exact", "今日は晴れです", "今日は晴れです", 0},
+		{"punctuation ignored", "今日は、晴れです。", "今日は 晴れです", 0},
+		{"one substitution", "あいうえ", "あいおえ", 0.25},
+		{"deletions", "あいうえ", "あい", 0.5},
+		{"insertions can exceed one", "あい", "あいうえおか", 2},
+		{"empty reference", "", "あ", 1},
+		{"both empty", "", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CharacterErrorRate(tt.reference, tt.hypothesis); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("CharacterErrorRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestEditDistance tests the rune-level Levenshtein distance
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "あい", 2},
+		{"あいう", "あいう", 0},
+		{"あいう", "あえう", 1},
+		{"今日は晴れ", "今日晴れです", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package asr
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ChapterOptions controls topic segmentation of long transcripts
+type ChapterOptions struct {
+	BlockDuration  float64 // seconds of transcript per comparison block
+	Window         int     // blocks compared on each side of a candidate boundary
+	MinDuration    float64 // minimum chapter length in seconds
+	TargetDuration float64 // typical chapter length; limits the number of chapters
+	PauseWeight    float64 // score bonus for a boundary at a long pause (scaled from 1s to 10s)
+	MinScore       float64 // boundaries scoring lower are never used
+	MaxTitleLength int     // title length in characters
+	MaxDuration    float64 // longer chapters are split into equal parts at cue boundaries (0 = no limit)
+}
+
+// DefaultChapterOptions returns options tuned for 1-2 hour meetings
+func DefaultChapterOptions() ChapterOptions {
+	return ChapterOptions{
+		BlockDuration:  30,
+		Window:         4,
+		MinDuration:    180,
+		TargetDuration: 600,
+		PauseWeight:    0.3,
+		MinScore:       0.1,
+		MaxTitleLength: 30,
+		MaxDuration:    1800,
+	}
+}
+
+// Chapter is a topical section of a transcript
+type Chapter struct {
+	Title     string  `json:"title"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	Text      string  `json:"text"`
+}
+
+// chapterBlock is a run of cues compared as one unit
+type chapterBlock struct {
+	first, last int // cue indexes (inclusive)
+	start, end  float64
+	terms       map[string]float64
+}
+
+// Chapters splits the transcript into topical chapters
+//
+// The transcript is cut into fixed-length blocks and a boundary is scored
+// between each pair of blocks by how much the vocabulary changes across it
+// (TextTiling-style depth of the similarity curve), plus a bonus for a long
+// pause at that point. The best boundaries are kept subject to the minimum
+// chapter length. Chapters longer than MaxDuration (long stretches without a
+// clear topic change) are then split into equal parts. Returns a single
+// chapter for short transcripts and nil when there is no text.
+func (r *Result) Chapters(opts ChapterOptions) []Chapter {
+	cues := r.Cues()
+	if len(cues) == 0 {
+		return nil
+	}
+
+	blocks := chapterBlocks(cues, opts.BlockDuration)
+	total := cues[len(cues)-1].EndTime - cues[0].StartTime
+
+	var boundaries []int // block indexes starting a new chapter
+	if len(blocks) > 2 && total >= 2*opts.MinDuration {
+		boundaries = selectBoundaries(blocks, opts, total)
+	}
+
+	chapters := make([]Chapter, 0, len(boundaries)+1)
+	startBlock := 0
+	for _, b := range append(boundaries, len(blocks)) {
+		for _, part := range splitCueRange(cues, blocks[startBlock].first, blocks[b-1].last, opts.MaxDuration) {
+			first, last := part[0], part[1]
+			text := joinCueText(cues[first : last+1])
+			chapters = append(chapters, Chapter{
+				Title:     chapterTitle(text, opts.MaxTitleLength),
+				StartTime: cues[first].StartTime,
+				EndTime:   cues[last].EndTime,
+				Text:      text,
+			})
+		}
+		startBlock = b
+	}
+	return chapters
+}
+
+// splitCueRange splits cues[first..last] into the fewest parts of about equal
+// length that are no longer than maxDuration, cutting at cue boundaries.
+// Returns the inclusive cue index range of each part
+func splitCueRange(cues []Segment, first, last int, maxDuration float64) [][2]int {
+	duration := cues[last].EndTime - cues[first].StartTime
+	if maxDuration <= 0 || duration <= maxDuration {
+		return [][2]int{{first, last}}
+	}
+
+	n := int(math.Ceil(duration / maxDuration))
+	length := duration / float64(n)
+	var parts [][2]int
+	start := first
+	for k := 1; k < n; k++ {
+		at := cues[first].StartTime + float64(k)*length
+		// The part ends before the first cue starting at or after the cut
+		end := start
+		for end < last && cues[end+1].StartTime < at {
+			end++
+		}
+		if end == last {
+			break
+		}
+		parts = append(parts, [2]int{start, end})
+		start = end + 1
+	}
+	return append(parts, [2]int{start, last})
+}
+
+// chapterBlocks groups consecutive cues into blocks of about blockDuration seconds
+func chapterBlocks(cues []Segment, blockDuration float64) []chapterBlock {
+	var blocks []chapterBlock
+	for idx, cue := range cues {
+		if len(blocks) == 0 || blocks[len(blocks)-1].end-blocks[len(blocks)-1].start >= blockDuration {
+			blocks = append(blocks, chapterBlock{
+				first: idx,
+				start: cue.StartTime,
+				terms: map[string]float64{},
+			})
+		}
+		b := &blocks[len(blocks)-1]
+		b.last = idx
+		b.end = cue.EndTime
+		for _, term := range chapterTerms(cue.Text) {
+			b.terms[term]++
+		}
+	}
+	return blocks
+}
+
+// selectBoundaries scores each block boundary and keeps the strongest ones
+func selectBoundaries(blocks []chapterBlock, opts ChapterOptions, total float64) []int {
+	window := max(opts.Window, 1)
+
+	// Similarity between the windows on each side of boundary i (before block i)
+	sims := make([]float64, len(blocks))
+	for i := 1; i < len(blocks); i++ {
+		left := mergeTerms(blocks[max(0, i-window):i])
+		right := mergeTerms(blocks[i:min(len(blocks), i+window)])
+		sims[i] = cosine(left, right)
+	}
+
+	type candidate struct {
+		block int
+		score float64
+	}
+	var candidates []candidate
+	for i := 1; i < len(blocks); i++ {
+		// Depth: how far the similarity drops below the nearest peaks on each side
+		leftPeak := sims[i]
+		for j := i - 1; j >= 1 && sims[j] >= leftPeak; j-- {
+			leftPeak = sims[j]
+		}
+		rightPeak := sims[i]
+		for j := i + 1; j < len(blocks) && sims[j] >= rightPeak; j++ {
+			rightPeak = sims[j]
+		}
+		score := (leftPeak - sims[i]) + (rightPeak - sims[i])
+
+		pause := blocks[i].start - blocks[i-1].end
+		score += opts.PauseWeight * math.Min(math.Max(pause-1, 0), 9) / 9
+
+		candidates = append(candidates, candidate{block: i, score: score})
+	}
+
+	// Keep boundaries clearly above average (mean - stddev/2, as in TextTiling)
+	var sum, sumSq float64
+	for _, c := range candidates {
+		sum += c.score
+		sumSq += c.score * c.score
+	}
+	mean := sum / float64(len(candidates))
+	threshold := mean - math.Sqrt(math.Max(sumSq/float64(len(candidates))-mean*mean, 0))/2
+
+	maxBoundaries := len(blocks) - 1
+	if opts.TargetDuration > 0 {
+		maxBoundaries = int(math.Ceil(total/opts.TargetDuration)) - 1
+	}
+
+	sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].score > candidates[b].score })
+
+	start, end := blocks[0].start, blocks[len(blocks)-1].end
+	var selected []int
+	for _, c := range candidates {
+		if len(selected) >= maxBoundaries {
+			break
+		}
+		if c.score < opts.MinScore || c.score < threshold {
+			break
+		}
+		at := blocks[c.block].start
+		if at-start < opts.MinDuration || end-at < opts.MinDuration {
+			continue
+		}
+		tooClose := false
+		for _, s := range selected {
+			if math.Abs(blocks[s].start-at) < opts.MinDuration {
+				tooClose = true
+				break
+			}
+		}
+		if !tooClose {
+			selected = append(selected, c.block)
+		}
+	}
+
+	sort.Ints(selected)
+	return selected
+}
+
+// chapterTerms extracts the terms compared between blocks
+// Latin words of 3+ letters, and character bigrams containing a kanji or
+// katakana (hiragana-only bigrams are mostly particles and endings)
+func chapterTerms(text string) []string {
+	var terms []string
+	var word []rune
+	flushWord := func() {
+		if len(word) >= 3 {
+			terms = append(terms, strings.ToLower(string(word)))
+		}
+		word = word[:0]
+	}
+
+	var prev rune
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word = append(word, r)
+			prev = 0
+			continue
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hiragana, r):
+			if prev != 0 && (isContentRune(prev) || isContentRune(r)) {
+				terms = append(terms, string([]rune{prev, r}))
+			}
+			prev = r
+		default:
+			prev = 0
+		}
+		flushWord()
+	}
+	flushWord()
+	return terms
+}
+
+// isContentRune reports whether r is a kanji or katakana (not the prolonged sound mark)
+func isContentRune(r rune) bool {
+	return unicode.Is(unicode.Han, r) || (unicode.Is(unicode.Katakana, r) && r != 'ー')
+}
+
+func mergeTerms(blocks []chapterBlock) map[string]float64 {
+	merged := map[string]float64{}
+	for _, b := range blocks {
+		for term, n := range b.terms {
+			merged[term] += n
+		}
+	}
+	return merged
+}
+
+func cosine(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for term, n := range a {
+		normA += n * n
+		dot += n * b[term]
+	}
+	for _, n := range b {
+		normB += n * n
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+func joinCueText(cues []Segment) string {
+	var sb strings.Builder
+	for _, cue := range cues {
+		sb.WriteString(cue.Text)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// chapterTitle uses the first sentence of the chapter, shortened to maxLen characters
+func chapterTitle(text string, maxLen int) string {
+	title := text
+	if idx := strings.IndexAny(title, "。！？!?\n"); idx >= 0 {
+		title = title[:idx]
+	}
+	title = strings.TrimSpace(title)
+	if maxLen > 0 && utf8.RuneCountInString(title) > maxLen {
+		title = string([]rune(title)[:maxLen]) + "…"
+	}
+	return title
+}
@@ -0,0 +1,91 @@
+package asr
+
+import (
+	"fmt"
+	"testing"
+)
+
+// topicSegments returns n 10-second cues starting at start, all about one topic
+func topicSegments(start float64, n int, text string) []Segment {
+	segs := make([]Segment, n)
+	for i := range segs {
+		t := start + float64(i)*10
+		segs[i] = Segment{Text: fmt.Sprintf("%s%d。", text, i), StartTime: t, EndTime: t + 9}
+	}
+	return segs
+}
+
+// TestChapters tests that a topic change is detected as a chapter boundary
+func TestChapters(t *testing.T) {
+	var segs []Segment
+	segs = append(segs, topicSegments(0, 60, "来期の予算について資料を確認します")...)
+	segs = append(segs, topicSegments(605, 60, "新卒採用の面接日程と候補者を調整します")...)
+	r := &Result{Segments: segs}
+
+	chapters := r.Chapters(DefaultChapterOptions())
+	if len(chapters) != 2 {
+		t.Fatalf("got %d chapters, want 2: %+v", len(chapters), chapters)
+	}
+	if chapters[1].StartTime != 605 {
+		t.Errorf("second chapter starts at %v, want 605", chapters[1].StartTime)
+	}
+	if chapters[0].Title != "来期の予算について資料を確認します0" {
+		t.Errorf("first chapter title = %q", chapters[0].Title)
+	}
+
+	// Short transcripts are a single chapter
+	short := &Result{Segments: topicSegments(0, 10, "短い会議です")}
+	if got := len(short.Chapters(DefaultChapterOptions())); got != 1 {
+		t.Errorf("short transcript: got %d chapters, want 1", got)
+	}
+
+	if got := (&Result{}).Chapters(DefaultChapterOptions()); got != nil {
+		t.Errorf("empty transcript: got %+v, want nil", got)
+	}
+}
+
+// TestChapterTitle tests title extraction from the first sentence
+func TestChapterTitle(t *testing.T) {
+	tests := []struct {
+		text   string
+		maxLen int
+		want   string
+	}{
+		{"では次の議題です。予算について", 30, "では次の議題です"},
+		{"あいうえおかきくけこ", 5, "あいうえお…"},
+		{"Next topic! Budget", 30, "Next topic"},
+	}
+	for _, tt := range tests {
+		if got := chapterTitle(tt.text, tt.maxLen); got != tt.want {
+			t.Errorf("chapterTitle(%q, %d) = %q, want %q", tt.text, tt.maxLen, got, tt.want)
+		}
+	}
+}
+
+// TestChaptersMaxDuration tests that long single-topic transcripts are split into equal parts
+func TestChaptersMaxDuration(t *testing.T) {
+	// 70 minutes of one topic
+	r := &Result{Segments: topicSegments(0, 420, "来期の予算について資料を確認します")}
+
+	chapters := r.Chapters(DefaultChapterOptions())
+	if len(chapters) != 3 {
+		t.Fatalf("got %d chapters, want 3: %+v", len(chapters), chapters)
+	}
+	for i, ch := range chapters {
+		if d := ch.EndTime - ch.StartTime; d > 1800 {
+			t.Errorf("chapter %d is %v seconds long", i, d)
+		}
+		if i > 0 && ch.StartTime <= chapters[i-1].EndTime-9 {
+			t.Errorf("chapter %d starts at %v, before the previous one ends (%v)", i, ch.StartTime, chapters[i-1].EndTime)
+		}
+	}
+	if chapters[1].StartTime != 1400 {
+		t.Errorf("second chapter starts at %v, want 1400", chapters[1].StartTime)
+	}
+
+	opts := DefaultChapterOptions()
+	opts.MaxDuration = 0
+	if got := len(r.Chapters(opts)); got != 1 {
+		t.Errorf("without a limit: got %d chapters, want 1", got)
+	}
+}
@@ -0,0 +1,169 @@
+package asr
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// minFinalChunkSec is the shortest final chunk decoded on its own. A
+	// shorter tail is merged into the previous chunk, or (when the merged
+	// chunk would exceed the model's maximum input) the last two chunks are
+	// split evenly. Very short inputs are dropped or misdecoded by the models
+	minFinalChunkSec = 5
+	// tailPaddingSec of silence is appended to the final chunk for transducer
+	// models, which otherwise tend to drop the last tokens at the end of speech
+	tailPaddingSec = 0.5
+	// trailingSilenceRMS is the level below which the end of the audio is
+	// treated as silence and not decoded (same as the production silence threshold)
+	trailingSilenceRMS = 0.0003
+	// maxChunkErrors is the number of error messages kept in a ChunkReport
+	maxChunkErrors = 10
+)
+
+// How the final chunk of a file was handled (ChunkReport.FinalChunk)
+const (
+	FinalChunkMerged     = "merged"     // appended to the previous chunk
+	FinalChunkRebalanced = "rebalanced" // the last two chunks were split evenly
+)
+
+// ChunkReport describes how one file was decoded in chunk mode
+// (fixed-length chunks without VAD)
+type ChunkReport struct {
+	File                   string   `json:"file,omitempty"`
+	Chunks                 int      `json:"chunks"`                             // chunks decoded
+	FailedChunks           int      `json:"failed_chunks,omitempty"`            // chunks whose decoding failed
+	SkippedSeconds         float64  `json:"skipped_seconds"`                    // audio not transcribed (failed chunks, decoder errors)
+	TrailingSilenceSeconds float64  `json:"trailing_silence_seconds,omitempty"` // silence at the end that was not decoded
+	FinalChunk             string   `json:"final_chunk,omitempty"`              // FinalChunk* value, empty when decoded as is
+	Errors                 []string `json:"errors,omitempty"`                   // first maxChunkErrors errors
+}
+
+// addError records an error that left seconds of audio untranscribed
+func (r *ChunkReport) addError(err error, seconds float64) {
+	r.SkippedSeconds += seconds
+	if len(r.Errors) < maxChunkErrors {
+		r.Errors = append(r.Errors, err.Error())
+	}
+}
+
+// finish records the error that stopped the decoder (e.g. the exit status
+// of ffmpeg): audio after a failure is counted as skipped. It returns an error when nothing could be
+// transcribed
+func (r *ChunkReport) finish(waitErr error, durationSec, processedSec float64) error {
+	if waitErr != nil {
+		r.addError(fmt.Errorf("decoder: %w", waitErr), math.Max(durationSec-processedSec, 0))
+	}
+	if r.Chunks > 0 && r.FailedChunks == r.Chunks {
+		return fmt.Errorf("all %d chunks failed: %s", r.Chunks, r.Errors[0])
+	}
+	if r.Chunks == 0 && waitErr != nil {
+		return fmt.Errorf("decoding failed: %w", waitErr)
+	}
+	r.SkippedSeconds = math.Round(r.SkippedSeconds*100) / 100
+	r.TrailingSilenceSeconds = math.Round(r.TrailingSilenceSeconds*100) / 100
+	return nil
+}
+
+// chunkDecoder decodes one chunk starting offset samples into the file.
+// samples may end with padding that is not part of the audio
+type chunkDecoder func(samples []float32, offset int) error
+
+// readChunks reads 16-bit mono PCM from r in chunks of chunkSamples and
+// decodes each one. The end of the audio is handled explicitly: trailing
+// silence is not decoded, a final chunk shorter than minFinalChunkSec is
+// merged into the previous one (or the two are split evenly when the merged
+// chunk would exceed maxSamples; 0 means no limit), and tailPadding samples
+// of silence are appended to the last chunk. Failed chunks are counted in
+// report and do not stop the remaining chunks; a cancelled ctx stops
+// reading with ctx.Err(). It returns the number of samples read
+func readChunks(ctx context.Context, r io.Reader, sampleRate, chunkSamples, maxSamples, tailPadding int, report *ChunkReport, decode chunkDecoder) (int, error) {
+	reader := bufio.NewReader(r)
+	minFinal := sampleRate * minFinalChunkSec
+	seconds := func(n int) float64 { return float64(n) / float64(sampleRate) }
+
+	cur, err := readPCM(reader, chunkSamples)
+	if err != nil {
+		return 0, err
+	}
+	offset := 0
+	for len(cur) > 0 {
+		if err := ctx.Err(); err != nil {
+			return offset, err
+		}
+		next, err := readPCM(reader, chunkSamples)
+		if err != nil {
+			return offset, err
+		}
+
+		// A short read means next is the final chunk (or cur is, when next is empty)
+		if len(next) < chunkSamples {
+			var silence int
+			next, silence = trimTrailingSilence(next, sampleRate)
+			if len(next) == 0 {
+				var s int
+				cur, s = trimTrailingSilence(cur, sampleRate)
+				silence += s
+			}
+			report.TrailingSilenceSeconds += seconds(silence)
+
+			if len(next) > 0 && len(next) < minFinal {
+				all := append(cur, next...)
+				if maxSamples <= 0 || len(all) <= maxSamples {
+					cur, next = all, nil
+					report.FinalChunk = FinalChunkMerged
+				} else {
+					half := len(all) / 2
+					cur, next = all[:half:half], all[half:]
+					report.FinalChunk = FinalChunkRebalanced
+				}
+			}
+		}
+
+		if len(cur) > 0 {
+			samples := cur
+			if len(next) == 0 && tailPadding > 0 {
+				samples = append(cur[:len(cur):len(cur)], make([]float32, tailPadding)...)
+			}
+			report.Chunks++
+			if err := decode(samples, offset); err != nil {
+				report.FailedChunks++
+				report.addError(fmt.Errorf("chunk at %.1fs: %w", seconds(offset), err), seconds(len(cur)))
+			}
+		}
+
+		offset += len(cur)
+		cur = next
+	}
+	// Cancellation ends the decoded audio like a normal end of audio
+	return offset, ctx.Err()
+}
+
+// readPCM reads up to n samples of 16-bit PCM. It returns fewer samples
+// (or none) at the end of the stream
+func readPCM(reader io.Reader, n int) ([]float32, error) {
+	buffer := make([]byte, n*2)
+	read, err := io.ReadFull(reader, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return bytesToFloat32SV(buffer[:read]), nil
+}
+
+// trimTrailingSilence removes silence (in 100ms windows) from the end of
+// samples and returns the rest and the number of samples removed
+func trimTrailingSilence(samples []float32, sampleRate int) ([]float32, int) {
+	window := max(sampleRate/10, 1)
+	end := len(samples)
+	for end > 0 {
+		start := max(end-window, 0)
+		if calculateRMS(samples[start:end]) >= trailingSilenceRMS {
+			break
+		}
+		end = start
+	}
+	return samples[:end], len(samples) - end
+}
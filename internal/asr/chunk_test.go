@@ -0,0 +1,147 @@
+package asr
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// pcmBytes encodes samples as 16-bit little-endian PCM
+func pcmBytes(samples []float32) []byte {
+	buf := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(buf[i*2:], uint16(int16(s*32767)))
+	}
+	return buf
+}
+
+// tone returns n samples of constant (non-silent) audio followed by silence samples
+func tone(n, silence int) []float32 {
+	samples := make([]float32, n+silence)
+	for i := 0; i < n; i++ {
+		samples[i] = 0.1
+	}
+	return samples
+}
+
+// TestReadChunks tests the handling of the final chunk: merging, rebalancing,
+// trailing silence and tail padding
+func TestReadChunks(t *testing.T) {
+	const rate = 10 // 10 samples per second: chunkSec 10 = 100 samples, minFinalChunkSec = 50 samples
+	tests := []struct {
+		name        string
+		audio       []float32
+		maxSamples  int
+		tailPadding int
+		wantLens    []int // decoded chunk lengths (including padding)
+		wantOffsets []int
+		wantFinal   string
+		wantSilence float64
+	}{
+		{"exact chunks", tone(200, 0), 0, 0, []int{100, 100}, []int{0, 100}, "", 0},
+		{"long final chunk", tone(260, 0), 0, 0, []int{100, 100, 60}, []int{0, 100, 200}, "", 0},
+		{"short final chunk is merged", tone(230, 0), 0, 0, []int{100, 130}, []int{0, 100}, FinalChunkMerged, 0},
+		{"rebalanced when too long to merge", tone(230, 0), 120, 0, []int{100, 65, 65}, []int{0, 100, 165}, FinalChunkRebalanced, 0},
+		{"trailing silence is not decoded", tone(200, 100), 0, 0, []int{100, 100}, []int{0, 100}, "", 10},
+		{"silence before a short tail", tone(120, 70), 0, 0, []int{120}, []int{0}, FinalChunkMerged, 7},
+		{"tail padding on the last chunk", tone(150, 0), 0, 5, []int{100, 55}, []int{0, 100}, "", 0},
+		{"shorter than one chunk", tone(30, 0), 0, 5, []int{35}, []int{0}, "", 0},
+		{"silence only", tone(0, 150), 0, 5, nil, nil, "", 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lens, offsets []int
+			var report ChunkReport
+			n, err := readChunks(context.Background(), bytes.NewReader(pcmBytes(tt.audio)), rate, 100, tt.maxSamples, tt.tailPadding, &report, func(samples []float32, offset int) error {
+				lens = append(lens, len(samples))
+				offsets = append(offsets, offset)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(lens, tt.wantLens) || !reflect.DeepEqual(offsets, tt.wantOffsets) {
+				t.Errorf("chunks = %v at %v, want %v at %v", lens, offsets, tt.wantLens, tt.wantOffsets)
+			}
+			if report.FinalChunk != tt.wantFinal {
+				t.Errorf("FinalChunk = %q, want %q", report.FinalChunk, tt.wantFinal)
+			}
+			if report.TrailingSilenceSeconds != tt.wantSilence {
+				t.Errorf("TrailingSilenceSeconds = %v, want %v", report.TrailingSilenceSeconds, tt.wantSilence)
+			}
+			if report.Chunks != len(tt.wantLens) {
+				t.Errorf("Chunks = %d, want %d", report.Chunks, len(tt.wantLens))
+			}
+			if wantRead := len(tt.audio) - int(tt.wantSilence*rate); n != wantRead {
+				t.Errorf("read %d samples, want %d", n, wantRead)
+			}
+		})
+	}
+}
+
+// TestReadChunksErrors tests that failed chunks are skipped and accounted for
+func TestReadChunksErrors(t *testing.T) {
+	var report ChunkReport
+	decoded := 0
+	_, err := readChunks(context.Background(), bytes.NewReader(pcmBytes(tone(260, 0))), 10, 100, 0, 0, &report, func(samples []float32, offset int) error {
+		if offset == 100 {
+			return errors.New("invalid input shape")
+		}
+		decoded++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != 2 || report.Chunks != 3 || report.FailedChunks != 1 {
+		t.Errorf("decoded %d, Chunks = %d, FailedChunks = %d, want 2, 3, 1", decoded, report.Chunks, report.FailedChunks)
+	}
+	if err := report.finish(nil, 26, 26); err != nil {
+		t.Fatal(err)
+	}
+	if report.SkippedSeconds != 10 {
+		t.Errorf("SkippedSeconds = %v, want 10", report.SkippedSeconds)
+	}
+	if want := []string{"chunk at 10.0s: invalid input shape"}; !reflect.DeepEqual(report.Errors, want) {
+		t.Errorf("Errors = %v, want %v", report.Errors, want)
+	}
+
+	// A decoder failure after 20 of 26 seconds skips the rest
+	if err := report.finish(errors.New("exit status 1"), 26, 20); err != nil {
+		t.Fatal(err)
+	}
+	if report.SkippedSeconds != 16 {
+		t.Errorf("SkippedSeconds after ffmpeg failure = %v, want 16", report.SkippedSeconds)
+	}
+
+	failed := ChunkReport{}
+	readChunks(context.Background(), bytes.NewReader(pcmBytes(tone(150, 0))), 10, 100, 0, 0, &failed, func([]float32, int) error {
+		return errors.New("broken model")
+	})
+	if err := failed.finish(nil, 15, 15); err == nil {
+		t.Error("finish() = nil, want an error when every chunk failed")
+	}
+}
+
+// TestReadChunksCancel tests that a cancelled context stops decoding
+func TestReadChunksCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var report ChunkReport
+	decoded := 0
+	_, err := readChunks(ctx, bytes.NewReader(pcmBytes(tone(500, 0))), 10, 100, 0, 0, &report, func(samples []float32, offset int) error {
+		decoded++
+		if decoded == 2 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if decoded != 2 {
+		t.Errorf("decoded %d chunks, want 2", decoded)
+	}
+}
@@ -0,0 +1,117 @@
+package asr
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// Clip formats
+const (
+	ClipFormatWAV  = "wav"  // 16-bit mono PCM at the sample rate of the source (no ffmpeg needed)
+	ClipFormatMP3  = "mp3"  // MP3 (ffmpeg)
+	ClipFormatOpus = "opus" // Opus in Ogg (ffmpeg)
+)
+
+// ClipContentTypes maps clip formats to response content types
+var ClipContentTypes = map[string]string{
+	ClipFormatWAV:  "audio/wav",
+	ClipFormatMP3:  "audio/mpeg",
+	ClipFormatOpus: "audio/ogg; codecs=opus",
+}
+
+// ClipExtensions maps clip formats to file extensions
+var ClipExtensions = map[string]string{
+	ClipFormatWAV:  ".wav",
+	ClipFormatMP3:  ".mp3",
+	ClipFormatOpus: ".ogg",
+}
+
+// MaxClipDuration is the longest span ExtractClip accepts (seconds)
+const MaxClipDuration = 3600
+
+// tempClipPattern is the name pattern of the clips written by ExtractClipTemp
+const tempClipPattern = "zbor-clip-*"
+
+// DefaultClipFormat returns MP3 when ffmpeg is available and WAV otherwise
+func DefaultClipFormat() string {
+	if FFmpegAvailable() {
+		return ClipFormatMP3
+	}
+	return ClipFormatWAV
+}
+
+// ExtractClip writes the audio of inputPath from start to end (seconds) to
+// outputPath in format. Only the requested span is decoded; a span past the
+// end of the audio is cut at the end. MP3 and Opus need ffmpeg (ErrNoFFmpeg)
+func ExtractClip(ctx context.Context, inputPath, outputPath string, start, end float64, format string) error {
+	if _, ok := ClipContentTypes[format]; !ok {
+		return fmt.Errorf("unsupported clip format: %s", format)
+	}
+	if start < 0 || end <= start {
+		return fmt.Errorf("invalid clip range: %.3f-%.3f", start, end)
+	}
+	if end-start > MaxClipDuration {
+		return fmt.Errorf("clip is longer than %d seconds", MaxClipDuration)
+	}
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		return fmt.Errorf("input file not found: %s", inputPath)
+	}
+
+	if format == ClipFormatWAV {
+		info, err := probeAudio(ctx, inputPath)
+		if err != nil {
+			return err
+		}
+		stream, err := openAudio(ctx, inputPath, decodeOptions{SampleRate: info.SampleRate, Start: start, Duration: end - start})
+		if err != nil {
+			return err
+		}
+		return writeWavFile(stream, outputPath, info.SampleRate)
+	}
+
+	if !FFmpegAvailable() {
+		return ErrNoFFmpeg
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	args := []string{
+		"-ss", strconv.FormatFloat(start, 'f', 3, 64),
+		"-t", strconv.FormatFloat(end-start, 'f', 3, 64),
+		"-i", inputPath,
+		"-vn",
+	}
+	switch format {
+	case ClipFormatMP3:
+		args = append(args, "-c:a", "libmp3lame", "-q:a", "4", "-f", "mp3")
+	case ClipFormatOpus:
+		args = append(args, "-c:a", "libopus", "-b:a", "64k", "-f", "ogg")
+	}
+	args = append(args, "-y", outputPath)
+
+	output, err := exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput()
+	if err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("ffmpeg clip extraction failed: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// ExtractClipTemp extracts a clip (see ExtractClip) to a temp file and
+// returns its path. The caller removes it
+func ExtractClipTemp(ctx context.Context, inputPath string, start, end float64, format string) (string, error) {
+	f, err := os.CreateTemp("", tempClipPattern+ClipExtensions[format])
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	f.Close()
+	if err := ExtractClip(ctx, inputPath, f.Name(), start, end, format); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,65 @@
+package asr
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// TestExtractClipWAV tests that a WAV clip holds only the requested span
+func TestExtractClipWAV(t *testing.T) {
+	const rate = 22050
+	dir := t.TempDir()
+	input := filepath.Join(dir, "tone.wav")
+	// 1s of 300Hz followed by 1s of 1200Hz
+	writeTestWAV(t, input, append(sine(rate, 1, 300, 0.5), sine(rate, 1, 1200, 0.5)...), rate, 1, wavFormatPCM, 16)
+	ctx := context.Background()
+
+	output := filepath.Join(dir, "clip.wav")
+	if err := ExtractClip(ctx, input, output, 1.25, 1.75, ClipFormatWAV); err != nil {
+		t.Fatal(err)
+	}
+	info, err := probeAudio(ctx, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.SampleRate != rate || info.Channels != 1 || math.Abs(info.Duration-0.5) > 1.0/rate {
+		t.Errorf("clip = %+v, want %dHz mono 0.5s", info, rate)
+	}
+	stream, err := openAudio(ctx, output, decodeOptions{SampleRate: rate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := dominantFrequency(readAllAudio(t, stream), rate); math.Abs(f-1200) > 5 {
+		t.Errorf("clip tone at %.1fHz, want 1200Hz", f)
+	}
+
+	// A span past the end is cut at the end
+	if err := ExtractClip(ctx, input, output, 1.5, 10, ClipFormatWAV); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := probeAudio(ctx, output); err != nil || math.Abs(info.Duration-0.5) > 1.0/rate {
+		t.Errorf("clip past the end = %+v, %v, want 0.5s", info, err)
+	}
+
+	for _, tt := range []struct {
+		start, end float64
+		format     string
+	}{
+		{-1, 1, ClipFormatWAV},
+		{1, 1, ClipFormatWAV},
+		{0, MaxClipDuration + 1, ClipFormatWAV},
+		{0, 1, "flac"},
+	} {
+		if err := ExtractClip(ctx, input, output, tt.start, tt.end, tt.format); err == nil {
+			t.Errorf("ExtractClip(%v, %v, %q) = nil, want an error", tt.start, tt.end, tt.format)
+		}
+	}
+	if !FFmpegAvailable() {
+		if err := ExtractClip(ctx, input, output, 0, 1, ClipFormatMP3); !errors.Is(err, ErrNoFFmpeg) {
+			t.Errorf("MP3 clip without ffmpeg = %v, want ErrNoFFmpeg", err)
+		}
+	}
+}
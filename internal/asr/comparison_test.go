@@ -1,6 +1,7 @@
 package asr
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -55,7 +56,7 @@ type ReazonSpeechTranscriber struct {
 }
 
 func (t *ReazonSpeechTranscriber) Transcribe(audioPath string) (*Result, error) {
-	return t.recognizer.TranscribeWithOverlap(audioPath, t.silenceConfig, 1.0, 2.0, nil)
+	return t.recognizer.TranscribeWithOverlap(context.Background(), audioPath, t.silenceConfig, 1.0, 2.0, nil)
 }
 
 func (t *ReazonSpeechTranscriber) Close() {
@@ -69,7 +70,7 @@ type SenseVoiceTranscriber struct {
 }
 
 func (t *SenseVoiceTranscriber) Transcribe(audioPath string) (*Result, error) {
-	return t.recognizer.TranscribeFile(audioPath, t.chunkSec, nil)
+	return t.recognizer.TranscribeFile(context.Background(), audioPath, t.chunkSec, nil)
 }
 
 func (t *SenseVoiceTranscriber) Close() {
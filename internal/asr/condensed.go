@@ -0,0 +1,132 @@
+package asr
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CondensedOptions controls the condensed (skim) view of a transcript
+type CondensedOptions struct {
+	Interval  float64 // seconds of audio per line
+	MaxLength int     // line length in characters (0 = no limit)
+}
+
+// DefaultCondensedOptions returns options for skimming multi-hour recordings
+func DefaultCondensedOptions() CondensedOptions {
+	return CondensedOptions{
+		Interval:  60,
+		MaxLength: 80,
+	}
+}
+
+// CondensedLine is one line of the condensed view
+type CondensedLine struct {
+	StartTime float64 `json:"start_time"` // start of the interval
+	EndTime   float64 `json:"end_time"`   // end of the interval
+	Time      float64 `json:"time"`       // start of the chosen segment (seek position)
+	Text      string  `json:"text"`
+	Speaker   string  `json:"speaker,omitempty"`
+}
+
+// Condensed builds a skim layer of the transcript: one line per interval,
+// taken from the densest segment (the one with the most spoken characters)
+// that starts in the interval. Intervals without speech are skipped, so the
+// view sits between a summary and the full text. Returns nil when there is
+// no text.
+func (r *Result) Condensed(opts CondensedOptions) []CondensedLine {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultCondensedOptions().Interval
+	}
+
+	// Densest segment of each interval (the first one on ties)
+	densest := map[int]Segment{}
+	counts := map[int]int{}
+	var intervals []int
+	for _, seg := range r.Cues() {
+		count := spokenLength(seg.Text)
+		if count == 0 {
+			continue
+		}
+		index := int(math.Floor(seg.StartTime / opts.Interval))
+		if _, ok := counts[index]; !ok {
+			intervals = append(intervals, index)
+		}
+		if count > counts[index] {
+			densest[index] = seg
+			counts[index] = count
+		}
+	}
+	sort.Ints(intervals)
+
+	lines := make([]CondensedLine, 0, len(intervals))
+	for _, index := range intervals {
+		seg := densest[index]
+		text := strings.TrimSpace(seg.Text)
+		if opts.MaxLength > 0 && utf8.RuneCountInString(text) > opts.MaxLength {
+			text = string([]rune(text)[:opts.MaxLength]) + "…"
+		}
+		line := CondensedLine{
+			StartTime: float64(index) * opts.Interval,
+			EndTime:   float64(index+1) * opts.Interval,
+			Time:      seg.StartTime,
+			Text:      text,
+			Speaker:   r.cueSpeaker(seg),
+		}
+		// The last interval ends with the recording
+		if total := float64(r.TotalDuration); total > line.Time && total < line.EndTime {
+			line.EndTime = total
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return lines
+}
+
+// exportCondensed writes the condensed view as "M:SS text" lines
+// (H:MM:SS when the recording is an hour or longer)
+func (r *Result) exportCondensed(opts ExportOptions) string {
+	condensedOpts := DefaultCondensedOptions()
+	if opts.CondensedInterval > 0 {
+		condensedOpts.Interval = opts.CondensedInterval
+	}
+	lines := r.Condensed(condensedOpts)
+	long := float64(r.TotalDuration) >= 3600
+	if n := len(lines); n > 0 && lines[n-1].StartTime >= 3600 {
+		long = true
+	}
+
+	var sb strings.Builder
+	lastSpeaker := ""
+	for _, line := range lines {
+		total := int(line.StartTime)
+		if long {
+			fmt.Fprintf(&sb, "%d:%02d:%02d ", total/3600, total/60%60, total%60)
+		} else {
+			fmt.Fprintf(&sb, "%d:%02d ", total/60, total%60)
+		}
+		if opts.SpeakerPrefix && line.Speaker != "" && line.Speaker != lastSpeaker {
+			fmt.Fprintf(&sb, "[%s] ", line.Speaker)
+			lastSpeaker = line.Speaker
+		}
+		sb.WriteString(line.Text)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// spokenLength counts the characters of text excluding spaces and punctuation
+func spokenLength(text string) int {
+	count := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) && !unicode.IsPunct(r) {
+			count++
+		}
+	}
+	return count
+}
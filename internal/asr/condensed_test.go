@@ -0,0 +1,64 @@
+package asr
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCondensed tests that each interval keeps its densest segment and silent intervals are skipped
+func TestCondensed(t *testing.T) {
+	r := &Result{
+		Segments: []Segment{
+			{Text: "はい", StartTime: 1, EndTime: 2},
+			{Text: "では予算の確認から始めます", StartTime: 5, EndTime: 9},
+			{Text: "えー", StartTime: 30, EndTime: 31},
+			{Text: "。", StartTime: 65, EndTime: 66},
+			{Text: "次は採用の件です", StartTime: 130, EndTime: 134, Speaker: "B"},
+		},
+		TotalDuration: 150,
+	}
+
+	lines := r.Condensed(CondensedOptions{Interval: 60})
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %+v", len(lines), lines)
+	}
+	if lines[0].Text != "では予算の確認から始めます" || lines[0].Time != 5 || lines[0].StartTime != 0 {
+		t.Errorf("line 0 = %+v", lines[0])
+	}
+	if lines[1].StartTime != 120 || lines[1].EndTime != 150 || lines[1].Speaker != "B" {
+		t.Errorf("line 1 = %+v, want interval 120-150 by B", lines[1])
+	}
+
+	short := r.Condensed(CondensedOptions{Interval: 60, MaxLength: 5})
+	if short[0].Text != "では予算の…" {
+		t.Errorf("truncated text = %q", short[0].Text)
+	}
+
+	if got := (&Result{}).Condensed(DefaultCondensedOptions()); got != nil {
+		t.Errorf("empty transcript: got %+v, want nil", got)
+	}
+}
+
+// TestExportCondensed tests the condensed export format and interval option
+func TestExportCondensed(t *testing.T) {
+	r := &Result{
+		Segments: []Segment{
+			{Text: "最初の話題", StartTime: 0, EndTime: 3},
+			{Text: "次の話題", StartTime: 45, EndTime: 48},
+		},
+	}
+
+	got, err := r.Export("condensed", ExportOptions{})
+	if err != nil {
+		t.Fatalf("Export(condensed) error: %v", err)
+	}
+	if got != "0:00 最初の話題\n" {
+		t.Errorf("condensed =\n%s", got)
+	}
+
+	got, _ = r.Export("condensed", ExportOptions{CondensedInterval: 30})
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if len(lines) != 2 || lines[1] != "0:30 次の話題" {
+		t.Errorf("condensed with 30s interval =\n%s", got)
+	}
+}
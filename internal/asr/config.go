@@ -8,16 +8,22 @@ import (
 
 // Config holds the configuration for the ASR recognizer
 type Config struct {
-	ModelPath      string // Base directory for the model
-	EncoderPath    string // Path to encoder.onnx or encoder.int8.onnx
-	DecoderPath    string // Path to decoder.onnx or decoder.int8.onnx
-	JoinerPath     string // Path to joiner.onnx or joiner.int8.onnx
-	TokensPath     string // Path to tokens.txt
-	VADModelPath   string // Path to silero_vad.onnx (optional, for VAD-based transcription)
-	NumThreads     int    // Number of threads for inference
-	SampleRate     int    // Audio sample rate (typically 16000)
-	DecodingMethod string // "greedy_search" (default) or "modified_beam_search"
-	MaxActivePaths int    // Used only when DecodingMethod is modified_beam_search (default: 4)
+	ModelPath      string    // Base directory for the model
+	EncoderPath    string    // Path to encoder.onnx or encoder.int8.onnx
+	DecoderPath    string    // Path to decoder.onnx or decoder.int8.onnx
+	JoinerPath     string    // Path to joiner.onnx or joiner.int8.onnx
+	TokensPath     string    // Path to tokens.txt
+	VADModelPath   string    // Path to silero_vad.onnx (optional, for VAD-based transcription)
+	NumThreads     int       // Number of threads for inference (0: auto, see DefaultNumThreads and ThreadTuner)
+	SampleRate     int       // Sample rate the model expects (16000, or 8000 for telephone models); input is resampled to it
+	DecodingMethod string    // "greedy_search" (default) or "modified_beam_search"
+	MaxActivePaths int       // Used only when DecodingMethod is modified_beam_search (default: 4)
+	BlankPenalty   float32   // Penalty for blank tokens (0: none, 1.0-2.0 emits more tokens on fast speech)
+	Provider       string    // Execution provider: cpu (default), cuda, coreml, directml
+	Rescorer       *Rescorer // Rescore ambiguous blocks with an LM (optional, block-based methods only)
+	RescorePaths   int       // Hypotheses decoded per block when rescoring (default: DefaultRescorePaths)
+	Hotwords       []string  // Words to favor while decoding (switches to modified_beam_search)
+	HotwordsScore  float32   // Bonus per hotword token (default: DefaultHotwordsScore)
 }
 
 // DefaultReazonSpeechConfig returns the default configuration for ReazonSpeech model
@@ -30,7 +36,6 @@ func DefaultReazonSpeechConfig() *Config {
 		DecoderPath: filepath.Join(modelDir, "decoder-epoch-99-avg-1.onnx"),
 		JoinerPath:  filepath.Join(modelDir, "joiner-epoch-99-avg-1.int8.onnx"),
 		TokensPath:  filepath.Join(modelDir, "tokens.txt"),
-		NumThreads:  2,
 		SampleRate:  16000,
 	}
 }
@@ -40,7 +45,6 @@ func DefaultReazonSpeechConfig() *Config {
 func NewConfig(modelDir string) (*Config, error) {
 	config := &Config{
 		ModelPath:  modelDir,
-		NumThreads: 2,
 		SampleRate: 16000,
 	}
 
@@ -100,6 +104,14 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.SampleRate <= 0 {
+		return fmt.Errorf("invalid sample rate: %d", c.SampleRate)
+	}
+
+	if _, err := ParseProvider(c.Provider); err != nil {
+		return err
+	}
+
 	return nil
 }
 
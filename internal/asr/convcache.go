@@ -0,0 +1,409 @@
+package asr
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tempWavPattern is the name pattern of the WAV files written by ConvertToWavTemp
+// and Enhancer.EnhanceFileTemp
+const tempWavPattern = "zbor-convert-*.wav"
+
+// legacyConvertedSuffix is the suffix of the WAV files older versions wrote
+// next to the source audio for playback and waveforms
+const legacyConvertedSuffix = "_converted.wav"
+
+// Suffixes of the cache entries: WAV conversions and waveform peaks (and
+// the transcoded streams, see streamEntrySuffixes)
+const (
+	wavEntrySuffix      = ".wav"
+	waveformEntrySuffix = ".peaks"
+)
+
+// ConversionCache keeps 16kHz mono WAV conversions of audio files in one
+// directory so they are shared between requests (playback, waveforms,
+// boundary adjustment) instead of piling up next to the sources, along with
+// the waveform peaks and the Opus/AAC streams of the files. Entries are
+// keyed by the source path, size and modification time, so an edited or
+// replaced file is converted again. Prune enforces the size and age limits
+type ConversionCache struct {
+	dir      string
+	maxBytes int64         // 0 = no size limit
+	maxAge   time.Duration // unused for this long (0 = no age limit)
+
+	mu       sync.Mutex
+	inUse    map[string]int             // entry path -> active Acquire calls
+	inflight map[string]*conversionCall // entry path -> running conversion
+}
+
+type conversionCall struct {
+	done chan struct{}
+	err  error
+}
+
+// PruneStats reports what Prune removed
+type PruneStats struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// NewConversionCache creates a cache in dir (created on first use)
+func NewConversionCache(dir string, maxBytes int64, maxAge time.Duration) *ConversionCache {
+	return &ConversionCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		maxAge:   maxAge,
+		inUse:    map[string]int{},
+		inflight: map[string]*conversionCall{},
+	}
+}
+
+// Dir returns the cache directory
+func (c *ConversionCache) Dir() string {
+	return c.dir
+}
+
+// Acquire returns a 16kHz mono WAV of inputPath, converting it on first use.
+// Concurrent calls for the same file share one conversion. The entry is not
+// pruned until release is called. Cancelling ctx kills the conversion; a
+// cancelled conversion is not cached
+func (c *ConversionCache) Acquire(ctx context.Context, inputPath string) (path string, release func(), err error) {
+	return c.acquire(ctx, inputPath, wavEntrySuffix, func(tmp string) error {
+		return ConvertToWav(ctx, inputPath, tmp)
+	})
+}
+
+// AcquireStream returns inputPath transcoded to format (StreamFormatOpus or
+// StreamFormatAAC) for playback, encoding it on first use like Acquire.
+// Returns ErrNoFFmpeg without ffmpeg
+func (c *ConversionCache) AcquireStream(ctx context.Context, inputPath, format string) (path string, release func(), err error) {
+	suffix, ok := streamEntrySuffixes[format]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported stream format: %s", format)
+	}
+	if !FFmpegAvailable() {
+		return "", nil, ErrNoFFmpeg
+	}
+	return c.acquire(ctx, inputPath, suffix, func(tmp string) error {
+		return EncodeForStreaming(ctx, inputPath, tmp, format)
+	})
+}
+
+// acquire returns the entry of inputPath with suffix, writing it with write
+// on first use
+func (c *ConversionCache) acquire(ctx context.Context, inputPath, suffix string, write func(tmp string) error) (path string, release func(), err error) {
+	info, err := os.Stat(inputPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("input file not found: %s", inputPath)
+	}
+	path = c.entryPath(inputPath, info, suffix)
+	release = c.use(path)
+
+	if err := c.build(ctx, path, write); err != nil {
+		release()
+		return "", nil, err
+	}
+	touchEntry(path)
+	return path, release, nil
+}
+
+// Waveform returns the waveform peaks of inputPath, computing them on first
+// use. The source is decoded directly (no WAV conversion), and the entry is
+// small (about 700KB per hour of audio), so later requests for any
+// resolution or time range are served from it at once
+func (c *ConversionCache) Waveform(ctx context.Context, inputPath string) (*Waveform, error) {
+	info, err := os.Stat(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("input file not found: %s", inputPath)
+	}
+	path := c.entryPath(inputPath, info, waveformEntrySuffix)
+	release := c.use(path)
+	defer release()
+
+	err = c.build(ctx, path, func(tmp string) error {
+		w, err := ComputeWaveform(ctx, inputPath)
+		if err != nil {
+			return err
+		}
+		f, err := os.Create(tmp)
+		if err != nil {
+			return fmt.Errorf("failed to create waveform file: %w", err)
+		}
+		if _, err := w.WriteTo(f); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write waveform file: %w", err)
+		}
+		return f.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+	touchEntry(path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open waveform file: %w", err)
+	}
+	defer f.Close()
+	w, err := ReadWaveform(bufio.NewReader(f))
+	if err != nil {
+		// A damaged entry is computed again on the next request
+		os.Remove(path)
+		return nil, err
+	}
+	return w, nil
+}
+
+// use marks the entry at path as in use until the returned function is called
+func (c *ConversionCache) use(path string) func() {
+	c.mu.Lock()
+	c.inUse[path]++
+	c.mu.Unlock()
+	return func() {
+		c.mu.Lock()
+		if c.inUse[path]--; c.inUse[path] <= 0 {
+			delete(c.inUse, path)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// touchEntry marks an entry as recently used for the age limit and LRU order
+func touchEntry(path string) {
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+}
+
+// build writes the entry at path with write unless it exists, waiting for a
+// build of the same entry that is already running
+func (c *ConversionCache) build(ctx context.Context, path string, write func(tmp string) error) error {
+	c.mu.Lock()
+	if call, ok := c.inflight[path]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if _, err := os.Stat(path); err == nil {
+		c.mu.Unlock()
+		return nil
+	}
+	call := &conversionCall{done: make(chan struct{})}
+	c.inflight[path] = call
+	c.mu.Unlock()
+
+	// Write to a temporary name so a half-written file is never served
+	tmp := path + ".tmp"
+	call.err = write(tmp)
+	if call.err == nil {
+		call.err = os.Rename(tmp, path)
+	}
+	if call.err != nil {
+		os.Remove(tmp)
+	}
+
+	c.mu.Lock()
+	delete(c.inflight, path)
+	c.mu.Unlock()
+	close(call.done)
+	return call.err
+}
+
+// entryPath returns the cache file of a source file version with suffix
+func (c *ConversionCache) entryPath(inputPath string, info os.FileInfo, suffix string) string {
+	abs, err := filepath.Abs(inputPath)
+	if err != nil {
+		abs = inputPath
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%d", abs, info.Size(), info.ModTime().UnixNano())))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+suffix)
+}
+
+// isCacheEntry reports whether a file in the cache directory is an entry
+// (not a temporary file or something else)
+func isCacheEntry(name string) bool {
+	if strings.HasSuffix(name, wavEntrySuffix) || strings.HasSuffix(name, waveformEntrySuffix) {
+		return true
+	}
+	for _, suffix := range streamEntrySuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Prune removes entries unused for longer than the age limit, then the least
+// recently used entries until the cache fits the size limit. Entries in use
+// are kept
+func (c *ConversionCache) Prune() (PruneStats, error) {
+	var stats PruneStats
+	dirEntries, err := os.ReadDir(c.dir)
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, fmt.Errorf("failed to read conversion cache: %w", err)
+	}
+
+	type entry struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var entries []entry
+	var total int64
+	for _, de := range dirEntries {
+		if de.IsDir() || !isCacheEntry(de.Name()) {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry{filepath.Join(c.dir, de.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+
+	now := time.Now()
+	for _, e := range entries {
+		expired := c.maxAge > 0 && now.Sub(e.modTime) > c.maxAge
+		oversize := c.maxBytes > 0 && total > c.maxBytes
+		if !expired && !oversize {
+			// Sorted oldest first: later entries are newer and the cache fits
+			break
+		}
+		c.mu.Lock()
+		busy := c.inUse[e.path] > 0 || c.inflight[e.path] != nil
+		c.mu.Unlock()
+		if busy {
+			continue
+		}
+		if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+			continue
+		}
+		total -= e.size
+		stats.Files++
+		stats.Bytes += e.size
+	}
+	return stats, nil
+}
+
+// Stats returns the number and total size of the cached conversions,
+// waveforms and streams
+func (c *ConversionCache) Stats() (PruneStats, error) {
+	var stats PruneStats
+	dirEntries, err := os.ReadDir(c.dir)
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, fmt.Errorf("failed to read conversion cache: %w", err)
+	}
+	for _, de := range dirEntries {
+		if info, err := de.Info(); err == nil && !de.IsDir() && isCacheEntry(de.Name()) {
+			stats.Files++
+			stats.Bytes += info.Size()
+		}
+	}
+	return stats, nil
+}
+
+// RemoveStaleTempWavs removes WAV files written by ConvertToWavTemp, the
+// decoded PCM of block transcription and clips written by ExtractClipTemp
+// that are older than olderThan (left behind when the process died
+// mid-transcription or mid-request)
+func RemoveStaleTempWavs(olderThan time.Duration) (PruneStats, error) {
+	var stats PruneStats
+	var matches []string
+	for _, pattern := range []string{tempWavPattern, tempPCMPattern, tempClipPattern} {
+		m, err := filepath.Glob(filepath.Join(os.TempDir(), pattern))
+		if err != nil {
+			return stats, err
+		}
+		matches = append(matches, m...)
+	}
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || time.Since(info.ModTime()) < olderThan {
+			continue
+		}
+		if os.Remove(path) == nil {
+			stats.Files++
+			stats.Bytes += info.Size()
+		}
+	}
+	return stats, nil
+}
+
+// RemoveLegacyConversions removes the "_converted.wav" files that older
+// versions wrote next to the source audio under root. Only files whose
+// non-WAV source is still beside them are removed (an uploaded file that
+// happens to have the suffix is kept). They are regenerated in the
+// conversion cache when needed
+func RemoveLegacyConversions(root string) (PruneStats, error) {
+	var stats PruneStats
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), legacyConvertedSuffix) || !hasConvertedSource(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if os.Remove(path) == nil {
+			stats.Files++
+			stats.Bytes += info.Size()
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	return stats, err
+}
+
+// hasConvertedSource reports whether a non-WAV audio file with the base name
+// of a legacy conversion exists in the same directory
+func hasConvertedSource(convertedPath string) bool {
+	base := strings.TrimSuffix(convertedPath, legacyConvertedSuffix)
+	matches, _ := filepath.Glob(globEscape(base) + ".*")
+	for _, m := range matches {
+		if ext := strings.ToLower(filepath.Ext(m)); ext != ".wav" && IsSupportedFormat(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// globEscape escapes the glob metacharacters of a path
+func globEscape(path string) string {
+	var sb strings.Builder
+	for _, r := range path {
+		if strings.ContainsRune(`*?[\\`, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,165 @@
+package asr
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeAged writes a file of size bytes with the given age
+func writeAged(t *testing.T, path string, size int, age time.Duration) {
+	t.Helper()
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-age)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestConversionCachePrune tests removal of expired and least recently used entries
+func TestConversionCachePrune(t *testing.T) {
+	dir := t.TempDir()
+	writeAged(t, filepath.Join(dir, "expired.wav"), 10, 10*24*time.Hour)
+	writeAged(t, filepath.Join(dir, "old.wav"), 100, 3*time.Hour)
+	writeAged(t, filepath.Join(dir, "busy.wav"), 100, 4*time.Hour)
+	writeAged(t, filepath.Join(dir, "new.wav"), 100, time.Hour)
+
+	cache := NewConversionCache(dir, 200, 7*24*time.Hour)
+	cache.inUse[filepath.Join(dir, "busy.wav")] = 1
+
+	stats, err := cache.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Files != 2 || stats.Bytes != 110 {
+		t.Errorf("Prune() = %+v, want 2 files / 110 bytes", stats)
+	}
+	for name, want := range map[string]bool{"expired.wav": false, "old.wav": false, "busy.wav": true, "new.wav": true} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if got := err == nil; got != want {
+			t.Errorf("%s exists = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// TestRemoveLegacyConversions tests that only conversions next to their source are removed
+func TestRemoveLegacyConversions(t *testing.T) {
+	dir := t.TempDir()
+	writeAged(t, filepath.Join(dir, "talk.mp3"), 10, 0)
+	writeAged(t, filepath.Join(dir, "talk_converted.wav"), 20, 0)
+	writeAged(t, filepath.Join(dir, "upload_converted.wav"), 30, 0)
+
+	stats, err := RemoveLegacyConversions(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Files != 1 || stats.Bytes != 20 {
+		t.Errorf("RemoveLegacyConversions() = %+v, want 1 file / 20 bytes", stats)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "upload_converted.wav")); err != nil {
+		t.Errorf("conversion without a source was removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "talk.mp3")); err != nil {
+		t.Errorf("source was removed")
+	}
+}
+
+// TestConversionCacheWaveform tests that cached waveforms match the peaks
+// computed from the WAV file at any resolution and time range
+func TestConversionCacheWaveform(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "synth.wav")
+	writeSynthWAV(t, path, synthesize(synthTimeline))
+	cache := NewConversionCache(t.TempDir(), 0, 0)
+	ctx := context.Background()
+
+	computed, err := cache.Waveform(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, err := cache.Waveform(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := cache.Stats(); stats.Files != 1 {
+		t.Errorf("Stats().Files = %d, want 1 waveform entry", stats.Files)
+	}
+
+	for _, rate := range []float64{50, 20, 3} {
+		want, duration, err := ComputeWaveformPeaks(path, rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cached.Duration != duration {
+			t.Errorf("Duration = %v, want %v", cached.Duration, duration)
+		}
+		for name, w := range map[string]*Waveform{"computed": computed, "cached": cached} {
+			got := w.Range(rate, 0, 0)
+			if len(got) != len(want) {
+				t.Fatalf("%s at %v/sec: %d peaks, want %d", name, rate, len(got), len(want))
+			}
+			for i := range got {
+				if math.Abs(got[i]-want[i]) > 1e-4 {
+					t.Errorf("%s at %v/sec: peak %d = %.5f, want %.5f", name, rate, i, got[i], want[i])
+					break
+				}
+			}
+		}
+	}
+
+	// A time range is the same part of a longer range
+	whole := cached.Range(10, 0, 4)
+	part := cached.Range(10, 2, 4)
+	if len(part) != 20 {
+		t.Fatalf("Range(10, 2, 4) = %d peaks, want 20", len(part))
+	}
+	for i := range part {
+		if part[i] != whole[20+i] {
+			t.Errorf("Range(10, 2, 4)[%d] = %v, want %v", i, part[i], whole[20+i])
+		}
+	}
+	if got := cached.Range(10, cached.Duration+1, 0); len(got) != 0 {
+		t.Errorf("range after the end: %d peaks, want 0", len(got))
+	}
+}
+
+// TestConversionCacheStreams tests that transcoded streams are cache entries
+// and need ffmpeg
+func TestConversionCacheStreams(t *testing.T) {
+	dir := t.TempDir()
+	writeAged(t, filepath.Join(dir, "a.opus.ogg"), 100, time.Hour)
+	writeAged(t, filepath.Join(dir, "a.m4a"), 100, time.Hour)
+	writeAged(t, filepath.Join(dir, "a.m4a.tmp"), 100, time.Hour)
+	cache := NewConversionCache(dir, 0, 0)
+	if stats, err := cache.Stats(); err != nil || stats.Files != 2 {
+		t.Errorf("Stats() = %+v, %v, want 2 streams", stats, err)
+	}
+
+	input := filepath.Join(dir, "synth.wav")
+	writeSynthWAV(t, input, synthesize(synthTimeline))
+	if _, _, err := cache.AcquireStream(context.Background(), input, "mp3"); err == nil {
+		t.Error("AcquireStream(mp3) = nil, want an error for an unsupported format")
+	}
+	if !FFmpegAvailable() {
+		if _, _, err := cache.AcquireStream(context.Background(), input, StreamFormatAAC); !errors.Is(err, ErrNoFFmpeg) {
+			t.Errorf("AcquireStream without ffmpeg = %v, want ErrNoFFmpeg", err)
+		}
+	}
+
+	for path, want := range map[string]string{
+		"talk.MP3":  "audio/mpeg",
+		"talk.m4a":  "audio/mp4",
+		"talk.flac": "audio/flac",
+		"talk.wma":  "",
+		"talk.amr":  "",
+	} {
+		if got := BrowserContentType(path); got != want {
+			t.Errorf("BrowserContentType(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package asr
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // SupportedFormats lists audio formats that can be converted
@@ -24,55 +27,24 @@ func IsSupportedFormat(filename string) bool {
 
 // ConvertToWav converts an audio file to WAV format (16kHz, mono)
 // Returns the path to the converted file
-func ConvertToWav(inputPath, outputPath string) error {
-	// Check if ffmpeg is available
-	if _, err := exec.LookPath("ffmpeg"); err != nil {
-		return fmt.Errorf("ffmpeg not found: please install ffmpeg to convert audio files")
-	}
-
-	// Check if input file exists
-	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
-		return fmt.Errorf("input file not found: %s", inputPath)
-	}
-
-	// Create output directory if needed
-	outputDir := filepath.Dir(outputPath)
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
-	}
-
-	// Run ffmpeg conversion
-	// -i: input file
-	// -ar 16000: sample rate 16kHz
-	// -ac 1: mono channel
-	// -f wav: output format
-	// -y: overwrite output file
-	cmd := exec.Command("ffmpeg",
-		"-i", inputPath,
-		"-ar", "16000",
-		"-ac", "1",
-		"-f", "wav",
-		"-y",
-		outputPath,
-	)
+func ConvertToWav(ctx context.Context, inputPath, outputPath string) error {
+	return ConvertToWavTrimmed(ctx, inputPath, outputPath, nil)
+}
 
-	output, err := cmd.CombinedOutput()
+// ConvertToWavTemp converts an audio file to WAV format in a temp directory
+// Returns the path to the converted file (caller should clean up). Each call
+// gets its own file, and files left behind by a crash are removed by
+// RemoveStaleTempWavs
+func ConvertToWavTemp(ctx context.Context, inputPath string) (string, error) {
+	f, err := os.CreateTemp("", tempWavPattern)
 	if err != nil {
-		return fmt.Errorf("ffmpeg conversion failed: %w\nOutput: %s", err, string(output))
+		return "", fmt.Errorf("failed to create temp file: %w", err)
 	}
+	outputPath := f.Name()
+	f.Close()
 
-	return nil
-}
-
-// ConvertToWavTemp converts an audio file to WAV format in a temp directory
-// Returns the path to the converted file (caller should clean up)
-func ConvertToWavTemp(inputPath string) (string, error) {
-	// Create temp file for output
-	tempDir := os.TempDir()
-	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	outputPath := filepath.Join(tempDir, baseName+"_converted.wav")
-
-	if err := ConvertToWav(inputPath, outputPath); err != nil {
+	if err := ConvertToWav(ctx, inputPath, outputPath); err != nil {
+		os.Remove(outputPath)
 		return "", err
 	}
 
@@ -81,70 +53,249 @@ func ConvertToWavTemp(inputPath string) (string, error) {
 
 // NeedsConversion checks if the file needs to be converted
 // WAV files at 16kHz mono don't need conversion
-func NeedsConversion(inputPath string) (bool, error) {
+func NeedsConversion(ctx context.Context, inputPath string) (bool, error) {
 	ext := strings.ToLower(filepath.Ext(inputPath))
 	if ext != ".wav" {
 		return true, nil
 	}
 
 	// For WAV files, check if they're already 16kHz mono
-	// Use ffprobe to check audio properties
-	if _, err := exec.LookPath("ffprobe"); err != nil {
-		// If ffprobe is not available, assume conversion is needed
+	info, err := probeAudio(ctx, inputPath)
+	if err != nil {
+		// If the header cannot be read, assume conversion is needed
 		return true, nil
 	}
+	return info.SampleRate != 16000 || info.Channels != 1, nil
+}
 
-	cmd := exec.Command("ffprobe",
-		"-v", "error",
-		"-select_streams", "a:0",
-		"-show_entries", "stream=sample_rate,channels",
-		"-of", "csv=p=0",
-		inputPath,
-	)
-
-	output, err := cmd.Output()
+// GetAudioDuration returns the duration of an audio file in seconds
+// (from the header of native formats, with ffprobe for the others)
+func GetAudioDuration(ctx context.Context, inputPath string) (float64, error) {
+	info, err := probeAudio(ctx, inputPath)
 	if err != nil {
-		// If ffprobe fails, assume conversion is needed
-		return true, nil
+		return 0, fmt.Errorf("failed to get audio duration: %w", err)
 	}
+	return info.Duration, nil
+}
 
-	// Parse output: "sample_rate,channels"
-	parts := strings.Split(strings.TrimSpace(string(output)), ",")
-	if len(parts) != 2 {
-		return true, nil
+// TimeRange represents a span of audio in seconds
+type TimeRange struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// TrimOptions describes which parts of a recording to keep
+// Times are relative to the original (untrimmed) audio
+type TrimOptions struct {
+	Start   float64     `json:"start,omitempty"`   // seconds to skip at the beginning
+	End     float64     `json:"end,omitempty"`     // cut-off time (0 = until the end)
+	Exclude []TimeRange `json:"exclude,omitempty"` // ranges to drop (e.g. known dead air)
+}
+
+// IsZero reports whether the options leave the audio untouched
+func (t *TrimOptions) IsZero() bool {
+	return t == nil || (t.Start <= 0 && t.End <= 0 && len(t.Exclude) == 0)
+}
+
+// Validate checks that the trim ranges are consistent
+func (t *TrimOptions) Validate() error {
+	if t == nil {
+		return nil
+	}
+	if t.Start < 0 || t.End < 0 {
+		return fmt.Errorf("trim times must not be negative")
 	}
+	if t.End > 0 && t.End <= t.Start {
+		return fmt.Errorf("trim end (%.2f) must be after trim start (%.2f)", t.End, t.Start)
+	}
+	for _, r := range t.Exclude {
+		if r.Start < 0 || r.End <= r.Start {
+			return fmt.Errorf("invalid exclude range: %.2f-%.2f", r.Start, r.End)
+		}
+	}
+	return nil
+}
 
-	// Check if it's 16000Hz and 1 channel
-	if parts[0] == "16000" && parts[1] == "1" {
-		return false, nil
+// filterExpr builds an ffmpeg audio filter that keeps only the selected ranges
+// and re-times the remaining samples so the output is contiguous
+func (t *TrimOptions) filterExpr() string {
+	var conds []string
+	if t.Start > 0 {
+		conds = append(conds, fmt.Sprintf("gte(t,%.3f)", t.Start))
+	}
+	if t.End > 0 {
+		conds = append(conds, fmt.Sprintf("lt(t,%.3f)", t.End))
 	}
+	for _, r := range t.Exclude {
+		conds = append(conds, fmt.Sprintf("not(between(t,%.3f,%.3f))", r.Start, r.End))
+	}
+	return fmt.Sprintf("aselect='%s',asetpts=N/SR/TB", strings.Join(conds, "*"))
+}
 
-	return true, nil
+// TrimmedWavPath returns the path used for the trimmed WAV of an audio file
+func TrimmedWavPath(inputPath string) string {
+	ext := filepath.Ext(inputPath)
+	return inputPath[:len(inputPath)-len(ext)] + "_trimmed.wav"
 }
 
-// GetAudioDuration returns the duration of an audio file in seconds
-func GetAudioDuration(inputPath string) (float64, error) {
-	if _, err := exec.LookPath("ffprobe"); err != nil {
-		return 0, fmt.Errorf("ffprobe not found: please install ffmpeg")
+// keeps reports whether the audio at sec is kept (the condition of filterExpr)
+func (t *TrimOptions) keeps(sec float64) bool {
+	if t.Start > 0 && sec < t.Start {
+		return false
+	}
+	if t.End > 0 && sec >= t.End {
+		return false
+	}
+	for _, r := range t.Exclude {
+		if sec >= r.Start && sec <= r.End {
+			return false
+		}
 	}
+	return true
+}
 
-	cmd := exec.Command("ffprobe",
-		"-v", "error",
-		"-show_entries", "format=duration",
-		"-of", "csv=p=0",
-		inputPath,
-	)
+// ConvertToWavTrimmed converts an audio file to WAV format (16kHz, mono),
+// keeping only the ranges selected by trim
+func ConvertToWavTrimmed(ctx context.Context, inputPath, outputPath string, trim *TrimOptions) error {
+	if err := trim.Validate(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		return fmt.Errorf("input file not found: %s", inputPath)
+	}
 
-	output, err := cmd.Output()
+	stream, err := openAudio(ctx, inputPath, decodeOptions{SampleRate: 16000, Trim: trim})
 	if err != nil {
-		return 0, fmt.Errorf("failed to get audio duration: %w", err)
+		return err
+	}
+	return writeWavFile(stream, outputPath, 16000)
+}
+
+// ErrNoFFmpeg is returned for work that needs ffmpeg (preview proxies) when
+// it is not installed
+var ErrNoFFmpeg = errors.New("ffmpeg not found: please install ffmpeg to encode preview proxies")
+
+// ProxyBitrate is the Opus bitrate of the low-quality preview proxy
+// Speech stays intelligible at this rate while a one-hour recording is ~11MB
+const ProxyBitrate = "24k"
+
+// ProxyContentType is the MIME type of the preview proxy
+const ProxyContentType = "audio/ogg; codecs=opus"
+
+// ProxyPath returns the path used for the low-bitrate preview of an audio file
+func ProxyPath(inputPath string) string {
+	ext := filepath.Ext(inputPath)
+	return inputPath[:len(inputPath)-len(ext)] + "_proxy.ogg"
+}
+
+// proxyEncodes are the running proxy encodes by output path, so concurrent
+// requests for the same proxy (parallel Range requests, a job preparing the
+// source) share one ffmpeg run
+var proxyEncodes = struct {
+	sync.Mutex
+	calls map[string]*conversionCall
+}{calls: map[string]*conversionCall{}}
+
+// ConvertToOpusProxy encodes a low-bitrate mono Opus preview of an audio file
+// for bandwidth-limited playback. Timing is preserved so transcripts stay in sync.
+// A call while the same proxy is being encoded waits for that encode
+func ConvertToOpusProxy(ctx context.Context, inputPath, outputPath string) error {
+	return runProxyEncode(ctx, outputPath, func() error {
+		return encodeOpusProxy(ctx, inputPath, outputPath)
+	})
+}
+
+// EnsureOpusProxy returns the preview of an audio file, encoding it unless it
+// exists and is newer than the file (a removed original keeps its preview)
+func EnsureOpusProxy(ctx context.Context, inputPath string) (string, error) {
+	proxyPath := ProxyPath(inputPath)
+	return proxyPath, runProxyEncode(ctx, proxyPath, func() error {
+		if proxyUpToDate(inputPath, proxyPath) {
+			return nil
+		}
+		return encodeOpusProxy(ctx, inputPath, proxyPath)
+	})
+}
+
+// proxyUpToDate reports whether the proxy exists and is not older than its source
+func proxyUpToDate(inputPath, proxyPath string) bool {
+	proxy, err := os.Stat(proxyPath)
+	if err != nil {
+		return false
+	}
+	source, err := os.Stat(inputPath)
+	if err != nil {
+		return os.IsNotExist(err)
 	}
+	return !proxy.ModTime().Before(source.ModTime())
+}
+
+// runProxyEncode runs encode unless an encode of outputPath is already
+// running, in which case it waits for that one
+func runProxyEncode(ctx context.Context, outputPath string, encode func() error) error {
+	proxyEncodes.Lock()
+	if call, ok := proxyEncodes.calls[outputPath]; ok {
+		proxyEncodes.Unlock()
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	call := &conversionCall{done: make(chan struct{})}
+	proxyEncodes.calls[outputPath] = call
+	proxyEncodes.Unlock()
+
+	call.err = encode()
+
+	proxyEncodes.Lock()
+	delete(proxyEncodes.calls, outputPath)
+	proxyEncodes.Unlock()
+	close(call.done)
+	return call.err
+}
+
+func encodeOpusProxy(ctx context.Context, inputPath, outputPath string) error {
+	if !FFmpegAvailable() {
+		return ErrNoFFmpeg
+	}
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		return fmt.Errorf("input file not found: %s", inputPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Write to a temp file first so a partially encoded proxy is never served
+	tmp, err := os.CreateTemp(filepath.Dir(outputPath), filepath.Base(outputPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmp.Close()
+	tmpPath := tmp.Name()
+	os.Chmod(tmpPath, 0644)
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-i", inputPath,
+		"-vn",
+		"-ac", "1",
+		"-c:a", "libopus",
+		"-b:a", ProxyBitrate,
+		"-application", "voip",
+		"-f", "ogg",
+		"-y",
+		tmpPath,
+	)
 
-	var duration float64
-	_, err = fmt.Sscanf(strings.TrimSpace(string(output)), "%f", &duration)
+	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse duration: %w", err)
+		os.Remove(tmpPath)
+		return fmt.Errorf("ffmpeg proxy encoding failed: %w\nOutput: %s", err, string(output))
 	}
 
-	return duration, nil
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,114 @@
+package asr
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestTrimOptionsFilterExpr tests the ffmpeg filter built from trim options
+func TestTrimOptionsFilterExpr(t *testing.T) {
+	tests := []struct {
+		name string
+		trim TrimOptions
+		want string
+	}{
+		{
+			name: "start only",
+			trim: TrimOptions{Start: 5},
+			want: "aselect='gte(t,5.000)',asetpts=N/SR/TB",
+		},
+		{
+			name: "start, end and exclude",
+			trim: TrimOptions{Start: 1.5, End: 60, Exclude: []TimeRange{{Start: 10, End: 20}}},
+			want: "aselect='gte(t,1.500)*lt(t,60.000)*not(between(t,10.000,20.000))',asetpts=N/SR/TB",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.trim.filterExpr(); got != tt.want {
+				t.Errorf("filterExpr() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTrimOptionsValidate tests rejection of inconsistent trim ranges
+func TestTrimOptionsValidate(t *testing.T) {
+	invalid := []TrimOptions{
+		{Start: -1},
+		{Start: 10, End: 5},
+		{Exclude: []TimeRange{{Start: 20, End: 10}}},
+	}
+	for _, trim := range invalid {
+		if err := trim.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", trim)
+		}
+	}
+
+	valid := TrimOptions{Start: 3, End: 90, Exclude: []TimeRange{{Start: 30, End: 45}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate(%+v) = %v, want nil", valid, err)
+	}
+}
+
+// TestRunProxyEncodeShared tests that concurrent encodes of the same proxy
+// run once and share the result
+func TestRunProxyEncodeShared(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+	encode := func() error {
+		runs.Add(1)
+		<-release
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := runProxyEncode(context.Background(), "/tmp/x_proxy.ogg", encode); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	// Let the callers queue up behind the first encode
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := runs.Load(); n != 1 {
+		t.Errorf("encode ran %d times, want 1", n)
+	}
+}
+
+// TestProxyUpToDate tests the freshness check of preview proxies
+func TestProxyUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "a.mp3")
+	proxy := ProxyPath(source)
+
+	if proxyUpToDate(source, proxy) {
+		t.Error("missing proxy reported up to date")
+	}
+	writeAged(t, proxy, 10, time.Hour)
+	if !proxyUpToDate(source, proxy) {
+		t.Error("proxy of a removed original reported stale")
+	}
+	writeAged(t, source, 10, 2*time.Hour)
+	if !proxyUpToDate(source, proxy) {
+		t.Error("proxy newer than the source reported stale")
+	}
+	if err := os.Chtimes(source, time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if proxyUpToDate(source, proxy) {
+		t.Error("proxy older than the source reported up to date")
+	}
+}
@@ -0,0 +1,475 @@
+package asr
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"zbor/internal/logging"
+)
+
+// Audio decoder modes (ZBOR_AUDIO_DECODER)
+const (
+	DecoderAuto   = "auto"   // native decoders, ffmpeg for the other formats
+	DecoderNative = "native" // native decoders only
+	DecoderFFmpeg = "ffmpeg" // ffmpeg for every format
+)
+
+// decoderMode is the audio decoder mode set by SetAudioDecoder
+var decoderMode = DecoderAuto
+
+// SetAudioDecoder selects how audio files are decoded (DecoderAuto,
+// DecoderNative or DecoderFFmpeg)
+func SetAudioDecoder(mode string) error {
+	switch mode {
+	case DecoderAuto, DecoderNative, DecoderFFmpeg:
+		decoderMode = mode
+		return nil
+	}
+	return fmt.Errorf("unknown audio decoder '%s' (auto, native or ffmpeg)", mode)
+}
+
+// AudioCapabilities reports the audio tools and decoders available to zbor
+type AudioCapabilities struct {
+	Decoder       string   `json:"decoder"`        // decoder mode (auto, native, ffmpeg)
+	FFmpeg        bool     `json:"ffmpeg"`         // ffmpeg found in PATH
+	FFprobe       bool     `json:"ffprobe"`        // ffprobe found in PATH
+	NativeFormats []string `json:"native_formats"` // extensions decoded without ffmpeg
+	Formats       []string `json:"formats"`        // extensions that can be decoded now
+	Proxy         bool     `json:"proxy"`          // Opus preview proxies can be encoded
+}
+
+// CheckAudioCapabilities looks up ffmpeg/ffprobe and reports which formats
+// can be decoded with the current decoder mode
+func CheckAudioCapabilities() AudioCapabilities {
+	caps := AudioCapabilities{
+		Decoder:       decoderMode,
+		FFmpeg:        FFmpegAvailable(),
+		FFprobe:       lookPath("ffprobe"),
+		NativeFormats: NativeFormats,
+	}
+	caps.Proxy = caps.FFmpeg
+	for _, ext := range SupportedFormats {
+		native := isNativeFormat(ext) && decoderMode != DecoderFFmpeg
+		viaFFmpeg := caps.FFmpeg && decoderMode != DecoderNative
+		if native || viaFFmpeg {
+			caps.Formats = append(caps.Formats, ext)
+		}
+	}
+	return caps
+}
+
+func lookPath(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// FFmpegAvailable reports whether ffmpeg can be started (needed for the
+// formats without a native decoder and for preview proxies)
+func FFmpegAvailable() bool {
+	return lookPath("ffmpeg")
+}
+
+// decodeOptions selects the part and format of the audio returned by openAudio
+type decodeOptions struct {
+	SampleRate int
+	Channels   int          // 1 (downmixed, also for 0) or 2
+	Start      float64      // seconds skipped at the beginning
+	Duration   float64      // seconds decoded from Start (0 = until the end)
+	Tempo      float64      // speed (atempo; 0 and 1.0 keep the original speed), mono only
+	Trim       *TrimOptions // ranges kept, times in the original audio
+}
+
+func (o *decodeOptions) channels() int {
+	return max(o.Channels, 1)
+}
+
+func (o *decodeOptions) changesTempo() bool {
+	return o.Tempo > 0 && o.Tempo != 1.0
+}
+
+// audioStream is decoded audio as interleaved float32 samples in [-1, 1]
+type audioStream interface {
+	// Read reads whole frames into buf and returns the number of samples.
+	// It returns io.EOF at the end of the audio, also when decoding failed
+	Read(buf []float32) (int, error)
+	// Close stops decoding. It returns the error that ended decoding early
+	// (like the exit status of ffmpeg), or nil
+	Close() error
+}
+
+// openAudio decodes inputPath with a native decoder, or with ffmpeg for the
+// formats without one (see decoderMode)
+func openAudio(ctx context.Context, inputPath string, opts decodeOptions) (audioStream, error) {
+	if opts.changesTempo() && opts.channels() != 1 {
+		return nil, fmt.Errorf("tempo can only be changed for mono audio")
+	}
+	if decoderMode != DecoderFFmpeg {
+		src, err := openNativeSource(inputPath)
+		if err == nil {
+			return newNativeStream(ctx, src, opts), nil
+		}
+		if decoderMode == DecoderNative {
+			return nil, err
+		}
+		if !FFmpegAvailable() {
+			if errors.Is(err, errNoNativeDecoder) {
+				return nil, fmt.Errorf("ffmpeg not found: please install ffmpeg to decode %s files (natively supported: %s)",
+					filepath.Ext(inputPath), strings.Join(NativeFormats, ", "))
+			}
+			return nil, err
+		}
+		// e.g. an Ogg file with Opus audio: ffmpeg may still decode it
+	}
+	return startFFmpegStream(ctx, inputPath, opts)
+}
+
+// openPCM returns the audio as 16-bit mono little-endian PCM, the format
+// the recognizers read (bytesToFloat32)
+func openPCM(ctx context.Context, inputPath string, opts decodeOptions) (io.ReadCloser, error) {
+	opts.Channels = 1
+	stream, err := openAudio(ctx, inputPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &pcm16Reader{stream: stream}, nil
+}
+
+// pcm16Reader encodes an audioStream as 16-bit little-endian PCM
+type pcm16Reader struct {
+	stream audioStream
+	buf    []float32
+}
+
+func (r *pcm16Reader) Read(p []byte) (int, error) {
+	n := len(p) / 2
+	if n == 0 {
+		return 0, nil
+	}
+	if cap(r.buf) < n {
+		r.buf = make([]float32, n)
+	}
+	read, err := r.stream.Read(r.buf[:n])
+	for i, s := range r.buf[:read] {
+		binary.LittleEndian.PutUint16(p[i*2:], uint16(floatToInt16(s)))
+	}
+	return read * 2, err
+}
+
+func (r *pcm16Reader) Close() error {
+	return r.stream.Close()
+}
+
+// floatToInt16 converts a sample like ffmpeg does (scaled by 2^15, clipped)
+func floatToInt16(s float32) int16 {
+	v := math.RoundToEven(float64(s) * 32768)
+	return int16(max(-32768, min(v, 32767)))
+}
+
+const (
+	// maxFFmpegStderr caps the ffmpeg output kept for errors and warnings
+	maxFFmpegStderr = 64 << 10
+	// maxFFmpegWarnings is the number of distinct ffmpeg warnings logged per stream
+	maxFFmpegWarnings = 10
+)
+
+// ffmpegStream decodes audio with an ffmpeg process writing f32le to a pipe
+type ffmpegStream struct {
+	cmd      *exec.Cmd
+	reader   *bufio.Reader
+	channels int
+	stderr   cappedBuffer
+	logger   *slog.Logger // the job's logger for the warnings
+	raw      []byte
+	ended    bool // the output was read to the end
+	closed   bool
+	waitErr  error
+}
+
+func startFFmpegStream(ctx context.Context, inputPath string, opts decodeOptions) (*ffmpegStream, error) {
+	var args []string
+	if opts.Start > 0 {
+		args = append(args, "-ss", fmt.Sprintf("%.3f", opts.Start))
+	}
+	args = append(args, "-i", inputPath)
+	if opts.Duration > 0 {
+		args = append(args, "-t", fmt.Sprintf("%.3f", opts.Duration))
+	}
+	var filters []string
+	if !opts.Trim.IsZero() {
+		filters = append(filters, opts.Trim.filterExpr())
+	}
+	if opts.changesTempo() {
+		filters = append(filters, "atempo="+strconv.FormatFloat(opts.Tempo, 'f', -1, 64))
+	}
+	if len(filters) > 0 {
+		args = append(args, "-af", strings.Join(filters, ","))
+	}
+	args = append(args,
+		"-vn",
+		"-f", "f32le",
+		"-acodec", "pcm_f32le",
+		"-ar", strconv.Itoa(opts.SampleRate),
+		"-ac", strconv.Itoa(opts.channels()),
+		"-loglevel", "warning",
+		"pipe:1",
+	)
+
+	s := &ffmpegStream{
+		cmd:      exec.CommandContext(ctx, "ffmpeg", args...),
+		channels: opts.channels(),
+		logger:   logging.FromContext(ctx),
+	}
+	s.cmd.Stderr = &s.stderr
+	stdout, err := s.cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	if err := s.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	s.reader = bufio.NewReaderSize(stdout, 64*1024)
+	return s, nil
+}
+
+func (s *ffmpegStream) Read(buf []float32) (int, error) {
+	size := len(buf) / s.channels * s.channels * 4
+	if size == 0 {
+		return 0, nil
+	}
+	if cap(s.raw) < size {
+		s.raw = make([]byte, size)
+	}
+	frameBytes := s.channels * 4
+	n, err := io.ReadAtLeast(s.reader, s.raw[:size], frameBytes)
+	if rest := n % frameBytes; rest != 0 && err == nil {
+		// Complete the last frame
+		var m int
+		m, err = io.ReadFull(s.reader, s.raw[n:n+frameBytes-rest])
+		n += m
+	}
+	n -= n % frameBytes
+	for i := 0; i < n/4; i++ {
+		buf[i] = math.Float32frombits(binary.LittleEndian.Uint32(s.raw[i*4:]))
+	}
+	if n > 0 {
+		return n / 4, nil
+	}
+	if err != nil {
+		s.ended = true
+		return 0, io.EOF
+	}
+	return 0, nil
+}
+
+func (s *ffmpegStream) Close() error {
+	if s.closed {
+		return s.waitErr
+	}
+	s.closed = true
+	if !s.ended {
+		// Stopped early by the caller: not a decoding error
+		s.cmd.Process.Kill()
+		s.cmd.Wait()
+		return nil
+	}
+	if err := s.cmd.Wait(); err != nil {
+		s.waitErr = err
+		if msg := strings.TrimSpace(s.stderr.String()); msg != "" {
+			s.waitErr = fmt.Errorf("%w: %s", err, msg)
+		}
+		return s.waitErr
+	}
+	s.logWarnings()
+	return nil
+}
+
+// logWarnings logs what ffmpeg reported while decoding successfully (e.g.
+// damaged frames it skipped), so they show up in the job's log
+func (s *ffmpegStream) logWarnings() {
+	var warnings []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(s.stderr.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !seen[line] {
+			seen[line] = true
+			warnings = append(warnings, line)
+		}
+	}
+	for _, warning := range warnings[:min(len(warnings), maxFFmpegWarnings)] {
+		s.logger.Warn("ffmpeg warning", "message", warning)
+	}
+	if len(warnings) > maxFFmpegWarnings {
+		s.logger.Warn("More ffmpeg warnings omitted", "count", len(warnings)-maxFFmpegWarnings)
+	}
+}
+
+// cappedBuffer keeps the first maxFFmpegStderr bytes written to it
+type cappedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := maxFFmpegStderr - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+// audioInfo describes the audio of a file
+type audioInfo struct {
+	SampleRate int
+	Channels   int
+	Duration   float64 // seconds
+}
+
+// probeAudio returns the format of inputPath from its header (native
+// formats) or with ffprobe
+func probeAudio(ctx context.Context, inputPath string) (*audioInfo, error) {
+	if decoderMode != DecoderFFmpeg {
+		src, err := openNativeSource(inputPath)
+		if err == nil {
+			defer src.Close()
+			info := &audioInfo{SampleRate: src.SampleRate(), Channels: src.Channels()}
+			frames := src.Length()
+			if frames < 0 {
+				// Unknown length (e.g. a FLAC stream without it): count the samples
+				if frames, err = countFrames(ctx, src); err != nil {
+					return nil, fmt.Errorf("failed to read audio: %w", err)
+				}
+			}
+			info.Duration = float64(frames) / float64(info.SampleRate)
+			return info, nil
+		}
+		if decoderMode == DecoderNative {
+			return nil, err
+		}
+	}
+	return ffprobeAudio(ctx, inputPath)
+}
+
+// countFrames reads src to the end and returns the number of frames
+func countFrames(ctx context.Context, src sampleSource) (int64, error) {
+	buf := make([]float32, 4096*src.Channels())
+	var frames int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		n, err := src.Read(buf)
+		frames += int64(n / src.Channels())
+		if err == io.EOF {
+			return frames, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// ffprobeAudio returns the format of the first audio stream of inputPath
+func ffprobeAudio(ctx context.Context, inputPath string) (*audioInfo, error) {
+	if !lookPath("ffprobe") {
+		return nil, fmt.Errorf("ffprobe not found: please install ffmpeg")
+	}
+	output, err := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-select_streams", "a:0",
+		"-show_entries", "stream=sample_rate,channels:format=duration",
+		"-of", "default=noprint_wrappers=1",
+		inputPath,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	// One key=value per line: sample_rate, channels (stream), duration (format)
+	info := &audioInfo{}
+	var hasDuration bool
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "sample_rate":
+			info.SampleRate, _ = strconv.Atoi(value)
+		case "channels":
+			info.Channels, _ = strconv.Atoi(value)
+		case "duration":
+			if d, err := strconv.ParseFloat(value, 64); err == nil {
+				info.Duration = d
+				hasDuration = true
+			}
+		}
+	}
+	if !hasDuration {
+		return nil, fmt.Errorf("failed to parse duration of %s", inputPath)
+	}
+	return info, nil
+}
+
+// writeWavFile writes stream (mono) to outputPath as a 16-bit mono WAV file
+// and closes it
+func writeWavFile(stream audioStream, outputPath string, sampleRate int) (err error) {
+	defer func() {
+		if closeErr := stream.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("decoding failed: %w", closeErr)
+		}
+	}()
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer out.Close()
+	// The sizes in the header are filled in once all samples are written
+	if err := writeWavHeader(out, sampleRate, 0); err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(out)
+	buf := make([]float32, 8192)
+	raw := make([]byte, 2*len(buf))
+	samples := 0
+	for {
+		n, err := stream.Read(buf)
+		for i, s := range buf[:n] {
+			binary.LittleEndian.PutUint16(raw[i*2:], uint16(floatToInt16(s)))
+		}
+		if _, err := writer.Write(raw[:n*2]); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		samples += n
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	if err := writeWavHeader(out, sampleRate, samples); err != nil {
+		return err
+	}
+	return out.Close()
+}
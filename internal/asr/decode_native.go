@@ -0,0 +1,613 @@
+package asr
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hajimehoshi/go-mp3"
+	"github.com/jfreymuth/oggvorbis"
+	"github.com/mewkiz/flac"
+)
+
+// NativeFormats lists the formats decoded in Go, without ffmpeg
+// (Ogg only with Vorbis audio)
+var NativeFormats = []string{".wav", ".mp3", ".flac", ".ogg"}
+
+func isNativeFormat(ext string) bool {
+	return slices.Contains(NativeFormats, strings.ToLower(ext))
+}
+
+// errNoNativeDecoder is returned for formats only ffmpeg can decode
+var errNoNativeDecoder = errors.New("no native decoder for this format")
+
+// sampleSource is a native decoder: the audio of a file at its own sample
+// rate and channel count
+type sampleSource interface {
+	Channels() int
+	SampleRate() int
+	// Length returns the number of frames, or -1 if unknown
+	Length() int64
+	// Read reads whole frames (interleaved samples) into buf and returns the
+	// number of samples. It returns io.EOF at the end of the audio
+	Read(buf []float32) (int, error)
+	// SeekFrame moves to a frame
+	SeekFrame(frame int64) error
+	Close() error
+}
+
+// openNativeSource opens inputPath with the native decoder of its format,
+// detected from the first bytes of the file (the extension of uploads and
+// temp files is not trusted)
+func openNativeSource(inputPath string) (sampleSource, error) {
+	f, err := os.Open(inputPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("input file not found: %s", inputPath)
+		}
+		return nil, fmt.Errorf("failed to open audio: %w", err)
+	}
+	header := make([]byte, 12)
+	n, _ := io.ReadFull(f, header)
+	header = header[:n]
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	var src sampleSource
+	switch {
+	case len(header) >= 12 && string(header[0:4]) == "RIFF" && string(header[8:12]) == "WAVE":
+		src, err = openWavSource(f)
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		src, err = openFlacSource(f)
+	case bytes.HasPrefix(header, []byte("OggS")):
+		src, err = openVorbisSource(f)
+	case isMP3Header(header):
+		src, err = openMP3Source(f)
+	default:
+		err = fmt.Errorf("%w: %s", errNoNativeDecoder, filepath.Base(inputPath))
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if src.Channels() < 1 || src.SampleRate() < 1 {
+		src.Close()
+		return nil, fmt.Errorf("invalid audio format: %d channels at %dHz", src.Channels(), src.SampleRate())
+	}
+	return src, nil
+}
+
+// isMP3Header reports whether header starts with an ID3v2 tag or an MPEG
+// audio layer III frame (not AAC, whose ADTS sync word has layer 0)
+func isMP3Header(header []byte) bool {
+	if bytes.HasPrefix(header, []byte("ID3")) {
+		return true
+	}
+	return len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0 && (header[1]>>1)&0x3 == 0x1
+}
+
+// WAV sample formats (the format tag of the fmt chunk)
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// wavFormatChunkMax is the size of the largest fmt chunk (WAVE_FORMAT_EXTENSIBLE)
+const wavFormatChunkMax = 40
+
+// wavSource decodes integer (8/16/24/32-bit) and float (32/64-bit) PCM WAV
+type wavSource struct {
+	file       *os.File
+	reader     *bufio.Reader
+	format     uint16
+	bits       int
+	channels   int
+	sampleRate int
+	dataStart  int64
+	dataSize   int64
+	remaining  int64 // bytes of the data chunk not read yet
+	raw        []byte
+}
+
+func openWavSource(f *os.File) (*wavSource, error) {
+	s := &wavSource{file: f, reader: bufio.NewReaderSize(f, 64*1024)}
+	if _, err := s.reader.Discard(12); err != nil {
+		return nil, fmt.Errorf("invalid WAV file: %w", err)
+	}
+	offset := int64(12)
+	hasFormat := false
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(s.reader, chunk[:]); err != nil {
+			return nil, fmt.Errorf("invalid WAV file: no data chunk")
+		}
+		id := string(chunk[:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		offset += 8
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("invalid WAV file: fmt chunk of %d bytes", size)
+			}
+			// Only the first 40 bytes (WAVE_FORMAT_EXTENSIBLE) are used; the size
+			// comes from the file, so the rest is skipped rather than allocated
+			data := make([]byte, min(size, wavFormatChunkMax))
+			if _, err := io.ReadFull(s.reader, data); err != nil {
+				return nil, fmt.Errorf("invalid WAV file: %w", err)
+			}
+			if _, err := s.reader.Discard(int(size - int64(len(data)))); err != nil {
+				return nil, fmt.Errorf("invalid WAV file: %w", err)
+			}
+			s.format = binary.LittleEndian.Uint16(data[0:])
+			s.channels = int(binary.LittleEndian.Uint16(data[2:]))
+			s.sampleRate = int(binary.LittleEndian.Uint32(data[4:]))
+			s.bits = int(binary.LittleEndian.Uint16(data[14:]))
+			if s.format == wavFormatExtensible && size >= 26 {
+				// The format tag is the start of the SubFormat GUID
+				s.format = binary.LittleEndian.Uint16(data[24:])
+			}
+			if !s.supported() {
+				return nil, fmt.Errorf("%w: WAV format %d with %d bits", errNoNativeDecoder, s.format, s.bits)
+			}
+			hasFormat = true
+			if size%2 == 1 {
+				s.reader.Discard(1)
+			}
+		case "data":
+			if !hasFormat {
+				return nil, fmt.Errorf("invalid WAV file: data before fmt chunk")
+			}
+			// Streamed WAV files may not have the real size in the header
+			info, err := f.Stat()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read audio: %w", err)
+			}
+			if size == 0 || size == 0xFFFFFFFF || offset+size > info.Size() {
+				size = info.Size() - offset
+			}
+			frameSize := int64(s.frameSize())
+			s.dataStart = offset
+			s.dataSize = size - size%frameSize
+			s.remaining = s.dataSize
+			return s, nil
+		default:
+			if _, err := s.reader.Discard(int(size + size%2)); err != nil {
+				return nil, fmt.Errorf("invalid WAV file: no data chunk")
+			}
+		}
+		offset += size + size%2
+	}
+}
+
+func (s *wavSource) supported() bool {
+	if s.channels < 1 {
+		return false
+	}
+	switch s.format {
+	case wavFormatPCM:
+		return s.bits == 8 || s.bits == 16 || s.bits == 24 || s.bits == 32
+	case wavFormatFloat:
+		return s.bits == 32 || s.bits == 64
+	}
+	return false
+}
+
+func (s *wavSource) frameSize() int {
+	return s.channels * s.bits / 8
+}
+
+func (s *wavSource) Channels() int   { return s.channels }
+func (s *wavSource) SampleRate() int { return s.sampleRate }
+func (s *wavSource) Length() int64   { return s.dataSize / int64(s.frameSize()) }
+
+func (s *wavSource) Read(buf []float32) (int, error) {
+	frameSize := s.frameSize()
+	size := min(int64(len(buf)/s.channels*frameSize), s.remaining)
+	if size == 0 {
+		if s.remaining == 0 {
+			return 0, io.EOF
+		}
+		return 0, nil
+	}
+	if int64(cap(s.raw)) < size {
+		s.raw = make([]byte, size)
+	}
+	n, err := io.ReadFull(s.reader, s.raw[:size])
+	n -= n % frameSize
+	s.remaining -= int64(n)
+	if err != nil {
+		// Truncated file: end at the last whole frame
+		s.remaining = 0
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+
+	bytesPerSample := s.bits / 8
+	raw := s.raw[:n]
+	for i := 0; i < n/bytesPerSample; i++ {
+		b := raw[i*bytesPerSample:]
+		switch {
+		case s.format == wavFormatFloat && s.bits == 32:
+			buf[i] = math.Float32frombits(binary.LittleEndian.Uint32(b))
+		case s.format == wavFormatFloat:
+			buf[i] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		case s.bits == 8:
+			buf[i] = float32(int(b[0])-128) / 128
+		case s.bits == 16:
+			buf[i] = float32(int16(binary.LittleEndian.Uint16(b))) / 32768
+		case s.bits == 24:
+			v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+			buf[i] = float32(v) / 8388608
+		default:
+			buf[i] = float32(float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648)
+		}
+	}
+	return n / bytesPerSample, nil
+}
+
+func (s *wavSource) SeekFrame(frame int64) error {
+	pos := min(max(frame, 0)*int64(s.frameSize()), s.dataSize)
+	if _, err := s.file.Seek(s.dataStart+pos, io.SeekStart); err != nil {
+		return err
+	}
+	s.reader.Reset(s.file)
+	s.remaining = s.dataSize - pos
+	return nil
+}
+
+func (s *wavSource) Close() error {
+	return s.file.Close()
+}
+
+// mp3Source decodes MP3 (go-mp3 always outputs 16-bit stereo)
+type mp3Source struct {
+	file    *os.File
+	decoder *mp3.Decoder
+	raw     []byte
+}
+
+func openMP3Source(f *os.File) (*mp3Source, error) {
+	decoder, err := mp3.NewDecoder(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MP3 file: %w", err)
+	}
+	return &mp3Source{file: f, decoder: decoder}, nil
+}
+
+func (s *mp3Source) Channels() int   { return 2 }
+func (s *mp3Source) SampleRate() int { return s.decoder.SampleRate() }
+
+func (s *mp3Source) Length() int64 {
+	if n := s.decoder.Length(); n > 0 {
+		return n / 4
+	}
+	return -1
+}
+
+func (s *mp3Source) Read(buf []float32) (int, error) {
+	size := len(buf) / 2 * 4
+	if size == 0 {
+		return 0, nil
+	}
+	if cap(s.raw) < size {
+		s.raw = make([]byte, size)
+	}
+	n, err := io.ReadFull(s.decoder, s.raw[:size])
+	n -= n % 4
+	for i := 0; i < n/2; i++ {
+		buf[i] = float32(int16(binary.LittleEndian.Uint16(s.raw[i*2:]))) / 32768
+	}
+	if n > 0 {
+		return n / 2, nil
+	}
+	if err == nil || err == io.EOF || err == io.ErrUnexpectedEOF {
+		return 0, io.EOF
+	}
+	return 0, err
+}
+
+func (s *mp3Source) SeekFrame(frame int64) error {
+	_, err := s.decoder.Seek(frame*4, io.SeekStart)
+	return err
+}
+
+func (s *mp3Source) Close() error {
+	return s.file.Close()
+}
+
+// flacSource decodes FLAC
+type flacSource struct {
+	file    *os.File
+	stream  *flac.Stream
+	pending []float32 // interleaved samples of the current FLAC frame
+	skip    int       // frames to drop from the next FLAC frame (after SeekFrame)
+}
+
+func openFlacSource(f *os.File) (*flacSource, error) {
+	stream, err := flac.NewSeek(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FLAC file: %w", err)
+	}
+	return &flacSource{file: f, stream: stream}, nil
+}
+
+func (s *flacSource) Channels() int   { return int(s.stream.Info.NChannels) }
+func (s *flacSource) SampleRate() int { return int(s.stream.Info.SampleRate) }
+
+func (s *flacSource) Length() int64 {
+	if n := s.stream.Info.NSamples; n > 0 {
+		return int64(n)
+	}
+	return -1
+}
+
+func (s *flacSource) Read(buf []float32) (int, error) {
+	channels := s.Channels()
+	for len(s.pending) == 0 {
+		frame, err := s.stream.ParseNext()
+		if err == io.EOF {
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to decode FLAC frame: %w", err)
+		}
+		bits := frame.BitsPerSample
+		if bits == 0 {
+			bits = s.stream.Info.BitsPerSample
+		}
+		scale := 1 / float32(int64(1)<<(bits-1))
+		n := int(frame.BlockSize)
+		for i := s.skip; i < n; i++ {
+			for ch := 0; ch < channels; ch++ {
+				s.pending = append(s.pending, float32(frame.Subframes[ch].Samples[i])*scale)
+			}
+		}
+		s.skip = max(s.skip-n, 0)
+	}
+	n := min(len(buf), len(s.pending)) / channels * channels
+	copy(buf, s.pending[:n])
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func (s *flacSource) SeekFrame(frame int64) error {
+	start, err := s.stream.Seek(uint64(max(frame, 0)))
+	if err != nil {
+		return err
+	}
+	s.pending = nil
+	s.skip = int(uint64(frame) - start)
+	return nil
+}
+
+func (s *flacSource) Close() error {
+	return s.file.Close()
+}
+
+// vorbisSource decodes Ogg Vorbis
+type vorbisSource struct {
+	file   *os.File
+	reader *oggvorbis.Reader
+}
+
+func openVorbisSource(f *os.File) (*vorbisSource, error) {
+	reader, err := oggvorbis.NewReader(f)
+	if err != nil {
+		// Most likely Ogg with Opus audio
+		return nil, fmt.Errorf("%w: Ogg without Vorbis audio (%v)", errNoNativeDecoder, err)
+	}
+	return &vorbisSource{file: f, reader: reader}, nil
+}
+
+func (s *vorbisSource) Channels() int   { return s.reader.Channels() }
+func (s *vorbisSource) SampleRate() int { return s.reader.SampleRate() }
+
+func (s *vorbisSource) Length() int64 {
+	if n := s.reader.Length(); n > 0 {
+		return n
+	}
+	return -1
+}
+
+func (s *vorbisSource) Read(buf []float32) (int, error) {
+	channels := s.Channels()
+	n, err := s.reader.Read(buf[:len(buf)/channels*channels])
+	if n > 0 {
+		return n, nil
+	}
+	if err == nil || err == io.EOF {
+		return 0, io.EOF
+	}
+	return 0, err
+}
+
+func (s *vorbisSource) SeekFrame(frame int64) error {
+	return s.reader.SetPosition(frame)
+}
+
+func (s *vorbisSource) Close() error {
+	return s.file.Close()
+}
+
+// nativeStream turns a sampleSource into the audio selected by
+// decodeOptions: it selects the time range, downmixes, resamples and
+// changes the tempo, block by block
+type nativeStream struct {
+	ctx        context.Context
+	src        sampleSource
+	channels   int          // output channels
+	frame      int64        // source frames read (the position in the original audio)
+	skip       int64        // source frames to drop before Start (when SeekFrame failed)
+	remaining  int64        // source frames left until Start+Duration (-1: until the end)
+	trim       *TrimOptions // nil: keep everything
+	resamplers []*resampler // one per output channel, nil at the same rate
+	stretcher  *timeStretcher
+	in         []float32
+	planes     [][]float32 // the current block of each output channel
+	out        []float32   // interleaved output not read yet
+	done       bool
+	err        error
+}
+
+// nativeBlockFrames is the number of source frames processed at a time
+const nativeBlockFrames = 4096
+
+func newNativeStream(ctx context.Context, src sampleSource, opts decodeOptions) *nativeStream {
+	s := &nativeStream{
+		ctx:       ctx,
+		src:       src,
+		channels:  opts.channels(),
+		remaining: -1,
+		in:        make([]float32, nativeBlockFrames*src.Channels()),
+		planes:    make([][]float32, opts.channels()),
+	}
+	rate := float64(src.SampleRate())
+	if opts.Start > 0 {
+		start := int64(math.Round(opts.Start * rate))
+		if err := src.SeekFrame(start); err == nil {
+			s.frame = start
+		} else {
+			s.skip = start
+		}
+	}
+	if opts.Duration > 0 {
+		s.remaining = int64(math.Round(opts.Duration * rate))
+	}
+	if !opts.Trim.IsZero() {
+		s.trim = opts.Trim
+	}
+	if src.SampleRate() != opts.SampleRate {
+		for range s.channels {
+			s.resamplers = append(s.resamplers, newResampler(src.SampleRate(), opts.SampleRate))
+		}
+	}
+	if opts.changesTempo() {
+		s.stretcher = newTimeStretcher(opts.SampleRate, opts.Tempo)
+	}
+	return s
+}
+
+func (s *nativeStream) Read(buf []float32) (int, error) {
+	for len(s.out) == 0 {
+		if s.done {
+			return 0, io.EOF
+		}
+		if err := s.ctx.Err(); err != nil {
+			// Like ffmpeg killed by CommandContext: the audio ends early
+			s.done = true
+			s.err = err
+			return 0, io.EOF
+		}
+		s.fill()
+	}
+	n := min(len(buf), len(s.out)) / s.channels * s.channels
+	copy(buf, s.out[:n])
+	s.out = s.out[n:]
+	return n, nil
+}
+
+// fill decodes the next block of the source into out
+func (s *nativeStream) fill() {
+	inChannels := s.src.Channels()
+	n, err := s.src.Read(s.in)
+	rate := float64(s.src.SampleRate())
+
+	for ch := range s.planes {
+		s.planes[ch] = s.planes[ch][:0]
+	}
+	for i := 0; i < n/inChannels; i++ {
+		frame := s.frame
+		s.frame++
+		if s.skip > 0 {
+			s.skip--
+			continue
+		}
+		if s.remaining == 0 {
+			break
+		}
+		if s.remaining > 0 {
+			s.remaining--
+		}
+		if s.trim != nil && !s.trim.keeps(float64(frame)/rate) {
+			continue
+		}
+		s.mix(s.in[i*inChannels : (i+1)*inChannels])
+	}
+
+	if err != nil && err != io.EOF {
+		s.err = err
+	}
+	end := err != nil || s.remaining == 0
+	if end {
+		s.done = true
+	}
+
+	processed := make([][]float32, len(s.planes))
+	for ch, plane := range s.planes {
+		if s.resamplers != nil {
+			plane = s.resamplers[ch].process(plane)
+			if end {
+				plane = append(plane, s.resamplers[ch].flush()...)
+			}
+		}
+		if s.stretcher != nil {
+			plane = s.stretcher.process(plane)
+			if end {
+				plane = append(plane, s.stretcher.flush()...)
+			}
+		}
+		processed[ch] = plane
+	}
+	out := make([]float32, 0, len(processed[0])*s.channels)
+	for i := range processed[0] {
+		for ch := range processed {
+			out = append(out, processed[ch][i])
+		}
+	}
+	s.out = out
+}
+
+// mix adds one source frame to the output channels: the average of all
+// channels for mono; for stereo, mono is duplicated and the even and odd
+// channels of multichannel audio are averaged into left and right
+func (s *nativeStream) mix(frame []float32) {
+	if s.channels == 1 || len(frame) == 1 {
+		var sum float32
+		for _, v := range frame {
+			sum += v
+		}
+		for ch := range s.planes {
+			s.planes[ch] = append(s.planes[ch], sum/float32(len(frame)))
+		}
+		return
+	}
+	var left, right float32
+	for i, v := range frame {
+		if i%2 == 0 {
+			left += v
+		} else {
+			right += v
+		}
+	}
+	s.planes[0] = append(s.planes[0], left/float32((len(frame)+1)/2))
+	s.planes[1] = append(s.planes[1], right/float32(len(frame)/2))
+}
+
+func (s *nativeStream) Close() error {
+	s.src.Close()
+	return s.err
+}
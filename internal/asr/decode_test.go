@@ -0,0 +1,529 @@
+package asr
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/frame"
+	"github.com/mewkiz/flac/meta"
+)
+
+// Native decoding tests write their input files in the test, so they run
+// without ffmpeg
+
+// sine returns seconds of a sine at freq sampled at rate
+func sine(rate int, seconds, freq, amp float64) []float32 {
+	samples := make([]float32, int(math.Round(seconds*float64(rate))))
+	for i := range samples {
+		samples[i] = float32(amp * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+	return samples
+}
+
+// writeTestWAV writes interleaved samples as a WAV file with the given
+// format tag (wavFormatPCM or wavFormatFloat) and sample size
+func writeTestWAV(t *testing.T, path string, samples []float32, rate, channels int, format uint16, bits int) {
+	t.Helper()
+	var data bytes.Buffer
+	for _, s := range samples {
+		switch {
+		case format == wavFormatFloat:
+			binary.Write(&data, binary.LittleEndian, s)
+		case bits == 8:
+			data.WriteByte(byte(int(math.Round(float64(s)*127)) + 128))
+		case bits == 16:
+			binary.Write(&data, binary.LittleEndian, int16(math.Round(float64(s)*32767)))
+		case bits == 24:
+			v := int32(math.Round(float64(s) * 8388607))
+			data.Write([]byte{byte(v), byte(v >> 8), byte(v >> 16)})
+		}
+	}
+	frameSize := channels * bits / 8
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(4+8+16+8+6+8+data.Len()))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, format)
+	binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	binary.Write(&buf, binary.LittleEndian, uint32(rate))
+	binary.Write(&buf, binary.LittleEndian, uint32(rate*frameSize))
+	binary.Write(&buf, binary.LittleEndian, uint16(frameSize))
+	binary.Write(&buf, binary.LittleEndian, uint16(bits))
+	// An odd-sized chunk before the data, like the LIST chunks of editors
+	buf.WriteString("LIST")
+	binary.Write(&buf, binary.LittleEndian, uint32(5))
+	buf.WriteString("INFO\x00\x00")
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(data.Len()))
+	buf.Write(data.Bytes())
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readAllAudio reads a stream to the end
+func readAllAudio(t *testing.T, stream audioStream) []float32 {
+	t.Helper()
+	var all []float32
+	buf := make([]float32, 1000)
+	for {
+		n, err := stream.Read(buf)
+		all = append(all, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return all
+}
+
+// dominantFrequency estimates the frequency of a tone from its zero crossings
+func dominantFrequency(samples []float32, rate int) float64 {
+	crossings := 0
+	for i := 1; i < len(samples); i++ {
+		if (samples[i-1] < 0) != (samples[i] < 0) {
+			crossings++
+		}
+	}
+	return float64(crossings) / 2 / (float64(len(samples)) / float64(rate))
+}
+
+// TestWavSourceFormats tests decoding the sample formats of WAV files
+func TestWavSourceFormats(t *testing.T) {
+	want := []float32{0, 0.5, -0.5, 0.25, -0.999, 0.999}
+	tests := []struct {
+		name   string
+		format uint16
+		bits   int
+		tol    float64
+	}{
+		{"pcm8", wavFormatPCM, 8, 1.0 / 64},
+		{"pcm16", wavFormatPCM, 16, 1.0 / 16384},
+		{"pcm24", wavFormatPCM, 24, 1.0 / 4194304},
+		{"float32", wavFormatFloat, 32, 1e-7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.name+".wav")
+			writeTestWAV(t, path, want, 8000, 2, tt.format, tt.bits)
+
+			src, err := openNativeSource(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer src.Close()
+			if src.Channels() != 2 || src.SampleRate() != 8000 || src.Length() != 3 {
+				t.Fatalf("got %d channels at %dHz, %d frames; want 2 at 8000Hz, 3", src.Channels(), src.SampleRate(), src.Length())
+			}
+			got := make([]float32, 10)
+			n, err := src.Read(got)
+			if err != nil || n != len(want) {
+				t.Fatalf("Read = %d, %v; want %d samples", n, err, len(want))
+			}
+			for i := range want {
+				if math.Abs(float64(got[i]-want[i])) > tt.tol {
+					t.Errorf("sample %d = %v, want %v", i, got[i], want[i])
+				}
+			}
+			if _, err := src.Read(got); err != io.EOF {
+				t.Errorf("Read at the end = %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+// TestWavSourceOversizedFormat tests that the fmt chunk size of a crafted
+// WAV file is not allocated before the file runs out
+func TestWavSourceOversizedFormat(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(52))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(0xFFFFFFF0))
+	binary.Write(&buf, binary.LittleEndian, uint16(wavFormatPCM))
+	binary.Write(&buf, binary.LittleEndian, uint16(1))
+	binary.Write(&buf, binary.LittleEndian, uint32(16000))
+	binary.Write(&buf, binary.LittleEndian, uint32(32000))
+	binary.Write(&buf, binary.LittleEndian, uint16(2))
+	binary.Write(&buf, binary.LittleEndian, uint16(16))
+	buf.Write(make([]byte, 24))
+	path := filepath.Join(t.TempDir(), "crafted.wav")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	src, err := openNativeSource(path)
+	runtime.ReadMemStats(&after)
+	if err == nil {
+		src.Close()
+		t.Fatal("openNativeSource() succeeded, want an error")
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("allocated %d bytes for a %d-byte file", allocated, buf.Len())
+	}
+}
+
+// TestOpenPCMNative tests that a 16kHz mono WAV is decoded unchanged, and
+// the time range options
+func TestOpenPCMNative(t *testing.T) {
+	samples := synthesize(synthTimeline)
+	path := filepath.Join(t.TempDir(), "synth.wav")
+	writeSynthWAV(t, path, samples)
+	ctx := context.Background()
+
+	read := func(opts decodeOptions) []byte {
+		t.Helper()
+		opts.SampleRate = synthSampleRate
+		stream, err := openPCM(ctx, path, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := stream.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	if !bytes.Equal(read(decodeOptions{}), pcm16(samples)) {
+		t.Error("decoded PCM differs from the WAV data")
+	}
+	if got, want := read(decodeOptions{Start: 1.5, Duration: 2}), pcm16(samples[24000:56000]); !bytes.Equal(got, want) {
+		t.Errorf("Start/Duration: got %d bytes, want %d (the same samples)", len(got), len(want))
+	}
+	// Start after the end: no audio
+	if got := read(decodeOptions{Start: 100}); len(got) != 0 {
+		t.Errorf("Start after the end: got %d bytes, want 0", len(got))
+	}
+
+	trim := &TrimOptions{Start: 1, End: 5, Exclude: []TimeRange{{Start: 2, End: 3}}}
+	got := read(decodeOptions{Trim: trim})
+	if n := len(got) / 2; math.Abs(float64(n)-3*synthSampleRate) > 2 {
+		t.Errorf("trimmed to %d samples, want about %d", n, 3*synthSampleRate)
+	}
+	if !bytes.Equal(got[:2*synthSampleRate], pcm16(samples[synthSampleRate:2*synthSampleRate])) {
+		t.Error("trimmed audio does not start at trim.Start")
+	}
+}
+
+// TestOpenAudioResampleDownmix tests a 44.1kHz stereo WAV decoded to
+// 16kHz mono: the length and the tone are kept
+func TestOpenAudioResampleDownmix(t *testing.T) {
+	const rate = 44100
+	left := sine(rate, 2, 440, 0.5)
+	stereo := make([]float32, 2*len(left))
+	for i, s := range left {
+		stereo[2*i] = s
+		stereo[2*i+1] = s * 0.5
+	}
+	path := filepath.Join(t.TempDir(), "stereo.wav")
+	writeTestWAV(t, path, stereo, rate, 2, wavFormatPCM, 16)
+
+	stream, err := openAudio(context.Background(), path, decodeOptions{SampleRate: 16000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := readAllAudio(t, stream)
+	if math.Abs(float64(len(got))-32000) > 1 {
+		t.Errorf("got %d samples, want 32000", len(got))
+	}
+	if f := dominantFrequency(got, 16000); math.Abs(f-440) > 2 {
+		t.Errorf("tone at %.1fHz, want 440Hz", f)
+	}
+	if peak := maxAbs(got); math.Abs(peak-0.375) > 0.01 {
+		t.Errorf("peak %.3f, want 0.375 (the average of the channels)", peak)
+	}
+
+	// Stereo output keeps the channels
+	stream, err = openAudio(context.Background(), path, decodeOptions{SampleRate: rate, Channels: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readAllAudio(t, stream); len(got) != len(stereo) || got[2*100+1] != float32(int16(math.Round(float64(stereo[2*100+1])*32767)))/32768 {
+		t.Errorf("stereo output: got %d samples, want the %d samples of the file", len(got), len(stereo))
+	}
+}
+
+func maxAbs(samples []float32) float64 {
+	var peak float64
+	for _, s := range samples {
+		peak = math.Max(peak, math.Abs(float64(s)))
+	}
+	return peak
+}
+
+// TestTimeStretcher tests that a tempo change scales the length and keeps
+// the pitch, for any block size
+func TestTimeStretcher(t *testing.T) {
+	in := sine(16000, 3, 200, 0.5)
+	for _, tempo := range []float64{0.8, 0.95, 1.25} {
+		for _, block := range []int{160, 4096, len(in)} {
+			st := newTimeStretcher(16000, tempo)
+			var out []float32
+			for i := 0; i < len(in); i += block {
+				out = append(out, st.process(in[i:min(i+block, len(in))])...)
+			}
+			out = append(out, st.flush()...)
+
+			want := math.Round(float64(len(in)) / tempo)
+			if math.Abs(float64(len(out))-want) > 1 {
+				t.Errorf("tempo %.2f, block %d: got %d samples, want %.0f", tempo, block, len(out), want)
+			}
+			// Skip the edges, where frames overlap silence
+			if f := dominantFrequency(out[1600:len(out)-1600], 16000); math.Abs(f-200) > 3 {
+				t.Errorf("tempo %.2f, block %d: tone at %.1fHz, want 200Hz", tempo, block, f)
+			}
+		}
+	}
+}
+
+// TestResampler tests the output length of stream resampling in blocks
+func TestResampler(t *testing.T) {
+	in := sine(48000, 1, 300, 0.5)
+	for _, to := range []int{16000, 8000, 22050, 96000} {
+		r := newResampler(48000, to)
+		var out []float32
+		for i := 0; i < len(in); i += 1000 {
+			out = append(out, r.process(in[i:min(i+1000, len(in))])...)
+		}
+		out = append(out, r.flush()...)
+		if len(out) != to {
+			t.Errorf("48000Hz to %dHz: got %d samples, want %d", to, len(out), to)
+		}
+		if f := dominantFrequency(out, to); math.Abs(f-300) > 2 {
+			t.Errorf("48000Hz to %dHz: tone at %.1fHz, want 300Hz", to, f)
+		}
+	}
+}
+
+// TestResamplerFilter tests that downsampling keeps the tones below the new
+// Nyquist frequency and removes the tones above it instead of folding them
+func TestResamplerFilter(t *testing.T) {
+	tests := []struct {
+		from, to int
+		freq     float64
+		gain     float64 // expected amplitude ratio
+	}{
+		{48000, 16000, 3000, 1},
+		{44100, 16000, 6500, 1},
+		{48000, 16000, 10000, 0},
+		{44100, 16000, 12000, 0},
+		{16000, 8000, 3000, 1},
+		{16000, 8000, 5000, 0},
+		{8000, 16000, 1000, 1},
+	}
+	for _, tt := range tests {
+		r := newResampler(tt.from, tt.to)
+		out := append(r.process(sine(tt.from, 1, tt.freq, 0.5)), r.flush()...)
+		// Skip the edges, where the filter reads the silence around the input
+		edge := tt.to / 10
+		peak := maxAbs(out[edge : len(out)-edge])
+		if tt.gain > 0 {
+			if math.Abs(peak/0.5-tt.gain) > 0.01 {
+				t.Errorf("%dHz to %dHz, %.0fHz tone: amplitude %.4f, want %.4f", tt.from, tt.to, tt.freq, peak, 0.5*tt.gain)
+			}
+		} else if peak > 0.5e-3 { // -60dB
+			t.Errorf("%dHz to %dHz, %.0fHz tone: aliased at amplitude %.5f", tt.from, tt.to, tt.freq, peak)
+		}
+	}
+}
+
+// TestDecodePCMRates tests that a 48kHz WAV file is decoded without ffmpeg
+// for 16kHz and 8kHz models, with the same speech blocks as the original
+func TestDecodePCMRates(t *testing.T) {
+	samples := synthesize(synthTimeline)
+	config := DefaultSilenceConfig()
+	config.MaxBlockDuration = 0
+	want, err := detectSilenceBlocks(bytes.NewReader(pcm16(samples)), synthSampleRate, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up := newResampler(synthSampleRate, 48000)
+	path := filepath.Join(t.TempDir(), "synth48k.wav")
+	writeTestWAV(t, path, append(up.process(samples), up.flush()...), 48000, 1, wavFormatPCM, 16)
+
+	seconds := float64(len(samples)) / synthSampleRate
+	for _, rate := range []int{16000, 8000} {
+		pcm, err := decodePCM(context.Background(), path, rate, 1.0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := int64(math.Round(seconds * float64(rate))); pcm.samples != want {
+			t.Errorf("%dHz: decoded %d samples, want %d", rate, pcm.samples, want)
+		}
+		got, err := detectSilenceBlocks(pcm.Reader(), rate, config)
+		pcm.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%dHz: %d blocks, want %d", rate, len(got), len(want))
+		}
+		for i := range got {
+			// Within one RMS frame of the original
+			if math.Abs(got[i].StartTime-want[i].StartTime) > 0.03 || math.Abs(got[i].EndTime-want[i].EndTime) > 0.03 {
+				t.Errorf("%dHz: block %d = %.3f-%.3f, want %.3f-%.3f", rate, i, got[i].StartTime, got[i].EndTime, want[i].StartTime, want[i].EndTime)
+			}
+		}
+	}
+}
+
+// TestFlacSource tests decoding and seeking a FLAC file
+func TestFlacSource(t *testing.T) {
+	const rate, blockSize = 16000, 4096
+	samples := pcm16(sine(rate, 1, 440, 0.5))
+	path := filepath.Join(t.TempDir(), "tone.flac")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &meta.StreamInfo{
+		BlockSizeMin:  blockSize,
+		BlockSizeMax:  blockSize,
+		SampleRate:    rate,
+		NChannels:     1,
+		BitsPerSample: 16,
+		NSamples:      uint64(len(samples) / 2),
+	}
+	enc, err := flac.NewEncoder(f, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ints := make([]int32, len(samples)/2)
+	for i := range ints {
+		ints[i] = int32(int16(binary.LittleEndian.Uint16(samples[2*i:])))
+	}
+	for i := 0; i < len(ints); i += blockSize {
+		block := ints[i:min(i+blockSize, len(ints))]
+		fr := &frame.Frame{
+			Header: frame.Header{
+				HasFixedBlockSize: true,
+				BlockSize:         uint16(len(block)),
+				SampleRate:        rate,
+				Channels:          frame.ChannelsMono,
+				BitsPerSample:     16,
+				Num:               uint64(i / blockSize),
+			},
+			Subframes: []*frame.Subframe{{
+				SubHeader: frame.SubHeader{Pred: frame.PredVerbatim},
+				Samples:   block,
+				NSamples:  len(block),
+			}},
+		}
+		if err := enc.WriteFrame(fr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	ctx := context.Background()
+	info2, err := probeAudio(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info2.SampleRate != rate || info2.Channels != 1 || info2.Duration != 1 {
+		t.Errorf("probeAudio = %+v, want 16000Hz mono, 1s", *info2)
+	}
+
+	stream, err := openPCM(ctx, path, decodeOptions{SampleRate: rate, Start: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(stream)
+	stream.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, samples[len(samples)/2:]) {
+		t.Errorf("got %d bytes from 0.5s, want the %d bytes of the file", len(got), len(samples)/2)
+	}
+}
+
+// TestNativeDecoderSelection tests the formats without a native decoder
+func TestNativeDecoderSelection(t *testing.T) {
+	dir := t.TempDir()
+	m4a := filepath.Join(dir, "audio.m4a")
+	if err := os.WriteFile(m4a, []byte("\x00\x00\x00\x20ftypM4A \x00\x00\x00\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openNativeSource(m4a); !errors.Is(err, errNoNativeDecoder) {
+		t.Errorf("m4a: got %v, want errNoNativeDecoder", err)
+	}
+
+	defer SetAudioDecoder(DecoderAuto)
+	if err := SetAudioDecoder(DecoderNative); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openAudio(context.Background(), m4a, decodeOptions{SampleRate: 16000}); !errors.Is(err, errNoNativeDecoder) {
+		t.Errorf("native mode: got %v, want errNoNativeDecoder", err)
+	}
+	if err := SetAudioDecoder("gstreamer"); err == nil {
+		t.Error("SetAudioDecoder accepted an unknown decoder")
+	}
+
+	for _, header := range [][]byte{{0xFF, 0xFB, 0x90}, []byte("ID3\x04")} {
+		if !isMP3Header(header) {
+			t.Errorf("isMP3Header(% x) = false, want true", header)
+		}
+	}
+	// ADTS (AAC) has the same sync word with layer 0
+	if isMP3Header([]byte{0xFF, 0xF1, 0x50}) {
+		t.Error("isMP3Header accepted an AAC ADTS header")
+	}
+}
+
+// TestConvertToWavNative tests converting a 48kHz stereo WAV without ffmpeg
+func TestConvertToWavNative(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.wav")
+	mono := sine(48000, 1.5, 440, 0.5)
+	stereo := make([]float32, 2*len(mono))
+	for i, s := range mono {
+		stereo[2*i], stereo[2*i+1] = s, s
+	}
+	writeTestWAV(t, input, stereo, 48000, 2, wavFormatFloat, 32)
+
+	ctx := context.Background()
+	if needs, _ := NeedsConversion(ctx, input); !needs {
+		t.Error("NeedsConversion(48kHz stereo) = false")
+	}
+	output := filepath.Join(dir, "out", "output.wav")
+	if err := ConvertToWav(ctx, input, output); err != nil {
+		t.Fatal(err)
+	}
+	if needs, _ := NeedsConversion(ctx, output); needs {
+		t.Error("NeedsConversion(converted) = true")
+	}
+	duration, err := GetAudioDuration(ctx, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(duration-1.5) > 0.001 {
+		t.Errorf("converted duration %.4f, want 1.5", duration)
+	}
+}
@@ -0,0 +1,252 @@
+package asr
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Thresholds above/below which a recording is flagged as problematic
+const (
+	quietLoudnessLUFS     = -35.0 // integrated loudness below this is too quiet
+	clippingWarnPercent   = 0.1   // percentage of clipped samples
+	dcOffsetWarn          = 0.01  // absolute mean sample value
+	channelImbalanceWarn  = 6.0   // dB difference between channels
+	clippingSampleLevel   = 0.999 // sample magnitude treated as clipped
+	diagnosticsSampleRate = 48000 // K-weighting coefficients are defined for 48kHz
+)
+
+// AudioDiagnostics contains recording quality metrics for an audio file
+type AudioDiagnostics struct {
+	File               string   `json:"file,omitempty"`
+	IntegratedLUFS     float64  `json:"integrated_lufs"`      // ITU-R BS.1770 integrated loudness
+	PeakDBFS           float64  `json:"peak_dbfs"`            // sample peak in dBFS
+	ClippingPercent    float64  `json:"clipping_percent"`     // percentage of clipped samples
+	DCOffset           float64  `json:"dc_offset"`            // largest per-channel mean sample value
+	ChannelImbalanceDB float64  `json:"channel_imbalance_db"` // RMS difference between L/R (0 for mono)
+	Channels           int      `json:"channels"`             // analyzed channel count
+	Warnings           []string `json:"warnings,omitempty"`   // human readable problems
+}
+
+// HasWarnings reports whether any recording problem was detected
+func (d *AudioDiagnostics) HasWarnings() bool {
+	return len(d.Warnings) > 0
+}
+
+// AnalyzeAudio decodes an audio file and computes loudness, clipping, DC
+// offset, and channel imbalance metrics
+func AnalyzeAudio(ctx context.Context, inputPath string) (*AudioDiagnostics, error) {
+	channels := 1
+	if info, err := probeAudio(ctx, inputPath); err == nil && info.Channels > 1 {
+		channels = 2 // downmix surround to stereo for analysis
+	}
+
+	stream, err := openAudio(ctx, inputPath, decodeOptions{SampleRate: diagnosticsSampleRate, Channels: channels})
+	if err != nil {
+		return nil, err
+	}
+
+	acc := newDiagnosticsAccumulator(channels, diagnosticsSampleRate)
+	buf := make([]float32, 4096*channels)
+	for {
+		n, err := stream.Read(buf)
+		for i := 0; i+channels <= n; i += channels {
+			acc.add(buf[i : i+channels])
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if err := stream.Close(); err != nil {
+		return nil, fmt.Errorf("decoding failed: %w", err)
+	}
+
+	return acc.result(), nil
+}
+
+// biquad is a second-order IIR filter (direct form I)
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// newKWeighting returns the two-stage K-weighting filter from ITU-R BS.1770 (48kHz)
+func newKWeighting() [2]biquad {
+	return [2]biquad{
+		{b0: 1.53512485958697, b1: -2.69169618940638, b2: 1.19839281085285, a1: -1.69065929318241, a2: 0.73248077421585},
+		{b0: 1.0, b1: -2.0, b2: 1.0, a1: -1.99004745483398, a2: 0.99007225036621},
+	}
+}
+
+// diagnosticsAccumulator collects per-sample statistics in a single pass
+type diagnosticsAccumulator struct {
+	channels   int
+	sampleRate int
+
+	filters     [][2]biquad
+	subBlockSum []float64 // K-weighted sum of squares for the current 100ms sub-block
+	subBlockLen int
+	subBlocks   []float64 // mean square (summed over channels) per 100ms sub-block
+
+	sum     []float64 // raw sample sum per channel (DC offset)
+	sumSq   []float64 // raw sum of squares per channel (RMS)
+	peak    float64
+	clipped int64
+	total   int64
+	frames  int64
+}
+
+func newDiagnosticsAccumulator(channels, sampleRate int) *diagnosticsAccumulator {
+	acc := &diagnosticsAccumulator{
+		channels:    channels,
+		sampleRate:  sampleRate,
+		filters:     make([][2]biquad, channels),
+		subBlockSum: make([]float64, channels),
+		sum:         make([]float64, channels),
+		sumSq:       make([]float64, channels),
+	}
+	for ch := range acc.filters {
+		acc.filters[ch] = newKWeighting()
+	}
+	return acc
+}
+
+// add processes one interleaved frame (one sample per channel)
+func (a *diagnosticsAccumulator) add(frame []float32) {
+	for ch := 0; ch < a.channels; ch++ {
+		x := float64(frame[ch])
+
+		a.sum[ch] += x
+		a.sumSq[ch] += x * x
+		abs := math.Abs(x)
+		if abs > a.peak {
+			a.peak = abs
+		}
+		if abs >= clippingSampleLevel {
+			a.clipped++
+		}
+		a.total++
+
+		y := a.filters[ch][0].process(x)
+		y = a.filters[ch][1].process(y)
+		a.subBlockSum[ch] += y * y
+	}
+	a.frames++
+	a.subBlockLen++
+
+	if a.subBlockLen == a.sampleRate/10 {
+		var power float64
+		for ch := 0; ch < a.channels; ch++ {
+			power += a.subBlockSum[ch] / float64(a.subBlockLen)
+			a.subBlockSum[ch] = 0
+		}
+		a.subBlocks = append(a.subBlocks, power)
+		a.subBlockLen = 0
+	}
+}
+
+// integratedLoudness applies the BS.1770 gating over 400ms blocks with 75% overlap
+func (a *diagnosticsAccumulator) integratedLoudness() float64 {
+	if len(a.subBlocks) < 4 {
+		return math.Inf(-1)
+	}
+
+	blocks := make([]float64, 0, len(a.subBlocks)-3)
+	for i := 0; i+4 <= len(a.subBlocks); i++ {
+		blocks = append(blocks, (a.subBlocks[i]+a.subBlocks[i+1]+a.subBlocks[i+2]+a.subBlocks[i+3])/4)
+	}
+
+	loudness := func(power float64) float64 {
+		return -0.691 + 10*math.Log10(power)
+	}
+	gatedMean := func(threshold float64) (float64, int) {
+		var sum float64
+		var n int
+		for _, p := range blocks {
+			if p > 0 && loudness(p) > threshold {
+				sum += p
+				n++
+			}
+		}
+		if n == 0 {
+			return 0, 0
+		}
+		return sum / float64(n), n
+	}
+
+	// Absolute gate at -70 LUFS, then relative gate 10 LU below
+	absMean, n := gatedMean(-70)
+	if n == 0 {
+		return math.Inf(-1)
+	}
+	relMean, n := gatedMean(loudness(absMean) - 10)
+	if n == 0 {
+		return math.Inf(-1)
+	}
+	return loudness(relMean)
+}
+
+// result computes the final metrics and warnings
+func (a *diagnosticsAccumulator) result() *AudioDiagnostics {
+	d := &AudioDiagnostics{Channels: a.channels}
+	if a.frames == 0 {
+		d.Warnings = append(d.Warnings, "no audio samples decoded")
+		return d
+	}
+
+	d.IntegratedLUFS = roundTo(a.integratedLoudness(), 1)
+	d.PeakDBFS = roundTo(20*math.Log10(a.peak), 1)
+	d.ClippingPercent = roundTo(float64(a.clipped)*100/float64(a.total), 3)
+
+	for ch := 0; ch < a.channels; ch++ {
+		mean := a.sum[ch] / float64(a.frames)
+		if math.Abs(mean) > math.Abs(d.DCOffset) {
+			d.DCOffset = mean
+		}
+	}
+	d.DCOffset = roundTo(d.DCOffset, 4)
+
+	if a.channels == 2 && a.sumSq[0] > 0 && a.sumSq[1] > 0 {
+		d.ChannelImbalanceDB = roundTo(math.Abs(10*math.Log10(a.sumSq[0]/a.sumSq[1])), 1)
+	}
+
+	// JSON cannot encode -Inf, so silent files report the floor value
+	if math.IsInf(d.IntegratedLUFS, -1) {
+		d.IntegratedLUFS = -70
+	}
+	if math.IsInf(d.PeakDBFS, -1) {
+		d.PeakDBFS = -96
+	}
+
+	if d.IntegratedLUFS < quietLoudnessLUFS {
+		d.Warnings = append(d.Warnings, fmt.Sprintf("recording is very quiet (%.1f LUFS)", d.IntegratedLUFS))
+	}
+	if d.ClippingPercent > clippingWarnPercent {
+		d.Warnings = append(d.Warnings, fmt.Sprintf("clipping detected in %.2f%% of samples", d.ClippingPercent))
+	}
+	if math.Abs(d.DCOffset) > dcOffsetWarn {
+		d.Warnings = append(d.Warnings, fmt.Sprintf("DC offset of %.3f", d.DCOffset))
+	}
+	if d.ChannelImbalanceDB > channelImbalanceWarn {
+		d.Warnings = append(d.Warnings, fmt.Sprintf("channels differ by %.1f dB", d.ChannelImbalanceDB))
+	}
+
+	return d
+}
+
+// roundTo rounds v to the given number of decimal places
+func roundTo(v float64, places int) float64 {
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return v
+	}
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,116 @@
+package asr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ReplacementRule is an entry of a replacement dictionary
+type ReplacementRule struct {
+	Pattern     string // surface form, or a regexp if Regex
+	Replacement string // regexp rules may use $1 etc.
+	Regex       bool
+}
+
+// Validate checks the pattern is usable
+func (r ReplacementRule) Validate() error {
+	if r.Pattern == "" {
+		return fmt.Errorf("pattern is required")
+	}
+	if !r.Regex {
+		return nil
+	}
+	re, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	// A pattern matching the empty string would insert the replacement everywhere
+	if re.MatchString("") {
+		return fmt.Errorf("pattern must not match an empty string")
+	}
+	return nil
+}
+
+// Dictionary rewrites recognized text with user-defined replacement rules,
+// e.g. "ゼットボル" -> "zbor". Rules are applied in order after ITN, to the
+// text, segment texts and tokens the same way as ITN rules
+type Dictionary struct {
+	rules []itnRule
+}
+
+// NewDictionary compiles the rules of a replacement dictionary
+func NewDictionary(rules []ReplacementRule) (*Dictionary, error) {
+	d := &Dictionary{}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("replacement %q: %w", rule.Pattern, err)
+		}
+		if rule.Regex {
+			d.rules = append(d.rules, itnRule{pattern: regexp.MustCompile(rule.Pattern), replace: rule.Replacement})
+			continue
+		}
+		// Surface forms match literally and are replaced as is
+		d.rules = append(d.rules, itnRule{
+			pattern: regexp.MustCompile(regexp.QuoteMeta(rule.Pattern)),
+			replace: strings.ReplaceAll(rule.Replacement, "$", "$$"),
+		})
+	}
+	return d, nil
+}
+
+// Len returns the number of rules
+func (d *Dictionary) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.rules)
+}
+
+// Normalize applies the rules to a plain string
+func (d *Dictionary) Normalize(s string) string {
+	if d == nil {
+		return s
+	}
+	for _, rule := range d.rules {
+		s = applyReplacements(s, rule.pass(s))
+	}
+	return s
+}
+
+// Apply rewrites the result in place: text, segment texts and tokens.
+// Tokens covered by a replacement are merged into one token spanning their
+// time range, so timestamps stay consistent with the rewritten text. Tokens
+// and segments left empty (rules that delete words) are removed
+func (d *Dictionary) Apply(r *Result) {
+	if d.Len() == 0 || r == nil {
+		return
+	}
+	r.Text = d.Normalize(r.Text)
+
+	segments := r.Segments[:0]
+	for _, seg := range r.Segments {
+		original := seg.Text
+		seg.Text = d.Normalize(seg.Text)
+		if seg.Text == "" && original != "" {
+			continue
+		}
+		segments = append(segments, seg)
+	}
+	if r.Segments != nil {
+		r.Segments = segments
+	}
+
+	for _, rule := range d.rules {
+		r.Tokens = rewriteTokens(r.Tokens, rule.pass)
+	}
+	tokens := r.Tokens[:0]
+	for _, t := range r.Tokens {
+		if t.Text != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	if r.Tokens != nil {
+		r.Tokens = tokens
+	}
+}
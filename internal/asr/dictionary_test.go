@@ -0,0 +1,65 @@
+package asr
+
+import "testing"
+
+// TestDictionaryApply tests that replacements keep text, segments and tokens
+// consistent and merge the timestamps of the replaced tokens
+func TestDictionaryApply(t *testing.T) {
+	d, err := NewDictionary([]ReplacementRule{
+		{Pattern: "ゼットボル", Replacement: "zbor"},
+		{Pattern: "えー", Replacement: ""},
+		{Pattern: `(\d+)ドル`, Replacement: "$$$1", Regex: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := append(charTokens("えーゼットボルで", 0, 0.2), charTokens("5ドル", 2, 0.2)...)
+	result := &Result{
+		Text:   "えーゼットボルで5ドル",
+		Tokens: tokens,
+		Segments: []Segment{
+			{Text: "えーゼットボルで", StartTime: 0, EndTime: 1.6},
+			{Text: "5ドル", StartTime: 2, EndTime: 2.6},
+		},
+	}
+	d.Apply(result)
+
+	if result.Text != "zborで$5" {
+		t.Errorf("text = %q, want %q", result.Text, "zborで$5")
+	}
+	if got := segmentTexts(result.Segments); len(got) != 2 || got[0] != "zborで" || got[1] != "$5" {
+		t.Errorf("segments = %q", got)
+	}
+	want := []Token{
+		{Text: "zbor", StartTime: 0.4, Duration: 1.0},
+		{Text: "で", StartTime: 1.4, Duration: 0.2},
+		{Text: "$5", StartTime: 2, Duration: 0.6},
+	}
+	if len(result.Tokens) != len(want) {
+		t.Fatalf("tokens = %+v, want %+v", result.Tokens, want)
+	}
+	for i, w := range want {
+		got := result.Tokens[i]
+		if got.Text != w.Text || !approx(float64(got.StartTime), float64(w.StartTime)) || !approx(float64(got.Duration), float64(w.Duration)) {
+			t.Errorf("token %d = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+// TestReplacementRuleValidate tests rejecting unusable patterns
+func TestReplacementRuleValidate(t *testing.T) {
+	invalid := []ReplacementRule{
+		{Pattern: ""},
+		{Pattern: "(", Regex: true},
+		{Pattern: "a*", Regex: true},
+	}
+	for _, rule := range invalid {
+		if err := rule.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", rule)
+		}
+	}
+	if err := (ReplacementRule{Pattern: "(", Replacement: "("}).Validate(); err != nil {
+		t.Errorf("surface form: %v", err)
+	}
+}
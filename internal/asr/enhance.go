@@ -0,0 +1,224 @@
+package asr
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+)
+
+// DefaultEnhanceModelPath is the GTCRN speech enhancement model (relative to project root)
+const DefaultEnhanceModelPath = "models/gtcrn_simple.onnx"
+
+// enhanceChunkSec is the length of audio denoised at once; it bounds the
+// memory used for long recordings
+const enhanceChunkSec = 60
+
+// EnhanceConfig holds the configuration of the speech enhancement (denoising) model
+type EnhanceConfig struct {
+	ModelPath  string // GTCRN ONNX model
+	NumThreads int    // 0: auto (DefaultNumThreads)
+	Provider   string // execution provider: cpu (default), cuda, coreml, directml
+}
+
+// DefaultEnhanceConfig returns the default speech enhancement configuration
+func DefaultEnhanceConfig(modelPath string) *EnhanceConfig {
+	return &EnhanceConfig{
+		ModelPath:  modelPath,
+		NumThreads: 0, // auto
+	}
+}
+
+// Validate checks if the model file exists (without loading the model)
+func (c *EnhanceConfig) Validate() error {
+	if _, err := os.Stat(c.ModelPath); os.IsNotExist(err) {
+		return fmt.Errorf("enhancement model not found: %s", c.ModelPath)
+	}
+	_, err := ParseProvider(c.Provider)
+	return err
+}
+
+// EnhanceStats describes one enhanced file. The RMS levels (0-1) before and
+// after denoising show how much noise was removed
+type EnhanceStats struct {
+	File        string  `json:"file"`
+	Seconds     float64 `json:"seconds"`
+	InputRMS    float64 `json:"input_rms"`
+	OutputRMS   float64 `json:"output_rms"`
+	WallSeconds float64 `json:"wall_seconds"`
+}
+
+// Enhancer removes background noise from speech before VAD and ASR
+type Enhancer struct {
+	denoiser *sherpa.OfflineSpeechDenoiser
+}
+
+// NewEnhancer loads the speech enhancement model
+func NewEnhancer(config *EnhanceConfig) (*Enhancer, error) {
+	if config == nil {
+		return nil, fmt.Errorf("config is required")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	provider, _ := ParseProvider(config.Provider)
+
+	sherpaConfig := sherpa.OfflineSpeechDenoiserConfig{
+		Model: sherpa.OfflineSpeechDenoiserModelConfig{
+			Gtcrn:      sherpa.OfflineSpeechDenoiserGtcrnModelConfig{Model: config.ModelPath},
+			NumThreads: int32(numThreads(config.NumThreads)),
+			Provider:   provider,
+		},
+	}
+	denoiser := sherpa.NewOfflineSpeechDenoiser(&sherpaConfig)
+	if denoiser == nil {
+		return nil, fmt.Errorf("failed to create speech denoiser")
+	}
+	return &Enhancer{denoiser: denoiser}, nil
+}
+
+// Close releases the enhancer resources
+func (e *Enhancer) Close() {
+	if e.denoiser != nil {
+		sherpa.DeleteOfflineSpeechDenoiser(e.denoiser)
+		e.denoiser = nil
+	}
+}
+
+// EnhanceFile denoises any audio file and writes the result to outputPath as
+// 16-bit mono WAV at the model's sample rate
+func (e *Enhancer) EnhanceFile(ctx context.Context, inputPath, outputPath string) (*EnhanceStats, error) {
+	start := time.Now()
+	sampleRate := e.denoiser.SampleRate()
+
+	audio, err := openPCM(ctx, inputPath, decodeOptions{SampleRate: sampleRate})
+	if err != nil {
+		return nil, err
+	}
+	// Stop decoding if the output cannot be written
+	finished := false
+	defer func() {
+		if !finished {
+			audio.Close()
+		}
+	}()
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer out.Close()
+	// The sizes in the header are filled in once all samples are written
+	if err := writeWavHeader(out, sampleRate, 0); err != nil {
+		return nil, err
+	}
+	writer := bufio.NewWriter(out)
+
+	reader := bufio.NewReader(audio)
+	var inSum, outSum float64
+	var inCount, outCount int
+	buf := make([]byte, 2)
+	for {
+		samples, err := readPCM(reader, sampleRate*enhanceChunkSec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audio: %w", err)
+		}
+		if len(samples) == 0 {
+			break
+		}
+		inSum += sumSquares(samples)
+		inCount += len(samples)
+
+		denoised := e.denoiser.Run(samples, sampleRate)
+		outSum += sumSquares(denoised.Samples)
+		outCount += len(denoised.Samples)
+		for _, s := range denoised.Samples {
+			binary.LittleEndian.PutUint16(buf, uint16(int16(max(-1, min(s, 1))*32767)))
+			if _, err := writer.Write(buf); err != nil {
+				return nil, fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+	}
+	finished = true
+	if err := audio.Close(); err != nil {
+		return nil, fmt.Errorf("decoding failed: %w", err)
+	}
+	if inCount == 0 {
+		return nil, fmt.Errorf("no audio in %s", inputPath)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write output: %w", err)
+	}
+	if _, err := out.Seek(0, 0); err != nil {
+		return nil, fmt.Errorf("failed to write output: %w", err)
+	}
+	if err := writeWavHeader(out, sampleRate, outCount); err != nil {
+		return nil, err
+	}
+
+	return &EnhanceStats{
+		File:        filepath.Base(inputPath),
+		Seconds:     roundTo(float64(inCount)/float64(sampleRate), 2),
+		InputRMS:    roundTo(math.Sqrt(inSum/float64(inCount)), 6),
+		OutputRMS:   roundTo(math.Sqrt(outSum/float64(max(outCount, 1))), 6),
+		WallSeconds: roundTo(time.Since(start).Seconds(), 2),
+	}, nil
+}
+
+// EnhanceFileTemp denoises an audio file into a WAV file in the temp directory
+// Returns the path to the enhanced file (caller should clean up); files left
+// behind by a crash are removed by RemoveStaleTempWavs
+func (e *Enhancer) EnhanceFileTemp(ctx context.Context, inputPath string) (string, *EnhanceStats, error) {
+	f, err := os.CreateTemp("", tempWavPattern)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	outputPath := f.Name()
+	f.Close()
+
+	stats, err := e.EnhanceFile(ctx, inputPath, outputPath)
+	if err != nil {
+		os.Remove(outputPath)
+		return "", nil, err
+	}
+	return outputPath, stats, nil
+}
+
+func sumSquares(samples []float32) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return sum
+}
+
+// writeWavHeader writes the 44-byte header of a 16-bit mono PCM WAV file
+func writeWavHeader(w io.Writer, sampleRate, samples int) error {
+	dataSize := uint32(samples * 2)
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], 36+dataSize)
+	copy(header[8:], "WAVE")
+	copy(header[12:], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)                 // fmt chunk size
+	binary.LittleEndian.PutUint16(header[20:], 1)                  // PCM
+	binary.LittleEndian.PutUint16(header[22:], 1)                  // mono
+	binary.LittleEndian.PutUint32(header[24:], uint32(sampleRate)) // sample rate
+	binary.LittleEndian.PutUint32(header[28:], uint32(sampleRate*2))
+	binary.LittleEndian.PutUint16(header[32:], 2)  // block align
+	binary.LittleEndian.PutUint16(header[34:], 16) // bits per sample
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], dataSize)
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write WAV header: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package asr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestWriteWavHeader tests that enhanced audio is written as a readable 16-bit mono WAV file
+func TestWriteWavHeader(t *testing.T) {
+	const sampleRate = 16000
+	samples := make([]int16, sampleRate*2)
+	for i := range samples {
+		samples[i] = int16(i%200) * 100
+	}
+
+	var buf bytes.Buffer
+	if err := writeWavHeader(&buf, sampleRate, len(samples)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 44 {
+		t.Fatalf("header length = %d, want 44", buf.Len())
+	}
+	if err := binary.Write(&buf, binary.LittleEndian, samples); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "enhanced.wav")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, duration, err := ComputeWaveformPeaks(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if duration != 2 {
+		t.Errorf("duration = %v, want 2", duration)
+	}
+}
+
+// TestEnhanceConfigValidate tests detection of a missing enhancement model
+func TestEnhanceConfigValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gtcrn_simple.onnx")
+	config := DefaultEnhanceConfig(path)
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() = nil for a missing model")
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package asr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// ensembleWindowSec is the window in which the text of the other models is
+// aligned on the pivot's timestamps (Whisper's chunk length)
+const ensembleWindowSec = 30
+
+// EnsembleSegment is one pivot segment after voting
+type EnsembleSegment struct {
+	StartTime    float64  `json:"start_time"`
+	EndTime      float64  `json:"end_time"`
+	Text         string   `json:"text"`         // consensus text
+	Hypotheses   []string `json:"hypotheses"`   // text of each model in this segment (pivot first)
+	Disagreement float64  `json:"disagreement"` // 0 when every model agrees, see EnsembleVote
+}
+
+// EnsembleResult is the consensus of several recognizers over the same audio
+type EnsembleResult struct {
+	Result       *Result           `json:"result"`       // consensus transcript on the pivot's timestamps
+	Segments     []EnsembleSegment `json:"segments"`     // per pivot segment
+	Disagreement float64           `json:"disagreement"` // mean over the voting slots of all segments
+}
+
+// EnsembleVote merges the results of two or more recognizers for the same
+// audio by ROVER-style character voting. The first result is the pivot
+// (ReazonSpeech or SenseVoice, whose token timestamps are accurate): the
+// others are aligned on its timestamps (as whisper:align does), cut at its
+// segment boundaries and aligned character by character to its text with
+// the LCS alignment. A pivot character is kept unless a majority of the
+// models drop it, and text the other models insert is taken only when a
+// strict majority inserts the same text, so with two models the first one
+// wins every tie and the consensus is its text. Spaces and punctuation of
+// the pivot are kept as is and do not vote.
+//
+// The disagreement of a segment is the mean over its voting slots (each
+// pivot character, and each gap where some model inserted text) of the
+// share of the models that did not vote for the winner
+func EnsembleVote(results []*Result) (*EnsembleResult, error) {
+	if len(results) < 2 {
+		return nil, fmt.Errorf("ensemble needs at least 2 results, got %d", len(results))
+	}
+	pivot := results[0]
+	if len(pivot.Tokens) == 0 {
+		return nil, fmt.Errorf("pivot result has no tokens")
+	}
+	segments := pivot.Segments
+	if len(segments) == 0 {
+		segments = tokensToSegments(pivot.Tokens)
+	}
+
+	others := make([]*Result, len(results)-1)
+	for i, r := range results[1:] {
+		others[i] = AlignWhisperResult(pivot, r, ensembleWindowSec)
+	}
+
+	ensemble := &EnsembleResult{
+		Result: &Result{
+			TotalDuration: pivot.TotalDuration,
+			Language:      pivot.Language,
+		},
+	}
+	var slotSum float64
+	var slotCount int
+	for idx, seg := range segments {
+		// Tokens belong to the segment they start in; the first and last
+		// segments take everything before and after them
+		from, to := seg.StartTime, segments[min(idx+1, len(segments)-1)].StartTime
+		if idx == 0 {
+			from = math.Inf(-1)
+		}
+		if idx == len(segments)-1 {
+			to = math.Inf(1)
+		}
+
+		pivotTokens := tokensInRange(pivot.Tokens, from, to)
+		hypotheses := []string{RebuildTextFromTokens(pivotTokens)}
+		for _, r := range others {
+			hypotheses = append(hypotheses, RebuildTextFromTokens(tokensInRange(r.Tokens, from, to)))
+		}
+
+		text, disagreement, slots := voteCharacters(hypotheses)
+		slotSum += disagreement * float64(slots)
+		slotCount += slots
+		ensemble.Segments = append(ensemble.Segments, EnsembleSegment{
+			StartTime:    seg.StartTime,
+			EndTime:      seg.EndTime,
+			Text:         text,
+			Hypotheses:   hypotheses,
+			Disagreement: roundTo(disagreement, 3),
+		})
+		if text == "" {
+			continue
+		}
+
+		tokens := AlignTokensWithText(pivotTokens, text)
+		if len(tokens) == 0 {
+			tokens = distributeUniformly([]rune(text), seg.StartTime, seg.EndTime)
+		}
+		ensemble.Result.Tokens = append(ensemble.Result.Tokens, tokens...)
+		ensemble.Result.Segments = append(ensemble.Result.Segments, Segment{
+			Text:      text,
+			StartTime: seg.StartTime,
+			EndTime:   seg.EndTime,
+			Speaker:   seg.Speaker,
+		})
+	}
+	if slotCount > 0 {
+		ensemble.Disagreement = roundTo(slotSum/float64(slotCount), 3)
+	}
+	ensemble.Result.Text = RebuildTextFromTokens(ensemble.Result.Tokens)
+	return ensemble, nil
+}
+
+// tokensInRange returns the tokens starting in [from, to)
+func tokensInRange(tokens []Token, from, to float64) []Token {
+	var inRange []Token
+	for _, t := range tokens {
+		if start := float64(t.StartTime); start >= from && start < to {
+			inRange = append(inRange, t)
+		}
+	}
+	return inRange
+}
+
+// voteCharacters votes on the hypotheses of one segment (pivot first) and
+// returns the consensus text, the mean disagreement of the voting slots and
+// the number of slots
+func voteCharacters(hypotheses []string) (string, float64, int) {
+	n := len(hypotheses)
+	pivotRaw := []rune(hypotheses[0])
+	pivot := cerRunes(hypotheses[0])
+
+	// keeps[i] counts the models that kept pivot character i; inserts[i]
+	// counts the text each model inserted before it (inserts[len(pivot)]
+	// after the last one)
+	keeps := make([]int, len(pivot))
+	inserts := make([]map[string]int, len(pivot)+1)
+	for i := range keeps {
+		keeps[i] = 1
+	}
+	for _, hypothesis := range hypotheses[1:] {
+		// Inserted text goes to the gap before the next kept pivot character,
+		// so that substitutions land in the same gap whatever the order of
+		// their delete and insert steps
+		var pending []rune
+		flush := func(gap int) {
+			if len(pending) == 0 {
+				return
+			}
+			if inserts[gap] == nil {
+				inserts[gap] = map[string]int{}
+			}
+			inserts[gap][string(pending)]++
+			pending = nil
+		}
+		for _, entry := range computeAlignment(pivot, cerRunes(hypothesis)) {
+			switch entry.op {
+			case opMatch:
+				flush(entry.origIdx)
+				keeps[entry.origIdx]++
+			case opInsert:
+				pending = append(pending, entry.whisperRune)
+			}
+		}
+		flush(len(pivot))
+	}
+
+	var text strings.Builder
+	var disagreement float64
+	slots := 0
+	// voteGap writes the winning insertion of a gap, if a strict majority inserted it
+	voteGap := func(gap int) {
+		if len(inserts[gap]) == 0 {
+			return
+		}
+		none := n
+		best, bestVotes := "", 0
+		for s, votes := range inserts[gap] {
+			none -= votes
+			if votes > bestVotes || (votes == bestVotes && s < best) {
+				best, bestVotes = s, votes
+			}
+		}
+		if bestVotes*2 > n {
+			text.WriteString(best)
+		}
+		disagreement += 1 - float64(max(bestVotes, none))/float64(n)
+		slots++
+	}
+
+	voted := 0
+	for _, r := range pivotRaw {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) {
+			text.WriteRune(r)
+			continue
+		}
+		voteGap(voted)
+		if keeps[voted]*2 >= n {
+			text.WriteRune(r)
+		}
+		disagreement += 1 - float64(max(keeps[voted], n-keeps[voted]))/float64(n)
+		slots++
+		voted++
+	}
+	voteGap(len(pivot))
+
+	if slots > 0 {
+		disagreement /= float64(slots)
+	}
+	return strings.TrimSpace(text.String()), disagreement, slots
+}
@@ -0,0 +1,77 @@
+package asr
+
+import "testing"
+
+func ensembleTestPivot() *Result {
+	tokens := append(charTokens("今日は晴れ", 0, 0.4), charTokens("明日わ雨です", 3, 0.3)...)
+	return &Result{
+		Text:   "今日は晴れ明日わ雨です",
+		Tokens: tokens,
+		Segments: []Segment{
+			{Text: "今日は晴れ", StartTime: 0, EndTime: 2},
+			{Text: "明日わ雨です", StartTime: 3, EndTime: 5},
+		},
+	}
+}
+
+// TestVoteCharacters tests majority voting on kept, dropped and inserted characters
+func TestVoteCharacters(t *testing.T) {
+	tests := []struct {
+		name         string
+		hypotheses   []string
+		want         string
+		disagreement float64
+	}{
+		{"all agree", []string{"今日は晴れ", "今日は晴れ", "今日は晴れ"}, "今日は晴れ", 0},
+		{"substitution by majority", []string{"明日わ雨", "明日は雨", "明日は雨"}, "明日は雨", 2.0 / 15},
+		{"minority substitution", []string{"今日は", "きょうは", "今日は"}, "今日は", 0.25},
+		{"two models keep the pivot", []string{"明日わ雨", "明日は雨"}, "明日わ雨", 0.2},
+		{"pivot punctuation is kept", []string{"はい、そうです。", "はいそうです", "はいそうです"}, "はい、そうです。", 0},
+		{"empty pivot", []string{"", "はい", "はい"}, "はい", 1.0 / 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, disagreement, _ := voteCharacters(tt.hypotheses)
+			if text != tt.want {
+				t.Errorf("text = %q, want %q", text, tt.want)
+			}
+			if roundTo(disagreement, 3) != roundTo(tt.disagreement, 3) {
+				t.Errorf("disagreement = %v, want %v", disagreement, tt.disagreement)
+			}
+		})
+	}
+}
+
+// TestEnsembleVote tests that the consensus keeps the pivot's segments and
+// timestamps and scores each segment's disagreement
+func TestEnsembleVote(t *testing.T) {
+	second := &Result{Tokens: append(charTokens("今日は晴れ", 0, 0.4), charTokens("明日は雨です", 3, 0.3)...)}
+	third := &Result{Tokens: append(charTokens("きょうは晴れ", 0, 0.3), charTokens("明日は雨です", 3, 0.3)...)}
+
+	ensemble, err := EnsembleVote([]*Result{ensembleTestPivot(), second, third})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ensemble.Result.Text != "今日は晴れ明日は雨です" {
+		t.Errorf("Text = %q", ensemble.Result.Text)
+	}
+	if len(ensemble.Segments) != 2 || len(ensemble.Result.Segments) != 2 {
+		t.Fatalf("got %d segments, want 2", len(ensemble.Segments))
+	}
+	if got := ensemble.Segments[0].Hypotheses; len(got) != 3 || got[2] != "きょうは晴れ" {
+		t.Errorf("hypotheses = %q", got)
+	}
+	if ensemble.Segments[0].Disagreement == 0 || ensemble.Segments[1].Disagreement == 0 {
+		t.Errorf("disagreement = %v, %v, want both > 0", ensemble.Segments[0].Disagreement, ensemble.Segments[1].Disagreement)
+	}
+	if seg := ensemble.Result.Segments[1]; seg.Text != "明日は雨です" || seg.StartTime != 3 {
+		t.Errorf("second segment = %+v", seg)
+	}
+	if tok := ensemble.Result.Tokens[0]; tok.Text != "今" || tok.StartTime != 0 {
+		t.Errorf("first token = %+v, want the pivot's timestamp", tok)
+	}
+
+	if _, err := EnsembleVote([]*Result{ensembleTestPivot()}); err == nil {
+		t.Error("expected an error for a single result")
+	}
+}
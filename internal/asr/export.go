@@ -0,0 +1,178 @@
+package asr
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ExportFormats lists the formats supported by Result.Export
+var ExportFormats = []string{"srt", "vtt", "txt", "json", "fcpxml", "ttml", "markers", "chapters", "condensed"}
+
+// ExportOptions controls subtitle/text export
+type ExportOptions struct {
+	SpeakerPrefix bool // prefix cues with the speaker label when known
+	MaxLineLength int  // wrap cue text at this many characters (0 = no wrapping)
+
+	// Options for the editing formats (fcpxml, ttml, markers)
+	FrameRate FrameRate // cue times are snapped to frames (zero value = DefaultFrameRate)
+	Language  string    // caption language (default: ja)
+	Title     string    // project name (fcpxml)
+	StartHour int       // timeline start hour of marker timecodes (Resolve timelines start at 01:00:00:00)
+	Bookmarks []Marker  // user bookmarks exported alongside the chapters (markers, chapters)
+
+	CondensedInterval float64 // seconds per line of the condensed view (0 = DefaultCondensedOptions)
+}
+
+// Export formats the transcription as srt, vtt, txt, json, fcpxml (Final Cut Pro),
+// ttml (Premiere Pro caption import), markers (DaVinci Resolve marker CSV)
+// chapters (chapter list with bookmarks) or condensed (one line per interval for skimming)
+func (r *Result) Export(format string, opts ExportOptions) (string, error) {
+	switch format {
+	case "srt":
+		return r.exportSRT(opts), nil
+	case "vtt":
+		return r.exportVTT(opts), nil
+	case "txt":
+		return r.exportText(opts), nil
+	case "json":
+		return r.FormatAsJSON()
+	case "fcpxml":
+		return r.exportFCPXML(opts), nil
+	case "ttml":
+		return r.exportTTML(opts), nil
+	case "markers":
+		return r.exportMarkers(opts)
+	case "chapters":
+		return r.exportChapters(opts), nil
+	case "condensed":
+		return r.exportCondensed(opts), nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// ExportExtension returns the file extension for an export format
+func ExportExtension(format string) string {
+	switch format {
+	case "markers":
+		return "csv"
+	case "chapters":
+		return "chapters.txt"
+	case "condensed":
+		return "condensed.txt"
+	}
+	return format
+}
+
+// Cues returns the segments used as subtitle cues
+// Results without segments fall back to token gaps, then to the full text
+func (r *Result) Cues() []Segment {
+	if len(r.Segments) > 0 {
+		return r.Segments
+	}
+	if len(r.Tokens) > 0 {
+		return tokensToSegments(r.Tokens)
+	}
+	if r.Text == "" {
+		return nil
+	}
+	return []Segment{{Text: r.Text, EndTime: float64(r.TotalDuration)}}
+}
+
+// cueSpeaker returns the speaker label of a cue (falling back to the result's speaker)
+func (r *Result) cueSpeaker(seg Segment) string {
+	if seg.Speaker != "" {
+		return seg.Speaker
+	}
+	return r.Speaker
+}
+
+func (r *Result) exportSRT(opts ExportOptions) string {
+	var sb strings.Builder
+	for i, seg := range r.Cues() {
+		text := wrapText(seg.Text, opts.MaxLineLength)
+		if speaker := r.cueSpeaker(seg); opts.SpeakerPrefix && speaker != "" {
+			text = fmt.Sprintf("[%s] %s", speaker, text)
+		}
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(formatSRTSegment(i+1, seg.StartTime, seg.EndTime, text))
+	}
+	return sb.String()
+}
+
+func (r *Result) exportVTT(opts ExportOptions) string {
+	var sb strings.Builder
+	sb.WriteString("WEBVTT\n")
+	for i, seg := range r.Cues() {
+		text := wrapText(seg.Text, opts.MaxLineLength)
+		// WebVTT has a voice span for speakers, which players can style or show
+		if speaker := r.cueSpeaker(seg); opts.SpeakerPrefix && speaker != "" {
+			text = fmt.Sprintf("<v %s>%s", speaker, text)
+		}
+		fmt.Fprintf(&sb, "\n%d\n%s --> %s\n%s\n", i+1, formatVTTTime(seg.StartTime), formatVTTTime(seg.EndTime), text)
+	}
+	return sb.String()
+}
+
+// exportText writes one cue per line, adding the speaker label when it changes
+func (r *Result) exportText(opts ExportOptions) string {
+	var sb strings.Builder
+	lastSpeaker := ""
+	for _, seg := range r.Cues() {
+		text := wrapText(seg.Text, opts.MaxLineLength)
+		if speaker := r.cueSpeaker(seg); opts.SpeakerPrefix && speaker != "" && speaker != lastSpeaker {
+			if sb.Len() > 0 {
+				sb.WriteString("\n")
+			}
+			fmt.Fprintf(&sb, "[%s]\n", speaker)
+			lastSpeaker = speaker
+		}
+		sb.WriteString(text)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// wrapText breaks text into lines of at most maxLen characters
+// Lines break after a space or Japanese punctuation when one falls in the
+// second half of the line; otherwise they break at the limit
+func wrapText(text string, maxLen int) string {
+	if maxLen <= 0 || utf8.RuneCountInString(text) <= maxLen {
+		return text
+	}
+
+	var lines []string
+	runes := []rune(strings.TrimSpace(text))
+	for len(runes) > maxLen {
+		cut := maxLen
+		for i := maxLen; i > maxLen/2; i-- {
+			if isLineBreakAfter(runes[i-1]) {
+				cut = i
+				break
+			}
+		}
+		lines = append(lines, strings.TrimSpace(string(runes[:cut])))
+		runes = []rune(strings.TrimLeft(string(runes[cut:]), " "))
+	}
+	if len(runes) > 0 {
+		lines = append(lines, string(runes))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isLineBreakAfter reports whether a line may end after r
+func isLineBreakAfter(r rune) bool {
+	switch r {
+	case ' ', '、', '。', '，', '．', '！', '？', ',', '.', '!', '?':
+		return true
+	}
+	return false
+}
+
+// formatVTTTime converts seconds to WebVTT time format (HH:MM:SS.mmm)
+func formatVTTTime(seconds float64) string {
+	return strings.Replace(formatSRTTime(seconds), ",", ".", 1)
+}
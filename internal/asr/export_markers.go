@@ -0,0 +1,141 @@
+package asr
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Marker colors understood by DaVinci Resolve
+const (
+	MarkerColorChapter  = "Blue"
+	MarkerColorBookmark = "Green"
+)
+
+// MarkerColors lists the DaVinci Resolve marker colors
+var MarkerColors = []string{
+	"Blue", "Cyan", "Green", "Yellow", "Red", "Pink", "Purple", "Fuchsia",
+	"Rose", "Lavender", "Sky", "Mint", "Lemon", "Sand", "Cocoa", "Cream",
+}
+
+// IsMarkerColor reports whether color is one of MarkerColors
+func IsMarkerColor(color string) bool {
+	return slices.Contains(MarkerColors, color)
+}
+
+// Marker is a notable moment exported as an editor timeline marker
+type Marker struct {
+	Time     float64 `json:"time"`               // seconds
+	Duration float64 `json:"duration,omitempty"` // seconds (0 = single frame)
+	Name     string  `json:"name"`
+	Note     string  `json:"note,omitempty"`
+	Color    string  `json:"color,omitempty"` // Resolve marker color (default: MarkerColorBookmark)
+}
+
+// Markers returns the chapter starts and the given bookmarks as markers in time order
+func (r *Result) Markers(bookmarks []Marker) []Marker {
+	// A single chapter is just the whole transcript
+	var markers []Marker
+	if chapters := r.chapterMarkers(); len(chapters) > 1 {
+		markers = chapters
+	}
+	return sortMarkers(append(markers, bookmarkMarkers(bookmarks)...))
+}
+
+// chapterMarkers returns a marker at the start of each chapter
+func (r *Result) chapterMarkers() []Marker {
+	chapters := r.Chapters(DefaultChapterOptions())
+	markers := make([]Marker, 0, len(chapters))
+	for _, ch := range chapters {
+		markers = append(markers, Marker{
+			Time:  ch.StartTime,
+			Name:  ch.Title,
+			Note:  "Chapter",
+			Color: MarkerColorChapter,
+		})
+	}
+	return markers
+}
+
+// bookmarkMarkers returns the bookmarks with the default color filled in
+func bookmarkMarkers(bookmarks []Marker) []Marker {
+	markers := make([]Marker, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		if b.Color == "" {
+			b.Color = MarkerColorBookmark
+		}
+		markers = append(markers, b)
+	}
+	return markers
+}
+
+// sortMarkers sorts markers by time, keeping the order of markers at the same time
+func sortMarkers(markers []Marker) []Marker {
+	sort.SliceStable(markers, func(i, j int) bool {
+		return markers[i].Time < markers[j].Time
+	})
+	return markers
+}
+
+// exportChapters writes the chapters and bookmarks as a chapter list
+// ("0:00 Title" per line, the format of YouTube video descriptions)
+func (r *Result) exportChapters(opts ExportOptions) string {
+	markers := sortMarkers(append(r.chapterMarkers(), bookmarkMarkers(opts.Bookmarks)...))
+	long := float64(r.TotalDuration) >= 3600
+	if n := len(markers); n > 0 && markers[n-1].Time >= 3600 {
+		long = true
+	}
+
+	var sb strings.Builder
+	for _, m := range markers {
+		total := int(m.Time)
+		if long {
+			fmt.Fprintf(&sb, "%d:%02d:%02d %s\n", total/3600, total/60%60, total%60, m.Name)
+		} else {
+			fmt.Fprintf(&sb, "%d:%02d %s\n", total/60, total%60, m.Name)
+		}
+	}
+	return sb.String()
+}
+
+// exportMarkers writes the markers as a DaVinci Resolve marker list CSV
+// (# / Name / Record In / Record Out / Duration / Color / Notes)
+// Record timecodes are offset by opts.StartHour so they line up with a
+// timeline that starts at e.g. 01:00:00:00
+func (r *Result) exportMarkers(opts ExportOptions) (string, error) {
+	rate := opts.FrameRate.orDefault()
+	offset := int64(opts.StartHour) * rate.framesPerHour()
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"#", "Name", "Record In", "Record Out", "Duration", "Color", "Notes"}); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for i, m := range r.Markers(opts.Bookmarks) {
+		start := rate.Frames(m.Time)
+		duration := rate.Frames(m.Time+m.Duration) - start
+		if duration < 1 {
+			duration = 1
+		}
+		record := []string{
+			fmt.Sprintf("%d", i+1),
+			m.Name,
+			rate.Timecode(offset + start),
+			rate.Timecode(offset + start + duration),
+			rate.timecode(duration, ":"),
+			m.Color,
+			m.Note,
+		}
+		if err := w.Write(record); err != nil {
+			return "", fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,235 @@
+package asr
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestWrapText tests line wrapping at punctuation and at the hard limit
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		maxLen int
+		want   string
+	}{
+		{"no wrap", "こんにちは", 10, "こんにちは"},
+		{"disabled", "こんにちは世界", 0, "こんにちは世界"},
+		{"break after punctuation", "今日は晴れです、明日は雨です", 10, "今日は晴れです、\n明日は雨です"},
+		{"hard break", "あいうえおかきくけこさしすせそ", 5, "あいうえお\nかきくけこ\nさしすせそ"},
+		{"break at space", "hello world again", 12, "hello world\nagain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapText(tt.text, tt.maxLen); got != tt.want {
+				t.Errorf("wrapText(%q, %d) = %q, want %q", tt.text, tt.maxLen, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExport tests subtitle export with speaker labels
+func TestExport(t *testing.T) {
+	r := &Result{
+		Text: "こんにちはよろしく",
+		Segments: []Segment{
+			{Text: "こんにちは", StartTime: 0.5, EndTime: 1.25, Speaker: "田中"},
+			{Text: "よろしく", StartTime: 2, EndTime: 3, Speaker: "佐藤"},
+		},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"srt", "1\n00:00:00,500 --> 00:00:01,250\n[田中] こんにちは\n\n2\n00:00:02,000 --> 00:00:03,000\n[佐藤] よろしく\n"},
+		{"vtt", "WEBVTT\n\n1\n00:00:00.500 --> 00:00:01.250\n<v 田中>こんにちは\n\n2\n00:00:02.000 --> 00:00:03.000\n<v 佐藤>よろしく\n"},
+		{"txt", "[田中]\nこんにちは\n\n[佐藤]\nよろしく\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got, err := r.Export(tt.format, ExportOptions{SpeakerPrefix: true})
+			if err != nil {
+				t.Fatalf("Export(%q) error: %v", tt.format, err)
+			}
+			if got != tt.want {
+				t.Errorf("Export(%q) =\n%q\nwant\n%q", tt.format, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := r.Export("docx", ExportOptions{}); err == nil {
+		t.Error("Export(\"docx\") = nil error, want error")
+	}
+}
+
+// TestTimecode tests SMPTE timecode including drop-frame numbering
+func TestTimecode(t *testing.T) {
+	df, err := ParseFrameRate("29.97", true)
+	if err != nil {
+		t.Fatalf("ParseFrameRate error: %v", err)
+	}
+	fps25, _ := ParseFrameRate("25", false)
+	df5994, _ := ParseFrameRate("59.94", true)
+
+	tests := []struct {
+		name   string
+		rate   FrameRate
+		frames int64
+		want   string
+	}{
+		{"ndf 29.97", DefaultFrameRate, 1800, "00:01:00:00"},
+		{"df last frame of minute 0", df, 1799, "00:00:59;29"},
+		{"df skips frames 0 and 1", df, 1800, "00:01:00;02"},
+		{"df tenth minute is not dropped", df, 17982, "00:10:00;00"},
+		{"df one hour", df, 107892, "01:00:00;00"},
+		{"df 59.94 skips 4 frames", df5994, 3600, "00:01:00;04"},
+		{"25 fps", fps25, 90025, "01:00:01:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rate.Timecode(tt.frames); got != tt.want {
+				t.Errorf("Timecode(%d) = %q, want %q", tt.frames, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseFrameRate("25", true); err == nil {
+		t.Error("ParseFrameRate(\"25\", true) = nil error, want error")
+	}
+	if _, err := ParseFrameRate("12", false); err == nil {
+		t.Error("ParseFrameRate(\"12\", false) = nil error, want error")
+	}
+}
+
+// TestRational tests FCPXML rational times
+func TestRational(t *testing.T) {
+	fps25, _ := ParseFrameRate("25", false)
+	tests := []struct {
+		rate   FrameRate
+		frames int64
+		want   string
+	}{
+		{DefaultFrameRate, 1, "1001/30000s"},
+		{DefaultFrameRate, 30, "1001/1000s"},
+		{DefaultFrameRate, 0, "0s"},
+		{fps25, 50, "2s"},
+		{fps25, 1, "1/25s"},
+	}
+	for _, tt := range tests {
+		if got := tt.rate.Rational(tt.frames); got != tt.want {
+			t.Errorf("Rational(%d) = %q, want %q", tt.frames, got, tt.want)
+		}
+	}
+}
+
+// TestExportEditingFormats tests frame-snapped fcpxml and ttml export
+func TestExportEditingFormats(t *testing.T) {
+	r := &Result{
+		Segments: []Segment{
+			{Text: "A & B", StartTime: 0.5, EndTime: 1.25},
+			{Text: "次", StartTime: 1.2, EndTime: 2},
+		},
+	}
+	fps25, _ := ParseFrameRate("25", false)
+	df, _ := ParseFrameRate("29.97", true)
+
+	fcpxml, err := r.Export("fcpxml", ExportOptions{FrameRate: fps25, Title: "会議"})
+	if err != nil {
+		t.Fatalf("Export(fcpxml) error: %v", err)
+	}
+	for _, want := range []string{
+		`<format id="r1" frameDuration="1/25s"`,
+		`<project name="会議">`,
+		`tcFormat="NDF"`,
+		// first cue is trimmed to end where the overlapping second cue starts
+		`<caption lane="1" offset="13/25s" duration="17/25s" role="iTT?captionFormat=ITT.ja">`,
+		`<text-style ref="ts1">A &amp; B</text-style>`,
+		`<caption lane="1" offset="6/5s" duration="4/5s"`,
+	} {
+		if !strings.Contains(fcpxml, want) {
+			t.Errorf("fcpxml missing %q:\n%s", want, fcpxml)
+		}
+	}
+
+	ttml, err := r.Export("ttml", ExportOptions{FrameRate: df})
+	if err != nil {
+		t.Fatalf("Export(ttml) error: %v", err)
+	}
+	for _, want := range []string{
+		`ttp:timeBase="smpte" ttp:frameRate="30" ttp:frameRateMultiplier="1000 1001" ttp:dropMode="dropNTSC"`,
+		`<p begin="00:00:00:15" end="00:00:01:06" region="bottom" style="caption">A &amp; B</p>`,
+		`<p begin="00:00:01:06" end="00:00:02:00" region="bottom" style="caption">次</p>`,
+	} {
+		if !strings.Contains(ttml, want) {
+			t.Errorf("ttml missing %q:\n%s", want, ttml)
+		}
+	}
+}
+
+// TestExportMarkers tests the Resolve marker CSV from bookmarks
+func TestExportMarkers(t *testing.T) {
+	r := &Result{
+		Segments: []Segment{
+			{Text: "はい", StartTime: 0, EndTime: 1},
+			{Text: "えっと, 不明", StartTime: 2, EndTime: 3.5},
+			{Text: "確認", StartTime: 4, EndTime: 5},
+		},
+	}
+	fps25, _ := ParseFrameRate("25", false)
+
+	got, err := r.Export("markers", ExportOptions{
+		FrameRate: fps25,
+		StartHour: 1,
+		Bookmarks: []Marker{
+			{Time: 4.5, Name: "要確認", Note: "固有名詞"},
+			{Time: 2, Duration: 1.5, Name: "聞き直し", Note: "えっと, 不明", Color: "Yellow"},
+			{Time: 0.2, Name: "冒頭", Color: "Red"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Export(markers) error: %v", err)
+	}
+	want := "#,Name,Record In,Record Out,Duration,Color,Notes\n" +
+		"1,冒頭,01:00:00:05,01:00:00:06,00:00:00:01,Red,\n" +
+		"2,聞き直し,01:00:02:00,01:00:03:13,00:00:01:13,Yellow,\"えっと, 不明\"\n" +
+		"3,要確認,01:00:04:13,01:00:04:14,00:00:00:01,Green,固有名詞\n"
+	if got != want {
+		t.Errorf("markers =\n%s\nwant\n%s", got, want)
+	}
+
+	df, _ := ParseFrameRate("29.97", true)
+	if got := df.Timecode(df.framesPerHour()); got != "01:00:00;00" {
+		t.Errorf("drop-frame hour = %s, want 01:00:00;00", got)
+	}
+}
+
+// TestExportChapters tests the chapter list with bookmarks
+func TestExportChapters(t *testing.T) {
+	r := &Result{
+		Segments: []Segment{
+			{Text: "はじめに", StartTime: 0, EndTime: 2},
+			{Text: "本題", StartTime: 70, EndTime: 75},
+		},
+	}
+
+	got, err := r.Export("chapters", ExportOptions{
+		Bookmarks: []Marker{{Time: 65.9, Name: "要確認", Color: "Red"}},
+	})
+	if err != nil {
+		t.Fatalf("Export(chapters) error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "0:00 ") || lines[1] != "1:05 要確認" {
+		t.Errorf("chapters =\n%s", got)
+	}
+
+	r.TotalDuration = 3600
+	got, _ = r.Export("chapters", ExportOptions{})
+	if !strings.HasPrefix(got, "0:00:00 ") {
+		t.Errorf("chapters of a long transcript = %q, want H:MM:SS", got)
+	}
+}
@@ -0,0 +1,157 @@
+package asr
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// frameCue is a cue snapped to frame boundaries
+type frameCue struct {
+	start, end int64 // frames
+	text       string
+}
+
+// frameCues returns the cues snapped to frames, each at least one frame long
+// and not overlapping the next cue (editors reject overlapping captions)
+func (r *Result) frameCues(opts ExportOptions, rate FrameRate) []frameCue {
+	segments := r.Cues()
+	cues := make([]frameCue, 0, len(segments))
+	for _, seg := range segments {
+		text := wrapText(seg.Text, opts.MaxLineLength)
+		if speaker := r.cueSpeaker(seg); opts.SpeakerPrefix && speaker != "" {
+			text = fmt.Sprintf("[%s] %s", speaker, text)
+		}
+		start := rate.Frames(seg.StartTime)
+		if n := len(cues); n > 0 && start < cues[n-1].end {
+			cues[n-1].end = start
+		}
+		end := rate.Frames(seg.EndTime)
+		if end <= start {
+			end = start + 1
+		}
+		cues = append(cues, frameCue{start: start, end: end, text: text})
+	}
+
+	// Trimming the previous cue may have left it empty
+	kept := cues[:0]
+	for _, cue := range cues {
+		if cue.end > cue.start {
+			kept = append(kept, cue)
+		}
+	}
+	return kept
+}
+
+// exportFCPXML writes the cues as iTT captions in a Final Cut Pro XML project
+// Captions sit on a gap clip spanning the transcript, so the project can be
+// imported and the captions copied onto the edited timeline
+func (r *Result) exportFCPXML(opts ExportOptions) string {
+	rate := opts.FrameRate.orDefault()
+	cues := r.frameCues(opts, rate)
+	lang := exportLanguage(opts)
+	title := opts.Title
+	if title == "" {
+		title = "zbor"
+	}
+
+	var duration int64
+	if len(cues) > 0 {
+		duration = cues[len(cues)-1].end
+	}
+	if total := rate.Frames(float64(r.TotalDuration)); total > duration {
+		duration = total
+	}
+	tcFormat := "NDF"
+	if rate.DropFrame {
+		tcFormat = "DF"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString("<!DOCTYPE fcpxml>\n")
+	sb.WriteString("<fcpxml version=\"1.9\">\n")
+	sb.WriteString("  <resources>\n")
+	fmt.Fprintf(&sb, "    <format id=\"r1\" frameDuration=\"%s\" width=\"1920\" height=\"1080\"/>\n", rate.Rational(1))
+	sb.WriteString("  </resources>\n")
+	sb.WriteString("  <library>\n")
+	fmt.Fprintf(&sb, "    <event name=\"%s\">\n", escapeXML(title))
+	fmt.Fprintf(&sb, "      <project name=\"%s\">\n", escapeXML(title))
+	fmt.Fprintf(&sb, "        <sequence format=\"r1\" duration=\"%s\" tcStart=\"0s\" tcFormat=\"%s\">\n", rate.Rational(duration), tcFormat)
+	sb.WriteString("          <spine>\n")
+	fmt.Fprintf(&sb, "            <gap name=\"Gap\" offset=\"0s\" start=\"0s\" duration=\"%s\">\n", rate.Rational(duration))
+	for i, cue := range cues {
+		fmt.Fprintf(&sb, "              <caption lane=\"1\" offset=\"%s\" duration=\"%s\" role=\"iTT?captionFormat=ITT.%s\">\n",
+			rate.Rational(cue.start), rate.Rational(cue.end-cue.start), escapeXML(lang))
+		fmt.Fprintf(&sb, "                <text placement=\"bottom\"><text-style ref=\"ts%d\">%s</text-style></text>\n", i+1, escapeXML(cue.text))
+		fmt.Fprintf(&sb, "                <text-style-def id=\"ts%d\"><text-style font=\".SF NS Text\" fontSize=\"13\" fontFace=\"Regular\" fontColor=\"1 1 1 1\" backgroundColor=\"0 0 0 1\"/></text-style-def>\n", i+1)
+		sb.WriteString("              </caption>\n")
+	}
+	sb.WriteString("            </gap>\n")
+	sb.WriteString("          </spine>\n")
+	sb.WriteString("        </sequence>\n")
+	sb.WriteString("      </project>\n")
+	sb.WriteString("    </event>\n")
+	sb.WriteString("  </library>\n")
+	sb.WriteString("</fcpxml>\n")
+	return sb.String()
+}
+
+// exportTTML writes the cues as TTML (DFXP) with SMPTE frame timecodes,
+// which Premiere Pro imports as a caption track
+func (r *Result) exportTTML(opts ExportOptions) string {
+	rate := opts.FrameRate.orDefault()
+	cues := r.frameCues(opts, rate)
+
+	dropMode := "nonDrop"
+	if rate.DropFrame {
+		dropMode = "dropNTSC"
+	}
+	rateAttrs := fmt.Sprintf("ttp:frameRate=\"%d\"", rate.nominal())
+	if rate.Den == 1001 {
+		rateAttrs += " ttp:frameRateMultiplier=\"1000 1001\""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	fmt.Fprintf(&sb, "<tt xmlns=\"http://www.w3.org/ns/ttml\" xmlns:ttp=\"http://www.w3.org/ns/ttml#parameter\" xmlns:tts=\"http://www.w3.org/ns/ttml#styling\" xml:lang=\"%s\" ttp:timeBase=\"smpte\" %s ttp:dropMode=\"%s\">\n",
+		escapeXML(exportLanguage(opts)), rateAttrs, dropMode)
+	sb.WriteString("  <head>\n")
+	sb.WriteString("    <styling>\n")
+	sb.WriteString("      <style xml:id=\"caption\" tts:textAlign=\"center\" tts:color=\"white\" tts:backgroundColor=\"black\"/>\n")
+	sb.WriteString("    </styling>\n")
+	sb.WriteString("    <layout>\n")
+	sb.WriteString("      <region xml:id=\"bottom\" tts:origin=\"10% 80%\" tts:extent=\"80% 15%\" tts:displayAlign=\"after\"/>\n")
+	sb.WriteString("    </layout>\n")
+	sb.WriteString("  </head>\n")
+	sb.WriteString("  <body>\n")
+	sb.WriteString("    <div>\n")
+	for _, cue := range cues {
+		lines := strings.Split(cue.text, "\n")
+		for i := range lines {
+			lines[i] = escapeXML(lines[i])
+		}
+		// TTML SMPTE time expressions always use ":" (ttp:dropMode marks drop-frame)
+		fmt.Fprintf(&sb, "      <p begin=\"%s\" end=\"%s\" region=\"bottom\" style=\"caption\">%s</p>\n",
+			rate.timecode(cue.start, ":"), rate.timecode(cue.end, ":"), strings.Join(lines, "<br/>"))
+	}
+	sb.WriteString("    </div>\n")
+	sb.WriteString("  </body>\n")
+	sb.WriteString("</tt>\n")
+	return sb.String()
+}
+
+// exportLanguage returns the caption language code
+func exportLanguage(opts ExportOptions) string {
+	if opts.Language != "" {
+		return opts.Language
+	}
+	return "ja"
+}
+
+// escapeXML escapes text for XML content and attribute values
+func escapeXML(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
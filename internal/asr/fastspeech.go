@@ -0,0 +1,151 @@
+package asr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultFastSpeechRate is the speech rate (characters per second of
+	// speech) above which a chunk is treated as fast speech. Normal Japanese
+	// speech is around 6-8 characters per second in the transducer output
+	DefaultFastSpeechRate = 9.0
+	// minSpeechRateSpan is the shortest span used to estimate the speech
+	// rate, so that a few tokens in a short chunk are not reported as fast
+	minSpeechRateSpan = 1.0
+)
+
+// FastSpeechVariant is an alternative decoder configuration used on fast
+// speech. Decoding changes at the feature/decoder level replace slowing the
+// audio down with atempo, so timestamps stay in the original time
+type FastSpeechVariant struct {
+	Name           string
+	DecodingMethod string  // "greedy_search" or "modified_beam_search"
+	MaxActivePaths int     // used only with modified_beam_search
+	BlankPenalty   float32 // a positive penalty makes the decoder emit more tokens per frame
+}
+
+// DefaultFastSpeechVariants are the decoders that vote with the base
+// decoder on fast sections
+var DefaultFastSpeechVariants = []FastSpeechVariant{
+	{Name: "beam", DecodingMethod: "modified_beam_search", MaxActivePaths: 4},
+	{Name: "beam-blank1", DecodingMethod: "modified_beam_search", MaxActivePaths: 4, BlankPenalty: 1.0},
+	{Name: "greedy-blank2", DecodingMethod: "greedy_search", BlankPenalty: 2.0},
+}
+
+// FastSpeechDecision describes how one chunk was decoded by a FastSpeechDecoder
+type FastSpeechDecision struct {
+	Rate   float64 `json:"rate"`             // estimated characters per second (base decoder)
+	Fast   bool    `json:"fast"`             // the rate exceeded the threshold and the variants voted
+	Chosen string  `json:"chosen,omitempty"` // name of the winning decoder ("base" or a variant)
+}
+
+// FastSpeechDecoder decodes with a base recognizer and, on chunks whose
+// estimated speech rate exceeds Threshold, also with the variant
+// recognizers, choosing the hypothesis that agrees most with the others
+type FastSpeechDecoder struct {
+	base      *Recognizer
+	variants  []*Recognizer
+	names     []string
+	Threshold float64 // characters per second (default DefaultFastSpeechRate)
+}
+
+// NewFastSpeechDecoder creates the variant recognizers from the base
+// recognizer's model. The base recognizer is not closed by Close
+func NewFastSpeechDecoder(base *Recognizer, variants []FastSpeechVariant) (*FastSpeechDecoder, error) {
+	d := &FastSpeechDecoder{base: base, Threshold: DefaultFastSpeechRate}
+	for _, v := range variants {
+		config := *base.config
+		config.DecodingMethod = v.DecodingMethod
+		config.MaxActivePaths = v.MaxActivePaths
+		config.BlankPenalty = v.BlankPenalty
+		r, err := NewRecognizer(&config)
+		if err != nil {
+			d.Close()
+			return nil, fmt.Errorf("failed to create %s recognizer: %w", v.Name, err)
+		}
+		d.variants = append(d.variants, r)
+		d.names = append(d.names, v.Name)
+	}
+	return d, nil
+}
+
+// Decode transcribes samples. Slow and normal speech is decoded by the base
+// recognizer only
+func (d *FastSpeechDecoder) Decode(samples []float32, sampleRate int) (*Result, FastSpeechDecision, error) {
+	result, err := d.base.TranscribeBytes(samples, sampleRate)
+	if err != nil {
+		return nil, FastSpeechDecision{}, err
+	}
+	decision := FastSpeechDecision{Rate: SpeechRate(result.Tokens), Chosen: "base"}
+	if decision.Rate < d.Threshold || len(d.variants) == 0 {
+		return result, decision, nil
+	}
+	decision.Fast = true
+
+	results := []*Result{result}
+	hypotheses := []string{result.Text}
+	for i, r := range d.variants {
+		res, err := r.TranscribeBytes(samples, sampleRate)
+		if err != nil {
+			return nil, decision, fmt.Errorf("%s: %w", d.names[i], err)
+		}
+		results = append(results, res)
+		hypotheses = append(hypotheses, res.Text)
+	}
+
+	best := voteHypotheses(hypotheses)
+	if best > 0 {
+		decision.Chosen = d.names[best-1]
+	}
+	return results[best], decision, nil
+}
+
+// Close releases the variant recognizers
+func (d *FastSpeechDecoder) Close() error {
+	for _, r := range d.variants {
+		r.Close()
+	}
+	d.variants = nil
+	return nil
+}
+
+// SpeechRate estimates the speech rate in characters per second from the
+// span of the tokens (at least minSpeechRateSpan). It returns 0 without tokens
+func SpeechRate(tokens []Token) float64 {
+	if len(tokens) == 0 {
+		return 0
+	}
+	chars := 0
+	for _, t := range tokens {
+		chars += utf8.RuneCountInString(strings.TrimFunc(t.Text, unicode.IsSpace))
+	}
+	last := tokens[len(tokens)-1]
+	span := float64(last.StartTime + last.Duration - tokens[0].StartTime)
+	return float64(chars) / max(span, minSpeechRateSpan)
+}
+
+// voteHypotheses returns the index of the hypothesis with the smallest total
+// edit distance to the others (the medoid). Ties go to the earlier one, so
+// the base decoder wins when every hypothesis disagrees equally
+func voteHypotheses(hypotheses []string) int {
+	runes := make([][]rune, len(hypotheses))
+	for i, h := range hypotheses {
+		runes[i] = []rune(h)
+	}
+	best, bestTotal := 0, -1
+	for i := range runes {
+		total := 0
+		for j := range runes {
+			if i != j {
+				total += editDistance(runes[i], runes[j])
+			}
+		}
+		if bestTotal < 0 || total < bestTotal {
+			best, bestTotal = i, total
+		}
+	}
+	return best
+}
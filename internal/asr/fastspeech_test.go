@@ -0,0 +1,52 @@
+package asr
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSpeechRate tests the speech rate estimate from token timestamps
+func TestSpeechRate(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []Token
+		want   float64
+	}{
+		{"no tokens", nil, 0},
+		{"ten chars in one second", []Token{
+			{Text: "こんにち", StartTime: 0.5, Duration: 0.4},
+			{Text: "はせかい", StartTime: 0.9, Duration: 0.3},
+			{Text: " です", StartTime: 1.2, Duration: 0.3},
+		}, 10},
+		{"short span uses the minimum", []Token{{Text: "はい", StartTime: 2, Duration: 0.2}}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SpeechRate(tt.tokens); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("SpeechRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestVoteHypotheses tests that the hypothesis closest to the others wins
+func TestVoteHypotheses(t *testing.T) {
+	tests := []struct {
+		name       string
+		hypotheses []string
+		want       int
+	}{
+		{"single", []string{"あいう"}, 0},
+		{"all equal", []string{"あいう", "あいう", "あいう"}, 0},
+		{"majority of variants", []string{"あいう", "あいうえお", "あいうえお", "あいうえ"}, 1},
+		{"medoid", []string{"今日は晴れ", "今日は晴れです", "今日晴れです"}, 1},
+		{"tie goes to base", []string{"あ", "い", "う"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := voteHypotheses(tt.hypotheses); got != tt.want {
+				t.Errorf("voteHypotheses() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package asr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Golden-file tests run the DSP and timeline logic on synthesized audio
+// (tones and silences), so they need neither test recordings nor models.
+// Regenerate the expected output after an intended change with
+//
+//	go test ./internal/asr -run Golden -update
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+const synthSampleRate = 16000
+
+// synthPart is a tone (or silence when Freq is 0) of the synthesized audio
+type synthPart struct {
+	Seconds float64
+	Freq    float64
+	Amp     float64
+}
+
+// synthTimeline is the audio of the golden tests: speech-like tones of
+// different lengths separated by short and long silences
+var synthTimeline = []synthPart{
+	{Seconds: 0.5},
+	{Seconds: 1.2, Freq: 440, Amp: 0.3},
+	{Seconds: 0.8},
+	{Seconds: 3.5, Freq: 220, Amp: 0.5},
+	{Seconds: 0.15}, // shorter than MinSilenceDuration: does not split
+	{Seconds: 2.4, Freq: 330, Amp: 0.2},
+	{Seconds: 1.0},
+	{Seconds: 0.03, Freq: 660, Amp: 0.3}, // shorter than MinSpeechDuration: dropped
+	{Seconds: 0.9},
+	{Seconds: 0.6, Freq: 550, Amp: 0.05}, // quiet, still above SilenceThreshold
+	{Seconds: 0.5},
+}
+
+// synthesize returns the samples of parts at synthSampleRate
+func synthesize(parts []synthPart) []float32 {
+	var samples []float32
+	for _, p := range parts {
+		n := int(math.Round(p.Seconds * synthSampleRate))
+		for i := 0; i < n; i++ {
+			var v float64
+			if p.Freq > 0 {
+				v = p.Amp * math.Sin(2*math.Pi*p.Freq*float64(i)/synthSampleRate)
+			}
+			samples = append(samples, float32(v))
+		}
+	}
+	return samples
+}
+
+// pcm16 encodes samples as 16-bit little-endian PCM (what ffmpeg outputs)
+func pcm16(samples []float32) []byte {
+	data := make([]byte, 2*len(samples))
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(data[2*i:], uint16(int16(math.Round(float64(s)*32767))))
+	}
+	return data
+}
+
+// writeSynthWAV writes samples as a 16-bit mono WAV file
+func writeSynthWAV(t *testing.T, path string, samples []float32) {
+	t.Helper()
+	data := pcm16(samples)
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+len(data)))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(1)) // PCM
+	binary.Write(&buf, binary.LittleEndian, uint16(1)) // mono
+	binary.Write(&buf, binary.LittleEndian, uint32(synthSampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(synthSampleRate*2))
+	binary.Write(&buf, binary.LittleEndian, uint16(2))
+	binary.Write(&buf, binary.LittleEndian, uint16(16))
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// synthTokens returns one token per 0.2s of each tone of parts, so that the
+// timeline tests see text where the synthesized audio has sound
+func synthTokens(parts []synthPart) []Token {
+	const step = 0.2
+	kana := []rune("あいうえおかきくけこさしすせそたちつてと")
+	var tokens []Token
+	var at float64
+	for _, p := range parts {
+		if p.Freq > 0 {
+			for t := 0.0; t+step/2 < p.Seconds; t += step {
+				tokens = append(tokens, Token{
+					Text:       string(kana[len(tokens)%len(kana)]),
+					StartTime:  float32(roundTo(at+t, 3)),
+					Duration:   step,
+					Confidence: float32(0.5 + 0.1*float64(len(tokens)%5)),
+				})
+			}
+		}
+		at += p.Seconds
+	}
+	return tokens
+}
+
+// checkGolden compares got (as indented JSON) with testdata/golden/name.json,
+// or rewrites the file with -update
+func checkGolden(t *testing.T, name string, got any) {
+	t.Helper()
+	data, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(data, '\n')
+	path := filepath.Join("testdata", "golden", name+".json")
+
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("%s differs from the golden file (run with -update after an intended change)\ngot:\n%s", path, data)
+	}
+}
+
+// roundAll rounds values for golden files, so that they do not depend on the
+// last bits of floating-point results
+func roundAll(values []float64, places int) []float64 {
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[i] = roundTo(v, places)
+	}
+	return out
+}
+
+// TestGoldenSilenceBlocks tests speech block detection on synthesized PCM
+// and the splitting of long blocks (plain and with overlap)
+func TestGoldenSilenceBlocks(t *testing.T) {
+	config := DefaultSilenceConfig()
+	config.MaxBlockDuration = 0 // split below
+	blocks, err := detectSilenceBlocks(bytes.NewReader(pcm16(synthesize(synthTimeline))), synthSampleRate, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	round := func(blocks []SpeechBlock) []SpeechBlock {
+		out := make([]SpeechBlock, len(blocks))
+		for i, b := range blocks {
+			out[i] = SpeechBlock{StartTime: roundTo(b.StartTime, 3), EndTime: roundTo(b.EndTime, 3)}
+		}
+		return out
+	}
+
+	var overlap []OverlapBlock
+	for _, b := range splitLongBlocksWithOverlap(blocks, 2.5, 0.5) {
+		overlap = append(overlap, OverlapBlock{
+			SpeechBlock: round([]SpeechBlock{b.SpeechBlock})[0],
+			MainStart:   roundTo(b.MainStart, 3),
+			MainEnd:     roundTo(b.MainEnd, 3),
+		})
+	}
+	checkGolden(t, "silence_blocks", map[string]any{
+		"blocks":       round(blocks),
+		"split_2s":     round(splitLongBlocks(blocks, 2.0)),
+		"overlap_2.5s": overlap,
+	})
+}
+
+// TestGoldenWaveformPeaks tests the peaks of a synthesized WAV file and the
+// audio clusters and boundary adjustment computed from them
+func TestGoldenWaveformPeaks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "synth.wav")
+	writeSynthWAV(t, path, synthesize(synthTimeline))
+
+	const peaksPerSec = 20
+	peaks, duration, err := ComputeWaveformPeaks(path, peaksPerSec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := DefaultBoundaryParams()
+	clusters := FindAudioClusters(peaks, peaksPerSec, 0, duration, params.Threshold)
+	round := func(clusters []AudioCluster) []AudioCluster {
+		out := make([]AudioCluster, len(clusters))
+		for i, c := range clusters {
+			out[i] = AudioCluster{StartTime: roundTo(c.StartTime, 3), EndTime: roundTo(c.EndTime, 3), MaxPeak: roundTo(c.MaxPeak, 4)}
+		}
+		return out
+	}
+	// A segment whose ASR boundaries cut into the 3.5s tone on both sides
+	adjusted := AdjustBoundaries(peaks, peaksPerSec, 3.0, 5.5, params)
+	adjusted.OriginalStart = roundTo(adjusted.OriginalStart, 3)
+	adjusted.OriginalEnd = roundTo(adjusted.OriginalEnd, 3)
+	adjusted.AdjustedStart = roundTo(adjusted.AdjustedStart, 3)
+	adjusted.AdjustedEnd = roundTo(adjusted.AdjustedEnd, 3)
+	adjusted.MergedClusters = round(adjusted.MergedClusters)
+
+	checkGolden(t, "waveform_peaks", map[string]any{
+		"duration":        roundTo(duration, 3),
+		"peaks":           roundAll(peaks, 4),
+		"clusters":        round(clusters),
+		"merged_clusters": round(MergeClusters(clusters, 1000)),
+		"adjusted":        adjusted,
+	})
+}
+
+// TestGoldenDisplaySegments tests the fixed-interval timeline of tokens
+// placed on the tones of the synthesized audio
+func TestGoldenDisplaySegments(t *testing.T) {
+	tokens := synthTokens(synthTimeline)
+	segments := tokensToSegments(tokens)
+	var total float64
+	for _, p := range synthTimeline {
+		total += p.Seconds
+	}
+
+	display := GenerateDisplaySegments(tokens, segments, total, 5, 0.3, 5)
+	for i := range display {
+		seg := &display[i]
+		seg.StartTime = roundTo(seg.StartTime, 3)
+		seg.EndTime = roundTo(seg.EndTime, 3)
+		for j := range seg.Elements {
+			seg.Elements[j].StartTime = roundTo(seg.Elements[j].StartTime, 3)
+			seg.Elements[j].Duration = roundTo(seg.Elements[j].Duration, 3)
+		}
+		for j := range seg.ASRSegments {
+			seg.ASRSegments[j].StartTime = roundTo(seg.ASRSegments[j].StartTime, 3)
+			seg.ASRSegments[j].EndTime = roundTo(seg.ASRSegments[j].EndTime, 3)
+		}
+	}
+	checkGolden(t, "display_segments", display)
+}
+
+// TestGoldenMerge tests replacing a range of a synthesized transcript with
+// retranscribed tokens (token merge and segment redistribution)
+func TestGoldenMerge(t *testing.T) {
+	tokens := synthTokens(synthTimeline)
+	segments := tokensToSegments(tokens)
+	if len(segments) < 3 {
+		t.Fatalf("got %d segments, want at least 3", len(segments))
+	}
+
+	// Retranscribe the second segment with different text and timing
+	seg := segments[1]
+	var replacement []Token
+	for i, r := range []rune("かわったテキスト") {
+		replacement = append(replacement, Token{Text: string(r), StartTime: float32(roundTo(seg.StartTime+0.4*float64(i), 3)), Duration: 0.3})
+	}
+	merged := MergeTokens(tokens, replacement, seg.StartTime, seg.EndTime)
+	mergedSegments := MergeSegments(segments, 1, 1, replacement)
+	for i := range mergedSegments {
+		mergedSegments[i].StartTime = roundTo(mergedSegments[i].StartTime, 3)
+		mergedSegments[i].EndTime = roundTo(mergedSegments[i].EndTime, 3)
+		mergedSegments[i].Confidence = roundTo(mergedSegments[i].Confidence, 3)
+	}
+
+	checkGolden(t, "merge", map[string]any{
+		"text":     RebuildTextFromTokens(merged),
+		"tokens":   len(merged),
+		"segments": mergedSegments,
+	})
+}
@@ -0,0 +1,537 @@
+package asr
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ITN performs inverse text normalization on Japanese transcripts:
+// numbers followed by a counter (年, 月, 日, 時, 分, ...) are rewritten
+// as digits, e.g. "二千二十四年" -> "2024年", "三じ" -> "3時".
+// Idioms such as 十分 (enough) and 一時的 are left as they are, and a
+// hiragana counter is only converted after a kanji or digit numeral, so
+// にじ (虹) and ごかい (誤解) are not mistaken for times and counts.
+// Additional regexp rules can be loaded from a rule file.
+type ITN struct {
+	rules []itnRule
+}
+
+// itnRule is a user-defined regexp replacement
+type itnRule struct {
+	pattern *regexp.Regexp
+	replace string
+}
+
+// NewITN creates a normalizer with the built-in number rules only
+func NewITN() *ITN {
+	return &ITN{}
+}
+
+// LoadITN creates a normalizer with the built-in number rules plus the rules in path
+//
+// Rule file format: one rule per line, "<regexp>\t<replacement>".
+// Blank lines and lines starting with # are ignored. Replacements may use $1 etc.
+// Rules run after the built-in number conversion, in file order.
+func LoadITN(path string) (*ITN, error) {
+	itn := NewITN()
+	if path == "" {
+		return itn, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ITN rules: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("ITN rules line %d: expected <regexp>\\t<replacement>", lineNo)
+		}
+		re, err := regexp.Compile(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("ITN rules line %d: %w", lineNo, err)
+		}
+		itn.rules = append(itn.rules, itnRule{pattern: re, replace: parts[1]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ITN rules: %w", err)
+	}
+
+	return itn, nil
+}
+
+// itnReplacement describes a rewrite of text[start:end] (byte offsets)
+type itnReplacement struct {
+	start, end int
+	text       string
+}
+
+// passes returns the rewrite passes in the order they are applied
+func (n *ITN) passes() []func(string) []itnReplacement {
+	passes := []func(string) []itnReplacement{findNumberReplacements}
+	for _, rule := range n.rules {
+		passes = append(passes, rule.pass)
+	}
+	return passes
+}
+
+// pass finds the matches of the rule with their expanded replacements
+func (rule itnRule) pass(s string) []itnReplacement {
+	var reps []itnReplacement
+	for _, m := range rule.pattern.FindAllStringSubmatchIndex(s, -1) {
+		var dst []byte
+		dst = rule.pattern.ExpandString(dst, rule.replace, s, m)
+		reps = append(reps, itnReplacement{start: m[0], end: m[1], text: string(dst)})
+	}
+	return reps
+}
+
+// Normalize applies ITN to a plain string
+func (n *ITN) Normalize(s string) string {
+	for _, pass := range n.passes() {
+		s = applyReplacements(s, pass(s))
+	}
+	return s
+}
+
+// Apply normalizes the result in place: text, segment texts, and tokens.
+// Tokens covered by a rewrite are merged into one token spanning their time range.
+func (n *ITN) Apply(r *Result) {
+	if r == nil {
+		return
+	}
+	r.Text = n.Normalize(r.Text)
+	for i := range r.Segments {
+		r.Segments[i].Text = n.Normalize(r.Segments[i].Text)
+	}
+	for _, pass := range n.passes() {
+		r.Tokens = rewriteTokens(r.Tokens, pass)
+	}
+}
+
+// applyReplacements rewrites s with non-overlapping, ordered replacements
+func applyReplacements(s string, reps []itnReplacement) string {
+	if len(reps) == 0 {
+		return s
+	}
+	var b strings.Builder
+	pos := 0
+	for _, rep := range reps {
+		b.WriteString(s[pos:rep.start])
+		b.WriteString(rep.text)
+		pos = rep.end
+	}
+	b.WriteString(s[pos:])
+	return b.String()
+}
+
+// rewriteTokens applies replacements found on the concatenated token text.
+// Each replacement is attached to the tokens it overlaps; those tokens are
+// merged so timestamps stay consistent with the rewritten text.
+func rewriteTokens(tokens []Token, find func(string) []itnReplacement) []Token {
+	if len(tokens) == 0 {
+		return tokens
+	}
+
+	// Byte offset where each token starts in the concatenated text
+	offsets := make([]int, len(tokens)+1)
+	var b strings.Builder
+	for i, t := range tokens {
+		offsets[i] = b.Len()
+		b.WriteString(t.Text)
+	}
+	offsets[len(tokens)] = b.Len()
+	text := b.String()
+
+	reps := find(text)
+	if len(reps) == 0 {
+		return tokens
+	}
+
+	result := make([]Token, 0, len(tokens))
+	ti := 0
+	for r := 0; r < len(reps); {
+		// Copy tokens that end before the replacement
+		for ti < len(tokens) && offsets[ti+1] <= reps[r].start {
+			result = append(result, tokens[ti])
+			ti++
+		}
+		if ti >= len(tokens) {
+			break
+		}
+
+		// Merge the tokens the replacement overlaps, along with any following
+		// replacements that start within the same tokens
+		first := ti
+		next := r
+		for {
+			for ti < len(tokens) && offsets[ti] < reps[next].end {
+				ti++
+			}
+			next++
+			if next >= len(reps) || reps[next].start >= offsets[ti] {
+				break
+			}
+		}
+		last := ti - 1
+
+		// Keep any text of the merged tokens outside the replacements
+		var b strings.Builder
+		pos := offsets[first]
+		for _, rep := range reps[r:next] {
+			b.WriteString(text[pos:rep.start])
+			b.WriteString(rep.text)
+			pos = rep.end
+		}
+		b.WriteString(text[pos:offsets[last+1]])
+		r = next
+
+		start := tokens[first].StartTime
+		end := tokens[last].StartTime + tokens[last].Duration
+		var confidence float32
+		for _, t := range tokens[first : last+1] {
+			if confidence == 0 || (t.Confidence > 0 && t.Confidence < confidence) {
+				confidence = t.Confidence
+			}
+		}
+		result = append(result, Token{
+			Text:       b.String(),
+			StartTime:  start,
+			Duration:   end - start,
+			Confidence: confidence,
+		})
+	}
+	result = append(result, tokens[ti:]...)
+
+	return result
+}
+
+// Kanji numerals
+var kanjiDigits = map[rune]int64{
+	'〇': 0, '零': 0, '一': 1, '二': 2, '三': 3, '四': 4,
+	'五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+var kanjiSmallUnits = map[rune]int64{'十': 10, '百': 100, '千': 1000}
+
+var kanjiLargeUnits = map[rune]int64{'万': 10000, '億': 100000000}
+
+// numberReading maps a hiragana reading to a digit or unit value
+type numberReading struct {
+	reading string
+	value   int64
+	unit    bool
+	after   []int64 // rendaku forms are only valid after these digits
+}
+
+// Hiragana readings; longer forms come first so greedy matching prefers them
+var hiraganaReadings = []numberReading{
+	{"きゅう", 9, false, nil},
+	{"じゅう", 10, true, nil}, {"じゅっ", 10, true, nil},
+	{"ひゃく", 100, true, nil}, {"びゃく", 100, true, []int64{3}}, {"ぴゃく", 100, true, []int64{6, 8}},
+	{"ぜろ", 0, false, nil}, {"れい", 0, false, nil},
+	{"いち", 1, false, nil}, {"いっ", 1, false, nil},
+	{"さん", 3, false, nil}, {"よん", 4, false, nil},
+	{"ろく", 6, false, nil}, {"ろっ", 6, false, nil},
+	{"なな", 7, false, nil}, {"しち", 7, false, nil},
+	{"はち", 8, false, nil}, {"はっ", 8, false, nil},
+	{"じっ", 10, true, nil},
+	{"せん", 1000, true, nil}, {"ぜん", 1000, true, []int64{3}},
+	{"まん", 10000, true, nil}, {"おく", 100000000, true, nil},
+	{"に", 2, false, nil}, {"よ", 4, false, nil},
+	{"ご", 5, false, nil}, {"く", 9, false, nil},
+}
+
+// Counters that trigger conversion; hiragana readings map to their kanji form
+// (and are only used after a kanji or digit numeral)
+var counterReadings = []struct {
+	reading string
+	kanji   string
+}{
+	{"パーセント", "%"},
+	{"びょう", "秒"}, {"にち", "日"}, {"ねん", "年"}, {"がつ", "月"},
+	{"ふん", "分"}, {"ぷん", "分"}, {"えん", "円"}, {"にん", "人"},
+	{"かい", "回"}, {"さい", "歳"}, {"じ", "時"},
+	{"年", "年"}, {"月", "月"}, {"日", "日"}, {"時", "時"}, {"分", "分"},
+	{"秒", "秒"}, {"円", "円"}, {"人", "人"}, {"回", "回"}, {"個", "個"},
+	{"歳", "歳"}, {"%", "%"}, {"％", "%"},
+}
+
+// itnIdioms are words that start with a number and a counter but are not
+// quantities (一時的 is "temporary", not "1 o'clock"); they are never converted
+var itnIdioms = []struct {
+	word   string
+	unless []string // the word is a quantity when one of these follows
+}{
+	{"十分", []string{"後", "前", "間", "以内", "以上", "おき", "ごと", "ほど", "くらい", "ぐらい", "程度"}},
+	{"一時的", nil}, {"一時期", nil}, {"一時停止", nil},
+	{"一人一人", nil}, {"一人ひとり", nil}, {"一人前", nil}, {"一人っ子", nil},
+	{"一人称", nil}, {"二人称", nil}, {"三人称", nil}, {"一人者", nil}, {"二人三脚", nil},
+	{"一日中", nil}, {"一日一日", nil}, {"一年中", nil}, {"一回り", nil},
+	{"一分一秒", nil}, {"五分五分", nil}, {"九分九厘", nil},
+	{"十人十色", nil}, {"三日月", nil}, {"三日坊主", nil},
+}
+
+// idiomAt returns the end of the idiom at byte offset i, if any
+func idiomAt(s string, i int) (end int, ok bool) {
+	for _, idiom := range itnIdioms {
+		if !strings.HasPrefix(s[i:], idiom.word) {
+			continue
+		}
+		end := i + len(idiom.word)
+		quantity := false
+		for _, next := range idiom.unless {
+			if strings.HasPrefix(s[end:], next) {
+				quantity = true
+				break
+			}
+		}
+		if !quantity {
+			return end, true
+		}
+	}
+	return i, false
+}
+
+// numberParser accumulates a Japanese number expression
+type numberParser struct {
+	total, section, current int64
+	hasCurrent, any         bool
+	lastWasDigit            bool
+	kana                    bool // a hiragana reading was used
+}
+
+func (p *numberParser) digit(d int64) {
+	if p.hasCurrent && p.lastWasDigit {
+		// Positional digits, e.g. 二〇二四
+		p.current = p.current*10 + d
+	} else {
+		p.current = d
+	}
+	p.hasCurrent = true
+	p.lastWasDigit = true
+	p.any = true
+}
+
+func (p *numberParser) smallUnit(u int64) {
+	n := int64(1)
+	if p.hasCurrent {
+		n = p.current
+	}
+	p.section += n * u
+	p.current, p.hasCurrent = 0, false
+	p.lastWasDigit = false
+	p.any = true
+}
+
+func (p *numberParser) largeUnit(u int64) {
+	n := p.section
+	if p.hasCurrent {
+		n += p.current
+	}
+	if n == 0 {
+		n = 1
+	}
+	p.total += n * u
+	p.section, p.current, p.hasCurrent = 0, 0, false
+	p.lastWasDigit = false
+	p.any = true
+}
+
+func (p *numberParser) value() int64 {
+	v := p.total + p.section
+	if p.hasCurrent {
+		v += p.current
+	}
+	return v
+}
+
+// parseNumberAt parses a kanji or hiragana number starting at byte offset i.
+// It returns the value and the end offset, or ok=false if no number starts there.
+// kana reports whether the number was (partly) spelled in hiragana.
+func parseNumberAt(s string, i int) (value int64, end int, kana bool, ok bool) {
+	var p numberParser
+	pos := i
+	for pos < len(s) {
+		r, size := utf8.DecodeRuneInString(s[pos:])
+		if d, isDigit := kanjiDigits[r]; isDigit {
+			p.digit(d)
+			pos += size
+			continue
+		}
+		if u, isUnit := kanjiSmallUnits[r]; isUnit {
+			p.smallUnit(u)
+			pos += size
+			continue
+		}
+		if u, isUnit := kanjiLargeUnits[r]; isUnit && p.any {
+			p.largeUnit(u)
+			pos += size
+			continue
+		}
+
+		matched := false
+		for _, nr := range hiraganaReadings {
+			if !strings.HasPrefix(s[pos:], nr.reading) {
+				continue
+			}
+			if nr.after != nil && !(p.hasCurrent && containsInt64(nr.after, p.current)) {
+				continue
+			}
+			// Spoken numbers are never positional (ごご is 午後, not 55)
+			if !nr.unit && p.lastWasDigit {
+				continue
+			}
+			if nr.unit {
+				if nr.value >= 10000 {
+					if !p.any {
+						continue
+					}
+					p.largeUnit(nr.value)
+				} else {
+					p.smallUnit(nr.value)
+				}
+			} else {
+				p.digit(nr.value)
+			}
+			p.kana = true
+			pos += len(nr.reading)
+			matched = true
+			break
+		}
+		if !matched {
+			break
+		}
+	}
+	if !p.any {
+		return 0, i, false, false
+	}
+	return p.value(), pos, p.kana, true
+}
+
+// digitsAt returns the end of the run of ASCII digits at byte offset i
+func digitsAt(s string, i int) int {
+	end := i
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	return end
+}
+
+// counterAt returns the normalized counter at byte offset i, if any.
+// Hiragana counters are only returned when allowHiragana is true.
+func counterAt(s string, i int, allowHiragana bool) (kanji string, end int, ok bool) {
+	for _, c := range counterReadings {
+		if !allowHiragana && !isKanjiCounter(c.reading) {
+			continue
+		}
+		if strings.HasPrefix(s[i:], c.reading) {
+			return c.kanji, i + len(c.reading), true
+		}
+	}
+	return "", i, false
+}
+
+// isKanjiCounter reports whether a counter is written without hiragana
+func isKanjiCounter(counter string) bool {
+	for _, r := range counter {
+		if r >= 'ぁ' && r <= 'ゖ' {
+			return false
+		}
+	}
+	return true
+}
+
+// findNumberReplacements finds numbers followed by a counter.
+// A number is only converted when a counter follows, so words such as
+// 一緒 in running text are left untouched; idioms (itnIdioms) are skipped.
+func findNumberReplacements(s string) []itnReplacement {
+	var reps []itnReplacement
+	for i := 0; i < len(s); {
+		if end, ok := idiomAt(s, i); ok {
+			i = end
+			continue
+		}
+		if rep, ok := matchDigitsWithCounter(s, i); ok {
+			reps = append(reps, rep)
+			i = rep.end
+			continue
+		}
+		if end := digitsAt(s, i); end > i {
+			// Do not start a number in the middle of a digit run (12じ is not 1 + 2時)
+			i = end
+			continue
+		}
+		if rep, ok := matchNumberWithCounter(s, i); ok {
+			reps = append(reps, rep)
+			i = rep.end
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return reps
+}
+
+// matchDigitsWithCounter matches digits followed by a hiragana counter at
+// byte offset i ("3じ" -> "3時"); digits before a kanji counter are already normalized
+func matchDigitsWithCounter(s string, i int) (itnReplacement, bool) {
+	end := digitsAt(s, i)
+	if end == i {
+		return itnReplacement{}, false
+	}
+	counter, counterEnd, found := counterAt(s, end, true)
+	if !found || isKanjiCounter(s[end:counterEnd]) {
+		return itnReplacement{}, false
+	}
+	return itnReplacement{start: i, end: counterEnd, text: s[i:end] + counter}, true
+}
+
+// matchNumberWithCounter matches "<number><counter>" at byte offset i.
+// When greedy number matching swallows the start of a kanji counter,
+// shorter number spans are tried.
+func matchNumberWithCounter(s string, i int) (itnReplacement, bool) {
+	_, numEnd, _, ok := parseNumberAt(s, i)
+	if !ok {
+		return itnReplacement{}, false
+	}
+	for end := numEnd; end > i; {
+		v, vEnd, kana, ok := parseNumberAt(s[:end], i)
+		if counter, counterEnd, found := counterAt(s, end, !kana); found {
+			if ok && vEnd == end {
+				return itnReplacement{
+					start: i,
+					end:   counterEnd,
+					text:  strconv.FormatInt(v, 10) + counter,
+				}, true
+			}
+		}
+		_, size := utf8.DecodeLastRuneInString(s[i:end])
+		end -= size
+	}
+	return itnReplacement{}, false
+}
+
+// containsInt64 reports whether v is in values
+func containsInt64(values []int64, v int64) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
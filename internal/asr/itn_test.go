@@ -0,0 +1,95 @@
+package asr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestITNNormalize tests the built-in Japanese number rules
+func TestITNNormalize(t *testing.T) {
+	itn := NewITN()
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"二千二十四年", "2024年"},
+		{"二〇二四年", "2024年"},
+		{"十二がつ二十五にち", "12月25日"},
+		{"ごご三じ十ぷん", "ごご3時10分"},
+		{"さんびゃく円", "300円"},
+		{"三にん", "3人"},
+		{"3じ15ふん", "3時15分"},
+		{"一万五千円です", "15000円です"},
+		{"十分後に再開", "10分後に再開"},
+		{"一時間", "1時間"},
+		{"一緒に行きましょう", "一緒に行きましょう"},
+		{"ごぜんちゅう", "ごぜんちゅう"},
+		// Idioms and adverbs are not quantities
+		{"十分に注意してください", "十分に注意してください"},
+		{"一時的に止める", "一時的に止める"},
+		{"一人一人に聞く", "一人一人に聞く"},
+		{"一日中雨だった", "一日中雨だった"},
+		{"三日坊主", "三日坊主"},
+		// Hiragana counters only follow a kanji or digit numeral
+		{"にじがでた", "にじがでた"},
+		{"ごかいです", "ごかいです"},
+		{"くじびき", "くじびき"},
+		{"にせんにじゅうよねん", "にせんにじゅうよねん"},
+		{"2024ねん", "2024年"},
+		{"2024年", "2024年"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := itn.Normalize(tt.input); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestITNApply tests that tokens covered by a rewrite are merged with a
+// time range spanning the original tokens
+func TestITNApply(t *testing.T) {
+	result := &Result{
+		Text: "二十ねんまえ",
+		Tokens: []Token{
+			{Text: "二", StartTime: 1.0, Duration: 0.1},
+			{Text: "十", StartTime: 1.1, Duration: 0.2},
+			{Text: "ねん", StartTime: 1.3, Duration: 0.2},
+			{Text: "まえ", StartTime: 1.5, Duration: 0.2},
+		},
+		Segments: []Segment{{Text: "二十ねんまえ", StartTime: 1.0, EndTime: 1.7}},
+	}
+
+	NewITN().Apply(result)
+
+	if result.Text != "20年まえ" || result.Segments[0].Text != "20年まえ" {
+		t.Errorf("text = %q / %q, want 20年まえ", result.Text, result.Segments[0].Text)
+	}
+	if len(result.Tokens) != 2 {
+		t.Fatalf("got %d tokens, want 2: %+v", len(result.Tokens), result.Tokens)
+	}
+	tok := result.Tokens[0]
+	if tok.Text != "20年" || tok.StartTime != 1.0 || tok.Duration < 0.49 || tok.Duration > 0.51 {
+		t.Errorf("merged token = %+v, want {20年 1.0 0.5}", tok)
+	}
+}
+
+// TestLoadITN tests user-defined rules from a rule file
+func TestLoadITN(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "itn_rules.tsv")
+	rules := "# comment\n(\\d+)時(\\d+)分\t$1:$2\n"
+	if err := os.WriteFile(path, []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	itn, err := LoadITN(path)
+	if err != nil {
+		t.Fatalf("LoadITN failed: %v", err)
+	}
+	if got := itn.Normalize("十時三十分"); got != "10:30" {
+		t.Errorf("Normalize = %q, want %q", got, "10:30")
+	}
+}
@@ -0,0 +1,165 @@
+package asr
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+
+	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+)
+
+// DefaultLangIDModelDir is the multilingual Whisper model used for spoken
+// language identification (relative to project root)
+const DefaultLangIDModelDir = "models/sherpa-onnx-whisper-tiny"
+
+// DefaultLangIDSeconds is the length of audio inspected from the start of a file
+const DefaultLangIDSeconds = 30
+
+// SenseVoiceLanguages are the languages the SenseVoice model transcribes
+// (besides automatic detection)
+var SenseVoiceLanguages = map[string]bool{
+	"zh":  true,
+	"en":  true,
+	"ja":  true,
+	"ko":  true,
+	"yue": true,
+}
+
+// LangIDConfig holds the configuration of spoken language identification
+type LangIDConfig struct {
+	ModelDir   string // multilingual Whisper model (not *.en)
+	Seconds    int    // audio inspected from the start of the file (0: DefaultLangIDSeconds)
+	NumThreads int    // 0: auto (DefaultNumThreads)
+	Provider   string // execution provider: cpu (default), cuda, coreml, directml
+}
+
+// DefaultLangIDConfig returns the default language identification configuration
+func DefaultLangIDConfig(modelDir string) *LangIDConfig {
+	return &LangIDConfig{
+		ModelDir:   modelDir,
+		Seconds:    DefaultLangIDSeconds,
+		NumThreads: 0, // auto
+	}
+}
+
+// Validate checks if the model files exist (without loading the model)
+func (c *LangIDConfig) Validate() error {
+	if _, _, err := c.modelFiles(); err != nil {
+		return err
+	}
+	_, err := ParseProvider(c.Provider)
+	return err
+}
+
+// modelFiles finds the Whisper encoder and decoder in the model directory
+func (c *LangIDConfig) modelFiles() (encoderPath, decoderPath string, err error) {
+	encoderCandidates := []string{
+		"tiny-encoder.int8.onnx",
+		"tiny-encoder.onnx",
+		"base-encoder.int8.onnx",
+		"base-encoder.onnx",
+		"small-encoder.int8.onnx",
+		"small-encoder.onnx",
+		"encoder.int8.onnx",
+		"encoder.onnx",
+	}
+	decoderCandidates := []string{
+		"tiny-decoder.int8.onnx",
+		"tiny-decoder.onnx",
+		"base-decoder.int8.onnx",
+		"base-decoder.onnx",
+		"small-decoder.int8.onnx",
+		"small-decoder.onnx",
+		"decoder.int8.onnx",
+		"decoder.onnx",
+	}
+
+	encoderPath = findModelFile(c.ModelDir, encoderCandidates)
+	decoderPath = findModelFile(c.ModelDir, decoderCandidates)
+	if encoderPath == "" {
+		return "", "", fmt.Errorf("language identification encoder not found in %s", c.ModelDir)
+	}
+	if decoderPath == "" {
+		return "", "", fmt.Errorf("language identification decoder not found in %s", c.ModelDir)
+	}
+	return encoderPath, decoderPath, nil
+}
+
+// LanguageIdentifier detects the spoken language of audio files
+type LanguageIdentifier struct {
+	slid    *sherpa.SpokenLanguageIdentification
+	seconds int
+}
+
+// NewLanguageIdentifier loads the language identification model
+func NewLanguageIdentifier(config *LangIDConfig) (*LanguageIdentifier, error) {
+	if config == nil {
+		return nil, fmt.Errorf("config is required")
+	}
+	encoderPath, decoderPath, err := config.modelFiles()
+	if err != nil {
+		return nil, err
+	}
+	provider, err := ParseProvider(config.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	sherpaConfig := sherpa.SpokenLanguageIdentificationConfig{
+		Whisper: sherpa.SpokenLanguageIdentificationWhisperConfig{
+			Encoder: encoderPath,
+			Decoder: decoderPath,
+		},
+		NumThreads: numThreads(config.NumThreads),
+		Provider:   provider,
+	}
+	slid := sherpa.NewSpokenLanguageIdentification(&sherpaConfig)
+	if slid == nil {
+		return nil, fmt.Errorf("failed to create language identifier")
+	}
+
+	seconds := config.Seconds
+	if seconds <= 0 {
+		seconds = DefaultLangIDSeconds
+	}
+	return &LanguageIdentifier{slid: slid, seconds: seconds}, nil
+}
+
+// Close releases the language identifier resources
+func (l *LanguageIdentifier) Close() {
+	if l.slid != nil {
+		sherpa.DeleteSpokenLanguageIdentification(l.slid)
+		l.slid = nil
+	}
+}
+
+// IdentifyFile returns the language code (ja, en, zh, ...) spoken in the
+// first seconds of an audio file
+func (l *LanguageIdentifier) IdentifyFile(ctx context.Context, inputPath string) (string, error) {
+	const sampleRate = 16000
+
+	audio, err := openPCM(ctx, inputPath, decodeOptions{SampleRate: sampleRate, Duration: float64(l.seconds)})
+	if err != nil {
+		return "", err
+	}
+	samples, err := readPCM(bufio.NewReader(audio), sampleRate*l.seconds)
+	if err != nil {
+		audio.Close()
+		return "", fmt.Errorf("failed to read audio: %w", err)
+	}
+	if err := audio.Close(); err != nil {
+		return "", fmt.Errorf("decoding failed: %w", err)
+	}
+	if len(samples) == 0 {
+		return "", fmt.Errorf("no audio in %s", inputPath)
+	}
+
+	stream := l.slid.CreateStream()
+	defer sherpa.DeleteOfflineStream(stream)
+	stream.AcceptWaveform(sampleRate, samples)
+	result := l.slid.Compute(stream)
+	if result == nil || result.Lang == "" {
+		return "", fmt.Errorf("language could not be identified")
+	}
+	return result.Lang, nil
+}
@@ -0,0 +1,31 @@
+package asr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLangIDConfigValidate(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultLangIDConfig(dir)
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() = nil for an empty model directory")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tiny-encoder.int8.onnx"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() = nil without a decoder")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tiny-decoder.int8.onnx"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+	config.Provider = "tpu"
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() = nil for an unknown provider")
+	}
+}
@@ -0,0 +1,132 @@
+package asr
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// unknownLogProb is the log10 probability of a unit missing from an LM
+// without an <unk> entry
+const unknownLogProb = -10
+
+// NgramLM is a backoff n-gram language model loaded from an ARPA file (the
+// format written by KenLM's lmplz and SRILM). Its units are characters: train
+// it on text with the characters separated by spaces, which suits Japanese
+// without a word segmenter
+type NgramLM struct {
+	order int
+	grams map[string]ngram // units joined by spaces
+}
+
+type ngram struct {
+	logProb float64 // log10
+	backoff float64 // log10
+}
+
+// LoadNgramLM reads an ARPA language model
+func LoadNgramLM(path string) (*NgramLM, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open LM: %w", err)
+	}
+	defer f.Close()
+
+	lm := &NgramLM{grams: map[string]ngram{}}
+	n := 0 // order of the current section (0 outside \N-grams:)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case line == `\end\`:
+			n = 0
+			continue
+		case line == `\data\`:
+			continue
+		case strings.HasPrefix(line, `\`) && strings.HasSuffix(line, "-grams:"):
+			n, err = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, `\`), "-grams:"))
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("%s:%d: invalid section %q", path, lineNo, line)
+			}
+			lm.order = max(lm.order, n)
+			continue
+		}
+		if n == 0 {
+			continue // header ("ngram 1=...")
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != n+1 && len(fields) != n+2 {
+			return nil, fmt.Errorf("%s:%d: expected %d units", path, lineNo, n)
+		}
+		var g ngram
+		if g.logProb, err = strconv.ParseFloat(fields[0], 64); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid probability %q", path, lineNo, fields[0])
+		}
+		if len(fields) == n+2 {
+			if g.backoff, err = strconv.ParseFloat(fields[n+1], 64); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid backoff %q", path, lineNo, fields[n+1])
+			}
+		}
+		lm.grams[strings.Join(fields[1:n+1], " ")] = g
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read LM: %w", err)
+	}
+	if lm.order == 0 {
+		return nil, fmt.Errorf("%s: no n-grams", path)
+	}
+	return lm, nil
+}
+
+// Order returns the n-gram order of the model
+func (lm *NgramLM) Order() int {
+	return lm.order
+}
+
+// Score returns the log10 probability of text (including the sentence
+// boundaries). Whitespace is ignored
+func (lm *NgramLM) Score(text string) float64 {
+	units := []string{"<s>"}
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			units = append(units, string(r))
+		}
+	}
+	units = append(units, "</s>")
+
+	var total float64
+	for i := 1; i < len(units); i++ {
+		start := max(0, i-lm.order+1)
+		total += lm.logProb(units[start:i], units[i])
+	}
+	return total
+}
+
+// logProb returns log10 P(unit | context), backing off to shorter contexts
+func (lm *NgramLM) logProb(context []string, unit string) float64 {
+	var backoff float64
+	for len(context) > 0 {
+		key := strings.Join(context, " ")
+		if g, ok := lm.grams[key+" "+unit]; ok {
+			return backoff + g.logProb
+		}
+		backoff += lm.grams[key].backoff
+		context = context[1:]
+	}
+	if g, ok := lm.grams[unit]; ok {
+		return backoff + g.logProb
+	}
+	if g, ok := lm.grams["<unk>"]; ok {
+		return backoff + g.logProb
+	}
+	return backoff + unknownLogProb
+}
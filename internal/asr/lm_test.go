@@ -0,0 +1,96 @@
+package asr
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testARPA = `\data\
+ngram 1=5
+ngram 2=3
+
+\1-grams:
+-1.0	<s>	-0.5
+-1.0	</s>
+-0.5	あ	-0.3
+-0.7	い	-0.2
+-2.0	<unk>
+
+\2-grams:
+-0.2	<s> あ
+-0.1	あ い
+-0.3	い </s>
+
+\end\
+`
+
+func loadTestLM(t *testing.T) *NgramLM {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "lm.arpa")
+	if err := os.WriteFile(path, []byte(testARPA), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lm, err := LoadNgramLM(path)
+	if err != nil {
+		t.Fatalf("LoadNgramLM: %v", err)
+	}
+	return lm
+}
+
+// TestNgramLMScore tests n-gram hits, backoff and unknown characters
+func TestNgramLMScore(t *testing.T) {
+	lm := loadTestLM(t)
+	if lm.Order() != 2 {
+		t.Errorf("Order() = %d, want 2", lm.Order())
+	}
+
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{"あい", -0.2 - 0.1 - 0.3},                            // bigrams only
+		{"い あ", (-0.5 - 0.7) + (-0.2 - 0.5) + (-0.3 - 1.0)}, // backoff (whitespace ignored)
+		{"う", (-0.5 - 2.0) + -1.0},                          // <unk>
+	}
+	for _, tt := range tests {
+		if got := lm.Score(tt.text); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Score(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+// TestChooseCandidate tests voting, LM rescoring and unanimous blocks
+func TestChooseCandidate(t *testing.T) {
+	lm := loadTestLM(t)
+	tokens := func(texts ...string) []Token {
+		var out []Token
+		for _, s := range texts {
+			out = append(out, Token{Text: s})
+		}
+		return out
+	}
+	candidates := [][]Token{tokens("あ", "い"), tokens("い", "あ"), tokens("い", "あ")}
+
+	// Without an LM the majority wins
+	got, nbest := chooseCandidate(candidates, nil, 1, 2)
+	if got[0].Text != "い" || nbest == nil || nbest.Chosen != 1 || nbest.LMScores != nil {
+		t.Errorf("vote: got %v, nbest %+v", got, nbest)
+	}
+	if nbest.StartTime != 1 || nbest.EndTime != 2 || len(nbest.Hypotheses) != 3 {
+		t.Errorf("nbest = %+v", nbest)
+	}
+
+	// A strongly weighted LM prefers the likelier hypothesis
+	got, nbest = chooseCandidate(candidates, &Rescorer{LM: lm, Scale: 10}, 1, 2)
+	if got[0].Text != "あ" || nbest.Chosen != 0 || len(nbest.LMScores) != 3 {
+		t.Errorf("rescore: got %v, nbest %+v", got, nbest)
+	}
+
+	// Agreeing hypotheses are not ambiguous
+	got, nbest = chooseCandidate([][]Token{tokens("あ"), tokens("あ")}, &Rescorer{LM: lm, Scale: 10}, 0, 1)
+	if got[0].Text != "あ" || nbest != nil {
+		t.Errorf("unanimous: got %v, nbest %+v", got, nbest)
+	}
+}
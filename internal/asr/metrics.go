@@ -0,0 +1,92 @@
+package asr
+
+import (
+	"math"
+	"time"
+)
+
+// Metrics describes how fast a transcription ran. It is saved with the
+// transcription artifact so throughput can be compared across models and
+// parameter changes
+type Metrics struct {
+	Model          string  `json:"model,omitempty"`
+	WallSeconds    float64 `json:"wall_seconds"`    // processing time
+	AudioSeconds   float64 `json:"audio_seconds"`   // length of the audio
+	RTF            float64 `json:"rtf"`             // real-time factor: WallSeconds / AudioSeconds (below 1 is faster than real time)
+	Chunks         int     `json:"chunks"`          // chunks or blocks decoded
+	FFmpegRestarts int     `json:"ffmpeg_restarts"` // decoding passes after the first (block modes decode again for a tempo)
+	Files          int     `json:"files,omitempty"` // files merged into the result
+}
+
+// RecordMetrics fills in the result's Metrics (and Duration) after a file
+// was transcribed: wall is the processing time and audioSeconds the length
+// of the file (0 to use TotalDuration). Chunks and FFmpegRestarts reported
+// by the recognizer are kept; without them the chunks of ChunkReports are counted
+func (r *Result) RecordMetrics(model string, wall time.Duration, audioSeconds float64) {
+	if r.Metrics == nil {
+		r.Metrics = &Metrics{}
+	}
+	m := r.Metrics
+	m.Model = model
+	m.WallSeconds = wall.Seconds()
+	if audioSeconds <= 0 {
+		audioSeconds = float64(r.TotalDuration)
+	}
+	m.AudioSeconds = audioSeconds
+	if m.Chunks == 0 {
+		for _, report := range r.ChunkReports {
+			m.Chunks += report.Chunks
+		}
+	}
+	if m.Files == 0 {
+		m.Files = 1
+	}
+	m.round()
+	r.Duration = m.WallSeconds
+}
+
+// AddPass adds the processing of another pass over the same audio (e.g. the
+// ReazonSpeech pass that provides timestamps for whisper:align): the wall
+// time, chunks and ffmpeg restarts are added and the audio length is kept
+func (m *Metrics) AddPass(other *Metrics) {
+	if other == nil {
+		return
+	}
+	m.WallSeconds += other.WallSeconds
+	m.Chunks += other.Chunks
+	m.FFmpegRestarts += other.FFmpegRestarts
+	m.round()
+}
+
+// MergeMetrics sums the metrics of the files of a multi-file transcription
+// (nil entries are skipped; nil if there are none)
+func MergeMetrics(metrics ...*Metrics) *Metrics {
+	var merged *Metrics
+	for _, m := range metrics {
+		if m == nil {
+			continue
+		}
+		if merged == nil {
+			merged = &Metrics{Model: m.Model}
+		}
+		merged.WallSeconds += m.WallSeconds
+		merged.AudioSeconds += m.AudioSeconds
+		merged.Chunks += m.Chunks
+		merged.FFmpegRestarts += m.FFmpegRestarts
+		merged.Files += max(m.Files, 1)
+	}
+	if merged != nil {
+		merged.round()
+	}
+	return merged
+}
+
+// round computes RTF and rounds the times to milliseconds
+func (m *Metrics) round() {
+	m.WallSeconds = math.Round(m.WallSeconds*1000) / 1000
+	m.AudioSeconds = math.Round(m.AudioSeconds*1000) / 1000
+	m.RTF = 0
+	if m.AudioSeconds > 0 {
+		m.RTF = math.Round(m.WallSeconds/m.AudioSeconds*10000) / 10000
+	}
+}
@@ -0,0 +1,55 @@
+package asr
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRecordMetrics tests RTF, the chunk count fallback and the audio length fallback
+func TestRecordMetrics(t *testing.T) {
+	r := &Result{
+		TotalDuration: 118.5,
+		ChunkReports:  []ChunkReport{{Chunks: 6}},
+	}
+	r.RecordMetrics("sensevoice", 30*time.Second, 120)
+
+	m := r.Metrics
+	if m.Model != "sensevoice" || m.WallSeconds != 30 || m.AudioSeconds != 120 {
+		t.Fatalf("metrics = %+v", m)
+	}
+	if m.RTF != 0.25 {
+		t.Errorf("RTF = %v, want 0.25", m.RTF)
+	}
+	if m.Chunks != 6 || m.Files != 1 {
+		t.Errorf("chunks = %d, files = %d, want 6, 1", m.Chunks, m.Files)
+	}
+	if r.Duration != 30 {
+		t.Errorf("Duration = %v, want 30", r.Duration)
+	}
+
+	// Counts reported by the recognizer are kept; the audio length falls back to TotalDuration
+	r = &Result{TotalDuration: 50, Metrics: &Metrics{Chunks: 4, FFmpegRestarts: 4}}
+	r.RecordMetrics("reazonspeech", 10*time.Second, 0)
+	if r.Metrics.AudioSeconds != 50 || r.Metrics.RTF != 0.2 || r.Metrics.Chunks != 4 || r.Metrics.FFmpegRestarts != 4 {
+		t.Errorf("metrics = %+v", r.Metrics)
+	}
+}
+
+// TestMergeMetrics tests summing per-file metrics and adding a second pass
+func TestMergeMetrics(t *testing.T) {
+	if MergeMetrics(nil, nil) != nil {
+		t.Error("MergeMetrics of nil entries should be nil")
+	}
+
+	a := &Metrics{Model: "reazonspeech", WallSeconds: 10, AudioSeconds: 60, Chunks: 6, FFmpegRestarts: 6, Files: 1}
+	b := &Metrics{Model: "reazonspeech", WallSeconds: 20, AudioSeconds: 60, Chunks: 7, FFmpegRestarts: 7, Files: 1}
+	m := MergeMetrics(a, nil, b)
+	if m.WallSeconds != 30 || m.AudioSeconds != 120 || m.RTF != 0.25 || m.Chunks != 13 || m.FFmpegRestarts != 13 || m.Files != 2 {
+		t.Errorf("merged = %+v", m)
+	}
+
+	a.AddPass(b)
+	if a.WallSeconds != 30 || a.AudioSeconds != 60 || a.RTF != 0.5 || a.Chunks != 13 {
+		t.Errorf("after AddPass = %+v", a)
+	}
+}
@@ -0,0 +1,50 @@
+package asr
+
+// TranscriptionParams records the model and settings that produced a
+// transcription. It is saved with the transcription artifact so a transcript
+// can later be traced back to how it was made
+type TranscriptionParams struct {
+	Model              string   `json:"model,omitempty"`
+	DecodingMethod     string   `json:"decoding_method,omitempty"`      // greedy_search or modified_beam_search
+	MaxActivePaths     int      `json:"max_active_paths,omitempty"`     // hypotheses kept by modified_beam_search
+	Rescored           bool     `json:"rescored,omitempty"`             // ambiguous blocks rescored with the language model
+	Tempo              float64  `json:"tempo,omitempty"`                // playback speed applied before ASR (block mode)
+	Overlap            float64  `json:"overlap,omitempty"`              // seconds of overlap between blocks (block mode)
+	SilenceThreshold   float64  `json:"silence_threshold,omitempty"`    // RMS level treated as silence (block mode)
+	MinSilenceDuration float64  `json:"min_silence_duration,omitempty"` // seconds of silence that split blocks (block mode)
+	MaxBlockDuration   float64  `json:"max_block_duration,omitempty"`   // seconds before a block is split (block mode)
+	ChunkSeconds       float64  `json:"chunk_seconds,omitempty"`        // chunk length (chunk mode)
+	Language           string   `json:"language,omitempty"`             // decoding language of multilingual models
+	Hotwords           []string `json:"hotwords,omitempty"`             // words favored while decoding
+	Version            string   `json:"version,omitempty"`              // zbor version
+}
+
+// BlockParams returns the parameters of block-based transcription with
+// silenceConfig, tempo and overlap (see TranscribeWithOverlap)
+func BlockParams(model string, config *Config, silenceConfig *SilenceConfig, tempo, overlap float64) *TranscriptionParams {
+	params := &TranscriptionParams{
+		Model:              model,
+		DecodingMethod:     config.DecodingMethod,
+		Rescored:           config.Rescorer != nil,
+		Tempo:              tempo,
+		Overlap:            overlap,
+		SilenceThreshold:   silenceConfig.SilenceThreshold,
+		MinSilenceDuration: silenceConfig.MinSilenceDuration,
+		MaxBlockDuration:   silenceConfig.MaxBlockDuration,
+	}
+	if params.DecodingMethod == "" {
+		params.DecodingMethod = "greedy_search"
+	}
+	// Hotwords are decoded with modified_beam_search (see NewRecognizer)
+	if len(config.Hotwords) > 0 {
+		params.DecodingMethod = "modified_beam_search"
+		params.Hotwords = config.Hotwords
+	}
+	if params.DecodingMethod == "modified_beam_search" {
+		params.MaxActivePaths = config.MaxActivePaths
+		if params.MaxActivePaths <= 0 {
+			params.MaxActivePaths = 4
+		}
+	}
+	return params
+}
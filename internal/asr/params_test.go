@@ -0,0 +1,25 @@
+package asr
+
+import "testing"
+
+// TestBlockParams tests the recorded parameters of block-based transcription
+func TestBlockParams(t *testing.T) {
+	silence := DefaultSilenceConfig()
+	silence.SilenceThreshold = 0.0003
+	silence.MinSilenceDuration = 0.5
+	silence.MaxBlockDuration = 10
+
+	p := BlockParams("reazonspeech", &Config{}, silence, 1.0, 2.0)
+	if p.Model != "reazonspeech" || p.DecodingMethod != "greedy_search" || p.MaxActivePaths != 0 || p.Rescored {
+		t.Errorf("params = %+v", p)
+	}
+	if p.Tempo != 1 || p.Overlap != 2 || p.SilenceThreshold != 0.0003 || p.MinSilenceDuration != 0.5 || p.MaxBlockDuration != 10 {
+		t.Errorf("params = %+v", p)
+	}
+
+	// Beam search records the number of paths; a rescorer marks the result as rescored
+	p = BlockParams("reazonspeech:lm", &Config{DecodingMethod: "modified_beam_search", MaxActivePaths: 8, Rescorer: &Rescorer{}}, silence, 1.2, 2.0)
+	if p.DecodingMethod != "modified_beam_search" || p.MaxActivePaths != 8 || !p.Rescored || p.Tempo != 1.2 {
+		t.Errorf("params = %+v", p)
+	}
+}
@@ -2,9 +2,9 @@ package asr
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
-	"os/exec"
 )
 
 // PartialTranscribeOptions contains options for partial transcription
@@ -17,7 +17,7 @@ type PartialTranscribeOptions struct {
 
 // TranscribePartial transcribes a specific time range of an audio file
 // Returns tokens with timestamps adjusted to the original audio time
-func (r *Recognizer) TranscribePartial(filePath string, opts PartialTranscribeOptions) (*Result, error) {
+func (r *Recognizer) TranscribePartial(ctx context.Context, filePath string, opts PartialTranscribeOptions) (*Result, error) {
 	if opts.Tempo <= 0 {
 		opts.Tempo = 0.95
 	}
@@ -30,42 +30,20 @@ func (r *Recognizer) TranscribePartial(filePath string, opts PartialTranscribeOp
 		return nil, fmt.Errorf("invalid time range: %.2f - %.2f", opts.StartTime, opts.EndTime)
 	}
 
-	// Build ffmpeg command to extract and process the time range
-	// -ss: seek to start time
-	// -t: duration to extract
-	// -af atempo: adjust tempo
-	args := []string{
-		"-ss", fmt.Sprintf("%.3f", opts.StartTime),
-		"-i", filePath,
-		"-t", fmt.Sprintf("%.3f", duration),
-	}
-
-	// Add tempo filter if not 1.0
-	if opts.Tempo != 1.0 {
-		args = append(args, "-af", fmt.Sprintf("atempo=%.2f", opts.Tempo))
-	}
-
-	args = append(args,
-		"-f", "s16le",
-		"-acodec", "pcm_s16le",
-		"-ar", fmt.Sprintf("%d", r.config.SampleRate),
-		"-ac", "1",
-		"-loglevel", "error",
-		"pipe:1",
-	)
-
-	cmd := exec.Command("ffmpeg", args...)
-	stdout, err := cmd.StdoutPipe()
+	// Decode the time range at the tempo
+	audio, err := openPCM(ctx, filePath, decodeOptions{
+		SampleRate: r.config.SampleRate,
+		Start:      opts.StartTime,
+		Duration:   duration,
+		Tempo:      opts.Tempo,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+		return nil, err
 	}
+	defer audio.Close()
 
 	// Process audio in chunks
-	reader := bufio.NewReader(stdout)
+	reader := bufio.NewReader(audio)
 	chunkSamples := r.config.SampleRate * opts.ChunkSec
 	chunkBytes := chunkSamples * 2 // 16-bit = 2 bytes per sample
 
@@ -85,7 +63,6 @@ func (r *Recognizer) TranscribePartial(filePath string, opts PartialTranscribeOp
 		// Transcribe chunk
 		result, err := r.TranscribeBytes(samples, r.config.SampleRate)
 		if err != nil {
-			cmd.Wait()
 			return nil, fmt.Errorf("transcription failed: %w", err)
 		}
 
@@ -98,9 +75,10 @@ func (r *Recognizer) TranscribePartial(filePath string, opts PartialTranscribeOp
 		// 3. Add original start time to get absolute time
 		for _, token := range result.Tokens {
 			adjustedToken := Token{
-				Text:      token.Text,
-				StartTime: float32(opts.StartTime + (rawChunkOffset+float64(token.StartTime))*opts.Tempo),
-				Duration:  token.Duration * float32(opts.Tempo),
+				Text:       token.Text,
+				StartTime:  float32(opts.StartTime + (rawChunkOffset+float64(token.StartTime))*opts.Tempo),
+				Duration:   token.Duration * float32(opts.Tempo),
+				Confidence: token.Confidence,
 			}
 			allTokens = append(allTokens, adjustedToken)
 		}
@@ -113,7 +91,9 @@ func (r *Recognizer) TranscribePartial(filePath string, opts PartialTranscribeOp
 		}
 	}
 
-	cmd.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	return &Result{
 		Text:   allText,
@@ -263,9 +243,10 @@ func MergeSegmentsByRatio(original []Segment, startIdx, endIdx int, newTokens []
 				// Recalculate timestamp to fit within segment
 				tokenRatio := float64(j) / float64(max(tokenCount, 1))
 				adjustedToken := Token{
-					Text:      token.Text,
-					StartTime: float32(seg.StartTime + duration*tokenRatio),
-					Duration:  float32(duration / float64(max(tokenCount, 1))),
+					Text:       token.Text,
+					StartTime:  float32(seg.StartTime + duration*tokenRatio),
+					Duration:   float32(duration / float64(max(tokenCount, 1))),
+					Confidence: token.Confidence,
 				}
 				segTokens = append(segTokens, adjustedToken)
 				tokenIndex++
@@ -337,9 +318,10 @@ func MergeTokensBySegmentRatio(original []Token, newTokens []Token, segments []S
 			token := newTokens[tokenIndex]
 			tokenRatio := float64(j) / float64(max(tokenCount, 1))
 			adjustedToken := Token{
-				Text:      token.Text,
-				StartTime: float32(seg.StartTime + duration*tokenRatio),
-				Duration:  float32(duration / float64(max(tokenCount, 1))),
+				Text:       token.Text,
+				StartTime:  float32(seg.StartTime + duration*tokenRatio),
+				Duration:   float32(duration / float64(max(tokenCount, 1))),
+				Confidence: token.Confidence,
 			}
 			result = append(result, adjustedToken)
 			tokenIndex++
@@ -0,0 +1,97 @@
+package asr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// tempPCMPattern is the name pattern of the PCM files written by decodePCM
+const tempPCMPattern = "zbor-pcm-*.raw"
+
+// pcmFile is an audio file decoded once to 16-bit mono PCM in a temp file.
+// Block modes detect speech in it and read each block from it, instead of
+// decoding the file again per block (hundreds of times for a long recording)
+type pcmFile struct {
+	file       *os.File
+	sampleRate int
+	tempo      float64 // speed of the decoded audio (1.0 = original)
+	samples    int64
+}
+
+// decodePCM decodes inputPath at sampleRate, sped up by tempo (1.0 for the
+// original speed). Close removes the file
+func decodePCM(ctx context.Context, inputPath string, sampleRate int, tempo float64) (*pcmFile, error) {
+	f, err := os.CreateTemp("", tempPCMPattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	p := &pcmFile{file: f, sampleRate: sampleRate, tempo: tempo}
+
+	// The exact tempo: block times are divided by it across the whole file
+	stream, err := openPCM(ctx, inputPath, decodeOptions{SampleRate: sampleRate, Tempo: tempo})
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	_, copyErr := io.Copy(f, stream)
+	if err := stream.Close(); err != nil || copyErr != nil {
+		p.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if copyErr != nil {
+			return nil, fmt.Errorf("failed to write decoded audio: %w", copyErr)
+		}
+		return nil, fmt.Errorf("decoding failed: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		p.Close()
+		return nil, fmt.Errorf("failed to stat decoded audio: %w", err)
+	}
+	p.samples = info.Size() / 2
+	return p, nil
+}
+
+// withTempo returns the audio at tempo: p itself at the same speed, or
+// inputPath decoded again at the new tempo
+func (p *pcmFile) withTempo(ctx context.Context, inputPath string, tempo float64) (*pcmFile, error) {
+	if tempo == p.tempo {
+		return p, nil
+	}
+	return decodePCM(ctx, inputPath, p.sampleRate, tempo)
+}
+
+// Reader returns a reader of the whole audio from the start
+func (p *pcmFile) Reader() io.Reader {
+	return io.NewSectionReader(p.file, 0, p.samples*2)
+}
+
+// Block returns the samples of block, whose times are in the original audio
+// (they are divided by the tempo of the decoded audio)
+func (p *pcmFile) Block(block SpeechBlock) ([]float32, error) {
+	start := min(max(int64(math.Round(block.StartTime/p.tempo*float64(p.sampleRate))), 0), p.samples)
+	end := min(max(int64(math.Round(block.EndTime/p.tempo*float64(p.sampleRate))), start), p.samples)
+	if end == start {
+		return nil, nil
+	}
+	data := make([]byte, (end-start)*2)
+	if _, err := p.file.ReadAt(data, start*2); err != nil {
+		return nil, fmt.Errorf("failed to read audio: %w", err)
+	}
+	return bytesToFloat32(data), nil
+}
+
+// Close removes the file. It may be called more than once
+func (p *pcmFile) Close() {
+	if p.file == nil {
+		return
+	}
+	p.file.Close()
+	os.Remove(p.file.Name())
+	p.file = nil
+}
@@ -0,0 +1,83 @@
+package asr
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testPCMFile returns a pcmFile of samples as decodePCM would write it
+// (without ffmpeg)
+func testPCMFile(t *testing.T, samples []float32, sampleRate int, tempo float64) *pcmFile {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "audio.raw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(pcmBytes(samples)); err != nil {
+		t.Fatal(err)
+	}
+	p := &pcmFile{file: f, sampleRate: sampleRate, tempo: tempo, samples: int64(len(samples))}
+	t.Cleanup(p.Close)
+	return p
+}
+
+// TestPCMFileBlock tests reading blocks (in original audio time) from
+// decoded audio at normal and reduced tempo
+func TestPCMFileBlock(t *testing.T) {
+	samples := make([]float32, 100)
+	for i := range samples {
+		samples[i] = float32(i) / 200
+	}
+	quantized := bytesToFloat32(pcmBytes(samples))
+
+	tests := []struct {
+		name       string
+		tempo      float64
+		block      SpeechBlock
+		start, end int
+	}{
+		{"normal", 1.0, SpeechBlock{StartTime: 1, EndTime: 3}, 10, 30},
+		{"slowed", 0.5, SpeechBlock{StartTime: 1, EndTime: 3}, 20, 60},
+		{"past the end", 1.0, SpeechBlock{StartTime: 8, EndTime: 12}, 80, 100},
+		{"before the start", 1.0, SpeechBlock{StartTime: -1, EndTime: 0.5}, 0, 5},
+		{"empty", 1.0, SpeechBlock{StartTime: 11, EndTime: 12}, 100, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testPCMFile(t, samples, 10, tt.tempo)
+			got, err := p.Block(tt.block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := quantized[tt.start:tt.end]
+			if len(got) != len(want) {
+				t.Fatalf("got %d samples, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("sample %d = %v, want %v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestPCMFileReader tests that Reader starts over at the beginning, so
+// detection and blocks can read the same file
+func TestPCMFileReader(t *testing.T) {
+	p := testPCMFile(t, make([]float32, 50), 10, 1.0)
+	for range 2 {
+		data, err := io.ReadAll(p.Reader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != 100 {
+			t.Errorf("read %d bytes, want 100", len(data))
+		}
+	}
+
+	p.Close()
+	p.Close() // a second Close is a no-op
+}
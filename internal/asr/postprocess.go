@@ -0,0 +1,200 @@
+package asr
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ModelFamily groups recognizers whose raw output needs the same cleanup
+type ModelFamily string
+
+const (
+	ModelFamilyTransducer ModelFamily = "transducer" // ReazonSpeech zipformer and other k2 transducers
+	ModelFamilySenseVoice ModelFamily = "sensevoice"
+	ModelFamilyWhisper    ModelFamily = "whisper"
+)
+
+// PostProcessor cleans up recognizer output before it is stored.
+// Tokens may drop or merge tokens; Text is applied to the result and segment texts.
+type PostProcessor interface {
+	Tokens(tokens []Token) []Token
+	Text(s string) string
+}
+
+var (
+	postProcessorsMu sync.RWMutex
+	postProcessors   = map[ModelFamily][]PostProcessor{
+		ModelFamilyTransducer: {controlTokenStripper, byteTokenMerger{}},
+		ModelFamilySenseVoice: {specialTokenStripper, controlTokenStripper, byteTokenMerger{}},
+		ModelFamilyWhisper:    {specialTokenStripper, controlTokenStripper, byteTokenMerger{}},
+	}
+)
+
+// RegisterPostProcessor appends a post-processor to a model family.
+// Processors run in registration order, after the built-in ones.
+func RegisterPostProcessor(family ModelFamily, p PostProcessor) {
+	postProcessorsMu.Lock()
+	defer postProcessorsMu.Unlock()
+	postProcessors[family] = append(postProcessors[family], p)
+}
+
+// PostProcessors returns the post-processors registered for a model family
+func PostProcessors(family ModelFamily) []PostProcessor {
+	postProcessorsMu.RLock()
+	defer postProcessorsMu.RUnlock()
+	return append([]PostProcessor(nil), postProcessors[family]...)
+}
+
+// PostProcess cleans up the result in place with the family's post-processors:
+// tokens, text, and segment texts. Segments left empty by the cleanup are removed.
+// Unknown families are left unchanged.
+func PostProcess(family ModelFamily, r *Result) {
+	if r == nil {
+		return
+	}
+	processors := PostProcessors(family)
+	if len(processors) == 0 {
+		return
+	}
+
+	for _, p := range processors {
+		if len(r.Tokens) > 0 {
+			r.Tokens = p.Tokens(r.Tokens)
+		}
+		r.Text = p.Text(r.Text)
+	}
+
+	segments := r.Segments[:0]
+	for _, seg := range r.Segments {
+		original := seg.Text
+		for _, p := range processors {
+			seg.Text = p.Text(seg.Text)
+		}
+		if seg.Text == "" && original != "" {
+			continue
+		}
+		segments = append(segments, seg)
+	}
+	if r.Segments != nil {
+		r.Segments = segments
+	}
+}
+
+// markerStripper removes marker tokens matching a pattern.
+// Tokens that consist only of markers are dropped.
+type markerStripper struct {
+	pattern *regexp.Regexp
+}
+
+var (
+	// specialTokenStripper removes <|...|> tokens: language tags (<|ja|>), task and
+	// timestamp tokens (<|transcribe|>, <|0.00|>), SenseVoice emotion and event
+	// markers (<|NEUTRAL|>, <|Speech|>, <|BGM|>) and ITN flags (<|withitn|>)
+	specialTokenStripper = markerStripper{regexp.MustCompile(`<\|[^|<>]*\|>`)}
+
+	// controlTokenStripper removes vocabulary control symbols that should never
+	// be emitted as text (unknown, blank, sentence boundaries)
+	controlTokenStripper = markerStripper{regexp.MustCompile(`<(?:unk|blk|blank|pad|s|/s|sos/eos|eos|bos)>`)}
+)
+
+func (m markerStripper) Tokens(tokens []Token) []Token {
+	out := tokens[:0]
+	for _, t := range tokens {
+		if m.pattern.MatchString(t.Text) {
+			t.Text = m.pattern.ReplaceAllString(t.Text, "")
+			if strings.TrimSpace(t.Text) == "" {
+				continue
+			}
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func (m markerStripper) Text(s string) string {
+	if !m.pattern.MatchString(s) {
+		return s
+	}
+	return strings.TrimSpace(m.pattern.ReplaceAllString(s, ""))
+}
+
+// byteTokenMerger decodes byte-fallback tokens ("<0xE3>") and merges tokens
+// that each hold part of a UTF-8 sequence into one token spanning their time
+// range. Bytes that never form a valid character are dropped.
+type byteTokenMerger struct{}
+
+var byteTokenPattern = regexp.MustCompile(`<0x([0-9A-Fa-f]{2})>`)
+
+// decodeByteTokens replaces "<0xNN>" notation with the raw bytes
+func decodeByteTokens(s string) string {
+	if !strings.Contains(s, "<0x") {
+		return s
+	}
+	return byteTokenPattern.ReplaceAllStringFunc(s, func(m string) string {
+		b, _ := strconv.ParseUint(m[3:5], 16, 8)
+		return string([]byte{byte(b)})
+	})
+}
+
+func (byteTokenMerger) Tokens(tokens []Token) []Token {
+	out := tokens[:0]
+	var pending []Token
+	var buf strings.Builder
+
+	flush := func(final bool) {
+		text := buf.String()
+		if !final && !utf8.ValidString(text) {
+			return
+		}
+		text = strings.ToValidUTF8(text, "")
+		if text != "" {
+			out = append(out, mergeTokens(pending, text))
+		}
+		pending = pending[:0]
+		buf.Reset()
+	}
+
+	for _, t := range tokens {
+		text := decodeByteTokens(t.Text)
+		if len(pending) == 0 && utf8.ValidString(text) {
+			t.Text = text
+			out = append(out, t)
+			continue
+		}
+		pending = append(pending, t)
+		buf.WriteString(text)
+		flush(false)
+	}
+	if len(pending) > 0 {
+		flush(true)
+	}
+	return out
+}
+
+func (byteTokenMerger) Text(s string) string {
+	s = decodeByteTokens(s)
+	if utf8.ValidString(s) {
+		return s
+	}
+	return strings.ToValidUTF8(s, "")
+}
+
+// mergeTokens combines tokens into one token with the given text, spanning
+// their time range and keeping the lowest known confidence
+func mergeTokens(tokens []Token, text string) Token {
+	first, last := tokens[0], tokens[len(tokens)-1]
+	merged := Token{
+		Text:      text,
+		StartTime: first.StartTime,
+		Duration:  last.StartTime + last.Duration - first.StartTime,
+	}
+	for _, t := range tokens {
+		if merged.Confidence == 0 || (t.Confidence > 0 && t.Confidence < merged.Confidence) {
+			merged.Confidence = t.Confidence
+		}
+	}
+	return merged
+}
@@ -0,0 +1,138 @@
+package asr
+
+import (
+	"strings"
+	"testing"
+)
+
+// tokenTexts returns the texts of tokens for comparison
+func tokenTexts(tokens []Token) []string {
+	texts := make([]string, len(tokens))
+	for i, t := range tokens {
+		texts[i] = t.Text
+	}
+	return texts
+}
+
+// TestPostProcessWhisper tests that language, task and timestamp tokens are
+// stripped and UTF-8 sequences split across tokens are merged
+func TestPostProcessWhisper(t *testing.T) {
+	a := "あ" // e3 81 82
+	result := &Result{
+		Text: "<|ja|><|transcribe|><|notimestamps|>ありがとう<|endoftext|>",
+		Tokens: []Token{
+			{Text: "<|ja|>", StartTime: 0, Duration: 0.1},
+			{Text: a[:2], StartTime: 1.0, Duration: 0.1, Confidence: 0.9},
+			{Text: a[2:] + "りが", StartTime: 1.1, Duration: 0.2, Confidence: 0.5},
+			{Text: "とう", StartTime: 1.3, Duration: 0.2},
+			{Text: "<|1.50|>", StartTime: 1.5, Duration: 0},
+		},
+		Segments: []Segment{
+			{Text: "<|ja|> ありがとう", StartTime: 1.0, EndTime: 1.5},
+			{Text: "<|endoftext|>", StartTime: 1.5, EndTime: 1.5},
+		},
+	}
+
+	PostProcess(ModelFamilyWhisper, result)
+
+	if result.Text != "ありがとう" {
+		t.Errorf("text = %q, want ありがとう", result.Text)
+	}
+	if got := strings.Join(tokenTexts(result.Tokens), "|"); got != "ありが|とう" {
+		t.Fatalf("tokens = %q, want ありが|とう", got)
+	}
+	merged := result.Tokens[0]
+	if merged.StartTime != 1.0 || merged.Duration < 0.29 || merged.Duration > 0.31 || merged.Confidence != 0.5 {
+		t.Errorf("merged token = %+v, want start 1.0, duration 0.3, confidence 0.5", merged)
+	}
+	if len(result.Segments) != 1 || result.Segments[0].Text != "ありがとう" {
+		t.Errorf("segments = %+v, want one segment ありがとう", result.Segments)
+	}
+}
+
+// TestPostProcessSenseVoice tests that emotion, event and ITN markers are
+// stripped and byte-fallback tokens are decoded
+func TestPostProcessSenseVoice(t *testing.T) {
+	result := &Result{
+		Text: "<|ja|><|NEUTRAL|><|Speech|><|withitn|>あめ<unk>です",
+		Tokens: []Token{
+			{Text: "<|ja|>", StartTime: 0, Duration: 0},
+			{Text: "<|NEUTRAL|>", StartTime: 0, Duration: 0},
+			{Text: "<0xE3>", StartTime: 0.5, Duration: 0.05},
+			{Text: "<0x81>", StartTime: 0.55, Duration: 0.05},
+			{Text: "<0x82>", StartTime: 0.6, Duration: 0.05},
+			{Text: "め", StartTime: 0.7, Duration: 0.1},
+			{Text: "<unk>", StartTime: 0.8, Duration: 0.1},
+			{Text: "です", StartTime: 0.9, Duration: 0.2},
+			{Text: "<|BGM|>", StartTime: 1.2, Duration: 0},
+		},
+	}
+
+	PostProcess(ModelFamilySenseVoice, result)
+
+	if result.Text != "あめです" {
+		t.Errorf("text = %q, want あめです", result.Text)
+	}
+	if got := strings.Join(tokenTexts(result.Tokens), "|"); got != "あ|め|です" {
+		t.Fatalf("tokens = %q, want あ|め|です", got)
+	}
+	if tok := result.Tokens[0]; tok.StartTime != 0.5 || tok.Duration < 0.14 || tok.Duration > 0.16 {
+		t.Errorf("decoded token = %+v, want start 0.5, duration 0.15", tok)
+	}
+}
+
+// TestPostProcessTransducer tests that control symbols are dropped, incomplete
+// byte sequences are discarded and ordinary text is kept as is
+func TestPostProcessTransducer(t *testing.T) {
+	result := &Result{
+		Text: "こんにちは<unk>",
+		Tokens: []Token{
+			{Text: "<blk>", StartTime: 0, Duration: 0.1},
+			{Text: "こ", StartTime: 0.1, Duration: 0.1},
+			{Text: "んにちは", StartTime: 0.2, Duration: 0.4},
+			{Text: "<unk>", StartTime: 0.6, Duration: 0.1},
+			{Text: "<0xE3>", StartTime: 0.7, Duration: 0.1},
+		},
+		Segments: []Segment{{Text: "こんにちは<unk>", StartTime: 0.1, EndTime: 0.7}},
+	}
+
+	PostProcess(ModelFamilyTransducer, result)
+
+	if result.Text != "こんにちは" || result.Segments[0].Text != "こんにちは" {
+		t.Errorf("text = %q / %q, want こんにちは", result.Text, result.Segments[0].Text)
+	}
+	if got := strings.Join(tokenTexts(result.Tokens), "|"); got != "こ|んにちは" {
+		t.Errorf("tokens = %q, want こ|んにちは", got)
+	}
+}
+
+// upperProcessor is a test post-processor that upper-cases text
+type upperProcessor struct{}
+
+func (upperProcessor) Tokens(tokens []Token) []Token {
+	for i := range tokens {
+		tokens[i].Text = strings.ToUpper(tokens[i].Text)
+	}
+	return tokens
+}
+
+func (upperProcessor) Text(s string) string { return strings.ToUpper(s) }
+
+// TestRegisterPostProcessor tests that registered processors run for their
+// family only, and unknown families are left unchanged
+func TestRegisterPostProcessor(t *testing.T) {
+	const family ModelFamily = "test-family"
+	RegisterPostProcessor(family, upperProcessor{})
+
+	result := &Result{Text: "abc", Tokens: []Token{{Text: "abc"}}}
+	PostProcess(family, result)
+	if result.Text != "ABC" || result.Tokens[0].Text != "ABC" {
+		t.Errorf("got %q / %q, want ABC", result.Text, result.Tokens[0].Text)
+	}
+
+	untouched := &Result{Text: "<|ja|>abc"}
+	PostProcess("unknown", untouched)
+	if untouched.Text != "<|ja|>abc" {
+		t.Errorf("unknown family changed text to %q", untouched.Text)
+	}
+}
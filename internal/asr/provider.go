@@ -0,0 +1,40 @@
+package asr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Execution providers for ONNX Runtime inference (sherpa-onnx "provider")
+const (
+	ProviderCPU      = "cpu"
+	ProviderCUDA     = "cuda"     // NVIDIA GPUs (needs a CUDA build of sherpa-onnx / onnxruntime)
+	ProviderCoreML   = "coreml"   // Apple Silicon / macOS
+	ProviderDirectML = "directml" // Windows GPUs
+)
+
+// Providers lists the execution providers that can be selected
+var Providers = []string{ProviderCPU, ProviderCUDA, ProviderCoreML, ProviderDirectML}
+
+// ParseProvider normalizes an execution provider name. Empty means CPU
+func ParseProvider(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return ProviderCPU, nil
+	}
+	for _, p := range Providers {
+		if name == p {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown execution provider %q (want %s)", name, strings.Join(Providers, ", "))
+}
+
+// provider returns the provider passed to sherpa-onnx. sherpa-onnx falls back
+// to CPU (with a warning) when the provider is not available in the build
+func provider(name string) string {
+	if name == "" {
+		return ProviderCPU
+	}
+	return name
+}
@@ -0,0 +1,29 @@
+package asr
+
+import "testing"
+
+// TestParseProvider tests normalization and rejection of execution provider names
+func TestParseProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"", ProviderCPU, false},
+		{"cpu", ProviderCPU, false},
+		{" CUDA ", ProviderCUDA, false},
+		{"CoreML", ProviderCoreML, false},
+		{"directml", ProviderDirectML, false},
+		{"tensorrt", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseProvider(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseProvider(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseProvider(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
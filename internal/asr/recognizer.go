@@ -1,6 +1,7 @@
 package asr
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
@@ -34,11 +35,33 @@ func NewRecognizer(config *Config) (*Recognizer, error) {
 				Joiner:  config.JoinerPath,
 			},
 			Tokens:     config.TokensPath,
-			NumThreads: config.NumThreads,
+			NumThreads: numThreads(config.NumThreads),
+			Provider:   provider(config.Provider),
 			Debug:      0,
 		},
 		DecodingMethod: config.DecodingMethod,
 		MaxActivePaths: config.MaxActivePaths,
+		BlankPenalty:   config.BlankPenalty,
+	}
+
+	// Hotwords are only applied by modified_beam_search; the file is read
+	// when the recognizer is created
+	if len(config.Hotwords) > 0 {
+		hotwordsFile, err := writeHotwords(config.Hotwords)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(hotwordsFile)
+		sherpaConfig.DecodingMethod = "modified_beam_search"
+		sherpaConfig.HotwordsFile = hotwordsFile
+		sherpaConfig.HotwordsScore = config.HotwordsScore
+		if sherpaConfig.HotwordsScore <= 0 {
+			sherpaConfig.HotwordsScore = DefaultHotwordsScore
+		}
+		sherpaConfig.ModelConfig.ModelingUnit = "cjkchar"
+	}
+	if sherpaConfig.DecodingMethod == "modified_beam_search" && sherpaConfig.MaxActivePaths <= 0 {
+		sherpaConfig.MaxActivePaths = 4
 	}
 
 	// Create recognizer
@@ -53,8 +76,27 @@ func NewRecognizer(config *Config) (*Recognizer, error) {
 	}, nil
 }
 
+// DefaultHotwordsScore is the bonus of hotword tokens when Config.HotwordsScore is not set
+const DefaultHotwordsScore = 1.5
+
+// writeHotwords writes hotwords (one per line) to a temporary file for sherpa-onnx
+func writeHotwords(hotwords []string) (string, error) {
+	f, err := os.CreateTemp("", "zbor-hotwords-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create hotwords file: %w", err)
+	}
+	defer f.Close()
+	for _, word := range hotwords {
+		if _, err := fmt.Fprintln(f, word); err != nil {
+			os.Remove(f.Name())
+			return "", fmt.Errorf("failed to write hotwords file: %w", err)
+		}
+	}
+	return f.Name(), nil
+}
+
 // TranscribeFile transcribes audio from a WAV file
-func (r *Recognizer) TranscribeFile(audioPath string) (*Result, error) {
+func (r *Recognizer) TranscribeFile(ctx context.Context, audioPath string) (*Result, error) {
 	startTime := time.Now()
 
 	// Read audio file
@@ -62,6 +104,10 @@ func (r *Recognizer) TranscribeFile(audioPath string) (*Result, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read audio file: %w", err)
 	}
+	// The file is decoded in one call, so ctx can only stop it before decoding
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// Create stream
 	stream := sherpa.NewOfflineStream(r.recognizer)
@@ -174,6 +220,8 @@ func extractTokens(result *sherpa.OfflineRecognizerResult) []Token {
 			duration = result.Durations[i]
 		}
 
+		// The offline Go binding (OfflineRecognizerResult) has no per-token
+		// probabilities, so Confidence stays 0 (unknown); see Token
 		tokens = append(tokens, Token{
 			Text:      text,
 			StartTime: startTime,
@@ -184,60 +232,6 @@ func extractTokens(result *sherpa.OfflineRecognizerResult) []Token {
 	return tokens
 }
 
-// tokensToSegments groups tokens into segments for SRT output
-// Groups tokens with gaps > 0.5s into separate segments
-func tokensToSegments(tokens []Token) []Segment {
-	if len(tokens) == 0 {
-		return nil
-	}
-
-	const gapThreshold = 0.5 // seconds
-
-	var segments []Segment
-	var currentText string
-	var segmentStart float64
-	var lastEnd float32
-
-	for i, token := range tokens {
-		tokenEnd := token.StartTime + token.Duration
-
-		if i == 0 {
-			segmentStart = float64(token.StartTime)
-			currentText = token.Text
-			lastEnd = tokenEnd
-			continue
-		}
-
-		// Check if there's a significant gap
-		gap := token.StartTime - lastEnd
-		if gap > gapThreshold {
-			// Save current segment
-			segments = append(segments, Segment{
-				Text:      currentText,
-				StartTime: segmentStart,
-				EndTime:   float64(lastEnd),
-			})
-			// Start new segment
-			segmentStart = float64(token.StartTime)
-			currentText = token.Text
-		} else {
-			currentText += token.Text
-		}
-		lastEnd = tokenEnd
-	}
-
-	// Add final segment
-	if currentText != "" {
-		segments = append(segments, Segment{
-			Text:      currentText,
-			StartTime: segmentStart,
-			EndTime:   float64(lastEnd),
-		})
-	}
-
-	return segments
-}
-
 // Close releases resources used by the recognizer
 func (r *Recognizer) Close() error {
 	if r.recognizer != nil {
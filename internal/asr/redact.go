@@ -0,0 +1,162 @@
+package asr
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Redaction kinds
+const (
+	RedactionPhone   = "phone"
+	RedactionEmail   = "email"
+	RedactionKeyword = "keyword"
+)
+
+// RedactionMask replaces each redacted span
+const RedactionMask = "***"
+
+// Redaction marks a masked span of a segment's text. Start and End are
+// character (rune) offsets into the redacted text, covering the mask
+type Redaction struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Kind  string `json:"kind"` // phone, email, keyword
+}
+
+// Phone numbers: international (+81 90 1234 5678), hyphenated domestic
+// (03-1234-5678, 090-1234-5678) and 10-11 digit domestic numbers (09012345678)
+var phonePattern = regexp.MustCompile(`\+\d{1,3}[ -]?\d{1,4}[ -]?\d{1,4}[ -]?\d{3,4}\b|\b0\d{1,4}-\d{1,4}-\d{3,4}\b|\b0\d{9,10}\b`)
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+
+// Redactor masks phone numbers, email addresses and configured keywords
+// (profanity, names, ...) in transcripts that are shared outside. It works on
+// a copy, so the stored transcript keeps the original text
+type Redactor struct {
+	keywords *regexp.Regexp // nil: no keywords
+}
+
+// NewRedactor creates a redactor. Keywords match case-insensitively as written;
+// blank keywords are ignored
+func NewRedactor(keywords []string) *Redactor {
+	var quoted []string
+	for _, k := range keywords {
+		if k = strings.TrimSpace(k); k != "" {
+			quoted = append(quoted, regexp.QuoteMeta(k))
+		}
+	}
+	r := &Redactor{}
+	if len(quoted) > 0 {
+		// Longer keywords first so a keyword containing another is masked whole
+		sort.SliceStable(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+		r.keywords = regexp.MustCompile(`(?i)(?:` + strings.Join(quoted, "|") + `)`)
+	}
+	return r
+}
+
+// redactMatch is a span of text[start:end] (byte offsets) to mask
+type redactMatch struct {
+	start, end int
+	kind       string
+}
+
+// find returns the non-overlapping spans to mask in order. Where spans of
+// different kinds overlap the earlier (then longer) one wins
+func (r *Redactor) find(s string) []redactMatch {
+	var matches []redactMatch
+	add := func(re *regexp.Regexp, kind string) {
+		for _, m := range re.FindAllStringIndex(s, -1) {
+			matches = append(matches, redactMatch{start: m[0], end: m[1], kind: kind})
+		}
+	}
+	add(emailPattern, RedactionEmail)
+	add(phonePattern, RedactionPhone)
+	if r.keywords != nil {
+		add(r.keywords, RedactionKeyword)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].start != matches[j].start {
+			return matches[i].start < matches[j].start
+		}
+		return matches[i].end > matches[j].end
+	})
+
+	spans := matches[:0]
+	end := 0
+	for _, m := range matches {
+		if m.start < end {
+			continue
+		}
+		spans = append(spans, m)
+		end = m.end
+	}
+	return spans
+}
+
+// replacements returns the masks of the spans found in s
+func (r *Redactor) replacements(s string) []itnReplacement {
+	spans := r.find(s)
+	reps := make([]itnReplacement, len(spans))
+	for i, m := range spans {
+		reps[i] = itnReplacement{start: m.start, end: m.end, text: RedactionMask}
+	}
+	return reps
+}
+
+// RedactText masks a plain string
+func (r *Redactor) RedactText(s string) string {
+	return applyReplacements(s, r.replacements(s))
+}
+
+// redactSegment masks a segment's text and marks the masked spans
+func (r *Redactor) redactSegment(seg *Segment) {
+	spans := r.find(seg.Text)
+	if len(spans) == 0 {
+		return
+	}
+	var b strings.Builder
+	pos := 0
+	for _, m := range spans {
+		b.WriteString(seg.Text[pos:m.start])
+		start := utf8.RuneCountInString(b.String())
+		b.WriteString(RedactionMask)
+		seg.Redactions = append(seg.Redactions, Redaction{
+			Start: start,
+			End:   start + utf8.RuneCountInString(RedactionMask),
+			Kind:  m.kind,
+		})
+		pos = m.end
+	}
+	b.WriteString(seg.Text[pos:])
+	seg.Text = b.String()
+}
+
+// Redact returns a copy of the result with the text, segments and tokens
+// masked. Masked segments list their spans in Redactions; tokens covering a
+// mask are merged so timestamps are kept. Words are regrouped from the masked
+// tokens and n-best hypotheses (unmasked alternatives) are dropped
+func (r *Redactor) Redact(result *Result) *Result {
+	out := *result
+	out.Redacted = true
+	out.Text = r.RedactText(result.Text)
+	out.NBest = nil
+
+	if result.Segments != nil {
+		out.Segments = make([]Segment, len(result.Segments))
+		for i, seg := range result.Segments {
+			seg.Redactions = nil
+			r.redactSegment(&seg)
+			out.Segments[i] = seg
+		}
+	}
+	if result.Tokens != nil {
+		tokens := append([]Token(nil), result.Tokens...)
+		out.Tokens = rewriteTokens(tokens, r.replacements)
+	}
+	if len(result.Words) > 0 {
+		out.GroupWords(DefaultWordGroupingConfig())
+	}
+	return &out
+}
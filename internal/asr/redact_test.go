@@ -0,0 +1,80 @@
+package asr
+
+import "testing"
+
+// TestRedactText tests the phone, email and keyword patterns
+func TestRedactText(t *testing.T) {
+	r := NewRedactor([]string{"ばか", " ", "Secret Project"})
+	tests := []struct {
+		in, want string
+	}{
+		{"電話は090-1234-5678です", "電話は***です"},
+		{"03-1234-5678まで", "***まで"},
+		{"番号は09012345678", "番号は***"},
+		{"+81 90 1234 5678 へ", "*** へ"},
+		{"mail: foo.bar+zbor@example.co.jp まで", "mail: *** まで"},
+		{"ばかなことを言うな", "***なことを言うな"},
+		{"the secret project launch", "the *** launch"},
+		// Years, prices and times stay
+		{"2024年に1500円、10時30分", "2024年に1500円、10時30分"},
+	}
+	for _, tt := range tests {
+		if got := r.RedactText(tt.in); got != tt.want {
+			t.Errorf("RedactText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestRedact tests that the redacted copy marks segment spans, keeps token
+// timestamps and leaves the original untouched
+func TestRedact(t *testing.T) {
+	tokens := charTokens("連絡は0312345678へ", 0, 0.2)
+	original := &Result{
+		Text:   "連絡は0312345678へ",
+		Tokens: tokens,
+		Segments: []Segment{
+			{Text: "連絡は0312345678へ", StartTime: 0, EndTime: 3.2},
+		},
+		NBest: []NBest{{Hypotheses: []string{"連絡は0312345678へ"}}},
+	}
+	got := NewRedactor(nil).Redact(original)
+
+	if !got.Redacted || got.Text != "連絡は***へ" || got.NBest != nil {
+		t.Fatalf("redacted = %+v", got)
+	}
+	seg := got.Segments[0]
+	if seg.Text != "連絡は***へ" || len(seg.Redactions) != 1 {
+		t.Fatalf("segment = %+v", seg)
+	}
+	if r := seg.Redactions[0]; r.Start != 3 || r.End != 6 || r.Kind != RedactionPhone {
+		t.Errorf("redaction = %+v", r)
+	}
+	if len(got.Tokens) != 5 || got.Tokens[3].Text != RedactionMask {
+		t.Fatalf("tokens = %+v", got.Tokens)
+	}
+	if mask := got.Tokens[3]; !approx(float64(mask.StartTime), 0.6) || !approx(float64(mask.Duration), 2.0) {
+		t.Errorf("mask token = %+v, want start 0.6 duration 2.0", mask)
+	}
+
+	if original.Text != "連絡は0312345678へ" || original.Segments[0].Redactions != nil || len(original.Tokens) != len(tokens) {
+		t.Errorf("original was modified: %+v", original)
+	}
+}
+
+// TestRedactTokenWithSeveralMatches tests that every match inside one token is masked
+func TestRedactTokenWithSeveralMatches(t *testing.T) {
+	text := "電話は090-1234-5678、メールはa@example.comです"
+	got := NewRedactor(nil).Redact(&Result{
+		Text:   text,
+		Tokens: []Token{{Text: "先に", StartTime: 0, Duration: 0.5}, {Text: text, StartTime: 0.5, Duration: 3}},
+	})
+	want := []Token{{Text: "先に", StartTime: 0, Duration: 0.5}, {Text: "電話は***、メールは***です", StartTime: 0.5, Duration: 3}}
+	if len(got.Tokens) != len(want) {
+		t.Fatalf("tokens = %+v, want %+v", got.Tokens, want)
+	}
+	for i := range want {
+		if got.Tokens[i] != want[i] {
+			t.Errorf("token %d = %+v, want %+v", i, got.Tokens[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package asr
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Reasons a span is re-decoded by the second pass. Confidence is not a
+// reason: the decoders do not report token probabilities (see Token.Confidence)
+const (
+	RefineReasonCharRate = "char_rate" // characters per second outside MinCharRate-MaxCharRate
+)
+
+// RefineOptions selects the segments a second pass re-decodes with another
+// model and guards against accepting a failed decode
+type RefineOptions struct {
+	MinCharRate float64 // characters per second below this suggest dropped speech
+	MaxCharRate float64 // above this suggest a garbled decode; re-decoded text above it is rejected
+	MinRateSec  float64 // segments shorter than this are not checked for their character rate
+	MaxGapSec   float64 // adjacent flagged segments at most this far apart are re-decoded together
+	MaxSpanSec  float64 // maximum length of a re-decoded span (Whisper's window)
+}
+
+// DefaultRefineOptions returns the default second-pass options for Japanese speech
+func DefaultRefineOptions() RefineOptions {
+	return RefineOptions{
+		MinCharRate: 2,
+		MaxCharRate: 15,
+		MinRateSec:  1.5,
+		MaxGapSec:   1,
+		MaxSpanSec:  30,
+	}
+}
+
+// RefineSpan is a range of consecutive segments re-decoded by the second pass
+type RefineSpan struct {
+	StartSegment int     `json:"start_segment"`
+	EndSegment   int     `json:"end_segment"` // inclusive
+	StartTime    float64 `json:"start_time"`
+	EndTime      float64 `json:"end_time"`
+	Reason       string  `json:"reason"` // RefineReason* of the first flagged segment
+	OriginalText string  `json:"original_text"`
+	Text         string  `json:"text"`     // re-decoded text (set by the caller)
+	Accepted     bool    `json:"accepted"` // whether Text replaced the span (see ApplyRefinements)
+}
+
+// FindRefineSpans returns the spans of segments whose character rate is
+// implausible, merging adjacent flagged segments of the same speaker up to
+// opts.MaxSpanSec
+func FindRefineSpans(r *Result, opts RefineOptions) []RefineSpan {
+	var spans []RefineSpan
+	for idx, seg := range r.Segments {
+		reason := refineReason(seg, opts)
+		if reason == "" {
+			continue
+		}
+
+		if n := len(spans); n > 0 {
+			last := &spans[n-1]
+			prev := r.Segments[last.EndSegment]
+			if last.EndSegment == idx-1 && seg.StartTime-prev.EndTime <= opts.MaxGapSec &&
+				seg.EndTime-last.StartTime <= opts.MaxSpanSec && seg.Speaker == prev.Speaker {
+				last.EndSegment = idx
+				last.EndTime = seg.EndTime
+				last.OriginalText += seg.Text
+				continue
+			}
+		}
+
+		spans = append(spans, RefineSpan{
+			StartSegment: idx,
+			EndSegment:   idx,
+			StartTime:    seg.StartTime,
+			EndTime:      seg.EndTime,
+			Reason:       reason,
+			OriginalText: seg.Text,
+		})
+	}
+	return spans
+}
+
+func refineReason(seg Segment, opts RefineOptions) string {
+	duration := seg.EndTime - seg.StartTime
+	if duration < opts.MinRateSec {
+		return ""
+	}
+	rate := float64(countChars(seg.Text)) / duration
+	if rate < opts.MinCharRate || rate > opts.MaxCharRate {
+		return RefineReasonCharRate
+	}
+	return ""
+}
+
+// countChars counts the characters of text excluding spaces
+func countChars(text string) int {
+	n := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// ApplyRefinements replaces the segments of each span with its re-decoded
+// Text, aligned on the span's original token timestamps (as whisper:align
+// retranscription does), sets Accepted and returns the number of accepted
+// spans. Texts that are empty, unchanged, denser than opts.MaxCharRate or
+// that cannot be aligned are rejected. Text, segment confidence and words are
+// rebuilt if any span was accepted
+func (r *Result) ApplyRefinements(spans []RefineSpan, opts RefineOptions) int {
+	accepted := 0
+	// From the end so that earlier span indices stay valid
+	for idx := len(spans) - 1; idx >= 0; idx-- {
+		span := &spans[idx]
+		span.Accepted = false
+		text := strings.TrimSpace(span.Text)
+		if text == "" || text == span.OriginalText || span.EndSegment >= len(r.Segments) {
+			continue
+		}
+		if duration := span.EndTime - span.StartTime; duration > 0 && float64(countChars(text))/duration > opts.MaxCharRate {
+			continue
+		}
+
+		aligned := AlignTokensForSegmentsWithDiff(r.Tokens, text, r.Segments, span.StartSegment, span.EndSegment)
+		if len(aligned.Tokens) == 0 {
+			continue
+		}
+		for i := range aligned.Segments {
+			aligned.Segments[i].Speaker = r.Segments[span.StartSegment+i].Speaker
+		}
+		r.Tokens = MergeTokens(r.Tokens, aligned.Tokens, span.StartTime, span.EndTime)
+		segments := make([]Segment, 0, len(r.Segments))
+		segments = append(segments, r.Segments[:span.StartSegment]...)
+		segments = append(segments, aligned.Segments...)
+		segments = append(segments, r.Segments[span.EndSegment+1:]...)
+		r.Segments = segments
+
+		span.Accepted = true
+		accepted++
+	}
+
+	if accepted > 0 {
+		AnnotateSegmentConfidence(r.Segments, r.Tokens)
+		r.Text = RebuildTextFromTokens(r.Tokens)
+		if len(r.Words) > 0 {
+			r.GroupWords(DefaultWordGroupingConfig())
+		}
+	}
+	return accepted
+}
@@ -0,0 +1,83 @@
+package asr
+
+import (
+	"strings"
+	"testing"
+)
+
+func refineTestResult() *Result {
+	return &Result{
+		Text: "おはようございますこんにちわ世界です",
+		Tokens: []Token{
+			{Text: "おはよう", StartTime: 0.0},
+			{Text: "ございます", StartTime: 1.0},
+			{Text: "こんにちわ", StartTime: 2.1},
+			{Text: "世界", StartTime: 5.2},
+			{Text: "です", StartTime: 8.6},
+		},
+		Segments: []Segment{
+			{Text: "おはようございます", StartTime: 0, EndTime: 2},
+			{Text: "こんにちわ", StartTime: 2, EndTime: 5},
+			{Text: "世界", StartTime: 5.1, EndTime: 7},
+			{Text: "です", StartTime: 8.5, EndTime: 16},
+		},
+	}
+}
+
+// TestFindRefineSpans tests that segments with too few characters per second
+// are flagged and that adjacent flagged segments are merged into one span
+func TestFindRefineSpans(t *testing.T) {
+	spans := FindRefineSpans(refineTestResult(), DefaultRefineOptions())
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2: %+v", len(spans), spans)
+	}
+	first := spans[0]
+	if first.StartSegment != 1 || first.EndSegment != 2 || first.Reason != RefineReasonCharRate {
+		t.Errorf("first span = %+v, want segments 1-2 for the character rate", first)
+	}
+	if first.OriginalText != "こんにちわ世界" || first.StartTime != 2 || first.EndTime != 7 {
+		t.Errorf("first span = %+v", first)
+	}
+	if spans[1].StartSegment != 3 || spans[1].Reason != RefineReasonCharRate {
+		t.Errorf("second span = %+v, want segment 3 for the character rate", spans[1])
+	}
+}
+
+// TestApplyRefinements tests that re-decoded text replaces its span and that
+// empty or implausibly dense text is rejected
+func TestApplyRefinements(t *testing.T) {
+	result := refineTestResult()
+	opts := DefaultRefineOptions()
+	spans := FindRefineSpans(result, opts)
+	spans[0].Text = "こんにちは世界"
+	spans[1].Text = ""
+
+	if n := result.ApplyRefinements(spans, opts); n != 1 {
+		t.Fatalf("accepted %d spans, want 1", n)
+	}
+	if !spans[0].Accepted || spans[1].Accepted {
+		t.Errorf("accepted = %v, %v, want true, false", spans[0].Accepted, spans[1].Accepted)
+	}
+	if result.Text != "おはようございますこんにちは世界です" {
+		t.Errorf("Text = %q", result.Text)
+	}
+	if len(result.Segments) != 4 {
+		t.Fatalf("got %d segments, want 4", len(result.Segments))
+	}
+	if got := result.Segments[1].Text + result.Segments[2].Text; got != "こんにちは世界" {
+		t.Errorf("refined segments = %q", got)
+	}
+	if result.Segments[0].Text != "おはようございます" || result.Segments[3].Text != "です" {
+		t.Errorf("segments outside the span changed: %+v", result.Segments)
+	}
+
+	dense := refineTestResult()
+	spans = FindRefineSpans(dense, opts)
+	spans[0].Text = strings.Repeat("あいうえお", 16)
+	if n := dense.ApplyRefinements(spans, opts); n != 0 {
+		t.Errorf("accepted %d spans of implausibly dense text, want 0", n)
+	}
+	if dense.Text != refineTestResult().Text {
+		t.Errorf("rejected refinement changed the text: %q", dense.Text)
+	}
+}
@@ -0,0 +1,171 @@
+package asr
+
+import (
+	"math"
+	"sync"
+)
+
+// Parameters of the resampling filter: a sinc low-pass windowed by a Kaiser
+// window, as in soxr and libsamplerate
+const (
+	resampleZeros   = 32  // zero crossings of the sinc on each side
+	resampleBeta    = 8.0 // Kaiser window shape (about 80dB stopband attenuation)
+	resampleCutoff  = 0.9 // passband edge as a fraction of the lower Nyquist frequency
+	resampleDensity = 512 // kernel table entries per zero crossing
+)
+
+// resampleKernel is the windowed sinc from 0 to resampleZeros, sampled at
+// resampleDensity points per zero crossing (interpolated linearly on lookup)
+var resampleKernel = sync.OnceValue(func() []float32 {
+	n := resampleZeros * resampleDensity
+	table := make([]float32, n+2) // the last entries are 0 for interpolation
+	norm := besselI0(resampleBeta)
+	for i := 0; i <= n; i++ {
+		x := float64(i) / resampleDensity
+		sinc := 1.0
+		if x > 0 {
+			sinc = math.Sin(math.Pi*x) / (math.Pi * x)
+		}
+		r := x / resampleZeros
+		table[i] = float32(sinc * besselI0(resampleBeta*math.Sqrt(max(1-r*r, 0))) / norm)
+	}
+	return table
+})
+
+// besselI0 returns the modified Bessel function of the first kind of order 0
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > sum*1e-12; k++ {
+		term *= (x / 2 / float64(k)) * (x / 2 / float64(k))
+		sum += term
+	}
+	return sum
+}
+
+// resampler converts a stream of samples to another sample rate with a
+// windowed sinc filter, which also removes the frequencies above the new
+// Nyquist frequency when downsampling (44.1k/48k to 16k, 16k to 8k).
+// Blocks of any size can be passed to process; flush returns the samples
+// at the end of the stream. The input is silent before its first sample
+// and after its last, and len(input)*to/from samples are returned in total
+type resampler struct {
+	from, to int64
+	filter   *resampleFilter
+	next     int64 // index of the next output sample
+	base     int64 // input index of buf[0]
+	total    int64 // input samples received
+	buf      []float32
+	scratch  []float32 // taps computed for one output sample (filters without a table)
+}
+
+// resampleFilter holds the taps of a conversion, shared by the resamplers of
+// all streams with the same rates
+type resampleFilter struct {
+	scale  float64     // cutoff relative to the input Nyquist frequency
+	width  int64       // input samples on each side of an output sample
+	phases [][]float32 // taps for each fraction of an input sample (nil: computed per sample)
+}
+
+// resampleMaxPhases limits the size of the tables: rates with a larger
+// reduced ratio (44100:44101) compute the taps for each output sample
+const resampleMaxPhases = 1024
+
+var resampleFilters sync.Map // [2]int{from, to} -> *resampleFilter
+
+func newResampler(from, to int) *resampler {
+	key := [2]int{from, to}
+	f, ok := resampleFilters.Load(key)
+	if !ok {
+		f, _ = resampleFilters.LoadOrStore(key, newResampleFilter(from, to))
+	}
+	return &resampler{from: int64(from), to: int64(to), filter: f.(*resampleFilter)}
+}
+
+func newResampleFilter(from, to int) *resampleFilter {
+	scale := resampleCutoff * min(1, float64(to)/float64(from))
+	f := &resampleFilter{scale: scale, width: int64(math.Ceil(resampleZeros / scale))}
+	if phases := int64(to) / gcd(int64(from), int64(to)); phases <= resampleMaxPhases {
+		f.phases = make([][]float32, phases)
+		for p := range f.phases {
+			f.phases[p] = f.taps(float64(p)/float64(phases), nil)
+		}
+	}
+	return f
+}
+
+// taps returns the weights of the 2*width input samples around an output
+// sample frac samples after input sample width-1
+func (f *resampleFilter) taps(frac float64, buf []float32) []float32 {
+	kernel := resampleKernel()
+	buf = buf[:0]
+	for t := range 2 * f.width {
+		x := math.Abs(float64(f.width-1-t)+frac) * f.scale * resampleDensity
+		w := 0.0
+		if j := int(x); j < resampleZeros*resampleDensity {
+			w = float64(kernel[j]) + float64(kernel[j+1]-kernel[j])*(x-float64(j))
+		}
+		buf = append(buf, float32(w*f.scale))
+	}
+	return buf
+}
+
+// center returns the input sample at or before output sample n and the
+// position after it in units of 1/to, computed exactly so that rounding
+// errors do not add up over a long stream
+func (r *resampler) center(n int64) (int64, int64) {
+	pos := n * r.from
+	return pos / r.to, pos % r.to
+}
+
+// process returns the output samples that can be computed from the input so far
+func (r *resampler) process(in []float32) []float32 {
+	r.buf = append(r.buf, in...)
+	r.total += int64(len(in))
+	var out []float32
+	for {
+		i, rem := r.center(r.next)
+		if i+r.filter.width >= r.total {
+			break
+		}
+		out = append(out, r.sample(i, rem))
+		r.next++
+	}
+	// Keep the samples the next outputs are computed from
+	i, _ := r.center(r.next)
+	if drop := min(i-r.filter.width+1-r.base, int64(len(r.buf))); drop > 0 {
+		r.buf = append(r.buf[:0], r.buf[drop:]...)
+		r.base += drop
+	}
+	return out
+}
+
+// flush returns the output samples that need the input after its last sample
+func (r *resampler) flush() []float32 {
+	var out []float32
+	for r.next*r.from < r.total*r.to {
+		i, rem := r.center(r.next)
+		out = append(out, r.sample(i, rem))
+		r.next++
+	}
+	return out
+}
+
+// sample returns the output sample rem/to samples after input sample i
+func (r *resampler) sample(i, rem int64) float32 {
+	var taps []float32
+	if r.filter.phases != nil {
+		taps = r.filter.phases[rem*int64(len(r.filter.phases))/r.to]
+	} else {
+		r.scratch = r.filter.taps(float64(rem)/float64(r.to), r.scratch)
+		taps = r.scratch
+	}
+	// Input sample first+t is weighted by taps[t]; the input outside buf is silent
+	first := i - r.filter.width + 1 - r.base
+	lo := max(-first, 0)
+	hi := min(int64(len(taps)), int64(len(r.buf))-first)
+	var sum float32
+	for t := lo; t < hi; t++ {
+		sum += r.buf[first+t] * taps[t]
+	}
+	return sum
+}
@@ -0,0 +1,118 @@
+package asr
+
+import "strings"
+
+// DefaultLMScale is the default weight of the language model in rescoring
+const DefaultLMScale = 0.5
+
+// DefaultRescorePaths is the default number of hypotheses decoded per block
+// for rescoring
+const DefaultRescorePaths = 4
+
+// hypothesisShift is the step (in seconds) by which each extra hypothesis
+// delays the block. Shifts of 10ms move the audio across different feature
+// frame groupings, so the decoder sees slightly different inputs
+const hypothesisShift = 0.01
+
+// Rescorer picks among the hypotheses of an ambiguous block (hypotheses that
+// disagree) with an external language model. sherpa-onnx does not return its
+// n-best lists to Go, so the hypotheses come from decoding the block several
+// times with shifted input (see decodeShifted)
+type Rescorer struct {
+	LM    *NgramLM
+	Scale float64 // weight of the LM log10 probability against the disagreement with the other hypotheses (in characters)
+}
+
+// NBest records the hypotheses of an ambiguous block and the one that was kept
+type NBest struct {
+	StartTime  float64   `json:"start_time"` // in seconds
+	EndTime    float64   `json:"end_time"`   // in seconds
+	Hypotheses []string  `json:"hypotheses"`
+	LMScores   []float64 `json:"lm_scores,omitempty"` // log10 probabilities (when rescored)
+	Chosen     int       `json:"chosen"`              // index of the kept hypothesis
+}
+
+// decodeShifted decodes samples paths times, each delayed by a further
+// hypothesisShift of silence. decode gets the (padded) samples and the time
+// of their first sample; token start times before timeOffset are clamped
+func decodeShifted(samples []float32, sampleRate, paths int, timeOffset float32, decode func(samples []float32, timeOffset float32) []Token) [][]Token {
+	candidates := make([][]Token, 0, paths)
+	for k := 0; k < paths; k++ {
+		pad := int(float64(k) * hypothesisShift * float64(sampleRate))
+		shifted := samples
+		if pad > 0 {
+			shifted = make([]float32, pad+len(samples))
+			copy(shifted[pad:], samples)
+		}
+		// Timestamps of the shifted input are moved back by the padding
+		tokens := decode(shifted, timeOffset-float32(pad)/float32(sampleRate))
+		for i := range tokens {
+			tokens[i].StartTime = max(tokens[i].StartTime, timeOffset)
+		}
+		candidates = append(candidates, tokens)
+	}
+	return candidates
+}
+
+// chooseCandidate picks the tokens of one hypothesis. The hypothesis that
+// agrees most with the others is kept unless rescorer is set and the
+// hypotheses disagree, in which case the LM score is weighed in. An NBest is
+// returned for blocks whose hypotheses disagree (nil otherwise)
+func chooseCandidate(candidates [][]Token, rescorer *Rescorer, startTime, endTime float64) ([]Token, *NBest) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	hypotheses := make([]string, len(candidates))
+	for i, tokens := range candidates {
+		var text strings.Builder
+		for _, t := range tokens {
+			text.WriteString(t.Text)
+		}
+		hypotheses[i] = text.String()
+	}
+
+	ambiguous := false
+	for _, h := range hypotheses[1:] {
+		if h != hypotheses[0] {
+			ambiguous = true
+			break
+		}
+	}
+	if !ambiguous {
+		return candidates[0], nil
+	}
+
+	nbest := &NBest{StartTime: startTime, EndTime: endTime, Hypotheses: hypotheses}
+	if rescorer == nil || rescorer.LM == nil {
+		nbest.Chosen = voteHypotheses(hypotheses)
+	} else {
+		nbest.Chosen, nbest.LMScores = rescorer.rescore(hypotheses)
+	}
+	return candidates[nbest.Chosen], nbest
+}
+
+// rescore returns the index of the hypothesis with the best combined score
+// (Scale × LM log10 probability − mean edit distance to the other
+// hypotheses) and the LM scores
+func (r *Rescorer) rescore(hypotheses []string) (int, []float64) {
+	runes := make([][]rune, len(hypotheses))
+	for i, h := range hypotheses {
+		runes[i] = []rune(h)
+	}
+	scores := make([]float64, len(hypotheses))
+	best, bestScore := 0, 0.0
+	for i := range runes {
+		scores[i] = r.LM.Score(hypotheses[i])
+		var distance int
+		for j := range runes {
+			if i != j {
+				distance += editDistance(runes[i], runes[j])
+			}
+		}
+		score := r.Scale*scores[i] - float64(distance)/float64(len(runes)-1)
+		if i == 0 || score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best, scores
+}
@@ -0,0 +1,61 @@
+package asr
+
+// Resegment re-derives the result's segments from its tokens with the
+// segmentation options (see TokensToSegments), without re-running ASR. Speaker labels of merged
+// multi-speaker results are carried over: each token takes the speaker of the
+// original segment it falls in, and a speaker change always starts a new segment.
+// Results without tokens are left unchanged. Returns whether segments were rebuilt.
+func (r *Result) Resegment(opts SegmentationOptions) bool {
+	if len(r.Tokens) == 0 {
+		return false
+	}
+
+	hasSpeakers := false
+	for _, seg := range r.Segments {
+		if seg.Speaker != "" {
+			hasSpeakers = true
+			break
+		}
+	}
+	if !hasSpeakers {
+		r.Segments = TokensToSegments(r.Tokens, opts)
+		return true
+	}
+
+	var segments []Segment
+	runStart := 0
+	runSpeaker := segmentSpeakerAt(r.Segments, float64(r.Tokens[0].StartTime))
+	flush := func(end int) {
+		for _, seg := range TokensToSegments(r.Tokens[runStart:end], opts) {
+			seg.Speaker = runSpeaker
+			segments = append(segments, seg)
+		}
+	}
+	for i := 1; i < len(r.Tokens); i++ {
+		speaker := segmentSpeakerAt(r.Segments, float64(r.Tokens[i].StartTime))
+		if speaker != runSpeaker {
+			flush(i)
+			runStart, runSpeaker = i, speaker
+		}
+	}
+	flush(len(r.Tokens))
+
+	r.Segments = segments
+	return true
+}
+
+// segmentSpeakerAt returns the speaker of the segment containing the time,
+// or of the last segment starting before it when the time falls in a gap
+func segmentSpeakerAt(segments []Segment, t float64) string {
+	speaker := ""
+	for _, seg := range segments {
+		if seg.StartTime > t {
+			break
+		}
+		speaker = seg.Speaker
+		if t < seg.EndTime {
+			return speaker
+		}
+	}
+	return speaker
+}
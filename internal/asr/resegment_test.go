@@ -0,0 +1,61 @@
+package asr
+
+import "testing"
+
+// TestResegment tests that segments are rebuilt from token gaps
+func TestResegment(t *testing.T) {
+	result := &Result{
+		Tokens: []Token{
+			{Text: "こんにちは", StartTime: 0, Duration: 0.5},
+			{Text: "今日は", StartTime: 0.6, Duration: 0.4},
+			{Text: "晴れ", StartTime: 2.0, Duration: 0.3},
+		},
+		Segments: []Segment{{Text: "こんにちは今日は晴れ", StartTime: 0, EndTime: 2.3}},
+	}
+
+	if !result.Resegment(SegmentationOptions{}) {
+		t.Fatal("Resegment() = false, want true")
+	}
+	if len(result.Segments) != 2 {
+		t.Fatalf("got %d segments, want 2: %+v", len(result.Segments), result.Segments)
+	}
+	if result.Segments[0].Text != "こんにちは今日は" || result.Segments[1].Text != "晴れ" {
+		t.Errorf("segments = %+v", result.Segments)
+	}
+
+	empty := &Result{Text: "captions", Segments: []Segment{{Text: "captions", EndTime: 5}}}
+	if empty.Resegment(SegmentationOptions{}) || len(empty.Segments) != 1 {
+		t.Errorf("result without tokens was changed: %+v", empty.Segments)
+	}
+}
+
+// TestResegmentSpeakers tests that speaker labels are carried over and
+// speaker changes split segments even without a gap
+func TestResegmentSpeakers(t *testing.T) {
+	result := &Result{
+		Tokens: []Token{
+			{Text: "こんにちは", StartTime: 0, Duration: 0.5},
+			{Text: "よろしく", StartTime: 0.6, Duration: 0.5},
+			{Text: "はい", StartTime: 1.2, Duration: 0.3},
+		},
+		Segments: []Segment{
+			{Text: "こんにちは", StartTime: 0, EndTime: 0.5, Speaker: "田中"},
+			{Text: "よろしくはい", StartTime: 0.6, EndTime: 1.5, Speaker: "佐藤"},
+		},
+	}
+
+	result.Resegment(SegmentationOptions{})
+
+	if len(result.Segments) != 2 {
+		t.Fatalf("got %d segments, want 2: %+v", len(result.Segments), result.Segments)
+	}
+	want := []Segment{
+		{Text: "こんにちは", Speaker: "田中"},
+		{Text: "よろしくはい", Speaker: "佐藤"},
+	}
+	for i, w := range want {
+		if got := result.Segments[i]; got.Text != w.Text || got.Speaker != w.Speaker {
+			t.Errorf("segment %d = %+v, want %s: %s", i, got, w.Speaker, w.Text)
+		}
+	}
+}
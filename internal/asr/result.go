@@ -1,33 +1,74 @@
 package asr
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 )
 
-// Token represents a single word/subword with timestamp
+// Token represents a single word/subword with timestamp.
+// Confidence is carried through merges, alignment and ITN for decoders that
+// report it, but none of the bundled ones do: the sherpa-onnx Go binding
+// returns no per-token probabilities, so it stays 0 and is not shown in the
+// UI, exports or two-pass selection
 type Token struct {
-	Text      string  `json:"text"`
-	StartTime float32 `json:"start_time"` // in seconds
-	Duration  float32 `json:"duration"`   // in seconds
+	Text       string  `json:"text"`
+	StartTime  float32 `json:"start_time"`           // in seconds
+	Duration   float32 `json:"duration"`             // in seconds
+	Confidence float32 `json:"confidence,omitempty"` // 0-1, 0 when the decoder does not report it
 }
 
 // Segment represents a timestamped text segment in the transcription (legacy, for SRT)
 type Segment struct {
-	Text      string  `json:"text"`
-	StartTime float64 `json:"start_time"` // in seconds
-	EndTime   float64 `json:"end_time"`   // in seconds
+	Text       string      `json:"text"`
+	StartTime  float64     `json:"start_time"`           // in seconds
+	EndTime    float64     `json:"end_time"`             // in seconds
+	Confidence float64     `json:"confidence,omitempty"` // mean token confidence, 0 when unknown
+	Speaker    string      `json:"speaker,omitempty"`    // speaker label (merged multi-file results)
+	Redactions []Redaction `json:"redactions,omitempty"` // masked spans (redacted copies only, see Redactor)
+}
+
+// AnnotateSegmentConfidence sets each segment's Confidence to the mean confidence
+// of the tokens that start within it. Segments without scored tokens are left at 0.
+func AnnotateSegmentConfidence(segments []Segment, tokens []Token) {
+	for i := range segments {
+		seg := &segments[i]
+		var sum float64
+		var count int
+		for _, t := range tokens {
+			start := float64(t.StartTime)
+			if start < seg.StartTime || start >= seg.EndTime || t.Confidence <= 0 {
+				continue
+			}
+			sum += float64(t.Confidence)
+			count++
+		}
+		if count > 0 {
+			seg.Confidence = sum / float64(count)
+		} else {
+			seg.Confidence = 0
+		}
+	}
 }
 
 // Result represents the complete transcription result
 type Result struct {
-	Text          string    `json:"text"`                     // full transcription text
-	Tokens        []Token   `json:"tokens,omitempty"`         // word-level timestamps
-	Segments      []Segment `json:"segments,omitempty"`       // grouped segments (for SRT)
-	TotalDuration float32   `json:"total_duration,omitempty"` // audio duration in seconds
-	Duration      float64   `json:"duration"`                 // processing time in seconds
-	Speaker       string    `json:"speaker,omitempty"`        // speaker label (for multi-file)
+	Text          string               `json:"text"`                     // full transcription text
+	Tokens        []Token              `json:"tokens,omitempty"`         // word-level timestamps
+	Words         []WordTiming         `json:"words,omitempty"`          // tokens grouped into words (optional, see GroupWords)
+	Segments      []Segment            `json:"segments,omitempty"`       // grouped segments (for SRT)
+	TotalDuration float32              `json:"total_duration,omitempty"` // audio duration in seconds
+	Duration      float64              `json:"duration"`                 // processing time in seconds
+	Speaker       string               `json:"speaker,omitempty"`        // speaker label (for multi-file)
+	ChunkReports  []ChunkReport        `json:"chunk_reports,omitempty"`  // per-file chunk mode report
+	Metrics       *Metrics             `json:"metrics,omitempty"`        // throughput (see RecordMetrics)
+	NBest         []NBest              `json:"nbest,omitempty"`          // hypotheses of ambiguous chunks/blocks (beam approximation and LM rescoring)
+	Language      string               `json:"language,omitempty"`       // spoken language identified before ASR (ja, en, ...)
+	Params        *TranscriptionParams `json:"params,omitempty"`         // model and settings that produced the result
+	Redacted      bool                 `json:"redacted,omitempty"`       // PII and keywords are masked (see Redactor)
 }
 
 // FormatAsText returns the transcription as plain text
@@ -61,6 +102,72 @@ func (r *Result) FormatAsSRT() string {
 	return srt
 }
 
+// WordTiming represents a word or token with start/end times for word-level export
+type WordTiming struct {
+	Text      string  `json:"text"`
+	StartTime float64 `json:"start_time"` // in seconds
+	EndTime   float64 `json:"end_time"`   // in seconds
+	Duration  float64 `json:"duration"`   // in seconds
+}
+
+// TokenTimings returns token-level timings suitable for subtitle editors and alignment tools
+func (r *Result) TokenTimings() []WordTiming {
+	words := make([]WordTiming, 0, len(r.Tokens))
+	for _, t := range r.Tokens {
+		words = append(words, WordTiming{
+			Text:      t.Text,
+			StartTime: float64(t.StartTime),
+			EndTime:   float64(t.StartTime + t.Duration),
+			Duration:  float64(t.Duration),
+		})
+	}
+	return words
+}
+
+// wordTimings returns the grouped words when present, otherwise token-level timings
+func (r *Result) wordTimings() []WordTiming {
+	if len(r.Words) > 0 {
+		return r.Words
+	}
+	return r.TokenTimings()
+}
+
+// FormatAsWordsJSON returns word timings as formatted JSON
+// (grouped words when present, otherwise tokens)
+func (r *Result) FormatAsWordsJSON() (string, error) {
+	data, err := json.MarshalIndent(r.wordTimings(), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+// FormatAsWordsCSV returns word timings as CSV (text,start,end,duration)
+func (r *Result) FormatAsWordsCSV() (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write([]string{"text", "start", "end", "duration"}); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for _, word := range r.wordTimings() {
+		record := []string{
+			word.Text,
+			strconv.FormatFloat(word.StartTime, 'f', 3, 64),
+			strconv.FormatFloat(word.EndTime, 'f', 3, 64),
+			strconv.FormatFloat(word.Duration, 'f', 3, 64),
+		}
+		if err := w.Write(record); err != nil {
+			return "", fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.String(), nil
+}
+
 // formatSRTSegment formats a single SRT subtitle entry
 func formatSRTSegment(index int, startSec, endSec float64, text string) string {
 	return fmt.Sprintf("%d\n%s --> %s\n%s\n",
@@ -0,0 +1,39 @@
+package asr
+
+import (
+	"math"
+	"testing"
+)
+
+// TestAnnotateSegmentConfidence tests that segment confidence is the mean of
+// scored tokens inside the segment, and that unscored tokens are ignored
+func TestAnnotateSegmentConfidence(t *testing.T) {
+	tokens := []Token{
+		{Text: "a", StartTime: 0.0, Duration: 0.2, Confidence: 0.9},
+		{Text: "b", StartTime: 0.2, Duration: 0.2, Confidence: 0.5},
+		{Text: "c", StartTime: 1.5, Duration: 0.2},
+		{Text: "d", StartTime: 3.0, Duration: 0.2, Confidence: 0.4},
+	}
+	segments := []Segment{
+		{Text: "ab", StartTime: 0.0, EndTime: 0.4},
+		{Text: "c", StartTime: 1.5, EndTime: 1.7},
+		{Text: "d", StartTime: 3.0, EndTime: 3.2},
+	}
+
+	AnnotateSegmentConfidence(segments, tokens)
+
+	tests := []struct {
+		idx  int
+		want float64
+	}{
+		{0, 0.7},
+		{1, 0},
+		{2, 0.4},
+	}
+	for _, tt := range tests {
+		got := segments[tt.idx].Confidence
+		if math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("segment %d: confidence = %.3f, want %.3f", tt.idx, got, tt.want)
+		}
+	}
+}
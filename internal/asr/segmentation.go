@@ -0,0 +1,165 @@
+package asr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultSegmentGap is the silence between tokens (seconds) that starts a new segment
+const DefaultSegmentGap = 0.5
+
+// SegmentationOptions are the rules for grouping tokens into segments
+// (the cues of SRT/VTT output). Zero values keep the defaults: segments are
+// only split at gaps longer than DefaultSegmentGap
+type SegmentationOptions struct {
+	MinGap             float64 `json:"min_gap,omitempty"`              // seconds of silence that start a new segment
+	MaxChars           int     `json:"max_chars,omitempty"`            // characters per segment (0: no limit)
+	MaxDuration        float64 `json:"max_duration,omitempty"`         // seconds per segment (0: no limit)
+	SplitOnPunctuation bool    `json:"split_on_punctuation,omitempty"` // end segments at sentence-ending punctuation
+}
+
+// IsZero reports whether the options are the defaults
+func (o SegmentationOptions) IsZero() bool {
+	return o == SegmentationOptions{}
+}
+
+// Validate checks the options are within usable ranges
+func (o SegmentationOptions) Validate() error {
+	if o.MinGap < 0 || o.MinGap > 10 {
+		return fmt.Errorf("min_gap must be between 0 and 10 seconds")
+	}
+	if o.MaxChars < 0 || (o.MaxChars > 0 && o.MaxChars < 5) || o.MaxChars > 1000 {
+		return fmt.Errorf("max_chars must be between 5 and 1000")
+	}
+	if o.MaxDuration < 0 || (o.MaxDuration > 0 && o.MaxDuration < 1) || o.MaxDuration > 600 {
+		return fmt.Errorf("max_duration must be between 1 and 600 seconds")
+	}
+	return nil
+}
+
+// exceeds reports whether a segment of the tokens is over MaxChars or MaxDuration
+func (o SegmentationOptions) exceeds(tokens []Token) bool {
+	if o.MaxChars > 0 {
+		var sb strings.Builder
+		for _, t := range tokens {
+			sb.WriteString(t.Text)
+		}
+		if utf8.RuneCountInString(strings.TrimSpace(sb.String())) > o.MaxChars {
+			return true
+		}
+	}
+	if o.MaxDuration > 0 {
+		// 1ms of tolerance for the float32 token times
+		last := tokens[len(tokens)-1]
+		if float64(last.StartTime+last.Duration)-float64(tokens[0].StartTime) > o.MaxDuration+0.001 {
+			return true
+		}
+	}
+	return false
+}
+
+// tokensToSegments groups tokens into segments with the default options
+func tokensToSegments(tokens []Token) []Segment {
+	return TokensToSegments(tokens, SegmentationOptions{})
+}
+
+// TokensToSegments groups tokens into segments for SRT output.
+// A gap longer than MinGap and (with SplitOnPunctuation) sentence-ending
+// punctuation always end a segment. A segment that would go over MaxChars or
+// MaxDuration is split at the last punctuation or word boundary in it, or
+// before the token that does not fit. A single token is never split
+func TokensToSegments(tokens []Token, opts SegmentationOptions) []Segment {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	gapThreshold := float32(DefaultSegmentGap)
+	if opts.MinGap > 0 {
+		gapThreshold = float32(opts.MinGap)
+	}
+
+	var segments []Segment
+	start := 0
+	flush := func(end int) {
+		if end <= start {
+			return
+		}
+		if seg := joinTokens(tokens[start:end]); seg.Text != "" {
+			segments = append(segments, seg)
+		}
+		start = end
+	}
+
+	for i := 1; i < len(tokens); i++ {
+		prev := tokens[i-1]
+		// Check if there's a significant gap
+		if tokens[i].StartTime-(prev.StartTime+prev.Duration) > gapThreshold ||
+			(opts.SplitOnPunctuation && endsSentence(prev.Text)) {
+			flush(i)
+			continue
+		}
+		if opts.exceeds(tokens[start : i+1]) {
+			flush(softBreak(tokens, start, i))
+			// No break left room for the token: cut right before it
+			if start < i && opts.exceeds(tokens[start:i+1]) {
+				flush(i)
+			}
+		}
+	}
+	flush(len(tokens))
+
+	AnnotateSegmentConfidence(segments, tokens)
+	return segments
+}
+
+// joinTokens returns the segment spanning the tokens
+func joinTokens(tokens []Token) Segment {
+	var sb strings.Builder
+	for _, t := range tokens {
+		sb.WriteString(t.Text)
+	}
+	last := tokens[len(tokens)-1]
+	return Segment{
+		Text:      sb.String(),
+		StartTime: float64(tokens[0].StartTime),
+		EndTime:   float64(last.StartTime + last.Duration),
+	}
+}
+
+// softBreak returns where to split tokens[start:end] (start < result <= end):
+// after the last sentence-ending punctuation, else after the last clause
+// punctuation, else before the last token starting a word, else end
+func softBreak(tokens []Token, start, end int) int {
+	for _, isBreak := range []func(b int) bool{
+		func(b int) bool { return endsSentence(tokens[b-1].Text) },
+		func(b int) bool { return endsClause(tokens[b-1].Text) },
+		func(b int) bool { return tokenStartsWord(tokens[b].Text) },
+	} {
+		for b := end; b > start; b-- {
+			if isBreak(b) {
+				return b
+			}
+		}
+	}
+	return end
+}
+
+// endsSentence reports whether text ends with sentence-ending punctuation
+func endsSentence(text string) bool {
+	r, _ := utf8.DecodeLastRuneInString(strings.TrimSpace(text))
+	return strings.ContainsRune("。．.？?！!", r)
+}
+
+// endsClause reports whether text ends with clause punctuation
+func endsClause(text string) bool {
+	r, _ := utf8.DecodeLastRuneInString(strings.TrimSpace(text))
+	return strings.ContainsRune("、，,；;：:", r)
+}
+
+// tokenStartsWord reports whether a token starts a new word (space-separated languages)
+func tokenStartsWord(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return unicode.IsSpace(r) || strings.HasPrefix(text, wordBoundaryMarker)
+}
@@ -0,0 +1,107 @@
+package asr
+
+import (
+	"slices"
+	"testing"
+)
+
+// segmentTexts returns the text of each segment
+func segmentTexts(segments []Segment) []string {
+	texts := make([]string, len(segments))
+	for i, seg := range segments {
+		texts[i] = seg.Text
+	}
+	return texts
+}
+
+// TestTokensToSegmentsOptions tests the segmentation rules
+func TestTokensToSegmentsOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []Token
+		opts   SegmentationOptions
+		want   []string
+	}{
+		{
+			name:   "defaults keep a run without gaps together",
+			tokens: charTokens("今日は晴れ。明日は雨、風も強い。", 0, 0.2),
+			want:   []string{"今日は晴れ。明日は雨、風も強い。"},
+		},
+		{
+			name:   "split on punctuation",
+			tokens: charTokens("今日は晴れ。明日は雨、風も強い。", 0, 0.2),
+			opts:   SegmentationOptions{SplitOnPunctuation: true},
+			want:   []string{"今日は晴れ。", "明日は雨、風も強い。"},
+		},
+		{
+			name:   "max chars prefers punctuation",
+			tokens: charTokens("今日は晴れ。明日は雨、風も強い。", 0, 0.2),
+			opts:   SegmentationOptions{MaxChars: 8},
+			want:   []string{"今日は晴れ。", "明日は雨、", "風も強い。"},
+		},
+		{
+			name:   "max chars without punctuation cuts before the token",
+			tokens: charTokens("あいうえおかきくけこ", 0, 0.2),
+			opts:   SegmentationOptions{MaxChars: 6},
+			want:   []string{"あいうえおか", "きくけこ"},
+		},
+		{
+			name:   "max duration",
+			tokens: charTokens("あいうえおかきくけこ", 0, 0.2),
+			opts:   SegmentationOptions{MaxDuration: 1},
+			want:   []string{"あいうえお", "かきくけこ"},
+		},
+		{
+			name: "word boundaries",
+			tokens: []Token{
+				{Text: " Hello", StartTime: 0, Duration: 0.4},
+				{Text: " wor", StartTime: 0.4, Duration: 0.2},
+				{Text: "ld", StartTime: 0.6, Duration: 0.2},
+				{Text: " again", StartTime: 0.8, Duration: 0.4},
+			},
+			opts: SegmentationOptions{MaxChars: 12},
+			want: []string{" Hello world", " again"},
+		},
+		{
+			name: "min gap",
+			tokens: []Token{
+				{Text: "はい", StartTime: 0, Duration: 0.3},
+				{Text: "そうです", StartTime: 0.6, Duration: 0.5},
+			},
+			opts: SegmentationOptions{MinGap: 0.2},
+			want: []string{"はい", "そうです"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := segmentTexts(TokensToSegments(tt.tokens, tt.opts))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("segments = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSegmentationOptionsValidate tests the option ranges
+func TestSegmentationOptionsValidate(t *testing.T) {
+	valid := []SegmentationOptions{
+		{},
+		{MaxChars: 42, MaxDuration: 7, SplitOnPunctuation: true, MinGap: 0.3},
+	}
+	for _, opts := range valid {
+		if err := opts.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v, want nil", opts, err)
+		}
+	}
+	invalid := []SegmentationOptions{
+		{MaxChars: 2},
+		{MaxDuration: 0.5},
+		{MinGap: -1},
+	}
+	for _, opts := range invalid {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", opts)
+		}
+	}
+}
@@ -2,11 +2,12 @@ package asr
 
 import (
 	"bufio"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"strings"
 
 	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
@@ -17,10 +18,13 @@ type SenseVoiceConfig struct {
 	ModelDir       string
 	Language       string // zh, en, ja, ko, yue, auto
 	UseInt8        bool
-	NumThreads     int
+	NumThreads     int // 0: auto (DefaultNumThreads or ThreadTuner)
 	SampleRate     int
-	DecodingMethod string // greedy_search or modified_beam_search
-	MaxActivePaths int    // for beam search (default: 4)
+	DecodingMethod string    // greedy_search or modified_beam_search (approximated, see transcribeBytes)
+	MaxActivePaths int       // hypotheses per chunk for modified_beam_search (default: 4)
+	DisableITN     bool      // disable the model's built-in inverse text normalization
+	Provider       string    // execution provider: cpu (default), cuda, coreml, directml
+	Rescorer       *Rescorer // rescore ambiguous chunks of modified_beam_search with an LM (optional)
 }
 
 // DefaultSenseVoiceConfig returns default SenseVoice configuration
@@ -29,13 +33,35 @@ func DefaultSenseVoiceConfig(modelDir string) *SenseVoiceConfig {
 		ModelDir:       modelDir,
 		Language:       "ja",
 		UseInt8:        true,
-		NumThreads:     4,
+		NumThreads:     0, // auto
 		SampleRate:     16000,
 		DecodingMethod: "greedy_search",
 		MaxActivePaths: 4,
 	}
 }
 
+// Validate checks if the model files exist (without loading the model)
+func (c *SenseVoiceConfig) Validate() error {
+	modelPath, tokensPath := c.modelFiles()
+	if _, err := os.Stat(modelPath); os.IsNotExist(err) {
+		return fmt.Errorf("model file not found: %s", modelPath)
+	}
+	if _, err := os.Stat(tokensPath); os.IsNotExist(err) {
+		return fmt.Errorf("tokens file not found: %s", tokensPath)
+	}
+	_, err := ParseProvider(c.Provider)
+	return err
+}
+
+// modelFiles returns the model and tokens paths in the model directory
+func (c *SenseVoiceConfig) modelFiles() (modelPath, tokensPath string) {
+	modelFile := "model.onnx"
+	if c.UseInt8 {
+		modelFile = "model.int8.onnx"
+	}
+	return c.ModelDir + "/" + modelFile, c.ModelDir + "/tokens.txt"
+}
+
 // SenseVoiceRecognizer wraps SenseVoice model for speech recognition
 type SenseVoiceRecognizer struct {
 	recognizer *sherpa.OfflineRecognizer
@@ -48,31 +74,23 @@ func NewSenseVoiceRecognizer(config *SenseVoiceConfig) (*SenseVoiceRecognizer, e
 		return nil, fmt.Errorf("config is required")
 	}
 
-	modelFile := "model.onnx"
-	if config.UseInt8 {
-		modelFile = "model.int8.onnx"
-	}
-
-	modelPath := config.ModelDir + "/" + modelFile
-	if _, err := os.Stat(modelPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("model file not found: %s", modelPath)
-	}
-
-	tokensPath := config.ModelDir + "/tokens.txt"
-	if _, err := os.Stat(tokensPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("tokens file not found: %s", tokensPath)
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
+	modelPath, tokensPath := config.modelFiles()
 
-	// Set decoding method (default: greedy_search)
-	decodingMethod := config.DecodingMethod
-	if decodingMethod == "" {
-		decodingMethod = "greedy_search"
-	}
+	// sherpa-onnx only supports greedy_search for SenseVoice (other methods
+	// abort), so modified_beam_search is approximated in transcribeBytes
 	maxActivePaths := config.MaxActivePaths
 	if maxActivePaths <= 0 {
 		maxActivePaths = 4
 	}
 
+	useITN := 1
+	if config.DisableITN {
+		useITN = 0
+	}
+
 	sherpaConfig := sherpa.OfflineRecognizerConfig{
 		FeatConfig: sherpa.FeatureConfig{
 			SampleRate: config.SampleRate,
@@ -82,13 +100,14 @@ func NewSenseVoiceRecognizer(config *SenseVoiceConfig) (*SenseVoiceRecognizer, e
 			SenseVoice: sherpa.OfflineSenseVoiceModelConfig{
 				Model:                       modelPath,
 				Language:                    config.Language,
-				UseInverseTextNormalization: 1,
+				UseInverseTextNormalization: useITN,
 			},
 			Tokens:     tokensPath,
-			NumThreads: config.NumThreads,
+			NumThreads: numThreads(config.NumThreads),
+			Provider:   provider(config.Provider),
 			Debug:      0,
 		},
-		DecodingMethod: decodingMethod,
+		DecodingMethod: "greedy_search",
 		MaxActivePaths: maxActivePaths,
 	}
 
@@ -113,7 +132,7 @@ func (r *SenseVoiceRecognizer) Close() {
 
 // TranscribePartial transcribes a specific time range of an audio file
 // Returns tokens with timestamps adjusted to the original audio time
-func (r *SenseVoiceRecognizer) TranscribePartial(filePath string, opts PartialTranscribeOptions) (*Result, error) {
+func (r *SenseVoiceRecognizer) TranscribePartial(ctx context.Context, filePath string, opts PartialTranscribeOptions) (*Result, error) {
 	if opts.Tempo <= 0 {
 		opts.Tempo = 0.95
 	}
@@ -126,39 +145,20 @@ func (r *SenseVoiceRecognizer) TranscribePartial(filePath string, opts PartialTr
 		return nil, fmt.Errorf("invalid time range: %.2f - %.2f", opts.StartTime, opts.EndTime)
 	}
 
-	// Build ffmpeg command to extract and process the time range
-	args := []string{
-		"-ss", fmt.Sprintf("%.3f", opts.StartTime),
-		"-i", filePath,
-		"-t", fmt.Sprintf("%.3f", duration),
-	}
-
-	// Add tempo filter if not 1.0
-	if opts.Tempo != 1.0 {
-		args = append(args, "-af", fmt.Sprintf("atempo=%.2f", opts.Tempo))
-	}
-
-	args = append(args,
-		"-f", "s16le",
-		"-acodec", "pcm_s16le",
-		"-ar", fmt.Sprintf("%d", r.config.SampleRate),
-		"-ac", "1",
-		"-loglevel", "error",
-		"pipe:1",
-	)
-
-	cmd := exec.Command("ffmpeg", args...)
-	stdout, err := cmd.StdoutPipe()
+	// Decode the time range at the tempo
+	audio, err := openPCM(ctx, filePath, decodeOptions{
+		SampleRate: r.config.SampleRate,
+		Start:      opts.StartTime,
+		Duration:   duration,
+		Tempo:      opts.Tempo,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+		return nil, err
 	}
+	defer audio.Close()
 
 	// Process audio in chunks
-	reader := bufio.NewReader(stdout)
+	reader := bufio.NewReader(audio)
 	chunkSamples := r.config.SampleRate * opts.ChunkSec
 	chunkBytes := chunkSamples * 2
 
@@ -179,14 +179,15 @@ func (r *SenseVoiceRecognizer) TranscribePartial(filePath string, opts PartialTr
 		rawChunkOffset := float64(processedSamples) / float64(r.config.SampleRate)
 
 		// Transcribe chunk
-		tokens := r.transcribeBytes(samples, 0) // Use 0 offset, we'll adjust below
+		tokens, _ := r.transcribeBytes(samples, 0) // Use 0 offset, we'll adjust below
 
 		// Adjust token timestamps
 		for _, token := range tokens {
 			adjustedToken := Token{
-				Text:      token.Text,
-				StartTime: float32(opts.StartTime + (rawChunkOffset+float64(token.StartTime))*opts.Tempo),
-				Duration:  token.Duration * float32(opts.Tempo),
+				Text:       token.Text,
+				StartTime:  float32(opts.StartTime + (rawChunkOffset+float64(token.StartTime))*opts.Tempo),
+				Duration:   token.Duration * float32(opts.Tempo),
+				Confidence: token.Confidence,
 			}
 			allTokens = append(allTokens, adjustedToken)
 			allText.WriteString(token.Text)
@@ -199,7 +200,9 @@ func (r *SenseVoiceRecognizer) TranscribePartial(filePath string, opts PartialTr
 		}
 	}
 
-	cmd.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	return &Result{
 		Text:   allText.String(),
@@ -208,7 +211,7 @@ func (r *SenseVoiceRecognizer) TranscribePartial(filePath string, opts PartialTr
 }
 
 // TranscribeFile transcribes an audio file using SenseVoice
-func (r *SenseVoiceRecognizer) TranscribeFile(inputPath string, chunkSec int, onProgress ProgressCallback) (*Result, error) {
+func (r *SenseVoiceRecognizer) TranscribeFile(ctx context.Context, inputPath string, chunkSec int, onProgress ProgressCallback) (*Result, error) {
 	if chunkSec <= 0 {
 		chunkSec = 20
 	}
@@ -218,58 +221,33 @@ func (r *SenseVoiceRecognizer) TranscribeFile(inputPath string, chunkSec int, on
 	}
 
 	// Get duration for progress calculation
-	duration, _ := getAudioDuration(inputPath)
-
-	// Convert audio to raw PCM using ffmpeg
-	cmd := exec.Command("ffmpeg",
-		"-i", inputPath,
-		"-f", "s16le",
-		"-acodec", "pcm_s16le",
-		"-ar", fmt.Sprintf("%d", r.config.SampleRate),
-		"-ac", "1",
-		"-loglevel", "error",
-		"pipe:1",
-	)
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create pipe: %w", err)
-	}
-	cmd.Stderr = os.Stderr
+	duration, _ := getAudioDuration(ctx, inputPath)
 
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	// Decode the audio to raw PCM
+	stream, err := openPCM(ctx, inputPath, decodeOptions{SampleRate: r.config.SampleRate})
+	if err != nil {
+		return nil, err
 	}
 
-	reader := bufio.NewReader(stdout)
-
 	chunkSamples := r.config.SampleRate * chunkSec
-	chunkBytes := chunkSamples * 2
+	report := ChunkReport{File: filepath.Base(inputPath)}
 
 	var allTokens []Token
 	var allText strings.Builder
-	chunkNum := 0
-	var processedSamples int64
+	var nbests []NBest
 
 	if onProgress != nil {
 		onProgress(20, "transcribing")
 	}
 
-	for {
-		buffer := make([]byte, chunkBytes)
-		n, err := io.ReadFull(reader, buffer)
-		if n == 0 {
-			break
-		}
-
-		samples := bytesToFloat32SV(buffer[:n])
-		processedSamples += int64(len(samples))
-		chunkNum++
-
-		startSec := float32((chunkNum - 1) * chunkSec)
+	processed, readErr := readChunks(ctx, stream, r.config.SampleRate, chunkSamples, 0, 0, &report, func(samples []float32, offset int) error {
+		startSec := float32(offset) / float32(r.config.SampleRate)
 
 		// Transcribe chunk and get tokens with timestamps
-		tokens := r.transcribeBytes(samples, startSec)
+		tokens, nbest := r.transcribeBytes(samples, startSec)
+		if nbest != nil {
+			nbests = append(nbests, *nbest)
+		}
 		if len(tokens) > 0 {
 			allTokens = append(allTokens, tokens...)
 			for _, t := range tokens {
@@ -279,19 +257,22 @@ func (r *SenseVoiceRecognizer) TranscribeFile(inputPath string, chunkSec int, on
 
 		// Update progress
 		if onProgress != nil && duration > 0 {
-			progress := 20 + int(60*float64(processedSamples)/float64(r.config.SampleRate)/duration)
+			progress := 20 + int(60*float64(offset+len(samples))/float64(r.config.SampleRate)/duration)
 			if progress > 80 {
 				progress = 80
 			}
-			onProgress(progress, fmt.Sprintf("chunk %d", chunkNum))
-		}
-
-		if err == io.EOF || err == io.ErrUnexpectedEOF {
-			break
+			onProgress(progress, fmt.Sprintf("chunk %d", report.Chunks))
 		}
+		return nil
+	})
+	if readErr != nil {
+		stream.Close()
+		return nil, fmt.Errorf("failed to read audio: %w", readErr)
 	}
 
-	cmd.Wait()
+	if err := report.finish(stream.Close(), duration, float64(processed)/float64(r.config.SampleRate)); err != nil {
+		return nil, err
+	}
 
 	if onProgress != nil {
 		onProgress(90, "finalizing")
@@ -309,11 +290,41 @@ func (r *SenseVoiceRecognizer) TranscribeFile(inputPath string, chunkSec int, on
 		Tokens:        allTokens,
 		Segments:      tokensToSegments(allTokens),
 		TotalDuration: totalDuration,
+		ChunkReports:  []ChunkReport{report},
+		NBest:         nbests,
 	}, nil
 }
 
+// BeamPaths returns the number of hypotheses decoded per chunk
+// (1 unless DecodingMethod is modified_beam_search)
+func (r *SenseVoiceRecognizer) BeamPaths() int {
+	if r.config.DecodingMethod != "modified_beam_search" {
+		return 1
+	}
+	if r.config.MaxActivePaths <= 0 {
+		return 4
+	}
+	return r.config.MaxActivePaths
+}
+
 // transcribeBytes transcribes raw audio samples and returns tokens with timestamps
-func (r *SenseVoiceRecognizer) transcribeBytes(samples []float32, timeOffset float32) []Token {
+// With modified_beam_search it approximates a k-best search: the chunk is
+// decoded BeamPaths times (see decodeShifted) and the hypothesis that agrees
+// most with the others, or scores best with the Rescorer, is kept. The
+// hypotheses are returned when they disagree
+func (r *SenseVoiceRecognizer) transcribeBytes(samples []float32, timeOffset float32) ([]Token, *NBest) {
+	paths := r.BeamPaths()
+	if paths <= 1 || len(samples) == 0 {
+		return r.decode(samples, timeOffset), nil
+	}
+
+	candidates := decodeShifted(samples, r.config.SampleRate, paths, timeOffset, r.decode)
+	endTime := float64(timeOffset) + float64(len(samples))/float64(r.config.SampleRate)
+	return chooseCandidate(candidates, r.config.Rescorer, float64(timeOffset), endTime)
+}
+
+// decode runs the recognizer once over raw audio samples
+func (r *SenseVoiceRecognizer) decode(samples []float32, timeOffset float32) []Token {
 	if len(samples) == 0 {
 		return nil
 	}
@@ -376,19 +387,11 @@ func bytesToFloat32SV(data []byte) []float32 {
 	return samples
 }
 
-// getAudioDuration gets audio duration using ffprobe
-func getAudioDuration(path string) (float64, error) {
-	cmd := exec.Command("ffprobe",
-		"-v", "error",
-		"-show_entries", "format=duration",
-		"-of", "default=noprint_wrappers=1:nokey=1",
-		path,
-	)
-	output, err := cmd.Output()
+// getAudioDuration gets the audio duration for progress reporting
+func getAudioDuration(ctx context.Context, path string) (float64, error) {
+	info, err := probeAudio(ctx, path)
 	if err != nil {
 		return 0, err
 	}
-	var duration float64
-	fmt.Sscanf(string(output), "%f", &duration)
-	return duration, nil
+	return info.Duration, nil
 }
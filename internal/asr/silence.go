@@ -2,13 +2,14 @@ package asr
 
 import (
 	"bufio"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"math"
-	"os"
-	"os/exec"
 	"strings"
+
+	"zbor/internal/logging"
 )
 
 // SilenceConfig holds configuration for silence-based speech detection
@@ -27,6 +28,7 @@ type SilenceConfig struct {
 	MaxBlockDuration float64
 
 	// FrameSize is the number of samples per frame for RMS calculation
+	// (0: 30ms at the sample rate of the audio)
 	FrameSize int
 }
 
@@ -37,43 +39,28 @@ func DefaultSilenceConfig() *SilenceConfig {
 		MinSilenceDuration: 0.3,   // 300ms silence to split
 		MinSpeechDuration:  0.1,   // 100ms minimum speech
 		MaxBlockDuration:   5.0,   // 5 second max blocks
-		FrameSize:          480,   // 30ms at 16kHz
+		FrameSize:          0,     // 30ms (480 samples at 16kHz)
 	}
 }
 
 // detectSpeechBlocksBySilence detects speech blocks using energy-based silence detection
-func (r *Recognizer) detectSpeechBlocksBySilence(inputPath string, config *SilenceConfig) ([]SpeechBlock, error) {
+func (r *Recognizer) detectSpeechBlocksBySilence(pcm *pcmFile, config *SilenceConfig) ([]SpeechBlock, error) {
 	if config == nil {
 		config = DefaultSilenceConfig()
 	}
+	return detectSilenceBlocks(bufio.NewReader(pcm.Reader()), pcm.sampleRate, config)
+}
 
-	sampleRate := r.config.SampleRate
-
-	// Convert audio to raw PCM using ffmpeg
-	cmd := exec.Command("ffmpeg",
-		"-i", inputPath,
-		"-f", "s16le",
-		"-acodec", "pcm_s16le",
-		"-ar", fmt.Sprintf("%d", sampleRate),
-		"-ac", "1",
-		"-",
-	)
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create pipe: %w", err)
-	}
-	cmd.Stderr = nil // Suppress ffmpeg output
-
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
-	}
-
-	reader := bufio.NewReader(stdout)
-
+// detectSilenceBlocks reads 16-bit mono PCM at sampleRate from reader and
+// returns the speech blocks between silences (see SilenceConfig)
+func detectSilenceBlocks(reader io.Reader, sampleRate int, config *SilenceConfig) ([]SpeechBlock, error) {
 	// Read samples and calculate RMS for each frame
 	var frames []float64 // RMS values for each frame
-	frameSamples := make([]float32, 0, config.FrameSize)
+	frameSize := config.FrameSize
+	if frameSize <= 0 {
+		frameSize = max(sampleRate*30/1000, 1)
+	}
+	frameSamples := make([]float32, 0, frameSize)
 
 	buf := make([]byte, 2) // 16-bit samples
 	for {
@@ -82,7 +69,6 @@ func (r *Recognizer) detectSpeechBlocksBySilence(inputPath string, config *Silen
 			break
 		}
 		if err != nil {
-			cmd.Wait()
 			return nil, fmt.Errorf("failed to read audio: %w", err)
 		}
 
@@ -91,7 +77,7 @@ func (r *Recognizer) detectSpeechBlocksBySilence(inputPath string, config *Silen
 		frameSamples = append(frameSamples, sample)
 
 		// Calculate RMS when frame is complete
-		if len(frameSamples) >= config.FrameSize {
+		if len(frameSamples) >= frameSize {
 			rms := calculateRMS(frameSamples)
 			frames = append(frames, rms)
 			frameSamples = frameSamples[:0]
@@ -104,14 +90,12 @@ func (r *Recognizer) detectSpeechBlocksBySilence(inputPath string, config *Silen
 		frames = append(frames, rms)
 	}
 
-	cmd.Wait()
-
 	if len(frames) == 0 {
 		return nil, nil
 	}
 
 	// Convert frames to speech blocks
-	frameDuration := float64(config.FrameSize) / float64(sampleRate)
+	frameDuration := float64(frameSize) / float64(sampleRate)
 
 	minSilenceFrames := int(config.MinSilenceDuration / frameDuration)
 	minSpeechFrames := int(config.MinSpeechDuration / frameDuration)
@@ -252,7 +236,7 @@ func splitLongBlocksWithOverlap(blocks []SpeechBlock, maxDuration float64, overl
 
 // TranscribeWithSilenceDetection transcribes audio using energy-based silence detection
 // This is an alternative to VAD that detects any sound (not just voice)
-func (r *Recognizer) TranscribeWithSilenceDetection(inputPath string, config *SilenceConfig, tempo float64, onProgress ProgressCallback) (*Result, error) {
+func (r *Recognizer) TranscribeWithSilenceDetection(ctx context.Context, inputPath string, config *SilenceConfig, tempo float64, onProgress ProgressCallback) (*Result, error) {
 	if tempo <= 0 {
 		tempo = 1.0
 	}
@@ -265,7 +249,14 @@ func (r *Recognizer) TranscribeWithSilenceDetection(inputPath string, config *Si
 		onProgress(10, "detecting speech")
 	}
 
-	blocks, err := r.detectSpeechBlocksBySilence(inputPath, config)
+	// Decode once: speech detection and every block read the same PCM
+	pcm, err := decodePCM(ctx, inputPath, r.config.SampleRate, 1.0)
+	if err != nil {
+		return nil, err
+	}
+	defer pcm.Close()
+
+	blocks, err := r.detectSpeechBlocksBySilence(pcm, config)
 	if err != nil {
 		return nil, fmt.Errorf("silence detection failed: %w", err)
 	}
@@ -287,30 +278,42 @@ func (r *Recognizer) TranscribeWithSilenceDetection(inputPath string, config *Si
 	// Split long blocks
 	blocks = splitLongBlocks(blocks, config.MaxBlockDuration)
 
-	// Debug: print detected blocks
 	for i, b := range blocks {
-		fmt.Fprintf(os.Stderr, "  Block %d: %.2f - %.2f (%.2fs)\n", i+1, b.StartTime, b.EndTime, b.EndTime-b.StartTime)
+		logging.FromContext(ctx).Debug("Detected block", "block", i+1, "start", b.StartTime, "end", b.EndTime, "duration", b.EndTime-b.StartTime)
 	}
 
 	if onProgress != nil {
 		onProgress(20, fmt.Sprintf("found %d blocks", len(blocks)))
 	}
 
+	blockPCM, err := pcm.withTempo(ctx, inputPath, tempo)
+	if err != nil {
+		return nil, err
+	}
+	defer blockPCM.Close()
+
 	// Step 2: Process each block (reuse transcribeBlock from vad_block.go)
 	var allTokens []Token
 	var allText string
+	var nbests []NBest
 
 	for i, block := range blocks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		if onProgress != nil {
 			progress := 20 + int(60*float64(i)/float64(len(blocks)))
 			onProgress(progress, fmt.Sprintf("transcribing block %d/%d", i+1, len(blocks)))
 		}
 
-		tokens, text, err := r.transcribeBlock(inputPath, block, tempo)
+		tokens, text, nbest, err := r.transcribeBlock(blockPCM, block)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to transcribe block %d: %v\n", i+1, err)
+			logging.FromContext(ctx).Warn("Failed to transcribe block", "block", i+1, "error", err)
 			continue
 		}
+		if nbest != nil {
+			nbests = append(nbests, *nbest)
+		}
 
 		allTokens = append(allTokens, tokens...)
 		allText += text
@@ -332,13 +335,33 @@ func (r *Recognizer) TranscribeWithSilenceDetection(inputPath string, config *Si
 		Tokens:        allTokens,
 		Segments:      tokensToSegments(allTokens),
 		TotalDuration: totalDuration,
+		NBest:         nbests,
 	}, nil
 }
 
+// BlockResult is the result of one block of TranscribeWithOverlap. The
+// blocks are handed out as they finish so intermediate results can be stored
+// while a long file is transcribed, and an interrupted transcription can
+// resume after the last stored block instead of starting over
+type BlockResult struct {
+	Index  int     `json:"index"`
+	Blocks int     `json:"blocks"` // blocks of the file (a different count means the detection changed)
+	Tokens []Token `json:"tokens"` // tokens kept from the main portion (none if the block failed)
+	NBest  *NBest  `json:"nbest,omitempty"`
+}
+
 // TranscribeWithOverlap transcribes audio using overlapping chunks
 // This method helps with continuous speech that might get cut at word boundaries
 // overlap is the amount of overlap in seconds (default: 0.5s)
-func (r *Recognizer) TranscribeWithOverlap(inputPath string, config *SilenceConfig, tempo float64, overlap float64, onProgress ProgressCallback) (*Result, error) {
+func (r *Recognizer) TranscribeWithOverlap(ctx context.Context, inputPath string, config *SilenceConfig, tempo float64, overlap float64, onProgress ProgressCallback) (*Result, error) {
+	return r.TranscribeWithOverlapResume(ctx, inputPath, config, tempo, overlap, nil, nil, onProgress)
+}
+
+// TranscribeWithOverlapResume is TranscribeWithOverlap starting after the
+// blocks of resume, the leading blocks of an earlier attempt (blocks of
+// another block layout are ignored). onBlock, if set, receives each block
+// transcribed by this call as soon as it is finished
+func (r *Recognizer) TranscribeWithOverlapResume(ctx context.Context, inputPath string, config *SilenceConfig, tempo float64, overlap float64, resume []BlockResult, onBlock func(*BlockResult), onProgress ProgressCallback) (*Result, error) {
 	if tempo <= 0 {
 		tempo = 1.0
 	}
@@ -354,7 +377,14 @@ func (r *Recognizer) TranscribeWithOverlap(inputPath string, config *SilenceConf
 		onProgress(10, "detecting speech")
 	}
 
-	blocks, err := r.detectSpeechBlocksBySilence(inputPath, config)
+	// Decode once: speech detection and every block read the same PCM
+	pcm, err := decodePCM(ctx, inputPath, r.config.SampleRate, 1.0)
+	if err != nil {
+		return nil, err
+	}
+	defer pcm.Close()
+
+	blocks, err := r.detectSpeechBlocksBySilence(pcm, config)
 	if err != nil {
 		return nil, fmt.Errorf("silence detection failed: %w", err)
 	}
@@ -375,38 +405,76 @@ func (r *Recognizer) TranscribeWithOverlap(inputPath string, config *SilenceConf
 	// Split long blocks WITH OVERLAP
 	overlapBlocks := splitLongBlocksWithOverlap(blocks, config.MaxBlockDuration, overlap)
 
-	// Debug: print detected blocks
 	for i, b := range overlapBlocks {
-		fmt.Fprintf(os.Stderr, "  Block %d: %.2f - %.2f (main: %.2f - %.2f)\n",
-			i+1, b.StartTime, b.EndTime, b.MainStart, b.MainEnd)
+		logging.FromContext(ctx).Debug("Detected block", "block", i+1, "start", b.StartTime, "end", b.EndTime, "main_start", b.MainStart, "main_end", b.MainEnd)
 	}
 
 	if onProgress != nil {
 		onProgress(20, fmt.Sprintf("found %d blocks", len(overlapBlocks)))
 	}
 
+	blockPCM, err := pcm.withTempo(ctx, inputPath, tempo)
+	if err != nil {
+		return nil, err
+	}
+	defer blockPCM.Close()
+
 	// Step 2: Process each block, keeping only tokens in the "main" portion
 	var allTokens []Token
+	// One pass decodes the audio, and a second one at another tempo
+	metrics := &Metrics{Chunks: len(overlapBlocks)}
+	if blockPCM != pcm {
+		metrics.FFmpegRestarts = 1
+	}
+	var nbests []NBest
+
+	first := 0
+	for _, done := range resume {
+		if done.Index != first || done.Blocks != len(overlapBlocks) {
+			break
+		}
+		allTokens = append(allTokens, done.Tokens...)
+		if done.NBest != nil {
+			nbests = append(nbests, *done.NBest)
+		}
+		first++
+	}
+	if first > 0 {
+		logging.FromContext(ctx).Info("Resuming transcription from stored blocks", "block", first, "blocks", len(overlapBlocks))
+	}
 
-	for i, block := range overlapBlocks {
+	for i := first; i < len(overlapBlocks); i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block := overlapBlocks[i]
 		if onProgress != nil {
 			progress := 20 + int(60*float64(i)/float64(len(overlapBlocks)))
 			onProgress(progress, fmt.Sprintf("transcribing block %d/%d", i+1, len(overlapBlocks)))
 		}
 
-		tokens, _, err := r.transcribeBlock(inputPath, block.SpeechBlock, tempo)
+		done := BlockResult{Index: i, Blocks: len(overlapBlocks)}
+		tokens, _, nbest, err := r.transcribeBlock(blockPCM, block.SpeechBlock)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to transcribe block %d: %v\n", i+1, err)
-			continue
-		}
+			logging.FromContext(ctx).Warn("Failed to transcribe block", "block", i+1, "error", err)
+		} else {
+			if nbest != nil {
+				nbests = append(nbests, *nbest)
+				done.NBest = nbest
+			}
 
-		// Filter tokens: only keep those in the "main" portion
-		for _, token := range tokens {
-			tokenTime := float64(token.StartTime)
-			// Keep token if it starts within the main portion
-			if tokenTime >= block.MainStart && tokenTime < block.MainEnd {
-				allTokens = append(allTokens, token)
+			// Filter tokens: only keep those in the "main" portion
+			for _, token := range tokens {
+				tokenTime := float64(token.StartTime)
+				// Keep token if it starts within the main portion
+				if tokenTime >= block.MainStart && tokenTime < block.MainEnd {
+					done.Tokens = append(done.Tokens, token)
+				}
 			}
+			allTokens = append(allTokens, done.Tokens...)
+		}
+		if onBlock != nil {
+			onBlock(&done)
 		}
 	}
 
@@ -432,5 +500,7 @@ func (r *Recognizer) TranscribeWithOverlap(inputPath string, config *SilenceConf
 		Tokens:        allTokens,
 		Segments:      tokensToSegments(allTokens),
 		TotalDuration: totalDuration,
+		Metrics:       metrics,
+		NBest:         nbests,
 	}, nil
 }
@@ -1,6 +1,7 @@
 package asr
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -51,7 +52,7 @@ func TestTranscribeWithOverlap_OhayouYoroshiku(t *testing.T) {
 	overlap := 0.5 // 0.5 second overlap
 
 	// Transcribe with overlap
-	result, err := recognizer.TranscribeWithOverlap(testAudio, silenceConfig, tempo, overlap, nil)
+	result, err := recognizer.TranscribeWithOverlap(context.Background(), testAudio, silenceConfig, tempo, overlap, nil)
 	if err != nil {
 		t.Fatalf("Transcription failed: %v", err)
 	}
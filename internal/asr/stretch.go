@@ -0,0 +1,145 @@
+package asr
+
+import "math"
+
+// timeStretcher changes the tempo of a mono stream without changing its
+// pitch, like ffmpeg's atempo. It uses WSOLA: windowed frames are taken from
+// the input at tempo-scaled positions, each shifted (within tolerance) to
+// where the waveform best continues the previous frame, and overlap-added
+// at a fixed output hop
+type timeStretcher struct {
+	tempo     float64
+	hop       int // output hop (half a frame)
+	frameLen  int
+	tolerance int // maximum shift of a frame in the input
+	window    []float32
+
+	in       []float32 // input from position inBase
+	inBase   int64
+	inTotal  int64 // input samples received
+	frames   int64 // frames added
+	prev     int64 // input position of the last frame
+	ola      []float32
+	outTotal int64 // output samples returned
+}
+
+func newTimeStretcher(sampleRate int, tempo float64) *timeStretcher {
+	hop := max(sampleRate/50, 1) // 20ms frames overlapping by half
+	t := &timeStretcher{
+		tempo:     tempo,
+		hop:       hop,
+		frameLen:  2 * hop,
+		tolerance: sampleRate / 125, // 8ms: longer than the pitch period of speech
+		window:    make([]float32, 2*hop),
+		ola:       make([]float32, 2*hop),
+	}
+	// Periodic Hann: overlapping halves add up to 1
+	for i := range t.window {
+		t.window[i] = float32(0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(t.frameLen)))
+	}
+	return t
+}
+
+// process returns the output samples that can be computed from the input so far
+func (t *timeStretcher) process(in []float32) []float32 {
+	t.in = append(t.in, in...)
+	t.inTotal += int64(len(in))
+	return t.run(false)
+}
+
+// flush returns the rest of the output, len(input)/tempo samples in total
+func (t *timeStretcher) flush() []float32 {
+	out := t.run(true)
+	out = append(out, t.ola[:t.frameLen-t.hop]...)
+	want := int64(math.Round(float64(t.inTotal) / t.tempo))
+	if excess := t.outTotal + int64(len(t.ola[:t.frameLen-t.hop])) - want; excess > 0 {
+		out = out[:max(int64(len(out))-excess, 0)]
+	}
+	return out
+}
+
+// nominal returns the input position of frame k without the shift
+func (t *timeStretcher) nominal(k int64) int64 {
+	return int64(math.Round(float64(k*int64(t.hop)) * t.tempo))
+}
+
+// run adds the frames whose input is available (all of them when final,
+// reading silence after the end) and returns the finished output
+func (t *timeStretcher) run(final bool) []float32 {
+	var out []float32
+	for {
+		nominal := t.nominal(t.frames)
+		if final {
+			if nominal >= t.inTotal {
+				break
+			}
+		} else if nominal+int64(t.tolerance+t.frameLen) > t.inTotal {
+			break
+		}
+
+		pos := nominal
+		if t.frames > 0 {
+			pos = t.bestPosition(nominal)
+		}
+		for i, w := range t.window {
+			if t.frames == 0 && i < t.hop {
+				w = 1 // no fade-in at the start of the audio
+			}
+			t.ola[i] += t.sample(pos+int64(i)) * w
+		}
+		t.prev = pos
+		t.frames++
+
+		// The first hop is complete: no later frame overlaps it
+		out = append(out, t.ola[:t.hop]...)
+		copy(t.ola, t.ola[t.hop:])
+		clear(t.ola[t.frameLen-t.hop:])
+
+		// Drop the input before the next frame's candidates and continuation
+		keep := min(t.nominal(t.frames)-int64(t.tolerance), t.prev+int64(t.hop))
+		if drop := min(keep-t.inBase, int64(len(t.in))); drop > 0 {
+			t.in = append(t.in[:0], t.in[drop:]...)
+			t.inBase += drop
+		}
+	}
+	t.outTotal += int64(len(out))
+	return out
+}
+
+// bestPosition returns the position within tolerance of nominal whose first
+// hop correlates best with the natural continuation of the previous frame
+func (t *timeStretcher) bestPosition(nominal int64) int64 {
+	first := max(nominal-int64(t.tolerance), t.inBase)
+	natural := t.segment(t.prev+int64(t.hop), t.hop)
+	candidates := t.segment(first, int(nominal+int64(t.tolerance)-first)+t.hop)
+	best := nominal
+	bestScore := math.Inf(-1)
+	for offset := 0; offset+t.hop <= len(candidates); offset++ {
+		var score float32
+		for i, v := range candidates[offset : offset+t.hop] {
+			score += v * natural[i]
+		}
+		if float64(score) > bestScore {
+			best, bestScore = first+int64(offset), float64(score)
+		}
+	}
+	return best
+}
+
+// segment returns n input samples from pos (silence outside the input)
+func (t *timeStretcher) segment(pos int64, n int) []float32 {
+	out := make([]float32, n)
+	for i := range out {
+		out[i] = t.sample(pos + int64(i))
+	}
+	return out
+}
+
+// sample returns the input sample at pos (silence outside the input)
+func (t *timeStretcher) sample(pos int64) float32 {
+	i := pos - t.inBase
+	if i < 0 || i >= int64(len(t.in)) {
+		return 0
+	}
+	return t.in[i]
+}
@@ -0,0 +1,295 @@
+package asr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Subtitle formats accepted by ParseSubtitles
+const (
+	SubtitleFormatSRT  = "srt"
+	SubtitleFormatVTT  = "vtt"
+	SubtitleFormatJSON = "json" // a Result as written by Export("json")
+)
+
+// SubtitleFormats lists the formats accepted by ParseSubtitles
+var SubtitleFormats = []string{SubtitleFormatSRT, SubtitleFormatVTT, SubtitleFormatJSON}
+
+// DetectSubtitleFormat returns the format of a subtitle file from its
+// extension, falling back to its content ("" when unknown)
+func DetectSubtitleFormat(filename string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".srt":
+		return SubtitleFormatSRT
+	case ".vtt":
+		return SubtitleFormatVTT
+	case ".json":
+		return SubtitleFormatJSON
+	}
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\ufeff")))
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("WEBVTT")):
+		return SubtitleFormatVTT
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return SubtitleFormatJSON
+	case bytes.Contains(trimmed, []byte("-->")):
+		return SubtitleFormatSRT
+	}
+	return ""
+}
+
+// ParseSubtitles parses an SRT, WebVTT or JSON transcript into a Result.
+// Each cue becomes a segment (speaker from a WebVTT voice span or a "[name]"
+// prefix as written by Export) and tokens are synthesized from the cue text:
+// one per character, or per word for space-separated scripts, with the cue
+// duration spread over them by length. A JSON Result keeps its tokens and
+// gets segments or tokens synthesized when it has only one of them
+func ParseSubtitles(data []byte, format string) (*Result, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	switch format {
+	case SubtitleFormatSRT, SubtitleFormatVTT:
+		segments, err := parseCues(string(data), format == SubtitleFormatVTT)
+		if err != nil {
+			return nil, err
+		}
+		return resultFromSegments(segments), nil
+	case SubtitleFormatJSON:
+		return parseResultJSON(data)
+	default:
+		return nil, fmt.Errorf("unsupported subtitle format: %q (use %s)", format, strings.Join(SubtitleFormats, ", "))
+	}
+}
+
+// parseResultJSON reads a Result and fills in the segments or tokens it lacks
+func parseResultJSON(data []byte) (*Result, error) {
+	var result Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid transcript JSON: %w", err)
+	}
+	switch {
+	case len(result.Segments) == 0 && len(result.Tokens) == 0:
+		return nil, fmt.Errorf("transcript JSON has no segments or tokens")
+	case len(result.Tokens) == 0:
+		synthesized := resultFromSegments(result.Segments)
+		result.Tokens = synthesized.Tokens
+		if result.Text == "" {
+			result.Text = synthesized.Text
+		}
+	case len(result.Segments) == 0:
+		result.Segments = TokensToSegments(result.Tokens, SegmentationOptions{})
+	}
+	if result.Text == "" {
+		var sb strings.Builder
+		for _, t := range result.Tokens {
+			sb.WriteString(t.Text)
+		}
+		result.Text = strings.TrimSpace(sb.String())
+	}
+	for _, seg := range result.Segments {
+		result.TotalDuration = max(result.TotalDuration, float32(seg.EndTime))
+	}
+	// Processing details of the original run do not describe the import
+	result.Duration, result.Metrics, result.ChunkReports = 0, nil, nil
+	return &result, nil
+}
+
+// cueTimePattern matches the timing line of a cue ("00:01:02,500 --> 00:01:04.000 align:start")
+var cueTimePattern = regexp.MustCompile(`^\s*((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})\s*-->\s*((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})`)
+
+// voicePattern matches a WebVTT voice span ("<v Speaker>" or "<v.loud Speaker>")
+var voicePattern = regexp.MustCompile(`<v(?:\.[^ >]*)?\s+([^>]+)>`)
+
+// tagPattern matches the markup left in cue text (<i>, <c.color>, <00:01.000>, <font ...>)
+var tagPattern = regexp.MustCompile(`</?[^>]*>`)
+
+// speakerPrefixPattern matches the "[name] " prefix written by Export with SpeakerPrefix
+var speakerPrefixPattern = regexp.MustCompile(`^\[([^\]]{1,50})\]\s*`)
+
+// parseCues reads the cues of an SRT or WebVTT file in time order
+func parseCues(text string, vtt bool) ([]Segment, error) {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+	if vtt && !strings.HasPrefix(strings.TrimSpace(text), "WEBVTT") {
+		return nil, fmt.Errorf("not a WebVTT file (missing WEBVTT header)")
+	}
+
+	var segments []Segment
+	for _, block := range strings.Split(text, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		timing := -1
+		for i, line := range lines {
+			if strings.Contains(line, "-->") {
+				timing = i
+				break
+			}
+		}
+		// Headers, NOTE/STYLE/REGION blocks and stray text have no timing line
+		if timing < 0 {
+			continue
+		}
+		m := cueTimePattern.FindStringSubmatch(lines[timing])
+		if m == nil {
+			return nil, fmt.Errorf("invalid cue timing: %q", strings.TrimSpace(lines[timing]))
+		}
+		start, err := parseCueTime(m[1])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseCueTime(m[2])
+		if err != nil {
+			return nil, err
+		}
+
+		seg := Segment{StartTime: start, EndTime: max(end, start)}
+		seg.Text, seg.Speaker = cueText(lines[timing+1:])
+		if seg.Text != "" {
+			segments = append(segments, seg)
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no cues found")
+	}
+	sort.SliceStable(segments, func(i, j int) bool { return segments[i].StartTime < segments[j].StartTime })
+	return segments, nil
+}
+
+// parseCueTime parses "HH:MM:SS,mmm", "HH:MM:SS.mmm" or "MM:SS.mmm" into seconds
+func parseCueTime(s string) (float64, error) {
+	s = strings.Replace(s, ",", ".", 1)
+	parts := strings.Split(s, ":")
+	var seconds float64
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cue time: %q", s)
+		}
+		seconds = seconds*60 + v
+	}
+	return seconds, nil
+}
+
+// cueText joins the text lines of a cue and removes markup, returning the
+// text and the speaker (voice span or "[name]" prefix)
+func cueText(lines []string) (string, string) {
+	var text string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		text = joinCueLines(text, line)
+	}
+
+	speaker := ""
+	if m := voicePattern.FindStringSubmatch(text); m != nil {
+		speaker = strings.TrimSpace(m[1])
+	}
+	text = strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(text, "")))
+	if m := speakerPrefixPattern.FindStringSubmatch(text); m != nil && speaker == "" {
+		speaker = m[1]
+		text = strings.TrimSpace(text[len(m[0]):])
+	}
+	return text, speaker
+}
+
+// joinCueLines joins wrapped cue lines: with a space between words of
+// space-separated scripts, directly otherwise (Japanese)
+func joinCueLines(a, b string) string {
+	if a == "" {
+		return b
+	}
+	last, _ := utf8.DecodeLastRuneInString(a)
+	first, _ := utf8.DecodeRuneInString(b)
+	if isSpacedRune(last) || isSpacedRune(first) {
+		return a + " " + b
+	}
+	return a + b
+}
+
+// isSpacedRune reports whether r belongs to a script written with spaces between words
+func isSpacedRune(r rune) bool {
+	return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) ||
+		unicode.In(r, unicode.Latin, unicode.Cyrillic, unicode.Greek, unicode.Hangul)
+}
+
+// resultFromSegments builds a Result with synthesized tokens from cues
+func resultFromSegments(segments []Segment) *Result {
+	result := &Result{Segments: segments}
+	var text strings.Builder
+	prevSpaced := false
+	for _, seg := range segments {
+		first, _ := utf8.DecodeRuneInString(seg.Text)
+		// A space separates cues of space-separated scripts in the full text
+		leadingSpace := text.Len() > 0 && prevSpaced && isSpacedRune(first)
+		for _, t := range synthesizeTokens(seg, leadingSpace) {
+			result.Tokens = append(result.Tokens, t)
+			text.WriteString(t.Text)
+		}
+		last, _ := utf8.DecodeLastRuneInString(seg.Text)
+		prevSpaced = isSpacedRune(last) || unicode.IsPunct(last)
+		result.TotalDuration = max(result.TotalDuration, float32(seg.EndTime))
+	}
+	result.Text = text.String()
+	return result
+}
+
+// synthesizeTokens splits a cue into tokens (words of space-separated
+// scripts, other characters one by one; a space is kept at the start of the
+// token after it) and spreads the cue duration over them by length
+func synthesizeTokens(seg Segment, leadingSpace bool) []Token {
+	var units []string
+	var word strings.Builder
+	space := leadingSpace
+	flush := func() {
+		if word.Len() > 0 {
+			units = append(units, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range seg.Text {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+			space = true
+		case isSpacedRune(r) || (r == '\'' && word.Len() > 0):
+			if word.Len() == 0 && space {
+				word.WriteRune(' ')
+				space = false
+			}
+			word.WriteRune(r)
+		default:
+			flush()
+			unit := string(r)
+			if space {
+				unit, space = " "+unit, false
+			}
+			units = append(units, unit)
+		}
+	}
+	flush()
+
+	total := 0
+	for _, u := range units {
+		total += utf8.RuneCountInString(strings.TrimPrefix(u, " "))
+	}
+	if total == 0 {
+		return nil
+	}
+	perRune := (seg.EndTime - seg.StartTime) / float64(total)
+	tokens := make([]Token, 0, len(units))
+	at := seg.StartTime
+	for _, u := range units {
+		d := perRune * float64(utf8.RuneCountInString(strings.TrimPrefix(u, " ")))
+		tokens = append(tokens, Token{Text: u, StartTime: float32(at), Duration: float32(d)})
+		at += d
+	}
+	return tokens
+}
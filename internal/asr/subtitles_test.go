@@ -0,0 +1,165 @@
+package asr
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestParseSubtitlesRoundTrip tests that exported SRT and WebVTT parse back into the same segments
+func TestParseSubtitlesRoundTrip(t *testing.T) {
+	r := &Result{
+		Segments: []Segment{
+			{Text: "こんにちは", StartTime: 0.5, EndTime: 1.25, Speaker: "田中"},
+			{Text: "よろしく", StartTime: 2, EndTime: 3, Speaker: "佐藤"},
+		},
+	}
+
+	for _, format := range []string{SubtitleFormatSRT, SubtitleFormatVTT} {
+		t.Run(format, func(t *testing.T) {
+			data, err := r.Export(format, ExportOptions{SpeakerPrefix: true})
+			if err != nil {
+				t.Fatalf("Export(%q) error: %v", format, err)
+			}
+			got, err := ParseSubtitles([]byte(data), format)
+			if err != nil {
+				t.Fatalf("ParseSubtitles error: %v", err)
+			}
+			if len(got.Segments) != len(r.Segments) {
+				t.Fatalf("got %d segments, want %d", len(got.Segments), len(r.Segments))
+			}
+			for i, seg := range got.Segments {
+				want := r.Segments[i]
+				if seg.Text != want.Text || seg.Speaker != want.Speaker || seg.StartTime != want.StartTime || seg.EndTime != want.EndTime {
+					t.Errorf("segment %d = %+v, want %+v", i, seg, want)
+				}
+			}
+			if got.Text != "こんにちはよろしく" {
+				t.Errorf("Text = %q", got.Text)
+			}
+			if got.TotalDuration != 3 {
+				t.Errorf("TotalDuration = %v, want 3", got.TotalDuration)
+			}
+		})
+	}
+}
+
+// TestParseSubtitlesTokens tests the synthesized tokens of a cue
+func TestParseSubtitlesTokens(t *testing.T) {
+	srt := "1\r\n00:00:01,000 --> 00:00:03,000\r\n<i>Hello</i>\r\nworld\r\n\r\n2\r\n00:00:04,000 --> 00:00:05,000\r\nagain.\r\n"
+	got, err := ParseSubtitles([]byte(srt), SubtitleFormatSRT)
+	if err != nil {
+		t.Fatalf("ParseSubtitles error: %v", err)
+	}
+	if got.Segments[0].Text != "Hello world" {
+		t.Errorf("segment text = %q, want %q", got.Segments[0].Text, "Hello world")
+	}
+	if got.Text != "Hello world again." {
+		t.Errorf("Text = %q, want %q", got.Text, "Hello world again.")
+	}
+
+	var texts []string
+	for _, tok := range got.Tokens {
+		texts = append(texts, tok.Text)
+	}
+	if want := []string{"Hello", " world", " again", "."}; strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Errorf("tokens = %q, want %q", texts, want)
+	}
+	// The cue duration is spread over the words by length
+	if tok := got.Tokens[1]; math.Abs(float64(tok.StartTime)-2) > 1e-6 || math.Abs(float64(tok.Duration)-1) > 1e-6 {
+		t.Errorf("token %q at %v for %v, want 2 for 1", tok.Text, tok.StartTime, tok.Duration)
+	}
+}
+
+// TestParseSubtitlesVTT tests WebVTT headers, notes, cue settings and short timestamps
+func TestParseSubtitlesVTT(t *testing.T) {
+	vtt := `WEBVTT - meeting
+
+NOTE exported from a video site
+
+STYLE
+::cue { color: yellow }
+
+intro
+00:05.000 --> 00:06.500 align:start position:10%
+<v.loud Alice>Good &amp; fine</v>
+
+00:01.000 --> 00:02.000
+最初の
+発言
+`
+	got, err := ParseSubtitles([]byte(vtt), SubtitleFormatVTT)
+	if err != nil {
+		t.Fatalf("ParseSubtitles error: %v", err)
+	}
+	want := []Segment{
+		{Text: "最初の発言", StartTime: 1, EndTime: 2},
+		{Text: "Good & fine", StartTime: 5, EndTime: 6.5, Speaker: "Alice"},
+	}
+	if len(got.Segments) != len(want) {
+		t.Fatalf("got %d segments, want %d", len(got.Segments), len(want))
+	}
+	for i, seg := range got.Segments {
+		if seg.Text != want[i].Text || seg.Speaker != want[i].Speaker || seg.StartTime != want[i].StartTime || seg.EndTime != want[i].EndTime {
+			t.Errorf("segment %d = %+v, want %+v", i, seg, want[i])
+		}
+	}
+}
+
+// TestParseSubtitlesJSON tests that a JSON result gets the tokens or segments it lacks
+func TestParseSubtitlesJSON(t *testing.T) {
+	got, err := ParseSubtitles([]byte(`{"segments":[{"text":"はい","start_time":1,"end_time":2}],"duration":12.5}`), SubtitleFormatJSON)
+	if err != nil {
+		t.Fatalf("ParseSubtitles error: %v", err)
+	}
+	if len(got.Tokens) != 2 || got.Text != "はい" || got.Duration != 0 {
+		t.Errorf("got tokens %+v, text %q, duration %v", got.Tokens, got.Text, got.Duration)
+	}
+
+	if _, err := ParseSubtitles([]byte(`{"text":"only text"}`), SubtitleFormatJSON); err == nil {
+		t.Error("expected an error for JSON without segments or tokens")
+	}
+}
+
+// TestParseSubtitlesErrors tests invalid input
+func TestParseSubtitlesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format string
+	}{
+		{"no cues", "just some text\n", SubtitleFormatSRT},
+		{"bad timing", "1\n00:00:01 --> 00:00:02\nhi\n", SubtitleFormatSRT},
+		{"missing header", "00:01.000 --> 00:02.000\nhi\n", SubtitleFormatVTT},
+		{"unknown format", "", "ass"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSubtitles([]byte(tt.data), tt.format); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+// TestDetectSubtitleFormat tests detection by extension and by content
+func TestDetectSubtitleFormat(t *testing.T) {
+	tests := []struct {
+		filename string
+		data     string
+		want     string
+	}{
+		{"talk.SRT", "", SubtitleFormatSRT},
+		{"talk.vtt", "", SubtitleFormatVTT},
+		{"result.json", "", SubtitleFormatJSON},
+		{"upload", "\ufeffWEBVTT\n\n", SubtitleFormatVTT},
+		{"upload", `{"text":""}`, SubtitleFormatJSON},
+		{"upload", "1\n00:00:01,000 --> 00:00:02,000\nhi\n", SubtitleFormatSRT},
+		{"notes.txt", "hello", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectSubtitleFormat(tt.filename, []byte(tt.data)); got != tt.want {
+			t.Errorf("DetectSubtitleFormat(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
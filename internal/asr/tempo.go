@@ -1,20 +1,18 @@
 package asr
 
 import (
-	"bufio"
-	"encoding/binary"
+	"context"
 	"fmt"
-	"io"
-	"os"
-	"os/exec"
+	"path/filepath"
 )
 
 // TranscribeWithTempo transcribes audio with optional tempo adjustment for fast speech
-// Uses chunk-based processing (no VAD) for maximum accuracy
+// Uses chunk-based processing (no VAD) for maximum accuracy. Chunks that fail
+// to decode are skipped and reported in Result.ChunkReports
 //
 // 【実験用】本番では TranscribeWithVADBlock を使用すること。
 // このメソッドは固定チャンク分割のため、無音区間を跨ぐとタイムスタンプがずれる。
-func (r *Recognizer) TranscribeWithTempo(inputPath string, tempo float64, chunkSec int, onProgress ProgressCallback) (*Result, error) {
+func (r *Recognizer) TranscribeWithTempo(ctx context.Context, inputPath string, tempo float64, chunkSec int, onProgress ProgressCallback) (*Result, error) {
 	// Default values
 	if tempo <= 0 {
 		tempo = 1.0
@@ -24,7 +22,7 @@ func (r *Recognizer) TranscribeWithTempo(inputPath string, tempo float64, chunkS
 	}
 
 	// Get audio duration for progress calculation
-	duration, err := GetAudioDuration(inputPath)
+	duration, err := GetAudioDuration(ctx, inputPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get audio duration: %w", err)
 	}
@@ -34,52 +32,20 @@ func (r *Recognizer) TranscribeWithTempo(inputPath string, tempo float64, chunkS
 	// to get original audio timestamps
 	tempoFactor := tempo
 
-	// Start ffmpeg with optional tempo adjustment
-	var cmd *exec.Cmd
-	if tempo != 1.0 {
-		cmd = exec.Command("ffmpeg",
-			"-i", inputPath,
-			"-af", fmt.Sprintf("atempo=%.2f", tempo),
-			"-f", "s16le",
-			"-acodec", "pcm_s16le",
-			"-ar", fmt.Sprintf("%d", r.config.SampleRate),
-			"-ac", "1",
-			"-loglevel", "error",
-			"pipe:1",
-		)
-	} else {
-		cmd = exec.Command("ffmpeg",
-			"-i", inputPath,
-			"-f", "s16le",
-			"-acodec", "pcm_s16le",
-			"-ar", fmt.Sprintf("%d", r.config.SampleRate),
-			"-ac", "1",
-			"-loglevel", "error",
-			"pipe:1",
-		)
-	}
-
-	stdout, err := cmd.StdoutPipe()
+	// Decode with optional tempo adjustment
+	stream, err := openPCM(ctx, inputPath, decodeOptions{SampleRate: r.config.SampleRate, Tempo: tempo})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get stdout pipe: %w", err)
-	}
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+		return nil, err
 	}
 
 	// Process in chunks
-	reader := bufio.NewReader(stdout)
 	chunkSamples := r.config.SampleRate * chunkSec
-	chunkBytes := chunkSamples * 2 // 16-bit PCM
+	report := ChunkReport{File: filepath.Base(inputPath)}
 
 	var allTokens []Token
 	var allText string
-	var processedSamples int64
-	chunkNum := 0
 
-	reportProgress := func(step string) {
+	reportProgress := func(processedSamples int, step string) {
 		if onProgress != nil {
 			progressSec := float64(processedSamples) / float64(r.config.SampleRate) * tempoFactor
 			progress := int(30 + 60*progressSec/duration)
@@ -90,49 +56,41 @@ func (r *Recognizer) TranscribeWithTempo(inputPath string, tempo float64, chunkS
 		}
 	}
 
-	reportProgress("transcribing")
-
-	for {
-		buffer := make([]byte, chunkBytes)
-		n, err := io.ReadFull(reader, buffer)
-		if n == 0 {
-			break
-		}
-
-		samples := bytesToFloat32Tempo(buffer[:n])
-		processedSamples += int64(len(samples))
-		chunkNum++
+	reportProgress(0, "transcribing")
 
-		// Raw time in slowed audio
-		rawStartSec := float64(chunkNum-1) * float64(chunkSec)
+	tailPadding := int(tailPaddingSec * float64(r.config.SampleRate))
+	processed, readErr := readChunks(ctx, stream, r.config.SampleRate, chunkSamples, 0, tailPadding, &report, func(samples []float32, offset int) error {
+		// Corrected time in original audio (offset is in the slowed audio)
+		startSec := float64(offset) / float64(r.config.SampleRate) * tempoFactor
 
-		// Corrected time in original audio
-		startSec := rawStartSec * tempoFactor
-
-		// Transcribe chunk
-		result, transcribeErr := r.TranscribeBytes(samples, r.config.SampleRate)
-		if transcribeErr != nil {
-			continue
+		result, err := r.TranscribeBytes(samples, r.config.SampleRate)
+		if err != nil {
+			return err
 		}
 
 		// Adjust token timestamps
 		for _, token := range result.Tokens {
 			allTokens = append(allTokens, Token{
-				Text:      token.Text,
-				StartTime: float32(startSec) + token.StartTime*float32(tempoFactor),
-				Duration:  token.Duration * float32(tempoFactor),
+				Text:       token.Text,
+				StartTime:  float32(startSec) + token.StartTime*float32(tempoFactor),
+				Duration:   token.Duration * float32(tempoFactor),
+				Confidence: token.Confidence,
 			})
 		}
 		allText += result.Text
 
-		reportProgress("transcribing")
-
-		if err == io.EOF || err == io.ErrUnexpectedEOF {
-			break
-		}
+		reportProgress(offset+len(samples), "transcribing")
+		return nil
+	})
+	if readErr != nil {
+		stream.Close()
+		return nil, fmt.Errorf("failed to read audio: %w", readErr)
 	}
 
-	cmd.Wait()
+	processedSec := float64(processed) / float64(r.config.SampleRate) * tempoFactor
+	if err := report.finish(stream.Close(), duration, processedSec); err != nil {
+		return nil, err
+	}
 
 	// Calculate total duration from last token
 	var totalDuration float32
@@ -146,15 +104,6 @@ func (r *Recognizer) TranscribeWithTempo(inputPath string, tempo float64, chunkS
 		Tokens:        allTokens,
 		Segments:      tokensToSegments(allTokens),
 		TotalDuration: totalDuration,
+		ChunkReports:  []ChunkReport{report},
 	}, nil
 }
-
-// bytesToFloat32Tempo converts 16-bit PCM bytes to float32 samples
-func bytesToFloat32Tempo(data []byte) []float32 {
-	samples := make([]float32, len(data)/2)
-	for i := 0; i < len(samples); i++ {
-		sample := int16(binary.LittleEndian.Uint16(data[i*2:]))
-		samples[i] = float32(sample) / 32768.0
-	}
-	return samples
-}
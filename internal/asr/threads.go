@@ -0,0 +1,316 @@
+package asr
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	// maxAutoThreads caps the automatic thread count. ONNX Runtime gains
+	// little beyond this for the offline models and the rest of the server
+	// needs CPU too
+	maxAutoThreads = 8
+	// benchmarkSeconds is the length of the synthetic audio decoded per candidate
+	benchmarkSeconds = 10
+	// benchmarkTolerance prefers fewer threads when they are at most this much
+	// slower than the fastest candidate
+	benchmarkTolerance = 0.05
+)
+
+// DefaultNumThreads returns the thread count used when NumThreads is not set:
+// one less than the number of CPUs (leaving a core for the server), between 1 and maxAutoThreads
+func DefaultNumThreads() int {
+	return autoThreads(runtime.NumCPU())
+}
+
+// numThreads returns n, or DefaultNumThreads when n is not set
+func numThreads(n int) int {
+	if n <= 0 {
+		return DefaultNumThreads()
+	}
+	return n
+}
+
+func autoThreads(cpus int) int {
+	return min(max(cpus-1, 1), maxAutoThreads)
+}
+
+// ThreadBenchmark decodes the benchmark sample with the given thread count
+// and returns the decoding time (excluding model loading)
+type ThreadBenchmark func(threads int) (time.Duration, error)
+
+// ThreadTuner picks the number of inference threads for each recognizer.
+//
+// Recognizers without an explicit NumThreads get the fastest thread count
+// measured for their model on first use. Results are cached in memory and,
+// when cachePath is set, in a JSON file so the benchmark runs once per
+// machine. While other recognizers are running, new ones get a share of the
+// CPUs instead (threads of a running recognizer cannot be changed). A nil
+// *ThreadTuner only fills in DefaultNumThreads.
+type ThreadTuner struct {
+	cpus      int
+	cachePath string
+	benchmark bool
+	fixed     int // thread count for every recognizer (0: tune)
+
+	mu     sync.Mutex
+	tuned  map[string]int // model key -> fastest thread count
+	active int            // recognizers currently holding threads
+
+	benchMu sync.Mutex // one benchmark at a time
+}
+
+// NewThreadTuner creates a tuner. cachePath may be empty (no persistence).
+// With benchmark false the thread count is derived from the CPU count only.
+func NewThreadTuner(cachePath string, benchmark bool) *ThreadTuner {
+	t := &ThreadTuner{
+		cpus:      runtime.NumCPU(),
+		cachePath: cachePath,
+		benchmark: benchmark,
+		tuned:     make(map[string]int),
+	}
+	if cachePath != "" {
+		if data, err := os.ReadFile(cachePath); err == nil {
+			if err := json.Unmarshal(data, &t.tuned); err != nil {
+				slog.Warn("Ignoring invalid thread cache", "path", cachePath, "error", err)
+				t.tuned = make(map[string]int)
+			}
+		}
+	}
+	return t
+}
+
+// SetFixed makes recognizers without an explicit NumThreads use n threads
+// instead of the tuned count (0 restores tuning)
+func (t *ThreadTuner) SetFixed(n int) {
+	t.fixed = n
+}
+
+// TuneRecognizer sets config.NumThreads (when not set) for a ReazonSpeech recognizer.
+// Call the returned function when the recognizer is closed
+func (t *ThreadTuner) TuneRecognizer(config *Config) func() {
+	if t == nil {
+		if config.NumThreads <= 0 {
+			config.NumThreads = DefaultNumThreads()
+		}
+		return func() {}
+	}
+	base := *config
+	threads, release := t.acquire("reazonspeech:"+config.EncoderPath, config.NumThreads, func(threads int) (time.Duration, error) {
+		c := base
+		c.NumThreads = threads
+		r, err := NewRecognizer(&c)
+		if err != nil {
+			return 0, err
+		}
+		defer r.Close()
+		sample := benchmarkSample(c.SampleRate)
+		start := time.Now()
+		if _, err := r.TranscribeBytes(sample, c.SampleRate); err != nil {
+			return 0, err
+		}
+		return time.Since(start), nil
+	})
+	config.NumThreads = threads
+	return release
+}
+
+// TuneSenseVoice sets config.NumThreads (when not set) for a SenseVoice recognizer.
+// Call the returned function when the recognizer is closed
+func (t *ThreadTuner) TuneSenseVoice(config *SenseVoiceConfig) func() {
+	if t == nil {
+		if config.NumThreads <= 0 {
+			config.NumThreads = DefaultNumThreads()
+		}
+		return func() {}
+	}
+	base := *config
+	key := fmt.Sprintf("sensevoice:%s:%t:%s", config.ModelDir, config.UseInt8, config.DecodingMethod)
+	threads, release := t.acquire(key, config.NumThreads, func(threads int) (time.Duration, error) {
+		c := base
+		c.NumThreads = threads
+		r, err := NewSenseVoiceRecognizer(&c)
+		if err != nil {
+			return 0, err
+		}
+		defer r.Close()
+		sample := benchmarkSample(c.SampleRate)
+		start := time.Now()
+		r.transcribeBytes(sample, 0)
+		return time.Since(start), nil
+	})
+	config.NumThreads = threads
+	return release
+}
+
+// TuneWhisper sets config.NumThreads (when not set) for a Whisper recognizer.
+// Call the returned function when the recognizer is closed
+func (t *ThreadTuner) TuneWhisper(config *WhisperConfig) func() {
+	if t == nil {
+		if config.NumThreads <= 0 {
+			config.NumThreads = DefaultNumThreads()
+		}
+		return func() {}
+	}
+	base := *config
+	threads, release := t.acquire("whisper:"+config.ModelDir, config.NumThreads, func(threads int) (time.Duration, error) {
+		c := base
+		c.NumThreads = threads
+		r, err := NewWhisperRecognizer(&c)
+		if err != nil {
+			return 0, err
+		}
+		defer r.Close()
+		sample := benchmarkSample(c.SampleRate)
+		start := time.Now()
+		r.transcribeChunk(sample, 0)
+		return time.Since(start), nil
+	})
+	config.NumThreads = threads
+	return release
+}
+
+// acquire returns the thread count for a recognizer of the model and a
+// function releasing it. An explicit count (> 0) is used as is; otherwise
+// the tuned (or CPU-based) count is reduced to this recognizer's share of
+// the CPUs while others are running
+func (t *ThreadTuner) acquire(model string, explicit int, bench ThreadBenchmark) (int, func()) {
+	if explicit <= 0 {
+		explicit = t.fixed
+	}
+	best := explicit
+	if best <= 0 {
+		best = t.tunedThreads(model, bench)
+	}
+
+	t.mu.Lock()
+	t.active++
+	threads := best
+	if explicit <= 0 {
+		threads = min(best, max(t.cpus/t.active, 1))
+	}
+	t.mu.Unlock()
+
+	var once sync.Once
+	return threads, func() {
+		once.Do(func() {
+			t.mu.Lock()
+			t.active--
+			t.mu.Unlock()
+		})
+	}
+}
+
+// tunedThreads returns the cached thread count for the model, benchmarking it on first use.
+// Falls back to the CPU-based default when benchmarking is disabled or fails
+func (t *ThreadTuner) tunedThreads(model string, bench ThreadBenchmark) int {
+	key := fmt.Sprintf("%s@%dcpu", model, t.cpus)
+	t.mu.Lock()
+	threads, ok := t.tuned[key]
+	t.mu.Unlock()
+	if ok {
+		return threads
+	}
+	if !t.benchmark || bench == nil {
+		return autoThreads(t.cpus)
+	}
+
+	t.benchMu.Lock()
+	defer t.benchMu.Unlock()
+	t.mu.Lock()
+	threads, ok = t.tuned[key]
+	t.mu.Unlock()
+	if ok {
+		return threads
+	}
+
+	slog.Info("Benchmarking thread counts", "model", model)
+	threads, err := fastestThreads(threadCandidates(t.cpus), bench)
+	if err != nil {
+		slog.Warn("Thread benchmark failed", "model", model, "threads", autoThreads(t.cpus), "error", err)
+		return autoThreads(t.cpus)
+	}
+	slog.Info("Using tuned thread count", "model", model, "threads", threads)
+
+	t.mu.Lock()
+	t.tuned[key] = threads
+	t.mu.Unlock()
+	t.save()
+	return threads
+}
+
+// save writes the tuned thread counts to the cache file
+func (t *ThreadTuner) save() {
+	if t.cachePath == "" {
+		return
+	}
+	t.mu.Lock()
+	data, err := json.MarshalIndent(t.tuned, "", "  ")
+	t.mu.Unlock()
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(t.cachePath), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(t.cachePath, data, 0644)
+	}
+	if err != nil {
+		slog.Error("Failed to save thread cache", "path", t.cachePath, "error", err)
+	}
+}
+
+// threadCandidates returns the thread counts to benchmark: powers of two up
+// to the CPU count (capped at maxAutoThreads) plus the cap itself
+func threadCandidates(cpus int) []int {
+	limit := min(max(cpus, 1), maxAutoThreads)
+	var candidates []int
+	for n := 1; n < limit; n *= 2 {
+		candidates = append(candidates, n)
+	}
+	return append(candidates, limit)
+}
+
+// fastestThreads benchmarks each candidate and returns the smallest thread
+// count within benchmarkTolerance of the fastest one
+func fastestThreads(candidates []int, bench ThreadBenchmark) (int, error) {
+	times := make([]time.Duration, len(candidates))
+	fastest := time.Duration(math.MaxInt64)
+	for i, n := range candidates {
+		d, err := bench(n)
+		if err != nil {
+			return 0, fmt.Errorf("%d threads: %w", n, err)
+		}
+		times[i] = d
+		fastest = min(fastest, d)
+	}
+	for i, n := range candidates {
+		if float64(times[i]) <= float64(fastest)*(1+benchmarkTolerance) {
+			return n, nil
+		}
+	}
+	return candidates[len(candidates)-1], nil
+}
+
+// benchmarkSample returns benchmarkSeconds of low-level noise with a few
+// tones. Decoding time is dominated by the encoder, which depends on the
+// length of the audio rather than its content
+func benchmarkSample(sampleRate int) []float32 {
+	if sampleRate <= 0 {
+		sampleRate = 16000
+	}
+	rng := rand.New(rand.NewSource(1))
+	samples := make([]float32, benchmarkSeconds*sampleRate)
+	for i := range samples {
+		t := float64(i) / float64(sampleRate)
+		tone := 0.1 * math.Sin(2*math.Pi*(200+50*math.Floor(t))*t)
+		samples[i] = float32(tone + 0.01*rng.NormFloat64())
+	}
+	return samples
+}
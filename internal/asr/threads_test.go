@@ -0,0 +1,123 @@
+package asr
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestThreadCandidates tests the thread counts tried by the benchmark
+func TestThreadCandidates(t *testing.T) {
+	tests := []struct {
+		cpus int
+		want []int
+	}{
+		{1, []int{1}},
+		{2, []int{1, 2}},
+		{6, []int{1, 2, 4, 6}},
+		{8, []int{1, 2, 4, 8}},
+		{32, []int{1, 2, 4, 8}},
+	}
+	for _, tt := range tests {
+		if got := threadCandidates(tt.cpus); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("threadCandidates(%d) = %v, want %v", tt.cpus, got, tt.want)
+		}
+	}
+}
+
+// TestFastestThreads tests that the smallest count within the tolerance of the fastest wins
+func TestFastestThreads(t *testing.T) {
+	tests := []struct {
+		name  string
+		times map[int]time.Duration
+		want  int
+	}{
+		{"more is faster", map[int]time.Duration{1: 400, 2: 210, 4: 120, 8: 100}, 8},
+		{"saturates", map[int]time.Duration{1: 400, 2: 210, 4: 103, 8: 100}, 4},
+		{"oversubscribed", map[int]time.Duration{1: 400, 2: 200, 4: 150, 8: 180}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fastestThreads([]int{1, 2, 4, 8}, func(n int) (time.Duration, error) {
+				return tt.times[n] * time.Millisecond, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("fastestThreads() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestThreadTunerAcquire tests caching of the benchmark result, the
+// reduction for concurrent recognizers and explicit/fixed thread counts
+func TestThreadTunerAcquire(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "threads.json")
+	tuner := NewThreadTuner(cachePath, true)
+	tuner.cpus = 8
+
+	runs := 0
+	bench := func(n int) (time.Duration, error) {
+		runs++
+		return time.Duration(100/n+10) * time.Millisecond, nil
+	}
+
+	first, release1 := tuner.acquire("model", 0, bench)
+	if first != 8 {
+		t.Errorf("first recognizer got %d threads, want 8", first)
+	}
+	second, release2 := tuner.acquire("model", 0, bench)
+	if second != 4 {
+		t.Errorf("second concurrent recognizer got %d threads, want 4", second)
+	}
+	explicit, release3 := tuner.acquire("model", 3, bench)
+	if explicit != 3 {
+		t.Errorf("explicit recognizer got %d threads, want 3", explicit)
+	}
+	release1()
+	release2()
+	release3()
+	release3() // releasing twice is harmless
+
+	if runs != len(threadCandidates(8)) {
+		t.Errorf("benchmark ran %d times, want once per candidate (%d)", runs, len(threadCandidates(8)))
+	}
+
+	// The result is loaded from the cache file without benchmarking again
+	cached := NewThreadTuner(cachePath, true)
+	cached.cpus = 8
+	got, release := cached.acquire("model", 0, func(int) (time.Duration, error) {
+		t.Error("benchmark ran despite cached result")
+		return 0, nil
+	})
+	release()
+	if got != 8 {
+		t.Errorf("cached recognizer got %d threads, want 8", got)
+	}
+
+	cached.SetFixed(2)
+	if got, release := cached.acquire("model", 0, nil); got != 2 {
+		t.Errorf("fixed recognizer got %d threads, want 2", got)
+	} else {
+		release()
+	}
+
+	// Without benchmarking the count comes from the CPUs
+	plain := NewThreadTuner("", false)
+	plain.cpus = 4
+	if got, release := plain.acquire("model", 0, bench); got != 3 {
+		t.Errorf("unbenchmarked recognizer got %d threads, want 3", got)
+	} else {
+		release()
+	}
+
+	var none *ThreadTuner
+	config := &Config{}
+	none.TuneRecognizer(config)()
+	if config.NumThreads != DefaultNumThreads() {
+		t.Errorf("nil tuner set %d threads, want %d", config.NumThreads, DefaultNumThreads())
+	}
+}
@@ -0,0 +1,130 @@
+package asr
+
+import (
+	"fmt"
+	"math"
+)
+
+// FrameRate is a video frame rate as a rational number of frames per second
+type FrameRate struct {
+	Num       int64 // e.g. 30000 for 29.97 fps
+	Den       int64 // e.g. 1001 for 29.97 fps
+	DropFrame bool  // drop-frame timecode (29.97 and 59.94 only)
+}
+
+// DefaultFrameRate is used when no frame rate is given (29.97 fps, non-drop-frame)
+var DefaultFrameRate = FrameRate{Num: 30000, Den: 1001}
+
+// frameRates maps the accepted frame rate names to rational rates
+var frameRates = map[string]FrameRate{
+	"23.976": {Num: 24000, Den: 1001},
+	"23.98":  {Num: 24000, Den: 1001},
+	"24":     {Num: 24, Den: 1},
+	"25":     {Num: 25, Den: 1},
+	"29.97":  {Num: 30000, Den: 1001},
+	"30":     {Num: 30, Den: 1},
+	"50":     {Num: 50, Den: 1},
+	"59.94":  {Num: 60000, Den: 1001},
+	"60":     {Num: 60, Den: 1},
+}
+
+// ParseFrameRate parses a frame rate name such as "23.976", "25" or "29.97"
+// Drop-frame timecode is only defined for 29.97 and 59.94 fps
+func ParseFrameRate(name string, dropFrame bool) (FrameRate, error) {
+	rate, ok := frameRates[name]
+	if !ok {
+		return FrameRate{}, fmt.Errorf("unsupported frame rate: %s (use 23.976, 24, 25, 29.97, 30, 50, 59.94 or 60)", name)
+	}
+	if dropFrame {
+		if rate.Den != 1001 || rate.nominal()%30 != 0 {
+			return FrameRate{}, fmt.Errorf("drop-frame timecode requires 29.97 or 59.94 fps")
+		}
+		rate.DropFrame = true
+	}
+	return rate, nil
+}
+
+// orDefault returns DefaultFrameRate for the zero value
+func (f FrameRate) orDefault() FrameRate {
+	if f.Num <= 0 || f.Den <= 0 {
+		return DefaultFrameRate
+	}
+	return f
+}
+
+// nominal returns the integer frame count per timecode second (30 for 29.97)
+func (f FrameRate) nominal() int64 {
+	return int64(math.Round(float64(f.Num) / float64(f.Den)))
+}
+
+// framesPerHour returns the number of frames in one hour of timecode
+func (f FrameRate) framesPerHour() int64 {
+	nominal := f.nominal()
+	if f.DropFrame {
+		return (nominal*600 - nominal/15*9) * 6
+	}
+	return nominal * 3600
+}
+
+// Frames converts seconds to the nearest frame number
+func (f FrameRate) Frames(seconds float64) int64 {
+	if seconds <= 0 {
+		return 0
+	}
+	return int64(math.Round(seconds * float64(f.Num) / float64(f.Den)))
+}
+
+// Rational formats a frame count as an FCPXML rational time ("1001/30000s")
+func (f FrameRate) Rational(frames int64) string {
+	if frames == 0 {
+		return "0s"
+	}
+	num, den := frames*f.Den, f.Num
+	if g := gcd(num, den); g > 1 {
+		num, den = num/g, den/g
+	}
+	if den == 1 {
+		return fmt.Sprintf("%ds", num)
+	}
+	return fmt.Sprintf("%d/%ds", num, den)
+}
+
+// Timecode formats a frame number as SMPTE timecode (HH:MM:SS:FF, or HH:MM:SS;FF for drop-frame)
+func (f FrameRate) Timecode(frames int64) string {
+	sep := ":"
+	if f.DropFrame {
+		sep = ";"
+	}
+	return f.timecode(frames, sep)
+}
+
+// timecode formats a frame number as timecode with the given frame separator
+// Drop-frame timecode skips the first frame numbers of each minute
+// except every tenth minute, so it stays in sync with the clock
+func (f FrameRate) timecode(frames int64, sep string) string {
+	nominal := f.nominal()
+	if f.DropFrame {
+		drop := nominal / 15 // 2 frames at 29.97, 4 at 59.94
+		framesPerMinute := nominal*60 - drop
+		framesPer10Minutes := nominal*600 - drop*9
+
+		tens := frames / framesPer10Minutes
+		rem := frames % framesPer10Minutes
+		frames += drop * 9 * tens
+		if rem > drop {
+			frames += drop * ((rem - drop) / framesPerMinute)
+		}
+	}
+
+	ff := frames % nominal
+	totalSeconds := frames / nominal
+	return fmt.Sprintf("%02d:%02d:%02d%s%02d",
+		totalSeconds/3600, totalSeconds/60%60, totalSeconds%60, sep, ff)
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
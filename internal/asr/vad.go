@@ -2,11 +2,11 @@ package asr
 
 import (
 	"bufio"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 
 	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
 )
@@ -35,13 +35,13 @@ func DefaultVADConfig(modelPath string) *VADConfig {
 type ProgressCallback func(progressPercent int, currentStep string)
 
 // TranscribeWithVAD transcribes an audio/video file using VAD for efficient processing
-// It uses ffmpeg to convert to raw PCM and VAD to detect speech segments
+// It decodes the audio to raw PCM and uses VAD to detect speech segments
 //
 // 【実験用】本番では TranscribeWithVADBlock を使用すること。
 // このメソッドはtempo調整未対応で、タイムスタンプ精度に課題あり。
-func (r *Recognizer) TranscribeWithVAD(inputPath string, vadConfig *VADConfig, onProgress ProgressCallback) (*Result, error) {
+func (r *Recognizer) TranscribeWithVAD(ctx context.Context, inputPath string, vadConfig *VADConfig, onProgress ProgressCallback) (*Result, error) {
 	// Get audio duration for progress calculation
-	duration, err := GetAudioDuration(inputPath)
+	duration, err := GetAudioDuration(ctx, inputPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get audio duration: %w", err)
 	}
@@ -71,29 +71,15 @@ func (r *Recognizer) TranscribeWithVAD(inputPath string, vadConfig *VADConfig, o
 	}
 	defer sherpa.DeleteVoiceActivityDetector(vad)
 
-	// Start ffmpeg to convert to raw PCM
-	cmd := exec.Command("ffmpeg",
-		"-i", inputPath,
-		"-f", "s16le",
-		"-acodec", "pcm_s16le",
-		"-ar", fmt.Sprintf("%d", r.config.SampleRate),
-		"-ac", "1",
-		"-loglevel", "error",
-		"pipe:1",
-	)
-
-	stdout, err := cmd.StdoutPipe()
+	// Decode to raw PCM
+	stream, err := openPCM(ctx, inputPath, decodeOptions{SampleRate: r.config.SampleRate})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get stdout pipe: %w", err)
-	}
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+		return nil, err
 	}
+	defer stream.Close()
 
 	// Process audio through VAD
-	reader := bufio.NewReader(stdout)
+	reader := bufio.NewReader(stream)
 	windowSize := 512
 	windowBytes := windowSize * 2 // 16-bit = 2 bytes per sample
 
@@ -142,9 +128,10 @@ func (r *Recognizer) TranscribeWithVAD(inputPath string, vadConfig *VADConfig, o
 			// Adjust token timestamps with segment offset
 			for _, token := range result.Tokens {
 				allTokens = append(allTokens, Token{
-					Text:      token.Text,
-					StartTime: token.StartTime + segmentStartSec,
-					Duration:  token.Duration,
+					Text:       token.Text,
+					StartTime:  token.StartTime + segmentStartSec,
+					Duration:   token.Duration,
+					Confidence: token.Confidence,
 				})
 			}
 			allText += result.Text
@@ -175,15 +162,18 @@ func (r *Recognizer) TranscribeWithVAD(inputPath string, vadConfig *VADConfig, o
 
 		for _, token := range result.Tokens {
 			allTokens = append(allTokens, Token{
-				Text:      token.Text,
-				StartTime: token.StartTime + segmentStartSec,
-				Duration:  token.Duration,
+				Text:       token.Text,
+				StartTime:  token.StartTime + segmentStartSec,
+				Duration:   token.Duration,
+				Confidence: token.Confidence,
 			})
 		}
 		allText += result.Text
 	}
 
-	cmd.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// Calculate total duration from last token
 	var totalDuration float32
@@ -2,13 +2,15 @@ package asr
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"sort"
 	"strings"
 
+	"zbor/internal/logging"
+
 	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
 )
 
@@ -63,7 +65,7 @@ func splitLongBlocks(blocks []SpeechBlock, maxDuration float64) []SpeechBlock {
 //	vadConfig.MaxBlockDuration = 5.0    // 長いブロックを5秒で分割（冒頭ドロップ防止）
 //	tempo = 1.0                         // 通常は速度調整不要
 //	config.DecodingMethod = ""          // greedy_search（beam_searchは不要）
-func (r *Recognizer) TranscribeWithVADBlock(inputPath string, vadConfig *VADConfig, tempo float64, onProgress ProgressCallback) (*Result, error) {
+func (r *Recognizer) TranscribeWithVADBlock(ctx context.Context, inputPath string, vadConfig *VADConfig, tempo float64, onProgress ProgressCallback) (*Result, error) {
 	if tempo <= 0 {
 		tempo = 1.0
 	}
@@ -73,7 +75,14 @@ func (r *Recognizer) TranscribeWithVADBlock(inputPath string, vadConfig *VADConf
 		onProgress(10, "detecting speech")
 	}
 
-	blocks, err := r.detectSpeechBlocks(inputPath, vadConfig)
+	// Decode once: speech detection and every block read the same PCM
+	pcm, err := decodePCM(ctx, inputPath, r.config.SampleRate, 1.0)
+	if err != nil {
+		return nil, err
+	}
+	defer pcm.Close()
+
+	blocks, err := r.detectSpeechBlocks(ctx, pcm, vadConfig)
 	if err != nil {
 		return nil, fmt.Errorf("VAD detection failed: %w", err)
 	}
@@ -100,31 +109,43 @@ func (r *Recognizer) TranscribeWithVADBlock(inputPath string, vadConfig *VADConf
 	// Split long blocks to avoid recognition dropping beginning of audio
 	blocks = splitLongBlocks(blocks, vadConfig.MaxBlockDuration)
 
-	// Debug: print detected blocks
 	for i, b := range blocks {
-		fmt.Fprintf(os.Stderr, "  Block %d: %.2f - %.2f (%.2fs)\n", i+1, b.StartTime, b.EndTime, b.EndTime-b.StartTime)
+		logging.FromContext(ctx).Debug("Detected block", "block", i+1, "start", b.StartTime, "end", b.EndTime, "duration", b.EndTime-b.StartTime)
 	}
 
 	if onProgress != nil {
 		onProgress(20, fmt.Sprintf("found %d blocks", len(blocks)))
 	}
 
+	blockPCM, err := pcm.withTempo(ctx, inputPath, tempo)
+	if err != nil {
+		return nil, err
+	}
+	defer blockPCM.Close()
+
 	// Step 2: Process each block
 	var allTokens []Token
 	var allText string
+	var nbests []NBest
 
 	for i, block := range blocks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		if onProgress != nil {
 			progress := 20 + int(60*float64(i)/float64(len(blocks)))
 			onProgress(progress, fmt.Sprintf("transcribing block %d/%d", i+1, len(blocks)))
 		}
 
-		tokens, text, err := r.transcribeBlock(inputPath, block, tempo)
+		tokens, text, nbest, err := r.transcribeBlock(blockPCM, block)
 		if err != nil {
 			// Log but continue with other blocks
-			fmt.Fprintf(os.Stderr, "Warning: failed to transcribe block %d: %v\n", i+1, err)
+			logging.FromContext(ctx).Warn("Failed to transcribe block", "block", i+1, "error", err)
 			continue
 		}
+		if nbest != nil {
+			nbests = append(nbests, *nbest)
+		}
 
 		allTokens = append(allTokens, tokens...)
 		allText += text
@@ -158,11 +179,12 @@ func (r *Recognizer) TranscribeWithVADBlock(inputPath string, vadConfig *VADConf
 		Tokens:        allTokens,
 		Segments:      tokensToSegments(allTokens),
 		TotalDuration: totalDuration,
+		NBest:         nbests,
 	}, nil
 }
 
 // detectSpeechBlocks uses VAD to detect speech segments in the audio
-func (r *Recognizer) detectSpeechBlocks(inputPath string, vadConfig *VADConfig) ([]SpeechBlock, error) {
+func (r *Recognizer) detectSpeechBlocks(ctx context.Context, pcm *pcmFile, vadConfig *VADConfig) ([]SpeechBlock, error) {
 	// Check VAD model exists
 	if _, err := os.Stat(vadConfig.ModelPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("VAD model not found: %s", vadConfig.ModelPath)
@@ -188,35 +210,17 @@ func (r *Recognizer) detectSpeechBlocks(inputPath string, vadConfig *VADConfig)
 	}
 	defer sherpa.DeleteVoiceActivityDetector(vad)
 
-	// Convert audio to raw PCM (no tempo adjustment for VAD)
-	cmd := exec.Command("ffmpeg",
-		"-i", inputPath,
-		"-f", "s16le",
-		"-acodec", "pcm_s16le",
-		"-ar", fmt.Sprintf("%d", r.config.SampleRate),
-		"-ac", "1",
-		"-loglevel", "error",
-		"pipe:1",
-	)
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get stdout pipe: %w", err)
-	}
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
-	}
-
 	// Process audio through VAD
-	reader := bufio.NewReader(stdout)
+	reader := bufio.NewReader(pcm.Reader())
 	windowSize := 512
 	windowBytes := windowSize * 2
 
 	var blocks []SpeechBlock
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		buffer := make([]byte, windowBytes)
 		n, err := io.ReadFull(reader, buffer)
 		if n == 0 {
@@ -260,16 +264,18 @@ func (r *Recognizer) detectSpeechBlocks(inputPath string, vadConfig *VADConfig)
 		})
 	}
 
-	cmd.Wait()
-
 	return blocks, nil
 }
 
-// transcribeBlock transcribes a single speech block with tempo adjustment
-func (r *Recognizer) transcribeBlock(inputPath string, block SpeechBlock, tempo float64) ([]Token, string, error) {
+// transcribeBlock transcribes a single speech block read from pcm, which is
+// decoded at the tempo of the transcription (see pcmFile.withTempo)
+// With a Rescorer the block is decoded RescorePaths times and the hypotheses
+// are returned when they disagree (see chooseCandidate)
+func (r *Recognizer) transcribeBlock(pcm *pcmFile, block SpeechBlock) ([]Token, string, *NBest, error) {
+	tempo := pcm.tempo
 	duration := block.EndTime - block.StartTime
 	if duration <= 0 {
-		return nil, "", nil
+		return nil, "", nil, nil
 	}
 
 	// Minimum duration check: blocks shorter than 0.1s (after tempo) cause ONNX model crash
@@ -279,84 +285,64 @@ func (r *Recognizer) transcribeBlock(inputPath string, block SpeechBlock, tempo
 	resultingDuration := duration / tempo
 	if resultingDuration < minDuration {
 		// Skip blocks that are too short to process
-		return nil, "", nil
-	}
-
-	// Use ffmpeg to extract block with tempo adjustment
-	// Note: -ss and -t before -i applies to input (faster seek, duration is input duration)
-	// This ensures tempo filter doesn't get truncated by -t
-	args := []string{
-		"-ss", fmt.Sprintf("%.3f", block.StartTime),
-		"-t", fmt.Sprintf("%.3f", duration),
-		"-i", inputPath,
-	}
-
-	// Add tempo filter if not 1.0
-	if tempo != 1.0 {
-		args = append(args, "-af", fmt.Sprintf("atempo=%.2f", tempo))
+		return nil, "", nil, nil
 	}
 
-	args = append(args,
-		"-f", "s16le",
-		"-acodec", "pcm_s16le",
-		"-ar", fmt.Sprintf("%d", r.config.SampleRate),
-		"-ac", "1",
-		"-loglevel", "error",
-		"pipe:1",
-	)
-
-	cmd := exec.Command("ffmpeg", args...)
-	stdout, err := cmd.StdoutPipe()
+	allSamples, err := pcm.Block(block)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get stdout pipe: %w", err)
+		return nil, "", nil, err
 	}
 
-	if err := cmd.Start(); err != nil {
-		return nil, "", fmt.Errorf("failed to start ffmpeg: %w", err)
+	if len(allSamples) == 0 {
+		return nil, "", nil, nil
 	}
 
-	// Read all samples
-	var allSamples []float32
-	reader := bufio.NewReader(stdout)
-	buf := make([]byte, 16000) // Read in larger chunks
-
-	for {
-		n, err := reader.Read(buf)
-		if n > 0 {
-			samples := bytesToFloat32(buf[:n])
-			allSamples = append(allSamples, samples...)
+	// Transcribe
+	var tokens []Token
+	var text string
+	var nbest *NBest
+	if r.config.Rescorer != nil {
+		paths := r.config.RescorePaths
+		if paths <= 0 {
+			paths = DefaultRescorePaths
 		}
-		if err == io.EOF {
-			break
+		candidates := decodeShifted(allSamples, r.config.SampleRate, paths, 0, r.decodeTokens)
+		tokens, nbest = chooseCandidate(candidates, r.config.Rescorer, block.StartTime, block.EndTime)
+		for _, token := range tokens {
+			text += token.Text
 		}
+	} else {
+		result, err := r.TranscribeBytes(allSamples, r.config.SampleRate)
 		if err != nil {
-			cmd.Wait()
-			return nil, "", fmt.Errorf("failed to read audio: %w", err)
+			return nil, "", nil, fmt.Errorf("transcription failed: %w", err)
 		}
-	}
-
-	cmd.Wait()
-
-	if len(allSamples) == 0 {
-		return nil, "", nil
-	}
-
-	// Transcribe
-	result, err := r.TranscribeBytes(allSamples, r.config.SampleRate)
-	if err != nil {
-		return nil, "", fmt.Errorf("transcription failed: %w", err)
+		tokens, text = result.Tokens, result.Text
 	}
 
 	// Adjust timestamps to original audio time
 	var adjustedTokens []Token
-	for _, token := range result.Tokens {
+	for _, token := range tokens {
 		// Token timestamp is in slowed audio time, convert to original time
 		adjustedTokens = append(adjustedTokens, Token{
-			Text:      token.Text,
-			StartTime: float32(block.StartTime + float64(token.StartTime)*tempo),
-			Duration:  token.Duration * float32(tempo),
+			Text:       token.Text,
+			StartTime:  float32(block.StartTime + float64(token.StartTime)*tempo),
+			Duration:   token.Duration * float32(tempo),
+			Confidence: token.Confidence,
 		})
 	}
 
-	return adjustedTokens, result.Text, nil
+	return adjustedTokens, text, nbest, nil
+}
+
+// decodeTokens decodes samples and returns the tokens with start times
+// offset by timeOffset (nil if decoding fails)
+func (r *Recognizer) decodeTokens(samples []float32, timeOffset float32) []Token {
+	result, err := r.TranscribeBytes(samples, r.config.SampleRate)
+	if err != nil {
+		return nil
+	}
+	for i := range result.Tokens {
+		result.Tokens[i].StartTime += timeOffset
+	}
+	return result.Tokens
 }
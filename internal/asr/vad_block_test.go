@@ -1,6 +1,7 @@
 package asr
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -56,7 +57,7 @@ func TestTranscribeWithVADBlock_Mezurashii(t *testing.T) {
 	vadConfig.MaxBlockDuration = 5.0   // Split long blocks
 
 	// Transcribe
-	result, err := recognizer.TranscribeWithVADBlock(testAudio, vadConfig, 1.0, nil)
+	result, err := recognizer.TranscribeWithVADBlock(context.Background(), testAudio, vadConfig, 1.0, nil)
 	if err != nil {
 		t.Fatalf("Transcription failed: %v", err)
 	}
@@ -118,7 +119,7 @@ func TestTranscribeWithVADBlock_TimestampAccuracy(t *testing.T) {
 	vadConfig.MinSilenceDuration = 6.0
 	vadConfig.MaxBlockDuration = 5.0
 
-	result, err := recognizer.TranscribeWithVADBlock(testAudio, vadConfig, 1.0, nil)
+	result, err := recognizer.TranscribeWithVADBlock(context.Background(), testAudio, vadConfig, 1.0, nil)
 	if err != nil {
 		t.Fatalf("Transcription failed: %v", err)
 	}
@@ -0,0 +1,70 @@
+package asr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestModelConfigValidate tests detection of missing model files without loading the models
+func TestModelConfigValidate(t *testing.T) {
+	dir := t.TempDir()
+	touch := func(name string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sv := DefaultSenseVoiceConfig(dir)
+	wh := DefaultWhisperConfig(dir)
+	if err := sv.Validate(); err == nil {
+		t.Error("SenseVoiceConfig.Validate() = nil for an empty directory")
+	}
+	if err := wh.Validate(); err == nil {
+		t.Error("WhisperConfig.Validate() = nil for an empty directory")
+	}
+
+	touch("model.int8.onnx")
+	touch("tokens.txt")
+	if err := sv.Validate(); err != nil {
+		t.Errorf("SenseVoiceConfig.Validate() = %v", err)
+	}
+	touch("turbo-encoder.int8.onnx")
+	if err := wh.Validate(); err == nil {
+		t.Error("WhisperConfig.Validate() = nil without a decoder")
+	}
+	touch("turbo-decoder.int8.onnx")
+	if err := wh.Validate(); err != nil {
+		t.Errorf("WhisperConfig.Validate() = %v", err)
+	}
+
+	wh.Provider = "tensorrt"
+	if err := wh.Validate(); err == nil {
+		t.Error("WhisperConfig.Validate() = nil for an unknown provider")
+	}
+}
+
+// TestConfigValidateSampleRate tests that the transducer config requires a sample rate
+func TestConfigValidateSampleRate(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"encoder.onnx", "decoder.onnx", "joiner.onnx", "tokens.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config, err := NewConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rate := range []int{16000, 8000} {
+		config.SampleRate = rate
+		if err := config.Validate(); err != nil {
+			t.Errorf("Validate() = %v for %dHz", err, rate)
+		}
+	}
+	config.SampleRate = 0
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() = nil without a sample rate")
+	}
+}
@@ -1,6 +1,7 @@
 package asr
 
 import (
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -140,3 +141,125 @@ func ComputeWaveformPeaks(wavPath string, samplesPerSec float64) ([]float64, flo
 
 	return peaks, duration, nil
 }
+
+// WaveformPeakRate is the resolution of a Waveform (peaks per second).
+// Requests at lower rates are served by downsampling it
+const WaveformPeakRate = 100
+
+// Waveform holds the peaks of an audio file at a high resolution, so that
+// any resolution and time range can be served without decoding it again
+type Waveform struct {
+	Duration float64   // seconds
+	Peaks    []float32 // peak amplitude (0-1) of each 1/WaveformPeakRate second
+}
+
+// ComputeWaveform decodes an audio file at its own sample rate (downmixed to
+// mono) and computes its peaks at WaveformPeakRate
+func ComputeWaveform(ctx context.Context, audioPath string) (*Waveform, error) {
+	info, err := probeAudio(ctx, audioPath)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := openAudio(ctx, audioPath, decodeOptions{SampleRate: info.SampleRate, Channels: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	rate := int64(info.SampleRate)
+	var peaks []float32
+	var n int64 // samples read
+	buf := make([]float32, 8192)
+	for {
+		read, err := stream.Read(buf)
+		for _, s := range buf[:read] {
+			bin := n * WaveformPeakRate / rate
+			if bin == int64(len(peaks)) {
+				peaks = append(peaks, 0)
+			}
+			peaks[bin] = max(peaks[bin], float32(math.Abs(float64(s))))
+			n++
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err := stream.Close(); err != nil {
+		return nil, fmt.Errorf("decoding failed: %w", err)
+	}
+	return &Waveform{Duration: float64(n) / float64(rate), Peaks: peaks}, nil
+}
+
+// Range returns the peaks from start to end (seconds; end 0 means the end of
+// the audio) at samplesPerSec, each the maximum of the cached peaks it
+// covers. Like ComputeWaveformPeaks, the range is divided into
+// int((end-start)*samplesPerSec) equal parts (at least one)
+func (w *Waveform) Range(samplesPerSec, start, end float64) []float64 {
+	if end <= 0 || end > w.Duration {
+		end = w.Duration
+	}
+	start = max(start, 0)
+	if start >= end {
+		return []float64{}
+	}
+
+	numPeaks := max(int((end-start)*samplesPerSec), 1)
+	span := (end - start) / float64(numPeaks)
+	peaks := make([]float64, numPeaks)
+	for i := range peaks {
+		// The epsilon keeps boundaries that fall on a cached peak from
+		// taking the neighbouring one through rounding errors
+		from := int(math.Floor((start+float64(i)*span)*WaveformPeakRate + 1e-9))
+		to := int(math.Ceil((start+float64(i+1)*span)*WaveformPeakRate - 1e-9))
+		from = min(max(from, 0), len(w.Peaks))
+		to = min(max(to, from+1), len(w.Peaks))
+		for _, p := range w.Peaks[from:to] {
+			peaks[i] = max(peaks[i], float64(p))
+		}
+	}
+	return peaks
+}
+
+// waveformMagic starts a waveform file (see WriteTo)
+const waveformMagic = "ZWF1"
+
+// WriteTo writes the waveform in a compact binary form: the magic, the peak
+// rate and the number of peaks (uint32), the duration (float64) and the
+// peaks as uint16 (1/65535 steps), all little endian
+func (w *Waveform) WriteTo(out io.Writer) (int64, error) {
+	buf := make([]byte, 0, 20+2*len(w.Peaks))
+	buf = append(buf, waveformMagic...)
+	buf = binary.LittleEndian.AppendUint32(buf, WaveformPeakRate)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(w.Peaks)))
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(w.Duration))
+	for _, p := range w.Peaks {
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(math.Round(float64(min(max(p, 0), 1))*65535)))
+	}
+	n, err := out.Write(buf)
+	return int64(n), err
+}
+
+// ReadWaveform reads a waveform written by WriteTo
+func ReadWaveform(r io.Reader) (*Waveform, error) {
+	header := make([]byte, 20)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read waveform header: %w", err)
+	}
+	if string(header[:4]) != waveformMagic {
+		return nil, fmt.Errorf("not a waveform file")
+	}
+	if rate := binary.LittleEndian.Uint32(header[4:8]); rate != WaveformPeakRate {
+		return nil, fmt.Errorf("unsupported waveform resolution: %d peaks/sec", rate)
+	}
+	count := binary.LittleEndian.Uint32(header[8:12])
+	w := &Waveform{Duration: math.Float64frombits(binary.LittleEndian.Uint64(header[12:20]))}
+
+	data := make([]byte, 2*int64(count))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("failed to read waveform peaks: %w", err)
+	}
+	w.Peaks = make([]float32, count)
+	for i := range w.Peaks {
+		w.Peaks[i] = float32(binary.LittleEndian.Uint16(data[2*i:])) / 65535
+	}
+	return w, nil
+}
@@ -2,10 +2,10 @@ package asr
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
-	"os"
-	"os/exec"
+	"path/filepath"
 	"strings"
 
 	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
@@ -16,8 +16,9 @@ type WhisperConfig struct {
 	ModelDir   string
 	Language   string // ja, en, zh, etc. or empty for auto-detect
 	Task       string // transcribe or translate
-	NumThreads int
+	NumThreads int    // 0: auto (DefaultNumThreads or ThreadTuner)
 	SampleRate int
+	Provider   string // execution provider: cpu (default), cuda, coreml, directml
 }
 
 // DefaultWhisperConfig returns default Whisper configuration for Japanese
@@ -26,23 +27,22 @@ func DefaultWhisperConfig(modelDir string) *WhisperConfig {
 		ModelDir:   modelDir,
 		Language:   "ja",
 		Task:       "transcribe",
-		NumThreads: 4,
+		NumThreads: 0, // auto
 		SampleRate: 16000,
 	}
 }
 
-// WhisperRecognizer wraps Whisper model for speech recognition
-type WhisperRecognizer struct {
-	recognizer *sherpa.OfflineRecognizer
-	config     *WhisperConfig
-}
-
-// NewWhisperRecognizer creates a new Whisper recognizer
-func NewWhisperRecognizer(config *WhisperConfig) (*WhisperRecognizer, error) {
-	if config == nil {
-		return nil, fmt.Errorf("config is required")
+// Validate checks if the model files exist (without loading the model)
+func (c *WhisperConfig) Validate() error {
+	if _, _, _, err := c.modelFiles(); err != nil {
+		return err
 	}
+	_, err := ParseProvider(c.Provider)
+	return err
+}
 
+// modelFiles finds the encoder, decoder and tokens files in the model directory
+func (c *WhisperConfig) modelFiles() (encoderPath, decoderPath, tokensPath string, err error) {
 	// Find encoder and decoder files
 	encoderCandidates := []string{
 		"encoder.int8.onnx",
@@ -71,18 +71,40 @@ func NewWhisperRecognizer(config *WhisperConfig) (*WhisperRecognizer, error) {
 		"large-v2-tokens.txt",
 	}
 
-	encoderPath := findModelFile(config.ModelDir, encoderCandidates)
-	decoderPath := findModelFile(config.ModelDir, decoderCandidates)
-	tokensPath := findModelFile(config.ModelDir, tokensCandidates)
+	encoderPath = findModelFile(c.ModelDir, encoderCandidates)
+	decoderPath = findModelFile(c.ModelDir, decoderCandidates)
+	tokensPath = findModelFile(c.ModelDir, tokensCandidates)
 
 	if encoderPath == "" {
-		return nil, fmt.Errorf("encoder model not found in %s", config.ModelDir)
+		return "", "", "", fmt.Errorf("encoder model not found in %s", c.ModelDir)
 	}
 	if decoderPath == "" {
-		return nil, fmt.Errorf("decoder model not found in %s", config.ModelDir)
+		return "", "", "", fmt.Errorf("decoder model not found in %s", c.ModelDir)
 	}
 	if tokensPath == "" {
-		return nil, fmt.Errorf("tokens file not found in %s", config.ModelDir)
+		return "", "", "", fmt.Errorf("tokens file not found in %s", c.ModelDir)
+	}
+	return encoderPath, decoderPath, tokensPath, nil
+}
+
+// WhisperRecognizer wraps Whisper model for speech recognition
+type WhisperRecognizer struct {
+	recognizer *sherpa.OfflineRecognizer
+	config     *WhisperConfig
+}
+
+// NewWhisperRecognizer creates a new Whisper recognizer
+func NewWhisperRecognizer(config *WhisperConfig) (*WhisperRecognizer, error) {
+	if config == nil {
+		return nil, fmt.Errorf("config is required")
+	}
+
+	encoderPath, decoderPath, tokensPath, err := config.modelFiles()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ParseProvider(config.Provider); err != nil {
+		return nil, err
 	}
 
 	sherpaConfig := sherpa.OfflineRecognizerConfig{
@@ -98,7 +120,8 @@ func NewWhisperRecognizer(config *WhisperConfig) (*WhisperRecognizer, error) {
 				Task:     config.Task,
 			},
 			Tokens:     tokensPath,
-			NumThreads: config.NumThreads,
+			NumThreads: numThreads(config.NumThreads),
+			Provider:   provider(config.Provider),
 			Debug:      0,
 		},
 	}
@@ -125,7 +148,7 @@ func (r *WhisperRecognizer) Close() {
 
 // TranscribePartial transcribes a specific time range of an audio file
 // Since Whisper doesn't return timestamps, we distribute them uniformly
-func (r *WhisperRecognizer) TranscribePartial(filePath string, opts PartialTranscribeOptions) (*Result, error) {
+func (r *WhisperRecognizer) TranscribePartial(ctx context.Context, filePath string, opts PartialTranscribeOptions) (*Result, error) {
 	if opts.ChunkSec <= 0 {
 		opts.ChunkSec = 30 // Whisper supports up to 30 seconds natively
 	}
@@ -135,39 +158,20 @@ func (r *WhisperRecognizer) TranscribePartial(filePath string, opts PartialTrans
 		return nil, fmt.Errorf("invalid time range: %.2f - %.2f", opts.StartTime, opts.EndTime)
 	}
 
-	// Build ffmpeg command to extract and process the time range
-	args := []string{
-		"-ss", fmt.Sprintf("%.3f", opts.StartTime),
-		"-i", filePath,
-		"-t", fmt.Sprintf("%.3f", duration),
-	}
-
-	// Add tempo filter if not 1.0
-	if opts.Tempo > 0 && opts.Tempo != 1.0 {
-		args = append(args, "-af", fmt.Sprintf("atempo=%.2f", opts.Tempo))
-	}
-
-	args = append(args,
-		"-f", "s16le",
-		"-acodec", "pcm_s16le",
-		"-ar", fmt.Sprintf("%d", r.config.SampleRate),
-		"-ac", "1",
-		"-loglevel", "error",
-		"pipe:1",
-	)
-
-	cmd := exec.Command("ffmpeg", args...)
-	stdout, err := cmd.StdoutPipe()
+	// Decode the time range at the tempo
+	audio, err := openPCM(ctx, filePath, decodeOptions{
+		SampleRate: r.config.SampleRate,
+		Start:      opts.StartTime,
+		Duration:   duration,
+		Tempo:      opts.Tempo,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+		return nil, err
 	}
+	defer audio.Close()
 
 	// Read all audio data
-	reader := bufio.NewReader(stdout)
+	reader := bufio.NewReader(audio)
 	var allSamples []float32
 
 	chunkBytes := r.config.SampleRate * opts.ChunkSec * 2
@@ -183,7 +187,9 @@ func (r *WhisperRecognizer) TranscribePartial(filePath string, opts PartialTrans
 			break
 		}
 	}
-	cmd.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	if len(allSamples) == 0 {
 		return &Result{}, nil
@@ -241,7 +247,7 @@ func distributeTimestampsToWhisperTokens(whisperTokens []string, startTime, endT
 }
 
 // TranscribeFile transcribes an audio file using Whisper
-func (r *WhisperRecognizer) TranscribeFile(inputPath string, chunkSec int, onProgress ProgressCallback) (*Result, error) {
+func (r *WhisperRecognizer) TranscribeFile(ctx context.Context, inputPath string, chunkSec int, onProgress ProgressCallback) (*Result, error) {
 	if chunkSec <= 0 {
 		chunkSec = 30 // Whisper supports up to 30 seconds natively
 	}
@@ -251,55 +257,28 @@ func (r *WhisperRecognizer) TranscribeFile(inputPath string, chunkSec int, onPro
 	}
 
 	// Get duration for progress calculation
-	duration, _ := getAudioDuration(inputPath)
-
-	// Convert audio to raw PCM using ffmpeg
-	cmd := exec.Command("ffmpeg",
-		"-i", inputPath,
-		"-f", "s16le",
-		"-acodec", "pcm_s16le",
-		"-ar", fmt.Sprintf("%d", r.config.SampleRate),
-		"-ac", "1",
-		"-loglevel", "error",
-		"pipe:1",
-	)
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create pipe: %w", err)
-	}
-	cmd.Stderr = os.Stderr
+	duration, _ := getAudioDuration(ctx, inputPath)
 
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	// Decode the audio to raw PCM
+	stream, err := openPCM(ctx, inputPath, decodeOptions{SampleRate: r.config.SampleRate})
+	if err != nil {
+		return nil, err
 	}
 
-	reader := bufio.NewReader(stdout)
-
 	chunkSamples := r.config.SampleRate * chunkSec
-	chunkBytes := chunkSamples * 2
+	// A merged final chunk must still fit in Whisper's 30-second window
+	maxSamples := r.config.SampleRate * max(chunkSec, 30)
+	report := ChunkReport{File: filepath.Base(inputPath)}
 
 	var allTokens []Token
 	var allText strings.Builder
-	chunkNum := 0
-	var processedSamples int64
 
 	if onProgress != nil {
 		onProgress(20, "transcribing")
 	}
 
-	for {
-		buffer := make([]byte, chunkBytes)
-		n, err := io.ReadFull(reader, buffer)
-		if n == 0 {
-			break
-		}
-
-		samples := bytesToFloat32SV(buffer[:n]) // Reuse from sensevoice.go
-		processedSamples += int64(len(samples))
-		chunkNum++
-
-		startSec := float32((chunkNum - 1) * chunkSec)
+	processed, readErr := readChunks(ctx, stream, r.config.SampleRate, chunkSamples, maxSamples, 0, &report, func(samples []float32, offset int) error {
+		startSec := float32(offset) / float32(r.config.SampleRate)
 
 		// Transcribe chunk
 		tokens := r.transcribeChunk(samples, startSec)
@@ -312,19 +291,22 @@ func (r *WhisperRecognizer) TranscribeFile(inputPath string, chunkSec int, onPro
 
 		// Update progress
 		if onProgress != nil && duration > 0 {
-			progress := 20 + int(60*float64(processedSamples)/float64(r.config.SampleRate)/duration)
+			progress := 20 + int(60*float64(offset+len(samples))/float64(r.config.SampleRate)/duration)
 			if progress > 80 {
 				progress = 80
 			}
-			onProgress(progress, fmt.Sprintf("chunk %d", chunkNum))
-		}
-
-		if err == io.EOF || err == io.ErrUnexpectedEOF {
-			break
+			onProgress(progress, fmt.Sprintf("chunk %d", report.Chunks))
 		}
+		return nil
+	})
+	if readErr != nil {
+		stream.Close()
+		return nil, fmt.Errorf("failed to read audio: %w", readErr)
 	}
 
-	cmd.Wait()
+	if err := report.finish(stream.Close(), duration, float64(processed)/float64(r.config.SampleRate)); err != nil {
+		return nil, err
+	}
 
 	if onProgress != nil {
 		onProgress(90, "finalizing")
@@ -342,6 +324,7 @@ func (r *WhisperRecognizer) TranscribeFile(inputPath string, chunkSec int, onPro
 		Tokens:        allTokens,
 		Segments:      tokensToSegments(allTokens),
 		TotalDuration: totalDuration,
+		ChunkReports:  []ChunkReport{report},
 	}, nil
 }
 
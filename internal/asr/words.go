@@ -0,0 +1,149 @@
+package asr
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wordBoundaryMarker is the SentencePiece prefix that marks the first piece of a word
+const wordBoundaryMarker = "▁"
+
+// WordGroupingConfig controls how tokens are grouped into words
+type WordGroupingConfig struct {
+	// MaxGap is the silence between tokens (seconds) that always starts a new word.
+	// Zero disables the gap check.
+	MaxGap float64
+}
+
+// DefaultWordGroupingConfig returns the grouping settings used by the server
+func DefaultWordGroupingConfig() WordGroupingConfig {
+	return WordGroupingConfig{MaxGap: 0.5}
+}
+
+// charClass is the script class used to find word boundaries in text without spaces
+type charClass int
+
+const (
+	classOther charClass = iota
+	classKanji
+	classHiragana
+	classKatakana
+	classAlnum
+	classOpen       // opening brackets and quotes
+	classPunct      // closing brackets, full stops and other punctuation
+	classContinuous // prolonged sound mark and iteration marks, never start a word
+)
+
+// classOf returns the script class of a rune
+func classOf(r rune) charClass {
+	switch {
+	case r == 'ー' || r == '々' || r == 'ゝ' || r == 'ゞ' || r == 'ヽ' || r == 'ヾ':
+		return classContinuous
+	case unicode.Is(unicode.Han, r):
+		return classKanji
+	case unicode.Is(unicode.Hiragana, r):
+		return classHiragana
+	case unicode.Is(unicode.Katakana, r):
+		return classKatakana
+	case unicode.IsLetter(r) || unicode.IsDigit(r):
+		return classAlnum
+	case unicode.In(r, unicode.Ps, unicode.Pi):
+		return classOpen
+	case unicode.IsPunct(r) || unicode.IsSymbol(r):
+		return classPunct
+	default:
+		return classOther
+	}
+}
+
+// startsWord reports whether a token whose first character has class next starts
+// a new word after a word whose last character has class prev. It follows
+// bunsetsu-like rules: kana and punctuation attach to the preceding content word,
+// and a change to kanji, katakana or latin script starts a new one.
+func startsWord(prev, next charClass) bool {
+	switch {
+	case prev == classOpen, next == classContinuous:
+		return false
+	case next == classOpen, prev == classPunct:
+		return true
+	case next == classPunct, next == classHiragana:
+		return false
+	case prev == classAlnum && next == classKanji:
+		// counters and units: 3月, 10時, 5分
+		return false
+	default:
+		return prev != next
+	}
+}
+
+// GroupWords groups tokens into words while keeping the start time of each
+// word's first token. SentencePiece word markers ("▁") and leading spaces start
+// a new word; text without spaces (Japanese) is split at script changes, with
+// kana and punctuation attached to the preceding word. The word confidence is
+// the lowest known token confidence.
+func GroupWords(tokens []Token, cfg WordGroupingConfig) []WordTiming {
+	words := make([]WordTiming, 0, len(tokens))
+	var text strings.Builder
+	var current *WordTiming
+	var last charClass
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Text = strings.TrimSpace(text.String())
+		current.Duration = current.EndTime - current.StartTime
+		if current.Text != "" {
+			words = append(words, *current)
+		}
+		current = nil
+		text.Reset()
+	}
+
+	for _, t := range tokens {
+		piece := strings.ReplaceAll(t.Text, wordBoundaryMarker, " ")
+		trimmed := strings.TrimLeftFunc(piece, unicode.IsSpace)
+		if trimmed == "" {
+			// A bare word marker only separates the surrounding pieces
+			if piece != "" {
+				flush()
+			}
+			continue
+		}
+		start := float64(t.StartTime)
+		end := float64(t.StartTime + t.Duration)
+
+		first, _ := utf8.DecodeRuneInString(trimmed)
+		lastRune, _ := utf8.DecodeLastRuneInString(strings.TrimRightFunc(trimmed, unicode.IsSpace))
+		next := classOf(first)
+
+		newWord := current == nil ||
+			len(trimmed) != len(piece) ||
+			(cfg.MaxGap > 0 && start-current.EndTime > cfg.MaxGap) ||
+			startsWord(last, next)
+		if newWord {
+			flush()
+			current = &WordTiming{StartTime: start}
+		}
+
+		text.WriteString(piece)
+		if end > current.EndTime {
+			current.EndTime = end
+		}
+		if cls := classOf(lastRune); cls != classContinuous {
+			last = cls
+		}
+	}
+	flush()
+	return words
+}
+
+// GroupWords fills Words by grouping the result's tokens
+func (r *Result) GroupWords(cfg WordGroupingConfig) {
+	if len(r.Tokens) == 0 {
+		r.Words = nil
+		return
+	}
+	r.Words = GroupWords(r.Tokens, cfg)
+}
@@ -0,0 +1,126 @@
+package asr
+
+import (
+	"strings"
+	"testing"
+)
+
+// wordTexts joins the texts of words for comparison
+func wordTexts(words []WordTiming) string {
+	texts := make([]string, len(words))
+	for i, w := range words {
+		texts[i] = w.Text
+	}
+	return strings.Join(texts, "|")
+}
+
+// charTokens returns one token per character, each lasting step seconds
+func charTokens(text string, start, step float32) []Token {
+	var tokens []Token
+	for _, r := range text {
+		tokens = append(tokens, Token{Text: string(r), StartTime: start, Duration: step})
+		start += step
+	}
+	return tokens
+}
+
+// TestGroupWords tests word boundaries for Japanese text without spaces,
+// SentencePiece word markers and space-prefixed tokens
+func TestGroupWords(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []Token
+		want   string
+	}{
+		{
+			name:   "kana attaches to kanji",
+			tokens: charTokens("私は東京に行きました。", 0, 0.1),
+			want:   "私は|東京に|行きました。",
+		},
+		{
+			name:   "katakana and prolonged sound mark",
+			tokens: charTokens("今日はコーヒーを飲む", 0, 0.1),
+			want:   "今日は|コーヒーを|飲む",
+		},
+		{
+			name:   "counters follow digits",
+			tokens: charTokens("3月10日に", 0, 0.1),
+			want:   "3月|10日に",
+		},
+		{
+			name:   "opening brackets start a word",
+			tokens: charTokens("本当に「すごい」と", 0, 0.1),
+			want:   "本当に|「すごい」|と",
+		},
+		{
+			name: "sentencepiece markers",
+			tokens: []Token{
+				{Text: "▁hel", StartTime: 0, Duration: 0.1},
+				{Text: "lo", StartTime: 0.1, Duration: 0.1},
+				{Text: "▁", StartTime: 0.2, Duration: 0},
+				{Text: "wor", StartTime: 0.2, Duration: 0.1},
+				{Text: "ld", StartTime: 0.3, Duration: 0.1},
+			},
+			want: "hello|world",
+		},
+		{
+			name: "space-prefixed tokens",
+			tokens: []Token{
+				{Text: " Good", StartTime: 0, Duration: 0.2},
+				{Text: " morn", StartTime: 0.2, Duration: 0.1},
+				{Text: "ing", StartTime: 0.3, Duration: 0.1},
+				{Text: ".", StartTime: 0.4, Duration: 0.05},
+			},
+			want: "Good|morning.",
+		},
+		{
+			name: "long gap splits",
+			tokens: []Token{
+				{Text: "そう", StartTime: 0, Duration: 0.2},
+				{Text: "です", StartTime: 1.5, Duration: 0.2},
+			},
+			want: "そう|です",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wordTexts(GroupWords(tt.tokens, DefaultWordGroupingConfig()))
+			if got != tt.want {
+				t.Errorf("GroupWords() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGroupWordsTiming tests that words keep the first token's start time
+// and span to the last token's end
+func TestGroupWordsTiming(t *testing.T) {
+	result := &Result{Tokens: []Token{
+		{Text: "東", StartTime: 1.0, Duration: 0.1},
+		{Text: "京", StartTime: 1.1, Duration: 0.1},
+		{Text: "へ", StartTime: 1.25, Duration: 0.15},
+		{Text: "行く", StartTime: 1.5, Duration: 0.3},
+	}}
+	result.GroupWords(DefaultWordGroupingConfig())
+
+	if got := wordTexts(result.Words); got != "東京へ|行く" {
+		t.Fatalf("words = %q, want 東京へ|行く", got)
+	}
+	w := result.Words[0]
+	if w.StartTime != 1.0 || w.EndTime < 1.39 || w.EndTime > 1.41 {
+		t.Errorf("word = %+v, want start 1.0, end 1.4", w)
+	}
+	if w.Duration < 0.39 || w.Duration > 0.41 {
+		t.Errorf("duration = %v, want 0.4", w.Duration)
+	}
+
+	// Word exports use the grouped words once present
+	csv, err := result.FormatAsWordsCSV()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(csv, "東京へ,1.000,1.400") {
+		t.Errorf("csv does not contain the grouped word:\n%s", csv)
+	}
+}
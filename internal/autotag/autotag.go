@@ -0,0 +1,312 @@
+// Package autotag は記事の内容からタグを提案し、自動で付与する
+//
+// 既存のタグは記事に付与したうえで提案として記録し（承認・却下で確定）、
+// 新しいタグの候補は付与せずに提案だけを記録する（承認するとタグを作成して付与）。
+package autotag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/summarize"
+)
+
+// 提案の方法
+const (
+	MethodKeyword = "keyword" // 既存タグの出現とキーワード抽出
+	MethodLLM     = "llm"     // LLM（OpenAI互換API）
+)
+
+const (
+	// maxAttached は1記事に自動で付与する既存タグの最大数
+	maxAttached = 5
+	// maxNew は1記事に提案する新しいタグの最大数
+	maxNew = 5
+	// minTagScore は既存タグを付与する最小スコア（タイトルに1回、または本文に2回）
+	minTagScore = 2
+	// minKeywordScore は新しいタグとして提案するキーワードの最小スコア
+	minKeywordScore = 3
+	// maxLLMRunes はLLMに渡す本文の最大文字数
+	maxLLMRunes = 4000
+	// maxLLMTags はLLMに渡す既存タグの最大数
+	maxLLMTags = 200
+)
+
+const llmPrompt = `あなたは記事にタグを付けるアシスタントです。
+記事の主題を表すタグを最大5個、重要な順に JSON の文字列配列だけで答えてください（例: ["Go", "データベース"]）。
+- 既存のタグに当てはまるものがあれば、既存のタグ名をそのまま使う
+- タグは短い名詞（1〜3語）にする`
+
+// ProgressCallback は進捗を報告するコールバック
+type ProgressCallback func(progress int, step string)
+
+// Tagger は自動タグ付けジョブを処理する
+type Tagger struct {
+	client      *summarize.Client
+	articleRepo *storage.ArticleRepository
+	tagRepo     *storage.TagRepository
+	sourceRepo  *storage.SourceRepository
+	jobRepo     *storage.JobRepository
+}
+
+// NewTagger は新しいTaggerを作成
+// client が nil の場合はキーワード抽出で提案する
+func NewTagger(
+	client *summarize.Client,
+	articleRepo *storage.ArticleRepository,
+	tagRepo *storage.TagRepository,
+	sourceRepo *storage.SourceRepository,
+	jobRepo *storage.JobRepository,
+) *Tagger {
+	return &Tagger{
+		client:      client,
+		articleRepo: articleRepo,
+		tagRepo:     tagRepo,
+		sourceRepo:  sourceRepo,
+		jobRepo:     jobRepo,
+	}
+}
+
+// Method は提案の方法を返す
+func (t *Tagger) Method() string {
+	if t.client != nil {
+		return MethodLLM
+	}
+	return MethodKeyword
+}
+
+// CreateJob は記事の自動タグ付けジョブを作成
+// ジョブはソース単位のため、ソースを持たない記事には記事用のソースを作成して紐付ける
+func (t *Tagger) CreateJob(ctx context.Context, articleID string, priority int) (string, error) {
+	article, err := t.articleRepo.GetByID(ctx, articleID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get article: %w", err)
+	}
+	if article == nil {
+		return "", fmt.Errorf("article not found: %s", articleID)
+	}
+	if err := t.articleRepo.CheckHold(ctx, article.ID); err != nil {
+		return "", err
+	}
+
+	if article.SourceID == nil {
+		metadata, _ := json.Marshal(map[string]string{"article_id": article.ID})
+		source := &sqlc.Source{
+			Type:     storage.SourceTypeArticle,
+			Metadata: storage.Ptr(string(metadata)),
+			Status:   storage.Ptr(storage.SourceStatusCompleted),
+			OwnerID:  article.OwnerID,
+		}
+		if err := t.sourceRepo.Create(ctx, source); err != nil {
+			return "", fmt.Errorf("failed to create source: %w", err)
+		}
+		article.SourceID = &source.ID
+		if err := t.articleRepo.Update(ctx, article); err != nil {
+			return "", fmt.Errorf("failed to link article to source: %w", err)
+		}
+	}
+
+	job := &sqlc.ProcessingJob{
+		SourceID: article.SourceID,
+		Type:     storage.JobTypeAutoTag,
+		Priority: storage.Ptr(int64(priority)),
+	}
+	if err := t.jobRepo.Create(ctx, job); err != nil {
+		return "", fmt.Errorf("failed to create job: %w", err)
+	}
+	return job.ID, nil
+}
+
+// ProcessJob は自動タグ付けジョブを処理する（ワーカーから呼ばれる）
+// ソースに紐づく各記事のタグを提案し、既存タグは記事に付与する。リーガルホールド中の記事は変更しない
+func (t *Tagger) ProcessJob(ctx context.Context, job *sqlc.ProcessingJob, onProgress ProgressCallback) error {
+	if job.SourceID == nil {
+		return fmt.Errorf("job has no source ID")
+	}
+	reportProgress := func(progress int, step string) {
+		if onProgress != nil {
+			onProgress(progress, step)
+		}
+	}
+
+	articles, err := t.articleRepo.GetBySourceID(ctx, *job.SourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get articles: %w", err)
+	}
+
+	for idx := range articles {
+		article := &articles[idx]
+		reportProgress(10+80*idx/len(articles), "suggesting tags")
+
+		if err := t.articleRepo.CheckHold(ctx, article.ID); err != nil {
+			if errors.Is(err, storage.ErrLegalHold) {
+				continue
+			}
+			return err
+		}
+		if _, err := t.Apply(ctx, article); err != nil {
+			return fmt.Errorf("failed to tag article %s: %w", article.ID, err)
+		}
+	}
+
+	reportProgress(100, "")
+	return nil
+}
+
+// Apply は記事のタグを提案して保存し、新しく保存した提案を返す
+// 既に提案済み（却下済みを含む）の名前は保存しない
+func (t *Tagger) Apply(ctx context.Context, article *sqlc.Article) ([]sqlc.TagSuggestion, error) {
+	existing, err := t.tagRepo.List(ctx, 10000, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	tags := make(map[string]sqlc.Tag, len(existing))
+	names := make([]string, 0, len(existing))
+	for _, tag := range existing {
+		tags[strings.ToLower(tag.Name)] = tag
+		names = append(names, tag.Name)
+	}
+
+	candidates, err := t.Suggest(ctx, article, names)
+	if err != nil {
+		return nil, err
+	}
+
+	method := t.Method()
+	var created []sqlc.TagSuggestion
+	attached, proposed := 0, 0
+	for _, c := range candidates {
+		s := sqlc.TagSuggestion{ArticleID: article.ID, Name: c.Name, Score: c.Score, Method: method}
+		if tag, ok := tags[strings.ToLower(c.Name)]; ok {
+			if attached >= maxAttached {
+				continue
+			}
+			// 既存タグの表記に揃える
+			s.Name, s.TagID = tag.Name, &tag.ID
+			attached++
+		} else {
+			if proposed >= maxNew {
+				continue
+			}
+			proposed++
+		}
+		ok, err := t.tagRepo.CreateSuggestion(ctx, &s)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			created = append(created, s)
+		}
+	}
+	return created, nil
+}
+
+// Suggest は記事のタグの候補をスコアの高い順に返す（保存はしない）
+func (t *Tagger) Suggest(ctx context.Context, article *sqlc.Article, tagNames []string) ([]Candidate, error) {
+	if t.client != nil {
+		return t.suggestLLM(ctx, article, tagNames)
+	}
+
+	var candidates []Candidate
+	seen := make(map[string]bool)
+	for _, c := range matchTags(tagNames, article.Title, article.Content) {
+		if c.Score >= minTagScore {
+			candidates = append(candidates, c)
+			seen[strings.ToLower(c.Name)] = true
+		}
+	}
+	for _, c := range extractKeywords(article.Title, article.Content) {
+		if c.Score < minKeywordScore {
+			break
+		}
+		if !seen[strings.ToLower(c.Name)] {
+			candidates = append(candidates, c)
+			seen[strings.ToLower(c.Name)] = true
+		}
+	}
+	return candidates, nil
+}
+
+// suggestLLM はLLMにタグを選ばせる（スコアは回答の順に 1.0, 0.9, ...）
+func (t *Tagger) suggestLLM(ctx context.Context, article *sqlc.Article, tagNames []string) ([]Candidate, error) {
+	content := []rune(strings.TrimSpace(article.Content))
+	if len(content) > maxLLMRunes {
+		content = content[:maxLLMRunes]
+	}
+	if len(tagNames) > maxLLMTags {
+		tagNames = tagNames[:maxLLMTags]
+	}
+
+	var user strings.Builder
+	if len(tagNames) > 0 {
+		user.WriteString("既存のタグ: " + strings.Join(tagNames, ", ") + "\n\n")
+	}
+	user.WriteString("タイトル: " + article.Title + "\n\n" + string(content))
+
+	answer, err := t.client.Complete(ctx, []summarize.Message{
+		{Role: "system", Content: llmPrompt},
+		{Role: "user", Content: user.String()},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := strings.Index(answer, "["), strings.LastIndex(answer, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("LLM did not return a JSON array: %q", answer)
+	}
+	var names []string
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &names); err != nil {
+		return nil, fmt.Errorf("invalid LLM answer %q: %w", answer, err)
+	}
+
+	var candidates []Candidate
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		candidates = append(candidates, Candidate{Name: name, Score: 1 - 0.1*float64(len(candidates))})
+	}
+	return candidates, nil
+}
+
+// Run は新しく作成された記事の自動タグ付けジョブを interval ごとに作成する
+// 起動時刻より前に作成された記事は対象にしない（個別に CreateJob で実行する）
+func (t *Tagger) Run(ctx context.Context, interval time.Duration) {
+	since := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.queueNew(ctx, since)
+		}
+	}
+}
+
+// queueNew は since 以降に作成され、まだ自動タグ付けしていない記事のジョブを作成する
+func (t *Tagger) queueNew(ctx context.Context, since time.Time) {
+	ids, err := t.tagRepo.ListArticlesForAutoTag(ctx, since, 50)
+	if err != nil {
+		slog.Error("Auto-tag: failed to list articles", "error", err)
+		return
+	}
+	for _, id := range ids {
+		if _, err := t.CreateJob(ctx, id, storage.JobPriorityBatch); err != nil && !errors.Is(err, storage.ErrLegalHold) {
+			slog.Error("Auto-tag: failed to create job", "article_id", id, "error", err)
+		}
+	}
+}
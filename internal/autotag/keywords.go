@@ -0,0 +1,160 @@
+package autotag
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// titleWeight はタイトル中の出現に掛ける重み
+const titleWeight = 3
+
+// stopWords はキーワードにしない英単語
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true, "this": true,
+	"from": true, "are": true, "was": true, "were": true, "have": true, "has": true,
+	"not": true, "but": true, "you": true, "your": true, "can": true, "will": true,
+	"http": true, "https": true, "www": true, "com": true, "html": true,
+}
+
+// Candidate はタグの候補とスコア
+type Candidate struct {
+	Name  string
+	Score float64
+}
+
+// matchTags は既存タグのうちタイトル・本文に現れるものを出現回数でスコア付けする
+// （大文字小文字を区別しない。タイトル中の出現は titleWeight 倍）
+// 英数字のタグは単語の途中には一致させない（"Go" は "Google" に一致しない）
+func matchTags(names []string, title, content string) []Candidate {
+	title, content = strings.ToLower(title), strings.ToLower(content)
+	var candidates []Candidate
+	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if len([]rune(key)) < 2 {
+			continue
+		}
+		count := strings.Count
+		if isASCII(key) {
+			re := regexp.MustCompile(wordBoundary(key[0]) + regexp.QuoteMeta(key) + wordBoundary(key[len(key)-1]))
+			count = func(s, _ string) int { return len(re.FindAllStringIndex(s, -1)) }
+		}
+		score := titleWeight*count(title, key) + count(content, key)
+		if score > 0 {
+			candidates = append(candidates, Candidate{Name: name, Score: float64(score)})
+		}
+	}
+	sortCandidates(candidates)
+	return candidates
+}
+
+// Keywords はタイトル・本文の頻出キーワードをスコアの高い順に返す（記事の分析でも使う）
+func Keywords(title, content string) []Candidate {
+	return extractKeywords(title, content)
+}
+
+// extractKeywords はタイトル・本文からキーワードを抽出し、出現回数でスコア付けする
+// カタカナ語（3文字以上）、漢字の連続（2〜8文字）、英数字の語（3文字以上）を候補とする
+func extractKeywords(title, content string) []Candidate {
+	counts := make(map[string]int)
+	names := make(map[string]string) // 小文字 → 最初に現れた表記
+	add := func(text string, weight int) {
+		for _, term := range terms(text) {
+			key := strings.ToLower(term)
+			if _, ok := names[key]; !ok {
+				names[key] = term
+			}
+			counts[key] += weight
+		}
+	}
+	add(title, titleWeight)
+	add(content, 1)
+
+	candidates := make([]Candidate, 0, len(counts))
+	for key, count := range counts {
+		candidates = append(candidates, Candidate{Name: names[key], Score: float64(count)})
+	}
+	sortCandidates(candidates)
+	return candidates
+}
+
+// terms はテキストを文字種の連続で区切り、キーワード候補の語を返す
+func terms(text string) []string {
+	var out []string
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		kind := runeKind(runes[i])
+		j := i + 1
+		for j < len(runes) && (runeKind(runes[j]) == kind || (kind == kindKatakana && runes[j] == 'ー')) {
+			j++
+		}
+		term := string(runes[i:j])
+		n := j - i
+		switch kind {
+		case kindKatakana:
+			if n >= 3 {
+				out = append(out, term)
+			}
+		case kindKanji:
+			if n >= 2 && n <= 8 {
+				out = append(out, term)
+			}
+		case kindLatin:
+			if n >= 3 && !stopWords[strings.ToLower(term)] && unicode.IsLetter(runes[i]) {
+				out = append(out, term)
+			}
+		}
+		i = j
+	}
+	return out
+}
+
+const (
+	kindOther = iota
+	kindKatakana
+	kindKanji
+	kindLatin
+)
+
+// runeKind は文字の種類を返す
+func runeKind(r rune) int {
+	switch {
+	case unicode.Is(unicode.Katakana, r):
+		return kindKatakana
+	case unicode.Is(unicode.Han, r):
+		return kindKanji
+	case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+		return kindLatin
+	default:
+		return kindOther
+	}
+}
+
+// wordBoundary は英数字の前後に置く単語境界（記号には境界を付けない: "C++"）
+func wordBoundary(c byte) string {
+	if c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') {
+		return `\b`
+	}
+	return ""
+}
+
+// isASCII は文字列がASCII文字だけで構成されているかを返す
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r >= unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+// sortCandidates はスコアの高い順（同点は名前順）に並べる
+func sortCandidates(candidates []Candidate) {
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+}
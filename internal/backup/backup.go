@@ -0,0 +1,305 @@
+// Package backup は同じインスタンスを復旧するためのバックアップの作成・復元を提供する
+//
+// バックアップは tar.gz で、次の順に格納する
+//   - manifest.json: 形式のバージョン、作成日時、スキーマのバージョン、ソースディレクトリを含むか
+//   - zbor.db: データベースのスナップショット（SQLite のオンラインバックアップ API で作成）
+//   - sources/...: データディレクトリの sources/ 以下のファイル（IncludeSources の場合のみ）
+//
+// ライブラリのエクスポート（internal/library）と違い、ジョブ・ユーザー・APIキーを含めて
+// データベースをそのまま戻す。別のインスタンスへの移行には library を使う
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"zbor/internal/storage"
+	"zbor/internal/version"
+)
+
+// FormatVersion はバックアップ形式のバージョン
+const FormatVersion = 1
+
+// バックアップ内のパス
+const (
+	manifestName = "manifest.json"
+	databaseName = "zbor.db"
+	sourcesDir   = "sources/"
+)
+
+// Manifest はバックアップの内容
+type Manifest struct {
+	FormatVersion int       `json:"format_version"`
+	Version       string    `json:"version"` // 作成した zbor のバージョン
+	CreatedAt     time.Time `json:"created_at"`
+	SchemaVersion int       `json:"schema_version"`
+	DatabaseSize  int64     `json:"database_size"`
+	Sources       bool      `json:"sources"` // sources/ を含むか
+	Files         int       `json:"files"`   // sources/ のファイル数
+	FilesSize     int64     `json:"files_size"`
+}
+
+// Options はバックアップのオプション
+type Options struct {
+	IncludeSources bool // データディレクトリの sources/ を含める（音声・成果物）
+}
+
+// RestoreReport は復元の結果
+type RestoreReport struct {
+	Manifest     *Manifest
+	FilesWritten int
+	Migrations   []storage.Migration // 復元後に適用したマイグレーション
+}
+
+// Create はバックアップを w に tar.gz で書き出す
+// スナップショットは dataDir 内の一時ディレクトリに作成してから格納する
+func Create(ctx context.Context, db *storage.DB, dataDir string, w io.Writer, opts Options) (*Manifest, error) {
+	tmpDir, err := os.MkdirTemp(dataDir, ".backup-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	snapshot := filepath.Join(tmpDir, databaseName)
+	if err := db.Snapshot(ctx, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	status, err := storage.CheckSnapshot(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		Version:       version.Version,
+		CreatedAt:     time.Now(),
+		SchemaVersion: status.Version,
+		DatabaseSize:  info.Size(),
+		Sources:       opts.IncludeSources,
+	}
+	var files []string
+	if opts.IncludeSources {
+		files, err = sourceFiles(dataDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list source files: %w", err)
+		}
+		manifest.Files = len(files)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: manifestName, Mode: 0644, Size: int64(len(data)), ModTime: manifest.CreatedAt}); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, err
+	}
+	if _, err := writeFile(tw, databaseName, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to add database: %w", err)
+	}
+	for _, p := range files {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		rel, err := filepath.Rel(dataDir, p)
+		if err != nil {
+			return nil, err
+		}
+		size, err := writeFile(tw, filepath.ToSlash(rel), p)
+		if os.IsNotExist(err) {
+			continue // 列挙後に削除された
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s: %w", p, err)
+		}
+		manifest.FilesSize += size
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Restore はバックアップからデータベースと sources/ のファイルを復元する
+// サーバーを停止してから実行する。ファイルは上書きし、バックアップに無いファイルは残す。
+// データベースはアーカイブを最後まで読めた場合だけ置き換える（ファイルの途中で失敗した場合は書き込んだファイルだけ残る）
+func Restore(ctx context.Context, db *storage.DB, dataDir string, r io.Reader) (*RestoreReport, error) {
+	dataDir, err := filepath.Abs(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	tmpDir, err := os.MkdirTemp(dataDir, ".restore-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	report := &RestoreReport{}
+	snapshot := ""
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup: %w", err)
+		}
+		switch {
+		case hdr.Name == manifestName:
+			var m Manifest
+			if err := json.NewDecoder(tr).Decode(&m); err != nil {
+				return nil, fmt.Errorf("failed to read manifest: %w", err)
+			}
+			if m.FormatVersion < 1 || m.FormatVersion > FormatVersion {
+				return nil, fmt.Errorf("unsupported backup format version %d", m.FormatVersion)
+			}
+			report.Manifest = &m
+
+		case hdr.Name == databaseName:
+			if report.Manifest == nil {
+				return nil, fmt.Errorf("not a backup: %s is missing", manifestName)
+			}
+			snapshot = filepath.Join(tmpDir, databaseName)
+			if err := writeTarget(snapshot, tr); err != nil {
+				return nil, err
+			}
+			status, err := storage.CheckSnapshot(ctx, snapshot)
+			if err != nil {
+				return nil, err
+			}
+			if status.Version > status.Latest {
+				return nil, fmt.Errorf("the backup was created by a newer version of zbor (schema version %d, this binary supports %d)", status.Version, status.Latest)
+			}
+
+		case strings.HasPrefix(hdr.Name, sourcesDir) && hdr.Typeflag == tar.TypeReg:
+			if snapshot == "" {
+				return nil, fmt.Errorf("not a backup: %s is missing", databaseName)
+			}
+			if path.Clean(hdr.Name) != hdr.Name {
+				return nil, fmt.Errorf("unexpected backup entry: %s", hdr.Name)
+			}
+			target := filepath.Join(dataDir, filepath.FromSlash(hdr.Name))
+			if rel, err := filepath.Rel(dataDir, target); err != nil || strings.HasPrefix(rel, "..") {
+				return nil, fmt.Errorf("unexpected backup entry: %s", hdr.Name)
+			}
+			if err := writeTarget(target, tr); err != nil {
+				return nil, err
+			}
+			report.FilesWritten++
+		}
+	}
+	if snapshot == "" {
+		return nil, fmt.Errorf("not a backup: %s is missing", databaseName)
+	}
+
+	report.Migrations, err = db.RestoreSnapshot(ctx, snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore database: %w", err)
+	}
+	return report, nil
+}
+
+// sourceFiles はデータディレクトリの sources/ 以下のファイルを列挙する（書き込み途中の一時ファイルは除く）
+func sourceFiles(dataDir string) ([]string, error) {
+	root := filepath.Join(dataDir, "sources")
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) && p == root {
+			return fs.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || strings.HasSuffix(p, ".tmp") || strings.HasSuffix(p, ".part") {
+			return nil
+		}
+		files = append(files, p)
+		return nil
+	})
+	return files, err
+}
+
+// writeFile はファイルの内容を name で格納し、サイズを返す
+func writeFile(tw *tar.Writer, name, filePath string) (int64, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	// サイズは開いた時点のものを使う（列挙後に書き換えられても壊れないように）
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	_, err = io.CopyN(tw, f, info.Size())
+	return info.Size(), err
+}
+
+// writeTarget は r の内容を target に書き込む（一時ファイルに書いてから名前を変える）
+func writeTarget(target string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	tmp := target + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
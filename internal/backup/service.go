@@ -0,0 +1,289 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// ErrNotFound は指定した名前のバックアップが無い場合のエラー
+var ErrNotFound = errors.New("backup not found")
+
+// ファイル名（zbor-backup-20060102-150405.tar.gz）
+const (
+	filePrefix = "zbor-backup-"
+	fileSuffix = ".tar.gz"
+	timeLayout = "20060102-150405"
+)
+
+// File は保存先のバックアップ
+type File struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// jobMetadata は backup ジョブのメタデータ（オプションと作成したファイル）
+type jobMetadata struct {
+	IncludeSources bool   `json:"include_sources"`
+	File           string `json:"file,omitempty"`
+}
+
+// Service はバックアップを backup ジョブとして作成し、保存先のディレクトリで管理する
+// 定期バックアップは Run が間隔ごとにジョブを作成し、古いものは保持数を超えた分から削除する
+type Service struct {
+	db       *storage.DB
+	jobRepo  *storage.JobRepository
+	dataDir  string
+	dir      string
+	interval time.Duration // 定期バックアップの間隔（0 = 無効）
+	keep     int           // 保持するバックアップの数（0 = 全て）
+	sources  bool          // 定期バックアップに sources/ を含める
+}
+
+// NewService は新しいServiceを作成（dir はバックアップの保存先）
+func NewService(db *storage.DB, jobRepo *storage.JobRepository, dataDir, dir string) (*Service, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	return &Service{db: db, jobRepo: jobRepo, dataDir: dataDir, dir: dir}, nil
+}
+
+// SetSchedule は定期バックアップの間隔（0 で無効）と sources/ を含めるかを設定
+func (s *Service) SetSchedule(interval time.Duration, includeSources bool) {
+	s.interval = interval
+	s.sources = includeSources
+}
+
+// SetKeep は保持するバックアップの数を設定（0 = 削除しない）
+func (s *Service) SetKeep(keep int) {
+	s.keep = keep
+}
+
+// Dir はバックアップの保存先
+func (s *Service) Dir() string {
+	return s.dir
+}
+
+// Queue は backup ジョブを作成し、ジョブIDを返す（待機中・実行中のジョブがあればそのID）
+func (s *Service) Queue(ctx context.Context, opts Options, priority int) (string, error) {
+	id, _, err := s.queue(ctx, opts, priority)
+	return id, err
+}
+
+// queue はジョブを作成した（既存のジョブではない）かも返す
+func (s *Service) queue(ctx context.Context, opts Options, priority int) (string, bool, error) {
+	for _, status := range []string{storage.JobStatusRunning, storage.JobStatusQueued} {
+		jobs, err := s.jobRepo.ListByStatus(ctx, status, 1000)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to list jobs: %w", err)
+		}
+		for _, job := range jobs {
+			if job.Type == storage.JobTypeBackup {
+				return job.ID, false, nil
+			}
+		}
+	}
+
+	metadata, _ := json.Marshal(jobMetadata{IncludeSources: opts.IncludeSources})
+	job := &sqlc.ProcessingJob{
+		Type:     storage.JobTypeBackup,
+		Priority: storage.Ptr(int64(priority)),
+		Metadata: storage.Ptr(string(metadata)),
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return "", false, fmt.Errorf("failed to create job: %w", err)
+	}
+	return job.ID, true, nil
+}
+
+// ProcessJob は backup ジョブを処理する（作成したファイル名はジョブのメタデータ "file" に記録）
+func (s *Service) ProcessJob(ctx context.Context, job *sqlc.ProcessingJob, reportProgress func(int, string)) error {
+	var metadata jobMetadata
+	if job.Metadata != nil {
+		if err := json.Unmarshal([]byte(*job.Metadata), &metadata); err != nil {
+			return fmt.Errorf("invalid job metadata: %w", err)
+		}
+	}
+
+	step := "backing up database"
+	if metadata.IncludeSources {
+		step = "backing up database and sources"
+	}
+	reportProgress(10, step)
+	file, manifest, err := s.Create(ctx, Options{IncludeSources: metadata.IncludeSources})
+	if err != nil {
+		return err
+	}
+	slog.Info("Created backup", "file", file.Name, "bytes", file.Size, "files", manifest.Files)
+
+	metadata.File = file.Name
+	metadataJSON, _ := json.Marshal(metadata)
+	if err := s.jobRepo.UpdateMetadata(ctx, job.ID, storage.Ptr(string(metadataJSON))); err != nil {
+		return fmt.Errorf("failed to update job metadata: %w", err)
+	}
+
+	reportProgress(90, "removing old backups")
+	if n, err := s.Prune(); err != nil {
+		slog.Error("Failed to remove old backups", "error", err)
+	} else if n > 0 {
+		slog.Info("Removed old backups", "count", n)
+	}
+	reportProgress(100, "completed")
+	return nil
+}
+
+// Create はバックアップを保存先に作成する（一時ファイルに書いてから名前を変える）
+func (s *Service) Create(ctx context.Context, opts Options) (*File, *Manifest, error) {
+	now := time.Now()
+	name := filePrefix + now.Format(timeLayout) + fileSuffix
+	target := filepath.Join(s.dir, name)
+
+	tmp := target + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest, err := Create(ctx, s.db, s.dataDir, f, opts)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, nil, err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return nil, nil, err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &File{Name: name, Size: info.Size(), CreatedAt: now}, manifest, nil
+}
+
+// List は保存先のバックアップを新しい順に返す
+func (s *Service) List() ([]File, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	for _, e := range entries {
+		createdAt, ok := parseName(e.Name())
+		if !ok || !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, File{Name: e.Name(), Size: info.Size(), CreatedAt: createdAt})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].CreatedAt.After(files[j].CreatedAt) })
+	return files, nil
+}
+
+// Path はバックアップのファイルパスを返す（名前がバックアップの形式でない、存在しない場合は ErrNotFound）
+func (s *Service) Path(name string) (string, error) {
+	if _, ok := parseName(name); !ok || filepath.Base(name) != name {
+		return "", ErrNotFound
+	}
+	p := filepath.Join(s.dir, name)
+	if _, err := os.Stat(p); os.IsNotExist(err) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// Delete はバックアップを削除
+func (s *Service) Delete(name string) error {
+	p, err := s.Path(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(p)
+}
+
+// Prune は保持数を超えた古いバックアップを削除し、削除した数を返す
+func (s *Service) Prune() (int, error) {
+	if s.keep <= 0 {
+		return 0, nil
+	}
+	files, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for i := s.keep; i < len(files); i++ {
+		if err := os.Remove(filepath.Join(s.dir, files[i].Name)); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Run は check ごとに最新のバックアップを確認し、間隔を過ぎていれば backup ジョブを作成（ctx がキャンセルされるまで）
+func (s *Service) Run(ctx context.Context, check time.Duration) {
+	if s.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(check)
+	defer ticker.Stop()
+
+	for {
+		if queued, err := s.QueueDue(ctx); err != nil {
+			slog.Error("Backup scheduling failed", "error", err)
+		} else if queued {
+			slog.Info("Queued scheduled backup", "sources", s.sources)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// QueueDue は最新のバックアップから間隔を過ぎていれば backup ジョブを作成し、作成したかを返す
+func (s *Service) QueueDue(ctx context.Context) (bool, error) {
+	if s.interval <= 0 {
+		return false, nil
+	}
+	files, err := s.List()
+	if err != nil {
+		return false, err
+	}
+	if len(files) > 0 && time.Since(files[0].CreatedAt) < s.interval {
+		return false, nil
+	}
+	_, created, err := s.queue(ctx, Options{IncludeSources: s.sources}, storage.JobPriorityBatch)
+	return created, err
+}
+
+// parseName はバックアップのファイル名から作成日時を取得
+func parseName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix), time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
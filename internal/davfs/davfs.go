@@ -0,0 +1,328 @@
+package davfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+
+	"golang.org/x/net/webdav"
+)
+
+// Methods はWebDAVで受け付けるメソッド（読み取り専用）
+var Methods = []string{http.MethodGet, http.MethodHead, http.MethodOptions, "PROPFIND"}
+
+// exportFormats はソースフォルダに置くファイルの形式
+var exportFormats = []string{"txt", "srt", "vtt", "json"}
+
+// FS は文字起こしのエクスポートを読み取り専用のファイルシステムとして公開する
+//
+//	/<日付> <タイトル> [<ソースID>]/transcript.txt|srt|vtt|json
+//
+// ファイルの内容は開くたびに文字起こしから生成する（ディスクには書き出さない）
+type FS struct {
+	sourceRepo   *storage.SourceRepository
+	artifactRepo *storage.ArtifactRepository
+	redactor     *asr.Redactor // 文字起こしを伏せ字にする（nil なら伏せない）
+}
+
+// New は新しいFSを作成
+func New(sourceRepo *storage.SourceRepository, artifactRepo *storage.ArtifactRepository) *FS {
+	return &FS{
+		sourceRepo:   sourceRepo,
+		artifactRepo: artifactRepo,
+	}
+}
+
+// SetRedactor は公開する文字起こしを伏せ字にする（ZBOR_REDACT=1。WebDAV には ?redact=0 が無いため admin も伏せ字になる）
+func (fs *FS) SetRedactor(redactor *asr.Redactor) {
+	fs.redactor = redactor
+}
+
+// Handler は prefix 以下でWebDAVを提供するハンドラーを返す
+func (fs *FS) Handler(prefix string) http.Handler {
+	return &webdav.Handler{
+		Prefix:     prefix,
+		FileSystem: fs,
+		LockSystem: webdav.NewMemLS(),
+	}
+}
+
+// Mkdir は読み取り専用のため常に失敗する
+func (fs *FS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	return os.ErrPermission
+}
+
+// RemoveAll は読み取り専用のため常に失敗する
+func (fs *FS) RemoveAll(ctx context.Context, name string) error {
+	return os.ErrPermission
+}
+
+// Rename は読み取り専用のため常に失敗する
+func (fs *FS) Rename(ctx context.Context, oldName, newName string) error {
+	return os.ErrPermission
+}
+
+// OpenFile はファイル・フォルダを開く（書き込みフラグ付きの場合は失敗）
+func (fs *FS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return nil, os.ErrPermission
+	}
+
+	folder, file := splitName(name)
+	if folder == "" {
+		return fs.openRoot(ctx)
+	}
+
+	source, transcript, err := fs.load(ctx, folder)
+	if err != nil {
+		return nil, err
+	}
+	if file == "" {
+		return &dirFile{info: dirInfo(folder, source.CreatedAt), children: transcriptFiles(source, transcript)}, nil
+	}
+
+	format, ok := fileFormat(file)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	content, err := exportTranscript(transcript, format)
+	if err != nil {
+		return nil, err
+	}
+	return &contentFile{
+		Reader: bytes.NewReader(content),
+		info:   &fileInfo{name: file, size: int64(len(content)), modTime: source.CreatedAt},
+	}, nil
+}
+
+// Stat はファイル・フォルダの情報を取得
+func (fs *FS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Stat()
+}
+
+// openRoot は文字起こしのあるソースのフォルダ一覧を開く
+func (fs *FS) openRoot(ctx context.Context) (webdav.File, error) {
+	sources, err := fs.sourceRepo.ListTranscribed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]os.FileInfo, 0, len(sources))
+	modTime := time.Time{}
+	for _, source := range sources {
+		children = append(children, dirInfo(folderName(&source), source.CreatedAt))
+		if source.CreatedAt.After(modTime) {
+			modTime = source.CreatedAt
+		}
+	}
+	return &dirFile{info: dirInfo("/", modTime), children: children}, nil
+}
+
+// load はフォルダ名のソースと文字起こしを取得
+// フォルダ名はソースIDのみで照合する（タイトルが変わっても同じパスで開ける）
+func (fs *FS) load(ctx context.Context, folder string) (*sqlc.Source, *asr.Result, error) {
+	sourceID, ok := parseFolderName(folder)
+	if !ok {
+		return nil, nil, os.ErrNotExist
+	}
+	source, err := fs.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if source == nil {
+		return nil, nil, os.ErrNotExist
+	}
+
+	artifacts, err := fs.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, artifact := range artifacts {
+		if artifact.Type == storage.ArtifactTypeTranscription && artifact.Content != nil {
+			var result asr.Result
+			if err := json.Unmarshal([]byte(*artifact.Content), &result); err != nil {
+				return nil, nil, err
+			}
+			if fs.redactor != nil {
+				return source, fs.redactor.Redact(&result), nil
+			}
+			return source, &result, nil
+		}
+	}
+	return nil, nil, os.ErrNotExist
+}
+
+// transcriptFiles はソースフォルダ内のファイル一覧を作成
+func transcriptFiles(source *sqlc.Source, transcript *asr.Result) []os.FileInfo {
+	files := make([]os.FileInfo, 0, len(exportFormats))
+	for _, format := range exportFormats {
+		content, err := exportTranscript(transcript, format)
+		if err != nil {
+			continue
+		}
+		files = append(files, &fileInfo{
+			name:    "transcript." + format,
+			size:    int64(len(content)),
+			modTime: source.CreatedAt,
+		})
+	}
+	return files
+}
+
+// exportTranscript は文字起こしを指定形式で出力（話者ラベル付き）
+func exportTranscript(transcript *asr.Result, format string) ([]byte, error) {
+	output, err := transcript.Export(format, asr.ExportOptions{SpeakerPrefix: true})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(output), nil
+}
+
+// folderName はソースのフォルダ名（"2006-01-02 タイトル [ソースID]"）
+func folderName(source *sqlc.Source) string {
+	title := ""
+	if source.Metadata != nil {
+		var metadata struct {
+			Title string `json:"title"`
+		}
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err == nil {
+			title = sanitizeName(metadata.Title)
+		}
+	}
+	name := source.CreatedAt.Format("2006-01-02")
+	if title != "" {
+		name += " " + title
+	}
+	return name + " [" + source.ID + "]"
+}
+
+// parseFolderName はフォルダ名からソースIDを取り出す
+func parseFolderName(folder string) (string, bool) {
+	if !strings.HasSuffix(folder, "]") {
+		return "", false
+	}
+	start := strings.LastIndex(folder, "[")
+	if start < 0 {
+		return "", false
+	}
+	return folder[start+1 : len(folder)-1], true
+}
+
+// fileFormat はファイル名から出力形式を取得
+func fileFormat(file string) (string, bool) {
+	for _, format := range exportFormats {
+		if file == "transcript."+format {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// sanitizeName はファイル名に使えない文字を置き換える
+func sanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', '[', ']':
+			return '_'
+		}
+		if r < 0x20 {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if runes := []rune(name); len(runes) > 80 {
+		name = string(runes[:80])
+	}
+	return name
+}
+
+// splitName はパスをフォルダ名とファイル名に分割（それより深いパスは存在しない）
+func splitName(name string) (string, string) {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return "", ""
+	}
+	folder, file, _ := strings.Cut(name, "/")
+	return folder, file
+}
+
+// fileInfo は os.FileInfo の実装
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func dirInfo(name string, modTime time.Time) *fileInfo {
+	return &fileInfo{name: name, modTime: modTime, dir: true}
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.dir }
+func (fi *fileInfo) Sys() interface{}   { return nil }
+
+func (fi *fileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0555
+	}
+	return 0444
+}
+
+// dirFile はフォルダ（webdav.File）
+type dirFile struct {
+	info     *fileInfo
+	children []os.FileInfo
+	pos      int
+}
+
+func (d *dirFile) Close() error                                 { return nil }
+func (d *dirFile) Read(p []byte) (int, error)                   { return 0, os.ErrInvalid }
+func (d *dirFile) Seek(offset int64, whence int) (int64, error) { return 0, os.ErrInvalid }
+func (d *dirFile) Write(p []byte) (int, error)                  { return 0, os.ErrPermission }
+func (d *dirFile) Stat() (os.FileInfo, error)                   { return d.info, nil }
+
+func (d *dirFile) Readdir(count int) ([]os.FileInfo, error) {
+	rest := d.children[d.pos:]
+	if count <= 0 {
+		d.pos = len(d.children)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(rest) {
+		count = len(rest)
+	}
+	d.pos += count
+	return rest[:count], nil
+}
+
+// contentFile はメモリ上のファイル（webdav.File）
+type contentFile struct {
+	*bytes.Reader
+	info *fileInfo
+}
+
+func (f *contentFile) Close() error                             { return nil }
+func (f *contentFile) Write(p []byte) (int, error)              { return 0, os.ErrPermission }
+func (f *contentFile) Stat() (os.FileInfo, error)               { return f.info, nil }
+func (f *contentFile) Readdir(count int) ([]os.FileInfo, error) { return nil, os.ErrInvalid }
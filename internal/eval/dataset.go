@@ -0,0 +1,55 @@
+package eval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"zbor/internal/asr"
+)
+
+// Item is one audio file of a dataset and its reference transcript
+type Item struct {
+	Name          string // audio file name relative to the dataset directory
+	AudioPath     string
+	ReferencePath string
+	Reference     string
+}
+
+// LoadDataset returns the audio files of dir (see asr.SupportedFormats) that
+// have a reference transcript with the same base name and a .txt extension
+// (talk.mp3 and talk.txt), sorted by name. Audio files without a reference
+// are skipped; an empty dataset is an error
+func LoadDataset(dir string) ([]Item, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+
+	var items []Item
+	for _, entry := range entries {
+		if entry.IsDir() || !asr.IsSupportedFormat(entry.Name()) {
+			continue
+		}
+		audioPath := filepath.Join(dir, entry.Name())
+		referencePath := strings.TrimSuffix(audioPath, filepath.Ext(audioPath)) + ".txt"
+		data, err := os.ReadFile(referencePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read reference: %w", err)
+		}
+		items = append(items, Item{
+			Name:          entry.Name(),
+			AudioPath:     audioPath,
+			ReferencePath: referencePath,
+			Reference:     strings.TrimSpace(string(data)),
+		})
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no audio files with a reference transcript in %s", dir)
+	}
+	return items, nil
+}
@@ -0,0 +1,112 @@
+// Package eval scores ASR output against reference transcripts: the CER and
+// WER of each file of a dataset with their substitution/insertion/deletion
+// breakdown and the real-time factor, for comparing models and decoding
+// parameters (zbor eval)
+package eval
+
+import (
+	"context"
+	"time"
+
+	"zbor/internal/asr"
+)
+
+// TranscribeFunc transcribes one audio file
+type TranscribeFunc func(ctx context.Context, audioPath string) (*asr.Result, error)
+
+// FileResult is the score of one file
+type FileResult struct {
+	File              string  `json:"file"`
+	Chars             Counts  `json:"chars"`
+	CER               float64 `json:"cer"`
+	Words             Counts  `json:"words"`
+	WER               float64 `json:"wer"`
+	AudioSeconds      float64 `json:"audio_seconds"`
+	ProcessingSeconds float64 `json:"processing_seconds"`
+	RTF               float64 `json:"rtf"` // processing time / audio duration
+	Text              string  `json:"text,omitempty"`
+	Error             string  `json:"error,omitempty"` // transcription failed; not counted in the totals
+}
+
+// Summary is the corpus-level score: the rates are the summed edits divided
+// by the summed reference lengths, and the RTF the summed processing time
+// divided by the summed audio duration
+type Summary struct {
+	Files             int     `json:"files"`
+	Failed            int     `json:"failed"`
+	Chars             Counts  `json:"chars"`
+	CER               float64 `json:"cer"`
+	Words             Counts  `json:"words"`
+	WER               float64 `json:"wer"`
+	AudioSeconds      float64 `json:"audio_seconds"`
+	ProcessingSeconds float64 `json:"processing_seconds"`
+	RTF               float64 `json:"rtf"`
+}
+
+// Report is the result of one evaluation run
+type Report struct {
+	Model   string            `json:"model"`
+	Params  map[string]string `json:"params,omitempty"` // decoding parameters of the run
+	Dataset string            `json:"dataset"`
+	Files   []FileResult      `json:"files"`
+	Total   Summary           `json:"total"`
+}
+
+// Run transcribes each item with transcribe and scores it. onFile (may be
+// nil) is called after each file. Failed files are reported with their error
+// and the run goes on; it stops early only when ctx is cancelled
+func Run(ctx context.Context, items []Item, transcribe TranscribeFunc, onFile func(*FileResult)) *Report {
+	report := &Report{Files: make([]FileResult, 0, len(items))}
+	for _, item := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		file := FileResult{File: item.Name}
+		audioSec, _ := asr.GetAudioDuration(ctx, item.AudioPath)
+
+		start := time.Now()
+		result, err := transcribe(ctx, item.AudioPath)
+		file.ProcessingSeconds = time.Since(start).Seconds()
+		if err != nil {
+			file.Error = err.Error()
+		} else {
+			if audioSec == 0 {
+				audioSec = float64(result.TotalDuration)
+			}
+			file.Text = result.Text
+			file.Chars = CharCounts(item.Reference, result.Text)
+			file.CER = file.Chars.Rate()
+			file.Words = WordCounts(item.Reference, result.Text)
+			file.WER = file.Words.Rate()
+		}
+		file.AudioSeconds = audioSec
+		if audioSec > 0 {
+			file.RTF = file.ProcessingSeconds / audioSec
+		}
+
+		report.Files = append(report.Files, file)
+		report.Total.add(&file)
+		if onFile != nil {
+			onFile(&report.Files[len(report.Files)-1])
+		}
+	}
+	return report
+}
+
+// add counts a file in the summary
+func (s *Summary) add(file *FileResult) {
+	s.Files++
+	if file.Error != "" {
+		s.Failed++
+		return
+	}
+	s.Chars = s.Chars.Add(file.Chars)
+	s.CER = s.Chars.Rate()
+	s.Words = s.Words.Add(file.Words)
+	s.WER = s.Words.Rate()
+	s.AudioSeconds += file.AudioSeconds
+	s.ProcessingSeconds += file.ProcessingSeconds
+	if s.AudioSeconds > 0 {
+		s.RTF = s.ProcessingSeconds / s.AudioSeconds
+	}
+}
@@ -0,0 +1,55 @@
+package eval
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// csvHeader are the columns of WriteCSV
+var csvHeader = []string{
+	"file",
+	"ref_chars", "char_sub", "char_ins", "char_del", "cer",
+	"ref_words", "word_sub", "word_ins", "word_del", "wer",
+	"audio_sec", "processing_sec", "rtf", "error",
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes one row per file and a last row "TOTAL" with the summary
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, f := range r.Files {
+		if err := cw.Write(csvRow(f.File, f.Chars, f.CER, f.Words, f.WER, f.AudioSeconds, f.ProcessingSeconds, f.RTF, f.Error)); err != nil {
+			return err
+		}
+	}
+	t := r.Total
+	if err := cw.Write(csvRow("TOTAL", t.Chars, t.CER, t.Words, t.WER, t.AudioSeconds, t.ProcessingSeconds, t.RTF, "")); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvRow(name string, chars Counts, cer float64, words Counts, wer float64, audioSec, processingSec, rtf float64, errText string) []string {
+	return []string{
+		name,
+		strconv.Itoa(chars.Reference), strconv.Itoa(chars.Substitutions), strconv.Itoa(chars.Insertions), strconv.Itoa(chars.Deletions), formatFloat(cer),
+		strconv.Itoa(words.Reference), strconv.Itoa(words.Substitutions), strconv.Itoa(words.Insertions), strconv.Itoa(words.Deletions), formatFloat(wer),
+		formatFloat(audioSec), formatFloat(processingSec), formatFloat(rtf), errText,
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
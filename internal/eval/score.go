@@ -0,0 +1,127 @@
+package eval
+
+import (
+	"strings"
+	"unicode"
+
+	"zbor/internal/asr"
+)
+
+// Counts is the edit-distance breakdown of a hypothesis against a reference
+type Counts struct {
+	Reference     int `json:"reference"` // characters or words in the reference
+	Substitutions int `json:"substitutions"`
+	Insertions    int `json:"insertions"`
+	Deletions     int `json:"deletions"`
+}
+
+// Errors returns the number of edits
+func (c Counts) Errors() int {
+	return c.Substitutions + c.Insertions + c.Deletions
+}
+
+// Rate returns the error rate: the edits divided by the reference length
+// (1 for a non-empty hypothesis of an empty reference)
+func (c Counts) Rate() float64 {
+	if c.Reference == 0 {
+		if c.Errors() == 0 {
+			return 0
+		}
+		return 1
+	}
+	return float64(c.Errors()) / float64(c.Reference)
+}
+
+// Add returns the sum of two breakdowns (for corpus-level rates)
+func (c Counts) Add(o Counts) Counts {
+	return Counts{
+		Reference:     c.Reference + o.Reference,
+		Substitutions: c.Substitutions + o.Substitutions,
+		Insertions:    c.Insertions + o.Insertions,
+		Deletions:     c.Deletions + o.Deletions,
+	}
+}
+
+// CharCounts compares the characters of hypothesis and reference. Whitespace
+// and punctuation are ignored, so the rate equals asr.CharacterErrorRate
+func CharCounts(reference, hypothesis string) Counts {
+	return editCounts(chars(reference), chars(hypothesis))
+}
+
+// WordCounts compares the words of hypothesis and reference. Words are split
+// at spaces and, in text without spaces (Japanese), at script changes as
+// asr.GroupWords does, so both sides are segmented the same way
+func WordCounts(reference, hypothesis string) Counts {
+	return editCounts(words(reference), words(hypothesis))
+}
+
+// chars returns the characters compared by CharCounts
+func chars(s string) []string {
+	var out []string
+	for _, r := range s {
+		if !unicode.IsSpace(r) && !unicode.IsPunct(r) {
+			out = append(out, string(r))
+		}
+	}
+	return out
+}
+
+// words returns the words compared by WordCounts, without punctuation
+func words(s string) []string {
+	tokens := make([]asr.Token, 0, len(s))
+	for _, r := range s {
+		tokens = append(tokens, asr.Token{Text: string(r)})
+	}
+	var out []string
+	for _, w := range asr.GroupWords(tokens, asr.WordGroupingConfig{}) {
+		if word := strings.Join(chars(w.Text), ""); word != "" {
+			out = append(out, word)
+		}
+	}
+	return out
+}
+
+// editCounts returns the substitutions, insertions and deletions of a
+// minimum edit alignment of hyp to ref. Among alignments of equal cost,
+// substitutions are preferred over an insertion and a deletion
+func editCounts(ref, hyp []string) Counts {
+	m, n := len(ref), len(hyp)
+	dp := make([][]int, m+1)
+	for i := range dp {
+		dp[i] = make([]int, n+1)
+		dp[i][0] = i
+	}
+	for j := 0; j <= n; j++ {
+		dp[0][j] = j
+	}
+	for i := 1; i <= m; i++ {
+		for j := 1; j <= n; j++ {
+			cost := 1
+			if ref[i-1] == hyp[j-1] {
+				cost = 0
+			}
+			dp[i][j] = min(dp[i-1][j-1]+cost, dp[i-1][j]+1, dp[i][j-1]+1)
+		}
+	}
+
+	counts := Counts{Reference: m}
+	i, j := m, n
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && ref[i-1] == hyp[j-1] && dp[i][j] == dp[i-1][j-1]:
+			i--
+			j--
+		case i > 0 && j > 0 && dp[i][j] == dp[i-1][j-1]+1:
+			counts.Substitutions++
+			i--
+			j--
+		case i > 0 && dp[i][j] == dp[i-1][j]+1:
+			counts.Deletions++
+			i--
+		default:
+			counts.Insertions++
+			j--
+		}
+	}
+	return counts
+}
@@ -0,0 +1,143 @@
+// Package experiment records runs of the cmd/transcribe-* experiment tools
+// (parameters, per-chunk outputs, RTF and an optional reference transcript)
+// in the zbor database, so that they can be compared on /experiments
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// Flags are the command line flags that control recording
+type Flags struct {
+	Record    bool
+	DBPath    string
+	Name      string
+	Reference string
+}
+
+// ownFlags are not recorded as experiment parameters
+var ownFlags = map[string]bool{"record": true, "record-db": true, "record-name": true, "reference": true}
+
+// RegisterFlags adds -record, -record-db, -record-name and -reference to the
+// default flag set. Call it before flag.Parse
+func RegisterFlags() *Flags {
+	f := &Flags{}
+	flag.BoolVar(&f.Record, "record", false, "Record this run in the experiments store (compare on /experiments)")
+	flag.StringVar(&f.DBPath, "record-db", "", "Database for -record (default: $ZBOR_DB_PATH or ~/.zbor/zbor.db)")
+	flag.StringVar(&f.Name, "record-name", "", "Label of the recorded run")
+	flag.StringVar(&f.Reference, "reference", "", "Reference transcript (text file) to compute the CER against")
+	return f
+}
+
+// Run collects the output of one run. A nil Run (recording disabled)
+// ignores all calls
+type Run struct {
+	flags  *Flags
+	tool   string
+	input  string
+	chunks []sqlc.ExperimentChunk
+}
+
+// Start starts recording a run of tool on inputFile. It returns nil when
+// -record is not set
+func (f *Flags) Start(tool, inputFile string) *Run {
+	if f == nil || !f.Record {
+		return nil
+	}
+	return &Run{flags: f, tool: tool, input: inputFile}
+}
+
+// Chunk records the output of one chunk or VAD segment
+func (r *Run) Chunk(startSec, endSec float64, text string) {
+	if r == nil {
+		return
+	}
+	r.chunks = append(r.chunks, sqlc.ExperimentChunk{StartSec: startSec, EndSec: endSec, Text: text})
+}
+
+// Finish saves the run with the full transcript, the audio duration and
+// the processing time, and prints the experiment ID to stderr
+func (r *Run) Finish(text string, audioSec, processingSec float64) error {
+	if r == nil {
+		return nil
+	}
+
+	params, err := json.Marshal(commandLineParams())
+	if err != nil {
+		return err
+	}
+	experiment := &sqlc.Experiment{
+		Tool:              r.tool,
+		InputFile:         filepath.Base(r.input),
+		Params:            string(params),
+		Text:              text,
+		AudioSeconds:      audioSec,
+		ProcessingSeconds: processingSec,
+	}
+	if audioSec > 0 {
+		experiment.Rtf = processingSec / audioSec
+	}
+	if r.flags.Name != "" {
+		experiment.Name = &r.flags.Name
+	}
+	if r.flags.Reference != "" {
+		data, err := os.ReadFile(r.flags.Reference)
+		if err != nil {
+			return fmt.Errorf("failed to read reference: %w", err)
+		}
+		reference := strings.TrimSpace(string(data))
+		cer := asr.CharacterErrorRate(reference, text)
+		experiment.Reference = &reference
+		experiment.Cer = &cer
+	}
+
+	db, err := storage.Open(r.dbPath())
+	if err != nil {
+		return fmt.Errorf("failed to open experiments store: %w", err)
+	}
+	defer db.Close()
+
+	if err := storage.NewExperimentRepository(db).Create(context.Background(), experiment, r.chunks); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Recorded experiment #%d (%d chunks)", experiment.ID, len(r.chunks))
+	if experiment.Cer != nil {
+		fmt.Fprintf(os.Stderr, ", CER %.2f%%", *experiment.Cer*100)
+	}
+	fmt.Fprintln(os.Stderr)
+	return nil
+}
+
+// dbPath returns the database to record to (the server's by default)
+func (r *Run) dbPath() string {
+	if r.flags.DBPath != "" {
+		return r.flags.DBPath
+	}
+	if path := os.Getenv("ZBOR_DB_PATH"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".zbor", "zbor.db")
+}
+
+// commandLineParams returns every flag of the tool (set or default) except
+// the recording flags
+func commandLineParams() map[string]string {
+	params := map[string]string{}
+	flag.VisitAll(func(f *flag.Flag) {
+		if !ownFlags[f.Name] {
+			params[f.Name] = f.Value.String()
+		}
+	})
+	return params
+}
@@ -0,0 +1,235 @@
+// Package export は記事をノートアプリやWikiに持ち出せる形（front matter 付きのMarkdown、添付ファイルを含むZIP）で出力する
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"zbor/internal/asr"
+	"zbor/internal/ingestion"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// ErrNoAudio は音声の添付を指定したが記事に音声が無い場合のエラー
+var ErrNoAudio = errors.New("article has no audio")
+
+// Exporter は記事のMarkdownとバンドル（ZIP）を作成する
+type Exporter struct {
+	articleRepo  *storage.ArticleRepository
+	sourceRepo   *storage.SourceRepository
+	artifactRepo *storage.ArtifactRepository
+	ingester     *ingestion.AudioIngester
+}
+
+// NewExporter は新しいExporterを作成
+// ingester は他のノードが取り込んだ音声をブロブストアから取得するために使う（nil なら取得しない）
+func NewExporter(
+	articleRepo *storage.ArticleRepository,
+	sourceRepo *storage.SourceRepository,
+	artifactRepo *storage.ArtifactRepository,
+	ingester *ingestion.AudioIngester,
+) *Exporter {
+	return &Exporter{
+		articleRepo:  articleRepo,
+		sourceRepo:   sourceRepo,
+		artifactRepo: artifactRepo,
+		ingester:     ingester,
+	}
+}
+
+// Document は記事のタグとセクションを読み込んだ Document を返す（添付なし）
+func (e *Exporter) Document(ctx context.Context, article *sqlc.Article) (*Document, error) {
+	doc := &Document{Article: article}
+	if article.Sections != nil && *article.Sections != "" {
+		if err := json.Unmarshal([]byte(*article.Sections), &doc.Sections); err != nil {
+			return nil, fmt.Errorf("invalid sections: %w", err)
+		}
+	}
+	tags, err := e.articleRepo.GetArticleTags(ctx, article.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	for _, tag := range tags {
+		doc.Tags = append(doc.Tags, tag.Name)
+	}
+	return doc, nil
+}
+
+// BundleOptions はバンドルに含める添付ファイル
+type BundleOptions struct {
+	Transcript bool // 文字起こしのSRT（文字起こしが無い記事では無視）
+	Audio      bool // 音声（Start・End を指定するとその範囲のクリップ）
+	Start, End float64
+	// クリップの形式（asr.ClipFormatWAV|MP3|Opus、空なら asr.DefaultClipFormat）
+	ClipFormat string
+	// Redactor は文字起こしのSRTを伏せ字にする（nil なら伏せない。記事は呼び出し側で伏せる）
+	Redactor *asr.Redactor
+}
+
+// Bundle は記事のMarkdownと添付ファイル
+type Bundle struct {
+	Name       string // ファイル名（拡張子なし）
+	Markdown   []byte
+	Transcript []byte // SRT（無ければ nil）
+	AudioPath  string // 添付する音声ファイルのパス（無ければ空）
+	AudioName  string
+
+	tempAudio bool
+}
+
+// Bundle は記事のバンドルを作成する。使い終わったら Close で一時ファイルを削除する
+// 音声のクリップに ffmpeg が必要で使えない場合は asr.ErrNoFFmpeg、音声が無い場合は ErrNoAudio を返す
+func (e *Exporter) Bundle(ctx context.Context, article *sqlc.Article, opts BundleOptions) (*Bundle, error) {
+	doc, err := e.Document(ctx, article)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{Name: FileName(article.Title)}
+
+	if opts.Transcript && article.SourceID != nil {
+		transcript, err := e.transcript(ctx, *article.SourceID)
+		if err != nil {
+			return nil, err
+		}
+		if transcript != nil {
+			if opts.Redactor != nil {
+				transcript = opts.Redactor.Redact(transcript)
+			}
+			srt, err := transcript.Export("srt", asr.ExportOptions{SpeakerPrefix: true})
+			if err != nil {
+				return nil, fmt.Errorf("failed to export transcript: %w", err)
+			}
+			b.Transcript = []byte(srt)
+			doc.Transcript = b.Name + ".srt"
+		}
+	}
+
+	if opts.Audio {
+		if err := e.attachAudio(ctx, article, opts, b); err != nil {
+			return nil, err
+		}
+		doc.Audio = b.AudioName
+	}
+
+	b.Markdown = []byte(doc.Markdown())
+	return b, nil
+}
+
+// attachAudio は記事のソースの音声（またはクリップ）をバンドルに加える
+func (e *Exporter) attachAudio(ctx context.Context, article *sqlc.Article, opts BundleOptions, b *Bundle) error {
+	if article.SourceID == nil {
+		return ErrNoAudio
+	}
+	source, err := e.sourceRepo.GetByID(ctx, *article.SourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil || source.Metadata == nil {
+		return ErrNoAudio
+	}
+	var metadata struct {
+		Files []string `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil || len(metadata.Files) == 0 {
+		return ErrNoAudio
+	}
+	if e.ingester != nil {
+		if err := e.ingester.FetchFiles(ctx, source); err != nil {
+			return fmt.Errorf("failed to fetch audio: %w", err)
+		}
+	}
+
+	// 元の音声が無ければ（アーカイブ後など）再生用のプロキシを使う
+	audioPath := metadata.Files[0]
+	if _, err := os.Stat(audioPath); os.IsNotExist(err) {
+		audioPath = asr.ProxyPath(audioPath)
+		if _, err := os.Stat(audioPath); err != nil {
+			return ErrNoAudio
+		}
+	}
+
+	if opts.End > opts.Start {
+		format := opts.ClipFormat
+		if format == "" {
+			format = asr.DefaultClipFormat()
+		}
+		clipPath, err := asr.ExtractClipTemp(ctx, audioPath, opts.Start, opts.End, format)
+		if err != nil {
+			return err
+		}
+		b.AudioPath, b.AudioName, b.tempAudio = clipPath, b.Name+asr.ClipExtensions[format], true
+		return nil
+	}
+	b.AudioPath, b.AudioName = audioPath, b.Name+filepath.Ext(audioPath)
+	return nil
+}
+
+// transcript はソースの文字起こしを返す（無ければ nil）
+func (e *Exporter) transcript(ctx context.Context, sourceID string) (*asr.Result, error) {
+	artifacts, err := e.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	for _, artifact := range artifacts {
+		if artifact.Type == storage.ArtifactTypeTranscription && artifact.Content != nil {
+			var result asr.Result
+			if err := json.Unmarshal([]byte(*artifact.Content), &result); err != nil {
+				return nil, fmt.Errorf("failed to parse transcript: %w", err)
+			}
+			return &result, nil
+		}
+	}
+	return nil, nil
+}
+
+// WriteZip はMarkdownと添付ファイルをZIPで書き出す（すべてZIPの直下に置く）
+func (b *Bundle) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	if err := writeZipFile(zw, b.Name+".md", b.Markdown); err != nil {
+		return err
+	}
+	if b.Transcript != nil {
+		if err := writeZipFile(zw, b.Name+".srt", b.Transcript); err != nil {
+			return err
+		}
+	}
+	if b.AudioPath != "" {
+		f, err := os.Open(b.AudioPath)
+		if err != nil {
+			return fmt.Errorf("failed to open audio: %w", err)
+		}
+		defer f.Close()
+		// 音声は圧縮済みのため格納のみ
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: b.AudioName, Method: zip.Store})
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(fw, f); err != nil {
+			return fmt.Errorf("failed to write audio: %w", err)
+		}
+	}
+	return zw.Close()
+}
+
+func writeZipFile(zw *zip.Writer, name string, content []byte) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(content)
+	return err
+}
+
+// Close は音声のクリップの一時ファイルを削除する
+func (b *Bundle) Close() {
+	if b.tempAudio {
+		os.Remove(b.AudioPath)
+	}
+}
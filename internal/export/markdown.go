@@ -0,0 +1,108 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"zbor/internal/models"
+	"zbor/internal/storage/sqlc"
+)
+
+// Document はMarkdownに書き出す記事
+type Document struct {
+	Article  *sqlc.Article
+	Sections []models.Section
+	Tags     []string
+	// 同じフォルダ（ZIP）に置く添付ファイルの名前（front matter と本文末尾のリンクに使う）
+	Transcript string
+	Audio      string
+}
+
+// Markdown はYAMLのfront matter（メタデータ）と本文（セクションがあれば目次と見出し）を出力する
+func (d *Document) Markdown() string {
+	a := d.Article
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	writeField(&sb, "id", a.ID)
+	writeField(&sb, "title", a.Title)
+	writeOptionalField(&sb, "source_type", a.SourceType)
+	writeOptionalField(&sb, "source_url", a.SourceUrl)
+	writeOptionalField(&sb, "source_id", a.SourceID)
+	writeOptionalField(&sb, "author", a.Author)
+	writeOptionalField(&sb, "language", a.Language)
+	writeOptionalField(&sb, "status", a.Status)
+	if a.PublishedAt != nil {
+		writeField(&sb, "published", a.PublishedAt.UTC().Format(time.RFC3339))
+	}
+	writeField(&sb, "created", a.CreatedAt.UTC().Format(time.RFC3339))
+	writeField(&sb, "updated", a.UpdatedAt.UTC().Format(time.RFC3339))
+	if len(d.Tags) > 0 {
+		sb.WriteString("tags:\n")
+		for _, tag := range d.Tags {
+			sb.WriteString("  - " + yamlString(tag) + "\n")
+		}
+	}
+	if a.Summary != nil && strings.TrimSpace(*a.Summary) != "" {
+		writeField(&sb, "summary", strings.TrimSpace(*a.Summary))
+	}
+	if d.Transcript != "" {
+		writeField(&sb, "transcript", d.Transcript)
+	}
+	if d.Audio != "" {
+		writeField(&sb, "audio", d.Audio)
+	}
+	sb.WriteString("---\n\n")
+
+	body := models.Article{Title: a.Title, Content: a.Content, Sections: d.Sections}
+	sb.WriteString(body.FormatAsMarkdown())
+
+	if d.Transcript != "" || d.Audio != "" {
+		sb.WriteString("\n---\n\n")
+		for _, name := range []string{d.Transcript, d.Audio} {
+			if name != "" {
+				fmt.Fprintf(&sb, "- [%s](<%s>)\n", name, name)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func writeField(sb *strings.Builder, key, value string) {
+	sb.WriteString(key + ": " + yamlString(value) + "\n")
+}
+
+func writeOptionalField(sb *strings.Builder, key string, value *string) {
+	if value != nil && *value != "" {
+		writeField(sb, key, *value)
+	}
+}
+
+// yamlString は文字列をYAMLのダブルクォート文字列にする（JSONの文字列はそのままYAMLとして読める）
+func yamlString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// FileName は記事のファイル名（拡張子なし）。ファイル名に使えない文字は _ にし、長いタイトルは切り詰める
+func FileName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', '[', ']', '#', '^':
+			return '_'
+		}
+		if r < 0x20 {
+			return -1
+		}
+		return r
+	}, title)
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if runes := []rune(name); len(runes) > 80 {
+		name = strings.TrimSpace(string(runes[:80]))
+	}
+	if name == "" {
+		name = "article"
+	}
+	return name
+}
@@ -0,0 +1,228 @@
+package export
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"zbor/internal/storage"
+)
+
+// manifestName はフォルダに置く同期の記録（記事IDごとのファイル名と書き出した内容のハッシュ）
+const manifestName = ".zbor-sync.json"
+
+// syncEntry は同期した記事のファイル
+type syncEntry struct {
+	File string `json:"file"`
+	Hash string `json:"hash"`
+}
+
+// SyncResult は1回の同期の結果
+type SyncResult struct {
+	Written   int // 新しく書き出した・内容が変わって書き直したファイル
+	Removed   int // 記事の削除で消したファイル
+	Unchanged int
+}
+
+// FolderSync は処理が完了した記事をフォルダに1記事1ファイルの Markdown として書き出す（Obsidian の保管庫や静的サイトジェネレーター向け）
+//
+// ファイル名は最初に書き出したときのタイトルで決め、その後タイトルが変わっても変えない。
+// 記事が変わったファイルだけを書き直し、削除された記事のファイルは消す。
+// 同期が書き出したファイルは manifestName に記録し、それ以外のファイルには触れない
+type FolderSync struct {
+	exporter    *Exporter
+	articleRepo *storage.ArticleRepository
+	dir         string
+}
+
+// NewFolderSync は新しいFolderSyncを作成
+func NewFolderSync(exporter *Exporter, articleRepo *storage.ArticleRepository, dir string) *FolderSync {
+	return &FolderSync{
+		exporter:    exporter,
+		articleRepo: articleRepo,
+		dir:         dir,
+	}
+}
+
+// Dir は書き出し先のフォルダを返す
+func (s *FolderSync) Dir() string {
+	return s.dir
+}
+
+// Run は interval ごとに同期する
+func (s *FolderSync) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := s.Sync(ctx)
+		if err != nil {
+			slog.Error("Folder sync failed", "dir", s.dir, "error", err)
+		} else if result.Written > 0 || result.Removed > 0 {
+			slog.Info("Folder sync: updated files", "dir", s.dir, "written", result.Written, "removed", result.Removed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync は記事をフォルダに書き出す
+func (s *FolderSync) Sync(ctx context.Context) (SyncResult, error) {
+	var result SyncResult
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return result, fmt.Errorf("failed to create sync directory: %w", err)
+	}
+	manifest, err := s.loadManifest()
+	if err != nil {
+		return result, err
+	}
+
+	articles, err := s.articleRepo.ListCompleted(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to list articles: %w", err)
+	}
+
+	// 使用中のファイル名（大文字小文字を区別しないファイルシステムのため小文字で比較）
+	used := make(map[string]bool, len(manifest))
+	for _, entry := range manifest {
+		used[strings.ToLower(entry.File)] = true
+	}
+
+	current := make(map[string]bool, len(articles))
+	var firstErr error
+	for idx := range articles {
+		if ctx.Err() != nil {
+			break
+		}
+		article := &articles[idx]
+		current[article.ID] = true
+
+		doc, err := s.exporter.Document(ctx, article)
+		if err != nil {
+			firstErr = firstError(firstErr, fmt.Errorf("article %s: %w", article.ID, err))
+			continue
+		}
+		content := []byte(doc.Markdown())
+		sum := sha256.Sum256(content)
+		hash := hex.EncodeToString(sum[:])
+
+		entry, ok := manifest[article.ID]
+		if !ok {
+			entry.File = s.newFileName(article.Title, article.ID, used)
+			used[strings.ToLower(entry.File)] = true
+		}
+		path := filepath.Join(s.dir, entry.File)
+		if ok && entry.Hash == hash {
+			if _, err := os.Stat(path); err == nil {
+				result.Unchanged++
+				continue
+			}
+		}
+
+		if err := writeFileAtomic(path, content); err != nil {
+			firstErr = firstError(firstErr, err)
+			continue
+		}
+		_ = os.Chtimes(path, article.UpdatedAt, article.UpdatedAt)
+		entry.Hash = hash
+		manifest[article.ID] = entry
+		result.Written++
+	}
+
+	// 削除された（完了でなくなった）記事のファイルを消す
+	if ctx.Err() == nil {
+		for id, entry := range manifest {
+			if current[id] {
+				continue
+			}
+			if err := os.Remove(filepath.Join(s.dir, entry.File)); err != nil && !os.IsNotExist(err) {
+				firstErr = firstError(firstErr, err)
+				continue
+			}
+			delete(manifest, id)
+			result.Removed++
+		}
+	}
+
+	if err := s.saveManifest(manifest); err != nil {
+		return result, err
+	}
+	return result, firstErr
+}
+
+// newFileName は新しい記事のファイル名を返す
+// タイトルのファイル名が使用中・同期外のファイルと重なる場合は記事IDの先頭を付ける
+func (s *FolderSync) newFileName(title, id string, used map[string]bool) string {
+	name := FileName(title) + ".md"
+	if !used[strings.ToLower(name)] {
+		if _, err := os.Stat(filepath.Join(s.dir, name)); os.IsNotExist(err) {
+			return name
+		}
+	}
+	return fmt.Sprintf("%s (%s).md", FileName(title), id[:min(8, len(id))])
+}
+
+func (s *FolderSync) loadManifest() (map[string]syncEntry, error) {
+	manifest := map[string]syncEntry{}
+	data, err := os.ReadFile(filepath.Join(s.dir, manifestName))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid sync manifest %s: %w", manifestName, err)
+	}
+	return manifest, nil
+}
+
+func (s *FolderSync) saveManifest(manifest map[string]syncEntry) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync manifest: %w", err)
+	}
+	return writeFileAtomic(filepath.Join(s.dir, manifestName), data)
+}
+
+// writeFileAtomic は一時ファイルに書いてから置き換える（同期中のアプリが書きかけのファイルを読まないように）
+func writeFileAtomic(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".zbor-sync-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// firstError は最初のエラーを残す
+func firstError(first, err error) error {
+	if first != nil {
+		return first
+	}
+	return err
+}
@@ -0,0 +1,507 @@
+package feeds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"zbor/internal/ingestion"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/webfetch"
+)
+
+// ErrAlreadySubscribed は同じURLのフィードを購読済みの場合のエラー
+var ErrAlreadySubscribed = errors.New("feed already subscribed")
+
+// ErrInvalidURL はフィードのURLが http(s) でない場合のエラー
+var ErrInvalidURL = errors.New("invalid feed URL")
+
+// 購読のデフォルト値・上限
+const (
+	DefaultInterval     = 60 * time.Minute // 更新確認の間隔
+	MinInterval         = 5 * time.Minute
+	MaxEntriesPerUpdate = 20 // 1回の更新で取り込む新しいエントリーの上限
+
+	maxFeedSize = 10 * 1024 * 1024
+)
+
+// SubscribeOptions はフィード購読のオプション
+type SubscribeOptions struct {
+	URL       string
+	Title     string        // 省略時はフィードのタイトル
+	Interval  time.Duration // 更新確認の間隔（0 でデフォルト）
+	Summarize bool          // 取り込んだ記事の要約ジョブを作成
+	Priority  int           // 最初の更新ジョブの優先度
+
+	// Podcast はエピソードの音声（enclosure）を取り込んで文字起こしする
+	Podcast bool
+	Model   string // 文字起こしのモデル（storage.ASRModel*、空でデフォルト）
+
+	// Extract は本文抽出オプション（nil で webfetch.DefaultExtractOptions）
+	Extract *webfetch.ExtractOptions
+}
+
+// Subscription は購読中のフィード（API レスポンス）
+type Subscription struct {
+	ID              string     `json:"id"` // フィードのソースID
+	URL             string     `json:"url"`
+	Title           string     `json:"title"`
+	IntervalMinutes int        `json:"interval_minutes"`
+	Summarize       bool       `json:"summarize"`
+	Podcast         bool       `json:"podcast"`
+	Model           string     `json:"model,omitempty"`
+	Status          string     `json:"status"`
+	LastCheckedAt   *time.Time `json:"last_checked_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	Ingested        int        `json:"ingested"` // これまでに取り込んだエントリー数
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// feedMetadata はフィードソースのメタデータ（購読設定と更新状態）
+type feedMetadata struct {
+	Title           string                   `json:"title"`
+	IntervalMinutes int                      `json:"interval_minutes"`
+	Summarize       bool                     `json:"summarize"`
+	Extract         *webfetch.ExtractOptions `json:"extract,omitempty"`
+	Podcast         bool                     `json:"podcast,omitempty"`
+	Model           string                   `json:"model,omitempty"`
+
+	ETag          string     `json:"etag,omitempty"`
+	LastModified  string     `json:"last_modified,omitempty"`
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	Ingested      int        `json:"ingested"`
+}
+
+// Service はRSS/Atomフィードを購読し、新しいエントリーをWebページとして取り込む
+// フィードは feed タイプのソースとして保存し、更新は feed_refresh ジョブで行う
+// エントリーのページ取得・記事化は WebIngester の fetch ジョブに任せる
+// ポッドキャストの場合はエピソードの音声を AudioIngester の文字起こしジョブで取り込む
+type Service struct {
+	sourceRepo    *storage.SourceRepository
+	jobRepo       *storage.JobRepository
+	webIngester   *ingestion.WebIngester
+	audioIngester *ingestion.AudioIngester
+	httpClient    *http.Client
+}
+
+// NewService は新しいServiceを作成
+func NewService(sourceRepo *storage.SourceRepository, jobRepo *storage.JobRepository, webIngester *ingestion.WebIngester, audioIngester *ingestion.AudioIngester) *Service {
+	return &Service{
+		sourceRepo:    sourceRepo,
+		jobRepo:       jobRepo,
+		webIngester:   webIngester,
+		audioIngester: audioIngester,
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Subscribe はフィードを購読し、最初の更新ジョブを作成
+func (s *Service) Subscribe(ctx context.Context, opts SubscribeOptions) (*Subscription, string, error) {
+	feedURL := strings.TrimSpace(opts.URL)
+	parsed, err := url.Parse(feedURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, "", fmt.Errorf("%w: %s", ErrInvalidURL, opts.URL)
+	}
+
+	feeds, err := s.sourceRepo.ListByType(ctx, storage.SourceTypeFeed)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list feeds: %w", err)
+	}
+	for _, feed := range feeds {
+		if feed.OriginalUrl != nil && *feed.OriginalUrl == feedURL {
+			return nil, "", fmt.Errorf("%w: %s", ErrAlreadySubscribed, feedURL)
+		}
+	}
+
+	if opts.Podcast && opts.Model != "" {
+		if err := s.audioIngester.CheckModel(opts.Model); err != nil {
+			return nil, "", err
+		}
+	}
+
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if interval < MinInterval {
+		interval = MinInterval
+	}
+	metadata := feedMetadata{
+		Title:           opts.Title,
+		IntervalMinutes: int(interval / time.Minute),
+		Summarize:       opts.Summarize,
+		Extract:         opts.Extract,
+		Podcast:         opts.Podcast,
+		Model:           opts.Model,
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+	source := &sqlc.Source{
+		Type:        storage.SourceTypeFeed,
+		OriginalUrl: storage.Ptr(feedURL),
+		Metadata:    storage.Ptr(string(metadataJSON)),
+		Status:      storage.Ptr(storage.SourceStatusPending),
+	}
+	if err := s.sourceRepo.Create(ctx, source); err != nil {
+		return nil, "", fmt.Errorf("failed to create source: %w", err)
+	}
+
+	jobID, err := s.queueRefresh(ctx, source.ID, opts.Priority)
+	if err != nil {
+		return nil, "", err
+	}
+	return subscription(source, &metadata), jobID, nil
+}
+
+// List は購読中のフィードを新しい順に取得
+func (s *Service) List(ctx context.Context) ([]Subscription, error) {
+	feeds, err := s.sourceRepo.ListByType(ctx, storage.SourceTypeFeed)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions := make([]Subscription, 0, len(feeds))
+	for i := range feeds {
+		subscriptions = append(subscriptions, *subscription(&feeds[i], parseMetadata(&feeds[i])))
+	}
+	return subscriptions, nil
+}
+
+// Get は購読中のフィードを取得（存在しない場合は nil）
+func (s *Service) Get(ctx context.Context, id string) (*Subscription, error) {
+	source, err := s.getFeed(ctx, id)
+	if err != nil || source == nil {
+		return nil, err
+	}
+	return subscription(source, parseMetadata(source)), nil
+}
+
+// Unsubscribe は購読を解除（取り込み済みの記事は残す）
+func (s *Service) Unsubscribe(ctx context.Context, id string) error {
+	return s.sourceRepo.Delete(ctx, id)
+}
+
+// Refresh はフィードの更新ジョブを作成（実行待ちのジョブがあればそのIDを返す）
+func (s *Service) Refresh(ctx context.Context, id string, priority int) (string, error) {
+	if jobID, err := s.activeJob(ctx, id); err != nil || jobID != "" {
+		return jobID, err
+	}
+	return s.queueRefresh(ctx, id, priority)
+}
+
+// Run は interval ごとに更新時刻を過ぎたフィードの更新ジョブを作成（ctx がキャンセルされるまで）
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.QueueDue(ctx); err != nil {
+			slog.Error("Feed scheduling failed", "error", err)
+		} else if n > 0 {
+			slog.Info("Queued feed refresh", "feeds", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// QueueDue は更新時刻を過ぎたフィードの更新ジョブを作成し、その数を返す
+func (s *Service) QueueDue(ctx context.Context) (int, error) {
+	feeds, err := s.sourceRepo.ListByType(ctx, storage.SourceTypeFeed)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list feeds: %w", err)
+	}
+
+	queued := 0
+	now := time.Now()
+	for i := range feeds {
+		metadata := parseMetadata(&feeds[i])
+		if metadata.LastCheckedAt != nil && now.Sub(*metadata.LastCheckedAt) < metadata.interval() {
+			continue
+		}
+		jobID, err := s.activeJob(ctx, feeds[i].ID)
+		if err != nil {
+			return queued, err
+		}
+		if jobID != "" {
+			continue
+		}
+		if _, err := s.queueRefresh(ctx, feeds[i].ID, storage.JobPriorityBatch); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// ProcessRefresh はフィードを取得し、未取り込みのエントリーの取り込みジョブを作成（ワーカーから呼ばれる）
+// URLが既存のソースと一致するエントリーは取り込まない
+func (s *Service) ProcessRefresh(ctx context.Context, job *sqlc.ProcessingJob, onProgress ingestion.ProgressCallback) error {
+	reportProgress := func(progress int, step string) {
+		if onProgress != nil {
+			onProgress(progress, step)
+		}
+	}
+
+	if job.SourceID == nil {
+		return fmt.Errorf("job has no source ID")
+	}
+	source, err := s.getFeed(ctx, *job.SourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return fmt.Errorf("feed not found: %s", *job.SourceID)
+	}
+	metadata := parseMetadata(source)
+
+	reportProgress(10, "fetching feed")
+	ingested, err := s.refresh(ctx, source, metadata, reportProgress)
+	now := time.Now()
+	metadata.LastCheckedAt = &now
+	metadata.Ingested += ingested
+	metadata.LastError = ""
+	status := storage.SourceStatusCompleted
+	if err != nil {
+		metadata.LastError = err.Error()
+		status = storage.SourceStatusFailed
+	}
+
+	metadataJSON, _ := json.Marshal(metadata)
+	if err := s.sourceRepo.UpdateMetadata(ctx, source.ID, string(metadataJSON)); err != nil {
+		return fmt.Errorf("failed to update source metadata: %w", err)
+	}
+	if err := s.sourceRepo.UpdateStatus(ctx, source.ID, status); err != nil {
+		return fmt.Errorf("failed to update source status: %w", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	reportProgress(100, "")
+	return nil
+}
+
+// refresh はフィードを取得して新しいエントリーを取り込み、取り込んだ数を返す
+// metadata の ETag などは取得結果で更新する
+func (s *Service) refresh(ctx context.Context, source *sqlc.Source, metadata *feedMetadata, reportProgress func(int, string)) (int, error) {
+	feed, err := s.fetch(ctx, *source.OriginalUrl, metadata)
+	if err != nil {
+		return 0, err
+	}
+	if feed == nil {
+		return 0, nil // 更新なし（304 Not Modified）
+	}
+	if metadata.Title == "" {
+		metadata.Title = feed.Title
+	}
+
+	// 新しいものから上限まで選び、古い順に取り込む
+	var entries []Entry
+	for _, entry := range newestFirst(feed.Entries) {
+		if len(entries) >= MaxEntriesPerUpdate {
+			break
+		}
+		entryURL := metadata.entryURL(&entry)
+		if entryURL == "" {
+			continue
+		}
+		exists, err := s.sourceRepo.ExistsByURL(ctx, entryURL)
+		if err != nil {
+			return 0, fmt.Errorf("failed to check entry: %w", err)
+		}
+		if !exists {
+			entries = append(entries, entry)
+		}
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		done := len(entries) - i
+		reportProgress(10+done*85/len(entries), fmt.Sprintf("entry %d/%d", done, len(entries)))
+
+		if err := s.ingestEntry(ctx, source, metadata, &entry); err != nil {
+			return done - 1, fmt.Errorf("failed to ingest %s: %w", metadata.entryURL(&entry), err)
+		}
+	}
+	return len(entries), nil
+}
+
+// ingestEntry はエントリーを取り込む（ポッドキャストは音声、それ以外はページ）
+func (s *Service) ingestEntry(ctx context.Context, source *sqlc.Source, metadata *feedMetadata, entry *Entry) error {
+	if metadata.Podcast {
+		_, err := s.audioIngester.IngestPodcastEpisode(ctx, ingestion.IngestPodcastOptions{
+			AudioURL:     entry.AudioEnclosure().URL,
+			Title:        entry.Title,
+			EpisodeURL:   entry.URL,
+			PublishedAt:  entry.Published,
+			ShowNotes:    entry.ShowNotes(),
+			Show:         metadata.Title,
+			Model:        metadata.Model,
+			Priority:     storage.JobPriorityBatch,
+			FeedSourceID: source.ID,
+		})
+		return err
+	}
+
+	_, err := s.webIngester.IngestURL(ctx, ingestion.IngestURLOptions{
+		URL:          entry.URL,
+		Title:        entry.Title,
+		Summarize:    metadata.Summarize,
+		Priority:     storage.JobPriorityBatch,
+		Extract:      metadata.Extract,
+		FeedSourceID: source.ID,
+	})
+	return err
+}
+
+// fetch はフィードを取得して解析（前回から変更が無ければ nil を返す）
+func (s *Service) fetch(ctx context.Context, feedURL string, metadata *feedMetadata) (*Feed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", webfetch.UserAgent)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.8, */*;q=0.5")
+	if metadata.ETag != "" {
+		req.Header.Set("If-None-Match", metadata.ETag)
+	}
+	if metadata.LastModified != "" {
+		req.Header.Set("If-Modified-Since", metadata.LastModified)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch feed: HTTP %d", resp.StatusCode)
+	}
+
+	feed, err := Parse(io.LimitReader(resp.Body, maxFeedSize), resp.Request.URL.String())
+	if err != nil {
+		return nil, err
+	}
+	metadata.ETag = resp.Header.Get("ETag")
+	metadata.LastModified = resp.Header.Get("Last-Modified")
+	return feed, nil
+}
+
+// getFeed はフィードのソースを取得（存在しない、またはフィードでない場合は nil）
+func (s *Service) getFeed(ctx context.Context, id string) (*sqlc.Source, error) {
+	source, err := s.sourceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil || source.Type != storage.SourceTypeFeed || source.OriginalUrl == nil {
+		return nil, nil
+	}
+	return source, nil
+}
+
+// activeJob はフィードの実行待ち・実行中の更新ジョブのIDを返す（無ければ空）
+func (s *Service) activeJob(ctx context.Context, id string) (string, error) {
+	jobs, err := s.jobRepo.GetBySourceID(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if job.Type == storage.JobTypeFeedRefresh && job.Status != nil &&
+			(*job.Status == storage.JobStatusQueued || *job.Status == storage.JobStatusRunning) {
+			return job.ID, nil
+		}
+	}
+	return "", nil
+}
+
+func (s *Service) queueRefresh(ctx context.Context, id string, priority int) (string, error) {
+	job := &sqlc.ProcessingJob{
+		SourceID: storage.Ptr(id),
+		Type:     storage.JobTypeFeedRefresh,
+		Priority: storage.Ptr(int64(priority)),
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return "", fmt.Errorf("failed to create job: %w", err)
+	}
+	return job.ID, nil
+}
+
+// interval は更新確認の間隔
+func (m *feedMetadata) interval() time.Duration {
+	if m.IntervalMinutes <= 0 {
+		return DefaultInterval
+	}
+	return time.Duration(m.IntervalMinutes) * time.Minute
+}
+
+// entryURL は取り込み済みの判定に使うエントリーのURL
+// ポッドキャストは音声のURL（エピソードのソースの original_url）、それ以外はページのURL
+func (m *feedMetadata) entryURL(entry *Entry) string {
+	if m.Podcast {
+		if enclosure := entry.AudioEnclosure(); enclosure != nil {
+			return enclosure.URL
+		}
+		return ""
+	}
+	return entry.URL
+}
+
+func parseMetadata(source *sqlc.Source) *feedMetadata {
+	var metadata feedMetadata
+	if source.Metadata != nil {
+		_ = json.Unmarshal([]byte(*source.Metadata), &metadata)
+	}
+	return &metadata
+}
+
+func subscription(source *sqlc.Source, metadata *feedMetadata) *Subscription {
+	sub := &Subscription{
+		ID:              source.ID,
+		Title:           metadata.Title,
+		IntervalMinutes: int(metadata.interval() / time.Minute),
+		Summarize:       metadata.Summarize,
+		Podcast:         metadata.Podcast,
+		Model:           metadata.Model,
+		LastCheckedAt:   metadata.LastCheckedAt,
+		LastError:       metadata.LastError,
+		Ingested:        metadata.Ingested,
+		CreatedAt:       source.CreatedAt,
+	}
+	if source.OriginalUrl != nil {
+		sub.URL = *source.OriginalUrl
+	}
+	if source.Status != nil {
+		sub.Status = *source.Status
+	}
+	return sub
+}
+
+// newestFirst はエントリーを公開日時の新しい順に並べる（日時不明はフィード内の順序のまま末尾）
+func newestFirst(entries []Entry) []Entry {
+	sorted := append([]Entry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Published, sorted[j].Published
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
+		}
+		return a.After(b)
+	})
+	return sorted
+}
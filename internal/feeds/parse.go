@@ -0,0 +1,313 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"zbor/internal/asr"
+
+	"golang.org/x/net/html/charset"
+)
+
+// ErrNotFeed はRSS/Atomとして解析できない場合のエラー
+var ErrNotFeed = errors.New("not an RSS or Atom feed")
+
+// Feed は解析したフィード
+type Feed struct {
+	Title   string
+	Link    string // サイトのURL
+	Entries []Entry
+}
+
+// Entry はフィードのエントリー
+type Entry struct {
+	ID        string // guid / id（無ければURL）
+	Title     string
+	URL       string    // エントリーのページ（絶対URL）
+	Published time.Time // 公開日時（不明ならゼロ値）
+	Summary   string
+	Content   string // 本文（content:encoded / Atom の content。ポッドキャストのショーノート）
+
+	Enclosures []Enclosure
+}
+
+// Enclosure はエントリーの添付ファイル（ポッドキャストの音声など）
+type Enclosure struct {
+	URL    string // 絶対URL
+	Type   string // MIMEタイプ（省略されることもある）
+	Length int64  // バイト数（不明なら0）
+}
+
+// ShowNotes はエントリーの本文（無ければ概要）
+func (e *Entry) ShowNotes() string {
+	if e.Content != "" {
+		return e.Content
+	}
+	return e.Summary
+}
+
+// rawFeed は RSS 2.0 / RSS 1.0 (RDF) / Atom のいずれかのルート要素
+type rawFeed struct {
+	XMLName xml.Name
+	Channel *rssChannel `xml:"channel"`
+	Items   []rssItem   `xml:"item"` // RSS 1.0 はアイテムが channel の外にある
+
+	// Atom
+	Title   string      `xml:"title"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssChannel struct {
+	Title string    `xml:"title"`
+	Links []rssLink `xml:"link"`
+	Items []rssItem `xml:"item"`
+}
+
+// rssLink は RSS の <link>URL</link>（atom:link の場合は href 属性）
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Text string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string    `xml:"title"`
+	Links       []rssLink `xml:"link"`
+	GUID        string    `xml:"guid"`
+	PubDate     string    `xml:"pubDate"`
+	Date        string    `xml:"date"` // dc:date（RSS 1.0）
+	Description string    `xml:"description"`
+	Encoded     string    `xml:"encoded"` // content:encoded
+
+	Enclosures []rssEnclosure `xml:"enclosure"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+}
+
+// Parse は RSS 2.0 / RSS 1.0 / Atom を解析する
+// 相対URLは feedURL を基準に絶対URLにする
+func Parse(r io.Reader, feedURL string) (*Feed, error) {
+	base, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed URL: %w", err)
+	}
+
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+
+	var raw rawFeed
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotFeed, err)
+	}
+
+	switch strings.ToLower(raw.XMLName.Local) {
+	case "rss":
+		if raw.Channel == nil {
+			return nil, ErrNotFeed
+		}
+		return parseRSS(raw.Channel.Title, raw.Channel.Links, raw.Channel.Items, base), nil
+	case "rdf":
+		var title string
+		var links []rssLink
+		if raw.Channel != nil {
+			title, links = raw.Channel.Title, raw.Channel.Links
+		}
+		return parseRSS(title, links, raw.Items, base), nil
+	case "feed":
+		return parseAtom(&raw, base), nil
+	default:
+		return nil, ErrNotFeed
+	}
+}
+
+func parseRSS(title string, links []rssLink, items []rssItem, base *url.URL) *Feed {
+	feed := &Feed{
+		Title: strings.TrimSpace(title),
+		Link:  resolve(base, rssLinkURL(links)),
+	}
+	for _, item := range items {
+		entry := Entry{
+			ID:      strings.TrimSpace(item.GUID),
+			Title:   strings.TrimSpace(item.Title),
+			URL:     resolve(base, rssLinkURL(item.Links)),
+			Summary: strings.TrimSpace(item.Description),
+			Content: strings.TrimSpace(item.Encoded),
+		}
+		for _, enclosure := range item.Enclosures {
+			entry.addEnclosure(base, enclosure.URL, enclosure.Type, enclosure.Length)
+		}
+		// guid がURLの場合（isPermaLink）はリンクの代わりに使える
+		if entry.URL == "" && strings.HasPrefix(entry.ID, "http") {
+			entry.URL = resolve(base, entry.ID)
+		}
+		entry.Published = parseDate(item.PubDate)
+		if entry.Published.IsZero() {
+			entry.Published = parseDate(item.Date)
+		}
+		feed.Entries = append(feed.Entries, finishEntry(entry))
+	}
+	return feed
+}
+
+func parseAtom(raw *rawFeed, base *url.URL) *Feed {
+	feed := &Feed{
+		Title: strings.TrimSpace(raw.Title),
+		Link:  resolve(base, atomLinkURL(raw.Links)),
+	}
+	for _, e := range raw.Entries {
+		entry := Entry{
+			ID:      strings.TrimSpace(e.ID),
+			Title:   strings.TrimSpace(e.Title),
+			URL:     resolve(base, atomLinkURL(e.Links)),
+			Summary: strings.TrimSpace(e.Summary),
+			Content: strings.TrimSpace(e.Content),
+		}
+		if entry.Summary == "" {
+			entry.Summary = entry.Content
+		}
+		for _, link := range e.Links {
+			if link.Rel == "enclosure" {
+				entry.addEnclosure(base, link.Href, link.Type, link.Length)
+			}
+		}
+		entry.Published = parseDate(e.Published)
+		if entry.Published.IsZero() {
+			entry.Published = parseDate(e.Updated)
+		}
+		feed.Entries = append(feed.Entries, finishEntry(entry))
+	}
+	return feed
+}
+
+// finishEntry はIDが無いエントリーにURLをIDとして設定
+func finishEntry(entry Entry) Entry {
+	if entry.ID == "" {
+		entry.ID = entry.URL
+	}
+	return entry
+}
+
+// AudioEnclosure はエントリーの音声の添付ファイル（無ければ nil）
+// MIMEタイプが省略されている場合は拡張子で判定する
+func (e *Entry) AudioEnclosure() *Enclosure {
+	for i := range e.Enclosures {
+		enclosure := &e.Enclosures[i]
+		if strings.HasPrefix(enclosure.Type, "audio/") {
+			return enclosure
+		}
+		if enclosure.Type == "" || enclosure.Type == "application/octet-stream" {
+			if u, err := url.Parse(enclosure.URL); err == nil && asr.IsSupportedFormat(u.Path) {
+				return enclosure
+			}
+		}
+	}
+	return nil
+}
+
+// addEnclosure はURLが http(s) の添付ファイルを追加
+func (e *Entry) addEnclosure(base *url.URL, ref, mimeType, length string) {
+	enclosureURL := resolve(base, strings.TrimSpace(ref))
+	if enclosureURL == "" {
+		return
+	}
+	size, _ := strconv.ParseInt(strings.TrimSpace(length), 10, 64)
+	e.Enclosures = append(e.Enclosures, Enclosure{
+		URL:    enclosureURL,
+		Type:   strings.ToLower(strings.TrimSpace(mimeType)),
+		Length: size,
+	})
+}
+
+// rssLinkURL は RSS の <link> のURL（atom:link の href より本文を優先）
+func rssLinkURL(links []rssLink) string {
+	for _, link := range links {
+		if text := strings.TrimSpace(link.Text); text != "" {
+			return text
+		}
+	}
+	for _, link := range links {
+		if link.Href != "" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// atomLinkURL は Atom の rel="alternate"（または rel 省略）のリンク
+func atomLinkURL(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+// resolve は相対URLを base を基準に絶対URLにする（http/https以外は空）
+func resolve(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	u.Fragment = ""
+	return u.String()
+}
+
+// dateLayouts はフィードで使われる日時の形式
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	time.RFC3339Nano,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseDate はフィードの日時を解析（解析できない場合はゼロ値）
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"zbor/internal/analyze"
+	"zbor/internal/storage"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AnalyzeHandler は記事の分析（キーワード・固有表現の抽出）APIのハンドラー
+type AnalyzeHandler struct {
+	analyzer     *analyze.Analyzer
+	articleRepo  *storage.ArticleRepository
+	artifactRepo *storage.ArtifactRepository
+}
+
+// NewAnalyzeHandler は新しいAnalyzeHandlerを作成
+func NewAnalyzeHandler(analyzer *analyze.Analyzer, articleRepo *storage.ArticleRepository, artifactRepo *storage.ArtifactRepository) *AnalyzeHandler {
+	return &AnalyzeHandler{
+		analyzer:     analyzer,
+		articleRepo:  articleRepo,
+		artifactRepo: artifactRepo,
+	}
+}
+
+// CreateJob は記事の分析ジョブを作成
+// POST /api/articles/:id/analysis
+func (h *AnalyzeHandler) CreateJob(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	article, err := h.articleRepo.GetByID(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if article == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "article not found"})
+	}
+
+	jobID, err := h.analyzer.CreateJob(ctx, id, storage.JobPriorityImmediate)
+	if errors.Is(err, storage.ErrLegalHold) {
+		return holdError(c, err)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create job: " + err.Error()})
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"message":    "Analysis job created",
+		"article_id": id,
+		"job_id":     jobID,
+		"method":     h.analyzer.Method(),
+	})
+}
+
+// Get は記事の分析結果を取得
+// GET /api/articles/:id/analysis
+func (h *AnalyzeHandler) Get(c echo.Context) error {
+	ctx := c.Request().Context()
+	article, err := h.articleRepo.GetByID(ctx, c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if article == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "article not found"})
+	}
+
+	analysis, err := analyze.Load(ctx, h.artifactRepo, article)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if analysis == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "article has not been analyzed"})
+	}
+	return c.JSON(http.StatusOK, analysis)
+}
+
+// Facets は記事一覧の絞り込み候補（種類ごとの語と、言及している記事数）を取得
+// GET /api/articles/facets?kind=person&limit=20
+func (h *AnalyzeHandler) Facets(c echo.Context) error {
+	kind := c.QueryParam("kind")
+	if kind == "" {
+		kind = storage.EntityKindPerson
+	}
+	if !storage.ValidEntityKind(kind) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid kind: " + kind})
+	}
+	limit, _ := parsePagination(c, 20)
+
+	facets, err := h.articleRepo.EntityFacets(c.Request().Context(), kind, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, facets)
+}
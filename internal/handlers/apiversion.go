@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// APIVersionHeader はAPIのバージョンを指定するリクエストヘッダー（バージョンの無いパスの場合）で、
+// レスポンスでは処理したバージョンを返す
+const APIVersionHeader = "X-Zbor-API-Version"
+
+// CurrentAPIVersion は最新のAPIのバージョン
+const CurrentAPIVersion = 1
+
+// DefaultAPIVersion はバージョンの無いパスで使うバージョン（既存のスクリプトのため、新しいバージョンを追加しても変えない）
+const DefaultAPIVersion = 1
+
+// SupportedAPIVersions は受け付けるAPIのバージョン（古い順）
+// 互換性の無い変更をする場合は新しいバージョンを追加し、ハンドラーは RequestAPIVersion で分岐する
+var SupportedAPIVersions = []int{1}
+
+// echo.Context にリクエストのAPIバージョンを保存するキー
+const ctxAPIVersion = "api_version"
+
+// APIVersionRouter は /api/v<N>/... へのリクエストを /api/... のルートで処理する（e.Pre で使う）
+// バージョンの無い /api/... は互換のためのパスで、X-Zbor-API-Version のバージョン（無ければ DefaultAPIVersion）として処理し、
+// 非推奨として /api/v<N>/... を後継に通知する。対応していないバージョンはパスなら 404、ヘッダーなら 400 を返す
+func APIVersionRouter() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.URL.Path != "/api" && !strings.HasPrefix(req.URL.Path, "/api/") {
+				return next(c)
+			}
+
+			rest := strings.TrimPrefix(req.URL.Path, "/api")
+			version, unversioned, versioned := pathAPIVersion(rest)
+			if versioned {
+				if !supportedAPIVersion(version) {
+					return unsupportedAPIVersion(c, http.StatusNotFound, fmt.Sprintf("v%d", version))
+				}
+				// /api/v1/jobs → /api/jobs
+				req.URL.Path = "/api" + unversioned
+				if req.URL.RawPath != "" {
+					_, rawRest, _ := pathAPIVersion(strings.TrimPrefix(req.URL.RawPath, "/api"))
+					req.URL.RawPath = "/api" + rawRest
+				}
+			} else {
+				version = DefaultAPIVersion
+				if requested := req.Header.Get(APIVersionHeader); requested != "" {
+					v, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(requested), "v"))
+					if err != nil || !supportedAPIVersion(v) {
+						return unsupportedAPIVersion(c, http.StatusBadRequest, requested)
+					}
+					version = v
+				}
+				annotateDeprecated(c, fmt.Sprintf("/api/v%d%s", version, rest))
+			}
+
+			c.Set(ctxAPIVersion, version)
+			c.Response().Header().Set(APIVersionHeader, strconv.Itoa(version))
+			return next(c)
+		}
+	}
+}
+
+// RequestAPIVersion はリクエストのAPIバージョンを返す（APIVersionRouter を通っていなければ最新）
+func RequestAPIVersion(c echo.Context) int {
+	if v, ok := c.Get(ctxAPIVersion).(int); ok {
+		return v
+	}
+	return CurrentAPIVersion
+}
+
+// pathAPIVersion は /api に続くパスの先頭の /v<N> を解析し、バージョンと残りのパスを返す
+func pathAPIVersion(rest string) (int, string, bool) {
+	segment, remainder, found := strings.Cut(strings.TrimPrefix(rest, "/"), "/")
+	if len(segment) < 2 || segment[0] != 'v' {
+		return 0, rest, false
+	}
+	v, err := strconv.Atoi(segment[1:])
+	if err != nil || v <= 0 {
+		return 0, rest, false
+	}
+	if found {
+		remainder = "/" + remainder
+	}
+	return v, remainder, true
+}
+
+func supportedAPIVersion(version int) bool {
+	for _, v := range SupportedAPIVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func unsupportedAPIVersion(c echo.Context, code int, requested string) error {
+	supported := make([]string, len(SupportedAPIVersions))
+	for i, v := range SupportedAPIVersions {
+		supported[i] = fmt.Sprintf("v%d", v)
+	}
+	return c.JSON(code, map[string]interface{}{
+		"error":              "unsupported API version: " + requested,
+		"supported_versions": supported,
+	})
+}
+
+// annotateDeprecated はレスポンスに非推奨のヘッダーを付ける
+// （Deprecation: RFC 9745、Link rel="successor-version" で後継のエンドポイント）
+func annotateDeprecated(c echo.Context, successor string) {
+	header := c.Response().Header()
+	header.Set("Deprecation", "true")
+	header.Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TestPathAPIVersion tests parsing the version segment after /api
+func TestPathAPIVersion(t *testing.T) {
+	tests := []struct {
+		rest      string
+		version   int
+		remainder string
+		versioned bool
+	}{
+		{"/v1/jobs", 1, "/jobs", true},
+		{"/v2/jobs/abc/logs", 2, "/jobs/abc/logs", true},
+		{"/v1", 1, "", true},
+		{"/v1/", 1, "/", true},
+		{"/jobs", 0, "/jobs", false},
+		{"", 0, "", false},
+		{"/v", 0, "/v", false},
+		{"/v0/jobs", 0, "/v0/jobs", false},
+		{"/v-1/jobs", 0, "/v-1/jobs", false},
+		{"/vx/jobs", 0, "/vx/jobs", false},
+		{"/videos/1", 0, "/videos/1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rest, func(t *testing.T) {
+			version, remainder, versioned := pathAPIVersion(tt.rest)
+			if version != tt.version || remainder != tt.remainder || versioned != tt.versioned {
+				t.Errorf("pathAPIVersion(%q) = %d, %q, %v; want %d, %q, %v",
+					tt.rest, version, remainder, versioned, tt.version, tt.remainder, tt.versioned)
+			}
+		})
+	}
+}
+
+// TestAPIVersionRouter tests the path rewrite, the version headers and the
+// deprecation of unversioned paths
+func TestAPIVersionRouter(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		header     string
+		status     int
+		path       string // path seen by the route (echo.Context.Param("id"))
+		version    string
+		deprecated bool
+	}{
+		{name: "versioned", target: "/api/v1/items/abc", status: http.StatusOK, path: "abc", version: "1"},
+		{name: "escaped", target: "/api/v1/items/a%2Fb", status: http.StatusOK, path: "a%2Fb", version: "1"},
+		{name: "unversioned", target: "/api/items/abc", status: http.StatusOK, path: "abc", version: "1", deprecated: true},
+		{name: "header", target: "/api/items/abc", header: "v1", status: http.StatusOK, path: "abc", version: "1", deprecated: true},
+		{name: "unsupported path", target: "/api/v9/items/abc", status: http.StatusNotFound},
+		{name: "unsupported header", target: "/api/items/abc", header: "9", status: http.StatusBadRequest},
+		{name: "outside api", target: "/apiary", status: http.StatusOK, path: "apiary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Pre(APIVersionRouter())
+			e.GET("/api/items/:id", func(c echo.Context) error { return c.String(http.StatusOK, c.Param("id")) })
+			e.GET("/apiary", func(c echo.Context) error { return c.String(http.StatusOK, "apiary") })
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set(APIVersionHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := rec.Body.String(); got != tt.path {
+				t.Errorf("route got %q, want %q", got, tt.path)
+			}
+			if got := rec.Header().Get(APIVersionHeader); got != tt.version {
+				t.Errorf("%s = %q, want %q", APIVersionHeader, got, tt.version)
+			}
+			if got := rec.Header().Get("Deprecation") == "true"; got != tt.deprecated {
+				t.Errorf("deprecated = %v, want %v", got, tt.deprecated)
+			}
+			if tt.deprecated {
+				if link := rec.Header().Get("Link"); link != `</api/v1/items/abc>; rel="successor-version"` {
+					t.Errorf("Link = %q", link)
+				}
+			}
+		})
+	}
+}
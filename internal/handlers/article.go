@@ -1,9 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 
+	"zbor/internal/asr"
+	"zbor/internal/export"
+	"zbor/internal/models"
 	"zbor/internal/storage"
 	"zbor/internal/storage/sqlc"
 	"zbor/web/components"
@@ -13,40 +23,52 @@ import (
 
 // ArticleHandler は記事APIのハンドラー
 type ArticleHandler struct {
-	repo *storage.ArticleRepository
+	repo      *storage.ArticleRepository
+	holdRepo  *storage.HoldRepository
+	exporter  *export.Exporter
+	redaction *Redaction
 }
 
 // NewArticleHandler は新しいArticleHandlerを作成
-func NewArticleHandler(repo *storage.ArticleRepository) *ArticleHandler {
-	return &ArticleHandler{repo: repo}
+func NewArticleHandler(repo *storage.ArticleRepository, holdRepo *storage.HoldRepository, exporter *export.Exporter) *ArticleHandler {
+	return &ArticleHandler{repo: repo, holdRepo: holdRepo, exporter: exporter}
 }
 
-// List は記事一覧を取得
+// SetRedaction は記事の出力の伏せ字を設定（Redaction を参照）
+func (h *ArticleHandler) SetRedaction(redaction *Redaction) {
+	h.redaction = redaction
+}
+
+// List は記事一覧を取得（ETag を付け、If-None-Match が一致すれば 304）
+// ?person= / ?organization= / ?place= / ?keyword= で分析で抽出した語を含む記事に絞り込む（1つだけ指定可）
 func (h *ArticleHandler) List(c echo.Context) error {
 	ctx := c.Request().Context()
 	opts := storage.ListOptions{
-		Limit:      20,
 		Status:     c.QueryParam("status"),
 		SourceType: c.QueryParam("source_type"),
 	}
-
-	if limit := c.QueryParam("limit"); limit != "" {
-		if l, err := strconv.Atoi(limit); err == nil {
-			opts.Limit = l
+	opts.Limit, opts.Offset = parsePagination(c, 20)
+	for _, kind := range storage.EntityKinds {
+		name := c.QueryParam(kind)
+		if name == "" {
+			continue
 		}
-	}
-	if offset := c.QueryParam("offset"); offset != "" {
-		if o, err := strconv.Atoi(offset); err == nil {
-			opts.Offset = o
+		if opts.Entity != "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "only one of person, organization, place and keyword can be specified"})
 		}
+		opts.EntityKind, opts.Entity = kind, name
 	}
 
 	articles, err := h.repo.List(ctx, opts)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
+	total, err := h.repo.Count(ctx, opts)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
 
-	return c.JSON(http.StatusOK, articles)
+	return jsonWithETag(c, http.StatusOK, newPage(h.redaction.Articles(c, articles), total, opts.Limit, opts.Offset))
 }
 
 // Get は記事を取得
@@ -68,18 +90,119 @@ func (h *ArticleHandler) Get(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
+	// チャプター（sections列のJSON）を構造化して返す
+	sections, err := articleSections(article)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
 	// レスポンス用の構造体
 	type ArticleResponse struct {
 		sqlc.Article
-		Tags []sqlc.Tag `json:"tags,omitempty"`
+		Sections []models.Section `json:"sections,omitempty"`
+		Tags     []sqlc.Tag       `json:"tags,omitempty"`
 	}
 
 	return c.JSON(http.StatusOK, ArticleResponse{
-		Article: *article,
-		Tags:    tags,
+		Article:  *h.redaction.Article(c, article),
+		Sections: h.redaction.Sections(c, sections),
+		Tags:     tags,
 	})
 }
 
+// articleSections は sections列のJSONをパース（無ければ nil）
+func articleSections(article *sqlc.Article) ([]models.Section, error) {
+	var sections []models.Section
+	if article.Sections != nil && *article.Sections != "" {
+		if err := json.Unmarshal([]byte(*article.Sections), &sections); err != nil {
+			return nil, fmt.Errorf("invalid sections: %w", err)
+		}
+	}
+	return sections, nil
+}
+
+// Export は記事をダウンロード
+// GET /api/articles/:id/export?format=md|txt|zip
+// md は front matter（ID・タイトル・ソース・タグ・日時などのメタデータ）付きの Markdown、txt は本文のみ（どちらもセクションがあれば見出しを付ける）
+// zip は Markdown に文字起こしの SRT（srt=0 で除く）と、audio=1 で音声（start・end を秒で指定するとその範囲のクリップ、clip_format=wav|mp3|opus）を添付する
+func (h *ArticleHandler) Export(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "md"
+	}
+	if format != "txt" && format != "md" && format != "zip" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "format must be one of: md, txt, zip"})
+	}
+
+	opts := export.BundleOptions{
+		Transcript: format == "zip" && c.QueryParam("srt") != "0",
+		Audio:      format == "zip" && c.QueryParam("audio") == "1",
+		ClipFormat: c.QueryParam("clip_format"),
+	}
+	if opts.Audio && (c.QueryParam("start") != "" || c.QueryParam("end") != "") {
+		start, err := strconv.ParseFloat(c.QueryParam("start"), 64)
+		if err != nil || start < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid start"})
+		}
+		end, err := strconv.ParseFloat(c.QueryParam("end"), 64)
+		if err != nil || end <= start {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid end"})
+		}
+		if end-start > asr.MaxClipDuration {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("clip must be at most %d seconds", asr.MaxClipDuration)})
+		}
+		opts.Start, opts.End = start, end
+	}
+	if _, ok := asr.ClipContentTypes[opts.ClipFormat]; opts.ClipFormat != "" && !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "clip_format must be 'wav', 'mp3' or 'opus'"})
+	}
+
+	article, err := h.repo.GetByID(ctx, c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if article == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "article not found"})
+	}
+	if h.redaction.enabled(c) {
+		article = h.redaction.Article(c, article)
+		opts.Redactor = h.redaction.Redactor()
+	}
+
+	bundle, err := h.exporter.Bundle(ctx, article, opts)
+	if errors.Is(err, export.ErrNoAudio) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	if errors.Is(err, asr.ErrNoFFmpeg) {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "ffmpeg is required for " + opts.ClipFormat + " clips (use clip_format=wav)"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	defer bundle.Close()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=\"article.%s\"; filename*=UTF-8''%s", format, url.PathEscape(bundle.Name+"."+format)))
+	switch format {
+	case "zip":
+		c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+		c.Response().WriteHeader(http.StatusOK)
+		// ヘッダー送信後のエラーはレスポンスに返せないため、ZIPが途中で切れる
+		return bundle.WriteZip(c.Response())
+	case "txt":
+		sections, err := articleSections(article)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		doc := models.Article{Title: article.Title, Content: article.Content, Sections: sections}
+		return c.Blob(http.StatusOK, "text/plain; charset=utf-8", []byte(doc.FormatAsText()))
+	default:
+		return c.Blob(http.StatusOK, "text/markdown; charset=utf-8", bundle.Markdown)
+	}
+}
+
 // CreateRequest は記事作成リクエスト
 type CreateRequest struct {
 	Title      string `json:"title"`
@@ -188,13 +311,13 @@ func (h *ArticleHandler) Update(c echo.Context) error {
 	}
 
 	if err := h.repo.Update(ctx, article); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return holdError(c, err)
 	}
 
-	return c.JSON(http.StatusOK, article)
+	return c.JSON(http.StatusOK, h.redaction.Article(c, article))
 }
 
-// Delete は記事を削除
+// Delete は記事を削除（論理削除。保持期間を過ぎるまでは Restore で元に戻せる）
 func (h *ArticleHandler) Delete(c echo.Context) error {
 	ctx := c.Request().Context()
 	id := c.Param("id")
@@ -208,13 +331,112 @@ func (h *ArticleHandler) Delete(c echo.Context) error {
 	}
 
 	if err := h.repo.Delete(ctx, id); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return holdError(c, err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
 
-// Search は記事を検索
+// ListDeleted は削除済み（パージ前）の記事一覧を取得
+// GET /api/articles/deleted
+func (h *ArticleHandler) ListDeleted(c echo.Context) error {
+	ctx := c.Request().Context()
+	limit, offset := parsePagination(c, 20)
+
+	articles, err := h.repo.ListDeleted(ctx, limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	total, err := h.repo.CountDeleted(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, newPage(h.redaction.Articles(c, articles), total, limit, offset))
+}
+
+// Restore は削除済みの記事を元に戻す
+// POST /api/articles/:id/restore
+func (h *ArticleHandler) Restore(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	article, err := h.repo.GetDeleted(ctx, c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if article == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "deleted article not found"})
+	}
+
+	if err := h.repo.Restore(ctx, article); err != nil {
+		return holdError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, h.redaction.Article(c, article))
+}
+
+// Revisions は記事の版（更新前の内容）を新しい順に取得
+// GET /api/articles/:id/revisions
+func (h *ArticleHandler) Revisions(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+	limit, offset := parsePagination(c, 20)
+
+	article, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if article == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "article not found"})
+	}
+
+	revisions, err := h.repo.ListRevisions(ctx, id, limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	total, err := h.repo.CountRevisions(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, newPage(h.redaction.Revisions(c, revisions), total, limit, offset))
+}
+
+// RestoreRevision は記事を版の内容に戻す（現在の内容は新しい版として記録される）
+// POST /api/articles/:id/revisions/:revision_id/restore
+func (h *ArticleHandler) RestoreRevision(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	revisionID, err := strconv.ParseInt(c.Param("revision_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid revision_id"})
+	}
+
+	article, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if article == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "article not found"})
+	}
+
+	revision, err := h.repo.GetRevision(ctx, revisionID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if revision == nil || revision.ArticleID != id {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "revision not found"})
+	}
+
+	if err := h.repo.RestoreRevision(ctx, article, revision); err != nil {
+		return holdError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, h.redaction.Article(c, article))
+}
+
+// Search は記事を検索（ETag を付け、If-None-Match が一致すれば 304）
 func (h *ArticleHandler) Search(c echo.Context) error {
 	ctx := c.Request().Context()
 	query := c.QueryParam("q")
@@ -229,12 +451,27 @@ func (h *ArticleHandler) Search(c echo.Context) error {
 		}
 	}
 
-	articles, err := h.repo.Search(ctx, query, limit)
+	results, err := h.repo.Search(ctx, query, limit)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
-	return c.JSON(http.StatusOK, articles)
+	// レスポンス用の構造体（一致箇所はHTMLエスケープ済みで <mark> で囲む）
+	type SearchHit struct {
+		sqlc.Article
+		TitleHighlight string `json:"title_highlight"`
+		Snippet        string `json:"snippet"`
+	}
+
+	hits := make([]SearchHit, 0, len(results))
+	for _, r := range results {
+		hits = append(hits, SearchHit{
+			Article:        *h.redaction.Article(c, &r.Article),
+			TitleHighlight: highlightHTML(h.redaction.Highlight(c, r.TitleHighlight)),
+			Snippet:        highlightHTML(h.redaction.Highlight(c, r.Snippet)),
+		})
+	}
+	return jsonWithETag(c, http.StatusOK, hits)
 }
 
 // AddTag は記事にタグを追加
@@ -273,14 +510,41 @@ func (h *ArticleHandler) RemoveTag(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
-// ListPage は記事一覧ページを表示
+// ListPage は記事一覧ページを表示（?q= で検索し、一致箇所を表示。?person= で言及している人物で絞り込む）
 func (h *ArticleHandler) ListPage(c echo.Context) error {
 	ctx := c.Request().Context()
-	articles, err := h.repo.List(ctx, storage.ListOptions{Limit: 100})
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		person := components.PersonFilter{Selected: c.QueryParam("person")}
+		opts := storage.ListOptions{Limit: 100}
+		if person.Selected != "" {
+			opts.EntityKind, opts.Entity = storage.EntityKindPerson, person.Selected
+		}
+		articles, err := h.repo.List(ctx, opts)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+		person.Options, err = h.repo.EntityFacets(ctx, storage.EntityKindPerson, 50)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+		return renderWithETag(c, components.ArticleList(h.redaction.Articles(c, articles), "", nil, person))
+	}
+
+	results, err := h.repo.Search(ctx, query, 100)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
-	return render(c, components.ArticleList(articles))
+	articles := make([]sqlc.Article, 0, len(results))
+	highlights := make(map[string]components.SearchHighlight, len(results))
+	for _, r := range results {
+		articles = append(articles, *h.redaction.Article(c, &r.Article))
+		highlights[r.ID] = components.SearchHighlight{
+			Title:   highlightHTML(h.redaction.Highlight(c, r.TitleHighlight)),
+			Snippet: highlightHTML(h.redaction.Highlight(c, r.Snippet)),
+		}
+	}
+	return renderWithETag(c, components.ArticleList(articles, query, highlights, components.PersonFilter{}))
 }
 
 // DetailPage は記事詳細ページを表示
@@ -301,5 +565,47 @@ func (h *ArticleHandler) DetailPage(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
-	return render(c, components.ArticleDetail(article, tags))
+	hold, err := h.holdInfo(ctx, article)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	sections, err := articleSections(article)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return render(c, components.ArticleDetail(h.redaction.Article(c, article), tags, hold, h.redaction.Sections(c, sections)))
+}
+
+// holdInfo は記事とソースのロック状態・監査ログを取得
+func (h *ArticleHandler) holdInfo(ctx context.Context, article *sqlc.Article) (components.HoldInfo, error) {
+	var info components.HoldInfo
+	var err error
+
+	info.Article, err = h.holdRepo.Get(ctx, storage.HoldTargetArticle, article.ID)
+	if err != nil {
+		return info, err
+	}
+	info.Events, err = h.holdRepo.ListEvents(ctx, storage.HoldTargetArticle, article.ID)
+	if err != nil {
+		return info, err
+	}
+
+	if article.SourceID != nil {
+		info.Source, err = h.holdRepo.Get(ctx, storage.HoldTargetSource, *article.SourceID)
+		if err != nil {
+			return info, err
+		}
+		sourceEvents, err := h.holdRepo.ListEvents(ctx, storage.HoldTargetSource, *article.SourceID)
+		if err != nil {
+			return info, err
+		}
+		info.Events = append(info.Events, sourceEvents...)
+		sort.SliceStable(info.Events, func(i, j int) bool {
+			return info.Events[i].CreatedAt.After(info.Events[j].CreatedAt)
+		})
+	}
+
+	return info, nil
 }
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"zbor/internal/semantic"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AskHandler は記録全体への質問応答（RAG）APIのハンドラー
+type AskHandler struct {
+	asker     *semantic.Asker
+	redaction *Redaction
+}
+
+// NewAskHandler は新しいAskHandlerを作成
+func NewAskHandler(asker *semantic.Asker) *AskHandler {
+	return &AskHandler{asker: asker}
+}
+
+// SetRedaction は回答と引用の伏せ字を設定（Redaction を参照）
+func (h *AskHandler) SetRedaction(redaction *Redaction) {
+	h.redaction = redaction
+}
+
+// AskRequest は質問応答のリクエスト
+type AskRequest struct {
+	Question string `json:"question"`
+	Limit    int    `json:"limit,omitempty"` // LLMに渡すチャンクの数（デフォルト8、最大20）
+	Scope    string `json:"scope,omitempty"` // transcripts（デフォルト、文字起こしのみ）または all（すべての記事）
+}
+
+// Ask は質問に意味の近いチャンクを根拠に回答する（引用付き）
+// POST /api/ask
+func (h *AskHandler) Ask(c echo.Context) error {
+	if err := h.asker.Ready(); err != nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+	}
+
+	var req AskRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	req.Question = strings.TrimSpace(req.Question)
+	if req.Question == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "question is required"})
+	}
+	if req.Limit <= 0 {
+		req.Limit = semantic.DefaultAskChunks
+	}
+	var transcriptsOnly bool
+	switch req.Scope {
+	case "", "transcripts":
+		transcriptsOnly = true
+	case "all":
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "scope must be transcripts or all"})
+	}
+
+	answer, err := h.asker.Ask(c.Request().Context(), req.Question, min(req.Limit, semantic.MaxAskChunks), transcriptsOnly)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	// LLM には元のテキストを渡すため、回答と引用は出力するときに伏せる
+	answer.Answer = h.redaction.Text(c, answer.Answer)
+	for i := range answer.Citations {
+		answer.Citations[i].Hit = h.redaction.Hits(c, []semantic.Hit{answer.Citations[i].Hit})[0]
+	}
+	return c.JSON(http.StatusOK, answer)
+}
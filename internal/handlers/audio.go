@@ -1,15 +1,29 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
+	"zbor/internal/archive"
 	"zbor/internal/asr"
 	"zbor/internal/ingestion"
+	"zbor/internal/models"
+	"zbor/internal/retention"
 	"zbor/internal/storage"
+	"zbor/internal/translate"
 	"zbor/web/components"
 
 	"github.com/labstack/echo/v4"
@@ -22,7 +36,15 @@ type AudioHandler struct {
 	artifactRepo *storage.ArtifactRepository
 	articleRepo  *storage.ArticleRepository
 	jobRepo      *storage.JobRepository
+	bookmarkRepo *storage.BookmarkRepository
 	asrConfig    *asr.Config
+	archiver     *archive.Archiver
+	wavCache     *asr.ConversionCache
+	uploads      *ingestion.UploadStore // nil: resumable uploads disabled
+	blobs        storage.Blob           // object storage of the audio files (nil: local disk only)
+	// streamOriginal serves playable originals instead of WAV by default
+	streamOriginal bool
+	redaction      *Redaction // masks transcript output (nil: only for ?redact=1)
 }
 
 // NewAudioHandler creates a new AudioHandler
@@ -32,6 +54,7 @@ func NewAudioHandler(
 	artifactRepo *storage.ArtifactRepository,
 	articleRepo *storage.ArticleRepository,
 	jobRepo *storage.JobRepository,
+	bookmarkRepo *storage.BookmarkRepository,
 	asrConfig *asr.Config,
 ) *AudioHandler {
 	return &AudioHandler{
@@ -40,11 +63,139 @@ func NewAudioHandler(
 		artifactRepo: artifactRepo,
 		articleRepo:  articleRepo,
 		jobRepo:      jobRepo,
+		bookmarkRepo: bookmarkRepo,
 		asrConfig:    asrConfig,
 	}
 }
 
+// SetArchiver enables rehydration of archived sources
+func (h *AudioHandler) SetArchiver(archiver *archive.Archiver) {
+	h.archiver = archiver
+}
+
+// SetConversionCache sets where on-demand WAV conversions are kept
+func (h *AudioHandler) SetConversionCache(cache *asr.ConversionCache) {
+	h.wavCache = cache
+}
+
+// SetStreamOriginal makes the default stream serve the original file instead
+// of a WAV conversion when browsers can play it (MP3, M4A, Ogg, FLAC, ...)
+func (h *AudioHandler) SetStreamOriginal(enabled bool) {
+	h.streamOriginal = enabled
+}
+
+// SetRedaction sets how transcript output is redacted (see Redaction)
+func (h *AudioHandler) SetRedaction(redaction *Redaction) {
+	h.redaction = redaction
+}
+
+// SetBlobStore sets the object storage the audio files are copied to (see
+// AudioIngester.SetBlobStore). Files missing on this node are streamed or
+// fetched from it
+func (h *AudioHandler) SetBlobStore(blob storage.Blob) {
+	h.blobs = blob
+}
+
+// streamBlob serves an object of the blob store with Range support
+func (h *AudioHandler) streamBlob(c echo.Context, uri, contentType string) error {
+	obj, err := h.blobs.Open(c.Request().Context(), uri)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "failed to open audio in the blob store"})
+	}
+	defer obj.Close()
+
+	c.Response().Header().Set(echo.HeaderContentType, contentType)
+	http.ServeContent(c.Response(), c.Request(), "", obj.ModTime(), obj)
+	return nil
+}
+
+// acquireWav returns a WAV version of audioPath for playback. release must
+// be called once the file is no longer read
+func (h *AudioHandler) acquireWav(ctx context.Context, audioPath string) (string, func(), error) {
+	if filepath.Ext(audioPath) == ".wav" {
+		return audioPath, func() {}, nil
+	}
+	if h.wavCache != nil {
+		return h.wavCache.Acquire(ctx, audioPath)
+	}
+	// No cache configured: convert to a temp file that is removed on release
+	wavPath, err := asr.ConvertToWavTemp(ctx, audioPath)
+	if err != nil {
+		return "", nil, err
+	}
+	return wavPath, func() { os.Remove(wavPath) }, nil
+}
+
+// acquireStream returns audioPath transcoded to format for playback, kept in
+// the conversion cache when one is configured. release must be called once
+// the file is no longer read
+func (h *AudioHandler) acquireStream(ctx context.Context, audioPath, format string) (string, func(), error) {
+	if h.wavCache != nil {
+		return h.wavCache.AcquireStream(ctx, audioPath, format)
+	}
+	// No cache configured: encode to a temp file that is removed on release
+	f, err := os.CreateTemp("", "zbor-stream-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	f.Close()
+	if err := asr.EncodeForStreaming(ctx, audioPath, f.Name(), format); err != nil {
+		os.Remove(f.Name())
+		return "", nil, err
+	}
+	return f.Name(), func() { os.Remove(f.Name()) }, nil
+}
+
+// waveform returns the waveform peaks of audioPath, kept in the conversion
+// cache when one is configured
+func (h *AudioHandler) waveform(ctx context.Context, audioPath string) (*asr.Waveform, error) {
+	if h.wavCache != nil {
+		return h.wavCache.Waveform(ctx, audioPath)
+	}
+	return asr.ComputeWaveform(ctx, audioPath)
+}
+
+// playablePath returns audioPath, or its preview when the original was
+// removed by the storage cleanup (see retention.Manager)
+func playablePath(audioPath string) string {
+	if _, err := os.Stat(audioPath); os.IsNotExist(err) {
+		proxyPath := asr.ProxyPath(audioPath)
+		if _, err := os.Stat(proxyPath); err == nil {
+			return proxyPath
+		}
+	}
+	return audioPath
+}
+
+// Rehydrate restores the audio and artifacts of an archived source
+// POST /api/audio/:source_id/rehydrate
+func (h *AudioHandler) Rehydrate(c echo.Context) error {
+	ctx := c.Request().Context()
+	sourceID := c.Param("source_id")
+
+	source, err := h.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+	if !archive.IsArchived(source) {
+		return c.JSON(http.StatusOK, map[string]string{"source_id": sourceID, "status": *source.Status})
+	}
+	if h.archiver == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "archive is not configured (set ZBOR_ARCHIVE_DIR)"})
+	}
+
+	if err := h.archiver.Rehydrate(ctx, sourceID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"source_id": sourceID, "status": storage.SourceStatusCompleted})
+}
+
 // Upload handles audio file upload
+// Files are sent as multipart "files" and/or as "upload_id" values of
+// completed resumable uploads (see CreateUpload)
 // POST /api/ingest/audio
 func (h *AudioHandler) Upload(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -53,13 +204,23 @@ func (h *AudioHandler) Upload(c echo.Context) error {
 	title := c.FormValue("title")
 
 	// Get uploaded files
+	var files []*multipart.FileHeader
 	form, err := c.MultipartForm()
+	if err == nil {
+		files = form.File["files"]
+	} else if !errors.Is(err, http.ErrNotMultipart) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to parse form"})
+	}
+	params, err := c.FormParams()
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to parse form"})
 	}
+	uploadIDs := params["upload_id"]
+	if len(uploadIDs) > 0 && h.uploads == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "resumable uploads are not enabled"})
+	}
 
-	files := form.File["files"]
-	if len(files) == 0 {
+	if len(files) == 0 && len(uploadIDs) == 0 {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "no files uploaded"})
 	}
 
@@ -78,21 +239,323 @@ func (h *AudioHandler) Upload(c echo.Context) error {
 			Speaker:  "", // Will be extracted from filename
 		})
 	}
+	// Resumable uploads are read from their chunks
+	for _, id := range uploadIDs {
+		upload, r, err := h.uploads.Open(ctx, id)
+		if err != nil {
+			return uploadError(c, err)
+		}
+		defer r.Close()
+
+		audioFiles = append(audioFiles, ingestion.AudioFile{
+			Filename: upload.Filename,
+			Reader:   r,
+		})
+	}
+
+	// Parse optional trim parameters
+	trim, err := parseTrimOptions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	// Parse optional per-job transcription settings
+	transcription, err := parseTranscriptionOptions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if transcription != nil && transcription.Model != "" {
+		if err := h.ingester.CheckModel(transcription.Model); err != nil {
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+		}
+	}
 
 	// Ingest audio
 	result, err := h.ingester.Ingest(ctx, ingestion.IngestOptions{
 		Title:    title,
 		Files:    audioFiles,
 		Priority: 5, // Normal priority
+		Trim:     trim,
+		Enhance:  c.FormValue("enhance") == "1",
+		TwoPass:  c.FormValue("two_pass") == "1",
+		Force:    c.FormValue("force") == "1",
+
+		Transcription: transcription,
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return quotaError(c, err)
+	}
+
+	// The same audio was already uploaded: nothing was stored or queued.
+	// Resumable uploads are kept so force=1 can reuse them
+	if result.Duplicate {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"source_id": result.SourceID,
+			"job_id":    result.JobID,
+			"status":    result.Status,
+			"duplicate": true,
+			"url":       "/audio/" + result.SourceID + "/sync",
+			"message":   "The same audio was already uploaded (set force=1 to transcribe it again)",
+		})
 	}
 
-	return c.JSON(http.StatusAccepted, map[string]string{
+	// The files are stored with the source
+	for _, id := range uploadIDs {
+		if err := h.uploads.Delete(ctx, id); err != nil {
+			slog.Warn("Failed to remove upload", "upload_id", id, "error", err)
+		}
+	}
+
+	response := map[string]string{
 		"source_id": result.SourceID,
 		"job_id":    result.JobID,
 		"message":   "Audio ingestion started",
+	}
+	if result.RefineJobID != "" {
+		response["refine_job_id"] = result.RefineJobID
+	}
+	return c.JSON(http.StatusAccepted, response)
+}
+
+// IngestYouTubeRequest represents the request body for YouTube ingestion
+type IngestYouTubeRequest struct {
+	URL      string `json:"url"`
+	Title    string `json:"title"`
+	Language string `json:"language"` // caption language used if ASR fails (default "ja")
+	Enhance  bool   `json:"enhance"`  // denoise the audio before ASR
+}
+
+// IngestYouTube queues a YouTube video for download and transcription
+// POST /api/ingest/youtube
+func (h *AudioHandler) IngestYouTube(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req IngestYouTubeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if strings.TrimSpace(req.URL) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "url is required"})
+	}
+
+	result, err := h.ingester.IngestYouTube(ctx, ingestion.IngestYouTubeOptions{
+		URL:      req.URL,
+		Title:    req.Title,
+		Language: req.Language,
+		Priority: storage.JobPriorityNormal,
+		Enhance:  req.Enhance,
+	})
+	if err != nil {
+		return quotaError(c, err)
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"source_id": result.SourceID,
+		"job_id":    result.JobID,
+		"message":   "YouTube ingestion started",
+	})
+}
+
+// IngestAudioURLRequest represents the request body for audio URL ingestion
+type IngestAudioURLRequest struct {
+	URL     string `json:"url"`
+	Title   string `json:"title"`
+	Enhance bool   `json:"enhance"` // denoise the audio before ASR
+}
+
+// IngestAudioURL queues a direct media URL for download and transcription
+// POST /api/ingest/audio-url
+func (h *AudioHandler) IngestAudioURL(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req IngestAudioURLRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if u, err := url.Parse(strings.TrimSpace(req.URL)); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "url must be an http(s) URL"})
+	}
+
+	result, err := h.ingester.IngestAudioURL(ctx, ingestion.IngestAudioURLOptions{
+		URL:      req.URL,
+		Title:    req.Title,
+		Priority: storage.JobPriorityNormal,
+		Enhance:  req.Enhance,
+	})
+	if err != nil {
+		return quotaError(c, err)
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"source_id": result.SourceID,
+		"job_id":    result.JobID,
+		"message":   "Audio download started",
+	})
+}
+
+// maxTranscriptImportSize caps the transcript file of an import (it is parsed in memory)
+const maxTranscriptImportSize = 50 << 20
+
+// IngestTranscript imports an existing transcript (SRT, WebVTT or the JSON
+// of an exported transcript) as a completed source and article without ASR
+// POST /api/ingest/transcript
+// Multipart fields: file (required), audio (optional recording for playback
+// and clips), title, language, format (srt|vtt|json, detected when omitted)
+func (h *AudioHandler) IngestTranscript(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "transcript file is required"})
+	}
+	if fh.Size > maxTranscriptImportSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "transcript file is too large"})
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to open file"})
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to read file"})
+	}
+
+	opts := ingestion.ImportTranscriptOptions{
+		Title:    c.FormValue("title"),
+		Filename: fh.Filename,
+		Data:     data,
+		Format:   strings.ToLower(c.FormValue("format")),
+		Language: c.FormValue("language"),
+	}
+	if audio, err := c.FormFile("audio"); err == nil {
+		af, err := audio.Open()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to open file"})
+		}
+		defer af.Close()
+		opts.Audio = &ingestion.AudioFile{Filename: audio.Filename, Reader: af}
+	}
+
+	result, err := h.ingester.ImportTranscript(ctx, opts)
+	if err != nil {
+		if errors.Is(err, ingestion.ErrInvalidImport) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"source_id":  result.SourceID,
+		"article_id": result.ArticleID,
+		"segments":   result.Segments,
+		"url":        "/audio/" + result.SourceID + "/sync",
+		"message":    "Transcript imported",
+	})
+}
+
+// parseTrimOptions reads trim_start, trim_end (seconds) and trim_exclude
+// ("start-end" ranges separated by commas, e.g. "30-45,120.5-130") from the form
+func parseTrimOptions(c echo.Context) (*asr.TrimOptions, error) {
+	trim := &asr.TrimOptions{}
+
+	if v := strings.TrimSpace(c.FormValue("trim_start")); v != "" {
+		start, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trim_start: %s", v)
+		}
+		trim.Start = start
+	}
+	if v := strings.TrimSpace(c.FormValue("trim_end")); v != "" {
+		end, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trim_end: %s", v)
+		}
+		trim.End = end
+	}
+	if v := strings.TrimSpace(c.FormValue("trim_exclude")); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+			if len(bounds) != 2 {
+				return nil, fmt.Errorf("invalid trim_exclude range: %s", part)
+			}
+			start, err1 := strconv.ParseFloat(strings.TrimSpace(bounds[0]), 64)
+			end, err2 := strconv.ParseFloat(strings.TrimSpace(bounds[1]), 64)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("invalid trim_exclude range: %s", part)
+			}
+			trim.Exclude = append(trim.Exclude, asr.TimeRange{Start: start, End: end})
+		}
+	}
+
+	if trim.IsZero() {
+		return nil, nil
+	}
+	if err := trim.Validate(); err != nil {
+		return nil, err
+	}
+	return trim, nil
+}
+
+// parseTranscriptionOptions reads per-job transcription settings from the form:
+// model, decoding_method, max_active_paths, silence_threshold,
+// min_silence_duration, max_block_duration, tempo, overlap and hotwords
+// (separated by commas or newlines). Returns nil when none is set
+func parseTranscriptionOptions(c echo.Context) (*ingestion.TranscriptionOptions, error) {
+	opts := &ingestion.TranscriptionOptions{
+		Model:          strings.TrimSpace(c.FormValue("model")),
+		DecodingMethod: strings.TrimSpace(c.FormValue("decoding_method")),
+	}
+	if v := strings.TrimSpace(c.FormValue("max_active_paths")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_active_paths: %s", v)
+		}
+		opts.MaxActivePaths = n
+	}
+	floats := []struct {
+		name  string
+		value *float64
+	}{
+		{"silence_threshold", &opts.SilenceThreshold},
+		{"min_silence_duration", &opts.MinSilenceDuration},
+		{"max_block_duration", &opts.MaxBlockDuration},
+		{"tempo", &opts.Tempo},
+		{"overlap", &opts.Overlap},
+	}
+	for _, f := range floats {
+		if v := strings.TrimSpace(c.FormValue(f.name)); v != "" {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", f.name, v)
+			}
+			*f.value = n
+		}
+	}
+	for _, word := range strings.FieldsFunc(c.FormValue("hotwords"), func(r rune) bool { return r == ',' || r == '\n' }) {
+		if word = strings.TrimSpace(word); word != "" {
+			opts.Hotwords = append(opts.Hotwords, word)
+		}
+	}
+
+	if opts.IsZero() {
+		return nil, nil
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// Models returns which ASR models can be used and the default model
+// Unavailable models come with the reason (e.g. missing model files)
+// GET /api/models
+func (h *AudioHandler) Models(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"models":      h.ingester.Models(),
+		"default":     h.ingester.DefaultModel(),
+		"enhancement": h.ingester.EnhancementStatus(),
+		"language_id": h.ingester.LanguageIDStatus(),
 	})
 }
 
@@ -102,11 +565,32 @@ func (h *AudioHandler) UploadPage(c echo.Context) error {
 }
 
 // Stream serves audio file with Range request support
-// GET /api/audio/:source_id/stream
+// GET /api/audio/:source_id/stream?quality=low
+// GET /api/audio/:source_id/stream?quality=high&format=aac
+// quality=low serves a low-bitrate Opus proxy for slow connections;
+// quality=high serves an Opus (or AAC) transcode kept in the conversion cache;
+// quality=original serves the original file when browsers can play it;
+// the default (full) serves full quality WAV, or the original file when
+// SetStreamOriginal is enabled. Without ffmpeg, low and high fall back to
+// the original file or WAV
 func (h *AudioHandler) Stream(c echo.Context) error {
 	ctx := c.Request().Context()
 	sourceID := c.Param("source_id")
 
+	quality := c.QueryParam("quality")
+	switch quality {
+	case "", "low", "high", "original", "full":
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "quality must be 'low', 'high', 'original' or 'full'"})
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = asr.StreamFormatOpus
+	}
+	if _, ok := asr.StreamContentTypes[format]; !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "format must be 'opus' or 'aac'"})
+	}
+
 	// Get source
 	source, err := h.sourceRepo.GetByID(ctx, sourceID)
 	if err != nil {
@@ -135,32 +619,321 @@ func (h *AudioHandler) Stream(c echo.Context) error {
 	// Use first file (or convert to WAV if needed)
 	audioPath := metadata.Files[0]
 
-	// Check if WAV version exists
-	wavPath := audioPath
-	ext := filepath.Ext(audioPath)
-	if ext != ".wav" {
-		// Look for converted WAV file
-		wavPath = audioPath[:len(audioPath)-len(ext)] + "_converted.wav"
-		if _, err := os.Stat(wavPath); os.IsNotExist(err) {
-			// Convert on demand
-			if err := asr.ConvertToWav(audioPath, wavPath); err != nil {
-				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to convert audio"})
+	// Files ingested by another node: playable originals are streamed from
+	// the blob store, the rest is fetched for conversion
+	if _, err := os.Stat(audioPath); os.IsNotExist(err) && h.blobs != nil {
+		if uri := storage.BlobURIs(source)[audioPath]; uri != "" {
+			contentType := asr.BrowserContentType(audioPath)
+			if contentType != "" && (quality == "original" || (quality == "" && h.streamOriginal)) {
+				return h.streamBlob(c, uri, contentType)
+			}
+			if err := h.ingester.FetchFiles(ctx, source); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch audio"})
 			}
 		}
 	}
 
+	if quality == "low" {
+		// Sources ingested before proxies existed get one on first request
+		proxyPath, err := asr.EnsureOpusProxy(ctx, audioPath)
+		if err != nil && !errors.Is(err, asr.ErrNoFFmpeg) {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to encode preview audio"})
+		}
+		// Without ffmpeg there is no proxy: serve full quality
+		if err == nil {
+			c.Response().Header().Set(echo.HeaderContentType, asr.ProxyContentType)
+			return c.File(proxyPath)
+		}
+	}
+
+	// The preview stands in for originals removed by the storage cleanup
+	audioPath = playablePath(audioPath)
+
+	if quality == "high" {
+		streamPath, release, err := h.acquireStream(ctx, audioPath, format)
+		if err != nil && !errors.Is(err, asr.ErrNoFFmpeg) {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to encode audio"})
+		}
+		// Without ffmpeg: serve the original file if possible, otherwise WAV
+		if err == nil {
+			defer release()
+			c.Response().Header().Set(echo.HeaderContentType, asr.StreamContentTypes[format])
+			return c.File(streamPath)
+		}
+	}
+
+	// The original file when the browser can play it (no conversion)
+	if quality == "original" || quality == "high" || (quality != "full" && h.streamOriginal) {
+		if contentType := asr.BrowserContentType(audioPath); contentType != "" {
+			c.Response().Header().Set(echo.HeaderContentType, contentType)
+			return c.File(audioPath)
+		}
+	}
+
+	// Convert on demand (kept in the conversion cache)
+	wavPath, release, err := h.acquireWav(ctx, audioPath)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to convert audio"})
+	}
+	defer release()
+
 	// Serve file with Range support (Echo handles this automatically)
 	return c.File(wavPath)
 }
 
+// Clip returns the audio from start to end (seconds) so a segment can be
+// played or downloaded without fetching the whole file
+// GET /api/audio/:source_id/clip?start=12.5&end=20&format=mp3&download=1
+func (h *AudioHandler) Clip(c echo.Context) error {
+	ctx := c.Request().Context()
+	sourceID := c.Param("source_id")
+
+	start, err := strconv.ParseFloat(c.QueryParam("start"), 64)
+	if err != nil || start < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid start"})
+	}
+	end, err := strconv.ParseFloat(c.QueryParam("end"), 64)
+	if err != nil || end <= start {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid end"})
+	}
+	if end-start > asr.MaxClipDuration {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("clip must be at most %d seconds", asr.MaxClipDuration)})
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = asr.DefaultClipFormat()
+	}
+	contentType, ok := asr.ClipContentTypes[format]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "format must be 'wav', 'mp3' or 'opus'"})
+	}
+
+	// Get source
+	source, err := h.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+	if source.Metadata == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "no metadata"})
+	}
+
+	var metadata struct {
+		Files []string `json:"files"`
+		Title string   `json:"title"`
+	}
+	if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to parse metadata"})
+	}
+	if len(metadata.Files) == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "no audio files"})
+	}
+
+	// Files ingested by another node come from the blob store
+	if err := h.ingester.FetchFiles(ctx, source); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch audio"})
+	}
+
+	// Extract from the original audio (only the span is decoded)
+	clipPath, err := asr.ExtractClipTemp(ctx, playablePath(metadata.Files[0]), start, end, format)
+	if errors.Is(err, asr.ErrNoFFmpeg) {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "ffmpeg is required for " + format + " clips (use format=wav)"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to extract clip: " + err.Error()})
+	}
+	defer os.Remove(clipPath)
+
+	name := "clip"
+	if metadata.Title != "" {
+		name = metadata.Title
+	}
+	filename := fmt.Sprintf("%s_%s-%s%s", name, clipTimeLabel(start), clipTimeLabel(end), asr.ClipExtensions[format])
+	disposition := "inline"
+	if c.QueryParam("download") == "1" {
+		disposition = "attachment"
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("%s; filename=\"clip%s\"; filename*=UTF-8''%s", disposition, asr.ClipExtensions[format], url.PathEscape(filename)))
+	c.Response().Header().Set(echo.HeaderContentType, contentType)
+
+	// Served with Range support so players can seek within the clip
+	return c.File(clipPath)
+}
+
+// clipTimeLabel formats seconds for a clip file name (e.g. 1m02.5s)
+func clipTimeLabel(sec float64) string {
+	minutes := int(sec) / 60
+	return fmt.Sprintf("%dm%04.1fs", minutes, sec-float64(minutes*60))
+}
+
 // WaveformResponse represents the waveform data response
 type WaveformResponse struct {
-	Peaks    []float64 `json:"peaks"`    // Peak amplitude values (0-1)
-	Duration float64   `json:"duration"` // Total duration in seconds
+	Peaks         []float64 `json:"peaks"`           // Peak amplitude values (0-1)
+	Duration      float64   `json:"duration"`        // Total duration in seconds
+	Start         float64   `json:"start"`           // Start of the peaks in seconds
+	End           float64   `json:"end"`             // End of the peaks in seconds
+	SamplesPerSec float64   `json:"samples_per_sec"` // Peaks per second (len(peaks) / (end - start))
+}
+
+// RebuildChapters recomputes the chapters of the source's articles from its transcript
+// POST /api/audio/:source_id/chapters
+func (h *AudioHandler) RebuildChapters(c echo.Context) error {
+	ctx := c.Request().Context()
+	sourceID := c.Param("source_id")
+
+	source, err := h.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+
+	// Sources under legal hold cannot be modified
+	if err := h.sourceRepo.CheckHold(ctx, sourceID); err != nil {
+		return holdError(c, err)
+	}
+
+	count, err := h.ingester.RebuildChapters(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"source_id": sourceID,
+		"chapters":  count,
+	})
 }
 
+// ResegmentRequest selects the transcripts to resegment (all when empty)
+type ResegmentRequest struct {
+	SourceIDs  []string `json:"source_ids"`
+	SourceType string   `json:"source_type"`
+	Since      string   `json:"since"` // YYYY-MM-DD or RFC 3339, inclusive
+	Until      string   `json:"until"` // YYYY-MM-DD or RFC 3339, exclusive
+	DryRun     bool     `json:"dry_run"`
+
+	// Segmentation rules (nil: the configured rules, see ZBOR_SEGMENT_*)
+	Segmentation *asr.SegmentationOptions `json:"segmentation,omitempty"`
+}
+
+// ResegmentTranscripts queues batch jobs that re-derive segments from the stored
+// tokens of existing transcripts, so segmentation changes apply without re-running ASR
+// POST /api/transcripts/resegment
+func (h *AudioHandler) ResegmentTranscripts(c echo.Context) error {
+	var req ResegmentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	filter := ingestion.ResegmentFilter{
+		SourceIDs:  req.SourceIDs,
+		SourceType: req.SourceType,
+	}
+	if req.Since != "" {
+		t, err := parseDateOrTime(req.Since)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid since: use YYYY-MM-DD or RFC 3339"})
+		}
+		filter.Since = &t
+	}
+	if req.Until != "" {
+		t, err := parseDateOrTime(req.Until)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid until: use YYYY-MM-DD or RFC 3339"})
+		}
+		filter.Until = &t
+	}
+
+	if req.Segmentation != nil {
+		if err := req.Segmentation.Validate(); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+
+	result, err := h.ingester.QueueResegment(c.Request().Context(), filter, req.Segmentation, req.DryRun)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusAccepted, result)
+}
+
+// parseDateOrTime parses a date (local midnight) or an RFC 3339 timestamp
+func parseDateOrTime(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// parseSegmentationOptions reads the segmentation query parameters of the export
+// (max_chars, max_duration, split_punct, min_gap). Zero options keep the stored segments
+func parseSegmentationOptions(c echo.Context) (asr.SegmentationOptions, error) {
+	var opts asr.SegmentationOptions
+	if v := c.QueryParam("max_chars"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("max_chars must be an integer")
+		}
+		opts.MaxChars = n
+	}
+	if v := c.QueryParam("max_duration"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return opts, fmt.Errorf("max_duration must be a number of seconds")
+		}
+		opts.MaxDuration = f
+	}
+	if v := c.QueryParam("min_gap"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return opts, fmt.Errorf("min_gap must be a number of seconds")
+		}
+		opts.MinGap = f
+	}
+	opts.SplitOnPunctuation = c.QueryParam("split_punct") == "1"
+	return opts, opts.Validate()
+}
+
+// Condensed returns the condensed (skim) view of the source's transcript:
+// one line per interval taken from the densest segment
+// GET /api/audio/:source_id/condensed
+func (h *AudioHandler) Condensed(c echo.Context) error {
+	ctx := c.Request().Context()
+	sourceID := c.Param("source_id")
+
+	source, err := h.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+
+	lines, err := h.ingester.Condensed(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	if lines == nil {
+		lines = []asr.CondensedLine{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"source_id": sourceID,
+		"interval":  asr.DefaultCondensedOptions().Interval,
+		"lines":     h.redaction.Lines(c, lines),
+	})
+}
+
+// maxWaveformPeaks limits the peaks parameter of the waveform endpoint
+const maxWaveformPeaks = 20000
+
 // Waveform returns waveform peak data for visualization
-// GET /api/audio/:source_id/waveform?samples_per_sec=10
+// GET /api/audio/:source_id/waveform?samples_per_sec=10&start=60&end=120
+// GET /api/audio/:source_id/waveform?peaks=800&start=60&end=120
 func (h *AudioHandler) Waveform(c echo.Context) error {
 	ctx := c.Request().Context()
 	sourceID := c.Param("source_id")
@@ -168,11 +941,33 @@ func (h *AudioHandler) Waveform(c echo.Context) error {
 	// Parse samples_per_sec parameter (default 10)
 	samplesPerSec := 10.0
 	if sps := c.QueryParam("samples_per_sec"); sps != "" {
-		if v, err := strconv.ParseFloat(sps, 64); err == nil && v > 0 && v <= 100 {
+		if v, err := strconv.ParseFloat(sps, 64); err == nil && v > 0 && v <= asr.WaveformPeakRate {
 			samplesPerSec = v
 		}
 	}
 
+	// Parse range parameters (start/end in seconds, default: the whole file)
+	rangeStart, rangeEnd := 0.0, 0.0
+	if startStr := c.QueryParam("start"); startStr != "" {
+		if v, err := strconv.ParseFloat(startStr, 64); err == nil && v >= 0 {
+			rangeStart = v
+		}
+	}
+	if endStr := c.QueryParam("end"); endStr != "" {
+		if v, err := strconv.ParseFloat(endStr, 64); err == nil && v > rangeStart {
+			rangeEnd = v
+		}
+	}
+
+	// Zoom by the number of peaks for the range (e.g. the canvas width),
+	// instead of samples_per_sec
+	numPeaks := 0
+	if peaksStr := c.QueryParam("peaks"); peaksStr != "" {
+		if v, err := strconv.Atoi(peaksStr); err == nil && v > 0 && v <= maxWaveformPeaks {
+			numPeaks = v
+		}
+	}
+
 	// Get source
 	source, err := h.sourceRepo.GetByID(ctx, sourceID)
 	if err != nil {
@@ -199,38 +994,59 @@ func (h *AudioHandler) Waveform(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "no audio files"})
 	}
 
-	audioPath := metadata.Files[0]
-
-	// Check if WAV version exists
-	wavPath := audioPath
-	ext := filepath.Ext(audioPath)
-	if ext != ".wav" {
-		wavPath = audioPath[:len(audioPath)-len(ext)] + "_converted.wav"
-		if _, err := os.Stat(wavPath); os.IsNotExist(err) {
-			if err := asr.ConvertToWav(audioPath, wavPath); err != nil {
-				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to convert audio"})
-			}
-		}
+	// Files ingested by another node come from the blob store
+	if err := h.ingester.FetchFiles(ctx, source); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch audio"})
 	}
 
-	// Compute waveform peaks
-	peaks, duration, err := asr.ComputeWaveformPeaks(wavPath, samplesPerSec)
+	audioPath := playablePath(metadata.Files[0])
+
+	// Peaks at the requested resolution, downsampled from the cached waveform
+	waveform, err := h.waveform(ctx, audioPath)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to compute waveform: " + err.Error()})
 	}
 
+	if rangeEnd <= 0 || rangeEnd > waveform.Duration {
+		rangeEnd = waveform.Duration
+	}
+	rangeStart = min(rangeStart, rangeEnd)
+	if numPeaks > 0 && rangeEnd > rangeStart {
+		samplesPerSec = min(float64(numPeaks)/(rangeEnd-rangeStart), asr.WaveformPeakRate)
+	}
+
+	peaks := waveform.Range(samplesPerSec, rangeStart, rangeEnd)
+	if rangeEnd > rangeStart {
+		samplesPerSec = float64(len(peaks)) / (rangeEnd - rangeStart)
+	}
 	return c.JSON(http.StatusOK, WaveformResponse{
-		Peaks:    peaks,
-		Duration: duration,
+		Peaks:         peaks,
+		Duration:      waveform.Duration,
+		Start:         rangeStart,
+		End:           rangeEnd,
+		SamplesPerSec: samplesPerSec,
 	})
 }
 
 // Transcript returns the transcription artifact for a source
+// (?version=<id> returns an earlier version, see TranscriptVersions;
+// ?redact=1 masks phone numbers, emails and keywords, see SetRedaction)
 // GET /api/audio/:source_id/transcript
 func (h *AudioHandler) Transcript(c echo.Context) error {
 	ctx := c.Request().Context()
 	sourceID := c.Param("source_id")
 
+	if versionID := c.QueryParam("version"); versionID != "" {
+		result, err := h.ingester.TranscriptVersion(ctx, sourceID, versionID)
+		if errors.Is(err, ingestion.ErrTranscriptVersionNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusOK, h.redaction.Result(c, result))
+	}
+
 	// Get artifacts for source
 	artifacts, err := h.artifactRepo.GetBySourceID(ctx, sourceID)
 	if err != nil {
@@ -248,13 +1064,177 @@ func (h *AudioHandler) Transcript(c echo.Context) error {
 			if err := json.Unmarshal([]byte(*artifact.Content), &result); err != nil {
 				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to parse transcript"})
 			}
-			return c.JSON(http.StatusOK, result)
+			return c.JSON(http.StatusOK, h.redaction.Result(c, &result))
 		}
 	}
 
 	return c.JSON(http.StatusNotFound, map[string]string{"error": "transcript not found"})
 }
 
+// exportContentTypes maps export formats to response content types
+var exportContentTypes = map[string]string{
+	"srt":       "application/x-subrip; charset=utf-8",
+	"vtt":       "text/vtt; charset=utf-8",
+	"txt":       "text/plain; charset=utf-8",
+	"json":      "application/json; charset=utf-8",
+	"fcpxml":    "application/xml; charset=utf-8",
+	"ttml":      "application/ttml+xml; charset=utf-8",
+	"markers":   "text/csv; charset=utf-8",
+	"chapters":  "text/plain; charset=utf-8",
+	"condensed": "text/plain; charset=utf-8",
+}
+
+// ExportTranscript downloads the transcript as a subtitle or text file
+// GET /api/audio/:source_id/transcript/export?format=srt|vtt|txt|json|fcpxml|ttml|markers|chapters|condensed&speakers=1&max_line=42
+// fcpxml (Final Cut Pro) and ttml (Premiere Pro) snap cues to frames: fps=23.976|24|25|29.97|30|50|59.94|60 (default 29.97), df=1 for drop-frame, lang=ja
+// markers is a DaVinci Resolve marker CSV of chapters and bookmarks; tc_hour sets the timeline start hour (default 1)
+// chapters is a chapter list ("0:00 Title") of chapters and bookmarks
+// condensed is the skim view (one line per interval, default 60 seconds; interval=10-3600)
+// max_chars, max_duration, split_punct=1 and min_gap re-segment the tokens for the
+// export only (e.g. max_chars=42&max_duration=7 for subtitle-style cues)
+// redact=1 masks phone numbers, emails and keywords (after re-segmenting)
+// translation=<lang> exports the translation track instead of the transcript
+func (h *AudioHandler) ExportTranscript(c echo.Context) error {
+	ctx := c.Request().Context()
+	sourceID := c.Param("source_id")
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "srt"
+	}
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "format must be one of: " + strings.Join(asr.ExportFormats, ", ")})
+	}
+
+	opts := asr.ExportOptions{SpeakerPrefix: c.QueryParam("speakers") == "1"}
+	if v := c.QueryParam("max_line"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "max_line must be a non-negative integer"})
+		}
+		opts.MaxLineLength = n
+	}
+	if v := c.QueryParam("fps"); v != "" || c.QueryParam("df") == "1" {
+		if v == "" {
+			v = "29.97"
+		}
+		rate, err := asr.ParseFrameRate(v, c.QueryParam("df") == "1")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		opts.FrameRate = rate
+	}
+	opts.Language = c.QueryParam("lang")
+	if format == "markers" {
+		opts.StartHour = 1
+		if v := c.QueryParam("tc_hour"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 || n > 23 {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "tc_hour must be between 0 and 23"})
+			}
+			opts.StartHour = n
+		}
+	}
+	if v := c.QueryParam("interval"); v != "" && format == "condensed" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 10 || n > 3600 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "interval must be between 10 and 3600 seconds"})
+		}
+		opts.CondensedInterval = float64(n)
+	}
+	segmentation, err := parseSegmentationOptions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	source, err := h.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+
+	artifacts, err := h.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	var transcript *asr.Result
+	for _, artifact := range artifacts {
+		if artifact.Type == storage.ArtifactTypeTranscription && artifact.Content != nil {
+			var result asr.Result
+			if err := json.Unmarshal([]byte(*artifact.Content), &result); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to parse transcript"})
+			}
+			transcript = &result
+			break
+		}
+	}
+	if transcript == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "transcript not found"})
+	}
+	// The translation track replaces the transcript; its cues keep the transcript's timestamps
+	if lang := c.QueryParam("translation"); lang != "" {
+		translation, err := translate.Load(ctx, h.artifactRepo, sourceID, lang)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		if translation == nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "translation not found: " + lang})
+		}
+		transcript = translation
+		if opts.Language == "" {
+			opts.Language = lang
+		}
+	}
+	if !segmentation.IsZero() {
+		transcript.Resegment(segmentation)
+	}
+	transcript = h.redaction.Result(c, transcript)
+
+	// Name the download (and the fcpxml project) after the source title when there is one
+	name := "transcript"
+	if source.Metadata != nil {
+		var metadata struct {
+			Title string `json:"title"`
+		}
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err == nil && metadata.Title != "" {
+			name = metadata.Title
+			opts.Title = metadata.Title
+		}
+	}
+
+	if format == "markers" || format == "chapters" {
+		bookmarks, err := h.bookmarkRepo.ListBySourceID(ctx, sourceID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		for _, b := range bookmarks {
+			marker := asr.Marker{Time: b.TimeSeconds, Name: b.Label}
+			if b.Note != nil {
+				marker.Note = *b.Note
+			}
+			if b.Color != nil {
+				marker.Color = *b.Color
+			}
+			opts.Bookmarks = append(opts.Bookmarks, marker)
+		}
+	}
+
+	output, err := transcript.Export(format, opts)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	ext := asr.ExportExtension(format)
+	filename := name + "." + ext
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=\"transcript.%s\"; filename*=UTF-8''%s", ext, url.PathEscape(filename)))
+
+	return c.Blob(http.StatusOK, contentType, []byte(output))
+}
+
 // TranscriptSyncPage renders the transcript sync page
 // GET /audio/:source_id/sync?interval=10&start=0&end=300&waveform=1
 func (h *AudioHandler) TranscriptSyncPage(c echo.Context) error {
@@ -288,6 +1268,16 @@ func (h *AudioHandler) TranscriptSyncPage(c echo.Context) error {
 		}
 	}
 
+	// Deep link to a time (t, in seconds): show the 5-minute range containing it
+	// The page seeks to t on load
+	if tStr := c.QueryParam("t"); tStr != "" && !hasRangeParams {
+		if v, err := strconv.ParseFloat(tStr, 64); err == nil && v >= 0 {
+			rangeStart = math.Floor(v/300) * 300
+			rangeEnd = rangeStart + 300
+			hasRangeParams = true
+		}
+	}
+
 	// Parse waveform display flag
 	showWaveform := c.QueryParam("waveform") == "1"
 
@@ -300,6 +1290,17 @@ func (h *AudioHandler) TranscriptSyncPage(c echo.Context) error {
 		return c.String(http.StatusNotFound, "Source not found")
 	}
 
+	// Archived sources are pulled back from the archive when opened
+	if archive.IsArchived(source) {
+		if h.archiver == nil {
+			return c.String(http.StatusServiceUnavailable, "Source is archived and the archive is not configured")
+		}
+		if err := h.archiver.Rehydrate(ctx, sourceID); err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to restore archived source: "+err.Error())
+		}
+	}
+	_ = h.sourceRepo.TouchAccess(ctx, sourceID)
+
 	// Get transcript
 	artifacts, err := h.artifactRepo.GetBySourceID(ctx, sourceID)
 	if err != nil {
@@ -320,16 +1321,19 @@ func (h *AudioHandler) TranscriptSyncPage(c echo.Context) error {
 	if transcript == nil {
 		return c.String(http.StatusNotFound, "Transcript not found")
 	}
+	transcript = h.redaction.Result(c, transcript)
 
 	// Get title, filename, and duration from metadata
 	title := "Audio Transcript"
 	filename := ""
 	var totalDuration float64
+	var diagnostics []asr.AudioDiagnostics
 	if source.Metadata != nil {
 		var metadata struct {
-			Title    string   `json:"title"`
-			Files    []string `json:"files"`
-			Duration float64  `json:"duration"`
+			Title       string                 `json:"title"`
+			Files       []string               `json:"files"`
+			Duration    float64                `json:"duration"`
+			Diagnostics []asr.AudioDiagnostics `json:"diagnostics"`
 		}
 		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err == nil {
 			if metadata.Title != "" {
@@ -340,6 +1344,7 @@ func (h *AudioHandler) TranscriptSyncPage(c echo.Context) error {
 				filename = filepath.Base(metadata.Files[0])
 			}
 			totalDuration = metadata.Duration
+			diagnostics = metadata.Diagnostics
 		}
 	}
 
@@ -381,6 +1386,40 @@ func (h *AudioHandler) TranscriptSyncPage(c echo.Context) error {
 		}
 	}
 
+	// Chapters are stored in the article's sections
+	var chapters []models.Section
+	if articles, err := h.articleRepo.GetBySourceID(ctx, sourceID); err == nil {
+		for _, article := range articles {
+			if article.Sections != nil && *article.Sections != "" {
+				_ = json.Unmarshal([]byte(*article.Sections), &chapters)
+				chapters = h.redaction.Sections(c, chapters)
+				break
+			}
+		}
+	}
+
+	bookmarks, err := h.bookmarkRepo.ListBySourceID(ctx, sourceID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to get bookmarks: "+err.Error())
+	}
+
+	// The condensed view is a skim layer; the page works without it
+	condensed, _ := h.ingester.Condensed(ctx, sourceID)
+	condensed = h.redaction.Lines(c, condensed)
+
+	// Translations are an optional alternate track (?translation=<lang>)
+	var translations []string
+	if items, err := translate.Languages(ctx, h.artifactRepo, sourceID); err == nil {
+		for _, item := range items {
+			translations = append(translations, item.Language)
+		}
+	}
+	var translation *asr.Result
+	if lang := c.QueryParam("translation"); lang != "" {
+		translation, _ = translate.Load(ctx, h.artifactRepo, sourceID, lang)
+		translation = h.redaction.Result(c, translation)
+	}
+
 	// Build sync options for template
 	syncOpts := components.TranscriptSyncOptions{
 		SourceID:      sourceID,
@@ -393,6 +1432,12 @@ func (h *AudioHandler) TranscriptSyncPage(c echo.Context) error {
 		TotalDuration: totalDuration,
 		IntervalSec:   intervalSec,
 		ShowWaveform:  showWaveform,
+		Diagnostics:   diagnostics,
+		Chapters:      chapters,
+		Bookmarks:     bookmarks,
+		Condensed:     condensed,
+		Translation:   translation,
+		Translations:  translations,
 	}
 
 	return render(c, components.TranscriptSyncWithOptions(syncOpts))
@@ -403,8 +1448,9 @@ type RetranscribeRequest struct {
 	SegmentStart int     `json:"segment_start"` // Start segment index (0-based)
 	SegmentEnd   int     `json:"segment_end"`   // End segment index (inclusive)
 	Tempo        float64 `json:"tempo"`         // Audio tempo (0.85-1.0)
-	Model        string  `json:"model"`         // "reazonspeech", "sensevoice", or "whisper"
+	Model        string  `json:"model"`         // "reazonspeech", "sensevoice", "sensevoice:beam" or "whisper"
 	Preview      bool    `json:"preview"`       // If true, return result without saving
+	ITN          *bool   `json:"itn,omitempty"` // Override per-model ITN setting (nil = model default)
 
 	// Boundary adjustment parameters
 	AutoAdjustBoundary bool    `json:"auto_adjust_boundary"` // Enable waveform-based boundary adjustment
@@ -470,6 +1516,37 @@ type RetranscribeTokenInfo struct {
 	StartTime float64 `json:"start_time"`
 }
 
+// redactRetranscribe masks the texts of a retranscription response like the
+// transcript output (see Redaction). Tokens covering a mask are merged; the
+// character diff is dropped when either Whisper text is masked
+func (h *AudioHandler) redactRetranscribe(c echo.Context, response *RetranscribeResponse) {
+	if !h.redaction.enabled(c) {
+		return
+	}
+	redactor := h.redaction.Redactor()
+	for _, segments := range [][]RetranscribeSegmentInfo{response.OriginalSegments, response.NewSegments} {
+		for i := range segments {
+			seg := &segments[i]
+			seg.Text = redactor.RedactText(seg.Text)
+			tokens := make([]asr.Token, len(seg.Tokens))
+			for j, t := range seg.Tokens {
+				tokens[j] = asr.Token{Text: t.Text, StartTime: float32(t.StartTime)}
+			}
+			redacted := redactor.Redact(&asr.Result{Tokens: tokens})
+			seg.Tokens = seg.Tokens[:0]
+			for _, t := range redacted.Tokens {
+				seg.Tokens = append(seg.Tokens, RetranscribeTokenInfo{Text: t.Text, StartTime: float64(t.StartTime)})
+			}
+		}
+	}
+	rawText := redactor.RedactText(response.WhisperRawText)
+	originalText := redactor.RedactText(response.OriginalText)
+	if rawText != response.WhisperRawText || originalText != response.OriginalText {
+		response.AlignmentDiff = nil
+	}
+	response.WhisperRawText, response.OriginalText = rawText, originalText
+}
+
 // Retranscribe handles partial re-transcription of audio segments
 // POST /api/audio/:source_id/retranscribe
 func (h *AudioHandler) Retranscribe(c echo.Context) error {
@@ -498,6 +1575,14 @@ func (h *AudioHandler) Retranscribe(c echo.Context) error {
 	if source == nil {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
 	}
+	if retention.ReadCleanup(source) != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "original audio was removed by the storage cleanup"})
+	}
+
+	// Sources under legal hold cannot be modified
+	if err := h.sourceRepo.CheckHold(ctx, sourceID); err != nil {
+		return holdError(c, err)
+	}
 
 	// Get audio file path from metadata
 	var metadata struct {
@@ -512,6 +1597,10 @@ func (h *AudioHandler) Retranscribe(c echo.Context) error {
 	if len(metadata.Files) == 0 {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "no audio files"})
 	}
+	// Segments of merged multi-file transcripts come from different recordings
+	if len(metadata.Files) > 1 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "retranscription is not supported for multi-file sources"})
+	}
 	audioPath := metadata.Files[0]
 
 	// Get existing transcript
@@ -555,15 +1644,13 @@ func (h *AudioHandler) Retranscribe(c echo.Context) error {
 	// Boundary adjustment
 	var boundaryInfo *BoundaryAdjustmentInfo
 	if req.AutoAdjustBoundary {
-		// Get WAV path for waveform analysis
-		wavPath := audioPath
-		ext := filepath.Ext(audioPath)
-		if ext != ".wav" {
-			wavPath = audioPath[:len(audioPath)-len(ext)] + "_converted.wav"
+		// Waveform peaks for the analysis (50 samples/sec)
+		var peaks []float64
+		var duration float64
+		waveform, err := h.waveform(ctx, audioPath)
+		if err == nil {
+			peaks, duration = waveform.Range(50, 0, 0), waveform.Duration
 		}
-
-		// Compute waveform peaks
-		peaks, duration, err := asr.ComputeWaveformPeaks(wavPath, 50) // 50 samples/sec
 		if err == nil && duration > 0 {
 			// Set default params if not specified
 			params := asr.BoundaryAdjustmentParams{
@@ -633,6 +1720,9 @@ func (h *AudioHandler) Retranscribe(c echo.Context) error {
 	if model == "" {
 		model = storage.ASRModelReazonSpeech
 	}
+	if err := h.ingester.CheckModel(model); err != nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+	}
 
 	// Perform partial transcription based on model
 	opts := asr.PartialTranscribeOptions{
@@ -644,40 +1734,59 @@ func (h *AudioHandler) Retranscribe(c echo.Context) error {
 
 	var partialResult *asr.Result
 	switch model {
-	case storage.ASRModelSenseVoice:
+	case storage.ASRModelSenseVoice, storage.ASRModelSenseVoiceBeam:
 		svConfig := asr.DefaultSenseVoiceConfig("models/sherpa-onnx-sense-voice-zh-en-ja-ko-yue-2024-07-17")
+		svConfig.Provider = h.asrConfig.Provider
+		if model == storage.ASRModelSenseVoiceBeam {
+			svConfig.DecodingMethod = "modified_beam_search"
+		}
+		release := h.ingester.ThreadTuner().TuneSenseVoice(svConfig)
+		defer release()
 		svRecognizer, err := asr.NewSenseVoiceRecognizer(svConfig)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create sensevoice recognizer: " + err.Error()})
 		}
 		defer svRecognizer.Close()
-		partialResult, err = svRecognizer.TranscribePartial(audioPath, opts)
+		partialResult, err = svRecognizer.TranscribePartial(ctx, audioPath, opts)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "transcription failed: " + err.Error()})
 		}
 	case storage.ASRModelWhisper, storage.ASRModelWhisperAlign:
-		wConfig := asr.DefaultWhisperConfig("models/sherpa-onnx-whisper-turbo")
+		wConfig := asr.DefaultWhisperConfig(ingestion.WhisperModelDir)
+		wConfig.Provider = h.asrConfig.Provider
+		release := h.ingester.ThreadTuner().TuneWhisper(wConfig)
+		defer release()
 		wRecognizer, err := asr.NewWhisperRecognizer(wConfig)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create whisper recognizer: " + err.Error()})
 		}
 		defer wRecognizer.Close()
-		partialResult, err = wRecognizer.TranscribePartial(audioPath, opts)
+		partialResult, err = wRecognizer.TranscribePartial(ctx, audioPath, opts)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "transcription failed: " + err.Error()})
 		}
 	default: // reazonspeech
-		recognizer, err := asr.NewRecognizer(h.asrConfig)
+		config := *h.asrConfig
+		release := h.ingester.ThreadTuner().TuneRecognizer(&config)
+		defer release()
+		recognizer, err := asr.NewRecognizer(&config)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create recognizer: " + err.Error()})
 		}
 		defer recognizer.Close()
-		partialResult, err = recognizer.TranscribePartial(audioPath, opts)
+		partialResult, err = recognizer.TranscribePartial(ctx, audioPath, opts)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "transcription failed: " + err.Error()})
 		}
 	}
 
+	// Strip model special tokens, then ITN on the re-transcribed range
+	ingestion.PostProcess(model, partialResult)
+	h.ingester.ApplyITN(model, req.ITN, partialResult)
+	if err := h.ingester.ApplyDictionary(ctx, sourceID, partialResult); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
 	// Merge tokens and segments based on model type
 	var mergedTokens []asr.Token
 	var mergedSegments []asr.Segment
@@ -731,6 +1840,9 @@ func (h *AudioHandler) Retranscribe(c echo.Context) error {
 		}
 	}
 
+	// Recompute segment confidence from the merged tokens
+	asr.AnnotateSegmentConfidence(mergedSegments, mergedTokens)
+
 	// Build original segments info for response
 	originalSegments := make([]RetranscribeSegmentInfo, 0, req.SegmentEnd-req.SegmentStart+1)
 	for i := req.SegmentStart; i <= req.SegmentEnd && i < len(transcript.Segments); i++ {
@@ -815,6 +1927,7 @@ func (h *AudioHandler) Retranscribe(c echo.Context) error {
 			}
 		}
 
+		h.redactRetranscribe(c, &response)
 		return c.JSON(http.StatusOK, response)
 	}
 
@@ -830,6 +1943,10 @@ func (h *AudioHandler) Retranscribe(c echo.Context) error {
 		Duration:      transcript.Duration,
 		Speaker:       transcript.Speaker,
 	}
+	// Regroup words if the transcript was stored with them
+	if len(transcript.Words) > 0 {
+		updatedResult.GroupWords(asr.DefaultWordGroupingConfig())
+	}
 
 	// Update artifact
 	artifactContent, _ := json.Marshal(updatedResult)
@@ -837,7 +1954,15 @@ func (h *AudioHandler) Retranscribe(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to save transcript"})
 	}
 
-	return c.JSON(http.StatusOK, RetranscribeResponse{
+	// Keep the condensed view in sync with the edited transcript
+	if err := h.ingester.SaveCondensed(ctx, sourceID, updatedResult); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update condensed view: " + err.Error()})
+	}
+	if err := h.ingester.IndexTranscript(ctx, sourceID, updatedResult); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update search index: " + err.Error()})
+	}
+
+	response := RetranscribeResponse{
 		Success:            true,
 		Message:            "Retranscription completed",
 		OriginalSegments:   originalSegments,
@@ -845,16 +1970,20 @@ func (h *AudioHandler) Retranscribe(c echo.Context) error {
 		Model:              model,
 		Tempo:              req.Tempo,
 		BoundaryAdjustment: boundaryInfo,
-	})
+	}
+	h.redactRetranscribe(c, &response)
+	return c.JSON(http.StatusOK, response)
 }
 
 // RetranscribeFullRequest represents the request body for full re-transcription
 type RetranscribeFullRequest struct {
-	Model string `json:"model"` // "reazonspeech" (default) or "sensevoice"
+	Model  string                          `json:"model"`            // "reazonspeech" (default), "reazonspeech:lm", "sensevoice", "sensevoice:beam", "sensevoice:lm", "whisper" or "whisper:align"
+	Params *ingestion.TranscriptionOptions `json:"params,omitempty"` // per-job transcription settings (model is taken from Model)
 }
 
 // RetranscribeFull handles full re-transcription of audio
-// Deletes existing artifacts and articles, then creates a new transcription job
+// Keeps the current transcription as a version (see TranscriptVersions), deletes
+// the other artifacts and articles, then creates a new transcription job
 // POST /api/audio/:source_id/retranscribe-full
 func (h *AudioHandler) RetranscribeFull(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -873,11 +2002,21 @@ func (h *AudioHandler) RetranscribeFull(c echo.Context) error {
 	// Validate model
 	validModels := map[string]bool{
 		storage.ASRModelReazonSpeech: true,
-		storage.ASRModelSenseVoice:   true,
-		// Note: sensevoice:beam is not supported yet by sherpa-onnx
+		storage.ASRModelReazonSpeechLM: true,
+		storage.ASRModelSenseVoice:     true,
+		storage.ASRModelSenseVoiceBeam: true,
+		storage.ASRModelSenseVoiceLM:   true,
+		storage.ASRModelWhisper:        true,
+		storage.ASRModelWhisperAlign:   true,
 	}
 	if !validModels[model] {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid model: must be 'reazonspeech' or 'sensevoice'"})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid model: must be 'reazonspeech', 'reazonspeech:lm', 'sensevoice', 'sensevoice:beam', 'sensevoice:lm', 'whisper' or 'whisper:align'"})
+	}
+	if err := req.Params.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := h.ingester.CheckModel(model); err != nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
 	}
 
 	// Get source
@@ -888,9 +2027,22 @@ func (h *AudioHandler) RetranscribeFull(c echo.Context) error {
 	if source == nil {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
 	}
+	if retention.ReadCleanup(source) != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "original audio was removed by the storage cleanup"})
+	}
+
+	// Sources under legal hold cannot be modified
+	if err := h.sourceRepo.CheckHold(ctx, sourceID); err != nil {
+		return holdError(c, err)
+	}
 
-	// Delete existing artifacts by source_id
-	if err := h.artifactRepo.DeleteBySourceID(ctx, sourceID); err != nil {
+	// Refuse before deleting anything if the monthly quota is used up
+	if err := h.ingester.CheckQuota(ctx, model); err != nil {
+		return quotaError(c, err)
+	}
+
+	// Keep the current transcription as a version and delete the artifacts derived from it
+	if err := h.ingester.ResetTranscript(ctx, sourceID); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete artifacts: " + err.Error()})
 	}
 
@@ -900,7 +2052,7 @@ func (h *AudioHandler) RetranscribeFull(c echo.Context) error {
 	}
 
 	// Create new transcription job via ingester with model selection
-	jobID, err := h.ingester.CreateTranscriptionJob(ctx, sourceID, storage.JobPriorityImmediate, model)
+	jobID, err := h.ingester.CreateTranscriptionJob(ctx, sourceID, storage.JobPriorityImmediate, model, req.Params)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create job: " + err.Error()})
 	}
@@ -912,3 +2064,39 @@ func (h *AudioHandler) RetranscribeFull(c echo.Context) error {
 		"model":     model,
 	})
 }
+
+// Refine queues the second pass of two-pass transcription for an existing
+// transcript: segments with an implausible character rate are re-decoded with
+// Whisper and aligned on the stored timestamps
+// POST /api/audio/:source_id/refine
+func (h *AudioHandler) Refine(c echo.Context) error {
+	ctx := c.Request().Context()
+	sourceID := c.Param("source_id")
+
+	source, err := h.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+	if retention.ReadCleanup(source) != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "original audio was removed by the storage cleanup"})
+	}
+
+	jobID, err := h.ingester.QueueRefine(ctx, sourceID, storage.JobPriorityImmediate)
+	switch {
+	case errors.Is(err, storage.ErrLegalHold):
+		return holdError(c, err)
+	case errors.Is(err, ingestion.ErrRefinePending):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case err != nil:
+		return quotaError(c, err)
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"message":   "Refine job created",
+		"source_id": sourceID,
+		"job_id":    jobID,
+	})
+}
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"zbor/internal/storage"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+const (
+	// APIKeyHeader はAPIキーを渡すヘッダー（Authorization: Bearer <キー> でもよい）
+	APIKeyHeader = "X-API-Key"
+	// APIKeyCookie はWeb UIがAPIキーを保存するCookie（audio 要素のストリーミングにも使われる）
+	APIKeyCookie = "zbor_api_key"
+)
+
+// echo.Context に認証済みのキーを保存するキー
+const (
+	ctxAPIKeyName  = "api_key_name"
+	ctxAPIKeyScope = "api_key_scope"
+)
+
+// StaticAPIKey は環境変数（ZBOR_API_KEYS）で設定するAPIキー
+type StaticAPIKey struct {
+	Name  string
+	Scope string
+	Key   string
+}
+
+// ParseAPIKeys は "名前:スコープ:キー" のカンマ区切りを解析する
+func ParseAPIKeys(s string) ([]StaticAPIKey, error) {
+	var keys []StaticAPIKey
+	for i, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		// エラーメッセージにはキーを含めない
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid API key entry #%d (want name:scope:key)", i+1)
+		}
+		if !storage.ValidAPIScope(parts[1]) {
+			return nil, fmt.Errorf("invalid scope for API key %q: %s (read, write or admin)", parts[0], parts[1])
+		}
+		keys = append(keys, StaticAPIKey{Name: parts[0], Scope: parts[1], Key: parts[2]})
+	}
+	return keys, nil
+}
+
+// APIAuth はAPIキーまたはログインセッションによる認証と、スコープによる認可を行う
+// 環境変数のキーもDBのキーもユーザーも無い場合は認証しない（すべて admin として扱う）
+// ログインしたユーザーは管理者なら admin、それ以外は write で、自分のデータだけを参照できる
+type APIAuth struct {
+	repo   *storage.APIKeyRepository
+	users  *storage.UserRepository
+	static []StaticAPIKey
+}
+
+// NewAPIAuth は新しいAPIAuthを作成
+func NewAPIAuth(repo *storage.APIKeyRepository, users *storage.UserRepository, static []StaticAPIKey) *APIAuth {
+	return &APIAuth{repo: repo, users: users, static: static}
+}
+
+// Middleware は /api グループ用のミドルウェア
+// キーが無い・一致しない場合は 401、GET/HEAD/OPTIONS 以外を read のキーで呼んだ場合は 403 を返す
+// /api/worker/ 以下はワーカートークンで認証するため対象外
+func (a *APIAuth) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if strings.HasPrefix(c.Request().URL.Path, "/api/worker/") {
+				return next(c)
+			}
+
+			name, scope, err := a.authenticate(c)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			}
+			if scope == "" {
+				if n, err := a.users.Count(c.Request().Context()); err == nil && n > 0 {
+					c.Response().Header().Set(AuthHeader, "session")
+				}
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="zbor"`)
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or missing API key"})
+			}
+			c.Set(ctxAPIKeyName, name)
+			c.Set(ctxAPIKeyScope, scope)
+
+			need := storage.APIScopeWrite
+			switch c.Request().Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				need = storage.APIScopeRead
+			}
+			if !storage.APIScopeAllows(scope, need) {
+				return scopeError(c, need)
+			}
+			return next(c)
+		}
+	}
+}
+
+// authenticate はリクエストのAPIキーかセッションを照合し、キー（ユーザー）の名前とスコープを返す
+// （一致しない場合はスコープが空）。セッションの場合はリクエストのコンテキストをユーザーのものにする
+func (a *APIAuth) authenticate(c echo.Context) (string, string, error) {
+	ctx := c.Request().Context()
+	key := apiKeyFromRequest(c)
+
+	if key != "" {
+		for _, k := range a.static {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(k.Key)) == 1 {
+				setAPIKey(c, k.Name)
+				return k.Name, k.Scope, nil
+			}
+		}
+		k, err := a.repo.Authenticate(ctx, key)
+		if err != nil {
+			return "", "", err
+		}
+		if k != nil {
+			setAPIKey(c, k.Name)
+			return k.Name, k.Scope, nil
+		}
+	}
+
+	user, err := sessionUser(c, a.users)
+	if err != nil {
+		return "", "", err
+	}
+	if user != nil {
+		setUser(c, user)
+		return user.Username, userScope(user), nil
+	}
+
+	// キーもユーザーも無ければ認証しない
+	if len(a.static) == 0 {
+		n, err := a.repo.Count(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		users, err := a.users.Count(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		if n == 0 && users == 0 {
+			return "", storage.APIScopeAdmin, nil
+		}
+	}
+	return "", "", nil
+}
+
+// apiKeyFromRequest は Authorization: Bearer、X-API-Key ヘッダー、Cookie の順にAPIキーを探す
+func apiKeyFromRequest(c echo.Context) string {
+	if auth := c.Request().Header.Get(echo.HeaderAuthorization); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	if key := c.Request().Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	if cookie, err := c.Cookie(APIKeyCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// setAPIKey はリクエストのコンテキストを認証したAPIキーのものにする（作成したジョブの利用量をキーごとに集計する）
+func setAPIKey(c echo.Context, name string) {
+	c.SetRequest(c.Request().WithContext(storage.WithAPIKey(c.Request().Context(), name)))
+}
+
+// apiKeyName は認証したAPIキーの名前を返す（認証していない場合は空）
+func apiKeyName(c echo.Context) string {
+	name, _ := c.Get(ctxAPIKeyName).(string)
+	return name
+}
+
+// isAdmin は認証済みのキー（ユーザー）に admin の権限があるか
+func isAdmin(c echo.Context) bool {
+	scope, _ := c.Get(ctxAPIKeyScope).(string)
+	return storage.APIScopeAllows(scope, storage.APIScopeAdmin)
+}
+
+// RequireScope は認証済みのキーに scope 以上の権限が無いリクエストを 403 で拒否する
+// （APIAuth.Middleware の後に、admin が必要なルートに付ける）
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			have, _ := c.Get(ctxAPIKeyScope).(string)
+			if !storage.APIScopeAllows(have, scope) {
+				return scopeError(c, scope)
+			}
+			return next(c)
+		}
+	}
+}
+
+// WebDAVMiddleware は /dav 用のBasic認証のミドルウェア
+// ユーザーのユーザー名とパスワードならそのユーザーのデータだけを公開し、パスワードにAPIキーを使う場合は /api と同じく全てのデータを公開する。
+// 共有のユーザー名とパスワード（sharedPassword が空なら無効）はユーザーのいない場合だけ受け付ける（ユーザーごとの分離を迂回させない）
+func (a *APIAuth) WebDAVMiddleware(sharedUser, sharedPassword string) echo.MiddlewareFunc {
+	return middleware.BasicAuthWithConfig(middleware.BasicAuthConfig{
+		Realm: "zbor",
+		Validator: func(username, password string, c echo.Context) (bool, error) {
+			ctx := c.Request().Context()
+			user, err := a.users.Authenticate(ctx, username, password)
+			if err != nil {
+				return false, err
+			}
+			if user != nil {
+				setUser(c, user)
+				return true, nil
+			}
+
+			for _, k := range a.static {
+				if subtle.ConstantTimeCompare([]byte(password), []byte(k.Key)) == 1 {
+					setAPIKey(c, k.Name)
+					return true, nil
+				}
+			}
+			k, err := a.repo.Authenticate(ctx, password)
+			if err != nil {
+				return false, err
+			}
+			if k != nil {
+				setAPIKey(c, k.Name)
+				return true, nil
+			}
+
+			if sharedPassword == "" ||
+				subtle.ConstantTimeCompare([]byte(username), []byte(sharedUser)) != 1 ||
+				subtle.ConstantTimeCompare([]byte(password), []byte(sharedPassword)) != 1 {
+				return false, nil
+			}
+			n, err := a.users.Count(ctx)
+			if err != nil {
+				return false, err
+			}
+			return n == 0, nil
+		},
+	})
+}
+
+// HasCredentials は認証に使えるAPIキーかユーザーがあるかを返す
+func (a *APIAuth) HasCredentials(ctx context.Context) (bool, error) {
+	if len(a.static) > 0 {
+		return true, nil
+	}
+	n, err := a.repo.Count(ctx)
+	if err != nil || n > 0 {
+		return n > 0, err
+	}
+	users, err := a.users.Count(ctx)
+	return users > 0, err
+}
+
+// scopeError はスコープ不足の 403 を返す
+func scopeError(c echo.Context, need string) error {
+	return c.JSON(http.StatusForbidden, map[string]string{"error": "API key requires " + need + " scope"})
+}
+
+// APIKeyHandler はAPIキー管理APIのハンドラー
+type APIKeyHandler struct {
+	repo *storage.APIKeyRepository
+}
+
+// NewAPIKeyHandler は新しいAPIKeyHandlerを作成
+func NewAPIKeyHandler(repo *storage.APIKeyRepository) *APIKeyHandler {
+	return &APIKeyHandler{repo: repo}
+}
+
+// CreateAPIKeyRequest はAPIキー作成のリクエスト
+type CreateAPIKeyRequest struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+}
+
+// List はDBに登録したAPIキーの一覧を取得（キー本体・ハッシュは含まない）
+// GET /api/keys
+func (h *APIKeyHandler) List(c echo.Context) error {
+	keys, err := h.repo.List(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, keys)
+}
+
+// Create はAPIキーを生成（キー本体はこのレスポンスでのみ返す）
+// POST /api/keys
+func (h *APIKeyHandler) Create(c echo.Context) error {
+	var req CreateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name is required"})
+	}
+	if !storage.ValidAPIScope(req.Scope) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "scope must be read, write or admin"})
+	}
+	key, k, err := h.repo.Create(c.Request().Context(), req.Name, req.Scope)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"id":         k.ID,
+		"name":       k.Name,
+		"prefix":     k.Prefix,
+		"scope":      k.Scope,
+		"created_at": k.CreatedAt,
+		"key":        key,
+	})
+}
+
+// Delete はAPIキーを削除（失効）
+// DELETE /api/keys/:id
+func (h *APIKeyHandler) Delete(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	ok, err := h.repo.Delete(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "API key not found"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
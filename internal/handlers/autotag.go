@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"zbor/internal/autotag"
+	"zbor/internal/storage"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AutoTagHandler は自動タグ付け・タグ提案APIのハンドラー
+type AutoTagHandler struct {
+	tagger      *autotag.Tagger
+	articleRepo *storage.ArticleRepository
+	tagRepo     *storage.TagRepository
+}
+
+// NewAutoTagHandler は新しいAutoTagHandlerを作成
+func NewAutoTagHandler(tagger *autotag.Tagger, articleRepo *storage.ArticleRepository, tagRepo *storage.TagRepository) *AutoTagHandler {
+	return &AutoTagHandler{
+		tagger:      tagger,
+		articleRepo: articleRepo,
+		tagRepo:     tagRepo,
+	}
+}
+
+// CreateJob は記事の自動タグ付けジョブを作成
+// POST /api/articles/:id/tag-suggestions
+func (h *AutoTagHandler) CreateJob(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	article, err := h.articleRepo.GetByID(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if article == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "article not found"})
+	}
+
+	jobID, err := h.tagger.CreateJob(ctx, id, storage.JobPriorityImmediate)
+	if errors.Is(err, storage.ErrLegalHold) {
+		return c.JSON(http.StatusLocked, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create job: " + err.Error()})
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"message":    "Auto-tagging job created",
+		"article_id": id,
+		"job_id":     jobID,
+		"method":     h.tagger.Method(),
+	})
+}
+
+// List は記事のタグの提案を取得
+// GET /api/articles/:id/tag-suggestions
+func (h *AutoTagHandler) List(c echo.Context) error {
+	suggestions, err := h.tagRepo.ListSuggestions(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, suggestions)
+}
+
+// Accept はタグの提案を承認（新しいタグの候補はタグを作成して付与）
+// POST /api/articles/:id/tag-suggestions/:suggestion_id/accept
+func (h *AutoTagHandler) Accept(c echo.Context) error {
+	return h.decide(c, true)
+}
+
+// Reject はタグの提案を却下（付与済みのタグは記事から外す）
+// POST /api/articles/:id/tag-suggestions/:suggestion_id/reject
+func (h *AutoTagHandler) Reject(c echo.Context) error {
+	return h.decide(c, false)
+}
+
+// decide は提案を承認または却下する
+func (h *AutoTagHandler) decide(c echo.Context, accept bool) error {
+	ctx := c.Request().Context()
+	id, err := strconv.ParseInt(c.Param("suggestion_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid suggestion_id"})
+	}
+
+	s, err := h.tagRepo.GetSuggestion(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if s == nil || s.ArticleID != c.Param("id") {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "suggestion not found"})
+	}
+	if s.Status != storage.TagSuggestionPending {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "suggestion already " + s.Status})
+	}
+	if err := h.articleRepo.CheckHold(ctx, s.ArticleID); err != nil {
+		return holdError(c, err)
+	}
+
+	if accept {
+		err = h.tagRepo.AcceptSuggestion(ctx, s)
+	} else {
+		err = h.tagRepo.RejectSuggestion(ctx, s)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, s)
+}
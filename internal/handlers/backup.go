@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"zbor/internal/backup"
+	"zbor/internal/storage"
+
+	"github.com/labstack/echo/v4"
+)
+
+// BackupHandler はバックアップの作成・一覧・ダウンロードのハンドラー
+type BackupHandler struct {
+	service *backup.Service
+}
+
+// NewBackupHandler は新しいBackupHandlerを作成
+func NewBackupHandler(service *backup.Service) *BackupHandler {
+	return &BackupHandler{service: service}
+}
+
+// CreateBackupRequest はバックアップの作成リクエスト
+type CreateBackupRequest struct {
+	Sources bool `json:"sources"` // ソースディレクトリ（音声・成果物）を含める
+}
+
+// List は保存先のバックアップを新しい順に取得
+// GET /api/backups
+func (h *BackupHandler) List(c echo.Context) error {
+	files, err := h.service.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if files == nil {
+		files = []backup.File{}
+	}
+	return c.JSON(http.StatusOK, files)
+}
+
+// Create は backup ジョブを作成（作成したファイル名は完了後にジョブのメタデータ "file" に記録される）
+// POST /api/backups
+func (h *BackupHandler) Create(c echo.Context) error {
+	var req CreateBackupRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	jobID, err := h.service.Queue(c.Request().Context(), backup.Options{IncludeSources: req.Sources}, storage.JobPriorityNormal)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusAccepted, map[string]string{"job_id": jobID})
+}
+
+// Download はバックアップをダウンロード
+// GET /api/backups/:name
+func (h *BackupHandler) Download(c echo.Context) error {
+	name := c.Param("name")
+	path, err := h.service.Path(name)
+	if errors.Is(err, backup.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "backup not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.Attachment(path, name)
+}
+
+// Delete はバックアップを削除
+// DELETE /api/backups/:name
+func (h *BackupHandler) Delete(c echo.Context) error {
+	err := h.service.Delete(c.Param("name"))
+	if errors.Is(err, backup.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "backup not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+
+	"github.com/labstack/echo/v4"
+)
+
+// BookmarkHandler はブックマークAPIのハンドラー
+type BookmarkHandler struct {
+	bookmarkRepo *storage.BookmarkRepository
+	sourceRepo   *storage.SourceRepository
+}
+
+// NewBookmarkHandler は新しいBookmarkHandlerを作成
+func NewBookmarkHandler(bookmarkRepo *storage.BookmarkRepository, sourceRepo *storage.SourceRepository) *BookmarkHandler {
+	return &BookmarkHandler{
+		bookmarkRepo: bookmarkRepo,
+		sourceRepo:   sourceRepo,
+	}
+}
+
+// BookmarkRequest はブックマーク作成・更新のリクエスト
+type BookmarkRequest struct {
+	Time  float64 `json:"time"` // 秒（作成時のみ）
+	Label string  `json:"label"`
+	Note  string  `json:"note"`
+	Color string  `json:"color"` // DaVinci Resolve のマーカー色（省略時は Green）
+}
+
+// List はソースのブックマークを時刻順に取得
+// GET /api/audio/:source_id/bookmarks
+func (h *BookmarkHandler) List(c echo.Context) error {
+	ctx := c.Request().Context()
+	sourceID := c.Param("source_id")
+
+	source, err := h.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+
+	bookmarks, err := h.bookmarkRepo.ListBySourceID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, bookmarks)
+}
+
+// Create はブックマークを作成
+// POST /api/audio/:source_id/bookmarks
+func (h *BookmarkHandler) Create(c echo.Context) error {
+	ctx := c.Request().Context()
+	sourceID := c.Param("source_id")
+
+	req, err := bindBookmarkRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if req.Time < 0 || math.IsNaN(req.Time) || math.IsInf(req.Time, 0) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "time must be a non-negative number of seconds"})
+	}
+
+	source, err := h.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+
+	bookmark := &sqlc.Bookmark{
+		SourceID:    sourceID,
+		TimeSeconds: req.Time,
+	}
+	req.apply(bookmark)
+	if err := h.bookmarkRepo.Create(ctx, bookmark); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, bookmark)
+}
+
+// Update はブックマークのラベル・メモ・色を更新（時刻は変更しない）
+// PUT /api/audio/:source_id/bookmarks/:id
+func (h *BookmarkHandler) Update(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	req, err := bindBookmarkRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	ctx := c.Request().Context()
+	bookmark, err := h.bookmarkRepo.Get(ctx, c.Param("source_id"), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if bookmark == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "bookmark not found"})
+	}
+
+	req.apply(bookmark)
+	if err := h.bookmarkRepo.Update(ctx, bookmark); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, bookmark)
+}
+
+// Delete はブックマークを削除
+// DELETE /api/audio/:source_id/bookmarks/:id
+func (h *BookmarkHandler) Delete(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+
+	deleted, err := h.bookmarkRepo.Delete(c.Request().Context(), c.Param("source_id"), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !deleted {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "bookmark not found"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func bindBookmarkRequest(c echo.Context) (*BookmarkRequest, error) {
+	var req BookmarkRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, errors.New("invalid request body")
+	}
+	req.Label = strings.TrimSpace(req.Label)
+	req.Note = strings.TrimSpace(req.Note)
+	if req.Label == "" {
+		return nil, errors.New("label is required")
+	}
+	if req.Color == "" {
+		req.Color = asr.MarkerColorBookmark
+	}
+	if !asr.IsMarkerColor(req.Color) {
+		return nil, errors.New("color must be one of: " + strings.Join(asr.MarkerColors, ", "))
+	}
+	return &req, nil
+}
+
+// apply はリクエストのラベル・メモ・色をブックマークに設定
+func (req *BookmarkRequest) apply(bookmark *sqlc.Bookmark) {
+	bookmark.Label = req.Label
+	bookmark.Note = nil
+	if req.Note != "" {
+		bookmark.Note = storage.Ptr(req.Note)
+	}
+	bookmark.Color = storage.Ptr(req.Color)
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"zbor/internal/asr"
+	"zbor/internal/ingestion"
+	"zbor/internal/retention"
+	"zbor/internal/storage"
+)
+
+// AdjustBoundaryRequest represents the request body for segment boundary adjustment
+type AdjustBoundaryRequest struct {
+	Threshold  float64 `json:"threshold"`    // Audio detection threshold (0-0.1), default 0.03
+	MergeGapMs int     `json:"merge_gap_ms"` // Merge clusters closer than this (ms), default 300
+	SearchMs   int     `json:"search_ms"`    // Search window beyond the segment (ms), default 1000
+	PaddingMs  *int    `json:"padding_ms"`   // Padding added to the adjusted boundaries (ms), default 200
+	Save       bool    `json:"save"`         // Persist the adjusted times into the transcript
+}
+
+// AdjustBoundaryResponse represents the response for segment boundary adjustment
+type AdjustBoundaryResponse struct {
+	Index              int                     `json:"index"`
+	Saved              bool                    `json:"saved"`
+	Segment            asr.Segment             `json:"segment"` // Segment with the adjusted times
+	BoundaryAdjustment *BoundaryAdjustmentInfo `json:"boundary_adjustment"`
+}
+
+// AdjustBoundary adjusts the start/end of a transcript segment to the audio
+// activity around it, using the cached waveform peaks (see asr.AdjustBoundaries).
+// With save=true the adjusted times are written back to the transcript
+// POST /api/audio/:source_id/segments/:idx/adjust-boundary
+func (h *AudioHandler) AdjustBoundary(c echo.Context) error {
+	ctx := c.Request().Context()
+	sourceID := c.Param("source_id")
+
+	idx, err := strconv.Atoi(c.Param("idx"))
+	if err != nil || idx < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid segment index"})
+	}
+
+	var req AdjustBoundaryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	// Get source
+	source, err := h.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+	if retention.ReadCleanup(source) != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "original audio was removed by the storage cleanup"})
+	}
+
+	// Sources under legal hold cannot be modified
+	if req.Save {
+		if err := h.sourceRepo.CheckHold(ctx, sourceID); err != nil {
+			return holdError(c, err)
+		}
+	}
+
+	// Get audio file path from metadata
+	var metadata struct {
+		Files []string `json:"files"`
+	}
+	if source.Metadata == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "no metadata"})
+	}
+	if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to parse metadata"})
+	}
+	if len(metadata.Files) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "no audio files"})
+	}
+	// Segments of merged multi-file transcripts come from different recordings
+	if len(metadata.Files) > 1 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "boundary adjustment is not supported for multi-file sources"})
+	}
+
+	// Get existing transcript
+	artifacts, err := h.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	var transcript *asr.Result
+	var artifactID string
+	for _, artifact := range artifacts {
+		if artifact.Type == storage.ArtifactTypeTranscription && artifact.Content != nil {
+			var result asr.Result
+			if err := json.Unmarshal([]byte(*artifact.Content), &result); err == nil {
+				transcript = &result
+				artifactID = artifact.ID
+				break
+			}
+		}
+	}
+	if transcript == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "transcript not found"})
+	}
+	if idx >= len(transcript.Segments) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "segment index out of range"})
+	}
+
+	// Files ingested by another node come from the blob store
+	if err := h.ingester.FetchFiles(ctx, source); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch audio"})
+	}
+
+	// Waveform peaks for the analysis (50 samples/sec)
+	waveform, err := h.waveform(ctx, playablePath(metadata.Files[0]))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to compute waveform: " + err.Error()})
+	}
+	const samplesPerSec = 50
+	peaks := waveform.Range(samplesPerSec, 0, 0)
+
+	opts := asr.DefaultSegmentBoundaryOptions()
+	opts.Threshold = req.Threshold
+	opts.MergeGapMs = req.MergeGapMs
+	opts.SearchWindow = req.SearchMs
+	if req.PaddingMs != nil {
+		opts.PaddingMs = *req.PaddingMs
+	}
+	opts.Clamp()
+
+	result := asr.AdjustSegmentBoundary(peaks, samplesPerSec, waveform.Duration, transcript.Segments, idx, opts)
+
+	var clusters []AudioClusterInfo
+	for _, c := range result.MergedClusters {
+		clusters = append(clusters, AudioClusterInfo{
+			StartTime: c.StartTime,
+			EndTime:   c.EndTime,
+			MaxPeak:   c.MaxPeak,
+		})
+	}
+	info := &BoundaryAdjustmentInfo{
+		OriginalStart:   transcript.Segments[idx].StartTime,
+		OriginalEnd:     transcript.Segments[idx].EndTime,
+		AdjustedStart:   result.AdjustedStart,
+		AdjustedEnd:     result.AdjustedEnd,
+		StartExtendedMs: result.StartExtendedMs,
+		EndExtendedMs:   result.EndExtendedMs,
+		MergedClusters:  clusters,
+	}
+
+	segment := transcript.Segments[idx]
+	segment.StartTime = result.AdjustedStart
+	segment.EndTime = result.AdjustedEnd
+
+	if req.Save && (result.StartExtendedMs != 0 || result.EndExtendedMs != 0) {
+		transcript.Segments[idx] = segment
+		asr.AnnotateSegmentConfidence(transcript.Segments, transcript.Tokens)
+		segment = transcript.Segments[idx]
+
+		artifactContent, _ := json.Marshal(transcript)
+		if err := h.artifactRepo.UpdateContent(ctx, artifactID, string(artifactContent)); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to save transcript"})
+		}
+
+		// Keep the condensed view in sync with the edited transcript
+		if err := h.ingester.SaveCondensed(ctx, sourceID, transcript); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update condensed view: " + err.Error()})
+		}
+		if err := h.ingester.IndexTranscript(ctx, sourceID, transcript); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update search index: " + err.Error()})
+		}
+	}
+
+	// The segment text is output like the transcript (see Redaction)
+	segment = h.redaction.Result(c, &asr.Result{Segments: []asr.Segment{segment}}).Segments[0]
+	return c.JSON(http.StatusOK, AdjustBoundaryResponse{
+		Index:              idx,
+		Saved:              req.Save,
+		Segment:            segment,
+		BoundaryAdjustment: info,
+	})
+}
+
+// AdjustBoundaries queues a boundary adjustment job that snaps every segment
+// of the transcript to the audio activity around it and stores how far each
+// boundary moved as the boundary_report artifact
+// POST /api/audio/:source_id/adjust-boundaries
+func (h *AudioHandler) AdjustBoundaries(c echo.Context) error {
+	ctx := c.Request().Context()
+	sourceID := c.Param("source_id")
+
+	var opts ingestion.BoundaryOptions
+	if err := c.Bind(&opts); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	source, err := h.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+	if retention.ReadCleanup(source) != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "original audio was removed by the storage cleanup"})
+	}
+	var metadata struct {
+		Files []string `json:"files"`
+	}
+	if source.Metadata != nil {
+		_ = json.Unmarshal([]byte(*source.Metadata), &metadata)
+	}
+	if len(metadata.Files) > 1 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "boundary adjustment is not supported for multi-file sources"})
+	}
+
+	jobID, err := h.ingester.QueueAdjustBoundaries(ctx, sourceID, storage.JobPriorityImmediate, &opts)
+	switch {
+	case errors.Is(err, storage.ErrLegalHold):
+		return holdError(c, err)
+	case errors.Is(err, ingestion.ErrAdjustBoundariesPending):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"message":   "Boundary adjustment job created",
+		"source_id": sourceID,
+		"job_id":    jobID,
+	})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/a-h/templ"
+	"github.com/labstack/echo/v4"
+)
+
+// jsonWithETag は JSON のレスポンスに内容のハッシュの ETag を付けて返す
+// If-None-Match が一致する場合は本文を返さず 304 Not Modified を返す
+func jsonWithETag(c echo.Context, code int, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return blobWithETag(c, code, echo.MIMEApplicationJSON, body)
+}
+
+// renderWithETag は render と同じくページを表示し、jsonWithETag と同じく ETag を付ける
+func renderWithETag(c echo.Context, component templ.Component) error {
+	var buf bytes.Buffer
+	if err := component.Render(c.Request().Context(), &buf); err != nil {
+		return err
+	}
+	return blobWithETag(c, http.StatusOK, echo.MIMETextHTMLCharsetUTF8, buf.Bytes())
+}
+
+func blobWithETag(c echo.Context, code int, contentType string, body []byte) error {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	header := c.Response().Header()
+	header.Set("ETag", etag)
+	// キャッシュした内容は毎回 If-None-Match で確認してから使う
+	header.Set("Cache-Control", "private, no-cache")
+	if code == http.StatusOK && etagMatches(c.Request().Header.Get("If-None-Match"), etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.Blob(code, contentType, body)
+}
+
+// etagMatches は If-None-Match の値（カンマ区切り、* または弱いETagを含む）が etag に一致するかを返す
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, v := range strings.Split(ifNoneMatch, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"zbor/internal/storage"
+	"zbor/web/components"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxCompareExperiments は比較ページで並べられる実験の数
+const maxCompareExperiments = 6
+
+// ExperimentHandler は実験（cmd/transcribe-* の -record で記録した実行）のハンドラー
+type ExperimentHandler struct {
+	repo *storage.ExperimentRepository
+}
+
+// NewExperimentHandler は新しいExperimentHandlerを作成
+func NewExperimentHandler(repo *storage.ExperimentRepository) *ExperimentHandler {
+	return &ExperimentHandler{repo: repo}
+}
+
+// List は実験の一覧を新しい順に取得
+// GET /api/experiments?limit=20&offset=0
+func (h *ExperimentHandler) List(c echo.Context) error {
+	ctx := c.Request().Context()
+	limit, offset := parsePagination(c, 20)
+
+	experiments, err := h.repo.List(ctx, limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	total, err := h.repo.Count(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, newPage(experiments, total, limit, offset))
+}
+
+// Get は実験をパラメータとチャンクごとの出力付きで取得
+// GET /api/experiments/:id
+func (h *ExperimentHandler) Get(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	view, err := h.view(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if view == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "experiment not found"})
+	}
+	return c.JSON(http.StatusOK, view)
+}
+
+// Delete は実験を削除
+// DELETE /api/experiments/:id
+func (h *ExperimentHandler) Delete(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	ok, err := h.repo.Delete(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "experiment not found"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListPage は実験の一覧ページを表示（比較する実験を選ぶ）
+// GET /experiments
+func (h *ExperimentHandler) ListPage(c echo.Context) error {
+	experiments, err := h.repo.List(c.Request().Context(), 100, 0)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	return render(c, components.ExperimentList(experiments))
+}
+
+// ComparePage は実験のパラメータ・RTF・CER・チャンクごとの出力を並べて表示
+// GET /experiments/compare?ids=1,2,3
+func (h *ExperimentHandler) ComparePage(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var views []components.ExperimentView
+	for _, s := range strings.Split(c.QueryParam("ids"), ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			continue
+		}
+		view, err := h.view(ctx, id)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+		if view != nil {
+			views = append(views, *view)
+		}
+		if len(views) == maxCompareExperiments {
+			break
+		}
+	}
+	if len(views) == 0 {
+		return c.String(http.StatusBadRequest, "No experiments selected")
+	}
+	return render(c, components.ExperimentCompare(views))
+}
+
+// view は実験とパラメータ、チャンクごとの出力を取得（存在しない場合は nil）
+func (h *ExperimentHandler) view(ctx context.Context, id int64) (*components.ExperimentView, error) {
+	experiment, err := h.repo.GetByID(ctx, id)
+	if err != nil || experiment == nil {
+		return nil, err
+	}
+	chunks, err := h.repo.Chunks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	view := &components.ExperimentView{Experiment: *experiment, Chunks: chunks}
+	if err := json.Unmarshal([]byte(experiment.Params), &view.Params); err != nil {
+		view.Params = map[string]string{"params": experiment.Params}
+	}
+	return view, nil
+}
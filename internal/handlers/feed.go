@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"zbor/internal/feeds"
+	"zbor/internal/ingestion"
+	"zbor/internal/storage"
+	"zbor/internal/summarize"
+
+	"github.com/labstack/echo/v4"
+)
+
+// FeedHandler はフィード購読APIのハンドラー
+type FeedHandler struct {
+	service    *feeds.Service
+	summarizer *summarize.Summarizer
+}
+
+// NewFeedHandler は新しいFeedHandlerを作成
+func NewFeedHandler(service *feeds.Service, summarizer *summarize.Summarizer) *FeedHandler {
+	return &FeedHandler{
+		service:    service,
+		summarizer: summarizer,
+	}
+}
+
+// SubscribeFeedRequest はフィード購読のリクエスト
+type SubscribeFeedRequest struct {
+	URL             string `json:"url"`
+	Title           string `json:"title"`            // 省略時はフィードのタイトル
+	IntervalMinutes int    `json:"interval_minutes"` // 更新確認の間隔（デフォルト: 60、最小: 5）
+	Summarize       bool   `json:"summarize"`        // 取り込んだ記事の要約ジョブを作成
+	Podcast         bool   `json:"podcast"`          // エピソードの音声を文字起こしする
+	Model           string `json:"model"`            // ポッドキャストの文字起こしモデル（デフォルト: reazonspeech）
+	ExtractRequest
+}
+
+// podcastModels はポッドキャストの文字起こしに使えるモデル（see ingestion.TranscriptionJobType）
+var podcastModels = map[string]bool{
+	storage.ASRModelReazonSpeech:   true,
+	storage.ASRModelSenseVoice:     true,
+	storage.ASRModelSenseVoiceBeam: true,
+}
+
+// List は購読中のフィードを取得
+// GET /api/feeds
+func (h *FeedHandler) List(c echo.Context) error {
+	subscriptions, err := h.service.List(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, subscriptions)
+}
+
+// Subscribe はフィードを購読し、最初の更新ジョブを作成
+// POST /api/feeds
+func (h *FeedHandler) Subscribe(c echo.Context) error {
+	var req SubscribeFeedRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if strings.TrimSpace(req.URL) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "url is required"})
+	}
+	if req.IntervalMinutes != 0 && time.Duration(req.IntervalMinutes)*time.Minute < feeds.MinInterval {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("interval_minutes must be at least %d", int(feeds.MinInterval/time.Minute))})
+	}
+	extract, err := req.options()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if req.Model != "" && (!req.Podcast || !podcastModels[req.Model]) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "model must be 'reazonspeech', 'sensevoice' or 'sensevoice:beam' and requires podcast"})
+	}
+	if req.Summarize && !h.summarizer.Enabled() {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "summarization is not configured (set ZBOR_LLM_URL)"})
+	}
+
+	subscription, jobID, err := h.service.Subscribe(c.Request().Context(), feeds.SubscribeOptions{
+		URL:       req.URL,
+		Title:     strings.TrimSpace(req.Title),
+		Interval:  time.Duration(req.IntervalMinutes) * time.Minute,
+		Summarize: req.Summarize,
+		Priority:  storage.JobPriorityNormal,
+		Extract:   extract,
+		Podcast:   req.Podcast,
+		Model:     req.Model,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, feeds.ErrInvalidURL):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		case errors.Is(err, feeds.ErrAlreadySubscribed):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		case errors.Is(err, ingestion.ErrModelUnavailable):
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"feed":   subscription,
+		"job_id": jobID,
+	})
+}
+
+// Refresh はフィードの更新ジョブを作成
+// POST /api/feeds/:id/refresh
+func (h *FeedHandler) Refresh(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	subscription, err := h.service.Get(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if subscription == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "feed not found"})
+	}
+
+	jobID, err := h.service.Refresh(ctx, id, storage.JobPriorityNormal)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"source_id": id,
+		"job_id":    jobID,
+		"message":   "feed refresh queued",
+	})
+}
+
+// Unsubscribe はフィードの購読を解除（取り込み済みの記事は残す）
+// DELETE /api/feeds/:id
+func (h *FeedHandler) Unsubscribe(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	subscription, err := h.service.Get(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if subscription == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "feed not found"})
+	}
+
+	if err := h.service.Unsubscribe(ctx, id); err != nil {
+		return holdError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
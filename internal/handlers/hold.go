@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HoldHandler はリーガルホールドAPIのハンドラー
+type HoldHandler struct {
+	holdRepo    *storage.HoldRepository
+	sourceRepo  *storage.SourceRepository
+	articleRepo *storage.ArticleRepository
+}
+
+// NewHoldHandler は新しいHoldHandlerを作成
+func NewHoldHandler(holdRepo *storage.HoldRepository, sourceRepo *storage.SourceRepository, articleRepo *storage.ArticleRepository) *HoldHandler {
+	return &HoldHandler{
+		holdRepo:    holdRepo,
+		sourceRepo:  sourceRepo,
+		articleRepo: articleRepo,
+	}
+}
+
+// HoldRequest はロック・解除のリクエスト
+type HoldRequest struct {
+	Reason string `json:"reason"`
+	Actor  string `json:"actor"`
+}
+
+// HoldStatusResponse はロック状態と監査ログ
+type HoldStatusResponse struct {
+	Held   bool                  `json:"held"`
+	Hold   *sqlc.LegalHold       `json:"hold,omitempty"`
+	Events []sqlc.LegalHoldEvent `json:"events"`
+}
+
+// List は全ロックを取得
+// GET /api/holds
+func (h *HoldHandler) List(c echo.Context) error {
+	holds, err := h.holdRepo.List(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, holds)
+}
+
+// Status は対象のロック状態と監査ログを取得
+// GET /api/sources/:id/hold, GET /api/articles/:id/hold
+func (h *HoldHandler) Status(targetType string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		id := c.Param("id")
+
+		if status, err := h.targetExists(c, targetType, id); err != nil {
+			return c.JSON(status, map[string]string{"error": err.Error()})
+		}
+
+		hold, err := h.holdRepo.Get(ctx, targetType, id)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		events, err := h.holdRepo.ListEvents(ctx, targetType, id)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+
+		return c.JSON(http.StatusOK, HoldStatusResponse{
+			Held:   hold != nil,
+			Hold:   hold,
+			Events: events,
+		})
+	}
+}
+
+// Lock は対象をロック
+// POST /api/sources/:id/hold, POST /api/articles/:id/hold
+func (h *HoldHandler) Lock(targetType string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		id := c.Param("id")
+
+		req, err := bindHoldRequest(c)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		if status, err := h.targetExists(c, targetType, id); err != nil {
+			return c.JSON(status, map[string]string{"error": err.Error()})
+		}
+
+		existing, err := h.holdRepo.Get(ctx, targetType, id)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		if existing != nil {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "already locked"})
+		}
+
+		hold, err := h.holdRepo.Lock(ctx, targetType, id, req.Reason, req.Actor)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusCreated, hold)
+	}
+}
+
+// Unlock は対象のロックを解除（理由と操作者を監査ログに記録）
+// DELETE /api/sources/:id/hold, DELETE /api/articles/:id/hold
+func (h *HoldHandler) Unlock(targetType string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		id := c.Param("id")
+
+		req, err := bindHoldRequest(c)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		existing, err := h.holdRepo.Get(ctx, targetType, id)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		if existing == nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "not locked"})
+		}
+
+		if err := h.holdRepo.Unlock(ctx, targetType, id, req.Reason, req.Actor); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+// targetExists は対象が存在するかを確認（存在しない場合はステータスとエラーを返す）
+func (h *HoldHandler) targetExists(c echo.Context, targetType, id string) (int, error) {
+	ctx := c.Request().Context()
+
+	var found bool
+	switch targetType {
+	case storage.HoldTargetSource:
+		source, err := h.sourceRepo.GetByID(ctx, id)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		found = source != nil
+	case storage.HoldTargetArticle:
+		article, err := h.articleRepo.GetByID(ctx, id)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		found = article != nil
+	}
+	if !found {
+		return http.StatusNotFound, errors.New(targetType + " not found")
+	}
+	return 0, nil
+}
+
+func bindHoldRequest(c echo.Context) (*HoldRequest, error) {
+	var req HoldRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, errors.New("invalid request body")
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	req.Actor = strings.TrimSpace(req.Actor)
+	if req.Actor == "" {
+		// 省略時は認証したAPIキーの名前を操作者にする
+		req.Actor = apiKeyName(c)
+	}
+	if req.Reason == "" || req.Actor == "" {
+		return nil, errors.New("reason and actor are required")
+	}
+	return &req, nil
+}
+
+// holdError はリーガルホールドによる拒否を 423 Locked、それ以外を 500 で返す
+func holdError(c echo.Context, err error) error {
+	if errors.Is(err, storage.ErrLegalHold) {
+		return c.JSON(http.StatusLocked, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
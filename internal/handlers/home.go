@@ -1,14 +1,31 @@
 package handlers
 
 import (
+	"zbor/internal/logging"
+	"zbor/internal/storage"
 	"zbor/web/components"
 
 	"github.com/a-h/templ"
 	"github.com/labstack/echo/v4"
 )
 
-func Home(c echo.Context) error {
-	return render(c, components.Home())
+// HomeHandler はホーム（ダッシュボード）のハンドラー
+type HomeHandler struct {
+	blobRepo *storage.BlobRepository
+}
+
+// NewHomeHandler は新しいHomeHandlerを作成
+func NewHomeHandler(blobRepo *storage.BlobRepository) *HomeHandler {
+	return &HomeHandler{blobRepo: blobRepo}
+}
+
+// Page はホームページを表示（統計が取れない場合は統計なしで表示）
+func (h *HomeHandler) Page(c echo.Context) error {
+	stats, err := h.blobRepo.Stats(c.Request().Context())
+	if err != nil {
+		logging.FromContext(c.Request().Context()).Warn("Failed to get storage stats", "error", err)
+	}
+	return render(c, components.Home(stats))
 }
 
 func render(c echo.Context, component templ.Component) error {
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"zbor/internal/integrity"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+
+	"github.com/labstack/echo/v4"
+)
+
+// IntegrityHandler は整合性監査APIのハンドラー
+type IntegrityHandler struct {
+	auditor      *integrity.Auditor
+	checksumRepo *storage.ChecksumRepository
+}
+
+// NewIntegrityHandler は新しいIntegrityHandlerを作成
+func NewIntegrityHandler(auditor *integrity.Auditor, checksumRepo *storage.ChecksumRepository) *IntegrityHandler {
+	return &IntegrityHandler{
+		auditor:      auditor,
+		checksumRepo: checksumRepo,
+	}
+}
+
+// IntegrityStatusResponse は直近の監査結果と未解決の問題
+type IntegrityStatusResponse struct {
+	LastReport *integrity.Report `json:"last_report"`
+	Problems   []sqlc.Checksum   `json:"problems"`
+}
+
+// Status は直近の監査結果と、破損・欠損が見つかったチェックサムを取得
+// GET /api/integrity
+func (h *IntegrityHandler) Status(c echo.Context) error {
+	problems, err := h.checksumRepo.ListProblems(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, IntegrityStatusResponse{
+		LastReport: h.auditor.LastReport(),
+		Problems:   problems,
+	})
+}
+
+// Audit は監査を実行して結果を返す
+// POST /api/integrity/audit
+func (h *IntegrityHandler) Audit(c echo.Context) error {
+	report, err := h.auditor.Audit(c.Request().Context())
+	if errors.Is(err, integrity.ErrAuditRunning) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, report)
+}
@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"time"
 
+	"zbor/internal/ingestion"
 	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
 	"zbor/web/components"
 
 	"github.com/labstack/echo/v4"
@@ -12,7 +16,8 @@ import (
 
 // JobHandler はジョブAPIのハンドラー
 type JobHandler struct {
-	repo *storage.JobRepository
+	repo      *storage.JobRepository
+	redaction *Redaction
 }
 
 // NewJobHandler は新しいJobHandlerを作成
@@ -20,38 +25,88 @@ func NewJobHandler(repo *storage.JobRepository) *JobHandler {
 	return &JobHandler{repo: repo}
 }
 
+// SetRedaction は途中結果のテキストの伏せ字を設定（Redaction を参照）
+func (h *JobHandler) SetRedaction(redaction *Redaction) {
+	h.redaction = redaction
+}
+
 // List はジョブ一覧を取得
 func (h *JobHandler) List(c echo.Context) error {
 	ctx := c.Request().Context()
 	status := c.QueryParam("status")
+	limit, offset := parsePagination(c, 50)
 
-	limit := 50
-	if l := c.QueryParam("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil {
-			limit = parsed
-		}
+	jobs, err := h.repo.List(ctx, status, limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	total, err := h.repo.Count(ctx, status)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
+	return c.JSON(http.StatusOK, newPage(jobs, total, limit, offset))
+}
 
-	var jobs interface{}
-	var err error
+// Get はジョブを取得
+func (h *JobHandler) Get(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
 
-	if status != "" {
-		jobs, err = h.repo.ListByStatus(ctx, status, limit)
-	} else {
-		jobs, err = h.repo.ListRecent(ctx, limit)
+	job, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if job == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "job not found"})
 	}
 
+	// 完了を待っているジョブ（パイプラインのステップ）
+	dependsOn, err := h.repo.Dependencies(ctx, id)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
-	return c.JSON(http.StatusOK, jobs)
+	return c.JSON(http.StatusOK, struct {
+		sqlc.ProcessingJob
+		DependsOn []string `json:"depends_on,omitempty"`
+	}{*job, dependsOn})
 }
 
-// Get はジョブを取得
-func (h *JobHandler) Get(c echo.Context) error {
+// Partial は文字起こし中のジョブの途中結果（終わったファイルと保存済みのブロックのテキスト）を取得
+func (h *JobHandler) Partial(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	job, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if job == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "job not found"})
+	}
+
+	partial, err := ingestion.LoadPartialTranscript(ctx, h.repo, job)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	partial.Text = h.redaction.Text(c, partial.Text)
+	return c.JSON(http.StatusOK, partial)
+}
+
+const (
+	// jobLogPollInterval は follow で新しいログを確認する間隔
+	jobLogPollInterval = time.Second
+	// jobLogGrace はジョブの終了後にログを待つ時間（ログはまとめて保存されるため、最後の行は少し遅れる）
+	jobLogGrace = 2 * time.Second
+)
+
+// Logs はジョブのログを古い順に取得（after より後の行。limit は1回の件数）
+// follow=1 の場合はジョブが終わるまで新しい行を NDJSON（1行に1件）で送り続ける
+func (h *JobHandler) Logs(c echo.Context) error {
 	ctx := c.Request().Context()
 	id := c.Param("id")
+	limit, _ := parsePagination(c, maxPageLimit)
+	afterID, _ := strconv.ParseInt(c.QueryParam("after"), 10, 64)
 
 	job, err := h.repo.GetByID(ctx, id)
 	if err != nil {
@@ -61,7 +116,66 @@ func (h *JobHandler) Get(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "job not found"})
 	}
 
-	return c.JSON(http.StatusOK, job)
+	if c.QueryParam("follow") != "1" {
+		logs, err := h.repo.ListLogs(ctx, id, afterID, limit)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusOK, logs)
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	enc := json.NewEncoder(res)
+	ticker := time.NewTicker(jobLogPollInterval)
+	defer ticker.Stop()
+	var finishedAt time.Time
+	for {
+		// ジョブの状態は先に確認する（終了を確認した後に保存された行も送るため）
+		if finishedAt.IsZero() {
+			job, err := h.repo.GetByID(ctx, id)
+			if err != nil {
+				return nil
+			}
+			if job == nil || isFinished(job) {
+				finishedAt = time.Now()
+			}
+		}
+		for {
+			logs, err := h.repo.ListLogs(ctx, id, afterID, limit)
+			if err != nil {
+				return nil
+			}
+			for _, line := range logs {
+				if err := enc.Encode(line); err != nil {
+					return nil
+				}
+				afterID = line.ID
+			}
+			res.Flush()
+			if len(logs) < limit {
+				break
+			}
+		}
+		if !finishedAt.IsZero() && time.Since(finishedAt) >= jobLogGrace {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// isFinished はジョブが完了または失敗しているか
+func isFinished(job *sqlc.ProcessingJob) bool {
+	return job.Status != nil && (*job.Status == storage.JobStatusCompleted || *job.Status == storage.JobStatusFailed)
 }
 
 // Stats はジョブ統計を取得
@@ -111,5 +225,31 @@ func (h *JobHandler) ListPage(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
-	return render(c, components.JobList(jobs))
+	// 文字起こし中のジョブは途中結果の末尾を表示する（一覧は3秒ごとに再読み込みされる）
+	partials := map[string]string{}
+	for idx := range jobs {
+		job := &jobs[idx]
+		if job.Status == nil || *job.Status != storage.JobStatusRunning {
+			continue
+		}
+		partial, err := ingestion.LoadPartialTranscript(ctx, h.repo, job)
+		if err != nil || partial.Text == "" {
+			continue
+		}
+		partials[job.ID] = partialTail(h.redaction.Text(c, partial.Text), partialTailRunes)
+	}
+
+	return render(c, components.JobList(jobs, partials))
+}
+
+// partialTailRunes はジョブ一覧に表示する途中結果の文字数
+const partialTailRunes = 200
+
+// partialTail はテキストの末尾 n 文字を返す（省略した場合は先頭に … を付ける）
+func partialTail(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return "…" + string(runes[len(runes)-n:])
 }
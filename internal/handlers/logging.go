@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"log/slog"
+	"time"
+
+	"zbor/internal/logging"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// maxRequestIDLength は受け付ける X-Request-ID の最大長（これより長いものは生成し直す）
+const maxRequestIDLength = 128
+
+// quietRoutes はアクセスログを debug レベルで出力するルート（監視による定期的なアクセス）
+var quietRoutes = map[string]bool{
+	"/health":  true,
+	"/metrics": true,
+}
+
+// RequestLogger はリクエストごとにIDを付け、アクセスログを出力する
+// ID はリクエストの X-Request-ID（無ければ生成）で、レスポンスの X-Request-ID にも返す。
+// ID を付けたロガーを context に入れるので、ハンドラーやそこで作成したジョブのログにも request_id が付く
+func RequestLogger() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			req := c.Request()
+
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if id == "" || len(id) > maxRequestIDLength {
+				id = uuid.New().String()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+
+			logger := logging.FromContext(req.Context()).With("request_id", id)
+			c.SetRequest(req.WithContext(logging.WithLogger(req.Context(), logger)))
+
+			err := next(c)
+			if err != nil {
+				// ステータスコードを確定させるため、エラーはここでレスポンスにする
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			level := slog.LevelInfo
+			switch {
+			case status >= 500:
+				level = slog.LevelError
+			case quietRoutes[c.Path()]:
+				level = slog.LevelDebug
+			}
+			attrs := []any{
+				"method", req.Method,
+				"uri", req.RequestURI,
+				"route", c.Path(),
+				"status", status,
+				"duration", time.Since(start),
+				"bytes", c.Response().Size,
+				"remote_ip", c.RealIP(),
+			}
+			if err != nil {
+				attrs = append(attrs, "error", err)
+			}
+			logger.Log(req.Context(), level, "HTTP request", attrs...)
+			return err
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"zbor/internal/metrics"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MetricsMiddleware は HTTP リクエストの数と処理時間を記録する
+// route は登録したパス（/api/articles/:id など）で、どのルートにも一致しないリクエストは "other"
+func MetricsMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				// ステータスコードを確定させるため、エラーはここでレスポンスにする（送信済みなら外側では何もしない）
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" || route == "/*" {
+				route = "other"
+			}
+			method := c.Request().Method
+			metrics.HTTPRequests.Inc(method, route, strconv.Itoa(c.Response().Status))
+			metrics.HTTPDuration.ObserveDuration(time.Since(start), method, route)
+			return err
+		}
+	}
+}
+
+// Metrics は Prometheus のテキスト形式でメトリクスを返す
+// token が空でなければ Authorization: Bearer <token> が必要
+// GET /metrics
+func Metrics(token string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if token != "" {
+			got := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid metrics token"})
+			}
+		}
+		var buf bytes.Buffer
+		if err := metrics.Write(&buf); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return c.Blob(http.StatusOK, metrics.ContentType, buf.Bytes())
+	}
+}
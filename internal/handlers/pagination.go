@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxPageLimit は一覧APIで1回に返せる最大件数
+const maxPageLimit = 500
+
+// Page は一覧APIのレスポンス（ページネーション情報付き）
+type Page[T any] struct {
+	Items      []T   `json:"items"`
+	Total      int64 `json:"total"`
+	Limit      int   `json:"limit"`
+	Offset     int   `json:"offset"`
+	NextOffset *int  `json:"next_offset"` // 最後のページなら null
+}
+
+// newPage は一覧と総件数から Page を作成
+func newPage[T any](items []T, total int64, limit, offset int) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	page := Page[T]{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	if next := offset + len(items); len(items) > 0 && int64(next) < total {
+		page.NextOffset = &next
+	}
+	return page
+}
+
+// parsePagination はクエリの limit/offset を取得（不正な値はデフォルトを使用）
+func parsePagination(c echo.Context, defaultLimit int) (limit, offset int) {
+	limit = defaultLimit
+	if l, err := strconv.Atoi(c.QueryParam("limit")); err == nil && l > 0 {
+		limit = min(l, maxPageLimit)
+	}
+	if o, err := strconv.Atoi(c.QueryParam("offset")); err == nil && o > 0 {
+		offset = o
+	}
+	return limit, offset
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"zbor/internal/ingestion"
+	"zbor/internal/storage"
+	"zbor/internal/summarize"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PipelineHandler は名前付きパイプライン（依存関係のある一連のジョブ）のAPIのハンドラー
+type PipelineHandler struct {
+	ingester   *ingestion.AudioIngester
+	summarizer *summarize.Summarizer
+}
+
+// NewPipelineHandler は新しいPipelineHandlerを作成
+func NewPipelineHandler(ingester *ingestion.AudioIngester, summarizer *summarize.Summarizer) *PipelineHandler {
+	return &PipelineHandler{
+		ingester:   ingester,
+		summarizer: summarizer,
+	}
+}
+
+// PodcastEpisodeRequest はポッドキャストのエピソードのパイプラインのリクエスト
+type PodcastEpisodeRequest struct {
+	AudioURL    string `json:"audio_url"`    // エピソードの音声のURL（必須）
+	Title       string `json:"title"`        // エピソードのタイトル
+	EpisodeURL  string `json:"episode_url"`  // エピソードのページ
+	Show        string `json:"show"`         // 番組名（話者ラベルに使う）
+	ShowNotes   string `json:"show_notes"`   // 番組の説明（ショーノートに含める）
+	PublishedAt string `json:"published_at"` // 公開日時（RFC 3339 または YYYY-MM-DD）
+	Model       string `json:"model"`        // 文字起こしモデル（デフォルト: reazonspeech）
+	Summarize   *bool  `json:"summarize"`    // 要約する（デフォルト: LLMが設定されていれば true）
+}
+
+// List はパイプラインの一覧（各ステップのジョブタイプと依存関係）を取得
+// GET /api/pipelines
+func (h *PipelineHandler) List(c echo.Context) error {
+	return c.JSON(http.StatusOK, ingestion.Pipelines)
+}
+
+// PodcastEpisode はエピソードのダウンロード・文字起こし（チャプター付き）・要約・ショーノート・字幕のジョブを作成
+// POST /api/pipelines/podcast-episode
+func (h *PipelineHandler) PodcastEpisode(c echo.Context) error {
+	var req PodcastEpisodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	req.AudioURL = strings.TrimSpace(req.AudioURL)
+	if u, err := url.Parse(req.AudioURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "audio_url must be an http(s) URL"})
+	}
+	if req.Model != "" && !podcastModels[req.Model] {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "model must be 'reazonspeech', 'sensevoice' or 'sensevoice:beam'"})
+	}
+	if req.Model != "" {
+		if err := h.ingester.CheckModel(req.Model); err != nil {
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+		}
+	}
+	var publishedAt time.Time
+	if v := strings.TrimSpace(req.PublishedAt); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t, err = time.ParseInLocation("2006-01-02", v, time.Local)
+		}
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "published_at must be RFC 3339 or YYYY-MM-DD"})
+		}
+		publishedAt = t
+	}
+
+	summarizeEpisode := h.summarizer.Enabled()
+	if req.Summarize != nil {
+		if *req.Summarize && !h.summarizer.Enabled() {
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "summarization is not configured (set ZBOR_LLM_URL)"})
+		}
+		summarizeEpisode = *req.Summarize
+	}
+	var skip []string
+	if !summarizeEpisode {
+		skip = append(skip, "summarize")
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = "Podcast " + time.Now().Format("2006-01-02")
+	}
+
+	result, err := h.ingester.IngestPodcastPipeline(c.Request().Context(), ingestion.IngestPodcastOptions{
+		AudioURL:    req.AudioURL,
+		Title:       title,
+		EpisodeURL:  strings.TrimSpace(req.EpisodeURL),
+		PublishedAt: publishedAt,
+		ShowNotes:   req.ShowNotes,
+		Show:        strings.TrimSpace(req.Show),
+		Model:       req.Model,
+		Priority:    storage.JobPriorityNormal,
+	}, skip...)
+	if err != nil {
+		return quotaError(c, err)
+	}
+
+	return c.JSON(http.StatusAccepted, result)
+}
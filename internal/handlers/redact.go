@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+
+	"zbor/internal/asr"
+	"zbor/internal/models"
+	"zbor/internal/semantic"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Redaction は出力する文字起こし・記事の伏せ字（電話番号・メールアドレス・キーワード）の設定
+// 文字起こしや記事のテキストを返すハンドラー（API・ページ）は、すべてこれを通して出力する
+// nil の場合は ?redact=1 のときだけ電話番号・メールアドレスを伏せる
+type Redaction struct {
+	redactor *asr.Redactor
+	enforce  bool
+}
+
+// NewRedaction は新しいRedactionを作成
+// redactor が nil なら電話番号・メールアドレスのみを伏せる。enforce なら ?redact=1 が無くても常に伏せ字にする
+// （admin だけが ?redact=0 で元のテキストを参照できる）
+func NewRedaction(redactor *asr.Redactor, enforce bool) *Redaction {
+	if redactor == nil {
+		redactor = asr.NewRedactor(nil)
+	}
+	return &Redaction{redactor: redactor, enforce: enforce}
+}
+
+// Enforced は常に伏せ字にするか
+func (r *Redaction) Enforced() bool {
+	return r != nil && r.enforce
+}
+
+// Redactor は伏せ字に使う Redactor を返す
+func (r *Redaction) Redactor() *asr.Redactor {
+	if r == nil {
+		return asr.NewRedactor(nil)
+	}
+	return r.redactor
+}
+
+// enabled はリクエストへの出力を伏せ字にするか
+func (r *Redaction) enabled(c echo.Context) bool {
+	switch {
+	case c.QueryParam("redact") == "1":
+		return true
+	case !r.Enforced():
+		return false
+	case c.QueryParam("redact") == "0":
+		return !isAdmin(c)
+	}
+	return true
+}
+
+// Result は出力する文字起こし（伏せ字にする場合はコピー。nil はそのまま）
+func (r *Redaction) Result(c echo.Context, result *asr.Result) *asr.Result {
+	if result == nil || !r.enabled(c) {
+		return result
+	}
+	return r.Redactor().Redact(result)
+}
+
+// Text は出力するテキスト
+func (r *Redaction) Text(c echo.Context, s string) string {
+	if !r.enabled(c) {
+		return s
+	}
+	return r.Redactor().RedactText(s)
+}
+
+// Highlight は検索の一致箇所の記号（storage.HighlightStart/End）を含むテキストを伏せ字にする
+// 一致箇所が伏せる範囲にかかると検出できないため、伏せる箇所がある場合は記号を除いたテキストを返す
+func (r *Redaction) Highlight(c echo.Context, s string) string {
+	if !r.enabled(c) {
+		return s
+	}
+	plain := strings.NewReplacer(storage.HighlightStart, "", storage.HighlightEnd, "").Replace(s)
+	if redacted := r.Redactor().RedactText(plain); redacted != plain {
+		return redacted
+	}
+	return s
+}
+
+// Article は出力する記事（伏せ字にする場合はタイトル・本文・要約・チャプターを伏せたコピー）
+func (r *Redaction) Article(c echo.Context, article *sqlc.Article) *sqlc.Article {
+	if article == nil || !r.enabled(c) {
+		return article
+	}
+	redactor := r.Redactor()
+	out := *article
+	out.Title = redactor.RedactText(article.Title)
+	out.Content = redactor.RedactText(article.Content)
+	if article.Summary != nil {
+		summary := redactor.RedactText(*article.Summary)
+		out.Summary = &summary
+	}
+	if article.Sections != nil && *article.Sections != "" {
+		var sections []models.Section
+		if err := json.Unmarshal([]byte(*article.Sections), &sections); err == nil {
+			sections = r.Sections(c, sections)
+			if data, err := json.Marshal(sections); err == nil {
+				s := string(data)
+				out.Sections = &s
+			}
+		} else {
+			// 読めないチャプターは出力しない（伏せずに返さない）
+			out.Sections = nil
+		}
+	}
+	return &out
+}
+
+// Articles は出力する記事の一覧
+func (r *Redaction) Articles(c echo.Context, articles []sqlc.Article) []sqlc.Article {
+	if !r.enabled(c) {
+		return articles
+	}
+	out := make([]sqlc.Article, len(articles))
+	for i := range articles {
+		out[i] = *r.Article(c, &articles[i])
+	}
+	return out
+}
+
+// Sections は出力するチャプター
+func (r *Redaction) Sections(c echo.Context, sections []models.Section) []models.Section {
+	if !r.enabled(c) {
+		return sections
+	}
+	redactor := r.Redactor()
+	out := make([]models.Section, len(sections))
+	for i, section := range sections {
+		section.Title = redactor.RedactText(section.Title)
+		section.Content = redactor.RedactText(section.Content)
+		out[i] = section
+	}
+	return out
+}
+
+// Lines は出力するスキム（要約ビュー）の行
+func (r *Redaction) Lines(c echo.Context, lines []asr.CondensedLine) []asr.CondensedLine {
+	if !r.enabled(c) {
+		return lines
+	}
+	redactor := r.Redactor()
+	out := make([]asr.CondensedLine, len(lines))
+	for i, line := range lines {
+		line.Text = redactor.RedactText(line.Text)
+		out[i] = line
+	}
+	return out
+}
+
+// Hits は出力するセマンティック検索の結果
+func (r *Redaction) Hits(c echo.Context, hits []semantic.Hit) []semantic.Hit {
+	if !r.enabled(c) {
+		return hits
+	}
+	redactor := r.Redactor()
+	out := make([]semantic.Hit, len(hits))
+	for i, hit := range hits {
+		hit.Title = redactor.RedactText(hit.Title)
+		hit.Text = redactor.RedactText(hit.Text)
+		out[i] = hit
+	}
+	return out
+}
+
+// Revisions は出力する記事の版
+func (r *Redaction) Revisions(c echo.Context, revisions []sqlc.ArticleRevision) []sqlc.ArticleRevision {
+	if !r.enabled(c) {
+		return revisions
+	}
+	out := make([]sqlc.ArticleRevision, len(revisions))
+	for i, rev := range revisions {
+		article := r.Article(c, &sqlc.Article{Title: rev.Title, Content: rev.Content, Summary: rev.Summary, Sections: rev.Sections})
+		rev.Title, rev.Content, rev.Summary, rev.Sections = article.Title, article.Content, article.Summary, article.Sections
+		out[i] = rev
+	}
+	return out
+}
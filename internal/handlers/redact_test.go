@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"zbor/internal/asr"
+	"zbor/internal/davfs"
+	"zbor/internal/export"
+	"zbor/internal/ingestion"
+	"zbor/internal/models"
+	"zbor/internal/semantic"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	redactPhone = "090-1234-5678"
+	redactEmail = "taro@example.com"
+)
+
+// redactFixture は電話番号・メールアドレスを含む文字起こし・翻訳・記事を保存したサーバー
+type redactFixture struct {
+	echo     *echo.Echo
+	sourceID string
+	article  string
+	fs       *davfs.FS
+}
+
+func newRedactFixture(t *testing.T) *redactFixture {
+	t.Helper()
+	ctx := context.Background()
+	db, err := storage.Open(filepath.Join(t.TempDir(), "zbor.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	sourceRepo := storage.NewSourceRepository(db)
+	artifactRepo := storage.NewArtifactRepository(db)
+	articleRepo := storage.NewArticleRepository(db)
+	jobRepo := storage.NewJobRepository(db)
+	transcriptRepo := storage.NewTranscriptRepository(db)
+	ingester := ingestion.NewAudioIngester(sourceRepo, artifactRepo, articleRepo, jobRepo,
+		storage.NewChecksumRepository(db), transcriptRepo, nil, t.TempDir())
+
+	text := "連絡先は" + redactPhone + "、メールは" + redactEmail + "です"
+	status := storage.SourceStatusCompleted
+	metadata := `{"title":"打ち合わせ","duration":4}`
+	source := &sqlc.Source{Type: storage.SourceTypeAudio, Status: &status, Metadata: &metadata}
+	if err := sourceRepo.Create(ctx, source); err != nil {
+		t.Fatal(err)
+	}
+	transcript := &asr.Result{
+		Text:     text,
+		Tokens:   []asr.Token{{Text: text, StartTime: 0, Duration: 3}},
+		Segments: []asr.Segment{{Text: text, StartTime: 0, EndTime: 3}},
+	}
+	saveArtifact := func(artifactType string, content interface{}, metadata string) {
+		data, err := json.Marshal(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := string(data)
+		artifact := &sqlc.ProcessingArtifact{SourceID: &source.ID, Type: artifactType, Content: &s}
+		if metadata != "" {
+			artifact.Metadata = &metadata
+		}
+		if err := artifactRepo.Create(ctx, artifact); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saveArtifact(storage.ArtifactTypeTranscription, transcript, "")
+	saveArtifact(storage.ArtifactTypeTranslation, &asr.Result{
+		Text:     "Call " + redactPhone,
+		Segments: []asr.Segment{{Text: "Call " + redactPhone, StartTime: 0, EndTime: 3}},
+	}, `{"language":"en"}`)
+	if err := ingester.SaveCondensed(ctx, source.ID, transcript); err != nil {
+		t.Fatal(err)
+	}
+	if err := ingester.IndexTranscript(ctx, source.ID, transcript); err != nil {
+		t.Fatal(err)
+	}
+
+	sections, _ := json.Marshal([]models.Section{{ID: "1", Title: redactEmail + " の件", Content: text}})
+	sectionsJSON := string(sections)
+	article := &sqlc.Article{Title: "打ち合わせ", Content: text, SourceID: &source.ID, Sections: &sectionsJSON}
+	if err := articleRepo.Create(ctx, article); err != nil {
+		t.Fatal(err)
+	}
+
+	redaction := NewRedaction(nil, true)
+	audioHandler := NewAudioHandler(ingester, sourceRepo, artifactRepo, articleRepo, jobRepo, storage.NewBookmarkRepository(db), nil)
+	audioHandler.SetRedaction(redaction)
+	articleHandler := NewArticleHandler(articleRepo, storage.NewHoldRepository(db), export.NewExporter(articleRepo, sourceRepo, artifactRepo, nil))
+	articleHandler.SetRedaction(redaction)
+	translateHandler := NewTranslateHandler(nil, sourceRepo, artifactRepo)
+	translateHandler.SetRedaction(redaction)
+	searchHandler := NewSearchHandler(transcriptRepo, articleRepo, nil)
+	searchHandler.SetRedaction(redaction)
+
+	e := echo.New()
+	// X-Test-Scope の権限で認証したものとする（APIAuth.Middleware の代わり）
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(ctxAPIKeyScope, c.Request().Header.Get("X-Test-Scope"))
+			return next(c)
+		}
+	})
+	e.GET("/audio/:source_id/sync", audioHandler.TranscriptSyncPage)
+	e.GET("/articles", articleHandler.ListPage)
+	e.GET("/articles/:id", articleHandler.DetailPage)
+	e.GET("/api/audio/:source_id/transcript", audioHandler.Transcript)
+	e.GET("/api/audio/:source_id/transcript/export", audioHandler.ExportTranscript)
+	e.GET("/api/audio/:source_id/condensed", audioHandler.Condensed)
+	e.GET("/api/audio/:source_id/translations/:lang", translateHandler.Get)
+	e.GET("/api/articles", articleHandler.List)
+	e.GET("/api/articles/search", articleHandler.Search)
+	e.GET("/api/articles/:id", articleHandler.Get)
+	e.GET("/api/articles/:id/export", articleHandler.Export)
+	e.GET("/api/search/transcripts", searchHandler.Transcripts)
+
+	fs := davfs.New(sourceRepo, artifactRepo)
+	if redaction.Enforced() {
+		fs.SetRedactor(redaction.Redactor())
+	}
+	return &redactFixture{echo: e, sourceID: source.ID, article: article.ID, fs: fs}
+}
+
+// get は scope の権限でリクエストしたレスポンスの本文を返す
+func (f *redactFixture) get(t *testing.T, target, scope string) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("X-Test-Scope", scope)
+	rec := httptest.NewRecorder()
+	f.echo.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s = %d: %s", target, rec.Code, rec.Body.String())
+	}
+	return rec.Body.String()
+}
+
+// TestRedactionEnforced tests that with ZBOR_REDACT=1 no output gives a
+// non-admin the phone number or email, even with ?redact=0
+func TestRedactionEnforced(t *testing.T) {
+	f := newRedactFixture(t)
+	targets := []string{
+		"/audio/" + f.sourceID + "/sync",
+		"/audio/" + f.sourceID + "/sync?translation=en",
+		"/articles?q=" + "連絡先",
+		"/articles/" + f.article,
+		"/api/audio/" + f.sourceID + "/transcript",
+		"/api/audio/" + f.sourceID + "/transcript/export?format=txt",
+		"/api/audio/" + f.sourceID + "/transcript/export?format=condensed",
+		"/api/audio/" + f.sourceID + "/condensed",
+		"/api/audio/" + f.sourceID + "/translations/en",
+		"/api/articles",
+		"/api/articles/search?q=" + "連絡先",
+		"/api/articles/" + f.article,
+		"/api/articles/" + f.article + "/export?format=md",
+		"/api/articles/" + f.article + "/export?format=txt",
+		"/api/search/transcripts?q=" + "連絡先",
+	}
+	for _, target := range targets {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		for _, url := range []string{target, target + sep + "redact=0"} {
+			body := f.get(t, url, storage.APIScopeWrite)
+			if strings.Contains(body, redactPhone) || strings.Contains(body, redactEmail) {
+				t.Errorf("GET %s leaks the original text: %s", url, body)
+			}
+			if !strings.Contains(body, asr.RedactionMask) {
+				t.Errorf("GET %s has no masks: %s", url, body)
+			}
+		}
+	}
+
+	// admin は ?redact=0 で元のテキストを参照できる
+	for _, target := range []string{
+		"/audio/" + f.sourceID + "/sync?redact=0",
+		"/api/audio/" + f.sourceID + "/transcript?redact=0",
+		"/api/articles/" + f.article + "?redact=0",
+		"/api/search/transcripts?q=" + "連絡先" + "&redact=0",
+	} {
+		if body := f.get(t, target, storage.APIScopeAdmin); !strings.Contains(body, redactPhone) {
+			t.Errorf("GET %s as admin = %s, want the original text", target, body)
+		}
+	}
+
+	// WebDAV には ?redact=0 が無いため常に伏せ字
+	ctx := context.Background()
+	root, err := f.fs.OpenFile(ctx, "/", os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	folders, err := root.Readdir(0)
+	if err != nil || len(folders) != 1 {
+		t.Fatalf("Readdir = %v, %v", folders, err)
+	}
+	for _, format := range []string{"txt", "srt", "vtt", "json"} {
+		file, err := f.fs.OpenFile(ctx, "/"+folders[0].Name()+"/transcript."+format, os.O_RDONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), redactPhone) || strings.Contains(string(data), redactEmail) {
+			t.Errorf("WebDAV transcript.%s leaks the original text: %s", format, data)
+		}
+	}
+}
+
+// TestRedactionHits tests the semantic search hits and ask citations
+func TestRedactionHits(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/?redact=0", nil), httptest.NewRecorder())
+	c.Set(ctxAPIKeyScope, storage.APIScopeWrite)
+
+	hits := []semantic.Hit{{Title: redactEmail, Text: "連絡先は" + redactPhone}}
+	got := NewRedaction(nil, true).Hits(c, hits)
+	if got[0].Title != asr.RedactionMask || got[0].Text != "連絡先は"+asr.RedactionMask {
+		t.Errorf("Hits = %+v", got)
+	}
+	if hits[0].Text != "連絡先は"+redactPhone {
+		t.Error("Hits modified its input")
+	}
+	if got := NewRedaction(nil, false).Hits(c, hits); got[0].Text != hits[0].Text {
+		t.Errorf("Hits without ZBOR_REDACT = %+v", got)
+	}
+}
+
+// TestRedactionHighlight tests that a search match inside a phone number
+// does not keep the number from being masked
+func TestRedactionHighlight(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+	snippet := "連絡先は" + storage.HighlightStart + "090" + storage.HighlightEnd + "-1234-5678"
+	if got := NewRedaction(nil, true).Highlight(c, snippet); got != "連絡先は"+asr.RedactionMask {
+		t.Errorf("Highlight = %q", got)
+	}
+	plain := "連絡先は" + storage.HighlightStart + "電話" + storage.HighlightEnd
+	if got := NewRedaction(nil, true).Highlight(c, plain); got != plain {
+		t.Errorf("Highlight without matches = %q, want %q", got, plain)
+	}
+}
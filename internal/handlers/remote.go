@@ -0,0 +1,511 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"zbor/internal/ingestion"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/worker"
+
+	"github.com/labstack/echo/v4"
+)
+
+// WorkerIDHeader identifies the remote worker making a request
+const WorkerIDHeader = "X-Zbor-Worker"
+
+// maxChunkSize limits the body of a single result chunk upload
+const maxChunkSize = 32 << 20
+
+// RemoteWorkerHandler serves the API used by remote worker agents
+type RemoteWorkerHandler struct {
+	ingester   *ingestion.AudioIngester
+	jobRepo    *storage.JobRepository
+	workerRepo *storage.WorkerRepository
+	remote     *worker.Remote
+}
+
+// NewRemoteWorkerHandler creates a new RemoteWorkerHandler
+func NewRemoteWorkerHandler(ingester *ingestion.AudioIngester, jobRepo *storage.JobRepository, workerRepo *storage.WorkerRepository, remote *worker.Remote) *RemoteWorkerHandler {
+	return &RemoteWorkerHandler{
+		ingester:   ingester,
+		jobRepo:    jobRepo,
+		workerRepo: workerRepo,
+		remote:     remote,
+	}
+}
+
+// RequireWorkerToken rejects requests without the shared worker token
+// (Authorization: Bearer <token>)
+func RequireWorkerToken(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			got := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid worker token"})
+			}
+			if c.Request().Header.Get(WorkerIDHeader) == "" {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": WorkerIDHeader + " header is required"})
+			}
+			return next(c)
+		}
+	}
+}
+
+// agentModels are the models a remote agent can run (see ingestion.ModelForJobType)
+var agentModels = map[string]bool{
+	storage.ASRModelReazonSpeech:   true,
+	storage.ASRModelReazonSpeechLM: true,
+	storage.ASRModelSenseVoice:     true,
+	storage.ASRModelSenseVoiceBeam: true,
+	storage.ASRModelSenseVoiceLM:   true,
+	storage.ASRModelWhisper:        true,
+	storage.ASRModelWhisperAlign:   true,
+}
+
+// RegisterRequest describes a worker agent and what it can run
+type RegisterRequest struct {
+	Name     string   `json:"name"`
+	Models   []string `json:"models"`   // storage.ASRModel* values installed on the agent
+	Capacity int      `json:"capacity"` // number of jobs the agent runs at once
+	Version  string   `json:"version"`
+}
+
+// RegisterResponse tells the agent how to keep its registration alive
+type RegisterResponse struct {
+	WorkerID          string   `json:"worker_id"`
+	Held              []string `json:"held"`               // running jobs the agent should resume
+	LeaseTimeout      int      `json:"lease_timeout"`      // seconds without heartbeat before jobs are requeued
+	HeartbeatInterval int      `json:"heartbeat_interval"` // recommended heartbeat interval in seconds
+}
+
+// Register registers (or re-registers) the calling agent
+// POST /api/worker/register
+func (h *RemoteWorkerHandler) Register(c echo.Context) error {
+	ctx := c.Request().Context()
+	workerID := c.Request().Header.Get(WorkerIDHeader)
+
+	var req RegisterRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if len(req.Models) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "models is required"})
+	}
+	for _, m := range req.Models {
+		if !agentModels[m] {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "unknown model: " + m})
+		}
+	}
+	if req.Capacity <= 0 {
+		req.Capacity = 1
+	}
+
+	models, _ := json.Marshal(req.Models)
+	w := &sqlc.Worker{
+		ID:       workerID,
+		Name:     storage.Ptr(req.Name),
+		Models:   storage.Ptr(string(models)),
+		Capacity: storage.Ptr(int64(req.Capacity)),
+		Version:  storage.Ptr(req.Version),
+	}
+	if err := h.workerRepo.Register(ctx, w); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	held, err := h.remote.Held(ctx, workerID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	lease := h.remote.LeaseTimeout()
+	return c.JSON(http.StatusOK, RegisterResponse{
+		WorkerID:          workerID,
+		Held:              held,
+		LeaseTimeout:      int(lease.Seconds()),
+		HeartbeatInterval: int((lease / 4).Seconds()),
+	})
+}
+
+// Heartbeat keeps the agent registered and refreshes the lease of all its jobs
+// POST /api/worker/heartbeat
+// Returns 404 when the agent is not registered, so it registers again
+func (h *RemoteWorkerHandler) Heartbeat(c echo.Context) error {
+	ctx := c.Request().Context()
+	w, err := h.registeredWorker(c)
+	if w == nil {
+		return err
+	}
+
+	if err := h.workerRepo.Touch(ctx, w.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := h.remote.Heartbeat(ctx, w.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	// Jobs missing from this list were requeued; the agent should stop working on them
+	held, err := h.remote.Held(ctx, w.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"held": held})
+}
+
+// ClaimResponse is returned to a worker that claimed a job
+type ClaimResponse struct {
+	Job      *sqlc.ProcessingJob   `json:"job"`
+	Task     *ingestion.RemoteTask `json:"task"`
+	Received []int64               `json:"received"` // chunk indexes already uploaded (for resume)
+}
+
+// Claim assigns the next queued job the worker has a model for
+// POST /api/worker/jobs/claim
+// Returns 204 when there is no job or the worker is at capacity
+func (h *RemoteWorkerHandler) Claim(c echo.Context) error {
+	ctx := c.Request().Context()
+	w, err := h.registeredWorker(c)
+	if w == nil {
+		return err
+	}
+	_ = h.workerRepo.Touch(ctx, w.ID)
+
+	held, err := h.remote.Held(ctx, w.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if w.Capacity != nil && int64(len(held)) >= *w.Capacity {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	models := workerModels(w)
+	job, err := h.remote.Claim(ctx, w.ID, func(jobType string) bool {
+		return models[ingestion.ModelForJobType(jobType)]
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if job == nil {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	// New claims are prepared on the server (download, diagnostics, preview)
+	// before the worker fetches the audio
+	task, err := h.ingester.PrepareRemoteTranscription(ctx, job, func(progress int, step string) {
+		_ = h.jobRepo.UpdateProgressWithStep(ctx, job.ID, int64(progress), step)
+		_ = h.jobRepo.Heartbeat(ctx, job.ID)
+	})
+	if err != nil {
+		_ = h.remote.Fail(ctx, job, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	received, err := h.jobRepo.ListResultChunkIndexes(ctx, job.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, ClaimResponse{
+		Job:      job,
+		Task:     task,
+		Received: received,
+	})
+}
+
+// Job returns a job the worker already holds, for resuming after a restart
+// GET /api/worker/jobs/:id
+func (h *RemoteWorkerHandler) Job(c echo.Context) error {
+	ctx := c.Request().Context()
+	job, err := h.verify(c)
+	if job == nil {
+		return err
+	}
+
+	task, err := h.ingester.RemoteTask(ctx, job)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	received, err := h.jobRepo.ListResultChunkIndexes(ctx, job.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, ClaimResponse{
+		Job:      job,
+		Task:     task,
+		Received: received,
+	})
+}
+
+// Audio serves one of the job's audio files
+// GET /api/worker/jobs/:id/audio/:file
+func (h *RemoteWorkerHandler) Audio(c echo.Context) error {
+	job, err := h.verify(c)
+	if job == nil {
+		return err
+	}
+
+	index, err := strconv.Atoi(c.Param("file"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid file index"})
+	}
+	path, err := h.ingester.RemoteFilePath(c.Request().Context(), job, index)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	// Range support lets the worker resume an interrupted download
+	return c.File(path)
+}
+
+// Chunks lists the chunk indexes the server already has
+// GET /api/worker/jobs/:id/chunks
+func (h *RemoteWorkerHandler) Chunks(c echo.Context) error {
+	job, err := h.verify(c)
+	if job == nil {
+		return err
+	}
+
+	received, err := h.jobRepo.ListResultChunkIndexes(c.Request().Context(), job.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"received": received})
+}
+
+// UploadChunk stores one result chunk; uploading the same index again replaces it
+// PUT /api/worker/jobs/:id/chunks/:index
+func (h *RemoteWorkerHandler) UploadChunk(c echo.Context) error {
+	job, err := h.verify(c)
+	if job == nil {
+		return err
+	}
+
+	index, err := strconv.ParseInt(c.Param("index"), 10, 64)
+	if err != nil || index < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid chunk index"})
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxChunkSize+1))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to read chunk"})
+	}
+	if len(body) > maxChunkSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "chunk too large"})
+	}
+
+	// Validate before storing so a bad chunk is rejected at upload time
+	var chunk ingestion.RemoteChunk
+	if err := json.Unmarshal(body, &chunk); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid chunk: " + err.Error()})
+	}
+
+	if err := h.jobRepo.SaveResultChunk(c.Request().Context(), job.ID, index, string(body)); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ProgressRequest reports progress of a remote job
+type ProgressRequest struct {
+	Progress int    `json:"progress"`
+	Step     string `json:"step"`
+}
+
+// Progress updates job progress and doubles as a lease heartbeat
+// POST /api/worker/jobs/:id/progress
+func (h *RemoteWorkerHandler) Progress(c echo.Context) error {
+	job, err := h.verify(c)
+	if job == nil {
+		return err
+	}
+
+	var req ProgressRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if req.Progress < 0 || req.Progress > 99 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "progress must be between 0 and 99"})
+	}
+
+	if err := h.jobRepo.UpdateProgressWithStep(c.Request().Context(), job.ID, int64(req.Progress), req.Step); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CompleteRequest finishes a remote job
+type CompleteRequest struct {
+	Chunks int `json:"chunks"` // total number of chunks the worker produced
+}
+
+// Complete assembles the uploaded chunks and saves the transcription
+// POST /api/worker/jobs/:id/complete
+func (h *RemoteWorkerHandler) Complete(c echo.Context) error {
+	ctx := c.Request().Context()
+	job, err := h.verify(c)
+	if job == nil {
+		return err
+	}
+
+	var req CompleteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if req.Chunks <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "chunks must be positive"})
+	}
+
+	stored, err := h.jobRepo.ListResultChunks(ctx, job.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	// Every index 0..chunks-1 must be present; report the gaps so the worker can re-send them
+	var chunks []ingestion.RemoteChunk
+	var missing []int
+	next := 0
+	for _, sc := range stored {
+		if int(sc.ChunkIndex) >= req.Chunks {
+			break
+		}
+		for ; next < int(sc.ChunkIndex); next++ {
+			missing = append(missing, next)
+		}
+		var chunk ingestion.RemoteChunk
+		if err := json.Unmarshal([]byte(sc.Content), &chunk); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": fmt.Sprintf("stored chunk %d is corrupt", sc.ChunkIndex)})
+		}
+		chunks = append(chunks, chunk)
+		next++
+	}
+	for ; next < req.Chunks; next++ {
+		missing = append(missing, next)
+	}
+	if len(missing) > 0 {
+		return c.JSON(http.StatusConflict, map[string]interface{}{
+			"error":   "missing chunks",
+			"missing": missing,
+		})
+	}
+
+	_ = h.jobRepo.UpdateProgressWithStep(ctx, job.ID, 90, "saving")
+	if err := h.ingester.CompleteRemoteTranscription(ctx, job, chunks); err != nil {
+		_ = h.remote.Fail(ctx, job, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := h.remote.Complete(ctx, job.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"status": storage.JobStatusCompleted})
+}
+
+// FailRequest reports a failure on the worker
+type FailRequest struct {
+	Error string `json:"error"`
+}
+
+// Fail releases the job; it is retried like a locally failed job
+// POST /api/worker/jobs/:id/fail
+func (h *RemoteWorkerHandler) Fail(c echo.Context) error {
+	job, err := h.verify(c)
+	if job == nil {
+		return err
+	}
+
+	var req FailRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if req.Error == "" {
+		req.Error = "remote worker reported a failure"
+	}
+
+	if err := h.remote.Fail(c.Request().Context(), job, errors.New(req.Error)); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// verify loads the job and checks that the calling worker holds it
+// On failure it writes the error response and returns a nil job
+func (h *RemoteWorkerHandler) verify(c echo.Context) (*sqlc.ProcessingJob, error) {
+	workerID := c.Request().Header.Get(WorkerIDHeader)
+	job, err := h.remote.Verify(c.Request().Context(), c.Param("id"), workerID)
+	if errors.Is(err, worker.ErrNotClaimed) {
+		return nil, c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return job, nil
+}
+
+// registeredWorker loads the calling worker's registration
+// On failure it writes the error response (404 if unregistered) and returns nil
+func (h *RemoteWorkerHandler) registeredWorker(c echo.Context) (*sqlc.Worker, error) {
+	w, err := h.workerRepo.GetByID(c.Request().Context(), c.Request().Header.Get(WorkerIDHeader))
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if w == nil {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{"error": "worker is not registered"})
+	}
+	return w, nil
+}
+
+// workerModels returns the set of models a worker advertised
+func workerModels(w *sqlc.Worker) map[string]bool {
+	models := map[string]bool{}
+	if w.Models == nil {
+		return models
+	}
+	var list []string
+	_ = json.Unmarshal([]byte(*w.Models), &list)
+	for _, m := range list {
+		models[m] = true
+	}
+	return models
+}
+
+// WorkerInfo is a registered worker as shown in the worker list
+type WorkerInfo struct {
+	*sqlc.Worker
+	Online bool     `json:"online"`
+	Jobs   []string `json:"jobs"` // running jobs held by the worker
+}
+
+// ListWorkers lists registered worker agents
+// GET /api/workers
+func (h *RemoteWorkerHandler) ListWorkers(c echo.Context) error {
+	ctx := c.Request().Context()
+	workers, err := h.workerRepo.List(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	// A worker counts as online until its jobs would be requeued
+	cutoff := time.Now().Add(-h.remote.LeaseTimeout())
+	infos := make([]WorkerInfo, 0, len(workers))
+	for idx := range workers {
+		held, err := h.remote.Held(ctx, workers[idx].ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		infos = append(infos, WorkerInfo{
+			Worker: &workers[idx],
+			Online: workers[idx].LastSeenAt.After(cutoff),
+			Jobs:   held,
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"workers": infos})
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"zbor/internal/asr"
+	"zbor/internal/ingestion"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ReplacementHandler は置換辞書APIのハンドラー
+type ReplacementHandler struct {
+	replacementRepo *storage.ReplacementRepository
+	sourceRepo      *storage.SourceRepository
+	ingester        *ingestion.AudioIngester
+}
+
+// NewReplacementHandler は新しいReplacementHandlerを作成
+func NewReplacementHandler(replacementRepo *storage.ReplacementRepository, sourceRepo *storage.SourceRepository, ingester *ingestion.AudioIngester) *ReplacementHandler {
+	return &ReplacementHandler{
+		replacementRepo: replacementRepo,
+		sourceRepo:      sourceRepo,
+		ingester:        ingester,
+	}
+}
+
+// ReplacementRuleRequest は置換ルール作成・更新のリクエスト
+type ReplacementRuleRequest struct {
+	Pattern     string `json:"pattern"`     // 表記（regex が true なら正規表現）
+	Replacement string `json:"replacement"` // 空文字なら削除。正規表現では $1 などを使える
+	Regex       bool   `json:"regex"`
+	Enabled     *bool  `json:"enabled"` // 省略時は true
+	Note        string `json:"note"`
+}
+
+// ReplacementRule は置換ルールのレスポンス
+type ReplacementRule struct {
+	ID          int64     `json:"id"`
+	SourceID    *string   `json:"source_id"` // nil ならすべてのソースに適用
+	Pattern     string    `json:"pattern"`
+	Replacement string    `json:"replacement"`
+	Regex       bool      `json:"regex"`
+	Enabled     bool      `json:"enabled"`
+	Note        *string   `json:"note,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ListGlobal はすべてのソースに適用する置換ルールを取得
+// GET /api/replacements
+func (h *ReplacementHandler) ListGlobal(c echo.Context) error {
+	rules, err := h.replacementRepo.ListGlobal(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, replacementRules(rules))
+}
+
+// CreateGlobal はすべてのソースに適用する置換ルールを作成
+// POST /api/replacements
+func (h *ReplacementHandler) CreateGlobal(c echo.Context) error {
+	return h.create(c, nil)
+}
+
+// UpdateGlobal はすべてのソースに適用する置換ルールを更新
+// PUT /api/replacements/:id
+func (h *ReplacementHandler) UpdateGlobal(c echo.Context) error {
+	return h.update(c, nil)
+}
+
+// DeleteGlobal はすべてのソースに適用する置換ルールを削除
+// DELETE /api/replacements/:id
+func (h *ReplacementHandler) DeleteGlobal(c echo.Context) error {
+	return h.delete(c, nil)
+}
+
+// List はソースに適用される置換ルールを取得（全体のルールとソースのルール）
+// GET /api/audio/:source_id/replacements
+func (h *ReplacementHandler) List(c echo.Context) error {
+	ctx := c.Request().Context()
+	sourceID := c.Param("source_id")
+
+	source, err := h.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+
+	global, err := h.replacementRepo.ListGlobal(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	own, err := h.replacementRepo.ListBySourceID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"global": replacementRules(global),
+		"source": replacementRules(own),
+	})
+}
+
+// Create はソースだけに適用する置換ルールを作成
+// POST /api/audio/:source_id/replacements
+func (h *ReplacementHandler) Create(c echo.Context) error {
+	ctx := c.Request().Context()
+	sourceID := c.Param("source_id")
+
+	source, err := h.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+	return h.create(c, &sourceID)
+}
+
+// Update はソースの置換ルールを更新
+// PUT /api/audio/:source_id/replacements/:id
+func (h *ReplacementHandler) Update(c echo.Context) error {
+	sourceID := c.Param("source_id")
+	return h.update(c, &sourceID)
+}
+
+// Delete はソースの置換ルールを削除
+// DELETE /api/audio/:source_id/replacements/:id
+func (h *ReplacementHandler) Delete(c echo.Context) error {
+	sourceID := c.Param("source_id")
+	return h.delete(c, &sourceID)
+}
+
+// Apply は現在の置換辞書を保存済みの文字起こしに適用
+// （文字起こし後に追加したルールを反映する。タイムスタンプは維持）
+// POST /api/audio/:source_id/replacements/apply
+func (h *ReplacementHandler) Apply(c echo.Context) error {
+	ctx := c.Request().Context()
+	sourceID := c.Param("source_id")
+
+	source, err := h.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+	if err := h.sourceRepo.CheckHold(ctx, sourceID); err != nil {
+		return holdError(c, err)
+	}
+
+	changed, err := h.ingester.ReapplyDictionary(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"source_id": sourceID,
+		"changed":   changed,
+	})
+}
+
+// create はルールを作成（sourceID が nil なら全体のルール）
+func (h *ReplacementHandler) create(c echo.Context, sourceID *string) error {
+	req, err := bindReplacementRuleRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	rule := &sqlc.ReplacementRule{SourceID: sourceID}
+	req.apply(rule)
+	if err := h.replacementRepo.Create(c.Request().Context(), rule); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, replacementRuleOf(*rule))
+}
+
+// update はルールを更新（sourceID が nil なら全体のルールだけが対象）
+func (h *ReplacementHandler) update(c echo.Context, sourceID *string) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	req, err := bindReplacementRuleRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	ctx := c.Request().Context()
+	rule, err := h.getRule(c, id, sourceID)
+	if err != nil || rule == nil {
+		return err
+	}
+
+	req.apply(rule)
+	if _, err := h.replacementRepo.Update(ctx, rule); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, replacementRuleOf(*rule))
+}
+
+// delete はルールを削除（sourceID が nil なら全体のルールだけが対象）
+func (h *ReplacementHandler) delete(c echo.Context, sourceID *string) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+
+	rule, err := h.getRule(c, id, sourceID)
+	if err != nil || rule == nil {
+		return err
+	}
+	if _, err := h.replacementRepo.Delete(c.Request().Context(), id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// getRule はスコープ（全体またはソース）が一致するルールを取得。
+// 見つからなければエラーレスポンスを書き込んで nil を返す
+func (h *ReplacementHandler) getRule(c echo.Context, id int64, sourceID *string) (*sqlc.ReplacementRule, error) {
+	rule, err := h.replacementRepo.Get(c.Request().Context(), id)
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if rule == nil || !sameScope(rule.SourceID, sourceID) {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{"error": "replacement rule not found"})
+	}
+	return rule, nil
+}
+
+// sameScope はルールのスコープが一致するか判定
+func sameScope(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func bindReplacementRuleRequest(c echo.Context) (*ReplacementRuleRequest, error) {
+	var req ReplacementRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, errors.New("invalid request body")
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	rule := asr.ReplacementRule{Pattern: req.Pattern, Replacement: req.Replacement, Regex: req.Regex}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// apply はリクエストの内容をルールに設定
+func (req *ReplacementRuleRequest) apply(rule *sqlc.ReplacementRule) {
+	rule.Pattern = req.Pattern
+	rule.Replacement = req.Replacement
+	rule.IsRegex = boolInt(req.Regex)
+	rule.Enabled = boolInt(req.Enabled == nil || *req.Enabled)
+	rule.Note = nil
+	if req.Note != "" {
+		rule.Note = storage.Ptr(req.Note)
+	}
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// replacementRuleOf は保存されたルールをレスポンスに変換
+func replacementRuleOf(rule sqlc.ReplacementRule) ReplacementRule {
+	return ReplacementRule{
+		ID:          rule.ID,
+		SourceID:    rule.SourceID,
+		Pattern:     rule.Pattern,
+		Replacement: rule.Replacement,
+		Regex:       rule.IsRegex != 0,
+		Enabled:     rule.Enabled != 0,
+		Note:        rule.Note,
+		CreatedAt:   rule.CreatedAt,
+		UpdatedAt:   rule.UpdatedAt,
+	}
+}
+
+func replacementRules(rules []sqlc.ReplacementRule) []ReplacementRule {
+	items := make([]ReplacementRule, len(rules))
+	for idx, rule := range rules {
+		items[idx] = replacementRuleOf(rule)
+	}
+	return items
+}
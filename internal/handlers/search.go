@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"zbor/internal/semantic"
+	"zbor/internal/storage"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SearchHandler はトランスクリプト検索・セマンティック検索APIのハンドラー
+type SearchHandler struct {
+	transcriptRepo *storage.TranscriptRepository
+	articleRepo    *storage.ArticleRepository
+	index          *semantic.Index
+	redaction      *Redaction
+}
+
+// NewSearchHandler は新しいSearchHandlerを作成
+func NewSearchHandler(transcriptRepo *storage.TranscriptRepository, articleRepo *storage.ArticleRepository, index *semantic.Index) *SearchHandler {
+	return &SearchHandler{
+		transcriptRepo: transcriptRepo,
+		articleRepo:    articleRepo,
+		index:          index,
+	}
+}
+
+// SetRedaction は検索結果の伏せ字を設定（Redaction を参照）
+func (h *SearchHandler) SetRedaction(redaction *Redaction) {
+	h.redaction = redaction
+}
+
+// maxSemanticHits はセマンティック検索で返す最大件数
+const maxSemanticHits = 50
+
+// TranscriptHit はトランスクリプト検索の1件（セグメント単位）
+type TranscriptHit struct {
+	SourceID     string  `json:"source_id"`
+	Title        string  `json:"title,omitempty"` // ソースの記事タイトル
+	SegmentIndex int64   `json:"segment_index"`   // 文字起こし結果の segments の位置（0始まり）
+	StartTime    float64 `json:"start_time"`
+	EndTime      float64 `json:"end_time"`
+	Speaker      *string `json:"speaker,omitempty"`
+	Text         string  `json:"text"`
+	URL          string  `json:"url"` // 同期ページのその時刻へのリンク
+}
+
+// highlightHTML は検索結果の一致箇所の記号を <mark> に置き換える（他はHTMLエスケープ）
+func highlightHTML(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, storage.HighlightStart, "<mark>")
+	return strings.ReplaceAll(s, storage.HighlightEnd, "</mark>")
+}
+
+// Transcripts はトランスクリプトをフレーズで検索
+// GET /api/search/transcripts?q=...&limit=20&offset=0
+func (h *SearchHandler) Transcripts(c echo.Context) error {
+	ctx := c.Request().Context()
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "q is required"})
+	}
+	limit, offset := parsePagination(c, 20)
+
+	segments, err := h.transcriptRepo.Search(ctx, query, limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	total, err := h.transcriptRepo.CountSearch(ctx, query)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	// タイトルはソースごとに1回だけ取得
+	titles := map[string]string{}
+	hits := make([]TranscriptHit, 0, len(segments))
+	for _, seg := range segments {
+		title, ok := titles[seg.SourceID]
+		if !ok {
+			if articles, err := h.articleRepo.GetBySourceID(ctx, seg.SourceID); err == nil && len(articles) > 0 {
+				title = articles[0].Title
+			}
+			titles[seg.SourceID] = title
+		}
+		hits = append(hits, TranscriptHit{
+			SourceID:     seg.SourceID,
+			Title:        h.redaction.Text(c, title),
+			SegmentIndex: seg.SegmentIndex,
+			StartTime:    seg.StartTime,
+			EndTime:      seg.EndTime,
+			Speaker:      seg.Speaker,
+			Text:         h.redaction.Text(c, seg.Text),
+			URL:          fmt.Sprintf("/audio/%s/sync?t=%.2f", seg.SourceID, seg.StartTime),
+		})
+	}
+
+	return c.JSON(http.StatusOK, newPage(hits, total, limit, offset))
+}
+
+// Semantic は記事のチャンクを意味の近さで検索（類似度の高い順の配列）
+// GET /api/search/semantic?q=...&limit=10
+func (h *SearchHandler) Semantic(c echo.Context) error {
+	if !h.index.Enabled() {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": semantic.ErrDisabled.Error()})
+	}
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "q is required"})
+	}
+	limit, _ := parsePagination(c, 10)
+
+	hits, err := h.index.Search(c.Request().Context(), query, min(limit, maxSemanticHits))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, h.redaction.Hits(c, hits))
+}
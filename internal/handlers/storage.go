@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"zbor/internal/retention"
+	"zbor/internal/storage"
+
+	"github.com/labstack/echo/v4"
+)
+
+// 統計に含める使用量の大きいソースの数（デフォルトと上限）
+const (
+	defaultStorageTop = 20
+	maxStorageTop     = 500
+)
+
+// StorageHandler はストレージの使用量と元音声の削除（cleanup）のハンドラー
+type StorageHandler struct {
+	manager    *retention.Manager
+	sourceRepo *storage.SourceRepository
+}
+
+// NewStorageHandler は新しいStorageHandlerを作成
+func NewStorageHandler(manager *retention.Manager, sourceRepo *storage.SourceRepository) *StorageHandler {
+	return &StorageHandler{
+		manager:    manager,
+		sourceRepo: sourceRepo,
+	}
+}
+
+// Stats はソースごとの使用量の合計、使用量の大きいソース、重複排除と変換キャッシュの統計を取得
+// GET /api/storage/stats?limit=20
+func (h *StorageHandler) Stats(c echo.Context) error {
+	top := defaultStorageTop
+	if n, err := strconv.Atoi(c.QueryParam("limit")); err == nil && n >= 0 {
+		top = min(n, maxStorageTop)
+	}
+	stats, err := h.manager.Stats(c.Request().Context(), top)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, stats)
+}
+
+// SourceUsage はソースのディスク使用量を取得
+// GET /api/sources/:id/storage
+func (h *StorageHandler) SourceUsage(c echo.Context) error {
+	source, err := h.sourceRepo.GetByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+	return c.JSON(http.StatusOK, retention.Usage(source))
+}
+
+// Cleanup は保持期間（ZBOR_ORIGINAL_RETENTION_DAYS）を過ぎたソースの cleanup ジョブを作成
+// POST /api/storage/cleanup
+func (h *StorageHandler) Cleanup(c echo.Context) error {
+	n, err := h.manager.QueueExpired(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusAccepted, map[string]int{"queued": n})
+}
+
+// CleanupSource は保持期間に関係なく、ソースの元音声を削除する cleanup ジョブを作成
+// POST /api/sources/:id/cleanup
+func (h *StorageHandler) CleanupSource(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	source, err := h.sourceRepo.GetByID(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+
+	jobID, err := h.manager.QueueCleanup(ctx, id, storage.JobPriorityNormal)
+	if errors.Is(err, storage.ErrLegalHold) {
+		return c.JSON(http.StatusLocked, map[string]string{"error": err.Error()})
+	}
+	if errors.Is(err, retention.ErrNotCleanable) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create job: " + err.Error()})
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"message":   "Cleanup job created",
+		"source_id": id,
+		"job_id":    jobID,
+	})
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"zbor/internal/storage"
+	"zbor/internal/summarize"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SummarizeHandler は要約APIのハンドラー
+type SummarizeHandler struct {
+	summarizer  *summarize.Summarizer
+	articleRepo *storage.ArticleRepository
+}
+
+// NewSummarizeHandler は新しいSummarizeHandlerを作成
+func NewSummarizeHandler(summarizer *summarize.Summarizer, articleRepo *storage.ArticleRepository) *SummarizeHandler {
+	return &SummarizeHandler{
+		summarizer:  summarizer,
+		articleRepo: articleRepo,
+	}
+}
+
+// Summarize は記事の要約ジョブを作成
+// POST /api/articles/:id/summarize
+func (h *SummarizeHandler) Summarize(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	if !h.summarizer.Enabled() {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "summarization is not configured (set ZBOR_LLM_URL)"})
+	}
+
+	article, err := h.articleRepo.GetByID(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if article == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "article not found"})
+	}
+
+	jobID, err := h.summarizer.CreateJob(ctx, id, storage.JobPriorityImmediate)
+	if errors.Is(err, storage.ErrLegalHold) {
+		return c.JSON(http.StatusLocked, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create job: " + err.Error()})
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"message":    "Summarization job created",
+		"article_id": id,
+		"job_id":     jobID,
+	})
+}
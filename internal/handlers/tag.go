@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"zbor/internal/storage"
 	"zbor/internal/storage/sqlc"
@@ -23,20 +24,26 @@ func NewTagHandler(repo *storage.TagRepository) *TagHandler {
 // List はタグ一覧を取得
 func (h *TagHandler) List(c echo.Context) error {
 	ctx := c.Request().Context()
+	limit, offset := parsePagination(c, 100)
+
+	total, err := h.repo.Count(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
 
 	if c.QueryParam("with_count") == "true" {
-		tags, err := h.repo.ListWithCount(ctx)
+		tags, err := h.repo.ListWithCount(ctx, limit, offset)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		}
-		return c.JSON(http.StatusOK, tags)
+		return c.JSON(http.StatusOK, newPage(tags, total, limit, offset))
 	}
 
-	tags, err := h.repo.List(ctx)
+	tags, err := h.repo.List(ctx, limit, offset)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
-	return c.JSON(http.StatusOK, tags)
+	return c.JSON(http.StatusOK, newPage(tags, total, limit, offset))
 }
 
 // Get はタグを取得
@@ -150,3 +157,22 @@ func (h *TagHandler) Delete(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+// Suggest は入力中の文字列で始まるタグを記事数の多い順に取得
+// GET /api/tags/suggest?q=<prefix>&limit=<n>
+func (h *TagHandler) Suggest(c echo.Context) error {
+	prefix := strings.TrimSpace(c.QueryParam("q"))
+	if prefix == "" {
+		return c.JSON(http.StatusOK, []sqlc.SearchTagsByPrefixRow{})
+	}
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	tags, err := h.repo.SearchByPrefix(c.Request().Context(), prefix, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, tags)
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"zbor/internal/ingestion"
+	"zbor/internal/storage"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TranscriptVersions lists the transcriptions of a source: the current one
+// and earlier ones kept by full re-transcriptions, with the model and
+// metadata that produced them
+// GET /api/audio/:source_id/transcripts
+func (h *AudioHandler) TranscriptVersions(c echo.Context) error {
+	versions, err := h.ingester.TranscriptVersions(c.Request().Context(), c.Param("source_id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, versions)
+}
+
+// PromoteTranscript makes an earlier transcription the current one again
+// (the replaced one is kept as a version)
+// POST /api/audio/:source_id/transcripts/:version_id/promote
+func (h *AudioHandler) PromoteTranscript(c echo.Context) error {
+	version, err := h.ingester.PromoteTranscript(c.Request().Context(), c.Param("source_id"), c.Param("version_id"))
+	switch {
+	case errors.Is(err, ingestion.ErrTranscriptVersionNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, ingestion.ErrTranscriptionActive):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, storage.ErrLegalHold):
+		return holdError(c, err)
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, version)
+}
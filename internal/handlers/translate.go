@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"zbor/internal/storage"
+	"zbor/internal/translate"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TranslateHandler は翻訳APIのハンドラー
+type TranslateHandler struct {
+	translator   *translate.Translator
+	sourceRepo   *storage.SourceRepository
+	artifactRepo *storage.ArtifactRepository
+	redaction    *Redaction
+}
+
+// NewTranslateHandler は新しいTranslateHandlerを作成
+func NewTranslateHandler(translator *translate.Translator, sourceRepo *storage.SourceRepository, artifactRepo *storage.ArtifactRepository) *TranslateHandler {
+	return &TranslateHandler{
+		translator:   translator,
+		sourceRepo:   sourceRepo,
+		artifactRepo: artifactRepo,
+	}
+}
+
+// SetRedaction は翻訳の出力の伏せ字を設定（Redaction を参照）
+func (h *TranslateHandler) SetRedaction(redaction *Redaction) {
+	h.redaction = redaction
+}
+
+// Translate はソースの文字起こしの翻訳ジョブを作成
+// POST /api/audio/:source_id/translate
+func (h *TranslateHandler) Translate(c echo.Context) error {
+	ctx := c.Request().Context()
+	sourceID := c.Param("source_id")
+
+	if !h.translator.Enabled() {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "translation is not configured (set ZBOR_TRANSLATE_URL or ZBOR_LLM_URL)"})
+	}
+
+	var opts translate.Options
+	if err := c.Bind(&opts); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if !translate.ValidLanguage(opts.Target) || (opts.Source != "" && !translate.ValidLanguage(opts.Source)) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": translate.ErrInvalidLanguage.Error()})
+	}
+
+	source, err := h.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if source == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+	}
+
+	jobID, err := h.translator.CreateJob(ctx, sourceID, opts, storage.JobPriorityImmediate)
+	switch {
+	case errors.Is(err, storage.ErrLegalHold):
+		return holdError(c, err)
+	case errors.Is(err, translate.ErrTranslationPending):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create job: " + err.Error()})
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"message":   "Translation job created",
+		"source_id": sourceID,
+		"target":    opts.Target,
+		"job_id":    jobID,
+	})
+}
+
+// List はソースの翻訳の一覧を取得
+// GET /api/audio/:source_id/translations
+func (h *TranslateHandler) List(c echo.Context) error {
+	items, err := translate.Languages(c.Request().Context(), h.artifactRepo, c.Param("source_id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, items)
+}
+
+// Get はソースの翻訳（セグメントは文字起こしと同じ時刻）を取得
+// GET /api/audio/:source_id/translations/:lang
+func (h *TranslateHandler) Get(c echo.Context) error {
+	lang := c.Param("lang")
+	result, err := translate.Load(c.Request().Context(), h.artifactRepo, c.Param("source_id"), lang)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if result == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "translation not found: " + lang})
+	}
+	return c.JSON(http.StatusOK, h.redaction.Result(c, result))
+}
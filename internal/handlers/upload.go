@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"zbor/internal/ingestion"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SetUploadStore enables resumable uploads: large files are sent in chunks
+// to /api/uploads and ingested by passing upload_id to /api/ingest/audio
+func (h *AudioHandler) SetUploadStore(uploads *ingestion.UploadStore) {
+	h.uploads = uploads
+}
+
+// CreateUploadRequest starts a resumable upload
+type CreateUploadRequest struct {
+	Filename  string `json:"filename"`
+	Size      int64  `json:"size"`       // file size (bytes)
+	ChunkSize int64  `json:"chunk_size"` // optional (default 8MB)
+}
+
+// CreateUpload starts a resumable upload
+// POST /api/uploads
+func (h *AudioHandler) CreateUpload(c echo.Context) error {
+	if h.uploads == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "resumable uploads are not enabled"})
+	}
+	var req CreateUploadRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	upload, err := h.uploads.Create(c.Request().Context(), req.Filename, req.Size, req.ChunkSize)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, upload)
+}
+
+// GetUpload returns an upload and the chunks received so far; a client
+// resumes by sending the missing ones
+// GET /api/uploads/:upload_id
+func (h *AudioHandler) GetUpload(c echo.Context) error {
+	if h.uploads == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "resumable uploads are not enabled"})
+	}
+	upload, err := h.uploads.Get(c.Request().Context(), c.Param("upload_id"))
+	if err != nil {
+		return uploadError(c, err)
+	}
+	return c.JSON(http.StatusOK, upload)
+}
+
+// PutUploadChunk stores a chunk (raw request body). Chunks are numbered from
+// 0 and every chunk but the last is chunk_size bytes
+// PUT /api/uploads/:upload_id/chunks/:index
+func (h *AudioHandler) PutUploadChunk(c echo.Context) error {
+	if h.uploads == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "resumable uploads are not enabled"})
+	}
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid chunk index"})
+	}
+	upload, err := h.uploads.WriteChunk(c.Request().Context(), c.Param("upload_id"), index, c.Request().Body)
+	if err != nil {
+		return uploadError(c, err)
+	}
+	return c.JSON(http.StatusOK, upload)
+}
+
+// DeleteUpload aborts an upload and removes its chunks
+// DELETE /api/uploads/:upload_id
+func (h *AudioHandler) DeleteUpload(c echo.Context) error {
+	if h.uploads == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "resumable uploads are not enabled"})
+	}
+	if err := h.uploads.Delete(c.Request().Context(), c.Param("upload_id")); err != nil {
+		return uploadError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// uploadError maps UploadStore errors to responses
+func uploadError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ingestion.ErrUploadNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, ingestion.ErrUploadIncomplete):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, ingestion.ErrInvalidChunk):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"zbor/internal/ingestion"
+	"zbor/internal/storage"
+
+	"github.com/labstack/echo/v4"
+)
+
+// UsageHandler は文字起こしの利用量と月ごとの上限のAPIのハンドラー
+type UsageHandler struct {
+	repo *storage.UsageRepository
+}
+
+// NewUsageHandler は新しいUsageHandlerを作成
+func NewUsageHandler(repo *storage.UsageRepository) *UsageHandler {
+	return &UsageHandler{repo: repo}
+}
+
+// SetUsageQuotaRequest は上限の設定のリクエスト
+type SetUsageQuotaRequest struct {
+	Scope          string   `json:"scope"`           // user, api_key, model
+	Subject        string   `json:"subject"`         // ユーザー名・キーの名前・モデル名（* は個別の上限の無いすべて）
+	MonthlyMinutes *float64 `json:"monthly_minutes"` // 月ごとの上限（分）
+}
+
+// Report は1か月の利用量をユーザー・APIキー・モデルごとに集計する
+// ログインしたユーザー（管理者以外）は自分の利用量のみ
+// GET /api/usage?month=YYYY-MM（省略時は今月）
+func (h *UsageHandler) Report(c echo.Context) error {
+	month := time.Now()
+	if v := strings.TrimSpace(c.QueryParam("month")); v != "" {
+		t, err := time.ParseInLocation("2006-01", v, time.Local)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "month must be YYYY-MM"})
+		}
+		month = t
+	}
+	report, err := h.repo.Report(c.Request().Context(), month)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// ListQuotas は上限の一覧を今月の利用量とともに取得
+// GET /api/usage/quotas
+func (h *UsageHandler) ListQuotas(c echo.Context) error {
+	quotas, err := h.repo.Quotas(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, quotas)
+}
+
+// SetQuota は上限を設定（同じ対象の上限は置き換える）
+// PUT /api/usage/quotas
+func (h *UsageHandler) SetQuota(c echo.Context) error {
+	var req SetUsageQuotaRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if req.MonthlyMinutes == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "monthly_minutes is required"})
+	}
+	req.Subject = strings.TrimSpace(req.Subject)
+	if err := h.repo.SetQuota(c.Request().Context(), req.Scope, req.Subject, *req.MonthlyMinutes); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"scope":           req.Scope,
+		"subject":         req.Subject,
+		"monthly_minutes": *req.MonthlyMinutes,
+	})
+}
+
+// DeleteQuota は上限を削除
+// DELETE /api/usage/quotas/:scope/:subject
+func (h *UsageHandler) DeleteQuota(c echo.Context) error {
+	ok, err := h.repo.DeleteQuota(c.Request().Context(), c.Param("scope"), c.Param("subject"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "quota not found"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// quotaError は上限の超過を 429 Too Many Requests、モデルが無い場合を 503、それ以外を 500 で返す
+func quotaError(c echo.Context, err error) error {
+	if errors.Is(err, storage.ErrQuotaExceeded) {
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+	}
+	if errors.Is(err, ingestion.ErrModelUnavailable) {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
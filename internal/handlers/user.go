@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/web/components"
+	"zbor/web/layouts"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// SessionCookie はログインセッションのトークンを保存するCookie
+	SessionCookie = "zbor_session"
+	// AuthHeader はユーザーがいる場合の 401 に付けるヘッダー（Web UI はログイン画面に移動する）
+	AuthHeader = "X-Zbor-Auth"
+)
+
+// setUser はリクエストのコンテキストをユーザーのものにする
+// 管理者は全ユーザーのデータを、それ以外は自分のデータだけを参照できる
+// （ページでも isAdmin で判定できるように、ユーザーの権限をスコープとして設定する）
+func setUser(c echo.Context, user *sqlc.User) {
+	ctx := storage.WithOwner(c.Request().Context(), user.ID, user.IsAdmin == 1)
+	ctx = layouts.WithUsername(ctx, user.Username)
+	c.SetRequest(c.Request().WithContext(ctx))
+	c.Set(ctxAPIKeyScope, userScope(user))
+}
+
+// userScope はユーザーの権限（管理者は admin、それ以外は write）
+func userScope(user *sqlc.User) string {
+	if user.IsAdmin == 1 {
+		return storage.APIScopeAdmin
+	}
+	return storage.APIScopeWrite
+}
+
+// sessionUser はセッションCookieのユーザーを返す（ログインしていない場合は nil）
+func sessionUser(c echo.Context, repo *storage.UserRepository) (*sqlc.User, error) {
+	cookie, err := c.Cookie(SessionCookie)
+	if err != nil || cookie.Value == "" {
+		return nil, nil
+	}
+	return repo.SessionUser(c.Request().Context(), cookie.Value)
+}
+
+// RequireLogin は Web UI のページ用ミドルウェア
+// ユーザーがいる場合はログインしていなければ /login に移動し、ページにはそのユーザーのデータだけを表示する
+func RequireLogin(repo *storage.UserRepository) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			n, err := repo.Count(c.Request().Context())
+			if err != nil {
+				return c.String(http.StatusInternalServerError, err.Error())
+			}
+			if n == 0 {
+				return next(c)
+			}
+			user, err := sessionUser(c, repo)
+			if err != nil {
+				return c.String(http.StatusInternalServerError, err.Error())
+			}
+			if user == nil {
+				return c.Redirect(http.StatusSeeOther, "/login?next="+url.QueryEscape(c.Request().URL.RequestURI()))
+			}
+			setUser(c, user)
+			return next(c)
+		}
+	}
+}
+
+// OwnedSource は :param のソースを参照できないリクエストを 404 で拒否する
+// （ソースIDだけを使うハンドラーにもユーザーごとの分離を適用する）
+func OwnedSource(repo *storage.SourceRepository, param string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if storage.OwnerFromContext(c.Request().Context()) == "" {
+				return next(c)
+			}
+			source, err := repo.GetByID(c.Request().Context(), c.Param(param))
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			}
+			if source == nil {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "source not found"})
+			}
+			return next(c)
+		}
+	}
+}
+
+// OwnedArticle は :param の記事（削除済みを含む）を参照できないリクエストを 404 で拒否する
+func OwnedArticle(repo *storage.ArticleRepository, param string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if storage.OwnerFromContext(c.Request().Context()) == "" {
+				return next(c)
+			}
+			ok, err := repo.Accessible(c.Request().Context(), c.Param(param))
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			}
+			if !ok {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "article not found"})
+			}
+			return next(c)
+		}
+	}
+}
+
+// UserHandler はログインとユーザー管理のハンドラー
+type UserHandler struct {
+	repo *storage.UserRepository
+}
+
+// NewUserHandler は新しいUserHandlerを作成
+func NewUserHandler(repo *storage.UserRepository) *UserHandler {
+	return &UserHandler{repo: repo}
+}
+
+// CreateUserRequest はユーザー作成のリクエスト
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Admin    bool   `json:"admin"`
+}
+
+// LoginPage はログイン画面を表示
+// GET /login?next=/articles
+func (h *UserHandler) LoginPage(c echo.Context) error {
+	return render(c, components.Login(safeNext(c.QueryParam("next")), ""))
+}
+
+// Login はユーザー名とパスワードでログインし、セッションCookieを設定して next に移動する
+// POST /login
+func (h *UserHandler) Login(c echo.Context) error {
+	ctx := c.Request().Context()
+	next := safeNext(c.FormValue("next"))
+
+	user, err := h.repo.Authenticate(ctx, c.FormValue("username"), c.FormValue("password"))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	if user == nil {
+		c.Response().WriteHeader(http.StatusUnauthorized)
+		return render(c, components.Login(next, "ユーザー名またはパスワードが違います"))
+	}
+
+	token, expires, err := h.repo.CreateSession(ctx, user.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     SessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return c.Redirect(http.StatusSeeOther, next)
+}
+
+// Logout はセッションを削除してログイン画面に移動する
+// POST /logout
+func (h *UserHandler) Logout(c echo.Context) error {
+	if cookie, err := c.Cookie(SessionCookie); err == nil && cookie.Value != "" {
+		if err := h.repo.DeleteSession(c.Request().Context(), cookie.Value); err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     SessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return c.Redirect(http.StatusSeeOther, "/login")
+}
+
+// List はユーザーの一覧を取得（パスワードのハッシュは含まない）
+// GET /api/users
+func (h *UserHandler) List(c echo.Context) error {
+	users, err := h.repo.List(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, users)
+}
+
+// Create はユーザーを作成（最初のユーザーは管理者になり、既存のデータを引き継ぐ）
+// POST /api/users
+func (h *UserHandler) Create(c echo.Context) error {
+	var req CreateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	user, err := h.repo.Create(c.Request().Context(), req.Username, req.Password, req.Admin)
+	if errors.Is(err, storage.ErrUserExists) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, sqlc.ListUsersRow{
+		ID:        user.ID,
+		Username:  user.Username,
+		IsAdmin:   user.IsAdmin,
+		CreatedAt: user.CreatedAt,
+	})
+}
+
+// Delete はユーザーを削除（ユーザーのデータは残り、管理者だけが参照できる）
+// DELETE /api/users/:id
+func (h *UserHandler) Delete(c echo.Context) error {
+	ok, err := h.repo.Delete(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// safeNext はログイン後の移動先を同じサイトのパスに限る（それ以外は /）
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"zbor/internal/ingestion"
+	"zbor/internal/storage"
+	"zbor/internal/summarize"
+	"zbor/internal/webfetch"
+
+	"github.com/labstack/echo/v4"
+)
+
+// WebHandler はWebページ取り込みAPIのハンドラー
+type WebHandler struct {
+	ingester   *ingestion.WebIngester
+	summarizer *summarize.Summarizer
+}
+
+// NewWebHandler は新しいWebHandlerを作成
+func NewWebHandler(ingester *ingestion.WebIngester, summarizer *summarize.Summarizer) *WebHandler {
+	return &WebHandler{
+		ingester:   ingester,
+		summarizer: summarizer,
+	}
+}
+
+// IngestURLRequest はURL取り込みのリクエスト
+type IngestURLRequest struct {
+	URL       string `json:"url"`
+	Title     string `json:"title"`     // 省略時はページのタイトル
+	Summarize bool   `json:"summarize"` // 保存後に要約ジョブを作成
+	ExtractRequest
+}
+
+// ExtractRequest は本文抽出オプション（省略時は本文のみ、画像・リンク・表を残す）
+type ExtractRequest struct {
+	Extract    string `json:"extract"` // article（デフォルト）, full
+	KeepImages *bool  `json:"keep_images"`
+	KeepLinks  *bool  `json:"keep_links"`
+	KeepTables *bool  `json:"keep_tables"`
+}
+
+// options はリクエストから抽出オプションを作成
+func (r *ExtractRequest) options() (*webfetch.ExtractOptions, error) {
+	extract := webfetch.DefaultExtractOptions()
+	switch webfetch.ExtractMode(r.Extract) {
+	case "", webfetch.ExtractModeArticle:
+	case webfetch.ExtractModeFull:
+		extract.Mode = webfetch.ExtractModeFull
+	default:
+		return nil, errors.New("extract must be article or full")
+	}
+	if r.KeepImages != nil {
+		extract.KeepImages = *r.KeepImages
+	}
+	if r.KeepLinks != nil {
+		extract.KeepLinks = *r.KeepLinks
+	}
+	if r.KeepTables != nil {
+		extract.KeepTables = *r.KeepTables
+	}
+	return extract, nil
+}
+
+// IngestURL はWebページを記事として取り込むジョブを作成
+// POST /api/ingest/url
+func (h *WebHandler) IngestURL(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req IngestURLRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if strings.TrimSpace(req.URL) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "url is required"})
+	}
+	extract, err := req.options()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if req.Summarize && !h.summarizer.Enabled() {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "summarization is not configured (set ZBOR_LLM_URL)"})
+	}
+
+	result, err := h.ingester.IngestURL(ctx, ingestion.IngestURLOptions{
+		URL:       req.URL,
+		Title:     strings.TrimSpace(req.Title),
+		Summarize: req.Summarize,
+		Priority:  storage.JobPriorityNormal,
+		Extract:   extract,
+	})
+	if err != nil {
+		if errors.Is(err, ingestion.ErrInvalidURL) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"source_id": result.SourceID,
+		"job_id":    result.JobID,
+		"message":   "URL ingestion started",
+	})
+}
+
+// IngestCrawlRequest はサイトクロールのリクエスト
+type IngestCrawlRequest struct {
+	URL          string `json:"url"`
+	MaxDepth     *int   `json:"max_depth"`     // 開始ページから辿るリンクの深さ（デフォルト: 1）
+	MaxPages     int    `json:"max_pages"`     // 取得するページ数の上限（0 でデフォルト: 50）
+	PathPrefix   string `json:"path_prefix"`   // このパスで始まるページのみ（例: /docs/）
+	OtherDomains bool   `json:"other_domains"` // 他のホストへのリンクも辿る
+	DelaySeconds int    `json:"delay_seconds"` // ページ取得の間隔（デフォルト: 1秒）
+	Summarize    bool   `json:"summarize"`     // 各ページの保存後に要約ジョブを作成
+	ExtractRequest
+}
+
+// クロールの上限（誤って巨大なサイトを取り込まないため）
+const (
+	maxCrawlDepth = 5
+	maxCrawlPages = 500
+)
+
+// IngestCrawl はサイトをクロールして各ページを記事として取り込むジョブを作成
+// POST /api/ingest/crawl
+func (h *WebHandler) IngestCrawl(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req IngestCrawlRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if strings.TrimSpace(req.URL) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "url is required"})
+	}
+	maxDepth := 1
+	if req.MaxDepth != nil {
+		maxDepth = *req.MaxDepth
+	}
+	if maxDepth < 0 || maxDepth > maxCrawlDepth {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("max_depth must be between 0 and %d", maxCrawlDepth)})
+	}
+	if req.MaxPages < 0 || req.MaxPages > maxCrawlPages {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("max_pages must be between 0 and %d", maxCrawlPages)})
+	}
+	if req.DelaySeconds < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "delay_seconds must not be negative"})
+	}
+	extract, err := req.options()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if req.Summarize && !h.summarizer.Enabled() {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "summarization is not configured (set ZBOR_LLM_URL)"})
+	}
+
+	result, err := h.ingester.IngestCrawl(ctx, ingestion.IngestCrawlOptions{
+		URL:          req.URL,
+		MaxDepth:     maxDepth,
+		MaxPages:     req.MaxPages,
+		PathPrefix:   strings.TrimSpace(req.PathPrefix),
+		OtherDomains: req.OtherDomains,
+		DelaySeconds: req.DelaySeconds,
+		Summarize:    req.Summarize,
+		Priority:     storage.JobPriorityBatch,
+		Extract:      extract,
+	})
+	if err != nil {
+		if errors.Is(err, ingestion.ErrInvalidURL) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"source_id": result.SourceID,
+		"job_id":    result.JobID,
+		"message":   "crawl started",
+	})
+}
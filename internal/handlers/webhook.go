@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/webhooks"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxWebhookDeliveries は配信の一覧で返す最大件数
+const maxWebhookDeliveries = 200
+
+// WebhookHandler は Webhook APIのハンドラー
+type WebhookHandler struct {
+	webhookRepo *storage.WebhookRepository
+	dispatcher  *webhooks.Dispatcher
+}
+
+// NewWebhookHandler は新しいWebhookHandlerを作成
+func NewWebhookHandler(webhookRepo *storage.WebhookRepository, dispatcher *webhooks.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{
+		webhookRepo: webhookRepo,
+		dispatcher:  dispatcher,
+	}
+}
+
+// WebhookRequest は Webhook 作成・更新のリクエスト
+type WebhookRequest struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"` // 省略時・"*" はすべてのイベント
+	Secret      string   `json:"secret"` // 作成時に省略すると生成する。更新時に省略すると変更しない
+	Description string   `json:"description"`
+	Enabled     *bool    `json:"enabled"` // 省略時は true
+}
+
+// Webhook は Webhook のレスポンス
+type Webhook struct {
+	ID          int64     `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Secret      string    `json:"secret,omitempty"` // 作成時だけ返す
+	Enabled     bool      `json:"enabled"`
+	Description *string   `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery は配信のレスポンス
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	Event          string     `json:"event"`
+	Status         string     `json:"status"` // pending, delivered, failed
+	Attempts       int64      `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"` // 送信待ち・再試行待ちの場合
+	ResponseStatus *int64     `json:"response_status,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	Payload        string     `json:"payload"`
+}
+
+// List は Webhook の一覧を取得
+// GET /api/webhooks
+func (h *WebhookHandler) List(c echo.Context) error {
+	items, err := h.webhookRepo.List(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	result := make([]Webhook, len(items))
+	for idx, item := range items {
+		result[idx] = webhookOf(item)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// Create は Webhook を作成（署名の鍵はこのレスポンスでだけ返す）
+// POST /api/webhooks
+func (h *WebhookHandler) Create(c echo.Context) error {
+	req, events, err := bindWebhookRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = webhooks.NewSecret(); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+	webhook := &sqlc.Webhook{Secret: secret}
+	req.apply(webhook, events)
+	if err := h.webhookRepo.Create(c.Request().Context(), webhook); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	resp := webhookOf(*webhook)
+	resp.Secret = webhook.Secret
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// Get は Webhook を取得
+// GET /api/webhooks/:id
+func (h *WebhookHandler) Get(c echo.Context) error {
+	webhook, err := h.getWebhook(c)
+	if err != nil || webhook == nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, webhookOf(*webhook))
+}
+
+// Update は Webhook を更新
+// PUT /api/webhooks/:id
+func (h *WebhookHandler) Update(c echo.Context) error {
+	webhook, err := h.getWebhook(c)
+	if err != nil || webhook == nil {
+		return err
+	}
+	req, events, err := bindWebhookRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if req.Secret != "" {
+		webhook.Secret = req.Secret
+	}
+	req.apply(webhook, events)
+	if _, err := h.webhookRepo.Update(c.Request().Context(), webhook); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, webhookOf(*webhook))
+}
+
+// Delete は Webhook と配信の記録を削除
+// DELETE /api/webhooks/:id
+func (h *WebhookHandler) Delete(c echo.Context) error {
+	webhook, err := h.getWebhook(c)
+	if err != nil || webhook == nil {
+		return err
+	}
+	if _, err := h.webhookRepo.Delete(c.Request().Context(), webhook.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Deliveries は Webhook の配信を新しい順に取得（?limit=N、デフォルト: 50）
+// GET /api/webhooks/:id/deliveries
+func (h *WebhookHandler) Deliveries(c echo.Context) error {
+	webhook, err := h.getWebhook(c)
+	if err != nil || webhook == nil {
+		return err
+	}
+	limit := 50
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+		}
+		limit = min(n, maxWebhookDeliveries)
+	}
+
+	deliveries, err := h.webhookRepo.ListDeliveries(c.Request().Context(), webhook.ID, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	result := make([]WebhookDelivery, len(deliveries))
+	for idx, delivery := range deliveries {
+		result[idx] = webhookDeliveryOf(delivery, isAdmin(c))
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// Ping は接続の確認のイベント（ping）を送る。結果は配信の一覧で確認する
+// POST /api/webhooks/:id/ping
+func (h *WebhookHandler) Ping(c echo.Context) error {
+	webhook, err := h.getWebhook(c)
+	if err != nil || webhook == nil {
+		return err
+	}
+	id, err := h.dispatcher.Ping(c.Request().Context(), webhook)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusAccepted, map[string]any{
+		"delivery_id": id,
+		"message":     "Ping queued",
+	})
+}
+
+// Redeliver は配信をすぐに送り直す（失敗した配信も再試行する）
+// POST /api/webhooks/:id/deliveries/:delivery_id/redeliver
+func (h *WebhookHandler) Redeliver(c echo.Context) error {
+	webhook, err := h.getWebhook(c)
+	if err != nil || webhook == nil {
+		return err
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid delivery id"})
+	}
+
+	ok, err := h.dispatcher.Redeliver(c.Request().Context(), webhook.ID, deliveryID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "delivery not found"})
+	}
+	return c.JSON(http.StatusAccepted, map[string]any{
+		"delivery_id": deliveryID,
+		"message":     "Redelivery queued",
+	})
+}
+
+// getWebhook は :id の Webhook を取得。
+// 見つからなければエラーレスポンスを書き込んで nil を返す
+func (h *WebhookHandler) getWebhook(c echo.Context) (*sqlc.Webhook, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	webhook, err := h.webhookRepo.Get(c.Request().Context(), id)
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if webhook == nil {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{"error": "webhook not found"})
+	}
+	return webhook, nil
+}
+
+// bindWebhookRequest はリクエストを検証し、保存する形の購読するイベントを返す
+func bindWebhookRequest(c echo.Context) (*WebhookRequest, string, error) {
+	var req WebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, "", errors.New("invalid request body")
+	}
+	req.URL = strings.TrimSpace(req.URL)
+	req.Description = strings.TrimSpace(req.Description)
+	if err := webhooks.CheckURL(c.Request().Context(), req.URL); err != nil {
+		return nil, "", err
+	}
+	events, err := webhooks.NormalizeEvents(req.Events)
+	if err != nil {
+		return nil, "", err
+	}
+	return &req, events, nil
+}
+
+// apply はリクエストの内容を Webhook に設定（鍵は呼び出し側で設定）
+func (req *WebhookRequest) apply(webhook *sqlc.Webhook, events string) {
+	webhook.Url = req.URL
+	webhook.Events = events
+	webhook.Enabled = boolInt(req.Enabled == nil || *req.Enabled)
+	webhook.Description = nil
+	if req.Description != "" {
+		webhook.Description = storage.Ptr(req.Description)
+	}
+}
+
+// webhookOf は保存された Webhook をレスポンスに変換（鍵は含めない）
+func webhookOf(webhook sqlc.Webhook) Webhook {
+	return Webhook{
+		ID:          webhook.ID,
+		URL:         webhook.Url,
+		Events:      webhooks.SplitEvents(webhook.Events),
+		Enabled:     webhook.Enabled != 0,
+		Description: webhook.Description,
+		CreatedAt:   webhook.CreatedAt,
+		UpdatedAt:   webhook.UpdatedAt,
+	}
+}
+
+// webhookDeliveryOf は保存された配信をレスポンスに変換
+// 応答の本文は送信先の内部の情報を含むことがあるため、full でなければエラーは応答のステータスだけにする
+func webhookDeliveryOf(delivery sqlc.WebhookDelivery, full bool) WebhookDelivery {
+	resp := WebhookDelivery{
+		ID:             delivery.ID,
+		Event:          delivery.Event,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+		Payload:        delivery.Payload,
+	}
+	if delivery.Status == storage.WebhookDeliveryPending {
+		resp.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	if !full && delivery.LastError != nil && delivery.ResponseStatus != nil {
+		resp.LastError = storage.Ptr(fmt.Sprintf("HTTP %d", *delivery.ResponseStatus))
+	}
+	return resp
+}
@@ -2,17 +2,24 @@ package ingestion
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"zbor/internal/asr"
+	"zbor/internal/logging"
+	"zbor/internal/metrics"
 	"zbor/internal/storage"
 	"zbor/internal/storage/sqlc"
+	"zbor/internal/version"
+	"zbor/internal/youtube"
 
 	"github.com/google/uuid"
 )
@@ -23,8 +30,27 @@ type AudioIngester struct {
 	artifactRepo      *storage.ArtifactRepository
 	articleRepo       *storage.ArticleRepository
 	jobRepo           *storage.JobRepository
+	checksumRepo      *storage.ChecksumRepository
+	transcriptRepo    *storage.TranscriptRepository
 	asrConfig         *asr.Config
 	senseVoiceConfig  *asr.SenseVoiceConfig
+	whisperConfig     *asr.WhisperConfig
+	enhanceConfig     *asr.EnhanceConfig
+	langIDConfig      *asr.LangIDConfig // nil: language routing disabled
+	youtubeClient     *youtube.Client
+	itn               *asr.ITN
+	itnModels         map[string]bool
+	wordModels        map[string]bool
+	threadTuner       *asr.ThreadTuner
+	blobRepo          *storage.BlobRepository
+	blobStore         storage.Blob // nil: files stay on the local disk only
+	usageRepo         *storage.UsageRepository
+	wavCache          *asr.ConversionCache // nil: waveforms are computed on every use
+	chapterOptions    asr.ChapterOptions
+	segmentation      asr.SegmentationOptions // applied to new transcripts and resegment jobs
+	replacementRepo   *storage.ReplacementRepository // nil: no replacement dictionary
+	flushBlocks       int                    // partial blocks stored at once
+	models            map[string]ModelStatus // nil until DetectModels
 	dataDir           string
 }
 
@@ -34,6 +60,8 @@ func NewAudioIngester(
 	artifactRepo *storage.ArtifactRepository,
 	articleRepo *storage.ArticleRepository,
 	jobRepo *storage.JobRepository,
+	checksumRepo *storage.ChecksumRepository,
+	transcriptRepo *storage.TranscriptRepository,
 	asrConfig *asr.Config,
 	dataDir string,
 ) *AudioIngester {
@@ -45,12 +73,29 @@ func NewAudioIngester(
 		artifactRepo:      artifactRepo,
 		articleRepo:       articleRepo,
 		jobRepo:           jobRepo,
+		checksumRepo:      checksumRepo,
+		transcriptRepo:    transcriptRepo,
 		asrConfig:         asrConfig,
 		senseVoiceConfig:  asr.DefaultSenseVoiceConfig(senseVoiceModelDir),
+		whisperConfig:     asr.DefaultWhisperConfig(WhisperModelDir),
+		enhanceConfig:     asr.DefaultEnhanceConfig(asr.DefaultEnhanceModelPath),
+		langIDConfig:      asr.DefaultLangIDConfig(asr.DefaultLangIDModelDir),
+		youtubeClient:     youtube.NewClient(),
+		chapterOptions:    asr.DefaultChapterOptions(),
 		dataDir:           dataDir,
 	}
 }
 
+// WhisperModelDir is the Whisper model path (relative to project root)
+const WhisperModelDir = "models/sherpa-onnx-whisper-turbo"
+
+// senseVoiceChunkSec is the chunk length of full-file SenseVoice transcription
+const senseVoiceChunkSec = 20
+
+// whisperChunkSec is the chunk length of full-file Whisper transcription
+// (Whisper's native window); whisper:align aligns text window by window
+const whisperChunkSec = 30
+
 // AudioFile represents an uploaded audio file
 type AudioFile struct {
 	Filename string
@@ -60,15 +105,28 @@ type AudioFile struct {
 
 // IngestOptions contains options for audio ingestion
 type IngestOptions struct {
-	Title    string       // optional title for the article
-	Files    []AudioFile  // audio files to process
-	Priority int          // job priority (0-9, lower is higher priority)
+	Title    string           // optional title for the article
+	Files    []AudioFile      // audio files to process
+	Priority int              // job priority (0-9, lower is higher priority)
+	Trim     *asr.TrimOptions // optional trim applied to every file before processing
+	Enhance  bool             // denoise the audio before ASR (see SetEnhanceModel)
+	TwoPass  bool             // transcribe with ReazonSpeech, then refine doubtful segments with Whisper
+	Force    bool             // transcribe even when the same audio was already uploaded
+
+	Transcription *TranscriptionOptions // per-job transcription settings (optional)
 }
 
 // IngestResult contains the result of audio ingestion
 type IngestResult struct {
-	SourceID string
-	JobID    string
+	SourceID    string
+	JobID       string
+	RefineJobID string // second pass of TwoPass
+
+	// Duplicate is set when the same audio was already uploaded: SourceID is
+	// the existing source, JobID its latest transcription job and Status its
+	// status. Nothing new is stored or queued
+	Duplicate bool
+	Status    string
 }
 
 // ProgressCallback is called to report progress during processing
@@ -80,6 +138,41 @@ func (i *AudioIngester) Ingest(ctx context.Context, opts IngestOptions) (*Ingest
 	if len(opts.Files) == 0 {
 		return nil, fmt.Errorf("no audio files provided")
 	}
+	if err := opts.Trim.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid trim options: %w", err)
+	}
+	if err := opts.Transcription.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid transcription options: %w", err)
+	}
+	// The first pass of two-pass transcription is always ReazonSpeech
+	model := ""
+	if !opts.Transcription.IsZero() {
+		model = opts.Transcription.Model
+	}
+	if opts.TwoPass && model != "" && model != storage.ASRModelReazonSpeech {
+		return nil, fmt.Errorf("invalid transcription options: two-pass transcription uses %s", storage.ASRModelReazonSpeech)
+	}
+	if model != "" {
+		if err := i.CheckModel(model); err != nil {
+			return nil, err
+		}
+	}
+	if i.DefaultModel() == "" {
+		return nil, fmt.Errorf("%w: no ASR model is installed", ErrModelUnavailable)
+	}
+	if opts.Enhance {
+		if err := i.CheckEnhancement(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.TwoPass {
+		if err := i.CheckTwoPass(); err != nil {
+			return nil, err
+		}
+	}
+	if err := i.CheckQuota(ctx, ModelForJobType(TranscriptionJobType(model))); err != nil {
+		return nil, err
+	}
 
 	// Generate source ID
 	sourceID := uuid.New().String()
@@ -90,9 +183,9 @@ func (i *AudioIngester) Ingest(ctx context.Context, opts IngestOptions) (*Ingest
 		return nil, fmt.Errorf("failed to create source directory: %w", err)
 	}
 
-	// Save uploaded files
-	var filePaths []string
-	var speakers []string
+	// Save uploaded files, hashing them on the way for duplicate detection
+	var savedPaths []string
+	var fileHashes []string
 	for _, file := range opts.Files {
 		if !asr.IsSupportedFormat(file.Filename) {
 			return nil, fmt.Errorf("unsupported audio format: %s", file.Filename)
@@ -104,11 +197,47 @@ func (i *AudioIngester) Ingest(ctx context.Context, opts IngestOptions) (*Ingest
 			return nil, fmt.Errorf("failed to create file: %w", err)
 		}
 
-		_, err = io.Copy(dest, file.Reader)
+		hash := sha256.New()
+		_, err = io.Copy(io.MultiWriter(dest, hash), file.Reader)
 		dest.Close()
 		if err != nil {
 			return nil, fmt.Errorf("failed to save file: %w", err)
 		}
+		savedPaths = append(savedPaths, destPath)
+		fileHashes = append(fileHashes, hex.EncodeToString(hash.Sum(nil)))
+	}
+
+	// The same recording uploaded again: return the existing source instead
+	// of transcribing it twice
+	hash := contentHash(fileHashes, opts.Trim)
+	if !opts.Force {
+		duplicate, err := i.findDuplicate(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if duplicate != nil {
+			os.RemoveAll(sourceDir)
+			logging.FromContext(ctx).Info("Duplicate upload", "source_id", duplicate.SourceID, "content_hash", hash)
+			return duplicate, nil
+		}
+	}
+
+	var filePaths []string
+	var originalPaths []string
+	var speakers []string
+	for idx, file := range opts.Files {
+		destPath := savedPaths[idx]
+
+		// Apply trim once at ingestion so transcription, waveform, and playback
+		// all work from the same trimmed audio
+		if !opts.Trim.IsZero() {
+			trimmedPath := asr.TrimmedWavPath(destPath)
+			if err := asr.ConvertToWavTrimmed(ctx, destPath, trimmedPath, opts.Trim); err != nil {
+				return nil, fmt.Errorf("failed to trim %s: %w", file.Filename, err)
+			}
+			originalPaths = append(originalPaths, destPath)
+			destPath = trimmedPath
+		}
 
 		filePaths = append(filePaths, destPath)
 
@@ -122,16 +251,27 @@ func (i *AudioIngester) Ingest(ctx context.Context, opts IngestOptions) (*Ingest
 
 	// Create metadata
 	metadata := map[string]interface{}{
-		"files":    filePaths,
-		"speakers": speakers,
-		"title":    opts.Title,
+		"files":        filePaths,
+		"speakers":     speakers,
+		"title":        opts.Title,
+		"content_hash": hash,
+	}
+	if !opts.Trim.IsZero() {
+		metadata["trim"] = opts.Trim
+		metadata["original_files"] = originalPaths
+	}
+	if opts.Enhance {
+		metadata["enhance"] = true
+	}
+	if opts.TwoPass {
+		metadata["pipeline"] = PipelineTwoPass
 	}
 	metadataJSON, _ := json.Marshal(metadata)
 
 	// Create source record
 	source := &sqlc.Source{
 		ID:       sourceID,
-		Type:     "audio",
+		Type:     storage.SourceTypeAudio,
 		FilePath: storage.Ptr(sourceDir),
 		Metadata: storage.Ptr(string(metadataJSON)),
 		Status:   storage.Ptr(storage.SourceStatusPending),
@@ -139,27 +279,121 @@ func (i *AudioIngester) Ingest(ctx context.Context, opts IngestOptions) (*Ingest
 	if err := i.sourceRepo.Create(ctx, source); err != nil {
 		return nil, fmt.Errorf("failed to create source: %w", err)
 	}
+	i.recordFiles(ctx, sourceID, append(filePaths, originalPaths...))
 
 	// Create job for processing
 	job := &sqlc.ProcessingJob{
 		SourceID: &sourceID,
 		Type:     storage.JobTypeTranscribe,
 		Priority: storage.Ptr(int64(opts.Priority)),
+		Payload:  opts.Transcription.payload(),
+	}
+	if model != "" {
+		job.Type = TranscriptionJobType(model)
+	}
+	if opts.TwoPass {
+		// The first pass is always ReazonSpeech (no language routing)
+		job.Type = storage.JobTypeTranscribeReazonSpeech
 	}
 	if err := i.jobRepo.Create(ctx, job); err != nil {
 		return nil, fmt.Errorf("failed to create job: %w", err)
 	}
-
-	return &IngestResult{
+	result := &IngestResult{
 		SourceID: sourceID,
 		JobID:    job.ID,
-	}, nil
+	}
+
+	if opts.TwoPass {
+		refine := &sqlc.ProcessingJob{
+			SourceID: &sourceID,
+			Type:     storage.JobTypeRefine,
+			Priority: storage.Ptr(int64(opts.Priority)),
+		}
+		if err := i.jobRepo.CreateAfter(ctx, refine, job.ID); err != nil {
+			return nil, fmt.Errorf("failed to create refine job: %w", err)
+		}
+		result.RefineJobID = refine.ID
+	}
+
+	return result, nil
+}
+
+// contentHash identifies an upload for duplicate detection: the SHA-256 of a
+// single untrimmed file (the same as its checksum and blob), otherwise the
+// SHA-256 of the file hashes in order and the trim options
+func contentHash(fileHashes []string, trim *asr.TrimOptions) string {
+	if len(fileHashes) == 1 && trim.IsZero() {
+		return fileHashes[0]
+	}
+	h := sha256.New()
+	for _, sum := range fileHashes {
+		fmt.Fprintln(h, sum)
+	}
+	if !trim.IsZero() {
+		trimJSON, _ := json.Marshal(trim)
+		h.Write(trimJSON)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// findDuplicate returns the existing source uploaded with the same content
+// hash and its latest transcription job, or nil when there is none
+func (i *AudioIngester) findDuplicate(ctx context.Context, hash string) (*IngestResult, error) {
+	existing, err := i.sourceRepo.GetByContentHash(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up duplicate: %w", err)
+	}
+	if existing == nil {
+		return nil, nil
+	}
+	result := &IngestResult{SourceID: existing.ID, Duplicate: true}
+	if existing.Status != nil {
+		result.Status = *existing.Status
+	}
+	jobs, err := i.jobRepo.GetBySourceID(ctx, existing.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
+	}
+	// Newest first
+	for _, job := range jobs {
+		if job.Type == storage.JobTypeTranscribe || strings.HasPrefix(job.Type, storage.JobTypeTranscribe+":") {
+			result.JobID = job.ID
+			break
+		}
+	}
+	return result, nil
+}
+
+// recordFiles records the SHA-256 of stored audio files for integrity audits
+// and, when blob storage is enabled, replaces duplicates with links to a shared blob
+// and copies them to the object storage (SetBlobStore)
+// Failures are only logged; the audit records checksums that are still missing
+// and a file that could not be deduplicated is simply kept as a separate copy
+func (i *AudioIngester) recordFiles(ctx context.Context, sourceID string, paths []string) {
+	for _, path := range paths {
+		if err := i.checksumRepo.RecordFile(ctx, sourceID, path); err != nil {
+			logging.FromContext(ctx).Warn("Failed to record checksum", "path", path, "error", err)
+		}
+		if i.blobRepo != nil {
+			if err := i.blobRepo.Store(ctx, sourceID, path); err != nil {
+				logging.FromContext(ctx).Warn("Failed to deduplicate", "path", path, "error", err)
+			}
+		}
+	}
+	if i.blobStore != nil {
+		i.storeBlobs(ctx, sourceID, paths)
+	}
 }
 
 // CreateTranscriptionJob creates a new transcription job for an existing source
 // Used for retranscription (re-processing an existing source)
 // model: "reazonspeech" (default), "sensevoice"
-func (i *AudioIngester) CreateTranscriptionJob(ctx context.Context, sourceID string, priority int, model string) (string, error) {
+// opts (optional) are per-job transcription settings; their model is ignored
+func (i *AudioIngester) CreateTranscriptionJob(ctx context.Context, sourceID string, priority int, model string, opts *TranscriptionOptions) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", fmt.Errorf("invalid transcription options: %w", err)
+	}
+
 	// Verify source exists
 	source, err := i.sourceRepo.GetByID(ctx, sourceID)
 	if err != nil {
@@ -168,29 +402,26 @@ func (i *AudioIngester) CreateTranscriptionJob(ctx context.Context, sourceID str
 	if source == nil {
 		return "", fmt.Errorf("source not found: %s", sourceID)
 	}
+	if err := i.CheckQuota(ctx, ModelForJobType(TranscriptionJobType(model))); err != nil {
+		return "", err
+	}
 
 	// Reset source status
 	if err := i.sourceRepo.UpdateStatus(ctx, sourceID, storage.SourceStatusPending); err != nil {
 		return "", fmt.Errorf("failed to update source status: %w", err)
 	}
 
-	// Determine job type based on model
-	jobType := storage.JobTypeTranscribe // default
-	switch model {
-	case storage.ASRModelSenseVoice:
-		jobType = storage.JobTypeTranscribeSenseVoice
-	case storage.ASRModelSenseVoiceBeam:
-		jobType = storage.JobTypeTranscribeSenseVoiceBeam
-	case storage.ASRModelReazonSpeech:
-		jobType = storage.JobTypeTranscribeReazonSpeech
-	}
-
 	// Create job for processing
 	job := &sqlc.ProcessingJob{
 		SourceID: &sourceID,
-		Type:     jobType,
+		Type:     TranscriptionJobType(model),
 		Priority: storage.Ptr(int64(priority)),
 	}
+	if !opts.IsZero() {
+		jobOpts := *opts
+		jobOpts.Model = model
+		job.Payload = jobOpts.payload()
+	}
 	if err := i.jobRepo.Create(ctx, job); err != nil {
 		return "", fmt.Errorf("failed to create job: %w", err)
 	}
@@ -198,13 +429,22 @@ func (i *AudioIngester) CreateTranscriptionJob(ctx context.Context, sourceID str
 	return job.ID, nil
 }
 
+// sourceMetadata is the part of the source metadata used for transcription
+type sourceMetadata struct {
+	Files    []string `json:"files"`
+	Speakers []string `json:"speakers"`
+	Title    string   `json:"title"`
+	Language string   `json:"language"`
+	Enhance  bool     `json:"enhance"` // denoise before ASR
+
+	DetectedLanguage string `json:"detected_language"` // spoken language (see routeLanguage)
+
+	PublishedAt *time.Time `json:"published_at"` // podcast episode publish date
+}
+
 // ProcessTranscription processes a transcription job
 // This is called by the worker when processing the job
 func (i *AudioIngester) ProcessTranscription(ctx context.Context, job *sqlc.ProcessingJob, onProgress ProgressCallback) error {
-	if job.SourceID == nil {
-		return fmt.Errorf("job has no source ID")
-	}
-
 	// Helper to report progress (nil-safe)
 	reportProgress := func(progress int, step string) {
 		if onProgress != nil {
@@ -212,195 +452,836 @@ func (i *AudioIngester) ProcessTranscription(ctx context.Context, job *sqlc.Proc
 		}
 	}
 
+	// Per-job settings are checked before the audio is downloaded
+	opts, err := JobTranscriptionOptions(job)
+	if err != nil {
+		return err
+	}
+
+	source, metadata, err := i.prepareSource(ctx, job, reportProgress)
+	if err != nil {
+		return err
+	}
+
+	// Jobs without a requested model run with the model for the spoken language
+	job, language := i.routeLanguage(ctx, job, source, metadata, reportProgress)
+
+	reportProgress(10, "initializing")
+
+	// Run with the closest installed model if the job's model is missing
+	if model, fallback := ModelForJobType(job.Type), i.fallbackModel(ModelForJobType(job.Type)); fallback != "" && fallback != model {
+		logging.FromContext(ctx).Warn("Model is not available, transcribing with a fallback model", "model", model, "fallback", fallback, "reason", i.modelStatus(model).Reason)
+		fallbackJob := *job
+		fallbackJob.Type = TranscriptionJobType(fallback)
+		job = &fallbackJob
+	}
+
+	// Resume from the files and blocks an interrupted attempt finished
+	checkpoints, err := LoadJobCheckpoints(ctx, i.jobRepo, job, i.flushBlocks)
+	if err != nil {
+		return err
+	}
+
+	// Denoise the audio for ASR; without it the original audio is transcribed
+	files := metadata.Files
+	var artifactMetadata *string
+	if metadata.Enhance {
+		enhanced, info, cleanup, err := i.enhanceFiles(ctx, metadata.Files, checkpoints, reportProgress)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Speech enhancement failed, transcribing the original audio", "error", err)
+		} else {
+			defer cleanup()
+			files = enhanced
+			infoJSON, _ := json.Marshal(map[string]*EnhancementInfo{"enhancement": info})
+			artifactMetadata = storage.Ptr(string(infoJSON))
+		}
+	}
+
+	// Run ASR; YouTube sources fall back to the video's captions on failure
+	var finalResult *asr.Result
+	allResults, err := i.transcribeFiles(ctx, job, opts, language, files, metadata.Speakers, checkpoints, reportProgress)
+	if err != nil {
+		if source.Type != storage.SourceTypeYouTube || source.OriginalUrl == nil {
+			return err
+		}
+		reportProgress(80, "fetching captions")
+		fallback, info, ferr := i.captionFallback(*source.OriginalUrl, metadata.Language, err)
+		if ferr != nil {
+			return fmt.Errorf("%w (caption fallback failed: %v)", err, ferr)
+		}
+		finalResult = fallback
+		infoJSON, _ := json.Marshal(info)
+		artifactMetadata = storage.Ptr(string(infoJSON))
+	}
+
+	reportProgress(90, "saving")
+
+	// Merge results if multiple files
+	if finalResult == nil {
+		if len(allResults) == 1 {
+			finalResult = allResults[0]
+		} else {
+			finalResult = mergeResults(allResults)
+		}
+	}
+	finalResult.Language = language
+
+	if err := i.saveTranscription(ctx, job, source, metadata, finalResult, artifactMetadata); err != nil {
+		return err
+	}
+	if err := checkpoints.Clear(ctx); err != nil {
+		logging.FromContext(ctx).Warn("Failed to clear transcription checkpoints", "error", err)
+	}
+	// Caption fallbacks ran no ASR and are not counted
+	if allResults != nil {
+		i.recordUsage(ctx, job, allResults)
+	}
+
+	reportProgress(100, "")
+
+	return nil
+}
+
+// prepareSource loads the job's source and gets its audio ready for ASR:
+// YouTube/podcast/URL download, recording diagnostics, and the preview proxy
+func (i *AudioIngester) prepareSource(ctx context.Context, job *sqlc.ProcessingJob, reportProgress ProgressCallback) (*sqlc.Source, *sourceMetadata, error) {
+	if job.SourceID == nil {
+		return nil, nil, fmt.Errorf("job has no source ID")
+	}
+
 	reportProgress(5, "preparing")
 
 	// Get source
 	source, err := i.sourceRepo.GetByID(ctx, *job.SourceID)
 	if err != nil {
-		return fmt.Errorf("failed to get source: %w", err)
+		return nil, nil, fmt.Errorf("failed to get source: %w", err)
 	}
 	if source == nil {
-		return fmt.Errorf("source not found: %s", *job.SourceID)
+		return nil, nil, fmt.Errorf("source not found: %s", *job.SourceID)
 	}
 
 	// Update source status
 	if err := i.sourceRepo.UpdateStatus(ctx, source.ID, storage.SourceStatusProcessing); err != nil {
-		return fmt.Errorf("failed to update source status: %w", err)
+		return nil, nil, fmt.Errorf("failed to update source status: %w", err)
 	}
 
-	// Parse metadata
-	var metadata struct {
-		Files    []string `json:"files"`
-		Speakers []string `json:"speakers"`
-		Title    string   `json:"title"`
+	// YouTube, podcast and audio URL sources are downloaded by the job, not at ingestion
+	switch source.Type {
+	case storage.SourceTypeAudio:
+		if source.OriginalUrl != nil {
+			reportProgress(6, "downloading")
+			if err := i.downloadAudioURL(ctx, source, reportProgress); err != nil {
+				return nil, nil, err
+			}
+		}
+	case storage.SourceTypeYouTube:
+		reportProgress(6, "downloading")
+		if err := i.downloadYouTubeAudio(ctx, source); err != nil {
+			return nil, nil, err
+		}
+	case storage.SourceTypePodcast:
+		reportProgress(6, "downloading")
+		if err := i.downloadPodcastAudio(ctx, source); err != nil {
+			return nil, nil, err
+		}
 	}
+
+	// Parse metadata
+	var metadata sourceMetadata
 	if source.Metadata != nil {
 		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
-			return fmt.Errorf("failed to parse metadata: %w", err)
+			return nil, nil, fmt.Errorf("failed to parse metadata: %w", err)
 		}
 	}
 
-	reportProgress(10, "initializing")
+	// Files ingested by another node come from the blob store
+	if err := i.FetchFiles(ctx, source); err != nil {
+		return nil, nil, err
+	}
+
+	reportProgress(8, "analyzing")
+
+	// Recording quality diagnostics are informational; failures don't block transcription
+	if err := i.analyzeSource(ctx, source); err != nil {
+		logging.FromContext(ctx).Warn("Audio diagnostics failed", "source_id", source.ID, "error", err)
+	}
+
+	reportProgress(9, "encoding preview")
+
+	// The preview proxy only speeds up playback; the stream endpoint falls back to full quality
+	if err := generateProxies(ctx, metadata.Files); err != nil {
+		logging.FromContext(ctx).Warn("Preview proxy failed", "source_id", source.ID, "error", err)
+	}
+
+	return source, &metadata, nil
+}
+
+// saveTranscription post-processes the final result and stores it as the
+// source's transcription artifact and article
+func (i *AudioIngester) saveTranscription(ctx context.Context, job *sqlc.ProcessingJob, source *sqlc.Source, metadata *sourceMetadata, finalResult *asr.Result, artifactMetadata *string) error {
+	// Strip model special tokens, then ITN (numbers, dates, times; Japanese only)
+	PostProcess(ModelForJobType(job.Type), finalResult)
+	language := "ja"
+	var itn *bool
+	if finalResult.Language != "" && finalResult.Language != "ja" {
+		language = finalResult.Language
+		itn = storage.Ptr(false)
+	}
+	i.ApplyITN(ModelForJobType(job.Type), itn, finalResult)
+	// User replacement dictionary runs after ITN so rules see normalized text
+	if err := i.ApplyDictionary(ctx, source.ID, finalResult); err != nil {
+		logging.FromContext(ctx).Warn("Replacement dictionary failed", "source_id", source.ID, "error", err)
+	}
+	// Configured segmentation rules replace the default token-gap segments
+	if !i.segmentation.IsZero() {
+		finalResult.Resegment(i.segmentation)
+	}
+	i.GroupWords(ModelForJobType(job.Type), finalResult)
+
+	if finalResult.Metrics != nil && finalResult.Metrics.AudioSeconds > 0 {
+		metrics.TranscriptionRTF.Observe(finalResult.Metrics.RTF, ModelForJobType(job.Type))
+	}
+
+	// Save transcription artifact; an earlier transcription is kept as a version
+	if err := i.supersedeTranscripts(ctx, source.ID); err != nil {
+		return err
+	}
+	artifactContent, _ := json.Marshal(finalResult)
+	artifact := &sqlc.ProcessingArtifact{
+		SourceID: &source.ID,
+		Type:     storage.ArtifactTypeTranscription,
+		Content:  storage.Ptr(string(artifactContent)),
+		Format:   storage.Ptr("json"),
+		Metadata: transcriptionMetadata(job, finalResult, artifactMetadata),
+	}
+	if err := i.artifactRepo.Create(ctx, artifact); err != nil {
+		return fmt.Errorf("failed to save artifact: %w", err)
+	}
+
+	// Condensed view for skimming long recordings
+	if err := i.SaveCondensed(ctx, source.ID, finalResult); err != nil {
+		return fmt.Errorf("failed to save condensed view: %w", err)
+	}
+
+	// Segment index for transcript search
+	if err := i.IndexTranscript(ctx, source.ID, finalResult); err != nil {
+		return fmt.Errorf("failed to index transcript: %w", err)
+	}
+
+	// Generate article
+	if err := i.createTranscriptArticle(ctx, source, metadata, finalResult, language); err != nil {
+		return err
+	}
+
+	// Update source status to completed
+	if err := i.sourceRepo.UpdateStatus(ctx, source.ID, storage.SourceStatusCompleted); err != nil {
+		return fmt.Errorf("failed to update source status: %w", err)
+	}
+
+	return nil
+}
+
+// createTranscriptArticle creates the article of a source's transcription
+func (i *AudioIngester) createTranscriptArticle(ctx context.Context, source *sqlc.Source, metadata *sourceMetadata, result *asr.Result, language string) error {
+	title := metadata.Title
+	if title == "" {
+		title = fmt.Sprintf("Meeting %s", time.Now().Format("2006-01-02"))
+	}
+
+	article := &sqlc.Article{
+		Title:       title,
+		Content:     result.FormatAsText(),
+		SourceType:  storage.Ptr(source.Type),
+		SourceUrl:   source.OriginalUrl,
+		SourceID:    &source.ID,
+		PublishedAt: metadata.PublishedAt,
+		Language:    storage.Ptr(language),
+		Sections:    sectionsJSON(ChapterSections(result, i.chapterOptions)),
+	}
+	if err := i.articleRepo.Create(ctx, article); err != nil {
+		return fmt.Errorf("failed to create article: %w", err)
+	}
+	return nil
+}
+
+// SetITN enables post-processing inverse text normalization for the given models
+// (storage.ASRModel* values). Passing a nil normalizer disables ITN.
+func (i *AudioIngester) SetITN(itn *asr.ITN, models []string) {
+	i.itn = itn
+	i.itnModels = make(map[string]bool, len(models))
+	for _, m := range models {
+		i.itnModels[m] = true
+	}
+}
+
+// SetWordGrouping enables grouping tokens into words (Result.Words) for the
+// given models (storage.ASRModel* values). An empty list disables grouping.
+func (i *AudioIngester) SetWordGrouping(models []string) {
+	i.wordModels = make(map[string]bool, len(models))
+	for _, m := range models {
+		i.wordModels[m] = true
+	}
+}
+
+// SetThreadTuner sets the tuner that picks recognizer thread counts. Without
+// one, recognizers without an explicit NumThreads use asr.DefaultNumThreads.
+func (i *AudioIngester) SetThreadTuner(tuner *asr.ThreadTuner) {
+	i.threadTuner = tuner
+}
+
+// SetProvider sets the ONNX execution provider (asr.Provider*) of every model
+func (i *AudioIngester) SetProvider(provider string) {
+	i.asrConfig.Provider = provider
+	i.senseVoiceConfig.Provider = provider
+	i.whisperConfig.Provider = provider
+	i.enhanceConfig.Provider = provider
+	if i.langIDConfig != nil {
+		i.langIDConfig.Provider = provider
+	}
+}
+
+// SetSenseVoiceBeamPaths sets the number of hypotheses decoded per chunk by
+// sensevoice:beam jobs (0 keeps the default)
+func (i *AudioIngester) SetSenseVoiceBeamPaths(paths int) {
+	if paths > 0 {
+		i.senseVoiceConfig.MaxActivePaths = paths
+	}
+}
+
+// SetRescorer sets the language model rescorer of reazonspeech:lm and
+// sensevoice:lm jobs (paths: hypotheses per ReazonSpeech block, 0 keeps the default)
+func (i *AudioIngester) SetRescorer(rescorer *asr.Rescorer, paths int) {
+	i.asrConfig.Rescorer = rescorer
+	i.asrConfig.RescorePaths = paths
+	i.senseVoiceConfig.Rescorer = rescorer
+}
+
+// RescorerEnabled reports whether a language model is set for the :lm models
+func (i *AudioIngester) RescorerEnabled() bool {
+	return i.asrConfig.Rescorer != nil
+}
+
+// SetYouTubeCacheTTL sets how long YouTube video info and captions are
+// cached (0 disables the cache)
+func (i *AudioIngester) SetYouTubeCacheTTL(ttl time.Duration) {
+	i.youtubeClient.SetCacheTTL(ttl)
+}
+
+// SetPartialFlushBlocks sets how many finished blocks of a long file are
+// stored at once as intermediate results (0 uses DefaultPartialFlushBlocks)
+func (i *AudioIngester) SetPartialFlushBlocks(n int) {
+	i.flushBlocks = n
+}
+
+// SetMaxSectionDuration sets the maximum length in seconds of an article
+// section (chapter). Longer chapters are split into equal parts; 0 disables
+// the limit
+func (i *AudioIngester) SetMaxSectionDuration(seconds float64) {
+	i.chapterOptions.MaxDuration = seconds
+}
+
+// ThreadTuner returns the recognizer thread tuner (nil if not set)
+func (i *AudioIngester) ThreadTuner() *asr.ThreadTuner {
+	return i.threadTuner
+}
+
+// SetSegmentation sets the rules for grouping tokens into segments (subtitle
+// cues) of new transcripts and of resegment jobs without their own rules
+func (i *AudioIngester) SetSegmentation(opts asr.SegmentationOptions) {
+	i.segmentation = opts
+}
+
+// Segmentation returns the configured segmentation rules
+func (i *AudioIngester) Segmentation() asr.SegmentationOptions {
+	return i.segmentation
+}
+
+// SetBlobRepository enables content-addressed storage of ingested audio files so that
+// identical recordings share one copy on disk
+func (i *AudioIngester) SetBlobRepository(repo *storage.BlobRepository) {
+	i.blobRepo = repo
+}
+
+// SetUsageRepository enables usage accounting: completed transcriptions record
+// their audio minutes and new jobs are refused once a monthly quota is reached
+func (i *AudioIngester) SetUsageRepository(repo *storage.UsageRepository) {
+	i.usageRepo = repo
+}
+
+// SetConversionCache sets the cache of the waveform peaks used by boundary adjustment
+func (i *AudioIngester) SetConversionCache(cache *asr.ConversionCache) {
+	i.wavCache = cache
+}
+
+// CheckQuota returns an error wrapping storage.ErrQuotaExceeded if the context's
+// user, API key or the model has used up its monthly transcription quota
+func (i *AudioIngester) CheckQuota(ctx context.Context, model string) error {
+	if i.usageRepo == nil {
+		return nil
+	}
+	return i.usageRepo.CheckQuota(ctx, model)
+}
+
+// recordUsage records the audio minutes of a completed transcription job
+// Failures are only logged; the transcription itself has been saved
+func (i *AudioIngester) recordUsage(ctx context.Context, job *sqlc.ProcessingJob, results []*asr.Result) {
+	if i.usageRepo == nil {
+		return
+	}
+	var seconds float64
+	for _, r := range results {
+		seconds += float64(r.TotalDuration)
+	}
+	if err := i.usageRepo.Record(ctx, job, ModelForJobType(job.Type), seconds); err != nil {
+		logging.FromContext(ctx).Error("Failed to record usage", "error", err)
+	}
+}
+
+// GroupWords fills result.Words if word grouping is enabled for the model.
+// It runs after ITN so that normalized numbers are grouped as they are stored.
+func (i *AudioIngester) GroupWords(model string, result *asr.Result) {
+	if result == nil || !i.wordModels[model] {
+		return
+	}
+	result.GroupWords(asr.DefaultWordGroupingConfig())
+}
+
+// PostProcess strips the special tokens of the model's family (language tags,
+// emotion/event markers, byte tokens) from the result before it is stored
+func PostProcess(model string, result *asr.Result) {
+	asr.PostProcess(ModelFamily(model), result)
+}
+
+// ModelFamily returns the post-processing family of an ASR model
+// (storage.ASRModel* value); unknown or empty models are transducers
+func ModelFamily(model string) asr.ModelFamily {
+	switch model {
+	case storage.ASRModelSenseVoice, storage.ASRModelSenseVoiceBeam, storage.ASRModelSenseVoiceLM:
+		return asr.ModelFamilySenseVoice
+	case storage.ASRModelWhisper, storage.ASRModelWhisperAlign:
+		return asr.ModelFamilyWhisper
+	default:
+		return asr.ModelFamilyTransducer
+	}
+}
+
+// ApplyITN normalizes the result if ITN is enabled for the model
+// enabled overrides the per-model setting when non-nil (per-job control)
+func (i *AudioIngester) ApplyITN(model string, enabled *bool, result *asr.Result) {
+	if i.itn == nil || result == nil {
+		return
+	}
+	apply := i.itnModels[model]
+	if enabled != nil {
+		apply = *enabled
+	}
+	if apply {
+		i.itn.Apply(result)
+	}
+}
+
+// TranscriptionJobType returns the transcription job type for an ASR model
+// (storage.ASRModel* value); unknown or empty models use the default type
+func TranscriptionJobType(model string) string {
+	switch model {
+	case storage.ASRModelSenseVoice:
+		return storage.JobTypeTranscribeSenseVoice
+	case storage.ASRModelSenseVoiceBeam:
+		return storage.JobTypeTranscribeSenseVoiceBeam
+	case storage.ASRModelSenseVoiceLM:
+		return storage.JobTypeTranscribeSenseVoiceLM
+	case storage.ASRModelWhisper:
+		return storage.JobTypeTranscribeWhisper
+	case storage.ASRModelWhisperAlign:
+		return storage.JobTypeTranscribeWhisperAlign
+	case storage.ASRModelReazonSpeech:
+		return storage.JobTypeTranscribeReazonSpeech
+	case storage.ASRModelReazonSpeechLM:
+		return storage.JobTypeTranscribeReazonSpeechLM
+	default:
+		return storage.JobTypeTranscribe
+	}
+}
+
+// ModelForJobType returns the ASR model name used by a transcription job type
+func ModelForJobType(jobType string) string {
+	switch jobType {
+	case storage.JobTypeTranscribeSenseVoice:
+		return storage.ASRModelSenseVoice
+	case storage.JobTypeTranscribeSenseVoiceBeam:
+		return storage.ASRModelSenseVoiceBeam
+	case storage.JobTypeTranscribeSenseVoiceLM:
+		return storage.ASRModelSenseVoiceLM
+	case storage.JobTypeTranscribeReazonSpeechLM:
+		return storage.ASRModelReazonSpeechLM
+	case storage.JobTypeTranscribeWhisper:
+		return storage.ASRModelWhisper
+	case storage.JobTypeTranscribeWhisperAlign:
+		return storage.ASRModelWhisperAlign
+	default:
+		return storage.ASRModelReazonSpeech
+	}
+}
+
+// transcribeFiles runs the ASR model selected by the job type over each file
+// in language (empty: the configured language) with the job's settings (opts, may be nil)
+func (i *AudioIngester) transcribeFiles(ctx context.Context, job *sqlc.ProcessingJob, opts *TranscriptionOptions, language string, files []string, speakers []string, checkpoints *JobCheckpoints, reportProgress ProgressCallback) ([]*asr.Result, error) {
+	senseVoiceConfig, whisperConfig := i.languageConfigs(language)
+	return TranscribeFiles(ctx, i.asrConfig, senseVoiceConfig, whisperConfig, i.threadTuner, job.Type, opts, files, speakers, checkpoints, reportProgress)
+}
+
+// TranscribeFiles runs the ASR model selected by the job type over each file
+// and labels each result with its speaker. It is shared by the local worker
+// and remote agents. Panics from the recognizer are converted to errors so
+// callers can fall back. The thread count of the recognizer is picked by
+// tuner (may be nil) unless set in the config. Progress is logged with the
+// logger of ctx (see logging.WithLogger). Files finished by an earlier
+// attempt are taken from checkpoints (may be nil), and new progress is
+// recorded there. opts (may be nil) overrides the decoding and block settings
+// (see JobTranscriptionOptions)
+func TranscribeFiles(ctx context.Context, asrConfig *asr.Config, senseVoiceConfig *asr.SenseVoiceConfig, whisperConfig *asr.WhisperConfig, tuner *asr.ThreadTuner, jobType string, opts *TranscriptionOptions, files []string, speakers []string, checkpoints *JobCheckpoints, reportProgress ProgressCallback) (allResults []*asr.Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			allResults = nil
+			err = fmt.Errorf("recognizer panic: %v", r)
+		}
+	}()
+
+	if jobType == storage.JobTypeTranscribeWhisper || jobType == storage.JobTypeTranscribeWhisperAlign {
+		return transcribeFilesWhisper(ctx, asrConfig, whisperConfig, tuner, jobType == storage.JobTypeTranscribeWhisperAlign, opts, files, speakers, checkpoints, reportProgress)
+	}
 
 	// Determine which model to use based on job type
-	useSenseVoice := job.Type == storage.JobTypeTranscribeSenseVoice || job.Type == storage.JobTypeTranscribeSenseVoiceBeam
-	useBeamSearch := job.Type == storage.JobTypeTranscribeSenseVoiceBeam
+	useSenseVoice := jobType == storage.JobTypeTranscribeSenseVoice || jobType == storage.JobTypeTranscribeSenseVoiceBeam || jobType == storage.JobTypeTranscribeSenseVoiceLM
+	useBeamSearch := jobType == storage.JobTypeTranscribeSenseVoiceBeam || jobType == storage.JobTypeTranscribeSenseVoiceLM
+	// Ambiguous chunks/blocks are rescored with the language model only by the :lm types
+	useLM := jobType == storage.JobTypeTranscribeSenseVoiceLM || jobType == storage.JobTypeTranscribeReazonSpeechLM
 
 	// Process each file
-	var allResults []*asr.Result
-	fileCount := len(metadata.Files)
+	fileCount := len(files)
 	if fileCount == 0 {
-		return fmt.Errorf("no audio files in source metadata")
+		return nil, fmt.Errorf("no audio files in source metadata")
 	}
 
 	if useSenseVoice {
 		// === SenseVoice Model ===
-		svConfig := *i.senseVoiceConfig // Copy config
+		svConfig := *senseVoiceConfig // Copy config
 		if useBeamSearch {
+			// Hypotheses per chunk come from MaxActivePaths (see SenseVoiceRecognizer.BeamPaths)
 			svConfig.DecodingMethod = "modified_beam_search"
-			svConfig.MaxActivePaths = 4
 		}
+		opts.applySenseVoiceDecoding(&svConfig)
+		if !useLM {
+			svConfig.Rescorer = nil
+		} else if svConfig.Rescorer == nil {
+			return nil, fmt.Errorf("language model is not configured")
+		}
+		release := tuner.TuneSenseVoice(&svConfig)
+		defer release()
 		svRecognizer, err := asr.NewSenseVoiceRecognizer(&svConfig)
 		if err != nil {
-			return fmt.Errorf("failed to create SenseVoice recognizer: %w", err)
+			return nil, fmt.Errorf("failed to create SenseVoice recognizer: %w", err)
 		}
 		defer svRecognizer.Close()
 
-		for idx, filePath := range metadata.Files {
+		for idx, filePath := range files {
+			if result := checkpoints.resumedFile(ctx, idx, filePath, speakers); result != nil {
+				allResults = append(allResults, result)
+				continue
+			}
 			fileProgressStart := 30 + (60 * idx / fileCount)
 			fileProgressEnd := 30 + (60 * (idx + 1) / fileCount)
 
-			result, err := svRecognizer.TranscribeFile(filePath, 20, func(progress int, step string) {
+			start := time.Now()
+			result, err := svRecognizer.TranscribeFile(ctx, filePath, senseVoiceChunkSec, func(progress int, step string) {
 				fileProgress := fileProgressStart + (progress-10)*(fileProgressEnd-fileProgressStart)/80
 				reportProgress(fileProgress, step)
 			})
 			if err != nil {
-				return fmt.Errorf("failed to transcribe %s with SenseVoice: %w", filePath, err)
+				return nil, fmt.Errorf("failed to transcribe %s with SenseVoice: %w", filePath, err)
 			}
+			recordMetrics(ctx, result, ModelForJobType(jobType), filePath, start)
+			logChunkReports(ctx, result)
+			result.Params = &asr.TranscriptionParams{
+				Model:          ModelForJobType(jobType),
+				DecodingMethod: svConfig.DecodingMethod,
+				Rescored:       svConfig.Rescorer != nil,
+				ChunkSeconds:   senseVoiceChunkSec,
+				Language:       svConfig.Language,
+				Version:        version.Version,
+			}
+			if svConfig.DecodingMethod == "modified_beam_search" {
+				result.Params.MaxActivePaths = svConfig.MaxActivePaths
+			}
+			checkpoints.SaveFile(ctx, idx, result)
 
 			// Add speaker label
-			if idx < len(metadata.Speakers) {
-				result.Speaker = metadata.Speakers[idx]
+			if idx < len(speakers) {
+				result.Speaker = speakers[idx]
 			}
 
 			allResults = append(allResults, result)
 		}
 	} else {
 		// === ReazonSpeech Model (default) ===
-		recognizer, err := asr.NewRecognizer(i.asrConfig)
+		config := *asrConfig // Copy config
+		opts.applyDecoding(&config)
+		if !useLM {
+			config.Rescorer = nil
+		} else if config.Rescorer == nil {
+			return nil, fmt.Errorf("language model is not configured")
+		}
+		release := tuner.TuneRecognizer(&config)
+		defer release()
+		recognizer, err := asr.NewRecognizer(&config)
 		if err != nil {
-			return fmt.Errorf("failed to create recognizer: %w", err)
+			return nil, fmt.Errorf("failed to create recognizer: %w", err)
 		}
 		defer recognizer.Close()
 
-		// Determine transcription method
-		// VADモデルがあれば TranscribeWithOverlap を使用（本番推奨）
-		useOverlap := i.asrConfig.VADModelPath != ""
-
-		for idx, filePath := range metadata.Files {
+		for idx, filePath := range files {
+			if result := checkpoints.resumedFile(ctx, idx, filePath, speakers); result != nil {
+				allResults = append(allResults, result)
+				continue
+			}
 			// Calculate progress: transcribing takes 30-90%
 			// Each file gets an equal share of that range
 			fileProgressStart := 30 + (60 * idx / fileCount)
 			fileProgressEnd := 30 + (60 * (idx + 1) / fileCount)
 
 			var result *asr.Result
+			start := time.Now()
+
+			// Silence thresholds, tempo and overlap can be overridden per job
+			silenceConfig, tempo, overlap := opts.blockSettings()
 
-			if useOverlap {
-				// 【本番用】オーバーラップ付きsilence検出による文字起こし
-				// RMSベースの無音検出 + オーバーラップで連続発話も正確に認識
-				silenceConfig := asr.DefaultSilenceConfig()
-				silenceConfig.SilenceThreshold = 0.0003 // 静かな音声も検出
-				silenceConfig.MinSilenceDuration = 0.5  // 500ms以上の無音で分割
-				silenceConfig.MaxBlockDuration = 10.0   // 10秒チャンク
-
-				tempo := 1.0   // 通常は速度調整不要
-				overlap := 2.0 // 2秒オーバーラップ
-
-				result, err = recognizer.TranscribeWithOverlap(filePath, silenceConfig, tempo, overlap, func(progress int, step string) {
-					fileProgress := fileProgressStart + (progress-30)*(fileProgressEnd-fileProgressStart)/60
-					reportProgress(fileProgress, step)
-				})
-				if err != nil {
-					return fmt.Errorf("failed to transcribe %s: %w", filePath, err)
-				}
-			} else {
-				// Fallback: Convert to WAV and use standard transcription
-				reportProgress(fileProgressStart, "converting")
-				needsConvert, _ := asr.NeedsConversion(filePath)
-				wavPath := filePath
-				if needsConvert {
-					wavPath, err = asr.ConvertToWavTemp(filePath)
-					if err != nil {
-						return fmt.Errorf("failed to convert audio: %w", err)
-					}
-					defer os.Remove(wavPath)
-				}
-
-				reportProgress(fileProgressStart+10, "transcribing")
-				result, err = recognizer.TranscribeFile(wavPath)
-				if err != nil {
-					return fmt.Errorf("failed to transcribe %s: %w", filePath, err)
-				}
+			// Blocks stored by an earlier attempt are not transcribed again
+			saveBlock := func(block *asr.BlockResult) {
+				checkpoints.SaveBlock(ctx, idx, block)
 			}
+			result, err = recognizer.TranscribeWithOverlapResume(ctx, filePath, silenceConfig, tempo, overlap, checkpoints.Blocks(idx), saveBlock, func(progress int, step string) {
+				fileProgress := fileProgressStart + (progress-30)*(fileProgressEnd-fileProgressStart)/60
+				reportProgress(fileProgress, step)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to transcribe %s: %w", filePath, err)
+			}
+			recordMetrics(ctx, result, ModelForJobType(jobType), filePath, start)
+			result.Params = asr.BlockParams(ModelForJobType(jobType), &config, silenceConfig, tempo, overlap)
+			result.Params.Version = version.Version
+			checkpoints.SaveFile(ctx, idx, result)
 
 			// Add speaker label
-			if idx < len(metadata.Speakers) {
-				result.Speaker = metadata.Speakers[idx]
+			if idx < len(speakers) {
+				result.Speaker = speakers[idx]
 			}
 
 			allResults = append(allResults, result)
 		}
 	}
 
-	reportProgress(90, "saving")
+	return allResults, nil
+}
 
-	// Merge results if multiple files
-	var finalResult *asr.Result
-	if len(allResults) == 1 {
-		finalResult = allResults[0]
-	} else {
-		finalResult = mergeResults(allResults)
+// transcribeFilesWhisper transcribes each file with Whisper. Whisper's
+// timestamps are only spread evenly over each 30-second chunk, so with align
+// the files are first transcribed with ReazonSpeech (progress 30-60) and
+// Whisper's text is aligned onto those timestamps (progress 60-90)
+func transcribeFilesWhisper(ctx context.Context, asrConfig *asr.Config, whisperConfig *asr.WhisperConfig, tuner *asr.ThreadTuner, align bool, opts *TranscriptionOptions, files []string, speakers []string, checkpoints *JobCheckpoints, reportProgress ProgressCallback) ([]*asr.Result, error) {
+	if whisperConfig == nil {
+		return nil, fmt.Errorf("whisper model is not configured")
+	}
+	fileCount := len(files)
+	if fileCount == 0 {
+		return nil, fmt.Errorf("no audio files in source metadata")
 	}
 
-	// Save transcription artifact
-	artifactContent, _ := json.Marshal(finalResult)
-	artifact := &sqlc.ProcessingArtifact{
-		SourceID: &source.ID,
-		Type:     storage.ArtifactTypeTranscription,
-		Content:  storage.Ptr(string(artifactContent)),
-		Format:   storage.Ptr("json"),
+	var timing []*asr.Result
+	progressStart := 30
+	if align {
+		if asrConfig == nil {
+			return nil, fmt.Errorf("whisper:align requires the ReazonSpeech model for timestamps")
+		}
+		var err error
+		timing, err = TranscribeFiles(ctx, asrConfig, nil, nil, tuner, storage.JobTypeTranscribeReazonSpeech, opts, files, speakers, nil, func(progress int, step string) {
+			reportProgress(30+(progress-30)/2, step)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get timestamps for alignment: %w", err)
+		}
+		progressStart = 60
 	}
-	if err := i.artifactRepo.Create(ctx, artifact); err != nil {
-		return fmt.Errorf("failed to save artifact: %w", err)
+
+	config := *whisperConfig // Copy config
+	release := tuner.TuneWhisper(&config)
+	defer release()
+	recognizer, err := asr.NewWhisperRecognizer(&config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Whisper recognizer: %w", err)
 	}
+	defer recognizer.Close()
 
-	// Generate article
-	title := metadata.Title
-	if title == "" {
-		title = fmt.Sprintf("Meeting %s", time.Now().Format("2006-01-02"))
+	var results []*asr.Result
+	for idx, filePath := range files {
+		if result := checkpoints.resumedFile(ctx, idx, filePath, speakers); result != nil {
+			results = append(results, result)
+			continue
+		}
+		fileProgressStart := progressStart + ((90 - progressStart) * idx / fileCount)
+		fileProgressEnd := progressStart + ((90 - progressStart) * (idx + 1) / fileCount)
+
+		start := time.Now()
+		result, err := recognizer.TranscribeFile(ctx, filePath, whisperChunkSec, func(progress int, step string) {
+			fileProgress := fileProgressStart + (progress-10)*(fileProgressEnd-fileProgressStart)/80
+			reportProgress(fileProgress, step)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to transcribe %s with Whisper: %w", filePath, err)
+		}
+		recordMetrics(ctx, result, storage.ASRModelWhisper, filePath, start)
+		logChunkReports(ctx, result)
+
+		if align {
+			metrics := result.Metrics
+			metrics.Model = storage.ASRModelWhisperAlign
+			metrics.AddPass(timing[idx].Metrics)
+			result = asr.AlignWhisperResult(timing[idx], result, whisperChunkSec)
+			result.Metrics = metrics
+			result.Duration = metrics.WallSeconds
+		}
+		result.Params = &asr.TranscriptionParams{
+			Model:        storage.ASRModelWhisper,
+			ChunkSeconds: whisperChunkSec,
+			Language:     config.Language,
+			Version:      version.Version,
+		}
+		if align {
+			// Timestamps come from the ReazonSpeech pass
+			result.Params.Model = storage.ASRModelWhisperAlign
+			if timing[idx].Params != nil {
+				result.Params.DecodingMethod = timing[idx].Params.DecodingMethod
+				result.Params.SilenceThreshold = timing[idx].Params.SilenceThreshold
+				result.Params.MinSilenceDuration = timing[idx].Params.MinSilenceDuration
+				result.Params.MaxBlockDuration = timing[idx].Params.MaxBlockDuration
+				result.Params.Tempo = timing[idx].Params.Tempo
+				result.Params.Overlap = timing[idx].Params.Overlap
+			}
+		}
+		checkpoints.SaveFile(ctx, idx, result)
+
+		// Add speaker label
+		if idx < len(speakers) {
+			result.Speaker = speakers[idx]
+		}
+
+		results = append(results, result)
 	}
+	return results, nil
+}
 
-	article := &sqlc.Article{
-		Title:      title,
-		Content:    finalResult.FormatAsText(),
-		SourceType: storage.Ptr("audio"),
-		SourceID:   &source.ID,
-		Language:   storage.Ptr("ja"),
+// analyzeSource computes loudness/clipping diagnostics for each source file
+// and stores them in the source metadata under "diagnostics"
+func (i *AudioIngester) analyzeSource(ctx context.Context, source *sqlc.Source) error {
+	if source.Metadata == nil {
+		return nil
 	}
-	if err := i.articleRepo.Create(ctx, article); err != nil {
-		return fmt.Errorf("failed to create article: %w", err)
+
+	var metadata map[string]interface{}
+	if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+		return fmt.Errorf("failed to parse metadata: %w", err)
 	}
 
-	// Update source status to completed
-	if err := i.sourceRepo.UpdateStatus(ctx, source.ID, storage.SourceStatusCompleted); err != nil {
-		return fmt.Errorf("failed to update source status: %w", err)
+	// Prefer the untrimmed originals: the trimmed WAV is already downmixed to mono
+	var files struct {
+		Files         []string               `json:"files"`
+		OriginalFiles []string               `json:"original_files"`
+		Diagnostics   []asr.AudioDiagnostics `json:"diagnostics"`
+	}
+	if err := json.Unmarshal([]byte(*source.Metadata), &files); err != nil {
+		return fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	paths := files.Files
+	if len(files.OriginalFiles) > 0 {
+		paths = files.OriginalFiles
+	}
+	// Retranscriptions of the same audio keep the earlier diagnostics
+	if diagnosed(files.Diagnostics, paths) {
+		return nil
 	}
 
-	reportProgress(100, "")
+	var diagnostics []*asr.AudioDiagnostics
+	for _, path := range paths {
+		d, err := asr.AnalyzeAudio(ctx, path)
+		if err != nil {
+			return fmt.Errorf("failed to analyze %s: %w", filepath.Base(path), err)
+		}
+		d.File = filepath.Base(path)
+		diagnostics = append(diagnostics, d)
+	}
+
+	metadata["diagnostics"] = diagnostics
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if err := i.sourceRepo.UpdateMetadata(ctx, source.ID, string(metadataJSON)); err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+	source.Metadata = storage.Ptr(string(metadataJSON))
 
 	return nil
 }
 
+// diagnosed reports whether diagnostics were already recorded for exactly
+// these files
+func diagnosed(diagnostics []asr.AudioDiagnostics, paths []string) bool {
+	if len(diagnostics) == 0 || len(diagnostics) != len(paths) {
+		return false
+	}
+	for idx, path := range paths {
+		if diagnostics[idx].File != filepath.Base(path) {
+			return false
+		}
+	}
+	return true
+}
+
+// generateProxies encodes a low-bitrate Opus preview next to each source file
+// for the stream endpoint's quality=low variant, skipping files whose preview
+// is newer than the file (retranscriptions of unchanged audio). Without ffmpeg
+// (the only Opus encoder) there are no proxies and full quality is served
+func generateProxies(ctx context.Context, files []string) error {
+	if !asr.FFmpegAvailable() {
+		return nil
+	}
+	for _, path := range files {
+		if _, err := asr.EnsureOpusProxy(ctx, path); err != nil {
+			return fmt.Errorf("failed to encode proxy for %s: %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+// recordMetrics records the throughput of a file transcribed since start
+// in result.Metrics and logs it
+func recordMetrics(ctx context.Context, result *asr.Result, model, filePath string, start time.Time) {
+	audioSeconds, _ := asr.GetAudioDuration(ctx, filePath)
+	result.RecordMetrics(model, time.Since(start), audioSeconds)
+	m := result.Metrics
+	logging.FromContext(ctx).Info("Transcribed file", "file", filepath.Base(filePath), "model", model,
+		"audio_seconds", m.AudioSeconds, "wall_seconds", m.WallSeconds, "rtf", m.RTF, "chunks", m.Chunks, "ffmpeg_restarts", m.FFmpegRestarts)
+}
+
+// logChunkReports logs chunk mode reports with audio that was not transcribed
+func logChunkReports(ctx context.Context, result *asr.Result) {
+	for _, report := range result.ChunkReports {
+		if report.SkippedSeconds > 0 {
+			logging.FromContext(ctx).Warn("Chunk mode skipped audio", "file", report.File, "skipped_seconds", report.SkippedSeconds,
+				"failed_chunks", report.FailedChunks, "chunks", report.Chunks, "errors", strings.Join(report.Errors, "; "))
+		}
+	}
+}
+
 // mergeResults merges multiple transcription results sorted by timestamp
 func mergeResults(results []*asr.Result) *asr.Result {
 	if len(results) == 0 {
@@ -455,6 +1336,29 @@ func mergeResults(results []*asr.Result) *asr.Result {
 
 	merged.Text = textBuilder.String()
 
+	// Keep per-file segments (labelled with their speaker) for subtitle export
+	for _, r := range results {
+		for _, seg := range r.Segments {
+			seg.Speaker = r.Speaker
+			merged.Segments = append(merged.Segments, seg)
+		}
+	}
+	sort.SliceStable(merged.Segments, func(a, b int) bool {
+		return merged.Segments[a].StartTime < merged.Segments[b].StartTime
+	})
+
+	// Keep the per-file chunk mode reports and hypotheses and sum the throughput metrics
+	var metrics []*asr.Metrics
+	for _, r := range results {
+		merged.ChunkReports = append(merged.ChunkReports, r.ChunkReports...)
+		merged.NBest = append(merged.NBest, r.NBest...)
+		merged.Duration += r.Duration
+		metrics = append(metrics, r.Metrics)
+	}
+	merged.Metrics = asr.MergeMetrics(metrics...)
+	// Every file is transcribed with the same model and settings
+	merged.Params = results[0].Params
+
 	// Calculate total duration
 	if len(merged.Tokens) > 0 {
 		lastToken := merged.Tokens[len(merged.Tokens)-1]
@@ -0,0 +1,216 @@
+package ingestion
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/webfetch"
+
+	"github.com/google/uuid"
+)
+
+// IngestAudioURLOptions contains options for ingesting audio from a URL
+type IngestAudioURLOptions struct {
+	URL      string // direct link to an audio file (podcast MP3, presigned S3 URL, ...)
+	Title    string // optional title for the article
+	Priority int    // job priority (0-9, lower is higher priority)
+	Enhance  bool   // denoise the audio before ASR (see SetEnhanceModel)
+}
+
+// downloadProgressInterval is how often a download reports its progress
+const downloadProgressInterval = 2 * time.Second
+
+// IngestAudioURL creates an audio source for a media URL and queues a
+// transcription job. The file is downloaded by the job so the request
+// returns immediately; the job reports the download progress
+func (i *AudioIngester) IngestAudioURL(ctx context.Context, opts IngestAudioURLOptions) (*IngestResult, error) {
+	u, err := url.Parse(strings.TrimSpace(opts.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid audio URL: %s", opts.URL)
+	}
+	if i.DefaultModel() == "" {
+		return nil, fmt.Errorf("%w: no ASR model is installed", ErrModelUnavailable)
+	}
+	if opts.Enhance {
+		if err := i.CheckEnhancement(); err != nil {
+			return nil, err
+		}
+	}
+	if err := i.CheckQuota(ctx, ModelForJobType(storage.JobTypeTranscribe)); err != nil {
+		return nil, err
+	}
+
+	sourceID := uuid.New().String()
+	sourceDir := filepath.Join(i.dataDir, "sources", "audio", sourceID)
+	if err := os.MkdirAll(sourceDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create source directory: %w", err)
+	}
+
+	metadata := map[string]interface{}{
+		"files":    []string{},
+		"speakers": []string{},
+		"title":    opts.Title,
+	}
+	if opts.Enhance {
+		metadata["enhance"] = true
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	source := &sqlc.Source{
+		ID:          sourceID,
+		Type:        storage.SourceTypeAudio,
+		OriginalUrl: storage.Ptr(u.String()),
+		FilePath:    storage.Ptr(sourceDir),
+		Metadata:    storage.Ptr(string(metadataJSON)),
+		Status:      storage.Ptr(storage.SourceStatusPending),
+	}
+	if err := i.sourceRepo.Create(ctx, source); err != nil {
+		return nil, fmt.Errorf("failed to create source: %w", err)
+	}
+
+	job := &sqlc.ProcessingJob{
+		SourceID: &sourceID,
+		Type:     storage.JobTypeTranscribe,
+		Priority: storage.Ptr(int64(opts.Priority)),
+	}
+	if err := i.jobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return &IngestResult{
+		SourceID: sourceID,
+		JobID:    job.ID,
+	}, nil
+}
+
+// downloadAudioURL downloads the file of an audio source ingested from a URL
+// once and records it in the source metadata, with its SHA-256 as the
+// content hash so a later upload of the same file is detected as a duplicate
+func (i *AudioIngester) downloadAudioURL(ctx context.Context, source *sqlc.Source, reportProgress ProgressCallback) error {
+	if source.FilePath == nil {
+		return fmt.Errorf("audio source has no directory")
+	}
+
+	var metadata map[string]interface{}
+	if source.Metadata != nil {
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+			return fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+
+	// Already downloaded (e.g. on retry or retranscription)
+	if files, ok := metadata["files"].([]interface{}); ok && len(files) > 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *source.OriginalUrl, nil)
+	if err != nil {
+		return fmt.Errorf("invalid audio URL: %w", err)
+	}
+	req.Header.Set("User-Agent", webfetch.UserAgent)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download audio: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download audio: HTTP %d", resp.StatusCode)
+	}
+	if resp.ContentLength > MaxUploadSize {
+		return fmt.Errorf("audio file is larger than %d bytes", int64(MaxUploadSize))
+	}
+
+	// Name the file after the URL (presigned links carry it in the path) or
+	// "audio" with the extension of the Content-Type
+	ext := podcastExtension(resp.Request.URL.Path, resp.Header.Get("Content-Type"))
+	if ext == "" {
+		return fmt.Errorf("unsupported audio format: %s", *source.OriginalUrl)
+	}
+	filename := path.Base(resp.Request.URL.Path)
+	if !asr.IsSupportedFormat(filename) {
+		filename = "audio" + ext
+	}
+
+	// Write to a temporary file so an interrupted download is not mistaken for audio
+	outputPath := filepath.Join(*source.FilePath, filename)
+	tmpPath := outputPath + ".part"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	hash := sha256.New()
+	body := &progressReader{
+		r:     io.LimitReader(resp.Body, MaxUploadSize+1),
+		total: resp.ContentLength,
+		report: func(step string) {
+			reportProgress(6, step)
+		},
+	}
+	n, err := io.Copy(io.MultiWriter(out, hash), body)
+	out.Close()
+	if err == nil && n > MaxUploadSize {
+		err = fmt.Errorf("larger than %d bytes", int64(MaxUploadSize))
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to download audio: %w", err)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return fmt.Errorf("failed to save audio: %w", err)
+	}
+
+	metadata["files"] = []string{outputPath}
+	metadata["speakers"] = []string{strings.TrimSuffix(filename, filepath.Ext(filename))}
+	metadata["content_hash"] = hex.EncodeToString(hash.Sum(nil))
+	metadataJSON, _ := json.Marshal(metadata)
+	if err := i.sourceRepo.UpdateMetadata(ctx, source.ID, string(metadataJSON)); err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+	source.Metadata = storage.Ptr(string(metadataJSON))
+	i.recordFiles(ctx, source.ID, []string{outputPath})
+
+	return nil
+}
+
+// progressReader reports how much of a download was read, at most every
+// downloadProgressInterval ("downloading 42%", or the size when the total
+// is unknown)
+type progressReader struct {
+	r      io.Reader
+	total  int64 // -1 if unknown
+	read   int64
+	last   time.Time
+	report func(step string)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if now := time.Now(); now.Sub(p.last) >= downloadProgressInterval {
+		p.last = now
+		if p.total > 0 {
+			p.report(fmt.Sprintf("downloading %d%%", min(p.read*100/p.total, 100)))
+		} else {
+			p.report(fmt.Sprintf("downloading %dMB", p.read>>20))
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,106 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"zbor/internal/logging"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// SetBlobStore copies stored audio files to an object storage (see
+// storage.Blob) so nodes without the local files can transcribe and play
+// them. A nil store keeps files on the local disk only
+func (i *AudioIngester) SetBlobStore(blob storage.Blob) {
+	i.blobStore = blob
+}
+
+// storeBlobs copies files to the blob store and records their URIs in the
+// source metadata ("blob_uris", local path -> URI). Failures are only
+// logged; the files stay available on this node
+func (i *AudioIngester) storeBlobs(ctx context.Context, sourceID string, paths []string) {
+	uris := map[string]string{}
+	for _, path := range paths {
+		uri, err := storage.PutFile(ctx, i.blobStore, i.blobKey(sourceID, path), path)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to copy audio to the blob store", "path", path, "error", err)
+			continue
+		}
+		uris[path] = uri
+	}
+	if len(uris) == 0 {
+		return
+	}
+
+	source, err := i.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil || source == nil {
+		logging.FromContext(ctx).Warn("Failed to record blob URIs", "source_id", sourceID, "error", err)
+		return
+	}
+	metadata := map[string]interface{}{}
+	if source.Metadata != nil {
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+			logging.FromContext(ctx).Warn("Failed to record blob URIs", "source_id", sourceID, "error", err)
+			return
+		}
+	}
+	for path, uri := range storage.BlobURIs(source) {
+		if _, ok := uris[path]; !ok {
+			uris[path] = uri
+		}
+	}
+	metadata["blob_uris"] = uris
+	metadataJSON, _ := json.Marshal(metadata)
+	if err := i.sourceRepo.UpdateMetadata(ctx, sourceID, string(metadataJSON)); err != nil {
+		logging.FromContext(ctx).Warn("Failed to record blob URIs", "source_id", sourceID, "error", err)
+	}
+}
+
+// blobKey is the object key of a file: its path relative to the data
+// directory (sources/<type>/<id>/<file>)
+func (i *AudioIngester) blobKey(sourceID, path string) string {
+	if rel, err := filepath.Rel(i.dataDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return "sources/" + sourceID + "/" + filepath.Base(path)
+}
+
+// FetchFiles downloads the audio files of a source that are missing on
+// this node from the blob store (e.g. ingested by another node). Files
+// without a blob URI are left as they are
+func (i *AudioIngester) FetchFiles(ctx context.Context, source *sqlc.Source) error {
+	if i.blobStore == nil || source.Metadata == nil {
+		return nil
+	}
+	uris := storage.BlobURIs(source)
+	if len(uris) == 0 {
+		return nil
+	}
+	var metadata struct {
+		Files         []string `json:"files"`
+		OriginalFiles []string `json:"original_files"`
+	}
+	if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+		return fmt.Errorf("failed to parse metadata: %w", err)
+	}
+
+	for _, path := range append(metadata.Files, metadata.OriginalFiles...) {
+		uri := uris[path]
+		if uri == "" {
+			continue
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			continue
+		}
+		if err := storage.FetchFile(ctx, i.blobStore, uri, path); err != nil {
+			return fmt.Errorf("failed to fetch %s from the blob store: %w", filepath.Base(path), err)
+		}
+		logging.FromContext(ctx).Info("Fetched audio from the blob store", "source_id", source.ID, "path", path)
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"zbor/internal/asr"
+	"zbor/internal/logging"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// ErrAdjustBoundariesPending is returned when the source already has a
+// queued or running boundary adjustment job
+var ErrAdjustBoundariesPending = errors.New("boundary adjustment job is already queued")
+
+// boundaryPeakRate is the waveform resolution (peaks/sec) of boundary adjustment
+const boundaryPeakRate = 50
+
+// BoundaryOptions are the parameters of a boundary adjustment job, stored as
+// its payload. Zero values (and values outside the usable ranges) keep the
+// defaults of asr.DefaultSegmentBoundaryOptions
+type BoundaryOptions struct {
+	Threshold  float64 `json:"threshold,omitempty"`    // audio detection threshold (0-0.1)
+	MergeGapMs int     `json:"merge_gap_ms,omitempty"` // merge audio clusters closer than this
+	SearchMs   int     `json:"search_ms,omitempty"`    // search window beyond each segment
+	PaddingMs  *int    `json:"padding_ms,omitempty"`   // padding added to the adjusted boundaries
+	DryRun     bool    `json:"dry_run,omitempty"`      // only write the report, keep the transcript
+}
+
+// segmentOptions returns the asr options with the overrides applied
+func (o *BoundaryOptions) segmentOptions() asr.SegmentBoundaryOptions {
+	opts := asr.DefaultSegmentBoundaryOptions()
+	if o == nil {
+		return opts
+	}
+	opts.Threshold = o.Threshold
+	opts.MergeGapMs = o.MergeGapMs
+	opts.SearchWindow = o.SearchMs
+	if o.PaddingMs != nil {
+		opts.PaddingMs = *o.PaddingMs
+	}
+	opts.Clamp()
+	return opts
+}
+
+// BoundaryMove is how far the boundaries of one segment moved
+// (positive: the segment was extended)
+type BoundaryMove struct {
+	Segment       int     `json:"segment"`
+	OriginalStart float64 `json:"original_start"`
+	OriginalEnd   float64 `json:"original_end"`
+	AdjustedStart float64 `json:"adjusted_start"`
+	AdjustedEnd   float64 `json:"adjusted_end"`
+	StartMovedMs  int     `json:"start_moved_ms"`
+	EndMovedMs    int     `json:"end_moved_ms"`
+}
+
+// BoundaryReport is the content of the boundary report artifact
+type BoundaryReport struct {
+	Threshold  float64 `json:"threshold"`
+	MergeGapMs int     `json:"merge_gap_ms"`
+	SearchMs   int     `json:"search_ms"`
+	PaddingMs  int     `json:"padding_ms"`
+	Applied    bool    `json:"applied"` // false for dry runs
+
+	Segments    int            `json:"segments"`
+	Moved       int            `json:"moved"` // segments with at least one boundary moved
+	MeanStartMs float64        `json:"mean_start_moved_ms"`
+	MeanEndMs   float64        `json:"mean_end_moved_ms"`
+	MaxStartMs  int            `json:"max_start_moved_ms"`
+	MaxEndMs    int            `json:"max_end_moved_ms"`
+	Boundaries  []BoundaryMove `json:"boundaries"` // only the segments that moved
+}
+
+// QueueAdjustBoundaries creates a boundary adjustment job for a transcribed source
+func (i *AudioIngester) QueueAdjustBoundaries(ctx context.Context, sourceID string, priority int, opts *BoundaryOptions) (string, error) {
+	source, err := i.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return "", fmt.Errorf("source not found: %s", sourceID)
+	}
+	if opts == nil || !opts.DryRun {
+		if err := i.sourceRepo.CheckHold(ctx, sourceID); err != nil {
+			return "", err
+		}
+	}
+	pending, err := i.hasPendingJob(ctx, sourceID, storage.JobTypeAdjustBoundaries)
+	if err != nil {
+		return "", err
+	}
+	if pending {
+		return "", ErrAdjustBoundariesPending
+	}
+
+	job := &sqlc.ProcessingJob{
+		SourceID: &sourceID,
+		Type:     storage.JobTypeAdjustBoundaries,
+		Priority: storage.Ptr(int64(priority)),
+	}
+	if opts != nil {
+		data, _ := json.Marshal(opts)
+		job.Payload = storage.Ptr(string(data))
+	}
+	if err := i.jobRepo.Create(ctx, job); err != nil {
+		return "", fmt.Errorf("failed to create job: %w", err)
+	}
+	return job.ID, nil
+}
+
+// ProcessAdjustBoundaries snaps the start and end of every segment of the
+// source's transcript to the audio activity around it (asr.AdjustAllBoundaries
+// on the waveform peaks) and stores how far each boundary moved as the
+// boundary report artifact (replacing an earlier one). Unless the job is a dry
+// run, the transcript, condensed view, search index and chapters are updated.
+// Multi-file sources are left as is
+func (i *AudioIngester) ProcessAdjustBoundaries(ctx context.Context, job *sqlc.ProcessingJob, onProgress ProgressCallback) error {
+	reportProgress := func(progress int, step string) {
+		if onProgress != nil {
+			onProgress(progress, step)
+		}
+	}
+
+	if job.SourceID == nil {
+		return fmt.Errorf("job has no source ID")
+	}
+	sourceID := *job.SourceID
+
+	var opts BoundaryOptions
+	if job.Payload != nil && *job.Payload != "" {
+		if err := json.Unmarshal([]byte(*job.Payload), &opts); err != nil {
+			return fmt.Errorf("failed to parse job payload: %w", err)
+		}
+	}
+	segmentOpts := opts.segmentOptions()
+
+	reportProgress(5, "loading transcript")
+	source, err := i.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return fmt.Errorf("source not found: %s", sourceID)
+	}
+	var metadata sourceMetadata
+	if source.Metadata != nil {
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+			return fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+	// Segments of merged multi-file transcripts come from different recordings
+	if len(metadata.Files) != 1 {
+		reportProgress(100, "multi-file source")
+		return nil
+	}
+
+	artifacts, err := i.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	var artifact *sqlc.ProcessingArtifact
+	for idx := range artifacts {
+		if artifacts[idx].Type == storage.ArtifactTypeTranscription && artifacts[idx].Content != nil {
+			artifact = &artifacts[idx]
+			break
+		}
+	}
+	if artifact == nil {
+		return fmt.Errorf("transcript not found")
+	}
+	var transcript asr.Result
+	if err := json.Unmarshal([]byte(*artifact.Content), &transcript); err != nil {
+		return fmt.Errorf("failed to parse transcript: %w", err)
+	}
+
+	reportProgress(20, "computing waveform")
+	if err := i.FetchFiles(ctx, source); err != nil {
+		return fmt.Errorf("failed to fetch audio: %w", err)
+	}
+	waveform, err := i.waveform(ctx, metadata.Files[0])
+	if err != nil {
+		return fmt.Errorf("failed to compute waveform: %w", err)
+	}
+
+	reportProgress(50, "adjusting boundaries")
+	originals := make([]asr.Segment, len(transcript.Segments))
+	copy(originals, transcript.Segments)
+	results := asr.AdjustAllBoundaries(waveform.Range(boundaryPeakRate, 0, 0), boundaryPeakRate, waveform.Duration, transcript.Segments, segmentOpts)
+	report := buildBoundaryReport(originals, results, segmentOpts, !opts.DryRun)
+	logging.FromContext(ctx).Info("Segment boundaries adjusted", "segments", report.Segments, "moved", report.Moved, "dry_run", opts.DryRun)
+
+	reportProgress(60, "saving")
+	if err := i.saveBoundaryReport(ctx, sourceID, artifacts, report); err != nil {
+		return err
+	}
+	if opts.DryRun || report.Moved == 0 {
+		reportProgress(100, "")
+		return nil
+	}
+
+	asr.AnnotateSegmentConfidence(transcript.Segments, transcript.Tokens)
+	content, _ := json.Marshal(&transcript)
+	if err := i.artifactRepo.UpdateContent(ctx, artifact.ID, string(content)); err != nil {
+		return fmt.Errorf("failed to save transcript: %w", err)
+	}
+
+	reportProgress(70, "updating condensed view")
+	if err := i.SaveCondensed(ctx, sourceID, &transcript); err != nil {
+		return fmt.Errorf("failed to save condensed view: %w", err)
+	}
+
+	reportProgress(80, "indexing")
+	if err := i.IndexTranscript(ctx, sourceID, &transcript); err != nil {
+		return fmt.Errorf("failed to index transcript: %w", err)
+	}
+
+	reportProgress(90, "rebuilding chapters")
+	if _, err := i.RebuildChapters(ctx, sourceID); err != nil {
+		return fmt.Errorf("failed to rebuild chapters: %w", err)
+	}
+
+	reportProgress(100, "completed")
+	return nil
+}
+
+// waveform returns the waveform of an audio file, from the conversion cache if set
+func (i *AudioIngester) waveform(ctx context.Context, audioPath string) (*asr.Waveform, error) {
+	if i.wavCache != nil {
+		return i.wavCache.Waveform(ctx, audioPath)
+	}
+	return asr.ComputeWaveform(ctx, audioPath)
+}
+
+// buildBoundaryReport summarizes the adjustment of each segment
+func buildBoundaryReport(originals []asr.Segment, results []asr.BoundaryAdjustmentResult, opts asr.SegmentBoundaryOptions, applied bool) *BoundaryReport {
+	report := &BoundaryReport{
+		Threshold:  opts.Threshold,
+		MergeGapMs: opts.MergeGapMs,
+		SearchMs:   opts.SearchWindow,
+		PaddingMs:  opts.PaddingMs,
+		Applied:    applied,
+		Segments:   len(results),
+		Boundaries: []BoundaryMove{},
+	}
+	var startSum, endSum int
+	for idx, r := range results {
+		startSum += r.StartExtendedMs
+		endSum += r.EndExtendedMs
+		report.MaxStartMs = max(report.MaxStartMs, r.StartExtendedMs)
+		report.MaxEndMs = max(report.MaxEndMs, r.EndExtendedMs)
+		if r.StartExtendedMs == 0 && r.EndExtendedMs == 0 {
+			continue
+		}
+		report.Moved++
+		report.Boundaries = append(report.Boundaries, BoundaryMove{
+			Segment:       idx,
+			OriginalStart: originals[idx].StartTime,
+			OriginalEnd:   originals[idx].EndTime,
+			AdjustedStart: r.AdjustedStart,
+			AdjustedEnd:   r.AdjustedEnd,
+			StartMovedMs:  r.StartExtendedMs,
+			EndMovedMs:    r.EndExtendedMs,
+		})
+	}
+	if len(results) > 0 {
+		report.MeanStartMs = math.Round(float64(startSum)/float64(len(results))*10) / 10
+		report.MeanEndMs = math.Round(float64(endSum)/float64(len(results))*10) / 10
+	}
+	return report
+}
+
+// saveBoundaryReport stores the boundary report artifact (replacing an earlier one)
+func (i *AudioIngester) saveBoundaryReport(ctx context.Context, sourceID string, artifacts []sqlc.ProcessingArtifact, report *BoundaryReport) error {
+	content, _ := json.Marshal(report)
+	for _, artifact := range artifacts {
+		if artifact.Type == storage.ArtifactTypeBoundaryReport {
+			if err := i.artifactRepo.UpdateContent(ctx, artifact.ID, string(content)); err != nil {
+				return fmt.Errorf("failed to save boundary report: %w", err)
+			}
+			return nil
+		}
+	}
+	err := i.artifactRepo.Create(ctx, &sqlc.ProcessingArtifact{
+		SourceID: &sourceID,
+		Type:     storage.ArtifactTypeBoundaryReport,
+		Content:  storage.Ptr(string(content)),
+		Format:   storage.Ptr("json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save boundary report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package ingestion
+
+import (
+	"errors"
+	"fmt"
+
+	"zbor/internal/storage"
+)
+
+// ErrModelUnavailable is returned when a job or request asks for an ASR model
+// whose files or language model are not installed
+var ErrModelUnavailable = errors.New("model is not available")
+
+// ASRModels lists the ASR models in the order they are offered
+var ASRModels = []string{
+	storage.ASRModelReazonSpeech,
+	storage.ASRModelReazonSpeechLM,
+	storage.ASRModelSenseVoice,
+	storage.ASRModelSenseVoiceBeam,
+	storage.ASRModelSenseVoiceLM,
+	storage.ASRModelWhisper,
+	storage.ASRModelWhisperAlign,
+}
+
+// defaultModels are the models new transcriptions use when no model is
+// requested, in order of preference
+var defaultModels = []string{
+	storage.ASRModelReazonSpeech,
+	storage.ASRModelSenseVoice,
+	storage.ASRModelWhisper,
+}
+
+// modelFallbacks is the model a job runs with when its own model is missing
+// (the same family without the missing part, then the default model)
+var modelFallbacks = map[string]string{
+	storage.ASRModelReazonSpeechLM: storage.ASRModelReazonSpeech,
+	storage.ASRModelSenseVoiceLM:   storage.ASRModelSenseVoiceBeam,
+	storage.ASRModelSenseVoiceBeam: storage.ASRModelSenseVoice,
+	storage.ASRModelWhisperAlign:   storage.ASRModelWhisper,
+}
+
+// ModelStatus is whether an ASR model can be used on this server
+type ModelStatus struct {
+	Model     string `json:"model"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"` // why the model is unavailable
+}
+
+// DetectModels checks which ASR models are installed and remembers the
+// result: jobs and requests for missing models are then refused or run with
+// a fallback model instead of failing when the recognizer is created.
+// Until it is called (e.g. when transcription runs on remote workers) every
+// model is assumed to be available
+func (i *AudioIngester) DetectModels() []ModelStatus {
+	reasons := map[string]string{}
+	if err := i.asrConfig.Validate(); err != nil {
+		reasons[storage.ASRModelReazonSpeech] = err.Error()
+	}
+	if err := i.senseVoiceConfig.Validate(); err != nil {
+		reasons[storage.ASRModelSenseVoice] = err.Error()
+	}
+	if err := i.whisperConfig.Validate(); err != nil {
+		reasons[storage.ASRModelWhisper] = err.Error()
+	}
+
+	requires := map[string][]string{
+		storage.ASRModelReazonSpeechLM: {storage.ASRModelReazonSpeech},
+		storage.ASRModelSenseVoiceBeam: {storage.ASRModelSenseVoice},
+		storage.ASRModelSenseVoiceLM:   {storage.ASRModelSenseVoice},
+		storage.ASRModelWhisperAlign:   {storage.ASRModelWhisper, storage.ASRModelReazonSpeech},
+	}
+
+	i.models = map[string]ModelStatus{}
+	statuses := make([]ModelStatus, 0, len(ASRModels))
+	for _, model := range ASRModels {
+		reason := reasons[model]
+		for _, required := range requires[model] {
+			if reason == "" && reasons[required] != "" {
+				reason = required + " model is not installed: " + reasons[required]
+			}
+		}
+		if reason == "" && usesLM(model) && !i.RescorerEnabled() {
+			reason = lmNotConfigured
+		}
+		status := ModelStatus{Model: model, Available: reason == "", Reason: reason}
+		i.models[model] = status
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Models returns the availability of each ASR model (all available if
+// DetectModels has not been called)
+func (i *AudioIngester) Models() []ModelStatus {
+	statuses := make([]ModelStatus, 0, len(ASRModels))
+	for _, model := range ASRModels {
+		statuses = append(statuses, i.modelStatus(model))
+	}
+	return statuses
+}
+
+func (i *AudioIngester) modelStatus(model string) ModelStatus {
+	if status, ok := i.models[model]; ok {
+		return status
+	}
+	if i.models != nil {
+		return ModelStatus{Model: model, Reason: "unknown model"}
+	}
+	if usesLM(model) && !i.RescorerEnabled() {
+		return ModelStatus{Model: model, Reason: lmNotConfigured}
+	}
+	return ModelStatus{Model: model, Available: true}
+}
+
+const lmNotConfigured = "language model is not configured (set ZBOR_LM_PATH)"
+
+// usesLM reports whether the model rescores with the language model
+func usesLM(model string) bool {
+	return model == storage.ASRModelReazonSpeechLM || model == storage.ASRModelSenseVoiceLM
+}
+
+// CheckModel returns an error wrapping ErrModelUnavailable with the reason if
+// the model (storage.ASRModel* value) cannot be used
+func (i *AudioIngester) CheckModel(model string) error {
+	if status := i.modelStatus(model); !status.Available {
+		return fmt.Errorf("%w: %s: %s", ErrModelUnavailable, model, status.Reason)
+	}
+	return nil
+}
+
+// DefaultModel returns the model used when none is requested: ReazonSpeech,
+// or the first installed of SenseVoice and Whisper if it is missing
+// (empty if no model is installed)
+func (i *AudioIngester) DefaultModel() string {
+	for _, model := range defaultModels {
+		if i.modelStatus(model).Available {
+			return model
+		}
+	}
+	return ""
+}
+
+// fallbackModel returns the model a job for model runs with: the model
+// itself if available, otherwise the closest available one (empty if none)
+func (i *AudioIngester) fallbackModel(model string) string {
+	for m := model; m != ""; m = modelFallbacks[m] {
+		if i.modelStatus(m).Available {
+			return m
+		}
+	}
+	return i.DefaultModel()
+}
@@ -0,0 +1,71 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"zbor/internal/asr"
+	"zbor/internal/models"
+	"zbor/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// ChapterSections splits a transcription into chapters for Article.Sections
+// Returns nil when the transcript is a single chapter
+func ChapterSections(result *asr.Result, opts asr.ChapterOptions) []models.Section {
+	chapters := result.Chapters(opts)
+	if len(chapters) < 2 {
+		return nil
+	}
+
+	sections := make([]models.Section, len(chapters))
+	for idx, ch := range chapters {
+		start := int(math.Floor(ch.StartTime))
+		end := int(math.Ceil(ch.EndTime))
+		sections[idx] = models.Section{
+			ID:        uuid.New().String(),
+			Title:     ch.Title,
+			Content:   ch.Text,
+			StartTime: &start,
+			EndTime:   &end,
+			Order:     idx,
+		}
+	}
+	return sections
+}
+
+// sectionsJSON encodes sections for the article's sections column (nil when empty)
+func sectionsJSON(sections []models.Section) *string {
+	if len(sections) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(sections)
+	return storage.Ptr(string(data))
+}
+
+// RebuildChapters recomputes the chapters of a source's articles from its
+// transcription artifact. Returns the number of chapters (0 when the
+// transcript is too short to split)
+func (i *AudioIngester) RebuildChapters(ctx context.Context, sourceID string) (int, error) {
+	transcript, err := i.latestTranscript(ctx, sourceID)
+	if err != nil {
+		return 0, err
+	}
+
+	sections := ChapterSections(transcript, i.chapterOptions)
+	articles, err := i.articleRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get articles: %w", err)
+	}
+	for idx := range articles {
+		articles[idx].Sections = sectionsJSON(sections)
+		if err := i.articleRepo.Update(ctx, &articles[idx]); err != nil {
+			return 0, fmt.Errorf("failed to update article: %w", err)
+		}
+	}
+
+	return len(sections), nil
+}
@@ -0,0 +1,241 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"zbor/internal/asr"
+	"zbor/internal/logging"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// DefaultPartialFlushBlocks is how many finished blocks are stored at once
+// while a file is transcribed (a crash loses at most the blocks not stored)
+const DefaultPartialFlushBlocks = 5
+
+// JobCheckpoints records the progress of a transcription job so that the
+// next attempt (after a failure or a server restart) resumes from the last
+// finished file and block instead of starting over. Finished files are kept
+// as result chunks keyed by file index, like the ones remote workers upload,
+// and the finished blocks of the file being transcribed as partial blocks,
+// which also let the UI show the text while the job runs.
+// A nil *JobCheckpoints resumes and records nothing
+type JobCheckpoints struct {
+	jobRepo     *storage.JobRepository
+	jobID       string
+	files       map[int]*asr.Result
+	blocks      map[int][]asr.BlockResult // stored blocks of unfinished files
+	pending     []asr.BlockResult         // finished blocks not stored yet
+	flushBlocks int
+}
+
+// jobMetadata is the JSON stored in processing_jobs.metadata
+type jobMetadata struct {
+	Partial *PartialProgress `json:"partial,omitempty"`
+}
+
+// PartialProgress is the block progress of the file being transcribed
+type PartialProgress struct {
+	File   int `json:"file"`
+	Blocks int `json:"blocks"`
+	Done   int `json:"done"` // blocks stored
+}
+
+// LoadJobCheckpoints loads what earlier attempts of the job finished.
+// New blocks are stored every flushBlocks blocks (DefaultPartialFlushBlocks if 0)
+func LoadJobCheckpoints(ctx context.Context, jobRepo *storage.JobRepository, job *sqlc.ProcessingJob, flushBlocks int) (*JobCheckpoints, error) {
+	if flushBlocks <= 0 {
+		flushBlocks = DefaultPartialFlushBlocks
+	}
+	c := &JobCheckpoints{
+		jobRepo:     jobRepo,
+		jobID:       job.ID,
+		files:       map[int]*asr.Result{},
+		blocks:      map[int][]asr.BlockResult{},
+		flushBlocks: flushBlocks,
+	}
+
+	chunks, err := jobRepo.ListResultChunks(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, stored := range chunks {
+		var chunk RemoteChunk
+		if err := json.Unmarshal([]byte(stored.Content), &chunk); err != nil || int64(chunk.File) != stored.ChunkIndex {
+			// Not a whole file: the file is transcribed again
+			continue
+		}
+		c.files[chunk.File] = chunk.result()
+	}
+
+	blocks, err := jobRepo.ListPartialBlocks(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, stored := range blocks {
+		var block asr.BlockResult
+		if err := json.Unmarshal([]byte(stored.Content), &block); err != nil {
+			continue
+		}
+		file := int(stored.FileIndex)
+		c.blocks[file] = append(c.blocks[file], block)
+	}
+	return c, nil
+}
+
+// resumedFile returns the result of a file an earlier attempt finished,
+// labelled with its speaker (nil if the file has to be transcribed)
+func (c *JobCheckpoints) resumedFile(ctx context.Context, idx int, filePath string, speakers []string) *asr.Result {
+	if c == nil || c.files[idx] == nil {
+		return nil
+	}
+	logging.FromContext(ctx).Info("Reusing transcription from an earlier attempt", "file", filePath)
+	result := c.files[idx]
+	if idx < len(speakers) {
+		result.Speaker = speakers[idx]
+	}
+	return result
+}
+
+// Blocks returns the blocks of the file earlier attempts stored, in order
+func (c *JobCheckpoints) Blocks(idx int) []asr.BlockResult {
+	if c == nil {
+		return nil
+	}
+	return c.blocks[idx]
+}
+
+// SaveBlock records a finished block of a file. Blocks are stored every
+// flushBlocks blocks; failures are logged: the job goes on, only the resume
+// point is older
+func (c *JobCheckpoints) SaveBlock(ctx context.Context, idx int, block *asr.BlockResult) {
+	if c == nil {
+		return
+	}
+	c.pending = append(c.pending, *block)
+	if len(c.pending) < c.flushBlocks {
+		return
+	}
+	pending := c.pending
+	c.pending = nil
+
+	for _, b := range pending {
+		content, err := json.Marshal(b)
+		if err == nil {
+			err = c.jobRepo.SavePartialBlock(ctx, c.jobID, int64(idx), int64(b.Index), string(content))
+		}
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to save partial transcription", "file", idx, "block", b.Index, "error", err)
+			return
+		}
+	}
+	last := pending[len(pending)-1]
+	c.saveMetadata(ctx, &PartialProgress{File: idx, Blocks: last.Blocks, Done: last.Index + 1})
+}
+
+// SaveFile records a finished file and drops its blocks
+func (c *JobCheckpoints) SaveFile(ctx context.Context, idx int, result *asr.Result) {
+	if c == nil {
+		return
+	}
+	c.pending = nil
+	content, err := json.Marshal(NewRemoteChunk(idx, result))
+	if err == nil {
+		err = c.jobRepo.SaveResultChunk(ctx, c.jobID, int64(idx), string(content))
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to save transcription checkpoint", "file", idx, "error", err)
+		return
+	}
+	if err := c.jobRepo.DeletePartialBlocks(ctx, c.jobID); err != nil {
+		logging.FromContext(ctx).Warn("Failed to delete partial transcription", "file", idx, "error", err)
+	}
+	c.saveMetadata(ctx, nil)
+}
+
+// Clear removes the checkpoints once the transcription is saved
+func (c *JobCheckpoints) Clear(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	if err := c.jobRepo.DeleteResultChunks(ctx, c.jobID); err != nil {
+		return err
+	}
+	if err := c.jobRepo.DeletePartialBlocks(ctx, c.jobID); err != nil {
+		return err
+	}
+	return c.jobRepo.UpdateMetadata(ctx, c.jobID, nil)
+}
+
+func (c *JobCheckpoints) saveMetadata(ctx context.Context, progress *PartialProgress) {
+	var metadata *string
+	if progress != nil {
+		data, err := json.Marshal(jobMetadata{Partial: progress})
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to encode transcription progress", "error", err)
+			return
+		}
+		metadata = storage.Ptr(string(data))
+	}
+	if err := c.jobRepo.UpdateMetadata(ctx, c.jobID, metadata); err != nil {
+		logging.FromContext(ctx).Warn("Failed to save transcription progress", "error", err)
+	}
+}
+
+// PartialTranscript is the text a transcription job has stored so far:
+// the finished files followed by the stored blocks of the current file
+type PartialTranscript struct {
+	JobID    string           `json:"job_id"`
+	Status   string           `json:"status"`
+	Files    int              `json:"files"`              // finished files
+	Progress *PartialProgress `json:"progress,omitempty"` // blocks of the current file
+	Text     string           `json:"text"`
+}
+
+// LoadPartialTranscript loads the partial transcript of a job
+func LoadPartialTranscript(ctx context.Context, jobRepo *storage.JobRepository, job *sqlc.ProcessingJob) (*PartialTranscript, error) {
+	c, err := LoadJobCheckpoints(ctx, jobRepo, job, 0)
+	if err != nil {
+		return nil, err
+	}
+	partial := &PartialTranscript{JobID: job.ID, Files: len(c.files)}
+	if job.Status != nil {
+		partial.Status = *job.Status
+	}
+
+	var indexes []int
+	for idx := range c.files {
+		indexes = append(indexes, idx)
+	}
+	for idx := range c.blocks {
+		if c.files[idx] == nil {
+			indexes = append(indexes, idx)
+		}
+	}
+	sort.Ints(indexes)
+
+	var texts []string
+	for _, idx := range indexes {
+		if result := c.files[idx]; result != nil {
+			texts = append(texts, result.Text)
+			continue
+		}
+		var sb strings.Builder
+		blocks := c.blocks[idx]
+		for _, block := range blocks {
+			for _, token := range block.Tokens {
+				sb.WriteString(token.Text)
+			}
+		}
+		texts = append(texts, sb.String())
+		if len(blocks) > 0 {
+			last := blocks[len(blocks)-1]
+			partial.Progress = &PartialProgress{File: idx, Blocks: last.Blocks, Done: last.Index + 1}
+		}
+	}
+	partial.Text = strings.Join(texts, "\n\n")
+	return partial, nil
+}
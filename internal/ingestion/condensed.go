@@ -0,0 +1,83 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"zbor/internal/asr"
+	"zbor/internal/logging"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// condensedMetadata is stored as the condensed artifact metadata
+type condensedMetadata struct {
+	Interval float64 `json:"interval"` // seconds per line
+}
+
+// SaveCondensed stores the condensed (skim) view of a transcription as the
+// source's condensed artifact, replacing the previous one
+func (i *AudioIngester) SaveCondensed(ctx context.Context, sourceID string, result *asr.Result) error {
+	opts := asr.DefaultCondensedOptions()
+	content, _ := json.Marshal(result.Condensed(opts))
+
+	artifacts, err := i.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	for _, artifact := range artifacts {
+		if artifact.Type == storage.ArtifactTypeCondensed {
+			return i.artifactRepo.UpdateContent(ctx, artifact.ID, string(content))
+		}
+	}
+
+	metadata, _ := json.Marshal(condensedMetadata{Interval: opts.Interval})
+	return i.artifactRepo.Create(ctx, &sqlc.ProcessingArtifact{
+		SourceID: &sourceID,
+		Type:     storage.ArtifactTypeCondensed,
+		Content:  storage.Ptr(string(content)),
+		Format:   storage.Ptr("json"),
+		Metadata: storage.Ptr(string(metadata)),
+	})
+}
+
+// Condensed returns the condensed view of a source's transcription
+// Transcripts saved before the condensed view existed are condensed on
+// first access and the result is stored for next time
+func (i *AudioIngester) Condensed(ctx context.Context, sourceID string) ([]asr.CondensedLine, error) {
+	artifacts, err := i.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifacts: %w", err)
+	}
+
+	var transcript *asr.Result
+	for _, artifact := range artifacts {
+		if artifact.Content == nil {
+			continue
+		}
+		switch artifact.Type {
+		case storage.ArtifactTypeCondensed:
+			var lines []asr.CondensedLine
+			if err := json.Unmarshal([]byte(*artifact.Content), &lines); err == nil {
+				return lines, nil
+			}
+		case storage.ArtifactTypeTranscription:
+			if transcript == nil {
+				var result asr.Result
+				if err := json.Unmarshal([]byte(*artifact.Content), &result); err == nil {
+					transcript = &result
+				}
+			}
+		}
+	}
+	if transcript == nil {
+		return nil, fmt.Errorf("transcript not found")
+	}
+
+	// Storing is only a cache; sources under legal hold are condensed on every access
+	if err := i.SaveCondensed(ctx, sourceID, transcript); err != nil {
+		logging.FromContext(ctx).Warn("Failed to save condensed view", "source_id", sourceID, "error", err)
+	}
+	return transcript.Condensed(asr.DefaultCondensedOptions()), nil
+}
@@ -0,0 +1,249 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"zbor/internal/logging"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/webfetch"
+)
+
+// crawlTimeout limits how long a whole crawl may take
+const crawlTimeout = 2 * time.Hour
+
+// IngestCrawlOptions contains options for crawling a site into articles
+type IngestCrawlOptions struct {
+	URL          string // start URL
+	MaxDepth     int    // link depth to follow from the start page (0 = start page only)
+	MaxPages     int    // maximum number of pages to fetch (0 = webfetch.DefaultCrawlMaxPages)
+	PathPrefix   string // only follow links under this path (e.g. /docs/)
+	OtherDomains bool   // follow links to other hosts
+	DelaySeconds int    // delay between page fetches (0 = webfetch.DefaultCrawlDelay)
+	Summarize    bool   // queue a summarize job for each saved page
+	Priority     int    // job priority (0-9, lower is higher priority)
+
+	// Extract controls content extraction (nil uses webfetch.DefaultExtractOptions)
+	Extract *webfetch.ExtractOptions
+}
+
+// crawlSourceMetadata is the metadata of a crawl source
+// Each saved page gets its own web source that points back to the crawl source
+type crawlSourceMetadata struct {
+	Crawl     crawlSettings            `json:"crawl"`
+	Summarize bool                     `json:"summarize"`
+	Extract   *webfetch.ExtractOptions `json:"extract,omitempty"`
+	Pages     map[string]string        `json:"pages,omitempty"`  // page URL -> page source ID
+	Failed    map[string]string        `json:"failed,omitempty"` // page URL -> error
+}
+
+type crawlSettings struct {
+	MaxDepth     int    `json:"max_depth"`
+	MaxPages     int    `json:"max_pages"`
+	PathPrefix   string `json:"path_prefix,omitempty"`
+	OtherDomains bool   `json:"other_domains,omitempty"`
+	DelaySeconds int    `json:"delay_seconds,omitempty"`
+}
+
+// IngestCrawl creates a crawl source and queues a crawl job
+func (w *WebIngester) IngestCrawl(ctx context.Context, opts IngestCrawlOptions) (*IngestResult, error) {
+	startURL := strings.TrimSpace(opts.URL)
+	parsed, err := url.Parse(startURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidURL, opts.URL)
+	}
+
+	metadataJSON, _ := json.Marshal(crawlSourceMetadata{
+		Crawl: crawlSettings{
+			MaxDepth:     opts.MaxDepth,
+			MaxPages:     opts.MaxPages,
+			PathPrefix:   opts.PathPrefix,
+			OtherDomains: opts.OtherDomains,
+			DelaySeconds: opts.DelaySeconds,
+		},
+		Summarize: opts.Summarize,
+		Extract:   opts.Extract,
+	})
+	source := &sqlc.Source{
+		Type:        storage.SourceTypeCrawl,
+		OriginalUrl: storage.Ptr(startURL),
+		Metadata:    storage.Ptr(string(metadataJSON)),
+		Status:      storage.Ptr(storage.SourceStatusPending),
+	}
+	if err := w.sourceRepo.Create(ctx, source); err != nil {
+		return nil, fmt.Errorf("failed to create source: %w", err)
+	}
+
+	job := &sqlc.ProcessingJob{
+		SourceID: &source.ID,
+		Type:     storage.JobTypeCrawl,
+		Priority: storage.Ptr(int64(opts.Priority)),
+	}
+	if err := w.jobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return &IngestResult{
+		SourceID: source.ID,
+		JobID:    job.ID,
+	}, nil
+}
+
+// ProcessCrawl crawls from the start URL of a crawl source and saves each page
+// as an article with its own web source (called by the worker)
+// Pages saved by an earlier attempt of the job are not saved again
+func (w *WebIngester) ProcessCrawl(ctx context.Context, job *sqlc.ProcessingJob, onProgress ProgressCallback) error {
+	reportProgress := func(progress int, step string) {
+		if onProgress != nil {
+			onProgress(progress, step)
+		}
+	}
+
+	if job.SourceID == nil {
+		return fmt.Errorf("job has no source ID")
+	}
+	source, err := w.sourceRepo.GetByID(ctx, *job.SourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return fmt.Errorf("source not found: %s", *job.SourceID)
+	}
+	if source.OriginalUrl == nil {
+		return fmt.Errorf("crawl source has no URL")
+	}
+
+	var metadata crawlSourceMetadata
+	if source.Metadata != nil {
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+			return fmt.Errorf("failed to parse source metadata: %w", err)
+		}
+	}
+	if metadata.Pages == nil {
+		metadata.Pages = map[string]string{}
+	}
+	metadata.Failed = map[string]string{}
+
+	if err := w.sourceRepo.UpdateStatus(ctx, source.ID, storage.SourceStatusProcessing); err != nil {
+		return fmt.Errorf("failed to update source status: %w", err)
+	}
+
+	maxPages := metadata.Crawl.MaxPages
+	if maxPages <= 0 {
+		maxPages = webfetch.DefaultCrawlMaxPages
+	}
+	crawlOpts := &webfetch.CrawlOptions{
+		MaxDepth:   metadata.Crawl.MaxDepth,
+		MaxPages:   maxPages,
+		SameDomain: !metadata.Crawl.OtherDomains,
+		PathPrefix: metadata.Crawl.PathPrefix,
+		Delay:      time.Duration(metadata.Crawl.DelaySeconds) * time.Second,
+		Fetch: &webfetch.FetchOptions{
+			BlockAds: true,
+			Extract:  metadata.Extract,
+		},
+	}
+
+	fetched := 0
+	err = w.crawl(ctx, *source.OriginalUrl, crawlOpts, func(page *webfetch.CrawlPage) error {
+		fetched++
+		reportProgress(fetched*95/maxPages, fmt.Sprintf("page %d/%d", fetched, maxPages))
+
+		if page.Err != nil {
+			logging.FromContext(ctx).Warn("Crawl failed to fetch page", "source_id", source.ID, "url", page.URL, "error", page.Err)
+			metadata.Failed[page.URL] = page.Err.Error()
+			return nil
+		}
+		if _, ok := metadata.Pages[page.URL]; ok {
+			return nil
+		}
+		if strings.TrimSpace(page.Result.Content) == "" {
+			metadata.Failed[page.URL] = "no content extracted"
+			return nil
+		}
+
+		pageSourceID, err := w.savePage(ctx, source, &metadata, job, page)
+		if err != nil {
+			return err
+		}
+		metadata.Pages[page.URL] = pageSourceID
+
+		// Record progress so a retried job skips pages already saved
+		metadataJSON, _ := json.Marshal(metadata)
+		if err := w.sourceRepo.UpdateMetadata(ctx, source.ID, string(metadataJSON)); err != nil {
+			return fmt.Errorf("failed to update source metadata: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("crawl failed: %w", err)
+	}
+	if len(metadata.Pages) == 0 {
+		return fmt.Errorf("no pages saved from %s", *source.OriginalUrl)
+	}
+
+	metadataJSON, _ := json.Marshal(metadata)
+	if err := w.sourceRepo.UpdateMetadata(ctx, source.ID, string(metadataJSON)); err != nil {
+		return fmt.Errorf("failed to update source metadata: %w", err)
+	}
+	if err := w.sourceRepo.UpdateStatus(ctx, source.ID, storage.SourceStatusCompleted); err != nil {
+		return fmt.Errorf("failed to update source status: %w", err)
+	}
+
+	reportProgress(100, "")
+	return nil
+}
+
+// savePage creates a web source for a crawled page and saves the page as an article
+func (w *WebIngester) savePage(ctx context.Context, crawlSource *sqlc.Source, crawlMetadata *crawlSourceMetadata, job *sqlc.ProcessingJob, page *webfetch.CrawlPage) (string, error) {
+	pageMetadata := webSourceMetadata{
+		Summarize:     crawlMetadata.Summarize,
+		Extract:       crawlMetadata.Extract,
+		CrawlSourceID: crawlSource.ID,
+	}
+	metadataJSON, _ := json.Marshal(pageMetadata)
+	pageSource := &sqlc.Source{
+		Type:        storage.SourceTypeWeb,
+		OriginalUrl: storage.Ptr(page.URL),
+		Metadata:    storage.Ptr(string(metadataJSON)),
+		Status:      storage.Ptr(storage.SourceStatusProcessing),
+	}
+	if err := w.sourceRepo.Create(ctx, pageSource); err != nil {
+		return "", fmt.Errorf("failed to create page source: %w", err)
+	}
+	if err := w.saveArticle(ctx, pageSource, &pageMetadata, page.Result); err != nil {
+		return "", err
+	}
+
+	if crawlMetadata.Summarize {
+		summarizeJob := &sqlc.ProcessingJob{
+			SourceID: &pageSource.ID,
+			Type:     storage.JobTypeSummarize,
+			Priority: job.Priority,
+		}
+		if err := w.jobRepo.Create(ctx, summarizeJob); err != nil {
+			return "", fmt.Errorf("failed to create summarize job: %w", err)
+		}
+	}
+	return pageSource.ID, nil
+}
+
+// crawl runs a crawl on the shared browser, starting it if needed
+func (w *WebIngester) crawl(ctx context.Context, startURL string, opts *webfetch.CrawlOptions, fn func(*webfetch.CrawlPage) error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	client, err := w.clientLocked()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, crawlTimeout)
+	defer cancel()
+	return client.Crawl(ctx, startURL, opts, fn)
+}
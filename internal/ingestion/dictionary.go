@@ -0,0 +1,115 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// SetReplacementRepository enables the replacement dictionary: its rules are
+// applied to transcripts after recognition (and ITN)
+func (i *AudioIngester) SetReplacementRepository(repo *storage.ReplacementRepository) {
+	i.replacementRepo = repo
+}
+
+// Dictionary returns the replacement dictionary of a source: the enabled rules
+// for all sources followed by the source's own rules (nil when there are none)
+func (i *AudioIngester) Dictionary(ctx context.Context, sourceID string) (*asr.Dictionary, error) {
+	if i.replacementRepo == nil {
+		return nil, nil
+	}
+	rows, err := i.replacementRepo.ListEnabled(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replacement rules: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	rules := make([]asr.ReplacementRule, len(rows))
+	for idx, row := range rows {
+		rules[idx] = replacementRuleOf(row)
+	}
+	return asr.NewDictionary(rules)
+}
+
+// replacementRuleOf converts a stored replacement rule
+func replacementRuleOf(row sqlc.ReplacementRule) asr.ReplacementRule {
+	return asr.ReplacementRule{
+		Pattern:     row.Pattern,
+		Replacement: row.Replacement,
+		Regex:       row.IsRegex != 0,
+	}
+}
+
+// ApplyDictionary applies the source's replacement dictionary to a result
+func (i *AudioIngester) ApplyDictionary(ctx context.Context, sourceID string, result *asr.Result) error {
+	dict, err := i.Dictionary(ctx, sourceID)
+	if err != nil {
+		return err
+	}
+	dict.Apply(result)
+	return nil
+}
+
+// ReapplyDictionary applies the source's current replacement dictionary to its
+// stored transcript (for rules added after transcription) and refreshes the
+// condensed view, the search index and the transcript article.
+// Returns whether the transcript changed
+func (i *AudioIngester) ReapplyDictionary(ctx context.Context, sourceID string) (bool, error) {
+	dict, err := i.Dictionary(ctx, sourceID)
+	if err != nil {
+		return false, err
+	}
+	if dict.Len() == 0 {
+		return false, nil
+	}
+
+	artifacts, err := i.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	var artifact *sqlc.ProcessingArtifact
+	for idx := range artifacts {
+		if artifacts[idx].Type == storage.ArtifactTypeTranscription && artifacts[idx].Content != nil {
+			artifact = &artifacts[idx]
+			break
+		}
+	}
+	if artifact == nil {
+		return false, fmt.Errorf("transcript not found")
+	}
+	var transcript asr.Result
+	if err := json.Unmarshal([]byte(*artifact.Content), &transcript); err != nil {
+		return false, fmt.Errorf("failed to parse transcript: %w", err)
+	}
+
+	originalText := transcript.Text
+	original, _ := json.Marshal(&transcript)
+	dict.Apply(&transcript)
+	// Regroup words if the transcript was stored with them
+	if len(transcript.Words) > 0 {
+		transcript.GroupWords(asr.DefaultWordGroupingConfig())
+	}
+	content, _ := json.Marshal(&transcript)
+	if string(content) == string(original) {
+		return false, nil
+	}
+
+	if err := i.artifactRepo.UpdateContent(ctx, artifact.ID, string(content)); err != nil {
+		return false, fmt.Errorf("failed to save transcript: %w", err)
+	}
+	if err := i.SaveCondensed(ctx, sourceID, &transcript); err != nil {
+		return false, fmt.Errorf("failed to save condensed view: %w", err)
+	}
+	if err := i.IndexTranscript(ctx, sourceID, &transcript); err != nil {
+		return false, fmt.Errorf("failed to index transcript: %w", err)
+	}
+	if err := i.updateTranscriptArticle(ctx, sourceID, originalText, &transcript); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,86 @@
+package ingestion
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"zbor/internal/asr"
+	"zbor/internal/logging"
+)
+
+// EnhancementInfo is stored in the transcription artifact metadata under
+// "enhancement" when the audio was denoised before ASR
+type EnhancementInfo struct {
+	Model string              `json:"model"`
+	Files []*asr.EnhanceStats `json:"files"`
+}
+
+// SetEnhanceModel sets the speech enhancement (denoising) model used by
+// sources ingested with Enhance
+func (i *AudioIngester) SetEnhanceModel(path string) {
+	i.enhanceConfig.ModelPath = path
+}
+
+// EnhancementStatus returns whether speech enhancement can be used
+func (i *AudioIngester) EnhancementStatus() ModelStatus {
+	status := ModelStatus{Model: filepath.Base(i.enhanceConfig.ModelPath), Available: true}
+	if err := i.enhanceConfig.Validate(); err != nil {
+		status.Available = false
+		status.Reason = err.Error()
+	}
+	return status
+}
+
+// CheckEnhancement returns an error wrapping ErrModelUnavailable if the
+// speech enhancement model is not installed
+func (i *AudioIngester) CheckEnhancement() error {
+	if status := i.EnhancementStatus(); !status.Available {
+		return fmt.Errorf("%w: speech enhancement: %s", ErrModelUnavailable, status.Reason)
+	}
+	return nil
+}
+
+// enhanceFiles denoises the files for ASR into temporary WAV files (the
+// stored audio used for playback is left as is). Files an earlier attempt
+// already transcribed are not enhanced again. The returned function removes
+// the enhanced files
+func (i *AudioIngester) enhanceFiles(ctx context.Context, files []string, checkpoints *JobCheckpoints, reportProgress ProgressCallback) ([]string, *EnhancementInfo, func(), error) {
+	if err := i.CheckEnhancement(); err != nil {
+		return nil, nil, nil, err
+	}
+	config := *i.enhanceConfig // Copy config
+	enhancer, err := asr.NewEnhancer(&config)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create enhancer: %w", err)
+	}
+	defer enhancer.Close()
+
+	var temps []string
+	cleanup := func() {
+		for _, path := range temps {
+			os.Remove(path)
+		}
+	}
+
+	info := &EnhancementInfo{Model: filepath.Base(config.ModelPath)}
+	enhanced := make([]string, len(files))
+	for idx, filePath := range files {
+		if checkpoints != nil && checkpoints.files[idx] != nil {
+			enhanced[idx] = filePath
+			continue
+		}
+		reportProgress(10+20*idx/len(files), fmt.Sprintf("enhancing %d/%d", idx+1, len(files)))
+		path, stats, err := enhancer.EnhanceFileTemp(ctx, filePath)
+		if err != nil {
+			cleanup()
+			return nil, nil, nil, fmt.Errorf("failed to enhance %s: %w", filePath, err)
+		}
+		temps = append(temps, path)
+		enhanced[idx] = path
+		logging.FromContext(ctx).Info("Audio enhanced", "file", filePath, "input_rms", stats.InputRMS, "output_rms", stats.OutputRMS, "wall_seconds", stats.WallSeconds)
+		info.Files = append(info.Files, stats)
+	}
+	return enhanced, info, cleanup, nil
+}
@@ -0,0 +1,204 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"zbor/internal/asr"
+	"zbor/internal/logging"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/version"
+
+	"github.com/google/uuid"
+)
+
+// ModelImport is the model recorded for transcripts imported from subtitle
+// files instead of produced by ASR
+const ModelImport = "import"
+
+// ErrInvalidImport is returned when the transcript file or the audio of an
+// import cannot be used (unknown format, no cues, unsupported audio)
+var ErrInvalidImport = errors.New("invalid transcript import")
+
+// ImportTranscriptOptions contains options for importing an existing transcript
+type ImportTranscriptOptions struct {
+	Title    string     // optional title (defaults to the file name)
+	Filename string     // name of the transcript file
+	Data     []byte     // SRT, WebVTT or JSON (asr.Result) content
+	Format   string     // asr.SubtitleFormat* (detected from Filename and Data when empty)
+	Language string     // language of the transcript (defaults to the JSON's language, then "ja")
+	Audio    *AudioFile // optional recording the transcript belongs to (for playback and clips)
+}
+
+// ImportTranscriptResult contains the result of a transcript import
+type ImportTranscriptResult struct {
+	SourceID  string
+	ArticleID string
+	Segments  int
+}
+
+// ImportTranscript stores an existing transcript (subtitles from a meeting
+// tool, a transcript exported elsewhere) as a completed source with its
+// transcription artifact and article, without running ASR. Cues become
+// segments and tokens are synthesized from their text, so search, clips,
+// chapters and exports work as for transcribed audio
+func (i *AudioIngester) ImportTranscript(ctx context.Context, opts ImportTranscriptOptions) (*ImportTranscriptResult, error) {
+	format := opts.Format
+	if format == "" {
+		format = asr.DetectSubtitleFormat(opts.Filename, opts.Data)
+		if format == "" {
+			return nil, fmt.Errorf("%w: unknown transcript format of %s (use %s)", ErrInvalidImport, opts.Filename, strings.Join(asr.SubtitleFormats, ", "))
+		}
+	}
+	result, err := asr.ParseSubtitles(opts.Data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	if opts.Audio != nil && !asr.IsSupportedFormat(opts.Audio.Filename) {
+		return nil, fmt.Errorf("%w: unsupported audio format: %s", ErrInvalidImport, opts.Audio.Filename)
+	}
+
+	language := opts.Language
+	if language == "" {
+		language = result.Language
+	}
+	if language == "" {
+		language = "ja"
+	}
+	result.Language = language
+	title := opts.Title
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(opts.Filename), filepath.Ext(opts.Filename))
+	}
+
+	sourceID := uuid.New().String()
+	sourceDir := filepath.Join(i.dataDir, "sources", "audio", sourceID)
+	if err := os.MkdirAll(sourceDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create source directory: %w", err)
+	}
+	// The imported file is kept next to the audio as the original of the transcript
+	transcriptName := "transcript." + format
+	if err := os.WriteFile(filepath.Join(sourceDir, transcriptName), opts.Data, 0644); err != nil {
+		os.RemoveAll(sourceDir)
+		return nil, fmt.Errorf("failed to save transcript: %w", err)
+	}
+
+	metadata := sourceMetadata{Title: title, Language: language}
+	if opts.Audio != nil {
+		destPath := filepath.Join(sourceDir, filepath.Base(opts.Audio.Filename))
+		if err := saveFile(destPath, opts.Audio.Reader); err != nil {
+			os.RemoveAll(sourceDir)
+			return nil, err
+		}
+		speaker := opts.Audio.Speaker
+		if speaker == "" {
+			speaker = strings.TrimSuffix(opts.Audio.Filename, filepath.Ext(opts.Audio.Filename))
+		}
+		metadata.Files = []string{destPath}
+		metadata.Speakers = []string{speaker}
+	}
+	metadataJSON, _ := json.Marshal(map[string]interface{}{
+		"files":         metadata.Files,
+		"speakers":      metadata.Speakers,
+		"title":         title,
+		"language":      language,
+		"imported_from": format,
+		"transcript":    transcriptName,
+	})
+
+	source := &sqlc.Source{
+		ID:       sourceID,
+		Type:     storage.SourceTypeAudio,
+		FilePath: storage.Ptr(sourceDir),
+		Metadata: storage.Ptr(string(metadataJSON)),
+		Status:   storage.Ptr(storage.SourceStatusProcessing),
+	}
+	if err := i.sourceRepo.Create(ctx, source); err != nil {
+		os.RemoveAll(sourceDir)
+		return nil, fmt.Errorf("failed to create source: %w", err)
+	}
+	if len(metadata.Files) > 0 {
+		i.recordFiles(ctx, sourceID, metadata.Files)
+		// Playback proxy, as produced by transcription for uploaded audio
+		if err := generateProxies(ctx, metadata.Files); err != nil {
+			logging.FromContext(ctx).Warn("Failed to generate proxy", "source_id", sourceID, "error", err)
+		}
+	}
+
+	if err := i.saveImportedTranscript(ctx, source, &metadata, result, opts.Filename, format); err != nil {
+		_ = i.sourceRepo.UpdateStatus(ctx, sourceID, storage.SourceStatusFailed)
+		return nil, err
+	}
+
+	imported := &ImportTranscriptResult{SourceID: sourceID, Segments: len(result.Segments)}
+	articles, err := i.articleRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get article: %w", err)
+	}
+	if len(articles) > 0 {
+		imported.ArticleID = articles[0].ID
+	}
+	return imported, nil
+}
+
+// saveImportedTranscript stores the transcription artifact, condensed view,
+// search index and article of an imported transcript. Unlike
+// saveTranscription no model post-processing (ITN, dictionary,
+// resegmentation) is applied: the text is kept as it was written
+func (i *AudioIngester) saveImportedTranscript(ctx context.Context, source *sqlc.Source, metadata *sourceMetadata, result *asr.Result, filename, format string) error {
+	artifactContent, _ := json.Marshal(result)
+	artifactMetadata, _ := json.Marshal(map[string]interface{}{
+		"model":         ModelImport,
+		"imported_from": format,
+		"filename":      filename,
+		"zbor_version":  version.Version,
+	})
+	artifact := &sqlc.ProcessingArtifact{
+		SourceID: &source.ID,
+		Type:     storage.ArtifactTypeTranscription,
+		Content:  storage.Ptr(string(artifactContent)),
+		Format:   storage.Ptr("json"),
+		Metadata: storage.Ptr(string(artifactMetadata)),
+	}
+	if err := i.artifactRepo.Create(ctx, artifact); err != nil {
+		return fmt.Errorf("failed to save artifact: %w", err)
+	}
+
+	if err := i.SaveCondensed(ctx, source.ID, result); err != nil {
+		return fmt.Errorf("failed to save condensed view: %w", err)
+	}
+	if err := i.IndexTranscript(ctx, source.ID, result); err != nil {
+		return fmt.Errorf("failed to index transcript: %w", err)
+	}
+	if err := i.createTranscriptArticle(ctx, source, metadata, result, result.Language); err != nil {
+		return err
+	}
+
+	if err := i.sourceRepo.UpdateStatus(ctx, source.ID, storage.SourceStatusCompleted); err != nil {
+		return fmt.Errorf("failed to update source status: %w", err)
+	}
+	return nil
+}
+
+// saveFile copies r to a new file at path
+func saveFile(path string, r io.Reader) error {
+	dest, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	_, err = io.Copy(dest, r)
+	if closeErr := dest.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	return nil
+}
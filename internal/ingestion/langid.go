@@ -0,0 +1,145 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"zbor/internal/asr"
+	"zbor/internal/logging"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// SetLanguageID sets the language identification model and how many seconds
+// from the start of the audio it inspects (0 disables language routing)
+func (i *AudioIngester) SetLanguageID(modelDir string, seconds int) {
+	if seconds <= 0 {
+		i.langIDConfig = nil
+		return
+	}
+	if i.langIDConfig == nil {
+		i.langIDConfig = asr.DefaultLangIDConfig(modelDir)
+	}
+	i.langIDConfig.ModelDir = modelDir
+	i.langIDConfig.Seconds = seconds
+}
+
+// LanguageIDStatus returns whether jobs without a requested model are routed
+// by the spoken language
+func (i *AudioIngester) LanguageIDStatus() ModelStatus {
+	if i.langIDConfig == nil {
+		return ModelStatus{Reason: "language identification is disabled"}
+	}
+	status := ModelStatus{Model: filepath.Base(i.langIDConfig.ModelDir), Available: true}
+	if err := i.langIDConfig.Validate(); err != nil {
+		status.Available = false
+		status.Reason = err.Error()
+	}
+	return status
+}
+
+// routeLanguage identifies the spoken language of a job that did not request
+// a model and picks the model for it: Japanese stays on the default model,
+// the other SenseVoice languages (zh, en, ko, yue) go to SenseVoice and the
+// rest to Whisper, each falling back to whichever is installed. The detected
+// language is stored in the source metadata so that retries and
+// retranscriptions do not identify it again. Returns the job to run and the
+// language ("" if it could not be identified; the job is then unchanged)
+func (i *AudioIngester) routeLanguage(ctx context.Context, job *sqlc.ProcessingJob, source *sqlc.Source, metadata *sourceMetadata, reportProgress ProgressCallback) (*sqlc.ProcessingJob, string) {
+	if job.Type != storage.JobTypeTranscribe || len(metadata.Files) == 0 {
+		return job, ""
+	}
+
+	lang := metadata.DetectedLanguage
+	if lang == "" {
+		if status := i.LanguageIDStatus(); !status.Available {
+			return job, ""
+		}
+		reportProgress(9, "identifying language")
+		detected, err := i.identifyLanguage(ctx, metadata.Files[0])
+		if err != nil {
+			logging.FromContext(ctx).Warn("Language identification failed, transcribing with the default model", "error", err)
+			return job, ""
+		}
+		lang = detected
+		if err := i.saveDetectedLanguage(ctx, source, lang); err != nil {
+			logging.FromContext(ctx).Warn("Failed to save detected language", "error", err)
+		}
+	}
+
+	model := i.languageModel(lang)
+	logging.FromContext(ctx).Info("Language identified", "language", lang, "model", model)
+	if model == "" {
+		return job, lang
+	}
+	routed := *job
+	routed.Type = TranscriptionJobType(model)
+	return &routed, lang
+}
+
+func (i *AudioIngester) identifyLanguage(ctx context.Context, path string) (string, error) {
+	config := *i.langIDConfig // Copy config
+	identifier, err := asr.NewLanguageIdentifier(&config)
+	if err != nil {
+		return "", fmt.Errorf("failed to create language identifier: %w", err)
+	}
+	defer identifier.Close()
+	return identifier.IdentifyFile(ctx, path)
+}
+
+// languageModel returns the installed model that transcribes lang best
+// (empty if no model is installed)
+func (i *AudioIngester) languageModel(lang string) string {
+	if lang == "ja" {
+		return i.DefaultModel()
+	}
+	preferred := []string{storage.ASRModelWhisper, storage.ASRModelSenseVoice}
+	if asr.SenseVoiceLanguages[lang] {
+		preferred = []string{storage.ASRModelSenseVoice, storage.ASRModelWhisper}
+	}
+	for _, model := range preferred {
+		if i.modelStatus(model).Available {
+			return model
+		}
+	}
+	return i.DefaultModel()
+}
+
+// saveDetectedLanguage stores the detected language in the source metadata
+// under "detected_language"
+func (i *AudioIngester) saveDetectedLanguage(ctx context.Context, source *sqlc.Source, lang string) error {
+	metadata := map[string]interface{}{}
+	if source.Metadata != nil {
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+			return fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+	metadata["detected_language"] = lang
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if err := i.sourceRepo.UpdateMetadata(ctx, source.ID, string(metadataJSON)); err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+	source.Metadata = storage.Ptr(string(metadataJSON))
+	return nil
+}
+
+// languageConfigs returns copies of the SenseVoice and Whisper configurations
+// that transcribe lang (the configured language if lang is empty)
+func (i *AudioIngester) languageConfigs(lang string) (*asr.SenseVoiceConfig, *asr.WhisperConfig) {
+	if lang == "" {
+		return i.senseVoiceConfig, i.whisperConfig
+	}
+	svConfig := *i.senseVoiceConfig
+	whisperConfig := *i.whisperConfig
+	svConfig.Language = "auto"
+	if asr.SenseVoiceLanguages[lang] {
+		svConfig.Language = lang
+	}
+	whisperConfig.Language = lang
+	return &svConfig, &whisperConfig
+}
@@ -0,0 +1,189 @@
+package ingestion
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// MaxHotwords is the maximum number of hotwords of a job
+const MaxHotwords = 100
+
+// TranscriptionOptions are per-job overrides of the transcription settings.
+// They are given at ingestion (upload form, re-transcription API), stored as
+// the JSON payload of the job and applied by the worker (local or remote).
+// Zero values keep the defaults
+type TranscriptionOptions struct {
+	Model          string `json:"model,omitempty"`           // storage.ASRModel*; selects the job type
+	DecodingMethod string `json:"decoding_method,omitempty"` // greedy_search or modified_beam_search
+	MaxActivePaths int    `json:"max_active_paths,omitempty"`
+
+	// Block-based transcription (ReazonSpeech)
+	SilenceThreshold   float64 `json:"silence_threshold,omitempty"`    // RMS level treated as silence
+	MinSilenceDuration float64 `json:"min_silence_duration,omitempty"` // seconds of silence that split blocks
+	MaxBlockDuration   float64 `json:"max_block_duration,omitempty"`   // seconds before a block is split
+	Tempo              float64 `json:"tempo,omitempty"`                // playback speed applied before ASR (e.g. 0.9 for fast speech)
+	Overlap            float64 `json:"overlap,omitempty"`              // seconds of overlap between blocks
+
+	Hotwords []string `json:"hotwords,omitempty"` // words to favor (ReazonSpeech, decoded with modified_beam_search)
+}
+
+// Block transcription defaults of ProcessTranscription
+const (
+	defaultSilenceThreshold   = 0.0003 // 静かな音声も検出
+	defaultMinSilenceDuration = 0.5    // 500ms以上の無音で分割
+	defaultMaxBlockDuration   = 10.0   // 10秒チャンク
+	defaultTempo              = 1.0    // 通常は速度調整不要
+	defaultOverlap            = 2.0    // 2秒オーバーラップ
+)
+
+// IsZero reports whether no setting is overridden
+func (o *TranscriptionOptions) IsZero() bool {
+	return o == nil || (o.Model == "" && o.DecodingMethod == "" && o.MaxActivePaths == 0 &&
+		o.SilenceThreshold == 0 && o.MinSilenceDuration == 0 && o.MaxBlockDuration == 0 &&
+		o.Tempo == 0 && o.Overlap == 0 && len(o.Hotwords) == 0)
+}
+
+// Validate checks the overrides are within usable ranges
+func (o *TranscriptionOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if o.Model != "" && !isTranscriptionModel(o.Model) {
+		return fmt.Errorf("invalid model: %s", o.Model)
+	}
+	switch o.DecodingMethod {
+	case "", "greedy_search", "modified_beam_search":
+	default:
+		return fmt.Errorf("invalid decoding_method: must be 'greedy_search' or 'modified_beam_search'")
+	}
+	if o.MaxActivePaths < 0 || o.MaxActivePaths > 32 {
+		return fmt.Errorf("max_active_paths must be between 1 and 32")
+	}
+	if o.SilenceThreshold < 0 || o.SilenceThreshold >= 1 {
+		return fmt.Errorf("silence_threshold must be between 0 and 1")
+	}
+	if o.MinSilenceDuration < 0 || o.MinSilenceDuration > 10 {
+		return fmt.Errorf("min_silence_duration must be between 0 and 10 seconds")
+	}
+	if o.MaxBlockDuration < 0 || (o.MaxBlockDuration > 0 && o.MaxBlockDuration < 1) || o.MaxBlockDuration > 60 {
+		return fmt.Errorf("max_block_duration must be between 1 and 60 seconds")
+	}
+	if o.Tempo < 0 || (o.Tempo > 0 && o.Tempo < 0.5) || o.Tempo > 2 {
+		return fmt.Errorf("tempo must be between 0.5 and 2.0")
+	}
+	if o.Overlap < 0 || o.Overlap > 10 {
+		return fmt.Errorf("overlap must be between 0 and 10 seconds")
+	}
+	if len(o.Hotwords) > MaxHotwords {
+		return fmt.Errorf("too many hotwords (max %d)", MaxHotwords)
+	}
+	for _, word := range o.Hotwords {
+		if strings.TrimSpace(word) == "" || strings.ContainsAny(word, "\r\n") {
+			return fmt.Errorf("invalid hotword: %q", word)
+		}
+	}
+	return nil
+}
+
+// isTranscriptionModel reports whether model is a storage.ASRModel* value
+func isTranscriptionModel(model string) bool {
+	switch model {
+	case storage.ASRModelReazonSpeech, storage.ASRModelReazonSpeechLM,
+		storage.ASRModelSenseVoice, storage.ASRModelSenseVoiceBeam, storage.ASRModelSenseVoiceLM,
+		storage.ASRModelWhisper, storage.ASRModelWhisperAlign:
+		return true
+	}
+	return false
+}
+
+// payload returns the options as a job payload (nil when nothing is overridden)
+func (o *TranscriptionOptions) payload() *string {
+	if o.IsZero() {
+		return nil
+	}
+	data, _ := json.Marshal(o)
+	return storage.Ptr(string(data))
+}
+
+// JobTranscriptionOptions parses the transcription overrides in the payload
+// of a job (nil when the job has none)
+func JobTranscriptionOptions(job *sqlc.ProcessingJob) (*TranscriptionOptions, error) {
+	if job.Payload == nil || *job.Payload == "" {
+		return nil, nil
+	}
+	var opts TranscriptionOptions
+	if err := json.Unmarshal([]byte(*job.Payload), &opts); err != nil {
+		return nil, fmt.Errorf("failed to parse job payload: %w", err)
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+	return &opts, nil
+}
+
+// blockSettings returns the silence detection, tempo and overlap of
+// block-based transcription with the overrides applied
+func (o *TranscriptionOptions) blockSettings() (*asr.SilenceConfig, float64, float64) {
+	// オーバーラップ付きsilence検出による文字起こし（VADモデルは使わない）
+	// RMSベースの無音検出 + オーバーラップで連続発話も正確に認識
+	silenceConfig := asr.DefaultSilenceConfig()
+	silenceConfig.SilenceThreshold = defaultSilenceThreshold
+	silenceConfig.MinSilenceDuration = defaultMinSilenceDuration
+	silenceConfig.MaxBlockDuration = defaultMaxBlockDuration
+	tempo := defaultTempo
+	overlap := defaultOverlap
+	if o == nil {
+		return silenceConfig, tempo, overlap
+	}
+	if o.SilenceThreshold > 0 {
+		silenceConfig.SilenceThreshold = o.SilenceThreshold
+	}
+	if o.MinSilenceDuration > 0 {
+		silenceConfig.MinSilenceDuration = o.MinSilenceDuration
+	}
+	if o.MaxBlockDuration > 0 {
+		silenceConfig.MaxBlockDuration = o.MaxBlockDuration
+	}
+	if o.Tempo > 0 {
+		tempo = o.Tempo
+	}
+	if o.Overlap > 0 {
+		overlap = o.Overlap
+	}
+	return silenceConfig, tempo, overlap
+}
+
+// applyDecoding applies the decoding overrides to a ReazonSpeech config
+func (o *TranscriptionOptions) applyDecoding(config *asr.Config) {
+	if o == nil {
+		return
+	}
+	if o.DecodingMethod != "" {
+		config.DecodingMethod = o.DecodingMethod
+	}
+	if o.MaxActivePaths > 0 {
+		config.MaxActivePaths = o.MaxActivePaths
+	}
+	if len(o.Hotwords) > 0 {
+		config.Hotwords = o.Hotwords
+	}
+}
+
+// applySenseVoiceDecoding applies the decoding overrides to a SenseVoice config
+// (hotwords are not supported by SenseVoice)
+func (o *TranscriptionOptions) applySenseVoiceDecoding(config *asr.SenseVoiceConfig) {
+	if o == nil {
+		return
+	}
+	if o.DecodingMethod != "" {
+		config.DecodingMethod = o.DecodingMethod
+	}
+	if o.MaxActivePaths > 0 {
+		config.MaxActivePaths = o.MaxActivePaths
+	}
+}
@@ -0,0 +1,329 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// Pipeline is a named chain of jobs created in one call. Each step is a
+// job that waits for the jobs of the steps it depends on
+type Pipeline struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Steps       []PipelineStep `json:"steps"`
+}
+
+// PipelineStep is one job of a pipeline
+type PipelineStep struct {
+	Name      string   `json:"name"`
+	JobType   string   `json:"job_type"`             // storage.JobType* (transcribe uses the requested model's type)
+	DependsOn []string `json:"depends_on,omitempty"` // steps whose jobs must complete first
+	Optional  bool     `json:"optional,omitempty"`   // skipped when the server can't run it (e.g. summarize without an LLM)
+	Note      string   `json:"note,omitempty"`
+}
+
+// PipelineJob is a job created for a pipeline step
+type PipelineJob struct {
+	Step      string   `json:"step"`
+	JobID     string   `json:"job_id"`
+	Type      string   `json:"type"`
+	DependsOn []string `json:"depends_on,omitempty"` // job IDs
+}
+
+// PipelineResult is the source and jobs created by a pipeline
+type PipelineResult struct {
+	Pipeline string        `json:"pipeline"`
+	SourceID string        `json:"source_id"`
+	Jobs     []PipelineJob `json:"jobs"`
+	Skipped  []string      `json:"skipped,omitempty"` // optional steps that were not created
+}
+
+// PipelinePodcastEpisode downloads, transcribes and publishes a podcast episode
+const PipelinePodcastEpisode = "podcast-episode"
+
+// PipelineTwoPass transcribes with ReazonSpeech and re-decodes the doubtful
+// segments with Whisper (see ProcessRefine)
+const PipelineTwoPass = "two-pass"
+
+// Pipelines lists the named pipelines
+var Pipelines = []Pipeline{
+	{
+		Name:        PipelinePodcastEpisode,
+		Description: "Download a podcast episode, transcribe it with chapters, summarize it and publish show notes and subtitles",
+		Steps: []PipelineStep{
+			{
+				Name:    "transcribe",
+				JobType: storage.JobTypeTranscribe,
+				Note:    "downloads the enclosure, transcribes with the show as the speaker label and splits the article into chapters",
+			},
+			{Name: "subtitles", JobType: storage.JobTypeSubtitles, DependsOn: []string{"transcribe"}},
+			{Name: "summarize", JobType: storage.JobTypeSummarize, DependsOn: []string{"transcribe"}, Optional: true},
+			{Name: "shownotes", JobType: storage.JobTypeShowNotes, DependsOn: []string{"summarize"}},
+		},
+	},
+	{
+		Name:        PipelineTwoPass,
+		Description: "Transcribe with the fast ReazonSpeech model, then re-decode segments with an implausible character rate with Whisper on the ReazonSpeech timestamps",
+		Steps: []PipelineStep{
+			{Name: "transcribe", JobType: storage.JobTypeTranscribeReazonSpeech},
+			{
+				Name:      "refine",
+				JobType:   storage.JobTypeRefine,
+				DependsOn: []string{"transcribe"},
+				Note:      "re-decodes segments with an implausible character rate (dropped or garbled speech) and keeps the Whisper text where it passes the checks",
+			},
+		},
+	},
+}
+
+// FindPipeline returns the named pipeline (nil if unknown)
+func FindPipeline(name string) *Pipeline {
+	for idx := range Pipelines {
+		if Pipelines[idx].Name == name {
+			return &Pipelines[idx]
+		}
+	}
+	return nil
+}
+
+// IngestPodcastPipeline creates a podcast source and queues the jobs of the
+// podcast-episode pipeline. Steps named in skip are not created; steps that
+// depend on a skipped step wait for its dependencies instead
+func (i *AudioIngester) IngestPodcastPipeline(ctx context.Context, opts IngestPodcastOptions, skip ...string) (*PipelineResult, error) {
+	pipeline := FindPipeline(PipelinePodcastEpisode)
+
+	ingest, err := i.IngestPodcastEpisode(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PipelineResult{Pipeline: pipeline.Name, SourceID: ingest.SourceID}
+	first := pipeline.Steps[0]
+	result.Jobs = append(result.Jobs, PipelineJob{
+		Step:  first.Name,
+		JobID: ingest.JobID,
+		Type:  TranscriptionJobType(opts.Model),
+	})
+
+	// Job IDs each step's dependents wait for (a skipped step passes on its own dependencies)
+	waitFor := map[string][]string{first.Name: {ingest.JobID}}
+	skipped := make(map[string]bool, len(skip))
+	for _, name := range skip {
+		skipped[name] = true
+	}
+
+	for _, step := range pipeline.Steps[1:] {
+		var deps []string
+		for _, dep := range step.DependsOn {
+			deps = append(deps, waitFor[dep]...)
+		}
+		if step.Optional && skipped[step.Name] {
+			waitFor[step.Name] = deps
+			result.Skipped = append(result.Skipped, step.Name)
+			continue
+		}
+
+		job := &sqlc.ProcessingJob{
+			SourceID: &ingest.SourceID,
+			Type:     step.JobType,
+			Priority: storage.Ptr(int64(opts.Priority)),
+		}
+		if err := i.jobRepo.CreateAfter(ctx, job, deps...); err != nil {
+			return nil, fmt.Errorf("failed to create %s job: %w", step.Name, err)
+		}
+		waitFor[step.Name] = []string{job.ID}
+		result.Jobs = append(result.Jobs, PipelineJob{
+			Step:      step.Name,
+			JobID:     job.ID,
+			Type:      job.Type,
+			DependsOn: deps,
+		})
+	}
+
+	return result, nil
+}
+
+// ProcessSubtitles stores SRT and VTT subtitles of the source's
+// transcription as artifacts (replacing earlier ones)
+func (i *AudioIngester) ProcessSubtitles(ctx context.Context, job *sqlc.ProcessingJob, onProgress ProgressCallback) error {
+	if job.SourceID == nil {
+		return fmt.Errorf("job has no source ID")
+	}
+	reportProgress := func(progress int, step string) {
+		if onProgress != nil {
+			onProgress(progress, step)
+		}
+	}
+
+	reportProgress(10, "loading transcript")
+	transcript, err := i.latestTranscript(ctx, *job.SourceID)
+	if err != nil {
+		return err
+	}
+
+	artifacts, err := i.artifactRepo.GetBySourceID(ctx, *job.SourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get artifacts: %w", err)
+	}
+
+	reportProgress(50, "exporting")
+	// Same output as the export endpoint's defaults
+	for _, format := range []string{"srt", "vtt"} {
+		content, err := transcript.Export(format, asr.ExportOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", format, err)
+		}
+
+		existing := ""
+		for _, artifact := range artifacts {
+			if artifact.Type == storage.ArtifactTypeSubtitle && artifact.Format != nil && *artifact.Format == format {
+				existing = artifact.ID
+				break
+			}
+		}
+		if existing != "" {
+			err = i.artifactRepo.UpdateContent(ctx, existing, content)
+		} else {
+			err = i.artifactRepo.Create(ctx, &sqlc.ProcessingArtifact{
+				SourceID: job.SourceID,
+				Type:     storage.ArtifactTypeSubtitle,
+				Content:  storage.Ptr(content),
+				Format:   storage.Ptr(format),
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save %s subtitles: %w", format, err)
+		}
+	}
+
+	reportProgress(100, "")
+	return nil
+}
+
+// ProcessShowNotes creates a show-notes article for a transcribed podcast
+// episode: episode details, the summary (if summarized), the chapter list
+// and the feed's description. The article is a child of the transcript article
+func (i *AudioIngester) ProcessShowNotes(ctx context.Context, job *sqlc.ProcessingJob, onProgress ProgressCallback) error {
+	if job.SourceID == nil {
+		return fmt.Errorf("job has no source ID")
+	}
+	reportProgress := func(progress int, step string) {
+		if onProgress != nil {
+			onProgress(progress, step)
+		}
+	}
+
+	reportProgress(10, "loading transcript")
+	source, err := i.sourceRepo.GetByID(ctx, *job.SourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return fmt.Errorf("source not found: %s", *job.SourceID)
+	}
+	var metadata podcastMetadata
+	if source.Metadata != nil {
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+			return fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+
+	articles, err := i.articleRepo.GetBySourceID(ctx, source.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get articles: %w", err)
+	}
+	if len(articles) == 0 {
+		return fmt.Errorf("no article for source: %s", source.ID)
+	}
+	episode := &articles[0]
+
+	transcript, err := i.latestTranscript(ctx, source.ID)
+	if err != nil {
+		return err
+	}
+
+	reportProgress(50, "writing show notes")
+	content := showNotes(source, &metadata, episode, transcript)
+
+	article := &sqlc.Article{
+		Title:       "Show notes: " + episode.Title,
+		Content:     content,
+		Summary:     episode.Summary,
+		SourceType:  storage.Ptr(storage.SourceTypePodcast),
+		SourceUrl:   source.OriginalUrl,
+		PublishedAt: episode.PublishedAt,
+		Language:    episode.Language,
+		ParentID:    &episode.ID,
+		OwnerID:     episode.OwnerID,
+	}
+	if metadata.EpisodeURL != "" {
+		article.SourceUrl = &metadata.EpisodeURL
+	}
+	if err := i.articleRepo.Create(ctx, article); err != nil {
+		return fmt.Errorf("failed to create article: %w", err)
+	}
+
+	reportProgress(100, "")
+	return nil
+}
+
+// showNotes formats the show-notes article body (Markdown)
+func showNotes(source *sqlc.Source, metadata *podcastMetadata, episode *sqlc.Article, transcript *asr.Result) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", episode.Title)
+	if metadata.Show != "" {
+		fmt.Fprintf(&sb, "- 番組: %s\n", metadata.Show)
+	}
+	if metadata.PublishedAt != nil {
+		fmt.Fprintf(&sb, "- 公開日: %s\n", metadata.PublishedAt.Format("2006-01-02"))
+	}
+	if metadata.EpisodeURL != "" {
+		fmt.Fprintf(&sb, "- エピソード: %s\n", metadata.EpisodeURL)
+	}
+	fmt.Fprintf(&sb, "- 長さ: %s\n", formatDuration(float64(transcript.TotalDuration)))
+	fmt.Fprintf(&sb, "- 文字起こし: /articles/%s\n", episode.ID)
+	fmt.Fprintf(&sb, "- 字幕: /api/audio/%s/transcript/export?format=srt（vtt も可）\n", source.ID)
+
+	if episode.Summary != nil && strings.TrimSpace(*episode.Summary) != "" {
+		fmt.Fprintf(&sb, "\n## 概要\n\n%s\n", strings.TrimSpace(*episode.Summary))
+	}
+	if chapters, err := transcript.Export("chapters", asr.ExportOptions{}); err == nil && strings.TrimSpace(chapters) != "" {
+		fmt.Fprintf(&sb, "\n## チャプター\n\n%s", chapters)
+	}
+	if notes := strings.TrimSpace(metadata.ShowNotes); notes != "" {
+		fmt.Fprintf(&sb, "\n## 番組の説明\n\n%s\n", notes)
+	}
+	return sb.String()
+}
+
+// formatDuration formats seconds as h:mm:ss (or m:ss under an hour)
+func formatDuration(seconds float64) string {
+	total := int(seconds)
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
+
+// latestTranscript loads the source's transcription artifact
+func (i *AudioIngester) latestTranscript(ctx context.Context, sourceID string) (*asr.Result, error) {
+	artifacts, err := i.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	for _, artifact := range artifacts {
+		if artifact.Type == storage.ArtifactTypeTranscription && artifact.Content != nil {
+			var result asr.Result
+			if err := json.Unmarshal([]byte(*artifact.Content), &result); err == nil {
+				return &result, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("transcript not found")
+}
@@ -0,0 +1,205 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/webfetch"
+
+	"github.com/google/uuid"
+)
+
+// IngestPodcastOptions contains options for podcast episode ingestion
+type IngestPodcastOptions struct {
+	AudioURL    string    // enclosure URL of the episode audio
+	Title       string    // episode title
+	EpisodeURL  string    // episode page (optional)
+	PublishedAt time.Time // publish date (zero if unknown)
+	ShowNotes   string    // episode description / show notes
+	Show        string    // podcast title (used as the speaker label)
+	Model       string    // ASR model (storage.ASRModel* value, empty for the default)
+	Priority    int       // job priority (0-9, lower is higher priority)
+
+	FeedSourceID string // feed source the episode was found in (recorded in the source metadata)
+}
+
+// podcastMetadata is the source metadata of a podcast episode
+type podcastMetadata struct {
+	Files        []string   `json:"files"`
+	Speakers     []string   `json:"speakers"`
+	Title        string     `json:"title"`
+	PublishedAt  *time.Time `json:"published_at,omitempty"`
+	EpisodeURL   string     `json:"episode_url,omitempty"`
+	ShowNotes    string     `json:"show_notes,omitempty"`
+	Show         string     `json:"show,omitempty"`
+	Model        string     `json:"model,omitempty"`
+	FeedSourceID string     `json:"feed_source_id,omitempty"`
+}
+
+// podcastContentTypes maps audio MIME types to file extensions for
+// enclosure URLs without a usable extension
+var podcastContentTypes = map[string]string{
+	"audio/mpeg":  ".mp3",
+	"audio/mp3":   ".mp3",
+	"audio/mp4":   ".m4a",
+	"audio/x-m4a": ".m4a",
+	"audio/aac":   ".aac",
+	"audio/ogg":   ".ogg",
+	"audio/opus":  ".opus",
+	"audio/flac":  ".flac",
+	"audio/wav":   ".wav",
+	"audio/x-wav": ".wav",
+	"audio/webm":  ".webm",
+}
+
+// IngestPodcastEpisode creates a podcast source and queues a transcription job
+// The audio is downloaded by the job so feed refreshes stay fast
+func (i *AudioIngester) IngestPodcastEpisode(ctx context.Context, opts IngestPodcastOptions) (*IngestResult, error) {
+	if strings.TrimSpace(opts.AudioURL) == "" {
+		return nil, fmt.Errorf("no audio URL provided")
+	}
+	if err := i.CheckQuota(ctx, ModelForJobType(TranscriptionJobType(opts.Model))); err != nil {
+		return nil, err
+	}
+
+	sourceID := uuid.New().String()
+	sourceDir := filepath.Join(i.dataDir, "sources", "podcast", sourceID)
+	if err := os.MkdirAll(sourceDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create source directory: %w", err)
+	}
+
+	metadata := podcastMetadata{
+		Files:        []string{},
+		Speakers:     []string{},
+		Title:        opts.Title,
+		EpisodeURL:   opts.EpisodeURL,
+		ShowNotes:    opts.ShowNotes,
+		Show:         opts.Show,
+		Model:        opts.Model,
+		FeedSourceID: opts.FeedSourceID,
+	}
+	if !opts.PublishedAt.IsZero() {
+		metadata.PublishedAt = &opts.PublishedAt
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	source := &sqlc.Source{
+		ID:          sourceID,
+		Type:        storage.SourceTypePodcast,
+		OriginalUrl: storage.Ptr(opts.AudioURL),
+		FilePath:    storage.Ptr(sourceDir),
+		Metadata:    storage.Ptr(string(metadataJSON)),
+		Status:      storage.Ptr(storage.SourceStatusPending),
+	}
+	if err := i.sourceRepo.Create(ctx, source); err != nil {
+		return nil, fmt.Errorf("failed to create source: %w", err)
+	}
+
+	job := &sqlc.ProcessingJob{
+		SourceID: &sourceID,
+		Type:     TranscriptionJobType(opts.Model),
+		Priority: storage.Ptr(int64(opts.Priority)),
+	}
+	if err := i.jobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return &IngestResult{
+		SourceID: sourceID,
+		JobID:    job.ID,
+	}, nil
+}
+
+// downloadPodcastAudio downloads the enclosure of a podcast source once
+// and records the file in the source metadata
+func (i *AudioIngester) downloadPodcastAudio(ctx context.Context, source *sqlc.Source) error {
+	if source.OriginalUrl == nil || source.FilePath == nil {
+		return fmt.Errorf("podcast source has no URL or directory")
+	}
+
+	var metadata podcastMetadata
+	if source.Metadata != nil {
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+			return fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+
+	// Already downloaded (e.g. on retry or retranscription)
+	if len(metadata.Files) > 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *source.OriginalUrl, nil)
+	if err != nil {
+		return fmt.Errorf("invalid audio URL: %w", err)
+	}
+	req.Header.Set("User-Agent", webfetch.UserAgent)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download audio: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download audio: HTTP %d", resp.StatusCode)
+	}
+
+	ext := podcastExtension(resp.Request.URL.Path, resp.Header.Get("Content-Type"))
+	if ext == "" {
+		return fmt.Errorf("unsupported audio format: %s", *source.OriginalUrl)
+	}
+
+	// Write to a temporary file so an interrupted download is not mistaken for audio
+	outputPath := filepath.Join(*source.FilePath, "episode"+ext)
+	tmpPath := outputPath + ".part"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	_, err = io.Copy(out, resp.Body)
+	out.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to download audio: %w", err)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return fmt.Errorf("failed to save audio: %w", err)
+	}
+
+	speaker := metadata.Show
+	if speaker == "" {
+		speaker = "episode"
+	}
+	metadata.Files = []string{outputPath}
+	metadata.Speakers = []string{speaker}
+	metadataJSON, _ := json.Marshal(metadata)
+	if err := i.sourceRepo.UpdateMetadata(ctx, source.ID, string(metadataJSON)); err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+	source.Metadata = storage.Ptr(string(metadataJSON))
+	i.recordFiles(ctx, source.ID, []string{outputPath})
+
+	return nil
+}
+
+// podcastExtension returns the audio file extension from the URL path,
+// falling back to the response Content-Type ("" if unsupported)
+func podcastExtension(urlPath, contentType string) string {
+	if asr.IsSupportedFormat(urlPath) {
+		return strings.ToLower(path.Ext(urlPath))
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return podcastContentTypes[mediaType]
+}
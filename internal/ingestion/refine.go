@@ -0,0 +1,266 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"zbor/internal/asr"
+	"zbor/internal/logging"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// ErrRefinePending is returned when the source already has a queued or
+// running refine job
+var ErrRefinePending = errors.New("refine job is already queued")
+
+// RefinementInfo is the content of the refinement artifact: the spans the
+// second pass re-decoded and whether each replaced the first-pass text
+type RefinementInfo struct {
+	Model    string           `json:"model"`
+	Accepted int              `json:"accepted"`
+	Spans    []asr.RefineSpan `json:"spans"`
+}
+
+// CheckTwoPass returns an error wrapping ErrModelUnavailable if ReazonSpeech
+// or Whisper (the models of the two passes) is not installed
+func (i *AudioIngester) CheckTwoPass() error {
+	for _, model := range []string{storage.ASRModelReazonSpeech, storage.ASRModelWhisper} {
+		if err := i.CheckModel(model); err != nil {
+			return fmt.Errorf("two-pass transcription: %w", err)
+		}
+	}
+	return nil
+}
+
+// QueueRefine creates a refine job for a transcribed source (the second pass
+// of two-pass transcription run on an existing transcript)
+func (i *AudioIngester) QueueRefine(ctx context.Context, sourceID string, priority int) (string, error) {
+	source, err := i.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return "", fmt.Errorf("source not found: %s", sourceID)
+	}
+	if err := i.sourceRepo.CheckHold(ctx, sourceID); err != nil {
+		return "", err
+	}
+	if err := i.CheckModel(storage.ASRModelWhisper); err != nil {
+		return "", err
+	}
+	pending, err := i.hasPendingJob(ctx, sourceID, storage.JobTypeRefine)
+	if err != nil {
+		return "", err
+	}
+	if pending {
+		return "", ErrRefinePending
+	}
+
+	job := &sqlc.ProcessingJob{
+		SourceID: &sourceID,
+		Type:     storage.JobTypeRefine,
+		Priority: storage.Ptr(int64(priority)),
+	}
+	if err := i.jobRepo.Create(ctx, job); err != nil {
+		return "", fmt.Errorf("failed to create job: %w", err)
+	}
+	return job.ID, nil
+}
+
+// ProcessRefine is the second pass of two-pass transcription: segments of the
+// source's transcript with an implausible character rate are re-decoded with
+// Whisper and aligned on the first pass's timestamps (as whisper:align
+// retranscription of those segments does). The spans and
+// whether each was accepted are stored as the refinement artifact; the
+// transcript, condensed view, search index and article are updated if any
+// span was accepted. Multi-file sources are left as is
+func (i *AudioIngester) ProcessRefine(ctx context.Context, job *sqlc.ProcessingJob, onProgress ProgressCallback) error {
+	reportProgress := func(progress int, step string) {
+		if onProgress != nil {
+			onProgress(progress, step)
+		}
+	}
+
+	if job.SourceID == nil {
+		return fmt.Errorf("job has no source ID")
+	}
+	sourceID := *job.SourceID
+
+	reportProgress(5, "loading transcript")
+	source, err := i.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return fmt.Errorf("source not found: %s", sourceID)
+	}
+	var metadata sourceMetadata
+	if source.Metadata != nil {
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+			return fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+	// Segments of merged multi-file transcripts come from different recordings
+	if len(metadata.Files) != 1 {
+		reportProgress(100, "multi-file source")
+		return nil
+	}
+	audioPath := metadata.Files[0]
+
+	artifacts, err := i.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	var artifact *sqlc.ProcessingArtifact
+	for idx := range artifacts {
+		if artifacts[idx].Type == storage.ArtifactTypeTranscription && artifacts[idx].Content != nil {
+			artifact = &artifacts[idx]
+			break
+		}
+	}
+	if artifact == nil {
+		return fmt.Errorf("transcript not found")
+	}
+	var transcript asr.Result
+	if err := json.Unmarshal([]byte(*artifact.Content), &transcript); err != nil {
+		return fmt.Errorf("failed to parse transcript: %w", err)
+	}
+	originalText := transcript.Text
+
+	opts := asr.DefaultRefineOptions()
+	spans := asr.FindRefineSpans(&transcript, opts)
+	if len(spans) > 0 {
+		if err := i.redecodeSpans(ctx, sourceID, audioPath, transcript.Language, spans, reportProgress); err != nil {
+			return err
+		}
+	}
+
+	reportProgress(85, "saving")
+	accepted := transcript.ApplyRefinements(spans, opts)
+	info := &RefinementInfo{Model: storage.ASRModelWhisper, Accepted: accepted, Spans: spans}
+	if info.Spans == nil {
+		info.Spans = []asr.RefineSpan{}
+	}
+	if err := i.saveRefinement(ctx, sourceID, artifacts, info); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("Transcript refined", "spans", len(spans), "accepted", accepted)
+	if accepted == 0 {
+		reportProgress(100, "")
+		return nil
+	}
+
+	content, _ := json.Marshal(&transcript)
+	if err := i.artifactRepo.UpdateContent(ctx, artifact.ID, string(content)); err != nil {
+		return fmt.Errorf("failed to save transcript: %w", err)
+	}
+	if err := i.SaveCondensed(ctx, sourceID, &transcript); err != nil {
+		return fmt.Errorf("failed to save condensed view: %w", err)
+	}
+	if err := i.IndexTranscript(ctx, sourceID, &transcript); err != nil {
+		return fmt.Errorf("failed to index transcript: %w", err)
+	}
+	if err := i.updateTranscriptArticle(ctx, sourceID, originalText, &transcript); err != nil {
+		return err
+	}
+
+	reportProgress(100, "")
+	return nil
+}
+
+// redecodeSpans transcribes each span with Whisper and sets its Text. Spans
+// that fail are logged and left without text (rejected)
+func (i *AudioIngester) redecodeSpans(ctx context.Context, sourceID, audioPath, language string, spans []asr.RefineSpan, reportProgress ProgressCallback) error {
+	if err := i.CheckModel(storage.ASRModelWhisper); err != nil {
+		return err
+	}
+	_, whisperConfig := i.languageConfigs(language)
+	config := *whisperConfig // Copy config
+	release := i.threadTuner.TuneWhisper(&config)
+	defer release()
+	recognizer, err := asr.NewWhisperRecognizer(&config)
+	if err != nil {
+		return fmt.Errorf("failed to create Whisper recognizer: %w", err)
+	}
+	defer recognizer.Close()
+
+	// ITN only applies to Japanese
+	var itn *bool
+	if language != "" && language != "ja" {
+		itn = storage.Ptr(false)
+	}
+	// Re-decoded text gets the same replacements as the transcript it is compared with
+	dict, err := i.Dictionary(ctx, sourceID)
+	if err != nil {
+		return err
+	}
+	for idx := range spans {
+		span := &spans[idx]
+		reportProgress(10+70*idx/len(spans), fmt.Sprintf("re-decoding %d/%d", idx+1, len(spans)))
+		partial, err := recognizer.TranscribePartial(ctx, audioPath, asr.PartialTranscribeOptions{
+			StartTime: span.StartTime,
+			EndTime:   span.EndTime,
+			Tempo:     1.0,
+		})
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to re-decode span", "start", span.StartTime, "end", span.EndTime, "error", err)
+			continue
+		}
+		PostProcess(storage.ASRModelWhisperAlign, partial)
+		i.ApplyITN(storage.ASRModelWhisperAlign, itn, partial)
+		dict.Apply(partial)
+		span.Text = partial.Text
+	}
+	return nil
+}
+
+// saveRefinement stores the refinement artifact (replacing an earlier one)
+func (i *AudioIngester) saveRefinement(ctx context.Context, sourceID string, artifacts []sqlc.ProcessingArtifact, info *RefinementInfo) error {
+	content, _ := json.Marshal(info)
+	for _, artifact := range artifacts {
+		if artifact.Type == storage.ArtifactTypeRefinement {
+			if err := i.artifactRepo.UpdateContent(ctx, artifact.ID, string(content)); err != nil {
+				return fmt.Errorf("failed to save refinement: %w", err)
+			}
+			return nil
+		}
+	}
+	err := i.artifactRepo.Create(ctx, &sqlc.ProcessingArtifact{
+		SourceID: &sourceID,
+		Type:     storage.ArtifactTypeRefinement,
+		Content:  storage.Ptr(string(content)),
+		Format:   storage.Ptr("json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save refinement: %w", err)
+	}
+	return nil
+}
+
+// updateTranscriptArticle replaces the text of the source's transcript
+// article with the refined text. Articles edited since transcription (whose
+// content is no longer the transcript text) are kept; the chapters are
+// rebuilt either way
+func (i *AudioIngester) updateTranscriptArticle(ctx context.Context, sourceID, originalText string, transcript *asr.Result) error {
+	articles, err := i.articleRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get articles: %w", err)
+	}
+	for idx := range articles {
+		article := &articles[idx]
+		if article.ParentID != nil || article.Content != originalText {
+			continue
+		}
+		article.Content = transcript.FormatAsText()
+		if err := i.articleRepo.Update(ctx, article); err != nil {
+			return fmt.Errorf("failed to update article: %w", err)
+		}
+	}
+	if _, err := i.RebuildChapters(ctx, sourceID); err != nil {
+		return fmt.Errorf("failed to rebuild chapters: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage/sqlc"
+)
+
+// RemoteFile describes an audio file a remote worker downloads for a job
+type RemoteFile struct {
+	Index   int    `json:"index"`
+	Name    string `json:"name"`
+	Speaker string `json:"speaker,omitempty"`
+}
+
+// RemoteTask is what a remote worker needs to run a claimed transcription job
+type RemoteTask struct {
+	Model string       `json:"model"` // storage.ASRModel* value
+	Files []RemoteFile `json:"files"`
+}
+
+// RemoteChunk is one piece of a transcription uploaded by a remote worker
+// Workers split each file into chunks in a deterministic order so that a
+// restarted worker can skip chunks the server already has
+type RemoteChunk struct {
+	File          int           `json:"file"` // index into RemoteTask.Files
+	Text          string        `json:"text"`
+	Tokens        []asr.Token   `json:"tokens,omitempty"`         // times relative to the file start
+	Segments      []asr.Segment `json:"segments,omitempty"`       // times relative to the file start
+	TotalDuration float32       `json:"total_duration,omitempty"` // audio duration covered so far
+	Duration      float64       `json:"duration"`                 // processing time in seconds
+
+	ChunkReports []asr.ChunkReport `json:"chunk_reports,omitempty"` // chunk mode report of the file
+	Metrics      *asr.Metrics      `json:"metrics,omitempty"`       // throughput of the file
+	NBest        []asr.NBest       `json:"nbest,omitempty"`         // hypotheses of ambiguous blocks
+}
+
+// PrepareRemoteTranscription runs the server-side preparation of a claimed job
+// (download, diagnostics, preview proxy) and returns the files to transcribe
+func (i *AudioIngester) PrepareRemoteTranscription(ctx context.Context, job *sqlc.ProcessingJob, onProgress ProgressCallback) (*RemoteTask, error) {
+	reportProgress := func(progress int, step string) {
+		if onProgress != nil {
+			onProgress(progress, step)
+		}
+	}
+
+	if _, _, err := i.prepareSource(ctx, job, reportProgress); err != nil {
+		return nil, err
+	}
+
+	reportProgress(10, "waiting for worker")
+
+	return i.RemoteTask(ctx, job)
+}
+
+// RemoteTask describes the files and model of a job already prepared for remote workers
+func (i *AudioIngester) RemoteTask(ctx context.Context, job *sqlc.ProcessingJob) (*RemoteTask, error) {
+	metadata, err := i.jobSourceMetadata(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata.Files) == 0 {
+		return nil, fmt.Errorf("no audio files in source metadata")
+	}
+
+	task := &RemoteTask{Model: ModelForJobType(job.Type)}
+	for idx, path := range metadata.Files {
+		f := RemoteFile{Index: idx, Name: filepath.Base(path)}
+		if idx < len(metadata.Speakers) {
+			f.Speaker = metadata.Speakers[idx]
+		}
+		task.Files = append(task.Files, f)
+	}
+	return task, nil
+}
+
+// jobSourceMetadata loads the transcription metadata of the job's source
+func (i *AudioIngester) jobSourceMetadata(ctx context.Context, job *sqlc.ProcessingJob) (*sourceMetadata, error) {
+	if job.SourceID == nil {
+		return nil, fmt.Errorf("job has no source ID")
+	}
+	source, err := i.sourceRepo.GetByID(ctx, *job.SourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return nil, fmt.Errorf("source not found: %s", *job.SourceID)
+	}
+
+	var metadata sourceMetadata
+	if source.Metadata != nil {
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+			return nil, fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+	return &metadata, nil
+}
+
+// RemoteFilePath returns the local path of a job's audio file for download
+func (i *AudioIngester) RemoteFilePath(ctx context.Context, job *sqlc.ProcessingJob, index int) (string, error) {
+	metadata, err := i.jobSourceMetadata(ctx, job)
+	if err != nil {
+		return "", err
+	}
+	if index < 0 || index >= len(metadata.Files) {
+		return "", fmt.Errorf("file index out of range: %d", index)
+	}
+	return metadata.Files[index], nil
+}
+
+// CompleteRemoteTranscription assembles uploaded chunks into per-file results
+// and saves them the same way as a locally processed job
+func (i *AudioIngester) CompleteRemoteTranscription(ctx context.Context, job *sqlc.ProcessingJob, chunks []RemoteChunk) error {
+	if job.SourceID == nil {
+		return fmt.Errorf("job has no source ID")
+	}
+	source, err := i.sourceRepo.GetByID(ctx, *job.SourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return fmt.Errorf("source not found: %s", *job.SourceID)
+	}
+
+	var metadata sourceMetadata
+	if source.Metadata != nil {
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+			return fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+
+	allResults, err := assembleChunks(chunks, len(metadata.Files), metadata.Speakers)
+	if err != nil {
+		return err
+	}
+
+	var finalResult *asr.Result
+	if len(allResults) == 1 {
+		finalResult = allResults[0]
+	} else {
+		finalResult = mergeResults(allResults)
+	}
+
+	if err := i.saveTranscription(ctx, job, source, &metadata, finalResult, nil); err != nil {
+		return err
+	}
+	i.recordUsage(ctx, job, allResults)
+	return nil
+}
+
+// assembleChunks concatenates chunks (already in upload order) into one result per file
+func assembleChunks(chunks []RemoteChunk, fileCount int, speakers []string) ([]*asr.Result, error) {
+	if fileCount == 0 {
+		return nil, fmt.Errorf("no audio files in source metadata")
+	}
+
+	results := make([]*asr.Result, fileCount)
+	texts := make([]strings.Builder, fileCount)
+	for idx := range results {
+		results[idx] = &asr.Result{}
+		if idx < len(speakers) {
+			results[idx].Speaker = speakers[idx]
+		}
+	}
+
+	for _, c := range chunks {
+		if c.File < 0 || c.File >= fileCount {
+			return nil, fmt.Errorf("chunk refers to unknown file index %d", c.File)
+		}
+		r := results[c.File]
+		texts[c.File].WriteString(c.Text)
+		r.Tokens = append(r.Tokens, c.Tokens...)
+		r.Segments = append(r.Segments, c.Segments...)
+		r.Duration += c.Duration
+		r.ChunkReports = append(r.ChunkReports, c.ChunkReports...)
+		r.NBest = append(r.NBest, c.NBest...)
+		if c.Metrics != nil {
+			r.Metrics = asr.MergeMetrics(r.Metrics, c.Metrics)
+		}
+		if c.TotalDuration > r.TotalDuration {
+			r.TotalDuration = c.TotalDuration
+		}
+	}
+
+	for idx, r := range results {
+		r.Text = texts[idx].String()
+		sort.SliceStable(r.Tokens, func(a, b int) bool { return r.Tokens[a].StartTime < r.Tokens[b].StartTime })
+		sort.SliceStable(r.Segments, func(a, b int) bool { return r.Segments[a].StartTime < r.Segments[b].StartTime })
+	}
+
+	return results, nil
+}
+
+// NewRemoteChunk builds the chunk a remote worker uploads for a transcribed file
+func NewRemoteChunk(file int, result *asr.Result) RemoteChunk {
+	return RemoteChunk{
+		File:          file,
+		Text:          result.Text,
+		Tokens:        result.Tokens,
+		Segments:      result.Segments,
+		TotalDuration: result.TotalDuration,
+		Duration:      result.Duration,
+		ChunkReports:  result.ChunkReports,
+		Metrics:       result.Metrics,
+		NBest:         result.NBest,
+	}
+}
+
+// result converts a chunk holding a whole file back into the file's result
+func (c RemoteChunk) result() *asr.Result {
+	return &asr.Result{
+		Text:          c.Text,
+		Tokens:        c.Tokens,
+		Segments:      c.Segments,
+		TotalDuration: c.TotalDuration,
+		Duration:      c.Duration,
+		ChunkReports:  c.ChunkReports,
+		Metrics:       c.Metrics,
+		NBest:         c.NBest,
+	}
+}
@@ -0,0 +1,189 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// ResegmentFilter selects the transcripts to resegment. Zero values match all.
+type ResegmentFilter struct {
+	SourceIDs  []string   // only these sources
+	SourceType string     // only sources of this type (audio, youtube, podcast)
+	Since      *time.Time // only sources created at or after this time
+	Until      *time.Time // only sources created before this time
+}
+
+// ResegmentQueueResult reports the jobs created by QueueResegment
+type ResegmentQueueResult struct {
+	Sources []string `json:"sources"` // sources queued (or that would be queued with dryRun)
+	JobIDs  []string `json:"job_ids"`
+	Held    []string `json:"held,omitempty"`    // sources skipped because of a legal hold
+	Pending []string `json:"pending,omitempty"` // sources that already have a queued or running job
+}
+
+// QueueResegment creates a batch-priority resegment job for each transcribed
+// source matching the filter. Sources under legal hold and sources that already
+// have a pending resegment job are skipped. With dryRun only the matching
+// sources are reported and no jobs are created. Segmentation rules given in
+// opts are stored in the job payload (nil: the configured rules at run time)
+func (i *AudioIngester) QueueResegment(ctx context.Context, filter ResegmentFilter, opts *asr.SegmentationOptions, dryRun bool) (*ResegmentQueueResult, error) {
+	var payload *string
+	if opts != nil {
+		data, _ := json.Marshal(opts)
+		payload = storage.Ptr(string(data))
+	}
+
+	sources, err := i.sourceRepo.ListTranscribed(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transcribed sources: %w", err)
+	}
+
+	ids := make(map[string]bool, len(filter.SourceIDs))
+	for _, id := range filter.SourceIDs {
+		ids[id] = true
+	}
+
+	result := &ResegmentQueueResult{Sources: []string{}, JobIDs: []string{}}
+	for _, source := range sources {
+		switch {
+		case len(ids) > 0 && !ids[source.ID],
+			filter.SourceType != "" && source.Type != filter.SourceType,
+			filter.Since != nil && source.CreatedAt.Before(*filter.Since),
+			filter.Until != nil && !source.CreatedAt.Before(*filter.Until):
+			continue
+		}
+
+		if err := i.sourceRepo.CheckHold(ctx, source.ID); err != nil {
+			if errors.Is(err, storage.ErrLegalHold) {
+				result.Held = append(result.Held, source.ID)
+				continue
+			}
+			return nil, err
+		}
+		pending, err := i.hasPendingJob(ctx, source.ID, storage.JobTypeResegment)
+		if err != nil {
+			return nil, err
+		}
+		if pending {
+			result.Pending = append(result.Pending, source.ID)
+			continue
+		}
+
+		result.Sources = append(result.Sources, source.ID)
+		if dryRun {
+			continue
+		}
+		job := &sqlc.ProcessingJob{
+			SourceID: storage.Ptr(source.ID),
+			Type:     storage.JobTypeResegment,
+			Priority: storage.Ptr(int64(storage.JobPriorityBatch)),
+			Payload:  payload,
+		}
+		if err := i.jobRepo.Create(ctx, job); err != nil {
+			return nil, fmt.Errorf("failed to create job: %w", err)
+		}
+		result.JobIDs = append(result.JobIDs, job.ID)
+	}
+	return result, nil
+}
+
+// hasPendingJob reports whether the source has a queued or running job of the type
+func (i *AudioIngester) hasPendingJob(ctx context.Context, sourceID, jobType string) (bool, error) {
+	jobs, err := i.jobRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if job.Type != jobType || job.Status == nil {
+			continue
+		}
+		if *job.Status == storage.JobStatusQueued || *job.Status == storage.JobStatusRunning {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ProcessResegment re-derives the segments of a source's transcript from its
+// stored tokens and refreshes everything derived from the segments: the condensed view, the transcript search index and the article chapters.
+// ASR is not re-run. Transcripts without tokens (caption fallbacks) are left as is.
+// The segmentation rules come from the job payload, else the configured rules.
+func (i *AudioIngester) ProcessResegment(ctx context.Context, job *sqlc.ProcessingJob, onProgress ProgressCallback) error {
+	reportProgress := func(progress int, step string) {
+		if onProgress != nil {
+			onProgress(progress, step)
+		}
+	}
+
+	if job.SourceID == nil {
+		return fmt.Errorf("job has no source ID")
+	}
+	sourceID := *job.SourceID
+
+	opts := i.segmentation
+	if job.Payload != nil && *job.Payload != "" {
+		opts = asr.SegmentationOptions{}
+		if err := json.Unmarshal([]byte(*job.Payload), &opts); err != nil {
+			return fmt.Errorf("failed to parse job payload: %w", err)
+		}
+		if err := opts.Validate(); err != nil {
+			return fmt.Errorf("invalid job payload: %w", err)
+		}
+	}
+
+	artifacts, err := i.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	var artifact *sqlc.ProcessingArtifact
+	for idx := range artifacts {
+		if artifacts[idx].Type == storage.ArtifactTypeTranscription && artifacts[idx].Content != nil {
+			artifact = &artifacts[idx]
+			break
+		}
+	}
+	if artifact == nil {
+		return fmt.Errorf("transcript not found")
+	}
+
+	var transcript asr.Result
+	if err := json.Unmarshal([]byte(*artifact.Content), &transcript); err != nil {
+		return fmt.Errorf("failed to parse transcript: %w", err)
+	}
+
+	reportProgress(20, "resegmenting")
+	if !transcript.Resegment(opts) {
+		reportProgress(100, "no tokens")
+		return nil
+	}
+
+	content, _ := json.Marshal(&transcript)
+	if err := i.artifactRepo.UpdateContent(ctx, artifact.ID, string(content)); err != nil {
+		return fmt.Errorf("failed to save transcript: %w", err)
+	}
+
+	reportProgress(50, "updating condensed view")
+	if err := i.SaveCondensed(ctx, sourceID, &transcript); err != nil {
+		return fmt.Errorf("failed to save condensed view: %w", err)
+	}
+
+	reportProgress(70, "indexing")
+	if err := i.IndexTranscript(ctx, sourceID, &transcript); err != nil {
+		return fmt.Errorf("failed to index transcript: %w", err)
+	}
+
+	reportProgress(90, "rebuilding chapters")
+	if _, err := i.RebuildChapters(ctx, sourceID); err != nil {
+		return fmt.Errorf("failed to rebuild chapters: %w", err)
+	}
+
+	reportProgress(100, "completed")
+	return nil
+}
@@ -0,0 +1,85 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"zbor/internal/asr"
+	"zbor/internal/logging"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// IndexTranscript replaces the search index of a source with the segments
+// of its transcription. Segment indexes are positions in result.Segments
+// (0-based), the same indexes the sync view and retranscription use
+func (i *AudioIngester) IndexTranscript(ctx context.Context, sourceID string, result *asr.Result) error {
+	segments := make([]sqlc.TranscriptSegment, 0, len(result.Segments))
+	for idx, seg := range result.Segments {
+		text := strings.TrimSpace(seg.Text)
+		if text == "" {
+			continue
+		}
+		speaker := seg.Speaker
+		if speaker == "" {
+			speaker = result.Speaker
+		}
+		s := sqlc.TranscriptSegment{
+			SegmentIndex: int64(idx),
+			StartTime:    seg.StartTime,
+			EndTime:      seg.EndTime,
+			Text:         text,
+		}
+		if speaker != "" {
+			s.Speaker = storage.Ptr(speaker)
+		}
+		segments = append(segments, s)
+	}
+	return i.transcriptRepo.Replace(ctx, sourceID, segments)
+}
+
+// IndexMissingTranscripts indexes transcripts saved before transcript search
+// existed. Returns the number of sources indexed
+func (i *AudioIngester) IndexMissingTranscripts(ctx context.Context) (int, error) {
+	sources, err := i.sourceRepo.ListTranscribed(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list transcribed sources: %w", err)
+	}
+
+	indexed := 0
+	for _, source := range sources {
+		if ctx.Err() != nil {
+			return indexed, ctx.Err()
+		}
+		ok, err := i.transcriptRepo.IsIndexed(ctx, source.ID)
+		if err != nil {
+			return indexed, err
+		}
+		if ok {
+			continue
+		}
+
+		artifacts, err := i.artifactRepo.GetBySourceID(ctx, source.ID)
+		if err != nil {
+			return indexed, fmt.Errorf("failed to get artifacts: %w", err)
+		}
+		for _, artifact := range artifacts {
+			if artifact.Type != storage.ArtifactTypeTranscription || artifact.Content == nil {
+				continue
+			}
+			var result asr.Result
+			if err := json.Unmarshal([]byte(*artifact.Content), &result); err != nil {
+				logging.FromContext(ctx).Warn("Skipping transcript index", "source_id", source.ID, "error", err)
+				break
+			}
+			if err := i.IndexTranscript(ctx, source.ID, &result); err != nil {
+				return indexed, err
+			}
+			indexed++
+			break
+		}
+	}
+	return indexed, nil
+}
@@ -0,0 +1,315 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// Chunk sizes of resumable uploads (bytes)
+const (
+	DefaultUploadChunkSize = 8 << 20
+	MinUploadChunkSize     = 256 << 10
+	MaxUploadChunkSize     = 64 << 20
+)
+
+// MaxUploadSize is the largest file a resumable upload accepts (bytes)
+const MaxUploadSize = 16 << 30
+
+// uploadInfoFile holds the Upload of an upload directory; chunks are
+// stored next to it as <index>.part
+const uploadInfoFile = "upload.json"
+
+var (
+	ErrUploadNotFound   = errors.New("upload not found")
+	ErrUploadIncomplete = errors.New("upload is incomplete")
+	ErrInvalidChunk     = errors.New("invalid chunk")
+)
+
+// Upload is a file uploaded in chunks. Chunks can be sent in any order and
+// again after a failure; the client asks for the received chunks to resume
+type Upload struct {
+	ID        string    `json:"upload_id"`
+	Filename  string    `json:"filename"`
+	Size      int64     `json:"size"`
+	ChunkSize int64     `json:"chunk_size"`
+	Chunks    int       `json:"chunks"`
+	Received  []int     `json:"received"` // indexes of the stored chunks
+	Complete  bool      `json:"complete"`
+	OwnerID   string    `json:"owner_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UploadStore keeps the chunks of resumable uploads on disk until the file
+// is ingested (see Open) or the upload is abandoned (see Prune)
+type UploadStore struct {
+	dir string
+	mu  sync.Mutex // serializes chunk writes and removal
+}
+
+// NewUploadStore creates a store in dir (created on first use)
+func NewUploadStore(dir string) *UploadStore {
+	return &UploadStore{dir: dir}
+}
+
+// Create starts an upload of a file of size bytes. chunkSize 0 uses
+// DefaultUploadChunkSize. The upload belongs to the user of ctx
+func (s *UploadStore) Create(ctx context.Context, filename string, size, chunkSize int64) (*Upload, error) {
+	filename = filepath.Base(filename)
+	if !asr.IsSupportedFormat(filename) {
+		return nil, fmt.Errorf("unsupported audio format: %s", filename)
+	}
+	if size <= 0 || size > MaxUploadSize {
+		return nil, fmt.Errorf("size must be between 1 and %d bytes", int64(MaxUploadSize))
+	}
+	if chunkSize == 0 {
+		chunkSize = DefaultUploadChunkSize
+	}
+	if chunkSize < MinUploadChunkSize || chunkSize > MaxUploadChunkSize {
+		return nil, fmt.Errorf("chunk_size must be between %d and %d bytes", MinUploadChunkSize, MaxUploadChunkSize)
+	}
+
+	upload := &Upload{
+		ID:        uuid.New().String(),
+		Filename:  filename,
+		Size:      size,
+		ChunkSize: chunkSize,
+		Chunks:    int((size + chunkSize - 1) / chunkSize),
+		Received:  []int{},
+		OwnerID:   storage.OwnerFromContext(ctx),
+		CreatedAt: time.Now(),
+	}
+	if err := os.MkdirAll(s.uploadDir(upload.ID), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	data, err := json.Marshal(upload)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(s.uploadDir(upload.ID), uploadInfoFile), data, 0644); err != nil {
+		os.RemoveAll(s.uploadDir(upload.ID))
+		return nil, fmt.Errorf("failed to save upload: %w", err)
+	}
+	return upload, nil
+}
+
+// Get returns an upload with the chunks received so far
+func (s *UploadStore) Get(ctx context.Context, id string) (*Upload, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrUploadNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(s.uploadDir(id), uploadInfoFile))
+	if os.IsNotExist(err) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var upload Upload
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, fmt.Errorf("failed to parse upload: %w", err)
+	}
+	// Other users' uploads do not exist for them
+	if owner := storage.OwnerFromContext(ctx); owner != "" && owner != upload.OwnerID {
+		return nil, ErrUploadNotFound
+	}
+
+	entries, err := os.ReadDir(s.uploadDir(id))
+	if err != nil {
+		return nil, err
+	}
+	upload.Received = []int{}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".part")
+		if !ok {
+			continue
+		}
+		if index, err := strconv.Atoi(name); err == nil {
+			upload.Received = append(upload.Received, index)
+		}
+	}
+	sort.Ints(upload.Received)
+	upload.Complete = len(upload.Received) == upload.Chunks
+	return &upload, nil
+}
+
+// WriteChunk stores chunk index of an upload; sending the same chunk again
+// replaces it. Every chunk but the last must be exactly ChunkSize bytes
+func (s *UploadStore) WriteChunk(ctx context.Context, id string, index int, r io.Reader) (*Upload, error) {
+	upload, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= upload.Chunks {
+		return nil, fmt.Errorf("%w: index must be between 0 and %d", ErrInvalidChunk, upload.Chunks-1)
+	}
+	expected := min(upload.ChunkSize, upload.Size-int64(index)*upload.ChunkSize)
+
+	// Written to a temp file so an interrupted request leaves no partial chunk
+	f, err := os.CreateTemp(s.uploadDir(id), "chunk-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chunk: %w", err)
+	}
+	n, err := io.Copy(f, io.LimitReader(r, expected+1))
+	f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to save chunk: %w", err)
+	}
+	if n != expected {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("%w: chunk %d must be %d bytes", ErrInvalidChunk, index, expected)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Aborted while the chunk was being received
+	if _, err := os.Stat(filepath.Join(s.uploadDir(id), uploadInfoFile)); os.IsNotExist(err) {
+		os.Remove(f.Name())
+		return nil, ErrUploadNotFound
+	}
+	if err := os.Rename(f.Name(), s.chunkPath(id, index)); err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to save chunk: %w", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// Open returns the assembled file of a complete upload, read from its chunks
+// in order. Delete the upload once the file is stored
+func (s *UploadStore) Open(ctx context.Context, id string) (*Upload, io.ReadCloser, error) {
+	upload, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !upload.Complete {
+		return nil, nil, fmt.Errorf("%w: %d of %d chunks received", ErrUploadIncomplete, len(upload.Received), upload.Chunks)
+	}
+
+	return upload, newChunkReader(upload.Chunks, func(index int) (io.ReadCloser, error) {
+		return os.Open(s.chunkPath(id, index))
+	}), nil
+}
+
+// Delete removes an upload and its chunks
+func (s *UploadStore) Delete(ctx context.Context, id string) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return os.RemoveAll(s.uploadDir(id))
+}
+
+// Prune removes uploads that received no chunk for olderThan and returns
+// how many were removed
+func (s *UploadStore) Prune(olderThan time.Duration) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read uploads: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cutoff := time.Now().Add(-olderThan)
+	removed := 0
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if lastWrite(filepath.Join(s.dir, e.Name())).After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(s.dir, e.Name())); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// lastWrite returns the latest modification time of the files in dir
+func lastWrite(dir string) time.Time {
+	var latest time.Time
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+func (s *UploadStore) uploadDir(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+func (s *UploadStore) chunkPath(id string, index int) string {
+	return filepath.Join(s.uploadDir(id), strconv.Itoa(index)+".part")
+}
+
+// chunkReader reads count chunks one after another. Each chunk is opened
+// when the previous one reaches EOF, so an upload of many small chunks holds
+// one file descriptor at a time
+type chunkReader struct {
+	open  func(index int) (io.ReadCloser, error)
+	count int
+	next  int           // index of the next chunk to open
+	cur   io.ReadCloser // chunk being read (nil between chunks)
+}
+
+func newChunkReader(count int, open func(index int) (io.ReadCloser, error)) *chunkReader {
+	return &chunkReader{open: open, count: count}
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if r.next == r.count {
+				return 0, io.EOF
+			}
+			f, err := r.open(r.next)
+			if err != nil {
+				return 0, err
+			}
+			r.cur = f
+			r.next++
+		}
+		n, err := r.cur.Read(p)
+		if err == io.EOF {
+			r.cur.Close()
+			r.cur = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.cur == nil {
+		return nil
+	}
+	err := r.cur.Close()
+	r.cur = nil
+	return err
+}
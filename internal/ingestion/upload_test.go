@@ -0,0 +1,78 @@
+package ingestion
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// countingReader counts the Read calls of a chunk and tracks open chunks
+type countingReader struct {
+	io.Reader
+	reads int
+	open  *int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.reads++
+	return r.Reader.Read(p)
+}
+
+func (r *countingReader) Close() error {
+	*r.open--
+	return nil
+}
+
+// TestChunkReader tests that chunks are assembled in order, opened one at a
+// time, and not read again once finished
+func TestChunkReader(t *testing.T) {
+	const count = 2000
+	var want strings.Builder
+	var chunks []*countingReader
+	open, maxOpen := 0, 0
+	r := newChunkReader(count, func(index int) (io.ReadCloser, error) {
+		data := strings.Repeat(string(rune('a'+index%26)), 100+index%300)
+		want.WriteString(data)
+		open++
+		maxOpen = max(maxOpen, open)
+		chunk := &countingReader{Reader: strings.NewReader(data), open: &open}
+		chunks = append(chunks, chunk)
+		return chunk, nil
+	})
+
+	var got bytes.Buffer
+	buf := make([]byte, 256)
+	for {
+		n, err := r.Read(buf)
+		got.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got.String() != want.String() {
+		t.Errorf("assembled %d bytes, want %d", got.Len(), want.Len())
+	}
+	if len(chunks) != count {
+		t.Errorf("opened %d chunks, want %d", len(chunks), count)
+	}
+	if maxOpen != 1 {
+		t.Errorf("%d chunks open at once, want 1", maxOpen)
+	}
+	for i, chunk := range chunks {
+		// ceil(size/256) reads with data, plus at most one returning EOF
+		size := 100 + i%300
+		if limit := (size+255)/256 + 1; chunk.reads > limit {
+			t.Errorf("chunk %d read %d times, want at most %d", i, chunk.reads, limit)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if open != 0 {
+		t.Errorf("%d chunks left open", open)
+	}
+}
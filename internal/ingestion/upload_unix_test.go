@@ -0,0 +1,64 @@
+//go:build unix
+
+package ingestion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestUploadStoreOpenManyChunks tests assembling an upload with more chunks
+// than the file descriptor limit
+func TestUploadStoreOpenManyChunks(t *testing.T) {
+	const count = 2000
+	s := NewUploadStore(t.TempDir())
+	upload := Upload{ID: uuid.New().String(), Filename: "a.wav", ChunkSize: 8, Chunks: count}
+	if err := os.MkdirAll(s.uploadDir(upload.ID), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(upload)
+	if err := os.WriteFile(filepath.Join(s.uploadDir(upload.ID), uploadInfoFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var want bytes.Buffer
+	for index := range count {
+		chunk := []byte(strconv.Itoa(index) + ",")
+		want.Write(chunk)
+		if err := os.WriteFile(s.chunkPath(upload.ID, index), chunk, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		t.Fatal(err)
+	}
+	budget := limit
+	budget.Cur = 256
+	if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &budget); err != nil {
+		t.Skipf("cannot lower the file descriptor limit: %v", err)
+	}
+	defer syscall.Setrlimit(syscall.RLIMIT_NOFILE, &limit)
+
+	_, r, err := s.Open(context.Background(), upload.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want.Bytes()) {
+		t.Errorf("assembled %d bytes, want %d", len(got), want.Len())
+	}
+}
@@ -0,0 +1,325 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/version"
+)
+
+// ErrTranscriptVersionNotFound is returned when a source has no transcript
+// version with the requested ID
+var ErrTranscriptVersionNotFound = errors.New("transcript version not found")
+
+// ErrTranscriptionActive is returned when a version is promoted while a
+// transcription job of the source is queued or running (its result would
+// replace the promoted version)
+var ErrTranscriptionActive = errors.New("a transcription job of the source is queued or running")
+
+// TranscriptVersion is a transcription of a source: the current one or an
+// earlier one replaced by a re-transcription (see PromoteTranscript)
+type TranscriptVersion struct {
+	ID           string                   `json:"id"` // artifact ID
+	Current      bool                     `json:"current"`
+	Model        string                   `json:"model,omitempty"`
+	JobID        string                   `json:"job_id,omitempty"`
+	ZborVersion  string                   `json:"zbor_version,omitempty"`
+	Params       *asr.TranscriptionParams `json:"params,omitempty"` // model and settings (transcriptions since params were recorded)
+	Segments     int                      `json:"segments"`
+	AudioSeconds float32                  `json:"audio_seconds,omitempty"`
+	Archived     bool                     `json:"archived,omitempty"` // content moved to the archive
+	CreatedAt    time.Time                `json:"created_at"`
+	SupersededAt *time.Time               `json:"superseded_at,omitempty"`
+	Metadata     json.RawMessage          `json:"metadata,omitempty"` // artifact metadata (model, enhancement, caption fallback, ...)
+}
+
+// versionMetadata is the part of the transcription artifact metadata read
+// for versions
+type versionMetadata struct {
+	Model        string                   `json:"model"`
+	JobID        string                   `json:"job_id"`
+	ZborVersion  string                   `json:"zbor_version"`
+	Params       *asr.TranscriptionParams `json:"params"`
+	SupersededAt *time.Time               `json:"superseded_at"`
+}
+
+// transcriptionMetadata adds the model, job, transcription parameters and
+// zbor version of a transcription to the artifact metadata (a JSON object or nil)
+func transcriptionMetadata(job *sqlc.ProcessingJob, result *asr.Result, artifactMetadata *string) *string {
+	metadata := map[string]interface{}{}
+	if artifactMetadata != nil {
+		_ = json.Unmarshal([]byte(*artifactMetadata), &metadata)
+	}
+	metadata["model"] = ModelForJobType(job.Type)
+	metadata["job_id"] = job.ID
+	metadata["zbor_version"] = version.Version
+	if result.Params != nil {
+		metadata["params"] = result.Params
+	}
+	data, _ := json.Marshal(metadata)
+	return storage.Ptr(string(data))
+}
+
+// setMetadataKey sets (value != nil) or removes a key of the artifact metadata
+func setMetadataKey(artifactMetadata *string, key string, value interface{}) *string {
+	metadata := map[string]interface{}{}
+	if artifactMetadata != nil {
+		_ = json.Unmarshal([]byte(*artifactMetadata), &metadata)
+	}
+	if value == nil {
+		delete(metadata, key)
+	} else {
+		metadata[key] = value
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(metadata)
+	return storage.Ptr(string(data))
+}
+
+// supersedeTranscripts keeps the source's current transcriptions as earlier
+// versions (recording when they were replaced)
+func (i *AudioIngester) supersedeTranscripts(ctx context.Context, sourceID string) error {
+	artifacts, err := i.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	now := time.Now()
+	for _, artifact := range artifacts {
+		if artifact.Type != storage.ArtifactTypeTranscription {
+			continue
+		}
+		metadata := setMetadataKey(artifact.Metadata, "superseded_at", now)
+		if err := i.artifactRepo.UpdateType(ctx, artifact.ID, storage.ArtifactTypeTranscriptionVersion, metadata); err != nil {
+			return fmt.Errorf("failed to keep transcript version: %w", err)
+		}
+	}
+	return nil
+}
+
+// ResetTranscript prepares a source for a full re-transcription: the current
+// transcription is kept as a version and the artifacts derived from it
+// (condensed view, subtitles, refinement, summaries, ...) and the transcript
+// search index are deleted
+func (i *AudioIngester) ResetTranscript(ctx context.Context, sourceID string) error {
+	if err := i.supersedeTranscripts(ctx, sourceID); err != nil {
+		return err
+	}
+	artifacts, err := i.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	for _, artifact := range artifacts {
+		if artifact.Type == storage.ArtifactTypeTranscriptionVersion {
+			continue
+		}
+		if err := i.artifactRepo.Delete(ctx, artifact.ID); err != nil {
+			return fmt.Errorf("failed to delete artifact: %w", err)
+		}
+	}
+	if err := i.transcriptRepo.DeleteBySourceID(ctx, sourceID); err != nil {
+		return fmt.Errorf("failed to delete transcript index: %w", err)
+	}
+	return nil
+}
+
+// TranscriptVersions lists the transcriptions of a source, the current one
+// first and then earlier versions from the newest
+func (i *AudioIngester) TranscriptVersions(ctx context.Context, sourceID string) ([]TranscriptVersion, error) {
+	artifacts, err := i.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	versions := []TranscriptVersion{}
+	for _, artifact := range artifacts {
+		if artifact.Type != storage.ArtifactTypeTranscription && artifact.Type != storage.ArtifactTypeTranscriptionVersion {
+			continue
+		}
+		versions = append(versions, transcriptVersion(&artifact))
+	}
+	sort.SliceStable(versions, func(a, b int) bool {
+		if versions[a].Current != versions[b].Current {
+			return versions[a].Current
+		}
+		return versions[a].CreatedAt.After(versions[b].CreatedAt)
+	})
+	return versions, nil
+}
+
+// TranscriptVersion returns a transcription of a source by artifact ID (the
+// current one or an earlier version)
+func (i *AudioIngester) TranscriptVersion(ctx context.Context, sourceID, versionID string) (*asr.Result, error) {
+	artifact, err := i.artifactRepo.GetByID(ctx, versionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifact: %w", err)
+	}
+	if artifact == nil || artifact.SourceID == nil || *artifact.SourceID != sourceID || artifact.Content == nil ||
+		(artifact.Type != storage.ArtifactTypeTranscription && artifact.Type != storage.ArtifactTypeTranscriptionVersion) {
+		return nil, ErrTranscriptVersionNotFound
+	}
+	var result asr.Result
+	if err := json.Unmarshal([]byte(*artifact.Content), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse transcript: %w", err)
+	}
+	return &result, nil
+}
+
+// PromoteTranscript makes an earlier version the current transcription. The
+// replaced transcription is kept as a version; the condensed view, search
+// index, chapters and the transcript article (unless edited since) are
+// rebuilt from the promoted version, and stale subtitles and refinements are
+// deleted
+func (i *AudioIngester) PromoteTranscript(ctx context.Context, sourceID, versionID string) (*TranscriptVersion, error) {
+	if err := i.sourceRepo.CheckHold(ctx, sourceID); err != nil {
+		return nil, err
+	}
+	source, err := i.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return nil, fmt.Errorf("source not found: %s", sourceID)
+	}
+
+	jobs, err := i.jobRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if (job.Type == storage.JobTypeTranscribe || strings.HasPrefix(job.Type, storage.JobTypeTranscribe+":")) && job.Status != nil &&
+			(*job.Status == storage.JobStatusQueued || *job.Status == storage.JobStatusRunning) {
+			return nil, ErrTranscriptionActive
+		}
+	}
+
+	artifacts, err := i.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	var target, current *sqlc.ProcessingArtifact
+	for idx := range artifacts {
+		switch {
+		case artifacts[idx].ID == versionID:
+			target = &artifacts[idx]
+		case artifacts[idx].Type == storage.ArtifactTypeTranscription && current == nil:
+			current = &artifacts[idx]
+		}
+	}
+	if target == nil || (target.Type != storage.ArtifactTypeTranscription && target.Type != storage.ArtifactTypeTranscriptionVersion) {
+		return nil, ErrTranscriptVersionNotFound
+	}
+	if target.Type == storage.ArtifactTypeTranscription {
+		version := transcriptVersion(target)
+		return &version, nil
+	}
+	if target.Content == nil {
+		return nil, fmt.Errorf("transcript version %s is archived", versionID)
+	}
+	var result asr.Result
+	if err := json.Unmarshal([]byte(*target.Content), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse transcript: %w", err)
+	}
+	originalText := ""
+	if current != nil && current.Content != nil {
+		var currentResult asr.Result
+		if err := json.Unmarshal([]byte(*current.Content), &currentResult); err == nil {
+			originalText = currentResult.FormatAsText()
+		}
+	}
+
+	if err := i.supersedeTranscripts(ctx, sourceID); err != nil {
+		return nil, err
+	}
+	target.Type = storage.ArtifactTypeTranscription
+	target.Metadata = setMetadataKey(target.Metadata, "superseded_at", nil)
+	if err := i.artifactRepo.UpdateType(ctx, target.ID, target.Type, target.Metadata); err != nil {
+		return nil, fmt.Errorf("failed to promote transcript version: %w", err)
+	}
+	for _, artifact := range artifacts {
+		if artifact.Type == storage.ArtifactTypeSubtitle || artifact.Type == storage.ArtifactTypeRefinement ||
+			artifact.Type == storage.ArtifactTypeBoundaryReport || artifact.Type == storage.ArtifactTypeTranslation {
+			if err := i.artifactRepo.Delete(ctx, artifact.ID); err != nil {
+				return nil, fmt.Errorf("failed to delete artifact: %w", err)
+			}
+		}
+	}
+
+	if err := i.SaveCondensed(ctx, sourceID, &result); err != nil {
+		return nil, fmt.Errorf("failed to save condensed view: %w", err)
+	}
+	if err := i.IndexTranscript(ctx, sourceID, &result); err != nil {
+		return nil, fmt.Errorf("failed to index transcript: %w", err)
+	}
+
+	// A failed re-transcription leaves the source without its article
+	articles, err := i.articleRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get articles: %w", err)
+	}
+	if len(articles) == 0 {
+		var metadata sourceMetadata
+		if source.Metadata != nil {
+			if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+				return nil, fmt.Errorf("failed to parse metadata: %w", err)
+			}
+		}
+		language := result.Language
+		if language == "" {
+			language = "ja"
+		}
+		if err := i.createTranscriptArticle(ctx, source, &metadata, &result, language); err != nil {
+			return nil, err
+		}
+	} else if err := i.updateTranscriptArticle(ctx, sourceID, originalText, &result); err != nil {
+		return nil, err
+	}
+
+	if err := i.sourceRepo.UpdateStatus(ctx, sourceID, storage.SourceStatusCompleted); err != nil {
+		return nil, fmt.Errorf("failed to update source status: %w", err)
+	}
+	version := transcriptVersion(target)
+	return &version, nil
+}
+
+// transcriptVersion describes a transcription artifact
+func transcriptVersion(artifact *sqlc.ProcessingArtifact) TranscriptVersion {
+	version := TranscriptVersion{
+		ID:        artifact.ID,
+		Current:   artifact.Type == storage.ArtifactTypeTranscription,
+		Archived:  artifact.Content == nil,
+		CreatedAt: artifact.CreatedAt,
+	}
+	if artifact.Metadata != nil {
+		var metadata versionMetadata
+		if err := json.Unmarshal([]byte(*artifact.Metadata), &metadata); err == nil {
+			version.Model = metadata.Model
+			version.JobID = metadata.JobID
+			version.ZborVersion = metadata.ZborVersion
+			version.Params = metadata.Params
+			version.SupersededAt = metadata.SupersededAt
+		}
+		if json.Valid([]byte(*artifact.Metadata)) {
+			version.Metadata = json.RawMessage(*artifact.Metadata)
+		}
+	}
+	if artifact.Content != nil {
+		var result struct {
+			Segments      []json.RawMessage `json:"segments"`
+			TotalDuration float32           `json:"total_duration"`
+		}
+		if err := json.Unmarshal([]byte(*artifact.Content), &result); err == nil {
+			version.Segments = len(result.Segments)
+			version.AudioSeconds = result.TotalDuration
+		}
+	}
+	return version
+}
@@ -0,0 +1,285 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/webfetch"
+)
+
+// ErrInvalidURL is returned by IngestURL for URLs that are not http(s)
+var ErrInvalidURL = errors.New("invalid URL")
+
+// fetchTimeout limits how long a single page fetch may take
+const fetchTimeout = 2 * time.Minute
+
+// WebIngester saves web pages as Markdown articles (read-later)
+type WebIngester struct {
+	sourceRepo  *storage.SourceRepository
+	articleRepo *storage.ArticleRepository
+	jobRepo     *storage.JobRepository
+	clientOpts  *webfetch.Options
+
+	mu     sync.Mutex
+	client *webfetch.Client // browser is started on the first fetch
+}
+
+// NewWebIngester creates a new WebIngester
+func NewWebIngester(
+	sourceRepo *storage.SourceRepository,
+	articleRepo *storage.ArticleRepository,
+	jobRepo *storage.JobRepository,
+	clientOpts *webfetch.Options,
+) *WebIngester {
+	return &WebIngester{
+		sourceRepo:  sourceRepo,
+		articleRepo: articleRepo,
+		jobRepo:     jobRepo,
+		clientOpts:  clientOpts,
+	}
+}
+
+// IngestURLOptions contains options for web page ingestion
+type IngestURLOptions struct {
+	URL       string // page URL
+	Title     string // optional title (defaults to the page title)
+	Summarize bool   // queue a summarize job after the article is saved
+	Priority  int    // job priority (0-9, lower is higher priority)
+
+	// Extract controls content extraction (nil uses webfetch.DefaultExtractOptions)
+	Extract *webfetch.ExtractOptions
+
+	FeedSourceID string // feed source the page was found in (recorded in the source metadata)
+}
+
+// webSourceMetadata is the metadata of a web source
+type webSourceMetadata struct {
+	Title     string                   `json:"title"`
+	Summarize bool                     `json:"summarize"`
+	Extract   *webfetch.ExtractOptions `json:"extract,omitempty"`
+	FinalURL  string                   `json:"final_url,omitempty"`
+	Page      *webfetch.Metadata       `json:"page,omitempty"`
+
+	CrawlSourceID string `json:"crawl_source_id,omitempty"` // set for pages saved by a crawl
+	FeedSourceID  string `json:"feed_source_id,omitempty"`  // set for feed entries
+}
+
+// IngestURL creates a web source and queues a fetch job
+// The page is fetched by the job so the request returns immediately
+func (w *WebIngester) IngestURL(ctx context.Context, opts IngestURLOptions) (*IngestResult, error) {
+	pageURL := strings.TrimSpace(opts.URL)
+	parsed, err := url.Parse(pageURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidURL, opts.URL)
+	}
+
+	metadataJSON, _ := json.Marshal(webSourceMetadata{
+		Title:        opts.Title,
+		Summarize:    opts.Summarize,
+		Extract:      opts.Extract,
+		FeedSourceID: opts.FeedSourceID,
+	})
+	source := &sqlc.Source{
+		Type:        storage.SourceTypeWeb,
+		OriginalUrl: storage.Ptr(pageURL),
+		Metadata:    storage.Ptr(string(metadataJSON)),
+		Status:      storage.Ptr(storage.SourceStatusPending),
+	}
+	if err := w.sourceRepo.Create(ctx, source); err != nil {
+		return nil, fmt.Errorf("failed to create source: %w", err)
+	}
+
+	job := &sqlc.ProcessingJob{
+		SourceID: &source.ID,
+		Type:     storage.JobTypeFetch,
+		Priority: storage.Ptr(int64(opts.Priority)),
+	}
+	if err := w.jobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return &IngestResult{
+		SourceID: source.ID,
+		JobID:    job.ID,
+	}, nil
+}
+
+// ProcessFetch fetches the page of a web source and saves it as an article,
+// then queues a summarize job if requested (called by the worker)
+func (w *WebIngester) ProcessFetch(ctx context.Context, job *sqlc.ProcessingJob, onProgress ProgressCallback) error {
+	reportProgress := func(progress int, step string) {
+		if onProgress != nil {
+			onProgress(progress, step)
+		}
+	}
+
+	if job.SourceID == nil {
+		return fmt.Errorf("job has no source ID")
+	}
+	source, err := w.sourceRepo.GetByID(ctx, *job.SourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return fmt.Errorf("source not found: %s", *job.SourceID)
+	}
+	if source.OriginalUrl == nil {
+		return fmt.Errorf("web source has no URL")
+	}
+
+	var metadata webSourceMetadata
+	if source.Metadata != nil {
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+			return fmt.Errorf("failed to parse source metadata: %w", err)
+		}
+	}
+
+	// A retried job may have saved the article already
+	articles, err := w.articleRepo.GetBySourceID(ctx, source.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get articles: %w", err)
+	}
+	if len(articles) == 0 {
+		if err := w.sourceRepo.UpdateStatus(ctx, source.ID, storage.SourceStatusProcessing); err != nil {
+			return fmt.Errorf("failed to update source status: %w", err)
+		}
+
+		reportProgress(10, "fetching")
+		result, err := w.fetch(ctx, *source.OriginalUrl, metadata.Extract)
+		if err != nil {
+			return fmt.Errorf("failed to fetch page: %w", err)
+		}
+		if strings.TrimSpace(result.Content) == "" {
+			return fmt.Errorf("no content extracted from %s", *source.OriginalUrl)
+		}
+
+		reportProgress(80, "saving")
+		if err := w.saveArticle(ctx, source, &metadata, result); err != nil {
+			return err
+		}
+	}
+
+	if metadata.Summarize {
+		reportProgress(95, "queueing summary")
+		summarizeJob := &sqlc.ProcessingJob{
+			SourceID: &source.ID,
+			Type:     storage.JobTypeSummarize,
+			Priority: job.Priority,
+		}
+		if err := w.jobRepo.Create(ctx, summarizeJob); err != nil {
+			return fmt.Errorf("failed to create summarize job: %w", err)
+		}
+	}
+
+	reportProgress(100, "")
+	return nil
+}
+
+// saveArticle creates the article and records the page metadata on the source
+func (w *WebIngester) saveArticle(ctx context.Context, source *sqlc.Source, metadata *webSourceMetadata, result *webfetch.Result) error {
+	page := result.Metadata
+	if page == nil {
+		page = &webfetch.Metadata{}
+	}
+
+	title := metadata.Title
+	if title == "" {
+		title = page.Title
+	}
+	if title == "" {
+		title = result.URL
+	}
+
+	finalURL := result.URL
+	if finalURL == "" {
+		finalURL = *source.OriginalUrl
+	}
+
+	article := &sqlc.Article{
+		Title:       title,
+		Content:     result.Content,
+		SourceType:  storage.Ptr(storage.SourceTypeWeb),
+		SourceUrl:   storage.Ptr(finalURL),
+		PublishedAt: page.PublishedAt,
+		SourceID:    &source.ID,
+	}
+	if page.Author != "" {
+		article.Author = storage.Ptr(page.Author)
+	}
+	if page.Language != "" {
+		article.Language = storage.Ptr(page.Language)
+	}
+	if page.SiteName != "" || page.Excerpt != "" {
+		customJSON, _ := json.Marshal(map[string]string{
+			"site_name": page.SiteName,
+			"excerpt":   page.Excerpt,
+		})
+		article.CustomMetadata = storage.Ptr(string(customJSON))
+	}
+	if err := w.articleRepo.Create(ctx, article); err != nil {
+		return fmt.Errorf("failed to create article: %w", err)
+	}
+
+	metadata.FinalURL = finalURL
+	metadata.Page = page
+	metadataJSON, _ := json.Marshal(metadata)
+	if err := w.sourceRepo.UpdateMetadata(ctx, source.ID, string(metadataJSON)); err != nil {
+		return fmt.Errorf("failed to update source metadata: %w", err)
+	}
+	if err := w.sourceRepo.UpdateStatus(ctx, source.ID, storage.SourceStatusCompleted); err != nil {
+		return fmt.Errorf("failed to update source status: %w", err)
+	}
+	return nil
+}
+
+// fetch fetches a page as Markdown, starting the browser if needed
+// Fetches are serialized because they share one browser
+func (w *WebIngester) fetch(ctx context.Context, pageURL string, extract *webfetch.ExtractOptions) (*webfetch.Result, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	client, err := w.clientLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	return client.FetchMarkdown(ctx, pageURL, &webfetch.FetchOptions{
+		BlockAds: true,
+		Extract:  extract,
+	})
+}
+
+// clientLocked returns the browser client, starting it on first use (w.mu must be held)
+func (w *WebIngester) clientLocked() (*webfetch.Client, error) {
+	if w.client == nil {
+		client, err := webfetch.NewClient(w.clientOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start browser: %w", err)
+		}
+		w.client = client
+	}
+	return w.client, nil
+}
+
+// Close stops the browser if it was started
+func (w *WebIngester) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.client == nil {
+		return nil
+	}
+	err := w.client.Close()
+	w.client = nil
+	return err
+}
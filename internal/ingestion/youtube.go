@@ -0,0 +1,216 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/youtube"
+
+	"github.com/google/uuid"
+)
+
+// IngestYouTubeOptions contains options for YouTube ingestion
+type IngestYouTubeOptions struct {
+	URL      string // video URL
+	Title    string // optional title (defaults to the video title)
+	Language string // caption language for the fallback (default: "ja")
+	Priority int    // job priority (0-9, lower is higher priority)
+	Enhance  bool   // denoise the audio before ASR (see SetEnhanceModel)
+}
+
+// CaptionFallbackInfo is stored as transcription artifact metadata when the
+// transcript comes from YouTube captions instead of ASR
+type CaptionFallbackInfo struct {
+	Degraded        bool   `json:"degraded"`
+	Fallback        string `json:"fallback"`
+	CaptionLanguage string `json:"caption_language"`
+	ASRError        string `json:"asr_error"`
+}
+
+// CaptionFallbackYouTube identifies transcripts built from YouTube captions
+const CaptionFallbackYouTube = "youtube_captions"
+
+// IngestYouTube creates a YouTube source and queues a transcription job
+// The audio is downloaded by the job so the request returns immediately
+func (i *AudioIngester) IngestYouTube(ctx context.Context, opts IngestYouTubeOptions) (*IngestResult, error) {
+	if strings.TrimSpace(opts.URL) == "" {
+		return nil, fmt.Errorf("no video URL provided")
+	}
+	if opts.Language == "" {
+		opts.Language = "ja"
+	}
+	if opts.Enhance {
+		if err := i.CheckEnhancement(); err != nil {
+			return nil, err
+		}
+	}
+	if err := i.CheckQuota(ctx, ModelForJobType(storage.JobTypeTranscribe)); err != nil {
+		return nil, err
+	}
+
+	sourceID := uuid.New().String()
+	sourceDir := filepath.Join(i.dataDir, "sources", "youtube", sourceID)
+	if err := os.MkdirAll(sourceDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create source directory: %w", err)
+	}
+
+	metadata := map[string]interface{}{
+		"files":    []string{},
+		"speakers": []string{},
+		"title":    opts.Title,
+		"language": opts.Language,
+	}
+	if opts.Enhance {
+		metadata["enhance"] = true
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	source := &sqlc.Source{
+		ID:          sourceID,
+		Type:        storage.SourceTypeYouTube,
+		OriginalUrl: storage.Ptr(opts.URL),
+		FilePath:    storage.Ptr(sourceDir),
+		Metadata:    storage.Ptr(string(metadataJSON)),
+		Status:      storage.Ptr(storage.SourceStatusPending),
+	}
+	if err := i.sourceRepo.Create(ctx, source); err != nil {
+		return nil, fmt.Errorf("failed to create source: %w", err)
+	}
+
+	job := &sqlc.ProcessingJob{
+		SourceID: &sourceID,
+		Type:     storage.JobTypeTranscribe,
+		Priority: storage.Ptr(int64(opts.Priority)),
+	}
+	if err := i.jobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return &IngestResult{
+		SourceID: sourceID,
+		JobID:    job.ID,
+	}, nil
+}
+
+// downloadYouTubeAudio downloads the audio track of a YouTube source once
+// and records the file in the source metadata
+func (i *AudioIngester) downloadYouTubeAudio(ctx context.Context, source *sqlc.Source) error {
+	if source.OriginalUrl == nil || source.FilePath == nil {
+		return fmt.Errorf("youtube source has no URL or directory")
+	}
+
+	var metadata map[string]interface{}
+	if source.Metadata != nil {
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+			return fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+
+	// Already downloaded (e.g. on retry or retranscription)
+	if files, ok := metadata["files"].([]interface{}); ok && len(files) > 0 {
+		return nil
+	}
+
+	video, err := i.youtubeClient.GetVideo(*source.OriginalUrl)
+	if err != nil {
+		return fmt.Errorf("failed to get video: %w", err)
+	}
+
+	formats, err := i.youtubeClient.GetAudioFormats(*source.OriginalUrl)
+	if err != nil {
+		return fmt.Errorf("failed to get audio formats: %w", err)
+	}
+	ext := ".m4a"
+	if len(formats) > 0 {
+		ext = formats[0].Extension()
+	}
+
+	outputPath := filepath.Join(*source.FilePath, video.ID+ext)
+	if err := i.youtubeClient.DownloadAudio(*source.OriginalUrl, &youtube.DownloadAudioOptions{
+		Format:     "best",
+		OutputPath: outputPath,
+	}); err != nil {
+		return fmt.Errorf("failed to download audio: %w", err)
+	}
+
+	metadata["files"] = []string{outputPath}
+	metadata["speakers"] = []string{video.Author}
+	if title, _ := metadata["title"].(string); title == "" {
+		metadata["title"] = video.Title
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+	if err := i.sourceRepo.UpdateMetadata(ctx, source.ID, string(metadataJSON)); err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+	source.Metadata = storage.Ptr(string(metadataJSON))
+	i.recordFiles(ctx, source.ID, []string{outputPath})
+
+	return nil
+}
+
+// captionFallback builds a transcription from the video's captions
+// (auto-generated tracks are used when no manual track exists)
+func (i *AudioIngester) captionFallback(videoURL, lang string, asrErr error) (*asr.Result, *CaptionFallbackInfo, error) {
+	video, err := i.youtubeClient.GetVideo(videoURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get video: %w", err)
+	}
+	if !video.HasCaptions() {
+		return nil, nil, fmt.Errorf("no captions available")
+	}
+
+	caption, err := i.youtubeClient.FetchCaption(video, lang)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch captions: %w", err)
+	}
+
+	info := &CaptionFallbackInfo{
+		Degraded:        true,
+		Fallback:        CaptionFallbackYouTube,
+		CaptionLanguage: caption.LanguageCode,
+		ASRError:        asrErr.Error(),
+	}
+	return captionToResult(caption), info, nil
+}
+
+// captionToResult converts caption entries into an asr.Result
+// Each caption entry becomes one segment and one token
+func captionToResult(caption *youtube.CaptionResult) *asr.Result {
+	result := &asr.Result{}
+	var text strings.Builder
+	for _, entry := range caption.Entries {
+		if strings.TrimSpace(entry.Text) == "" {
+			continue
+		}
+		start := entry.StartTime.Seconds()
+		end := entry.EndTime().Seconds()
+
+		result.Tokens = append(result.Tokens, asr.Token{
+			Text:      entry.Text,
+			StartTime: float32(start),
+			Duration:  float32(end - start),
+		})
+		result.Segments = append(result.Segments, asr.Segment{
+			Text:      entry.Text,
+			StartTime: start,
+			EndTime:   end,
+		})
+		text.WriteString(entry.Text)
+
+		if float32(end) > result.TotalDuration {
+			result.TotalDuration = float32(end)
+		}
+	}
+	result.Text = text.String()
+	return result
+}
@@ -0,0 +1,298 @@
+package integrity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"zbor/internal/notify"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// ErrAuditRunning は監査が既に実行中の場合に返す
+var ErrAuditRunning = errors.New("integrity audit is already running")
+
+// auditBatch は1回に読み込むチェックサム・ソースの件数
+const auditBatch = 100
+
+// maxNotifyDetails は通知に含める問題の最大件数
+const maxNotifyDetails = 20
+
+// Problem は監査で見つかった破損・欠損
+type Problem struct {
+	TargetType string  `json:"target_type"`
+	TargetID   string  `json:"target_id"`
+	SourceID   *string `json:"source_id,omitempty"`
+	Status     string  `json:"status"` // mismatch, missing
+	Detail     string  `json:"detail,omitempty"`
+}
+
+// Report は監査の結果
+type Report struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Recorded   int       `json:"recorded"` // 新たにチェックサムを記録した件数
+	Verified   int       `json:"verified"`
+	Problems   []Problem `json:"problems"`
+}
+
+// Auditor はデータディレクトリの音声ファイルとアーティファクトのチェックサムを検証する
+type Auditor struct {
+	sourceRepo   *storage.SourceRepository
+	artifactRepo *storage.ArtifactRepository
+	checksumRepo *storage.ChecksumRepository
+	notifier     *notify.Notifier
+
+	running sync.Mutex // 実行中の監査
+	mu      sync.Mutex
+	last    *Report
+}
+
+// NewAuditor は新しいAuditorを作成
+func NewAuditor(
+	sourceRepo *storage.SourceRepository,
+	artifactRepo *storage.ArtifactRepository,
+	checksumRepo *storage.ChecksumRepository,
+	notifier *notify.Notifier,
+) *Auditor {
+	return &Auditor{
+		sourceRepo:   sourceRepo,
+		artifactRepo: artifactRepo,
+		checksumRepo: checksumRepo,
+		notifier:     notifier,
+	}
+}
+
+// Run は interval ごとに監査を実行（ctx がキャンセルされるまで）
+// 起動直後は実行せず、最初の interval 経過後から始める
+func (a *Auditor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := a.Audit(ctx); err != nil && !errors.Is(err, ErrAuditRunning) {
+			slog.Error("Integrity audit failed", "error", err)
+		}
+	}
+}
+
+// LastReport は直近の監査結果を返す（未実行の場合は nil）
+func (a *Auditor) LastReport() *Report {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.last
+}
+
+// Audit は全チェックサムを検証し、問題があれば通知する
+// チェックサムが未記録のファイル・アーティファクトは現在の内容で記録する
+// 既に実行中の場合は ErrAuditRunning を返す
+func (a *Auditor) Audit(ctx context.Context) (*Report, error) {
+	if !a.running.TryLock() {
+		return nil, ErrAuditRunning
+	}
+	defer a.running.Unlock()
+
+	report := &Report{StartedAt: time.Now(), Problems: []Problem{}}
+
+	recorded, err := a.recordMissing(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.Recorded = recorded
+
+	for offset := 0; ; offset += auditBatch {
+		checksums, err := a.checksumRepo.ListForAudit(ctx, auditBatch, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list checksums: %w", err)
+		}
+		for _, c := range checksums {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			status, detail := a.verify(ctx, c)
+			if err := a.checksumRepo.UpdateStatus(ctx, c.TargetType, c.TargetID, status); err != nil {
+				return nil, fmt.Errorf("failed to update checksum status: %w", err)
+			}
+			report.Verified++
+			if status != storage.ChecksumStatusOK {
+				report.Problems = append(report.Problems, Problem{
+					TargetType: c.TargetType,
+					TargetID:   c.TargetID,
+					SourceID:   c.SourceID,
+					Status:     status,
+					Detail:     detail,
+				})
+			}
+		}
+		if len(checksums) < auditBatch {
+			break
+		}
+	}
+	report.FinishedAt = time.Now()
+
+	a.mu.Lock()
+	a.last = report
+	a.mu.Unlock()
+
+	slog.Info("Integrity audit finished", "verified", report.Verified, "recorded", report.Recorded, "problems", len(report.Problems))
+	if len(report.Problems) > 0 {
+		if err := a.notifier.Notify(ctx, problemMessage(report)); err != nil {
+			slog.Error("Failed to send integrity notification", "error", err)
+		}
+	}
+	return report, nil
+}
+
+// verify はチェックサムを検証し、ステータスと詳細を返す
+func (a *Auditor) verify(ctx context.Context, c sqlc.Checksum) (string, string) {
+	switch c.TargetType {
+	case storage.ChecksumTargetFile:
+		sum, size, err := storage.HashFile(c.TargetID)
+		if os.IsNotExist(err) {
+			return storage.ChecksumStatusMissing, "file not found"
+		}
+		if err != nil {
+			return storage.ChecksumStatusMissing, err.Error()
+		}
+		if sum != c.Sha256 {
+			return storage.ChecksumStatusMismatch, fmt.Sprintf("size %d (recorded %d)", size, c.Size)
+		}
+	case storage.ChecksumTargetArtifact:
+		artifact, err := a.artifactRepo.GetByID(ctx, c.TargetID)
+		if err != nil {
+			return storage.ChecksumStatusMissing, err.Error()
+		}
+		if artifact == nil || artifact.Content == nil {
+			return storage.ChecksumStatusMissing, "artifact content not found"
+		}
+		if storage.HashContent(*artifact.Content) != c.Sha256 {
+			return storage.ChecksumStatusMismatch, fmt.Sprintf("size %d (recorded %d)", len(*artifact.Content), c.Size)
+		}
+	}
+	return storage.ChecksumStatusOK, ""
+}
+
+// recordMissing はチェックサムが未記録の音声ファイル・アーティファクトを記録する
+// （チェックサム導入前に取り込んだデータ用）
+func (a *Auditor) recordMissing(ctx context.Context) (int, error) {
+	recorded := 0
+	for offset := 0; ; offset += auditBatch {
+		sources, err := a.sourceRepo.List(ctx, auditBatch, offset)
+		if err != nil {
+			return recorded, fmt.Errorf("failed to list sources: %w", err)
+		}
+		for _, source := range sources {
+			if source.Status != nil && *source.Status == storage.SourceStatusArchived {
+				continue
+			}
+			n, err := a.recordSource(ctx, &source)
+			if err != nil {
+				return recorded, err
+			}
+			recorded += n
+		}
+		if len(sources) < auditBatch {
+			break
+		}
+	}
+	return recorded, nil
+}
+
+func (a *Auditor) recordSource(ctx context.Context, source *sqlc.Source) (int, error) {
+	recorded := 0
+
+	for _, path := range audioFiles(source) {
+		existing, err := a.checksumRepo.Get(ctx, storage.ChecksumTargetFile, path)
+		if err != nil {
+			return recorded, err
+		}
+		if existing != nil {
+			continue
+		}
+		if err := a.checksumRepo.RecordFile(ctx, source.ID, path); err != nil {
+			// 存在しないファイルは記録できない（ログのみ）
+			slog.Warn("Failed to record checksum", "path", path, "error", err)
+			continue
+		}
+		recorded++
+	}
+
+	artifacts, err := a.artifactRepo.GetBySourceID(ctx, source.ID)
+	if err != nil {
+		return recorded, fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	for _, artifact := range artifacts {
+		if artifact.Content == nil {
+			continue
+		}
+		existing, err := a.checksumRepo.Get(ctx, storage.ChecksumTargetArtifact, artifact.ID)
+		if err != nil {
+			return recorded, err
+		}
+		if existing != nil {
+			continue
+		}
+		if err := a.checksumRepo.RecordArtifact(ctx, &artifact); err != nil {
+			return recorded, err
+		}
+		recorded++
+	}
+	return recorded, nil
+}
+
+// audioFiles はソースのメタデータから保存した音声ファイル（元ファイル・トリム済み）を取得
+// プレビュー用の変換ファイルは再生成できるため対象外。保持ポリシーで元音声を削除したソースも対象外
+func audioFiles(source *sqlc.Source) []string {
+	if source.Metadata == nil {
+		return nil
+	}
+	var metadata struct {
+		Files          []string        `json:"files"`
+		OriginalFiles  []string        `json:"original_files"`
+		StorageCleanup json.RawMessage `json:"storage_cleanup"`
+	}
+	if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+		return nil
+	}
+	if len(metadata.StorageCleanup) > 0 {
+		return nil
+	}
+	return append(metadata.Files, metadata.OriginalFiles...)
+}
+
+// problemMessage は監査結果の通知メッセージを作成
+func problemMessage(report *Report) notify.Message {
+	var details []string
+	for idx, p := range report.Problems {
+		if idx == maxNotifyDetails {
+			details = append(details, fmt.Sprintf("...and %d more", len(report.Problems)-maxNotifyDetails))
+			break
+		}
+		line := fmt.Sprintf("%s %s: %s", p.Status, p.TargetType, p.TargetID)
+		if p.SourceID != nil {
+			line += " (source " + *p.SourceID + ")"
+		}
+		if p.Detail != "" {
+			line += " - " + p.Detail
+		}
+		details = append(details, line)
+	}
+	return notify.Message{
+		Level:   notify.LevelError,
+		Title:   "Integrity audit found problems",
+		Text:    fmt.Sprintf("%d of %d stored items are corrupted or missing", len(report.Problems), report.Verified),
+		Details: details,
+	}
+}
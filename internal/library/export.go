@@ -0,0 +1,288 @@
+package library
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/version"
+)
+
+// exportBatch は記事・ソースを読み出す1回あたりの件数
+const exportBatch = 500
+
+// Export はライブラリ全体を w に tar.gz で書き出す
+// dataDir はこのインスタンスのデータディレクトリ（インポート先でパスを付け替えるために記録する）
+func Export(ctx context.Context, db *storage.DB, dataDir string, w io.Writer) (*Manifest, error) {
+	dataDir, err := filepath.Abs(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := readTables(ctx, db.Queries)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		Version:       version.Version,
+		ExportedAt:    time.Now(),
+		DataDir:       dataDir,
+		Counts:        t.counts(),
+	}
+	for _, source := range t.Sources {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		files, missing, err := sourceMedia(dataDir, source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files of source %s: %w", source.ID, err)
+		}
+		manifest.Media = append(manifest.Media, files...)
+		if missing != "" {
+			manifest.Missing = append(manifest.Missing, missing)
+		}
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := writeJSON(tw, manifestName, manifest); err != nil {
+		return nil, err
+	}
+	for _, e := range t.entries() {
+		if err := writeJSON(tw, dbDir+e.name+".json", e.rows); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range manifest.Media {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err := writeFile(tw, f.Name, f.Path); err != nil {
+			return nil, fmt.Errorf("failed to add %s: %w", f.Path, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// readTables はエクスポートする全ての行を読み出す
+func readTables(ctx context.Context, q *sqlc.Queries) (*tables, error) {
+	t := &tables{}
+
+	for offset := int64(0); ; offset += exportBatch {
+		sources, err := q.ListSources(ctx, sqlc.ListSourcesParams{Limit: exportBatch, Offset: offset})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sources: %w", err)
+		}
+		t.Sources = append(t.Sources, sources...)
+		if len(sources) < exportBatch {
+			break
+		}
+	}
+	for _, source := range t.Sources {
+		id := source.ID
+		artifacts, err := q.GetArtifactsBySourceID(ctx, &id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get artifacts: %w", err)
+		}
+		t.Artifacts = append(t.Artifacts, artifacts...)
+
+		bookmarks, err := q.ListBookmarksBySourceID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list bookmarks: %w", err)
+		}
+		t.Bookmarks = append(t.Bookmarks, bookmarks...)
+	}
+
+	for offset := int64(0); ; offset += exportBatch {
+		articles, err := q.ListArticlesAll(ctx, sqlc.ListArticlesAllParams{Limit: exportBatch, Offset: offset})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list articles: %w", err)
+		}
+		t.Articles = append(t.Articles, articles...)
+		if len(articles) < exportBatch {
+			break
+		}
+	}
+	for _, article := range t.Articles {
+		id := article.ID
+		tags, err := q.GetArticleTags(ctx, &id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get article tags: %w", err)
+		}
+		for _, tag := range tags {
+			tagID := tag.ID
+			t.ArticleTags = append(t.ArticleTags, sqlc.ArticleTag{ArticleID: &id, TagID: &tagID})
+		}
+	}
+
+	for offset := int64(0); ; offset += exportBatch {
+		tags, err := q.ListTags(ctx, sqlc.ListTagsParams{Limit: exportBatch, Offset: offset})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags: %w", err)
+		}
+		t.Tags = append(t.Tags, tags...)
+		if len(tags) < exportBatch {
+			break
+		}
+	}
+
+	holds, err := q.ListLegalHolds(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list legal holds: %w", err)
+	}
+	t.LegalHolds = holds
+	// 履歴は解除済みの対象にも残っているため、エクスポートする全ての対象について読み出す
+	targets := make([]sqlc.ListLegalHoldEventsParams, 0, len(t.Sources)+len(t.Articles))
+	for _, source := range t.Sources {
+		targets = append(targets, sqlc.ListLegalHoldEventsParams{TargetType: storage.HoldTargetSource, TargetID: source.ID})
+	}
+	for _, article := range t.Articles {
+		targets = append(targets, sqlc.ListLegalHoldEventsParams{TargetType: storage.HoldTargetArticle, TargetID: article.ID})
+	}
+	for _, target := range targets {
+		events, err := q.ListLegalHoldEvents(ctx, target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list legal hold events: %w", err)
+		}
+		t.LegalHoldEvents = append(t.LegalHoldEvents, events...)
+	}
+
+	return t, nil
+}
+
+// sourceMedia はソースディレクトリ内のファイルを列挙する
+// データディレクトリ内のファイルは media/<データディレクトリからの相対パス>、
+// 外部のファイルは media/external/<ソースID>/<相対パス> に格納する
+// ディレクトリが無い場合（アーカイブ済みなど）は missing にパスを返す
+func sourceMedia(dataDir string, source sqlc.Source) (files []MediaFile, missing string, err error) {
+	if source.FilePath == nil || *source.FilePath == "" {
+		return nil, "", nil
+	}
+	root, err := filepath.Abs(*source.FilePath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	info, err := os.Stat(root)
+	if os.IsNotExist(err) {
+		return nil, root, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	prefix := "external/" + source.ID
+	if rel, ok := within(dataDir, root); ok {
+		prefix = rel
+	}
+
+	// ファイル単体（file_path がファイルを指すソース）
+	if !info.IsDir() {
+		return []MediaFile{{
+			SourceID: source.ID,
+			Name:     mediaDir + prefix,
+			Path:     root,
+			Size:     info.Size(),
+		}}, "", nil
+	}
+
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || strings.HasSuffix(p, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, MediaFile{
+			SourceID: source.ID,
+			Name:     mediaDir + path.Join(prefix, filepath.ToSlash(rel)),
+			Path:     p,
+			Size:     fi.Size(),
+		})
+		return nil
+	})
+	return files, "", err
+}
+
+// within は p が dir の中にあれば dir からの相対パス（スラッシュ区切り）を返す
+func within(dir, p string) (string, bool) {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// writeJSON は v をJSONにして name で格納する
+func writeJSON(tw *tar.Writer, name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	return err
+}
+
+// writeFile はファイルの内容を name で格納する
+func writeFile(tw *tar.Writer, name, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// サイズは開いた時点のものを使う（列挙後に書き換えられても壊れないように）
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(tw, f, info.Size())
+	return err
+}
@@ -0,0 +1,607 @@
+package library
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// ConflictPolicy はインポート先に同じIDの行がある場合の扱い
+type ConflictPolicy string
+
+const (
+	ConflictSkip ConflictPolicy = "skip" // 既存の行を残し、アーカイブ側（と、その成果物・ブックマーク）を取り込まない
+	ConflictCopy ConflictPolicy = "copy" // 新しいIDを割り当てて別の行として取り込む
+	ConflictFail ConflictPolicy = "fail" // 何も変更せずにエラーにする
+)
+
+// ParseConflictPolicy は文字列を ConflictPolicy に変換
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case ConflictSkip, ConflictCopy, ConflictFail:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy: %s (skip, copy or fail)", s)
+}
+
+// ImportOptions はインポートの設定
+type ImportOptions struct {
+	DataDir    string         // インポート先のデータディレクトリ
+	OnConflict ConflictPolicy // デフォルト: skip
+	DryRun     bool           // 衝突の確認だけ行い、何も書き込まない
+}
+
+// ImportReport はインポートの結果
+type ImportReport struct {
+	Manifest     *Manifest
+	Imported     map[string]int // テーブルごとの取り込んだ行数
+	Skipped      map[string]int // テーブルごとの取り込まなかった行数
+	Conflicts    []string       // IDが衝突した行（"source <id>" など）
+	Renamed      int            // 新しいIDを割り当てた行数（copy）
+	FilesWritten int
+	FilesKept    int // 既に存在したため上書きしなかったファイル数
+}
+
+// importer は1回のインポートの状態
+type importer struct {
+	db      *storage.DB
+	opts    ImportOptions
+	dataDir string
+	report  *ImportReport
+
+	manifest *Manifest
+	t        tables
+	media    map[string]MediaFile // アーカイブ内のパス → ファイル
+
+	planned  bool
+	ids      map[string]string // 元のID → 新しいID（copy）
+	skipped  map[string]bool   // 取り込まないソース・記事・成果物のID
+	paths    *strings.Replacer // 元のパス → インポート先のパス
+	idSwap   *strings.Replacer // 元のID → 新しいID
+	written  []string          // 書き込んだファイル（失敗時に削除）
+	finished bool
+}
+
+// Import は Export で書き出したアーカイブを取り込む
+// ソース・記事・成果物のID、作成日時、状態はそのまま引き継ぐ
+// ファイルはデータディレクトリ内の同じ相対パスに展開し、DB内のパスを付け替える
+func Import(ctx context.Context, db *storage.DB, r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	if opts.OnConflict == "" {
+		opts.OnConflict = ConflictSkip
+	}
+	dataDir, err := filepath.Abs(opts.DataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	im := &importer{
+		db:      db,
+		opts:    opts,
+		dataDir: dataDir,
+		report: &ImportReport{
+			Imported: map[string]int{},
+			Skipped:  map[string]int{},
+		},
+		ids:     map[string]string{},
+		skipped: map[string]bool{},
+	}
+	defer im.cleanup()
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a library archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if err := im.readEntry(ctx, hdr, tr); err != nil {
+			return nil, err
+		}
+		if im.planned && opts.DryRun {
+			return im.report, nil
+		}
+	}
+
+	if !im.planned {
+		if err := im.plan(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if opts.DryRun {
+		return im.report, nil
+	}
+	if err := im.insert(ctx); err != nil {
+		return nil, err
+	}
+	im.finished = true
+	return im.report, nil
+}
+
+// readEntry はアーカイブの1エントリを処理する
+// manifest.json と db/ が先、media/ が後に格納されている
+func (im *importer) readEntry(ctx context.Context, hdr *tar.Header, r io.Reader) error {
+	name := hdr.Name
+	switch {
+	case name == manifestName:
+		var m Manifest
+		if err := json.NewDecoder(r).Decode(&m); err != nil {
+			return fmt.Errorf("failed to read manifest: %w", err)
+		}
+		if m.FormatVersion < 1 || m.FormatVersion > FormatVersion {
+			return fmt.Errorf("unsupported archive format version %d", m.FormatVersion)
+		}
+		im.manifest = &m
+		im.report.Manifest = &m
+		im.media = make(map[string]MediaFile, len(m.Media))
+		for _, f := range m.Media {
+			im.media[f.Name] = f
+		}
+		return nil
+
+	case strings.HasPrefix(name, dbDir):
+		table := strings.TrimSuffix(strings.TrimPrefix(name, dbDir), ".json")
+		for _, e := range im.t.entries() {
+			if e.name == table {
+				if err := json.NewDecoder(r).Decode(e.rows); err != nil {
+					return fmt.Errorf("failed to read %s: %w", name, err)
+				}
+				return nil
+			}
+		}
+		return nil // 新しいバージョンで追加されたテーブルは無視
+
+	case strings.HasPrefix(name, mediaDir):
+		if !im.planned {
+			if err := im.plan(ctx); err != nil {
+				return err
+			}
+			if im.opts.DryRun {
+				return nil
+			}
+		}
+		return im.writeMedia(name, r)
+	}
+	return nil
+}
+
+// plan は衝突を確認し、IDとパスの付け替えを決める（書き込みはしない）
+func (im *importer) plan(ctx context.Context) error {
+	im.planned = true
+	if im.manifest == nil {
+		return fmt.Errorf("not a library archive: %s is missing", manifestName)
+	}
+	q := im.db.Queries
+
+	check := func(kind, id string, exists func() error) error {
+		err := exists()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check %s %s: %w", kind, id, err)
+		}
+		im.report.Conflicts = append(im.report.Conflicts, kind+" "+id)
+		switch im.opts.OnConflict {
+		case ConflictSkip:
+			im.skipped[id] = true
+		case ConflictCopy:
+			im.ids[id] = uuid.New().String()
+		}
+		return nil
+	}
+
+	for _, s := range im.t.Sources {
+		err := check("source", s.ID, func() error {
+			_, err := q.GetSourceByID(ctx, s.ID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, a := range im.t.Artifacts {
+		// ソースを取り込まない場合は成果物も取り込まない
+		if a.SourceID != nil && im.skipped[*a.SourceID] {
+			im.skipped[a.ID] = true
+			continue
+		}
+		err := check("artifact", a.ID, func() error {
+			_, err := q.GetArtifactByID(ctx, a.ID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, a := range im.t.Articles {
+		err := check("article", a.ID, func() error {
+			_, err := q.GetArticleByID(ctx, a.ID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if im.opts.OnConflict == ConflictFail && len(im.report.Conflicts) > 0 {
+		shown := im.report.Conflicts
+		if len(shown) > 10 {
+			shown = shown[:10]
+		}
+		return fmt.Errorf("%d rows already exist (%s)", len(im.report.Conflicts), strings.Join(shown, ", "))
+	}
+	im.report.Renamed = len(im.ids)
+
+	// パスの付け替え: データディレクトリ外のソースは <データディレクトリ>/imported/<ソースID> に展開する
+	var pairs []string
+	for _, s := range im.t.Sources {
+		if s.FilePath == nil || *s.FilePath == "" {
+			continue
+		}
+		if _, ok := within(im.manifest.DataDir, *s.FilePath); !ok {
+			pairs = append(pairs, *s.FilePath, filepath.Join(im.dataDir, "imported", s.ID))
+		}
+	}
+	pairs = append(pairs, im.manifest.DataDir+string(filepath.Separator), im.dataDir+string(filepath.Separator))
+	im.paths = strings.NewReplacer(pairs...)
+
+	pairs = pairs[:0]
+	for oldID, newID := range im.ids {
+		pairs = append(pairs, oldID, newID)
+	}
+	im.idSwap = strings.NewReplacer(pairs...)
+	return nil
+}
+
+// writeMedia はファイルをインポート先に展開する（既存のファイルは上書きしない）
+func (im *importer) writeMedia(name string, r io.Reader) error {
+	f, ok := im.media[name]
+	if !ok || path.Clean(name) != name {
+		return fmt.Errorf("unexpected archive entry: %s", name)
+	}
+	if im.skipped[f.SourceID] {
+		return nil
+	}
+
+	rel := strings.TrimPrefix(name, mediaDir)
+	if strings.HasPrefix(rel, "external/") {
+		rel = "imported/" + strings.TrimPrefix(rel, "external/")
+	}
+	target := filepath.Join(im.dataDir, filepath.FromSlash(im.idSwap.Replace(rel)))
+	if _, ok := within(im.dataDir, target); !ok {
+		return fmt.Errorf("unexpected archive entry: %s", name)
+	}
+
+	if _, err := os.Stat(target); err == nil {
+		im.report.FilesKept++
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	tmp := target + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	im.written = append(im.written, target)
+	im.report.FilesWritten++
+	return nil
+}
+
+// cleanup は失敗したインポートで書き込んだファイルを削除する
+func (im *importer) cleanup() {
+	if im.finished {
+		return
+	}
+	for _, p := range im.written {
+		os.Remove(p)
+	}
+}
+
+// id は取り込む行のIDを返す（copy で付け替えた場合は新しいID）
+func (im *importer) id(id string) string {
+	if newID, ok := im.ids[id]; ok {
+		return newID
+	}
+	return id
+}
+
+// idPtr は id のポインタ版
+func (im *importer) idPtr(id *string) *string {
+	if id == nil {
+		return nil
+	}
+	return storage.Ptr(im.id(*id))
+}
+
+// rewrite はパスやIDを含む文字列（file_path、メタデータ）を付け替える
+func (im *importer) rewrite(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	return storage.Ptr(im.idSwap.Replace(im.paths.Replace(*s)))
+}
+
+// insert は全ての行を1つのトランザクションで登録する
+func (im *importer) insert(ctx context.Context) error {
+	tx, err := im.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := im.db.Queries.WithTx(tx)
+	count := func(table string, skipped bool) {
+		if skipped {
+			im.report.Skipped[table]++
+		} else {
+			im.report.Imported[table]++
+		}
+	}
+
+	for _, s := range im.t.Sources {
+		if im.skipped[s.ID] {
+			count("sources", true)
+			continue
+		}
+		err := q.CreateSource(ctx, sqlc.CreateSourceParams{
+			ID:          im.id(s.ID),
+			Type:        s.Type,
+			OriginalUrl: s.OriginalUrl,
+			FilePath:    im.rewrite(s.FilePath),
+			Metadata:    im.rewrite(s.Metadata),
+			CreatedAt:   s.CreatedAt,
+			Status:      s.Status,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert source %s: %w", s.ID, err)
+		}
+		count("sources", false)
+	}
+
+	for _, a := range im.t.Artifacts {
+		if im.skipped[a.ID] {
+			count("artifacts", true)
+			continue
+		}
+		err := q.CreateArtifact(ctx, sqlc.CreateArtifactParams{
+			ID:        im.id(a.ID),
+			SourceID:  im.idPtr(a.SourceID),
+			Type:      a.Type,
+			Content:   a.Content,
+			Format:    a.Format,
+			FilePath:  im.rewrite(a.FilePath),
+			Metadata:  im.rewrite(a.Metadata),
+			CreatedAt: a.CreatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert artifact %s: %w", a.ID, err)
+		}
+		count("artifacts", false)
+	}
+
+	for _, a := range parentsFirst(im.t.Articles) {
+		if im.skipped[a.ID] {
+			count("articles", true)
+			continue
+		}
+		id := im.id(a.ID)
+		err := q.CreateArticle(ctx, sqlc.CreateArticleParams{
+			ID:             id,
+			Title:          a.Title,
+			Content:        a.Content,
+			Summary:        a.Summary,
+			SourceType:     a.SourceType,
+			SourceUrl:      a.SourceUrl,
+			Author:         a.Author,
+			PublishedAt:    a.PublishedAt,
+			Language:       a.Language,
+			CreatedAt:      a.CreatedAt,
+			UpdatedAt:      a.UpdatedAt,
+			Status:         a.Status,
+			SourceID:       im.idPtr(a.SourceID),
+			ParentID:       im.idPtr(a.ParentID),
+			Sections:       a.Sections,
+			CustomMetadata: im.rewrite(a.CustomMetadata),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert article %s: %w", a.ID, err)
+		}
+		summary := ""
+		if a.Summary != nil {
+			summary = *a.Summary
+		}
+		err = q.InsertArticleFTS(ctx, sqlc.InsertArticleFTSParams{
+			ArticleID: id,
+			Title:     a.Title,
+			Content:   a.Content,
+			Summary:   summary,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert FTS: %w", err)
+		}
+		count("articles", false)
+	}
+
+	// タグは名前で照合し、無ければ作成する
+	tagIDs := make(map[int64]int64, len(im.t.Tags))
+	for _, tag := range im.t.Tags {
+		existing, err := q.GetTagByName(ctx, tag.Name)
+		if err == nil {
+			tagIDs[tag.ID] = existing.ID
+			count("tags", true)
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get tag %s: %w", tag.Name, err)
+		}
+		created, err := q.CreateTag(ctx, sqlc.CreateTagParams{
+			Name:      tag.Name,
+			Color:     tag.Color,
+			CreatedAt: tag.CreatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create tag %s: %w", tag.Name, err)
+		}
+		tagIDs[tag.ID] = created.ID
+		count("tags", false)
+	}
+	for _, at := range im.t.ArticleTags {
+		if at.ArticleID == nil || at.TagID == nil {
+			continue
+		}
+		tagID, ok := tagIDs[*at.TagID]
+		if !ok || im.skipped[*at.ArticleID] {
+			count("article_tags", true)
+			continue
+		}
+		err := q.AddArticleTag(ctx, sqlc.AddArticleTagParams{
+			ArticleID: im.idPtr(at.ArticleID),
+			TagID:     &tagID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to tag article %s: %w", *at.ArticleID, err)
+		}
+		count("article_tags", false)
+	}
+
+	// ブックマークのIDは連番のため、インポート先で振り直す
+	for _, b := range im.t.Bookmarks {
+		if im.skipped[b.SourceID] {
+			count("bookmarks", true)
+			continue
+		}
+		_, err := q.CreateBookmark(ctx, sqlc.CreateBookmarkParams{
+			SourceID:    im.id(b.SourceID),
+			TimeSeconds: b.TimeSeconds,
+			Label:       b.Label,
+			Note:        b.Note,
+			Color:       b.Color,
+			CreatedAt:   b.CreatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert bookmark: %w", err)
+		}
+		count("bookmarks", false)
+	}
+
+	for _, h := range im.t.LegalHolds {
+		if im.skipped[h.TargetID] {
+			count("legal_holds", true)
+			continue
+		}
+		targetID := im.id(h.TargetID)
+		_, err := q.GetLegalHold(ctx, sqlc.GetLegalHoldParams{TargetType: h.TargetType, TargetID: targetID})
+		if err == nil {
+			count("legal_holds", true)
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get legal hold: %w", err)
+		}
+		err = q.CreateLegalHold(ctx, sqlc.CreateLegalHoldParams{
+			TargetType: h.TargetType,
+			TargetID:   targetID,
+			Reason:     h.Reason,
+			LockedBy:   h.LockedBy,
+			LockedAt:   h.LockedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert legal hold: %w", err)
+		}
+		count("legal_holds", false)
+	}
+	for _, e := range im.t.LegalHoldEvents {
+		if im.skipped[e.TargetID] {
+			count("legal_hold_events", true)
+			continue
+		}
+		err := q.CreateLegalHoldEvent(ctx, sqlc.CreateLegalHoldEventParams{
+			TargetType: e.TargetType,
+			TargetID:   im.id(e.TargetID),
+			Action:     e.Action,
+			Actor:      e.Actor,
+			Reason:     e.Reason,
+			CreatedAt:  e.CreatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert legal hold event: %w", err)
+		}
+		count("legal_hold_events", false)
+	}
+
+	return tx.Commit()
+}
+
+// parentsFirst は親記事が子記事より先になるように並べ替える（外部キー制約のため）
+func parentsFirst(articles []sqlc.Article) []sqlc.Article {
+	inSet := make(map[string]bool, len(articles))
+	for _, a := range articles {
+		inSet[a.ID] = true
+	}
+
+	sorted := make([]sqlc.Article, 0, len(articles))
+	done := make(map[string]bool, len(articles))
+	for len(sorted) < len(articles) {
+		progressed := false
+		for _, a := range articles {
+			if done[a.ID] {
+				continue
+			}
+			if a.ParentID != nil && inSet[*a.ParentID] && !done[*a.ParentID] && *a.ParentID != a.ID {
+				continue
+			}
+			sorted = append(sorted, a)
+			done[a.ID] = true
+			progressed = true
+		}
+		if !progressed {
+			// 循環参照（通常は起こらない）は元の順序のまま追加
+			for _, a := range articles {
+				if !done[a.ID] {
+					sorted = append(sorted, a)
+					done[a.ID] = true
+				}
+			}
+		}
+	}
+	return sorted
+}
@@ -0,0 +1,92 @@
+// Package library は zbor インスタンス間でライブラリ全体を移行するための
+// エクスポート・インポートを提供する
+//
+// アーカイブは tar.gz で、次の順に格納する
+//   - manifest.json: 形式のバージョン、元のデータディレクトリ、件数、メディアの一覧
+//   - db/<table>.json: DBの行（sqlc の構造体のJSON配列）
+//   - media/...: ソースディレクトリ内のファイル（音声、変換済みWAV、プレビューなど）
+//
+// ジョブ、ワーカー、チェックサム、検索用セグメントは含めない
+// （チェックサムは整合性監査、セグメントはサーバー起動時に再作成される）
+package library
+
+import (
+	"time"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// FormatVersion はアーカイブ形式のバージョン
+const FormatVersion = 1
+
+// アーカイブ内のパス
+const (
+	manifestName = "manifest.json"
+	dbDir        = "db/"
+	mediaDir     = "media/"
+)
+
+// Manifest はアーカイブの内容
+type Manifest struct {
+	FormatVersion int            `json:"format_version"`
+	Version       string         `json:"version"` // エクスポートした zbor のバージョン
+	ExportedAt    time.Time      `json:"exported_at"`
+	DataDir       string         `json:"data_dir"` // エクスポート元のデータディレクトリ
+	Counts        map[string]int `json:"counts"`
+	Media         []MediaFile    `json:"media"`
+	Missing       []string       `json:"missing,omitempty"` // 含められなかったソースディレクトリ（アーカイブ済みなど）
+}
+
+// MediaFile はアーカイブに含めたファイル
+type MediaFile struct {
+	SourceID string `json:"source_id"`
+	Name     string `json:"name"` // アーカイブ内のパス
+	Path     string `json:"path"` // エクスポート元のパス
+	Size     int64  `json:"size"`
+}
+
+// tables はDBの行（db/<table>.json）
+type tables struct {
+	Sources         []sqlc.Source             `json:"sources"`
+	Artifacts       []sqlc.ProcessingArtifact `json:"artifacts"`
+	Articles        []sqlc.Article            `json:"articles"`
+	Tags            []sqlc.Tag                `json:"tags"`
+	ArticleTags     []sqlc.ArticleTag         `json:"article_tags"`
+	Bookmarks       []sqlc.Bookmark           `json:"bookmarks"`
+	LegalHolds      []sqlc.LegalHold          `json:"legal_holds"`
+	LegalHoldEvents []sqlc.LegalHoldEvent     `json:"legal_hold_events"`
+}
+
+// entries は db/ 以下のファイル名と tables のフィールドの対応（書き出し順）
+func (t *tables) entries() []struct {
+	name string
+	rows interface{}
+} {
+	return []struct {
+		name string
+		rows interface{}
+	}{
+		{"sources", &t.Sources},
+		{"artifacts", &t.Artifacts},
+		{"articles", &t.Articles},
+		{"tags", &t.Tags},
+		{"article_tags", &t.ArticleTags},
+		{"bookmarks", &t.Bookmarks},
+		{"legal_holds", &t.LegalHolds},
+		{"legal_hold_events", &t.LegalHoldEvents},
+	}
+}
+
+// counts はテーブルごとの行数
+func (t *tables) counts() map[string]int {
+	return map[string]int{
+		"sources":           len(t.Sources),
+		"artifacts":         len(t.Artifacts),
+		"articles":          len(t.Articles),
+		"tags":              len(t.Tags),
+		"article_tags":      len(t.ArticleTags),
+		"bookmarks":         len(t.Bookmarks),
+		"legal_holds":       len(t.LegalHolds),
+		"legal_hold_events": len(t.LegalHoldEvents),
+	}
+}
@@ -0,0 +1,212 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// JobIDKey はジョブのログに付ける属性のキー（logger.With("job_id", id)）
+const JobIDKey = "job_id"
+
+// JobLine はジョブのログの1行
+type JobLine struct {
+	JobID   string
+	Time    time.Time
+	Level   slog.Level
+	Message string // メッセージと属性（key=value。job_id と job_type は除く）
+}
+
+const (
+	// jobLogBuffer は保存を待つ行の最大数（超えた行は捨てる）
+	jobLogBuffer = 4096
+	// jobLogBatch は1回に保存する行の最大数
+	jobLogBatch = 200
+	// jobLogFlushInterval は行を保存する間隔
+	jobLogFlushInterval = 500 * time.Millisecond
+)
+
+// JobLogs はジョブのログ（job_id の属性を持つロガーの出力）を集めて保存する
+// ログを書くゴルーチンを待たせないよう、行はバッファーに入れて Run でまとめて保存する
+type JobLogs struct {
+	lines   chan JobLine
+	dropped atomic.Int64
+}
+
+// NewJobLogs は新しいJobLogsを作成
+func NewJobLogs() *JobLogs {
+	return &JobLogs{lines: make(chan JobLine, jobLogBuffer)}
+}
+
+// Capture はデフォルトのロガーの出力のうち、ジョブのログで level 以上のものを集める
+// （標準エラー出力には従来どおり LOG_LEVEL 以上を出力する）。Setup の後に呼ぶ
+func (j *JobLogs) Capture(level slog.Level) {
+	slog.SetDefault(slog.New(&jobHandler{next: slog.Default().Handler(), level: level, logs: j}))
+}
+
+// add は行をバッファーに入れる（いっぱいなら捨てる）
+func (j *JobLogs) add(line JobLine) {
+	select {
+	case j.lines <- line:
+	default:
+		j.dropped.Add(1)
+	}
+}
+
+// Run は集めた行を write で保存する（ctx の終了まで）
+func (j *JobLogs) Run(ctx context.Context, write func(ctx context.Context, lines []JobLine) error) {
+	ticker := time.NewTicker(jobLogFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]JobLine, 0, jobLogBatch)
+	flush := func() {
+		if n := j.dropped.Swap(0); n > 0 {
+			slog.Warn("Job log buffer full, lines dropped", "count", n)
+		}
+		if len(batch) == 0 {
+			return
+		}
+		// 保存のエラーはジョブのログにしない（job_id を付けない）
+		if err := write(context.WithoutCancel(ctx), batch); err != nil {
+			slog.Error("Failed to save job logs", "lines", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			// 終了前に残りを保存する
+			for {
+				select {
+				case line := <-j.lines:
+					batch = append(batch, line)
+					if len(batch) == jobLogBatch {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		case line := <-j.lines:
+			batch = append(batch, line)
+			if len(batch) == jobLogBatch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// jobHandler は next に出力し、ジョブのログを JobLogs にも渡す slog.Handler
+// ジョブは With で付けた job_id の属性か、レコードの job_id の属性で判別する
+type jobHandler struct {
+	next   slog.Handler
+	level  slog.Level
+	logs   *JobLogs
+	jobID  string // With で付けた job_id
+	attrs  string // With で付けた属性（key=value）
+	prefix string // WithGroup のグループ（"group."）
+}
+
+func (h *jobHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level) || level >= h.level
+}
+
+func (h *jobHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= h.level {
+		h.capture(r)
+	}
+	if !h.next.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// capture はジョブのログなら行を JobLogs に渡す
+func (h *jobHandler) capture(r slog.Record) {
+	jobID := h.jobID
+	var b strings.Builder
+	b.WriteString(r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		if h.prefix == "" && a.Key == JobIDKey {
+			if jobID == "" {
+				jobID = a.Value.String()
+			}
+			return true
+		}
+		appendAttr(&b, h.prefix, a)
+		return true
+	})
+	if jobID == "" {
+		return
+	}
+	h.logs.add(JobLine{JobID: jobID, Time: r.Time, Level: r.Level, Message: b.String()})
+}
+
+func (h *jobHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	for _, a := range attrs {
+		if h.prefix == "" && a.Key == JobIDKey {
+			clone.jobID = a.Value.String()
+			continue
+		}
+		appendAttr(&b, h.prefix, a)
+	}
+	clone.attrs = b.String()
+	return &clone
+}
+
+func (h *jobHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// appendAttr は属性を " key=value" の形で追加する（job_type はログの対象のジョブで分かるため除く）
+func appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) || prefix == "" && a.Key == "job_type" {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		group := prefix
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendAttr(b, group, ga)
+		}
+		return
+	}
+	b.WriteByte(' ')
+	b.WriteString(prefix)
+	b.WriteString(a.Key)
+	b.WriteByte('=')
+	var value string
+	switch a.Value.Kind() {
+	case slog.KindTime:
+		value = a.Value.Time().Format(time.RFC3339)
+	case slog.KindAny:
+		value = fmt.Sprint(a.Value.Any())
+	default:
+		value = a.Value.String()
+	}
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		value = strconv.Quote(value)
+	}
+	b.WriteString(value)
+}
@@ -0,0 +1,268 @@
+// Package metrics はサーバーの監視用メトリクスを Prometheus のテキスト形式で公開する
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// サーバーのメトリクス（GET /metrics）
+var (
+	// HTTPRequests は HTTP リクエストの数（method, route, status）
+	HTTPRequests = NewCounterVec("zbor_http_requests_total", "HTTP requests by method, route and status code", "method", "route", "status")
+	// HTTPDuration は HTTP リクエストの処理時間（method, route）
+	HTTPDuration = NewHistogramVec("zbor_http_request_duration_seconds", "HTTP request latency in seconds", DefaultBuckets, "method", "route")
+	// JobDuration はジョブの処理時間（type, status: completed / failed）
+	JobDuration = NewHistogramVec("zbor_job_duration_seconds", "Job run time in seconds by type and outcome", JobBuckets, "type", "status")
+	// WorkerErrors は失敗したジョブの実行の数（リトライを含む）
+	WorkerErrors = NewCounterVec("zbor_worker_errors_total", "Failed job runs (including retried ones) by type", "type")
+	// TranscriptionRTF は文字起こしのリアルタイム係数（処理時間 / 音声の長さ）
+	TranscriptionRTF = NewHistogramVec("zbor_transcription_rtf", "Real-time factor of transcriptions (processing time / audio length) by model", RTFBuckets, "model")
+)
+
+// ヒストグラムのバケット
+var (
+	DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	JobBuckets     = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200}
+	RTFBuckets     = []float64{0.01, 0.025, 0.05, 0.1, 0.2, 0.3, 0.5, 0.75, 1, 1.5, 2}
+)
+
+// collector は Write でテキスト形式を出力するメトリクス
+type collector interface {
+	write(w io.Writer) error
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []collector
+)
+
+func register(c collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, c)
+}
+
+// Write は登録された全てのメトリクスを Prometheus のテキスト形式（0.0.4）で書き出す
+func Write(w io.Writer) error {
+	registryMu.Lock()
+	collectors := append([]collector(nil), registry...)
+	registryMu.Unlock()
+	for _, c := range collectors {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ContentType は Write の出力の Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// CounterVec はラベルごとの単調増加カウンター
+type CounterVec struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	values map[string]float64 // key: ラベル値を \xff で連結
+}
+
+// NewCounterVec は新しいカウンターを作成して登録する
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, values: map[string]float64{}}
+	register(c)
+	return c
+}
+
+// Inc はラベル値のカウンターを1増やす（ラベル値は labels と同じ順）
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add はラベル値のカウンターを v 増やす
+func (c *CounterVec) Add(v float64, values ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[labelKey(values)] += v
+}
+
+func (c *CounterVec) write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(c.values) {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, key, "", ""), formatValue(c.values[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HistogramVec はラベルごとの累積ヒストグラム
+type HistogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64 // バケットごと（累積ではない）
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec は新しいヒストグラムを作成して登録する（buckets は昇順）
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, series: map[string]*histogram{}}
+	register(h)
+	return h
+}
+
+// Observe はラベル値のヒストグラムに v を記録する
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := labelKey(values)
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, le := range h.buckets {
+		if v <= le {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+// ObserveDuration はラベル値のヒストグラムに d を秒で記録する
+func (h *HistogramVec) ObserveDuration(d time.Duration, values ...string) {
+	h.Observe(d.Seconds(), values...)
+}
+
+func (h *HistogramVec) write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += s.counts[i]
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, "le", formatValue(le)), cumulative); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, formatLabels(h.labels, key, "le", "+Inf"), s.count,
+			h.name, formatLabels(h.labels, key, "", ""), formatValue(s.sum),
+			h.name, formatLabels(h.labels, key, "", ""), s.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sample はゲージの値の1つ（Labels は GaugeFunc の labels と同じ順）
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// GaugeFunc は出力のたびに collect で値を求めるゲージ
+type GaugeFunc struct {
+	name, help string
+	labels     []string
+	collect    func() []Sample
+}
+
+// NewGaugeFunc は新しいゲージを作成して登録する
+func NewGaugeFunc(name, help string, collect func() []Sample, labels ...string) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, labels: labels, collect: collect}
+	register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name); err != nil {
+		return err
+	}
+	for _, s := range g.collect() {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labels, labelKey(s.Labels), "", ""), formatValue(s.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labelEscaper はラベル値のバックスラッシュ・引用符・改行をエスケープする
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels は {name="value",...} を返す（extraName が空でなければ最後に追加する）
+func formatLabels(names []string, key, extraName, extraValue string) string {
+	var values []string
+	if len(names) > 0 {
+		values = strings.Split(key, "\xff")
+	}
+	var sb strings.Builder
+	for i, name := range names {
+		v := ""
+		if i < len(values) {
+			v = values[i]
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `%s="%s"`, name, labelEscaper.Replace(v))
+	}
+	if extraName != "" {
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `%s="%s"`, extraName, labelEscaper.Replace(extraValue))
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	return "{" + sb.String() + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
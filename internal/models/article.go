@@ -2,6 +2,8 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -113,3 +115,63 @@ func (a *Article) ParseCustomMetadata(data string) error {
 	}
 	return json.Unmarshal([]byte(data), &a.CustomMetadata)
 }
+
+// Anchor はセクションのページ内リンク用のID（section-1, section-2, ...）
+func (s Section) Anchor() string {
+	return fmt.Sprintf("section-%d", s.Order+1)
+}
+
+// TimeLabel はセクションの開始時刻（"12:34"、1時間以上は "1:02:03"）。時刻が無ければ空
+func (s Section) TimeLabel() string {
+	if s.StartTime == nil {
+		return ""
+	}
+	t := *s.StartTime
+	if t >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", t/3600, t/60%60, t%60)
+	}
+	return fmt.Sprintf("%d:%02d", t/60, t%60)
+}
+
+// heading はセクションの見出し（開始時刻があれば "[12:34] タイトル"）
+func (s Section) heading() string {
+	if label := s.TimeLabel(); label != "" {
+		return "[" + label + "] " + s.Title
+	}
+	return s.Title
+}
+
+// FormatAsText は本文をプレーンテキストで出力
+// Sections があればセクションごとに見出し行を付けて出力する
+func (a *Article) FormatAsText() string {
+	if len(a.Sections) == 0 {
+		return a.Content
+	}
+	parts := make([]string, len(a.Sections))
+	for i, s := range a.Sections {
+		parts[i] = "■ " + s.heading() + "\n\n" + s.Content
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// FormatAsMarkdown は記事を Markdown で出力
+// Sections があれば目次と、セクションごとにアンカー付きの見出しを出力する
+func (a *Article) FormatAsMarkdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", a.Title)
+	if len(a.Sections) == 0 {
+		sb.WriteString(a.Content)
+		sb.WriteString("\n")
+		return sb.String()
+	}
+
+	for _, s := range a.Sections {
+		fmt.Fprintf(&sb, "- [%s](#%s)\n", s.heading(), s.Anchor())
+	}
+	for _, s := range a.Sections {
+		fmt.Fprintf(&sb, "\n<a id=\"%s\"></a>\n\n## %s\n\n", s.Anchor(), s.heading())
+		sb.WriteString(s.Content)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
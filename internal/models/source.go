@@ -41,6 +41,7 @@ const (
 	ArtifactTypeTranscription = "transcription"
 	ArtifactTypeSummary       = "summary"
 	ArtifactTypeTranslation   = "translation"
+	ArtifactTypeCondensed     = "condensed" // 流し読み用の一覧（一定間隔ごとに1行）
 )
 
 // GetMetadata はメタデータをmapとして取得
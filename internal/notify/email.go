@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// EmailConfig はメール送信の設定
+type EmailConfig struct {
+	Addr     string // SMTP サーバー（host:port）
+	Username string // 空なら認証しない
+	Password string
+	From     string   // 差出人
+	To       []string // 宛先
+}
+
+// Email は通知をメールで送信する送信先
+// サーバーが対応していれば STARTTLS で暗号化する
+type Email struct {
+	config EmailConfig
+}
+
+// NewEmail は新しいEmailを作成
+func NewEmail(config EmailConfig) *Email {
+	return &Email{config: config}
+}
+
+// Name は送信先の名前を返す
+func (e *Email) Name() string {
+	return "email"
+}
+
+// Send は通知を送信
+func (e *Email) Send(ctx context.Context, msg Message) error {
+	if e.config.From == "" || len(e.config.To) == 0 {
+		return fmt.Errorf("email sender or recipients not configured")
+	}
+
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		host, _, err := net.SplitHostPort(e.config.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address: %w", err)
+		}
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, host)
+	}
+
+	subject := fmt.Sprintf("[zbor] %s: %s", strings.ToUpper(msg.Level), msg.Title)
+	body := msg.Text
+	if len(msg.Details) > 0 {
+		body += "\n\n" + strings.Join(msg.Details, "\n")
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(&sb, "To: %s\r\n", strings.Join(e.config.To, ", "))
+	fmt.Fprintf(&sb, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&sb, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	sb.WriteString("\r\n")
+
+	// smtp.SendMail はコンテキストに対応していないため、キャンセル時は結果を待たない
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(e.config.Addr, auth, e.config.From, e.config.To, []byte(sb.String()))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// 通知のレベル
+const (
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+// Message は運用通知の内容
+type Message struct {
+	Level   string   `json:"level"`
+	Title   string   `json:"title"`
+	Text    string   `json:"text"`
+	Details []string `json:"details,omitempty"`
+}
+
+// plainText は Message をタイトル・本文・詳細の順に改行で連結したテキストにする
+func (m Message) plainText() string {
+	text := m.Title + "\n" + m.Text
+	if len(m.Details) > 0 {
+		text += "\n" + strings.Join(m.Details, "\n")
+	}
+	return text
+}
+
+// Channel は通知の送信先（Webhook、Slack、メールなど）
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// Notifier は運用上の問題（データ破損、ジョブの連続失敗など）を通知する
+// 通知は常にログに出力し、設定された全ての送信先に送る
+type Notifier struct {
+	channels []Channel
+}
+
+// New は新しいNotifierを作成（送信先が無い場合はログのみ）
+func New(channels ...Channel) *Notifier {
+	return &Notifier{channels: channels}
+}
+
+// NewFromEnv は環境変数から送信先を設定したNotifierを作成
+//   - ZBOR_NOTIFY_WEBHOOK_URL: JSON を POST する Webhook
+//   - ZBOR_NOTIFY_SLACK_WEBHOOK_URL: Slack の Incoming Webhook
+//   - ZBOR_NOTIFY_SMTP_ADDR, ZBOR_NOTIFY_EMAIL_FROM, ZBOR_NOTIFY_EMAIL_TO: メール
+//     （ZBOR_NOTIFY_SMTP_USER, ZBOR_NOTIFY_SMTP_PASSWORD 設定時は認証する）
+func NewFromEnv() *Notifier {
+	var channels []Channel
+	if url := os.Getenv("ZBOR_NOTIFY_WEBHOOK_URL"); url != "" {
+		channels = append(channels, NewWebhook(url))
+	}
+	if url := os.Getenv("ZBOR_NOTIFY_SLACK_WEBHOOK_URL"); url != "" {
+		channels = append(channels, NewSlack(url))
+	}
+	if addr := os.Getenv("ZBOR_NOTIFY_SMTP_ADDR"); addr != "" {
+		var to []string
+		for _, rcpt := range strings.Split(os.Getenv("ZBOR_NOTIFY_EMAIL_TO"), ",") {
+			if rcpt = strings.TrimSpace(rcpt); rcpt != "" {
+				to = append(to, rcpt)
+			}
+		}
+		channels = append(channels, NewEmail(EmailConfig{
+			Addr:     addr,
+			Username: os.Getenv("ZBOR_NOTIFY_SMTP_USER"),
+			Password: os.Getenv("ZBOR_NOTIFY_SMTP_PASSWORD"),
+			From:     os.Getenv("ZBOR_NOTIFY_EMAIL_FROM"),
+			To:       to,
+		}))
+	}
+	return New(channels...)
+}
+
+// Channels は設定された送信先の名前を返す
+func (n *Notifier) Channels() []string {
+	names := make([]string, 0, len(n.channels))
+	for _, ch := range n.channels {
+		names = append(names, ch.Name())
+	}
+	return names
+}
+
+// Notify は通知を送信
+// 一部の送信先で失敗しても残りには送信し、失敗をまとめて返す
+func (n *Notifier) Notify(ctx context.Context, msg Message) error {
+	level := slog.LevelInfo
+	switch msg.Level {
+	case LevelWarning:
+		level = slog.LevelWarn
+	case LevelError:
+		level = slog.LevelError
+	}
+	slog.Log(ctx, level, msg.Title, "message", msg.Text, "details", msg.Details)
+
+	var errs []error
+	for _, ch := range n.channels {
+		if err := ch.Send(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// defaultHTTPClient は HTTP で送信する送信先のクライアント
+func defaultHTTPClient() *http.Client {
+	return &http.Client{Timeout: 10 * time.Second}
+}
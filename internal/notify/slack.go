@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// slackColors はレベルごとの添付の色
+var slackColors = map[string]string{
+	LevelInfo:    "good",
+	LevelWarning: "warning",
+	LevelError:   "danger",
+}
+
+// Slack は Slack の Incoming Webhook に送信する送信先
+type Slack struct {
+	webhookURL string
+	httpClient *http.Client
+}
+
+// NewSlack は新しいSlackを作成
+func NewSlack(webhookURL string) *Slack {
+	return &Slack{
+		webhookURL: webhookURL,
+		httpClient: defaultHTTPClient(),
+	}
+}
+
+// Name は送信先の名前を返す
+func (s *Slack) Name() string {
+	return "slack"
+}
+
+// Send は通知を送信
+// タイトルを本文に、内容と詳細をレベルの色の添付にする（通知のプレビューにはタイトルが出る）
+func (s *Slack) Send(ctx context.Context, msg Message) error {
+	text := msg.Text
+	if len(msg.Details) > 0 {
+		text += "\n```" + strings.Join(msg.Details, "\n") + "```"
+	}
+	return postJSON(ctx, s.httpClient, s.webhookURL, map[string]interface{}{
+		"text": "*" + msg.Title + "*",
+		"attachments": []map[string]interface{}{
+			{
+				"color":    slackColors[msg.Level],
+				"text":     text,
+				"fallback": msg.plainText(),
+			},
+		},
+	})
+}
@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Webhook は通知を JSON で POST する送信先
+type Webhook struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhook は新しいWebhookを作成
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		url:        url,
+		httpClient: defaultHTTPClient(),
+	}
+}
+
+// Name は送信先の名前を返す
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+// Send は通知を送信
+func (w *Webhook) Send(ctx context.Context, msg Message) error {
+	// "text" は Slack 互換の Incoming Webhook でそのまま表示される
+	return postJSON(ctx, w.httpClient, w.url, map[string]interface{}{
+		"text":    msg.plainText(),
+		"level":   msg.Level,
+		"title":   msg.Title,
+		"message": msg.Text,
+		"details": msg.Details,
+	})
+}
+
+// postJSON は payload を JSON で POST し、2xx 以外をエラーにする
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
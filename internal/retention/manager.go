@@ -0,0 +1,499 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// sweepBatch は1回のスイープでジョブを作成する最大ソース数
+const sweepBatch = 50
+
+// statsBatch は使用量の集計でまとめて取得するソース数
+const statsBatch = 100
+
+// ErrNotCleanable は元音声を削除できないソース（文字起こし未完了・アーカイブ済み・削除済み）のエラー
+var ErrNotCleanable = errors.New("source cannot be cleaned up")
+
+// Cleanup は元音声を削除した記録（ソースのメタデータ "storage_cleanup" に保存）
+type Cleanup struct {
+	CleanedAt time.Time `json:"cleaned_at"`
+	Files     []string  `json:"files"` // 削除したファイル
+	Bytes     int64     `json:"bytes"` // 削除したファイルの合計サイズ
+}
+
+// SourceUsage はソースのディスク使用量（ソースディレクトリ内のファイル）
+type SourceUsage struct {
+	SourceID     string     `json:"source_id"`
+	Type         string     `json:"type"`
+	Title        string     `json:"title,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	Files        int        `json:"files"`
+	Bytes        int64      `json:"bytes"`
+	AudioBytes   int64      `json:"audio_bytes"`          // 元音声（アップロード・トリム済み・ダウンロード）
+	PreviewBytes int64      `json:"preview_bytes"`        // 再生用のプレビュー
+	CleanedAt    *time.Time `json:"cleaned_at,omitempty"` // 元音声を削除した日時
+	FreedBytes   int64      `json:"freed_bytes,omitempty"`
+}
+
+// Stats はストレージの使用量
+// 重複排除の統計は互換のため最上位に展開する（実体を共有するファイルはソースごとの使用量では重複して数える）
+type Stats struct {
+	*storage.BlobStats
+	Sources               int             `json:"sources"`
+	SourceFiles           int             `json:"source_files"`
+	SourceBytes           int64           `json:"source_bytes"`
+	AudioBytes            int64           `json:"audio_bytes"`
+	PreviewBytes          int64           `json:"preview_bytes"`
+	CleanedSources        int             `json:"cleaned_sources"`
+	FreedBytes            int64           `json:"freed_bytes"` // 元音声の削除で空けた容量
+	Cache                 *asr.PruneStats `json:"cache,omitempty"`
+	OriginalRetentionDays int             `json:"original_retention_days"` // 0 = 元音声を削除しない
+	Largest               []SourceUsage   `json:"largest"`                 // 使用量の大きいソース
+}
+
+// Manager はソースごとのディスク使用量を集計し、保持ポリシーに従って元音声を削除する
+// 削除は cleanup ジョブとして実行する。文字起こし・記事・プレビューは残るため、削除後も閲覧・再生できる
+type Manager struct {
+	sourceRepo   *storage.SourceRepository
+	artifactRepo *storage.ArtifactRepository
+	jobRepo      *storage.JobRepository
+	blobRepo     *storage.BlobRepository
+	checksumRepo *storage.ChecksumRepository
+	cache        *asr.ConversionCache
+	blobs        storage.Blob
+	keep         time.Duration // 文字起こしの完了から元音声を削除するまでの期間（0 = 削除しない）
+}
+
+// NewManager は新しいManagerを作成
+func NewManager(sourceRepo *storage.SourceRepository, artifactRepo *storage.ArtifactRepository, jobRepo *storage.JobRepository) *Manager {
+	return &Manager{
+		sourceRepo:   sourceRepo,
+		artifactRepo: artifactRepo,
+		jobRepo:      jobRepo,
+	}
+}
+
+// SetOriginalRetention は文字起こしの完了から元音声を削除するまでの期間を設定（0 で削除しない）
+func (m *Manager) SetOriginalRetention(keep time.Duration) {
+	m.keep = keep
+}
+
+// SetBlobRepository は音声の実体の参照を管理する BlobRepository を設定
+// 削除したファイルの参照を外し、統計に重複排除の統計を含める
+func (m *Manager) SetBlobRepository(repo *storage.BlobRepository) {
+	m.blobRepo = repo
+}
+
+// SetChecksumRepository は削除したファイルのチェックサムを消す ChecksumRepository を設定
+// （整合性監査で欠損として報告されないように）
+func (m *Manager) SetChecksumRepository(repo *storage.ChecksumRepository) {
+	m.checksumRepo = repo
+}
+
+// SetBlobStore は音声ファイルのコピーを置いたオブジェクトストレージを設定
+// 元音声の削除でオブジェクトも削除する
+func (m *Manager) SetBlobStore(blob storage.Blob) {
+	m.blobs = blob
+}
+
+// SetConversionCache は統計に含める変換キャッシュを設定
+func (m *Manager) SetConversionCache(cache *asr.ConversionCache) {
+	m.cache = cache
+}
+
+// Run は interval ごとに保持期間を過ぎたソースの cleanup ジョブを作成（ctx がキャンセルされるまで）
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := m.QueueExpired(ctx); err != nil {
+			slog.Error("Storage cleanup sweep failed", "error", err)
+		} else if n > 0 {
+			slog.Info("Queued storage cleanup jobs", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// QueueExpired は保持期間を過ぎたソースの cleanup ジョブを作成し、作成した件数を返す
+// 保持期間が設定されていない場合は何もしない
+func (m *Manager) QueueExpired(ctx context.Context) (int, error) {
+	if m.keep <= 0 {
+		return 0, nil
+	}
+	sources, err := m.sourceRepo.ListCleanable(ctx, time.Now().Add(-m.keep), sweepBatch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sources: %w", err)
+	}
+
+	queued := 0
+	for _, source := range sources {
+		if ctx.Err() != nil {
+			break
+		}
+		_, created, err := m.queueCleanup(ctx, source.ID, storage.JobPriorityBatch)
+		if err != nil {
+			if !errors.Is(err, storage.ErrLegalHold) {
+				slog.Error("Failed to queue storage cleanup", "source_id", source.ID, "error", err)
+			}
+			continue
+		}
+		if created {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+// QueueCleanup はソースの元音声を削除する cleanup ジョブを作成し、ジョブIDを返す
+// 保持期間に関係なく削除する。待機中・実行中のジョブがあればそのIDを返す
+func (m *Manager) QueueCleanup(ctx context.Context, sourceID string, priority int) (string, error) {
+	id, _, err := m.queueCleanup(ctx, sourceID, priority)
+	return id, err
+}
+
+// queueCleanup はジョブを作成した（既存のジョブではない）かも返す
+func (m *Manager) queueCleanup(ctx context.Context, sourceID string, priority int) (string, bool, error) {
+	source, err := m.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return "", false, fmt.Errorf("source not found: %s", sourceID)
+	}
+	if err := m.checkCleanable(ctx, source); err != nil {
+		return "", false, err
+	}
+
+	jobs, err := m.jobRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if job.Type == storage.JobTypeCleanup && job.Status != nil &&
+			(*job.Status == storage.JobStatusQueued || *job.Status == storage.JobStatusRunning) {
+			return job.ID, false, nil
+		}
+	}
+
+	job := &sqlc.ProcessingJob{
+		SourceID: storage.Ptr(sourceID),
+		Type:     storage.JobTypeCleanup,
+		Priority: storage.Ptr(int64(priority)),
+	}
+	if err := m.jobRepo.Create(ctx, job); err != nil {
+		return "", false, fmt.Errorf("failed to create job: %w", err)
+	}
+	return job.ID, true, nil
+}
+
+// ProcessJob は cleanup ジョブを処理する
+func (m *Manager) ProcessJob(ctx context.Context, job *sqlc.ProcessingJob, reportProgress func(int, string)) error {
+	if job.SourceID == nil {
+		return fmt.Errorf("cleanup job has no source")
+	}
+	reportProgress(10, "removing original audio")
+	cleanup, err := m.RemoveOriginals(ctx, *job.SourceID)
+	if err != nil {
+		return err
+	}
+	slog.Info("Removed original audio", "source_id", *job.SourceID, "files", len(cleanup.Files), "bytes", cleanup.Bytes)
+	reportProgress(100, "completed")
+	return nil
+}
+
+// RemoveOriginals はソースの元音声（アップロード・トリム済み・ダウンロードしたファイル）を削除する
+// 再生できるよう、先にプレビューを作成する（ffmpeg が無い場合は作成できず、削除後は再生できない）。
+// 削除したファイルはメタデータ "storage_cleanup" に記録し、再文字起こしはできなくなる
+func (m *Manager) RemoveOriginals(ctx context.Context, sourceID string) (*Cleanup, error) {
+	source, err := m.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return nil, fmt.Errorf("source not found: %s", sourceID)
+	}
+	if err := m.sourceRepo.CheckHold(ctx, sourceID); err != nil {
+		return nil, err
+	}
+	if err := m.checkCleanable(ctx, source); err != nil {
+		return nil, err
+	}
+
+	files, originals := audioFiles(source)
+	for _, path := range files {
+		if err := ensureProxy(ctx, path); err != nil {
+			return nil, err
+		}
+	}
+
+	cleanup := &Cleanup{CleanedAt: time.Now(), Files: []string{}}
+	for _, path := range append(files, originals...) {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		cleanup.Files = append(cleanup.Files, path)
+		cleanup.Bytes += info.Size()
+	}
+
+	// 記録してからファイルを削除（途中で失敗しても再実行で同じファイルを削除しない）
+	uris := storage.BlobURIs(source)
+	if err := m.updateCleanup(ctx, source, cleanup); err != nil {
+		return nil, err
+	}
+	if m.blobs != nil {
+		for _, path := range append(files, originals...) {
+			if uri := uris[path]; uri != "" {
+				if err := m.blobs.Delete(ctx, uri); err != nil {
+					slog.Warn("Failed to delete original audio from the blob store", "uri", uri, "error", err)
+				}
+			}
+		}
+	}
+	for _, path := range cleanup.Files {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to remove original audio", "path", path, "error", err)
+		}
+		if m.checksumRepo != nil {
+			if err := m.checksumRepo.Delete(ctx, storage.ChecksumTargetFile, path); err != nil {
+				slog.Warn("Failed to delete checksum", "path", path, "error", err)
+			}
+		}
+	}
+	// 実体として保存しているのは元音声のみ。他のソースから参照されていない実体はここで削除される
+	if m.blobRepo != nil {
+		if _, err := m.blobRepo.Release(ctx, sourceID); err != nil {
+			slog.Warn("Failed to release blobs", "source_id", sourceID, "error", err)
+		}
+	}
+	return cleanup, nil
+}
+
+// checkCleanable は元音声を削除できるソース（文字起こし済みで、アーカイブ・削除されていない）かを確認
+func (m *Manager) checkCleanable(ctx context.Context, source *sqlc.Source) error {
+	if source.Status == nil || *source.Status != storage.SourceStatusCompleted {
+		return fmt.Errorf("%w: only completed sources can be cleaned up", ErrNotCleanable)
+	}
+	if ReadCleanup(source) != nil {
+		return fmt.Errorf("%w: original audio was already removed", ErrNotCleanable)
+	}
+	artifacts, err := m.artifactRepo.GetBySourceID(ctx, source.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	for _, artifact := range artifacts {
+		if artifact.Type == storage.ArtifactTypeTranscription && artifact.Content != nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: source has no transcription", ErrNotCleanable)
+}
+
+// ensureProxy は再生用のプレビューが無ければ作成する（ffmpeg が無い場合は何もしない）
+func ensureProxy(ctx context.Context, path string) error {
+	proxyPath := asr.ProxyPath(path)
+	if _, err := os.Stat(proxyPath); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	err := asr.ConvertToOpusProxy(ctx, path, proxyPath)
+	if errors.Is(err, asr.ErrNoFFmpeg) {
+		slog.Warn("No preview for playback after cleanup: ffmpeg not found", "path", path)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode preview for %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// updateCleanup はメタデータの "storage_cleanup" を更新
+func (m *Manager) updateCleanup(ctx context.Context, source *sqlc.Source, cleanup *Cleanup) error {
+	metadata := map[string]interface{}{}
+	if source.Metadata != nil && *source.Metadata != "" {
+		if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+			return fmt.Errorf("failed to parse metadata: %w", err)
+		}
+	}
+	metadata["storage_cleanup"] = cleanup
+	// 削除したファイルは他のノードからも取得できなくなる
+	delete(metadata, "blob_uris")
+
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if err := m.sourceRepo.UpdateMetadata(ctx, source.ID, string(metadataJSON)); err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+	source.Metadata = storage.Ptr(string(metadataJSON))
+	return nil
+}
+
+// ReadCleanup はメタデータから元音声の削除の記録を取得（削除していなければ nil）
+func ReadCleanup(source *sqlc.Source) *Cleanup {
+	if source.Metadata == nil {
+		return nil
+	}
+	var metadata struct {
+		Cleanup *Cleanup `json:"storage_cleanup"`
+	}
+	if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+		return nil
+	}
+	return metadata.Cleanup
+}
+
+// audioFiles はメタデータから文字起こしに使う音声ファイルとトリム前の元ファイルを取得
+func audioFiles(source *sqlc.Source) (files, originals []string) {
+	if source.Metadata == nil {
+		return nil, nil
+	}
+	var metadata struct {
+		Files         []string `json:"files"`
+		OriginalFiles []string `json:"original_files"`
+	}
+	if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+		return nil, nil
+	}
+	return metadata.Files, metadata.OriginalFiles
+}
+
+// Usage はソースディレクトリ内のファイルの使用量を集計
+func Usage(source *sqlc.Source) SourceUsage {
+	usage := SourceUsage{SourceID: source.ID, Type: source.Type}
+	if source.Status != nil {
+		usage.Status = *source.Status
+	}
+	if source.Metadata != nil {
+		var metadata struct {
+			Title string `json:"title"`
+		}
+		if json.Unmarshal([]byte(*source.Metadata), &metadata) == nil {
+			usage.Title = metadata.Title
+		}
+	}
+	if cleanup := ReadCleanup(source); cleanup != nil {
+		usage.CleanedAt = &cleanup.CleanedAt
+		usage.FreedBytes = cleanup.Bytes
+	}
+
+	audio, previews := map[string]bool{}, map[string]bool{}
+	files, originals := audioFiles(source)
+	for _, path := range files {
+		audio[path] = true
+		previews[asr.ProxyPath(path)] = true
+	}
+	for _, path := range originals {
+		audio[path] = true
+	}
+
+	if source.FilePath == nil || *source.FilePath == "" {
+		return usage
+	}
+	root := *source.FilePath
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		usage.Files++
+		usage.Bytes += info.Size()
+		switch {
+		case audio[path]:
+			usage.AudioBytes += info.Size()
+		case previews[path]:
+			usage.PreviewBytes += info.Size()
+		}
+		return nil
+	})
+	return usage
+}
+
+// Stats はソース（コンテキストの利用者のソースのみ）の使用量を集計し、
+// 使用量の大きい順に top 件のソースを含めて返す
+func (m *Manager) Stats(ctx context.Context, top int) (*Stats, error) {
+	stats := &Stats{
+		OriginalRetentionDays: int(m.keep / (24 * time.Hour)),
+		Largest:               []SourceUsage{},
+	}
+	var usages []SourceUsage
+	for offset := 0; ; offset += statsBatch {
+		sources, err := m.sourceRepo.List(ctx, statsBatch, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sources: %w", err)
+		}
+		for _, source := range sources {
+			usage := Usage(&source)
+			stats.Sources++
+			stats.SourceFiles += usage.Files
+			stats.SourceBytes += usage.Bytes
+			stats.AudioBytes += usage.AudioBytes
+			stats.PreviewBytes += usage.PreviewBytes
+			if usage.CleanedAt != nil {
+				stats.CleanedSources++
+				stats.FreedBytes += usage.FreedBytes
+			}
+			if usage.Bytes > 0 {
+				usages = append(usages, usage)
+			}
+		}
+		if len(sources) < statsBatch {
+			break
+		}
+	}
+	sort.SliceStable(usages, func(a, b int) bool { return usages[a].Bytes > usages[b].Bytes })
+	if len(usages) > top {
+		usages = usages[:top]
+	}
+	stats.Largest = append(stats.Largest, usages...)
+
+	if m.blobRepo != nil {
+		blobStats, err := m.blobRepo.Stats(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get blob stats: %w", err)
+		}
+		stats.BlobStats = blobStats
+	}
+	if m.cache != nil {
+		cacheStats, err := m.cache.Stats()
+		if err != nil {
+			return nil, err
+		}
+		stats.Cache = &cacheStats
+	}
+	return stats, nil
+}
@@ -0,0 +1,151 @@
+package semantic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"zbor/internal/summarize"
+)
+
+// ErrAskDisabled は質問応答に必要なLLMが設定されていない場合のエラー
+var ErrAskDisabled = errors.New("question answering requires an LLM (set ZBOR_LLM_URL)")
+
+const (
+	// DefaultAskChunks は質問応答でLLMに渡すチャンクの数
+	DefaultAskChunks = 8
+	// MaxAskChunks は質問応答でLLMに渡すチャンクの最大数
+	MaxAskChunks = 20
+	// maxAskContextRunes はLLMに渡す抜粋の合計の最大文字数
+	maxAskContextRunes = 12000
+)
+
+const askPrompt = `あなたは会議や録音の文字起こしを検索して質問に答えるアシスタントです。
+ユーザーの質問に、与えられた抜粋だけを根拠にして、質問と同じ言語で簡潔に答えてください。
+- 根拠にした抜粋の番号を文中に [1] のように付ける（複数なら [1][3]）
+- 抜粋に答えが無い場合は、推測せずに見つからないと答える
+- 日時や決定事項は抜粋の表現をそのまま使う`
+
+// citationPattern は回答中の抜粋の番号（[1]）
+var citationPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// Citation は回答の根拠にした抜粋（Ref は回答中の [n] の番号）
+type Citation struct {
+	Ref int `json:"ref"`
+	Hit
+}
+
+// Answer は質問への回答
+type Answer struct {
+	Question  string     `json:"question"`
+	Answer    string     `json:"answer"`
+	Citations []Citation `json:"citations"` // 回答中で参照された抜粋（番号順）
+	Model     string     `json:"model"`
+}
+
+// Asker はセマンティック検索で取り出したチャンクを根拠に、LLMで質問に答える（RAG）
+type Asker struct {
+	index  *Index
+	client *summarize.Client
+}
+
+// NewAsker は新しいAskerを作成
+// client が nil の場合は無効（Ask は ErrAskDisabled を返す）
+func NewAsker(index *Index, client *summarize.Client) *Asker {
+	return &Asker{index: index, client: client}
+}
+
+// Ready は質問応答に必要な埋め込みのバックエンドとLLMが設定されているかを確認する
+// 足りない場合は ErrDisabled または ErrAskDisabled を返す
+func (a *Asker) Ready() error {
+	if !a.index.Enabled() {
+		return ErrDisabled
+	}
+	if a.client == nil {
+		return ErrAskDisabled
+	}
+	return nil
+}
+
+// Ask は質問に意味の近いチャンクを最大 limit 件取り出し、それを根拠に回答する
+// transcriptsOnly が true の場合は文字起こしのチャンクだけを使う（引用は必ずソースと時刻の範囲を持つ）
+func (a *Asker) Ask(ctx context.Context, question string, limit int, transcriptsOnly bool) (*Answer, error) {
+	if err := a.Ready(); err != nil {
+		return nil, err
+	}
+
+	var hits []Hit
+	var err error
+	if transcriptsOnly {
+		hits, err = a.index.SearchTranscripts(ctx, question, limit)
+	} else {
+		hits, err = a.index.Search(ctx, question, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Answer{Question: question, Citations: []Citation{}, Model: a.client.Model()}
+	if len(hits) == 0 {
+		result.Answer = "関連する記録が見つかりませんでした。"
+		return result, nil
+	}
+
+	answer, err := a.client.Complete(ctx, []summarize.Message{
+		{Role: "system", Content: askPrompt},
+		{Role: "user", Content: excerpts(hits) + "質問: " + question},
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Answer = strings.TrimSpace(answer)
+
+	// 回答中で参照された番号の抜粋だけを引用として返す
+	refs := map[int]bool{}
+	for _, m := range citationPattern.FindAllStringSubmatch(result.Answer, -1) {
+		n, _ := strconv.Atoi(m[1])
+		if n >= 1 && n <= len(hits) {
+			refs[n] = true
+		}
+	}
+	for n := range refs {
+		result.Citations = append(result.Citations, Citation{Ref: n, Hit: hits[n-1]})
+	}
+	sort.Slice(result.Citations, func(i, j int) bool { return result.Citations[i].Ref < result.Citations[j].Ref })
+	return result, nil
+}
+
+// excerpts はLLMに渡す番号付きの抜粋を作る（合計 maxAskContextRunes 文字まで）
+func excerpts(hits []Hit) string {
+	var b strings.Builder
+	total := 0
+	for i, hit := range hits {
+		text := []rune(hit.Text)
+		if total+len(text) > maxAskContextRunes {
+			text = text[:max(maxAskContextRunes-total, 0)]
+		}
+		total += len(text)
+
+		fmt.Fprintf(&b, "[%d] %s", i+1, hit.Title)
+		if hit.StartTime != nil && hit.EndTime != nil {
+			fmt.Fprintf(&b, "（%s〜%s）", formatOffset(*hit.StartTime), formatOffset(*hit.EndTime))
+		}
+		b.WriteString("\n")
+		b.WriteString(string(text))
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
+
+// formatOffset は録音の先頭からの秒数を h:mm:ss（1時間未満は m:ss）にする
+func formatOffset(seconds float64) string {
+	s := int(seconds)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s%3600/60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
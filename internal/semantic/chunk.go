@@ -0,0 +1,65 @@
+package semantic
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/summarize"
+)
+
+const (
+	// maxChunkRunes はチャンクの最大文字数
+	maxChunkRunes = 400
+	// maxChunkSeconds は文字起こしのチャンクの最大の長さ（秒）
+	maxChunkSeconds = 90
+)
+
+// Chunk は埋め込みベクトルを作る単位
+type Chunk struct {
+	Text      string
+	StartTime *float64 // 文字起こしのチャンクの範囲（秒）。テキストのチャンクは nil
+	EndTime   *float64
+}
+
+// transcriptChunks は文字起こしのセグメントを順にまとめてチャンクにする
+// maxChunkRunes 文字または maxChunkSeconds 秒を超える前で区切る（1セグメントが長い場合はそのまま1チャンク）
+func transcriptChunks(segments []sqlc.TranscriptSegment) []Chunk {
+	var chunks []Chunk
+	var b strings.Builder
+	var start, end float64
+	flush := func() {
+		if text := strings.TrimSpace(b.String()); text != "" {
+			s, e := start, end
+			chunks = append(chunks, Chunk{Text: text, StartTime: &s, EndTime: &e})
+		}
+		b.Reset()
+	}
+	for _, seg := range segments {
+		text := strings.TrimSpace(seg.Text)
+		if text == "" {
+			continue
+		}
+		if b.Len() > 0 && (utf8.RuneCountInString(b.String())+utf8.RuneCountInString(text) > maxChunkRunes || seg.EndTime-start > maxChunkSeconds) {
+			flush()
+		}
+		if b.Len() == 0 {
+			start = seg.StartTime
+		} else {
+			b.WriteString(" ")
+		}
+		b.WriteString(text)
+		end = seg.EndTime
+	}
+	flush()
+	return chunks
+}
+
+// textChunks は本文を改行・句点でチャンクに区切る
+func textChunks(content string) []Chunk {
+	var chunks []Chunk
+	for _, text := range summarize.SplitText(content, maxChunkRunes) {
+		chunks = append(chunks, Chunk{Text: text})
+	}
+	return chunks
+}
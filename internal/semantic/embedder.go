@@ -0,0 +1,251 @@
+// Package semantic は記事のチャンクの埋め込みベクトルを作成し、意味の近いチャンクを検索する
+//
+// チャンクは文字起こしの記事ならセグメントをまとめたもの（時刻の範囲を持つ）、それ以外は本文を区切ったもの。
+// ベクトルは embedding_chunks テーブルに保存し、検索時はメモリに読み込んだベクトルとのコサイン類似度で並べる。
+package semantic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// バックエンドの種類
+const (
+	BackendAPI  = "api"  // OpenAI互換の埋め込みAPI（Ollama・llama.cpp などのローカルサーバーも同じAPIで使える）
+	BackendHash = "hash" // 文字n-gramのハッシュ（モデル不要。表記の近さだけを見る）
+)
+
+// DefaultModel はモデル未指定時に使う埋め込みモデル名
+const DefaultModel = "text-embedding-3-small"
+
+// defaultHashDimensions は hash バックエンドの次元数
+const defaultHashDimensions = 512
+
+// apiBatchSize は1回のリクエストで埋め込むテキストの最大数
+const apiBatchSize = 32
+
+// Embedder はテキストを埋め込みベクトルにする
+type Embedder interface {
+	// Name はチャンクに記録するバックエンドとモデルの名前（例: api:text-embedding-3-small）
+	// 名前が変わると既存のチャンクは検索に使わず、インデクサーが作り直す
+	Name() string
+	// Embed は texts を同じ順・同じ数のベクトルにする
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbedderFromEnv は環境変数から埋め込みのバックエンドを作成
+// ZBOR_EMBED_BACKEND: api または hash（未設定時は ZBOR_EMBED_URL があれば api）
+// ZBOR_EMBED_URL: 埋め込みAPIのベースURL（/embeddings を除く）、ZBOR_EMBED_API_KEY: そのAPIキー
+// ZBOR_EMBED_MODEL: モデル名（api、デフォルト text-embedding-3-small）または次元数（hash、デフォルト 512）
+// どちらも使えない場合は nil を返す（セマンティック検索は無効）
+func NewEmbedderFromEnv() (Embedder, error) {
+	kind := os.Getenv("ZBOR_EMBED_BACKEND")
+	url := os.Getenv("ZBOR_EMBED_URL")
+	model := os.Getenv("ZBOR_EMBED_MODEL")
+	if kind == "" {
+		if url == "" {
+			return nil, nil
+		}
+		kind = BackendAPI
+	}
+
+	switch kind {
+	case BackendAPI:
+		if url == "" {
+			return nil, fmt.Errorf("ZBOR_EMBED_URL is required for the api backend")
+		}
+		return NewAPIEmbedder(url, os.Getenv("ZBOR_EMBED_API_KEY"), model), nil
+	case BackendHash:
+		dims := defaultHashDimensions
+		if model != "" {
+			n, err := strconv.Atoi(model)
+			if err != nil || n < 16 {
+				return nil, fmt.Errorf("invalid dimensions for the hash backend: %q", model)
+			}
+			dims = n
+		}
+		return NewHashEmbedder(dims), nil
+	default:
+		return nil, fmt.Errorf("unknown embedding backend: %s", kind)
+	}
+}
+
+// APIEmbedder はOpenAI互換の埋め込みAPI（POST /embeddings）でベクトルを作成する
+type APIEmbedder struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewAPIEmbedder は新しいAPIEmbedderを作成
+// baseURL は /embeddings を除いたAPIのベースURL（例: https://api.openai.com/v1, http://localhost:11434/v1）
+func NewAPIEmbedder(baseURL, apiKey, model string) *APIEmbedder {
+	if model == "" {
+		model = DefaultModel
+	}
+	return &APIEmbedder{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// Name はバックエンドとモデルの名前を返す
+func (e *APIEmbedder) Name() string {
+	return BackendAPI + ":" + e.model
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Embed はテキストを apiBatchSize 個ずつリクエストしてベクトルにする
+func (e *APIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += apiBatchSize {
+		batch, err := e.embedBatch(ctx, texts[start:min(start+apiBatchSize, len(texts))])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+func (e *APIEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(embeddingRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding response: %w", err)
+	}
+	var result embeddingResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("invalid embedding response (status %d): %w", resp.StatusCode, err)
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("embedding error (status %d): %s", resp.StatusCode, result.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding request failed with status %d", resp.StatusCode)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding count mismatch: got %d, want %d", len(result.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index out of range: %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// HashEmbedder は文字の2-gram・3-gramをハッシュで固定の次元に集計したベクトルを作る
+// モデルやサーバーが不要な代わりに、言い換え（同義語）は近いと判定できない
+type HashEmbedder struct {
+	dims int
+}
+
+// NewHashEmbedder は新しいHashEmbedderを作成
+func NewHashEmbedder(dims int) *HashEmbedder {
+	return &HashEmbedder{dims: dims}
+}
+
+// Name はバックエンドと次元数を返す
+func (e *HashEmbedder) Name() string {
+	return BackendHash + ":" + strconv.Itoa(e.dims)
+}
+
+// Embed はテキストごとに n-gram を数えて正規化したベクトルを返す
+func (e *HashEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+func (e *HashEmbedder) embed(text string) []float32 {
+	v := make([]float32, e.dims)
+	var runes []rune
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, r)
+		}
+	}
+	for n := 2; n <= 3; n++ {
+		for i := 0; i+n <= len(runes); i++ {
+			h := fnv.New32a()
+			h.Write([]byte(string(runes[i : i+n])))
+			sum := h.Sum32()
+			// 最上位ビットで符号を分け、ハッシュの衝突を打ち消し合うようにする
+			if sum&(1<<31) != 0 {
+				v[sum%uint32(e.dims)]--
+			} else {
+				v[sum%uint32(e.dims)]++
+			}
+		}
+	}
+	normalize(v)
+	return v
+}
+
+// normalize はベクトルを長さ1にする（ゼロベクトルはそのまま）
+func normalize(v []float32) {
+	var sum float64
+	for _, f := range v {
+		sum += float64(f) * float64(f)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+}
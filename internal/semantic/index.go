@@ -0,0 +1,347 @@
+package semantic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// ErrDisabled は埋め込みのバックエンドが設定されていない場合のエラー
+var ErrDisabled = errors.New("semantic search is not configured (set ZBOR_EMBED_URL or ZBOR_EMBED_BACKEND)")
+
+// indexBatch はインデクサーが1回に処理する記事の最大数
+const indexBatch = 20
+
+// Hit は検索に一致したチャンク
+type Hit struct {
+	ChunkID    int64    `json:"chunk_id"`
+	ArticleID  string   `json:"article_id"`
+	Title      string   `json:"title"`
+	SourceID   *string  `json:"source_id,omitempty"`
+	ChunkIndex int64    `json:"chunk_index"`
+	StartTime  *float64 `json:"start_time,omitempty"` // 文字起こしのチャンクの範囲（秒）
+	EndTime    *float64 `json:"end_time,omitempty"`
+	Text       string   `json:"text"`
+	Score      float64  `json:"score"` // コサイン類似度（1 が最も近い）
+	URL        string   `json:"url"`   // 同期ページのその時刻、または記事へのリンク
+}
+
+// entry はメモリに読み込んだチャンクのベクトル（長さ1に正規化済み）
+type entry struct {
+	id        int64
+	articleID string
+	vector    []float32
+}
+
+// Index は記事のチャンクの埋め込みベクトルを管理し、検索する
+type Index struct {
+	embedder       Embedder
+	embeddingRepo  *storage.EmbeddingRepository
+	articleRepo    *storage.ArticleRepository
+	transcriptRepo *storage.TranscriptRepository
+
+	mu      sync.RWMutex
+	loaded  bool
+	entries []entry
+}
+
+// NewIndex は新しいIndexを作成
+// embedder が nil の場合は無効（Search は ErrDisabled を返す）
+func NewIndex(
+	embedder Embedder,
+	embeddingRepo *storage.EmbeddingRepository,
+	articleRepo *storage.ArticleRepository,
+	transcriptRepo *storage.TranscriptRepository,
+) *Index {
+	return &Index{
+		embedder:       embedder,
+		embeddingRepo:  embeddingRepo,
+		articleRepo:    articleRepo,
+		transcriptRepo: transcriptRepo,
+	}
+}
+
+// Enabled は埋め込みのバックエンドが設定されているかを返す
+func (x *Index) Enabled() bool {
+	return x.embedder != nil
+}
+
+// Model はチャンクに記録するバックエンドとモデルの名前を返す
+func (x *Index) Model() string {
+	if x.embedder == nil {
+		return ""
+	}
+	return x.embedder.Name()
+}
+
+// Chunks は記事のチャンクを返す
+// 文字起こしが検索インデックスにある記事はセグメントをまとめ、それ以外は本文を区切る
+func (x *Index) Chunks(ctx context.Context, article *sqlc.Article) ([]Chunk, error) {
+	if article.SourceID != nil {
+		segments, err := x.transcriptRepo.ListBySourceID(ctx, *article.SourceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transcript segments: %w", err)
+		}
+		if chunks := transcriptChunks(segments); len(chunks) > 0 {
+			return chunks, nil
+		}
+	}
+	return textChunks(article.Content), nil
+}
+
+// IndexArticle は記事のチャンクの埋め込みベクトルを作り直し、チャンク数を返す
+func (x *Index) IndexArticle(ctx context.Context, article *sqlc.Article) (int, error) {
+	if x.embedder == nil {
+		return 0, ErrDisabled
+	}
+	chunks, err := x.Chunks(ctx, article)
+	if err != nil {
+		return 0, err
+	}
+
+	// タイトルを付けて埋め込む（チャンクだけでは何の話か分からないことが多い）
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = article.Title + "\n" + c.Text
+	}
+	vectors, err := x.embedder.Embed(ctx, texts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to embed chunks: %w", err)
+	}
+	if len(vectors) != len(chunks) {
+		return 0, fmt.Errorf("embedding count mismatch: got %d, want %d", len(vectors), len(chunks))
+	}
+
+	model := x.embedder.Name()
+	rows := make([]sqlc.EmbeddingChunk, len(chunks))
+	for i, c := range chunks {
+		normalize(vectors[i])
+		rows[i] = sqlc.EmbeddingChunk{
+			SourceID:   article.SourceID,
+			ChunkIndex: int64(i),
+			StartTime:  c.StartTime,
+			EndTime:    c.EndTime,
+			Text:       c.Text,
+			Model:      model,
+			Dimensions: int64(len(vectors[i])),
+			Embedding:  storage.EncodeVector(vectors[i]),
+		}
+	}
+	ids, err := x.embeddingRepo.Replace(ctx, article.ID, rows)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save chunks: %w", err)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.loaded {
+		kept := x.entries[:0]
+		for _, e := range x.entries {
+			if e.articleID != article.ID {
+				kept = append(kept, e)
+			}
+		}
+		for i, id := range ids {
+			kept = append(kept, entry{id: id, articleID: article.ID, vector: vectors[i]})
+		}
+		x.entries = kept
+	}
+	return len(chunks), nil
+}
+
+// load は現在のモデルのベクトルをメモリに読み込む（最初の検索・インデックス作成時に1回）
+func (x *Index) load(ctx context.Context) error {
+	x.mu.RLock()
+	loaded := x.loaded
+	x.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.loaded {
+		return nil
+	}
+	rows, err := x.embeddingRepo.Vectors(ctx, x.embedder.Name())
+	if err != nil {
+		return fmt.Errorf("failed to load vectors: %w", err)
+	}
+	x.entries = make([]entry, len(rows))
+	for i, row := range rows {
+		x.entries[i] = entry{id: row.ID, articleID: row.ArticleID, vector: storage.DecodeVector(row.Embedding)}
+	}
+	x.loaded = true
+	return nil
+}
+
+// scored はクエリとの類似度を計算したチャンク
+type scored struct {
+	entry
+	score float64
+}
+
+// Search はクエリに意味の近いチャンクを最大 limit 件、類似度の高い順に返す
+// コンテキストの利用者が参照できない記事・削除済みの記事のチャンクと、類似度が0以下のチャンクは除く
+func (x *Index) Search(ctx context.Context, query string, limit int) ([]Hit, error) {
+	return x.search(ctx, query, limit, false)
+}
+
+// SearchTranscripts は Search と同じだが、文字起こしのチャンク（時刻の範囲を持つもの）だけを返す
+func (x *Index) SearchTranscripts(ctx context.Context, query string, limit int) ([]Hit, error) {
+	return x.search(ctx, query, limit, true)
+}
+
+func (x *Index) search(ctx context.Context, query string, limit int, transcriptsOnly bool) ([]Hit, error) {
+	if x.embedder == nil {
+		return nil, ErrDisabled
+	}
+	if err := x.load(ctx); err != nil {
+		return nil, err
+	}
+
+	vectors, err := x.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embedding count mismatch: got %d, want 1", len(vectors))
+	}
+	q := vectors[0]
+	normalize(q)
+
+	x.mu.RLock()
+	candidates := make([]scored, 0, len(x.entries))
+	for _, e := range x.entries {
+		if len(e.vector) != len(q) {
+			continue
+		}
+		var dot float64
+		for i, f := range e.vector {
+			dot += float64(f) * float64(q[i])
+		}
+		candidates = append(candidates, scored{entry: e, score: dot})
+	}
+	x.mu.RUnlock()
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	// 記事の参照可否は記事ごとに1回だけ確認する
+	articles := map[string]*sqlc.Article{}
+	hits := make([]Hit, 0, limit)
+	for _, c := range candidates {
+		if len(hits) >= limit || c.score <= 0 {
+			break
+		}
+		article, ok := articles[c.articleID]
+		if !ok {
+			article, err = x.articleRepo.GetByID(ctx, c.articleID)
+			if err != nil {
+				return nil, err
+			}
+			articles[c.articleID] = article
+		}
+		if article == nil {
+			continue
+		}
+		chunk, err := x.embeddingRepo.Get(ctx, c.id)
+		if err != nil {
+			return nil, err
+		}
+		if chunk == nil {
+			// 本文の変更で削除されたチャンク（インデクサーが作り直すまで）
+			continue
+		}
+		if transcriptsOnly && chunk.StartTime == nil {
+			continue
+		}
+		hits = append(hits, newHit(article, chunk, c.score))
+	}
+	return hits, nil
+}
+
+// newHit は検索結果の1件を作る
+func newHit(article *sqlc.Article, chunk *sqlc.GetEmbeddingChunkRow, score float64) Hit {
+	hit := Hit{
+		ChunkID:    chunk.ID,
+		ArticleID:  article.ID,
+		Title:      article.Title,
+		SourceID:   chunk.SourceID,
+		ChunkIndex: chunk.ChunkIndex,
+		StartTime:  chunk.StartTime,
+		EndTime:    chunk.EndTime,
+		Text:       chunk.Text,
+		Score:      score,
+		URL:        "/articles/" + article.ID,
+	}
+	if chunk.SourceID != nil && chunk.StartTime != nil {
+		hit.URL = fmt.Sprintf("/audio/%s/sync?t=%.2f", *chunk.SourceID, *chunk.StartTime)
+	}
+	return hit
+}
+
+// Run はチャンクの無い記事（新しい記事、本文が変わった記事、モデルを変えた後の全記事）のベクトルを interval ごとに作成する
+func (x *Index) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// 失敗した記事・チャンクが作れない記事は、このプロセスでは再試行しない
+	skipped := map[string]bool{}
+	for {
+		x.indexPending(ctx, skipped)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// indexPending はチャンクの無い記事を最大 indexBatch 件処理する
+func (x *Index) indexPending(ctx context.Context, skipped map[string]bool) {
+	ids, err := x.embeddingRepo.ListArticlesToEmbed(ctx, x.embedder.Name(), indexBatch+len(skipped))
+	if err != nil {
+		slog.Error("Semantic index: failed to list articles", "error", err)
+		return
+	}
+	if err := x.load(ctx); err != nil {
+		slog.Error("Semantic index: failed to load vectors", "error", err)
+		return
+	}
+
+	indexed := 0
+	for _, id := range ids {
+		if ctx.Err() != nil || indexed >= indexBatch {
+			break
+		}
+		if skipped[id] {
+			continue
+		}
+		article, err := x.articleRepo.GetByID(ctx, id)
+		if err != nil || article == nil || strings.TrimSpace(article.Content) == "" {
+			skipped[id] = true
+			continue
+		}
+		n, err := x.IndexArticle(ctx, article)
+		if err != nil {
+			slog.Error("Semantic index: failed to index article", "article_id", id, "error", err)
+			skipped[id] = true
+			continue
+		}
+		if n == 0 {
+			skipped[id] = true
+		}
+		indexed++
+	}
+	if indexed > 0 {
+		slog.Info("Semantic index: indexed articles", "count", indexed, "model", x.embedder.Name())
+	}
+}
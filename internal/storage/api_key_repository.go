@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// APIキーのスコープ（admin は write を、write は read を含む）
+const (
+	APIScopeRead  = "read"  // 参照（GET）
+	APIScopeWrite = "write" // 作成・更新・削除
+	APIScopeAdmin = "admin" // リーガルホールド、整合性監査、一括処理、APIキーの管理
+)
+
+// apiKeyPrefix は生成するAPIキーの先頭に付ける文字列
+const apiKeyPrefix = "zbor_"
+
+// apiKeyTouchInterval は最終使用時刻を更新する間隔（リクエストごとに書き込まないため）
+const apiKeyTouchInterval = time.Minute
+
+var apiScopeRank = map[string]int{
+	APIScopeRead:  1,
+	APIScopeWrite: 2,
+	APIScopeAdmin: 3,
+}
+
+// ValidAPIScope はスコープ名が正しいかを返す
+func ValidAPIScope(scope string) bool {
+	return apiScopeRank[scope] > 0
+}
+
+// APIScopeAllows はスコープ have で need の操作ができるかを返す
+func APIScopeAllows(have, need string) bool {
+	return ValidAPIScope(have) && apiScopeRank[have] >= apiScopeRank[need]
+}
+
+// apiKeyContextKey はコンテキストに認証したAPIキーの名前を保存するキー
+type apiKeyContextKey struct{}
+
+// WithAPIKey は認証したAPIキーの名前 name のコンテキストを返す
+// 作成したジョブにはキーの名前が記録され、利用量の集計と上限の確認に使われる
+func WithAPIKey(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, name)
+}
+
+// APIKeyFromContext はコンテキストのAPIキーの名前を返す（無い場合は空）
+func APIKeyFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(apiKeyContextKey{}).(string)
+	return name
+}
+
+// HashAPIKey はAPIキーの照合用ハッシュ（SHA-256、16進）を返す
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyRepository はAPIキーのデータアクセス層
+type APIKeyRepository struct {
+	db *DB
+}
+
+// NewAPIKeyRepository は新しいAPIKeyRepositoryを作成
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create はAPIキーを生成して登録し、キー本体と登録内容を返す
+// キー本体は保存しないため、この戻り値でしか取得できない
+func (r *APIKeyRepository) Create(ctx context.Context, name, scope string) (string, *sqlc.ApiKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil, fmt.Errorf("name is required")
+	}
+	if !ValidAPIScope(scope) {
+		return "", nil, fmt.Errorf("invalid scope: %s (read, write or admin)", scope)
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	key := apiKeyPrefix + hex.EncodeToString(buf)
+
+	k := &sqlc.ApiKey{
+		Name:      name,
+		KeyHash:   HashAPIKey(key),
+		Prefix:    key[:len(apiKeyPrefix)+8],
+		Scope:     scope,
+		CreatedAt: time.Now(),
+	}
+	id, err := r.db.Queries.CreateAPIKey(ctx, sqlc.CreateAPIKeyParams{
+		Name:      k.Name,
+		KeyHash:   k.KeyHash,
+		Prefix:    k.Prefix,
+		Scope:     k.Scope,
+		CreatedAt: k.CreatedAt,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	k.ID = id
+	return key, k, nil
+}
+
+// Authenticate はキー本体に一致するAPIキーを返す（一致しない場合は nil）
+// 最終使用時刻も更新する
+func (r *APIKeyRepository) Authenticate(ctx context.Context, key string) (*sqlc.ApiKey, error) {
+	k, err := r.db.Queries.GetAPIKeyByHash(ctx, HashAPIKey(key))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyTouchInterval {
+		if err := r.db.Queries.TouchAPIKey(ctx, sqlc.TouchAPIKeyParams{LastUsedAt: &now, ID: k.ID}); err != nil {
+			return nil, err
+		}
+		k.LastUsedAt = &now
+	}
+	return &k, nil
+}
+
+// List は登録済みのAPIキーを新しい順に取得（ハッシュは含まない）
+func (r *APIKeyRepository) List(ctx context.Context) ([]sqlc.ListAPIKeysRow, error) {
+	return r.db.Queries.ListAPIKeys(ctx)
+}
+
+// Count は登録済みのAPIキーの数を返す
+func (r *APIKeyRepository) Count(ctx context.Context) (int64, error) {
+	return r.db.Queries.CountAPIKeys(ctx)
+}
+
+// Delete はAPIキーを削除（失効）する。存在しない場合は false
+func (r *APIKeyRepository) Delete(ctx context.Context, id int64) (bool, error) {
+	n, err := r.db.Queries.DeleteAPIKey(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -3,7 +3,11 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 	"unicode/utf8"
 
@@ -21,6 +25,29 @@ func NewArticleRepository(db *DB) *ArticleRepository {
 	return &ArticleRepository{db: db}
 }
 
+// defaultArticleCacheTTL は記事の一覧・検索の結果をキャッシュする期間のデフォルト
+const defaultArticleCacheTTL = 30 * time.Second
+
+// SetCache は一覧・件数・検索の結果をキャッシュする期間と件数の上限を設定する（ttl が 0 でキャッシュしない）
+// 記事の作成・更新・削除でキャッシュは破棄されるため、ttl は他のプロセスによる書き込みが反映されるまでの時間
+func (r *ArticleRepository) SetCache(ttl time.Duration, maxEntries int) {
+	r.db.articles.configure(ttl, maxEntries)
+}
+
+// invalidate は記事の書き込み後にキャッシュを破棄する
+func (r *ArticleRepository) invalidate() {
+	r.db.articles.invalidate()
+}
+
+// cacheKey はキャッシュのキー（コンテキストの利用者ごと）
+func cacheKey(ctx context.Context, kind string, args ...any) string {
+	owner := "*"
+	if id := ownerFilter(ctx); id != nil {
+		owner = *id
+	}
+	return fmt.Sprintf("%s|%s|%#v", kind, owner, args)
+}
+
 // Create は新しい記事を作成
 func (r *ArticleRepository) Create(ctx context.Context, article *sqlc.Article) error {
 	if article.ID == "" {
@@ -46,6 +73,22 @@ func (r *ArticleRepository) Create(ctx context.Context, article *sqlc.Article) e
 
 	qtx := r.db.Queries.WithTx(tx)
 
+	// ロック中のソースには記事を追加できない
+	if article.SourceID != nil {
+		if err := checkSourceHold(ctx, qtx, *article.SourceID); err != nil {
+			return err
+		}
+	}
+
+	// 所有者はソースの所有者（ソースが無ければ作成した利用者）
+	if article.OwnerID == nil && article.SourceID != nil {
+		article.OwnerID, err = sourceOwner(ctx, qtx, *article.SourceID)
+		if err != nil {
+			return err
+		}
+	}
+	article.OwnerID = ownerForNew(ctx, article.OwnerID)
+
 	// 記事を挿入
 	err = qtx.CreateArticle(ctx, sqlc.CreateArticleParams{
 		ID:             article.ID,
@@ -64,6 +107,7 @@ func (r *ArticleRepository) Create(ctx context.Context, article *sqlc.Article) e
 		ParentID:       article.ParentID,
 		Sections:       article.Sections,
 		CustomMetadata: article.CustomMetadata,
+		OwnerID:        article.OwnerID,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to insert article: %w", err)
@@ -84,11 +128,40 @@ func (r *ArticleRepository) Create(ctx context.Context, article *sqlc.Article) e
 		return fmt.Errorf("failed to insert FTS: %w", err)
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.invalidate()
+	r.db.emit(ctx, Event{Type: EventArticleCreated, OwnerID: article.OwnerID, Data: article})
+	return nil
 }
 
-// GetByID はIDで記事を取得
+// GetByID はIDで記事を取得（削除済みの記事、他のユーザーの記事は nil）
 func (r *ArticleRepository) GetByID(ctx context.Context, id string) (*sqlc.Article, error) {
+	article, err := r.get(ctx, id)
+	if err != nil || article == nil || article.DeletedAt != nil {
+		return nil, err
+	}
+	return article, nil
+}
+
+// GetDeleted は削除済み（パージ前）の記事を取得（削除されていない記事、他のユーザーの記事は nil）
+func (r *ArticleRepository) GetDeleted(ctx context.Context, id string) (*sqlc.Article, error) {
+	article, err := r.get(ctx, id)
+	if err != nil || article == nil || article.DeletedAt == nil {
+		return nil, err
+	}
+	return article, nil
+}
+
+// Accessible は記事（削除済みを含む）がありコンテキストの利用者が参照できるかを返す
+func (r *ArticleRepository) Accessible(ctx context.Context, id string) (bool, error) {
+	article, err := r.get(ctx, id)
+	return article != nil, err
+}
+
+// get はIDで記事を取得（存在しない記事、他のユーザーの記事は nil）
+func (r *ArticleRepository) get(ctx context.Context, id string) (*sqlc.Article, error) {
 	article, err := r.db.Queries.GetArticleByID(ctx, id)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -96,12 +169,16 @@ func (r *ArticleRepository) GetByID(ctx context.Context, id string) (*sqlc.Artic
 	if err != nil {
 		return nil, err
 	}
+	if !ownerAllows(ctx, article.OwnerID) {
+		return nil, nil
+	}
 	return &article, nil
 }
 
 // Update は記事を更新
+// タイトル・本文・要約・ステータス・チャプター・メタデータのいずれかが変わる場合は、更新前の内容を版として記録する
 func (r *ArticleRepository) Update(ctx context.Context, article *sqlc.Article) error {
-	article.UpdatedAt = time.Now()
+	now := time.Now()
 
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -111,6 +188,41 @@ func (r *ArticleRepository) Update(ctx context.Context, article *sqlc.Article) e
 
 	qtx := r.db.Queries.WithTx(tx)
 
+	if err := checkArticleHold(ctx, qtx, article.ID); err != nil {
+		return err
+	}
+
+	prev, err := qtx.GetArticleByID(ctx, article.ID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("article not found: %s", article.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get article: %w", err)
+	}
+	// 本文が変わった記事のセマンティック検索のチャンクは作り直す（インデクサーがチャンクの無い記事を拾う）
+	if prev.Title != article.Title || prev.Content != article.Content {
+		if err := qtx.DeleteEmbeddingChunksByArticleID(ctx, article.ID); err != nil {
+			return fmt.Errorf("failed to delete embedding chunks: %w", err)
+		}
+	}
+	if revisionChanged(&prev, article) {
+		err = qtx.CreateArticleRevision(ctx, sqlc.CreateArticleRevisionParams{
+			ArticleID:      prev.ID,
+			Title:          prev.Title,
+			Content:        prev.Content,
+			Summary:        prev.Summary,
+			Status:         prev.Status,
+			Sections:       prev.Sections,
+			CustomMetadata: prev.CustomMetadata,
+			UpdatedAt:      prev.UpdatedAt,
+			CreatedAt:      now,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record revision: %w", err)
+		}
+	}
+
+	article.UpdatedAt = now
 	err = qtx.UpdateArticle(ctx, sqlc.UpdateArticleParams{
 		Title:          article.Title,
 		Content:        article.Content,
@@ -152,10 +264,70 @@ func (r *ArticleRepository) Update(ctx context.Context, article *sqlc.Article) e
 		return err
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.invalidate()
+	return nil
+}
+
+// revisionChanged は版として記録する項目が更新で変わるかを返す
+func revisionChanged(prev, next *sqlc.Article) bool {
+	return prev.Title != next.Title ||
+		prev.Content != next.Content ||
+		!equalPtr(prev.Summary, next.Summary) ||
+		!equalPtr(prev.Status, next.Status) ||
+		!equalPtr(prev.Sections, next.Sections) ||
+		!equalPtr(prev.CustomMetadata, next.CustomMetadata)
+}
+
+// equalPtr は2つのポインタが同じ値（または両方 nil）を指すかを返す
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// ListRevisions は記事の版を新しい順に取得
+func (r *ArticleRepository) ListRevisions(ctx context.Context, articleID string, limit, offset int) ([]sqlc.ArticleRevision, error) {
+	return r.db.Queries.ListArticleRevisions(ctx, sqlc.ListArticleRevisionsParams{
+		ArticleID: articleID,
+		Limit:     int64(limit),
+		Offset:    int64(offset),
+	})
+}
+
+// CountRevisions は記事の版の数を取得
+func (r *ArticleRepository) CountRevisions(ctx context.Context, articleID string) (int64, error) {
+	return r.db.Queries.CountArticleRevisions(ctx, articleID)
+}
+
+// GetRevision はIDで版を取得
+func (r *ArticleRepository) GetRevision(ctx context.Context, id int64) (*sqlc.ArticleRevision, error) {
+	revision, err := r.db.Queries.GetArticleRevision(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// RestoreRevision は記事を版の内容に戻す（現在の内容は新しい版として記録される）
+func (r *ArticleRepository) RestoreRevision(ctx context.Context, article *sqlc.Article, revision *sqlc.ArticleRevision) error {
+	article.Title = revision.Title
+	article.Content = revision.Content
+	article.Summary = revision.Summary
+	article.Status = revision.Status
+	article.Sections = revision.Sections
+	article.CustomMetadata = revision.CustomMetadata
+	return r.Update(ctx, article)
 }
 
-// Delete は記事を削除
+// Delete は記事を削除（論理削除。検索インデックスからは外し、Restore で元に戻せる）
+// 物理削除は保持期間を過ぎてから Purge で行う
 func (r *ArticleRepository) Delete(ctx context.Context, id string) error {
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -165,17 +337,126 @@ func (r *ArticleRepository) Delete(ctx context.Context, id string) error {
 
 	qtx := r.db.Queries.WithTx(tx)
 
+	if err := checkArticleHold(ctx, qtx, id); err != nil {
+		return err
+	}
+
 	err = qtx.DeleteArticleFTS(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	err = qtx.DeleteArticle(ctx, id)
+	now := time.Now()
+	err = qtx.SoftDeleteArticle(ctx, sqlc.SoftDeleteArticleParams{
+		DeletedAt: &now,
+		ID:        id,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.invalidate()
+	return nil
+}
+
+// Restore は削除済みの記事を元に戻す
+func (r *ArticleRepository) Restore(ctx context.Context, article *sqlc.Article) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := r.db.Queries.WithTx(tx)
+
+	if err := checkArticleHold(ctx, qtx, article.ID); err != nil {
+		return err
+	}
+
+	if err := qtx.UndeleteArticle(ctx, article.ID); err != nil {
+		return err
+	}
+
+	summary := ""
+	if article.Summary != nil {
+		summary = *article.Summary
+	}
+	err = qtx.InsertArticleFTS(ctx, sqlc.InsertArticleFTSParams{
+		ArticleID: article.ID,
+		Title:     article.Title,
+		Content:   article.Content,
+		Summary:   summary,
+	})
 	if err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.invalidate()
+	article.DeletedAt = nil
+	return nil
+}
+
+// ListDeleted は削除済み（パージ前）の記事を削除日時の新しい順に取得
+func (r *ArticleRepository) ListDeleted(ctx context.Context, limit, offset int) ([]sqlc.Article, error) {
+	return r.db.Queries.ListDeletedArticles(ctx, sqlc.ListDeletedArticlesParams{
+		OwnerID: ownerFilter(ctx),
+		Limit:   int64(limit),
+		Offset:  int64(offset),
+	})
+}
+
+// ListCompleted は処理が完了したすべての記事を作成日時の古い順に取得（所有者を問わない。フォルダ同期用）
+func (r *ArticleRepository) ListCompleted(ctx context.Context) ([]sqlc.Article, error) {
+	return r.db.Queries.ListCompletedArticles(ctx)
+}
+
+// CountDeleted は削除済み（パージ前）の記事数を取得
+func (r *ArticleRepository) CountDeleted(ctx context.Context) (int64, error) {
+	return r.db.Queries.CountDeletedArticles(ctx, ownerFilter(ctx))
+}
+
+// purgeBatch は Purge が1回のクエリで取得する記事数
+const purgeBatch = 100
+
+// Purge は before より前に削除された記事を物理削除し、削除した件数を返す
+// 版・タグ・タグの提案も削除される。リーガルホールド中の記事は削除しない
+func (r *ArticleRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+	defer func() {
+		if purged > 0 {
+			r.invalidate()
+		}
+	}()
+	for {
+		ids, err := r.db.Queries.ListPurgeableArticles(ctx, sqlc.ListPurgeableArticlesParams{
+			DeletedAt: &before,
+			Limit:     purgeBatch,
+		})
+		if err != nil {
+			return purged, fmt.Errorf("failed to list deleted articles: %w", err)
+		}
+		for _, id := range ids {
+			if err := checkArticleHold(ctx, r.db.Queries, id); err != nil {
+				if errors.Is(err, ErrLegalHold) {
+					continue
+				}
+				return purged, err
+			}
+			if err := r.db.Queries.DeleteArticle(ctx, id); err != nil {
+				return purged, fmt.Errorf("failed to purge article %s: %w", id, err)
+			}
+			purged++
+		}
+		if len(ids) < purgeBatch {
+			return purged, nil
+		}
+	}
 }
 
 // ListOptions はリスト取得のオプション
@@ -184,91 +465,273 @@ type ListOptions struct {
 	Offset     int
 	Status     string
 	SourceType string
+	EntityKind string // Entity と組み合わせて、分析で抽出した固有表現・キーワードを含む記事に絞り込む
+	Entity     string
 }
 
-// List は記事一覧を取得
+// List は記事一覧を取得（コンテキストの利用者の記事のみ）
 func (r *ArticleRepository) List(ctx context.Context, opts ListOptions) ([]sqlc.Article, error) {
 	if opts.Limit == 0 {
 		opts.Limit = 20
 	}
+	return cached(r.db.articles, cacheKey(ctx, "list", opts), func() ([]sqlc.Article, error) {
+		return r.list(ctx, opts)
+	})
+}
+
+func (r *ArticleRepository) list(ctx context.Context, opts ListOptions) ([]sqlc.Article, error) {
+	ownerID := ownerFilter(ctx)
 
 	// フィルタ条件に応じて適切なクエリを選択
+	if opts.Entity != "" {
+		return r.db.Queries.ListArticlesByEntity(ctx, sqlc.ListArticlesByEntityParams{
+			Kind:       opts.EntityKind,
+			Name:       opts.Entity,
+			Status:     nilIfEmpty(opts.Status),
+			SourceType: nilIfEmpty(opts.SourceType),
+			OwnerID:    ownerID,
+			Limit:      int64(opts.Limit),
+			Offset:     int64(opts.Offset),
+		})
+	}
 	if opts.Status != "" && opts.SourceType != "" {
 		return r.db.Queries.ListArticlesByStatusAndSourceType(ctx, sqlc.ListArticlesByStatusAndSourceTypeParams{
 			Status:     &opts.Status,
 			SourceType: &opts.SourceType,
+			OwnerID:    ownerID,
 			Limit:      int64(opts.Limit),
 			Offset:     int64(opts.Offset),
 		})
 	}
 	if opts.Status != "" {
 		return r.db.Queries.ListArticlesByStatus(ctx, sqlc.ListArticlesByStatusParams{
-			Status: &opts.Status,
-			Limit:  int64(opts.Limit),
-			Offset: int64(opts.Offset),
+			Status:  &opts.Status,
+			OwnerID: ownerID,
+			Limit:   int64(opts.Limit),
+			Offset:  int64(opts.Offset),
 		})
 	}
 	if opts.SourceType != "" {
 		return r.db.Queries.ListArticlesBySourceType(ctx, sqlc.ListArticlesBySourceTypeParams{
 			SourceType: &opts.SourceType,
+			OwnerID:    ownerID,
 			Limit:      int64(opts.Limit),
 			Offset:     int64(opts.Offset),
 		})
 	}
 	return r.db.Queries.ListArticlesAll(ctx, sqlc.ListArticlesAllParams{
-		Limit:  int64(opts.Limit),
-		Offset: int64(opts.Offset),
+		OwnerID: ownerID,
+		Limit:   int64(opts.Limit),
+		Offset:  int64(opts.Offset),
 	})
 }
 
-// Search は記事を検索
-func (r *ArticleRepository) Search(ctx context.Context, query string, limit int) ([]sqlc.Article, error) {
+// 検索結果の一致箇所を囲む記号（表示側でタグなどに置き換える）
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// snippetTokens は FTS5 の snippet() で切り出すトークン数（trigram ではほぼ文字数）
+const snippetTokens = 32
+
+// SearchResult は検索に一致した記事と一致箇所
+type SearchResult struct {
+	sqlc.Article
+	TitleHighlight string `json:"title_highlight"` // 一致箇所を HighlightStart/HighlightEnd で囲んだタイトル
+	Snippet        string `json:"snippet"`         // 一致箇所の前後を切り出した本文（または要約）
+}
+
+// Search は記事を検索（コンテキストの利用者の記事のみ）
+func (r *ArticleRepository) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
 	if limit == 0 {
 		limit = 20
 	}
+	return cached(r.db.articles, cacheKey(ctx, "search", query, limit), func() ([]SearchResult, error) {
+		return r.search(ctx, query, limit)
+	})
+}
+
+func (r *ArticleRepository) search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
 
-	// 3文字未満はLIKEで検索
-	if utf8.RuneCountInString(query) < 3 {
-		pattern := "%" + query + "%"
-		return r.db.Queries.SearchArticlesLike(ctx, sqlc.SearchArticlesLikeParams{
+	// 3文字未満の1語はLIKEで検索
+	terms := strings.Fields(query)
+	if len(terms) <= 1 && utf8.RuneCountInString(strings.TrimSpace(query)) < 3 {
+		pattern := "%" + strings.TrimSpace(query) + "%"
+		articles, err := r.db.Queries.SearchArticlesLike(ctx, sqlc.SearchArticlesLikeParams{
 			Title:   pattern,
 			Content: pattern,
+			OwnerID: ownerFilter(ctx),
 			Limit:   int64(limit),
 		})
+		if err != nil {
+			return nil, err
+		}
+		results := make([]SearchResult, 0, len(articles))
+		for _, a := range articles {
+			results = append(results, likeResult(a, terms))
+		}
+		return results, nil
+	}
+
+	// 空白区切りの全ての語を含む記事を検索（sqlcではなく手動で実行）
+	// 3文字以上の語はFTS5のフレーズとして、trigramで一致しない短い語はLIKEで絞り込む
+	var phrases, shortTerms, conds []string
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) >= 3 {
+			phrases = append(phrases, ftsPhrase(term))
+		} else {
+			shortTerms = append(shortTerms, term)
+		}
 	}
 
-	// FTS5で検索（sqlcではなく手動で実行）
-	rows, err := r.db.Query(`
+	// 一致箇所は FTS5 の highlight() / snippet() で取得（FTSを使わない場合は空）
+	columns := "'', '', ''"
+	from, order := "articles a", "a.created_at DESC"
+	var args []interface{}
+	conds = append(conds, "a.deleted_at IS NULL")
+	if len(phrases) > 0 {
+		columns = fmt.Sprintf("highlight(articles_fts, 1, ?, ?), snippet(articles_fts, 2, ?, ?, '…', %d), snippet(articles_fts, 3, ?, ?, '…', %d)", snippetTokens, snippetTokens)
+		args = append(args, HighlightStart, HighlightEnd, HighlightStart, HighlightEnd, HighlightStart, HighlightEnd)
+		from += " JOIN articles_fts f ON a.id = f.article_id"
+		order = "rank"
+		conds = append(conds, "articles_fts MATCH ?")
+		args = append(args, strings.Join(phrases, " "))
+	}
+	for _, term := range shortTerms {
+		conds = append(conds, "(a.title LIKE ? OR a.content LIKE ?)")
+		args = append(args, "%"+term+"%", "%"+term+"%")
+	}
+	conds = append(conds, "a.owner_id IS COALESCE(?, a.owner_id)")
+	args = append(args, ownerFilter(ctx), limit)
+
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT a.id, a.title, a.content, a.summary,
 			a.source_type, a.source_url, a.author, a.published_at, a.language,
 			a.created_at, a.updated_at, a.status,
-			a.source_id, a.parent_id, a.sections, a.custom_metadata
-		FROM articles a
-		JOIN articles_fts f ON a.id = f.article_id
-		WHERE articles_fts MATCH ?
-		ORDER BY rank
-		LIMIT ?`, query, limit)
+			a.source_id, a.parent_id, a.sections, a.custom_metadata, a.deleted_at, a.owner_id,
+			`+columns+`
+		FROM `+from+`
+		WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY `+order+`
+		LIMIT ?`, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var articles []sqlc.Article
+	var shortRe *regexp.Regexp
+	if len(shortTerms) > 0 {
+		shortRe = termsRegexp(shortTerms)
+	}
+
+	results := []SearchResult{}
 	for rows.Next() {
-		var a sqlc.Article
+		var res SearchResult
+		var summarySnippet string
+		a := &res.Article
 		err := rows.Scan(
 			&a.ID, &a.Title, &a.Content, &a.Summary,
 			&a.SourceType, &a.SourceUrl, &a.Author, &a.PublishedAt, &a.Language,
 			&a.CreatedAt, &a.UpdatedAt, &a.Status,
-			&a.SourceID, &a.ParentID, &a.Sections, &a.CustomMetadata,
+			&a.SourceID, &a.ParentID, &a.Sections, &a.CustomMetadata, &a.DeletedAt, &a.OwnerID,
+			&res.TitleHighlight, &res.Snippet, &summarySnippet,
 		)
 		if err != nil {
 			return nil, err
 		}
-		articles = append(articles, a)
+		if len(phrases) == 0 {
+			res = likeResult(res.Article, shortTerms)
+		} else {
+			// 本文に一致箇所が無く要約にある場合は要約を表示
+			if !strings.Contains(res.Snippet, HighlightStart) && strings.Contains(summarySnippet, HighlightStart) {
+				res.Snippet = summarySnippet
+			}
+			if shortRe != nil {
+				res.TitleHighlight = markTerms(res.TitleHighlight, shortRe)
+				res.Snippet = markTerms(res.Snippet, shortRe)
+			}
+		}
+		results = append(results, res)
 	}
 
-	return articles, rows.Err()
+	return results, rows.Err()
+}
+
+// likeResult はLIKEで一致した記事の一致箇所を作成（FTS5の highlight() / snippet() の代わり）
+func likeResult(a sqlc.Article, terms []string) SearchResult {
+	res := SearchResult{Article: a, TitleHighlight: a.Title}
+	if len(terms) == 0 {
+		res.Snippet = clipRunes(a.Content, 0, snippetTokens)
+		return res
+	}
+	re := termsRegexp(terms)
+
+	res.TitleHighlight = markTerms(a.Title, re)
+	loc := re.FindStringIndex(a.Content)
+	if loc == nil {
+		res.Snippet = clipRunes(a.Content, 0, snippetTokens)
+		return res
+	}
+	// 一致箇所の前に1/4、後ろに残りを含める
+	start := utf8.RuneCountInString(a.Content[:loc[0]]) - snippetTokens/4
+	res.Snippet = markTerms(clipRunes(a.Content, start, snippetTokens), re)
+	return res
+}
+
+// termsRegexp は語のいずれかに一致する正規表現を作成（LIKEと同じく大文字小文字を区別しない）
+func termsRegexp(terms []string) *regexp.Regexp {
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
+
+// markTerms は re に一致する箇所を HighlightStart/HighlightEnd で囲む（既に囲まれた箇所は除く）
+func markTerms(s string, re *regexp.Regexp) string {
+	mark := HighlightStart + "$0" + HighlightEnd
+	var b strings.Builder
+	for {
+		i := strings.Index(s, HighlightStart)
+		if i < 0 {
+			b.WriteString(re.ReplaceAllString(s, mark))
+			return b.String()
+		}
+		j := strings.Index(s[i:], HighlightEnd)
+		if j < 0 {
+			b.WriteString(re.ReplaceAllString(s[:i], mark))
+			b.WriteString(s[i:])
+			return b.String()
+		}
+		j += i + len(HighlightEnd)
+		b.WriteString(re.ReplaceAllString(s[:i], mark))
+		b.WriteString(s[i:j])
+		s = s[j:]
+	}
+}
+
+// clipRunes は s の start 文字目から n 文字を切り出し、前後が続く場合は … を付ける
+func clipRunes(s string, start, n int) string {
+	runes := []rune(s)
+	if start < 0 {
+		start = 0
+	}
+	if start > len(runes) {
+		start = len(runes)
+	}
+	end := start + n
+	if end > len(runes) {
+		end = len(runes)
+	}
+	clipped := string(runes[start:end])
+	if start > 0 {
+		clipped = "…" + clipped
+	}
+	if end < len(runes) {
+		clipped += "…"
+	}
+	return clipped
 }
 
 // GetArticleTags は記事のタグを取得
@@ -292,9 +755,122 @@ func (r *ArticleRepository) RemoveTag(ctx context.Context, articleID string, tag
 	})
 }
 
-// Count は記事数を取得
-func (r *ArticleRepository) Count(ctx context.Context) (int64, error) {
-	return r.db.Queries.CountArticles(ctx)
+// 分析で抽出する語の種類（article_entities.kind）
+const (
+	EntityKindPerson       = "person"
+	EntityKindOrganization = "organization"
+	EntityKindPlace        = "place"
+	EntityKindKeyword      = "keyword"
+)
+
+// EntityKinds は語の種類の一覧
+var EntityKinds = []string{EntityKindPerson, EntityKindOrganization, EntityKindPlace, EntityKindKeyword}
+
+// ValidEntityKind は語の種類が有効かを返す
+func ValidEntityKind(kind string) bool {
+	return slices.Contains(EntityKinds, kind)
+}
+
+// SetEntities は記事の分析結果（固有表現・キーワード）を置き換える
+func (r *ArticleRepository) SetEntities(ctx context.Context, articleID string, entities []sqlc.ArticleEntity) error {
+	now := time.Now()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := r.db.Queries.WithTx(tx)
+
+	if err := checkArticleHold(ctx, qtx, articleID); err != nil {
+		return err
+	}
+	if err := qtx.DeleteArticleEntities(ctx, articleID); err != nil {
+		return err
+	}
+	for _, e := range entities {
+		err := qtx.CreateArticleEntity(ctx, sqlc.CreateArticleEntityParams{
+			ArticleID: articleID,
+			Kind:      e.Kind,
+			Name:      e.Name,
+			Mentions:  max(e.Mentions, 1),
+			CreatedAt: now,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save entity %q: %w", e.Name, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.invalidate()
+	return nil
+}
+
+// GetEntities は記事の分析結果（固有表現・キーワード）を取得
+func (r *ArticleRepository) GetEntities(ctx context.Context, articleID string) ([]sqlc.ArticleEntity, error) {
+	return r.db.Queries.GetArticleEntities(ctx, articleID)
+}
+
+// EntityFacets は種類ごとの語を、言及している記事の多い順に返す（コンテキストの利用者の記事のみ）
+func (r *ArticleRepository) EntityFacets(ctx context.Context, kind string, limit int) ([]sqlc.ListEntityFacetsRow, error) {
+	return cached(r.db.articles, cacheKey(ctx, "facets", kind, limit), func() ([]sqlc.ListEntityFacetsRow, error) {
+		return r.db.Queries.ListEntityFacets(ctx, sqlc.ListEntityFacetsParams{
+			Kind:    kind,
+			OwnerID: ownerFilter(ctx),
+			Limit:   int64(limit),
+		})
+	})
+}
+
+// nilIfEmpty は空文字列を nil（条件なし）にする
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// Count は記事数を取得（List と同じフィルタ条件を使用、Limit/Offset は無視）
+func (r *ArticleRepository) Count(ctx context.Context, opts ListOptions) (int64, error) {
+	opts.Limit, opts.Offset = 0, 0
+	return cached(r.db.articles, cacheKey(ctx, "count", opts), func() (int64, error) {
+		return r.count(ctx, opts)
+	})
+}
+
+func (r *ArticleRepository) count(ctx context.Context, opts ListOptions) (int64, error) {
+	ownerID := ownerFilter(ctx)
+	if opts.Entity != "" {
+		return r.db.Queries.CountArticlesByEntity(ctx, sqlc.CountArticlesByEntityParams{
+			Kind:       opts.EntityKind,
+			Name:       opts.Entity,
+			Status:     nilIfEmpty(opts.Status),
+			SourceType: nilIfEmpty(opts.SourceType),
+			OwnerID:    ownerID,
+		})
+	}
+	if opts.Status != "" && opts.SourceType != "" {
+		return r.db.Queries.CountArticlesByStatusAndSourceType(ctx, sqlc.CountArticlesByStatusAndSourceTypeParams{
+			Status:     &opts.Status,
+			SourceType: &opts.SourceType,
+			OwnerID:    ownerID,
+		})
+	}
+	if opts.Status != "" {
+		return r.db.Queries.CountArticlesByStatus(ctx, sqlc.CountArticlesByStatusParams{
+			Status:  &opts.Status,
+			OwnerID: ownerID,
+		})
+	}
+	if opts.SourceType != "" {
+		return r.db.Queries.CountArticlesBySourceType(ctx, sqlc.CountArticlesBySourceTypeParams{
+			SourceType: &opts.SourceType,
+			OwnerID:    ownerID,
+		})
+	}
+	return r.db.Queries.CountArticles(ctx, ownerID)
 }
 
 // GetBySourceID はソースIDで記事一覧を取得
@@ -304,6 +880,9 @@ func (r *ArticleRepository) GetBySourceID(ctx context.Context, sourceID string)
 
 // DeleteBySourceID はソースIDで記事を削除（FTSも含む）
 func (r *ArticleRepository) DeleteBySourceID(ctx context.Context, sourceID string) error {
+	if err := checkSourceHold(ctx, r.db.Queries, sourceID); err != nil {
+		return err
+	}
 	// まず関連するFTSエントリを削除
 	articles, err := r.GetBySourceID(ctx, sourceID)
 	if err != nil {
@@ -313,5 +892,14 @@ func (r *ArticleRepository) DeleteBySourceID(ctx context.Context, sourceID strin
 		_ = r.db.Queries.DeleteArticleFTS(ctx, article.ID)
 	}
 	// 記事を削除
-	return r.db.Queries.DeleteArticlesBySourceID(ctx, &sourceID)
+	if err := r.db.Queries.DeleteArticlesBySourceID(ctx, &sourceID); err != nil {
+		return err
+	}
+	r.invalidate()
+	return nil
+}
+
+// CheckHold は記事（または記事のソース）がリーガルホールド中なら ErrLegalHold を返す
+func (r *ArticleRepository) CheckHold(ctx context.Context, id string) error {
+	return checkArticleHold(ctx, r.db.Queries, id)
 }
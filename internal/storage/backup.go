@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"modernc.org/sqlite"
+)
+
+// sqliteBackupConn は SQLite のオンラインバックアップ API を持つドライバーの接続（modernc.org/sqlite）
+type sqliteBackupConn interface {
+	NewBackup(dstURI string) (*sqlite.Backup, error)
+	NewRestore(srcURI string) (*sqlite.Backup, error)
+}
+
+// Snapshot はデータベースのスナップショットを path に作成する（SQLite のオンラインバックアップ API）
+// ファイルのコピーと違い、書き込み中でも WAL の内容を含めた一貫した状態を複製する。
+// path に既存のファイルがあれば置き換える
+func (db *DB) Snapshot(ctx context.Context, path string) error {
+	for _, p := range []string{path, path + "-wal", path + "-shm"} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return db.backup(ctx, func(conn sqliteBackupConn) (*sqlite.Backup, error) {
+		return conn.NewBackup(path)
+	})
+}
+
+// RestoreSnapshot はデータベースの内容を path のスナップショットで置き換え、未適用のマイグレーションを適用する
+// 他のプロセス（サーバー）が同じデータベースを使っていない状態で実行する
+func (db *DB) RestoreSnapshot(ctx context.Context, path string) ([]Migration, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	err := db.backup(ctx, func(conn sqliteBackupConn) (*sqlite.Backup, error) {
+		return conn.NewRestore(path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	db.articles.invalidate()
+	return db.Migrate(ctx)
+}
+
+// backup は接続を1つ確保し、バックアップ（復元）を最後まで実行する
+func (db *DB) backup(ctx context.Context, start func(sqliteBackupConn) (*sqlite.Backup, error)) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		bc, ok := driverConn.(sqliteBackupConn)
+		if !ok {
+			return fmt.Errorf("database driver does not support backups")
+		}
+		b, err := start(bc)
+		if err != nil {
+			return fmt.Errorf("failed to start backup: %w", err)
+		}
+		// 全てのページを1回でコピーする（途中で他の接続が書き込むと最初からやり直しになるため）
+		if _, err := b.Step(-1); err != nil {
+			b.Finish()
+			return fmt.Errorf("backup failed: %w", err)
+		}
+		return b.Finish()
+	})
+}
+
+// CheckSnapshot はスナップショットの整合性を確認し、スキーマのバージョンを返す
+func CheckSnapshot(ctx context.Context, path string) (*SchemaStatus, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRowContext(ctx, `PRAGMA integrity_check`).Scan(&result); err != nil {
+		return nil, fmt.Errorf("not a zbor database: %w", err)
+	}
+	if result != "ok" {
+		return nil, fmt.Errorf("database snapshot is corrupted: %s", result)
+	}
+	var sources int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'sources'`).Scan(&sources); err != nil {
+		return nil, err
+	}
+	if sources == 0 {
+		return nil, fmt.Errorf("not a zbor database: %s", path)
+	}
+	return schemaStatus(ctx, db)
+}
@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// BlobRepository は音声ファイルをコンテンツアドレス（SHA-256）の実体として保存する
+// ソースディレクトリのファイルは実体へのハードリンクに置き換えるため、既存のパスはそのまま使える。
+// 同じ内容のファイルを持つソースが複数あってもディスク上の実体は1つで、参照数が 0 になったら削除する
+type BlobRepository struct {
+	db  *DB
+	dir string
+
+	mu sync.Mutex // 実体の作成・削除を直列化
+}
+
+// BlobStats は重複排除の統計
+type BlobStats struct {
+	Blobs           int64 `json:"blobs"`            // 実体の数
+	Files           int64 `json:"files"`            // 実体を参照しているソースのファイル数
+	StoredBytes     int64 `json:"stored_bytes"`     // 実体の合計サイズ（実際のディスク使用量）
+	ReferencedBytes int64 `json:"referenced_bytes"` // ファイルの合計サイズ（重複排除しない場合の使用量）
+	SavedBytes      int64 `json:"saved_bytes"`      // 重複排除で節約した容量
+}
+
+// NewBlobRepository は新しいBlobRepositoryを作成
+// dir: 実体の保存先（<dir>/<SHA-256の先頭2文字>/<SHA-256>）。ソースと同じファイルシステムに置く
+func NewBlobRepository(db *DB, dir string) *BlobRepository {
+	return &BlobRepository{db: db, dir: dir}
+}
+
+// Store はソースのファイルを実体として登録する
+// 同じ内容の実体が既にあればファイルをそのハードリンクに置き換え、無ければファイルを新しい実体にする。
+// 登録済みのパスでは何もしない
+func (r *BlobRepository) Store(ctx context.Context, sourceID, path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.db.Queries.GetSourceBlob(ctx, path); err == nil {
+		return nil
+	} else if err != sql.ErrNoRows {
+		return err
+	}
+
+	sum, size, err := HashFile(path)
+	if err != nil {
+		return err
+	}
+
+	blobPath := r.blobPath(sum)
+	if _, err := os.Stat(blobPath); err == nil {
+		if err := replaceWithLink(blobPath, path); err != nil {
+			return fmt.Errorf("failed to link %s to blob: %w", filepath.Base(path), err)
+		}
+	} else if os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(blobPath), 0755); err != nil {
+			return err
+		}
+		if err := os.Link(path, blobPath); err != nil {
+			return fmt.Errorf("failed to create blob: %w", err)
+		}
+	} else {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := r.db.Queries.WithTx(tx)
+	now := time.Now()
+	if err := qtx.CreateBlob(ctx, sqlc.CreateBlobParams{Sha256: sum, Size: size, CreatedAt: now}); err != nil {
+		return fmt.Errorf("failed to insert blob: %w", err)
+	}
+	if err := qtx.AddBlobRef(ctx, sum); err != nil {
+		return fmt.Errorf("failed to add blob reference: %w", err)
+	}
+	err = qtx.CreateSourceBlob(ctx, sqlc.CreateSourceBlobParams{
+		Path:      path,
+		SourceID:  sourceID,
+		Sha256:    sum,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert source blob: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Release はソースのファイルの参照を外し、参照の無くなった実体を削除する
+// ソースのファイル自体は削除しない。参照を外したファイルのパスを返す
+func (r *BlobRepository) Release(ctx context.Context, sourceID string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	paths, err := releaseSourceBlobs(ctx, r.db.Queries.WithTx(tx), sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.prune(ctx); err != nil {
+		return paths, err
+	}
+	return paths, nil
+}
+
+// Files はソースのファイルのうち実体を参照しているもののパスを返す
+func (r *BlobRepository) Files(ctx context.Context, sourceID string) ([]string, error) {
+	refs, err := r.db.Queries.ListSourceBlobs(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		paths = append(paths, ref.Path)
+	}
+	return paths, nil
+}
+
+// Prune は参照の無い実体（ソースの削除などで残ったもの）を削除し、削除した数を返す
+func (r *BlobRepository) Prune(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.prune(ctx)
+}
+
+func (r *BlobRepository) prune(ctx context.Context) (int, error) {
+	blobs, err := r.db.Queries.ListUnreferencedBlobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, blob := range blobs {
+		if err := os.Remove(r.blobPath(blob.Sha256)); err != nil && !os.IsNotExist(err) {
+			return 0, err
+		}
+		if err := r.db.Queries.DeleteBlob(ctx, blob.Sha256); err != nil {
+			return 0, err
+		}
+	}
+	return len(blobs), nil
+}
+
+// Stats は重複排除の統計を返す
+func (r *BlobRepository) Stats(ctx context.Context) (*BlobStats, error) {
+	row, err := r.db.Queries.GetBlobStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &BlobStats{
+		Blobs:           row.BlobCount,
+		Files:           row.FileCount,
+		StoredBytes:     row.StoredBytes,
+		ReferencedBytes: row.ReferencedBytes,
+		SavedBytes:      max(row.ReferencedBytes-row.StoredBytes, 0),
+	}, nil
+}
+
+func (r *BlobRepository) blobPath(sum string) string {
+	return filepath.Join(r.dir, sum[:2], sum)
+}
+
+// releaseSourceBlobs はソースのファイルの参照を外し、参照数を減らす（実体は削除しない）
+func releaseSourceBlobs(ctx context.Context, q *sqlc.Queries, sourceID string) ([]string, error) {
+	refs, err := q.ListSourceBlobs(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if err := q.DeleteSourceBlob(ctx, ref.Path); err != nil {
+			return nil, err
+		}
+		if err := q.ReleaseBlobRef(ctx, ref.Sha256); err != nil {
+			return nil, err
+		}
+		paths = append(paths, ref.Path)
+	}
+	return paths, nil
+}
+
+// replaceWithLink は path を target へのハードリンクに置き換える（一時ファイルに作ってからリネーム）
+func replaceWithLink(target, path string) error {
+	tmp := path + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Link(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
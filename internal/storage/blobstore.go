@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// Blob はソースの音声ファイルの保存先（ローカルのファイルシステム、S3 互換のオブジェクトストレージ）
+// 複数のノードで動かす場合に、取り込んだノード以外からも音声を読めるようにする。
+// オブジェクトは URI（file:///... または s3://<bucket>/<key>）で識別し、ソースのメタデータ "blob_uris" に保存する
+type Blob interface {
+	// Put は r の size バイトを key（"/" 区切りの相対パス）に保存し、URI を返す
+	Put(ctx context.Context, key string, r io.Reader, size int64) (string, error)
+	// Open は URI のオブジェクトを開く（Seek で任意の位置から読める）
+	Open(ctx context.Context, uri string) (BlobObject, error)
+	// Delete は URI のオブジェクトを削除する（存在しない場合は何もしない）
+	Delete(ctx context.Context, uri string) error
+}
+
+// BlobObject は開いたオブジェクト
+type BlobObject interface {
+	io.ReadSeekCloser
+	Size() int64
+	ModTime() time.Time
+}
+
+// PutFile はファイルを key に保存し、URI を返す
+func PutFile(ctx context.Context, blob Blob, key, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	return blob.Put(ctx, key, f, info.Size())
+}
+
+// FetchFile は URI のオブジェクトを path にダウンロードする（一時ファイルに書いてからリネーム）
+func FetchFile(ctx context.Context, blob Blob, uri, path string) error {
+	obj, err := blob.Open(ctx, uri)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, obj); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// BlobURIs はソースのメタデータからファイルのパスと URI の対応を取得（無ければ nil）
+func BlobURIs(source *sqlc.Source) map[string]string {
+	if source.Metadata == nil {
+		return nil
+	}
+	var metadata struct {
+		BlobURIs map[string]string `json:"blob_uris"`
+	}
+	if err := json.Unmarshal([]byte(*source.Metadata), &metadata); err != nil {
+		return nil
+	}
+	return metadata.BlobURIs
+}
+
+// LocalBlob はディレクトリを保存先にする Blob
+// 全てのノードからマウントした共有ディレクトリ（NFS など）を指定する
+type LocalBlob struct {
+	root string
+}
+
+// NewLocalBlob は新しいLocalBlobを作成
+func NewLocalBlob(root string) (*LocalBlob, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &LocalBlob{root: root}, nil
+}
+
+// Put はデータを保存（一時ファイルに書いてからリネーム）
+func (b *LocalBlob) Put(ctx context.Context, key string, r io.Reader, size int64) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid blob key: %s", key)
+	}
+	path := filepath.Join(b.root, clean)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+	n, err := io.Copy(f, r)
+	if err == nil && n != size {
+		err = fmt.Errorf("wrote %d of %d bytes", n, size)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", err
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String(), nil
+}
+
+// Open はファイルを開く
+func (b *LocalBlob) Open(ctx context.Context, uri string) (BlobObject, error) {
+	path, err := b.path(uri)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &localObject{File: f, info: info}, nil
+}
+
+// Delete はファイルを削除（存在しない場合は何もしない）
+func (b *LocalBlob) Delete(ctx context.Context, uri string) error {
+	path, err := b.path(uri)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// path は URI をファイルパスに変換（ルート外を指す URI は拒否）
+func (b *LocalBlob) path(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", fmt.Errorf("invalid blob URI: %s", uri)
+	}
+	path := filepath.Clean(filepath.FromSlash(u.Path))
+	if !strings.HasPrefix(path, b.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("blob URI is outside %s: %s", b.root, uri)
+	}
+	return path, nil
+}
+
+// localObject は開いたファイル
+type localObject struct {
+	*os.File
+	info os.FileInfo
+}
+
+func (o *localObject) Size() int64        { return o.info.Size() }
+func (o *localObject) ModTime() time.Time { return o.info.ModTime() }
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// BookmarkRepository はブックマークのデータアクセス層
+type BookmarkRepository struct {
+	db *DB
+}
+
+// NewBookmarkRepository は新しいBookmarkRepositoryを作成
+func NewBookmarkRepository(db *DB) *BookmarkRepository {
+	return &BookmarkRepository{db: db}
+}
+
+// Create は新しいブックマークを作成
+func (r *BookmarkRepository) Create(ctx context.Context, bookmark *sqlc.Bookmark) error {
+	created, err := r.db.Queries.CreateBookmark(ctx, sqlc.CreateBookmarkParams{
+		SourceID:    bookmark.SourceID,
+		TimeSeconds: bookmark.TimeSeconds,
+		Label:       bookmark.Label,
+		Note:        bookmark.Note,
+		Color:       bookmark.Color,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	bookmark.ID = created.ID
+	bookmark.CreatedAt = created.CreatedAt
+	return nil
+}
+
+// Get はソースのブックマークを取得（該当なしの場合は nil）
+func (r *BookmarkRepository) Get(ctx context.Context, sourceID string, id int64) (*sqlc.Bookmark, error) {
+	bookmark, err := r.db.Queries.GetBookmark(ctx, sqlc.GetBookmarkParams{
+		ID:       id,
+		SourceID: sourceID,
+	})
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bookmark, nil
+}
+
+// ListBySourceID はソースのブックマークを時刻順に取得
+func (r *BookmarkRepository) ListBySourceID(ctx context.Context, sourceID string) ([]sqlc.Bookmark, error) {
+	return r.db.Queries.ListBookmarksBySourceID(ctx, sourceID)
+}
+
+// Update はブックマークのラベル・メモ・色を更新
+func (r *BookmarkRepository) Update(ctx context.Context, bookmark *sqlc.Bookmark) error {
+	_, err := r.db.Queries.UpdateBookmark(ctx, sqlc.UpdateBookmarkParams{
+		Label:    bookmark.Label,
+		Note:     bookmark.Note,
+		Color:    bookmark.Color,
+		ID:       bookmark.ID,
+		SourceID: bookmark.SourceID,
+	})
+	return err
+}
+
+// Delete はブックマークを削除（該当なしの場合は false）
+func (r *BookmarkRepository) Delete(ctx context.Context, sourceID string, id int64) (bool, error) {
+	n, err := r.db.Queries.DeleteBookmark(ctx, sqlc.DeleteBookmarkParams{
+		ID:       id,
+		SourceID: sourceID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
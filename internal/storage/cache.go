@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"sync"
+	"time"
+)
+
+// readCache は一覧・検索の結果をメモリに保持する読み取りキャッシュ
+// 書き込みのたびに invalidate で世代を進めて全て破棄する。
+// 読み込み中に書き込みがあった結果（古い世代の結果）は保存しない。
+// 他のプロセス（CLIツールなど）による書き込みは検知できないため、ttl を過ぎた結果も使わない
+type readCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration // 0 でキャッシュしない
+	maxEntries int
+	gen        uint64
+	entries    map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+// defaultCacheEntries は readCache が保持する結果の数の上限のデフォルト
+const defaultCacheEntries = 256
+
+func newReadCache(ttl time.Duration, maxEntries int) *readCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheEntries
+	}
+	return &readCache{ttl: ttl, maxEntries: maxEntries, entries: map[string]cacheEntry{}}
+}
+
+// configure は有効期間と上限を変更し、保持している結果を破棄する
+func (c *readCache) configure(ttl time.Duration, maxEntries int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheEntries
+	}
+	c.ttl = ttl
+	c.maxEntries = maxEntries
+	c.gen++
+	c.entries = map[string]cacheEntry{}
+}
+
+// invalidate は世代を進め、保持している結果を全て破棄する
+func (c *readCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	clear(c.entries)
+}
+
+// lookup は key の結果と現在の世代を返す
+func (c *readCache) lookup(key string) (any, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if ok && time.Now().After(e.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	return e.value, c.gen, ok
+}
+
+// store は世代 gen で読み込んだ結果を保存する（その後に書き込みがあった場合は保存しない）
+func (c *readCache) store(key string, gen uint64, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl <= 0 || gen != c.gen {
+		return
+	}
+	now := time.Now()
+	if len(c.entries) >= c.maxEntries {
+		// 期限切れを削除し、それでも多ければ全て破棄する
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			clear(c.entries)
+		}
+	}
+	c.entries[key] = cacheEntry{value: value, expires: now.Add(c.ttl)}
+}
+
+// cached は key の結果をキャッシュから返し、無ければ load で読み込んで保存する
+// キャッシュの結果は呼び出し元で共有されるため、変更しないこと
+func cached[T any](c *readCache, key string, load func() (T, error)) (T, error) {
+	v, gen, ok := c.lookup(key)
+	if ok {
+		return v.(T), nil
+	}
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.store(key, gen, value)
+	return value, nil
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"os"
+	"time"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// チェックサムの対象
+const (
+	ChecksumTargetFile     = "file"     // 音声ファイル（IDはファイルパス）
+	ChecksumTargetArtifact = "artifact" // アーティファクトのコンテンツ
+)
+
+// 整合性監査の結果
+const (
+	ChecksumStatusOK       = "ok"
+	ChecksumStatusMismatch = "mismatch" // 内容が変わっている（破損）
+	ChecksumStatusMissing  = "missing"  // ファイル・コンテンツが見つからない
+)
+
+// ChecksumRepository はチェックサムのデータアクセス層
+type ChecksumRepository struct {
+	db *DB
+}
+
+// NewChecksumRepository は新しいChecksumRepositoryを作成
+func NewChecksumRepository(db *DB) *ChecksumRepository {
+	return &ChecksumRepository{db: db}
+}
+
+// RecordFile はファイルのSHA-256を計算して記録
+func (r *ChecksumRepository) RecordFile(ctx context.Context, sourceID, path string) error {
+	sum, size, err := HashFile(path)
+	if err != nil {
+		return err
+	}
+	return r.db.Queries.UpsertChecksum(ctx, sqlc.UpsertChecksumParams{
+		TargetType: ChecksumTargetFile,
+		TargetID:   path,
+		SourceID:   &sourceID,
+		Sha256:     sum,
+		Size:       size,
+		RecordedAt: time.Now(),
+	})
+}
+
+// RecordArtifact はアーティファクトのコンテンツのチェックサムを記録
+func (r *ChecksumRepository) RecordArtifact(ctx context.Context, artifact *sqlc.ProcessingArtifact) error {
+	if artifact.Content == nil {
+		return nil
+	}
+	return recordArtifactChecksum(ctx, r.db.Queries, artifact.ID, artifact.SourceID, *artifact.Content)
+}
+
+// Get は対象のチェックサムを取得（未記録の場合は nil）
+func (r *ChecksumRepository) Get(ctx context.Context, targetType, targetID string) (*sqlc.Checksum, error) {
+	checksum, err := r.db.Queries.GetChecksum(ctx, sqlc.GetChecksumParams{
+		TargetType: targetType,
+		TargetID:   targetID,
+	})
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &checksum, nil
+}
+
+// ListForAudit は監査対象のチェックサムを取得（アーカイブ済みソースを除く）
+func (r *ChecksumRepository) ListForAudit(ctx context.Context, limit, offset int) ([]sqlc.Checksum, error) {
+	return r.db.Queries.ListChecksumsForAudit(ctx, sqlc.ListChecksumsForAuditParams{
+		Limit:  int64(limit),
+		Offset: int64(offset),
+	})
+}
+
+// ListProblems は直近の監査で破損・欠損が見つかったチェックサムを取得
+func (r *ChecksumRepository) ListProblems(ctx context.Context) ([]sqlc.Checksum, error) {
+	return r.db.Queries.ListChecksumProblems(ctx)
+}
+
+// UpdateStatus は監査結果を記録
+func (r *ChecksumRepository) UpdateStatus(ctx context.Context, targetType, targetID, status string) error {
+	return r.db.Queries.UpdateChecksumStatus(ctx, sqlc.UpdateChecksumStatusParams{
+		Status:     &status,
+		VerifiedAt: Ptr(time.Now()),
+		TargetType: targetType,
+		TargetID:   targetID,
+	})
+}
+
+// Delete はチェックサムを削除
+func (r *ChecksumRepository) Delete(ctx context.Context, targetType, targetID string) error {
+	return r.db.Queries.DeleteChecksum(ctx, sqlc.DeleteChecksumParams{
+		TargetType: targetType,
+		TargetID:   targetID,
+	})
+}
+
+// HashFile はファイルのSHA-256（16進）とサイズを返す
+func HashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// HashContent はコンテンツのSHA-256（16進）を返す
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordArtifactChecksum はアーティファクトのコンテンツのチェックサムを記録
+func recordArtifactChecksum(ctx context.Context, q *sqlc.Queries, id string, sourceID *string, content string) error {
+	return q.UpsertChecksum(ctx, sqlc.UpsertChecksumParams{
+		TargetType: ChecksumTargetArtifact,
+		TargetID:   id,
+		SourceID:   sourceID,
+		Sha256:     HashContent(content),
+		Size:       int64(len(content)),
+		RecordedAt: time.Now(),
+	})
+}
@@ -1,11 +1,14 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	_ "embed"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"zbor/internal/storage/sqlc"
 
@@ -19,10 +22,22 @@ var schemaSQL string
 type DB struct {
 	*sql.DB
 	Queries *sqlc.Queries
+
+	articles *readCache   // 記事の一覧・検索の結果（記事の書き込みで破棄）
+	events   EventHandler // データの変更の通知（SetEventHandler）
 }
 
-// Open はデータベースに接続し、スキーマを初期化する
+// Open はデータベースに接続し、スキーマを初期化して未適用のマイグレーションを適用する
 func Open(path string) (*DB, error) {
+	return open(path, true)
+}
+
+// OpenWithoutMigrations はマイグレーションを適用せずに接続する（zbor migrate で適用前の状態を確認するため）
+func OpenWithoutMigrations(path string) (*DB, error) {
+	return open(path, false)
+}
+
+func open(path string, applyMigrations bool) (*DB, error) {
 	// ディレクトリが存在しない場合は作成
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -59,11 +74,21 @@ func Open(path string) (*DB, error) {
 		db.Close()
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
+	if applyMigrations {
+		applied, err := migrate(context.Background(), db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		for _, m := range applied {
+			slog.Info("Applied database migration", "version", m.Version, "name", m.Name)
+		}
+	}
 
-	return &DB{DB: db, Queries: sqlc.New(db)}, nil
+	return &DB{DB: db, Queries: sqlc.New(db), articles: newReadCache(defaultArticleCacheTTL, 0)}, nil
 }
 
-// initSchema はスキーマを初期化する
+// initSchema はベースラインのスキーマ（schema.sql）を作成し、バージョン管理の導入前の変更を適用する
 func initSchema(db *sql.DB) error {
 	if _, err := db.Exec(schemaSQL); err != nil {
 		return err
@@ -73,7 +98,8 @@ func initSchema(db *sql.DB) error {
 	return runMigrations(db)
 }
 
-// runMigrations runs migrations for schema updates
+// runMigrations runs the schema updates made before versioned migrations
+// (see migrate.go); new changes go to migrations/
 func runMigrations(db *sql.DB) error {
 	// Migration: Add current_step column to processing_jobs if not exists
 	_, err := db.Exec(`
@@ -85,7 +111,92 @@ func runMigrations(db *sql.DB) error {
 		// SQLite returns "duplicate column name" for existing columns
 	}
 
-	return nil
+	// Migration: Add color column to bookmarks (databases created before bookmark colors)
+	_, _ = db.Exec(`
+		ALTER TABLE bookmarks ADD COLUMN color TEXT;
+	`)
+
+	// Migration: Add deleted_at column to articles (databases created before soft delete)
+	_, _ = db.Exec(`
+		ALTER TABLE articles ADD COLUMN deleted_at DATETIME;
+	`)
+
+	// Migration: Add owner_id columns (databases created before multi-user support)
+	for _, table := range []string{"articles", "sources", "processing_jobs"} {
+		_, _ = db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN owner_id TEXT;`)
+	}
+
+	// Migration: Add api_key column to processing_jobs (databases created before usage accounting)
+	_, _ = db.Exec(`
+		ALTER TABLE processing_jobs ADD COLUMN api_key TEXT;
+	`)
+
+	// Migration: Add metadata column to processing_jobs (databases created before job checkpoints)
+	_, _ = db.Exec(`
+		ALTER TABLE processing_jobs ADD COLUMN metadata TEXT;
+	`)
+
+	// Migration: Rebuild articles_fts with the trigram tokenizer (databases created
+	// with the default tokenizer cannot match Japanese substrings)
+	return migrateArticlesFTS(db)
+}
+
+// articlesFTSSchema は記事の全文検索テーブルの定義（schema.sql と同じ）
+const articlesFTSSchema = `
+	CREATE VIRTUAL TABLE articles_fts USING fts5(
+		article_id UNINDEXED,
+		title,
+		content,
+		summary,
+		tokenize = 'trigram'
+	)`
+
+// migrateArticlesFTS は articles_fts が trigram でない場合に作り直し、
+// インデックスの行数が記事数と一致しない場合は全記事を登録し直す（削除済みの記事は登録しない）
+func migrateArticlesFTS(db *sql.DB) error {
+	var ddl string
+	err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'articles_fts'`).Scan(&ddl)
+	if err != nil {
+		return fmt.Errorf("failed to read articles_fts schema: %w", err)
+	}
+	recreate := !strings.Contains(strings.ToLower(ddl), "trigram")
+
+	if !recreate {
+		var indexed, articles int64
+		if err := db.QueryRow(`SELECT COUNT(*) FROM articles_fts`).Scan(&indexed); err != nil {
+			return err
+		}
+		if err := db.QueryRow(`SELECT COUNT(*) FROM articles WHERE deleted_at IS NULL`).Scan(&articles); err != nil {
+			return err
+		}
+		if indexed == articles {
+			return nil
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if recreate {
+		if _, err := tx.Exec(`DROP TABLE articles_fts`); err != nil {
+			return fmt.Errorf("failed to drop articles_fts: %w", err)
+		}
+		if _, err := tx.Exec(articlesFTSSchema); err != nil {
+			return fmt.Errorf("failed to create articles_fts: %w", err)
+		}
+	} else if _, err := tx.Exec(`DELETE FROM articles_fts`); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO articles_fts (article_id, title, content, summary)
+		SELECT id, title, content, COALESCE(summary, '') FROM articles WHERE deleted_at IS NULL`)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild articles_fts: %w", err)
+	}
+	return tx.Commit()
 }
 
 // Close はデータベース接続を閉じる
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// EmbeddingRepository はセマンティック検索用のチャンクと埋め込みベクトルのデータアクセス層
+type EmbeddingRepository struct {
+	db *DB
+}
+
+// NewEmbeddingRepository は新しいEmbeddingRepositoryを作成
+func NewEmbeddingRepository(db *DB) *EmbeddingRepository {
+	return &EmbeddingRepository{db: db}
+}
+
+// Replace は記事のチャンクを置き換え（他のモデルのチャンクも削除する）、作成したチャンクのIDを順に返す
+func (r *EmbeddingRepository) Replace(ctx context.Context, articleID string, chunks []sqlc.EmbeddingChunk) ([]int64, error) {
+	now := time.Now()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := r.db.Queries.WithTx(tx)
+	if err := qtx.DeleteEmbeddingChunksByArticleID(ctx, articleID); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(chunks))
+	for _, c := range chunks {
+		id, err := qtx.CreateEmbeddingChunk(ctx, sqlc.CreateEmbeddingChunkParams{
+			ArticleID:  articleID,
+			SourceID:   c.SourceID,
+			ChunkIndex: c.ChunkIndex,
+			StartTime:  c.StartTime,
+			EndTime:    c.EndTime,
+			Text:       c.Text,
+			Model:      c.Model,
+			Dimensions: c.Dimensions,
+			Embedding:  c.Embedding,
+			CreatedAt:  now,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert chunk: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Get はチャンクを取得（埋め込みベクトルは含まない。該当なしの場合は nil）
+func (r *EmbeddingRepository) Get(ctx context.Context, id int64) (*sqlc.GetEmbeddingChunkRow, error) {
+	chunk, err := r.db.Queries.GetEmbeddingChunk(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &chunk, nil
+}
+
+// Vectors はモデルの全チャンクの埋め込みベクトルを取得（検索インデックスの読み込み用）
+func (r *EmbeddingRepository) Vectors(ctx context.Context, model string) ([]sqlc.ListEmbeddingVectorsRow, error) {
+	return r.db.Queries.ListEmbeddingVectors(ctx, model)
+}
+
+// Count はモデルのチャンク数を取得
+func (r *EmbeddingRepository) Count(ctx context.Context, model string) (int64, error) {
+	return r.db.Queries.CountEmbeddingChunks(ctx, model)
+}
+
+// ListArticlesToEmbed はモデルのチャンクが無い記事（新しい記事、本文が変わった記事、モデルを変えた後の記事）を
+// 更新日時の新しい順に取得（削除済み・本文が空の記事は除く）
+func (r *EmbeddingRepository) ListArticlesToEmbed(ctx context.Context, model string, limit int) ([]string, error) {
+	return r.db.Queries.ListArticlesWithoutEmbeddings(ctx, sqlc.ListArticlesWithoutEmbeddingsParams{
+		Model: model,
+		Limit: int64(limit),
+	})
+}
+
+// EncodeVector は埋め込みベクトルを float32 のリトルエンディアンのバイト列にする
+func EncodeVector(v []float32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+// DecodeVector は EncodeVector のバイト列を埋め込みベクトルに戻す
+func DecodeVector(b []byte) []float32 {
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
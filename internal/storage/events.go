@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// イベントの種類（Webhook で外部のシステムに通知する変更）
+const (
+	EventJobCompleted      = "job.completed"      // Data: *sqlc.ProcessingJob
+	EventJobFailed         = "job.failed"         // Data: *sqlc.ProcessingJob（依存先の失敗で失敗したジョブを含む）
+	EventArticleCreated    = "article.created"    // Data: *sqlc.Article
+	EventTranscriptUpdated = "transcript.updated" // Data: *sqlc.ProcessingArtifact（文字起こしの保存・編集・版の切り替え）
+)
+
+// Event はデータの変更の通知
+type Event struct {
+	Type    string
+	OwnerID *string // 変更したデータの所有者（コンテキストの利用者ではない）
+	Data    any
+}
+
+// EventHandler はデータの変更を受け取る関数
+// 書き込みのコミット後に書き込んだゴルーチンで呼ばれるため、時間のかかる処理はしない
+type EventHandler func(ctx context.Context, event Event)
+
+// SetEventHandler はデータの変更を通知する関数を設定（nil で通知しない）
+// 起動時、リポジトリを使い始める前に設定する
+func (db *DB) SetEventHandler(handler EventHandler) {
+	db.events = handler
+}
+
+// emit はイベントを通知する
+func (db *DB) emit(ctx context.Context, event Event) {
+	if db.events != nil {
+		db.events(ctx, event)
+	}
+}
+
+// emitJobFinished はジョブの完了・失敗を通知する（ジョブが取得できなければ通知しない）
+func (db *DB) emitJobFinished(ctx context.Context, eventType, id string) {
+	if db.events == nil {
+		return
+	}
+	job, err := db.Queries.GetJobByID(ctx, id)
+	if err != nil {
+		return
+	}
+	db.emit(ctx, Event{Type: eventType, OwnerID: job.OwnerID, Data: &job})
+}
+
+// emitTranscriptUpdated は文字起こしのアーティファクトの変更を通知する（所有者はソースの所有者）
+func (db *DB) emitTranscriptUpdated(ctx context.Context, artifact *sqlc.ProcessingArtifact) {
+	if db.events == nil || artifact.Type != ArtifactTypeTranscription || artifact.SourceID == nil {
+		return
+	}
+	owner, err := sourceOwner(ctx, db.Queries, *artifact.SourceID)
+	if err != nil {
+		return
+	}
+	db.emit(ctx, Event{Type: EventTranscriptUpdated, OwnerID: owner, Data: artifact})
+}
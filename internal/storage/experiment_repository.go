@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// ExperimentRepository は実験（cmd/transcribe-* の実行記録）のデータアクセス層
+type ExperimentRepository struct {
+	db *DB
+}
+
+// NewExperimentRepository は新しいExperimentRepositoryを作成
+func NewExperimentRepository(db *DB) *ExperimentRepository {
+	return &ExperimentRepository{db: db}
+}
+
+// Create は実験とチャンクごとの出力を保存し、experiment.ID を設定する
+func (r *ExperimentRepository) Create(ctx context.Context, experiment *sqlc.Experiment, chunks []sqlc.ExperimentChunk) error {
+	if experiment.CreatedAt.IsZero() {
+		experiment.CreatedAt = time.Now()
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := r.db.Queries.WithTx(tx)
+
+	id, err := qtx.CreateExperiment(ctx, sqlc.CreateExperimentParams{
+		Tool:              experiment.Tool,
+		Name:              experiment.Name,
+		InputFile:         experiment.InputFile,
+		Params:            experiment.Params,
+		Text:              experiment.Text,
+		AudioSeconds:      experiment.AudioSeconds,
+		ProcessingSeconds: experiment.ProcessingSeconds,
+		Rtf:               experiment.Rtf,
+		Reference:         experiment.Reference,
+		Cer:               experiment.Cer,
+		CreatedAt:         experiment.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create experiment: %w", err)
+	}
+
+	for i, chunk := range chunks {
+		err := qtx.CreateExperimentChunk(ctx, sqlc.CreateExperimentChunkParams{
+			ExperimentID: id,
+			Idx:          int64(i),
+			StartSec:     chunk.StartSec,
+			EndSec:       chunk.EndSec,
+			Text:         chunk.Text,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create experiment chunk: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	experiment.ID = id
+	return nil
+}
+
+// GetByID はIDで実験を取得
+func (r *ExperimentRepository) GetByID(ctx context.Context, id int64) (*sqlc.Experiment, error) {
+	experiment, err := r.db.Queries.GetExperiment(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// Chunks は実験のチャンクごとの出力を順に取得
+func (r *ExperimentRepository) Chunks(ctx context.Context, id int64) ([]sqlc.ExperimentChunk, error) {
+	return r.db.Queries.ListExperimentChunks(ctx, id)
+}
+
+// List は実験を新しい順に取得
+func (r *ExperimentRepository) List(ctx context.Context, limit, offset int) ([]sqlc.Experiment, error) {
+	if limit == 0 {
+		limit = 20
+	}
+	return r.db.Queries.ListExperiments(ctx, sqlc.ListExperimentsParams{
+		Limit:  int64(limit),
+		Offset: int64(offset),
+	})
+}
+
+// Count は実験の数を返す
+func (r *ExperimentRepository) Count(ctx context.Context) (int64, error) {
+	return r.db.Queries.CountExperiments(ctx)
+}
+
+// Delete は実験を削除する。存在しない場合は false
+func (r *ExperimentRepository) Delete(ctx context.Context, id int64) (bool, error) {
+	n, err := r.db.Queries.DeleteExperiment(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
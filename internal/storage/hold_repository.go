@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// ErrLegalHold はリーガルホールド中のソース・記事を変更しようとした場合のエラー
+var ErrLegalHold = errors.New("locked by legal hold")
+
+// リーガルホールドの対象
+const (
+	HoldTargetSource  = "source"  // ソースと、その成果物・記事すべて
+	HoldTargetArticle = "article" // 記事単体（ソースの削除・再文字起こしも不可）
+)
+
+// リーガルホールドの操作（監査ログ）
+const (
+	HoldActionLock   = "lock"
+	HoldActionUnlock = "unlock"
+)
+
+// HoldRepository はリーガルホールドのデータアクセス層
+type HoldRepository struct {
+	db *DB
+}
+
+// NewHoldRepository は新しいHoldRepositoryを作成
+func NewHoldRepository(db *DB) *HoldRepository {
+	return &HoldRepository{db: db}
+}
+
+// Lock は対象をロックし、監査ログに記録する
+func (r *HoldRepository) Lock(ctx context.Context, targetType, targetID, reason, actor string) (*sqlc.LegalHold, error) {
+	if err := validateHold(targetType, reason, actor); err != nil {
+		return nil, err
+	}
+
+	hold := &sqlc.LegalHold{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+		LockedBy:   actor,
+		LockedAt:   time.Now(),
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := r.db.Queries.WithTx(tx)
+
+	err = qtx.CreateLegalHold(ctx, sqlc.CreateLegalHoldParams{
+		TargetType: hold.TargetType,
+		TargetID:   hold.TargetID,
+		Reason:     hold.Reason,
+		LockedBy:   hold.LockedBy,
+		LockedAt:   hold.LockedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert legal hold: %w", err)
+	}
+
+	err = qtx.CreateLegalHoldEvent(ctx, sqlc.CreateLegalHoldEventParams{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Action:     HoldActionLock,
+		Actor:      actor,
+		Reason:     reason,
+		CreatedAt:  hold.LockedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert legal hold event: %w", err)
+	}
+
+	return hold, tx.Commit()
+}
+
+// Unlock はロックを解除し、監査ログに記録する（理由と操作者は必須）
+func (r *HoldRepository) Unlock(ctx context.Context, targetType, targetID, reason, actor string) error {
+	if err := validateHold(targetType, reason, actor); err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := r.db.Queries.WithTx(tx)
+
+	err = qtx.DeleteLegalHold(ctx, sqlc.DeleteLegalHoldParams{
+		TargetType: targetType,
+		TargetID:   targetID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete legal hold: %w", err)
+	}
+
+	err = qtx.CreateLegalHoldEvent(ctx, sqlc.CreateLegalHoldEventParams{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Action:     HoldActionUnlock,
+		Actor:      actor,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert legal hold event: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Get は対象のロックを取得（ロックされていない場合は nil）
+func (r *HoldRepository) Get(ctx context.Context, targetType, targetID string) (*sqlc.LegalHold, error) {
+	hold, err := r.db.Queries.GetLegalHold(ctx, sqlc.GetLegalHoldParams{
+		TargetType: targetType,
+		TargetID:   targetID,
+	})
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// List はロックを新しい順に取得（コンテキストの利用者のソース・記事のロックのみ）
+func (r *HoldRepository) List(ctx context.Context) ([]sqlc.LegalHold, error) {
+	return r.db.Queries.ListLegalHolds(ctx, ownerFilter(ctx))
+}
+
+// ListEvents は対象のロック・解除履歴を新しい順に取得
+func (r *HoldRepository) ListEvents(ctx context.Context, targetType, targetID string) ([]sqlc.LegalHoldEvent, error) {
+	return r.db.Queries.ListLegalHoldEvents(ctx, sqlc.ListLegalHoldEventsParams{
+		TargetType: targetType,
+		TargetID:   targetID,
+	})
+}
+
+func validateHold(targetType, reason, actor string) error {
+	if targetType != HoldTargetSource && targetType != HoldTargetArticle {
+		return fmt.Errorf("invalid hold target: %s", targetType)
+	}
+	if reason == "" || actor == "" {
+		return fmt.Errorf("reason and actor are required")
+	}
+	return nil
+}
+
+// checkSourceHold はソース（またはソースの記事）がロックされていれば ErrLegalHold を返す
+func checkSourceHold(ctx context.Context, q *sqlc.Queries, sourceID string) error {
+	n, err := q.CountSourceHolds(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to check legal hold: %w", err)
+	}
+	if n > 0 {
+		return fmt.Errorf("%w: source %s", ErrLegalHold, sourceID)
+	}
+	return nil
+}
+
+// checkArticleHold は記事（または記事のソース）がロックされていれば ErrLegalHold を返す
+func checkArticleHold(ctx context.Context, q *sqlc.Queries, articleID string) error {
+	n, err := q.CountArticleHolds(ctx, articleID)
+	if err != nil {
+		return fmt.Errorf("failed to check legal hold: %w", err)
+	}
+	if n > 0 {
+		return fmt.Errorf("%w: article %s", ErrLegalHold, articleID)
+	}
+	return nil
+}
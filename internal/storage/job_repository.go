@@ -3,9 +3,11 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"zbor/internal/logging"
 	"zbor/internal/storage/sqlc"
 )
 
@@ -21,6 +23,40 @@ func NewJobRepository(db *DB) *JobRepository {
 
 // Create は新しいジョブを作成
 func (r *JobRepository) Create(ctx context.Context, job *sqlc.ProcessingJob) error {
+	return r.create(ctx, r.db.Queries, job)
+}
+
+// CreateAfter は dependsOn のジョブが全て完了してから実行するジョブを作成
+// 依存先が失敗した場合、ジョブはキュー中のまま失敗になる
+func (r *JobRepository) CreateAfter(ctx context.Context, job *sqlc.ProcessingJob, dependsOn ...string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := r.db.Queries.WithTx(tx)
+	// 既に失敗した依存先は完了しないため、最初から失敗にする
+	for _, dep := range dependsOn {
+		depJob, err := qtx.GetJobByID(ctx, dep)
+		if err == nil && depJob.Status != nil && *depJob.Status == JobStatusFailed {
+			job.Status = Ptr(JobStatusFailed)
+			job.Error = Ptr(fmt.Sprintf("dependency %s failed", dep))
+			break
+		}
+	}
+	if err := r.create(ctx, qtx, job); err != nil {
+		return err
+	}
+	for _, dep := range dependsOn {
+		if err := qtx.AddJobDependency(ctx, sqlc.AddJobDependencyParams{JobID: job.ID, DependsOn: dep}); err != nil {
+			return fmt.Errorf("failed to add job dependency: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *JobRepository) create(ctx context.Context, q *sqlc.Queries, job *sqlc.ProcessingJob) error {
 	if job.ID == "" {
 		job.ID = uuid.New().String()
 	}
@@ -34,7 +70,29 @@ func (r *JobRepository) Create(ctx context.Context, job *sqlc.ProcessingJob) err
 		job.Priority = &priority
 	}
 
-	return r.db.Queries.CreateJob(ctx, sqlc.CreateJobParams{
+	// ロック中のソースは再文字起こし・要約などの処理をしない
+	if job.SourceID != nil {
+		if err := checkSourceHold(ctx, q, *job.SourceID); err != nil {
+			return err
+		}
+	}
+
+	// 所有者はソースの所有者（ソースが無ければ作成した利用者）
+	if job.OwnerID == nil && job.SourceID != nil {
+		var err error
+		job.OwnerID, err = sourceOwner(ctx, q, *job.SourceID)
+		if err != nil {
+			return err
+		}
+	}
+	job.OwnerID = ownerForNew(ctx, job.OwnerID)
+	if job.ApiKey == nil {
+		if name := APIKeyFromContext(ctx); name != "" {
+			job.ApiKey = &name
+		}
+	}
+
+	return q.CreateJob(ctx, sqlc.CreateJobParams{
 		ID:          job.ID,
 		SourceID:    job.SourceID,
 		Type:        job.Type,
@@ -47,10 +105,14 @@ func (r *JobRepository) Create(ctx context.Context, job *sqlc.ProcessingJob) err
 		CreatedAt:   job.CreatedAt,
 		StartedAt:   job.StartedAt,
 		CompletedAt: job.CompletedAt,
+		OwnerID:     job.OwnerID,
+		ApiKey:      job.ApiKey,
+		Metadata:    job.Metadata,
+		Payload:     job.Payload,
 	})
 }
 
-// GetByID はIDでジョブを取得
+// GetByID はIDでジョブを取得（他のユーザーのジョブは nil）
 func (r *JobRepository) GetByID(ctx context.Context, id string) (*sqlc.ProcessingJob, error) {
 	job, err := r.db.Queries.GetJobByID(ctx, id)
 	if err == sql.ErrNoRows {
@@ -59,10 +121,13 @@ func (r *JobRepository) GetByID(ctx context.Context, id string) (*sqlc.Processin
 	if err != nil {
 		return nil, err
 	}
+	if !ownerAllows(ctx, job.OwnerID) {
+		return nil, nil
+	}
 	return &job, nil
 }
 
-// GetNextQueued は次に処理すべきキュー済みジョブを取得（優先度順）
+// GetNextQueued は次に処理すべきキュー済みジョブを取得（優先度順、依存先が完了していないジョブを除く）
 func (r *JobRepository) GetNextQueued(ctx context.Context) (*sqlc.ProcessingJob, error) {
 	job, err := r.db.Queries.GetNextQueuedJob(ctx)
 	if err == sql.ErrNoRows {
@@ -74,6 +139,16 @@ func (r *JobRepository) GetNextQueued(ctx context.Context) (*sqlc.ProcessingJob,
 	return &job, nil
 }
 
+// ListRunnable は実行できるキュー済みジョブを優先度順に取得（依存先が完了していないジョブを除く）
+func (r *JobRepository) ListRunnable(ctx context.Context, limit int) ([]sqlc.ProcessingJob, error) {
+	return r.db.Queries.ListRunnableJobs(ctx, int64(limit))
+}
+
+// Dependencies はジョブが完了を待つジョブのIDを取得
+func (r *JobRepository) Dependencies(ctx context.Context, id string) ([]string, error) {
+	return r.db.Queries.ListJobDependencies(ctx, id)
+}
+
 // Start はジョブを開始状態にする
 func (r *JobRepository) Start(ctx context.Context, id string) error {
 	now := time.Now()
@@ -83,6 +158,159 @@ func (r *JobRepository) Start(ctx context.Context, id string) error {
 	})
 }
 
+// Claim はキュー済みジョブを実行中にしてワーカーに割り当てる
+// 他のワーカーが先に取得していた場合は false を返す
+func (r *JobRepository) Claim(ctx context.Context, id string, workerID string) (bool, error) {
+	now := time.Now()
+	n, err := r.db.Queries.ClaimQueuedJob(ctx, sqlc.ClaimQueuedJobParams{
+		StartedAt: &now,
+		ID:        id,
+	})
+	if err != nil || n == 0 {
+		return false, err
+	}
+	err = r.db.Queries.UpsertJobClaim(ctx, sqlc.UpsertJobClaimParams{
+		JobID:       id,
+		WorkerID:    workerID,
+		ClaimedAt:   now,
+		HeartbeatAt: now,
+	})
+	return err == nil, err
+}
+
+// GetClaim はジョブの割り当て情報を取得
+func (r *JobRepository) GetClaim(ctx context.Context, jobID string) (*sqlc.JobClaim, error) {
+	claim, err := r.db.Queries.GetJobClaim(ctx, jobID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// ListClaimsByWorker はワーカーが実行中のジョブの割り当て一覧を取得（割り当て順）
+func (r *JobRepository) ListClaimsByWorker(ctx context.Context, workerID string) ([]sqlc.JobClaim, error) {
+	return r.db.Queries.ListJobClaimsByWorker(ctx, workerID)
+}
+
+// Heartbeat は割り当てのハートビート時刻を更新
+func (r *JobRepository) Heartbeat(ctx context.Context, jobID string) error {
+	return r.db.Queries.TouchJobClaim(ctx, sqlc.TouchJobClaimParams{
+		HeartbeatAt: time.Now(),
+		JobID:       jobID,
+	})
+}
+
+// HeartbeatByWorker はワーカーの全割り当てのハートビート時刻を更新
+func (r *JobRepository) HeartbeatByWorker(ctx context.Context, workerID string) error {
+	return r.db.Queries.TouchJobClaimsByWorker(ctx, sqlc.TouchJobClaimsByWorkerParams{
+		HeartbeatAt: time.Now(),
+		WorkerID:    workerID,
+	})
+}
+
+// ReleaseClaim はジョブの割り当てを解除
+func (r *JobRepository) ReleaseClaim(ctx context.Context, jobID string) error {
+	return r.db.Queries.DeleteJobClaim(ctx, jobID)
+}
+
+// ListStaleClaims は指定時間ハートビートの無い割り当て一覧を取得
+func (r *JobRepository) ListStaleClaims(ctx context.Context, timeout time.Duration) ([]sqlc.JobClaim, error) {
+	return r.db.Queries.ListStaleJobClaims(ctx, time.Now().Add(-timeout))
+}
+
+// SaveResultChunk は結果チャンクを保存（同じインデックスは上書き）
+func (r *JobRepository) SaveResultChunk(ctx context.Context, jobID string, index int64, content string) error {
+	return r.db.Queries.UpsertJobResultChunk(ctx, sqlc.UpsertJobResultChunkParams{
+		JobID:      jobID,
+		ChunkIndex: index,
+		Content:    content,
+		CreatedAt:  time.Now(),
+	})
+}
+
+// ListResultChunkIndexes は受信済みチャンクのインデックス一覧を取得
+func (r *JobRepository) ListResultChunkIndexes(ctx context.Context, jobID string) ([]int64, error) {
+	return r.db.Queries.ListJobResultChunkIndexes(ctx, jobID)
+}
+
+// ListResultChunks は受信済みチャンクをインデックス順に取得
+func (r *JobRepository) ListResultChunks(ctx context.Context, jobID string) ([]sqlc.JobResultChunk, error) {
+	return r.db.Queries.ListJobResultChunks(ctx, jobID)
+}
+
+// DeleteResultChunks はジョブの結果チャンクを削除
+func (r *JobRepository) DeleteResultChunks(ctx context.Context, jobID string) error {
+	return r.db.Queries.DeleteJobResultChunks(ctx, jobID)
+}
+
+// SavePartialBlock はファイルのブロックの途中結果を保存（同じブロックは上書き）
+func (r *JobRepository) SavePartialBlock(ctx context.Context, jobID string, fileIndex, blockIndex int64, content string) error {
+	return r.db.Queries.UpsertJobPartialBlock(ctx, sqlc.UpsertJobPartialBlockParams{
+		JobID:      jobID,
+		FileIndex:  fileIndex,
+		BlockIndex: blockIndex,
+		Content:    content,
+		CreatedAt:  time.Now(),
+	})
+}
+
+// ListPartialBlocks はジョブの途中結果をファイル・ブロック順に取得
+func (r *JobRepository) ListPartialBlocks(ctx context.Context, jobID string) ([]sqlc.JobPartialBlock, error) {
+	return r.db.Queries.ListJobPartialBlocks(ctx, jobID)
+}
+
+// DeletePartialBlocks はジョブの途中結果を削除
+func (r *JobRepository) DeletePartialBlocks(ctx context.Context, jobID string) error {
+	return r.db.Queries.DeleteJobPartialBlocks(ctx, jobID)
+}
+
+// AppendLogs はジョブのログの行を保存（削除済みのジョブの行は捨てる）
+func (r *JobRepository) AppendLogs(ctx context.Context, lines []logging.JobLine) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := r.db.Queries.WithTx(tx)
+	exists := make(map[string]bool)
+	for _, line := range lines {
+		ok, checked := exists[line.JobID]
+		if !checked {
+			_, err := qtx.GetJobByID(ctx, line.JobID)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			ok = err == nil
+			exists[line.JobID] = ok
+		}
+		if !ok {
+			continue
+		}
+		if err := qtx.CreateJobLog(ctx, sqlc.CreateJobLogParams{
+			JobID:     line.JobID,
+			Level:     line.Level.String(),
+			Message:   line.Message,
+			CreatedAt: line.Time,
+		}); err != nil {
+			return fmt.Errorf("failed to save job log: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListLogs はジョブのログを afterID より後から古い順に取得
+func (r *JobRepository) ListLogs(ctx context.Context, jobID string, afterID int64, limit int) ([]sqlc.JobLog, error) {
+	return r.db.Queries.ListJobLogs(ctx, sqlc.ListJobLogsParams{
+		JobID: jobID,
+		ID:    afterID,
+		Limit: int64(limit),
+	})
+}
+
 // UpdateProgress はジョブの進捗を更新
 func (r *JobRepository) UpdateProgress(ctx context.Context, id string, progress int64) error {
 	return r.db.Queries.UpdateJobProgress(ctx, sqlc.UpdateJobProgressParams{
@@ -103,20 +331,55 @@ func (r *JobRepository) UpdateProgressWithStep(ctx context.Context, id string, p
 // Complete はジョブを完了状態にする
 func (r *JobRepository) Complete(ctx context.Context, id string) error {
 	now := time.Now()
-	return r.db.Queries.CompleteJob(ctx, sqlc.CompleteJobParams{
+	err := r.db.Queries.CompleteJob(ctx, sqlc.CompleteJobParams{
 		CompletedAt: &now,
 		ID:          id,
 	})
+	if err != nil {
+		return err
+	}
+	r.db.emitJobFinished(ctx, EventJobCompleted, id)
+	return nil
 }
 
 // Fail はジョブを失敗状態にする
+// このジョブの完了を待つキュー中のジョブも（依存関係をたどって）失敗にする
 func (r *JobRepository) Fail(ctx context.Context, id string, errorMsg string) error {
 	now := time.Now()
-	return r.db.Queries.FailJob(ctx, sqlc.FailJobParams{
+	err := r.db.Queries.FailJob(ctx, sqlc.FailJobParams{
 		Error:       &errorMsg,
 		CompletedAt: &now,
 		ID:          id,
 	})
+	if err != nil {
+		return err
+	}
+	r.db.emitJobFinished(ctx, EventJobFailed, id)
+
+	dependents, err := r.db.Queries.ListQueuedJobDependents(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, dep := range dependents {
+		if err := r.Fail(ctx, dep, fmt.Sprintf("dependency %s failed", id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListOrphaned はリモートワーカーに割り当てられていない実行中のジョブを取得（開始順）
+// サーバーの起動時には、これらを実行していたローカルのワーカーは既に存在しない
+func (r *JobRepository) ListOrphaned(ctx context.Context) ([]sqlc.ProcessingJob, error) {
+	return r.db.Queries.ListOrphanedJobs(ctx)
+}
+
+// UpdateMetadata はジョブのメタデータ（JSON。nil で削除）を更新
+func (r *JobRepository) UpdateMetadata(ctx context.Context, id string, metadata *string) error {
+	return r.db.Queries.UpdateJobMetadata(ctx, sqlc.UpdateJobMetadataParams{
+		Metadata: metadata,
+		ID:       id,
+	})
 }
 
 // Retry はジョブを再試行キューに戻す
@@ -131,21 +394,43 @@ func (r *JobRepository) GetBySourceID(ctx context.Context, sourceID string) ([]s
 
 // ListByStatus はステータスでジョブ一覧を取得
 func (r *JobRepository) ListByStatus(ctx context.Context, status string, limit int) ([]sqlc.ProcessingJob, error) {
-	if limit == 0 {
-		limit = 50
-	}
-	return r.db.Queries.ListJobsByStatus(ctx, sqlc.ListJobsByStatusParams{
-		Status: &status,
-		Limit:  int64(limit),
-	})
+	return r.List(ctx, status, limit, 0)
 }
 
 // ListRecent は最近のジョブ一覧を取得
 func (r *JobRepository) ListRecent(ctx context.Context, limit int) ([]sqlc.ProcessingJob, error) {
+	return r.List(ctx, "", limit, 0)
+}
+
+// List はジョブ一覧を取得（status が空なら新しい順に全件、指定時は優先度順。コンテキストの利用者のジョブのみ）
+func (r *JobRepository) List(ctx context.Context, status string, limit, offset int) ([]sqlc.ProcessingJob, error) {
 	if limit == 0 {
 		limit = 50
 	}
-	return r.db.Queries.ListRecentJobs(ctx, int64(limit))
+	if status != "" {
+		return r.db.Queries.ListJobsByStatus(ctx, sqlc.ListJobsByStatusParams{
+			Status:  &status,
+			OwnerID: ownerFilter(ctx),
+			Limit:   int64(limit),
+			Offset:  int64(offset),
+		})
+	}
+	return r.db.Queries.ListRecentJobs(ctx, sqlc.ListRecentJobsParams{
+		OwnerID: ownerFilter(ctx),
+		Limit:   int64(limit),
+		Offset:  int64(offset),
+	})
+}
+
+// Count はジョブ数を取得（status が空なら全件。コンテキストの利用者のジョブのみ）
+func (r *JobRepository) Count(ctx context.Context, status string) (int64, error) {
+	if status != "" {
+		return r.db.Queries.CountJobsWithStatus(ctx, sqlc.CountJobsWithStatusParams{
+			Status:  &status,
+			OwnerID: ownerFilter(ctx),
+		})
+	}
+	return r.db.Queries.CountJobs(ctx, ownerFilter(ctx))
 }
 
 // Delete はジョブを削除
@@ -159,6 +444,11 @@ func (r *JobRepository) CleanupCompleted(ctx context.Context, olderThanDays int)
 	return r.db.Queries.CleanupCompletedJobs(ctx, &cutoff)
 }
 
+// CountFailedByTypeSince は since 以降に失敗（リトライ上限到達）したジョブ数をタイプごとに取得
+func (r *JobRepository) CountFailedByTypeSince(ctx context.Context, since time.Time) ([]sqlc.CountFailedJobsByTypeSinceRow, error) {
+	return r.db.Queries.CountFailedJobsByTypeSince(ctx, &since)
+}
+
 // CountByStatus はステータスごとのジョブ数を取得
 func (r *JobRepository) CountByStatus(ctx context.Context) ([]sqlc.CountJobsByStatusRow, error) {
 	return r.db.Queries.CountJobsByStatus(ctx)
@@ -170,19 +460,38 @@ const (
 
 	// Model-specific transcription types
 	JobTypeTranscribeReazonSpeech   = "transcribe:reazonspeech"
+	JobTypeTranscribeReazonSpeechLM = "transcribe:reazonspeech:lm" // ReazonSpeech with LM rescoring of ambiguous blocks
 	JobTypeTranscribeSenseVoice     = "transcribe:sensevoice"
 	JobTypeTranscribeSenseVoiceBeam = "transcribe:sensevoice:beam" // SenseVoice with beam search
+	JobTypeTranscribeSenseVoiceLM   = "transcribe:sensevoice:lm"   // SenseVoice beam search with LM rescoring
+	JobTypeTranscribeWhisper        = "transcribe:whisper"
+	JobTypeTranscribeWhisperAlign   = "transcribe:whisper:align" // Whisper text on ReazonSpeech timestamps
+
+	JobTypeFetch       = "fetch"
+	JobTypeCrawl       = "crawl"
+	JobTypeFeedRefresh = "feed_refresh"
+	JobTypeSummarize   = "summarize"
+	JobTypeDownload    = "download"
+	JobTypeResegment   = "resegment" // Re-derive segments from stored tokens (no ASR)
+	JobTypeAutoTag     = "autotag"   // Suggest and attach tags to the source's articles
+	JobTypeShowNotes   = "shownotes" // Generate a show-notes article from a transcribed episode
+	JobTypeSubtitles   = "subtitles" // Store SRT/VTT subtitles of the transcription as artifacts
+	JobTypeRefine      = "refine"    // Re-decode low-confidence segments with Whisper (two-pass transcription)
+	JobTypeCleanup     = "cleanup"   // Delete the original audio of a transcribed source (retention policy)
+	JobTypeBackup      = "backup"    // Snapshot the database (and optionally the sources directory) to the backup directory
 
-	JobTypeFetch     = "fetch"
-	JobTypeSummarize = "summarize"
-	JobTypeDownload  = "download"
+	JobTypeAdjustBoundaries = "adjust_boundaries" // Snap every segment boundary of the transcript to the audio activity (waveform peaks)
+	JobTypeTranslate        = "translate"         // Translate the transcript segment by segment (translation artifact per language)
+	JobTypeAnalyze          = "analyze"           // Extract frequent keywords and named entities from the source's articles
 )
 
 // ASR Model types
 const (
 	ASRModelReazonSpeech   = "reazonspeech"
+	ASRModelReazonSpeechLM = "reazonspeech:lm" // ReazonSpeech with LM rescoring of ambiguous blocks
 	ASRModelSenseVoice     = "sensevoice"
 	ASRModelSenseVoiceBeam = "sensevoice:beam" // SenseVoice with beam search
+	ASRModelSenseVoiceLM   = "sensevoice:lm"   // SenseVoice beam search with LM rescoring
 	ASRModelWhisper        = "whisper"         // Whisper (no timestamps)
 	ASRModelWhisperAlign   = "whisper:align"   // Whisper with LCS-based timestamp alignment
 )
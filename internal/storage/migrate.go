@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFS はバージョン付きのマイグレーション（migrations/<バージョン>_<名前>.sql）
+// schema.sql はバージョン管理の導入時点のスキーマ（ベースライン）で、以降のスキーマ変更はマイグレーションとして追加する
+//
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// schemaMigrationsTable は適用済みのマイグレーションの記録
+const schemaMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`
+
+// Migration はバージョン付きのスキーマ変更
+type Migration struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	SQL       string     `json:"-"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"` // nil = 未適用
+}
+
+// SchemaStatus はデータベースのスキーマのバージョン
+type SchemaStatus struct {
+	Version int `json:"version"` // 適用済みの最新のバージョン（0 = ベースラインのみ）
+	Latest  int `json:"latest"`  // このバイナリのマイグレーションの最新のバージョン
+	Pending int `json:"pending"` // 未適用のマイグレーションの数
+}
+
+// Current はスキーマがこのバイナリと一致しているか（未適用のマイグレーションが無く、新しいバイナリで更新されていない）
+func (s *SchemaStatus) Current() bool {
+	return s.Pending == 0 && s.Version <= s.Latest
+}
+
+// Migrations はこのバイナリのマイグレーションをバージョン順に返す
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	seen := map[int]string{}
+	for _, e := range entries {
+		base := strings.TrimSuffix(e.Name(), ".sql")
+		prefix, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name: %s", e.Name())
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s, %s", version, other, e.Name())
+		}
+		seen[version] = e.Name()
+		data, err := migrationFS.ReadFile(path.Join("migrations", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(data)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrationHistory はこのバイナリのマイグレーションを適用日時付きで返す
+func (db *DB) MigrationHistory(ctx context.Context) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, db.DB)
+	if err != nil {
+		return nil, err
+	}
+	for i := range migrations {
+		if at, ok := applied[migrations[i].Version]; ok {
+			migrations[i].AppliedAt = &at
+		}
+	}
+	return migrations, nil
+}
+
+// SchemaStatus はスキーマのバージョンを返す
+func (db *DB) SchemaStatus(ctx context.Context) (*SchemaStatus, error) {
+	return schemaStatus(ctx, db.DB)
+}
+
+// schemaStatus は db のスキーマのバージョンを返す
+func schemaStatus(ctx context.Context, db *sql.DB) (*SchemaStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	status := &SchemaStatus{}
+	for version := range applied {
+		status.Version = max(status.Version, version)
+	}
+	for _, m := range migrations {
+		status.Latest = max(status.Latest, m.Version)
+		if _, ok := applied[m.Version]; !ok {
+			status.Pending++
+		}
+	}
+	return status, nil
+}
+
+// Migrate は未適用のマイグレーションをバージョン順に適用し、適用したものを返す
+// 1つのマイグレーションは1つのトランザクションで適用する（失敗したら以降は適用しない）
+func (db *DB) Migrate(ctx context.Context) ([]Migration, error) {
+	return migrate(ctx, db.DB)
+}
+
+// migrate は未適用のマイグレーションを適用する
+// 複数のプロセスが同時に起動しても、記録の INSERT で書き込みロックを取ってから適用するため二重に適用しない
+func migrate(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, schemaMigrationsTable); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		ok, err := applyMigration(ctx, db, m)
+		if err != nil {
+			return done, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		if ok {
+			done = append(done, m)
+		}
+	}
+	return done, nil
+}
+
+// applyMigration はマイグレーションを適用する（他のプロセスが適用済みなら false）
+func applyMigration(ctx context.Context, db *sql.DB, m Migration) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT OR IGNORE INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.Version, m.Name, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// appliedMigrations は適用済みのバージョンと適用日時を返す
+func appliedMigrations(ctx context.Context, db *sql.DB) (map[int]time.Time, error) {
+	if _, err := db.ExecContext(ctx, schemaMigrationsTable); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,4 @@
+-- ソースの記事・ジョブ・成果物の取得（ソースの詳細、削除、再文字起こし）で全件走査しないようにする
+CREATE INDEX IF NOT EXISTS idx_articles_source ON articles(source_id);
+CREATE INDEX IF NOT EXISTS idx_jobs_source ON processing_jobs(source_id);
+CREATE INDEX IF NOT EXISTS idx_artifacts_source ON processing_artifacts(source_id);
@@ -0,0 +1,2 @@
+-- ジョブごとの処理パラメーター（JSON。文字起こしのモデル・しきい値・テンポ・ホットワードなどの上書き）
+ALTER TABLE processing_jobs ADD COLUMN payload TEXT;
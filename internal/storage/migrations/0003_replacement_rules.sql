@@ -0,0 +1,14 @@
+-- 置換辞書（認識後に文字起こしのテキスト・トークン・セグメントへ適用する置換ルール）
+CREATE TABLE IF NOT EXISTS replacement_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_id TEXT,                          -- NULL: すべてのソースに適用
+    pattern TEXT NOT NULL,                   -- 置換前の表記（is_regex=1 なら正規表現）
+    replacement TEXT NOT NULL,
+    is_regex INTEGER NOT NULL DEFAULT 0,     -- 1: pattern は正規表現（replacement で $1 などを使える）
+    enabled INTEGER NOT NULL DEFAULT 1,
+    note TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_replacement_rules_source ON replacement_rules(source_id);
@@ -0,0 +1,11 @@
+-- 記事の分析結果（頻出キーワードと固有表現）。記事一覧の絞り込み（言及された人物など）に使う
+CREATE TABLE IF NOT EXISTS article_entities (
+    article_id TEXT NOT NULL,
+    kind TEXT NOT NULL,                      -- person / organization / place / keyword
+    name TEXT NOT NULL,
+    mentions INTEGER NOT NULL DEFAULT 1,     -- 記事中の出現回数（LLMで抽出した場合は 1）
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (article_id, kind, name),
+    FOREIGN KEY (article_id) REFERENCES articles(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_article_entities_kind_name ON article_entities(kind, name);
@@ -0,0 +1,17 @@
+-- セマンティック検索用の記事のチャンクと埋め込みベクトル
+CREATE TABLE IF NOT EXISTS embedding_chunks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id TEXT NOT NULL,
+    source_id TEXT,                          -- 文字起こしのチャンクはソース（時刻は start_time / end_time）
+    chunk_index INTEGER NOT NULL,            -- 記事内のチャンクの位置（0始まり）
+    start_time REAL,                         -- 文字起こしのチャンクの範囲（秒）。テキストのチャンクは NULL
+    end_time REAL,
+    text TEXT NOT NULL,
+    model TEXT NOT NULL,                     -- 埋め込みのバックエンドとモデル（例: api:text-embedding-3-small）
+    dimensions INTEGER NOT NULL,
+    embedding BLOB NOT NULL,                 -- float32 のリトルエンディアン（sqlite-vec の vec_f32 と同じ形式）
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (article_id) REFERENCES articles(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_embedding_chunks_article ON embedding_chunks(article_id);
+CREATE INDEX IF NOT EXISTS idx_embedding_chunks_model ON embedding_chunks(model);
@@ -0,0 +1,31 @@
+-- 利用者が登録した Webhook（イベントを JSON で POST する送信先）
+CREATE TABLE IF NOT EXISTS webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    owner_id TEXT,                           -- 所有者（users.id）。所有者のデータのイベントだけを送る
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,                    -- 署名（HMAC-SHA256）の鍵
+    events TEXT NOT NULL,                    -- 送るイベント（カンマ区切り、* はすべて）
+    enabled INTEGER NOT NULL DEFAULT 1,
+    description TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_owner ON webhooks(owner_id);
+
+-- Webhook の配信（送信待ち・再試行中・結果）
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    payload TEXT NOT NULL,                   -- 送信する JSON（署名の対象）
+    status TEXT NOT NULL DEFAULT 'pending',  -- pending, delivered, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    response_status INTEGER,                 -- 最後の試行の HTTP ステータス（接続できなければ NULL）
+    last_error TEXT,
+    created_at DATETIME NOT NULL,
+    delivered_at DATETIME,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id);
@@ -0,0 +1,10 @@
+-- ジョブのログ（実行中に job_id 付きのロガーで出力した行。ffmpeg の警告、ブロックごとのメッセージなど）
+CREATE TABLE IF NOT EXISTS job_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id TEXT NOT NULL,
+    level TEXT NOT NULL,                     -- DEBUG, INFO, WARN, ERROR
+    message TEXT NOT NULL,                   -- メッセージと属性（key=value）
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (job_id) REFERENCES processing_jobs(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_job_logs_job ON job_logs(job_id, id);
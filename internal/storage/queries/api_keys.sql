@@ -0,0 +1,23 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (name, key_hash, prefix, scope, created_at)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: GetAPIKeyByHash :one
+SELECT id, name, key_hash, prefix, scope, created_at, last_used_at
+FROM api_keys WHERE key_hash = ?;
+
+-- name: ListAPIKeys :many
+-- キーのハッシュは返さない
+SELECT id, name, prefix, scope, created_at, last_used_at
+FROM api_keys
+ORDER BY created_at DESC, id DESC;
+
+-- name: CountAPIKeys :one
+SELECT COUNT(*) FROM api_keys;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys SET last_used_at = ? WHERE id = ?;
+
+-- name: DeleteAPIKey :execrows
+DELETE FROM api_keys WHERE id = ?;
@@ -0,0 +1,21 @@
+-- name: CreateArticleRevision :exec
+INSERT INTO article_revisions (
+    article_id, title, content, summary, status, sections, custom_metadata,
+    updated_at, created_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetArticleRevision :one
+SELECT id, article_id, title, content, summary, status, sections, custom_metadata,
+    updated_at, created_at
+FROM article_revisions WHERE id = ?;
+
+-- name: ListArticleRevisions :many
+SELECT id, article_id, title, content, summary, status, sections, custom_metadata,
+    updated_at, created_at
+FROM article_revisions
+WHERE article_id = ?
+ORDER BY id DESC
+LIMIT ? OFFSET ?;
+
+-- name: CountArticleRevisions :one
+SELECT COUNT(*) FROM article_revisions WHERE article_id = ?;
@@ -3,14 +3,14 @@ INSERT INTO articles (
     id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+    source_id, parent_id, sections, custom_metadata, owner_id
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: GetArticleByID :one
 SELECT id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
 FROM articles WHERE id = ?;
 
 -- name: UpdateArticle :exec
@@ -28,8 +28,9 @@ DELETE FROM articles WHERE id = ?;
 SELECT id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
 FROM articles
+WHERE deleted_at IS NULL AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id)
 ORDER BY created_at DESC
 LIMIT ? OFFSET ?;
 
@@ -37,9 +38,9 @@ LIMIT ? OFFSET ?;
 SELECT id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
 FROM articles
-WHERE status = ?
+WHERE status = ? AND deleted_at IS NULL AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id)
 ORDER BY created_at DESC
 LIMIT ? OFFSET ?;
 
@@ -47,9 +48,9 @@ LIMIT ? OFFSET ?;
 SELECT id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
 FROM articles
-WHERE source_type = ?
+WHERE source_type = ? AND deleted_at IS NULL AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id)
 ORDER BY created_at DESC
 LIMIT ? OFFSET ?;
 
@@ -57,9 +58,9 @@ LIMIT ? OFFSET ?;
 SELECT id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
 FROM articles
-WHERE status = ? AND source_type = ?
+WHERE status = ? AND source_type = ? AND deleted_at IS NULL AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id)
 ORDER BY created_at DESC
 LIMIT ? OFFSET ?;
 
@@ -67,14 +68,27 @@ LIMIT ? OFFSET ?;
 SELECT id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
 FROM articles
-WHERE title LIKE ? OR content LIKE ?
+WHERE (title LIKE ? OR content LIKE ?) AND deleted_at IS NULL AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id)
 ORDER BY created_at DESC
 LIMIT ?;
 
 -- name: CountArticles :one
-SELECT COUNT(*) FROM articles;
+SELECT COUNT(*) FROM articles WHERE deleted_at IS NULL
+  AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id);
+
+-- name: CountArticlesByStatus :one
+SELECT COUNT(*) FROM articles WHERE status = ? AND deleted_at IS NULL
+  AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id);
+
+-- name: CountArticlesBySourceType :one
+SELECT COUNT(*) FROM articles WHERE source_type = ? AND deleted_at IS NULL
+  AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id);
+
+-- name: CountArticlesByStatusAndSourceType :one
+SELECT COUNT(*) FROM articles WHERE status = ? AND source_type = ? AND deleted_at IS NULL
+  AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id);
 
 -- name: InsertArticleFTS :exec
 INSERT INTO articles_fts (article_id, title, content, summary)
@@ -99,8 +113,52 @@ DELETE FROM article_tags WHERE article_id = ? AND tag_id = ?;
 SELECT id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
-FROM articles WHERE source_id = ?;
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
+FROM articles WHERE source_id = ? AND deleted_at IS NULL;
 
 -- name: DeleteArticlesBySourceID :exec
 DELETE FROM articles WHERE source_id = ?;
+
+-- name: SoftDeleteArticle :exec
+UPDATE articles SET deleted_at = ? WHERE id = ?;
+
+-- name: UndeleteArticle :exec
+UPDATE articles SET deleted_at = NULL WHERE id = ?;
+
+-- name: ListDeletedArticles :many
+SELECT id, title, content, summary,
+    source_type, source_url, author, published_at, language,
+    created_at, updated_at, status,
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
+FROM articles
+WHERE deleted_at IS NOT NULL AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id)
+ORDER BY deleted_at DESC
+LIMIT ? OFFSET ?;
+
+-- name: CountDeletedArticles :one
+SELECT COUNT(*) FROM articles WHERE deleted_at IS NOT NULL
+  AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id);
+
+-- name: ListPurgeableArticles :many
+-- 削除から保持期間を過ぎた記事（記事またはソースがロック中のものを除く）
+SELECT a.id FROM articles a
+WHERE a.deleted_at IS NOT NULL AND a.deleted_at < ?
+  AND NOT EXISTS (
+    SELECT 1 FROM legal_holds h
+    WHERE (h.target_type = 'article' AND h.target_id = a.id)
+       OR (h.target_type = 'source' AND h.target_id = a.source_id)
+  )
+ORDER BY a.deleted_at
+LIMIT ?;
+
+-- name: ListCompletedArticles :many
+-- 処理が完了した記事（削除済み・本文の無い記事と、ソースの処理中・失敗した記事を除く）
+SELECT a.id, a.title, a.content, a.summary,
+    a.source_type, a.source_url, a.author, a.published_at, a.language,
+    a.created_at, a.updated_at, a.status,
+    a.source_id, a.parent_id, a.sections, a.custom_metadata, a.deleted_at, a.owner_id
+FROM articles a
+LEFT JOIN sources s ON s.id = a.source_id
+WHERE a.deleted_at IS NULL AND a.content != ''
+  AND (s.id IS NULL OR s.status IS NULL OR s.status IN ('completed', 'archived'))
+ORDER BY a.created_at;
@@ -0,0 +1,40 @@
+-- name: GetBlob :one
+SELECT sha256, size, ref_count, created_at FROM blobs WHERE sha256 = ?;
+
+-- name: CreateBlob :exec
+INSERT INTO blobs (sha256, size, ref_count, created_at)
+VALUES (?, ?, 0, ?)
+ON CONFLICT(sha256) DO NOTHING;
+
+-- name: AddBlobRef :exec
+UPDATE blobs SET ref_count = ref_count + 1 WHERE sha256 = ?;
+
+-- name: ReleaseBlobRef :exec
+UPDATE blobs SET ref_count = ref_count - 1 WHERE sha256 = ? AND ref_count > 0;
+
+-- name: DeleteBlob :exec
+DELETE FROM blobs WHERE sha256 = ? AND ref_count = 0;
+
+-- name: ListUnreferencedBlobs :many
+SELECT sha256, size, ref_count, created_at FROM blobs WHERE ref_count = 0;
+
+-- name: GetSourceBlob :one
+SELECT path, source_id, sha256, created_at FROM source_blobs WHERE path = ?;
+
+-- name: CreateSourceBlob :exec
+INSERT INTO source_blobs (path, source_id, sha256, created_at)
+VALUES (?, ?, ?, ?);
+
+-- name: ListSourceBlobs :many
+SELECT path, source_id, sha256, created_at FROM source_blobs WHERE source_id = ? ORDER BY path;
+
+-- name: DeleteSourceBlob :exec
+DELETE FROM source_blobs WHERE path = ?;
+
+-- name: GetBlobStats :one
+-- stored_bytes: 実際に保存している容量、referenced_bytes: 重複を除かない場合の容量
+SELECT
+    CAST((SELECT COUNT(*) FROM blobs) AS INTEGER) AS blob_count,
+    CAST((SELECT COALESCE(SUM(size), 0) FROM blobs) AS INTEGER) AS stored_bytes,
+    CAST((SELECT COUNT(*) FROM source_blobs) AS INTEGER) AS file_count,
+    CAST((SELECT COALESCE(SUM(b.size), 0) FROM source_blobs sb JOIN blobs b ON b.sha256 = sb.sha256) AS INTEGER) AS referenced_bytes;
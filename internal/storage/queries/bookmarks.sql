@@ -0,0 +1,22 @@
+-- name: CreateBookmark :one
+INSERT INTO bookmarks (source_id, time_seconds, label, note, color, created_at)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, source_id, time_seconds, label, note, created_at, color;
+
+-- name: GetBookmark :one
+SELECT id, source_id, time_seconds, label, note, created_at, color
+FROM bookmarks WHERE id = ? AND source_id = ?;
+
+-- name: ListBookmarksBySourceID :many
+SELECT id, source_id, time_seconds, label, note, created_at, color
+FROM bookmarks
+WHERE source_id = ?
+ORDER BY time_seconds, id;
+
+-- name: UpdateBookmark :execrows
+UPDATE bookmarks
+SET label = ?, note = ?, color = ?
+WHERE id = ? AND source_id = ?;
+
+-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks WHERE id = ? AND source_id = ?;
@@ -0,0 +1,38 @@
+-- name: UpsertChecksum :exec
+INSERT INTO checksums (target_type, target_id, source_id, sha256, size, recorded_at)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(target_type, target_id) DO UPDATE SET
+    source_id = excluded.source_id,
+    sha256 = excluded.sha256,
+    size = excluded.size,
+    recorded_at = excluded.recorded_at,
+    verified_at = NULL,
+    status = NULL;
+
+-- name: GetChecksum :one
+SELECT target_type, target_id, source_id, sha256, size, recorded_at, verified_at, status
+FROM checksums WHERE target_type = ? AND target_id = ?;
+
+-- name: ListChecksumsForAudit :many
+-- アーカイブ済みソースのデータはローカルに無いため除外
+SELECT c.target_type, c.target_id, c.source_id, c.sha256, c.size, c.recorded_at, c.verified_at, c.status
+FROM checksums c
+LEFT JOIN sources s ON s.id = c.source_id
+WHERE COALESCE(s.status, '') != 'archived'
+ORDER BY c.target_type, c.target_id
+LIMIT ? OFFSET ?;
+
+-- name: ListChecksumProblems :many
+SELECT target_type, target_id, source_id, sha256, size, recorded_at, verified_at, status
+FROM checksums
+WHERE status IN ('mismatch', 'missing')
+ORDER BY verified_at DESC;
+
+-- name: UpdateChecksumStatus :exec
+UPDATE checksums SET status = ?, verified_at = ? WHERE target_type = ? AND target_id = ?;
+
+-- name: DeleteChecksum :exec
+DELETE FROM checksums WHERE target_type = ? AND target_id = ?;
+
+-- name: DeleteArtifactChecksumsBySourceID :exec
+DELETE FROM checksums WHERE target_type = 'artifact' AND source_id = ?;
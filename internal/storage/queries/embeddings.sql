@@ -0,0 +1,27 @@
+-- name: CreateEmbeddingChunk :one
+INSERT INTO embedding_chunks (article_id, source_id, chunk_index, start_time, end_time, text, model, dimensions, embedding, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: DeleteEmbeddingChunksByArticleID :exec
+DELETE FROM embedding_chunks WHERE article_id = ?;
+
+-- name: GetEmbeddingChunk :one
+SELECT id, article_id, source_id, chunk_index, start_time, end_time, text, model
+FROM embedding_chunks WHERE id = ?;
+
+-- name: ListEmbeddingVectors :many
+SELECT id, article_id, embedding
+FROM embedding_chunks
+WHERE model = ?
+ORDER BY id;
+
+-- name: ListArticlesWithoutEmbeddings :many
+SELECT a.id FROM articles a
+WHERE a.deleted_at IS NULL AND a.content != ''
+  AND NOT EXISTS (SELECT 1 FROM embedding_chunks e WHERE e.article_id = a.id AND e.model = ?)
+ORDER BY a.updated_at DESC
+LIMIT ?;
+
+-- name: CountEmbeddingChunks :one
+SELECT COUNT(*) FROM embedding_chunks WHERE model = ?;
@@ -0,0 +1,45 @@
+-- name: CreateArticleEntity :exec
+INSERT INTO article_entities (article_id, kind, name, mentions, created_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (article_id, kind, name) DO UPDATE SET mentions = mentions + excluded.mentions;
+
+-- name: DeleteArticleEntities :exec
+DELETE FROM article_entities WHERE article_id = ?;
+
+-- name: GetArticleEntities :many
+SELECT article_id, kind, name, mentions, created_at
+FROM article_entities
+WHERE article_id = ?
+ORDER BY kind, mentions DESC, name;
+
+-- name: ListEntityFacets :many
+SELECT e.name, COUNT(*) AS count
+FROM article_entities e
+JOIN articles a ON a.id = e.article_id
+WHERE e.kind = ? AND a.deleted_at IS NULL
+  AND a.owner_id IS COALESCE(sqlc.narg(owner_id), a.owner_id)
+GROUP BY e.name
+ORDER BY count DESC, e.name
+LIMIT ?;
+
+-- name: ListArticlesByEntity :many
+SELECT id, title, content, summary,
+    source_type, source_url, author, published_at, language,
+    created_at, updated_at, status,
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
+FROM articles
+WHERE id IN (SELECT article_id FROM article_entities WHERE kind = ? AND name = ?)
+  AND deleted_at IS NULL
+  AND status IS COALESCE(sqlc.narg(status), status)
+  AND source_type IS COALESCE(sqlc.narg(source_type), source_type)
+  AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id)
+ORDER BY created_at DESC
+LIMIT ? OFFSET ?;
+
+-- name: CountArticlesByEntity :one
+SELECT COUNT(*) FROM articles
+WHERE id IN (SELECT article_id FROM article_entities WHERE kind = ? AND name = ?)
+  AND deleted_at IS NULL
+  AND status IS COALESCE(sqlc.narg(status), status)
+  AND source_type IS COALESCE(sqlc.narg(source_type), source_type)
+  AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id);
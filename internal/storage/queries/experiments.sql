@@ -0,0 +1,35 @@
+-- name: CreateExperiment :one
+INSERT INTO experiments (
+    tool, name, input_file, params, text, audio_seconds, processing_seconds,
+    rtf, reference, cer, created_at
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: CreateExperimentChunk :exec
+INSERT INTO experiment_chunks (experiment_id, idx, start_sec, end_sec, text)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: GetExperiment :one
+SELECT id, tool, name, input_file, params, text, audio_seconds, processing_seconds,
+       rtf, reference, cer, created_at
+FROM experiments WHERE id = ?;
+
+-- name: ListExperiments :many
+SELECT id, tool, name, input_file, params, text, audio_seconds, processing_seconds,
+       rtf, reference, cer, created_at
+FROM experiments
+ORDER BY created_at DESC, id DESC
+LIMIT ? OFFSET ?;
+
+-- name: CountExperiments :one
+SELECT COUNT(*) FROM experiments;
+
+-- name: ListExperimentChunks :many
+SELECT experiment_id, idx, start_sec, end_sec, text
+FROM experiment_chunks
+WHERE experiment_id = ?
+ORDER BY idx;
+
+-- name: DeleteExperiment :execrows
+DELETE FROM experiments WHERE id = ?;
@@ -0,0 +1,40 @@
+-- name: CreateLegalHold :exec
+INSERT INTO legal_holds (target_type, target_id, reason, locked_by, locked_at)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: GetLegalHold :one
+SELECT target_type, target_id, reason, locked_by, locked_at
+FROM legal_holds WHERE target_type = ? AND target_id = ?;
+
+-- name: ListLegalHolds :many
+SELECT h.target_type, h.target_id, h.reason, h.locked_by, h.locked_at
+FROM legal_holds h
+LEFT JOIN sources s ON h.target_type = 'source' AND s.id = h.target_id
+LEFT JOIN articles a ON h.target_type = 'article' AND a.id = h.target_id
+WHERE COALESCE(s.owner_id, a.owner_id) IS COALESCE(sqlc.narg(owner_id), COALESCE(s.owner_id, a.owner_id))
+ORDER BY h.locked_at DESC;
+
+-- name: DeleteLegalHold :exec
+DELETE FROM legal_holds WHERE target_type = ? AND target_id = ?;
+
+-- name: CountSourceHolds :one
+-- ソース自体、またはソースに紐づく記事のロック数
+SELECT COUNT(*) FROM legal_holds
+WHERE (target_type = 'source' AND target_id = sqlc.arg(source_id))
+   OR (target_type = 'article' AND target_id IN (SELECT id FROM articles WHERE source_id = sqlc.arg(source_id)));
+
+-- name: CountArticleHolds :one
+-- 記事自体、または記事のソースのロック数
+SELECT COUNT(*) FROM legal_holds
+WHERE (target_type = 'article' AND target_id = sqlc.arg(article_id))
+   OR (target_type = 'source' AND target_id IN (SELECT source_id FROM articles WHERE id = sqlc.arg(article_id)));
+
+-- name: CreateLegalHoldEvent :exec
+INSERT INTO legal_hold_events (target_type, target_id, action, actor, reason, created_at)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListLegalHoldEvents :many
+SELECT id, target_type, target_id, action, actor, reason, created_at
+FROM legal_hold_events
+WHERE target_type = ? AND target_id = ?
+ORDER BY created_at DESC, id DESC;
@@ -0,0 +1,11 @@
+-- name: CreateJobLog :exec
+INSERT INTO job_logs (job_id, level, message, created_at)
+VALUES (?, ?, ?, ?);
+
+-- name: ListJobLogs :many
+-- id より後の行を古い順に
+SELECT id, job_id, level, message, created_at
+FROM job_logs
+WHERE job_id = ? AND id > ?
+ORDER BY id
+LIMIT ?;
@@ -1,27 +1,57 @@
 -- name: CreateJob :exec
 INSERT INTO processing_jobs (
     id, source_id, type, status, priority, progress, current_step,
-    retry_count, error, created_at, started_at, completed_at
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: GetJobByID :one
 SELECT id, source_id, type, status, priority, progress, current_step,
-    retry_count, error, created_at, started_at, completed_at
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
 FROM processing_jobs WHERE id = ?;
 
 -- name: GetNextQueuedJob :one
 SELECT id, source_id, type, status, priority, progress, current_step,
-    retry_count, error, created_at, started_at, completed_at
-FROM processing_jobs
-WHERE status = 'queued'
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
+FROM processing_jobs j
+WHERE status = 'queued' AND NOT EXISTS (
+    SELECT 1 FROM job_dependencies d JOIN processing_jobs p ON p.id = d.depends_on
+    WHERE d.job_id = j.id AND p.status != 'completed'
+)
 ORDER BY priority ASC, created_at ASC
 LIMIT 1;
 
+-- name: ListRunnableJobs :many
+SELECT id, source_id, type, status, priority, progress, current_step,
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
+FROM processing_jobs j
+WHERE status = 'queued' AND NOT EXISTS (
+    SELECT 1 FROM job_dependencies d JOIN processing_jobs p ON p.id = d.depends_on
+    WHERE d.job_id = j.id AND p.status != 'completed'
+)
+ORDER BY priority ASC, created_at ASC
+LIMIT ?;
+
+-- name: AddJobDependency :exec
+INSERT INTO job_dependencies (job_id, depends_on) VALUES (?, ?);
+
+-- name: ListJobDependencies :many
+SELECT depends_on FROM job_dependencies WHERE job_id = ? ORDER BY depends_on;
+
+-- name: ListQueuedJobDependents :many
+SELECT j.id FROM job_dependencies d JOIN processing_jobs j ON j.id = d.job_id
+WHERE d.depends_on = ? AND j.status = 'queued'
+ORDER BY j.id;
+
 -- name: StartJob :exec
 UPDATE processing_jobs
 SET status = 'running', started_at = ?
 WHERE id = ?;
 
+-- name: ClaimQueuedJob :execrows
+UPDATE processing_jobs
+SET status = 'running', started_at = ?
+WHERE id = ? AND status = 'queued';
+
 -- name: UpdateJobProgress :exec
 UPDATE processing_jobs SET progress = ? WHERE id = ?;
 
@@ -43,27 +73,40 @@ UPDATE processing_jobs
 SET status = 'queued', retry_count = retry_count + 1, error = NULL, current_step = NULL
 WHERE id = ?;
 
+-- name: UpdateJobMetadata :exec
+UPDATE processing_jobs SET metadata = ? WHERE id = ?;
+
+-- name: ListOrphanedJobs :many
+SELECT id, source_id, type, status, priority, progress, current_step,
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
+FROM processing_jobs j
+WHERE status = 'running' AND NOT EXISTS (
+    SELECT 1 FROM job_claims c WHERE c.job_id = j.id
+)
+ORDER BY started_at ASC;
+
 -- name: GetJobsBySourceID :many
 SELECT id, source_id, type, status, priority, progress, current_step,
-    retry_count, error, created_at, started_at, completed_at
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
 FROM processing_jobs
 WHERE source_id = ?
 ORDER BY created_at DESC;
 
 -- name: ListJobsByStatus :many
 SELECT id, source_id, type, status, priority, progress, current_step,
-    retry_count, error, created_at, started_at, completed_at
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
 FROM processing_jobs
-WHERE status = ?
+WHERE status = ? AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id)
 ORDER BY priority ASC, created_at ASC
-LIMIT ?;
+LIMIT ? OFFSET ?;
 
 -- name: ListRecentJobs :many
 SELECT id, source_id, type, status, priority, progress, current_step,
-    retry_count, error, created_at, started_at, completed_at
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
 FROM processing_jobs
+WHERE owner_id IS COALESCE(sqlc.narg(owner_id), owner_id)
 ORDER BY created_at DESC
-LIMIT ?;
+LIMIT ? OFFSET ?;
 
 -- name: DeleteJob :exec
 DELETE FROM processing_jobs WHERE id = ?;
@@ -74,3 +117,16 @@ WHERE status = 'completed' AND completed_at < ?;
 
 -- name: CountJobsByStatus :many
 SELECT status, COUNT(*) as count FROM processing_jobs GROUP BY status;
+
+-- name: CountFailedJobsByTypeSince :many
+SELECT type, COUNT(*) as count FROM processing_jobs
+WHERE status = 'failed' AND completed_at >= ?
+GROUP BY type;
+
+-- name: CountJobs :one
+SELECT COUNT(*) FROM processing_jobs
+WHERE owner_id IS COALESCE(sqlc.narg(owner_id), owner_id);
+
+-- name: CountJobsWithStatus :one
+SELECT COUNT(*) FROM processing_jobs
+WHERE status = ? AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id);
@@ -0,0 +1,70 @@
+-- name: UpsertJobClaim :exec
+INSERT INTO job_claims (job_id, worker_id, claimed_at, heartbeat_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(job_id) DO UPDATE SET
+    worker_id = excluded.worker_id,
+    claimed_at = excluded.claimed_at,
+    heartbeat_at = excluded.heartbeat_at;
+
+-- name: GetJobClaim :one
+SELECT job_id, worker_id, claimed_at, heartbeat_at
+FROM job_claims WHERE job_id = ?;
+
+-- name: ListJobClaimsByWorker :many
+SELECT c.job_id, c.worker_id, c.claimed_at, c.heartbeat_at
+FROM job_claims c
+JOIN processing_jobs j ON j.id = c.job_id
+WHERE c.worker_id = ? AND j.status = 'running'
+ORDER BY c.claimed_at ASC;
+
+-- name: TouchJobClaim :exec
+UPDATE job_claims SET heartbeat_at = ? WHERE job_id = ?;
+
+-- name: TouchJobClaimsByWorker :exec
+UPDATE job_claims SET heartbeat_at = ? WHERE worker_id = ?;
+
+-- name: DeleteJobClaim :exec
+DELETE FROM job_claims WHERE job_id = ?;
+
+-- name: ListStaleJobClaims :many
+SELECT job_id, worker_id, claimed_at, heartbeat_at
+FROM job_claims
+WHERE heartbeat_at < ?
+ORDER BY heartbeat_at ASC;
+
+-- name: UpsertJobResultChunk :exec
+INSERT INTO job_result_chunks (job_id, chunk_index, content, created_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(job_id, chunk_index) DO UPDATE SET
+    content = excluded.content,
+    created_at = excluded.created_at;
+
+-- name: ListJobResultChunkIndexes :many
+SELECT chunk_index FROM job_result_chunks
+WHERE job_id = ?
+ORDER BY chunk_index ASC;
+
+-- name: ListJobResultChunks :many
+SELECT job_id, chunk_index, content, created_at
+FROM job_result_chunks
+WHERE job_id = ?
+ORDER BY chunk_index ASC;
+
+-- name: DeleteJobResultChunks :exec
+DELETE FROM job_result_chunks WHERE job_id = ?;
+
+-- name: UpsertJobPartialBlock :exec
+INSERT INTO job_partial_blocks (job_id, file_index, block_index, content, created_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(job_id, file_index, block_index) DO UPDATE SET
+    content = excluded.content,
+    created_at = excluded.created_at;
+
+-- name: ListJobPartialBlocks :many
+SELECT job_id, file_index, block_index, content, created_at
+FROM job_partial_blocks
+WHERE job_id = ?
+ORDER BY file_index ASC, block_index ASC;
+
+-- name: DeleteJobPartialBlocks :exec
+DELETE FROM job_partial_blocks WHERE job_id = ?;
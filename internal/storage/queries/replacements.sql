@@ -0,0 +1,34 @@
+-- name: CreateReplacementRule :one
+INSERT INTO replacement_rules (source_id, pattern, replacement, is_regex, enabled, note, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, source_id, pattern, replacement, is_regex, enabled, note, created_at, updated_at;
+
+-- name: GetReplacementRule :one
+SELECT id, source_id, pattern, replacement, is_regex, enabled, note, created_at, updated_at
+FROM replacement_rules WHERE id = ?;
+
+-- name: ListGlobalReplacementRules :many
+SELECT id, source_id, pattern, replacement, is_regex, enabled, note, created_at, updated_at
+FROM replacement_rules
+WHERE source_id IS NULL
+ORDER BY id;
+
+-- name: ListReplacementRulesBySourceID :many
+SELECT id, source_id, pattern, replacement, is_regex, enabled, note, created_at, updated_at
+FROM replacement_rules
+WHERE source_id = ?
+ORDER BY id;
+
+-- name: ListEnabledReplacementRules :many
+SELECT id, source_id, pattern, replacement, is_regex, enabled, note, created_at, updated_at
+FROM replacement_rules
+WHERE enabled = 1 AND (source_id IS NULL OR source_id = ?)
+ORDER BY source_id IS NOT NULL, id;
+
+-- name: UpdateReplacementRule :execrows
+UPDATE replacement_rules
+SET pattern = ?, replacement = ?, is_regex = ?, enabled = ?, note = ?, updated_at = ?
+WHERE id = ?;
+
+-- name: DeleteReplacementRule :execrows
+DELETE FROM replacement_rules WHERE id = ?;
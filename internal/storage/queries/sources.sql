@@ -1,23 +1,87 @@
 -- name: CreateSource :exec
-INSERT INTO sources (id, type, original_url, file_path, metadata, created_at, status)
-VALUES (?, ?, ?, ?, ?, ?, ?);
+INSERT INTO sources (id, type, original_url, file_path, metadata, created_at, status, owner_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: GetSourceByID :one
-SELECT id, type, original_url, file_path, metadata, created_at, status
+SELECT id, type, original_url, file_path, metadata, created_at, status, owner_id
 FROM sources WHERE id = ?;
 
+-- name: GetSourceByContentHash :one
+-- 同じ内容の音声をアップロードしたソース（失敗したものを除き、最も古いもの）
+SELECT id, type, original_url, file_path, metadata, created_at, status, owner_id
+FROM sources
+WHERE json_extract(metadata, '$.content_hash') = sqlc.arg(content_hash)
+  AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id)
+  AND COALESCE(status, '') != 'failed'
+ORDER BY created_at
+LIMIT 1;
+
 -- name: UpdateSourceStatus :exec
 UPDATE sources SET status = ? WHERE id = ?;
 
+-- name: UpdateSourceMetadata :exec
+UPDATE sources SET metadata = ? WHERE id = ?;
+
 -- name: DeleteSource :exec
 DELETE FROM sources WHERE id = ?;
 
 -- name: ListSources :many
-SELECT id, type, original_url, file_path, metadata, created_at, status
+SELECT id, type, original_url, file_path, metadata, created_at, status, owner_id
 FROM sources
+WHERE owner_id IS COALESCE(sqlc.narg(owner_id), owner_id)
 ORDER BY created_at DESC
 LIMIT ? OFFSET ?;
 
+-- name: ListSourcesByType :many
+SELECT id, type, original_url, file_path, metadata, created_at, status, owner_id
+FROM sources
+WHERE type = ? AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id)
+ORDER BY created_at DESC;
+
+-- name: CountSourcesByOriginalURL :one
+SELECT COUNT(*) FROM sources WHERE original_url = ?;
+
+-- name: ListArchivableSources :many
+SELECT s.id, s.type, s.original_url, s.file_path, s.metadata, s.created_at, s.status, s.owner_id
+FROM sources s
+LEFT JOIN source_access a ON a.source_id = s.id
+WHERE s.status = 'completed'
+  AND s.type IN ('audio', 'youtube')
+  AND s.id NOT IN (SELECT target_id FROM legal_holds WHERE target_type = 'source')
+  AND COALESCE(a.accessed_at, s.created_at) < sqlc.arg(cutoff)
+ORDER BY s.created_at
+LIMIT sqlc.arg(limit);
+
+-- name: ListCleanableSources :many
+-- 文字起こしの完了（最新の文字起こし成果物の作成日時）から cutoff 以上経ち、元音声をまだ削除していないソース
+SELECT s.id, s.type, s.original_url, s.file_path, s.metadata, s.created_at, s.status, s.owner_id
+FROM sources s
+WHERE s.status = 'completed'
+  AND s.type IN ('audio', 'youtube', 'podcast')
+  AND s.id NOT IN (SELECT target_id FROM legal_holds WHERE target_type = 'source')
+  AND json_extract(COALESCE(s.metadata, '{}'), '$.storage_cleanup') IS NULL
+  AND (
+    SELECT MAX(a.created_at) FROM processing_artifacts a
+    WHERE a.source_id = s.id AND a.type = 'transcription' AND a.content IS NOT NULL
+  ) < sqlc.arg(cutoff)
+ORDER BY s.created_at
+LIMIT sqlc.arg(limit);
+
+-- name: ListTranscribedSources :many
+SELECT s.id, s.type, s.original_url, s.file_path, s.metadata, s.created_at, s.status, s.owner_id
+FROM sources s
+WHERE s.owner_id IS COALESCE(sqlc.narg(owner_id), s.owner_id)
+  AND EXISTS (
+  SELECT 1 FROM processing_artifacts a
+  WHERE a.source_id = s.id AND a.type = 'transcription' AND a.content IS NOT NULL
+)
+ORDER BY s.created_at DESC;
+
+-- name: UpsertSourceAccess :exec
+INSERT INTO source_access (source_id, accessed_at)
+VALUES (?, ?)
+ON CONFLICT(source_id) DO UPDATE SET accessed_at = excluded.accessed_at;
+
 -- name: CreateArtifact :exec
 INSERT INTO processing_artifacts (id, source_id, type, content, format, file_path, metadata, created_at)
 VALUES (?, ?, ?, ?, ?, ?, ?, ?);
@@ -40,3 +104,6 @@ DELETE FROM processing_artifacts WHERE source_id = ?;
 
 -- name: UpdateArtifactContent :exec
 UPDATE processing_artifacts SET content = ? WHERE id = ?;
+
+-- name: UpdateArtifactType :exec
+UPDATE processing_artifacts SET type = ?, metadata = ? WHERE id = ?;
@@ -19,11 +19,53 @@ DELETE FROM tags WHERE id = ?;
 DELETE FROM article_tags WHERE tag_id = ?;
 
 -- name: ListTags :many
-SELECT id, name, color, created_at FROM tags ORDER BY name;
+SELECT id, name, color, created_at FROM tags ORDER BY name
+LIMIT ? OFFSET ?;
 
 -- name: ListTagsWithCount :many
 SELECT t.id, t.name, t.color, t.created_at, COUNT(at.article_id) as count
 FROM tags t
 LEFT JOIN article_tags at ON t.id = at.tag_id
 GROUP BY t.id
-ORDER BY count DESC, t.name;
+ORDER BY count DESC, t.name
+LIMIT ? OFFSET ?;
+
+-- name: CountTags :one
+SELECT COUNT(*) FROM tags;
+
+-- name: SearchTagsByPrefix :many
+SELECT t.id, t.name, t.color, t.created_at, COUNT(at.article_id) as count
+FROM tags t
+LEFT JOIN article_tags at ON t.id = at.tag_id
+WHERE t.name LIKE ? ESCAPE '\'
+GROUP BY t.id
+ORDER BY count DESC, t.name
+LIMIT ?;
+
+-- name: CreateTagSuggestion :execrows
+INSERT INTO tag_suggestions (article_id, name, tag_id, score, method, status, created_at)
+VALUES (?, ?, ?, ?, ?, 'pending', ?)
+ON CONFLICT (article_id, name) DO NOTHING;
+
+-- name: GetTagSuggestion :one
+SELECT id, article_id, name, tag_id, score, method, status, created_at, decided_at
+FROM tag_suggestions WHERE id = ?;
+
+-- name: ListTagSuggestionsByArticle :many
+SELECT id, article_id, name, tag_id, score, method, status, created_at, decided_at
+FROM tag_suggestions WHERE article_id = ?
+ORDER BY score DESC, name;
+
+-- name: DecideTagSuggestion :exec
+UPDATE tag_suggestions SET status = ?, tag_id = ?, decided_at = ? WHERE id = ?;
+
+-- name: ListArticlesForAutoTag :many
+SELECT a.id FROM articles a
+WHERE a.created_at >= ? AND a.deleted_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM tag_suggestions s WHERE s.article_id = a.id)
+  AND NOT EXISTS (
+    SELECT 1 FROM processing_jobs j
+    WHERE j.type = 'autotag' AND j.source_id = a.source_id
+  )
+ORDER BY a.created_at
+LIMIT ?;
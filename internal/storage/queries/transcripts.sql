@@ -0,0 +1,36 @@
+-- name: CreateTranscriptSegment :one
+INSERT INTO transcript_segments (source_id, segment_index, start_time, end_time, speaker, text)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: InsertTranscriptSegmentFTS :exec
+INSERT INTO transcript_segments_fts (rowid, text) VALUES (?, ?);
+
+-- name: DeleteTranscriptSegmentFTSBySourceID :exec
+DELETE FROM transcript_segments_fts
+WHERE rowid IN (SELECT id FROM transcript_segments WHERE source_id = ?);
+
+-- name: DeleteTranscriptSegmentsBySourceID :exec
+DELETE FROM transcript_segments WHERE source_id = ?;
+
+-- name: CountTranscriptSegmentsBySourceID :one
+SELECT COUNT(*) FROM transcript_segments WHERE source_id = ?;
+
+-- name: SearchTranscriptSegmentsLike :many
+SELECT id, source_id, segment_index, start_time, end_time, speaker, text
+FROM transcript_segments
+WHERE text LIKE ?
+  AND source_id IN (SELECT id FROM sources WHERE owner_id IS COALESCE(sqlc.narg(owner_id), owner_id))
+ORDER BY id
+LIMIT ? OFFSET ?;
+
+-- name: CountTranscriptSegmentsLike :one
+SELECT COUNT(*) FROM transcript_segments
+WHERE text LIKE ?
+  AND source_id IN (SELECT id FROM sources WHERE owner_id IS COALESCE(sqlc.narg(owner_id), owner_id));
+
+-- name: ListTranscriptSegmentsBySourceID :many
+SELECT id, source_id, segment_index, start_time, end_time, speaker, text
+FROM transcript_segments
+WHERE source_id = ?
+ORDER BY segment_index;
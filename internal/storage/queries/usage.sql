@@ -0,0 +1,39 @@
+-- name: CreateUsageRecord :exec
+INSERT INTO usage_records (job_id, source_id, owner_id, api_key, model, seconds, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: SumUsageSince :one
+-- 絞り込みの無い条件（NULL）は全件に一致する
+SELECT CAST(COALESCE(SUM(seconds), 0) AS REAL) AS seconds
+FROM usage_records
+WHERE created_at >= sqlc.arg(since)
+  AND owner_id IS COALESCE(sqlc.narg(owner_id), owner_id)
+  AND api_key IS COALESCE(sqlc.narg(api_key), api_key)
+  AND model IS COALESCE(sqlc.narg(model), model);
+
+-- name: ListUsageSummary :many
+SELECT r.owner_id, u.username, r.api_key, r.model,
+    COUNT(*) AS jobs,
+    CAST(SUM(r.seconds) AS REAL) AS seconds
+FROM usage_records r
+LEFT JOIN users u ON u.id = r.owner_id
+WHERE r.created_at >= sqlc.arg(since) AND r.created_at < sqlc.arg(until)
+  AND r.owner_id IS COALESCE(sqlc.narg(owner_id), r.owner_id)
+GROUP BY r.owner_id, r.api_key, r.model
+ORDER BY seconds DESC;
+
+-- name: ListUsageQuotas :many
+SELECT scope, subject, monthly_minutes, updated_at FROM usage_quotas ORDER BY scope, subject;
+
+-- name: GetUsageQuota :one
+SELECT scope, subject, monthly_minutes, updated_at FROM usage_quotas WHERE scope = ? AND subject = ?;
+
+-- name: UpsertUsageQuota :exec
+INSERT INTO usage_quotas (scope, subject, monthly_minutes, updated_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(scope, subject) DO UPDATE SET
+    monthly_minutes = excluded.monthly_minutes,
+    updated_at = excluded.updated_at;
+
+-- name: DeleteUsageQuota :execrows
+DELETE FROM usage_quotas WHERE scope = ? AND subject = ?;
@@ -0,0 +1,50 @@
+-- name: CreateUser :exec
+INSERT INTO users (id, username, password_hash, is_admin, created_at)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: GetUserByID :one
+SELECT id, username, password_hash, is_admin, created_at
+FROM users WHERE id = ?;
+
+-- name: GetUserByUsername :one
+SELECT id, username, password_hash, is_admin, created_at
+FROM users WHERE username = ?;
+
+-- name: ListUsers :many
+-- パスワードのハッシュは返さない
+SELECT id, username, is_admin, created_at
+FROM users
+ORDER BY created_at, username;
+
+-- name: CountUsers :one
+SELECT COUNT(*) FROM users;
+
+-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = ?;
+
+-- name: AssignUnownedArticles :exec
+-- ユーザー作成前の記事を最初のユーザーのものにする
+UPDATE articles SET owner_id = ? WHERE owner_id IS NULL;
+
+-- name: AssignUnownedSources :exec
+UPDATE sources SET owner_id = ? WHERE owner_id IS NULL;
+
+-- name: AssignUnownedJobs :exec
+UPDATE processing_jobs SET owner_id = ? WHERE owner_id IS NULL;
+
+-- name: CreateSession :exec
+INSERT INTO sessions (id, user_id, created_at, expires_at)
+VALUES (?, ?, ?, ?);
+
+-- name: GetSessionUser :one
+-- 有効期限内のセッションのユーザー
+SELECT u.id, u.username, u.password_hash, u.is_admin, u.created_at
+FROM sessions s
+JOIN users u ON u.id = s.user_id
+WHERE s.id = ? AND s.expires_at > ?;
+
+-- name: DeleteSession :exec
+DELETE FROM sessions WHERE id = ?;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions WHERE expires_at <= ?;
@@ -0,0 +1,64 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (owner_id, url, secret, events, enabled, description, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, owner_id, url, secret, events, enabled, description, created_at, updated_at;
+
+-- name: GetWebhook :one
+SELECT id, owner_id, url, secret, events, enabled, description, created_at, updated_at
+FROM webhooks WHERE id = ?;
+
+-- name: ListWebhooks :many
+SELECT id, owner_id, url, secret, events, enabled, description, created_at, updated_at
+FROM webhooks
+WHERE owner_id IS COALESCE(sqlc.narg(owner_id), owner_id)
+ORDER BY id;
+
+-- name: ListEnabledWebhooksByOwner :many
+-- イベントを送る Webhook（所有者が NULL のデータは所有者が NULL の Webhook だけ）
+SELECT id, owner_id, url, secret, events, enabled, description, created_at, updated_at
+FROM webhooks
+WHERE enabled = 1 AND owner_id IS sqlc.narg(owner_id)
+ORDER BY id;
+
+-- name: UpdateWebhook :execrows
+UPDATE webhooks
+SET url = ?, secret = ?, events = ?, enabled = ?, description = ?, updated_at = ?
+WHERE id = ?;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks WHERE id = ?;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at, created_at)
+VALUES (?, ?, ?, 'pending', ?, ?)
+RETURNING id;
+
+-- name: ListWebhookDeliveries :many
+SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status, last_error, created_at, delivered_at
+FROM webhook_deliveries
+WHERE webhook_id = ?
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: ListDueWebhookDeliveries :many
+-- 送信時刻になった配信（無効にした Webhook の配信は再び有効にするまで送らない）
+SELECT d.id, d.webhook_id, d.event, d.payload, d.attempts, w.url, w.secret
+FROM webhook_deliveries d
+JOIN webhooks w ON w.id = d.webhook_id
+WHERE d.status = 'pending' AND d.next_attempt_at <= ? AND w.enabled = 1
+ORDER BY d.next_attempt_at, d.id
+LIMIT ?;
+
+-- name: UpdateWebhookDeliveryResult :exec
+UPDATE webhook_deliveries
+SET status = ?, attempts = ?, next_attempt_at = ?, response_status = ?, last_error = ?, delivered_at = ?
+WHERE id = ?;
+
+-- name: RedeliverWebhookDelivery :execrows
+UPDATE webhook_deliveries
+SET status = 'pending', next_attempt_at = ?
+WHERE id = ? AND webhook_id = ?;
+
+-- name: DeleteFinishedWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE status != 'pending' AND created_at < ?;
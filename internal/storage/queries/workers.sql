@@ -0,0 +1,25 @@
+-- name: UpsertWorker :exec
+INSERT INTO workers (id, name, models, capacity, version, registered_at, last_seen_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET
+    name = excluded.name,
+    models = excluded.models,
+    capacity = excluded.capacity,
+    version = excluded.version,
+    registered_at = excluded.registered_at,
+    last_seen_at = excluded.last_seen_at;
+
+-- name: GetWorkerByID :one
+SELECT id, name, models, capacity, version, registered_at, last_seen_at
+FROM workers WHERE id = ?;
+
+-- name: ListWorkers :many
+SELECT id, name, models, capacity, version, registered_at, last_seen_at
+FROM workers
+ORDER BY last_seen_at DESC;
+
+-- name: TouchWorker :exec
+UPDATE workers SET last_seen_at = ? WHERE id = ?;
+
+-- name: DeleteWorker :exec
+DELETE FROM workers WHERE id = ?;
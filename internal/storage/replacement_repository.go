@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// ReplacementRepository は置換辞書のデータアクセス層
+type ReplacementRepository struct {
+	db *DB
+}
+
+// NewReplacementRepository は新しいReplacementRepositoryを作成
+func NewReplacementRepository(db *DB) *ReplacementRepository {
+	return &ReplacementRepository{db: db}
+}
+
+// Create は置換ルールを作成（SourceID が nil ならすべてのソースに適用）
+func (r *ReplacementRepository) Create(ctx context.Context, rule *sqlc.ReplacementRule) error {
+	now := time.Now()
+	created, err := r.db.Queries.CreateReplacementRule(ctx, sqlc.CreateReplacementRuleParams{
+		SourceID:    rule.SourceID,
+		Pattern:     rule.Pattern,
+		Replacement: rule.Replacement,
+		IsRegex:     rule.IsRegex,
+		Enabled:     rule.Enabled,
+		Note:        rule.Note,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	if err != nil {
+		return err
+	}
+	*rule = created
+	return nil
+}
+
+// Get は置換ルールを取得（該当なしの場合は nil）
+func (r *ReplacementRepository) Get(ctx context.Context, id int64) (*sqlc.ReplacementRule, error) {
+	rule, err := r.db.Queries.GetReplacementRule(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListGlobal はすべてのソースに適用するルールを作成順に取得
+func (r *ReplacementRepository) ListGlobal(ctx context.Context) ([]sqlc.ReplacementRule, error) {
+	return r.db.Queries.ListGlobalReplacementRules(ctx)
+}
+
+// ListBySourceID はソースだけに適用するルールを作成順に取得
+func (r *ReplacementRepository) ListBySourceID(ctx context.Context, sourceID string) ([]sqlc.ReplacementRule, error) {
+	return r.db.Queries.ListReplacementRulesBySourceID(ctx, &sourceID)
+}
+
+// ListEnabled はソースの文字起こしに適用する有効なルールを適用順（全体のルール、ソースのルールの順）に取得
+func (r *ReplacementRepository) ListEnabled(ctx context.Context, sourceID string) ([]sqlc.ReplacementRule, error) {
+	return r.db.Queries.ListEnabledReplacementRules(ctx, &sourceID)
+}
+
+// Update は置換ルールの内容・有効/無効を更新（該当なしの場合は false）
+func (r *ReplacementRepository) Update(ctx context.Context, rule *sqlc.ReplacementRule) (bool, error) {
+	rule.UpdatedAt = time.Now()
+	n, err := r.db.Queries.UpdateReplacementRule(ctx, sqlc.UpdateReplacementRuleParams{
+		Pattern:     rule.Pattern,
+		Replacement: rule.Replacement,
+		IsRegex:     rule.IsRegex,
+		Enabled:     rule.Enabled,
+		Note:        rule.Note,
+		UpdatedAt:   rule.UpdatedAt,
+		ID:          rule.ID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Delete は置換ルールを削除（該当なしの場合は false）
+func (r *ReplacementRepository) Delete(ctx context.Context, id int64) (bool, error) {
+	n, err := r.db.Queries.DeleteReplacementRule(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
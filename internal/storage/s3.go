@@ -0,0 +1,316 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3MaxPutSize は1回の PUT で保存できる最大サイズ（S3 の上限）
+const s3MaxPutSize = 5 << 30
+
+// s3UnsignedPayload は本文をハッシュせずに署名する（HTTPS で送信するため改ざんは検出される）
+const s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Config は S3 互換のオブジェクトストレージの設定
+type S3Config struct {
+	Endpoint        string // 例: https://s3.ap-northeast-1.amazonaws.com, http://minio:9000, https://storage.googleapis.com（空なら AWS のリージョンのエンドポイント）
+	Region          string // 署名に使うリージョン（空なら us-east-1、GCS は auto）
+	Bucket          string
+	Prefix          string // キーの前に付けるパス（例: zbor/）
+	AccessKeyID     string // GCS は HMAC キー
+	SecretAccessKey string
+	SessionToken    string // 一時的な認証情報（省略可）
+	PathStyle       bool   // https://<endpoint>/<bucket>/<key> の形式を使う（MinIO など）
+}
+
+// S3Blob は S3 互換のオブジェクトストレージ（AWS S3, MinIO, Cloudflare R2, GCS の XML API など）を保存先にする Blob
+// リクエストは署名バージョン4（SigV4）で署名する
+type S3Blob struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3Blob は新しいS3Blobを作成
+func NewS3Blob(cfg S3Config) (*S3Blob, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3 credentials are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", cfg.Endpoint)
+	}
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+	return &S3Blob{cfg: cfg, endpoint: endpoint, client: http.DefaultClient}, nil
+}
+
+// Put はデータを保存（5GB まで）
+func (b *S3Blob) Put(ctx context.Context, key string, r io.Reader, size int64) (string, error) {
+	if size > s3MaxPutSize {
+		return "", fmt.Errorf("object is larger than %d bytes", int64(s3MaxPutSize))
+	}
+	if b.cfg.Prefix != "" {
+		key = b.cfg.Prefix + "/" + key
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, b.objectURL(key), io.NopCloser(r))
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	resp, err := b.do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return "s3://" + b.cfg.Bucket + "/" + key, nil
+}
+
+// Open はオブジェクトを開く（読み出しは Range 指定の GET で、Seek の後は新しい位置から読み直す）
+func (b *S3Blob) Open(ctx context.Context, uri string) (BlobObject, error) {
+	key, err := b.key(uri)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, b.objectURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &s3Object{ctx: ctx, blob: b, key: key, size: resp.ContentLength, modTime: modTime}, nil
+}
+
+// Delete はオブジェクトを削除（存在しない場合は何もしない）
+func (b *S3Blob) Delete(ctx context.Context, uri string) error {
+	key, err := b.key(uri)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, b.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := b.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// key は URI をバケット内のキーに変換（別のバケットの URI は拒否）
+func (b *S3Blob) key(uri string) (string, error) {
+	rest, ok := strings.CutPrefix(uri, "s3://"+b.cfg.Bucket+"/")
+	if !ok || rest == "" {
+		return "", fmt.Errorf("invalid blob URI: %s", uri)
+	}
+	return rest, nil
+}
+
+// objectURL はオブジェクトのURL
+func (b *S3Blob) objectURL(key string) string {
+	u := *b.endpoint
+	path := "/" + key
+	if b.cfg.PathStyle {
+		path = "/" + b.cfg.Bucket + path
+	} else {
+		u.Host = b.cfg.Bucket + "." + u.Host
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawPath = s3Escape(u.Path, false)
+	return u.String()
+}
+
+// do は署名したリクエストを送信し、2xx 以外（DELETE の 404 を除く）をエラーにする
+func (b *S3Blob) do(req *http.Request) (*http.Response, error) {
+	signS3(req, b.cfg, s3UnsignedPayload, time.Now())
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 || (req.Method == http.MethodDelete && resp.StatusCode == http.StatusNotFound) {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("s3 %s %s: HTTP %d %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// signS3 はリクエストに SigV4 の Authorization ヘッダーを付ける
+// 署名するヘッダーは host, range, content-type と x-amz-*
+func signS3(req *http.Request, cfg S3Config, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	if cfg.SessionToken != "" {
+		req.Header.Set("x-amz-security-token", cfg.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "range" || name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			params = append(params, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+cfg.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// s3Escape は SigV4 の URI エンコード（A-Z a-z 0-9 - _ . ~ 以外をエンコード。encodeSlash が false なら "/" はそのまま）
+func s3Escape(s string, encodeSlash bool) string {
+	var sb strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			sb.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// s3Object は開いたオブジェクト
+// Read は現在の位置から末尾までの GET を開いて読み進め、Seek で位置が変わったら閉じる
+type s3Object struct {
+	ctx     context.Context
+	blob    *S3Blob
+	key     string
+	size    int64
+	modTime time.Time
+	offset  int64
+	body    io.ReadCloser
+}
+
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+	if o.body == nil {
+		req, err := http.NewRequestWithContext(o.ctx, http.MethodGet, o.blob.objectURL(o.key), nil)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", o.offset))
+		resp, err := o.blob.do(req)
+		if err != nil {
+			return 0, err
+		}
+		o.body = resp.Body
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	if err == io.EOF && o.offset < o.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.size
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("invalid seek offset: %d", offset)
+	}
+	if offset != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = offset
+	return offset, nil
+}
+
+func (o *s3Object) Close() error {
+	if o.body != nil {
+		return o.body.Close()
+	}
+	return nil
+}
+
+func (o *s3Object) Size() int64        { return o.size }
+func (o *s3Object) ModTime() time.Time { return o.modTime }
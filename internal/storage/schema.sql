@@ -1,5 +1,7 @@
 -- Zbor Database Schema
 -- Version: 1.0
+-- バージョン管理の導入時点のスキーマ（ベースライン）。以降のスキーマ変更は
+-- migrations/<バージョン>_<名前>.sql に追加する（起動時に未適用のものを順に適用）
 
 -- 記事テーブル
 CREATE TABLE IF NOT EXISTS articles (
@@ -28,10 +30,31 @@ CREATE TABLE IF NOT EXISTS articles (
     sections TEXT,
     custom_metadata TEXT,
 
+    -- 削除日時（論理削除。保持期間を過ぎるとパージジョブが物理削除する）
+    deleted_at DATETIME,
+
+    -- 所有者（users.id。ユーザー作成前のデータは NULL）
+    owner_id TEXT,
+
     FOREIGN KEY (source_id) REFERENCES sources(id),
     FOREIGN KEY (parent_id) REFERENCES articles(id)
 );
 
+-- 記事の版（更新前の内容を記録し、復元に使う）
+CREATE TABLE IF NOT EXISTS article_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id TEXT NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    summary TEXT,
+    status TEXT,
+    sections TEXT,
+    custom_metadata TEXT,
+    updated_at DATETIME NOT NULL, -- この版が保存された日時（更新前の記事の updated_at）
+    created_at DATETIME NOT NULL, -- 版を記録した日時（次の版で置き換えられた日時）
+    FOREIGN KEY (article_id) REFERENCES articles(id) ON DELETE CASCADE
+);
+
 -- 全文検索用仮想テーブル（FTS5 + trigram）
 CREATE VIRTUAL TABLE IF NOT EXISTS articles_fts USING fts5(
     article_id UNINDEXED,
@@ -78,7 +101,80 @@ CREATE TABLE IF NOT EXISTS sources (
     file_path TEXT,
     metadata TEXT,
     created_at DATETIME NOT NULL,
-    status TEXT DEFAULT 'pending'
+    status TEXT DEFAULT 'pending',
+    owner_id TEXT                            -- 所有者（users.id）
+);
+
+-- ソースの最終アクセス（アーカイブ判定用、未アクセスなら created_at を使う）
+CREATE TABLE IF NOT EXISTS source_access (
+    source_id TEXT PRIMARY KEY,
+    accessed_at DATETIME NOT NULL,
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
+);
+
+-- リーガルホールド（ロック中のソース・記事は削除・再文字起こし・編集できない）
+CREATE TABLE IF NOT EXISTS legal_holds (
+    target_type TEXT NOT NULL, -- source, article
+    target_id TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    locked_by TEXT NOT NULL,
+    locked_at DATETIME NOT NULL,
+    PRIMARY KEY (target_type, target_id)
+);
+
+-- リーガルホールドのロック・解除履歴（監査ログ、削除しない）
+CREATE TABLE IF NOT EXISTS legal_hold_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    target_type TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    action TEXT NOT NULL, -- lock, unlock
+    actor TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+-- 保存データのチェックサム（整合性監査用）
+CREATE TABLE IF NOT EXISTS checksums (
+    target_type TEXT NOT NULL, -- file, artifact
+    target_id TEXT NOT NULL,   -- ファイルパス、アーティファクトID
+    source_id TEXT,
+    sha256 TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    recorded_at DATETIME NOT NULL,
+    verified_at DATETIME,
+    status TEXT, -- ok, mismatch, missing（未検証はNULL）
+    PRIMARY KEY (target_type, target_id),
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
+);
+
+-- 音声ファイルの実体（内容のSHA-256で保存し、同じ内容のファイルは1つだけ持つ）
+CREATE TABLE IF NOT EXISTS blobs (
+    sha256 TEXT PRIMARY KEY,
+    size INTEGER NOT NULL,
+    ref_count INTEGER NOT NULL DEFAULT 0,   -- source_blobs からの参照数（0 になった実体は削除する）
+    created_at DATETIME NOT NULL
+);
+
+-- ソースのファイルと実体の対応（ソースディレクトリのファイルは実体へのハードリンク）
+CREATE TABLE IF NOT EXISTS source_blobs (
+    path TEXT PRIMARY KEY,
+    source_id TEXT NOT NULL,
+    sha256 TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE,
+    FOREIGN KEY (sha256) REFERENCES blobs(sha256)
+);
+
+-- 文字起こしレビュー中に付けたブックマーク（編集ソフトのマーカーとして書き出す）
+CREATE TABLE IF NOT EXISTS bookmarks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_id TEXT NOT NULL,
+    time_seconds REAL NOT NULL,
+    label TEXT NOT NULL,
+    note TEXT,
+    created_at DATETIME NOT NULL,
+    color TEXT, -- マーカーの色（DaVinci Resolve の色名）
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
 );
 
 -- 処理ジョブテーブル
@@ -95,6 +191,9 @@ CREATE TABLE IF NOT EXISTS processing_jobs (
     created_at DATETIME NOT NULL,
     started_at DATETIME,
     completed_at DATETIME,
+    owner_id TEXT,                           -- 所有者（users.id）
+    api_key TEXT,                            -- ジョブを作成したAPIキーの名前（利用量の集計用）
+    metadata TEXT,                           -- JSON: 中断から再開するためのチェックポイントなど
     FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
 );
 
@@ -111,10 +210,184 @@ CREATE TABLE IF NOT EXISTS processing_artifacts (
     FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
 );
 
+-- リモートワーカー（エージェント）
+CREATE TABLE IF NOT EXISTS workers (
+    id TEXT PRIMARY KEY,
+    name TEXT,
+    models TEXT,
+    capacity INTEGER DEFAULT 1,
+    version TEXT,
+    registered_at DATETIME NOT NULL,
+    last_seen_at DATETIME NOT NULL
+);
+
+-- ジョブの依存関係（depends_on のジョブが完了するまで job_id のジョブは実行しない）
+-- 依存先が失敗すると、キュー中の依存するジョブも失敗にする
+CREATE TABLE IF NOT EXISTS job_dependencies (
+    job_id TEXT NOT NULL,
+    depends_on TEXT NOT NULL,
+    PRIMARY KEY (job_id, depends_on),
+    FOREIGN KEY (job_id) REFERENCES processing_jobs(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_dependencies_depends_on ON job_dependencies(depends_on);
+
+-- リモートワーカーのジョブ割り当て（リース）
+CREATE TABLE IF NOT EXISTS job_claims (
+    job_id TEXT PRIMARY KEY,
+    worker_id TEXT NOT NULL,
+    claimed_at DATETIME NOT NULL,
+    heartbeat_at DATETIME NOT NULL,
+    FOREIGN KEY (job_id) REFERENCES processing_jobs(id) ON DELETE CASCADE
+);
+
+-- リモートワーカーからチャンク単位でアップロードされた文字起こし結果
+CREATE TABLE IF NOT EXISTS job_result_chunks (
+    job_id TEXT NOT NULL,
+    chunk_index INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (job_id, chunk_index),
+    FOREIGN KEY (job_id) REFERENCES processing_jobs(id) ON DELETE CASCADE
+);
+
+-- 文字起こし中のファイルのブロックごとの途中結果（ファイルが終わると削除）
+CREATE TABLE IF NOT EXISTS job_partial_blocks (
+    job_id TEXT NOT NULL,
+    file_index INTEGER NOT NULL,
+    block_index INTEGER NOT NULL,
+    content TEXT NOT NULL,        -- JSON: ブロックのトークンと n-best
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (job_id, file_index, block_index),
+    FOREIGN KEY (job_id) REFERENCES processing_jobs(id) ON DELETE CASCADE
+);
+
+-- 文字起こしの利用量（完了したジョブごとの音声の長さ、ジョブを削除しても残す）
+CREATE TABLE IF NOT EXISTS usage_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id TEXT NOT NULL,
+    source_id TEXT,
+    owner_id TEXT,        -- ジョブの所有者（users.id）
+    api_key TEXT,         -- ジョブを作成したAPIキーの名前
+    model TEXT NOT NULL,  -- ASRモデル（reazonspeech, sensevoice, whisper など）
+    seconds REAL NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+-- 月ごとの文字起こしの上限（分）
+CREATE TABLE IF NOT EXISTS usage_quotas (
+    scope TEXT NOT NULL,   -- user, api_key, model
+    subject TEXT NOT NULL, -- ユーザー名・APIキーの名前・モデル名（* は個別の上限の無いすべて）
+    monthly_minutes REAL NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (scope, subject)
+);
+
+-- 文字起こしのセグメント（トランスクリプト検索用、文字起こし成果物から生成）
+CREATE TABLE IF NOT EXISTS transcript_segments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_id TEXT NOT NULL,
+    segment_index INTEGER NOT NULL, -- 文字起こし結果の segments の位置（0始まり）
+    start_time REAL NOT NULL,
+    end_time REAL NOT NULL,
+    speaker TEXT,
+    text TEXT NOT NULL,
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
+);
+
+-- トランスクリプト全文検索用仮想テーブル（FTS5 + trigram、rowid = transcript_segments.id）
+CREATE VIRTUAL TABLE IF NOT EXISTS transcript_segments_fts USING fts5(
+    text,
+    tokenize = 'trigram'
+);
+
+-- タグの提案（自動タグ付けジョブが作成し、承認・却下で確定する）
+CREATE TABLE IF NOT EXISTS tag_suggestions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    tag_id INTEGER,                          -- 既存タグ（提案時に記事へ付与済み）。新しいタグの候補は NULL
+    score REAL NOT NULL DEFAULT 0,
+    method TEXT NOT NULL,                    -- keyword, llm
+    status TEXT NOT NULL DEFAULT 'pending',  -- pending, accepted, rejected
+    created_at DATETIME NOT NULL,
+    decided_at DATETIME,
+    UNIQUE (article_id, name),
+    FOREIGN KEY (article_id) REFERENCES articles(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE SET NULL
+);
+
+-- APIキー（キー本体は保存せず、SHA-256ハッシュで照合する）
+CREATE TABLE IF NOT EXISTS api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    prefix TEXT NOT NULL,                    -- キーの先頭（一覧での識別用）
+    scope TEXT NOT NULL,                     -- read, write, admin
+    created_at DATETIME NOT NULL,
+    last_used_at DATETIME
+);
+
+-- ユーザー（1人でもいればWeb UIはログインが必要になり、データは所有者ごとに分離される）
+CREATE TABLE IF NOT EXISTS users (
+    id TEXT PRIMARY KEY,
+    username TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,             -- pbkdf2-sha256$<反復回数>$<ソルト>$<ハッシュ>
+    is_admin INTEGER NOT NULL DEFAULT 0,     -- 1: 全ユーザーのデータを参照・管理できる
+    created_at DATETIME NOT NULL
+);
+
+-- ログインセッション（トークン本体は保存せず、SHA-256ハッシュをIDにする）
+CREATE TABLE IF NOT EXISTS sessions (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 実験（cmd/transcribe-* の実行記録。/experiments で比較する）
+CREATE TABLE IF NOT EXISTS experiments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tool TEXT NOT NULL,                      -- transcribe-precision 等
+    name TEXT,                               -- 任意のラベル（-record-name）
+    input_file TEXT NOT NULL,
+    params TEXT NOT NULL,                    -- コマンドラインのフラグ（JSON）
+    text TEXT NOT NULL,                      -- 文字起こし全文
+    audio_seconds REAL NOT NULL,
+    processing_seconds REAL NOT NULL,
+    rtf REAL NOT NULL,                       -- processing_seconds / audio_seconds
+    reference TEXT,                          -- 正解の文字起こし（-reference）
+    cer REAL,                                -- reference に対する文字誤り率
+    created_at DATETIME NOT NULL
+);
+
+-- 実験のチャンク（VADセグメント）ごとの出力
+CREATE TABLE IF NOT EXISTS experiment_chunks (
+    experiment_id INTEGER NOT NULL,
+    idx INTEGER NOT NULL,
+    start_sec REAL NOT NULL,
+    end_sec REAL NOT NULL,
+    text TEXT NOT NULL,
+    PRIMARY KEY (experiment_id, idx),
+    FOREIGN KEY (experiment_id) REFERENCES experiments(id) ON DELETE CASCADE
+);
+
 -- インデックス
 CREATE INDEX IF NOT EXISTS idx_articles_created_at ON articles(created_at DESC);
 CREATE INDEX IF NOT EXISTS idx_articles_source_type ON articles(source_type);
 CREATE INDEX IF NOT EXISTS idx_articles_status ON articles(status);
+CREATE INDEX IF NOT EXISTS idx_article_revisions_article ON article_revisions(article_id, id DESC);
 CREATE INDEX IF NOT EXISTS idx_sources_status ON sources(status);
+CREATE INDEX IF NOT EXISTS idx_sources_type ON sources(type);
+CREATE INDEX IF NOT EXISTS idx_sources_original_url ON sources(original_url);
+CREATE INDEX IF NOT EXISTS idx_sources_content_hash ON sources(json_extract(metadata, '$.content_hash'));
 CREATE INDEX IF NOT EXISTS idx_jobs_status ON processing_jobs(status);
 CREATE INDEX IF NOT EXISTS idx_jobs_priority ON processing_jobs(priority, created_at);
+CREATE INDEX IF NOT EXISTS idx_usage_records_created_at ON usage_records(created_at);
+CREATE INDEX IF NOT EXISTS idx_legal_hold_events_target ON legal_hold_events(target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_checksums_source ON checksums(source_id);
+CREATE INDEX IF NOT EXISTS idx_source_blobs_source ON source_blobs(source_id);
+CREATE INDEX IF NOT EXISTS idx_source_blobs_sha256 ON source_blobs(sha256);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_source ON bookmarks(source_id, time_seconds);
+CREATE INDEX IF NOT EXISTS idx_transcript_segments_source ON transcript_segments(source_id, segment_index);
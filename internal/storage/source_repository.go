@@ -19,7 +19,7 @@ func NewSourceRepository(db *DB) *SourceRepository {
 	return &SourceRepository{db: db}
 }
 
-// Create は新しいソースを作成
+// Create は新しいソースを作成（所有者はコンテキストの利用者）
 func (r *SourceRepository) Create(ctx context.Context, source *sqlc.Source) error {
 	if source.ID == "" {
 		source.ID = uuid.New().String()
@@ -29,6 +29,7 @@ func (r *SourceRepository) Create(ctx context.Context, source *sqlc.Source) erro
 		status := "pending"
 		source.Status = &status
 	}
+	source.OwnerID = ownerForNew(ctx, source.OwnerID)
 
 	return r.db.Queries.CreateSource(ctx, sqlc.CreateSourceParams{
 		ID:          source.ID,
@@ -38,10 +39,11 @@ func (r *SourceRepository) Create(ctx context.Context, source *sqlc.Source) erro
 		Metadata:    source.Metadata,
 		CreatedAt:   source.CreatedAt,
 		Status:      source.Status,
+		OwnerID:     source.OwnerID,
 	})
 }
 
-// GetByID はIDでソースを取得
+// GetByID はIDでソースを取得（他のユーザーのソースは nil）
 func (r *SourceRepository) GetByID(ctx context.Context, id string) (*sqlc.Source, error) {
 	source, err := r.db.Queries.GetSourceByID(ctx, id)
 	if err == sql.ErrNoRows {
@@ -50,9 +52,40 @@ func (r *SourceRepository) GetByID(ctx context.Context, id string) (*sqlc.Source
 	if err != nil {
 		return nil, err
 	}
+	if !ownerAllows(ctx, source.OwnerID) {
+		return nil, nil
+	}
+	return &source, nil
+}
+
+// GetByContentHash は同じ内容の音声をアップロードしたソースを取得（コンテキストの利用者のソースのみ、無ければ nil）
+// 失敗したソースは対象外。複数ある場合は最も古いもの
+func (r *SourceRepository) GetByContentHash(ctx context.Context, hash string) (*sqlc.Source, error) {
+	source, err := r.db.Queries.GetSourceByContentHash(ctx, sqlc.GetSourceByContentHashParams{
+		ContentHash: hash,
+		OwnerID:     ownerFilter(ctx),
+	})
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 	return &source, nil
 }
 
+// sourceOwner はソースの所有者を返す（ソースが無い場合は nil）
+func sourceOwner(ctx context.Context, q *sqlc.Queries, sourceID string) (*string, error) {
+	source, err := q.GetSourceByID(ctx, sourceID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return source.OwnerID, nil
+}
+
 // UpdateStatus はソースのステータスを更新
 func (r *SourceRepository) UpdateStatus(ctx context.Context, id, status string) error {
 	return r.db.Queries.UpdateSourceStatus(ctx, sqlc.UpdateSourceStatusParams{
@@ -61,19 +94,86 @@ func (r *SourceRepository) UpdateStatus(ctx context.Context, id, status string)
 	})
 }
 
+// UpdateMetadata はソースのメタデータ(JSON)を更新
+func (r *SourceRepository) UpdateMetadata(ctx context.Context, id, metadata string) error {
+	return r.db.Queries.UpdateSourceMetadata(ctx, sqlc.UpdateSourceMetadataParams{
+		Metadata: &metadata,
+		ID:       id,
+	})
+}
+
 // Delete はソースを削除
 func (r *SourceRepository) Delete(ctx context.Context, id string) error {
+	if err := checkSourceHold(ctx, r.db.Queries, id); err != nil {
+		return err
+	}
+	// 音声の実体の参照数を減らす（参照の無くなった実体は BlobRepository.Prune で削除）
+	if _, err := releaseSourceBlobs(ctx, r.db.Queries, id); err != nil {
+		return err
+	}
 	return r.db.Queries.DeleteSource(ctx, id)
 }
 
-// List はソース一覧を取得
+// CheckHold はソース（またはソースの記事）がリーガルホールド中なら ErrLegalHold を返す
+func (r *SourceRepository) CheckHold(ctx context.Context, id string) error {
+	return checkSourceHold(ctx, r.db.Queries, id)
+}
+
+// List はソース一覧を取得（コンテキストの利用者のソースのみ）
 func (r *SourceRepository) List(ctx context.Context, limit, offset int) ([]sqlc.Source, error) {
 	if limit == 0 {
 		limit = 20
 	}
 	return r.db.Queries.ListSources(ctx, sqlc.ListSourcesParams{
+		OwnerID: ownerFilter(ctx),
+		Limit:   int64(limit),
+		Offset:  int64(offset),
+	})
+}
+
+// ListByType は指定タイプのソースを新しい順に取得（コンテキストの利用者のソースのみ）
+func (r *SourceRepository) ListByType(ctx context.Context, sourceType string) ([]sqlc.Source, error) {
+	return r.db.Queries.ListSourcesByType(ctx, sqlc.ListSourcesByTypeParams{
+		Type:    sourceType,
+		OwnerID: ownerFilter(ctx),
+	})
+}
+
+// ExistsByURL は元URLが一致するソースがあるかを返す
+func (r *SourceRepository) ExistsByURL(ctx context.Context, originalURL string) (bool, error) {
+	n, err := r.db.Queries.CountSourcesByOriginalURL(ctx, &originalURL)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ListTranscribed は文字起こし（コンテンツあり）のあるソースを新しい順に取得（コンテキストの利用者のソースのみ）
+func (r *SourceRepository) ListTranscribed(ctx context.Context) ([]sqlc.Source, error) {
+	return r.db.Queries.ListTranscribedSources(ctx, ownerFilter(ctx))
+}
+
+// ListArchivable はアーカイブ対象のソース（完了済みで cutoff 以降アクセスの無い音声）を古い順に取得
+func (r *SourceRepository) ListArchivable(ctx context.Context, cutoff time.Time, limit int) ([]sqlc.Source, error) {
+	return r.db.Queries.ListArchivableSources(ctx, sqlc.ListArchivableSourcesParams{
+		Cutoff: cutoff,
+		Limit:  int64(limit),
+	})
+}
+
+// ListCleanable は元音声の削除対象のソース（cutoff より前に文字起こしが完了し、まだ削除していない音声）を古い順に取得
+func (r *SourceRepository) ListCleanable(ctx context.Context, cutoff time.Time, limit int) ([]sqlc.Source, error) {
+	return r.db.Queries.ListCleanableSources(ctx, sqlc.ListCleanableSourcesParams{
+		Cutoff: cutoff,
 		Limit:  int64(limit),
-		Offset: int64(offset),
+	})
+}
+
+// TouchAccess はソースの最終アクセス時刻を更新
+func (r *SourceRepository) TouchAccess(ctx context.Context, id string) error {
+	return r.db.Queries.UpsertSourceAccess(ctx, sqlc.UpsertSourceAccessParams{
+		SourceID:   id,
+		AccessedAt: time.Now(),
 	})
 }
 
@@ -94,7 +194,14 @@ func (r *ArtifactRepository) Create(ctx context.Context, artifact *sqlc.Processi
 	}
 	artifact.CreatedAt = time.Now()
 
-	return r.db.Queries.CreateArtifact(ctx, sqlc.CreateArtifactParams{
+	// ロック中のソースには成果物を追加できない
+	if artifact.SourceID != nil {
+		if err := checkSourceHold(ctx, r.db.Queries, *artifact.SourceID); err != nil {
+			return err
+		}
+	}
+
+	err := r.db.Queries.CreateArtifact(ctx, sqlc.CreateArtifactParams{
 		ID:        artifact.ID,
 		SourceID:  artifact.SourceID,
 		Type:      artifact.Type,
@@ -104,6 +211,18 @@ func (r *ArtifactRepository) Create(ctx context.Context, artifact *sqlc.Processi
 		Metadata:  artifact.Metadata,
 		CreatedAt: artifact.CreatedAt,
 	})
+	if err != nil {
+		return err
+	}
+
+	// 整合性監査用にコンテンツのチェックサムを記録
+	if artifact.Content != nil {
+		if err := recordArtifactChecksum(ctx, r.db.Queries, artifact.ID, artifact.SourceID, *artifact.Content); err != nil {
+			return err
+		}
+	}
+	r.db.emitTranscriptUpdated(ctx, artifact)
+	return nil
 }
 
 // GetByID はIDでアーティファクトを取得
@@ -125,35 +244,145 @@ func (r *ArtifactRepository) GetBySourceID(ctx context.Context, sourceID string)
 
 // Delete はアーティファクトを削除
 func (r *ArtifactRepository) Delete(ctx context.Context, id string) error {
-	return r.db.Queries.DeleteArtifact(ctx, id)
+	if err := r.checkHold(ctx, id); err != nil {
+		return err
+	}
+	if err := r.db.Queries.DeleteArtifact(ctx, id); err != nil {
+		return err
+	}
+	return r.db.Queries.DeleteChecksum(ctx, sqlc.DeleteChecksumParams{
+		TargetType: ChecksumTargetArtifact,
+		TargetID:   id,
+	})
 }
 
 // DeleteBySourceID はソースIDでアーティファクトを削除
 func (r *ArtifactRepository) DeleteBySourceID(ctx context.Context, sourceID string) error {
-	return r.db.Queries.DeleteArtifactsBySourceID(ctx, &sourceID)
+	if err := checkSourceHold(ctx, r.db.Queries, sourceID); err != nil {
+		return err
+	}
+	if err := r.db.Queries.DeleteArtifactsBySourceID(ctx, &sourceID); err != nil {
+		return err
+	}
+	// 文字起こしから生成した検索用セグメントも削除
+	if err := deleteTranscriptSegments(ctx, r.db.Queries, sourceID); err != nil {
+		return err
+	}
+	return r.db.Queries.DeleteArtifactChecksumsBySourceID(ctx, &sourceID)
 }
 
 // UpdateContent はアーティファクトのコンテンツを更新
 func (r *ArtifactRepository) UpdateContent(ctx context.Context, id, content string) error {
+	if err := r.checkHold(ctx, id); err != nil {
+		return err
+	}
+	err := r.db.Queries.UpdateArtifactContent(ctx, sqlc.UpdateArtifactContentParams{
+		Content: &content,
+		ID:      id,
+	})
+	if err != nil {
+		return err
+	}
+
+	artifact, err := r.db.Queries.GetArtifactByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := recordArtifactChecksum(ctx, r.db.Queries, id, artifact.SourceID, content); err != nil {
+		return err
+	}
+	r.db.emitTranscriptUpdated(ctx, &artifact)
+	return nil
+}
+
+// UpdateType はアーティファクトのタイプとメタデータを更新（文字起こしの版の切り替え）
+func (r *ArtifactRepository) UpdateType(ctx context.Context, id, artifactType string, metadata *string) error {
+	if err := r.checkHold(ctx, id); err != nil {
+		return err
+	}
+	err := r.db.Queries.UpdateArtifactType(ctx, sqlc.UpdateArtifactTypeParams{
+		Type:     artifactType,
+		Metadata: metadata,
+		ID:       id,
+	})
+	if err != nil {
+		return err
+	}
+	// 以前の版を現在の文字起こしに戻した場合
+	if artifactType == ArtifactTypeTranscription {
+		if artifact, err := r.db.Queries.GetArtifactByID(ctx, id); err == nil {
+			r.db.emitTranscriptUpdated(ctx, &artifact)
+		}
+	}
+	return nil
+}
+
+// RestoreContent はアーカイブしたコンテンツを書き戻す（内容は変わらないためロック中でも可）
+// チェックサムは更新しないので、復元したコンテンツは次の監査で検証される
+func (r *ArtifactRepository) RestoreContent(ctx context.Context, id, content string) error {
 	return r.db.Queries.UpdateArtifactContent(ctx, sqlc.UpdateArtifactContentParams{
 		Content: &content,
 		ID:      id,
 	})
 }
 
+// ClearContent はアーティファクトのコンテンツを削除（アーカイブ時）
+func (r *ArtifactRepository) ClearContent(ctx context.Context, id string) error {
+	return r.db.Queries.UpdateArtifactContent(ctx, sqlc.UpdateArtifactContentParams{
+		Content: nil,
+		ID:      id,
+	})
+}
+
+// checkHold はアーティファクトのソースがロックされていれば ErrLegalHold を返す
+func (r *ArtifactRepository) checkHold(ctx context.Context, id string) error {
+	artifact, err := r.db.Queries.GetArtifactByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if artifact.SourceID == nil {
+		return nil
+	}
+	return checkSourceHold(ctx, r.db.Queries, *artifact.SourceID)
+}
+
+// ソースタイプ定数
+const (
+	SourceTypeAudio   = "audio"
+	SourceTypeYouTube = "youtube"
+	SourceTypeWeb     = "web"     // URLから取り込んだWebページ
+	SourceTypeCrawl   = "crawl"   // サイトのクロール（各ページは web ソースとして保存）
+	SourceTypeFeed    = "feed"    // RSS/Atom フィードの購読（新しいエントリーは web ソースとして保存）
+	SourceTypePodcast = "podcast" // ポッドキャストのエピソード（音声は文字起こしジョブでダウンロード）
+	SourceTypeArticle = "article" // 手動作成の記事（要約ジョブ用）
+)
+
 // ソースステータス定数
 const (
 	SourceStatusPending    = "pending"
 	SourceStatusProcessing = "processing"
 	SourceStatusCompleted  = "completed"
 	SourceStatusFailed     = "failed"
+	SourceStatusArchived   = "archived" // 音声・成果物をアーカイブ済み（記事は検索可能）
 )
 
 // アーティファクトタイプ定数
 const (
-	ArtifactTypeTranscription = "transcription"
-	ArtifactTypeSummary       = "summary"
-	ArtifactTypeTranslation   = "translation"
+	ArtifactTypeTranscription  = "transcription"
+	ArtifactTypeSummary        = "summary"
+	ArtifactTypeTranslation    = "translation"
+	ArtifactTypeCondensed      = "condensed"       // 流し読み用の一覧（一定間隔ごとに1行）
+	ArtifactTypeSubtitle       = "subtitle"        // 字幕（format は srt / vtt）
+	ArtifactTypeRefinement     = "refinement"      // 二段階の文字起こしで再認識した区間（JSON）
+	ArtifactTypeBoundaryReport = "boundary_report" // セグメント境界の一括調整で動いた量（JSON）
+	ArtifactTypeEntities       = "entities"        // 記事の頻出キーワードと固有表現（JSON、metadata に article_id）
+
+	// ArtifactTypeTranscriptionVersion は以前の文字起こし（再文字起こしで置き換えたもの）
+	// 現在の文字起こしは常に ArtifactTypeTranscription の1つだけで、版を戻す時はタイプを入れ替える
+	ArtifactTypeTranscriptionVersion = "transcription_version"
 )
 
 // Ptr はstring型のポインタを返すヘルパー
@@ -25,11 +25,76 @@ func (q *Queries) AddArticleTag(ctx context.Context, arg AddArticleTagParams) er
 }
 
 const countArticles = `-- name: CountArticles :one
-SELECT COUNT(*) FROM articles
+SELECT COUNT(*) FROM articles WHERE deleted_at IS NULL
+  AND owner_id IS COALESCE(?, owner_id)
 `
 
-func (q *Queries) CountArticles(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countArticles)
+func (q *Queries) CountArticles(ctx context.Context, ownerID *string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countArticles, ownerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countArticlesBySourceType = `-- name: CountArticlesBySourceType :one
+SELECT COUNT(*) FROM articles WHERE source_type = ? AND deleted_at IS NULL
+  AND owner_id IS COALESCE(?, owner_id)
+`
+
+type CountArticlesBySourceTypeParams struct {
+	SourceType *string `json:"source_type"`
+	OwnerID    *string `json:"owner_id"`
+}
+
+func (q *Queries) CountArticlesBySourceType(ctx context.Context, arg CountArticlesBySourceTypeParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countArticlesBySourceType, arg.SourceType, arg.OwnerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countArticlesByStatus = `-- name: CountArticlesByStatus :one
+SELECT COUNT(*) FROM articles WHERE status = ? AND deleted_at IS NULL
+  AND owner_id IS COALESCE(?, owner_id)
+`
+
+type CountArticlesByStatusParams struct {
+	Status  *string `json:"status"`
+	OwnerID *string `json:"owner_id"`
+}
+
+func (q *Queries) CountArticlesByStatus(ctx context.Context, arg CountArticlesByStatusParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countArticlesByStatus, arg.Status, arg.OwnerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countArticlesByStatusAndSourceType = `-- name: CountArticlesByStatusAndSourceType :one
+SELECT COUNT(*) FROM articles WHERE status = ? AND source_type = ? AND deleted_at IS NULL
+  AND owner_id IS COALESCE(?, owner_id)
+`
+
+type CountArticlesByStatusAndSourceTypeParams struct {
+	Status     *string `json:"status"`
+	SourceType *string `json:"source_type"`
+	OwnerID    *string `json:"owner_id"`
+}
+
+func (q *Queries) CountArticlesByStatusAndSourceType(ctx context.Context, arg CountArticlesByStatusAndSourceTypeParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countArticlesByStatusAndSourceType, arg.Status, arg.SourceType, arg.OwnerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countDeletedArticles = `-- name: CountDeletedArticles :one
+SELECT COUNT(*) FROM articles WHERE deleted_at IS NOT NULL
+  AND owner_id IS COALESCE(?, owner_id)
+`
+
+func (q *Queries) CountDeletedArticles(ctx context.Context, ownerID *string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countDeletedArticles, ownerID)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -40,8 +105,8 @@ INSERT INTO articles (
     id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    source_id, parent_id, sections, custom_metadata, owner_id
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateArticleParams struct {
@@ -61,6 +126,7 @@ type CreateArticleParams struct {
 	ParentID       *string    `json:"parent_id"`
 	Sections       *string    `json:"sections"`
 	CustomMetadata *string    `json:"custom_metadata"`
+	OwnerID        *string    `json:"owner_id"`
 }
 
 func (q *Queries) CreateArticle(ctx context.Context, arg CreateArticleParams) error {
@@ -81,6 +147,7 @@ func (q *Queries) CreateArticle(ctx context.Context, arg CreateArticleParams) er
 		arg.ParentID,
 		arg.Sections,
 		arg.CustomMetadata,
+		arg.OwnerID,
 	)
 	return err
 }
@@ -116,7 +183,7 @@ const getArticleByID = `-- name: GetArticleByID :one
 SELECT id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
 FROM articles WHERE id = ?
 `
 
@@ -140,6 +207,8 @@ func (q *Queries) GetArticleByID(ctx context.Context, id string) (Article, error
 		&i.ParentID,
 		&i.Sections,
 		&i.CustomMetadata,
+		&i.DeletedAt,
+		&i.OwnerID,
 	)
 	return i, err
 }
@@ -183,8 +252,8 @@ const getArticlesBySourceID = `-- name: GetArticlesBySourceID :many
 SELECT id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
-FROM articles WHERE source_id = ?
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
+FROM articles WHERE source_id = ? AND deleted_at IS NULL
 `
 
 func (q *Queries) GetArticlesBySourceID(ctx context.Context, sourceID *string) ([]Article, error) {
@@ -213,6 +282,8 @@ func (q *Queries) GetArticlesBySourceID(ctx context.Context, sourceID *string) (
 			&i.ParentID,
 			&i.Sections,
 			&i.CustomMetadata,
+			&i.DeletedAt,
+			&i.OwnerID,
 		); err != nil {
 			return nil, err
 		}
@@ -253,19 +324,21 @@ const listArticlesAll = `-- name: ListArticlesAll :many
 SELECT id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
 FROM articles
+WHERE deleted_at IS NULL AND owner_id IS COALESCE(?, owner_id)
 ORDER BY created_at DESC
 LIMIT ? OFFSET ?
 `
 
 type ListArticlesAllParams struct {
-	Limit  int64 `json:"limit"`
-	Offset int64 `json:"offset"`
+	OwnerID *string `json:"owner_id"`
+	Limit   int64   `json:"limit"`
+	Offset  int64   `json:"offset"`
 }
 
 func (q *Queries) ListArticlesAll(ctx context.Context, arg ListArticlesAllParams) ([]Article, error) {
-	rows, err := q.db.QueryContext(ctx, listArticlesAll, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listArticlesAll, arg.OwnerID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -290,6 +363,8 @@ func (q *Queries) ListArticlesAll(ctx context.Context, arg ListArticlesAllParams
 			&i.ParentID,
 			&i.Sections,
 			&i.CustomMetadata,
+			&i.DeletedAt,
+			&i.OwnerID,
 		); err != nil {
 			return nil, err
 		}
@@ -308,21 +383,27 @@ const listArticlesBySourceType = `-- name: ListArticlesBySourceType :many
 SELECT id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
 FROM articles
-WHERE source_type = ?
+WHERE source_type = ? AND deleted_at IS NULL AND owner_id IS COALESCE(?, owner_id)
 ORDER BY created_at DESC
 LIMIT ? OFFSET ?
 `
 
 type ListArticlesBySourceTypeParams struct {
 	SourceType *string `json:"source_type"`
+	OwnerID    *string `json:"owner_id"`
 	Limit      int64   `json:"limit"`
 	Offset     int64   `json:"offset"`
 }
 
 func (q *Queries) ListArticlesBySourceType(ctx context.Context, arg ListArticlesBySourceTypeParams) ([]Article, error) {
-	rows, err := q.db.QueryContext(ctx, listArticlesBySourceType, arg.SourceType, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listArticlesBySourceType,
+		arg.SourceType,
+		arg.OwnerID,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -347,6 +428,8 @@ func (q *Queries) ListArticlesBySourceType(ctx context.Context, arg ListArticles
 			&i.ParentID,
 			&i.Sections,
 			&i.CustomMetadata,
+			&i.DeletedAt,
+			&i.OwnerID,
 		); err != nil {
 			return nil, err
 		}
@@ -365,21 +448,27 @@ const listArticlesByStatus = `-- name: ListArticlesByStatus :many
 SELECT id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
 FROM articles
-WHERE status = ?
+WHERE status = ? AND deleted_at IS NULL AND owner_id IS COALESCE(?, owner_id)
 ORDER BY created_at DESC
 LIMIT ? OFFSET ?
 `
 
 type ListArticlesByStatusParams struct {
-	Status *string `json:"status"`
-	Limit  int64   `json:"limit"`
-	Offset int64   `json:"offset"`
+	Status  *string `json:"status"`
+	OwnerID *string `json:"owner_id"`
+	Limit   int64   `json:"limit"`
+	Offset  int64   `json:"offset"`
 }
 
 func (q *Queries) ListArticlesByStatus(ctx context.Context, arg ListArticlesByStatusParams) ([]Article, error) {
-	rows, err := q.db.QueryContext(ctx, listArticlesByStatus, arg.Status, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listArticlesByStatus,
+		arg.Status,
+		arg.OwnerID,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -404,6 +493,8 @@ func (q *Queries) ListArticlesByStatus(ctx context.Context, arg ListArticlesBySt
 			&i.ParentID,
 			&i.Sections,
 			&i.CustomMetadata,
+			&i.DeletedAt,
+			&i.OwnerID,
 		); err != nil {
 			return nil, err
 		}
@@ -422,9 +513,9 @@ const listArticlesByStatusAndSourceType = `-- name: ListArticlesByStatusAndSourc
 SELECT id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
 FROM articles
-WHERE status = ? AND source_type = ?
+WHERE status = ? AND source_type = ? AND deleted_at IS NULL AND owner_id IS COALESCE(?, owner_id)
 ORDER BY created_at DESC
 LIMIT ? OFFSET ?
 `
@@ -432,6 +523,7 @@ LIMIT ? OFFSET ?
 type ListArticlesByStatusAndSourceTypeParams struct {
 	Status     *string `json:"status"`
 	SourceType *string `json:"source_type"`
+	OwnerID    *string `json:"owner_id"`
 	Limit      int64   `json:"limit"`
 	Offset     int64   `json:"offset"`
 }
@@ -440,6 +532,7 @@ func (q *Queries) ListArticlesByStatusAndSourceType(ctx context.Context, arg Lis
 	rows, err := q.db.QueryContext(ctx, listArticlesByStatusAndSourceType,
 		arg.Status,
 		arg.SourceType,
+		arg.OwnerID,
 		arg.Limit,
 		arg.Offset,
 	)
@@ -467,6 +560,63 @@ func (q *Queries) ListArticlesByStatusAndSourceType(ctx context.Context, arg Lis
 			&i.ParentID,
 			&i.Sections,
 			&i.CustomMetadata,
+			&i.DeletedAt,
+			&i.OwnerID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCompletedArticles = `-- name: ListCompletedArticles :many
+SELECT a.id, a.title, a.content, a.summary,
+    a.source_type, a.source_url, a.author, a.published_at, a.language,
+    a.created_at, a.updated_at, a.status,
+    a.source_id, a.parent_id, a.sections, a.custom_metadata, a.deleted_at, a.owner_id
+FROM articles a
+LEFT JOIN sources s ON s.id = a.source_id
+WHERE a.deleted_at IS NULL AND a.content != ''
+  AND (s.id IS NULL OR s.status IS NULL OR s.status IN ('completed', 'archived'))
+ORDER BY a.created_at
+`
+
+// 処理が完了した記事（削除済み・本文の無い記事と、ソースの処理中・失敗した記事を除く）
+func (q *Queries) ListCompletedArticles(ctx context.Context) ([]Article, error) {
+	rows, err := q.db.QueryContext(ctx, listCompletedArticles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Article{}
+	for rows.Next() {
+		var i Article
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			&i.Summary,
+			&i.SourceType,
+			&i.SourceUrl,
+			&i.Author,
+			&i.PublishedAt,
+			&i.Language,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.SourceID,
+			&i.ParentID,
+			&i.Sections,
+			&i.CustomMetadata,
+			&i.DeletedAt,
+			&i.OwnerID,
 		); err != nil {
 			return nil, err
 		}
@@ -481,6 +631,106 @@ func (q *Queries) ListArticlesByStatusAndSourceType(ctx context.Context, arg Lis
 	return items, nil
 }
 
+const listDeletedArticles = `-- name: ListDeletedArticles :many
+SELECT id, title, content, summary,
+    source_type, source_url, author, published_at, language,
+    created_at, updated_at, status,
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
+FROM articles
+WHERE deleted_at IS NOT NULL AND owner_id IS COALESCE(?, owner_id)
+ORDER BY deleted_at DESC
+LIMIT ? OFFSET ?
+`
+
+type ListDeletedArticlesParams struct {
+	OwnerID *string `json:"owner_id"`
+	Limit   int64   `json:"limit"`
+	Offset  int64   `json:"offset"`
+}
+
+func (q *Queries) ListDeletedArticles(ctx context.Context, arg ListDeletedArticlesParams) ([]Article, error) {
+	rows, err := q.db.QueryContext(ctx, listDeletedArticles, arg.OwnerID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Article{}
+	for rows.Next() {
+		var i Article
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			&i.Summary,
+			&i.SourceType,
+			&i.SourceUrl,
+			&i.Author,
+			&i.PublishedAt,
+			&i.Language,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.SourceID,
+			&i.ParentID,
+			&i.Sections,
+			&i.CustomMetadata,
+			&i.DeletedAt,
+			&i.OwnerID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPurgeableArticles = `-- name: ListPurgeableArticles :many
+SELECT a.id FROM articles a
+WHERE a.deleted_at IS NOT NULL AND a.deleted_at < ?
+  AND NOT EXISTS (
+    SELECT 1 FROM legal_holds h
+    WHERE (h.target_type = 'article' AND h.target_id = a.id)
+       OR (h.target_type = 'source' AND h.target_id = a.source_id)
+  )
+ORDER BY a.deleted_at
+LIMIT ?
+`
+
+type ListPurgeableArticlesParams struct {
+	DeletedAt *time.Time `json:"deleted_at"`
+	Limit     int64      `json:"limit"`
+}
+
+// 削除から保持期間を過ぎた記事（記事またはソースがロック中のものを除く）
+func (q *Queries) ListPurgeableArticles(ctx context.Context, arg ListPurgeableArticlesParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listPurgeableArticles, arg.DeletedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const removeArticleTag = `-- name: RemoveArticleTag :exec
 DELETE FROM article_tags WHERE article_id = ? AND tag_id = ?
 `
@@ -499,21 +749,27 @@ const searchArticlesLike = `-- name: SearchArticlesLike :many
 SELECT id, title, content, summary,
     source_type, source_url, author, published_at, language,
     created_at, updated_at, status,
-    source_id, parent_id, sections, custom_metadata
+    source_id, parent_id, sections, custom_metadata, deleted_at, owner_id
 FROM articles
-WHERE title LIKE ? OR content LIKE ?
+WHERE (title LIKE ? OR content LIKE ?) AND deleted_at IS NULL AND owner_id IS COALESCE(?, owner_id)
 ORDER BY created_at DESC
 LIMIT ?
 `
 
 type SearchArticlesLikeParams struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
-	Limit   int64  `json:"limit"`
+	Title   string  `json:"title"`
+	Content string  `json:"content"`
+	OwnerID *string `json:"owner_id"`
+	Limit   int64   `json:"limit"`
 }
 
 func (q *Queries) SearchArticlesLike(ctx context.Context, arg SearchArticlesLikeParams) ([]Article, error) {
-	rows, err := q.db.QueryContext(ctx, searchArticlesLike, arg.Title, arg.Content, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, searchArticlesLike,
+		arg.Title,
+		arg.Content,
+		arg.OwnerID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -538,6 +794,8 @@ func (q *Queries) SearchArticlesLike(ctx context.Context, arg SearchArticlesLike
 			&i.ParentID,
 			&i.Sections,
 			&i.CustomMetadata,
+			&i.DeletedAt,
+			&i.OwnerID,
 		); err != nil {
 			return nil, err
 		}
@@ -552,6 +810,29 @@ func (q *Queries) SearchArticlesLike(ctx context.Context, arg SearchArticlesLike
 	return items, nil
 }
 
+const softDeleteArticle = `-- name: SoftDeleteArticle :exec
+UPDATE articles SET deleted_at = ? WHERE id = ?
+`
+
+type SoftDeleteArticleParams struct {
+	DeletedAt *time.Time `json:"deleted_at"`
+	ID        string     `json:"id"`
+}
+
+func (q *Queries) SoftDeleteArticle(ctx context.Context, arg SoftDeleteArticleParams) error {
+	_, err := q.db.ExecContext(ctx, softDeleteArticle, arg.DeletedAt, arg.ID)
+	return err
+}
+
+const undeleteArticle = `-- name: UndeleteArticle :exec
+UPDATE articles SET deleted_at = NULL WHERE id = ?
+`
+
+func (q *Queries) UndeleteArticle(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, undeleteArticle, id)
+	return err
+}
+
 const updateArticle = `-- name: UpdateArticle :exec
 UPDATE articles SET
     title = ?, content = ?, summary = ?,
@@ -10,6 +10,39 @@ import (
 	"time"
 )
 
+const addJobDependency = `-- name: AddJobDependency :exec
+INSERT INTO job_dependencies (job_id, depends_on) VALUES (?, ?)
+`
+
+type AddJobDependencyParams struct {
+	JobID     string `json:"job_id"`
+	DependsOn string `json:"depends_on"`
+}
+
+func (q *Queries) AddJobDependency(ctx context.Context, arg AddJobDependencyParams) error {
+	_, err := q.db.ExecContext(ctx, addJobDependency, arg.JobID, arg.DependsOn)
+	return err
+}
+
+const claimQueuedJob = `-- name: ClaimQueuedJob :execrows
+UPDATE processing_jobs
+SET status = 'running', started_at = ?
+WHERE id = ? AND status = 'queued'
+`
+
+type ClaimQueuedJobParams struct {
+	StartedAt *time.Time `json:"started_at"`
+	ID        string     `json:"id"`
+}
+
+func (q *Queries) ClaimQueuedJob(ctx context.Context, arg ClaimQueuedJobParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, claimQueuedJob, arg.StartedAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const cleanupCompletedJobs = `-- name: CleanupCompletedJobs :execrows
 DELETE FROM processing_jobs
 WHERE status = 'completed' AND completed_at < ?
@@ -39,6 +72,52 @@ func (q *Queries) CompleteJob(ctx context.Context, arg CompleteJobParams) error
 	return err
 }
 
+const countFailedJobsByTypeSince = `-- name: CountFailedJobsByTypeSince :many
+SELECT type, COUNT(*) as count FROM processing_jobs
+WHERE status = 'failed' AND completed_at >= ?
+GROUP BY type
+`
+
+type CountFailedJobsByTypeSinceRow struct {
+	Type  string `json:"type"`
+	Count int64  `json:"count"`
+}
+
+func (q *Queries) CountFailedJobsByTypeSince(ctx context.Context, completedAt *time.Time) ([]CountFailedJobsByTypeSinceRow, error) {
+	rows, err := q.db.QueryContext(ctx, countFailedJobsByTypeSince, completedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountFailedJobsByTypeSinceRow{}
+	for rows.Next() {
+		var i CountFailedJobsByTypeSinceRow
+		if err := rows.Scan(&i.Type, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countJobs = `-- name: CountJobs :one
+SELECT COUNT(*) FROM processing_jobs
+WHERE owner_id IS COALESCE(?, owner_id)
+`
+
+func (q *Queries) CountJobs(ctx context.Context, ownerID *string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countJobs, ownerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countJobsByStatus = `-- name: CountJobsByStatus :many
 SELECT status, COUNT(*) as count FROM processing_jobs GROUP BY status
 `
@@ -71,11 +150,28 @@ func (q *Queries) CountJobsByStatus(ctx context.Context) ([]CountJobsByStatusRow
 	return items, nil
 }
 
+const countJobsWithStatus = `-- name: CountJobsWithStatus :one
+SELECT COUNT(*) FROM processing_jobs
+WHERE status = ? AND owner_id IS COALESCE(?, owner_id)
+`
+
+type CountJobsWithStatusParams struct {
+	Status  *string `json:"status"`
+	OwnerID *string `json:"owner_id"`
+}
+
+func (q *Queries) CountJobsWithStatus(ctx context.Context, arg CountJobsWithStatusParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countJobsWithStatus, arg.Status, arg.OwnerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createJob = `-- name: CreateJob :exec
 INSERT INTO processing_jobs (
     id, source_id, type, status, priority, progress, current_step,
-    retry_count, error, created_at, started_at, completed_at
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateJobParams struct {
@@ -91,6 +187,10 @@ type CreateJobParams struct {
 	CreatedAt   time.Time  `json:"created_at"`
 	StartedAt   *time.Time `json:"started_at"`
 	CompletedAt *time.Time `json:"completed_at"`
+	OwnerID     *string    `json:"owner_id"`
+	ApiKey      *string    `json:"api_key"`
+	Metadata    *string    `json:"metadata"`
+	Payload     *string    `json:"payload"`
 }
 
 func (q *Queries) CreateJob(ctx context.Context, arg CreateJobParams) error {
@@ -107,6 +207,10 @@ func (q *Queries) CreateJob(ctx context.Context, arg CreateJobParams) error {
 		arg.CreatedAt,
 		arg.StartedAt,
 		arg.CompletedAt,
+		arg.OwnerID,
+		arg.ApiKey,
+		arg.Metadata,
+		arg.Payload,
 	)
 	return err
 }
@@ -139,7 +243,7 @@ func (q *Queries) FailJob(ctx context.Context, arg FailJobParams) error {
 
 const getJobByID = `-- name: GetJobByID :one
 SELECT id, source_id, type, status, priority, progress, current_step,
-    retry_count, error, created_at, started_at, completed_at
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
 FROM processing_jobs WHERE id = ?
 `
 
@@ -159,13 +263,17 @@ func (q *Queries) GetJobByID(ctx context.Context, id string) (ProcessingJob, err
 		&i.CreatedAt,
 		&i.StartedAt,
 		&i.CompletedAt,
+		&i.OwnerID,
+		&i.ApiKey,
+		&i.Metadata,
+		&i.Payload,
 	)
 	return i, err
 }
 
 const getJobsBySourceID = `-- name: GetJobsBySourceID :many
 SELECT id, source_id, type, status, priority, progress, current_step,
-    retry_count, error, created_at, started_at, completed_at
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
 FROM processing_jobs
 WHERE source_id = ?
 ORDER BY created_at DESC
@@ -193,6 +301,10 @@ func (q *Queries) GetJobsBySourceID(ctx context.Context, sourceID *string) ([]Pr
 			&i.CreatedAt,
 			&i.StartedAt,
 			&i.CompletedAt,
+			&i.OwnerID,
+			&i.ApiKey,
+			&i.Metadata,
+			&i.Payload,
 		); err != nil {
 			return nil, err
 		}
@@ -209,9 +321,12 @@ func (q *Queries) GetJobsBySourceID(ctx context.Context, sourceID *string) ([]Pr
 
 const getNextQueuedJob = `-- name: GetNextQueuedJob :one
 SELECT id, source_id, type, status, priority, progress, current_step,
-    retry_count, error, created_at, started_at, completed_at
-FROM processing_jobs
-WHERE status = 'queued'
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
+FROM processing_jobs j
+WHERE status = 'queued' AND NOT EXISTS (
+    SELECT 1 FROM job_dependencies d JOIN processing_jobs p ON p.id = d.depends_on
+    WHERE d.job_id = j.id AND p.status != 'completed'
+)
 ORDER BY priority ASC, created_at ASC
 LIMIT 1
 `
@@ -232,26 +347,64 @@ func (q *Queries) GetNextQueuedJob(ctx context.Context) (ProcessingJob, error) {
 		&i.CreatedAt,
 		&i.StartedAt,
 		&i.CompletedAt,
+		&i.OwnerID,
+		&i.ApiKey,
+		&i.Metadata,
+		&i.Payload,
 	)
 	return i, err
 }
 
+const listJobDependencies = `-- name: ListJobDependencies :many
+SELECT depends_on FROM job_dependencies WHERE job_id = ? ORDER BY depends_on
+`
+
+func (q *Queries) ListJobDependencies(ctx context.Context, jobID string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listJobDependencies, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var depends_on string
+		if err := rows.Scan(&depends_on); err != nil {
+			return nil, err
+		}
+		items = append(items, depends_on)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listJobsByStatus = `-- name: ListJobsByStatus :many
 SELECT id, source_id, type, status, priority, progress, current_step,
-    retry_count, error, created_at, started_at, completed_at
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
 FROM processing_jobs
-WHERE status = ?
+WHERE status = ? AND owner_id IS COALESCE(?, owner_id)
 ORDER BY priority ASC, created_at ASC
-LIMIT ?
+LIMIT ? OFFSET ?
 `
 
 type ListJobsByStatusParams struct {
-	Status *string `json:"status"`
-	Limit  int64   `json:"limit"`
+	Status  *string `json:"status"`
+	OwnerID *string `json:"owner_id"`
+	Limit   int64   `json:"limit"`
+	Offset  int64   `json:"offset"`
 }
 
 func (q *Queries) ListJobsByStatus(ctx context.Context, arg ListJobsByStatusParams) ([]ProcessingJob, error) {
-	rows, err := q.db.QueryContext(ctx, listJobsByStatus, arg.Status, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, listJobsByStatus,
+		arg.Status,
+		arg.OwnerID,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -272,6 +425,10 @@ func (q *Queries) ListJobsByStatus(ctx context.Context, arg ListJobsByStatusPara
 			&i.CreatedAt,
 			&i.StartedAt,
 			&i.CompletedAt,
+			&i.OwnerID,
+			&i.ApiKey,
+			&i.Metadata,
+			&i.Payload,
 		); err != nil {
 			return nil, err
 		}
@@ -286,16 +443,154 @@ func (q *Queries) ListJobsByStatus(ctx context.Context, arg ListJobsByStatusPara
 	return items, nil
 }
 
+const listOrphanedJobs = `-- name: ListOrphanedJobs :many
+SELECT id, source_id, type, status, priority, progress, current_step,
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
+FROM processing_jobs j
+WHERE status = 'running' AND NOT EXISTS (
+    SELECT 1 FROM job_claims c WHERE c.job_id = j.id
+)
+ORDER BY started_at ASC
+`
+
+func (q *Queries) ListOrphanedJobs(ctx context.Context) ([]ProcessingJob, error) {
+	rows, err := q.db.QueryContext(ctx, listOrphanedJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ProcessingJob{}
+	for rows.Next() {
+		var i ProcessingJob
+		if err := rows.Scan(
+			&i.ID,
+			&i.SourceID,
+			&i.Type,
+			&i.Status,
+			&i.Priority,
+			&i.Progress,
+			&i.CurrentStep,
+			&i.RetryCount,
+			&i.Error,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.OwnerID,
+			&i.ApiKey,
+			&i.Metadata,
+			&i.Payload,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listQueuedJobDependents = `-- name: ListQueuedJobDependents :many
+SELECT j.id FROM job_dependencies d JOIN processing_jobs j ON j.id = d.job_id
+WHERE d.depends_on = ? AND j.status = 'queued'
+ORDER BY j.id
+`
+
+func (q *Queries) ListQueuedJobDependents(ctx context.Context, dependsOn string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listQueuedJobDependents, dependsOn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listRecentJobs = `-- name: ListRecentJobs :many
 SELECT id, source_id, type, status, priority, progress, current_step,
-    retry_count, error, created_at, started_at, completed_at
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
 FROM processing_jobs
+WHERE owner_id IS COALESCE(?, owner_id)
 ORDER BY created_at DESC
+LIMIT ? OFFSET ?
+`
+
+type ListRecentJobsParams struct {
+	OwnerID *string `json:"owner_id"`
+	Limit   int64   `json:"limit"`
+	Offset  int64   `json:"offset"`
+}
+
+func (q *Queries) ListRecentJobs(ctx context.Context, arg ListRecentJobsParams) ([]ProcessingJob, error) {
+	rows, err := q.db.QueryContext(ctx, listRecentJobs, arg.OwnerID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ProcessingJob{}
+	for rows.Next() {
+		var i ProcessingJob
+		if err := rows.Scan(
+			&i.ID,
+			&i.SourceID,
+			&i.Type,
+			&i.Status,
+			&i.Priority,
+			&i.Progress,
+			&i.CurrentStep,
+			&i.RetryCount,
+			&i.Error,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.OwnerID,
+			&i.ApiKey,
+			&i.Metadata,
+			&i.Payload,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRunnableJobs = `-- name: ListRunnableJobs :many
+SELECT id, source_id, type, status, priority, progress, current_step,
+    retry_count, error, created_at, started_at, completed_at, owner_id, api_key, metadata, payload
+FROM processing_jobs j
+WHERE status = 'queued' AND NOT EXISTS (
+    SELECT 1 FROM job_dependencies d JOIN processing_jobs p ON p.id = d.depends_on
+    WHERE d.job_id = j.id AND p.status != 'completed'
+)
+ORDER BY priority ASC, created_at ASC
 LIMIT ?
 `
 
-func (q *Queries) ListRecentJobs(ctx context.Context, limit int64) ([]ProcessingJob, error) {
-	rows, err := q.db.QueryContext(ctx, listRecentJobs, limit)
+func (q *Queries) ListRunnableJobs(ctx context.Context, limit int64) ([]ProcessingJob, error) {
+	rows, err := q.db.QueryContext(ctx, listRunnableJobs, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -316,6 +611,10 @@ func (q *Queries) ListRecentJobs(ctx context.Context, limit int64) ([]Processing
 			&i.CreatedAt,
 			&i.StartedAt,
 			&i.CompletedAt,
+			&i.OwnerID,
+			&i.ApiKey,
+			&i.Metadata,
+			&i.Payload,
 		); err != nil {
 			return nil, err
 		}
@@ -357,6 +656,20 @@ func (q *Queries) StartJob(ctx context.Context, arg StartJobParams) error {
 	return err
 }
 
+const updateJobMetadata = `-- name: UpdateJobMetadata :exec
+UPDATE processing_jobs SET metadata = ? WHERE id = ?
+`
+
+type UpdateJobMetadataParams struct {
+	Metadata *string `json:"metadata"`
+	ID       string  `json:"id"`
+}
+
+func (q *Queries) UpdateJobMetadata(ctx context.Context, arg UpdateJobMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateJobMetadata, arg.Metadata, arg.ID)
+	return err
+}
+
 const updateJobProgress = `-- name: UpdateJobProgress :exec
 UPDATE processing_jobs SET progress = ? WHERE id = ?
 `
@@ -8,6 +8,16 @@ import (
 	"time"
 )
 
+type ApiKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	KeyHash    string     `json:"key_hash"`
+	Prefix     string     `json:"prefix"`
+	Scope      string     `json:"scope"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
 type Article struct {
 	ID             string     `json:"id"`
 	Title          string     `json:"title"`
@@ -25,6 +35,16 @@ type Article struct {
 	ParentID       *string    `json:"parent_id"`
 	Sections       *string    `json:"sections"`
 	CustomMetadata *string    `json:"custom_metadata"`
+	DeletedAt      *time.Time `json:"deleted_at"`
+	OwnerID        *string    `json:"owner_id"`
+}
+
+type ArticleEntity struct {
+	ArticleID string    `json:"article_id"`
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	Mentions  int64     `json:"mentions"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type ArticleRelation struct {
@@ -35,6 +55,19 @@ type ArticleRelation struct {
 	CreatedAt     time.Time `json:"created_at"`
 }
 
+type ArticleRevision struct {
+	ID             int64     `json:"id"`
+	ArticleID      string    `json:"article_id"`
+	Title          string    `json:"title"`
+	Content        string    `json:"content"`
+	Summary        *string   `json:"summary"`
+	Status         *string   `json:"status"`
+	Sections       *string   `json:"sections"`
+	CustomMetadata *string   `json:"custom_metadata"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
 type ArticleTag struct {
 	ArticleID *string `json:"article_id"`
 	TagID     *int64  `json:"tag_id"`
@@ -47,6 +80,124 @@ type ArticlesFt struct {
 	Summary   string `json:"summary"`
 }
 
+type Blob struct {
+	Sha256    string    `json:"sha256"`
+	Size      int64     `json:"size"`
+	RefCount  int64     `json:"ref_count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Bookmark struct {
+	ID          int64     `json:"id"`
+	SourceID    string    `json:"source_id"`
+	TimeSeconds float64   `json:"time_seconds"`
+	Label       string    `json:"label"`
+	Note        *string   `json:"note"`
+	CreatedAt   time.Time `json:"created_at"`
+	Color       *string   `json:"color"`
+}
+
+type Checksum struct {
+	TargetType string     `json:"target_type"`
+	TargetID   string     `json:"target_id"`
+	SourceID   *string    `json:"source_id"`
+	Sha256     string     `json:"sha256"`
+	Size       int64      `json:"size"`
+	RecordedAt time.Time  `json:"recorded_at"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	Status     *string    `json:"status"`
+}
+
+type EmbeddingChunk struct {
+	ID         int64     `json:"id"`
+	ArticleID  string    `json:"article_id"`
+	SourceID   *string   `json:"source_id"`
+	ChunkIndex int64     `json:"chunk_index"`
+	StartTime  *float64  `json:"start_time"`
+	EndTime    *float64  `json:"end_time"`
+	Text       string    `json:"text"`
+	Model      string    `json:"model"`
+	Dimensions int64     `json:"dimensions"`
+	Embedding  []byte    `json:"embedding"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Experiment struct {
+	ID                int64     `json:"id"`
+	Tool              string    `json:"tool"`
+	Name              *string   `json:"name"`
+	InputFile         string    `json:"input_file"`
+	Params            string    `json:"params"`
+	Text              string    `json:"text"`
+	AudioSeconds      float64   `json:"audio_seconds"`
+	ProcessingSeconds float64   `json:"processing_seconds"`
+	Rtf               float64   `json:"rtf"`
+	Reference         *string   `json:"reference"`
+	Cer               *float64  `json:"cer"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type ExperimentChunk struct {
+	ExperimentID int64   `json:"experiment_id"`
+	Idx          int64   `json:"idx"`
+	StartSec     float64 `json:"start_sec"`
+	EndSec       float64 `json:"end_sec"`
+	Text         string  `json:"text"`
+}
+
+type JobClaim struct {
+	JobID       string    `json:"job_id"`
+	WorkerID    string    `json:"worker_id"`
+	ClaimedAt   time.Time `json:"claimed_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+}
+
+type JobDependency struct {
+	JobID     string `json:"job_id"`
+	DependsOn string `json:"depends_on"`
+}
+
+type JobLog struct {
+	ID        int64     `json:"id"`
+	JobID     string    `json:"job_id"`
+	Level     string    `json:"level"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type JobPartialBlock struct {
+	JobID      string    `json:"job_id"`
+	FileIndex  int64     `json:"file_index"`
+	BlockIndex int64     `json:"block_index"`
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type JobResultChunk struct {
+	JobID      string    `json:"job_id"`
+	ChunkIndex int64     `json:"chunk_index"`
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type LegalHold struct {
+	TargetType string    `json:"target_type"`
+	TargetID   string    `json:"target_id"`
+	Reason     string    `json:"reason"`
+	LockedBy   string    `json:"locked_by"`
+	LockedAt   time.Time `json:"locked_at"`
+}
+
+type LegalHoldEvent struct {
+	ID         int64     `json:"id"`
+	TargetType string    `json:"target_type"`
+	TargetID   string    `json:"target_id"`
+	Action     string    `json:"action"`
+	Actor      string    `json:"actor"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 type ProcessingArtifact struct {
 	ID        string    `json:"id"`
 	SourceID  *string   `json:"source_id"`
@@ -71,6 +222,29 @@ type ProcessingJob struct {
 	CreatedAt   time.Time  `json:"created_at"`
 	StartedAt   *time.Time `json:"started_at"`
 	CompletedAt *time.Time `json:"completed_at"`
+	OwnerID     *string    `json:"owner_id"`
+	ApiKey      *string    `json:"api_key"`
+	Metadata    *string    `json:"metadata"`
+	Payload     *string    `json:"payload"`
+}
+
+type ReplacementRule struct {
+	ID          int64     `json:"id"`
+	SourceID    *string   `json:"source_id"`
+	Pattern     string    `json:"pattern"`
+	Replacement string    `json:"replacement"`
+	IsRegex     int64     `json:"is_regex"`
+	Enabled     int64     `json:"enabled"`
+	Note        *string   `json:"note"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type Session struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 type Source struct {
@@ -81,6 +255,19 @@ type Source struct {
 	Metadata    *string   `json:"metadata"`
 	CreatedAt   time.Time `json:"created_at"`
 	Status      *string   `json:"status"`
+	OwnerID     *string   `json:"owner_id"`
+}
+
+type SourceBlob struct {
+	Path      string    `json:"path"`
+	SourceID  string    `json:"source_id"`
+	Sha256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SourceAccess struct {
+	SourceID   string    `json:"source_id"`
+	AccessedAt time.Time `json:"accessed_at"`
 }
 
 type Tag struct {
@@ -89,3 +276,91 @@ type Tag struct {
 	Color     *string   `json:"color"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+type TagSuggestion struct {
+	ID        int64      `json:"id"`
+	ArticleID string     `json:"article_id"`
+	Name      string     `json:"name"`
+	TagID     *int64     `json:"tag_id"`
+	Score     float64    `json:"score"`
+	Method    string     `json:"method"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	DecidedAt *time.Time `json:"decided_at"`
+}
+
+type TranscriptSegment struct {
+	ID           int64   `json:"id"`
+	SourceID     string  `json:"source_id"`
+	SegmentIndex int64   `json:"segment_index"`
+	StartTime    float64 `json:"start_time"`
+	EndTime      float64 `json:"end_time"`
+	Speaker      *string `json:"speaker"`
+	Text         string  `json:"text"`
+}
+
+type TranscriptSegmentsFt struct {
+	Text string `json:"text"`
+}
+
+type UsageQuota struct {
+	Scope          string    `json:"scope"`
+	Subject        string    `json:"subject"`
+	MonthlyMinutes float64   `json:"monthly_minutes"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type UsageRecord struct {
+	ID        int64     `json:"id"`
+	JobID     string    `json:"job_id"`
+	SourceID  *string   `json:"source_id"`
+	OwnerID   *string   `json:"owner_id"`
+	ApiKey    *string   `json:"api_key"`
+	Model     string    `json:"model"`
+	Seconds   float64   `json:"seconds"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type User struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"password_hash"`
+	IsAdmin      int64     `json:"is_admin"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Webhook struct {
+	ID          int64     `json:"id"`
+	OwnerID     *string   `json:"owner_id"`
+	Url         string    `json:"url"`
+	Secret      string    `json:"secret"`
+	Events      string    `json:"events"`
+	Enabled     int64     `json:"enabled"`
+	Description *string   `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	WebhookID      int64      `json:"webhook_id"`
+	Event          string     `json:"event"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int64      `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	ResponseStatus *int64     `json:"response_status"`
+	LastError      *string    `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+type Worker struct {
+	ID           string    `json:"id"`
+	Name         *string   `json:"name"`
+	Models       *string   `json:"models"`
+	Capacity     *int64    `json:"capacity"`
+	Version      *string   `json:"version"`
+	RegisteredAt time.Time `json:"registered_at"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
+}
@@ -10,6 +10,17 @@ import (
 	"time"
 )
 
+const countSourcesByOriginalURL = `-- name: CountSourcesByOriginalURL :one
+SELECT COUNT(*) FROM sources WHERE original_url = ?
+`
+
+func (q *Queries) CountSourcesByOriginalURL(ctx context.Context, originalUrl *string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSourcesByOriginalURL, originalUrl)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createArtifact = `-- name: CreateArtifact :exec
 INSERT INTO processing_artifacts (id, source_id, type, content, format, file_path, metadata, created_at)
 VALUES (?, ?, ?, ?, ?, ?, ?, ?)
@@ -41,8 +52,8 @@ func (q *Queries) CreateArtifact(ctx context.Context, arg CreateArtifactParams)
 }
 
 const createSource = `-- name: CreateSource :exec
-INSERT INTO sources (id, type, original_url, file_path, metadata, created_at, status)
-VALUES (?, ?, ?, ?, ?, ?, ?)
+INSERT INTO sources (id, type, original_url, file_path, metadata, created_at, status, owner_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateSourceParams struct {
@@ -53,6 +64,7 @@ type CreateSourceParams struct {
 	Metadata    *string   `json:"metadata"`
 	CreatedAt   time.Time `json:"created_at"`
 	Status      *string   `json:"status"`
+	OwnerID     *string   `json:"owner_id"`
 }
 
 func (q *Queries) CreateSource(ctx context.Context, arg CreateSourceParams) error {
@@ -64,6 +76,7 @@ func (q *Queries) CreateSource(ctx context.Context, arg CreateSourceParams) erro
 		arg.Metadata,
 		arg.CreatedAt,
 		arg.Status,
+		arg.OwnerID,
 	)
 	return err
 }
@@ -155,8 +168,40 @@ func (q *Queries) GetArtifactsBySourceID(ctx context.Context, sourceID *string)
 	return items, nil
 }
 
+const getSourceByContentHash = `-- name: GetSourceByContentHash :one
+SELECT id, type, original_url, file_path, metadata, created_at, status, owner_id
+FROM sources
+WHERE json_extract(metadata, '$.content_hash') = ?1
+  AND owner_id IS COALESCE(?2, owner_id)
+  AND COALESCE(status, '') != 'failed'
+ORDER BY created_at
+LIMIT 1
+`
+
+type GetSourceByContentHashParams struct {
+	ContentHash interface{} `json:"content_hash"`
+	OwnerID     *string     `json:"owner_id"`
+}
+
+// 同じ内容の音声をアップロードしたソース（失敗したものを除き、最も古いもの）
+func (q *Queries) GetSourceByContentHash(ctx context.Context, arg GetSourceByContentHashParams) (Source, error) {
+	row := q.db.QueryRowContext(ctx, getSourceByContentHash, arg.ContentHash, arg.OwnerID)
+	var i Source
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.OriginalUrl,
+		&i.FilePath,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.Status,
+		&i.OwnerID,
+	)
+	return i, err
+}
+
 const getSourceByID = `-- name: GetSourceByID :one
-SELECT id, type, original_url, file_path, metadata, created_at, status
+SELECT id, type, original_url, file_path, metadata, created_at, status, owner_id
 FROM sources WHERE id = ?
 `
 
@@ -171,24 +216,129 @@ func (q *Queries) GetSourceByID(ctx context.Context, id string) (Source, error)
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.Status,
+		&i.OwnerID,
 	)
 	return i, err
 }
 
+const listArchivableSources = `-- name: ListArchivableSources :many
+SELECT s.id, s.type, s.original_url, s.file_path, s.metadata, s.created_at, s.status, s.owner_id
+FROM sources s
+LEFT JOIN source_access a ON a.source_id = s.id
+WHERE s.status = 'completed'
+  AND s.type IN ('audio', 'youtube')
+  AND s.id NOT IN (SELECT target_id FROM legal_holds WHERE target_type = 'source')
+  AND COALESCE(a.accessed_at, s.created_at) < ?1
+ORDER BY s.created_at
+LIMIT ?2
+`
+
+type ListArchivableSourcesParams struct {
+	Cutoff time.Time `json:"cutoff"`
+	Limit  int64     `json:"limit"`
+}
+
+func (q *Queries) ListArchivableSources(ctx context.Context, arg ListArchivableSourcesParams) ([]Source, error) {
+	rows, err := q.db.QueryContext(ctx, listArchivableSources, arg.Cutoff, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Source{}
+	for rows.Next() {
+		var i Source
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.OriginalUrl,
+			&i.FilePath,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.Status,
+			&i.OwnerID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCleanableSources = `-- name: ListCleanableSources :many
+SELECT s.id, s.type, s.original_url, s.file_path, s.metadata, s.created_at, s.status, s.owner_id
+FROM sources s
+WHERE s.status = 'completed'
+  AND s.type IN ('audio', 'youtube', 'podcast')
+  AND s.id NOT IN (SELECT target_id FROM legal_holds WHERE target_type = 'source')
+  AND json_extract(COALESCE(s.metadata, '{}'), '$.storage_cleanup') IS NULL
+  AND (
+    SELECT MAX(a.created_at) FROM processing_artifacts a
+    WHERE a.source_id = s.id AND a.type = 'transcription' AND a.content IS NOT NULL
+  ) < ?1
+ORDER BY s.created_at
+LIMIT ?2
+`
+
+type ListCleanableSourcesParams struct {
+	Cutoff time.Time `json:"cutoff"`
+	Limit  int64     `json:"limit"`
+}
+
+// 文字起こしの完了（最新の文字起こし成果物の作成日時）から cutoff 以上経ち、元音声をまだ削除していないソース
+func (q *Queries) ListCleanableSources(ctx context.Context, arg ListCleanableSourcesParams) ([]Source, error) {
+	rows, err := q.db.QueryContext(ctx, listCleanableSources, arg.Cutoff, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Source{}
+	for rows.Next() {
+		var i Source
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.OriginalUrl,
+			&i.FilePath,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.Status,
+			&i.OwnerID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listSources = `-- name: ListSources :many
-SELECT id, type, original_url, file_path, metadata, created_at, status
+SELECT id, type, original_url, file_path, metadata, created_at, status, owner_id
 FROM sources
+WHERE owner_id IS COALESCE(?, owner_id)
 ORDER BY created_at DESC
 LIMIT ? OFFSET ?
 `
 
 type ListSourcesParams struct {
-	Limit  int64 `json:"limit"`
-	Offset int64 `json:"offset"`
+	OwnerID *string `json:"owner_id"`
+	Limit   int64   `json:"limit"`
+	Offset  int64   `json:"offset"`
 }
 
 func (q *Queries) ListSources(ctx context.Context, arg ListSourcesParams) ([]Source, error) {
-	rows, err := q.db.QueryContext(ctx, listSources, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listSources, arg.OwnerID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -204,6 +354,94 @@ func (q *Queries) ListSources(ctx context.Context, arg ListSourcesParams) ([]Sou
 			&i.Metadata,
 			&i.CreatedAt,
 			&i.Status,
+			&i.OwnerID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSourcesByType = `-- name: ListSourcesByType :many
+SELECT id, type, original_url, file_path, metadata, created_at, status, owner_id
+FROM sources
+WHERE type = ? AND owner_id IS COALESCE(?, owner_id)
+ORDER BY created_at DESC
+`
+
+type ListSourcesByTypeParams struct {
+	Type    string  `json:"type"`
+	OwnerID *string `json:"owner_id"`
+}
+
+func (q *Queries) ListSourcesByType(ctx context.Context, arg ListSourcesByTypeParams) ([]Source, error) {
+	rows, err := q.db.QueryContext(ctx, listSourcesByType, arg.Type, arg.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Source{}
+	for rows.Next() {
+		var i Source
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.OriginalUrl,
+			&i.FilePath,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.Status,
+			&i.OwnerID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranscribedSources = `-- name: ListTranscribedSources :many
+SELECT s.id, s.type, s.original_url, s.file_path, s.metadata, s.created_at, s.status, s.owner_id
+FROM sources s
+WHERE s.owner_id IS COALESCE(?, s.owner_id)
+  AND EXISTS (
+  SELECT 1 FROM processing_artifacts a
+  WHERE a.source_id = s.id AND a.type = 'transcription' AND a.content IS NOT NULL
+)
+ORDER BY s.created_at DESC
+`
+
+func (q *Queries) ListTranscribedSources(ctx context.Context, ownerID *string) ([]Source, error) {
+	rows, err := q.db.QueryContext(ctx, listTranscribedSources, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Source{}
+	for rows.Next() {
+		var i Source
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.OriginalUrl,
+			&i.FilePath,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.Status,
+			&i.OwnerID,
 		); err != nil {
 			return nil, err
 		}
@@ -232,6 +470,35 @@ func (q *Queries) UpdateArtifactContent(ctx context.Context, arg UpdateArtifactC
 	return err
 }
 
+const updateArtifactType = `-- name: UpdateArtifactType :exec
+UPDATE processing_artifacts SET type = ?, metadata = ? WHERE id = ?
+`
+
+type UpdateArtifactTypeParams struct {
+	Type     string  `json:"type"`
+	Metadata *string `json:"metadata"`
+	ID       string  `json:"id"`
+}
+
+func (q *Queries) UpdateArtifactType(ctx context.Context, arg UpdateArtifactTypeParams) error {
+	_, err := q.db.ExecContext(ctx, updateArtifactType, arg.Type, arg.Metadata, arg.ID)
+	return err
+}
+
+const updateSourceMetadata = `-- name: UpdateSourceMetadata :exec
+UPDATE sources SET metadata = ? WHERE id = ?
+`
+
+type UpdateSourceMetadataParams struct {
+	Metadata *string `json:"metadata"`
+	ID       string  `json:"id"`
+}
+
+func (q *Queries) UpdateSourceMetadata(ctx context.Context, arg UpdateSourceMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateSourceMetadata, arg.Metadata, arg.ID)
+	return err
+}
+
 const updateSourceStatus = `-- name: UpdateSourceStatus :exec
 UPDATE sources SET status = ? WHERE id = ?
 `
@@ -245,3 +512,19 @@ func (q *Queries) UpdateSourceStatus(ctx context.Context, arg UpdateSourceStatus
 	_, err := q.db.ExecContext(ctx, updateSourceStatus, arg.Status, arg.ID)
 	return err
 }
+
+const upsertSourceAccess = `-- name: UpsertSourceAccess :exec
+INSERT INTO source_access (source_id, accessed_at)
+VALUES (?, ?)
+ON CONFLICT(source_id) DO UPDATE SET accessed_at = excluded.accessed_at
+`
+
+type UpsertSourceAccessParams struct {
+	SourceID   string    `json:"source_id"`
+	AccessedAt time.Time `json:"accessed_at"`
+}
+
+func (q *Queries) UpsertSourceAccess(ctx context.Context, arg UpsertSourceAccessParams) error {
+	_, err := q.db.ExecContext(ctx, upsertSourceAccess, arg.SourceID, arg.AccessedAt)
+	return err
+}
@@ -10,6 +10,17 @@ import (
 	"time"
 )
 
+const countTags = `-- name: CountTags :one
+SELECT COUNT(*) FROM tags
+`
+
+func (q *Queries) CountTags(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countTags)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createTag = `-- name: CreateTag :one
 INSERT INTO tags (name, color, created_at)
 VALUES (?, ?, ?)
@@ -34,6 +45,57 @@ func (q *Queries) CreateTag(ctx context.Context, arg CreateTagParams) (Tag, erro
 	return i, err
 }
 
+const createTagSuggestion = `-- name: CreateTagSuggestion :execrows
+INSERT INTO tag_suggestions (article_id, name, tag_id, score, method, status, created_at)
+VALUES (?, ?, ?, ?, ?, 'pending', ?)
+ON CONFLICT (article_id, name) DO NOTHING
+`
+
+type CreateTagSuggestionParams struct {
+	ArticleID string    `json:"article_id"`
+	Name      string    `json:"name"`
+	TagID     *int64    `json:"tag_id"`
+	Score     float64   `json:"score"`
+	Method    string    `json:"method"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreateTagSuggestion(ctx context.Context, arg CreateTagSuggestionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createTagSuggestion,
+		arg.ArticleID,
+		arg.Name,
+		arg.TagID,
+		arg.Score,
+		arg.Method,
+		arg.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const decideTagSuggestion = `-- name: DecideTagSuggestion :exec
+UPDATE tag_suggestions SET status = ?, tag_id = ?, decided_at = ? WHERE id = ?
+`
+
+type DecideTagSuggestionParams struct {
+	Status    string     `json:"status"`
+	TagID     *int64     `json:"tag_id"`
+	DecidedAt *time.Time `json:"decided_at"`
+	ID        int64      `json:"id"`
+}
+
+func (q *Queries) DecideTagSuggestion(ctx context.Context, arg DecideTagSuggestionParams) error {
+	_, err := q.db.ExecContext(ctx, decideTagSuggestion,
+		arg.Status,
+		arg.TagID,
+		arg.DecidedAt,
+		arg.ID,
+	)
+	return err
+}
+
 const deleteArticleTagsByTagID = `-- name: DeleteArticleTagsByTagID :exec
 DELETE FROM article_tags WHERE tag_id = ?
 `
@@ -84,12 +146,119 @@ func (q *Queries) GetTagByName(ctx context.Context, name string) (Tag, error) {
 	return i, err
 }
 
+const getTagSuggestion = `-- name: GetTagSuggestion :one
+SELECT id, article_id, name, tag_id, score, method, status, created_at, decided_at
+FROM tag_suggestions WHERE id = ?
+`
+
+func (q *Queries) GetTagSuggestion(ctx context.Context, id int64) (TagSuggestion, error) {
+	row := q.db.QueryRowContext(ctx, getTagSuggestion, id)
+	var i TagSuggestion
+	err := row.Scan(
+		&i.ID,
+		&i.ArticleID,
+		&i.Name,
+		&i.TagID,
+		&i.Score,
+		&i.Method,
+		&i.Status,
+		&i.CreatedAt,
+		&i.DecidedAt,
+	)
+	return i, err
+}
+
+const listArticlesForAutoTag = `-- name: ListArticlesForAutoTag :many
+SELECT a.id FROM articles a
+WHERE a.created_at >= ? AND a.deleted_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM tag_suggestions s WHERE s.article_id = a.id)
+  AND NOT EXISTS (
+    SELECT 1 FROM processing_jobs j
+    WHERE j.type = 'autotag' AND j.source_id = a.source_id
+  )
+ORDER BY a.created_at
+LIMIT ?
+`
+
+type ListArticlesForAutoTagParams struct {
+	CreatedAt time.Time `json:"created_at"`
+	Limit     int64     `json:"limit"`
+}
+
+func (q *Queries) ListArticlesForAutoTag(ctx context.Context, arg ListArticlesForAutoTagParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listArticlesForAutoTag, arg.CreatedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTagSuggestionsByArticle = `-- name: ListTagSuggestionsByArticle :many
+SELECT id, article_id, name, tag_id, score, method, status, created_at, decided_at
+FROM tag_suggestions WHERE article_id = ?
+ORDER BY score DESC, name
+`
+
+func (q *Queries) ListTagSuggestionsByArticle(ctx context.Context, articleID string) ([]TagSuggestion, error) {
+	rows, err := q.db.QueryContext(ctx, listTagSuggestionsByArticle, articleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TagSuggestion{}
+	for rows.Next() {
+		var i TagSuggestion
+		if err := rows.Scan(
+			&i.ID,
+			&i.ArticleID,
+			&i.Name,
+			&i.TagID,
+			&i.Score,
+			&i.Method,
+			&i.Status,
+			&i.CreatedAt,
+			&i.DecidedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTags = `-- name: ListTags :many
 SELECT id, name, color, created_at FROM tags ORDER BY name
+LIMIT ? OFFSET ?
 `
 
-func (q *Queries) ListTags(ctx context.Context) ([]Tag, error) {
-	rows, err := q.db.QueryContext(ctx, listTags)
+type ListTagsParams struct {
+	Limit  int64 `json:"limit"`
+	Offset int64 `json:"offset"`
+}
+
+func (q *Queries) ListTags(ctx context.Context, arg ListTagsParams) ([]Tag, error) {
+	rows, err := q.db.QueryContext(ctx, listTags, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -122,8 +291,14 @@ FROM tags t
 LEFT JOIN article_tags at ON t.id = at.tag_id
 GROUP BY t.id
 ORDER BY count DESC, t.name
+LIMIT ? OFFSET ?
 `
 
+type ListTagsWithCountParams struct {
+	Limit  int64 `json:"limit"`
+	Offset int64 `json:"offset"`
+}
+
 type ListTagsWithCountRow struct {
 	ID        int64     `json:"id"`
 	Name      string    `json:"name"`
@@ -132,8 +307,8 @@ type ListTagsWithCountRow struct {
 	Count     int64     `json:"count"`
 }
 
-func (q *Queries) ListTagsWithCount(ctx context.Context) ([]ListTagsWithCountRow, error) {
-	rows, err := q.db.QueryContext(ctx, listTagsWithCount)
+func (q *Queries) ListTagsWithCount(ctx context.Context, arg ListTagsWithCountParams) ([]ListTagsWithCountRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTagsWithCount, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -161,6 +336,58 @@ func (q *Queries) ListTagsWithCount(ctx context.Context) ([]ListTagsWithCountRow
 	return items, nil
 }
 
+const searchTagsByPrefix = `-- name: SearchTagsByPrefix :many
+SELECT t.id, t.name, t.color, t.created_at, COUNT(at.article_id) as count
+FROM tags t
+LEFT JOIN article_tags at ON t.id = at.tag_id
+WHERE t.name LIKE ? ESCAPE '\'
+GROUP BY t.id
+ORDER BY count DESC, t.name
+LIMIT ?
+`
+
+type SearchTagsByPrefixParams struct {
+	Name  string `json:"name"`
+	Limit int64  `json:"limit"`
+}
+
+type SearchTagsByPrefixRow struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Color     *string   `json:"color"`
+	CreatedAt time.Time `json:"created_at"`
+	Count     int64     `json:"count"`
+}
+
+func (q *Queries) SearchTagsByPrefix(ctx context.Context, arg SearchTagsByPrefixParams) ([]SearchTagsByPrefixRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchTagsByPrefix, arg.Name, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchTagsByPrefixRow{}
+	for rows.Next() {
+		var i SearchTagsByPrefixRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Color,
+			&i.CreatedAt,
+			&i.Count,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateTag = `-- name: UpdateTag :exec
 UPDATE tags SET name = ?, color = ? WHERE id = ?
 `
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"time"
 
 	"zbor/internal/storage/sqlc"
@@ -109,11 +110,172 @@ func (r *TagRepository) Delete(ctx context.Context, id int64) error {
 }
 
 // List はタグ一覧を取得
-func (r *TagRepository) List(ctx context.Context) ([]sqlc.Tag, error) {
-	return r.db.Queries.ListTags(ctx)
+func (r *TagRepository) List(ctx context.Context, limit, offset int) ([]sqlc.Tag, error) {
+	if limit == 0 {
+		limit = 100
+	}
+	return r.db.Queries.ListTags(ctx, sqlc.ListTagsParams{
+		Limit:  int64(limit),
+		Offset: int64(offset),
+	})
 }
 
 // ListWithCount は記事数付きでタグ一覧を取得
-func (r *TagRepository) ListWithCount(ctx context.Context) ([]sqlc.ListTagsWithCountRow, error) {
-	return r.db.Queries.ListTagsWithCount(ctx)
+func (r *TagRepository) ListWithCount(ctx context.Context, limit, offset int) ([]sqlc.ListTagsWithCountRow, error) {
+	if limit == 0 {
+		limit = 100
+	}
+	return r.db.Queries.ListTagsWithCount(ctx, sqlc.ListTagsWithCountParams{
+		Limit:  int64(limit),
+		Offset: int64(offset),
+	})
+}
+
+// Count はタグ数を取得
+func (r *TagRepository) Count(ctx context.Context) (int64, error) {
+	return r.db.Queries.CountTags(ctx)
+}
+
+// SearchByPrefix は名前が prefix で始まるタグを記事数の多い順に取得（入力中のタグ候補用）
+func (r *TagRepository) SearchByPrefix(ctx context.Context, prefix string, limit int) ([]sqlc.SearchTagsByPrefixRow, error) {
+	if limit == 0 {
+		limit = 10
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+	return r.db.Queries.SearchTagsByPrefix(ctx, sqlc.SearchTagsByPrefixParams{
+		Name:  escaped + "%",
+		Limit: int64(limit),
+	})
+}
+
+// タグ提案のステータス
+const (
+	TagSuggestionPending  = "pending"
+	TagSuggestionAccepted = "accepted"
+	TagSuggestionRejected = "rejected"
+)
+
+// CreateSuggestion はタグの提案を保存し、既存タグの提案は記事に付与する
+// 同じ記事・名前の提案が既にある場合（却下済みを含む）は何もせず false を返す
+func (r *TagRepository) CreateSuggestion(ctx context.Context, s *sqlc.TagSuggestion) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	qtx := r.db.Queries.WithTx(tx)
+	n, err := qtx.CreateTagSuggestion(ctx, sqlc.CreateTagSuggestionParams{
+		ArticleID: s.ArticleID,
+		Name:      s.Name,
+		TagID:     s.TagID,
+		Score:     s.Score,
+		Method:    s.Method,
+		CreatedAt: time.Now(),
+	})
+	if err != nil || n == 0 {
+		return false, err
+	}
+	if s.TagID != nil {
+		if err := qtx.AddArticleTag(ctx, sqlc.AddArticleTagParams{ArticleID: &s.ArticleID, TagID: s.TagID}); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// GetSuggestion はIDでタグの提案を取得
+func (r *TagRepository) GetSuggestion(ctx context.Context, id int64) (*sqlc.TagSuggestion, error) {
+	s, err := r.db.Queries.GetTagSuggestion(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListSuggestions は記事のタグの提案をスコアの高い順に取得
+func (r *TagRepository) ListSuggestions(ctx context.Context, articleID string) ([]sqlc.TagSuggestion, error) {
+	return r.db.Queries.ListTagSuggestionsByArticle(ctx, articleID)
+}
+
+// AcceptSuggestion はタグの提案を承認する
+// 新しいタグの候補はタグを作成して記事に付与する
+func (r *TagRepository) AcceptSuggestion(ctx context.Context, s *sqlc.TagSuggestion) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := r.db.Queries.WithTx(tx)
+	tagID := s.TagID
+	if tagID == nil {
+		tag, err := qtx.GetTagByName(ctx, s.Name)
+		if err == sql.ErrNoRows {
+			tag, err = qtx.CreateTag(ctx, sqlc.CreateTagParams{Name: s.Name, CreatedAt: time.Now()})
+		}
+		if err != nil {
+			return err
+		}
+		tagID = &tag.ID
+	}
+	if err := qtx.AddArticleTag(ctx, sqlc.AddArticleTagParams{ArticleID: &s.ArticleID, TagID: tagID}); err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := qtx.DecideTagSuggestion(ctx, sqlc.DecideTagSuggestionParams{
+		Status:    TagSuggestionAccepted,
+		TagID:     tagID,
+		DecidedAt: &now,
+		ID:        s.ID,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.Status, s.TagID, s.DecidedAt = TagSuggestionAccepted, tagID, &now
+	return nil
+}
+
+// RejectSuggestion はタグの提案を却下し、付与済みのタグを記事から外す
+// 却下した提案は自動タグ付けで再び提案されない
+func (r *TagRepository) RejectSuggestion(ctx context.Context, s *sqlc.TagSuggestion) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := r.db.Queries.WithTx(tx)
+	if s.TagID != nil {
+		if err := qtx.RemoveArticleTag(ctx, sqlc.RemoveArticleTagParams{ArticleID: &s.ArticleID, TagID: s.TagID}); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	if err := qtx.DecideTagSuggestion(ctx, sqlc.DecideTagSuggestionParams{
+		Status:    TagSuggestionRejected,
+		TagID:     s.TagID,
+		DecidedAt: &now,
+		ID:        s.ID,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.Status, s.DecidedAt = TagSuggestionRejected, &now
+	return nil
+}
+
+// ListArticlesForAutoTag は since 以降に作成され、まだ自動タグ付けしていない記事のIDを古い順に取得
+func (r *TagRepository) ListArticlesForAutoTag(ctx context.Context, since time.Time, limit int) ([]string, error) {
+	return r.db.Queries.ListArticlesForAutoTag(ctx, sqlc.ListArticlesForAutoTagParams{
+		CreatedAt: since,
+		Limit:     int64(limit),
+	})
 }
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// TranscriptRepository はトランスクリプト検索用セグメントのデータアクセス層
+// セグメントは文字起こし成果物から生成するため、成果物を更新したら Replace で作り直す
+type TranscriptRepository struct {
+	db *DB
+}
+
+// NewTranscriptRepository は新しいTranscriptRepositoryを作成
+func NewTranscriptRepository(db *DB) *TranscriptRepository {
+	return &TranscriptRepository{db: db}
+}
+
+// Replace はソースのセグメントを置き換える（FTSインデックスも更新）
+func (r *TranscriptRepository) Replace(ctx context.Context, sourceID string, segments []sqlc.TranscriptSegment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := r.db.Queries.WithTx(tx)
+	if err := deleteTranscriptSegments(ctx, qtx, sourceID); err != nil {
+		return err
+	}
+
+	for _, seg := range segments {
+		id, err := qtx.CreateTranscriptSegment(ctx, sqlc.CreateTranscriptSegmentParams{
+			SourceID:     sourceID,
+			SegmentIndex: seg.SegmentIndex,
+			StartTime:    seg.StartTime,
+			EndTime:      seg.EndTime,
+			Speaker:      seg.Speaker,
+			Text:         seg.Text,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert segment: %w", err)
+		}
+		err = qtx.InsertTranscriptSegmentFTS(ctx, sqlc.InsertTranscriptSegmentFTSParams{
+			Rowid: id,
+			Text:  seg.Text,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert FTS: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteBySourceID はソースのセグメントを削除
+func (r *TranscriptRepository) DeleteBySourceID(ctx context.Context, sourceID string) error {
+	return deleteTranscriptSegments(ctx, r.db.Queries, sourceID)
+}
+
+// ListBySourceID はソースのセグメントを順に取得
+func (r *TranscriptRepository) ListBySourceID(ctx context.Context, sourceID string) ([]sqlc.TranscriptSegment, error) {
+	return r.db.Queries.ListTranscriptSegmentsBySourceID(ctx, sourceID)
+}
+
+// IsIndexed はソースのセグメントが登録済みかを返す
+func (r *TranscriptRepository) IsIndexed(ctx context.Context, sourceID string) (bool, error) {
+	n, err := r.db.Queries.CountTranscriptSegmentsBySourceID(ctx, sourceID)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Search はフレーズを含むセグメントを検索（コンテキストの利用者のソースのみ）
+func (r *TranscriptRepository) Search(ctx context.Context, query string, limit, offset int) ([]sqlc.TranscriptSegment, error) {
+	if limit == 0 {
+		limit = 20
+	}
+
+	// 3文字未満はLIKEで検索
+	if utf8.RuneCountInString(query) < 3 {
+		return r.db.Queries.SearchTranscriptSegmentsLike(ctx, sqlc.SearchTranscriptSegmentsLikeParams{
+			Text:    "%" + query + "%",
+			OwnerID: ownerFilter(ctx),
+			Limit:   int64(limit),
+			Offset:  int64(offset),
+		})
+	}
+
+	// FTS5で検索（sqlcではなく手動で実行）
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT s.id, s.source_id, s.segment_index, s.start_time, s.end_time, s.speaker, s.text
+		FROM transcript_segments s
+		JOIN transcript_segments_fts f ON s.id = f.rowid
+		WHERE transcript_segments_fts MATCH ?
+		  AND s.source_id IN (SELECT id FROM sources WHERE owner_id IS COALESCE(?, owner_id))
+		ORDER BY rank
+		LIMIT ? OFFSET ?`, ftsPhrase(query), ownerFilter(ctx), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	segments := []sqlc.TranscriptSegment{}
+	for rows.Next() {
+		var s sqlc.TranscriptSegment
+		if err := rows.Scan(&s.ID, &s.SourceID, &s.SegmentIndex, &s.StartTime, &s.EndTime, &s.Speaker, &s.Text); err != nil {
+			return nil, err
+		}
+		segments = append(segments, s)
+	}
+
+	return segments, rows.Err()
+}
+
+// CountSearch は Search に一致するセグメント数を取得
+func (r *TranscriptRepository) CountSearch(ctx context.Context, query string) (int64, error) {
+	if utf8.RuneCountInString(query) < 3 {
+		return r.db.Queries.CountTranscriptSegmentsLike(ctx, sqlc.CountTranscriptSegmentsLikeParams{
+			Text:    "%" + query + "%",
+			OwnerID: ownerFilter(ctx),
+		})
+	}
+
+	var count int64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM transcript_segments s
+		JOIN transcript_segments_fts f ON s.id = f.rowid
+		WHERE transcript_segments_fts MATCH ?
+		  AND s.source_id IN (SELECT id FROM sources WHERE owner_id IS COALESCE(?, owner_id))`, ftsPhrase(query), ownerFilter(ctx)).Scan(&count)
+	return count, err
+}
+
+// ftsPhrase はクエリをFTS5のフレーズにする（語順どおりの部分一致、演算子は使えない）
+func ftsPhrase(query string) string {
+	return `"` + strings.ReplaceAll(query, `"`, `""`) + `"`
+}
+
+// deleteTranscriptSegments はソースのセグメントとFTSインデックスを削除
+func deleteTranscriptSegments(ctx context.Context, q *sqlc.Queries, sourceID string) error {
+	if err := q.DeleteTranscriptSegmentFTSBySourceID(ctx, sourceID); err != nil {
+		return err
+	}
+	return q.DeleteTranscriptSegmentsBySourceID(ctx, sourceID)
+}
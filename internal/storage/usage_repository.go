@@ -0,0 +1,289 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// 利用量の上限の対象
+const (
+	UsageScopeUser   = "user"    // ユーザーごと（subject はユーザー名）
+	UsageScopeAPIKey = "api_key" // APIキーごと（subject はキーの名前）
+	UsageScopeModel  = "model"   // ASRモデルごと（subject はモデル名、全利用者の合計）
+)
+
+// UsageQuotaDefault は個別の上限の無いすべてのユーザー・APIキーに適用する上限の subject
+const UsageQuotaDefault = "*"
+
+// ErrQuotaExceeded は今月の文字起こしの上限に達している場合のエラー
+var ErrQuotaExceeded = errors.New("monthly transcription quota exceeded")
+
+// ValidUsageScope は上限の対象の名前が正しいかを返す
+func ValidUsageScope(scope string) bool {
+	switch scope {
+	case UsageScopeUser, UsageScopeAPIKey, UsageScopeModel:
+		return true
+	}
+	return false
+}
+
+// MonthStart は t を含む月の初め（ローカル時刻）を返す
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+}
+
+// UsageRepository は文字起こしの利用量と月ごとの上限のデータアクセス層
+type UsageRepository struct {
+	db *DB
+}
+
+// NewUsageRepository は新しいUsageRepositoryを作成
+func NewUsageRepository(db *DB) *UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+// UsageTotal は利用者・APIキー・モデルごとの合計
+type UsageTotal struct {
+	Name    string  `json:"name"` // ユーザー名・キーの名前・モデル名（無い場合は空）
+	Jobs    int64   `json:"jobs"`
+	Minutes float64 `json:"minutes"`
+}
+
+// UsageReport は1か月の利用量
+type UsageReport struct {
+	Month    string                     `json:"month"` // YYYY-MM
+	Jobs     int64                      `json:"jobs"`
+	Minutes  float64                    `json:"minutes"`
+	ByUser   []UsageTotal               `json:"by_user"`
+	ByAPIKey []UsageTotal               `json:"by_api_key"`
+	ByModel  []UsageTotal               `json:"by_model"`
+	Rows     []sqlc.ListUsageSummaryRow `json:"rows"` // ユーザー×キー×モデルの内訳（seconds は秒）
+}
+
+// UsageQuotaStatus は上限と今月の利用量
+type UsageQuotaStatus struct {
+	Scope          string    `json:"scope"`
+	Subject        string    `json:"subject"`
+	MonthlyMinutes float64   `json:"monthly_minutes"`
+	UsedMinutes    *float64  `json:"used_minutes,omitempty"` // 既定の上限（*）では対象ごとに異なるため省略
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Record は完了した文字起こしジョブの利用量（音声の長さ）を記録する
+func (r *UsageRepository) Record(ctx context.Context, job *sqlc.ProcessingJob, model string, seconds float64) error {
+	return r.db.Queries.CreateUsageRecord(ctx, sqlc.CreateUsageRecordParams{
+		JobID:     job.ID,
+		SourceID:  job.SourceID,
+		OwnerID:   job.OwnerID,
+		ApiKey:    job.ApiKey,
+		Model:     model,
+		Seconds:   seconds,
+		CreatedAt: time.Now(),
+	})
+}
+
+// CheckQuota はコンテキストの利用者・APIキーと model の今月の利用量が上限に達していれば
+// ErrQuotaExceeded を返す（上限の無い対象は確認しない）
+func (r *UsageRepository) CheckQuota(ctx context.Context, model string) error {
+	since := MonthStart(time.Now())
+
+	type check struct {
+		scope, subject string
+		filter         sqlc.SumUsageSinceParams
+	}
+	var checks []check
+	if ownerID := OwnerFromContext(ctx); ownerID != "" {
+		user, err := r.db.Queries.GetUserByID(ctx, ownerID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil {
+			checks = append(checks, check{UsageScopeUser, user.Username, sqlc.SumUsageSinceParams{OwnerID: &ownerID}})
+		}
+	}
+	if key := APIKeyFromContext(ctx); key != "" {
+		checks = append(checks, check{UsageScopeAPIKey, key, sqlc.SumUsageSinceParams{ApiKey: &key}})
+	}
+	if model != "" {
+		checks = append(checks, check{UsageScopeModel, model, sqlc.SumUsageSinceParams{Model: &model}})
+	}
+
+	for _, c := range checks {
+		quota, err := r.quotaFor(ctx, c.scope, c.subject)
+		if err != nil {
+			return err
+		}
+		if quota == nil {
+			continue
+		}
+		c.filter.Since = since
+		seconds, err := r.db.Queries.SumUsageSince(ctx, c.filter)
+		if err != nil {
+			return err
+		}
+		if used := seconds / 60; used >= quota.MonthlyMinutes {
+			return fmt.Errorf("%w: %s %q has used %.1f of %.0f minutes this month (resets %s)",
+				ErrQuotaExceeded, c.scope, c.subject, used, quota.MonthlyMinutes,
+				since.AddDate(0, 1, 0).Format("2006-01-02"))
+		}
+	}
+	return nil
+}
+
+// quotaFor は対象の上限を返す（ユーザー・APIキーは個別の上限が無ければ既定の上限、どちらも無ければ nil）
+func (r *UsageRepository) quotaFor(ctx context.Context, scope, subject string) (*sqlc.UsageQuota, error) {
+	subjects := []string{subject}
+	if scope != UsageScopeModel {
+		subjects = append(subjects, UsageQuotaDefault)
+	}
+	for _, s := range subjects {
+		quota, err := r.db.Queries.GetUsageQuota(ctx, sqlc.GetUsageQuotaParams{Scope: scope, Subject: s})
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &quota, nil
+	}
+	return nil, nil
+}
+
+// Report は month を含む月の利用量を集計する（コンテキストの利用者の分のみ）
+func (r *UsageRepository) Report(ctx context.Context, month time.Time) (*UsageReport, error) {
+	since := MonthStart(month)
+	rows, err := r.db.Queries.ListUsageSummary(ctx, sqlc.ListUsageSummaryParams{
+		Since:   since,
+		Until:   since.AddDate(0, 1, 0),
+		OwnerID: ownerFilter(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UsageReport{Month: since.Format("2006-01"), Rows: rows}
+	byUser := map[string]*UsageTotal{}
+	byKey := map[string]*UsageTotal{}
+	byModel := map[string]*UsageTotal{}
+	for _, row := range rows {
+		minutes := row.Seconds / 60
+		report.Jobs += row.Jobs
+		report.Minutes += minutes
+		addUsage(byUser, deref(row.Username), row.Jobs, minutes)
+		addUsage(byKey, deref(row.ApiKey), row.Jobs, minutes)
+		addUsage(byModel, row.Model, row.Jobs, minutes)
+	}
+	report.ByUser = sortedUsage(byUser)
+	report.ByAPIKey = sortedUsage(byKey)
+	report.ByModel = sortedUsage(byModel)
+	return report, nil
+}
+
+// Quotas は上限の一覧を今月の利用量とともに返す
+func (r *UsageRepository) Quotas(ctx context.Context) ([]UsageQuotaStatus, error) {
+	quotas, err := r.db.Queries.ListUsageQuotas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	since := MonthStart(time.Now())
+	statuses := make([]UsageQuotaStatus, 0, len(quotas))
+	for _, q := range quotas {
+		status := UsageQuotaStatus{
+			Scope:          q.Scope,
+			Subject:        q.Subject,
+			MonthlyMinutes: q.MonthlyMinutes,
+			UpdatedAt:      q.UpdatedAt,
+		}
+		if q.Subject != UsageQuotaDefault {
+			filter := sqlc.SumUsageSinceParams{Since: since}
+			switch q.Scope {
+			case UsageScopeUser:
+				user, err := r.db.Queries.GetUserByUsername(ctx, q.Subject)
+				if err != nil && err != sql.ErrNoRows {
+					return nil, err
+				}
+				// 存在しないユーザーの利用量は 0
+				id := user.ID
+				filter.OwnerID = &id
+			case UsageScopeAPIKey:
+				filter.ApiKey = &q.Subject
+			case UsageScopeModel:
+				filter.Model = &q.Subject
+			}
+			seconds, err := r.db.Queries.SumUsageSince(ctx, filter)
+			if err != nil {
+				return nil, err
+			}
+			used := seconds / 60
+			status.UsedMinutes = &used
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// SetQuota は対象の月ごとの上限（分）を設定する
+func (r *UsageRepository) SetQuota(ctx context.Context, scope, subject string, monthlyMinutes float64) error {
+	if !ValidUsageScope(scope) {
+		return fmt.Errorf("invalid scope: %s (user, api_key or model)", scope)
+	}
+	if subject == "" || (scope == UsageScopeModel && subject == UsageQuotaDefault) {
+		return fmt.Errorf("invalid subject for %s quota: %q", scope, subject)
+	}
+	if monthlyMinutes < 0 {
+		return fmt.Errorf("monthly_minutes must not be negative")
+	}
+	return r.db.Queries.UpsertUsageQuota(ctx, sqlc.UpsertUsageQuotaParams{
+		Scope:          scope,
+		Subject:        subject,
+		MonthlyMinutes: monthlyMinutes,
+		UpdatedAt:      time.Now(),
+	})
+}
+
+// DeleteQuota は上限を削除する（無い場合は false）
+func (r *UsageRepository) DeleteQuota(ctx context.Context, scope, subject string) (bool, error) {
+	n, err := r.db.Queries.DeleteUsageQuota(ctx, sqlc.DeleteUsageQuotaParams{Scope: scope, Subject: subject})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func addUsage(totals map[string]*UsageTotal, name string, jobs int64, minutes float64) {
+	t, ok := totals[name]
+	if !ok {
+		t = &UsageTotal{Name: name}
+		totals[name] = t
+	}
+	t.Jobs += jobs
+	t.Minutes += minutes
+}
+
+// sortedUsage は合計を利用量の多い順に並べる
+func sortedUsage(totals map[string]*UsageTotal) []UsageTotal {
+	list := make([]UsageTotal, 0, len(totals))
+	for _, t := range totals {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Minutes != list[j].Minutes {
+			return list[i].Minutes > list[j].Minutes
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
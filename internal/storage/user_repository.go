@@ -0,0 +1,330 @@
+package storage
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"zbor/internal/storage/sqlc"
+)
+
+// SessionTTL はログインセッションの有効期間
+const SessionTTL = 30 * 24 * time.Hour
+
+// パスワードのハッシュ（PBKDF2-SHA256）のパラメータ
+const (
+	passwordIterations = 600000
+	passwordSaltBytes  = 16
+	passwordKeyBytes   = 32
+	passwordScheme     = "pbkdf2-sha256"
+)
+
+// MinPasswordLength はパスワードの最小文字数
+const MinPasswordLength = 8
+
+// ErrUserExists は同じユーザー名のユーザーが既にいる場合のエラー
+var ErrUserExists = errors.New("user already exists")
+
+// ownerContextKey はコンテキストに利用者を保存するキー
+type ownerContextKey struct{}
+
+// owner はリクエストの利用者
+type owner struct {
+	id  string
+	all bool // 全ユーザーのデータを参照できる（管理者）
+}
+
+// WithOwner は利用者 userID のコンテキストを返す
+// 作成したソース・記事・ジョブは userID のものになり、all が false の場合は取得・一覧・検索も
+// userID のデータに限られる。コンテキストに利用者が無い場合（ユーザー未作成、ユーザーに紐づかない
+// APIキー、バックグラウンド処理、CLI）は制限しない
+func WithOwner(ctx context.Context, userID string, all bool) context.Context {
+	return context.WithValue(ctx, ownerContextKey{}, owner{id: userID, all: all})
+}
+
+// OwnerFromContext はコンテキストの利用者のIDを返す（無い場合は空）
+func OwnerFromContext(ctx context.Context) string {
+	o, _ := ctx.Value(ownerContextKey{}).(owner)
+	return o.id
+}
+
+// ownerFilter は一覧・検索を絞り込む所有者のID（制限しない場合は nil）
+func ownerFilter(ctx context.Context) *string {
+	o, ok := ctx.Value(ownerContextKey{}).(owner)
+	if !ok || o.all {
+		return nil
+	}
+	return &o.id
+}
+
+// ownerAllows は所有者が ownerID のデータをコンテキストの利用者が参照できるかを返す
+func ownerAllows(ctx context.Context, ownerID *string) bool {
+	filter := ownerFilter(ctx)
+	return filter == nil || (ownerID != nil && *ownerID == *filter)
+}
+
+// ownerForNew は新しく作成するデータの所有者を返す
+// current（指定済みの所有者）が無ければコンテキストの利用者
+func ownerForNew(ctx context.Context, current *string) *string {
+	if current != nil {
+		return current
+	}
+	if id := OwnerFromContext(ctx); id != "" {
+		return &id
+	}
+	return nil
+}
+
+// HashPassword はパスワードを PBKDF2-SHA256 でハッシュ化する
+// 形式は pbkdf2-sha256$<反復回数>$<ソルト>$<ハッシュ>（ソルトとハッシュは base64）
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeyBytes)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{
+		passwordScheme,
+		strconv.Itoa(passwordIterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+// checkPassword はパスワードがハッシュと一致するかを返す
+func checkPassword(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != passwordScheme {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// validUsername はユーザー名が使えるかを返す（1〜64文字、空白・制御文字を含まない）
+func validUsername(username string) bool {
+	if username == "" || len([]rune(username)) > 64 {
+		return false
+	}
+	for _, r := range username {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// sessionID はセッショントークンから保存用のID（SHA-256、16進）を作成
+func sessionID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// UserRepository はユーザーとログインセッションのデータアクセス層
+type UserRepository struct {
+	db *DB
+}
+
+// NewUserRepository は新しいUserRepositoryを作成
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Create はユーザーを作成
+// 最初のユーザーは管理者になり、それまでのソース・記事・ジョブはそのユーザーのものになる
+func (r *UserRepository) Create(ctx context.Context, username, password string, admin bool) (*sqlc.User, error) {
+	username = strings.TrimSpace(username)
+	if !validUsername(username) {
+		return nil, fmt.Errorf("invalid username: %q", username)
+	}
+	if len([]rune(password)) < MinPasswordLength {
+		return nil, fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := r.db.Queries.WithTx(tx)
+
+	if _, err := qtx.GetUserByUsername(ctx, username); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUserExists, username)
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+	n, err := qtx.CountUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	first := n == 0
+
+	user := &sqlc.User{
+		ID:           uuid.New().String(),
+		Username:     username,
+		PasswordHash: hash,
+		CreatedAt:    time.Now(),
+	}
+	if admin || first {
+		user.IsAdmin = 1
+	}
+	err = qtx.CreateUser(ctx, sqlc.CreateUserParams{
+		ID:           user.ID,
+		Username:     user.Username,
+		PasswordHash: user.PasswordHash,
+		IsAdmin:      user.IsAdmin,
+		CreatedAt:    user.CreatedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if first {
+		if err := qtx.AssignUnownedSources(ctx, &user.ID); err != nil {
+			return nil, fmt.Errorf("failed to assign sources: %w", err)
+		}
+		if err := qtx.AssignUnownedArticles(ctx, &user.ID); err != nil {
+			return nil, fmt.Errorf("failed to assign articles: %w", err)
+		}
+		if err := qtx.AssignUnownedJobs(ctx, &user.ID); err != nil {
+			return nil, fmt.Errorf("failed to assign jobs: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if first {
+		// 記事の所有者が変わるため、一覧・検索のキャッシュを破棄
+		r.db.articles.invalidate()
+	}
+	return user, nil
+}
+
+// Authenticate はユーザー名とパスワードが一致するユーザーを返す（一致しない場合は nil）
+func (r *UserRepository) Authenticate(ctx context.Context, username, password string) (*sqlc.User, error) {
+	user, err := r.db.Queries.GetUserByUsername(ctx, strings.TrimSpace(username))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !checkPassword(user.PasswordHash, password) {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+// GetByID はIDでユーザーを取得
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*sqlc.User, error) {
+	user, err := r.db.Queries.GetUserByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// List はユーザーを作成順に取得（パスワードのハッシュは含まない）
+func (r *UserRepository) List(ctx context.Context) ([]sqlc.ListUsersRow, error) {
+	return r.db.Queries.ListUsers(ctx)
+}
+
+// Count はユーザー数を返す（0 ならログイン不要・データの分離なし）
+func (r *UserRepository) Count(ctx context.Context) (int64, error) {
+	return r.db.Queries.CountUsers(ctx)
+}
+
+// Delete はユーザーとそのセッションを削除する。存在しない場合は false
+// ユーザーのデータは削除せず、管理者だけが参照できる
+func (r *UserRepository) Delete(ctx context.Context, id string) (bool, error) {
+	n, err := r.db.Queries.DeleteUser(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// CreateSession はログインセッションを作成し、トークンと有効期限を返す
+// トークンは保存しないため、この戻り値でしか取得できない。期限切れのセッションもここで削除する
+func (r *UserRepository) CreateSession(ctx context.Context, userID string) (string, time.Time, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(buf)
+
+	now := time.Now()
+	if _, err := r.db.Queries.DeleteExpiredSessions(ctx, now); err != nil {
+		return "", time.Time{}, err
+	}
+	expires := now.Add(SessionTTL)
+	err := r.db.Queries.CreateSession(ctx, sqlc.CreateSessionParams{
+		ID:        sessionID(token),
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: expires,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expires, nil
+}
+
+// SessionUser はセッショントークンのユーザーを返す（無効・期限切れの場合は nil）
+func (r *UserRepository) SessionUser(ctx context.Context, token string) (*sqlc.User, error) {
+	user, err := r.db.Queries.GetSessionUser(ctx, sqlc.GetSessionUserParams{
+		ID:        sessionID(token),
+		ExpiresAt: time.Now(),
+	})
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DeleteSession はセッションを削除（ログアウト）
+func (r *UserRepository) DeleteSession(ctx context.Context, token string) error {
+	return r.db.Queries.DeleteSession(ctx, sessionID(token))
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// Webhook の配信の状態
+const (
+	WebhookDeliveryPending   = "pending"   // 送信待ち・再試行待ち
+	WebhookDeliveryDelivered = "delivered" // 2xx の応答があった
+	WebhookDeliveryFailed    = "failed"    // 再試行の上限に達した
+)
+
+// WebhookRepository は Webhook と配信のデータアクセス層
+type WebhookRepository struct {
+	db *DB
+}
+
+// NewWebhookRepository は新しいWebhookRepositoryを作成
+func NewWebhookRepository(db *DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// Create は Webhook を作成（所有者はコンテキストの利用者）
+func (r *WebhookRepository) Create(ctx context.Context, webhook *sqlc.Webhook) error {
+	now := time.Now()
+	created, err := r.db.Queries.CreateWebhook(ctx, sqlc.CreateWebhookParams{
+		OwnerID:     ownerForNew(ctx, webhook.OwnerID),
+		Url:         webhook.Url,
+		Secret:      webhook.Secret,
+		Events:      webhook.Events,
+		Enabled:     webhook.Enabled,
+		Description: webhook.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	if err != nil {
+		return err
+	}
+	*webhook = created
+	return nil
+}
+
+// Get は Webhook を取得（該当なし・他のユーザーの Webhook は nil）
+func (r *WebhookRepository) Get(ctx context.Context, id int64) (*sqlc.Webhook, error) {
+	webhook, err := r.db.Queries.GetWebhook(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ownerAllows(ctx, webhook.OwnerID) {
+		return nil, nil
+	}
+	return &webhook, nil
+}
+
+// List は利用者の Webhook を作成順に取得
+func (r *WebhookRepository) List(ctx context.Context) ([]sqlc.Webhook, error) {
+	return r.db.Queries.ListWebhooks(ctx, ownerFilter(ctx))
+}
+
+// ListForOwner は所有者が ownerID のデータのイベントを送る有効な Webhook を取得
+func (r *WebhookRepository) ListForOwner(ctx context.Context, ownerID *string) ([]sqlc.Webhook, error) {
+	return r.db.Queries.ListEnabledWebhooksByOwner(ctx, ownerID)
+}
+
+// Update は Webhook の送信先・鍵・イベント・有効/無効を更新（該当なしの場合は false）
+func (r *WebhookRepository) Update(ctx context.Context, webhook *sqlc.Webhook) (bool, error) {
+	webhook.UpdatedAt = time.Now()
+	n, err := r.db.Queries.UpdateWebhook(ctx, sqlc.UpdateWebhookParams{
+		Url:         webhook.Url,
+		Secret:      webhook.Secret,
+		Events:      webhook.Events,
+		Enabled:     webhook.Enabled,
+		Description: webhook.Description,
+		UpdatedAt:   webhook.UpdatedAt,
+		ID:          webhook.ID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Delete は Webhook と配信の記録を削除（該当なしの場合は false）
+func (r *WebhookRepository) Delete(ctx context.Context, id int64) (bool, error) {
+	n, err := r.db.Queries.DeleteWebhook(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Enqueue は配信を作成（すぐに送信する）
+func (r *WebhookRepository) Enqueue(ctx context.Context, webhookID int64, event, payload string) (int64, error) {
+	now := time.Now()
+	return r.db.Queries.CreateWebhookDelivery(ctx, sqlc.CreateWebhookDeliveryParams{
+		WebhookID:     webhookID,
+		Event:         event,
+		Payload:       payload,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+}
+
+// ListDue は送信時刻になった配信を古い順に最大 limit 件取得
+func (r *WebhookRepository) ListDue(ctx context.Context, limit int) ([]sqlc.ListDueWebhookDeliveriesRow, error) {
+	return r.db.Queries.ListDueWebhookDeliveries(ctx, sqlc.ListDueWebhookDeliveriesParams{
+		NextAttemptAt: time.Now(),
+		Limit:         int64(limit),
+	})
+}
+
+// RecordAttempt は配信の試行の結果を記録する
+// status が WebhookDeliveryPending なら next に再試行する。responseStatus は接続できなければ nil
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, id, attempts int64, status string, next time.Time, responseStatus *int64, lastError *string) error {
+	var deliveredAt *time.Time
+	if status == WebhookDeliveryDelivered {
+		now := time.Now()
+		deliveredAt = &now
+	}
+	return r.db.Queries.UpdateWebhookDeliveryResult(ctx, sqlc.UpdateWebhookDeliveryResultParams{
+		Status:         status,
+		Attempts:       attempts,
+		NextAttemptAt:  next,
+		ResponseStatus: responseStatus,
+		LastError:      lastError,
+		DeliveredAt:    deliveredAt,
+		ID:             id,
+	})
+}
+
+// ListDeliveries は Webhook の配信を新しい順に最大 limit 件取得
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID int64, limit int) ([]sqlc.WebhookDelivery, error) {
+	return r.db.Queries.ListWebhookDeliveries(ctx, sqlc.ListWebhookDeliveriesParams{
+		WebhookID: webhookID,
+		Limit:     int64(limit),
+	})
+}
+
+// Redeliver は配信をすぐに送り直す（試行回数はそのまま。該当なしの場合は false）
+func (r *WebhookRepository) Redeliver(ctx context.Context, webhookID, id int64) (bool, error) {
+	n, err := r.db.Queries.RedeliverWebhookDelivery(ctx, sqlc.RedeliverWebhookDeliveryParams{
+		NextAttemptAt: time.Now(),
+		ID:            id,
+		WebhookID:     webhookID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// DeleteFinishedDeliveries は before より前に作成した送信済み・失敗の配信を削除し、削除した件数を返す
+func (r *WebhookRepository) DeleteFinishedDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	return r.db.Queries.DeleteFinishedWebhookDeliveries(ctx, before)
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"zbor/internal/storage/sqlc"
+)
+
+// WorkerRepository はリモートワーカーのデータアクセス層
+type WorkerRepository struct {
+	db *DB
+}
+
+// NewWorkerRepository は新しいWorkerRepositoryを作成
+func NewWorkerRepository(db *DB) *WorkerRepository {
+	return &WorkerRepository{db: db}
+}
+
+// Register はワーカーを登録（登録済みの場合は情報を更新）
+func (r *WorkerRepository) Register(ctx context.Context, w *sqlc.Worker) error {
+	now := time.Now()
+	w.RegisteredAt = now
+	w.LastSeenAt = now
+	return r.db.Queries.UpsertWorker(ctx, sqlc.UpsertWorkerParams{
+		ID:           w.ID,
+		Name:         w.Name,
+		Models:       w.Models,
+		Capacity:     w.Capacity,
+		Version:      w.Version,
+		RegisteredAt: w.RegisteredAt,
+		LastSeenAt:   w.LastSeenAt,
+	})
+}
+
+// GetByID はIDでワーカーを取得
+func (r *WorkerRepository) GetByID(ctx context.Context, id string) (*sqlc.Worker, error) {
+	w, err := r.db.Queries.GetWorkerByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// List は全ワーカーを最終接続の新しい順に取得
+func (r *WorkerRepository) List(ctx context.Context) ([]sqlc.Worker, error) {
+	return r.db.Queries.ListWorkers(ctx)
+}
+
+// Touch はワーカーの最終接続時刻を更新
+func (r *WorkerRepository) Touch(ctx context.Context, id string) error {
+	return r.db.Queries.TouchWorker(ctx, sqlc.TouchWorkerParams{
+		LastSeenAt: time.Now(),
+		ID:         id,
+	})
+}
+
+// Delete はワーカーを削除
+func (r *WorkerRepository) Delete(ctx context.Context, id string) error {
+	return r.db.Queries.DeleteWorker(ctx, id)
+}
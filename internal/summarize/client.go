@@ -0,0 +1,122 @@
+package summarize
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultModel はモデル未指定時に使うモデル名
+const DefaultModel = "gpt-4o-mini"
+
+// Client はOpenAI互換のChat Completions APIクライアント
+type Client struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewClient は新しいクライアントを作成
+// baseURL は /chat/completions を除いたAPIのベースURL（例: https://api.openai.com/v1）
+func NewClient(baseURL, apiKey, model string) *Client {
+	if model == "" {
+		model = DefaultModel
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// NewClientFromEnv は環境変数からクライアントを作成
+// ZBOR_LLM_URL が未設定の場合は nil を返す（要約は無効）
+func NewClientFromEnv() *Client {
+	baseURL := os.Getenv("ZBOR_LLM_URL")
+	if baseURL == "" {
+		return nil
+	}
+	return NewClient(baseURL, os.Getenv("ZBOR_LLM_API_KEY"), os.Getenv("ZBOR_LLM_MODEL"))
+}
+
+// Model は使用するモデル名を返す
+func (c *Client) Model() string {
+	return c.model
+}
+
+// Message はチャットメッセージ
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Complete はメッセージを送信して応答テキストを返す
+func (c *Client) Complete(ctx context.Context, messages []Message) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model:       c.model,
+		Messages:    messages,
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("LLM request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read LLM response: %w", err)
+	}
+
+	var result chatResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("invalid LLM response (status %d): %w", resp.StatusCode, err)
+	}
+	if result.Error != nil {
+		return "", fmt.Errorf("LLM error (status %d): %s", resp.StatusCode, result.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("LLM request failed with status %d", resp.StatusCode)
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("LLM returned no choices")
+	}
+
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
@@ -0,0 +1,235 @@
+package summarize
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// maxChunkRunes は1回のリクエストで要約する本文の最大文字数
+// これを超える本文は分割して要約し、最後に要約同士をまとめる
+const maxChunkRunes = 8000
+
+const systemPrompt = `あなたは会議の文字起こしや記事を要約するアシスタントです。
+入力された本文の要点を、本文と同じ言語で簡潔にまとめてください。
+- 冒頭に2〜3文の概要
+- 続けて重要なポイントを箇条書き（決定事項・課題・次のアクションがあれば含める）
+- 本文に無い情報は追加しない`
+
+const mergePrompt = `以下は長い本文を分割して作成した部分要約です。
+重複を除いて1つの要約にまとめてください（冒頭に概要、続けて箇条書き）。`
+
+// ProgressCallback は進捗を報告するコールバック
+type ProgressCallback func(progress int, step string)
+
+// Summarizer は記事の要約ジョブを処理する
+type Summarizer struct {
+	client       *Client
+	articleRepo  *storage.ArticleRepository
+	sourceRepo   *storage.SourceRepository
+	artifactRepo *storage.ArtifactRepository
+	jobRepo      *storage.JobRepository
+}
+
+// NewSummarizer は新しいSummarizerを作成
+// client が nil の場合、要約は無効
+func NewSummarizer(
+	client *Client,
+	articleRepo *storage.ArticleRepository,
+	sourceRepo *storage.SourceRepository,
+	artifactRepo *storage.ArtifactRepository,
+	jobRepo *storage.JobRepository,
+) *Summarizer {
+	return &Summarizer{
+		client:       client,
+		articleRepo:  articleRepo,
+		sourceRepo:   sourceRepo,
+		artifactRepo: artifactRepo,
+		jobRepo:      jobRepo,
+	}
+}
+
+// Enabled はLLMエンドポイントが設定されているかを返す
+func (s *Summarizer) Enabled() bool {
+	return s.client != nil
+}
+
+// CreateJob は記事の要約ジョブを作成
+// ジョブはソース単位のため、ソースを持たない記事には記事用のソースを作成して紐付ける
+func (s *Summarizer) CreateJob(ctx context.Context, articleID string, priority int) (string, error) {
+	article, err := s.articleRepo.GetByID(ctx, articleID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get article: %w", err)
+	}
+	if article == nil {
+		return "", fmt.Errorf("article not found: %s", articleID)
+	}
+	if err := s.articleRepo.CheckHold(ctx, article.ID); err != nil {
+		return "", err
+	}
+
+	if article.SourceID == nil {
+		metadata, _ := json.Marshal(map[string]string{"article_id": article.ID})
+		source := &sqlc.Source{
+			Type:     storage.SourceTypeArticle,
+			Metadata: storage.Ptr(string(metadata)),
+			Status:   storage.Ptr(storage.SourceStatusCompleted),
+			OwnerID:  article.OwnerID,
+		}
+		if err := s.sourceRepo.Create(ctx, source); err != nil {
+			return "", fmt.Errorf("failed to create source: %w", err)
+		}
+		article.SourceID = &source.ID
+		if err := s.articleRepo.Update(ctx, article); err != nil {
+			return "", fmt.Errorf("failed to link article to source: %w", err)
+		}
+	}
+
+	job := &sqlc.ProcessingJob{
+		SourceID: article.SourceID,
+		Type:     storage.JobTypeSummarize,
+		Priority: storage.Ptr(int64(priority)),
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return "", fmt.Errorf("failed to create job: %w", err)
+	}
+	return job.ID, nil
+}
+
+// ProcessJob は要約ジョブを処理する（ワーカーから呼ばれる）
+// ソースに紐づく各記事を要約し、Article.Summary と要約アーティファクトを保存する
+func (s *Summarizer) ProcessJob(ctx context.Context, job *sqlc.ProcessingJob, onProgress ProgressCallback) error {
+	if s.client == nil {
+		return fmt.Errorf("summarization is not configured (set ZBOR_LLM_URL)")
+	}
+	if job.SourceID == nil {
+		return fmt.Errorf("job has no source ID")
+	}
+
+	reportProgress := func(progress int, step string) {
+		if onProgress != nil {
+			onProgress(progress, step)
+		}
+	}
+
+	articles, err := s.articleRepo.GetBySourceID(ctx, *job.SourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get articles: %w", err)
+	}
+	if len(articles) == 0 {
+		return fmt.Errorf("no article for source: %s", *job.SourceID)
+	}
+
+	for idx := range articles {
+		article := &articles[idx]
+		base := 10 + 80*idx/len(articles)
+		span := 80 / len(articles)
+
+		summary, err := s.Summarize(ctx, article.Title, article.Content, func(done, total int) {
+			reportProgress(base+span*done/total, "summarizing")
+		})
+		if err != nil {
+			return fmt.Errorf("failed to summarize article %s: %w", article.ID, err)
+		}
+
+		reportProgress(base+span, "saving")
+
+		metadata, _ := json.Marshal(map[string]string{
+			"article_id": article.ID,
+			"model":      s.client.Model(),
+		})
+		artifact := &sqlc.ProcessingArtifact{
+			SourceID: job.SourceID,
+			Type:     storage.ArtifactTypeSummary,
+			Content:  storage.Ptr(summary),
+			Format:   storage.Ptr("text"),
+			Metadata: storage.Ptr(string(metadata)),
+		}
+		if err := s.artifactRepo.Create(ctx, artifact); err != nil {
+			return fmt.Errorf("failed to save artifact: %w", err)
+		}
+
+		article.Summary = storage.Ptr(summary)
+		if err := s.articleRepo.Update(ctx, article); err != nil {
+			return fmt.Errorf("failed to update article: %w", err)
+		}
+	}
+
+	reportProgress(100, "")
+	return nil
+}
+
+// Summarize は本文を要約する
+// 長い本文は分割して要約した後、部分要約をまとめる（onChunk で進捗を通知）
+func (s *Summarizer) Summarize(ctx context.Context, title, content string, onChunk func(done, total int)) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", fmt.Errorf("article has no content")
+	}
+
+	chunks := SplitText(content, maxChunkRunes)
+	total := len(chunks)
+	if total > 1 {
+		total++ // 最後のまとめ
+	}
+
+	var partials []string
+	for idx, chunk := range chunks {
+		user := chunk
+		if title != "" {
+			user = "タイトル: " + title + "\n\n" + chunk
+		}
+		summary, err := s.client.Complete(ctx, []Message{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: user},
+		})
+		if err != nil {
+			return "", err
+		}
+		partials = append(partials, summary)
+		if onChunk != nil {
+			onChunk(idx+1, total)
+		}
+	}
+
+	if len(partials) == 1 {
+		return partials[0], nil
+	}
+
+	summary, err := s.client.Complete(ctx, []Message{
+		{Role: "system", Content: systemPrompt + "\n\n" + mergePrompt},
+		{Role: "user", Content: strings.Join(partials, "\n\n---\n\n")},
+	})
+	if err != nil {
+		return "", err
+	}
+	if onChunk != nil {
+		onChunk(total, total)
+	}
+	return summary, nil
+}
+
+// SplitText は本文を最大 maxRunes 文字ごとに分割する（可能な限り改行・句点で区切る）
+func SplitText(text string, maxRunes int) []string {
+	runes := []rune(text)
+	var chunks []string
+	for len(runes) > maxRunes {
+		cut := maxRunes
+		for i := maxRunes; i > maxRunes/2; i-- {
+			if runes[i-1] == '\n' || runes[i-1] == '。' {
+				cut = i
+				break
+			}
+		}
+		chunks = append(chunks, strings.TrimSpace(string(runes[:cut])))
+		runes = runes[cut:]
+	}
+	if rest := strings.TrimSpace(string(runes)); rest != "" {
+		chunks = append(chunks, rest)
+	}
+	return chunks
+}
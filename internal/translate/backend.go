@@ -0,0 +1,188 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"zbor/internal/summarize"
+)
+
+// バックエンドの種類
+const (
+	BackendLLM            = "llm"            // OpenAI互換API（ZBOR_LLM_*）
+	BackendLibreTranslate = "libretranslate" // LibreTranslate 互換のローカルNMTサーバー
+)
+
+// Backend はテキストをまとめて翻訳する
+type Backend interface {
+	// Name はアーティファクトに記録するバックエンドとモデルの名前（例: llm:gpt-4o-mini）
+	Name() string
+	// Translate は texts を source から target の言語に翻訳し、同じ順・同じ数で返す
+	Translate(ctx context.Context, texts []string, source, target string) ([]string, error)
+}
+
+// NewBackendFromEnv は環境変数からバックエンドを作成
+// ZBOR_TRANSLATE_BACKEND: llm または libretranslate（未設定時は ZBOR_TRANSLATE_URL があれば libretranslate、なければ llm）
+// ZBOR_TRANSLATE_URL: LibreTranslate のベースURL、ZBOR_TRANSLATE_API_KEY: そのAPIキー
+// どちらも使えない場合は nil を返す（翻訳は無効）
+func NewBackendFromEnv() (Backend, error) {
+	kind := os.Getenv("ZBOR_TRANSLATE_BACKEND")
+	url := os.Getenv("ZBOR_TRANSLATE_URL")
+	if kind == "" {
+		kind = BackendLLM
+		if url != "" {
+			kind = BackendLibreTranslate
+		}
+	}
+
+	switch kind {
+	case BackendLLM:
+		client := summarize.NewClientFromEnv()
+		if client == nil {
+			return nil, nil
+		}
+		return NewLLMBackend(client), nil
+	case BackendLibreTranslate:
+		if url == "" {
+			return nil, fmt.Errorf("ZBOR_TRANSLATE_URL is required for the libretranslate backend")
+		}
+		return NewLibreTranslateBackend(url, os.Getenv("ZBOR_TRANSLATE_API_KEY")), nil
+	default:
+		return nil, fmt.Errorf("unknown translation backend: %s", kind)
+	}
+}
+
+const llmPrompt = `あなたは文字起こしを翻訳するアシスタントです。
+入力は文字起こしのセグメントの JSON の文字列配列です。各要素を %s から %s に翻訳し、
+同じ数・同じ順の JSON の文字列配列だけで答えてください。
+- 要素をまとめたり分けたりしない（字幕のタイミングに使う）
+- 言い淀み・相づちは自然に訳すか、訳せなければ空文字にする
+- 固有名詞・数値はそのまま使う`
+
+// LLMBackend はOpenAI互換APIで翻訳する
+type LLMBackend struct {
+	client *summarize.Client
+}
+
+// NewLLMBackend は新しいLLMBackendを作成
+func NewLLMBackend(client *summarize.Client) *LLMBackend {
+	return &LLMBackend{client: client}
+}
+
+// Name はバックエンドとモデルの名前を返す
+func (b *LLMBackend) Name() string {
+	return BackendLLM + ":" + b.client.Model()
+}
+
+// Translate はセグメントの配列を1回のリクエストで翻訳する
+func (b *LLMBackend) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	input, _ := json.Marshal(texts)
+	answer, err := b.client.Complete(ctx, []summarize.Message{
+		{Role: "system", Content: fmt.Sprintf(llmPrompt, source, target)},
+		{Role: "user", Content: string(input)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := strings.Index(answer, "["), strings.LastIndex(answer, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("LLM did not return a JSON array: %q", answer)
+	}
+	var translated []string
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &translated); err != nil {
+		return nil, fmt.Errorf("invalid LLM answer %q: %w", answer, err)
+	}
+	if len(translated) != len(texts) {
+		return nil, fmt.Errorf("%w: got %d, want %d", ErrCountMismatch, len(translated), len(texts))
+	}
+	return translated, nil
+}
+
+// LibreTranslateBackend は LibreTranslate 互換のAPI（POST /translate）で翻訳する
+// （Argos Translate などのローカルNMTモデルをHTTPで提供するサーバー）
+type LibreTranslateBackend struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewLibreTranslateBackend は新しいLibreTranslateBackendを作成
+// baseURL は /translate を除いたベースURL（例: http://localhost:5000）
+func NewLibreTranslateBackend(baseURL, apiKey string) *LibreTranslateBackend {
+	return &LibreTranslateBackend{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// Name はバックエンドの名前を返す
+func (b *LibreTranslateBackend) Name() string {
+	return BackendLibreTranslate
+}
+
+type libreTranslateRequest struct {
+	Q      []string `json:"q"`
+	Source string   `json:"source"`
+	Target string   `json:"target"`
+	Format string   `json:"format"`
+	APIKey string   `json:"api_key,omitempty"`
+}
+
+type libreTranslateResponse struct {
+	TranslatedText []string `json:"translatedText"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// Translate はセグメントの配列を1回のリクエストで翻訳する
+func (b *LibreTranslateBackend) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	body, err := json.Marshal(libreTranslateRequest{
+		Q:      texts,
+		Source: source,
+		Target: target,
+		Format: "text",
+		APIKey: b.apiKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.baseURL+"/translate", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("translation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read translation response: %w", err)
+	}
+	var result libreTranslateResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("invalid translation response (status %d): %w", resp.StatusCode, err)
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("translation error (status %d): %s", resp.StatusCode, result.Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("translation request failed with status %d", resp.StatusCode)
+	}
+	if len(result.TranslatedText) != len(texts) {
+		return nil, fmt.Errorf("%w: got %d, want %d", ErrCountMismatch, len(result.TranslatedText), len(texts))
+	}
+	return result.TranslatedText, nil
+}
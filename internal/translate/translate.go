@@ -0,0 +1,375 @@
+// Package translate は文字起こしをセグメント単位で別の言語に翻訳する
+//
+// 翻訳は元のセグメントの開始・終了時刻をそのまま使い、言語ごとに translation
+// アーティファクト（asr.Result の JSON、トークンは無し）として保存する。
+// エクスポート・同期ページでは文字起こしの代わりの字幕トラックとして使う。
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"zbor/internal/asr"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+const (
+	// maxBatchSegments は1回のリクエストで翻訳するセグメントの最大数
+	maxBatchSegments = 40
+	// maxBatchRunes は1回のリクエストで翻訳する本文の最大文字数
+	maxBatchRunes = 3000
+)
+
+var (
+	// ErrTranslationPending は同じソースの翻訳ジョブが待機中・実行中
+	ErrTranslationPending = errors.New("a translation job is already queued or running for this source")
+	// ErrCountMismatch はバックエンドが返した訳文の数が入力と違う
+	ErrCountMismatch = errors.New("translation count mismatch")
+	// ErrInvalidLanguage は言語コードが不正
+	ErrInvalidLanguage = errors.New("language must be a language code such as en, ja or zh-Hans")
+)
+
+// languagePattern は言語コード（ISO 639 とスクリプト・地域の添え字）
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z]{2,4})?$`)
+
+// ValidLanguage は言語コードとして使えるかを返す
+func ValidLanguage(lang string) bool {
+	return languagePattern.MatchString(lang)
+}
+
+// ProgressCallback は進捗を報告するコールバック
+type ProgressCallback func(progress int, step string)
+
+// Options は翻訳ジョブの設定（ジョブの payload に保存）
+type Options struct {
+	Target string `json:"target"`           // 翻訳先の言語
+	Source string `json:"source,omitempty"` // 元の言語（省略時は文字起こしの言語、なければ ja）
+}
+
+// Metadata は翻訳アーティファクトのメタデータ
+type Metadata struct {
+	Language        string `json:"language"`
+	SourceLanguage  string `json:"source_language"`
+	Backend         string `json:"backend"`
+	TranscriptionID string `json:"transcription_id"` // 翻訳した文字起こしのアーティファクト
+	Segments        int    `json:"segments"`
+}
+
+// Translator は翻訳ジョブを処理する
+type Translator struct {
+	backend      Backend
+	sourceRepo   *storage.SourceRepository
+	artifactRepo *storage.ArtifactRepository
+	jobRepo      *storage.JobRepository
+}
+
+// NewTranslator は新しいTranslatorを作成
+// backend が nil の場合、翻訳は無効
+func NewTranslator(
+	backend Backend,
+	sourceRepo *storage.SourceRepository,
+	artifactRepo *storage.ArtifactRepository,
+	jobRepo *storage.JobRepository,
+) *Translator {
+	return &Translator{
+		backend:      backend,
+		sourceRepo:   sourceRepo,
+		artifactRepo: artifactRepo,
+		jobRepo:      jobRepo,
+	}
+}
+
+// Enabled は翻訳のバックエンドが設定されているかを返す
+func (t *Translator) Enabled() bool {
+	return t.backend != nil
+}
+
+// CreateJob はソースの文字起こしの翻訳ジョブを作成
+func (t *Translator) CreateJob(ctx context.Context, sourceID string, opts Options, priority int) (string, error) {
+	if !ValidLanguage(opts.Target) || (opts.Source != "" && !ValidLanguage(opts.Source)) {
+		return "", ErrInvalidLanguage
+	}
+	source, err := t.sourceRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get source: %w", err)
+	}
+	if source == nil {
+		return "", fmt.Errorf("source not found: %s", sourceID)
+	}
+	if err := t.sourceRepo.CheckHold(ctx, sourceID); err != nil {
+		return "", err
+	}
+
+	jobs, err := t.jobRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if job.Type == storage.JobTypeTranslate && job.Status != nil &&
+			(*job.Status == storage.JobStatusQueued || *job.Status == storage.JobStatusRunning) {
+			return "", ErrTranslationPending
+		}
+	}
+
+	payload, _ := json.Marshal(opts)
+	job := &sqlc.ProcessingJob{
+		SourceID: &sourceID,
+		Type:     storage.JobTypeTranslate,
+		Priority: storage.Ptr(int64(priority)),
+		Payload:  storage.Ptr(string(payload)),
+	}
+	if err := t.jobRepo.Create(ctx, job); err != nil {
+		return "", fmt.Errorf("failed to create job: %w", err)
+	}
+	return job.ID, nil
+}
+
+// ProcessJob は翻訳ジョブを処理する（ワーカーから呼ばれる）
+// 文字起こしのセグメントを順にまとめて翻訳し、同じ言語の翻訳があれば置き換える
+func (t *Translator) ProcessJob(ctx context.Context, job *sqlc.ProcessingJob, onProgress ProgressCallback) error {
+	if t.backend == nil {
+		return fmt.Errorf("translation is not configured (set ZBOR_TRANSLATE_URL or ZBOR_LLM_URL)")
+	}
+	if job.SourceID == nil {
+		return fmt.Errorf("job has no source ID")
+	}
+	sourceID := *job.SourceID
+
+	reportProgress := func(progress int, step string) {
+		if onProgress != nil {
+			onProgress(progress, step)
+		}
+	}
+
+	var opts Options
+	if job.Payload != nil && *job.Payload != "" {
+		if err := json.Unmarshal([]byte(*job.Payload), &opts); err != nil {
+			return fmt.Errorf("failed to parse job payload: %w", err)
+		}
+	}
+	if !ValidLanguage(opts.Target) {
+		return ErrInvalidLanguage
+	}
+
+	reportProgress(5, "loading transcript")
+	artifacts, err := t.artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	var artifact *sqlc.ProcessingArtifact
+	for idx := range artifacts {
+		if artifacts[idx].Type == storage.ArtifactTypeTranscription && artifacts[idx].Content != nil {
+			artifact = &artifacts[idx]
+			break
+		}
+	}
+	if artifact == nil {
+		return fmt.Errorf("transcript not found")
+	}
+	var transcript asr.Result
+	if err := json.Unmarshal([]byte(*artifact.Content), &transcript); err != nil {
+		return fmt.Errorf("failed to parse transcript: %w", err)
+	}
+	if opts.Source == "" {
+		opts.Source = transcript.Language
+	}
+	if opts.Source == "" {
+		opts.Source = "ja"
+	}
+
+	// トークンから作ったキュー（セグメントの無い文字起こしも同じ区切りで翻訳する）
+	cues := transcript.Cues()
+	texts := make([]string, len(cues))
+	for idx, cue := range cues {
+		texts[idx] = strings.TrimSpace(cue.Text)
+	}
+	translated, err := t.translateAll(ctx, texts, opts.Source, opts.Target, func(done, total int) {
+		reportProgress(10+85*done/total, fmt.Sprintf("translating %d/%d", done, total))
+	})
+	if err != nil {
+		return err
+	}
+
+	reportProgress(95, "saving")
+	result := &asr.Result{
+		Language:      opts.Target,
+		TotalDuration: transcript.TotalDuration,
+		Segments:      make([]asr.Segment, len(cues)),
+	}
+	var text []string
+	for idx, cue := range cues {
+		result.Segments[idx] = asr.Segment{
+			Text:      translated[idx],
+			StartTime: cue.StartTime,
+			EndTime:   cue.EndTime,
+			Speaker:   cue.Speaker,
+		}
+		if translated[idx] != "" {
+			text = append(text, translated[idx])
+		}
+	}
+	result.Text = strings.Join(text, joiner(opts.Target))
+
+	metadata := Metadata{
+		Language:        opts.Target,
+		SourceLanguage:  opts.Source,
+		Backend:         t.backend.Name(),
+		TranscriptionID: artifact.ID,
+		Segments:        len(cues),
+	}
+	if err := t.save(ctx, sourceID, artifacts, result, &metadata); err != nil {
+		return err
+	}
+
+	reportProgress(100, "")
+	return nil
+}
+
+// translateAll はテキストを maxBatchSegments・maxBatchRunes ごとにまとめて翻訳する
+// （空のテキストは送らない）。訳文の数が合わないまとまりは1つずつ翻訳し直す
+func (t *Translator) translateAll(ctx context.Context, texts []string, source, target string, onBatch func(done, total int)) ([]string, error) {
+	translated := make([]string, len(texts))
+	var batch []int
+	runes := 0
+	done := 0
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		inputs := make([]string, len(batch))
+		for idx, i := range batch {
+			inputs[idx] = texts[i]
+		}
+		outputs, err := t.backend.Translate(ctx, inputs, source, target)
+		if errors.Is(err, ErrCountMismatch) && len(inputs) > 1 {
+			outputs = make([]string, len(inputs))
+			for idx, input := range inputs {
+				out, err := t.backend.Translate(ctx, []string{input}, source, target)
+				if err != nil {
+					return fmt.Errorf("failed to translate: %w", err)
+				}
+				outputs[idx] = out[0]
+			}
+		} else if err != nil {
+			return fmt.Errorf("failed to translate: %w", err)
+		}
+		for idx, i := range batch {
+			translated[i] = strings.TrimSpace(outputs[idx])
+		}
+		done += len(batch)
+		if onBatch != nil {
+			onBatch(done, len(texts))
+		}
+		batch = batch[:0]
+		runes = 0
+		return nil
+	}
+
+	for i, text := range texts {
+		if text == "" {
+			done++
+			continue
+		}
+		n := len([]rune(text))
+		if len(batch) >= maxBatchSegments || (len(batch) > 0 && runes+n > maxBatchRunes) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		batch = append(batch, i)
+		runes += n
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return translated, nil
+}
+
+// save は翻訳アーティファクトを保存（同じ言語の翻訳は置き換える）
+func (t *Translator) save(ctx context.Context, sourceID string, artifacts []sqlc.ProcessingArtifact, result *asr.Result, metadata *Metadata) error {
+	content, _ := json.Marshal(result)
+	meta, _ := json.Marshal(metadata)
+	for _, artifact := range artifacts {
+		if artifact.Type != storage.ArtifactTypeTranslation || artifactLanguage(artifact) != metadata.Language {
+			continue
+		}
+		if err := t.artifactRepo.Delete(ctx, artifact.ID); err != nil {
+			return fmt.Errorf("failed to replace translation: %w", err)
+		}
+	}
+	err := t.artifactRepo.Create(ctx, &sqlc.ProcessingArtifact{
+		SourceID: &sourceID,
+		Type:     storage.ArtifactTypeTranslation,
+		Content:  storage.Ptr(string(content)),
+		Format:   storage.Ptr("json"),
+		Metadata: storage.Ptr(string(meta)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save translation: %w", err)
+	}
+	return nil
+}
+
+// Load はソースの lang の翻訳アーティファクトを読み込む（無ければ nil）
+func Load(ctx context.Context, artifactRepo *storage.ArtifactRepository, sourceID, lang string) (*asr.Result, error) {
+	artifacts, err := artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	for _, artifact := range artifacts {
+		if artifact.Type != storage.ArtifactTypeTranslation || artifact.Content == nil || artifactLanguage(artifact) != lang {
+			continue
+		}
+		var result asr.Result
+		if err := json.Unmarshal([]byte(*artifact.Content), &result); err != nil {
+			return nil, fmt.Errorf("failed to parse translation: %w", err)
+		}
+		return &result, nil
+	}
+	return nil, nil
+}
+
+// Languages はソースの翻訳の一覧（メタデータ）を返す
+func Languages(ctx context.Context, artifactRepo *storage.ArtifactRepository, sourceID string) ([]Metadata, error) {
+	artifacts, err := artifactRepo.GetBySourceID(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	items := []Metadata{}
+	for _, artifact := range artifacts {
+		if artifact.Type != storage.ArtifactTypeTranslation || artifact.Metadata == nil {
+			continue
+		}
+		var metadata Metadata
+		if err := json.Unmarshal([]byte(*artifact.Metadata), &metadata); err == nil && metadata.Language != "" {
+			items = append(items, metadata)
+		}
+	}
+	return items, nil
+}
+
+// artifactLanguage は翻訳アーティファクトの言語を返す
+func artifactLanguage(artifact sqlc.ProcessingArtifact) string {
+	if artifact.Metadata == nil {
+		return ""
+	}
+	var metadata Metadata
+	if err := json.Unmarshal([]byte(*artifact.Metadata), &metadata); err != nil {
+		return ""
+	}
+	return metadata.Language
+}
+
+// joiner は全文をつなぐ文字（日本語・中国語は区切らない）
+func joiner(lang string) string {
+	if lang == "ja" || lang == "zh" || strings.HasPrefix(lang, "zh-") {
+		return ""
+	}
+	return " "
+}
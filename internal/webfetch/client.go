@@ -25,6 +25,9 @@ type FetchOptions struct {
 	BlockImages bool          // 画像ブロック
 	WaitTime    time.Duration // 待機時間
 	Selector    string        // 待機セレクタ
+
+	// Extract はMarkdown変換の抽出オプション（nil の場合は DefaultExtractOptions）
+	Extract *ExtractOptions
 }
 
 // NewClient は新しいクライアントを作成
@@ -61,20 +64,10 @@ func (c *Client) Close() error {
 }
 
 // FetchMarkdown はURLからMarkdownを取得
+// デフォルトでは本文のみを抽出する（opts.Extract で変更可能）
 func (c *Client) FetchMarkdown(ctx context.Context, url string, opts *FetchOptions) (*Result, error) {
-	fetchOpts := buildFetchOptions(opts)
-	fetchOpts = append(fetchOpts, htmlfetch.WithMarkdown())
-
-	result, err := c.fetcher.Fetch(ctx, url, fetchOpts...)
-	if err != nil {
-		return nil, err
-	}
-
-	return &Result{
-		URL:      result.FinalURL,
-		Content:  result.Markdown,
-		Duration: result.Duration,
-	}, nil
+	result, _, err := c.fetchPage(ctx, url, opts)
+	return result, err
 }
 
 // FetchHTML はURLからHTMLを取得
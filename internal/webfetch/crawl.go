@@ -0,0 +1,237 @@
+package webfetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// ErrStopCrawl をコールバックが返すとクロールを正常終了する
+var ErrStopCrawl = errors.New("stop crawl")
+
+// クロールのデフォルト値
+const (
+	DefaultCrawlMaxPages = 50
+	DefaultCrawlDelay    = time.Second
+)
+
+// CrawlOptions はクロールオプション
+type CrawlOptions struct {
+	MaxDepth   int           // 開始ページからたどるリンクの深さ（0 は開始ページのみ）
+	MaxPages   int           // 取得するページ数の上限（0 はデフォルト）
+	SameDomain bool          // 開始ページと同じホストのみ
+	PathPrefix string        // このパスで始まるページのみ（例: /docs/）
+	Delay      time.Duration // ページ取得の間隔（robots.txt の Crawl-delay が長ければそちら）
+	Fetch      *FetchOptions // 各ページの取得オプション
+}
+
+// CrawlPage はクロールした1ページ
+type CrawlPage struct {
+	URL    string  // リンク元で見つけたURL
+	Depth  int     // 開始ページからの深さ
+	Result *Result // 取得結果（Err が nil の場合）
+	Err    error   // 取得エラー（クロールは続行する）
+}
+
+// skipExtensions はページではないため辿らない拡張子
+var skipExtensions = map[string]bool{
+	".pdf": true, ".zip": true, ".gz": true, ".tar": true, ".dmg": true, ".exe": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true, ".ico": true,
+	".mp3": true, ".mp4": true, ".wav": true, ".m4a": true, ".mov": true, ".webm": true,
+	".css": true, ".js": true, ".json": true, ".xml": true, ".rss": true,
+}
+
+type crawlItem struct {
+	url   *url.URL
+	depth int
+}
+
+// Crawl は開始URLからリンクを辿って各ページをMarkdownとして取得し、fn に渡す
+// robots.txt（zbor または * 向けのルール）に従い、ページ取得の間隔を空ける
+// fn がエラーを返すとクロールを中止する（ErrStopCrawl の場合は nil を返す）
+func (c *Client) Crawl(ctx context.Context, startURL string, opts *CrawlOptions, fn func(*CrawlPage) error) error {
+	if opts == nil {
+		opts = &CrawlOptions{SameDomain: true}
+	}
+	maxPages := opts.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultCrawlMaxPages
+	}
+	delay := opts.Delay
+	if delay <= 0 {
+		delay = DefaultCrawlDelay
+	}
+
+	start, err := url.Parse(startURL)
+	if err != nil || (start.Scheme != "http" && start.Scheme != "https") || start.Host == "" {
+		return fmt.Errorf("invalid start URL: %s", startURL)
+	}
+	start.Fragment = ""
+
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	robots := map[string]*robotsRules{}
+	robotsFor := func(u *url.URL) *robotsRules {
+		if rules, ok := robots[u.Host]; ok {
+			return rules
+		}
+		rules := fetchRobots(ctx, httpClient, u)
+		robots[u.Host] = rules
+		return rules
+	}
+
+	inScope := func(u *url.URL) bool {
+		if opts.SameDomain && !strings.EqualFold(u.Host, start.Host) {
+			return false
+		}
+		if opts.PathPrefix != "" && !strings.HasPrefix(u.Path, opts.PathPrefix) {
+			return false
+		}
+		return !skipExtensions[strings.ToLower(path.Ext(u.Path))]
+	}
+
+	visited := map[string]bool{start.String(): true}
+	queue := []crawlItem{{url: start, depth: 0}}
+	fetched := 0
+	var lastFetch time.Time
+
+	for len(queue) > 0 && fetched < maxPages {
+		item := queue[0]
+		queue = queue[1:]
+
+		rules := robotsFor(item.url)
+		if !rules.allowed(item.url.RequestURI()) {
+			continue
+		}
+
+		// ページ取得の間隔を空ける
+		wait := delay
+		if rules != nil && rules.crawlDelay > wait {
+			wait = rules.crawlDelay
+		}
+		if !lastFetch.IsZero() {
+			if remaining := wait - time.Since(lastFetch); remaining > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(remaining):
+				}
+			}
+		}
+		lastFetch = time.Now()
+		fetched++
+
+		page := &CrawlPage{URL: item.url.String(), Depth: item.depth}
+		result, links, err := c.fetchPage(ctx, item.url.String(), opts.Fetch)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			page.Err = err
+		} else {
+			page.Result = result
+			// リダイレクト先も訪問済みにする
+			if final, err := url.Parse(result.URL); err == nil {
+				final.Fragment = ""
+				visited[final.String()] = true
+			}
+		}
+
+		if err := fn(page); err != nil {
+			if errors.Is(err, ErrStopCrawl) {
+				return nil
+			}
+			return err
+		}
+
+		if item.depth >= opts.MaxDepth {
+			continue
+		}
+		for _, link := range links {
+			if visited[link.String()] || !inScope(link) {
+				continue
+			}
+			visited[link.String()] = true
+			queue = append(queue, crawlItem{url: link, depth: item.depth + 1})
+		}
+	}
+	return nil
+}
+
+// fetchPage はページを取得してMarkdownとリンクを返す
+func (c *Client) fetchPage(ctx context.Context, pageURL string, opts *FetchOptions) (*Result, []*url.URL, error) {
+	result, err := c.fetcher.Fetch(ctx, pageURL, buildFetchOptions(opts)...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var extractOpts *ExtractOptions
+	if opts != nil {
+		extractOpts = opts.Extract
+	}
+	markdown, err := ExtractMarkdown(result.HTML, result.FinalURL, extractOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &Result{
+		URL:      result.FinalURL,
+		Content:  markdown,
+		Metadata: ExtractMetadata(result.HTML, result.FinalURL),
+		Duration: result.Duration,
+	}, extractLinks(result.HTML, result.FinalURL), nil
+}
+
+// extractLinks はHTMLのリンク（http/https、フラグメント除去済み）を出現順に返す
+func extractLinks(rawHTML, pageURL string) []*url.URL {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	doc, err := html.Parse(strings.NewReader(rawHTML))
+	if err != nil {
+		return nil
+	}
+
+	var links []*url.URL
+	seen := map[string]bool{}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "a" || n.Data == "base") {
+			for _, attr := range n.Attr {
+				if attr.Key != "href" {
+					continue
+				}
+				ref, err := url.Parse(strings.TrimSpace(attr.Val))
+				if err != nil {
+					break
+				}
+				resolved := base.ResolveReference(ref)
+				// <base href> 以降のリンクはその URL を基準にする
+				if n.Data == "base" {
+					base = resolved
+					break
+				}
+				if resolved.Scheme != "http" && resolved.Scheme != "https" {
+					break
+				}
+				resolved.Fragment = ""
+				if !seen[resolved.String()] {
+					seen[resolved.String()] = true
+					links = append(links, resolved)
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return links
+}
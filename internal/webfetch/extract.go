@@ -0,0 +1,151 @@
+package webfetch
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/commonmark"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/strikethrough"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/table"
+	"github.com/go-shiori/go-readability"
+	"golang.org/x/net/html"
+)
+
+// ExtractMode はMarkdown変換の対象範囲
+type ExtractMode string
+
+const (
+	// ExtractModeArticle は本文を検出して変換（ナビゲーション・フッター等を除去）
+	ExtractModeArticle ExtractMode = "article"
+	// ExtractModeFull はページ全体を変換（スクリプト・スタイルのみ除去）
+	ExtractModeFull ExtractMode = "full"
+)
+
+// ExtractOptions は本文抽出オプション
+type ExtractOptions struct {
+	Mode       ExtractMode `json:"mode"`
+	KeepImages bool        `json:"keep_images"` // 画像を残す
+	KeepLinks  bool        `json:"keep_links"`  // リンクを残す（false の場合はテキストのみ）
+	KeepTables bool        `json:"keep_tables"` // 表をMarkdownの表として残す（false の場合は削除）
+}
+
+// DefaultExtractOptions はデフォルトの抽出オプション（本文のみ、画像・リンク・表を残す）
+func DefaultExtractOptions() *ExtractOptions {
+	return &ExtractOptions{
+		Mode:       ExtractModeArticle,
+		KeepImages: true,
+		KeepLinks:  true,
+		KeepTables: true,
+	}
+}
+
+// minArticleLength は本文として採用する最小文字数（これより短い場合はページ全体から定型部分を除いて変換）
+const minArticleLength = 200
+
+// boilerplateTags は本文が検出できなかった場合に除去する定型部分
+var boilerplateTags = []string{"nav", "header", "footer", "aside", "form", "button", "dialog"}
+
+// ExtractMarkdown はHTMLをMarkdownに変換
+// 本文モードでは Readability で本文を検出し、タイトルを見出しとして先頭に付ける
+func ExtractMarkdown(rawHTML, pageURL string, opts *ExtractOptions) (string, error) {
+	if opts == nil {
+		opts = DefaultExtractOptions()
+	}
+
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid page URL: %w", err)
+	}
+
+	var node *html.Node
+	var title string
+	if opts.Mode != ExtractModeFull {
+		article, err := readability.FromReader(strings.NewReader(rawHTML), parsedURL)
+		if err == nil && article.Node != nil && len([]rune(strings.TrimSpace(article.TextContent))) >= minArticleLength {
+			node = article.Node
+			title = strings.TrimSpace(article.Title)
+		}
+	}
+	if node == nil {
+		doc, err := html.Parse(strings.NewReader(rawHTML))
+		if err != nil {
+			return "", fmt.Errorf("failed to parse HTML: %w", err)
+		}
+		node = doc
+		if opts.Mode != ExtractModeFull {
+			removeElements(node, boilerplateTags...)
+		}
+	}
+
+	if !opts.KeepImages {
+		removeElements(node, "img", "picture", "svg", "figure")
+	}
+	if !opts.KeepLinks {
+		unwrapElements(node, "a")
+	}
+	if !opts.KeepTables {
+		removeElements(node, "table")
+	}
+
+	plugins := []converter.Plugin{
+		base.NewBasePlugin(),
+		commonmark.NewCommonmarkPlugin(),
+		strikethrough.NewStrikethroughPlugin(),
+	}
+	if opts.KeepTables {
+		plugins = append(plugins, table.NewTablePlugin())
+	}
+	conv := converter.NewConverter(converter.WithPlugins(plugins...))
+
+	markdown, err := conv.ConvertNode(node, converter.WithDomain(pageURL))
+	if err != nil {
+		return "", fmt.Errorf("failed to convert to Markdown: %w", err)
+	}
+
+	content := strings.TrimSpace(string(markdown))
+	if title != "" && !strings.HasPrefix(content, "# ") {
+		content = "# " + title + "\n\n" + content
+	}
+	return content, nil
+}
+
+// removeElements は指定したタグの要素を子要素ごと削除
+func removeElements(node *html.Node, tags ...string) {
+	var next *html.Node
+	for child := node.FirstChild; child != nil; child = next {
+		next = child.NextSibling
+		if child.Type == html.ElementNode && containsTag(tags, child.Data) {
+			node.RemoveChild(child)
+			continue
+		}
+		removeElements(child, tags...)
+	}
+}
+
+// unwrapElements は指定したタグの要素を子要素で置き換える（タグのみ除去）
+func unwrapElements(node *html.Node, tags ...string) {
+	var next *html.Node
+	for child := node.FirstChild; child != nil; child = next {
+		next = child.NextSibling
+		unwrapElements(child, tags...)
+		if child.Type == html.ElementNode && containsTag(tags, child.Data) {
+			for grandchild := child.FirstChild; grandchild != nil; grandchild = child.FirstChild {
+				child.RemoveChild(grandchild)
+				node.InsertBefore(grandchild, child)
+			}
+			node.RemoveChild(child)
+		}
+	}
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
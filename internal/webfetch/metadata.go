@@ -0,0 +1,40 @@
+package webfetch
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-shiori/go-readability"
+)
+
+// Metadata はページから抽出した記事のメタデータ
+type Metadata struct {
+	Title       string     `json:"title,omitempty"`
+	Author      string     `json:"author,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	SiteName    string     `json:"site_name,omitempty"`
+	Excerpt     string     `json:"excerpt,omitempty"`
+	Language    string     `json:"language,omitempty"`
+}
+
+// ExtractMetadata はHTMLからタイトル・著者・公開日時などを抽出
+// OGP・JSON-LD・metaタグ・本文の署名行を参照する（抽出できない項目は空）
+func ExtractMetadata(html, pageURL string) *Metadata {
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return &Metadata{}
+	}
+	article, err := readability.FromReader(strings.NewReader(html), parsedURL)
+	if err != nil {
+		return &Metadata{}
+	}
+	return &Metadata{
+		Title:       strings.TrimSpace(article.Title),
+		Author:      strings.TrimSpace(article.Byline),
+		PublishedAt: article.PublishedTime,
+		SiteName:    strings.TrimSpace(article.SiteName),
+		Excerpt:     strings.TrimSpace(article.Excerpt),
+		Language:    strings.TrimSpace(article.Language),
+	}
+}
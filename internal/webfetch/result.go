@@ -10,6 +10,7 @@ import (
 type Result struct {
 	URL      string        `json:"url"`
 	Content  string        `json:"content"`
+	Metadata *Metadata     `json:"metadata,omitempty"` // FetchMarkdown のみ
 	Duration time.Duration `json:"duration"`
 }
 
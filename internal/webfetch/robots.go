@@ -0,0 +1,143 @@
+package webfetch
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UserAgent はrobots.txtで照合するクローラー名
+const UserAgent = "zbor"
+
+// robotsRules はrobots.txtのうち、このクローラーに適用されるルール
+type robotsRules struct {
+	allow      []string
+	disallow   []string
+	crawlDelay time.Duration
+}
+
+// allowed はパスがクロール可能かを判定（最長一致、同じ長さなら Allow を優先）
+func (r *robotsRules) allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	best := -1
+	allowed := true
+	for _, p := range r.disallow {
+		if robotsMatch(p, path) && len(p) > best {
+			best = len(p)
+			allowed = false
+		}
+	}
+	for _, p := range r.allow {
+		if robotsMatch(p, path) && len(p) >= best {
+			best = len(p)
+			allowed = true
+		}
+	}
+	return allowed
+}
+
+// robotsMatch はrobots.txtのパスパターン（* と末尾の $ に対応）がパスに一致するかを判定
+func robotsMatch(pattern, path string) bool {
+	if pattern == "" {
+		return false
+	}
+	if !strings.ContainsAny(pattern, "*$") {
+		return strings.HasPrefix(path, pattern)
+	}
+	anchored := strings.HasSuffix(pattern, "$")
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(strings.TrimSuffix(pattern, "$")), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(path)
+}
+
+// fetchRobots はサイトのrobots.txtを取得して解析（取得できない場合は制限なし）
+func fetchRobots(ctx context.Context, httpClient *http.Client, siteURL *url.URL) *robotsRules {
+	robotsURL := url.URL{Scheme: siteURL.Scheme, Host: siteURL.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	return parseRobots(io.LimitReader(resp.Body, 512*1024))
+}
+
+// parseRobots はrobots.txtを解析し、"zbor" 向けのグループ（無ければ "*"）のルールを返す
+func parseRobots(r io.Reader) *robotsRules {
+	groups := map[string]*robotsRules{}
+	var current []string // 現在のグループのUser-agent
+	inRules := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			// ルールの後のUser-agentは新しいグループ
+			if inRules {
+				current = nil
+				inRules = false
+			}
+			agent := strings.ToLower(value)
+			current = append(current, agent)
+			if groups[agent] == nil {
+				groups[agent] = &robotsRules{}
+			}
+			continue
+		}
+
+		inRules = true
+		for _, agent := range current {
+			rules := groups[agent]
+			switch key {
+			case "allow":
+				rules.allow = append(rules.allow, value)
+			case "disallow":
+				// 空の Disallow はすべて許可
+				if value != "" {
+					rules.disallow = append(rules.disallow, value)
+				}
+			case "crawl-delay":
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					rules.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	if rules, ok := groups[UserAgent]; ok {
+		return rules
+	}
+	return groups["*"]
+}
@@ -0,0 +1,173 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+	"zbor/internal/version"
+)
+
+// retryDelays は失敗した配信を再試行するまでの時間（試行の回数ごと）
+// 最初の送信と合わせて len(retryDelays)+1 回失敗すると配信を失敗にする
+var retryDelays = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	6 * time.Hour,
+}
+
+const (
+	// deliveryTimeout は1回の送信の応答を待つ時間
+	deliveryTimeout = 10 * time.Second
+	// deliveryBatch は1回に送信する配信の最大数
+	deliveryBatch = 50
+	// deliveryRetention は送信済み・失敗の配信の記録を残す期間
+	deliveryRetention = 30 * 24 * time.Hour
+	// maxErrorLength は記録する応答・エラーの最大文字数
+	maxErrorLength = 500
+)
+
+// sender は配信を POST する
+type sender struct {
+	client    *http.Client
+	userAgent string
+}
+
+// newSender は送信するクライアントを作成する
+// allowPrivate が false なら公開されていないアドレスには接続しない。リダイレクトはたどらない（3xx は失敗）。
+// 環境変数のプロキシは使わない（接続先のアドレスを確認できないため）
+func newSender(allowPrivate bool) *sender {
+	dialer := &net.Dialer{Timeout: deliveryTimeout}
+	if !allowPrivate {
+		dialer.Control = checkDial
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &sender{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   deliveryTimeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		userAgent: "zbor-webhooks/" + version.Version,
+	}
+}
+
+// send は配信を POST し、応答のステータスを返す（接続できなければ 0）。2xx 以外はエラー
+func (s *sender) send(ctx context.Context, delivery *sqlc.ListDueWebhookDeliveriesRow) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", s.userAgent)
+	for key, value := range deliveryHeaders(delivery, time.Now()) {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if len(body) > 0 {
+			return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+		}
+		return resp.StatusCode, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Run は送信待ちの配信を送信する（新しいイベントはすぐに、再試行は interval ごとに確認）
+// 送信済み・失敗の配信の記録は deliveryRetention を過ぎると削除する
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastPrune time.Time
+	for {
+		if _, err := d.DeliverDue(ctx); err != nil {
+			slog.Error("Webhook delivery failed", "error", err)
+		}
+		if time.Since(lastPrune) >= time.Hour {
+			lastPrune = time.Now()
+			if n, err := d.repo.DeleteFinishedDeliveries(ctx, time.Now().Add(-deliveryRetention)); err != nil {
+				slog.Error("Failed to delete old webhook deliveries", "error", err)
+			} else if n > 0 {
+				slog.Info("Deleted old webhook deliveries", "count", n)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// DeliverDue は送信時刻になった配信を送信し、送信した数を返す
+// 失敗した配信は retryDelays の後に再試行する
+func (d *Dispatcher) DeliverDue(ctx context.Context) (int, error) {
+	sent := 0
+	for {
+		deliveries, err := d.repo.ListDue(ctx, deliveryBatch)
+		if err != nil {
+			return sent, err
+		}
+		for idx := range deliveries {
+			if ctx.Err() != nil {
+				return sent, nil
+			}
+			if err := d.deliver(ctx, &deliveries[idx]); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+		if len(deliveries) < deliveryBatch {
+			return sent, nil
+		}
+	}
+}
+
+// deliver は配信を1回送信し、結果を記録する
+func (d *Dispatcher) deliver(ctx context.Context, delivery *sqlc.ListDueWebhookDeliveriesRow) error {
+	attempts := delivery.Attempts + 1
+	status, sendErr := d.sender.send(ctx, delivery)
+	var responseStatus *int64
+	if status != 0 {
+		responseStatus = storage.Ptr(int64(status))
+	}
+	if sendErr == nil {
+		return d.repo.RecordAttempt(ctx, delivery.ID, attempts, storage.WebhookDeliveryDelivered, time.Now(), responseStatus, nil)
+	}
+
+	lastError := sendErr.Error()
+	if runes := []rune(lastError); len(runes) > maxErrorLength {
+		lastError = string(runes[:maxErrorLength])
+	}
+	if int(attempts) > len(retryDelays) {
+		slog.Warn("Webhook delivery failed", "webhook_id", delivery.WebhookID, "delivery_id", delivery.ID,
+			"event", delivery.Event, "attempts", attempts, "error", lastError)
+		return d.repo.RecordAttempt(ctx, delivery.ID, attempts, storage.WebhookDeliveryFailed, time.Now(), responseStatus, &lastError)
+	}
+	next := time.Now().Add(retryDelays[attempts-1])
+	slog.Info("Webhook delivery will be retried", "webhook_id", delivery.WebhookID, "delivery_id", delivery.ID,
+		"event", delivery.Event, "attempts", attempts, "next", next.Format(time.RFC3339), "error", lastError)
+	return d.repo.RecordAttempt(ctx, delivery.ID, attempts, storage.WebhookDeliveryPending, next, responseStatus, &lastError)
+}
@@ -0,0 +1,80 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"syscall"
+)
+
+// ErrPrivateDestination は公開されていないアドレス（ループバック、プライベート、リンクローカル等）への送信のエラー
+var ErrPrivateDestination = errors.New("webhook destination is not a public address")
+
+// nonPublicPrefixes は IsPrivate 等で判定できない、インターネットに公開されていないアドレス
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // this network
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64（IPv4 のアドレスに変換される）
+}
+
+// allowPrivateDestinations は公開されていないアドレスへの送信を許可するか
+// （ZBOR_WEBHOOK_ALLOW_PRIVATE=1。同じネットワークのサービスに送る場合に運用者が設定する）
+func allowPrivateDestinations() bool {
+	return os.Getenv("ZBOR_WEBHOOK_ALLOW_PRIVATE") == "1"
+}
+
+// publicAddr はインターネットに公開されたユニキャストのアドレスか
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckURL は Webhook の送信先を検証する（http/https で、ホストが公開されたアドレスだけに解決されること）
+// 送信時も接続するアドレスを確認するため、ここでの確認は登録時に誤りを知らせるためのもの
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an http or https URL")
+	}
+	if allowPrivateDestinations() {
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("cannot resolve %s: %w", u.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrPrivateDestination, u.Hostname(), addr.Unmap())
+		}
+	}
+	return nil
+}
+
+// checkDial は接続する直前にアドレスを確認する（net.Dialer.Control）
+// 登録後に DNS の応答が変わっても（DNS rebinding）公開されていないアドレスには接続しない
+func checkDial(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrPrivateDestination, addrPort.Addr().Unmap())
+	}
+	return nil
+}
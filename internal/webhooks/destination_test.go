@@ -0,0 +1,107 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"zbor/internal/storage/sqlc"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false}, // クラウドのメタデータ
+		{"fe80::1", false},
+		{"fd00:ec2::254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:93.184.216.34", true},
+		{"64:ff9b::7f00:1", false},
+	}
+	for _, tt := range tests {
+		if got := publicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("publicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	t.Setenv("ZBOR_WEBHOOK_ALLOW_PRIVATE", "")
+	ctx := context.Background()
+	for _, rawURL := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"https://10.0.0.5/hook",
+		"http://localhost/hook",
+	} {
+		if err := CheckURL(ctx, rawURL); !errors.Is(err, ErrPrivateDestination) {
+			t.Errorf("CheckURL(%s) = %v, want ErrPrivateDestination", rawURL, err)
+		}
+	}
+	for _, rawURL := range []string{"ftp://example.com/", "/hook", "http://"} {
+		if err := CheckURL(ctx, rawURL); err == nil || errors.Is(err, ErrPrivateDestination) {
+			t.Errorf("CheckURL(%s) = %v, want invalid URL", rawURL, err)
+		}
+	}
+	if err := CheckURL(ctx, "https://93.184.216.34/hook"); err != nil {
+		t.Errorf("CheckURL(public) = %v", err)
+	}
+
+	t.Setenv("ZBOR_WEBHOOK_ALLOW_PRIVATE", "1")
+	if err := CheckURL(ctx, "http://127.0.0.1:8080/hook"); err != nil {
+		t.Errorf("CheckURL with ZBOR_WEBHOOK_ALLOW_PRIVATE=1 = %v", err)
+	}
+}
+
+func TestSenderRefusesPrivateAddress(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	status, err := newSender(false).send(context.Background(), &sqlc.ListDueWebhookDeliveriesRow{Url: server.URL, Payload: "{}"})
+	if !errors.Is(err, ErrPrivateDestination) || status != 0 {
+		t.Fatalf("send = %d, %v, want ErrPrivateDestination", status, err)
+	}
+	if called {
+		t.Fatal("request reached a loopback server")
+	}
+}
+
+func TestSenderDoesNotFollowRedirects(t *testing.T) {
+	redirected := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	status, err := newSender(true).send(context.Background(), &sqlc.ListDueWebhookDeliveriesRow{Url: server.URL, Payload: "{}"})
+	if err == nil || status != http.StatusTemporaryRedirect {
+		t.Fatalf("send = %d, %v, want a failed 307", status, err)
+	}
+	if redirected {
+		t.Fatal("redirect was followed")
+	}
+}
@@ -0,0 +1,257 @@
+// Package webhooks は利用者が登録したURLにイベント（ジョブの完了・失敗、記事の作成、文字起こしの更新）を JSON で POST する
+//
+// イベントは Webhook ごとの配信として保存してから送信するため、送信先が落ちていても
+// サーバーの再起動をまたいで再試行する。本文は Webhook の鍵の HMAC-SHA256 で署名する
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// EventPing は接続の確認に送るイベント（POST /api/webhooks/:id/ping）
+const EventPing = "ping"
+
+// Events は Webhook で購読できるイベント
+var Events = []string{
+	storage.EventJobCompleted,
+	storage.EventJobFailed,
+	storage.EventArticleCreated,
+	storage.EventTranscriptUpdated,
+}
+
+// AllEvents はすべてのイベントを購読する指定
+const AllEvents = "*"
+
+// ヘッダー
+const (
+	HeaderEvent     = "X-Zbor-Event"
+	HeaderDelivery  = "X-Zbor-Delivery"
+	HeaderTimestamp = "X-Zbor-Timestamp"
+	HeaderSignature = "X-Zbor-Signature"
+)
+
+// Payload は送信する JSON
+type Payload struct {
+	ID        string    `json:"id"` // イベントのID（同じイベントの各 Webhook への配信で共通。再送の重複の検出に使う）
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Sign は本文の署名（sha256=<HMAC-SHA256(secret, timestamp + "." + body) の16進>）を返す
+// 受信側は X-Zbor-Timestamp と本文から同じ値を計算して X-Zbor-Signature と比べる
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret は署名の鍵を生成する
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// NormalizeEvents は購読するイベントの指定を検証し、保存する形（カンマ区切り）にする
+// 空、または AllEvents を含む場合はすべてのイベント
+func NormalizeEvents(events []string) (string, error) {
+	var selected []string
+	seen := map[string]bool{}
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if event == AllEvents {
+			return AllEvents, nil
+		}
+		if !isEvent(event) {
+			return "", fmt.Errorf("unknown event: %q (use %s or %s)", event, strings.Join(Events, ", "), AllEvents)
+		}
+		if !seen[event] {
+			seen[event] = true
+			selected = append(selected, event)
+		}
+	}
+	if len(selected) == 0 {
+		return AllEvents, nil
+	}
+	return strings.Join(selected, ","), nil
+}
+
+// SplitEvents は保存した購読するイベントを一覧にする
+func SplitEvents(events string) []string {
+	return strings.Split(events, ",")
+}
+
+// subscribed は Webhook が event を購読しているかを返す
+func subscribed(webhook *sqlc.Webhook, event string) bool {
+	for _, e := range SplitEvents(webhook.Events) {
+		if e == AllEvents || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func isEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispatcher はイベントを配信として保存し、送信する
+type Dispatcher struct {
+	repo   *storage.WebhookRepository
+	sender *sender
+	wake   chan struct{}
+}
+
+// NewDispatcher は新しいDispatcherを作成
+func NewDispatcher(repo *storage.WebhookRepository) *Dispatcher {
+	return &Dispatcher{
+		repo:   repo,
+		sender: newSender(allowPrivateDestinations()),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Publish はイベントを購読している Webhook への配信を作成する（storage.EventHandler）
+// 送信は Run のゴルーチンで行うため、書き込みを待たせない
+func (d *Dispatcher) Publish(ctx context.Context, event storage.Event) {
+	// 書き込みの直後にリクエストが終わってもイベントは保存する
+	ctx = context.WithoutCancel(ctx)
+	webhooks, err := d.repo.ListForOwner(ctx, event.OwnerID)
+	if err != nil {
+		slog.Error("Failed to list webhooks", "event", event.Type, "error", err)
+		return
+	}
+	var payload []byte
+	for idx := range webhooks {
+		webhook := &webhooks[idx]
+		if !subscribed(webhook, event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = newPayload(event.Type, eventData(event)); err != nil {
+				slog.Error("Failed to encode webhook payload", "event", event.Type, "error", err)
+				return
+			}
+		}
+		if _, err := d.repo.Enqueue(ctx, webhook.ID, event.Type, string(payload)); err != nil {
+			slog.Error("Failed to queue webhook delivery", "webhook_id", webhook.ID, "event", event.Type, "error", err)
+		}
+	}
+	if payload != nil {
+		d.notify()
+	}
+}
+
+// Ping は接続の確認のイベントを Webhook に送る（購読しているイベントにかかわらず）
+func (d *Dispatcher) Ping(ctx context.Context, webhook *sqlc.Webhook) (int64, error) {
+	payload, err := newPayload(EventPing, map[string]any{"webhook_id": webhook.ID})
+	if err != nil {
+		return 0, err
+	}
+	id, err := d.repo.Enqueue(ctx, webhook.ID, EventPing, string(payload))
+	if err != nil {
+		return 0, err
+	}
+	d.notify()
+	return id, nil
+}
+
+// Redeliver は配信をすぐに送り直す（該当なしの場合は false）
+func (d *Dispatcher) Redeliver(ctx context.Context, webhookID, deliveryID int64) (bool, error) {
+	ok, err := d.repo.Redeliver(ctx, webhookID, deliveryID)
+	if ok {
+		d.notify()
+	}
+	return ok, err
+}
+
+// notify は Run に送信待ちの配信があることを知らせる
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// newPayload は送信する JSON を作る
+func newPayload(event string, data any) ([]byte, error) {
+	return json.Marshal(Payload{
+		ID:        uuid.New().String(),
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+}
+
+// eventData はイベントの data（ジョブの内部の状態や記事の本文は含めない）
+func eventData(event storage.Event) any {
+	switch v := event.Data.(type) {
+	case *sqlc.ProcessingJob:
+		return map[string]any{"job": map[string]any{
+			"id":           v.ID,
+			"type":         v.Type,
+			"status":       v.Status,
+			"source_id":    v.SourceID,
+			"error":        v.Error,
+			"retry_count":  v.RetryCount,
+			"created_at":   v.CreatedAt,
+			"started_at":   v.StartedAt,
+			"completed_at": v.CompletedAt,
+		}}
+	case *sqlc.Article:
+		return map[string]any{"article": map[string]any{
+			"id":          v.ID,
+			"title":       v.Title,
+			"source_id":   v.SourceID,
+			"source_type": v.SourceType,
+			"source_url":  v.SourceUrl,
+			"language":    v.Language,
+			"status":      v.Status,
+			"created_at":  v.CreatedAt,
+			"url":         "/articles/" + v.ID,
+		}}
+	case *sqlc.ProcessingArtifact:
+		data := map[string]any{"source_id": v.SourceID, "artifact_id": v.ID}
+		if v.SourceID != nil {
+			data["url"] = "/audio/" + *v.SourceID + "/sync"
+		}
+		return data
+	}
+	return event.Data
+}
+
+// deliveryHeaders は配信のヘッダー（署名を含む）
+func deliveryHeaders(delivery *sqlc.ListDueWebhookDeliveriesRow, now time.Time) map[string]string {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	return map[string]string{
+		HeaderEvent:     delivery.Event,
+		HeaderDelivery:  strconv.FormatInt(delivery.ID, 10),
+		HeaderTimestamp: timestamp,
+		HeaderSignature: Sign(delivery.Secret, timestamp, []byte(delivery.Payload)),
+	}
+}
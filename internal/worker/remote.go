@@ -0,0 +1,191 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"zbor/internal/logging"
+	"zbor/internal/metrics"
+	"zbor/internal/storage"
+	"zbor/internal/storage/sqlc"
+)
+
+// DefaultLeaseTimeout is how long a remote agent may go without a heartbeat
+// before its job is requeued for another worker
+const DefaultLeaseTimeout = 5 * time.Minute
+
+// remoteScanLimit is how many queued jobs are scanned when looking for a match
+const remoteScanLimit = 50
+
+// ErrNotClaimed is returned when a worker acts on a job it does not hold
+var ErrNotClaimed = errors.New("job is not claimed by this worker")
+
+// Remote coordinates jobs that are processed by remote worker agents over
+// the API: claiming, lease heartbeats, and completion/failure bookkeeping
+type Remote struct {
+	jobRepo      *storage.JobRepository
+	types        map[string]bool
+	leaseTimeout time.Duration
+}
+
+// NewRemote creates a coordinator that hands the given job types to remote agents
+func NewRemote(jobRepo *storage.JobRepository, jobTypes []string) *Remote {
+	types := make(map[string]bool, len(jobTypes))
+	for _, t := range jobTypes {
+		types[t] = true
+	}
+	return &Remote{
+		jobRepo:      jobRepo,
+		types:        types,
+		leaseTimeout: DefaultLeaseTimeout,
+	}
+}
+
+// SetLeaseTimeout sets how long a claim survives without heartbeats
+func (r *Remote) SetLeaseTimeout(timeout time.Duration) {
+	r.leaseTimeout = timeout
+}
+
+// Handles reports whether the job type is processed by remote agents
+func (r *Remote) Handles(jobType string) bool {
+	return r.types[jobType]
+}
+
+// Claim assigns the next queued remote job the worker accepts
+// accept filters job types the worker can run (nil accepts every remote type).
+// Returns nil when there is nothing to do
+func (r *Remote) Claim(ctx context.Context, workerID string, accept func(jobType string) bool) (*sqlc.ProcessingJob, error) {
+	jobs, err := r.jobRepo.ListRunnable(ctx, remoteScanLimit)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range jobs {
+		if !r.Handles(jobs[idx].Type) || (accept != nil && !accept(jobs[idx].Type)) {
+			continue
+		}
+		// Another agent may win the race; move on to the next job
+		ok, err := r.jobRepo.Claim(ctx, jobs[idx].ID, workerID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			logging.FromContext(ctx).Info("Job claimed by remote worker", "job_id", jobs[idx].ID, "job_type", jobs[idx].Type, "worker", workerID)
+			return r.jobRepo.GetByID(ctx, jobs[idx].ID)
+		}
+	}
+	return nil, nil
+}
+
+// Held returns the IDs of running jobs the worker holds, oldest claim first
+// An agent that restarts uses this to resume its jobs
+func (r *Remote) Held(ctx context.Context, workerID string) ([]string, error) {
+	claims, err := r.jobRepo.ListClaimsByWorker(ctx, workerID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(claims))
+	for _, claim := range claims {
+		ids = append(ids, claim.JobID)
+	}
+	return ids, nil
+}
+
+// Heartbeat refreshes the lease of every job the worker holds
+func (r *Remote) Heartbeat(ctx context.Context, workerID string) error {
+	return r.jobRepo.HeartbeatByWorker(ctx, workerID)
+}
+
+// LeaseTimeout returns how long a claim survives without heartbeats
+func (r *Remote) LeaseTimeout() time.Duration {
+	return r.leaseTimeout
+}
+
+// Verify checks that the worker holds the job and refreshes its lease
+func (r *Remote) Verify(ctx context.Context, jobID, workerID string) (*sqlc.ProcessingJob, error) {
+	claim, err := r.jobRepo.GetClaim(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if claim == nil || claim.WorkerID != workerID {
+		return nil, ErrNotClaimed
+	}
+
+	job, err := r.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.Status == nil || *job.Status != storage.JobStatusRunning {
+		return nil, ErrNotClaimed
+	}
+
+	if err := r.jobRepo.Heartbeat(ctx, jobID); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Complete marks a remote job completed and drops its claim and uploaded chunks
+func (r *Remote) Complete(ctx context.Context, jobID string) error {
+	job, err := r.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if err := r.jobRepo.Complete(ctx, jobID); err != nil {
+		return err
+	}
+	if job != nil {
+		observeRemoteJob(job, storage.JobStatusCompleted)
+	}
+	if err := r.jobRepo.ReleaseClaim(ctx, jobID); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("Job completed by remote worker", "job_id", jobID)
+	return r.jobRepo.DeleteResultChunks(ctx, jobID)
+}
+
+// Fail records a failure reported by a remote agent, retrying like local jobs
+// Uploaded chunks are kept so the next attempt can resume
+func (r *Remote) Fail(ctx context.Context, job *sqlc.ProcessingJob, jobErr error) error {
+	if err := r.jobRepo.ReleaseClaim(ctx, job.ID); err != nil {
+		return err
+	}
+	logger := logging.FromContext(ctx).With("job_id", job.ID, "job_type", job.Type)
+	logger.Warn("Job failed on remote worker", "error", jobErr)
+	observeRemoteJob(job, storage.JobStatusFailed)
+	handleJobFailure(logging.WithLogger(ctx, logger), r.jobRepo, job, jobErr)
+	return nil
+}
+
+// observeRemoteJob records the run time of a remote job (since the agent claimed it)
+func observeRemoteJob(job *sqlc.ProcessingJob, status string) {
+	if job.StartedAt != nil {
+		metrics.JobDuration.ObserveDuration(time.Since(*job.StartedAt), job.Type, status)
+	}
+}
+
+// ReleaseStale requeues jobs whose agent has not sent a heartbeat within the lease timeout
+func (r *Remote) ReleaseStale(ctx context.Context) error {
+	claims, err := r.jobRepo.ListStaleClaims(ctx, r.leaseTimeout)
+	if err != nil {
+		return err
+	}
+	for _, claim := range claims {
+		job, err := r.jobRepo.GetByID(ctx, claim.JobID)
+		if err != nil {
+			return err
+		}
+		if job == nil || job.Status == nil || *job.Status != storage.JobStatusRunning {
+			if err := r.jobRepo.ReleaseClaim(ctx, claim.JobID); err != nil {
+				return err
+			}
+			continue
+		}
+		jobErr := fmt.Errorf("remote worker %s stopped responding", claim.WorkerID)
+		if err := r.Fail(ctx, job, jobErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
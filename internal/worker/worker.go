@@ -2,25 +2,46 @@ package worker
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 
+	"zbor/internal/logging"
+	"zbor/internal/metrics"
 	"zbor/internal/storage"
 	"zbor/internal/storage/sqlc"
 )
 
+// progressCheckInterval is how often a running job's progress is checked
+// for the worker heartbeat
+const progressCheckInterval = 30 * time.Second
+
+// maxRetries is how many times a failed job is queued again before it is
+// marked failed
+const maxRetries = 3
+
+// errInterrupted is the failure recorded for jobs a previous process left running
+var errInterrupted = errors.New("interrupted by server restart")
+
 // JobHandler is a function that processes a job
 type JobHandler func(ctx context.Context, job *sqlc.ProcessingJob) error
 
 // Worker processes jobs from the queue
 type Worker struct {
-	jobRepo  *storage.JobRepository
-	handlers map[string]JobHandler
-	interval time.Duration
-	stop     chan struct{}
-	wg       sync.WaitGroup
-	mu       sync.RWMutex
+	jobRepo    *storage.JobRepository
+	handlers   map[string]JobHandler
+	remote     *Remote
+	interval   time.Duration
+	jobTimeout time.Duration
+	lastSweep  time.Time
+	stop       chan struct{}
+	wg         sync.WaitGroup
+	mu         sync.RWMutex
+
+	beatMu    sync.Mutex
+	heartbeat time.Time
 }
 
 // NewWorker creates a new worker
@@ -40,23 +61,69 @@ func (w *Worker) RegisterHandler(jobType string, handler JobHandler) {
 	w.handlers[jobType] = handler
 }
 
+// SetRemote hands the remote job types to remote worker agents
+// The local worker skips those jobs and requeues them when an agent's lease expires
+func (w *Worker) SetRemote(remote *Remote) {
+	w.remote = remote
+}
+
 // SetInterval sets the polling interval
 func (w *Worker) SetInterval(interval time.Duration) {
 	w.interval = interval
 }
 
+// SetJobTimeout limits how long a job handler may run (0 = no limit). The
+// handler's context is cancelled at the limit, which stops ffmpeg and the
+// decoding; a timed-out job is failed without retrying
+func (w *Worker) SetJobTimeout(timeout time.Duration) {
+	w.jobTimeout = timeout
+}
+
+// LastHeartbeat returns when the worker last showed signs of life: polling
+// the queue, or the running job reporting progress (zero before Start)
+func (w *Worker) LastHeartbeat() time.Time {
+	w.beatMu.Lock()
+	defer w.beatMu.Unlock()
+	return w.heartbeat
+}
+
+func (w *Worker) beat() {
+	w.beatMu.Lock()
+	w.heartbeat = time.Now()
+	w.beatMu.Unlock()
+}
+
+// RecoverInterrupted requeues the jobs a previous process left running (it
+// died or was killed mid-job), counting the interruption as a failed
+// attempt so a job that keeps crashing the server ends up failed. Jobs held
+// by remote agents are left to their lease. Call before Start. Returns the
+// number of jobs recovered
+func (w *Worker) RecoverInterrupted(ctx context.Context) (int, error) {
+	jobs, err := w.jobRepo.ListOrphaned(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for idx := range jobs {
+		job := &jobs[idx]
+		logger := logging.FromContext(ctx).With("job_id", job.ID, "job_type", job.Type)
+		logger.Warn("Recovering interrupted job")
+		handleJobFailure(logging.WithLogger(ctx, logger), w.jobRepo, job, errInterrupted)
+	}
+	return len(jobs), nil
+}
+
 // Start begins processing jobs
 func (w *Worker) Start(ctx context.Context) {
 	w.wg.Add(1)
 	go w.run(ctx)
-	log.Println("Worker started")
+	slog.Info("Worker started")
 }
 
 // Stop gracefully stops the worker
 func (w *Worker) Stop() {
 	close(w.stop)
 	w.wg.Wait()
-	log.Println("Worker stopped")
+	slog.Info("Worker stopped")
 }
 
 func (w *Worker) run(ctx context.Context) {
@@ -72,74 +139,184 @@ func (w *Worker) run(ctx context.Context) {
 		case <-w.stop:
 			return
 		case <-ticker.C:
+			w.beat()
+			w.sweepRemote(ctx)
 			w.processNextJob(ctx)
 		}
 	}
 }
 
 func (w *Worker) processNextJob(ctx context.Context) {
-	job, err := w.jobRepo.GetNextQueued(ctx)
+	job, err := w.nextJob(ctx)
 	if err != nil {
-		log.Printf("Error getting next job: %v", err)
+		slog.Error("Error getting next job", "error", err)
 		return
 	}
 	if job == nil {
 		return // No jobs to process
 	}
 
+	// Everything logged while running the job carries its ID and type
+	logger := logging.FromContext(ctx).With("job_id", job.ID, "job_type", job.Type)
+	ctx = logging.WithLogger(ctx, logger)
+
 	w.mu.RLock()
 	handler, ok := w.handlers[job.Type]
 	w.mu.RUnlock()
 
 	if !ok {
-		log.Printf("No handler for job type: %s", job.Type)
+		logger.Error("No handler for job type")
 		_ = w.jobRepo.Fail(ctx, job.ID, "no handler registered for job type: "+job.Type)
 		return
 	}
 
 	// Start the job
 	if err := w.jobRepo.Start(ctx, job.ID); err != nil {
-		log.Printf("Error starting job %s: %v", job.ID, err)
+		logger.Error("Error starting job", "error", err)
 		return
 	}
 
-	log.Printf("Processing job %s (type: %s)", job.ID, job.Type)
+	logger.Info("Processing job")
+
+	// Sources, articles and jobs created by the handler belong to the job's owner
+	if job.OwnerID != nil {
+		ctx = storage.WithOwner(ctx, *job.OwnerID, true)
+	}
 
 	// Execute the handler
-	if err := handler(ctx, job); err != nil {
-		log.Printf("Job %s failed: %v", job.ID, err)
-		w.handleJobFailure(ctx, job, err)
+	jobCtx, cancelJob := ctx, context.CancelFunc(func() {})
+	if w.jobTimeout > 0 {
+		jobCtx, cancelJob = context.WithTimeout(ctx, w.jobTimeout)
+	}
+	stopWatch := w.watchProgress(ctx, job.ID)
+	start := time.Now()
+	err = handler(jobCtx, job)
+	timedOut := errors.Is(jobCtx.Err(), context.DeadlineExceeded)
+	cancelJob()
+	stopWatch()
+	if err != nil {
+		logger.Warn("Job failed", "error", err, "duration", time.Since(start))
+		metrics.JobDuration.ObserveDuration(time.Since(start), job.Type, storage.JobStatusFailed)
+		if timedOut {
+			// A retry would run into the same limit
+			metrics.WorkerErrors.Inc(job.Type)
+			if err := w.jobRepo.Fail(ctx, job.ID, fmt.Sprintf("timed out after %s", w.jobTimeout)); err != nil {
+				logger.Error("Error failing job", "error", err)
+			}
+			return
+		}
+		handleJobFailure(ctx, w.jobRepo, job, err)
 		return
 	}
 
 	// Complete the job
 	if err := w.jobRepo.Complete(ctx, job.ID); err != nil {
-		log.Printf("Error completing job %s: %v", job.ID, err)
+		logger.Error("Error completing job", "error", err)
 		return
 	}
+	metrics.JobDuration.ObserveDuration(time.Since(start), job.Type, storage.JobStatusCompleted)
+
+	logger.Info("Job completed", "duration", time.Since(start))
+}
 
-	log.Printf("Job %s completed", job.ID)
+// watchProgress keeps the heartbeat going while the job reports progress,
+// so a long job is not mistaken for a stalled worker. Returns a function
+// that stops watching
+func (w *Worker) watchProgress(ctx context.Context, jobID string) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(progressCheckInterval)
+		defer ticker.Stop()
+
+		var lastProgress int64
+		var lastStep string
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			job, err := w.jobRepo.GetByID(ctx, jobID)
+			if err != nil || job == nil {
+				continue
+			}
+			progress, step := int64(0), ""
+			if job.Progress != nil {
+				progress = *job.Progress
+			}
+			if job.CurrentStep != nil {
+				step = *job.CurrentStep
+			}
+			if progress != lastProgress || step != lastStep {
+				lastProgress, lastStep = progress, step
+				w.beat()
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
 }
 
-func (w *Worker) handleJobFailure(ctx context.Context, job *sqlc.ProcessingJob, jobErr error) {
+// nextJob returns the next queued job for the local worker, skipping jobs
+// reserved for remote agents
+func (w *Worker) nextJob(ctx context.Context) (*sqlc.ProcessingJob, error) {
+	if w.remote == nil {
+		return w.jobRepo.GetNextQueued(ctx)
+	}
+
+	jobs, err := w.jobRepo.ListRunnable(ctx, remoteScanLimit)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range jobs {
+		if !w.remote.Handles(jobs[idx].Type) {
+			return &jobs[idx], nil
+		}
+	}
+	return nil, nil
+}
+
+// sweepRemote requeues remote jobs whose agent stopped sending heartbeats
+func (w *Worker) sweepRemote(ctx context.Context) {
+	if w.remote == nil || time.Since(w.lastSweep) < w.remote.leaseTimeout/4 {
+		return
+	}
+	w.lastSweep = time.Now()
+	if err := w.remote.ReleaseStale(ctx); err != nil {
+		slog.Error("Error releasing stale remote jobs", "error", err)
+	}
+}
+
+// handleJobFailure retries the job or marks it failed after too many attempts
+// ctx carries the job's logger (see logging.WithLogger)
+func handleJobFailure(ctx context.Context, jobRepo *storage.JobRepository, job *sqlc.ProcessingJob, jobErr error) {
+	metrics.WorkerErrors.Inc(job.Type)
+	logger := logging.FromContext(ctx)
+
 	retryCount := int64(0)
 	if job.RetryCount != nil {
 		retryCount = *job.RetryCount
 	}
 
-	maxRetries := int64(3)
-
 	if retryCount < maxRetries {
 		// Retry the job
-		if err := w.jobRepo.Retry(ctx, job.ID); err != nil {
-			log.Printf("Error retrying job %s: %v", job.ID, err)
+		if err := jobRepo.Retry(ctx, job.ID); err != nil {
+			logger.Error("Error retrying job", "error", err)
 		} else {
-			log.Printf("Job %s queued for retry (attempt %d/%d)", job.ID, retryCount+1, maxRetries)
+			logger.Info("Job queued for retry", "attempt", retryCount+1, "max_retries", maxRetries)
 		}
 	} else {
 		// Max retries exceeded, mark as failed
-		if err := w.jobRepo.Fail(ctx, job.ID, jobErr.Error()); err != nil {
-			log.Printf("Error failing job %s: %v", job.ID, err)
+		if err := jobRepo.Fail(ctx, job.ID, jobErr.Error()); err != nil {
+			logger.Error("Error failing job", "error", err)
 		}
 	}
 }
@@ -156,7 +333,7 @@ func (w *Worker) SubmitJob(ctx context.Context, jobType, sourceID string, priori
 		return nil, err
 	}
 
-	log.Printf("Job %s submitted (type: %s, priority: %d)", job.ID, jobType, priority)
+	logging.FromContext(ctx).Info("Job submitted", "job_id", job.ID, "job_type", jobType, "priority", priority)
 	return job, nil
 }
 
@@ -43,7 +43,7 @@ type DownloadAudioOptions struct {
 
 // GetAudioFormats は利用可能な音声フォーマット一覧を取得
 func (c *Client) GetAudioFormats(videoURL string) ([]AudioFormat, error) {
-	video, err := c.client.GetVideo(videoURL)
+	video, err := c.video(videoURL)
 	if err != nil {
 		return nil, err
 	}
@@ -152,7 +152,7 @@ func (c *Client) DownloadAudioWithProgress(videoURL string, opts *DownloadAudioO
 	}
 
 	// 動画情報を取得
-	video, err := c.client.GetVideo(videoURL)
+	video, err := c.video(videoURL)
 	if err != nil {
 		return fmt.Errorf("failed to get video: %w", err)
 	}
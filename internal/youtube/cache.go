@@ -0,0 +1,135 @@
+package youtube
+
+import (
+	"sync"
+	"time"
+
+	ytdl "github.com/kkdai/youtube/v2"
+)
+
+// DefaultCacheTTL は動画情報と字幕をキャッシュする時間のデフォルト
+const DefaultCacheTTL = 10 * time.Minute
+
+// maxCacheEntries は保持する動画情報・字幕それぞれの数の上限
+const maxCacheEntries = 256
+
+// responseCache は動画情報と字幕をメモリに保持するキャッシュ
+// 情報表示・字幕一覧・字幕取得・ダウンロードで同じ動画を何度も取得しないようにする。
+// 動画情報（期限付きのストリームURLを含む）は ttl の間だけ使う。
+// 字幕は ttl を過ぎても ETag / Last-Modified があれば条件付きリクエストで再検証し、304 なら保持している内容を使う
+type responseCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration // 0 でキャッシュしない
+	videos   map[string]videoEntry
+	captions map[string]captionEntry
+}
+
+type videoEntry struct {
+	video   *ytdl.Video
+	expires time.Time
+}
+
+type captionEntry struct {
+	body         []byte
+	etag         string
+	lastModified string
+	expires      time.Time
+}
+
+// validator は条件付きリクエストに使えるかどうかを返す
+func (e captionEntry) validator() bool {
+	return e.etag != "" || e.lastModified != ""
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{
+		ttl:      ttl,
+		videos:   map[string]videoEntry{},
+		captions: map[string]captionEntry{},
+	}
+}
+
+// setTTL は有効期間を変更し、保持している内容を破棄する
+func (c *responseCache) setTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+	clear(c.videos)
+	clear(c.captions)
+}
+
+// video は有効期間内の動画情報を返す（無ければ nil）
+// 動画情報は呼び出し元で共有されるため、変更しないこと
+func (c *responseCache) video(key string) *ytdl.Video {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.videos[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(e.expires) {
+		delete(c.videos, key)
+		return nil
+	}
+	return e.video
+}
+
+// storeVideo は動画情報を保存する
+func (c *responseCache) storeVideo(key string, video *ytdl.Video) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl <= 0 {
+		return
+	}
+	now := time.Now()
+	if len(c.videos) >= maxCacheEntries {
+		// 期限切れを削除し、それでも多ければ全て破棄する
+		for k, e := range c.videos {
+			if now.After(e.expires) {
+				delete(c.videos, k)
+			}
+		}
+		if len(c.videos) >= maxCacheEntries {
+			clear(c.videos)
+		}
+	}
+	c.videos[key] = videoEntry{video: video, expires: now.Add(c.ttl)}
+}
+
+// caption は字幕のエントリと、有効期間内かどうかを返す
+// 期限切れでも再検証に使えるエントリは返す
+func (c *responseCache) caption(url string) (captionEntry, bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.captions[url]
+	if !ok {
+		return captionEntry{}, false, false
+	}
+	fresh := time.Now().Before(e.expires)
+	if !fresh && !e.validator() {
+		delete(c.captions, url)
+		return captionEntry{}, false, false
+	}
+	return e, fresh, true
+}
+
+// storeCaption は字幕を保存する（304 の場合は有効期間の延長に使う）
+func (c *responseCache) storeCaption(url string, entry captionEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl <= 0 {
+		return
+	}
+	if _, ok := c.captions[url]; !ok && len(c.captions) >= maxCacheEntries {
+		// 最も古いエントリを破棄する
+		var oldest string
+		for k, e := range c.captions {
+			if oldest == "" || e.expires.Before(c.captions[oldest].expires) {
+				oldest = k
+			}
+		}
+		delete(c.captions, oldest)
+	}
+	entry.expires = time.Now().Add(c.ttl)
+	c.captions[url] = entry
+}
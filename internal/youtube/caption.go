@@ -42,12 +42,45 @@ func (c *Client) FetchCaption(video *VideoInfo, lang string) (*CaptionResult, er
 
 // FetchCaptionByURL はURLから直接字幕を取得
 func (c *Client) FetchCaptionByURL(url string) (*CaptionResult, error) {
-	resp, err := http.Get(url)
+	body, err := c.fetchCaption(url)
+	if err != nil {
+		return nil, err
+	}
+	return parseTranscriptXML(body)
+}
+
+// fetchCaption は字幕のXMLを取得する
+// キャッシュが有効期間内ならそれを使い、期限切れなら条件付きリクエストで再検証する
+func (c *Client) fetchCaption(url string) ([]byte, error) {
+	cached, fresh, ok := c.cache.caption(url)
+	if ok && fresh {
+		return cached.body, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid caption URL: %w", err)
+	}
+	if ok {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// 変更が無ければ保持している内容を使う
+	if ok && resp.StatusCode == http.StatusNotModified {
+		c.cache.storeCaption(url, cached)
+		return cached.body, nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
@@ -57,7 +90,12 @@ func (c *Client) FetchCaptionByURL(url string) (*CaptionResult, error) {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	return parseTranscriptXML(body)
+	c.cache.storeCaption(url, captionEntry{
+		body:         body,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	})
+	return body, nil
 }
 
 // parseTranscriptXML はXMLをパースしてCaptionResultを返す
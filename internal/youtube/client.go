@@ -1,23 +1,34 @@
 package youtube
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/kkdai/youtube/v2"
 )
 
 // Client はYouTube API操作を抽象化するクライアント
+// 動画情報と字幕は DefaultCacheTTL の間キャッシュする（SetCacheTTL で変更）
 type Client struct {
-	client youtube.Client
+	client     youtube.Client
+	httpClient *http.Client
+	cache      *responseCache
 }
 
 // NewClient は新しいYouTubeクライアントを作成
 func NewClient() *Client {
 	return &Client{
-		client: youtube.Client{},
+		client:     youtube.Client{},
+		httpClient: http.DefaultClient,
+		cache:      newResponseCache(DefaultCacheTTL),
 	}
 }
 
+// SetCacheTTL は動画情報と字幕をキャッシュする時間を設定（0 でキャッシュしない）
+func (c *Client) SetCacheTTL(ttl time.Duration) {
+	c.cache.setTTL(ttl)
+}
+
 // VideoInfo は動画のメタ情報
 type VideoInfo struct {
 	ID          string
@@ -37,7 +48,7 @@ type CaptionTrack struct {
 
 // GetVideo は動画情報を取得
 func (c *Client) GetVideo(url string) (*VideoInfo, error) {
-	video, err := c.client.GetVideo(url)
+	video, err := c.video(url)
 	if err != nil {
 		return nil, err
 	}
@@ -62,6 +73,25 @@ func (c *Client) GetVideo(url string) (*VideoInfo, error) {
 	}, nil
 }
 
+// video は動画情報をキャッシュから返し、無ければ取得して保存する
+// キーは動画ID（URLの形式が違っても同じ動画なら共有する）
+func (c *Client) video(url string) (*youtube.Video, error) {
+	key, err := youtube.ExtractVideoID(url)
+	if err != nil {
+		key = url
+	}
+	if video := c.cache.video(key); video != nil {
+		return video, nil
+	}
+
+	video, err := c.client.GetVideo(url)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.storeVideo(key, video)
+	return video, nil
+}
+
 // FindCaption は指定言語の字幕トラックを検索
 // 見つからない場合は最初の字幕トラックを返す
 func (v *VideoInfo) FindCaption(lang string) *CaptionTrack {
@@ -0,0 +1,17 @@
+package layouts
+
+import "context"
+
+// usernameContextKey はコンテキストにログイン中のユーザー名を保存するキー
+type usernameContextKey struct{}
+
+// WithUsername はログイン中のユーザー名をコンテキストに設定する（ヘッダーに表示する）
+func WithUsername(ctx context.Context, username string) context.Context {
+	return context.WithValue(ctx, usernameContextKey{}, username)
+}
+
+// username はログイン中のユーザー名を返す（ログインしていない場合は空）
+func username(ctx context.Context) string {
+	name, _ := ctx.Value(usernameContextKey{}).(string)
+	return name
+}